@@ -12,11 +12,15 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 
 	"github.com/btcsuite/btcwallet/chain"
 	"github.com/btcsuite/btcwallet/rpc/legacyrpc"
+	"github.com/btcsuite/btcwallet/rpc/zmqpub"
+	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/wallet/hwi"
 	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/lightninglabs/neutrino"
 )
@@ -70,6 +74,8 @@ func walletMain() error {
 
 	dbDir := networkDir(cfg.AppDataDir.Value, activeNet.Params)
 	loader := wallet.NewLoader(activeNet.Params, dbDir, 250)
+	loader.SetKDF(kdfOptions())
+	loader.SetEncryptTxStore(cfg.EncryptTxStore)
 
 	// Create and start HTTP server to serve wallet client connections.
 	// This will be updated with the wallet and chain server RPC client
@@ -80,14 +86,190 @@ func walletMain() error {
 		return err
 	}
 
+	var publisher *zmqpub.Publisher
+	if len(cfg.ZMQPub) != 0 {
+		publisher, err = startZMQPublisher()
+		if err != nil {
+			log.Errorf("Unable to start ZMQ publisher: %v", err)
+			return err
+		}
+	}
+
+	// Log the configuration hash, and its changes by RPCs changing the
+	// policies of the wallet.
+	go configDriftMonitor(loader)
+
+	if cfg.DiagnosticsInterval > 0 {
+		err := startDiagnostics(loader, legacyRPCServer)
+		if err != nil {
+			log.Errorf("Unable to start diagnostics: %v", err)
+			return err
+		}
+	}
+
 	// Create and start chain RPC client so it's ready to connect to
-	// the wallet when loaded later.
-	if !cfg.NoInitialLoad {
+	// the wallet when loaded later.  Offline wallets never connect.
+	if !cfg.NoInitialLoad && !cfg.Offline {
 		go rpcClientConnectLoop(legacyRPCServer, loader)
 	}
 
+	var screener *wallet.HTTPScreener
+	if cfg.ScreenURL != "" {
+		screener, err = wallet.NewHTTPScreener(cfg.ScreenURL, cfg.ScreenAPIKey)
+		if err != nil {
+			log.Errorf("Unable to configure address screening: %v", err)
+			return err
+		}
+	}
+
+	webhooks := make([]*wallet.BroadcastWebhook, len(cfg.BroadcastWebhooks))
+	for i, u := range cfg.BroadcastWebhooks {
+		webhooks[i], err = wallet.NewBroadcastWebhook(u,
+			cfg.BroadcastWebhookAPIKey)
+		if err != nil {
+			log.Errorf("Unable to configure broadcast webhook: %v", err)
+			return err
+		}
+	}
+
+	accelerators := make([]*wallet.HTTPAccelerator, len(cfg.Accelerators))
+	for i, u := range cfg.Accelerators {
+		accelerators[i], err = wallet.NewHTTPAccelerator(u,
+			cfg.AcceleratorAPIKey)
+		if err != nil {
+			log.Errorf("Unable to configure transaction accelerator: %v", err)
+			return err
+		}
+	}
+
+	var device *hwi.Device
+	if cfg.HWI != "" {
+		device, err = hwi.New(cfg.HWI, cfg.HWIFingerprint, activeNet.Params)
+		if err != nil {
+			log.Errorf("Unable to configure the hardware wallet: %v", err)
+			return err
+		}
+	}
+
+	var pruneHook wallet.PruneHook
+	if cfg.PruneExportCmd != "" {
+		pruneHook = wallet.NewExecPruneHook(cfg.PruneExportCmd)
+	}
+	if cfg.LogRetention > 0 {
+		go logRetentionMonitor(filepath.Join(cfg.LogDir,
+			defaultLogFilename), cfg.LogRetention, pruneHook)
+	}
+
 	loader.RunAfterLoad(func(w *wallet.Wallet) {
+		w.SetOffline(cfg.Offline)
+		w.SetUnlockLockout(cfg.UnlockMaxFailures, cfg.UnlockLockout)
+		w.NtfnServer.SetBalanceNotificationInterval(
+			cfg.BalanceNtfnInterval, cfg.BalanceNtfnFlushOnSend)
+		w.NtfnServer.SetReplaySize(cfg.NtfnReplaySize)
+		if err := w.NtfnServer.SetQueueSize(cfg.NtfnQueueSize); err != nil {
+			log.Errorf("Cannot load the notification queue: %v", err)
+		}
+		w.SetRescanBatchLatency(cfg.RescanLatency)
+		if device != nil {
+			w.SetAccountSigner(waddrmgr.DefaultAccountNum, device)
+		}
+		for _, path := range cfg.TxHooks {
+			w.RegisterTxHook(wallet.NewExecTxHook(path))
+		}
+		if screener != nil {
+			w.SetAddressScreener(screener, screeningPolicy(), cfg.ScreenOnReceive)
+		}
+		for _, a := range accelerators {
+			w.RegisterTxAccelerator(a)
+		}
+		if len(cfg.AccountQuotas) != 0 {
+			w.SetAccountQuotas(accountQuotas(), cfg.QuotaPrune)
+		}
+		if cfg.ConsolidateFeeRate.Amount > 0 {
+			w.SetConsolidationPolicy(&wallet.ConsolidationPolicy{
+				MaxFeeRate: cfg.ConsolidateFeeRate.Amount,
+				MaxInputs:  cfg.ConsolidateMaxInputs,
+				DryRun:     cfg.ConsolidateDryRun,
+			})
+		}
+		if cfg.FragmentationFeeRate.Amount > 0 {
+			w.SetFragmentationPolicy(&wallet.FragmentationPolicy{
+				MaxFeeRate: cfg.FragmentationFeeRate.Amount,
+				Threshold:  cfg.FragmentationThreshold.Amount,
+				MinOutputs: cfg.FragmentationOutputs,
+				MaxInputs:  cfg.ConsolidateMaxInputs,
+				DryRun:     cfg.ConsolidateDryRun,
+			})
+		}
+		w.SetDustQuarantine(cfg.DustThreshold.Amount, cfg.SpendQuarantined)
+		w.SetDustPolicy(cfg.DustRelayFee.Amount, cfg.RejectDustChange)
+		w.SetFeeCap(wallet.FeeCap{
+			MaxFee:        cfg.MaxTxFee.Amount,
+			MaxFeePercent: cfg.MaxFeePercent,
+			MaxDailyFee:   cfg.MaxDailyFee.Amount,
+		})
+		// The coin selection was validated by loadConfig.
+		selector, _ := wallet.CoinSelectorByName(cfg.CoinSelection)
+		w.SetCoinSelector(selector)
+		// The change policy was validated by loadConfig.
+		changePolicy, _ := wallet.ParseChangePolicy(cfg.ChangePolicy)
+		w.SetChangePolicy(changePolicy)
+		w.SetReplaceableByDefault(!cfg.NoRBF)
+		w.SetAntiFeeSniping(!cfg.NoLockTime)
+		w.SetConfTarget(cfg.ConfTarget)
+		w.SetBroadcastHold(cfg.BroadcastHold, webhooks...)
+		w.SetMetadataAnchorInterval(cfg.MetadataAnchorInterval)
+		w.SetPaymentBatchInterval(cfg.BatchInterval)
+		if cfg.RefillHotAccount != "" {
+			refillDir := cfg.RefillDir
+			if refillDir == "" {
+				refillDir = filepath.Join(networkDir(
+					cfg.AppDataDir.Value, activeNet.Params),
+					"refills")
+			}
+			w.SetRefillPolicy(&wallet.RefillPolicy{
+				HotAccount:  cfg.RefillHotAccount,
+				ColdAccount: cfg.RefillColdAccount,
+				Threshold:   cfg.RefillThreshold.Amount,
+				Target:      cfg.RefillTarget.Amount,
+				Dir:         refillDir,
+				Webhook:     cfg.RefillWebhook,
+			})
+		}
+		if len(cfg.DigestTo) != 0 {
+			w.SetEmailDigest(&wallet.EmailDigestConfig{
+				SMTPServer: cfg.DigestSMTPServer,
+				Username:   cfg.DigestSMTPUser,
+				Password:   cfg.DigestSMTPPass,
+				From:       cfg.DigestFrom,
+				To:         cfg.DigestTo,
+				Interval:   cfg.DigestInterval,
+				LowBalance: cfg.DigestLowBalance.Amount,
+			})
+		}
+		w.SetKeyUsagePolicy(&wallet.KeyUsagePolicy{
+			Factor:        cfg.KeyUsageAlertFactor,
+			MinSignatures: cfg.KeyUsageAlertMin,
+		})
+		w.SetAuditRetention(wallet.AuditRetention{
+			MaxAge:     cfg.AuditRetention,
+			MaxRecords: cfg.AuditMaxRecords,
+		}, pruneHook)
+		switch {
+		case cfg.BackupPass != "":
+			w.SetBackupEncrypter(wallet.NewPassphraseBackupEncrypter(
+				[]byte(cfg.BackupPass), kdfOptions()))
+		case len(cfg.BackupGPGRecipients) != 0:
+			w.SetBackupEncrypter(wallet.NewGPGBackupEncrypter(
+				cfg.BackupGPG, cfg.BackupGPGRecipients...))
+		case cfg.BackupToken != "":
+			w.SetBackupToken(wallet.NewExecBackupToken(cfg.BackupToken))
+			w.SetBackupEncrypter(w.TokenBackupEncrypter())
+		}
 		startWalletRPCServices(w, rpcs, legacyRPCServer)
+		if publisher != nil {
+			publisher.PublishWallet(w)
+		}
 	})
 
 	if !cfg.NoInitialLoad {
@@ -109,6 +291,16 @@ func walletMain() error {
 			log.Errorf("Failed to close wallet: %v", err)
 		}
 	})
+	if cfg.DiagnosticsInterval > 0 {
+		// Record the state at shutdown while the wallet is still
+		// loaded.
+		addInterruptHandler(func() {
+			if err := diagnosticsRecorder.Stop(); err != nil {
+				log.Errorf("Cannot record diagnostics snapshot: %v",
+					err)
+			}
+		})
+	}
 	if rpcs != nil {
 		addInterruptHandler(func() {
 			// TODO: Does this need to wait for the grpc server to
@@ -118,6 +310,13 @@ func walletMain() error {
 			log.Info("RPC server shutdown")
 		})
 	}
+	if publisher != nil {
+		addInterruptHandler(func() {
+			log.Warn("Stopping ZMQ publisher...")
+			publisher.Stop()
+			log.Info("ZMQ publisher shutdown")
+		})
+	}
 	if legacyRPCServer != nil {
 		addInterruptHandler(func() {
 			log.Warn("Stopping legacy RPC server...")
@@ -239,6 +438,53 @@ func rpcClientConnectLoop(legacyRPCServer *legacyrpc.Server, loader *wallet.Load
 	}
 }
 
+// kdfOptions returns the key derivation function and costs of the passphrase
+// keys of created wallets, or nil for the default scrypt costs.
+func kdfOptions() *waddrmgr.ScryptOptions {
+	if cfg.KDF != "argon2id" {
+		return nil
+	}
+	opts := waddrmgr.DefaultArgon2idOptions
+	opts.Memory = cfg.KDFMemory * 1024
+	opts.Time = cfg.KDFIterations
+	return &opts
+}
+
+// screeningPolicy returns the address screening policy of the config.  Risk
+// categories without an explicit action are allowed, and provider failures
+// block transactions unless the policy fails open.
+func screeningPolicy() *wallet.ScreeningPolicy {
+	policy := &wallet.ScreeningPolicy{
+		Actions: make(map[string]wallet.ScreeningAction),
+		Default: wallet.ScreenAllow,
+		Failure: wallet.ScreenBlock,
+	}
+	if cfg.ScreenFailOpen {
+		policy.Failure = wallet.ScreenAllow
+	}
+	for _, risk := range cfg.ScreenWarn {
+		policy.Actions[strings.ToLower(risk)] = wallet.ScreenWarn
+	}
+	for _, risk := range cfg.ScreenBlock {
+		policy.Actions[strings.ToLower(risk)] = wallet.ScreenBlock
+	}
+	return policy
+}
+
+// accountQuotas returns the account footprint quotas of the config, keyed by
+// account name.  The quotas are validated when the config is loaded.
+func accountQuotas() map[string]int64 {
+	quotas := make(map[string]int64, len(cfg.AccountQuotas))
+	for _, q := range cfg.AccountQuotas {
+		account, quota, err := parseAccountQuota(q)
+		if err != nil {
+			continue
+		}
+		quotas[account] = quota
+	}
+	return quotas
+}
+
 func readCAFile() []byte {
 	// Read certificate file if TLS is not disabled.
 	var certs []byte
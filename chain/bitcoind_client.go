@@ -3,6 +3,7 @@ package chain
 import (
 	"container/list"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
@@ -12,9 +13,10 @@ import (
 	"github.com/btcsuite/btcd/btcjson"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
-	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wtxmgr"
 )
@@ -202,6 +204,31 @@ func (c *BitcoindClient) SendRawOrder(order *wire.MsgOdr,
 	return c.chainConn.client.SendRawOrder(order, allowHighFees)
 }
 
+// EstimateFeeRate returns the fee rate per kilobyte estimated by bitcoind's
+// estimatesmartfee for a transaction to be mined within numBlocks blocks, or
+// -1 when bitcoind has no estimate yet.
+func (c *BitcoindClient) EstimateFeeRate(numBlocks int64) (btcutil.Amount, error) {
+	param, err := json.Marshal(numBlocks)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.chainConn.client.RawRequest("estimatesmartfee",
+		[]json.RawMessage{param})
+	if err != nil {
+		return 0, err
+	}
+	var estimate struct {
+		FeeRate *float64 `json:"feerate"`
+	}
+	if err := json.Unmarshal(resp, &estimate); err != nil {
+		return 0, err
+	}
+	if estimate.FeeRate == nil || *estimate.FeeRate < 0 {
+		return -1, nil
+	}
+	return btcutil.NewAmount(*estimate.FeeRate)
+}
+
 // Notifications returns a channel to retrieve notifications from.
 //
 // NOTE: This is part of the chain.Interface interface.
@@ -209,6 +236,12 @@ func (c *BitcoindClient) Notifications() <-chan interface{} {
 	return c.notificationQueue.ChanOut()
 }
 
+// NotificationQueueDepth returns the number of notifications queued for
+// reads from the Notifications channel.
+func (c *BitcoindClient) NotificationQueueDepth() int {
+	return c.notificationQueue.Len()
+}
+
 // NotifyReceived allows the chain backend to notify the caller whenever a
 // transaction pays to any of the given addresses.
 //
@@ -883,17 +916,24 @@ func (c *BitcoindClient) FilterBlocks(
 
 	// Iterate over the requested blocks, fetching each from the rpc client.
 	// Each block will scanned using the reverse addresses indexes generated
-	// above, breaking out early if any addresses are found.
-	for i, block := range req.Blocks {
-		// TODO(conner): add prefetching, since we already know we'll be
-		// fetching *every* block
-		rawBlock, err := c.GetBlock(&block.Hash)
+	// above, breaking out early if any addresses are found.  Blocks are
+	// prefetched PipelineDepth blocks ahead over the request clients of
+	// the connection, so bitcoind serves several of them at once.
+	var resp *FilterBlocksResponse
+	blocks := make([]rpcclient.FutureGetBlockResult, len(req.Blocks))
+	request := func(i int) {
+		blocks[i] = c.chainConn.requestClient().GetBlockAsync(
+			&req.Blocks[i].Hash,
+		)
+	}
+	receive := func(i int) (bool, error) {
+		rawBlock, err := blocks[i].Receive()
 		if err != nil {
-			return nil, err
+			return false, err
 		}
 
 		if !blockFilterer.FilterBlock(rawBlock) {
-			continue
+			return false, nil
 		}
 
 		// If any external or internal addresses were detected in this
@@ -901,20 +941,23 @@ func (c *BitcoindClient) FilterBlocks(
 		// windows can widened with subsequent addresses. The
 		// `BatchIndex` is returned so that the caller can compute the
 		// *next* block from which to begin again.
-		resp := &FilterBlocksResponse{
+		resp = &FilterBlocksResponse{
 			BatchIndex:         uint32(i),
-			BlockMeta:          block,
+			BlockMeta:          req.Blocks[i],
 			FoundExternalAddrs: blockFilterer.FoundExternal,
 			FoundInternalAddrs: blockFilterer.FoundInternal,
 			FoundOutPoints:     blockFilterer.FoundOutPoints,
 			RelevantTxns:       blockFilterer.RelevantTxns,
 		}
-
-		return resp, nil
+		return true, nil
+	}
+	err := pipeline(len(req.Blocks), PipelineDepth, request, receive)
+	if err != nil {
+		return nil, err
 	}
 
-	// No addresses were found for this range.
-	return nil, nil
+	// The response is nil when no addresses were found for this range.
+	return resp, nil
 }
 
 // rescan performs a rescan of the chain using a bitcoind backend, from the
@@ -1216,7 +1259,7 @@ func (c *BitcoindClient) filterTx(tx *wire.MsgTx,
 	// any of the currently watched addresses. If an output matches, we'll
 	// add it to our watch list.
 	for i, out := range tx.TxOut {
-		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(
 			out.PkScript, c.chainParams,
 		)
 		if err != nil {
@@ -33,6 +33,13 @@ type BitcoindConn struct {
 	// client is the RPC client to the bitcoind node.
 	client *rpcclient.Client
 
+	// requestClients are the RPC clients pipelined requests are spread
+	// over, since an HTTP POST client only sends a request once the
+	// previous one is answered.  requestCounter picks the next client and
+	// is used atomically.
+	requestClients []*rpcclient.Client
+	requestCounter uint64
+
 	// zmqBlockHost is the host listening for ZMQ connections that will be
 	// responsible for delivering raw transaction events.
 	zmqBlockHost string
@@ -77,10 +84,22 @@ func NewBitcoindConn(chainParams *chaincfg.Params,
 	if err != nil {
 		return nil, err
 	}
+	requestClients := make([]*rpcclient.Client, bitcoindRequestClients)
+	for i := range requestClients {
+		requestClients[i], err = rpcclient.New(clientCfg, nil)
+		if err != nil {
+			client.Shutdown()
+			for _, c := range requestClients[:i] {
+				c.Shutdown()
+			}
+			return nil, err
+		}
+	}
 
 	conn := &BitcoindConn{
 		chainParams:     chainParams,
 		client:          client,
+		requestClients:  requestClients,
 		zmqBlockHost:    zmqBlockHost,
 		zmqTxHost:       zmqTxHost,
 		zmqPollInterval: zmqPollInterval,
@@ -155,11 +174,24 @@ func (c *BitcoindConn) Stop() {
 
 	close(c.quit)
 	c.client.Shutdown()
+	for _, client := range c.requestClients {
+		client.Shutdown()
+	}
 
 	c.client.WaitForShutdown()
+	for _, client := range c.requestClients {
+		client.WaitForShutdown()
+	}
 	c.wg.Wait()
 }
 
+// requestClient returns the RPC client of the next pipelined request, so that
+// concurrent requests are answered concurrently by bitcoind.
+func (c *BitcoindConn) requestClient() *rpcclient.Client {
+	n := atomic.AddUint64(&c.requestCounter, 1)
+	return c.requestClients[n%uint64(len(c.requestClients))]
+}
+
 // blockEventHandler reads raw blocks events from the ZMQ block socket and
 // forwards them along to the current rescan clients.
 //
@@ -2,9 +2,9 @@ package chain
 
 import (
 	"github.com/btcsuite/btcd/chaincfg"
-	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 )
 
@@ -143,7 +143,7 @@ func (bf *BlockFilterer) FilterTx(tx *wire.MsgTx) bool {
 	// indexes for both external and internal addresses. If a new output is
 	// found, we will add the outpoint to our set of FoundOutPoints.
 	for i, out := range tx.TxOut {
-		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(
 			out.PkScript, bf.Params,
 		)
 		if err != nil {
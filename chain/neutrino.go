@@ -4,16 +4,17 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/rpcclient"
-	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/gcs"
 	"github.com/btcsuite/btcutil/gcs/builder"
+	"github.com/btcsuite/btcwallet/internal/taproot"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wtxmgr"
 	"github.com/lightninglabs/neutrino"
@@ -34,6 +35,10 @@ type NeutrinoClient struct {
 	lastProgressSent    bool
 	currentBlock        chan *waddrmgr.BlockStamp
 
+	// queueDepth is the number of notifications queued by the handler.
+	// It is used atomically.
+	queueDepth int32
+
 	quit       chan struct{}
 	rescanQuit chan struct{}
 	rescanErr  <-chan error
@@ -280,7 +285,7 @@ func buildFilterBlocksWatchList(req *FilterBlocksRequest) ([][]byte, error) {
 	watchList := make([][]byte, 0, watchListSize)
 
 	for _, addr := range req.ExternalAddrs {
-		p2shAddr, err := txscript.PayToAddrScript(addr)
+		p2shAddr, err := taproot.PayToAddrScript(addr)
 		if err != nil {
 			return nil, err
 		}
@@ -289,7 +294,7 @@ func buildFilterBlocksWatchList(req *FilterBlocksRequest) ([][]byte, error) {
 	}
 
 	for _, addr := range req.InternalAddrs {
-		p2shAddr, err := txscript.PayToAddrScript(addr)
+		p2shAddr, err := taproot.PayToAddrScript(addr)
 		if err != nil {
 			return nil, err
 		}
@@ -298,7 +303,7 @@ func buildFilterBlocksWatchList(req *FilterBlocksRequest) ([][]byte, error) {
 	}
 
 	for _, addr := range req.WatchedOutPoints {
-		addr, err := txscript.PayToAddrScript(addr)
+		addr, err := taproot.PayToAddrScript(addr)
 		if err != nil {
 			return nil, err
 		}
@@ -387,7 +392,7 @@ func (s *NeutrinoClient) Rescan(startHash *chainhash.Hash, addrs []btcutil.Addre
 
 	var inputsToWatch []neutrino.InputWithScript
 	for op, addr := range outPoints {
-		addrScript, err := txscript.PayToAddrScript(addr)
+		addrScript, err := taproot.PayToAddrScript(addr)
 		if err != nil {
 		}
 
@@ -468,6 +473,12 @@ func (s *NeutrinoClient) Notifications() <-chan interface{} {
 	return s.dequeueNotification
 }
 
+// NotificationQueueDepth replicates the RPC client's NotificationQueueDepth
+// method.
+func (s *NeutrinoClient) NotificationQueueDepth() int {
+	return int(atomic.LoadInt32(&s.queueDepth))
+}
+
 // SetStartTime is a non-interface method to set the birthday of the wallet
 // using this object. Since only a single rescan at a time is currently
 // supported, only one birthday needs to be set. This does not fully restart a
@@ -672,6 +683,7 @@ out:
 				dequeue = s.dequeueNotification
 			}
 			notifications = append(notifications, n)
+			atomic.StoreInt32(&s.queueDepth, int32(len(notifications)))
 
 		case dequeue <- next:
 			if n, ok := next.(BlockConnected); ok {
@@ -683,6 +695,7 @@ out:
 
 			notifications[0] = nil
 			notifications = notifications[1:]
+			atomic.StoreInt32(&s.queueDepth, int32(len(notifications)))
 			if len(notifications) != 0 {
 				next = notifications[0]
 			} else {
@@ -0,0 +1,36 @@
+package chain
+
+// PipelineDepth is the number of requests kept in flight to the chain
+// back end by the requests processing blocks in bulk, such as those of
+// FilterBlocks.
+const PipelineDepth = 32
+
+// bitcoindRequestClients is the number of HTTP POST clients a BitcoindConn
+// spreads pipelined requests over.  Each client sends its requests one at a
+// time, so this bounds the number of requests processed concurrently by
+// bitcoind.
+const bitcoindRequestClients = 4
+
+// pipeline processes n requests in order while keeping up to depth of them in
+// flight.  request is called to issue the request of an index before receive
+// is called, in increasing order, to wait for and process its response.
+// Processing stops at the first error or once receive returns done, leaving
+// the responses of the requests issued past that index unreceived.
+func pipeline(n, depth int, request func(i int),
+	receive func(i int) (done bool, err error)) error {
+
+	if depth < 1 {
+		depth = 1
+	}
+	issued := 0
+	for i := 0; i < n; i++ {
+		for ; issued < n && issued < i+depth; issued++ {
+			request(issued)
+		}
+		done, err := receive(i)
+		if err != nil || done {
+			return err
+		}
+	}
+	return nil
+}
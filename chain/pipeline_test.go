@@ -0,0 +1,51 @@
+package chain
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestPipeline checks that pipelined requests are issued ahead of their
+// responses, at most depth at a time, and that processing stops early.
+func TestPipeline(t *testing.T) {
+	const n, depth = 10, 3
+
+	var issued, received []int
+	request := func(i int) {
+		if len(issued)-len(received) >= depth {
+			t.Errorf("request %d issued with %d requests in flight",
+				i, depth)
+		}
+		issued = append(issued, i)
+	}
+	receive := func(i int) (bool, error) {
+		if len(issued) <= i {
+			t.Fatalf("response %d received before its request", i)
+		}
+		received = append(received, i)
+		return i == 6, nil
+	}
+	if err := pipeline(n, depth, request, receive); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 7 || len(issued) != 9 {
+		t.Errorf("received %d responses of %d requests, want 7 of 9",
+			len(received), len(issued))
+	}
+	for i := range issued {
+		if issued[i] != i {
+			t.Errorf("request %d issued as %d", issued[i], i)
+		}
+	}
+
+	errStop := errors.New("stop")
+	received = nil
+	err := pipeline(n, depth, func(int) {}, func(i int) (bool, error) {
+		received = append(received, i)
+		return false, errStop
+	})
+	if err != errStop || len(received) != 1 {
+		t.Errorf("pipeline returned %v after %d responses", err,
+			len(received))
+	}
+}
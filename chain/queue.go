@@ -2,6 +2,7 @@ package chain
 
 import (
 	"container/list"
+	"sync/atomic"
 )
 
 // ConcurrentQueue is a concurrent-safe FIFO queue with unbounded capacity.
@@ -14,6 +15,10 @@ type ConcurrentQueue struct {
 	chanOut  chan interface{}
 	quit     chan struct{}
 	overflow *list.List
+
+	// overflowLen is the length of the overflow list.  It is used
+	// atomically.
+	overflowLen int32
 }
 
 // NewConcurrentQueue constructs a ConcurrentQueue. The bufferSize parameter is
@@ -39,6 +44,11 @@ func (cq *ConcurrentQueue) ChanOut() <-chan interface{} {
 	return cq.chanOut
 }
 
+// Len returns the number of items in the queue.
+func (cq *ConcurrentQueue) Len() int {
+	return len(cq.chanOut) + int(atomic.LoadInt32(&cq.overflowLen))
+}
+
 // Start begins a goroutine that manages moving items from the in channel to
 // the out channel. The queue tries to move items directly to the out channel
 // minimize overhead, but if the out channel is full it pushes items to an
@@ -60,6 +70,7 @@ func (cq *ConcurrentQueue) Start() {
 						return
 					default:
 						cq.overflow.PushBack(item)
+						atomic.AddInt32(&cq.overflowLen, 1)
 					}
 				case <-cq.quit:
 					return
@@ -71,8 +82,10 @@ func (cq *ConcurrentQueue) Start() {
 				select {
 				case item := <-cq.chanIn:
 					cq.overflow.PushBack(item)
+					atomic.AddInt32(&cq.overflowLen, 1)
 				case cq.chanOut <- nextElement.Value:
 					cq.overflow.Remove(nextElement)
+					atomic.AddInt32(&cq.overflowLen, -1)
 				case <-cq.quit:
 					return
 				}
@@ -5,8 +5,10 @@
 package chain
 
 import (
+	"encoding/json"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/btcsuite/btcd/btcjson"
@@ -33,6 +35,10 @@ type RPCClient struct {
 	dequeueNotification chan interface{}
 	currentBlock        chan *waddrmgr.BlockStamp
 
+	// queueDepth is the number of notifications queued by the handler.
+	// It is used atomically.
+	queueDepth int32
+
 	quit    chan struct{}
 	wg      sync.WaitGroup
 	started bool
@@ -155,6 +161,28 @@ func (c *RPCClient) Rescan(startHash *chainhash.Hash, addrs []btcutil.Address,
 	return c.Client.Rescan(startHash, addrs, flatOutpoints)
 }
 
+// EstimateFeeRate returns the fee rate per kilobyte estimated by btcd for a
+// transaction to be mined within numBlocks blocks, or -1 when btcd has no
+// estimate yet.
+func (c *RPCClient) EstimateFeeRate(numBlocks int64) (btcutil.Amount, error) {
+	param, err := json.Marshal(numBlocks)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.RawRequest("estimatefee", []json.RawMessage{param})
+	if err != nil {
+		return 0, err
+	}
+	var feeRate float64
+	if err := json.Unmarshal(resp, &feeRate); err != nil {
+		return 0, err
+	}
+	if feeRate < 0 {
+		return -1, nil
+	}
+	return btcutil.NewAmount(feeRate)
+}
+
 // WaitForShutdown blocks until both the client has finished disconnecting
 // and all handlers have exited.
 func (c *RPCClient) WaitForShutdown() {
@@ -170,6 +198,12 @@ func (c *RPCClient) Notifications() <-chan interface{} {
 	return c.dequeueNotification
 }
 
+// NotificationQueueDepth returns the number of notifications queued for
+// reads from the Notifications channel.
+func (c *RPCClient) NotificationQueueDepth() int {
+	return int(atomic.LoadInt32(&c.queueDepth))
+}
+
 // BlockStamp returns the latest block notified by the client, or an error
 // if the client has been shut down.
 func (c *RPCClient) BlockStamp() (*waddrmgr.BlockStamp, error) {
@@ -203,36 +237,45 @@ func (c *RPCClient) FilterBlocks(
 	// Iterate over the requested blocks, fetching the compact filter for
 	// each one, and matching it against the watchlist generated above. If
 	// the filter returns a positive match, the full block is then requested
-	// and scanned for addresses using the block filterer.
-	for i, blk := range req.Blocks {
-		rawFilter, err := c.GetCFilter(&blk.Hash, wire.GCSFilterRegular)
+	// and scanned for addresses using the block filterer.  The filters are
+	// requested PipelineDepth blocks ahead, so the round trips to btcd
+	// overlap.
+	var resp *FilterBlocksResponse
+	filters := make([]rpcclient.FutureGetCFilterResult, len(req.Blocks))
+	request := func(i int) {
+		filters[i] = c.GetCFilterAsync(&req.Blocks[i].Hash,
+			wire.GCSFilterRegular)
+	}
+	receive := func(i int) (bool, error) {
+		blk := req.Blocks[i]
+		rawFilter, err := filters[i].Receive()
 		if err != nil {
-			return nil, err
+			return false, err
 		}
 
 		// Ensure the filter is large enough to be deserialized.
 		if len(rawFilter.Data) < 4 {
-			continue
+			return false, nil
 		}
 
 		filter, err := gcs.FromNBytes(
 			builder.DefaultP, builder.DefaultM, rawFilter.Data,
 		)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
 
 		// Skip any empty filters.
 		if filter.N() == 0 {
-			continue
+			return false, nil
 		}
 
 		key := builder.DeriveKey(&blk.Hash)
 		matched, err := filter.MatchAny(key, watchList)
 		if err != nil {
-			return nil, err
+			return false, err
 		} else if !matched {
-			continue
+			return false, nil
 		}
 
 		log.Infof("Fetching block height=%d hash=%v",
@@ -240,11 +283,11 @@ func (c *RPCClient) FilterBlocks(
 
 		rawBlock, err := c.GetBlock(&blk.Hash)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
 
 		if !blockFilterer.FilterBlock(rawBlock) {
-			continue
+			return false, nil
 		}
 
 		// If any external or internal addresses were detected in this
@@ -252,7 +295,7 @@ func (c *RPCClient) FilterBlocks(
 		// windows can widened with subsequent addresses. The
 		// `BatchIndex` is returned so that the caller can compute the
 		// *next* block from which to begin again.
-		resp := &FilterBlocksResponse{
+		resp = &FilterBlocksResponse{
 			BatchIndex:         uint32(i),
 			BlockMeta:          blk,
 			FoundExternalAddrs: blockFilterer.FoundExternal,
@@ -260,12 +303,15 @@ func (c *RPCClient) FilterBlocks(
 			FoundOutPoints:     blockFilterer.FoundOutPoints,
 			RelevantTxns:       blockFilterer.RelevantTxns,
 		}
-
-		return resp, nil
+		return true, nil
+	}
+	err = pipeline(len(req.Blocks), PipelineDepth, request, receive)
+	if err != nil {
+		return nil, err
 	}
 
-	// No addresses were found for this range.
-	return nil, nil
+	// The response is nil when no addresses were found for this range.
+	return resp, nil
 }
 
 // parseBlock parses a btcws definition of the block a tx is mined it to the
@@ -405,6 +451,7 @@ out:
 				dequeue = c.dequeueNotification
 			}
 			notifications = append(notifications, n)
+			atomic.StoreInt32(&c.queueDepth, int32(len(notifications)))
 
 		case dequeue <- next:
 			if n, ok := next.(BlockConnected); ok {
@@ -416,6 +463,7 @@ out:
 
 			notifications[0] = nil
 			notifications = notifications[1:]
+			atomic.StoreInt32(&c.queueDepth, int32(len(notifications)))
 			if len(notifications) != 0 {
 				next = notifications[0]
 			} else {
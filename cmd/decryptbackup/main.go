@@ -0,0 +1,82 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/jessevdk/go-flags"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Flags.
+var opts = struct {
+	In    string `long:"in" description:"Path of the backup made by backupwallet with a backup passphrase or backup tokens" required:"true"`
+	Out   string `long:"out" description:"Path of the wallet database to write, which must not already exist" required:"true"`
+	Token string `long:"token" description:"Path of the program answering challenges with an enrolled backup token, to decrypt a backup wrapped to backup tokens"`
+}{}
+
+func init() {
+	_, err := flags.Parse(&opts)
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func main() {
+	os.Exit(mainInt())
+}
+
+func mainInt() int {
+	backup, err := ioutil.ReadFile(opts.In)
+	if err != nil {
+		fmt.Println("Failed to read backup:", err)
+		return 1
+	}
+	if _, err := os.Stat(opts.Out); !os.IsNotExist(err) {
+		fmt.Println("Output file already exists")
+		return 1
+	}
+
+	var db []byte
+	if opts.Token != "" {
+		fmt.Println("Touch the backup token if it blinks")
+		token := wallet.NewExecBackupToken(opts.Token)
+		db, err = wallet.DecryptTokenBackup(backup, token)
+	} else {
+		fmt.Print("Backup passphrase: ")
+		var passphrase []byte
+		passphrase, err = terminal.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			fmt.Println("Failed to read backup passphrase:", err)
+			return 1
+		}
+		db, err = wallet.DecryptBackup(backup, passphrase)
+	}
+	if err != nil {
+		fmt.Println("Failed to decrypt backup:", err)
+		return 1
+	}
+	f, err := os.OpenFile(opts.Out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		fmt.Println("Failed to create wallet database:", err)
+		return 1
+	}
+	_, err = f.Write(db)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		fmt.Println("Failed to write wallet database:", err)
+		os.Remove(opts.Out)
+		return 1
+	}
+	fmt.Println("Wallet database written to", opts.Out)
+	return 0
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	rpcUser = "e2e"
+	rpcPass = "e2e"
+
+	// walletPass is the private passphrase of the wallets created with
+	// --createtemp.
+	walletPass = "password"
+
+	// pollInterval and pollTimeout bound every wait for the processes to
+	// reach some state.
+	pollInterval = 100 * time.Millisecond
+	pollTimeout  = 60 * time.Second
+)
+
+// process is a btcd or btcwallet process started by the test, with an RPC
+// client connected to it.
+type process struct {
+	name    string
+	rpcAddr string
+	cmd     *exec.Cmd
+	log     *os.File
+	client  *rpcclient.Client
+}
+
+// node is a simnet btcd process.
+type node struct {
+	process
+	p2pAddr string
+}
+
+// wallet is a btcwallet process connected to a node.
+type wallet struct {
+	process
+}
+
+// freePort returns a localhost TCP port which is not in use.
+func freePort() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	return port, err
+}
+
+// start runs the program with its arguments, logging its output to a file of
+// the directory, and connects an RPC client to rpcAddr.
+func (p *process) start(dir, program, rpcAddr string, args []string) error {
+	log, err := os.Create(filepath.Join(dir, p.name+".log"))
+	if err != nil {
+		return err
+	}
+	p.log = log
+	p.rpcAddr = rpcAddr
+	p.cmd = exec.Command(program, args...)
+	p.cmd.Stdout = log
+	p.cmd.Stderr = log
+	if err := p.cmd.Start(); err != nil {
+		return fmt.Errorf("cannot start %s: %v", p.name, err)
+	}
+
+	p.client, err = rpcclient.New(&rpcclient.ConnConfig{
+		Host:         rpcAddr,
+		User:         rpcUser,
+		Pass:         rpcPass,
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return poll(p.name+" RPC server", func() (bool, error) {
+		_, err := p.client.GetBlockCount()
+		return err == nil, nil
+	})
+}
+
+// stop kills the process and waits for it to exit.
+func (p *process) stop() {
+	if p.client != nil {
+		p.client.Shutdown()
+	}
+	if p.cmd != nil && p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+		p.cmd.Wait()
+	}
+	if p.log != nil {
+		p.log.Close()
+	}
+}
+
+// startNode starts a simnet btcd process mining to miningAddr.
+func startNode(name, dir, program string, miningAddr btcutil.Address) (*node, error) {
+	rpcPort, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	p2pPort, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	n := &node{
+		process: process{name: name},
+		p2pAddr: net.JoinHostPort("127.0.0.1", p2pPort),
+	}
+	rpcAddr := net.JoinHostPort("127.0.0.1", rpcPort)
+	dataDir := filepath.Join(dir, name)
+	args := []string{
+		"--simnet",
+		"--datadir=" + filepath.Join(dataDir, "data"),
+		"--logdir=" + filepath.Join(dataDir, "logs"),
+		"--rpcuser=" + rpcUser,
+		"--rpcpass=" + rpcPass,
+		"--rpclisten=" + rpcAddr,
+		"--listen=" + n.p2pAddr,
+		"--notls",
+		"--nobanning",
+		"--miningaddr=" + miningAddr.EncodeAddress(),
+	}
+	if err := n.start(dir, program, rpcAddr, args); err != nil {
+		n.stop()
+		return nil, err
+	}
+	return n, nil
+}
+
+// startWallet creates a simnet wallet in its own application data directory
+// and starts a btcwallet process for it, connected to the node.
+func startWallet(name, dir, program string, n *node) (*wallet, error) {
+	rpcPort, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	w := &wallet{process: process{name: name}}
+	rpcAddr := net.JoinHostPort("127.0.0.1", rpcPort)
+	args := []string{
+		"--simnet",
+		"--createtemp",
+		"--appdata=" + filepath.Join(dir, name),
+		"--username=" + rpcUser,
+		"--password=" + rpcPass,
+		"--rpcconnect=" + n.rpcAddr,
+		"--noclienttls",
+		"--rpclisten=" + rpcAddr,
+		"--noservertls",
+	}
+	if err := w.start(dir, program, rpcAddr, args); err != nil {
+		w.stop()
+		return nil, err
+	}
+	return w, nil
+}
+
+// poll calls f until it returns true or an error, failing after pollTimeout.
+func poll(what string, f func() (bool, error)) error {
+	deadline := time.Now().Add(pollTimeout)
+	for {
+		done, err := f()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for %s", what)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// key is a deterministic private key of the test.
+type key struct {
+	wif  *btcutil.WIF
+	addr *btcutil.AddressPubKeyHash
+}
+
+// newKey returns the key derived from the seed byte, so that every run of the
+// test mines to and spends from the same addresses.
+func newKey(seed byte, params *chaincfg.Params) (*key, error) {
+	var b [32]byte
+	for i := range b {
+		b[i] = seed
+	}
+	priv, pub := btcec.PrivKeyFromBytes(btcec.S256(), b[:])
+	wif, err := btcutil.NewWIF(priv, params, true)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(pub.SerializeCompressed()), params)
+	if err != nil {
+		return nil, err
+	}
+	return &key{wif: wif, addr: addr}, nil
+}
@@ -0,0 +1,389 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// simnete2e is an end-to-end acceptance test of btcwallet on simnet.  It
+// starts two btcd nodes and two wallets, mines blocks, sends between the
+// wallets, forces a reorg of a block containing a wallet transaction, and
+// asserts the final balances of both wallets.  Every run mines to the same
+// deterministic keys and sends the same amounts, so failures reproduce.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/netparams"
+	"github.com/jessevdk/go-flags"
+)
+
+var activeNet = &netparams.SimNetParams
+
+// Flags.
+var opts = struct {
+	Btcd      string `long:"btcd" description:"Path of the btcd program"`
+	Btcwallet string `long:"btcwallet" description:"Path of the btcwallet program"`
+	DataDir   string `long:"datadir" description:"Directory of the node and wallet data and logs (default a temporary directory)"`
+	Keep      bool   `long:"keep" description:"Keep the data directory after the test"`
+}{
+	Btcd:      "btcd",
+	Btcwallet: "btcwallet",
+}
+
+// The amounts sent by the test.  The fee rate is fixed so that fees only
+// depend on the sizes of the transactions.
+const (
+	firstSend  = 10 * btcutil.SatoshiPerBitcoin
+	reorgSend  = 5 * btcutil.SatoshiPerBitcoin
+	testFeeKb  = 1e4
+	setupDepth = 110
+)
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+
+func main() {
+	_, err := flags.Parse(&opts)
+	if err != nil {
+		os.Exit(1)
+	}
+
+	dir := opts.DataDir
+	if dir == "" {
+		dir, err = ioutil.TempDir("", "simnete2e")
+		if err != nil {
+			fatalf("%v", err)
+		}
+	} else if err := os.MkdirAll(dir, 0700); err != nil {
+		fatalf("%v", err)
+	}
+
+	h := &harness{dir: dir}
+	err = h.run()
+	h.stop()
+	if err != nil {
+		fatalf("FAIL: %v (logs are in %s)", err, dir)
+	}
+	if !opts.Keep && opts.DataDir == "" {
+		os.RemoveAll(dir)
+	}
+	fmt.Println("PASS")
+}
+
+// harness holds the processes of the test.
+type harness struct {
+	dir string
+
+	// miner is the node the wallets are connected to, and other is the
+	// node which mines the competing chain of the reorg.
+	miner, other *node
+	alice, bob   *wallet
+
+	// minerKey is imported to alice, so she owns the coinbase outputs of
+	// the blocks of miner.  The blocks of other pay to nobody in the test.
+	minerKey, otherKey *key
+}
+
+func (h *harness) stop() {
+	for _, w := range []*wallet{h.alice, h.bob} {
+		if w != nil {
+			w.stop()
+		}
+	}
+	for _, n := range []*node{h.other, h.miner} {
+		if n != nil {
+			n.stop()
+		}
+	}
+}
+
+func step(format string, args ...interface{}) {
+	fmt.Printf("--- "+format+"\n", args...)
+}
+
+func (h *harness) run() error {
+	var err error
+	h.minerKey, err = newKey(1, activeNet.Params)
+	if err != nil {
+		return err
+	}
+	h.otherKey, err = newKey(2, activeNet.Params)
+	if err != nil {
+		return err
+	}
+
+	step("starting nodes and wallets")
+	h.miner, err = startNode("miner", h.dir, opts.Btcd, h.minerKey.addr)
+	if err != nil {
+		return err
+	}
+	h.other, err = startNode("other", h.dir, opts.Btcd, h.otherKey.addr)
+	if err != nil {
+		return err
+	}
+	if err := h.connect(); err != nil {
+		return err
+	}
+	h.alice, err = startWallet("alice", h.dir, opts.Btcwallet, h.miner)
+	if err != nil {
+		return err
+	}
+	h.bob, err = startWallet("bob", h.dir, opts.Btcwallet, h.miner)
+	if err != nil {
+		return err
+	}
+	alice, bob := h.alice.client, h.bob.client
+	if err := alice.WalletPassphrase(walletPass, 0); err != nil {
+		return err
+	}
+	if err := alice.SetTxFee(testFeeKb); err != nil {
+		return err
+	}
+	if err := alice.ImportPrivKeyRescan(h.minerKey.wif, "", false); err != nil {
+		return err
+	}
+
+	step("mining %d blocks to alice", setupDepth)
+	if _, err := h.miner.client.Generate(setupDepth); err != nil {
+		return err
+	}
+	if err := h.sync(); err != nil {
+		return err
+	}
+
+	step("sending %v from alice to bob", btcutil.Amount(firstSend))
+	bobAddr, err := bob.GetNewAddress("default")
+	if err != nil {
+		return err
+	}
+	firstTx, err := alice.SendToAddress(bobAddr, firstSend)
+	if err != nil {
+		return err
+	}
+	if err := h.waitMempool(h.miner, firstTx); err != nil {
+		return err
+	}
+	if _, err := h.miner.client.Generate(1); err != nil {
+		return err
+	}
+	if err := h.sync(); err != nil {
+		return err
+	}
+	if err := expectBalance(bob, "bob", 1, firstSend); err != nil {
+		return err
+	}
+
+	step("reorging the block of a send of %v from alice to bob",
+		btcutil.Amount(reorgSend))
+	if err := h.disconnect(); err != nil {
+		return err
+	}
+	bobAddr, err = bob.GetNewAddress("default")
+	if err != nil {
+		return err
+	}
+	reorgTx, err := alice.SendToAddress(bobAddr, reorgSend)
+	if err != nil {
+		return err
+	}
+	if err := h.waitMempool(h.miner, reorgTx); err != nil {
+		return err
+	}
+	if _, err := h.miner.client.Generate(1); err != nil {
+		return err
+	}
+	if err := h.syncWallets(); err != nil {
+		return err
+	}
+	if err := expectBalance(bob, "bob", 1, firstSend+reorgSend); err != nil {
+		return err
+	}
+	if _, err := h.other.client.Generate(2); err != nil {
+		return err
+	}
+	if err := h.connect(); err != nil {
+		return err
+	}
+	if err := h.sync(); err != nil {
+		return err
+	}
+	if err := expectBalance(bob, "bob", 1, firstSend); err != nil {
+		return err
+	}
+	if err := expectBalance(bob, "bob", 0, firstSend+reorgSend); err != nil {
+		return err
+	}
+
+	step("mining the reorged send again")
+	if err := h.waitMempool(h.miner, reorgTx); err != nil {
+		return err
+	}
+	if _, err := h.miner.client.Generate(1); err != nil {
+		return err
+	}
+	if err := h.sync(); err != nil {
+		return err
+	}
+
+	step("checking final balances")
+	if err := expectBalance(bob, "bob", 1, firstSend+reorgSend); err != nil {
+		return err
+	}
+	var fees btcutil.Amount
+	for _, txHash := range []*chainhash.Hash{firstTx, reorgTx} {
+		tx, err := alice.GetTransaction(txHash)
+		if err != nil {
+			return err
+		}
+		fee, err := btcutil.NewAmount(-tx.Fee)
+		if err != nil {
+			return err
+		}
+		fees += fee
+	}
+	mined, err := h.matureCoinbase()
+	if err != nil {
+		return err
+	}
+	return expectBalance(alice, "alice", 1,
+		mined-firstSend-reorgSend-fees)
+}
+
+// connect connects other to miner and waits for the connection.  Blocks
+// mined by either node are then relayed to the other.
+func (h *harness) connect() error {
+	err := h.other.client.AddNode(h.miner.p2pAddr, rpcclient.ANOneTry)
+	if err != nil {
+		return err
+	}
+	return poll("nodes to connect", func() (bool, error) {
+		peers, err := h.other.client.GetPeerInfo()
+		return len(peers) != 0, err
+	})
+}
+
+// disconnect disconnects other from miner, so that both mine their own chain.
+func (h *harness) disconnect() error {
+	err := h.other.client.Node(btcjson.NDisconnect, h.miner.p2pAddr, nil)
+	if err != nil {
+		return err
+	}
+	return poll("nodes to disconnect", func() (bool, error) {
+		peers, err := h.miner.client.GetPeerInfo()
+		return len(peers) == 0, err
+	})
+}
+
+// sync waits for both nodes and both wallets to be synced to the same best
+// block.
+func (h *harness) sync() error {
+	err := poll("nodes to sync", func() (bool, error) {
+		minerBest, err := h.miner.client.GetBestBlockHash()
+		if err != nil {
+			return false, err
+		}
+		otherBest, err := h.other.client.GetBestBlockHash()
+		if err != nil {
+			return false, err
+		}
+		return *minerBest == *otherBest, nil
+	})
+	if err != nil {
+		return err
+	}
+	return h.syncWallets()
+}
+
+// syncWallets waits for both wallets to be synced to the best block of miner.
+func (h *harness) syncWallets() error {
+	best, err := h.miner.client.GetBestBlockHash()
+	if err != nil {
+		return err
+	}
+	for _, w := range []*wallet{h.alice, h.bob} {
+		err := poll(w.name+" to sync", func() (bool, error) {
+			hash, _, err := w.client.GetBestBlock()
+			if err != nil {
+				return false, err
+			}
+			return *hash == *best, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitMempool waits for a transaction to be accepted to the mempool of a
+// node.
+func (h *harness) waitMempool(n *node, txHash *chainhash.Hash) error {
+	return poll(fmt.Sprintf("%v in %s mempool", txHash, n.name), func() (bool, error) {
+		mempool, err := n.client.GetRawMempool()
+		if err != nil {
+			return false, err
+		}
+		for _, hash := range mempool {
+			if *hash == *txHash {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// matureCoinbase returns the total value of the mature coinbase outputs of the
+// best chain of miner paying to the key imported by alice.
+func (h *harness) matureCoinbase() (btcutil.Amount, error) {
+	pkScript, err := txscript.PayToAddrScript(h.minerKey.addr)
+	if err != nil {
+		return 0, err
+	}
+	tip, err := h.miner.client.GetBlockCount()
+	if err != nil {
+		return 0, err
+	}
+	maturity := int64(activeNet.Params.CoinbaseMaturity)
+	var total btcutil.Amount
+	for height := int64(1); tip-height+1 >= maturity; height++ {
+		hash, err := h.miner.client.GetBlockHash(height)
+		if err != nil {
+			return 0, err
+		}
+		block, err := h.miner.client.GetBlock(hash)
+		if err != nil {
+			return 0, err
+		}
+		for _, out := range block.Transactions[0].TxOut {
+			if bytes.Equal(out.PkScript, pkScript) {
+				total += btcutil.Amount(out.Value)
+			}
+		}
+	}
+	return total, nil
+}
+
+// expectBalance checks the balance of the outputs of a wallet with at least
+// minConf confirmations.
+func expectBalance(c *rpcclient.Client, name string, minConf int, want btcutil.Amount) error {
+	var got btcutil.Amount
+	err := poll(fmt.Sprintf("%s balance of %v", name, want), func() (bool, error) {
+		var err error
+		got, err = c.GetBalanceMinConf("*", minConf)
+		return got == want, err
+	})
+	if err != nil {
+		return fmt.Errorf("%s has a balance of %v with %d confirmations, "+
+			"expected %v", name, got, minConf, want)
+	}
+	return nil
+}
@@ -7,17 +7,21 @@ package main
 import (
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/internal/cfgutil"
+	"github.com/btcsuite/btcwallet/internal/diagnostics"
 	"github.com/btcsuite/btcwallet/internal/legacy/keystore"
+	"github.com/btcsuite/btcwallet/internal/slip39"
 	"github.com/btcsuite/btcwallet/netparams"
 	"github.com/btcsuite/btcwallet/wallet"
 	flags "github.com/jessevdk/go-flags"
@@ -30,8 +34,32 @@ const (
 	defaultLogLevel         = "info"
 	defaultLogDirname       = "logs"
 	defaultLogFilename      = "btcwallet.log"
+	defaultLogMaxSize       = 10 // MiB
+	defaultLogMaxRolls      = 3
 	defaultRPCMaxClients    = 10
 	defaultRPCMaxWebsockets = 25
+	defaultRPCWSFrameSize   = 64 * 1024
+	defaultRPCWSMessageSize = 16 * 1024 * 1024
+	defaultKDF              = "scrypt"
+	defaultKDFMemory        = 64 // MiB
+	defaultKDFIterations    = 3
+	defaultZMQPubPort       = "28335"
+
+	// minRPCWSFrameSize is the smallest payload size of the frames sent
+	// to legacy RPC websocket clients.
+	minRPCWSFrameSize = 512
+
+	// maxKDFMemory is the largest argon2id memory cost, in MiB, whose
+	// size in KiB fits the wallet header.
+	maxKDFMemory = 1<<22 - 1
+
+	// defaultDiagnosticsInterval is the interval between two diagnostics
+	// snapshots.
+	defaultDiagnosticsInterval = time.Minute
+
+	// defaultUnlockLockout is how long unlocking is disabled after
+	// --unlockmaxfailures consecutive wrong passphrases.
+	defaultUnlockLockout = time.Hour
 
 	walletDbName = "wallet.db"
 )
@@ -51,6 +79,8 @@ type config struct {
 	ShowVersion   bool                    `short:"V" long:"version" description:"Display version information and exit"`
 	Create        bool                    `long:"create" description:"Create the wallet if it does not exist"`
 	PassPhrase    string                  `long:"passphrase" description:"Passphrase for non-interactive --create (insecure)"`
+	Bootstrap     string                  `long:"bootstrap" description:"With --create, create a watching-only wallet from a JSON file with an account descriptor and the outputs it had unspent when exported from another system, without rescanning the chain"`
+	SeedShares    string                  `long:"seedshares" description:"With --create, display a generated wallet seed as SLIP-0039 mnemonic shares, given as threshold-of-count (e.g. 2-of-3), instead of in hexadecimal"`
 	CreateTemp    bool                    `long:"createtemp" description:"Create a temporary simulation wallet (pass=password) in the data directory indicated; must call with --datadir"`
 	AppDataDir    *cfgutil.ExplicitString `short:"A" long:"appdata" description:"Application data directory for wallet config, databases and logs"`
 	TestNet3      bool                    `long:"testnet" description:"Use the test Bitcoin network (version 3) (default mainnet)"`
@@ -60,8 +90,126 @@ type config struct {
 	LogDir        string                  `long:"logdir" description:"Directory to log output."`
 	Profile       string                  `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
 
+	// Diagnostics options
+	DiagnosticsInterval  time.Duration `long:"diagnosticsinterval" description:"Interval between two snapshots of internal metrics and logged errors recorded to a ring file in the network directory and returned by getdiagnostics (0 disables diagnostics).  Valid time units are {s, m, h}"`
+	DiagnosticsSnapshots int           `long:"diagnosticssnapshots" description:"Number of diagnostics snapshots kept in the ring file"`
+
 	// Wallet options
-	WalletPass string `long:"walletpass" default-mask:"-" description:"The public wallet password -- Only required if the wallet was created with one"`
+	WalletPass string   `long:"walletpass" default-mask:"-" description:"The public wallet password -- Only required if the wallet was created with one"`
+	TxHooks    []string `long:"txhook" description:"Program invoked with a JSON description of each transaction before coin selection, before signing, before broadcast and on confirmation; it may veto or annotate the transaction (may be specified multiple times)"`
+
+	// Wallet encryption options
+	KDF            string `long:"kdf" description:"Key derivation function deriving the encryption keys of created wallets from their passphrases {scrypt, argon2id}"`
+	KDFMemory      uint32 `long:"kdfmemory" description:"Memory cost, in MiB, of the argon2id key derivation function"`
+	KDFIterations  uint32 `long:"kdfiterations" description:"Number of iterations of the argon2id key derivation function"`
+	EncryptTxStore bool   `long:"encrypttxstore" description:"Encrypt the transaction history and unspent outputs of the wallet with a key protected by the public wallet password (--walletpass); existing wallets are encrypted when opened, and stay encrypted"`
+
+	// Unlock throttling options
+	UnlockMaxFailures uint32        `long:"unlockmaxfailures" description:"Disable unlocking after this many consecutive wrong passphrases (default 0 never disables unlocking; attempts are always delayed after wrong passphrases)"`
+	UnlockLockout     time.Duration `long:"unlocklockout" description:"How long unlocking is disabled after --unlockmaxfailures wrong passphrases, or 0 until restarted.  Valid time units are {s, m, h}"`
+
+	// Notification options
+	BalanceNtfnInterval    time.Duration `long:"balancentfninterval" description:"Minimum interval between two balance notifications of an account; balance changes during the interval, such as those of rescans and bursts of blocks, are coalesced into one notification (default 0 notifies every change).  Valid time units are {ms, s, m, h}"`
+	BalanceNtfnFlushOnSend bool          `long:"balancentfnflushonsend" description:"Notify pending balance changes as soon as the wallet sends a transaction, without waiting for the end of the balance notification interval"`
+	NtfnReplaySize         int           `long:"ntfnreplaysize" description:"Number of the latest notifications kept for websocket clients to replay with replaynotifications after reconnecting (0 disables replays)"`
+	NtfnQueueSize          int           `long:"ntfnqueuesize" description:"Number of the notifications created while no client subscribed to them, such as deposits during maintenance, kept on disk until a websocket client subscribes to them (0 disables the queue)"`
+
+	// Account quota options
+	AccountQuotas []string `long:"accountquota" description:"Maximum size of the transactions of an account, as account:size with an optional k, M or G suffix; accounts exceeding their quota are logged and notified (may be specified multiple times)"`
+	QuotaPrune    bool     `long:"quotaprune" description:"Prune the oldest deeply confirmed and fully spent transactions of accounts exceeding their --accountquota"`
+
+	// Change consolidation options
+	ConsolidateFeeRate   *cfgutil.AmountFlag `long:"consolidatefeerate" description:"Consolidate confirmed legacy and segwit v0 change outputs into taproot outputs of their account whenever the fee rate estimated by btcd is at most this rate, in BTC/kB (default 0 never consolidates)"`
+	ConsolidateMaxInputs int                 `long:"consolidatemaxinputs" description:"Maximum number of change outputs spent by one consolidation transaction"`
+	ConsolidateDryRun    bool                `long:"consolidatedryrun" description:"Only log and report the consolidations of --consolidatefeerate and --fragmentationfeerate without sending them"`
+
+	// Fragmentation consolidation options
+	FragmentationFeeRate   *cfgutil.AmountFlag `long:"fragmentationfeerate" description:"Consolidate the confirmed outputs below --fragmentationthreshold of every fragmented account into taproot outputs of the account whenever the fee rate estimated by btcd is at most this rate, in BTC/kB (default 0 never consolidates)"`
+	FragmentationThreshold *cfgutil.AmountFlag `long:"fragmentationthreshold" description:"Amount in BTC below which outputs fragment an account"`
+	FragmentationOutputs   int                 `long:"fragmentationoutputs" description:"Number of outputs below --fragmentationthreshold of one account and token from which the account is consolidated"`
+
+	// Dust options
+	DustThreshold    *cfgutil.AmountFlag `long:"dustthreshold" description:"Quarantine received outputs worth at most this amount in BTC, as sent to track wallet addresses, so that they are not spent by coin selection (0 disables the quarantine)"`
+	SpendQuarantined bool                `long:"spendquarantined" description:"Allow coin selection to spend quarantined dust outputs"`
+	DustRelayFee     *cfgutil.AmountFlag `long:"dustrelayfee" description:"Relay fee rate in BTC/kB from which the dust limit of created outputs is derived, refusing to create outputs below it (0 disables the limit)"`
+	RejectDustChange bool                `long:"rejectdustchange" description:"Refuse to create transactions whose change is below the dust limit instead of adding the change to the fee"`
+
+	// Fee cap options
+	MaxTxFee      *cfgutil.AmountFlag `long:"maxtxfee" description:"Refuse to create transactions paying a fee above this amount in BTC unless the request overrides the fee cap (0 disables the limit)"`
+	MaxFeePercent float64             `long:"maxfeepercent" description:"Refuse to create transactions paying a fee above this percentage of the amount they send unless the request overrides the fee cap (default 0 disables the limit)"`
+	MaxDailyFee   *cfgutil.AmountFlag `long:"maxdailyfee" description:"Refuse to create transactions once the fees of the transactions sent over the last 24 hours would exceed this amount in BTC unless the request overrides the fee cap (default 0 disables the limit)"`
+
+	// Coin selection options
+	CoinSelection string `long:"coinselection" description:"Strategy picking the outputs spent by sent transactions, one of oldestfirst, largestfirst or branchandbound"`
+	NoRBF         bool   `long:"norbf" description:"Do not signal BIP0125 replaceability in sent transactions unless they opt in, for recipients relying on the first transaction seen"`
+	NoLockTime    bool   `long:"nolocktime" description:"Do not set the lock time of sent transactions to the current block height, which discourages miners from reorganizing the chain to take their fees"`
+	ConfTarget    int64  `long:"conftarget" description:"Number of blocks within which the fee rate of sent transactions is estimated by the chain server to get them mined, unless set with settxfee"`
+	ChangePolicy  string `long:"changepolicy" description:"Address receiving the change of sent transactions, either new for a new internal address per transaction or reuse for the last internal address of the account"`
+
+	// Broadcast hold options
+	BroadcastHold          time.Duration `long:"broadcasthold" description:"Hold sent transactions for this window before they are broadcast, notifying them to pendingbroadcast subscribers and --broadcastwebhook, so that they can be cancelled with cancelbroadcast (default 0 broadcasts immediately).  Valid time units are {s, m, h}"`
+	BroadcastWebhooks      []string      `long:"broadcastwebhook" description:"URL POSTed the decoded transactions held by --broadcasthold (may be specified multiple times)"`
+	BroadcastWebhookAPIKey string        `long:"broadcastwebhookapikey" default-mask:"-" description:"API key sent as a bearer token to the broadcast webhooks"`
+
+	// Metadata anchoring options
+	MetadataAnchorInterval time.Duration `long:"metadataanchorinterval" description:"Minimum interval between two transactions anchoring the hash of the metadata of an account in an OP_RETURN output, sent when the metadata changed (default 0 only anchors with anchoraccountmetadata).  Valid time units are {m, h}"`
+
+	// Digest email options
+	DigestTo         []string            `long:"digestto" description:"Email a digest of received funds, confirmations, and low balance and failed broadcast alerts to this address (may be specified multiple times)"`
+	DigestFrom       string              `long:"digestfrom" description:"Sender address of the digest emails"`
+	DigestSMTPServer string              `long:"digestsmtpserver" description:"SMTP server relaying the digest emails, as host:port"`
+	DigestSMTPUser   string              `long:"digestsmtpuser" description:"Username for SMTP authentication"`
+	DigestSMTPPass   string              `long:"digestsmtppass" default-mask:"-" description:"Password for SMTP authentication"`
+	DigestInterval   time.Duration       `long:"digestinterval" description:"Interval between two digest emails; no digest is sent for an interval without activity.  Valid time units are {m, h}"`
+	DigestLowBalance *cfgutil.AmountFlag `long:"digestlowbalance" description:"Alert in the digest when the confirmed balance of the wallet is below this amount, in BTC (default 0 never alerts)"`
+
+	// Key usage alert options
+	KeyUsageAlertFactor float64 `long:"keyusagealertfactor" description:"Alert when the signatures of an address or account in an hour exceed this factor of its hourly baseline (0 disables the alerts)"`
+	KeyUsageAlertMin    uint64  `long:"keyusagealertmin" description:"Minimum number of signatures of an address or account in an hour to alert on"`
+
+	// Payment batching options
+	BatchInterval time.Duration `long:"batchinterval" description:"Interval between two batch transactions sending the payments queued with queuepayment (default 0 only sends them with sendqueuedpayments).  Valid time units are {m, h}"`
+
+	// Hot wallet refill options
+	RefillHotAccount  string              `long:"refillhotaccount" description:"Account holding the float spent by the wallet, refilled from --refillcoldaccount when its confirmed balance falls below --refillthreshold"`
+	RefillColdAccount string              `long:"refillcoldaccount" description:"Account of the watch-only addresses of the cold storage paying the refills of --refillhotaccount"`
+	RefillThreshold   *cfgutil.AmountFlag `long:"refillthreshold" description:"Balance in BTC of --refillhotaccount below which a refill request is written: a PSBT paying the hot account from the cold account, to be signed offline"`
+	RefillTarget      *cfgutil.AmountFlag `long:"refilltarget" description:"Balance in BTC --refillhotaccount is refilled to (default twice --refillthreshold)"`
+	RefillDir         string              `long:"refilldir" description:"Directory the refill request PSBTs are written to (default refills in the network directory)"`
+	RefillWebhook     string              `long:"refillwebhook" description:"URL POSTed the refill requests, with their base64 PSBTs"`
+
+	// Data retention options
+	LogMaxSize      int64         `long:"logmaxsize" description:"Size, in MiB, at which the log file is rolled"`
+	LogMaxRolls     int           `long:"logmaxrolls" description:"Maximum number of rolled log files kept"`
+	LogRetention    time.Duration `long:"logretention" description:"Prune the rolled log files older than this duration (default 0 keeps them until logmaxrolls is exceeded).  Valid time units are {h}"`
+	AuditRetention  time.Duration `long:"auditretention" description:"Prune the audit log records older than this duration (default 0 keeps them).  Valid time units are {h}"`
+	AuditMaxRecords int           `long:"auditmaxrecords" description:"Prune the oldest audit log records exceeding this number (default 0 keeps them)"`
+	PruneExportCmd  string        `long:"pruneexportcmd" description:"Program archiving pruned log files and audit records before they are removed; it receives the kind and name of the data as arguments and the data on stdin, and a failure keeps the data"`
+
+	// Backup options
+	BackupPass          string   `long:"backuppass" default-mask:"-" description:"Passphrase encrypting the backups made by backupwallet, distinct from the wallet passphrases so that the operators keeping backups cannot spend"`
+	BackupGPGRecipients []string `long:"backupgpgrecipient" description:"Encrypt the backups made by backupwallet to the GPG public key with this key ID, fingerprint or email instead of a passphrase; may be repeated"`
+	BackupGPG           string   `long:"backupgpg" description:"Path of the gpg program encrypting the backups to --backupgpgrecipient"`
+	BackupToken         string   `long:"backuptoken" description:"Path of the program answering challenges with a hardware token, such as a YubiKey; the backups made by backupwallet are then wrapped to the tokens enrolled by enrollbackuptoken"`
+
+	// Hardware wallet options
+	HWI            string `long:"hwi" description:"Path of the HWI program used to sign the transactions of the default account with a hardware wallet instead of the wallet's private keys; with --create and no --bootstrap, create a watching-only wallet for a new BIP0084 account of the device"`
+	HWIFingerprint string `long:"hwifingerprint" description:"Master key fingerprint, in hex, of the hardware wallet used by --hwi"`
+
+	// Address screening options
+	ScreenURL       string   `long:"screenurl" description:"URL of an address screening provider queried with the destination addresses of every transaction before broadcast"`
+	ScreenAPIKey    string   `long:"screenapikey" default-mask:"-" description:"API key sent as a bearer token to the screening provider"`
+	ScreenBlock     []string `long:"screenblock" description:"Risk category of the screening provider that blocks a transaction (may be specified multiple times)"`
+	ScreenWarn      []string `long:"screenwarn" description:"Risk category of the screening provider that is logged as a warning (may be specified multiple times)"`
+	ScreenFailOpen  bool     `long:"screenfailopen" description:"Allow transactions when the screening provider fails (default blocks them)"`
+	ScreenOnReceive bool     `long:"screenonreceive" description:"Also screen the addresses of received transactions, locking outputs received by blocked addresses"`
+
+	// Transaction accelerator options
+	Accelerators      []string `long:"accelerator" description:"URL of a third-party transaction acceleration service, such as the API of a mining pool, that acceleratetx may POST stuck transactions to (may be specified multiple times)"`
+	AcceleratorAPIKey string   `long:"acceleratorapikey" default-mask:"-" description:"API key sent as a bearer token to the acceleration services"`
+
+	// ZMQ publisher options
+	ZMQPub []string `long:"zmqpub" description:"Publish the recvtx, spent, balance and block events of the wallet on a ZMQ PUB socket bound to this address, as tcp://host:port (default port: 28335; may be specified multiple times)"`
 
 	// RPC client options
 	RPCConnect       string                  `short:"c" long:"rpcconnect" description:"Hostname/IP and port of btcd RPC server to connect to (default localhost:8334, testnet: localhost:18334, simnet: localhost:18556)"`
@@ -72,6 +220,8 @@ type config struct {
 	Proxy            string                  `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
 	ProxyUser        string                  `long:"proxyuser" description:"Username for proxy server"`
 	ProxyPass        string                  `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
+	Offline          bool                    `long:"offline" description:"Run without connecting to btcd, creating transactions from the outputs known at the last sync and queuing them for broadcast once the wallet runs with a connection"`
+	RescanLatency    time.Duration           `long:"rescanlatency" description:"Response time targeted by the requests filtering blocks during catch-up rescans, whose block ranges grow on fast backends and shrink on slow or remote ones (0 filters a fixed number of blocks per request).  Valid time units are {ms, s, m, h}"`
 
 	// SPV client options
 	UseSPV       bool          `long:"usespv" description:"Enables the experimental use of SPV rather than RPC for chain synchronization"`
@@ -93,11 +243,21 @@ type config struct {
 	RPCKey                 *cfgutil.ExplicitString `long:"rpckey" description:"File containing the certificate key"`
 	OneTimeTLSKey          bool                    `long:"onetimetlskey" description:"Generate a new TLS certpair at startup, but only write the certificate to disk"`
 	DisableServerTLS       bool                    `long:"noservertls" description:"Disable TLS for the RPC server -- NOTE: This is only allowed if the RPC server is bound to localhost"`
+	RPCClientCerts         []string                `long:"rpcclientcert" description:"Require RPC clients to present the certificate in this PEM file for mutual TLS, pinning the frontends permitted to connect (may be specified multiple times)"`
 	LegacyRPCListeners     []string                `long:"rpclisten" description:"Listen for legacy RPC connections on this interface/port (default port: 8332, testnet: 18332, simnet: 18554)"`
 	LegacyRPCMaxClients    int64                   `long:"rpcmaxclients" description:"Max number of legacy RPC clients for standard connections"`
 	LegacyRPCMaxWebsockets int64                   `long:"rpcmaxwebsockets" description:"Max number of legacy RPC websocket connections"`
 	Username               string                  `short:"u" long:"username" description:"Username for legacy RPC and btcd authentication (if btcdusername is unset)"`
 	Password               string                  `short:"P" long:"password" default-mask:"-" description:"Password for legacy RPC and btcd authentication (if btcdpassword is unset)"`
+	BlindedUsername        string                  `long:"rpcblindeduser" description:"Username for legacy RPC websocket clients only permitted to receive receive notifications without amounts"`
+	BlindedPassword        string                  `long:"rpcblindedpass" default-mask:"-" description:"Password for legacy RPC websocket clients only permitted to receive receive notifications without amounts"`
+	RPCNoSharedAuth        bool                    `long:"rpcnosharedauth" description:"Refuse legacy RPC clients authenticating with --username and --password, so that each frontend must authenticate with its own API token created by createapitoken"`
+	RPCAllow               []string                `long:"rpcallow" description:"Only accept RPC connections from this network, in CIDR notation, optionally followed by @ and the listen address of the only listener the rule applies to (may be specified multiple times)"`
+	RPCDeny                []string                `long:"rpcdeny" description:"Refuse RPC connections from this network, in CIDR notation, optionally followed by @ and the listen address of the only listener the rule applies to; takes precedence over --rpcallow (may be specified multiple times)"`
+	RPCAllowedOrigins      []string                `long:"rpcallowedorigin" description:"Only accept legacy RPC websocket upgrades from browsers at this origin, such as https://example.com (may be specified multiple times; default accepts every origin)"`
+	RPCWSNoCompression     bool                    `long:"rpcwsnocompression" description:"Disable the permessage-deflate compression of legacy RPC websocket messages, which is negotiated with clients supporting it"`
+	RPCWSMaxFrameSize      int                     `long:"rpcwsmaxframesize" description:"Max payload size in bytes of the frames sent to legacy RPC websocket clients, larger messages being split across frames"`
+	RPCWSMaxMessageSize    int64                   `long:"rpcwsmaxmessagesize" description:"Max size in bytes of the messages read from legacy RPC websocket clients, which are disconnected when they send larger ones (0 for no limit)"`
 
 	// EXPERIMENTAL RPC server options
 	//
@@ -242,6 +402,104 @@ func parseAndSetDebugLevels(debugLevel string) error {
 	return nil
 }
 
+// parseAccountQuota parses an account quota of the form account:size, where
+// the size in bytes may have a k, M or G suffix for binary multiples.  Account
+// names may contain colons, so the size follows the last one.
+func parseAccountQuota(s string) (account string, quota int64, err error) {
+	i := strings.LastIndex(s, ":")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("account quota %q is not of the form "+
+			"account:size", s)
+	}
+	account, size := s[:i], s[i+1:]
+	multiplier := int64(1)
+	if n := len(size); n != 0 {
+		switch size[n-1] {
+		case 'k', 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier != 1 {
+			size = size[:n-1]
+		}
+	}
+	quota, err = strconv.ParseInt(size, 10, 64)
+	if err != nil || quota <= 0 {
+		return "", 0, fmt.Errorf("account quota %q does not have a "+
+			"positive size", s)
+	}
+	return account, quota * multiplier, nil
+}
+
+// parseSeedShares parses a --seedshares option of the form threshold-of-count.
+func parseSeedShares(s string) (threshold, count int, err error) {
+	parts := strings.Split(s, "-of-")
+	if len(parts) == 2 {
+		threshold, err = strconv.Atoi(parts[0])
+		if err == nil {
+			count, err = strconv.Atoi(parts[1])
+		}
+	}
+	if len(parts) != 2 || err != nil {
+		return 0, 0, fmt.Errorf("seed shares %q are not of the form "+
+			"threshold-of-count", s)
+	}
+	if count < 1 || count > slip39.MaxShares {
+		return 0, 0, fmt.Errorf("seed share count must be between 1 "+
+			"and %d", slip39.MaxShares)
+	}
+	if threshold < 1 || threshold > count || threshold == 1 && count > 1 {
+		return 0, 0, fmt.Errorf("seed share threshold must be between "+
+			"2 and the share count %d", count)
+	}
+	return threshold, count, nil
+}
+
+// parseListenerRule parses an --rpcallow or --rpcdeny rule of the form
+// network[@listener], where the network is in CIDR notation or a single IP
+// address.  The listener address is normalized with the default RPC port, and
+// is empty for rules applying to every listener.
+func parseListenerRule(s string) (network *net.IPNet, listener string, err error) {
+	cidr := s
+	if i := strings.LastIndex(s, "@"); i != -1 {
+		cidr = s[:i]
+		listener, err = cfgutil.NormalizeAddress(s[i+1:],
+			activeNet.RPCServerPort)
+		if err != nil {
+			return nil, "", fmt.Errorf("listener of rule %q is "+
+				"invalid: %v", s, err)
+		}
+	}
+	_, network, err = net.ParseCIDR(cidr)
+	if err != nil {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return nil, "", fmt.Errorf("rule %q is not a network "+
+				"in CIDR notation or an IP address", s)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	}
+	return network, listener, nil
+}
+
+// normalizeOrigin returns the scheme and host of a websocket origin in
+// lowercase, as sent by browsers in the Origin header.
+func normalizeOrigin(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("origin %q is not of the form "+
+			"scheme://host[:port]", s)
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
 // loadConfig initializes and parses the config using a config file and command
 // line options.
 //
@@ -261,12 +519,44 @@ func loadConfig() (*config, []string, error) {
 		ConfigFile:             cfgutil.NewExplicitString(defaultConfigFile),
 		AppDataDir:             cfgutil.NewExplicitString(defaultAppDataDir),
 		LogDir:                 defaultLogDir,
+		DiagnosticsInterval:    defaultDiagnosticsInterval,
+		DiagnosticsSnapshots:   diagnostics.DefaultSnapshots,
 		WalletPass:             wallet.InsecurePubPassphrase,
+		KDF:                    defaultKDF,
+		KDFMemory:              defaultKDFMemory,
+		KDFIterations:          defaultKDFIterations,
+		UnlockLockout:          defaultUnlockLockout,
+		ConsolidateFeeRate:     cfgutil.NewAmountFlag(0),
+		DigestInterval:         wallet.DefaultEmailDigestInterval,
+		DigestLowBalance:       cfgutil.NewAmountFlag(0),
+		KeyUsageAlertFactor:    wallet.DefaultKeyUsageFactor,
+		NtfnReplaySize:         wallet.DefaultNotificationReplaySize,
+		NtfnQueueSize:          wallet.DefaultNotificationQueueSize,
+		RescanLatency:          wallet.DefaultRescanBatchLatency,
+		KeyUsageAlertMin:       wallet.DefaultKeyUsageMinSignatures,
+		LogMaxSize:             defaultLogMaxSize,
+		BackupGPG:              "gpg",
+		LogMaxRolls:            defaultLogMaxRolls,
+		ConsolidateMaxInputs:   wallet.DefaultConsolidationMaxInputs,
+		FragmentationFeeRate:   cfgutil.NewAmountFlag(0),
+		FragmentationThreshold: cfgutil.NewAmountFlag(wallet.DefaultFragmentationThreshold),
+		FragmentationOutputs:   wallet.DefaultFragmentationMinOutputs,
+		DustThreshold:          cfgutil.NewAmountFlag(wallet.DefaultDustThreshold),
+		DustRelayFee:           cfgutil.NewAmountFlag(wallet.DefaultDustRelayFeePerKb),
+		MaxTxFee:               cfgutil.NewAmountFlag(wallet.DefaultMaxTxFee),
+		MaxDailyFee:            cfgutil.NewAmountFlag(0),
+		RefillThreshold:        cfgutil.NewAmountFlag(0),
+		RefillTarget:           cfgutil.NewAmountFlag(0),
+		CoinSelection:          wallet.CoinSelectionOldestFirst,
+		ChangePolicy:           string(wallet.ChangePolicyNew),
+		ConfTarget:             wallet.DefaultConfTarget,
 		CAFile:                 cfgutil.NewExplicitString(""),
 		RPCKey:                 cfgutil.NewExplicitString(defaultRPCKeyFile),
 		RPCCert:                cfgutil.NewExplicitString(defaultRPCCertFile),
 		LegacyRPCMaxClients:    defaultRPCMaxClients,
 		LegacyRPCMaxWebsockets: defaultRPCMaxWebsockets,
+		RPCWSMaxFrameSize:      defaultRPCWSFrameSize,
+		RPCWSMaxMessageSize:    defaultRPCWSMessageSize,
 		DataDir:                cfgutil.NewExplicitString(defaultAppDataDir),
 		UseSPV:                 false,
 		AddPeers:               []string{},
@@ -386,9 +676,22 @@ func loadConfig() (*config, []string, error) {
 		os.Exit(0)
 	}
 
+	if cfg.LogMaxSize < 1 {
+		err := fmt.Errorf("The --logmaxsize option must be positive.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.LogMaxRolls < 0 {
+		err := fmt.Errorf("The --logmaxrolls option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
 	// Initialize log rotation.  After log rotation has been initialized, the
 	// logger variables may be used.
-	initLogRotator(filepath.Join(cfg.LogDir, defaultLogFilename))
+	initLogRotator(filepath.Join(cfg.LogDir, defaultLogFilename),
+		cfg.LogMaxSize*1024, cfg.LogMaxRolls)
 
 	// Parse, validate, and set debug log level(s).
 	if err := parseAndSetDebugLevels(cfg.DebugLevel); err != nil {
@@ -414,6 +717,327 @@ func loadConfig() (*config, []string, error) {
 		os.Exit(0)
 	}
 
+	// The hardware wallet used by --hwi is selected by its fingerprint.
+	if cfg.HWI != "" {
+		if cfg.HWIFingerprint == "" {
+			err := fmt.Errorf("The --hwi option requires " +
+				"--hwifingerprint.")
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		cfg.HWI = cleanAndExpandPath(cfg.HWI)
+	}
+
+	// The costs only apply to argon2id, and must allow it to derive keys.
+	switch cfg.KDF {
+	case "scrypt":
+	case "argon2id":
+		if cfg.KDFMemory == 0 || cfg.KDFMemory > maxKDFMemory {
+			err := fmt.Errorf("The --kdfmemory option must be "+
+				"between 1 and %d MiB.", maxKDFMemory)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.KDFIterations == 0 {
+			err := fmt.Errorf("The --kdfiterations option must be " +
+				"positive.")
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	default:
+		err := fmt.Errorf("The --kdf option must be one of scrypt " +
+			"or argon2id.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.BalanceNtfnInterval < 0 {
+		err := fmt.Errorf("The --balancentfninterval option may not " +
+			"be negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.NtfnReplaySize < 0 {
+		err := fmt.Errorf("The --ntfnreplaysize option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.NtfnQueueSize < 0 {
+		err := fmt.Errorf("The --ntfnqueuesize option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.RescanLatency < 0 {
+		err := fmt.Errorf("The --rescanlatency option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.UnlockLockout < 0 {
+		err := fmt.Errorf("The --unlocklockout option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.DiagnosticsInterval < 0 {
+		err := fmt.Errorf("The --diagnosticsinterval option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.DiagnosticsSnapshots < 1 {
+		err := fmt.Errorf("The --diagnosticssnapshots option must be " +
+			"positive.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.MetadataAnchorInterval < 0 {
+		err := fmt.Errorf("The --metadataanchorinterval option may " +
+			"not be negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.BroadcastHold < 0 {
+		err := fmt.Errorf("The --broadcasthold option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if len(cfg.BroadcastWebhooks) != 0 && cfg.BroadcastHold == 0 {
+		err := fmt.Errorf("The --broadcastwebhook option requires " +
+			"--broadcasthold.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if len(cfg.DigestTo) != 0 {
+		if cfg.DigestFrom == "" || cfg.DigestSMTPServer == "" {
+			err := fmt.Errorf("The --digestto option requires " +
+				"--digestfrom and --digestsmtpserver.")
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if _, _, err := net.SplitHostPort(cfg.DigestSMTPServer); err != nil {
+			err := fmt.Errorf("The --digestsmtpserver option is "+
+				"not of the form host:port: %v", err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.DigestInterval <= 0 {
+			err := fmt.Errorf("The --digestinterval option must be " +
+				"positive.")
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.DigestLowBalance.Amount < 0 {
+			err := fmt.Errorf("The --digestlowbalance option may " +
+				"not be negative.")
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
+	if cfg.BatchInterval < 0 {
+		err := fmt.Errorf("The --batchinterval option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.LogRetention < 0 || cfg.AuditRetention < 0 ||
+		cfg.AuditMaxRecords < 0 {
+
+		err := fmt.Errorf("The --logretention, --auditretention and " +
+			"--auditmaxrecords options may not be negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.BackupPass != "" && len(cfg.BackupGPGRecipients) != 0 {
+		err := fmt.Errorf("The --backuppass and --backupgpgrecipient " +
+			"options may not be used together.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.BackupToken != "" && (cfg.BackupPass != "" ||
+		len(cfg.BackupGPGRecipients) != 0) {
+
+		err := fmt.Errorf("The --backuptoken option may not be used " +
+			"with --backuppass or --backupgpgrecipient.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.BackupToken != "" {
+		cfg.BackupToken = cleanAndExpandPath(cfg.BackupToken)
+	}
+
+	if cfg.KeyUsageAlertFactor < 0 {
+		err := fmt.Errorf("The --keyusagealertfactor option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	for _, q := range cfg.AccountQuotas {
+		if _, _, err := parseAccountQuota(q); err != nil {
+			err := fmt.Errorf("The --accountquota option is invalid: %v",
+				err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
+	if cfg.SeedShares != "" {
+		if _, _, err := parseSeedShares(cfg.SeedShares); err != nil {
+			err := fmt.Errorf("The --seedshares option is invalid: %v",
+				err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
+	if cfg.ConsolidateFeeRate.Amount < 0 {
+		err := fmt.Errorf("The --consolidatefeerate option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.ConsolidateMaxInputs < 1 {
+		err := fmt.Errorf("The --consolidatemaxinputs option must be " +
+			"positive.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.FragmentationFeeRate.Amount < 0 {
+		err := fmt.Errorf("The --fragmentationfeerate option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.FragmentationThreshold.Amount <= 0 {
+		err := fmt.Errorf("The --fragmentationthreshold option must be " +
+			"positive.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.FragmentationOutputs < 2 {
+		err := fmt.Errorf("The --fragmentationoutputs option must be " +
+			"at least 2.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.DustThreshold.Amount < 0 {
+		err := fmt.Errorf("The --dustthreshold option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.DustRelayFee.Amount < 0 {
+		err := fmt.Errorf("The --dustrelayfee option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.MaxTxFee.Amount < 0 {
+		err := fmt.Errorf("The --maxtxfee option may not be negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.MaxFeePercent < 0 || cfg.MaxFeePercent > 100 {
+		err := fmt.Errorf("The --maxfeepercent option must be " +
+			"between 0 and 100.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.MaxDailyFee.Amount < 0 {
+		err := fmt.Errorf("The --maxdailyfee option may not be " +
+			"negative.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if (cfg.RefillHotAccount == "") != (cfg.RefillColdAccount == "") {
+		err := fmt.Errorf("The --refillhotaccount and " +
+			"--refillcoldaccount options must be used together.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.RefillHotAccount != "" {
+		if cfg.RefillHotAccount == cfg.RefillColdAccount {
+			err := fmt.Errorf("The --refillhotaccount and " +
+				"--refillcoldaccount options must be distinct " +
+				"accounts.")
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.RefillThreshold.Amount <= 0 {
+			err := fmt.Errorf("The --refillhotaccount option " +
+				"requires a positive --refillthreshold.")
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.RefillTarget.Amount == 0 {
+			cfg.RefillTarget.Amount = 2 * cfg.RefillThreshold.Amount
+		}
+		if cfg.RefillTarget.Amount <= cfg.RefillThreshold.Amount {
+			err := fmt.Errorf("The --refilltarget option must be " +
+				"above --refillthreshold.")
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.RefillDir != "" {
+			cfg.RefillDir = cleanAndExpandPath(cfg.RefillDir)
+		}
+		if cfg.RefillWebhook != "" {
+			u, err := url.Parse(cfg.RefillWebhook)
+			if err != nil || (u.Scheme != "http" &&
+				u.Scheme != "https") || u.Host == "" {
+
+				err := fmt.Errorf("The --refillwebhook option " +
+					"is not an HTTP URL.")
+				fmt.Fprintln(os.Stderr, err)
+				return nil, nil, err
+			}
+		}
+	}
+
+	if _, err := wallet.CoinSelectorByName(cfg.CoinSelection); err != nil {
+		err := fmt.Errorf("The --coinselection option is invalid: %v",
+			err)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if _, err := wallet.ParseChangePolicy(cfg.ChangePolicy); err != nil {
+		err := fmt.Errorf("The --changepolicy option is invalid: %v",
+			err)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	if cfg.ConfTarget < 1 {
+		err := fmt.Errorf("The --conftarget option must be positive.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	// Offline wallets do not sync, by RPC or SPV.
+	if cfg.Offline && cfg.UseSPV {
+		err := fmt.Errorf("The --offline and --usespv options may " +
+			"not be used together.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
 	// Ensure the wallet exists or create it when the create flag is set.
 	netDir := networkDir(cfg.AppDataDir.Value, activeNet.Params)
 	dbPath := filepath.Join(netDir, walletDbName)
@@ -455,6 +1079,10 @@ func loadConfig() (*config, []string, error) {
 			}
 		}
 	} else if cfg.Create {
+		if cfg.Bootstrap != "" {
+			cfg.Bootstrap = cleanAndExpandPath(cfg.Bootstrap)
+		}
+
 		// Error if the create flag is set and the wallet already
 		// exists.
 		if dbFileExists {
@@ -596,6 +1224,17 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	for i, addr := range cfg.ZMQPub {
+		cfg.ZMQPub[i] = strings.TrimPrefix(addr, "tcp://")
+	}
+	cfg.ZMQPub, err = cfgutil.NormalizeAddresses(cfg.ZMQPub,
+		defaultZMQPubPort)
+	if err != nil {
+		fmt.Fprintf(os.Stderr,
+			"Invalid network address in ZMQ publishers: %v\n", err)
+		return nil, nil, err
+	}
+
 	// Both RPC servers may not listen on the same interface/port.
 	if len(cfg.LegacyRPCListeners) > 0 && len(cfg.ExperimentalRPCListeners) > 0 {
 		seenAddresses := make(map[string]struct{}, len(cfg.LegacyRPCListeners))
@@ -614,6 +1253,51 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// Connection rules may only name the listeners of the RPC servers.
+	listeners := make(map[string]struct{})
+	for _, addr := range cfg.LegacyRPCListeners {
+		listeners[addr] = struct{}{}
+	}
+	for _, addr := range cfg.ExperimentalRPCListeners {
+		listeners[addr] = struct{}{}
+	}
+	for _, rule := range append(cfg.RPCAllow, cfg.RPCDeny...) {
+		_, listener, err := parseListenerRule(rule)
+		if err == nil && listener != "" {
+			if _, ok := listeners[listener]; !ok {
+				err = fmt.Errorf("rule %q names %s, which is "+
+					"not an RPC listener", rule, listener)
+			}
+		}
+		if err != nil {
+			err := fmt.Errorf("The --rpcallow and --rpcdeny options "+
+				"are invalid: %v", err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+	for i, origin := range cfg.RPCAllowedOrigins {
+		cfg.RPCAllowedOrigins[i], err = normalizeOrigin(origin)
+		if err != nil {
+			err := fmt.Errorf("The --rpcallowedorigin option is "+
+				"invalid: %v", err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+	if cfg.RPCWSMaxFrameSize < minRPCWSFrameSize {
+		err := fmt.Errorf("The --rpcwsmaxframesize option must be at "+
+			"least %d bytes", minRPCWSFrameSize)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.RPCWSMaxMessageSize < 0 {
+		err := fmt.Errorf("The --rpcwsmaxmessagesize option may not " +
+			"be negative")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
 	// Only allow server TLS to be disabled if the RPC server is bound to
 	// localhost addresses.
 	if cfg.DisableServerTLS {
@@ -640,10 +1324,39 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// Client certificates are presented during the TLS handshake.
+	if cfg.DisableServerTLS && len(cfg.RPCClientCerts) != 0 {
+		err := fmt.Errorf("The --rpcclientcert option may not be used " +
+			"with --noservertls.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
 	// Expand environment variable and leading ~ for filepaths.
 	cfg.CAFile.Value = cleanAndExpandPath(cfg.CAFile.Value)
 	cfg.RPCCert.Value = cleanAndExpandPath(cfg.RPCCert.Value)
 	cfg.RPCKey.Value = cleanAndExpandPath(cfg.RPCKey.Value)
+	for i, path := range cfg.RPCClientCerts {
+		cfg.RPCClientCerts[i] = cleanAndExpandPath(path)
+	}
+	for i, path := range cfg.TxHooks {
+		cfg.TxHooks[i] = cleanAndExpandPath(path)
+	}
+	if cfg.PruneExportCmd != "" {
+		cfg.PruneExportCmd = cleanAndExpandPath(cfg.PruneExportCmd)
+	}
+
+	// The blinded credentials must not authenticate clients with full
+	// access.
+	if (cfg.BlindedUsername != "" || cfg.BlindedPassword != "") &&
+		cfg.BlindedUsername == cfg.Username &&
+		cfg.BlindedPassword == cfg.Password {
+
+		err := fmt.Errorf("The --rpcblindeduser and --rpcblindedpass " +
+			"options may not match --username and --password.")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
 
 	// If the btcd username or password are unset, use the same auth as for
 	// the client.  The two settings were previously shared for btcd and
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcwallet/internal/cfgutil"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// configCheckInterval is the interval between two checks of the
+// configuration fingerprint for changes of the runtime policies.
+const configCheckInterval = time.Minute
+
+// configExcluded are the options left out of the configuration fingerprint,
+// which request one-time actions rather than configure the daemon.
+var configExcluded = []string{
+	"configfile", "version", "create", "createtemp", "passphrase",
+	"bootstrap", "seedshares", "datadir",
+}
+
+// configDrift holds the last configuration fingerprint, so that its changes
+// are logged.
+var configDrift struct {
+	mu   sync.Mutex
+	last *cfgutil.Fingerprint
+}
+
+// configFingerprint returns the fingerprint of the effective configuration,
+// including the policies of the loaded wallet which can be changed at runtime
+// over RPC.  Daemons with equal configurations have equal fingerprints, so
+// comparing their hashes detects configuration drift across hosts.
+func configFingerprint(loader *wallet.Loader) *cfgutil.Fingerprint {
+	settings := cfgutil.Settings(cfg, configExcluded...)
+	if w, ok := loader.LoadedWallet(); ok {
+		settings = append(settings,
+			cfgutil.Setting{
+				Name:  "wallet.changepolicy",
+				Value: string(w.ChangePolicy()),
+			},
+			cfgutil.Setting{
+				Name:  "wallet.txfee",
+				Value: w.TxFee().String(),
+			})
+		sort.SliceStable(settings, func(i, j int) bool {
+			return settings[i].Name < settings[j].Name
+		})
+	}
+	return cfgutil.NewFingerprint(settings)
+}
+
+// checkConfigFingerprint returns the current configuration fingerprint, and
+// logs it when it changed since the last check.
+func checkConfigFingerprint(loader *wallet.Loader) *cfgutil.Fingerprint {
+	f := configFingerprint(loader)
+
+	configDrift.mu.Lock()
+	defer configDrift.mu.Unlock()
+	last := configDrift.last
+	switch {
+	case last == nil:
+		log.Infof("Configuration hash %v", f)
+	case last.Hash != f.Hash:
+		log.Infof("Configuration hash changed from %v to %v (%s)",
+			last, f, strings.Join(cfgutil.Changed(last, f), ", "))
+	}
+	configDrift.last = f
+	return f
+}
+
+// configDriftMonitor periodically checks the configuration fingerprint for
+// changes.  It must be run as a goroutine.
+func configDriftMonitor(loader *wallet.Loader) {
+	ticker := time.NewTicker(configCheckInterval)
+	defer ticker.Stop()
+	for {
+		checkConfigFingerprint(loader)
+		<-ticker.C
+	}
+}
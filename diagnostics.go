@@ -0,0 +1,87 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/btcsuite/btcwallet/rpc/legacyrpc"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// diagnosticsFilename is the name of the ring file of diagnostics snapshots,
+// in the network directory.
+const diagnosticsFilename = "diagnostics.log"
+
+// notificationQueue is implemented by the chain clients reporting the depth
+// of their notification queue.
+type notificationQueue interface {
+	NotificationQueueDepth() int
+}
+
+// startDiagnostics opens the diagnostics ring file, registers the collectors
+// of the process, wallet, chain client and legacy RPC server metrics, and
+// starts recording snapshots every --diagnosticsinterval.
+func startDiagnostics(loader *wallet.Loader, legacyServer *legacyrpc.Server) error {
+	path := filepath.Join(networkDir(cfg.AppDataDir.Value, activeNet.Params),
+		diagnosticsFilename)
+	err := diagnosticsRecorder.Open(path, cfg.DiagnosticsSnapshots)
+	if err != nil {
+		return err
+	}
+
+	diagnosticsRecorder.Register(func(metrics map[string]int64) {
+		// The first 8 bytes of the configuration hash are enough to
+		// compare the configurations of hosts.
+		f := checkConfigFingerprint(loader)
+		metrics["config.hash"] = int64(binary.BigEndian.Uint64(f.Hash[:8]))
+	})
+	diagnosticsRecorder.Register(func(metrics map[string]int64) {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		metrics["goroutines"] = int64(runtime.NumGoroutine())
+		metrics["heapalloc"] = int64(mem.HeapAlloc)
+	})
+	diagnosticsRecorder.Register(func(metrics map[string]int64) {
+		w, ok := loader.LoadedWallet()
+		if !ok {
+			return
+		}
+		dbPath := filepath.Join(networkDir(cfg.AppDataDir.Value,
+			activeNet.Params), walletDbName)
+		if fi, err := os.Stat(dbPath); err == nil {
+			metrics["wallet.dbsize"] = fi.Size()
+		}
+		stats, err := w.StoreStats()
+		if err != nil {
+			log.Warnf("Cannot read wallet store diagnostics: %v", err)
+		} else {
+			metrics["wallet.unminedtxs"] = int64(stats.UnminedTxs)
+			metrics["wallet.unspentoutputs"] = int64(stats.UnspentOutputs)
+			metrics["wallet.lockedoutpoints"] = int64(stats.LockedOutpoints)
+			metrics["wallet.queuedbroadcasts"] = int64(stats.QueuedBroadcasts)
+			metrics["wallet.pendingbroadcasts"] = int64(stats.PendingBroadcasts)
+		}
+		signatures, alerts := w.KeyUsageTotals()
+		metrics["wallet.signatures"] = int64(signatures)
+		metrics["wallet.keyusagealerts"] = int64(alerts)
+		if q, ok := w.ChainClient().(notificationQueue); ok {
+			metrics["chain.queuedepth"] = int64(q.NotificationQueueDepth())
+		}
+	})
+	if legacyServer != nil {
+		diagnosticsRecorder.Register(func(metrics map[string]int64) {
+			active, handled := legacyServer.HandlerStats()
+			metrics["rpc.activehandlers"] = int64(active)
+			metrics["rpc.handledrequests"] = int64(handled)
+		})
+	}
+
+	diagnosticsRecorder.Start(cfg.DiagnosticsInterval, log.Errorf)
+	return nil
+}
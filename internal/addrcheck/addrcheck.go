@@ -0,0 +1,223 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package addrcheck decodes the addresses given to the wallet by its users.
+// Addresses are strictly validated: their checksum, their network and the
+// case of bech32 addresses are checked before they are decoded, and invalid
+// addresses are described precisely, such as a testnet address given to a
+// mainnet wallet or a mistyped character breaking the checksum.
+package addrcheck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+)
+
+// ErrorKind identifies a kind of invalid address.
+type ErrorKind int
+
+// These constants are the kinds of invalid addresses.
+const (
+	// ErrEmpty indicates an empty address.
+	ErrEmpty ErrorKind = iota
+
+	// ErrMalformed indicates a string which is not an address encoding,
+	// or encodes an unknown kind of address.
+	ErrMalformed
+
+	// ErrMixedCase indicates a bech32 address mixing upper and lower case
+	// characters, which BIP0173 forbids.
+	ErrMixedCase
+
+	// ErrChecksum indicates an address whose checksum does not match, most
+	// likely because of a mistyped character.
+	ErrChecksum
+
+	// ErrWrongNetwork indicates an address of another network.
+	ErrWrongNetwork
+)
+
+// Error describes an invalid address.
+type Error struct {
+	Address     string
+	Kind        ErrorKind
+	Description string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid address %q: %s", e.Address, e.Description)
+}
+
+// IsError returns whether err is an *Error of the kind.
+func IsError(err error, kind ErrorKind) bool {
+	e, ok := err.(*Error)
+	return ok && e.Kind == kind
+}
+
+// Networks are the networks recognized in the addresses of other networks,
+// to name them in the error of an address given on the wrong network.
+var Networks = []*chaincfg.Params{
+	&chaincfg.MainNetParams,
+	&chaincfg.TestNet3Params,
+	&chaincfg.RegressionNetParams,
+	&chaincfg.SimNetParams,
+}
+
+func addrError(s string, kind ErrorKind, format string, args ...interface{}) error {
+	return &Error{
+		Address:     s,
+		Kind:        kind,
+		Description: fmt.Sprintf(format, args...),
+	}
+}
+
+// Decode decodes a base58 or bech32 address of the network of params.
+// Invalid addresses are reported as an *Error.
+func Decode(s string, params *chaincfg.Params) (btcutil.Address, error) {
+	if s == "" {
+		return nil, addrError(s, ErrEmpty, "address is empty")
+	}
+	if strings.TrimSpace(s) != s {
+		return nil, addrError(s, ErrMalformed, "address has leading "+
+			"or trailing whitespace")
+	}
+
+	var addr btcutil.Address
+	var err error
+	sep := strings.LastIndexByte(s, '1')
+	if sep > 0 && (strings.ToLower(s[:sep]) == params.Bech32HRPSegwit ||
+		bech32Network(strings.ToLower(s[:sep])) != nil) {
+
+		addr, err = decodeBech32(s, sep, params)
+	} else {
+		addr, err = decodeBase58(s, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The checks above should leave no address of another network, but
+	// the decoded address is checked nonetheless.
+	if !addr.IsForNet(params) {
+		return nil, addrError(s, ErrWrongNetwork, "address is not "+
+			"intended for use on %s", params.Name)
+	}
+	return addr, nil
+}
+
+// bech32Network returns the network using the human readable part of segwit
+// addresses, or nil.
+func bech32Network(hrp string) *chaincfg.Params {
+	for _, net := range Networks {
+		if hrp == net.Bech32HRPSegwit {
+			return net
+		}
+	}
+	return nil
+}
+
+// base58Network returns the network of the version byte of base58 addresses,
+// or nil.
+func base58Network(version byte) *chaincfg.Params {
+	for _, net := range Networks {
+		if version == net.PubKeyHashAddrID ||
+			version == net.ScriptHashAddrID {
+
+			return net
+		}
+	}
+	return nil
+}
+
+// isPrivateKeyID returns whether version is the version byte of the private
+// keys of params or of a known network.
+func isPrivateKeyID(version byte, params *chaincfg.Params) bool {
+	if version == params.PrivateKeyID {
+		return true
+	}
+	for _, net := range Networks {
+		if version == net.PrivateKeyID {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeBech32(s string, sep int, params *chaincfg.Params) (btcutil.Address, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return nil, addrError(s, ErrMixedCase, "bech32 address mixes "+
+			"upper and lower case characters")
+	}
+	hrp := strings.ToLower(s[:sep])
+	if hrp != params.Bech32HRPSegwit {
+		return nil, addrError(s, ErrWrongNetwork, "this is a %s "+
+			"address, but the wallet is on %s",
+			bech32Network(hrp).Name, params.Name)
+	}
+	variant, err := taproot.Bech32Checksum(s)
+	if err != nil {
+		return nil, addrError(s, ErrMalformed, "bech32 address has "+
+			"invalid characters or is too short")
+	}
+	if variant == taproot.ChecksumInvalid {
+		return nil, addrError(s, ErrChecksum, "bech32 checksum does "+
+			"not match, the address may be mistyped")
+	}
+	addr, err := taproot.DecodeAddress(strings.ToLower(s), params)
+	if err != nil {
+		// Version 0 programs use the bech32 checksum, and later
+		// versions the bech32m checksum.
+		return nil, addrError(s, ErrMalformed, "invalid %s segwit "+
+			"address: %v", variantName(variant), err)
+	}
+	return addr, nil
+}
+
+func variantName(v taproot.ChecksumVariant) string {
+	if v == taproot.ChecksumBech32m {
+		return "bech32m"
+	}
+	return "bech32"
+}
+
+func decodeBase58(s string, params *chaincfg.Params) (btcutil.Address, error) {
+	_, version, err := base58.CheckDecode(s)
+	switch err {
+	case nil:
+	case base58.ErrChecksum:
+		return nil, addrError(s, ErrChecksum, "base58 checksum does "+
+			"not match, the address may be mistyped")
+	default:
+		return nil, addrError(s, ErrMalformed, "not a base58 or "+
+			"bech32 address")
+	}
+
+	switch {
+	case version == params.PubKeyHashAddrID,
+		version == params.ScriptHashAddrID:
+
+	case isPrivateKeyID(version, params):
+		return nil, addrError(s, ErrMalformed, "this is a private key "+
+			"in wallet import format, not an address")
+	case base58Network(version) != nil:
+		return nil, addrError(s, ErrWrongNetwork, "this is a %s "+
+			"address, but the wallet is on %s",
+			base58Network(version).Name, params.Name)
+	default:
+		return nil, addrError(s, ErrMalformed, "unknown base58 "+
+			"address version %d", version)
+	}
+
+	addr, err := btcutil.DecodeAddress(s, params)
+	if err != nil {
+		return nil, addrError(s, ErrMalformed, "%v", err)
+	}
+	return addr, nil
+}
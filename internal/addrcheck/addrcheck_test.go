@@ -0,0 +1,64 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package addrcheck
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+func TestDecode(t *testing.T) {
+	mainnet := &chaincfg.MainNetParams
+	testnet := &chaincfg.TestNet3Params
+	tests := []struct {
+		addr   string
+		params *chaincfg.Params
+		valid  bool
+		kind   ErrorKind
+	}{
+		{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", mainnet, true, 0},
+		{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", testnet, false, ErrWrongNetwork},
+		{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3", mainnet, false, ErrChecksum},
+		{"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", testnet, true, 0},
+		{"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", mainnet, false, ErrWrongNetwork},
+		{"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ", mainnet, false, ErrMalformed},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", mainnet, true, 0},
+		{"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", mainnet, true, 0},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kV8F3t4", mainnet, false, ErrMixedCase},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", mainnet, false, ErrChecksum},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", testnet, false, ErrWrongNetwork},
+		{"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", testnet, true, 0},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", mainnet, true, 0},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj2", mainnet, false, ErrChecksum},
+		{"", mainnet, false, ErrEmpty},
+		{" 1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", mainnet, false, ErrMalformed},
+		{"not an address", mainnet, false, ErrMalformed},
+	}
+	for _, test := range tests {
+		addr, err := Decode(test.addr, test.params)
+		if test.valid {
+			if err != nil {
+				t.Errorf("%q: %v", test.addr, err)
+			} else if !addr.IsForNet(test.params) {
+				t.Errorf("%q: decoded for the wrong network",
+					test.addr)
+			}
+			continue
+		}
+		if !IsError(err, test.kind) {
+			t.Errorf("%q: error is %v, want kind %d", test.addr, err,
+				test.kind)
+		}
+	}
+
+	// Wrong network errors name the network of the address.
+	_, err := Decode("mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", &chaincfg.MainNetParams)
+	want := `invalid address "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn": ` +
+		"this is a testnet3 address, but the wallet is on mainnet"
+	if err == nil || err.Error() != want {
+		t.Errorf("error is %v, want %s", err, want)
+	}
+}
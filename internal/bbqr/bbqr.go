@@ -0,0 +1,158 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package bbqr splits files such as PSBTs into the parts of an animated QR
+// code using the BBQr format, which is read by air-gapped signing devices.
+//
+// Every part starts with an 8 character header: the "B$" marker, the
+// encoding of the data, the file type, and the number of parts and the index
+// of the part as two base 36 digits each.  The remaining characters of the
+// parts are concatenated in index order to form the encoded file.
+package bbqr
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// FileType is the type of the file carried by the parts.
+type FileType byte
+
+// These constants define the file types used by the wallet.
+const (
+	// PSBT is a BIP0174 partially signed transaction.
+	PSBT FileType = 'P'
+
+	// Transaction is a network serialized transaction.
+	Transaction FileType = 'T'
+)
+
+// These constants define the encodings of the file data.
+const (
+	encodingHex    = 'H'
+	encodingBase32 = '2'
+	encodingZlib   = 'Z'
+)
+
+const (
+	headerLen = 8
+
+	// MaxParts is the largest number of parts, which is the largest two
+	// digit base 36 number.
+	MaxParts = 36*36 - 1
+)
+
+// base32Encoding is the character set of the base32 encodings, which is
+// within the alphanumeric mode of QR codes.
+var base32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Split encodes data in base32 and splits it into parts of at most partLen
+// characters, including the header.
+func Split(data []byte, fileType FileType, partLen int) ([]string, error) {
+	// Every base32 character encodes 5 bits, so parts carry a multiple of
+	// 8 characters to keep them aligned to whole bytes.
+	chunkLen := (partLen - headerLen) / 8 * 8
+	if chunkLen <= 0 {
+		return nil, fmt.Errorf("part length %d is too short", partLen)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("no data")
+	}
+	encoded := base32Encoding.EncodeToString(data)
+	total := (len(encoded) + chunkLen - 1) / chunkLen
+	if total > MaxParts {
+		return nil, fmt.Errorf("data requires %d parts, more than the "+
+			"maximum of %d", total, MaxParts)
+	}
+
+	parts := make([]string, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * chunkLen
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		header := fmt.Sprintf("B$%c%c%s%s", encodingBase32, fileType,
+			base36(total), base36(i))
+		parts = append(parts, header+encoded[i*chunkLen:end])
+	}
+	return parts, nil
+}
+
+// base36 returns the two digit base 36 encoding of n.
+func base36(n int) string {
+	s := strings.ToUpper(strconv.FormatInt(int64(n), 36))
+	if len(s) == 1 {
+		s = "0" + s
+	}
+	return s
+}
+
+// Join decodes the file carried by the parts, which may be passed in any
+// order and may include duplicates, and returns it with its file type.
+func Join(parts []string) ([]byte, FileType, error) {
+	if len(parts) == 0 {
+		return nil, 0, errors.New("no parts")
+	}
+
+	var encoding, fileType byte
+	var chunks []string
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if len(part) < headerLen || part[:2] != "B$" {
+			return nil, 0, errors.New("part is not in BBQr format")
+		}
+		total, err1 := strconv.ParseUint(part[4:6], 36, 16)
+		index, err2 := strconv.ParseUint(part[6:8], 36, 16)
+		if err1 != nil || err2 != nil || total == 0 || index >= total {
+			return nil, 0, fmt.Errorf("invalid part header %q",
+				part[:headerLen])
+		}
+		if chunks == nil {
+			encoding, fileType = part[2], part[3]
+			chunks = make([]string, total)
+		}
+		if part[2] != encoding || part[3] != fileType ||
+			int(total) != len(chunks) {
+
+			return nil, 0, errors.New("parts are from different files")
+		}
+		chunks[index] = part[headerLen:]
+	}
+	for i, chunk := range chunks {
+		if chunk == "" {
+			return nil, 0, fmt.Errorf("missing part %d of %d", i+1,
+				len(chunks))
+		}
+	}
+	encoded := strings.Join(chunks, "")
+
+	var data []byte
+	var err error
+	switch encoding {
+	case encodingHex:
+		data, err = hex.DecodeString(encoded)
+	case encodingBase32:
+		data, err = base32Encoding.DecodeString(encoded)
+	case encodingZlib:
+		data, err = base32Encoding.DecodeString(encoded)
+		if err == nil {
+			// The data is compressed with raw deflate.
+			data, err = ioutil.ReadAll(flate.NewReader(
+				bytes.NewReader(data)))
+		}
+	default:
+		return nil, 0, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid part data: %v", err)
+	}
+	return data, FileType(fileType), nil
+}
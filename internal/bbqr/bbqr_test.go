@@ -0,0 +1,72 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bbqr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitJoin(t *testing.T) {
+	data := bytes.Repeat([]byte{0x70, 0x73, 0x62, 0x74, 0xff}, 100)
+	parts, err := Split(data, PSBT, 100)
+	if err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+	// 500 bytes are 800 base32 characters, split in parts of 88.
+	if len(parts) != 10 {
+		t.Fatalf("got %d parts, want 10", len(parts))
+	}
+	if parts[0][:8] != "B$2P0A00" || parts[9][:8] != "B$2P0A09" {
+		t.Errorf("unexpected headers %s and %s", parts[0][:8],
+			parts[9][:8])
+	}
+	for _, part := range parts {
+		if len(part) > 100 {
+			t.Errorf("part of %d characters exceeds 100", len(part))
+		}
+	}
+
+	// Parts may be scanned in any order and more than once.
+	shuffled := append([]string{parts[3]}, parts...)
+	shuffled[1], shuffled[9] = shuffled[9], shuffled[1]
+	joined, fileType, err := Join(shuffled)
+	if err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	if fileType != PSBT || !bytes.Equal(joined, data) {
+		t.Errorf("joined data differs")
+	}
+
+	if _, _, err := Join(parts[1:]); err == nil {
+		t.Errorf("missing part was accepted")
+	}
+	if _, err := Split(data, PSBT, 15); err == nil {
+		t.Errorf("part length shorter than the header was accepted")
+	}
+}
+
+// TestJoinEncodings checks the encodings written by other implementations.
+func TestJoinEncodings(t *testing.T) {
+	tests := []struct {
+		parts []string
+		data  string
+	}{
+		{[]string{"B$HT010068656C6C6F"}, "hello"},
+		{[]string{"B$2T0100NBSWY3DP"}, "hello"},
+		{[]string{"B$ZT0100ZNEM3SOJK5EEUKRM2JI4RQGKAYAA"},
+			"hello bbqr, hello bbqr, hello bbqr"},
+	}
+
+	for _, test := range tests {
+		data, fileType, err := Join(test.parts)
+		if err != nil {
+			t.Fatalf("Join(%v): %v", test.parts, err)
+		}
+		if fileType != Transaction || string(data) != test.data {
+			t.Errorf("Join(%v) = %q, %c", test.parts, data, fileType)
+		}
+	}
+}
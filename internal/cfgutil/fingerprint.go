@@ -0,0 +1,166 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cfgutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maskedValue replaces the values of secret options which are set.
+const maskedValue = "(set)"
+
+// Setting is the value of an option of a configuration.  Options specified
+// multiple times have a setting per value, in the order they were given.
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// String returns the setting as name=value, with the value quoted.
+func (s Setting) String() string {
+	return s.Name + "=" + strconv.Quote(s.Value)
+}
+
+// marshaler is the flags.Marshaler interface.
+type marshaler interface {
+	MarshalFlag() (string, error)
+}
+
+// Settings returns the settings of the options of cfg, a pointer to a struct
+// of go-flags options, ordered by option name.  The values of secret options,
+// which are masked with default-mask:"-", are replaced by a marker when they
+// are set, and options named in exclude are left out.
+func Settings(cfg interface{}, exclude ...string) []Setting {
+	excluded := make(map[string]struct{}, len(exclude))
+	for _, name := range exclude {
+		excluded[name] = struct{}{}
+	}
+
+	var settings []Setting
+	v := reflect.Indirect(reflect.ValueOf(cfg))
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("long")
+		if name == "" {
+			continue
+		}
+		if _, ok := excluded[name]; ok {
+			continue
+		}
+		values := optionValues(v.Field(i))
+		if field.Tag.Get("default-mask") == "-" {
+			for j := range values {
+				if values[j] != "" {
+					values[j] = maskedValue
+				}
+			}
+		}
+		for _, value := range values {
+			settings = append(settings, Setting{name, value})
+		}
+	}
+	sort.SliceStable(settings, func(i, j int) bool {
+		return settings[i].Name < settings[j].Name
+	})
+	return settings
+}
+
+// optionValues returns the values of an option field in canonical form.
+func optionValues(v reflect.Value) []string {
+	if m, ok := v.Interface().(marshaler); ok {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return []string{""}
+		}
+		s, err := m.MarshalFlag()
+		if err != nil {
+			return []string{""}
+		}
+		return []string{s}
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		values := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, optionValues(v.Index(i))...)
+		}
+		return values
+	case reflect.Ptr:
+		if v.IsNil() {
+			return []string{""}
+		}
+		return optionValues(v.Elem())
+	}
+	if d, ok := v.Interface().(time.Duration); ok {
+		return []string{d.String()}
+	}
+	return []string{fmt.Sprint(v.Interface())}
+}
+
+// Fingerprint is the canonical serialization of a set of settings and its
+// SHA-256 hash.  Equal configurations have equal fingerprints whatever the
+// order in which their options were given.
+type Fingerprint struct {
+	Settings []Setting
+	Hash     [sha256.Size]byte
+}
+
+// NewFingerprint returns the fingerprint of settings, which must be ordered
+// by name.
+func NewFingerprint(settings []Setting) *Fingerprint {
+	f := &Fingerprint{Settings: settings}
+	f.Hash = sha256.Sum256(f.Serialize())
+	return f
+}
+
+// Serialize returns the canonical serialization of the settings, a line per
+// setting formatted as name="value".
+func (f *Fingerprint) Serialize() []byte {
+	var buf bytes.Buffer
+	for _, s := range f.Settings {
+		buf.WriteString(s.String())
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// String returns the hash of the fingerprint in hex.
+func (f *Fingerprint) String() string {
+	return hex.EncodeToString(f.Hash[:])
+}
+
+// Changed returns the names of the options whose settings differ between two
+// fingerprints, ordered by name.
+func Changed(old, new *Fingerprint) []string {
+	values := func(f *Fingerprint) map[string][]string {
+		m := make(map[string][]string)
+		for _, s := range f.Settings {
+			m[s.Name] = append(m[s.Name], s.Value)
+		}
+		return m
+	}
+	oldValues, newValues := values(old), values(new)
+
+	var changed []string
+	for name, v := range newValues {
+		if !reflect.DeepEqual(oldValues[name], v) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range oldValues {
+		if _, ok := newValues[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,278 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package diagnostics periodically records snapshots of internal metrics,
+// such as queue depths, request handler counts and store sizes, together with
+// the errors logged since the previous snapshot.  The last snapshots are kept
+// in a ring file which survives restarts, so they remain available for
+// post-mortem analysis when no metrics collector was attached.
+//
+// The ring file holds a JSON object per line, oldest first, and is replaced
+// atomically every time a snapshot is recorded.
+package diagnostics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// These constants define the default size of the recorded history.
+const (
+	// DefaultSnapshots is the default number of snapshots kept in the
+	// ring file.
+	DefaultSnapshots = 60
+
+	// DefaultErrors is the default number of errors kept per snapshot.
+	DefaultErrors = 20
+)
+
+// errorLevels are the log levels of the lines captured as errors.
+var errorLevels = [][]byte{[]byte(" [ERR] "), []byte(" [CRT] ")}
+
+// Snapshot is the state of the metrics at a point in time, and the errors
+// logged since the previous snapshot.
+type Snapshot struct {
+	Time    int64            `json:"time"`
+	Metrics map[string]int64 `json:"metrics"`
+	Errors  []string         `json:"errors,omitempty"`
+}
+
+// Collector adds the current value of one or more metrics to a snapshot.
+type Collector func(metrics map[string]int64)
+
+// Recorder records snapshots of metrics to a ring file.  Recorders are
+// io.Writers so they can capture the errors written to the log.
+type Recorder struct {
+	mu         sync.Mutex
+	path       string
+	capacity   int
+	maxErrors  int
+	collectors []Collector
+	errors     []string
+	snapshots  []*Snapshot
+
+	// fileMu serializes the writes of the ring file, so logging is not
+	// blocked on them.
+	fileMu sync.Mutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewRecorder returns a recorder keeping up to maxErrors of the errors logged
+// between snapshots.  Snapshots are only kept in memory until a ring file is
+// opened.
+func NewRecorder(maxErrors int) *Recorder {
+	return &Recorder{
+		capacity:  DefaultSnapshots,
+		maxErrors: maxErrors,
+	}
+}
+
+// Register adds a collector of metrics, called for every snapshot.
+func (r *Recorder) Register(c Collector) {
+	r.mu.Lock()
+	r.collectors = append(r.collectors, c)
+	r.mu.Unlock()
+}
+
+// Write captures the error and critical lines of log output.  It never
+// fails.
+func (r *Recorder) Write(p []byte) (int, error) {
+	if r.maxErrors <= 0 || !isError(p) {
+		return len(p), nil
+	}
+	line := string(bytes.TrimRight(p, "\n"))
+	r.mu.Lock()
+	if len(r.errors) == r.maxErrors {
+		copy(r.errors, r.errors[1:])
+		r.errors = r.errors[:len(r.errors)-1]
+	}
+	r.errors = append(r.errors, line)
+	r.mu.Unlock()
+	return len(p), nil
+}
+
+// isError returns whether a line of log output is logged at an error level.
+func isError(line []byte) bool {
+	for _, level := range errorLevels {
+		if bytes.Contains(line, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Open loads the snapshots of the ring file at path, which is created by the
+// next snapshot if it does not exist, and keeps up to capacity snapshots in
+// it from then on.
+func (r *Recorder) Open(path string, capacity int) error {
+	var snapshots []*Snapshot
+	f, err := os.Open(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	default:
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			var s Snapshot
+			if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+				return err
+			}
+			snapshots = append(snapshots, &s)
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	r.path = path
+	r.capacity = capacity
+	r.snapshots = append(snapshots, r.snapshots...)
+	r.trim()
+	r.mu.Unlock()
+	return nil
+}
+
+// trim drops the oldest snapshots over capacity.  It must be called with the
+// mutex held.
+func (r *Recorder) trim() {
+	if n := len(r.snapshots) - r.capacity; n > 0 {
+		r.snapshots = append([]*Snapshot(nil), r.snapshots[n:]...)
+	}
+}
+
+// Current returns a snapshot of the metrics and the errors logged since the
+// last recorded snapshot, without recording it.
+func (r *Recorder) Current() *Snapshot {
+	s := r.collect()
+	r.mu.Lock()
+	s.Errors = append([]string(nil), r.errors...)
+	r.mu.Unlock()
+	return s
+}
+
+// collect returns a snapshot of the metrics.
+func (r *Recorder) collect() *Snapshot {
+	r.mu.Lock()
+	collectors := r.collectors
+	r.mu.Unlock()
+
+	// Collectors may take locks of their own, and log, so they are called
+	// without the mutex held.
+	s := &Snapshot{
+		Time:    time.Now().Unix(),
+		Metrics: make(map[string]int64),
+	}
+	for _, c := range collectors {
+		c(s.Metrics)
+	}
+	return s
+}
+
+// Record records a snapshot and writes the ring file, when one is open.
+func (r *Recorder) Record() error {
+	s := r.collect()
+
+	r.mu.Lock()
+	s.Errors, r.errors = r.errors, nil
+	r.snapshots = append(r.snapshots, s)
+	r.trim()
+	path := r.path
+	var buf bytes.Buffer
+	for _, s := range r.snapshots {
+		b, err := json.Marshal(s)
+		if err != nil {
+			r.mu.Unlock()
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	r.mu.Unlock()
+
+	if path == "" {
+		return nil
+	}
+	r.fileMu.Lock()
+	defer r.fileMu.Unlock()
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// Snapshots returns the recorded snapshots, oldest first, including those
+// loaded from the ring file.
+func (r *Recorder) Snapshots() []*Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Snapshot(nil), r.snapshots...)
+}
+
+// Start records a snapshot every interval until Stop is called.  Errors
+// recording snapshots are passed to the errorf function.
+func (r *Recorder) Start(interval time.Duration, errorf func(format string,
+	params ...interface{})) {
+
+	r.quit = make(chan struct{})
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.Record(); err != nil {
+					errorf("Cannot record diagnostics "+
+						"snapshot: %v", err)
+				}
+			case <-r.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops recording snapshots and records a last one, so the state at
+// shutdown is kept.
+func (r *Recorder) Stop() error {
+	if r.quit != nil {
+		close(r.quit)
+		r.wg.Wait()
+		r.quit = nil
+	}
+	return r.Record()
+}
+
+// writeFileAtomic replaces the file at path with the passed data, by writing
+// and renaming a temporary file.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
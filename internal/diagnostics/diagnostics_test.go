@@ -0,0 +1,81 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package diagnostics
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diagnostics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "diagnostics.log")
+
+	r := NewRecorder(2)
+	if err := r.Open(path, 3); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	var n int64
+	r.Register(func(metrics map[string]int64) {
+		n++
+		metrics["n"] = n
+	})
+	r.Write([]byte("2018-01-01 00:00:00.000 [INF] BTCW: started\n"))
+	r.Write([]byte("2018-01-01 00:00:01.000 [ERR] WLLT: first\n"))
+	r.Write([]byte("2018-01-01 00:00:02.000 [ERR] WLLT: second\n"))
+	r.Write([]byte("2018-01-01 00:00:03.000 [CRT] CHNS: third\n"))
+	for i := 0; i < 4; i++ {
+		if err := r.Record(); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	// A new recorder loads the last snapshots of the ring file.  The
+	// errors were recorded by the first snapshot, which was dropped.
+	r = NewRecorder(2)
+	if err := r.Open(path, 3); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	snapshots := r.Snapshots()
+	if len(snapshots) != 3 {
+		t.Fatalf("got %d snapshots, want 3", len(snapshots))
+	}
+	for i, s := range snapshots {
+		if s.Metrics["n"] != int64(i+2) {
+			t.Errorf("snapshot %d: got metric %d, want %d", i,
+				s.Metrics["n"], i+2)
+		}
+		if len(s.Errors) != 0 {
+			t.Errorf("snapshot %d: unexpected errors %q", i, s.Errors)
+		}
+	}
+
+	// Only the last errors logged between snapshots are kept.
+	r.Write([]byte("2018-01-01 00:00:01.000 [ERR] WLLT: first\n"))
+	r.Write([]byte("2018-01-01 00:00:02.000 [ERR] WLLT: second\n"))
+	r.Write([]byte("2018-01-01 00:00:03.000 [CRT] CHNS: third\n"))
+	if errs := r.Current().Errors; len(errs) != 2 ||
+		errs[1] != "2018-01-01 00:00:03.000 [CRT] CHNS: third" {
+		t.Fatalf("unexpected current errors %q", errs)
+	}
+	if err := r.Record(); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	snapshots = r.Snapshots()
+	last := snapshots[len(snapshots)-1]
+	if len(last.Errors) != 2 || last.Errors[0] !=
+		"2018-01-01 00:00:02.000 [ERR] WLLT: second" {
+		t.Fatalf("unexpected recorded errors %q", last.Errors)
+	}
+	if errs := r.Current().Errors; len(errs) != 0 {
+		t.Fatalf("errors %q not cleared by Record", errs)
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcwallet/internal/legacy/keystore"
+	"github.com/btcsuite/btcwallet/internal/slip39"
 	"github.com/btcsuite/golangcrypto/ssh/terminal"
 )
 
@@ -257,10 +258,13 @@ func PublicPass(reader *bufio.Reader, privPass []byte,
 
 // Seed prompts the user whether they want to use an existing wallet generation
 // seed.  When the user answers no, a seed will be generated and displayed to
-// the user along with prompting them for confirmation.  When the user answers
-// yes, a the user is prompted for it.  All prompts are repeated until the user
-// enters a valid response.
-func Seed(reader *bufio.Reader) ([]byte, error) {
+// the user along with prompting them for confirmation.  A count of shares
+// displays the generated seed as that many SLIP-0039 mnemonic shares, any
+// threshold of which restore it, instead of in hexadecimal.  When the user
+// answers yes, a the user is prompted for it, either in hexadecimal or as
+// SLIP-0039 shares.  All prompts are repeated until the user enters a valid
+// response.
+func Seed(reader *bufio.Reader, threshold, count int) ([]byte, error) {
 	useUserSeed := false
 	if reader != nil {
 		// Ascertain the wallet generation seed.
@@ -277,10 +281,26 @@ func Seed(reader *bufio.Reader) ([]byte, error) {
 			return nil, err
 		}
 
-		fmt.Println("Your wallet generation seed is:")
-		fmt.Printf("%x\n", seed)
-		fmt.Println("IMPORTANT: Keep the seed in a safe place as you\n" +
-			"will NOT be able to restore your wallet without it.")
+		if count == 0 {
+			fmt.Println("Your wallet generation seed is:")
+			fmt.Printf("%x\n", seed)
+			fmt.Println("IMPORTANT: Keep the seed in a safe place as you\n" +
+				"will NOT be able to restore your wallet without it.")
+		} else {
+			shares, err := slip39.Split(seed, nil, threshold, count)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Your wallet generation seed is split into %d "+
+				"SLIP-0039 shares,\nany %d of which restore it:\n",
+				count, threshold)
+			for i, share := range shares {
+				fmt.Printf("Share %d: %s\n", i+1, share.Mnemonic())
+			}
+			fmt.Println("IMPORTANT: Keep the shares in separate safe " +
+				"places as you\nwill NOT be able to restore your " +
+				"wallet without them.")
+		}
 		fmt.Println("Please keep in mind that anyone who has access\n" +
 			"to the seed can also restore your wallet thereby\n" +
 			"giving them access to all your funds, so it is\n" +
@@ -306,13 +326,23 @@ func Seed(reader *bufio.Reader) ([]byte, error) {
 	}
 
 	for {
-		fmt.Print("Enter existing wallet seed: ")
+		fmt.Print("Enter existing wallet seed or SLIP-0039 share: ")
 		seedStr, err := reader.ReadString('\n')
 		if err != nil {
 			return nil, err
 		}
 		seedStr = strings.TrimSpace(strings.ToLower(seedStr))
 
+		// Mnemonic shares are words separated by spaces.
+		if strings.Contains(seedStr, " ") {
+			seed, err := seedShares(reader, seedStr)
+			if err != nil {
+				fmt.Printf("Invalid shares specified: %v\n", err)
+				continue
+			}
+			return seed, nil
+		}
+
 		seed, err := hex.DecodeString(seedStr)
 		if err != nil || len(seed) < hdkeychain.MinSeedBytes ||
 			len(seed) > hdkeychain.MaxSeedBytes {
@@ -327,3 +357,32 @@ func Seed(reader *bufio.Reader) ([]byte, error) {
 		return seed, nil
 	}
 }
+
+// seedShares prompts the user for further SLIP-0039 shares after the first
+// until the shares restore the seed.  The shares are expected to have no
+// passphrase.
+func seedShares(reader *bufio.Reader, mnemonic string) ([]byte, error) {
+	share, err := slip39.ParseShare(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	shares := []*slip39.Share{share}
+	for {
+		seed, err := slip39.Combine(shares, nil)
+		if err != slip39.ErrTooFewShares {
+			return seed, err
+		}
+
+		fmt.Printf("Enter share %d: ", len(shares)+1)
+		mnemonic, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		share, err := slip39.ParseShare(mnemonic)
+		if err != nil {
+			fmt.Printf("Invalid share specified: %v\n", err)
+			continue
+		}
+		shares = append(shares, share)
+	}
+}
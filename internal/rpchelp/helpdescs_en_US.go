@@ -14,6 +14,12 @@ var helpDescsEnUS = map[string]string{
 	"addmultisigaddress-nrequired": "The number of signatures required to redeem outputs paid to this address",
 	"addmultisigaddress--result0":  "The imported pay-to-script-hash address",
 
+	// BackupWalletCmd help.
+	"backupwallet--synopsis": "Writes a backup of the wallet database to a file, replacing any existing file.\n" +
+		"The backup is encrypted with the --backuppass passphrase or to the --backupgpgrecipient keys when they are set, so that it can be kept by operators who do not know the private passphrase.\n" +
+		"Backups encrypted with the backup passphrase are decrypted with the decryptbackup utility, and backups encrypted to GPG recipients with gpg --decrypt.",
+	"backupwallet-destination": "Path of the backup file",
+
 	// CreateMultisigCmd help.
 	"createmultisig--synopsis": "Generate a multisig address and redeem script.",
 	"createmultisig-keys":      "Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address",
@@ -28,6 +34,14 @@ var helpDescsEnUS = map[string]string{
 	"dumpprivkey-address":   "The address to return a private key for",
 	"dumpprivkey--result0":  "The WIF-encoded private key",
 
+	// DumpWalletCmd help.
+	"dumpwallet--synopsis": "Writes the private keys of all wallet addresses to a new file in the wallet dump format of bitcoind, for import by importwallet or other software.\n" +
+		"Each key is written with its address, account name and BIP0032 derivation path from the master key, whose fingerprint is written in the header of the file.",
+	"dumpwallet-filename": "Path of the file to create, which must not already exist",
+
+	// DumpWalletResult help.
+	"dumpwalletresult-filename": "The absolute path of the written file",
+
 	// GetAccountCmd help.
 	"getaccount--synopsis": "DEPRECATED -- Lookup the account name that some wallet address belongs to.",
 	"getaccount-address":   "The address to query the account for",
@@ -40,7 +54,7 @@ var helpDescsEnUS = map[string]string{
 	"getaccountaddress--result0": "The unused address for 'account'",
 
 	// GetAddressesByAccountCmd help.
-	"getaddressesbyaccount--synopsis": "DEPRECATED -- Returns all addresses strings controlled by a single account.",
+	"getaddressesbyaccount--synopsis": "DEPRECATED -- Returns all payment address strings controlled by a single account.  Change addresses are not returned.",
 	"getaddressesbyaccount-account":   "Account name to fetch addresses for",
 	"getaddressesbyaccount--result0":  "All addresses controlled by 'account'",
 
@@ -48,6 +62,7 @@ var helpDescsEnUS = map[string]string{
 	"getbalance--synopsis":   "Calculates and returns the balance of one or all accounts.",
 	"getbalance-minconf":     "Minimum number of block confirmations required before an unspent output's value is included in the balance",
 	"getbalance-account":     "DEPRECATED -- The account name to query the balance for, or \"*\" to consider all accounts (default=\"*\")",
+	"getbalance-token":       "Token of the balance (default=\"STB\")",
 	"getbalance--condition0": "account != \"*\"",
 	"getbalance--condition1": "account = \"*\"",
 	"getbalance--result0":    "The balance of 'account' valued in bitcoin",
@@ -93,7 +108,7 @@ var helpDescsEnUS = map[string]string{
 	"getrawchangeaddress--result0":  "The internal payment address",
 
 	// GetReceivedByAccountCmd help.
-	"getreceivedbyaccount--synopsis": "DEPRECATED -- Returns the total amount received by addresses of some account, including spent outputs.",
+	"getreceivedbyaccount--synopsis": "DEPRECATED -- Returns the total amount received by addresses of some account, including spent outputs and excluding change.",
 	"getreceivedbyaccount-account":   "Account name to query total received amount for",
 	"getreceivedbyaccount-minconf":   "Minimum number of block confirmations required before an output's value is included in the total",
 	"getreceivedbyaccount--result0":  "The total received amount valued in bitcoin",
@@ -118,18 +133,22 @@ var helpDescsEnUS = map[string]string{
 	"help--result1":    "Help for specified command",
 
 	// GetTransactionResult help.
-	"gettransactionresult-amount":          "The total amount this transaction credits to the wallet, valued in bitcoin",
-	"gettransactionresult-fee":             "The total input value minus the total output value, or 0 if 'txid' is not a sent transaction",
-	"gettransactionresult-confirmations":   "The number of block confirmations of the transaction",
-	"gettransactionresult-blockhash":       "The hash of the block this transaction is mined in, or the empty string if unmined",
-	"gettransactionresult-blockindex":      "Unset",
-	"gettransactionresult-blocktime":       "The Unix time of the block header this transaction is mined in, or 0 if unmined",
-	"gettransactionresult-txid":            "The transaction hash",
-	"gettransactionresult-walletconflicts": "Unset",
-	"gettransactionresult-time":            "The earliest Unix time this transaction was known to exist",
-	"gettransactionresult-timereceived":    "The earliest Unix time this transaction was known to exist",
-	"gettransactionresult-details":         "Additional details for each recorded wallet credit and debit",
-	"gettransactionresult-hex":             "The transaction encoded as a hexadecimal string",
+	"gettransactionresult-amount":             "The total amount this transaction credits to the wallet, valued in bitcoin",
+	"gettransactionresult-fee":                "The total input value minus the total output value, or 0 if 'txid' is not a sent transaction",
+	"gettransactionresult-confirmations":      "The number of block confirmations of the transaction",
+	"gettransactionresult-blockhash":          "The hash of the block this transaction is mined in, or the empty string if unmined",
+	"gettransactionresult-blockindex":         "Unset",
+	"gettransactionresult-blocktime":          "The Unix time of the block header this transaction is mined in, or 0 if unmined",
+	"gettransactionresult-txid":               "The transaction hash",
+	"gettransactionresult-walletconflicts":    "Unset",
+	"gettransactionresult-time":               "The earliest Unix time this transaction was known to exist",
+	"gettransactionresult-timereceived":       "The earliest Unix time this transaction was known to exist",
+	"gettransactionresult-bip125-replaceable": "Whether the transaction can be replaced by BIP0125 replacement: \"yes\" while it is unmined and signals replaceability, or \"no\"",
+	"gettransactionresult-replaces_txid":      "The hash of the transaction this transaction replaced with bumpfee (omitted if it is not a replacement)",
+	"gettransactionresult-replaced_by_txid":   "The hash of the transaction which replaced this transaction with bumpfee (omitted if it was not replaced)",
+	"gettransactionresult-signerattestation":  "The attestation of the external signing device of the transaction (omitted unless signed by a device identifying itself)",
+	"gettransactionresult-details":            "Additional details for each recorded wallet credit and debit",
+	"gettransactionresult-hex":                "The transaction encoded as a hexadecimal string",
 
 	// GetTransactionDetailsResult help.
 	"gettransactiondetailsresult-account":           "DEPRECATED -- Unset",
@@ -140,12 +159,24 @@ var helpDescsEnUS = map[string]string{
 	"gettransactiondetailsresult-vout":              "The transaction output index",
 	"gettransactiondetailsresult-involveswatchonly": "Unset",
 
+	// ImportAddressCmd help.
+	"importaddress--synopsis": "Imports an address without its private key to an account.\n" +
+		"Outputs paying to the address are included in the balances and transactions of the account as watch-only, and are never spent by the wallet.",
+	"importaddress-address": "The address to watch",
+	"importaddress-account": "The name of the account to import the address to (default=\"default\")",
+	"importaddress-rescan":  "Rescan the blockchain (since the genesis block) for outputs paying to the address",
+
 	// ImportPrivKeyCmd help.
 	"importprivkey--synopsis": "Imports a WIF-encoded private key to the 'imported' account.",
 	"importprivkey-privkey":   "The WIF-encoded private key",
 	"importprivkey-label":     "Unused (must be unset or 'imported')",
 	"importprivkey-rescan":    "Rescan the blockchain (since the genesis block) for outputs controlled by the imported key",
 
+	// ImportWalletCmd help.
+	"importwallet--synopsis": "Imports the private keys of a wallet dump file written by dumpwallet or bitcoind to the 'imported' account, and rescans the blockchain from the block of the earliest key time.\n" +
+		"Keys already in the wallet are skipped, and scripts of the dump are ignored.",
+	"importwallet-filename": "Path of the wallet dump file",
+
 	// KeypoolRefillCmd help.
 	"keypoolrefill--synopsis": "DEPRECATED -- This request does nothing since no keypool is maintained.",
 	"keypoolrefill-newsize":   "Unused",
@@ -158,14 +189,14 @@ var helpDescsEnUS = map[string]string{
 	"listaccounts--result0--value": "The account balance valued in bitcoin",
 
 	// ListLockUnspentCmd help.
-	"listlockunspent--synopsis": "Returns a JSON array of outpoints marked as locked (with lockunspent) for this wallet session.",
+	"listlockunspent--synopsis": "Returns a JSON array of outpoints marked as locked (with lockunspent) for this wallet.",
 
 	// TransactionInput help.
 	"transactioninput-txid": "The transaction hash of the referenced output",
 	"transactioninput-vout": "The output index of the referenced output",
 
 	// ListReceivedByAccountCmd help.
-	"listreceivedbyaccount--synopsis":        "DEPRECATED -- Returns a JSON array of objects listing all accounts and the total amount received by each account.",
+	"listreceivedbyaccount--synopsis":        "DEPRECATED -- Returns a JSON array of objects listing all accounts and the total amount received by each account, excluding change.",
 	"listreceivedbyaccount-minconf":          "Minimum number of block confirmations required before a transaction is considered",
 	"listreceivedbyaccount-includeempty":     "Unused",
 	"listreceivedbyaccount-includewatchonly": "Unused",
@@ -176,7 +207,7 @@ var helpDescsEnUS = map[string]string{
 	"listreceivedbyaccountresult-confirmations": "Number of block confirmations of the most recent transaction relevant to the account",
 
 	// ListReceivedByAddressCmd help.
-	"listreceivedbyaddress--synopsis":        "Returns a JSON array of objects listing wallet payment addresses and their total received amounts.",
+	"listreceivedbyaddress--synopsis":        "Returns a JSON array of objects listing wallet payment addresses and their total received amounts.  Change addresses and the change received by them are not listed.",
 	"listreceivedbyaddress-minconf":          "Minimum number of block confirmations required before a transaction is considered",
 	"listreceivedbyaddress-includeempty":     "Unused",
 	"listreceivedbyaddress-includewatchonly": "Unused",
@@ -219,7 +250,7 @@ var helpDescsEnUS = map[string]string{
 	"listtransactionsresult-walletconflicts":    "Unset",
 	"listtransactionsresult-time":               "The earliest Unix time this transaction was known to exist",
 	"listtransactionsresult-timereceived":       "The earliest Unix time this transaction was known to exist",
-	"listtransactionsresult-involveswatchonly":  "Unset",
+	"listtransactionsresult-involveswatchonly":  "Whether the output pays to a watch-only address",
 	"listtransactionsresult-comment":            "Unset",
 	"listtransactionsresult-otheraccount":       "Unset",
 	"listtransactionsresult-trusted":            "Unset",
@@ -238,6 +269,7 @@ var helpDescsEnUS = map[string]string{
 	"listunspent-minconf":   "Minimum number of block confirmations required before a transaction output is considered",
 	"listunspent-maxconf":   "Maximum number of block confirmations required before a transaction output is excluded",
 	"listunspent-addresses": "If set, limits the returned details to unspent outputs received by any of these payment addresses",
+	"listunspent-token":     "If set, limits the returned details to unspent outputs of this token",
 
 	// ListUnspentResult help.
 	"listunspentresult-txid":          "The transaction hash of the referenced output",
@@ -247,13 +279,14 @@ var helpDescsEnUS = map[string]string{
 	"listunspentresult-scriptPubKey":  "The output script encoded as a hexadecimal string",
 	"listunspentresult-redeemScript":  "Unset",
 	"listunspentresult-amount":        "The amount of the output valued in bitcoin",
+	"listunspentresult-token":         "The token of the output",
 	"listunspentresult-confirmations": "The number of block confirmations of the transaction",
 	"listunspentresult-spendable":     "Whether the output is entirely controlled by wallet keys/scripts (false for partially controlled multisig outputs or outputs to watch-only addresses)",
 
 	// LockUnspentCmd help.
 	"lockunspent--synopsis": "Locks or unlocks an unspent output.\n" +
 		"Locked outputs are not chosen for transaction inputs of authored transactions and are not included in 'listunspent' results.\n" +
-		"Locked outputs are volatile and are not saved across wallet restarts.\n" +
+		"Locked outputs are saved across wallet restarts and are not included in spendable balances.\n" +
 		"If unlock is true and no transaction outputs are specified, all locked outputs are marked unlocked.",
 	"lockunspent-unlock":       "True to unlock outputs, false to lock",
 	"lockunspent-transactions": "Transaction outputs to lock or unlock",
@@ -261,18 +294,26 @@ var helpDescsEnUS = map[string]string{
 
 	// SendFromCmd help.
 	"sendfrom--synopsis": "DEPRECATED -- Authors, signs, and sends a transaction that outputs some amount to a payment address.\n" +
-		"A change output is automatically included to send extra output value back to the original account.",
+		"A change output is automatically included to send extra output value back to the original account.\n" +
+		"The fee rate is set with settxfee, or estimated by the chain server for the --conftarget confirmation target.\n" +
+		"An optional eighth parameter, after the token, is a JSON object of travel rule metadata attached to the sent transaction, with the \"originator\" and \"beneficiary\" objects and optional \"originatingvasp\" and \"beneficiaryvasp\" names of settravelrule.",
 	"sendfrom-fromaccount": "Account to pick unspent outputs from",
 	"sendfrom-toaddress":   "Address to pay",
 	"sendfrom-amount":      "Amount to send to the payment address valued in bitcoin",
 	"sendfrom-minconf":     "Minimum number of block confirmations required before a transaction output is eligible to be spent",
 	"sendfrom-comment":     "Unused",
 	"sendfrom-commentto":   "Unused",
+	"sendfrom-token":       "Token to send (default=\"STB\")",
 	"sendfrom--result0":    "The transaction hash of the sent transaction",
 
 	// SendManyCmd help.
 	"sendmany--synopsis": "Authors, signs, and sends a transaction that outputs to many payment addresses.\n" +
-		"A change output is automatically included to send extra output value back to the original account.",
+		"A change output is automatically included to send extra output value back to the original account.\n" +
+		"The fee rate is set with settxfee, or estimated by the chain server for the --conftarget confirmation target.\n" +
+		"An optional sixth parameter, after the token, lists recipient addresses paying the fee instead of the account; the fee is deducted from their amounts in proportion to them.\n" +
+		"An optional seventh parameter is a hex-encoded data payload of at most 80 bytes, embedded in a zero-value OP_RETURN output of the transaction.\n" +
+		"An optional eighth parameter is a P2WPKH address receiving the change instead of the account, and an optional ninth parameter an account whose internal address receives it; both may not be set.\n" +
+		"An optional tenth parameter creates the transaction even when its fee exceeds the fee cap of the wallet, and an optional eleventh parameter is a JSON object of travel rule metadata attached to the sent transaction, with the \"originator\" and \"beneficiary\" objects and optional \"originatingvasp\" and \"beneficiaryvasp\" names of settravelrule.",
 	"sendmany-fromaccount":    "DEPRECATED -- Account to pick unspent outputs from",
 	"sendmany-amounts":        "Pairs of payment addresses and the output amount to pay each",
 	"sendmany-amounts--desc":  "JSON object using payment addresses as keys and output amounts valued in bitcoin to send to each address",
@@ -280,16 +321,21 @@ var helpDescsEnUS = map[string]string{
 	"sendmany-amounts--value": "Amount to send to the payment address valued in bitcoin",
 	"sendmany-minconf":        "Minimum number of block confirmations required before a transaction output is eligible to be spent",
 	"sendmany-comment":        "Unused",
+	"sendmany-token":          "Token to send (default=\"STB\")",
 	"sendmany--result0":       "The transaction hash of the sent transaction",
 
 	// SendToAddressCmd help.
 	"sendtoaddress--synopsis": "Authors, signs, and sends a transaction that outputs some amount to a payment address.\n" +
 		"Unlike sendfrom, outputs are always chosen from the default account.\n" +
-		"A change output is automatically included to send extra output value back to the original account.",
+		"A change output is automatically included to send extra output value back to the original account.\n" +
+		"The fee rate is set with settxfee, or estimated by the chain server for the --conftarget confirmation target.\n" +
+		"An optional sixth parameter, after the token, is a hex-encoded data payload of at most 80 bytes, embedded in a zero-value OP_RETURN output of the transaction.\n" +
+		"An optional seventh parameter creates the transaction even when its fee exceeds the fee cap of the wallet, and an optional eighth parameter is a JSON object of travel rule metadata attached to the sent transaction, with the \"originator\" and \"beneficiary\" objects and optional \"originatingvasp\" and \"beneficiaryvasp\" names of settravelrule.",
 	"sendtoaddress-address":   "Address to pay",
 	"sendtoaddress-amount":    "Amount to send to the payment address valued in bitcoin",
 	"sendtoaddress-comment":   "Unused",
 	"sendtoaddress-commentto": "Unused",
+	"sendtoaddress-token":     "Token to send (default=\"STB\")",
 	"sendtoaddress--result0":  "The transaction hash of the sent transaction",
 
 	// BidCmd help.
@@ -298,6 +344,7 @@ var helpDescsEnUS = map[string]string{
 		"Return and change output are automatically included to send output value back to the original account.",
 	"bid-amount":   "Amount to buy valued in NDR",
 	"bid-price":    "Buying price valued in NDR/STB",
+	"bid-minconf":  "Minimum number of block confirmations required before a transaction output is eligible to be spent",
 	"bid--result0": "The hash of the sent order",
 
 	// AskCmd help.
@@ -306,18 +353,21 @@ var helpDescsEnUS = map[string]string{
 		"Return and change output are automatically included to send output value back to the original account.",
 	"ask-amount":   "Amount to buy valued in NDR",
 	"ask-price":    "Selling price valued in NDR/STB",
+	"ask-minconf":  "Minimum number of block confirmations required before a transaction output is eligible to be spent",
 	"ask--result0": "The hash of the sent order",
 
 	// SetTxFeeCmd help.
-	"settxfee--synopsis": "Modify the increment used each time more fee is required for an authored transaction.",
-	"settxfee-amount":    "The new fee increment valued in bitcoin",
-	"settxfee--result0":  "The boolean 'true'",
+	"settxfee--synopsis": "Sets the fee rate per kilobyte of sent transactions, overriding the fee rate estimated by the chain server for the --conftarget confirmation target.\n" +
+		"A zero fee rate removes the override, and the relay fee rate is used when the chain server has no estimate.",
+	"settxfee-amount":   "The new fee rate per kilobyte valued in bitcoin, or 0 to estimate it",
+	"settxfee--result0": "The boolean 'true'",
 
 	// SignMessageCmd help.
-	"signmessage--synopsis": "Signs a message using the private key of a payment address.",
-	"signmessage-address":   "Payment address of private key used to sign the message with",
-	"signmessage-message":   "Message to sign",
-	"signmessage--result0":  "The signed message encoded as a base64 string",
+	"signmessage--synopsis": "Signs a message using the private key of a payment address.\n" +
+		"The signature records whether the public key of the address is compressed, so that it can be verified against P2PKH addresses of uncompressed keys.",
+	"signmessage-address":  "Payment address of private key used to sign the message with",
+	"signmessage-message":  "Message to sign",
+	"signmessage--result0": "The signed message encoded as a base64 string",
 
 	// SignRawTransactionCmd help.
 	"signrawtransaction--synopsis": "Signs transaction inputs using private keys from this wallet and request.\n" +
@@ -363,7 +413,9 @@ var helpDescsEnUS = map[string]string{
 	"validateaddresswalletresult-sigsrequired": "The number of required signatures to redeem outputs to the multisig address",
 
 	// VerifyMessageCmd help.
-	"verifymessage--synopsis": "Verify a message was signed with the associated private key of some address.",
+	"verifymessage--synopsis": "Verify a message was signed with the associated private key of some address.\n" +
+		"P2PKH, P2PK, P2WPKH and nested P2WPKH addresses are supported; the last two only commit to compressed public keys.\n" +
+		"BIP0322 simple signatures created by signmessagebip322 are also accepted for P2WPKH and P2TR addresses.",
 	"verifymessage-address":   "Address used to sign message",
 	"verifymessage-signature": "The signature to verify",
 	"verifymessage-message":   "The message to verify",
@@ -373,12 +425,16 @@ var helpDescsEnUS = map[string]string{
 	"walletlock--synopsis": "Lock the wallet.",
 
 	// WalletPassphraseCmd help.
-	"walletpassphrase--synopsis":  "Unlock the wallet.",
+	"walletpassphrase--synopsis": "Unlock the wallet.\n" +
+		"An optional third parameter names an account protected by its own passphrase, set with setaccountpassphrase, to unlock with its passphrase instead of the wallet.\n" +
+		"After a wrong passphrase, passphrases are not tried again for a delay doubling with every consecutive wrong passphrase, and requests fail with error code -41 until it expires; the wallet may also be configured to disable unlocking after too many wrong passphrases.\n" +
+		"Websocket clients subscribed with notifyunlockfailures are sent an unlockfailed notification for every wrong passphrase.",
 	"walletpassphrase-passphrase": "The wallet passphrase",
 	"walletpassphrase-timeout":    "The number of seconds to wait before the wallet automatically locks",
 
 	// WalletPassphraseChangeCmd help.
-	"walletpassphrasechange--synopsis":     "Change the wallet passphrase.",
+	"walletpassphrasechange--synopsis": "Change the wallet passphrase, re-encrypting the wallet keys under the new passphrase in a single database transaction.\n" +
+		"The wallet keeps its lock state and unlock timeout, and websocket clients subscribed with notifylockstate are sent a walletlockstate notification, since the old passphrase no longer unlocks the wallet.",
 	"walletpassphrasechange-oldpassphrase": "The old wallet passphrase",
 	"walletpassphrasechange-newpassphrase": "The new wallet passphrase",
 
@@ -422,4 +478,998 @@ var helpDescsEnUS = map[string]string{
 	// WalletIsLockedCmd help.
 	"walletislocked--synopsis": "Returns whether or not the wallet is locked.",
 	"walletislocked--result0":  "Whether the wallet is locked",
+
+	// GetNewTaprootAddressCmd help.
+	"getnewtaprootaddress--synopsis": "Generates and returns a new BIP0086 pay-to-taproot address encoded as bech32m.\n" +
+		"The wallet must be unlocked the first time this is called on a wallet created without the BIP0086 key scope.",
+	"getnewtaprootaddress-account":  "Account name of the BIP0086 key scope the new address will belong to (default=\"default\")",
+	"getnewtaprootaddress--result0": "The payment address",
+
+	// ImportWitnessScriptCmd help.
+	"importwitnessscript--synopsis": "Adds a P2WSH witness script to the wallet so that outputs paying to its P2WSH and P2SH-P2WSH addresses are credited to the imported account and can be spent.\n" +
+		"Multisig, pay-to-pubkey and pay-to-pubkey-hash witness scripts can be spent when the wallet controls enough of their keys.",
+	"importwitnessscript-script": "Hex-encoded witness script",
+
+	// ImportWitnessScriptResult help.
+	"importwitnessscriptresult-address":     "The P2WSH address of the script",
+	"importwitnessscriptresult-p2shaddress": "The P2SH-P2WSH address of the script",
+
+	// SetTravelRuleCmd help.
+	"settravelrule--synopsis": "Attaches travel rule originator and beneficiary metadata to a wallet transaction, replacing any metadata attached earlier.\n" +
+		"The metadata is stored encrypted and requires the wallet to be unlocked.",
+	"settravelrule-txid":            "Hash of the wallet transaction",
+	"settravelrule-originator":      "The person or institution sending the funds",
+	"settravelrule-beneficiary":     "The person or institution receiving the funds",
+	"settravelrule-originatingvasp": "Legal name of the virtual asset service provider of the originator",
+	"settravelrule-beneficiaryvasp": "Legal name of the virtual asset service provider of the beneficiary",
+
+	// TravelRuleParty help.
+	"travelruleparty-firstname":      "First name of a natural person",
+	"travelruleparty-lastname":       "Last name of a natural person",
+	"travelruleparty-legalname":      "Name of a legal person; may not be combined with a natural person name",
+	"travelruleparty-streetname":     "Street of the geographic address",
+	"travelruleparty-buildingnumber": "Building number of the geographic address",
+	"travelruleparty-postcode":       "Post code of the geographic address",
+	"travelruleparty-townname":       "Town of the geographic address",
+	"travelruleparty-country":        "ISO 3166-1 alpha-2 country code of the geographic address",
+	"travelruleparty-nationalid":     "National identifier, such as a passport number or LEI",
+	"travelruleparty-nationalidtype": "IVMS101 national identifier type code (such as CCPT, RAID or LEIX)",
+	"travelruleparty-dateofbirth":    "Date of birth of a natural person (YYYY-MM-DD)",
+	"travelruleparty-placeofbirth":   "Place of birth of a natural person",
+	"travelruleparty-accountnumber":  "Account or address of the party used for the transfer",
+
+	// ExportTravelRuleCmd help.
+	"exporttravelrule--synopsis": "Exports travel rule metadata as IVMS101 JSON.\n" +
+		"The wallet must be unlocked.",
+	"exporttravelrule-txid":     "Hash of the transaction to export; when omitted, the metadata of every transaction is exported as an array of objects with txid and ivms101 keys",
+	"exporttravelrule--result0": "The IVMS101 JSON document",
+
+	// WalletCreateFundedPsbtCmd help.
+	"walletcreatefundedpsbt--synopsis": "Authors an unsigned transaction that outputs to many payment addresses and returns it as a BIP0174 partially signed transaction (PSBT) for external signers.\n" +
+		"A change output is automatically included to send extra output value back to the original account.\n" +
+		"The spent outputs are locked until they are unlocked with lockunspent.",
+	"walletcreatefundedpsbt-fromaccount":    "Account to pick unspent outputs from (default=\"default\")",
+	"walletcreatefundedpsbt-amounts":        "Pairs of payment addresses and the output amount to pay each",
+	"walletcreatefundedpsbt-amounts--desc":  "JSON object using payment addresses as keys and output amounts valued in bitcoin to send to each address",
+	"walletcreatefundedpsbt-amounts--key":   "Address to pay",
+	"walletcreatefundedpsbt-amounts--value": "Amount to send to the payment address valued in bitcoin",
+	"walletcreatefundedpsbt-minconf":        "Minimum number of block confirmations required before a transaction output is eligible to be spent (default=1)",
+	"walletcreatefundedpsbt-token":          "Token of the outputs (default=\"STB\")",
+	"walletcreatefundedpsbt-coinselection":  "Strategy picking the outputs to spend, one of oldestfirst, largestfirst or branchandbound (default=the wallet's coinselection option)",
+	"walletcreatefundedpsbt-replaceable":    "Whether the transaction signals BIP0125 replaceability (default=true unless the wallet runs with --norbf)",
+	"walletcreatefundedpsbt-conftarget":     "Number of blocks within which the fee rate is estimated to get the transaction mined, unless set with settxfee (default=the wallet's conftarget option)",
+	"walletcreatefundedpsbt-feerate":        "Fee rate of the transaction in satoshis per virtual byte, which may not be used with conftarget (default=the wallet's fee rate)",
+	"walletcreatefundedpsbt-locktime":       "Lock time of the transaction, a block height below 500000000 and a UNIX timestamp otherwise, such as required to spend outputs locked with OP_CHECKLOCKTIMEVERIFY (default=the current block height unless the wallet runs with --nolocktime)",
+	"walletcreatefundedpsbt-overridefeecap": "Create the transaction even when its fee exceeds the fee cap of the wallet set by --maxtxfee, --maxfeepercent and --maxdailyfee (default=false)",
+
+	// WalletCreateFundedPsbtResult help.
+	"walletcreatefundedpsbtresult-psbt":      "The base64-encoded PSBT",
+	"walletcreatefundedpsbtresult-fee":       "The fee paid by the transaction valued in bitcoin",
+	"walletcreatefundedpsbtresult-changepos": "The index of the change output, or -1 if no change output was added",
+
+	// WalletProcessPsbtCmd help.
+	"walletprocesspsbt--synopsis": "Updates a PSBT with the UTXO data, scripts and key derivations known to the wallet, optionally adds the signatures of wallet keys, and finalizes the inputs that have all of their signatures.\n" +
+		"Signing requires the wallet to be unlocked.",
+	"walletprocesspsbt-psbt":        "The base64-encoded PSBT",
+	"walletprocesspsbt-sign":        "Sign the inputs with wallet keys (default=true)",
+	"walletprocesspsbt-sighashtype": "The signature hash type to sign with, which must match the type of inputs that specify one, one of \"ALL\", \"NONE\", \"SINGLE\", \"ALL|ANYONECANPAY\", \"NONE|ANYONECANPAY\", or \"SINGLE|ANYONECANPAY\" (default=\"ALL\")",
+
+	// WalletProcessPsbtResult help.
+	"walletprocesspsbtresult-psbt":     "The base64-encoded updated PSBT",
+	"walletprocesspsbtresult-complete": "Whether every input of the PSBT is finalized",
+
+	// FinalizePsbtCmd help.
+	"finalizepsbt--synopsis": "Finalizes the inputs of a PSBT that have all of their signatures and, when every input is finalized, extracts the signed transaction.",
+	"finalizepsbt-psbt":      "The base64-encoded PSBT",
+	"finalizepsbt-extract":   "Return the signed transaction instead of the PSBT when the PSBT is complete (default=true)",
+
+	// FinalizePsbtResult help.
+	"finalizepsbtresult-psbt":     "The base64-encoded PSBT, if the transaction was not extracted",
+	"finalizepsbtresult-hex":      "The hex-encoded signed transaction, if it was extracted",
+	"finalizepsbtresult-complete": "Whether every input of the PSBT is finalized",
+
+	// ExportPsbtCmd help.
+	"exportpsbt--synopsis": "Exports a PSBT for an offline signer, as the parts of an animated QR code in the BBQr format and optionally as a binary PSBT file.",
+	"exportpsbt-psbt":      "The base64-encoded PSBT",
+	"exportpsbt-file":      "Path of a new file the binary PSBT is written to",
+	"exportpsbt-qrpartlen": "Maximum number of characters of each QR code part (default=400)",
+
+	// ExportPsbtResult help.
+	"exportpsbtresult-file":    "The path of the written file, if any",
+	"exportpsbtresult-qrparts": "The BBQr parts of the PSBT, to be shown in order as an animated QR code",
+
+	// ImportSignedTxCmd help.
+	"importsignedtx--synopsis": "Broadcasts a transaction signed by an offline signer, usually a PSBT created with walletcreatefundedpsbt, and adds it to the wallet.\n" +
+		"The signed transaction is either read from a file or passed as the scanned BBQr parts of an animated QR code, as a base64-encoded PSBT, or as a hex-encoded transaction.\n" +
+		"Returns the transaction hash of the broadcast transaction.",
+	"importsignedtx-parts":    "The BBQr parts in any order, or a single base64-encoded PSBT or hex-encoded transaction; empty when file is set",
+	"importsignedtx-file":     "Path of a file holding the signed PSBT or transaction, in binary or text encoding",
+	"importsignedtx--result0": "The transaction hash of the broadcast transaction",
+
+	// GetAggregateBalanceCmd help.
+	"getaggregatebalance--synopsis": "Calculates the balances of all or some accounts concurrently and returns them with their totals.",
+	"getaggregatebalance-accounts":  "Names of the accounts to include (default=all accounts)",
+	"getaggregatebalance-minconf":   "Minimum number of block confirmations required before an unspent output's value is included in the spendable balance (default=1)",
+	"getaggregatebalance-token":     "Token of the balances (default=\"STB\")",
+
+	// GetAggregateBalanceResult help.
+	"getaggregatebalanceresult-accounts":       "The balances of each account",
+	"getaggregatebalanceresult-total":          "The total balance of the accounts valued in bitcoin",
+	"getaggregatebalanceresult-spendable":      "The spendable balance of the accounts valued in bitcoin",
+	"getaggregatebalanceresult-immaturereward": "The immature coinbase reward balance of the accounts valued in bitcoin",
+	"getaggregatebalanceresult-watchonly":      "The balance of the accounts paid to watch-only addresses valued in bitcoin",
+
+	// AggregateAccountBalance help.
+	"aggregateaccountbalance-account":        "The name of the account",
+	"aggregateaccountbalance-total":          "The total balance of the account valued in bitcoin",
+	"aggregateaccountbalance-spendable":      "The balance of the account with at least minconf confirmations valued in bitcoin",
+	"aggregateaccountbalance-immaturereward": "The immature coinbase reward balance of the account valued in bitcoin",
+	"aggregateaccountbalance-watchonly":      "The balance of the account paid to watch-only addresses valued in bitcoin",
+
+	// SweepPrivKeyCmd help.
+	"sweepprivkey--synopsis": "Sends every unspent output paying to a private key to the next address of an account, without importing the key.\n" +
+		"The outputs are found by filtering the blocks from startheight for the P2PKH, P2WPKH and nested P2WPKH addresses of the key.",
+	"sweepprivkey-privkey":     "The private key to sweep encoded as a WIF string",
+	"sweepprivkey-account":     "The account the outputs are swept to (default=\"default\")",
+	"sweepprivkey-startheight": "Height of the first block filtered for outputs of the key (default=0)",
+	"sweepprivkey-token":       "Token of the swept outputs (default=\"STB\")",
+	"sweepprivkey-pool":        "Address pool the outputs are swept to instead of an account, using the next address of the pool",
+
+	// SweepPrivKeyResult help.
+	"sweepprivkeyresult-txid":    "The hash of the sweep transaction",
+	"sweepprivkeyresult-address": "The address the outputs were swept to",
+	"sweepprivkeyresult-amount":  "The amount received by the wallet valued in bitcoin",
+	"sweepprivkeyresult-fee":     "The fee paid by the sweep transaction valued in bitcoin",
+	"sweepprivkeyresult-inputs":  "The number of swept outputs",
+
+	// CreateAccountWithPathCmd help.
+	"createaccountwithpath--synopsis": "Creates a new account whose extended key is derived from the master key along a custom derivation path instead of m/44'/<coin type>'/<account>'.\n" +
+		"Addresses of the account are derived below the account key as for other accounts.  The wallet must be unlocked.",
+	"createaccountwithpath-account": "Name of the new account",
+	"createaccountwithpath-path":    "Derivation path of the account key, such as m/44'/60'/0', with hardened levels marked with ' or h",
+
+	// ReserveAddressIndexesCmd help.
+	"reserveaddressindexes--synopsis": "Reserves the next external address indexes of an account for a system deriving addresses itself from the account extended public key.\n" +
+		"The wallet never returns addresses at reserved indexes from getnewaddress, but watches them for transactions.",
+	"reserveaddressindexes-account": "Name of the account",
+	"reserveaddressindexes-count":   "Number of indexes to reserve (at most 10000)",
+
+	// ReserveAddressIndexesResult help.
+	"reserveaddressindexesresult-account":    "The name of the account",
+	"reserveaddressindexesresult-branch":     "The branch of the reserved indexes, which is always the external branch 0",
+	"reserveaddressindexesresult-firstindex": "The first reserved index",
+	"reserveaddressindexesresult-lastindex":  "The last reserved index",
+
+	// ImportMultiCmd help.
+	"importmulti--synopsis": "Imports private keys, watch-only addresses and witness scripts, each with the time it was first used on the chain.\n" +
+		"A single rescan is started from the first block within two hours of the earliest timestamp, instead of a rescan from the genesis block for each import.",
+	"importmulti-requests": "The keys, addresses and scripts to import",
+	"importmulti-rescan":   "Rescan the blockchain for outputs paying to the imports (default=true)",
+
+	// ImportMultiRequest help.
+	"importmultirequest-privkey":   "WIF-encoded private key to import to the 'imported' account",
+	"importmultirequest-address":   "Address to import as watch-only",
+	"importmultirequest-script":    "Hex-encoded witness script to import",
+	"importmultirequest-account":   "The account a watch-only address is imported to (default=\"default\")",
+	"importmultirequest-timestamp": "Unix time the key, address or script was first used, or 0 to rescan from the genesis block",
+
+	// ImportMultiResult help.
+	"importmultiresult-success": "Whether the import succeeded",
+	"importmultiresult-error":   "The reason the import failed",
+
+	// ExportLedgerCmd help.
+	"exportledger--synopsis": "Exports the mined wallet transactions as a double-entry accounting ledger.\n" +
+		"Each account is an asset account under Assets:Wallet, with fees posted to Expenses:Fees and value exchanged with other wallets to Income:Received and Expenses:Sent.",
+	"exportledger-format":      "The plain text accounting format, either \"ledger\" for ledger-cli and hledger or \"beancount\"",
+	"exportledger-startheight": "Height of the first block of the exported transactions (default=0)",
+	"exportledger-endheight":   "Height of the last block of the exported transactions (default=the height the wallet is synced to)",
+	"exportledger--result0":    "The ledger file contents",
+
+	// GetAddressUsageCmd help.
+	"getaddressusage--synopsis": "Returns when a wallet address was generated or imported, and when it was first seen and last used on the chain.\n" +
+		"Addresses are used by the transactions paying to and spending from them, at the time of their block or when received if unmined.",
+	"getaddressusage-address": "The wallet address",
+
+	// GetAddressUsageResult help.
+	"getaddressusageresult-address":   "The address",
+	"getaddressusageresult-generated": "Unix time the address was derived or imported",
+	"getaddressusageresult-used":      "Whether a transaction used the address",
+	"getaddressusageresult-firstseen": "Unix time of the first transaction using the address (omitted when unknown)",
+	"getaddressusageresult-lastused":  "Unix time of the last transaction using the address (omitted when unknown)",
+
+	// GetUnlockTimeoutCmd help.
+	"getunlocktimeout--synopsis": "Returns whether the wallet is locked and, when it was unlocked by walletpassphrase with a timeout, when it is relocked.\n" +
+		"The wallet is relocked automatically when the timeout expires, and websocket clients subscribed with notifylockstate are sent a walletlockstate notification.",
+
+	// GetUnlockTimeoutResult help.
+	"getunlocktimeoutresult-locked":        "Whether the wallet is locked",
+	"getunlocktimeoutresult-unlockeduntil": "Unix time the wallet is relocked (omitted when locked or unlocked without a timeout)",
+	"getunlocktimeoutresult-remaining":     "Seconds remaining until the wallet is relocked (omitted when locked or unlocked without a timeout)",
+
+	// ImportPrivKeysCmd help.
+	"importprivkeys--synopsis": "Imports many WIF-encoded private keys to the 'imported' account in a single database update, with a single rescan for all of them.\n" +
+		"The keys are validated and encrypted concurrently, so that thousands of keys may be imported at once. The wallet must be unlocked.",
+	"importprivkeys-privkeys":  "The WIF-encoded private keys to import",
+	"importprivkeys-timestamp": "Unix time the keys were first used, or 0 to rescan from the genesis block",
+	"importprivkeys-rescan":    "Rescan the blockchain for outputs paying to the imported keys",
+
+	// ImportPrivKeysResult help.
+	"importprivkeysresult-imported":   "The number of keys imported",
+	"importprivkeysresult-duplicates": "The number of keys that were already in the wallet",
+	"importprivkeysresult-errors":     "The keys that failed to import",
+
+	// ImportPrivKeysError help.
+	"importprivkeyserror-index": "The index of the key in the request",
+	"importprivkeyserror-error": "The reason the key failed to import",
+
+	// SetAccountPassphraseCmd help.
+	"setaccountpassphrase--synopsis": "Protects an account with its own passphrase, or removes the passphrase of the account when the passphrase is empty. The wallet must be unlocked.\n" +
+		"The private keys of an account protected by its own passphrase are only available after the account is unlocked with walletpassphrase and the account name, independently from the lock state of the wallet.",
+	"setaccountpassphrase-account":    "The name of the account",
+	"setaccountpassphrase-passphrase": "The passphrase of the account, or an empty string to remove it",
+
+	// GetOperationSequenceCmd help.
+	"getoperationsequence--synopsis": "Returns the wallet operation sequence, the number of mutating requests that succeeded since the wallet was created.\n" +
+		"The responses to mutating requests include the sequence after the request in a sequence member, next to the result.\n" +
+		"A mutating request with a sequence member is only run when the wallet operation sequence still has that value, and otherwise fails with error code -40.",
+	"getoperationsequence--result0": "The wallet operation sequence",
+
+	// GetAccountFootprintCmd help.
+	"getaccountfootprint--synopsis": "Returns the size of the transactions of each account, counting a transaction for every account it credits or debits, with the quota configured for the account.\n" +
+		"Websocket clients subscribed with notifyaccountquota are sent an accountquota notification when an account first exceeds its quota.",
+	"getaccountfootprint-account": "The account to return the footprint of (default=all accounts)",
+
+	// GetAccountFootprintResult help.
+	"getaccountfootprintresult-account":   "The name of the account",
+	"getaccountfootprintresult-footprint": "The size in bytes of the serialized transactions of the account",
+	"getaccountfootprintresult-quota":     "The maximum footprint of the account in bytes (omitted when the account has no quota)",
+
+	// GetAuditLogCmd help.
+	"getauditlog--synopsis": "Returns records of the audit log of sensitive wallet operations: unlocks, wrong passphrases, passphrase changes, private key exports, imports, sends and address generation.\n" +
+		"Every record includes the hash of the previous record, and the request fails when a record was removed or modified.",
+	"getauditlog-from":  "The sequence number of the first record to return",
+	"getauditlog-count": "The maximum number of records to return",
+
+	// GetAuditLogResult help.
+	"getauditlogresult-seq":       "The sequence number of the record, starting at 1",
+	"getauditlogresult-time":      "The time of the operation in seconds since 1 Jan 1970 GMT",
+	"getauditlogresult-operation": "The operation (unlock, wrongpassphrase, passphrasechange, keyexport, import, send, newaddress or totp)",
+	"getauditlogresult-details":   "A description of the operation",
+	"getauditlogresult-hash":      "The hex-encoded SHA256 hash of the record",
+	"getauditlogresult-prevhash":  "The hex-encoded hash of the previous record, or zeros for the first record",
+
+	// GetStateCommitmentCmd help.
+	"getstatecommitment--synopsis": "Returns a commitment to the outputs of the wallet unspent at a block height and to the balances of its accounts at that height.\n" +
+		"The outputs and the balances are the leaves of two merkle trees, and the root commits to both trees, the block and the last record of the audit log.\n" +
+		"Recomputing the commitment of a height later returns the same merkle roots unless the wallet history of that height was altered or pruned.",
+	"getstatecommitment-height": "The height of the state to commit to (default=the height the wallet is synced to)",
+
+	// GetStateCommitmentResult help.
+	"getstatecommitmentresult-height":      "The height of the committed state",
+	"getstatecommitmentresult-blockhash":   "The hash of the block at the height",
+	"getstatecommitmentresult-utxos":       "The number of unspent outputs",
+	"getstatecommitmentresult-utxoroot":    "The merkle root of the unspent outputs, sorted by outpoint",
+	"getstatecommitmentresult-balances":    "The balances of the accounts, sorted by account and token",
+	"getstatecommitmentresult-balanceroot": "The merkle root of the balances",
+	"getstatecommitmentresult-auditseq":    "The sequence number of the last record of the audit log, or 0 when it is empty",
+	"getstatecommitmentresult-audithash":   "The hex-encoded hash of the last record of the audit log",
+	"getstatecommitmentresult-root":        "The commitment to the block, both merkle roots and the last record of the audit log",
+
+	// StateCommitmentBalance help.
+	"statecommitmentbalance-account": "The name of the account",
+	"statecommitmentbalance-token":   "The token of the balance",
+	"statecommitmentbalance-amount":  "The balance of the token in the unspent outputs of the account",
+
+	// GetProofOfReservesCmd help.
+	"getproofofreserves--synopsis": "Returns a proof of the reserves of the wallet: the outputs unspent at the block the wallet is synced to, grouped by address, with every address signing a message which commits to the block and a challenge.\n" +
+		"The signatures are created like those of signmessage, and can be checked with verifymessage by anyone holding the message, without exporting any key.\n" +
+		"Outputs already spent by unmined transactions are not included, and the wallet must be unlocked.",
+	"getproofofreserves-challenge": "A challenge chosen by the verifier, such as a random nonce, included in the signed message so that proofs cannot be reused",
+
+	// GetProofOfReservesResult help.
+	"getproofofreservesresult-height":    "The height of the block of the proof",
+	"getproofofreservesresult-blockhash": "The hash of the block of the proof",
+	"getproofofreservesresult-message":   "The message signed by every address",
+	"getproofofreservesresult-addresses": "The addresses with unspent outputs",
+	"getproofofreservesresult-totals":    "The total reserves of each token",
+
+	// ReserveAddress help.
+	"reserveaddress-address":   "The address",
+	"reserveaddress-signature": "The base64-encoded signature of the message by the key of the address (omitted when the wallet has no private key for the address)",
+	"reserveaddress-outputs":   "The unspent outputs of the address",
+
+	// ReserveOutput help.
+	"reserveoutput-txid":   "The hash of the transaction of the output",
+	"reserveoutput-vout":   "The index of the output in its transaction",
+	"reserveoutput-height": "The height of the block of the transaction",
+	"reserveoutput-amount": "The amount of the output",
+	"reserveoutput-token":  "The token of the output",
+
+	// ReserveTotal help.
+	"reservetotal-token":  "The token",
+	"reservetotal-amount": "The total amount of the unspent outputs of the token",
+	"reservetotal-signed": "The total amount of the unspent outputs of the token paying to addresses which signed the message",
+
+	// EnrollTOTPCmd help.
+	"enrolltotp--synopsis": "Enrolls a new TOTP secret, replacing any enrolled secret.\n" +
+		"Once a secret is enrolled, requests spending outputs, signing transactions or exporting private keys must include the current one-time password of the secret in a totp member of the request object, next to the params, and otherwise fail with error code -42.\n" +
+		"Every one-time password is only accepted once, and wrong passwords are throttled like wrong passphrases.\n" +
+		"Replacing an enrolled secret requires a one-time password of that secret.",
+
+	// EnrollTOTPResult help.
+	"enrolltotpresult-secret": "The base32-encoded secret to add to an authenticator app",
+	"enrolltotpresult-uri":    "The otpauth URI of the secret, which authenticator apps can read from a QR code",
+
+	// DisableTOTPCmd help.
+	"disabletotp--synopsis": "Removes the enrolled TOTP secret, so that one-time passwords are no longer required.\n" +
+		"The request requires a one-time password of the secret.",
+
+	// SignMessageBIP322Cmd help.
+	"signmessagebip322--synopsis": "Signs a message using the private key of a P2WPKH or P2TR address with the BIP0322 simple signature format.\n" +
+		"The signature is the witness spending the address output of a virtual transaction committing to the message, and can be checked with verifymessage.",
+	"signmessagebip322-address":  "Address of the private key used to sign the message",
+	"signmessagebip322-message":  "Message to sign",
+	"signmessagebip322--result0": "The base64-encoded serialized witness",
+
+	// PinAddressPoolCmd help.
+	"pinaddresspool--synopsis": "Adds a receive-only pool of addresses derived from the first branch of an external descriptor, such as the cold wallet of a custodian, or updates the window and accounts of the pool with the same descriptor.\n" +
+		"The wallet holds no keys of the pool and does not watch its addresses.\n" +
+		"Outputs may be swept to the pool with sweepprivkey, and transactions of the pinned accounts may only pay to addresses of their pools.",
+	"pinaddresspool-name":       "The name of the pool",
+	"pinaddresspool-descriptor": "The output descriptor of the pool addresses",
+	"pinaddresspool-window":     "The number of addresses past the next pool address which are also accepted as destinations, at most 1000",
+	"pinaddresspool-accounts":   "The accounts pinned to the pool",
+
+	// UnpinAddressPoolCmd help.
+	"unpinaddresspool--synopsis": "Removes an address pool, unpinning its accounts.",
+	"unpinaddresspool-name":      "The name of the pool",
+
+	// ListAddressPoolsCmd help.
+	"listaddresspools--synopsis": "Returns the address pools.",
+
+	// ListAddressPoolsResult help.
+	"listaddresspoolsresult-name":       "The name of the pool",
+	"listaddresspoolsresult-descriptor": "The output descriptor of the pool addresses",
+	"listaddresspoolsresult-nextindex":  "The index of the next pool address",
+	"listaddresspoolsresult-window":     "The number of addresses past the next pool address which are also accepted as destinations",
+	"listaddresspoolsresult-accounts":   "The accounts pinned to the pool",
+
+	// GetPoolAddressCmd help.
+	"getpooladdress--synopsis": "Returns the next address of an address pool, which is not returned again.",
+	"getpooladdress-name":      "The name of the pool",
+	"getpooladdress--result0":  "The pool address",
+
+	// ConsolidateChangeCmd help.
+	"consolidatechange--synopsis": "Consolidates the legacy and segwit v0 change outputs with at least 6 confirmations of every account into taproot outputs of the account, so that they are cheaper to spend later.\n" +
+		"Each transaction spends the change outputs of one token of one account, and the amount of each output pays its fee.\n" +
+		"The wallet must be unlocked unless dryrun is set.",
+	"consolidatechange-feerate":   "The fee rate in BTC/kB (default is the rate estimated by btcd for confirmation within a day)",
+	"consolidatechange-maxinputs": "The maximum number of change outputs spent by one transaction",
+	"consolidatechange-dryrun":    "Only report the consolidations without sending them",
+
+	// GetConsolidationReportCmd help.
+	"getconsolidationreport--synopsis": "Returns the report of the last consolidation of change outputs by consolidatechange or the --consolidatefeerate policy, or of fragmented accounts by the --fragmentationfeerate policy, or null if outputs were not consolidated since the wallet started.",
+
+	// ConsolidationReportResult help.
+	"consolidationreportresult-time":           "The time of the consolidation as a unix timestamp",
+	"consolidationreportresult-feerate":        "The fee rate in BTC/kB",
+	"consolidationreportresult-dryrun":         "Whether the consolidations were only reported",
+	"consolidationreportresult-consolidations": "The consolidation transactions",
+
+	// ConsolidationResult help.
+	"consolidationresult-account": "The account of the change outputs",
+	"consolidationresult-token":   "The token of the change outputs",
+	"consolidationresult-inputs":  "The consolidated outpoints as txid:vout",
+	"consolidationresult-amount":  "The total amount of the change outputs",
+	"consolidationresult-fee":     "The fee of the transaction",
+	"consolidationresult-address": "The taproot address paid by the transaction (omitted for dry runs and failures)",
+	"consolidationresult-txid":    "The hash of the transaction (omitted for dry runs and failures)",
+	"consolidationresult-error":   "The error which prevented the consolidation, if any",
+
+	// SweepAllCmd help.
+	"sweepall--synopsis": "Sends every spendable output of a token of an account, or only those paying to some of its addresses, to one or more destinations split by percentage, without change.\n" +
+		"The fee is deducted before the swept amount is split, and the satoshis left over by rounding go to the destinations with the largest remainders, so the outputs add up to the swept amount less the fee exactly.",
+	"sweepall-fromaccount":         "The account to sweep",
+	"sweepall-destinations":        "Pairs of destination addresses and their percentage of the swept amount",
+	"sweepall-destinations--desc":  "JSON object using destination addresses as keys and percentages with at most two decimals, adding up to 100, as values",
+	"sweepall-destinations--key":   "Destination address",
+	"sweepall-destinations--value": "Percentage of the swept amount sent to the address",
+	"sweepall-addresses":           "Addresses of the account whose outputs are swept (default is every address of the account)",
+	"sweepall-token":               "Token of the swept outputs (default=\"STB\")",
+	"sweepall-minconf":             "Minimum number of block confirmations of the swept outputs",
+	"sweepall-feerate":             "Fee rate of the transaction in satoshis per virtual byte (default=the wallet's fee rate)",
+
+	// SweepAllResult help.
+	"sweepallresult-txid":    "The hash of the sweep transaction",
+	"sweepallresult-outputs": "The outputs of the sweep transaction",
+	"sweepallresult-fee":     "The fee paid by the sweep transaction valued in bitcoin",
+	"sweepallresult-inputs":  "The number of swept outputs",
+
+	// SweepAllOutput help.
+	"sweepalloutput-address": "The destination address",
+	"sweepalloutput-amount":  "The amount sent to the address valued in bitcoin",
+
+	// SendWithInputsCmd help.
+	"sendwithinputs--synopsis": "Authors, signs, and sends a transaction that outputs to many payment addresses, spending every one of the chosen outputs of an account and no other output.\n" +
+		"The chosen outputs must be unlocked and have at least minconf confirmations, and leftover inputs not sent to the payment addresses or paid as fee are sent back to a change address.",
+	"sendwithinputs-fromaccount":    "Account of the spent outputs",
+	"sendwithinputs-amounts":        "Pairs of payment addresses and the output amount to pay each",
+	"sendwithinputs-amounts--desc":  "JSON object using payment addresses as keys and output amounts valued in bitcoin to send to each address",
+	"sendwithinputs-amounts--key":   "Address to pay",
+	"sendwithinputs-amounts--value": "Amount to send to the payment address valued in bitcoin",
+	"sendwithinputs-inputs":         "The outputs to spend",
+	"sendwithinputs-token":          "Token of the outputs (default=\"STB\")",
+	"sendwithinputs-minconf":        "Minimum number of block confirmations of the spent outputs",
+	"sendwithinputs-replaceable":    "Whether the transaction signals BIP0125 replaceability (default=true unless the wallet runs with --norbf)",
+	"sendwithinputs-conftarget":     "Number of blocks within which the fee rate is estimated to get the transaction mined, unless set with settxfee (default=the wallet's conftarget option)",
+	"sendwithinputs-feerate":        "Fee rate of the transaction in satoshis per virtual byte, which may not be used with conftarget (default=the wallet's fee rate)",
+	"sendwithinputs-overridefeecap": "Create the transaction even when its fee exceeds the fee cap of the wallet set by --maxtxfee, --maxfeepercent and --maxdailyfee (default=false)",
+	"sendwithinputs--result0":       "The transaction hash of the sent transaction",
+
+	// CancelBroadcastCmd help.
+	"cancelbroadcast--synopsis": "Cancels a transaction held before it is broadcast, removing it and any transaction spending its outputs from the wallet.\n" +
+		"When the wallet runs with --broadcasthold, the transactions it sends are held for the hold window before they are broadcast, and can be cancelled until then.\n" +
+		"Websocket clients subscribed with notifypendingbroadcast are sent a pendingbroadcast notification with the decoded transaction and its annotations for every held transaction.",
+	"cancelbroadcast-txid": "The hash of the held transaction",
+
+	// ListUnspentFilteredCmd help.
+	"listunspentfiltered--synopsis":     "Returns a JSON array of objects representing unlocked unspent outputs controlled by wallet keys, like listunspent, excluding the outputs worth less than a minimum amount.",
+	"listunspentfiltered-minconf":       "Minimum number of block confirmations required before a transaction output is considered",
+	"listunspentfiltered-maxconf":       "Maximum number of block confirmations required before a transaction output is excluded",
+	"listunspentfiltered-addresses":     "If set, limits the returned details to unspent outputs received by any of these payment addresses",
+	"listunspentfiltered-token":         "If set, limits the returned details to unspent outputs of this token",
+	"listunspentfiltered-minimumamount": "Minimum amount of the returned outputs valued in bitcoin",
+
+	// GetDiagnosticsCmd help.
+	"getdiagnostics--synopsis": "Returns the current diagnostics metrics and the snapshots recorded periodically to the diagnostics ring file, including those of previous runs of the wallet, with the errors logged between them.\n" +
+		"The method is available without a loaded wallet, unless diagnostics are disabled with --diagnosticsinterval=0.",
+
+	// GetDiagnosticsResult help.
+	"getdiagnosticsresult-current":   "The current metrics, and the errors logged since the last recorded snapshot",
+	"getdiagnosticsresult-snapshots": "The recorded snapshots, oldest first",
+
+	// DiagnosticsSnapshot help.
+	"diagnosticssnapshot-time":           "The Unix time of the snapshot",
+	"diagnosticssnapshot-metrics":        "The metrics, such as notification queue depths, request handler counts and store sizes",
+	"diagnosticssnapshot-metrics--desc":  "JSON object using metric names as keys and metric values as values",
+	"diagnosticssnapshot-metrics--key":   "The metric name",
+	"diagnosticssnapshot-metrics--value": "The metric value",
+	"diagnosticssnapshot-errors":         "The errors logged before the snapshot, since the previous one (omitted when there are none)",
+
+	// SetAccountMetadataCmd help.
+	"setaccountmetadata--synopsis": "Sets a metadata key of an account, such as a label or a bookkeeping reference, or removes it when the value is empty.",
+	"setaccountmetadata-account":   "The name of the account",
+	"setaccountmetadata-key":       "The metadata key",
+	"setaccountmetadata-value":     "The value of the key, or an empty string to remove it",
+
+	// GetAccountMetadataCmd help.
+	"getaccountmetadata--synopsis": "Returns the metadata of an account, the hash committing to it, and the last transaction anchoring the metadata on chain.",
+	"getaccountmetadata-account":   "The name of the account",
+
+	// GetAccountMetadataResult help.
+	"getaccountmetadataresult-metadata":        "The metadata of the account",
+	"getaccountmetadataresult-metadata--desc":  "JSON object using metadata keys as keys and their values as values",
+	"getaccountmetadataresult-metadata--key":   "The metadata key",
+	"getaccountmetadataresult-metadata--value": "The value of the key",
+	"getaccountmetadataresult-hash":            "The double SHA256 hash of the account number and of the keys and values sorted by key",
+	"getaccountmetadataresult-lastanchor":      "The last anchor of the metadata (omitted if it was never anchored)",
+
+	// MetadataAnchorResult help.
+	"metadataanchorresult-hash": "The metadata hash committed to by the anchor",
+	"metadataanchorresult-txid": "The hash of the anchor transaction",
+	"metadataanchorresult-time": "The Unix time of the anchor",
+
+	// AnchorAccountMetadataCmd help.
+	"anchoraccountmetadata--synopsis": "Sends a transaction from an account with an OP_RETURN output committing to the hash of its metadata, prefixed with \"BWMA\", as a timestamped proof of the metadata once mined.\n" +
+		"The fee is paid from the account.  With --metadataanchorinterval, the metadata of the accounts is also anchored periodically when it changed.",
+	"anchoraccountmetadata-account":  "The name of the account",
+	"anchoraccountmetadata--result0": "The hash of the anchor transaction",
+
+	// BumpFeeCmd help.
+	"bumpfee--synopsis": "Replaces an unconfirmed transaction sent by the wallet which signals BIP0125 replaceability with the same transaction paying a higher fee, deducted from its change output.\n" +
+		"The replaced transaction is removed from the wallet once the replacement is broadcast, and gettransaction reports the replacements of a transaction.",
+	"bumpfee-txid":    "The hash of the transaction to replace",
+	"bumpfee-feerate": "The fee rate of the replacement in satoshis per virtual byte (default: the larger of the wallet's fee rate and the fee rate of the transaction increased by 1 satoshi per virtual byte)",
+
+	// BumpFeeResult help.
+	"bumpfeeresult-txid":    "The hash of the replacement transaction",
+	"bumpfeeresult-origfee": "The fee of the replaced transaction, valued in bitcoin",
+	"bumpfeeresult-fee":     "The fee of the replacement transaction, valued in bitcoin",
+
+	// BumpFeeCPFPCmd help.
+	"bumpfeecpfp--synopsis": "Raises the effective fee rate of an unconfirmed transaction paying the wallet, such as an incoming transaction or a send which does not signal BIP0125 replaceability, by sending one of its outputs back to the wallet with a fee bringing the fee rate of both transactions up to the requested rate (child pays for parent).\n" +
+		"The change output of the transaction is spent when there is one.  The fee of a transaction spending outputs of other wallets is unknown, so the child pays the fee of both transactions.",
+	"bumpfeecpfp-txid":    "The hash of the unconfirmed transaction",
+	"bumpfeecpfp-feerate": "The fee rate of both transactions in satoshis per virtual byte (default=the wallet's fee rate)",
+
+	// BumpFeeCPFPResult help.
+	"bumpfeecpfpresult-txid":      "The hash of the child transaction",
+	"bumpfeecpfpresult-fee":       "The fee of the child transaction, valued in bitcoin",
+	"bumpfeecpfpresult-parentfee": "The fee of the unconfirmed transaction, valued in bitcoin, or 0 if it spends outputs of other wallets",
+
+	// GetKeyUsageCmd help.
+	"getkeyusage--synopsis": "Returns how often the keys of the accounts and of their addresses signed since the wallet started, with the signatures of the current hour and the baseline of signatures per hour, averaged over about a day.\n" +
+		"When the signatures of an address or account in an hour exceed --keyusagealertfactor times its baseline, an alert is logged and websocket clients subscribed with notifykeyusagealerts are sent a keyusagealert notification.",
+	"getkeyusage-account": "Only return the signatures of this account",
+
+	// GetKeyUsageResult help.
+	"getkeyusageresult-account":     "The name of the account",
+	"getkeyusageresult-signatures":  "The number of signatures by the keys of the account",
+	"getkeyusageresult-currenthour": "The number of signatures in the current hour",
+	"getkeyusageresult-baseline":    "The average number of signatures per hour",
+	"getkeyusageresult-addresses":   "The addresses of the account which signed, by decreasing signatures",
+
+	// KeyUsageAddressResult help.
+	"keyusageaddressresult-address":     "The address whose key signed",
+	"keyusageaddressresult-signatures":  "The number of signatures by the key of the address",
+	"keyusageaddressresult-currenthour": "The number of signatures in the current hour",
+	"keyusageaddressresult-baseline":    "The average number of signatures per hour",
+
+	// QueuePaymentCmd help.
+	"queuepayment--synopsis": "Queues a payment from an account to be sent with the other queued payments of the account in a single batch transaction, reducing the fees of frequent payouts.\n" +
+		"Queued payments are sent every --batchinterval, or when sendqueuedpayments is called.  The fee of the batch transaction is paid from the account.",
+	"queuepayment-fromaccount": "The account the payment is sent from",
+	"queuepayment-address":     "The address to pay",
+	"queuepayment-amount":      "The amount to pay",
+	"queuepayment-token":       "The token to pay (default=STB)",
+	"queuepayment--result0":    "The ID of the queued payment",
+
+	// GetQueuedPaymentCmd help.
+	"getqueuedpayment--synopsis": "Returns a queued payment, with the hash of its batch transaction once it is sent.",
+	"getqueuedpayment-id":        "The ID of the payment",
+
+	// ListQueuedPaymentsCmd help.
+	"listqueuedpayments--synopsis": "Returns the queued payments which are neither sent nor cancelled, ordered by ID.",
+
+	// QueuedPaymentResult help.
+	"queuedpaymentresult-id":        "The ID of the payment",
+	"queuedpaymentresult-account":   "The account the payment is sent from",
+	"queuedpaymentresult-address":   "The address paid (omitted for scripts without a single address)",
+	"queuedpaymentresult-amount":    "The amount paid",
+	"queuedpaymentresult-token":     "The token paid",
+	"queuedpaymentresult-time":      "The Unix time the payment was queued",
+	"queuedpaymentresult-txid":      "The hash of the batch transaction which sent the payment (omitted until it is sent)",
+	"queuedpaymentresult-cancelled": "Whether the payment was cancelled",
+
+	// CancelQueuedPaymentCmd help.
+	"cancelqueuedpayment--synopsis": "Cancels a queued payment which was not sent yet.",
+	"cancelqueuedpayment-id":        "The ID of the payment",
+
+	// SendQueuedPaymentsCmd help.
+	"sendqueuedpayments--synopsis": "Sends the queued payments now, in one batch transaction per account and token paying the wallet's fee rate.\n" +
+		"Payments which cannot be sent, such as those of an account without enough confirmed funds, stay queued; an error is only returned when no batch transaction could be sent.",
+
+	// PaymentBatchResult help.
+	"paymentbatchresult-account":  "The account the payments are sent from",
+	"paymentbatchresult-token":    "The token paid",
+	"paymentbatchresult-txid":     "The hash of the batch transaction",
+	"paymentbatchresult-payments": "The IDs of the payments sent by the transaction",
+
+	// SetTransactionLabelCmd help.
+	"settransactionlabel--synopsis": "Sets the label of a wallet transaction, such as a bookkeeping category, used to group the transactions of getspendingreport.",
+	"settransactionlabel-txid":      "The hash of the wallet transaction",
+	"settransactionlabel-label":     "The label of the transaction, or an empty string to remove its label",
+
+	// GetSpendingReportCmd help.
+	"getspendingreport--synopsis": "Returns the funds received from and sent to other wallets by the transactions mined over a period, with their counts and fees, summed by transaction label.\n" +
+		"Transactions without label are summed in the category with an empty label.  Transactions are dated by the time of their block, and fees are only known for transactions spending wallet outputs only.",
+	"getspendingreport-starttime": "The Unix time the period starts at",
+	"getspendingreport-endtime":   "The Unix time the period ends before",
+	"getspendingreport-account":   "Only sum the transactions debiting or crediting this account (default=\"*\" for all accounts)",
+	"getspendingreport-token":     "The token of the amounts summed (default=STB)",
+
+	// GetSpendingReportResult help.
+	"getspendingreportresult-starttime":  "The Unix time the period starts at",
+	"getspendingreportresult-endtime":    "The Unix time the period ends before",
+	"getspendingreportresult-categories": "The sums of the transactions of every label, ordered by label",
+	"getspendingreportresult-total":      "The sums of all transactions",
+
+	// SpendingCategoryResult help.
+	"spendingcategoryresult-label":        "The label of the transactions",
+	"spendingcategoryresult-received":     "The amount received from other wallets",
+	"spendingcategoryresult-receivecount": "The number of transactions receiving funds",
+	"spendingcategoryresult-sent":         "The amount sent to other wallets, excluding fees",
+	"spendingcategoryresult-sendcount":    "The number of transactions sending funds",
+	"spendingcategoryresult-fees":         "The fees paid by the transactions",
+
+	// FundRawTransactionCmd help.
+	"fundrawtransaction--synopsis": "Adds inputs spending unspent outputs of an account to a raw transaction until they pay for its outputs and fee, and a change output when the leftover value is not dust.\n" +
+		"The inputs and outputs of the transaction are kept, and its inputs must spend outputs of wallet transactions.  The funded transaction is not signed.",
+	"fundrawtransaction-hextx":   "The hex-encoded raw transaction to fund",
+	"fundrawtransaction-options": "Funding options",
+
+	// FundRawTransactionOpts help.
+	"fundrawtransactionopts-fromaccount":            "Account to pick unspent outputs from (default=\"default\")",
+	"fundrawtransactionopts-minconf":                "Minimum number of block confirmations required before a transaction output is eligible to be spent (default=1)",
+	"fundrawtransactionopts-changePosition":         "Index of the change output (default=random)",
+	"fundrawtransactionopts-lockUnspents":           "Lock the added inputs so that other transactions do not spend them (default=false)",
+	"fundrawtransactionopts-feeRate":                "Fee rate of the transaction in bitcoin per kilobyte, which may not be used with fee_rate nor conf_target (default=the wallet's fee rate)",
+	"fundrawtransactionopts-fee_rate":               "Fee rate of the transaction in satoshis per virtual byte, which may not be used with feeRate nor conf_target (default=the wallet's fee rate)",
+	"fundrawtransactionopts-conf_target":            "Number of blocks within which the fee rate is estimated to get the transaction mined, unless set with settxfee (default=the wallet's conftarget option)",
+	"fundrawtransactionopts-subtractFeeFromOutputs": "Indexes of the outputs paying the fee in proportion to their amounts, instead of the inputs",
+	"fundrawtransactionopts-replaceable":            "Whether the added inputs signal BIP0125 replaceability (default=true unless the wallet runs with --norbf)",
+	"fundrawtransactionopts-coinselection":          "Strategy picking the outputs to spend, one of oldestfirst, largestfirst or branchandbound (default=the wallet's coinselection option)",
+	"fundrawtransactionopts-changeAddress":          "P2WPKH address receiving the change, which may not be used with changeaccount (default=an internal address following the change policy)",
+	"fundrawtransactionopts-changeaccount":          "Account whose internal address receives the change, which may not be used with changeAddress (default=fromaccount)",
+	"fundrawtransactionopts-overridefeecap":         "Create the transaction even when its fee exceeds the fee cap of the wallet set by --maxtxfee, --maxfeepercent and --maxdailyfee (default=false)",
+
+	// FundRawTransactionResult help.
+	"fundrawtransactionresult-hex":       "The funded transaction encoded as a hexadecimal string",
+	"fundrawtransactionresult-fee":       "The fee paid by the transaction valued in bitcoin",
+	"fundrawtransactionresult-changepos": "The index of the change output, or -1 if no change output was added",
+
+	// SignRawTransactionWithWalletCmd help.
+	"signrawtransactionwithwallet--synopsis": "Signs transaction inputs using private keys from this wallet.\n" +
+		"The previous outputs of the inputs are taken from the inputs argument, the wallet transactions, or the chain server when it is connected.\n" +
+		"The valid sighashtype options are ALL, NONE, SINGLE, ALL|ANYONECANPAY, NONE|ANYONECANPAY, and SINGLE|ANYONECANPAY.",
+	"signrawtransactionwithwallet-rawtx":       "Unsigned or partially unsigned transaction to sign encoded as a hexadecimal string",
+	"signrawtransactionwithwallet-inputs":      "Previous outputs spent by the transaction that this wallet may not be tracking",
+	"signrawtransactionwithwallet-sighashtype": "Sighash type",
+
+	// SignerAttestationResult help.
+	"signerattestationresult-signer":      "The kind of external signer, such as hwi",
+	"signerattestationresult-type":        "The vendor of the signing device (omitted if unknown)",
+	"signerattestationresult-model":       "The model of the signing device (omitted if unknown)",
+	"signerattestationresult-fingerprint": "The hex encoded master key fingerprint of the signing device",
+	"signerattestationresult-evidence":    "The hex encoded attestation evidence signed by the device (omitted if the device provides none)",
+	"signerattestationresult-time":        "The Unix time the transaction was signed",
+
+	// SetChangePolicyCmd help.
+	"setchangepolicy--synopsis": "Sets the policy choosing the internal addresses receiving the change of the transactions created by the wallet, until the wallet is restarted.\n" +
+		"The new policy derives a new address for every change output, and the reuse policy pays the change of an account to its last internal address.",
+	"setchangepolicy-policy": "The change policy, new or reuse",
+
+	// ConsolidateUTXOsCmd help.
+	"consolidateutxos--synopsis": "Spends the outputs of a token of an account worth less than a threshold, the smallest first, to a single new change address of the account, so that later transactions spend fewer inputs.\n" +
+		"At least two outputs must be below the threshold. The outputs of the imported account are consolidated to an address of the default account.",
+	"consolidateutxos-threshold":   "The amount in bitcoin below which outputs are consolidated",
+	"consolidateutxos-feerate":     "Fee rate of the transaction in satoshis per virtual byte (default=the wallet's fee rate)",
+	"consolidateutxos-fromaccount": "The account whose outputs are consolidated",
+	"consolidateutxos-token":       "Token of the consolidated outputs (default=\"STB\")",
+	"consolidateutxos-maxinputs":   "The maximum number of outputs spent by the transaction",
+	"consolidateutxos-minconf":     "Minimum number of block confirmations of the consolidated outputs",
+
+	// ConsolidateUTXOsResult help.
+	"consolidateutxosresult-txid":    "The hash of the consolidation transaction",
+	"consolidateutxosresult-address": "The change address receiving the consolidated amount",
+	"consolidateutxosresult-amount":  "The amount sent to the address valued in bitcoin",
+	"consolidateutxosresult-fee":     "The fee paid by the consolidation transaction valued in bitcoin",
+	"consolidateutxosresult-inputs":  "The number of consolidated outputs",
+
+	// GetRecoveryStatusCmd help.
+	"getrecoverystatus--synopsis": "Returns the step and progress of the recovery wizard, which restores a wallet from its seed in the steps seed, derivations, scanning and review, before the recovery is done.\n" +
+		"The wizard is available before a wallet is loaded. The scan resumes when the recovered wallet is reopened until the recovery is finalized.",
+
+	// RecoveryEnterSeedCmd help.
+	"recoveryenterseed--synopsis": "Enters the seed of the recovered wallet at the seed or derivations step, moving the recovery to the derivations step.\n" +
+		"The seed is only kept in memory until the scan starts.",
+	"recoveryenterseed-seed":     "The hex encoded seed of the recovered wallet",
+	"recoveryenterseed-birthday": "The Unix time of the creation of the recovered wallet, before which blocks are not scanned (default is the genesis block)",
+
+	// RecoveryChooseDerivationsCmd help.
+	"recoverychoosederivations--synopsis":      "Chooses the key scopes scanned for used addresses at the derivations step. Every default key scope is scanned until derivations are chosen.",
+	"recoverychoosederivations-purposes":       "The BIP0044 purposes of the scanned key scopes, among 44, 49, 84 and 86",
+	"recoverychoosederivations-recoverywindow": "The number of unused addresses scanned past the last used address of each branch",
+
+	// RecoveryStartScanCmd help.
+	"recoverystartscan--synopsis": "Creates the recovered wallet at the derivations step and starts the scan of the chosen derivations once the wallet is synced with the chain server, moving the recovery to the scanning step.\n" +
+		"The recovery moves to the review step once the wallet is synced.",
+	"recoverystartscan-passphrase":       "The private passphrase of the recovered wallet",
+	"recoverystartscan-publicpassphrase": "The public passphrase of the recovered wallet (default is the insecure public passphrase)",
+
+	// RecoveryFinalizeCmd help.
+	"recoveryfinalize--synopsis": "Ends the review of the funds found by the recovery, after which the wallet is done recovering.",
+
+	// RecoveryAbortCmd help.
+	"recoveryabort--synopsis": "Forgets the seed and choices of a recovery whose scan has not started, returning to the seed step.",
+
+	// RecoveryStatusResult help.
+	"recoverystatusresult-step":           "The step of the recovery: seed, derivations, scanning, review or done",
+	"recoverystatusresult-birthday":       "The Unix time before which blocks are not scanned (omitted at the seed step)",
+	"recoverystatusresult-scopes":         "The scanned key scopes (omitted at the seed step)",
+	"recoverystatusresult-recoverywindow": "The number of unused addresses scanned past the last used address of each branch (omitted at the seed step)",
+	"recoverystatusresult-syncedheight":   "The height of the block the recovered wallet is synced to (omitted before the scan)",
+	"recoverystatusresult-bestheight":     "The height of the best block of the chain server (omitted before the scan)",
+	"recoverystatusresult-found":          "The addresses and funds found in each scanned key scope (omitted before the scan)",
+
+	// RecoveredScopeResult help.
+	"recoveredscoperesult-scope":           "The derivation path of the key scope",
+	"recoveredscoperesult-addresses":       "The number of addresses of the default account of the scope up to its last used address",
+	"recoveredscoperesult-balances":        "The unspent outputs paying to the addresses of the scope by token",
+	"recoveredscoperesult-balances--desc":  "JSON object using tokens as keys and amounts as values",
+	"recoveredscoperesult-balances--key":   "The token",
+	"recoveredscoperesult-balances--value": "The amount of the token valued in bitcoin",
+
+	// ListQuarantinedCmd help.
+	"listquarantined--synopsis": "Returns the unspent outputs quarantined as dust, the oldest first.\n" +
+		"Outputs worth at most the --dustthreshold amount received by transactions not spending wallet outputs, as sent to track the addresses of a wallet, are quarantined and not spent by coin selection unless --spendquarantined is set.",
+
+	// ListQuarantinedResult help.
+	"listquarantinedresult-txid":        "The hash of the transaction of the output",
+	"listquarantinedresult-vout":        "The output index",
+	"listquarantinedresult-account":     "The account of the address paid by the output",
+	"listquarantinedresult-address":     "The address paid by the output (omitted for non-standard scripts)",
+	"listquarantinedresult-amount":      "The amount of the output valued in bitcoin",
+	"listquarantinedresult-token":       "The token of the output",
+	"listquarantinedresult-confirmed":   "Whether the transaction of the output is mined",
+	"listquarantinedresult-quarantined": "The Unix time the output was quarantined",
+
+	// SpendQuarantinedCmd help.
+	"spendquarantined--synopsis": "Spends quarantined outputs of the same token to an address, or provably burns them when no address is given.\n" +
+		"Burning transactions pay the whole value of the outputs as fee to a single zero-value OP_RETURN output, which must cover the fee at the fee rate.",
+	"spendquarantined-outputs": "The quarantined outputs to spend",
+	"spendquarantined-address": "The address paid the value of the outputs less the fee (default=burn the outputs)",
+	"spendquarantined-feerate": "Fee rate of the transaction in satoshis per virtual byte (default=the wallet's fee rate)",
+
+	// SpendQuarantinedResult help.
+	"spendquarantinedresult-txid":   "The hash of the transaction",
+	"spendquarantinedresult-fee":    "The fee paid by the transaction valued in bitcoin",
+	"spendquarantinedresult-burned": "Whether the outputs were burned",
+
+	// GetConfigHashCmd help.
+	"getconfighash--synopsis": "Returns the SHA-256 hash of the canonical serialization of the effective configuration, including the change policy and fee rate of the loaded wallet set over RPC, so that configuration drift across wallet daemons can be detected by comparing hashes.\n" +
+		"Secret options only record whether they are set, and options requesting one-time actions such as --create are left out.\n" +
+		"The method is available without a loaded wallet, and changes of the hash are logged.",
+	"getconfighash-verbose": "Also return the canonical settings the hash is computed from",
+
+	// GetConfigHashResult help.
+	"getconfighashresult-hash":     "The hash of the configuration in hex",
+	"getconfighashresult-settings": "The settings as name=\"value\" lines ordered by name, options specified multiple times having a line per value (only when verbose)",
+
+	// GetDustPolicyCmd help.
+	"getdustpolicy--synopsis": "Returns the dust policy of the wallet: the dust limits below which outputs are not created, how change below the limit is handled, and the quarantine of received dust.",
+
+	// GetDustPolicyResult help.
+	"getdustpolicyresult-dustrelayfee":        "The relay fee rate in BTC/kB the dust limits are derived from, 0 when outputs are not checked",
+	"getdustpolicyresult-rejectdustchange":    "Whether transactions whose change is below the dust limit are refused, rather than adding the change to the fee",
+	"getdustpolicyresult-dustlimits":          "The dust limits by address type",
+	"getdustpolicyresult-dustlimits--desc":    "JSON object using address types as keys and dust limits as values",
+	"getdustpolicyresult-dustlimits--key":     "The address type, one of p2pkh, p2sh, p2wpkh, p2wsh or p2tr",
+	"getdustpolicyresult-dustlimits--value":   "The amount below which outputs paying the address type are dust valued in bitcoin",
+	"getdustpolicyresult-quarantinethreshold": "The amount at or below which received outputs are quarantined valued in bitcoin, 0 when the quarantine is disabled",
+	"getdustpolicyresult-spendquarantined":    "Whether coin selection spends quarantined outputs",
+
+	// FreezeAccountCmd help.
+	"freezeaccount--synopsis": "Immediately blocks the spends of the outputs of an account, as an emergency brake during a suspected compromise.\n" +
+		"Transactions spending outputs of the account are neither created, signed nor broadcast, including transactions held for broadcast, while the account still receives outputs and is queried.\n" +
+		"The account number is frozen in every key scope, and the freeze persists across restarts until it is lifted with unfreezeaccount.",
+	"freezeaccount-account": "The name of the account to freeze",
+	"freezeaccount-reason":  "The reason of the freeze, recorded in the audit log (default=\"\")",
+
+	// UnfreezeAccountCmd help.
+	"unfreezeaccount--synopsis": "Lifts the freeze of an account set with freezeaccount.\n" +
+		"Lifting a freeze requires the private passphrase of the wallet, and the one-time password when enrolled, so that clients able to freeze accounts can't lift freezes.",
+	"unfreezeaccount-account":    "The name of the frozen account",
+	"unfreezeaccount-passphrase": "The private passphrase of the wallet",
+
+	// ListFrozenAccountsCmd help.
+	"listfrozenaccounts--synopsis": "Returns the frozen accounts ordered by account number.",
+
+	// ListFrozenAccountsResult help.
+	"listfrozenaccountsresult-account": "The name of the account",
+	"listfrozenaccountsresult-frozen":  "The Unix time the account was frozen",
+	"listfrozenaccountsresult-reason":  "The reason of the freeze (omitted when none was given)",
+
+	// GetDerivationProofCmd help.
+	"getderivationproof--synopsis": "Returns a proof that an address of the wallet is derived from the extended public key of its account, so that auditors and counterparties can verify that the address belongs to the account without trusting the wallet.\n" +
+		"Each step is a BIP0032 public child key derivation: the tweak is the left half of HMAC-SHA512(chaincode, parentkey || index as 4 big endian bytes), the child key is the parent key plus the tweak times the generator point, and the right half of the HMAC is the child chain code.\n" +
+		"The first step derives the branch key from the account key, and the second the address key, which encodes the address by its type.\n" +
+		"Imported addresses have no proof.",
+	"getderivationproof-address": "The address to prove",
+
+	// GetDerivationProofResult help.
+	"getderivationproofresult-address":     "The proven address",
+	"getderivationproofresult-addresstype": "The type of the address: p2pkh, p2sh-p2wpkh, p2wpkh or p2tr",
+	"getderivationproofresult-accountxpub": "The extended public key of the account",
+	"getderivationproofresult-accountpath": "The derivation path of the account key from the master key",
+	"getderivationproofresult-path":        "The derivation path of the address key from the master key",
+	"getderivationproofresult-steps":       "The derivations of the branch key from the account key and of the address key from the branch key",
+	"getderivationproofresult-outputkey":   "The hex-encoded x-only taproot output key committing to the address key (only for p2tr addresses)",
+
+	// DerivationProofStep help.
+	"derivationproofstep-index":          "The index of the child key",
+	"derivationproofstep-parentkey":      "The hex-encoded compressed parent public key",
+	"derivationproofstep-chaincode":      "The hex-encoded chain code of the parent key",
+	"derivationproofstep-tweak":          "The hex-encoded left half of the HMAC added to the parent key",
+	"derivationproofstep-childkey":       "The hex-encoded compressed child public key",
+	"derivationproofstep-childchaincode": "The hex-encoded chain code of the child key",
+
+	// PrepareTransactionCmd help.
+	"preparetransaction--synopsis": "Performs the coin selection and fee calculation of a transaction that outputs to many payment addresses, and returns its inputs, outputs, fee and virtual size without signing nor sending it, so that frontends can show a confirmation screen.\n" +
+		"The chosen outputs are not locked, and the transaction sent afterwards may spend other outputs and pay another fee when the wallet or the fee estimate changed in between.\n" +
+		"The change output has no address unless changeaddress is given, since the change address is only derived when the transaction is sent.",
+	"preparetransaction-amounts":         "Pairs of payment addresses and the output amount to pay each",
+	"preparetransaction-amounts--desc":   "JSON object using payment addresses as keys and output amounts valued in bitcoin to send to each address",
+	"preparetransaction-amounts--key":    "Address to pay",
+	"preparetransaction-amounts--value":  "Amount to send to the payment address valued in bitcoin",
+	"preparetransaction-fromaccount":     "Account to pick unspent outputs from (default=\"default\")",
+	"preparetransaction-minconf":         "Minimum number of block confirmations required before a transaction output is eligible to be spent (default=1)",
+	"preparetransaction-token":           "Token of the outputs (default=\"STB\")",
+	"preparetransaction-coinselection":   "Strategy picking the outputs to spend, one of oldestfirst, largestfirst or branchandbound (default=the wallet's coinselection option)",
+	"preparetransaction-conftarget":      "Number of blocks within which the fee rate is estimated to get the transaction mined, unless set with settxfee (default=the wallet's conftarget option)",
+	"preparetransaction-feerate":         "Fee rate of the transaction in satoshis per virtual byte, which may not be used with conftarget (default=the wallet's fee rate)",
+	"preparetransaction-subtractfeefrom": "Payment addresses paying the fee in proportion to their amounts, instead of the inputs",
+	"preparetransaction-changeaddress":   "P2WPKH address receiving the change (default=an internal address following the change policy)",
+
+	// PrepareTransactionResult help.
+	"preparetransactionresult-inputs":  "The outputs spent by the transaction",
+	"preparetransactionresult-outputs": "The outputs of the transaction",
+	"preparetransactionresult-fee":     "The fee of the transaction valued in bitcoin",
+	"preparetransactionresult-vsize":   "The estimated virtual size of the signed transaction in virtual bytes",
+
+	// PrepareTransactionInput help.
+	"preparetransactioninput-txid":    "The hash of the transaction of the spent output",
+	"preparetransactioninput-vout":    "The index of the spent output",
+	"preparetransactioninput-address": "The address of the spent output",
+	"preparetransactioninput-amount":  "The amount of the spent output valued in bitcoin",
+
+	// PrepareTransactionOutput help.
+	"preparetransactionoutput-address": "The address paid by the output (omitted for the change output until the transaction is sent, unless changeaddress is given)",
+	"preparetransactionoutput-amount":  "The amount of the output valued in bitcoin",
+	"preparetransactionoutput-change":  "Whether the output returns the change to the wallet",
+
+	// EnterMaintenanceCmd help.
+	"entermaintenance--synopsis": "Puts the wallet in maintenance mode so that its database file can be backed up or snapshotted without restarting the daemon.\n" +
+		"The processing of chain notifications is paused and the database is closed with its checksums once the open database transactions are done.\n" +
+		"Until exitmaintenance is called, the requests accessing the database fail, and the database file must not be modified.",
+	"entermaintenance-timeout": "Seconds to wait for the open database transactions to be done before giving up (default=30)",
+
+	// ExitMaintenanceCmd help.
+	"exitmaintenance--synopsis": "Reopens and verifies the wallet database closed by entermaintenance, resumes the processing of chain notifications, and rescans the blocks connected since maintenance was entered.\n" +
+		"The result describes the ended maintenance.",
+
+	// GetMaintenanceInfoCmd help.
+	"getmaintenanceinfo--synopsis": "Returns whether the wallet is in maintenance mode.",
+
+	// MaintenanceResult help.
+	"maintenanceresult-active": "Whether the wallet is in maintenance mode",
+	"maintenanceresult-since":  "The Unix time maintenance was entered",
+	"maintenanceresult-dbpath": "The path of the closed wallet database file",
+	"maintenanceresult-height": "The height of the block the wallet was synced to when maintenance was entered, from which the catch-up rescan starts",
+	"maintenanceresult-hash":   "The hash of the block the wallet was synced to when maintenance was entered",
+
+	// AccelerateTxCmd help.
+	"acceleratetx--synopsis": "Submits an unmined wallet transaction to third-party transaction acceleration services, such as the acceleration APIs of mining pools, configured with the accelerator option.\n" +
+		"Accelerators are outside the control of the wallet and of the network: acceleration is best effort, may be charged by the service, and discloses the transaction to it.\n" +
+		"A refusal of a service is reported in its acceleration rather than as an error.",
+	"acceleratetx-txid":         "The hash of the stuck transaction",
+	"acceleratetx-accelerators": "The names of the accelerators to submit the transaction to (default=every configured accelerator)",
+
+	// ListAccelerationsCmd help.
+	"listaccelerations--synopsis": "Returns the submissions of transactions to third-party acceleration services, and whether the transactions are mined.",
+	"listaccelerations-txid":      "The hash of the transaction whose submissions are returned (default=every transaction)",
+
+	// AccelerationsResult help.
+	"accelerationsresult-notice":        "A reminder that accelerators are third-party services",
+	"accelerationsresult-accelerations": "The submissions of transactions to accelerators",
+
+	// AccelerationResult help.
+	"accelerationresult-txid":        "The hash of the submitted transaction",
+	"accelerationresult-accelerator": "The name of the accelerator, the host of its service",
+	"accelerationresult-submitted":   "The Unix time the transaction was submitted",
+	"accelerationresult-accepted":    "Whether the accelerator accepted the transaction",
+	"accelerationresult-reference":   "The reference of the acceleration at the accelerator (omitted when none was given)",
+	"accelerationresult-message":     "The message of the accelerator, or the reason of its refusal (omitted when none was given)",
+	"accelerationresult-mined":       "Whether the transaction is mined",
+
+	// EvaluatePolicyCmd help.
+	"evaluatepolicy--synopsis": "Dry runs a send through the spend policies, the address screening provider and the broadcast hold, and reports the outcome of every rule.\n" +
+		"No transaction is created, signed or sent, and transaction hooks are not run, but the destination address is sent to the screening provider.",
+	"evaluatepolicy-address":     "The address to send to",
+	"evaluatepolicy-amount":      "The amount to send",
+	"evaluatepolicy-token":       "The token to send",
+	"evaluatepolicy-fromaccount": "The account to send from",
+	"evaluatepolicy-minconf":     "The minimum number of block confirmations of the spent outputs",
+	"evaluatepolicy-feerate":     "The fee rate in satoshis per virtual byte (default=the wallet fee rate)",
+
+	// EvaluatePolicyResult help.
+	"evaluatepolicyresult-allowed": "Whether no rule blocks the send",
+	"evaluatepolicyresult-rules":   "The outcome of every rule, in the order they are applied",
+	"evaluatepolicyresult-fee":     "The fee of the transaction the send would create (omitted when it could not be created)",
+	"evaluatepolicyresult-vsize":   "The estimated virtual size of the transaction the send would create (omitted when it could not be created)",
+
+	// PolicyRuleResult help.
+	"policyruleresult-rule":    "The name of the rule (accountfreeze, addresspool, dust, funds, feecap, screening, txhooks or broadcasthold)",
+	"policyruleresult-outcome": "The outcome of the rule (pass, warn, hold, block or skipped)",
+	"policyruleresult-detail":  "Why the rule triggered",
+
+	// CreatePaymentReferenceCmd help.
+	"createpaymentreference--synopsis": "Returns the payment reference tied to an external wallet address, creating one unique to the wallet when the address has none.\n" +
+		"References are ISO 11649 creditor references, which payers may quote as bank payment references.",
+	"createpaymentreference-address": "The address the reference is tied to",
+
+	// LookupPaymentReferenceCmd help.
+	"lookuppaymentreference--synopsis": "Returns the address a payment reference is tied to, and the amount it received.\n" +
+		"References are matched regardless of case and spaces, and mistyped references are refused.",
+	"lookuppaymentreference-reference": "The payment reference",
+	"lookuppaymentreference-minconf":   "The minimum number of block confirmations of the received outputs",
+
+	// ListPaymentReferencesCmd help.
+	"listpaymentreferences--synopsis": "Returns the payment references of the wallet, ordered by reference.",
+
+	// PaymentReferenceResult help.
+	"paymentreferenceresult-reference": "The payment reference",
+	"paymentreferenceresult-address":   "The address the reference is tied to",
+	"paymentreferenceresult-account":   "The account of the address",
+	"paymentreferenceresult-created":   "The Unix time the reference was created",
+	"paymentreferenceresult-uri":       "A BIP21 payment URI for the address, labeled with the reference",
+	"paymentreferenceresult-received":  "The amount received by the address (only returned by lookuppaymentreference)",
+
+	// EnrollBackupTokenCmd help.
+	"enrollbackuptoken--synopsis": "Enrolls the backup token configured with --backuptoken, such as a YubiKey, under a name.\n" +
+		"Backups made by backupwallet with --backuptoken are wrapped to every enrolled token, without requiring the tokens, and can only be decrypted with one of them.\n" +
+		"The token may need to be touched.",
+	"enrollbackuptoken-name": "A name distinguishing the token from the other enrolled tokens",
+
+	// ListBackupTokensCmd help.
+	"listbackuptokens--synopsis": "Returns the enrolled backup tokens, ordered by name.",
+
+	// RemoveBackupTokenCmd help.
+	"removebackuptoken--synopsis": "Removes an enrolled backup token, which no longer wraps new backups.\n" +
+		"Backups already wrapped by the token can still be decrypted with it.",
+	"removebackuptoken-name": "The name of the token",
+
+	// RecoverBackupCmd help.
+	"recoverbackup--synopsis": "Decrypts a backup wrapped by the enrolled backup tokens with the backup token configured with --backuptoken, and writes the wallet database to a new file.\n" +
+		"The token may need to be touched once for each token the backup is wrapped to.",
+	"recoverbackup-backup":      "The path of the backup made by backupwallet",
+	"recoverbackup-destination": "The path of the wallet database to write, which must not exist",
+
+	// BackupTokenResult help.
+	"backuptokenresult-name":     "The name of the token",
+	"backuptokenresult-enrolled": "The Unix time the token was enrolled",
+
+	// AddHeightTriggerCmd help.
+	"addheighttrigger--synopsis": "Adds a trigger firing when the wallet connects a block at or above a height, such as the height a timelock expires.\n" +
+		"Fired triggers are POSTed as JSON objects with the id, height, label and firedheight fields to the webhook, which is retried at every following block until it responds with a 2xx status.\n" +
+		"Triggers persist across restarts, and triggers whose height is already reached fire at the next block.",
+	"addheighttrigger-height":  "The height firing the trigger",
+	"addheighttrigger-webhook": "The HTTP URL POSTed the trigger when it fires",
+	"addheighttrigger-label":   "A label describing the trigger, posted with it",
+
+	// ListHeightTriggersCmd help.
+	"listheighttriggers--synopsis": "Returns the height triggers which are pending, or fired but not yet accepted by their webhooks, ordered by ID.",
+
+	// RemoveHeightTriggerCmd help.
+	"removeheighttrigger--synopsis": "Removes a height trigger which is pending, or whose webhook is still retried.",
+	"removeheighttrigger-id":        "The ID of the trigger",
+
+	// HeightTriggerResult help.
+	"heighttriggerresult-id":          "The ID of the trigger",
+	"heighttriggerresult-height":      "The height firing the trigger",
+	"heighttriggerresult-webhook":     "The HTTP URL POSTed the trigger when it fires",
+	"heighttriggerresult-label":       "The label of the trigger",
+	"heighttriggerresult-created":     "The Unix time the trigger was added",
+	"heighttriggerresult-firedheight": "The height of the block which fired the trigger, omitted while the trigger is pending",
+
+	// CreateAPITokenCmd help.
+	"createapitoken--synopsis": "Creates a named API token authenticating a frontend, such as a point of sale or a dashboard.\n" +
+		"Clients authenticate with the token by using its name and secret as their RPC username and password, so they are distinguished in the logs and may be revoked one by one.\n" +
+		"The secret is only returned once, and the wallet only records its hash.",
+	"createapitoken-name": "A name distinguishing the token from the other tokens, without colons",
+
+	// ListAPITokensCmd help.
+	"listapitokens--synopsis": "Returns the API tokens, ordered by name, without their secrets.",
+
+	// RevokeAPITokenCmd help.
+	"revokeapitoken--synopsis": "Revokes an API token.\n" +
+		"Clients can no longer authenticate with the token, and the websocket clients it authenticated are disconnected.",
+	"revokeapitoken-name": "The name of the token",
+
+	// APITokenResult help.
+	"apitokenresult-name":    "The name of the token",
+	"apitokenresult-created": "The Unix time the token was created",
+	"apitokenresult-secret":  "The secret of the token, only returned when it is created",
+
+	// GetRefillRequestCmd help.
+	"getrefillrequest--synopsis": "Returns the pending request to refill the hot account from the watch-only cold account, or null when the hot account was not below its threshold since it was last refilled.\n" +
+		"The request is created when the confirmed spendable balance of the hot account falls below the --refillthreshold option, and removed once the hot account is refilled.",
+
+	// RefillRequestResult help.
+	"refillrequestresult-created": "The Unix time the request was created",
+	"refillrequestresult-balance": "The balance of the hot account when the request was created",
+	"refillrequestresult-amount":  "The amount paid to the hot account",
+	"refillrequestresult-fee":     "The fee of the refill transaction",
+	"refillrequestresult-path":    "The path of the PSBT file written for the cold storage signers",
+	"refillrequestresult-psbt":    "The base64-encoded PSBT of the refill transaction",
 }
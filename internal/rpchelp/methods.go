@@ -6,7 +6,10 @@
 
 package rpchelp
 
-import "github.com/btcsuite/btcd/btcjson"
+import (
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/rpc/walletjson"
+)
 
 // Common return types.
 var (
@@ -24,8 +27,10 @@ var Methods = []struct {
 	ResultTypes []interface{}
 }{
 	{"addmultisigaddress", returnsString},
+	{"backupwallet", nil},
 	{"createmultisig", []interface{}{(*btcjson.CreateMultiSigResult)(nil)}},
 	{"dumpprivkey", returnsString},
+	{"dumpwallet", []interface{}{(*walletjson.DumpWalletResult)(nil)}},
 	{"getaccount", returnsString},
 	{"getaccountaddress", returnsString},
 	{"getaddressesbyaccount", returnsStringArray},
@@ -37,9 +42,11 @@ var Methods = []struct {
 	{"getrawchangeaddress", returnsString},
 	{"getreceivedbyaccount", returnsNumber},
 	{"getreceivedbyaddress", returnsNumber},
-	{"gettransaction", []interface{}{(*btcjson.GetTransactionResult)(nil)}},
+	{"gettransaction", []interface{}{(*walletjson.GetTransactionResult)(nil)}},
 	{"help", append(returnsString, returnsString[0])},
+	{"importaddress", nil},
 	{"importprivkey", nil},
+	{"importwallet", nil},
 	{"keypoolrefill", nil},
 	{"listaccounts", []interface{}{(*map[string]float64)(nil)}},
 	{"listlockunspent", []interface{}{(*[]btcjson.TransactionInput)(nil)}},
@@ -52,6 +59,8 @@ var Methods = []struct {
 	{"sendfrom", returnsString},
 	{"sendmany", returnsString},
 	{"sendtoaddress", returnsString},
+	{"bid", returnsString},
+	{"ask", returnsString},
 	{"settxfee", returnsBool},
 	{"signmessage", returnsString},
 	{"signrawtransaction", []interface{}{(*btcjson.SignRawTransactionResult)(nil)}},
@@ -68,6 +77,96 @@ var Methods = []struct {
 	{"listalltransactions", returnsLTRArray},
 	{"renameaccount", nil},
 	{"walletislocked", returnsBool},
+	{"getnewtaprootaddress", returnsString},
+	{"importwitnessscript", []interface{}{(*walletjson.ImportWitnessScriptResult)(nil)}},
+	{"settravelrule", nil},
+	{"exporttravelrule", returnsString},
+	{"walletcreatefundedpsbt", []interface{}{(*walletjson.WalletCreateFundedPsbtResult)(nil)}},
+	{"walletprocesspsbt", []interface{}{(*walletjson.WalletProcessPsbtResult)(nil)}},
+	{"finalizepsbt", []interface{}{(*walletjson.FinalizePsbtResult)(nil)}},
+	{"exportpsbt", []interface{}{(*walletjson.ExportPsbtResult)(nil)}},
+	{"importsignedtx", returnsString},
+	{"getaggregatebalance", []interface{}{(*walletjson.GetAggregateBalanceResult)(nil)}},
+	{"sweepprivkey", []interface{}{(*walletjson.SweepPrivKeyResult)(nil)}},
+	{"createaccountwithpath", nil},
+	{"reserveaddressindexes", []interface{}{(*walletjson.ReserveAddressIndexesResult)(nil)}},
+	{"importmulti", []interface{}{(*[]walletjson.ImportMultiResult)(nil)}},
+	{"exportledger", returnsString},
+	{"getaddressusage", []interface{}{(*walletjson.GetAddressUsageResult)(nil)}},
+	{"getunlocktimeout", []interface{}{(*walletjson.GetUnlockTimeoutResult)(nil)}},
+	{"importprivkeys", []interface{}{(*walletjson.ImportPrivKeysResult)(nil)}},
+	{"setaccountpassphrase", nil},
+	{"getoperationsequence", returnsNumber},
+	{"getaccountfootprint", []interface{}{(*[]walletjson.GetAccountFootprintResult)(nil)}},
+	{"getauditlog", []interface{}{(*[]walletjson.GetAuditLogResult)(nil)}},
+	{"getstatecommitment", []interface{}{(*walletjson.GetStateCommitmentResult)(nil)}},
+	{"getproofofreserves", []interface{}{(*walletjson.GetProofOfReservesResult)(nil)}},
+	{"enrolltotp", []interface{}{(*walletjson.EnrollTOTPResult)(nil)}},
+	{"disabletotp", nil},
+	{"signmessagebip322", returnsString},
+	{"pinaddresspool", nil},
+	{"unpinaddresspool", nil},
+	{"listaddresspools", []interface{}{(*[]walletjson.ListAddressPoolsResult)(nil)}},
+	{"getpooladdress", returnsString},
+	{"consolidatechange", []interface{}{(*walletjson.ConsolidationReportResult)(nil)}},
+	{"getconsolidationreport", []interface{}{(*walletjson.ConsolidationReportResult)(nil)}},
+	{"sweepall", []interface{}{(*walletjson.SweepAllResult)(nil)}},
+	{"sendwithinputs", returnsString},
+	{"cancelbroadcast", nil},
+	{"listunspentfiltered", []interface{}{(*btcjson.ListUnspentResult)(nil)}},
+	{"getdiagnostics", []interface{}{(*walletjson.GetDiagnosticsResult)(nil)}},
+	{"setaccountmetadata", nil},
+	{"getaccountmetadata", []interface{}{(*walletjson.GetAccountMetadataResult)(nil)}},
+	{"anchoraccountmetadata", returnsString},
+	{"bumpfee", []interface{}{(*walletjson.BumpFeeResult)(nil)}},
+	{"bumpfeecpfp", []interface{}{(*walletjson.BumpFeeCPFPResult)(nil)}},
+	{"getkeyusage", []interface{}{(*[]walletjson.GetKeyUsageResult)(nil)}},
+	{"queuepayment", returnsNumber},
+	{"getqueuedpayment", []interface{}{(*walletjson.QueuedPaymentResult)(nil)}},
+	{"listqueuedpayments", []interface{}{(*[]walletjson.QueuedPaymentResult)(nil)}},
+	{"cancelqueuedpayment", nil},
+	{"sendqueuedpayments", []interface{}{(*[]walletjson.PaymentBatchResult)(nil)}},
+	{"settransactionlabel", nil},
+	{"getspendingreport", []interface{}{(*walletjson.GetSpendingReportResult)(nil)}},
+	{"fundrawtransaction", []interface{}{(*walletjson.FundRawTransactionResult)(nil)}},
+	{"signrawtransactionwithwallet", []interface{}{(*btcjson.SignRawTransactionResult)(nil)}},
+	{"setchangepolicy", nil},
+	{"consolidateutxos", []interface{}{(*walletjson.ConsolidateUTXOsResult)(nil)}},
+	{"getrecoverystatus", []interface{}{(*walletjson.RecoveryStatusResult)(nil)}},
+	{"recoveryenterseed", []interface{}{(*walletjson.RecoveryStatusResult)(nil)}},
+	{"recoverychoosederivations", []interface{}{(*walletjson.RecoveryStatusResult)(nil)}},
+	{"recoverystartscan", []interface{}{(*walletjson.RecoveryStatusResult)(nil)}},
+	{"recoveryfinalize", []interface{}{(*walletjson.RecoveryStatusResult)(nil)}},
+	{"recoveryabort", []interface{}{(*walletjson.RecoveryStatusResult)(nil)}},
+	{"listquarantined", []interface{}{(*[]walletjson.ListQuarantinedResult)(nil)}},
+	{"spendquarantined", []interface{}{(*walletjson.SpendQuarantinedResult)(nil)}},
+	{"getconfighash", []interface{}{(*walletjson.GetConfigHashResult)(nil)}},
+	{"getdustpolicy", []interface{}{(*walletjson.GetDustPolicyResult)(nil)}},
+	{"freezeaccount", nil},
+	{"unfreezeaccount", nil},
+	{"listfrozenaccounts", []interface{}{(*[]walletjson.ListFrozenAccountsResult)(nil)}},
+	{"getderivationproof", []interface{}{(*walletjson.GetDerivationProofResult)(nil)}},
+	{"preparetransaction", []interface{}{(*walletjson.PrepareTransactionResult)(nil)}},
+	{"entermaintenance", []interface{}{(*walletjson.MaintenanceResult)(nil)}},
+	{"exitmaintenance", []interface{}{(*walletjson.MaintenanceResult)(nil)}},
+	{"getmaintenanceinfo", []interface{}{(*walletjson.MaintenanceResult)(nil)}},
+	{"acceleratetx", []interface{}{(*walletjson.AccelerationsResult)(nil)}},
+	{"listaccelerations", []interface{}{(*walletjson.AccelerationsResult)(nil)}},
+	{"evaluatepolicy", []interface{}{(*walletjson.EvaluatePolicyResult)(nil)}},
+	{"createpaymentreference", []interface{}{(*walletjson.PaymentReferenceResult)(nil)}},
+	{"lookuppaymentreference", []interface{}{(*walletjson.PaymentReferenceResult)(nil)}},
+	{"listpaymentreferences", []interface{}{(*[]walletjson.PaymentReferenceResult)(nil)}},
+	{"enrollbackuptoken", []interface{}{(*walletjson.BackupTokenResult)(nil)}},
+	{"listbackuptokens", []interface{}{(*[]walletjson.BackupTokenResult)(nil)}},
+	{"removebackuptoken", nil},
+	{"recoverbackup", nil},
+	{"addheighttrigger", []interface{}{(*walletjson.HeightTriggerResult)(nil)}},
+	{"listheighttriggers", []interface{}{(*[]walletjson.HeightTriggerResult)(nil)}},
+	{"removeheighttrigger", nil},
+	{"createapitoken", []interface{}{(*walletjson.APITokenResult)(nil)}},
+	{"listapitokens", []interface{}{(*[]walletjson.APITokenResult)(nil)}},
+	{"revokeapitoken", nil},
+	{"getrefillrequest", []interface{}{(*walletjson.RefillRequestResult)(nil)}},
 }
 
 // HelpDescs contains the locale-specific help strings along with the locale.
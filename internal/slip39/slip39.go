@@ -0,0 +1,496 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package slip39 implements SLIP-0039 Shamir backups, which split a master
+// secret into mnemonic shares so that any threshold of the shares restores
+// the secret while fewer shares reveal nothing about it.
+//
+// The master secret is first encrypted with an optional passphrase by a four
+// round Feistel network using PBKDF2-HMAC-SHA256, and the encrypted secret is
+// then split with Shamir's secret sharing over GF(256).  Shares of share sets
+// with several groups are combined, but Split only creates share sets of a
+// single group.
+package slip39
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	radix     = 1024
+	radixBits = 10
+
+	// customization is mixed into the checksum of the mnemonics and the
+	// salt of the encryption.  Shares of extendable share sets use their
+	// own checksum customization and no salt.
+	customization           = "shamir"
+	extendableCustomization = "shamir_extendable"
+
+	headerWords   = 4
+	checksumWords = 3
+
+	// MinSecretLen is the length in bytes of the shortest master secret.
+	// Master secrets also have an even length.
+	MinSecretLen = 16
+
+	// MaxShares is the largest number of shares of a group, and of groups
+	// of a share set.
+	MaxShares = 16
+
+	// iterationExponent is the iteration exponent of created shares.  The
+	// encryption takes 10000 << iterationExponent PBKDF2 iterations.
+	iterationExponent = 1
+	baseIterations    = 10000
+	rounds            = 4
+
+	digestLen   = 4
+	digestIndex = 254
+	secretIndex = 255
+)
+
+var (
+	// ErrChecksum is returned for mnemonics with an invalid checksum.
+	ErrChecksum = errors.New("invalid mnemonic checksum")
+
+	// ErrTooFewShares is returned when the shares do not meet the
+	// thresholds of their share set.
+	ErrTooFewShares = errors.New("too few shares to restore the secret")
+
+	// ErrDigest is returned when shares of a group or share set combine
+	// to a secret which fails its digest, which means that a share is
+	// corrupt or does not belong with the others.
+	ErrDigest = errors.New("shares do not restore a valid secret")
+)
+
+// Share is a share of a master secret.
+type Share struct {
+	// Identifier is the random identifier shared by the shares of a
+	// share set.
+	Identifier uint16
+
+	// Extendable reports whether the share set may be extended with
+	// shares of the same identifier.
+	Extendable bool
+
+	IterationExponent uint8
+	GroupIndex        uint8
+	GroupThreshold    uint8
+	GroupCount        uint8
+	MemberIndex       uint8
+	MemberThreshold   uint8
+
+	// Value is the share of the group secret.
+	Value []byte
+}
+
+var wordIndex = make(map[string]uint16, radix)
+
+// GF(256) exponent and logarithm tables of the generator x + 1 modulo the
+// Rijndael polynomial x^8 + x^4 + x^3 + x + 1.
+var (
+	expTable [255]byte
+	logTable [256]byte
+)
+
+func init() {
+	for i, word := range wordList {
+		wordIndex[word] = uint16(i)
+	}
+
+	poly := 1
+	for i := 0; i < 255; i++ {
+		expTable[i] = byte(poly)
+		logTable[poly] = byte(i)
+		poly = poly<<1 ^ poly
+		if poly&0x100 != 0 {
+			poly ^= 0x11b
+		}
+	}
+}
+
+// polymod returns the RS1024 checksum of values.
+func polymod(values []uint16) uint32 {
+	gen := [10]uint32{
+		0xe0e040, 0x1c1c080, 0x3838100, 0x7070200, 0xe0e0009,
+		0x1c0c2412, 0x38086c24, 0x3090fc48, 0x21b1f890, 0x3f3f120,
+	}
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xfffff)<<10 ^ uint32(v)
+		for i := uint(0); i < 10; i++ {
+			if b>>i&1 != 0 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func checksumValues(extendable bool, words []uint16) []uint16 {
+	custom := customization
+	if extendable {
+		custom = extendableCustomization
+	}
+	values := make([]uint16, 0, len(custom)+len(words)+checksumWords)
+	for i := 0; i < len(custom); i++ {
+		values = append(values, uint16(custom[i]))
+	}
+	return append(values, words...)
+}
+
+// Mnemonic returns the mnemonic of the share.
+func (s *Share) Mnemonic() string {
+	var ext uint32
+	if s.Extendable {
+		ext = 1
+	}
+	id := uint32(s.Identifier)<<5 | ext<<4 | uint32(s.IterationExponent)
+	params := uint32(s.GroupIndex)<<16 | uint32(s.GroupThreshold-1)<<12 |
+		uint32(s.GroupCount-1)<<8 | uint32(s.MemberIndex)<<4 |
+		uint32(s.MemberThreshold-1)
+	words := []uint16{
+		uint16(id >> 10), uint16(id & 1023),
+		uint16(params >> 10), uint16(params & 1023),
+	}
+
+	// The value is padded with leading zero bits to a whole number of
+	// words.
+	valueWords := (len(s.Value)*8 + radixBits - 1) / radixBits
+	acc, bits := uint32(0), uint(valueWords*radixBits-len(s.Value)*8)
+	for _, b := range s.Value {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= radixBits {
+			bits -= radixBits
+			words = append(words, uint16(acc>>bits&1023))
+			acc &= 1<<bits - 1
+		}
+	}
+
+	chk := polymod(append(checksumValues(s.Extendable, words), 0, 0, 0)) ^ 1
+	words = append(words, uint16(chk>>20&1023), uint16(chk>>10&1023),
+		uint16(chk&1023))
+
+	mnemonic := make([]string, len(words))
+	for i, w := range words {
+		mnemonic[i] = wordList[w]
+	}
+	return strings.Join(mnemonic, " ")
+}
+
+// ParseShare parses the mnemonic of a share.
+func ParseShare(mnemonic string) (*Share, error) {
+	fields := strings.Fields(strings.ToLower(mnemonic))
+	minWords := headerWords + checksumWords +
+		(MinSecretLen*8+radixBits-1)/radixBits
+	if len(fields) < minWords {
+		return nil, fmt.Errorf("mnemonic has %d words, at least %d are "+
+			"required", len(fields), minWords)
+	}
+	words := make([]uint16, len(fields))
+	for i, field := range fields {
+		w, ok := wordIndex[field]
+		if !ok {
+			return nil, fmt.Errorf("unknown mnemonic word %q", field)
+		}
+		words[i] = w
+	}
+
+	s := &Share{
+		Identifier:        uint16(uint32(words[0])<<5 | uint32(words[1])>>5),
+		Extendable:        words[1]>>4&1 == 1,
+		IterationExponent: uint8(words[1] & 15),
+	}
+	if polymod(checksumValues(s.Extendable, words)) != 1 {
+		return nil, ErrChecksum
+	}
+	params := uint32(words[2])<<10 | uint32(words[3])
+	s.GroupIndex = uint8(params >> 16)
+	s.GroupThreshold = uint8(params>>12&15) + 1
+	s.GroupCount = uint8(params>>8&15) + 1
+	s.MemberIndex = uint8(params >> 4 & 15)
+	s.MemberThreshold = uint8(params&15) + 1
+	if s.GroupThreshold > s.GroupCount {
+		return nil, fmt.Errorf("group threshold %d exceeds the group "+
+			"count %d", s.GroupThreshold, s.GroupCount)
+	}
+
+	valueWords := words[headerWords : len(words)-checksumWords]
+	padding := uint(len(valueWords) * radixBits % 16)
+	if padding > 8 {
+		return nil, errors.New("invalid mnemonic length")
+	}
+	acc, bits := uint32(0), uint(0)
+	for _, w := range valueWords {
+		acc = acc<<radixBits | uint32(w)
+		bits += radixBits
+		if padding > 0 && bits >= padding {
+			bits -= padding
+			if acc>>bits != 0 {
+				return nil, errors.New("invalid mnemonic padding")
+			}
+			padding = 0
+		}
+		for bits >= 8 {
+			bits -= 8
+			s.Value = append(s.Value, byte(acc>>bits))
+			acc &= 1<<bits - 1
+		}
+	}
+	return s, nil
+}
+
+// point is a share of a secret at x.
+type point struct {
+	x byte
+	y []byte
+}
+
+// interpolate returns the value at x of the polynomials through the points.
+func interpolate(points []point, x byte) []byte {
+	for _, p := range points {
+		if p.x == x {
+			return p.y
+		}
+	}
+
+	logProd := 0
+	for _, p := range points {
+		logProd += int(logTable[p.x^x])
+	}
+	result := make([]byte, len(points[0].y))
+	for i, p := range points {
+		logBasis := logProd - int(logTable[p.x^x])
+		for j, q := range points {
+			if j != i {
+				logBasis -= int(logTable[p.x^q.x])
+			}
+		}
+		logBasis = (logBasis%255 + 255) % 255
+		for k, y := range p.y {
+			if y != 0 {
+				result[k] ^= expTable[(int(logTable[y])+logBasis)%255]
+			}
+		}
+	}
+	return result
+}
+
+func digest(randomPart, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomPart)
+	mac.Write(secret)
+	return mac.Sum(nil)[:digestLen]
+}
+
+// splitSecret splits a secret into count shares, any threshold of which
+// restore the secret.
+func splitSecret(threshold, count int, secret []byte) ([][]byte, error) {
+	shares := make([][]byte, count)
+	if threshold == 1 {
+		for i := range shares {
+			shares[i] = append([]byte(nil), secret...)
+		}
+		return shares, nil
+	}
+
+	// The polynomials are fixed by threshold-2 random shares, the digest
+	// share and the secret.
+	points := make([]point, 0, threshold)
+	for i := 0; i < threshold-2; i++ {
+		shares[i] = make([]byte, len(secret))
+		if _, err := rand.Read(shares[i]); err != nil {
+			return nil, err
+		}
+		points = append(points, point{byte(i), shares[i]})
+	}
+	randomPart := make([]byte, len(secret)-digestLen)
+	if _, err := rand.Read(randomPart); err != nil {
+		return nil, err
+	}
+	points = append(points,
+		point{digestIndex, append(digest(randomPart, secret), randomPart...)},
+		point{secretIndex, secret})
+	for i := threshold - 2; i < count; i++ {
+		shares[i] = interpolate(points, byte(i))
+	}
+	return shares, nil
+}
+
+// recoverSecret restores the secret of threshold points and checks its
+// digest.
+func recoverSecret(threshold int, points []point) ([]byte, error) {
+	if threshold == 1 {
+		return points[0].y, nil
+	}
+	points = points[:threshold]
+	secret := interpolate(points, secretIndex)
+	d := interpolate(points, digestIndex)
+	if !hmac.Equal(d[:digestLen], digest(d[digestLen:], secret)) {
+		return nil, ErrDigest
+	}
+	return secret, nil
+}
+
+// feistel encrypts, or decrypts when decrypt is set, a master secret with the
+// passphrase.
+func feistel(secret, passphrase []byte, id uint16, extendable bool,
+	exp uint8, decrypt bool) []byte {
+
+	half := len(secret) / 2
+	l := append([]byte(nil), secret[:half]...)
+	r := append([]byte(nil), secret[half:]...)
+	iterations := baseIterations / rounds << exp
+	for i := 0; i < rounds; i++ {
+		round := i
+		if decrypt {
+			round = rounds - 1 - i
+		}
+		password := append([]byte{byte(round)}, passphrase...)
+		var salt []byte
+		if !extendable {
+			salt = append([]byte(customization), byte(id>>8), byte(id))
+		}
+		f := pbkdf2.Key(password, append(salt, r...), iterations, half,
+			sha256.New)
+		for j := range l {
+			l[j] ^= f[j]
+		}
+		l, r = r, l
+	}
+	return append(r, l...)
+}
+
+func checkPassphrase(passphrase []byte) error {
+	for _, c := range passphrase {
+		if c < 32 || c > 126 {
+			return errors.New("passphrase must consist of printable " +
+				"ASCII characters")
+		}
+	}
+	return nil
+}
+
+// Split encrypts a master secret with a passphrase, which may be empty, and
+// splits it into count shares of a single group, any threshold of which
+// restore the secret.
+func Split(secret, passphrase []byte, threshold, count int) ([]*Share, error) {
+	if len(secret) < MinSecretLen || len(secret)%2 != 0 {
+		return nil, fmt.Errorf("master secret must have an even length "+
+			"of at least %d bytes", MinSecretLen)
+	}
+	if count < 1 || count > MaxShares {
+		return nil, fmt.Errorf("share count must be between 1 and %d",
+			MaxShares)
+	}
+	if threshold < 1 || threshold > count {
+		return nil, fmt.Errorf("threshold must be between 1 and the "+
+			"share count %d", count)
+	}
+	if threshold == 1 && count > 1 {
+		return nil, errors.New("a threshold of 1 requires a single share")
+	}
+	if err := checkPassphrase(passphrase); err != nil {
+		return nil, err
+	}
+
+	var b [2]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	id := (uint16(b[0])<<8 | uint16(b[1])) & (1<<15 - 1)
+
+	encrypted := feistel(secret, passphrase, id, false, iterationExponent,
+		false)
+	values, err := splitSecret(threshold, count, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	shares := make([]*Share, count)
+	for i, value := range values {
+		shares[i] = &Share{
+			Identifier:        id,
+			IterationExponent: iterationExponent,
+			GroupThreshold:    1,
+			GroupCount:        1,
+			MemberIndex:       uint8(i),
+			MemberThreshold:   uint8(threshold),
+			Value:             value,
+		}
+	}
+	return shares, nil
+}
+
+// Combine restores the master secret of shares and decrypts it with the
+// passphrase.  ErrTooFewShares is returned when the shares do not meet the
+// thresholds of their share set, so that callers may ask for more shares.
+func Combine(shares []*Share, passphrase []byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, ErrTooFewShares
+	}
+	if err := checkPassphrase(passphrase); err != nil {
+		return nil, err
+	}
+
+	first := shares[0]
+	groups := make(map[uint8][]point)
+	thresholds := make(map[uint8]uint8)
+	for _, s := range shares {
+		if s.Identifier != first.Identifier ||
+			s.Extendable != first.Extendable ||
+			s.IterationExponent != first.IterationExponent ||
+			s.GroupThreshold != first.GroupThreshold ||
+			s.GroupCount != first.GroupCount ||
+			len(s.Value) != len(first.Value) {
+
+			return nil, errors.New("shares belong to different " +
+				"share sets")
+		}
+		if t, ok := thresholds[s.GroupIndex]; ok && t != s.MemberThreshold {
+			return nil, fmt.Errorf("shares of group %d have different "+
+				"thresholds", s.GroupIndex)
+		}
+		thresholds[s.GroupIndex] = s.MemberThreshold
+		for _, p := range groups[s.GroupIndex] {
+			if p.x == s.MemberIndex {
+				return nil, fmt.Errorf("duplicate share %d of "+
+					"group %d", s.MemberIndex, s.GroupIndex)
+			}
+		}
+		groups[s.GroupIndex] = append(groups[s.GroupIndex],
+			point{s.MemberIndex, s.Value})
+	}
+
+	var groupSecrets []point
+	for index, points := range groups {
+		threshold := int(thresholds[index])
+		if len(points) < threshold {
+			continue
+		}
+		secret, err := recoverSecret(threshold, points)
+		if err != nil {
+			return nil, err
+		}
+		groupSecrets = append(groupSecrets, point{index, secret})
+		if len(groupSecrets) == int(first.GroupThreshold) {
+			break
+		}
+	}
+	if len(groupSecrets) < int(first.GroupThreshold) {
+		return nil, ErrTooFewShares
+	}
+	encrypted, err := recoverSecret(int(first.GroupThreshold), groupSecrets)
+	if err != nil {
+		return nil, err
+	}
+	return feistel(encrypted, passphrase, first.Identifier,
+		first.Extendable, first.IterationExponent, true), nil
+}
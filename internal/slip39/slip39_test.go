@@ -0,0 +1,144 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package slip39
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestCombineVectors checks that share sets of the SLIP-0039 test vectors
+// restore their master secrets with the passphrase "TREZOR".
+func TestCombineVectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		mnemonics []string
+		secret    string
+	}{
+		{
+			name: "1-of-1",
+			mnemonics: []string{
+				"duckling enlarge academic academic agency result " +
+					"length solution fridge kidney coal piece deal " +
+					"husband erode duke ajar critical decision keyboard",
+			},
+			secret: "bb54aac4b89dc868ba37d9cc21b2cece",
+		},
+		{
+			name: "2-of-3",
+			mnemonics: []string{
+				"shadow pistol academic always adequate wildlife " +
+					"fancy gross oasis cylinder mustang wrist rescue " +
+					"view short owner flip making coding armed",
+				"shadow pistol academic acid actress prayer class " +
+					"unknown daughter sweater depict flip twice unkind " +
+					"craft early superior advocate guest smoking",
+			},
+			secret: "b43ceb7e57a0ea8766221624d01b0864",
+		},
+	}
+	for _, test := range tests {
+		var shares []*Share
+		for _, mnemonic := range test.mnemonics {
+			s, err := ParseShare(mnemonic)
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			if s.Mnemonic() != mnemonic {
+				t.Errorf("%s: mnemonic %q encoded as %q", test.name,
+					mnemonic, s.Mnemonic())
+			}
+			shares = append(shares, s)
+		}
+		secret, err := Combine(shares, []byte("TREZOR"))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if hex.EncodeToString(secret) != test.secret {
+			t.Errorf("%s: secret %x, want %s", test.name, secret,
+				test.secret)
+		}
+	}
+}
+
+// TestParseShareErrors checks that mnemonics with a wrong word are rejected.
+func TestParseShareErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+	}{
+		{"checksum", "duckling enlarge academic academic agency result " +
+			"length solution fridge kidney coal piece deal husband " +
+			"erode duke ajar critical decision kidney"},
+		{"padding", "duckling enlarge academic academic email result " +
+			"length solution fridge kidney coal piece deal husband " +
+			"erode duke ajar music cargo fitness"},
+		{"unknown word", "duckling enlarge academic academic agency " +
+			"result length solution fridge kidney coal piece deal " +
+			"husband erode duke ajar critical decision keyboards"},
+		{"short", "duckling enlarge academic academic agency result"},
+	}
+	for _, test := range tests {
+		if _, err := ParseShare(test.mnemonic); err == nil {
+			t.Errorf("mnemonic with invalid %s was parsed", test.name)
+		}
+	}
+}
+
+// TestSplitCombine checks that any threshold of split shares restores the
+// secret and that fewer shares do not.
+func TestSplitCombine(t *testing.T) {
+	secret := make([]byte, 32)
+	for i := range secret {
+		secret[i] = byte(i)
+	}
+	passphrase := []byte("passphrase")
+	shares, err := Split(secret, passphrase, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("split returned %d shares, want 5", len(shares))
+	}
+
+	parsed := make([]*Share, len(shares))
+	for i, s := range shares {
+		parsed[i], err = ParseShare(s.Mnemonic())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, set := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4, 0}} {
+		var subset []*Share
+		for _, i := range set {
+			subset = append(subset, parsed[i])
+		}
+		restored, err := Combine(subset, passphrase)
+		if err != nil {
+			t.Errorf("shares %v: %v", set, err)
+			continue
+		}
+		if !bytes.Equal(restored, secret) {
+			t.Errorf("shares %v restored %x", set, restored)
+		}
+	}
+
+	if _, err := Combine(parsed[:2], passphrase); err != ErrTooFewShares {
+		t.Errorf("two of three shares returned %v", err)
+	}
+	if _, err := Combine([]*Share{parsed[0], parsed[0], parsed[1]},
+		passphrase); err == nil {
+		t.Error("duplicate shares were combined")
+	}
+
+	if _, err := Split(secret[:15], nil, 2, 3); err == nil {
+		t.Error("short secret was split")
+	}
+	if _, err := Split(secret, nil, 1, 3); err == nil {
+		t.Error("threshold of 1 was split into several shares")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package taproot
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+)
+
+// ErrInvalidOutputKey is returned when a taproot output key is not 32 bytes.
+var ErrInvalidOutputKey = errors.New("taproot output key must be 32 bytes")
+
+// AddressTaproot is a pay-to-taproot (segwit version 1) address.  It
+// implements the btcutil.Address interface, encoding as bech32m.
+type AddressTaproot struct {
+	hrp       string
+	outputKey [32]byte
+}
+
+// NewAddressTaproot returns a new AddressTaproot paying to the x-only output
+// key.
+func NewAddressTaproot(outputKey []byte, net *chaincfg.Params) (*AddressTaproot, error) {
+	if len(outputKey) != 32 {
+		return nil, ErrInvalidOutputKey
+	}
+	addr := &AddressTaproot{hrp: strings.ToLower(net.Bech32HRPSegwit)}
+	copy(addr.outputKey[:], outputKey)
+	return addr, nil
+}
+
+// NewAddressTaprootFromPubKey returns the BIP0086 taproot address for an
+// internal public key with no script tree.
+func NewAddressTaprootFromPubKey(internalKey *btcec.PublicKey, net *chaincfg.Params) (*AddressTaproot, error) {
+	outputKey, err := ComputeOutputKey(internalKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewAddressTaproot(outputKey, net)
+}
+
+// EncodeAddress returns the bech32m string encoding of the address.
+//
+// This is part of the btcutil.Address interface implementation.
+func (a *AddressTaproot) EncodeAddress() string {
+	str, err := encodeSegWitV1Address(a.hrp, a.outputKey[:])
+	if err != nil {
+		return ""
+	}
+	return str
+}
+
+// ScriptAddress returns the witness program (output key) of the address.
+//
+// This is part of the btcutil.Address interface implementation.
+func (a *AddressTaproot) ScriptAddress() []byte {
+	return a.outputKey[:]
+}
+
+// IsForNet returns whether or not the address is associated with the passed
+// network.
+//
+// This is part of the btcutil.Address interface implementation.
+func (a *AddressTaproot) IsForNet(net *chaincfg.Params) bool {
+	return a.hrp == net.Bech32HRPSegwit
+}
+
+// String returns the bech32m encoding of the address.
+//
+// This is part of the btcutil.Address interface implementation.
+func (a *AddressTaproot) String() string {
+	return a.EncodeAddress()
+}
+
+// OutputKey returns the x-only output key of the address.
+func (a *AddressTaproot) OutputKey() []byte {
+	return a.outputKey[:]
+}
+
+// DecodeAddress decodes a string address, additionally recognizing bech32m
+// taproot addresses that btcutil.DecodeAddress does not understand.
+func DecodeAddress(addr string, defaultNet *chaincfg.Params) (btcutil.Address, error) {
+	hrp, program, err := decodeSegWitV1Address(addr)
+	if err == nil {
+		if hrp != defaultNet.Bech32HRPSegwit {
+			return nil, btcutil.ErrUnknownAddressType
+		}
+		return NewAddressTaproot(program, defaultNet)
+	}
+	return btcutil.DecodeAddress(addr, defaultNet)
+}
+
+// IsPayToTaproot returns whether the script is a segwit version 1 output
+// paying to a 32 byte output key.
+func IsPayToTaproot(pkScript []byte) bool {
+	return len(pkScript) == 34 && pkScript[0] == txscript.OP_1 &&
+		pkScript[1] == txscript.OP_DATA_32
+}
+
+// PayToTaprootScript returns the output script paying to the x-only output
+// key.
+func PayToTaprootScript(outputKey []byte) ([]byte, error) {
+	if len(outputKey) != 32 {
+		return nil, ErrInvalidOutputKey
+	}
+	return txscript.NewScriptBuilder().AddOp(txscript.OP_1).
+		AddData(outputKey).Script()
+}
+
+// PayToAddrScript creates a script paying to the address, handling taproot
+// addresses before deferring to txscript.PayToAddrScript.
+func PayToAddrScript(addr btcutil.Address) ([]byte, error) {
+	if a, ok := addr.(*AddressTaproot); ok {
+		return PayToTaprootScript(a.outputKey[:])
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+// ExtractPkScriptAddrs behaves like txscript.ExtractPkScriptAddrs, but also
+// extracts the address of a pay-to-taproot script.  Taproot scripts are
+// reported with the txscript.NonStandardTy class since the script engine
+// does not know about them.
+func ExtractPkScriptAddrs(pkScript []byte, chainParams *chaincfg.Params) (txscript.ScriptClass, []btcutil.Address, int, error) {
+	if IsPayToTaproot(pkScript) {
+		addr, err := NewAddressTaproot(pkScript[2:], chainParams)
+		if err != nil {
+			return txscript.NonStandardTy, nil, 0, err
+		}
+		return txscript.NonStandardTy, []btcutil.Address{addr}, 1, nil
+	}
+	return txscript.ExtractPkScriptAddrs(pkScript, chainParams)
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package taproot
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/btcsuite/btcutil/bech32"
+)
+
+// bech32mConst is the checksum constant of the BIP0350 bech32m encoding.
+const bech32mConst = 0x2bc830a3
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var gen = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+// Errors returned when decoding bech32m strings.
+var (
+	ErrInvalidBech32m  = errors.New("invalid bech32m string")
+	ErrInvalidChecksum = errors.New("invalid bech32m checksum")
+)
+
+func polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func hrpExpand(hrp string) []byte {
+	v := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		v = append(v, hrp[i]>>5)
+	}
+	v = append(v, 0)
+	for i := 0; i < len(hrp); i++ {
+		v = append(v, hrp[i]&31)
+	}
+	return v
+}
+
+// encodeBech32m encodes 5-bit groups with the human readable part using the
+// bech32m checksum.
+func encodeBech32m(hrp string, data []byte) string {
+	values := append(hrpExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := polymod(values) ^ bech32mConst
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, d := range data {
+		sb.WriteByte(charset[d])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(charset[(mod>>uint(5*(5-i)))&31])
+	}
+	return sb.String()
+}
+
+// decodeBech32m decodes a bech32m string into its human readable part and
+// 5-bit data groups, excluding the checksum.
+func decodeBech32m(s string) (string, []byte, error) {
+	if len(s) < 8 || len(s) > 90 {
+		return "", nil, ErrInvalidBech32m
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, ErrInvalidBech32m
+	}
+	s = strings.ToLower(s)
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", nil, ErrInvalidBech32m
+	}
+	hrp := s[:sep]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, ErrInvalidBech32m
+		}
+	}
+	data := make([]byte, 0, len(s)-sep-1)
+	for i := sep + 1; i < len(s); i++ {
+		d := strings.IndexByte(charset, s[i])
+		if d < 0 {
+			return "", nil, ErrInvalidBech32m
+		}
+		data = append(data, byte(d))
+	}
+	if polymod(append(hrpExpand(hrp), data...)) != bech32mConst {
+		return "", nil, ErrInvalidChecksum
+	}
+	return hrp, data[:len(data)-6], nil
+}
+
+// ChecksumVariant is the encoding variant of the checksum of a bech32 string.
+type ChecksumVariant int
+
+// Checksum variants of bech32 strings.
+const (
+	// ChecksumInvalid is the variant of strings whose checksum is neither
+	// a bech32 nor a bech32m checksum.
+	ChecksumInvalid ChecksumVariant = iota
+
+	// ChecksumBech32 is the BIP0173 checksum of segwit version 0
+	// addresses.
+	ChecksumBech32
+
+	// ChecksumBech32m is the BIP0350 checksum of segwit version 1 and
+	// later addresses.
+	ChecksumBech32m
+)
+
+// Bech32Checksum returns the variant of the checksum of a bech32 string, in
+// lower or upper case.  ErrInvalidBech32m is returned when the string is not
+// made of a human readable part, a separator and at least six characters of
+// the bech32 charset.
+func Bech32Checksum(s string) (ChecksumVariant, error) {
+	s = strings.ToLower(s)
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return ChecksumInvalid, ErrInvalidBech32m
+	}
+	values := hrpExpand(s[:sep])
+	for i := sep + 1; i < len(s); i++ {
+		d := strings.IndexByte(charset, s[i])
+		if d < 0 {
+			return ChecksumInvalid, ErrInvalidBech32m
+		}
+		values = append(values, byte(d))
+	}
+	switch polymod(values) {
+	case 1:
+		return ChecksumBech32, nil
+	case bech32mConst:
+		return ChecksumBech32m, nil
+	default:
+		return ChecksumInvalid, nil
+	}
+}
+
+// encodeSegWitV1Address encodes a version 1 witness program as a bech32m
+// address.
+func encodeSegWitV1Address(hrp string, program []byte) (string, error) {
+	conv, err := bech32.ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return encodeBech32m(hrp, append([]byte{1}, conv...)), nil
+}
+
+// decodeSegWitV1Address decodes a bech32m address, returning the human
+// readable part and the version 1 witness program.
+func decodeSegWitV1Address(addr string) (string, []byte, error) {
+	hrp, data, err := decodeBech32m(addr)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(data) < 1 || data[0] != 1 {
+		return "", nil, ErrInvalidBech32m
+	}
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(program) != 32 {
+		return "", nil, ErrInvalidBech32m
+	}
+	return hrp, program, nil
+}
@@ -0,0 +1,15 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package taproot implements the pieces of BIP0340, BIP0341, BIP0350 and BIP0086
+needed by the wallet to receive to and spend from pay-to-taproot outputs using
+the key path: schnorr signatures, the taproot key tweak, bech32m address
+encoding and the taproot signature hash.
+
+Script path spends are not supported.  The txscript engine vendored by the
+wallet predates taproot, so scripts spending version 1 witness programs must
+not be passed to it for validation.
+*/
+package taproot
@@ -0,0 +1,174 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package taproot
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// SignatureSize is the size of a BIP0340 schnorr signature.
+const SignatureSize = 64
+
+// Errors returned by the schnorr signing and key parsing functions.
+var (
+	ErrInvalidPrivKey = errors.New("invalid private key")
+	ErrInvalidPubKey  = errors.New("invalid x-only public key")
+	ErrInvalidHash    = errors.New("message hash must be 32 bytes")
+	ErrZeroNonce      = errors.New("schnorr nonce is zero")
+)
+
+var curve = btcec.S256()
+
+// TaggedHash implements the BIP0340 tagged hash construction
+// SHA256(SHA256(tag) || SHA256(tag) || msgs...).
+func TaggedHash(tag string, msgs ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, msg := range msgs {
+		h.Write(msg)
+	}
+	return h.Sum(nil)
+}
+
+// SerializeXOnly returns the 32 byte x-only encoding of a public key.
+func SerializeXOnly(pubKey *btcec.PublicKey) []byte {
+	return padScalar(pubKey.X)
+}
+
+// ParseXOnly parses a 32 byte x-only public key, returning the point with an
+// even Y coordinate as described by BIP0340.
+func ParseXOnly(xOnly []byte) (*btcec.PublicKey, error) {
+	if len(xOnly) != 32 {
+		return nil, ErrInvalidPubKey
+	}
+	x := new(big.Int).SetBytes(xOnly)
+	y, ok := liftX(x)
+	if !ok {
+		return nil, ErrInvalidPubKey
+	}
+	return &btcec.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// Sign creates a BIP0340 schnorr signature of the 32 byte hash using the
+// private key.  Fresh auxiliary randomness is read from crypto/rand.
+func Sign(privKey *btcec.PrivateKey, hash []byte) ([]byte, error) {
+	var aux [32]byte
+	if _, err := rand.Read(aux[:]); err != nil {
+		return nil, err
+	}
+	return signWithAux(privKey, hash, aux[:])
+}
+
+func signWithAux(privKey *btcec.PrivateKey, hash, aux []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, ErrInvalidHash
+	}
+	n := curve.N
+	d := new(big.Int).Set(privKey.D)
+	if d.Sign() == 0 || d.Cmp(n) >= 0 {
+		return nil, ErrInvalidPrivKey
+	}
+	px, py := curve.ScalarBaseMult(padScalar(d))
+	if py.Bit(0) != 0 {
+		d.Sub(n, d)
+	}
+	pBytes := padScalar(px)
+
+	t := padScalar(d)
+	auxHash := TaggedHash("BIP0340/aux", aux)
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+	nonce := TaggedHash("BIP0340/nonce", t, pBytes, hash)
+	k := new(big.Int).SetBytes(nonce)
+	k.Mod(k, n)
+	if k.Sign() == 0 {
+		return nil, ErrZeroNonce
+	}
+	rx, ry := curve.ScalarBaseMult(padScalar(k))
+	if ry.Bit(0) != 0 {
+		k.Sub(n, k)
+	}
+	rBytes := padScalar(rx)
+
+	e := challenge(rBytes, pBytes, hash)
+	s := new(big.Int).Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, n)
+
+	sig := make([]byte, 0, SignatureSize)
+	sig = append(sig, rBytes...)
+	sig = append(sig, padScalar(s)...)
+	return sig, nil
+}
+
+// Verify reports whether sig is a valid BIP0340 signature of hash by the
+// x-only public key.
+func Verify(xOnly, hash, sig []byte) bool {
+	if len(hash) != 32 || len(sig) != SignatureSize {
+		return false
+	}
+	pubKey, err := ParseXOnly(xOnly)
+	if err != nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(curve.P) >= 0 || s.Cmp(curve.N) >= 0 {
+		return false
+	}
+	e := challenge(sig[:32], xOnly, hash)
+
+	// R = s*G - e*P
+	sgx, sgy := curve.ScalarBaseMult(padScalar(s))
+	epx, epy := curve.ScalarMult(pubKey.X, pubKey.Y, padScalar(e))
+	epy.Sub(curve.P, epy)
+	rx, ry := curve.Add(sgx, sgy, epx, epy)
+	if rx.Sign() == 0 && ry.Sign() == 0 {
+		return false
+	}
+	return ry.Bit(0) == 0 && rx.Cmp(r) == 0
+}
+
+func challenge(r, p, hash []byte) *big.Int {
+	e := new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", r, p, hash))
+	return e.Mod(e, curve.N)
+}
+
+// liftX returns the even Y coordinate for the X coordinate, if the point is
+// on the curve.
+func liftX(x *big.Int) (*big.Int, bool) {
+	p := curve.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 {
+		return nil, false
+	}
+	c := new(big.Int).Mul(x, x)
+	c.Mul(c, x)
+	c.Add(c, big.NewInt(7))
+	c.Mod(c, p)
+	y := new(big.Int).Exp(c, curve.QPlus1Div4(), p)
+	if new(big.Int).Exp(y, big.NewInt(2), p).Cmp(c) != 0 {
+		return nil, false
+	}
+	if y.Bit(0) != 0 {
+		y.Sub(p, y)
+	}
+	return y, true
+}
+
+// padScalar serializes a big integer as a 32 byte big endian value.
+func padScalar(v *big.Int) []byte {
+	b := make([]byte, 32)
+	vb := v.Bytes()
+	copy(b[32-len(vb):], vb)
+	return b
+}
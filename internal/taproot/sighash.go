@@ -0,0 +1,172 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package taproot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// SigHashDefault is the BIP0341 default signature hash type.  It commits to
+// the same data as txscript.SigHashAll, but is encoded by omitting the hash
+// type byte from the signature.
+const SigHashDefault txscript.SigHashType = 0x00
+
+// Errors returned when computing the taproot signature hash.
+var (
+	ErrInvalidHashType   = errors.New("invalid taproot signature hash type")
+	ErrInputIndex        = errors.New("input index out of range")
+	ErrPrevOutputsLength = errors.New("previous outputs do not match inputs")
+	ErrNoSingleOutput    = errors.New("no output for SIGHASH_SINGLE input")
+)
+
+// PrevOutput describes an output spent by a transaction input.  The amounts
+// and scripts of every spent output are committed to by the signature hash.
+type PrevOutput struct {
+	Value    int64
+	PkScript []byte
+}
+
+// CalcSignatureHash computes the BIP0341 signature hash for the key path
+// spend of input idx.  prevOuts must describe the outputs spent by every
+// input of the transaction, in order.
+func CalcSignatureHash(tx *wire.MsgTx, idx int, hashType txscript.SigHashType,
+	prevOuts []PrevOutput) ([]byte, error) {
+
+	switch hashType & 0x7f {
+	case SigHashDefault:
+		if hashType != SigHashDefault {
+			return nil, ErrInvalidHashType
+		}
+	case txscript.SigHashAll, txscript.SigHashNone, txscript.SigHashSingle:
+	default:
+		return nil, ErrInvalidHashType
+	}
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, ErrInputIndex
+	}
+	if len(prevOuts) != len(tx.TxIn) {
+		return nil, ErrPrevOutputsLength
+	}
+
+	anyoneCanPay := hashType&txscript.SigHashAnyOneCanPay != 0
+	outputType := hashType & 0x03
+	if hashType == SigHashDefault {
+		outputType = txscript.SigHashAll
+	}
+
+	var msg bytes.Buffer
+	var scratch [8]byte
+
+	// Epoch, hash type and transaction data.
+	msg.WriteByte(0x00)
+	msg.WriteByte(byte(hashType))
+	binary.LittleEndian.PutUint32(scratch[:4], uint32(tx.Version))
+	msg.Write(scratch[:4])
+	binary.LittleEndian.PutUint32(scratch[:4], tx.LockTime)
+	msg.Write(scratch[:4])
+
+	if !anyoneCanPay {
+		var prevouts, amounts, scripts, sequences bytes.Buffer
+		for i, txIn := range tx.TxIn {
+			prevouts.Write(txIn.PreviousOutPoint.Hash[:])
+			binary.LittleEndian.PutUint32(scratch[:4], txIn.PreviousOutPoint.Index)
+			prevouts.Write(scratch[:4])
+
+			binary.LittleEndian.PutUint64(scratch[:], uint64(prevOuts[i].Value))
+			amounts.Write(scratch[:])
+
+			err := wire.WriteVarBytes(&scripts, 0, prevOuts[i].PkScript)
+			if err != nil {
+				return nil, err
+			}
+
+			binary.LittleEndian.PutUint32(scratch[:4], txIn.Sequence)
+			sequences.Write(scratch[:4])
+		}
+		writeSHA256(&msg, prevouts.Bytes())
+		writeSHA256(&msg, amounts.Bytes())
+		writeSHA256(&msg, scripts.Bytes())
+		writeSHA256(&msg, sequences.Bytes())
+	}
+
+	if outputType == txscript.SigHashAll {
+		var outputs bytes.Buffer
+		for _, txOut := range tx.TxOut {
+			if err := wire.WriteTxOut(&outputs, 0, 0, txOut); err != nil {
+				return nil, err
+			}
+		}
+		writeSHA256(&msg, outputs.Bytes())
+	}
+
+	// Spend type: key path, no annex.
+	msg.WriteByte(0x00)
+
+	if anyoneCanPay {
+		txIn := tx.TxIn[idx]
+		msg.Write(txIn.PreviousOutPoint.Hash[:])
+		binary.LittleEndian.PutUint32(scratch[:4], txIn.PreviousOutPoint.Index)
+		msg.Write(scratch[:4])
+		binary.LittleEndian.PutUint64(scratch[:], uint64(prevOuts[idx].Value))
+		msg.Write(scratch[:])
+		err := wire.WriteVarBytes(&msg, 0, prevOuts[idx].PkScript)
+		if err != nil {
+			return nil, err
+		}
+		binary.LittleEndian.PutUint32(scratch[:4], txIn.Sequence)
+		msg.Write(scratch[:4])
+	} else {
+		binary.LittleEndian.PutUint32(scratch[:4], uint32(idx))
+		msg.Write(scratch[:4])
+	}
+
+	if outputType == txscript.SigHashSingle {
+		if idx >= len(tx.TxOut) {
+			return nil, ErrNoSingleOutput
+		}
+		var output bytes.Buffer
+		if err := wire.WriteTxOut(&output, 0, 0, tx.TxOut[idx]); err != nil {
+			return nil, err
+		}
+		writeSHA256(&msg, output.Bytes())
+	}
+
+	return TaggedHash("TapSighash", msg.Bytes()), nil
+}
+
+// KeyPathWitness signs input idx of the transaction with the tweaked
+// internal private key and returns the key path spend witness.
+func KeyPathWitness(tx *wire.MsgTx, idx int, prevOuts []PrevOutput,
+	hashType txscript.SigHashType, internalKey *btcec.PrivateKey) (wire.TxWitness, error) {
+
+	hash, err := CalcSignatureHash(tx, idx, hashType, prevOuts)
+	if err != nil {
+		return nil, err
+	}
+	outputKey, err := TweakPrivKey(internalKey)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := Sign(outputKey, hash)
+	if err != nil {
+		return nil, err
+	}
+	if hashType != SigHashDefault {
+		sig = append(sig, byte(hashType))
+	}
+	return wire.TxWitness{sig}, nil
+}
+
+func writeSHA256(w *bytes.Buffer, data []byte) {
+	h := sha256.Sum256(data)
+	w.Write(h[:])
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package taproot
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func hexToBytes(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("bad hex %q: %v", s, err)
+	}
+	return b
+}
+
+// TestSchnorrVectors checks signing and verification against the BIP0340
+// test vectors.
+func TestSchnorrVectors(t *testing.T) {
+	tests := []struct {
+		secKey string
+		pubKey string
+		aux    string
+		msg    string
+		sig    string
+	}{
+		{
+			secKey: "0000000000000000000000000000000000000000000000000000000000000003",
+			pubKey: "F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+			aux:    "0000000000000000000000000000000000000000000000000000000000000000",
+			msg:    "0000000000000000000000000000000000000000000000000000000000000000",
+			sig:    "E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0",
+		},
+		{
+			secKey: "B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF",
+			pubKey: "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			aux:    "0000000000000000000000000000000000000000000000000000000000000001",
+			msg:    "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			sig:    "6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+		},
+	}
+
+	for i, test := range tests {
+		privKey, pubKey := btcec.PrivKeyFromBytes(btcec.S256(),
+			hexToBytes(t, test.secKey))
+		wantPub := hexToBytes(t, test.pubKey)
+		if !bytes.Equal(SerializeXOnly(pubKey), wantPub) {
+			t.Errorf("test %d: pubkey mismatch", i)
+		}
+		msg := hexToBytes(t, test.msg)
+		sig, err := signWithAux(privKey, msg, hexToBytes(t, test.aux))
+		if err != nil {
+			t.Fatalf("test %d: sign: %v", i, err)
+		}
+		wantSig := hexToBytes(t, test.sig)
+		if !bytes.Equal(sig, wantSig) {
+			t.Errorf("test %d: signature %x, want %x", i, sig, wantSig)
+		}
+		if !Verify(wantPub, msg, wantSig) {
+			t.Errorf("test %d: valid signature failed to verify", i)
+		}
+		wantSig[0] ^= 1
+		if Verify(wantPub, msg, wantSig) {
+			t.Errorf("test %d: invalid signature verified", i)
+		}
+	}
+}
+
+// TestBIP0086Address checks the output key and address of the first BIP0086
+// test vector receiving address.
+func TestBIP0086Address(t *testing.T) {
+	internalKey, err := ParseXOnly(hexToBytes(t,
+		"cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := NewAddressTaprootFromPubKey(internalKey, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantKey := "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c"
+	if hex.EncodeToString(addr.OutputKey()) != wantKey {
+		t.Errorf("output key %x, want %s", addr.OutputKey(), wantKey)
+	}
+	wantAddr := "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"
+	if addr.EncodeAddress() != wantAddr {
+		t.Errorf("address %s, want %s", addr.EncodeAddress(), wantAddr)
+	}
+
+	decoded, err := DecodeAddress(wantAddr, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !bytes.Equal(decoded.ScriptAddress(), addr.OutputKey()) {
+		t.Errorf("decoded address does not match")
+	}
+	if _, err := DecodeAddress(wantAddr, &chaincfg.TestNet3Params); err == nil {
+		t.Errorf("decoded mainnet address on testnet")
+	}
+
+	// A bech32 (not bech32m) checksum must be rejected.
+	if _, _, err := decodeSegWitV1Address(
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"); err == nil {
+		t.Errorf("decoded bech32 address as bech32m")
+	}
+}
+
+// TestKeyPathSpend checks that a key path witness verifies against the
+// output key for every signature hash type.
+func TestKeyPathSpend(t *testing.T) {
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{0x42}, 32))
+	addr, err := NewAddressTaprootFromPubKey(privKey.PubKey(), &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsPayToTaproot(pkScript) {
+		t.Fatalf("script %x is not pay-to-taproot", pkScript)
+	}
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 1), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, pkScript))
+	prevOuts := []PrevOutput{{Value: 2000, PkScript: pkScript}, {Value: 3000, PkScript: pkScript}}
+
+	hashTypes := []byte{0x00, 0x01, 0x02, 0x03, 0x81, 0x82, 0x83}
+	for _, ht := range hashTypes {
+		witness, err := KeyPathWitness(tx, 0, prevOuts, txscript.SigHashType(ht), privKey)
+		if err != nil {
+			t.Fatalf("hash type %x: %v", ht, err)
+		}
+		sig := witness[0]
+		if ht == 0 && len(sig) != SignatureSize {
+			t.Errorf("default hash type signature has length %d", len(sig))
+		}
+		hash, err := CalcSignatureHash(tx, 0, txscript.SigHashType(ht), prevOuts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Verify(addr.OutputKey(), hash, sig[:SignatureSize]) {
+			t.Errorf("hash type %x: signature does not verify", ht)
+		}
+	}
+
+	if _, err := CalcSignatureHash(tx, 1, txscript.SigHashType(0x03), prevOuts); err != ErrNoSingleOutput {
+		t.Errorf("SIGHASH_SINGLE without output: got %v", err)
+	}
+	if _, err := CalcSignatureHash(tx, 0, txscript.SigHashType(0x80), prevOuts); err != ErrInvalidHashType {
+		t.Errorf("invalid hash type: got %v", err)
+	}
+}
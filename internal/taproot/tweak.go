@@ -0,0 +1,62 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package taproot
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// ErrInvalidTweak is returned when the taproot tweak of a key is not a valid
+// scalar.  This happens with negligible probability.
+var ErrInvalidTweak = errors.New("taproot tweak exceeds curve order")
+
+// keyTweak returns the BIP0341 tweak for an internal key committing to no
+// script tree, as required by BIP0086.
+func keyTweak(internalKey *btcec.PublicKey) (*big.Int, error) {
+	t := new(big.Int).SetBytes(TaggedHash("TapTweak", SerializeXOnly(internalKey)))
+	if t.Cmp(curve.N) >= 0 {
+		return nil, ErrInvalidTweak
+	}
+	return t, nil
+}
+
+// ComputeOutputKey returns the x-only taproot output key for an internal
+// key with no script path, i.e. Q = lift_x(P) + int(hashTapTweak(P))*G.
+func ComputeOutputKey(internalKey *btcec.PublicKey) ([]byte, error) {
+	t, err := keyTweak(internalKey)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ParseXOnly(SerializeXOnly(internalKey))
+	if err != nil {
+		return nil, err
+	}
+	tx, ty := curve.ScalarBaseMult(padScalar(t))
+	qx, _ := curve.Add(p.X, p.Y, tx, ty)
+	return padScalar(qx), nil
+}
+
+// TweakPrivKey returns the private key for the taproot output key of the
+// passed internal private key, suitable for key-path spends.
+func TweakPrivKey(privKey *btcec.PrivateKey) (*btcec.PrivateKey, error) {
+	d := new(big.Int).Set(privKey.D)
+	if privKey.PubKey().Y.Bit(0) != 0 {
+		d.Sub(curve.N, d)
+	}
+	t, err := keyTweak(privKey.PubKey())
+	if err != nil {
+		return nil, err
+	}
+	d.Add(d, t)
+	d.Mod(d, curve.N)
+	if d.Sign() == 0 {
+		return nil, ErrInvalidTweak
+	}
+	tweaked, _ := btcec.PrivKeyFromBytes(curve, padScalar(d))
+	return tweaked, nil
+}
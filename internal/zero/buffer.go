@@ -0,0 +1,57 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package zero
+
+// Buffer is a fixed-size buffer for secrets such as private keys and
+// passphrases.  Where the platform allows it, the memory of the buffer is
+// allocated outside of the Go heap and locked into RAM, so that it is neither
+// copied by the garbage collector nor written to swap, and the secret only
+// exists in the buffer until it is destroyed.  Elsewhere, the buffer is
+// allocated on the heap and is only zeroed when destroyed.
+type Buffer struct {
+	b      []byte
+	mapped bool
+}
+
+// NewBuffer returns a zeroed buffer of n bytes.  The buffer must be destroyed
+// once the secret is no longer needed.
+func NewBuffer(n int) *Buffer {
+	if n == 0 {
+		return &Buffer{b: []byte{}}
+	}
+	if b, ok := allocLocked(n); ok {
+		return &Buffer{b: b, mapped: true}
+	}
+	return &Buffer{b: make([]byte, n)}
+}
+
+// NewBufferFrom returns a buffer holding a copy of the secret, which is
+// zeroed.
+func NewBufferFrom(secret []byte) *Buffer {
+	buf := NewBuffer(len(secret))
+	copy(buf.b, secret)
+	Bytes(secret)
+	return buf
+}
+
+// Bytes returns the contents of the buffer.  The returned slice must not be
+// used after the buffer is destroyed.
+func (b *Buffer) Bytes() []byte {
+	return b.b
+}
+
+// Destroy zeroes the buffer and releases its memory.  Destroying a buffer
+// more than once is a no-op.
+func (b *Buffer) Destroy() {
+	if b.b == nil {
+		return
+	}
+	Bytes(b.b)
+	if b.mapped {
+		freeLocked(b.b)
+	}
+	b.b = nil
+	b.mapped = false
+}
@@ -0,0 +1,15 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package zero
+
+// allocLocked always fails, so buffers are allocated on the heap.
+func allocLocked(n int) ([]byte, bool) {
+	return nil, false
+}
+
+// freeLocked is never called, since allocLocked always fails.
+func freeLocked(b []byte) {}
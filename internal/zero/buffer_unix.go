@@ -0,0 +1,31 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package zero
+
+import "syscall"
+
+// allocLocked maps n bytes of anonymous memory outside of the Go heap and
+// locks them into RAM.  The memory is only used when it can be locked, since
+// the limit of locked memory of the process may be reached.
+func allocLocked(n int) ([]byte, bool) {
+	b, err := syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE,
+		syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, false
+	}
+	if err := syscall.Mlock(b); err != nil {
+		syscall.Munmap(b)
+		return nil, false
+	}
+	return b, true
+}
+
+// freeLocked unlocks and unmaps memory mapped by allocLocked.
+func freeLocked(b []byte) {
+	syscall.Munlock(b)
+	syscall.Munmap(b)
+}
@@ -145,3 +145,30 @@ func TestBytea64(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestBuffer(t *testing.T) {
+	for _, n := range []int{0, 32, 4096, 5000} {
+		secret := makeOneBytes(n)
+		buf := NewBufferFrom(secret)
+		if err := checkZeroBytes(secret); err != nil {
+			t.Errorf("secret of %d bytes not zeroed: %v", n, err)
+		}
+		b := buf.Bytes()
+		if len(b) != n {
+			t.Errorf("buffer has %d bytes, want %d", len(b), n)
+			continue
+		}
+		for i, v := range b {
+			if v != 1 {
+				t.Errorf("buffer[%d] = %d, want 1", i, v)
+				break
+			}
+		}
+
+		buf.Destroy()
+		if buf.Bytes() != nil {
+			t.Errorf("destroyed buffer of %d bytes is not nil", n)
+		}
+		buf.Destroy()
+	}
+}
@@ -7,14 +7,18 @@ package main
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/btcsuite/btclog"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/internal/diagnostics"
 	"github.com/btcsuite/btcwallet/rpc/legacyrpc"
 	"github.com/btcsuite/btcwallet/rpc/rpcserver"
+	"github.com/btcsuite/btcwallet/rpc/zmqpub"
 	"github.com/btcsuite/btcwallet/wallet"
 	"github.com/btcsuite/btcwallet/wtxmgr"
 	"github.com/jrick/logrotate/rotator"
@@ -22,12 +26,14 @@ import (
 )
 
 // logWriter implements an io.Writer that outputs to both standard output and
-// the write-end pipe of an initialized log rotator.
+// the write-end pipe of an initialized log rotator.  Errors are also captured
+// by the diagnostics recorder.
 type logWriter struct{}
 
 func (logWriter) Write(p []byte) (n int, err error) {
 	os.Stdout.Write(p)
 	logRotatorPipe.Write(p)
+	diagnosticsRecorder.Write(p)
 	return len(p), nil
 }
 
@@ -53,6 +59,10 @@ var (
 	// is written to by the Write method of the logWriter type.
 	logRotatorPipe *io.PipeWriter
 
+	// diagnosticsRecorder records the errors written to the log in the
+	// diagnostics snapshots.
+	diagnosticsRecorder = diagnostics.NewRecorder(diagnostics.DefaultErrors)
+
 	log          = backendLog.Logger("BTCW")
 	walletLog    = backendLog.Logger("WLLT")
 	txmgrLog     = backendLog.Logger("TMGR")
@@ -60,6 +70,7 @@ var (
 	grpcLog      = backendLog.Logger("GRPC")
 	legacyRPCLog = backendLog.Logger("RPCS")
 	btcnLog      = backendLog.Logger("BTCN")
+	zmqpubLog    = backendLog.Logger("ZMQP")
 )
 
 // Initialize package-global logger variables.
@@ -71,6 +82,7 @@ func init() {
 	rpcserver.UseLogger(grpcLog)
 	legacyrpc.UseLogger(legacyRPCLog)
 	neutrino.UseLogger(btcnLog)
+	zmqpub.UseLogger(zmqpubLog)
 }
 
 // subsystemLoggers maps each subsystem identifier to its associated logger.
@@ -82,19 +94,21 @@ var subsystemLoggers = map[string]btclog.Logger{
 	"GRPC": grpcLog,
 	"RPCS": legacyRPCLog,
 	"BTCN": btcnLog,
+	"ZMQP": zmqpubLog,
 }
 
 // initLogRotator initializes the logging rotater to write logs to logFile and
-// create roll files in the same directory.  It must be called before the
+// create roll files in the same directory once the log file reaches maxSize
+// KiB, keeping at most maxRolls roll files.  It must be called before the
 // package-global log rotater variables are used.
-func initLogRotator(logFile string) {
+func initLogRotator(logFile string, maxSize int64, maxRolls int) {
 	logDir, _ := filepath.Split(logFile)
 	err := os.MkdirAll(logDir, 0700)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to create log directory: %v\n", err)
 		os.Exit(1)
 	}
-	r, err := rotator.New(logFile, 10*1024, false, 3)
+	r, err := rotator.New(logFile, maxSize, false, maxRolls)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to create file rotator: %v\n", err)
 		os.Exit(1)
@@ -107,6 +121,53 @@ func initLogRotator(logFile string) {
 	logRotatorPipe = pw
 }
 
+// pruneLogFiles removes the roll files of logFile last modified before
+// maxAge, after exporting them to the prune hook when it is not nil.  Roll
+// files failing to export are kept.
+func pruneLogFiles(logFile string, maxAge time.Duration, hook wallet.PruneHook) {
+	rolls, err := filepath.Glob(logFile + ".*")
+	if err != nil {
+		log.Errorf("Cannot list log roll files: %v", err)
+		return
+	}
+	for _, path := range rolls {
+		fi, err := os.Stat(path)
+		if err != nil || !fi.Mode().IsRegular() ||
+			time.Since(fi.ModTime()) <= maxAge {
+
+			continue
+		}
+		if hook != nil {
+			data, err := ioutil.ReadFile(path)
+			if err == nil {
+				err = hook.Export(wallet.PruneKindLogFile,
+					filepath.Base(path), data)
+			}
+			if err != nil {
+				log.Errorf("Prune hook %s cannot export log file "+
+					"%s: %v", hook.Name(), path, err)
+				continue
+			}
+		}
+		if err := os.Remove(path); err != nil {
+			log.Errorf("Cannot prune log file: %v", err)
+			continue
+		}
+		log.Infof("Pruned log file %s", path)
+	}
+}
+
+// logRetentionMonitor periodically prunes the log roll files older than
+// maxAge.  It must be run as a goroutine.
+func logRetentionMonitor(logFile string, maxAge time.Duration, hook wallet.PruneHook) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		pruneLogFiles(logFile, maxAge, hook)
+		<-ticker.C
+	}
+}
+
 // setLogLevel sets the logging level for provided subsystem.  Invalid
 // subsystems are ignored.  Uninitialized subsystems are dynamically created as
 // needed.
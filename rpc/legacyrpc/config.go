@@ -4,11 +4,56 @@
 
 package legacyrpc
 
+import (
+	"github.com/btcsuite/btcwallet/internal/cfgutil"
+	"github.com/btcsuite/btcwallet/internal/diagnostics"
+)
+
 // Options contains the required options for running the legacy RPC server.
 type Options struct {
 	Username string
 	Password string
 
+	// BlindedUsername and BlindedPassword are the credentials of websocket
+	// clients only permitted to receive amount-blinded receive
+	// notifications, such as display surfaces which must not expose
+	// balances.  They are disabled when empty.
+	BlindedUsername string
+	BlindedPassword string
+
+	// DisableSharedAuth refuses clients authenticating with Username and
+	// Password, so that clients must authenticate with the API tokens of
+	// the wallet or the blinded credentials.
+	DisableSharedAuth bool
+
+	// AllowedOrigins are the origins, as scheme://host[:port] in
+	// lowercase, of the browsers permitted to upgrade to websocket
+	// connections.  Every origin is permitted when it is empty.
+	AllowedOrigins []string
+
 	MaxPOSTClients      int64
 	MaxWebsocketClients int64
+
+	// WebsocketCompression enables the permessage-deflate compression of
+	// the messages of websocket clients supporting it.
+	WebsocketCompression bool
+
+	// MaxWebsocketFrameSize is the max payload size of the frames sent to
+	// websocket clients, and the size of the buffers of their connections.
+	// The default buffer size is used when it is zero.
+	MaxWebsocketFrameSize int
+
+	// MaxWebsocketMessageSize is the max size of the messages read from
+	// websocket clients, which are disconnected when they send larger
+	// ones.  There is no limit when it is zero.
+	MaxWebsocketMessageSize int64
+
+	// Diagnostics is the recorder of the snapshots returned by the
+	// getdiagnostics method, which is unavailable when it is nil.
+	Diagnostics *diagnostics.Recorder
+
+	// ConfigFingerprint returns the fingerprint of the effective
+	// configuration returned by the getconfighash method, which is
+	// unavailable when it is nil.
+	ConfigFingerprint func() *cfgutil.Fingerprint
 }
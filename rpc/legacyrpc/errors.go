@@ -37,6 +37,24 @@ type (
 	}
 )
 
+// ErrRPCOperationSequence is the error code of mutating requests that were not
+// run because the wallet operation sequence differed from the sequence of the
+// request.
+const ErrRPCOperationSequence btcjson.RPCErrorCode = -40
+
+// ErrRPCUnlockThrottled is the error code of requests whose passphrase was not
+// tried because of previous wrong passphrases.
+const ErrRPCUnlockThrottled btcjson.RPCErrorCode = -41
+
+// ErrRPCTOTP is the error code of requests which were not run because their
+// one-time password was missing or invalid.
+const ErrRPCTOTP btcjson.RPCErrorCode = -42
+
+// ErrRPCNotificationsExpired is the error code of replaynotifications requests
+// for notifications which are no longer kept, after which clients must resync
+// all state.
+const ErrRPCNotificationsExpired btcjson.RPCErrorCode = -43
+
 // Errors variables that are defined once here to avoid duplication below.
 var (
 	ErrNeedPositivePrice = InvalidParameterError{
@@ -81,6 +99,11 @@ var (
 		Message: "No information for transaction",
 	}
 
+	ErrBlindedClient = btcjson.RPCError{
+		Code:    btcjson.ErrRPCMisc,
+		Message: "Clients with blinded credentials may only request blinded receive notifications",
+	}
+
 	ErrReservedAccountName = btcjson.RPCError{
 		Code:    btcjson.ErrRPCInvalidParameter,
 		Message: "Account name is reserved by RPC server",
@@ -7,16 +7,22 @@ package legacyrpc
 
 import (
 	"bytes"
+	"encoding/base32"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/btcjson"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -24,11 +30,22 @@ import (
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/internal/addrcheck"
+	"github.com/btcsuite/btcwallet/internal/bbqr"
 	"github.com/btcsuite/btcwallet/internal/helpers"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/internal/zero"
+	"github.com/btcsuite/btcwallet/rpc/walletjson"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/wallet/descriptor"
+	"github.com/btcsuite/btcwallet/wallet/ledger"
+	"github.com/btcsuite/btcwallet/wallet/psbt"
+	"github.com/btcsuite/btcwallet/wallet/travelrule"
 	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/wallet/walletdump"
 	"github.com/btcsuite/btcwallet/wtxmgr"
 )
 
@@ -73,26 +90,39 @@ var rpcHandlers = map[string]struct {
 	// for the unimplemented handlers so every method has exactly one
 	// handler function.
 	noHelp bool
+
+	// mutating is set for the methods changing the wallet, which are run
+	// as wallet operations incrementing the wallet operation sequence.
+	mutating bool
+
+	// totp is set for the methods spending outputs or exporting private
+	// keys, which require a one-time password once a TOTP secret is
+	// enrolled.
+	totp bool
 }{
 	// Reference implementation wallet methods (implemented)
-	"addmultisigaddress":     {handler: addMultiSigAddress},
+	"addmultisigaddress":     {handler: addMultiSigAddress, mutating: true},
+	"backupwallet":           {handler: backupWallet},
 	"createmultisig":         {handler: createMultiSig},
-	"dumpprivkey":            {handler: dumpPrivKey},
+	"dumpprivkey":            {handler: dumpPrivKey, totp: true},
+	"dumpwallet":             {handler: dumpWallet, totp: true},
 	"getaccount":             {handler: getAccount},
-	"getaccountaddress":      {handler: getAccountAddress},
+	"getaccountaddress":      {handler: getAccountAddress, mutating: true},
 	"getaddressesbyaccount":  {handler: getAddressesByAccount},
 	"getbalance":             {handler: getBalance},
 	"getbestblockhash":       {handler: getBestBlockHash},
 	"getblockcount":          {handler: getBlockCount},
 	"getinfo":                {handlerWithChain: getInfo},
-	"getnewaddress":          {handler: getNewAddress},
-	"getrawchangeaddress":    {handler: getRawChangeAddress},
+	"getnewaddress":          {handler: getNewAddress, mutating: true},
+	"getrawchangeaddress":    {handler: getRawChangeAddress, mutating: true},
 	"getreceivedbyaccount":   {handler: getReceivedByAccount},
 	"getreceivedbyaddress":   {handler: getReceivedByAddress},
 	"gettransaction":         {handler: getTransaction},
 	"help":                   {handler: helpNoChainRPC, handlerWithChain: helpWithChainRPC},
-	"importprivkey":          {handler: importPrivKey},
-	"keypoolrefill":          {handler: keypoolRefill},
+	"importaddress":          {handler: importAddress, mutating: true},
+	"importprivkey":          {handler: importPrivKey, mutating: true},
+	"importwallet":           {handler: importWallet, mutating: true},
+	"keypoolrefill":          {handler: keypoolRefill, mutating: true},
 	"listaccounts":           {handler: listAccounts},
 	"listlockunspent":        {handler: listLockUnspent},
 	"listreceivedbyaccount":  {handler: listReceivedByAccount},
@@ -100,26 +130,27 @@ var rpcHandlers = map[string]struct {
 	"listsinceblock":         {handlerWithChain: listSinceBlock},
 	"listtransactions":       {handler: listTransactions},
 	"listunspent":            {handler: listUnspent},
-	"lockunspent":            {handler: lockUnspent},
-	"sendfrom":               {handlerWithChain: sendFrom},
-	"sendmany":               {handler: sendMany},
-	"sendtoaddress":          {handler: sendToAddress},
-	"bid":                    {handler: bid},
-	"ask":                    {handler: ask},
-	"settxfee":               {handler: setTxFee},
+	"lockunspent":            {handler: lockUnspent, mutating: true},
+	"sendfrom":               {handlerWithChain: sendFrom, mutating: true, totp: true},
+	"sendmany":               {handler: sendMany, mutating: true, totp: true},
+	"sendtoaddress":          {handler: sendToAddress, mutating: true, totp: true},
+	"bid":                    {handler: bid, mutating: true, totp: true},
+	"ask":                    {handler: ask, mutating: true, totp: true},
+	"settxfee":               {handler: setTxFee, mutating: true},
 	"signmessage":            {handler: signMessage},
-	"signrawtransaction":     {handlerWithChain: signRawTransaction},
+	"signrawtransaction":     {handlerWithChain: signRawTransaction, totp: true},
 	"validateaddress":        {handler: validateAddress},
 	"verifymessage":          {handler: verifyMessage},
-	"walletlock":             {handler: walletLock},
-	"walletpassphrase":       {handler: walletPassphrase},
-	"walletpassphrasechange": {handler: walletPassphraseChange},
+	"walletlock":             {handler: walletLock, mutating: true},
+	"walletpassphrase":       {handler: walletPassphrase, mutating: true},
+	"walletpassphrasechange": {handler: walletPassphraseChange, mutating: true},
+
+	// Reference implementation wallet methods of later versions
+	"fundrawtransaction":           {handler: fundRawTransaction, mutating: true},
+	"signrawtransactionwithwallet": {handler: signRawTransactionWithWallet, totp: true},
 
 	// Reference implementation methods (still unimplemented)
-	"backupwallet":         {handler: unimplemented, noHelp: true},
-	"dumpwallet":           {handler: unimplemented, noHelp: true},
 	"getwalletinfo":        {handler: unimplemented, noHelp: true},
-	"importwallet":         {handler: unimplemented, noHelp: true},
 	"listaddressgroupings": {handler: unimplemented, noHelp: true},
 
 	// Reference methods which can't be implemented by btcwallet due to
@@ -129,7 +160,7 @@ var rpcHandlers = map[string]struct {
 	"setaccount":    {handler: unsupported, noHelp: true},
 
 	// Extensions to the reference client JSON-RPC API
-	"createnewaccount": {handler: createNewAccount},
+	"createnewaccount": {handler: createNewAccount, mutating: true},
 	"getbestblock":     {handler: getBestBlock},
 	// This was an extension but the reference implementation added it as
 	// well, but with a different API (no account parameter).  It's listed
@@ -138,8 +169,88 @@ var rpcHandlers = map[string]struct {
 	"getunconfirmedbalance":   {handler: getUnconfirmedBalance},
 	"listaddresstransactions": {handler: listAddressTransactions},
 	"listalltransactions":     {handler: listAllTransactions},
-	"renameaccount":           {handler: renameAccount},
+	"renameaccount":           {handler: renameAccount, mutating: true},
 	"walletislocked":          {handler: walletIsLocked},
+	"getnewtaprootaddress":    {handler: getNewTaprootAddress, mutating: true},
+	"importwitnessscript":     {handler: importWitnessScript, mutating: true},
+	"settravelrule":           {handler: setTravelRule, mutating: true},
+	"exporttravelrule":        {handler: exportTravelRule},
+	"walletcreatefundedpsbt":  {handler: walletCreateFundedPsbt, mutating: true},
+	"walletprocesspsbt":       {handler: walletProcessPsbt, totp: true},
+	"finalizepsbt":            {handler: finalizePsbt},
+	"exportpsbt":              {handler: exportPsbt},
+	"importsignedtx":          {handler: importSignedTx, mutating: true, totp: true},
+	"getaggregatebalance":     {handler: getAggregateBalance},
+	"sweepprivkey":            {handler: sweepPrivKey, mutating: true, totp: true},
+	"createaccountwithpath":   {handler: createAccountWithPath, mutating: true},
+	"reserveaddressindexes":   {handler: reserveAddressIndexes, mutating: true},
+	"importmulti":             {handler: importMulti, mutating: true},
+	"exportledger":            {handler: exportLedger},
+	"getaddressusage":         {handler: getAddressUsage},
+	"getunlocktimeout":        {handler: getUnlockTimeout},
+	"importprivkeys":          {handler: importPrivKeys, mutating: true},
+	"setaccountpassphrase":    {handler: setAccountPassphrase, mutating: true},
+	"getoperationsequence":    {handler: getOperationSequence},
+	"getaccountfootprint":     {handler: getAccountFootprint},
+	"getauditlog":             {handler: getAuditLog},
+	"getstatecommitment":      {handler: getStateCommitment},
+	"getproofofreserves":      {handler: getProofOfReserves},
+	"enrolltotp":              {handler: enrollTOTP, mutating: true, totp: true},
+	"disabletotp":             {handler: disableTOTP, mutating: true, totp: true},
+	"signmessagebip322":       {handler: signMessageBIP322},
+	"pinaddresspool":          {handler: pinAddressPool, mutating: true, totp: true},
+	"unpinaddresspool":        {handler: unpinAddressPool, mutating: true, totp: true},
+	"listaddresspools":        {handler: listAddressPools},
+	"getpooladdress":          {handler: getPoolAddress, mutating: true},
+	"consolidatechange":       {handler: consolidateChange, mutating: true, totp: true},
+	"getconsolidationreport":  {handler: getConsolidationReport},
+	"sweepall":                {handler: sweepAll, mutating: true, totp: true},
+	"sendwithinputs":          {handler: sendWithInputs, mutating: true, totp: true},
+	"cancelbroadcast":         {handler: cancelBroadcast, mutating: true},
+	"listunspentfiltered":     {handler: listUnspentFiltered},
+	"setaccountmetadata":      {handler: setAccountMetadata, mutating: true},
+	"getaccountmetadata":      {handler: getAccountMetadata},
+	"anchoraccountmetadata":   {handler: anchorAccountMetadata, mutating: true, totp: true},
+	"bumpfee":                 {handler: bumpFee, mutating: true, totp: true},
+	"bumpfeecpfp":             {handler: bumpFeeCPFP, mutating: true, totp: true},
+	"getkeyusage":             {handler: getKeyUsage},
+	"queuepayment":            {handler: queuePayment, mutating: true},
+	"getqueuedpayment":        {handler: getQueuedPayment},
+	"listqueuedpayments":      {handler: listQueuedPayments},
+	"cancelqueuedpayment":     {handler: cancelQueuedPayment, mutating: true},
+	"sendqueuedpayments":      {handler: sendQueuedPayments, mutating: true, totp: true},
+	"settransactionlabel":     {handler: setTransactionLabel, mutating: true},
+	"getspendingreport":       {handler: getSpendingReport},
+	"setchangepolicy":         {handler: setChangePolicy, mutating: true},
+	"consolidateutxos":        {handler: consolidateUTXOs, mutating: true, totp: true},
+	"listquarantined":         {handler: listQuarantined},
+	"spendquarantined":        {handler: spendQuarantined, mutating: true, totp: true},
+	"getdustpolicy":           {handler: getDustPolicy},
+	"freezeaccount":           {handler: freezeAccount, mutating: true},
+	"unfreezeaccount":         {handler: unfreezeAccount, mutating: true, totp: true},
+	"listfrozenaccounts":      {handler: listFrozenAccounts},
+	"getderivationproof":      {handler: getDerivationProof},
+	"preparetransaction":      {handler: prepareTransaction},
+	"entermaintenance":        {handler: enterMaintenance, mutating: true},
+	"exitmaintenance":         {handler: exitMaintenance, mutating: true},
+	"getmaintenanceinfo":      {handler: getMaintenanceInfo},
+	"acceleratetx":            {handler: accelerateTx, mutating: true},
+	"listaccelerations":       {handler: listAccelerations},
+	"evaluatepolicy":          {handler: evaluatePolicy},
+	"createpaymentreference":  {handler: createPaymentReference, mutating: true},
+	"lookuppaymentreference":  {handler: lookupPaymentReference},
+	"listpaymentreferences":   {handler: listPaymentReferences},
+	"enrollbackuptoken":       {handler: enrollBackupToken, mutating: true, totp: true},
+	"listbackuptokens":        {handler: listBackupTokens},
+	"removebackuptoken":       {handler: removeBackupToken, mutating: true, totp: true},
+	"recoverbackup":           {handler: recoverBackup, totp: true},
+	"addheighttrigger":        {handler: addHeightTrigger, mutating: true},
+	"listheighttriggers":      {handler: listHeightTriggers},
+	"removeheighttrigger":     {handler: removeHeightTrigger, mutating: true},
+	"createapitoken":          {handler: createAPIToken, mutating: true, totp: true},
+	"listapitokens":           {handler: listAPITokens},
+	"revokeapitoken":          {handler: revokeAPIToken, mutating: true, totp: true},
+	"getrefillrequest":        {handler: getRefillRequest},
 }
 
 // unimplemented handles an unimplemented RPC request with the
@@ -160,33 +271,59 @@ func unsupported(interface{}, *wallet.Wallet) (interface{}, error) {
 	}
 }
 
+// extendedMethods maps the methods of the btcjson commands extended with
+// optional parameters by the wallet server to the methods the extended
+// walletjson commands are registered with.
+var extendedMethods = map[string]string{
+	"walletpassphrase": walletjson.WalletPassphraseAccountMethod,
+	"sendfrom":         walletjson.SendFromTravelRuleMethod,
+	"sendmany":         walletjson.SendManySubtractFeeMethod,
+	"sendtoaddress":    walletjson.SendToAddressDataMethod,
+}
+
+// unmarshalCmd unmarshals the command of a request, as the extended walletjson
+// command for the methods of extendedMethods.
+func unmarshalCmd(request *btcjson.Request) (interface{}, error) {
+	method, ok := extendedMethods[request.Method]
+	if !ok {
+		return btcjson.UnmarshalCmd(request)
+	}
+	extended := *request
+	extended.Method = method
+	return btcjson.UnmarshalCmd(&extended)
+}
+
 // lazyHandler is a closure over a requestHandler or passthrough request with
 // the RPC server's wallet and chain server variables as part of the closure
-// context.
-type lazyHandler func() (interface{}, *btcjson.RPCError)
+// context.  The wallet operation sequence after the request is returned for
+// mutating requests, and is nil for the others.
+type lazyHandler func() (interface{}, *uint64, *btcjson.RPCError)
 
 // lazyApplyHandler looks up the best request handler func for the method,
 // returning a closure that will execute it with the (required) wallet and
 // (optional) consensus RPC server.  If no handlers are found and the
 // chainClient is not nil, the returned handler performs RPC passthrough.
-func lazyApplyHandler(request *btcjson.Request, w *wallet.Wallet, chainClient chain.Interface) lazyHandler {
+// Mutating requests are run as wallet operations, conditional on the wallet
+// operation sequence of the request extensions when it is not nil.
+func lazyApplyHandler(request *btcjson.Request, ext *requestExtensions, w *wallet.Wallet,
+	chainClient chain.Interface) lazyHandler {
+
 	handlerData, ok := rpcHandlers[request.Method]
 	if ok && handlerData.handlerWithChain != nil && w != nil && chainClient != nil {
-		return func() (interface{}, *btcjson.RPCError) {
-			cmd, err := btcjson.UnmarshalCmd(request)
+		return func() (interface{}, *uint64, *btcjson.RPCError) {
+			cmd, err := unmarshalCmd(request)
 			if err != nil {
-				return nil, btcjson.ErrRPCInvalidRequest
+				return nil, nil, btcjson.ErrRPCInvalidRequest
 			}
 			switch client := chainClient.(type) {
 			case *chain.RPCClient:
-				resp, err := handlerData.handlerWithChain(cmd,
-					w, client)
-				if err != nil {
-					return nil, jsonError(err)
-				}
-				return resp, nil
+				return applyHandler(w, handlerData.mutating,
+					handlerData.totp, ext, func() (interface{}, error) {
+						return handlerData.handlerWithChain(cmd,
+							w, client)
+					})
 			default:
-				return nil, &btcjson.RPCError{
+				return nil, nil, &btcjson.RPCError{
 					Code:    -1,
 					Message: "Chain RPC is inactive",
 				}
@@ -194,23 +331,22 @@ func lazyApplyHandler(request *btcjson.Request, w *wallet.Wallet, chainClient ch
 		}
 	}
 	if ok && handlerData.handler != nil && w != nil {
-		return func() (interface{}, *btcjson.RPCError) {
-			cmd, err := btcjson.UnmarshalCmd(request)
-			if err != nil {
-				return nil, btcjson.ErrRPCInvalidRequest
-			}
-			resp, err := handlerData.handler(cmd, w)
+		return func() (interface{}, *uint64, *btcjson.RPCError) {
+			cmd, err := unmarshalCmd(request)
 			if err != nil {
-				return nil, jsonError(err)
+				return nil, nil, btcjson.ErrRPCInvalidRequest
 			}
-			return resp, nil
+			return applyHandler(w, handlerData.mutating,
+				handlerData.totp, ext, func() (interface{}, error) {
+					return handlerData.handler(cmd, w)
+				})
 		}
 	}
 
 	// Fallback to RPC passthrough
-	return func() (interface{}, *btcjson.RPCError) {
+	return func() (interface{}, *uint64, *btcjson.RPCError) {
 		if chainClient == nil {
-			return nil, &btcjson.RPCError{
+			return nil, nil, &btcjson.RPCError{
 				Code:    -1,
 				Message: "Chain RPC is inactive",
 			}
@@ -220,11 +356,11 @@ func lazyApplyHandler(request *btcjson.Request, w *wallet.Wallet, chainClient ch
 			resp, err := client.RawRequest(request.Method,
 				request.Params)
 			if err != nil {
-				return nil, jsonError(err)
+				return nil, nil, jsonError(err)
 			}
-			return &resp, nil
+			return &resp, nil, nil
 		default:
-			return nil, &btcjson.RPCError{
+			return nil, nil, &btcjson.RPCError{
 				Code:    -1,
 				Message: "Chain RPC is inactive",
 			}
@@ -232,6 +368,43 @@ func lazyApplyHandler(request *btcjson.Request, w *wallet.Wallet, chainClient ch
 	}
 }
 
+// applyHandler runs a request handler, as a wallet operation when the method
+// is mutating, after checking the one-time password of the request when the
+// method requires one.  The wallet operation sequence after the operation is
+// returned for mutating methods, even when the request fails.
+func applyHandler(w *wallet.Wallet, mutating, totp bool, ext *requestExtensions,
+	handler func() (interface{}, error)) (interface{}, *uint64, *btcjson.RPCError) {
+
+	if totp {
+		checked := handler
+		handler = func() (interface{}, error) {
+			if err := w.CheckTOTP(ext.TOTP); err != nil {
+				return nil, err
+			}
+			return checked()
+		}
+	}
+
+	if !mutating {
+		resp, err := handler()
+		if err != nil {
+			return nil, nil, jsonError(err)
+		}
+		return resp, nil, nil
+	}
+
+	var resp interface{}
+	seq, err := w.Operation(ext.Sequence, func() error {
+		var err error
+		resp, err = handler()
+		return err
+	})
+	if err != nil {
+		return nil, &seq, jsonError(err)
+	}
+	return resp, &seq, nil
+}
+
 // makeResponse makes the JSON-RPC response struct for the result and error
 // returned by a requestHandler.  The returned response is not ready for
 // marshaling and sending off to a client, but must be
@@ -277,12 +450,20 @@ func jsonError(err error) *btcjson.RPCError {
 		code = btcjson.ErrRPCInvalidParameter
 	case ParseError:
 		code = btcjson.ErrRPCParse.Code
+	case *wallet.OperationSequenceError:
+		code = ErrRPCOperationSequence
+	case *wallet.UnlockThrottledError:
+		code = ErrRPCUnlockThrottled
 	case waddrmgr.ManagerError:
 		switch e.ErrorCode {
 		case waddrmgr.ErrWrongPassphrase:
 			code = btcjson.ErrRPCWalletPassphraseIncorrect
 		}
 	}
+	switch err {
+	case wallet.ErrTOTPRequired, wallet.ErrTOTPInvalid:
+		code = ErrRPCTOTP
+	}
 	return &btcjson.RPCError{
 		Code:    code,
 		Message: err.Error(),
@@ -290,38 +471,34 @@ func jsonError(err error) *btcjson.RPCError {
 }
 
 // makeMultiSigScript is a helper function to combine common logic for
-// AddMultiSig and CreateMultiSig.
+// AddMultiSig and CreateMultiSig.  The keys may be any mix of hex encoded
+// public keys, which need not belong to the wallet, and addresses of the
+// wallet's own keys.  Invalid keys and numbers of signatures are reported as
+// invalid parameters, and failures of the wallet as internal errors.
 func makeMultiSigScript(w *wallet.Wallet, keys []string, nRequired int) ([]byte, error) {
-	keysesPrecious := make([]*btcutil.AddressPubKey, len(keys))
-
-	// The address list will made up either of addreseses (pubkey hash), for
-	// which we need to look up the keys in wallet, straight pubkeys, or a
-	// mixture of the two.
-	for i, a := range keys {
-		// try to parse as pubkey address
-		a, err := decodeAddress(a, w.ChainParams())
+	addrs := make([]btcutil.Address, len(keys))
+	for i, k := range keys {
+		addr, err := decodeAddress(k, w.ChainParams())
 		if err != nil {
 			return nil, err
 		}
-
-		switch addr := a.(type) {
-		case *btcutil.AddressPubKey:
-			keysesPrecious[i] = addr
-		default:
-			pubKey, err := w.PubKeyForAddress(addr)
-			if err != nil {
-				return nil, err
-			}
-			pubKeyAddr, err := btcutil.NewAddressPubKey(
-				pubKey.SerializeCompressed(), w.ChainParams())
-			if err != nil {
-				return nil, err
-			}
-			keysesPrecious[i] = pubKeyAddr
-		}
+		addrs[i] = addr
 	}
 
-	return txscript.MultiSigScript(keysesPrecious, nRequired)
+	script, err := w.MakeMultiSigScript(addrs, nRequired)
+	switch {
+	case err == nil:
+		return script, nil
+	case waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound):
+		return nil, &ErrAddressNotInWallet
+	}
+	if _, ok := err.(*wallet.MultiSigScriptError); ok {
+		return nil, InvalidParameterError{err}
+	}
+	return nil, &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInternal.Code,
+		Message: err.Error(),
+	}
 }
 
 // addMultiSigAddress handles an addmultisigaddress request by adding a
@@ -334,28 +511,39 @@ func addMultiSigAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error)
 		return nil, &ErrNotImportedAccount
 	}
 
-	secp256k1Addrs := make([]btcutil.Address, len(cmd.Keys))
-	for i, k := range cmd.Keys {
-		addr, err := decodeAddress(k, w.ChainParams())
-		if err != nil {
-			return nil, ParseError{err}
-		}
-		secp256k1Addrs[i] = addr
-	}
-
-	script, err := w.MakeMultiSigScript(secp256k1Addrs, cmd.NRequired)
+	script, err := makeMultiSigScript(w, cmd.Keys, cmd.NRequired)
 	if err != nil {
 		return nil, err
 	}
 
+	// Importing the redeem script also registers the P2SH address with the
+	// chain backend so that transactions paying to it are noticed.
 	p2shAddr, err := w.ImportP2SHRedeemScript(script)
 	if err != nil {
-		return nil, err
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInternal.Code,
+			Message: err.Error(),
+		}
 	}
 
 	return p2shAddr.EncodeAddress(), nil
 }
 
+// backupWallet handles a backupwallet request by writing a backup of the
+// wallet database to a file, encrypted with the backup passphrase or GPG
+// recipients of the wallet when they are configured.
+func backupWallet(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.BackupWalletCmd)
+
+	if err := w.BackupWallet(cmd.Destination); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
+		}
+	}
+	return nil, nil
+}
+
 // createMultiSig handles an createmultisig request by returning a
 // multisig address for the given inputs.
 func createMultiSig(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
@@ -363,7 +551,7 @@ func createMultiSig(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 
 	script, err := makeMultiSigScript(w, cmd.Keys, cmd.NRequired)
 	if err != nil {
-		return nil, ParseError{err}
+		return nil, err
 	}
 
 	address, err := btcutil.NewAddressScriptHash(script, w.ChainParams())
@@ -398,21 +586,36 @@ func dumpPrivKey(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	return key, err
 }
 
-// dumpWallet handles a dumpwallet request by returning  all private
-// keys in a wallet, or an appropiate error if the wallet is locked.
-// TODO: finish this to match bitcoind by writing the dump to a file.
+// dumpWallet handles a dumpwallet request by writing all private keys in a
+// wallet to a new file in the format of bitcoind, or an appropiate error if
+// the wallet is locked.
 func dumpWallet(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	keys, err := w.DumpPrivKeys()
+	cmd := icmd.(*btcjson.DumpWalletCmd)
+
+	d, err := w.DumpWallet()
 	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
 		return nil, &ErrWalletUnlockNeeded
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	return keys, err
+	filename, err := filepath.Abs(cmd.Filename)
+	if err != nil {
+		return nil, InvalidParameterError{err}
+	}
+	if err := walletdump.WriteFile(filename, d); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+	return walletjson.DumpWalletResult{Filename: filename}, nil
 }
 
 // getAddressesByAccount handles a getaddressesbyaccount request by returning
-// all addresses for an account, or an error if the requested account does
-// not exist.
+// all payment addresses for an account, or an error if the requested account
+// does not exist.  Change addresses are not returned.
 func getAddressesByAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	cmd := icmd.(*btcjson.GetAddressesByAccountCmd)
 
@@ -421,7 +624,7 @@ func getAddressesByAccount(icmd interface{}, w *wallet.Wallet) (interface{}, err
 		return nil, err
 	}
 
-	addrs, err := w.AccountAddresses(account)
+	addrs, err := w.AccountPaymentAddresses(account)
 	if err != nil {
 		return nil, err
 	}
@@ -500,6 +703,84 @@ func getBalance(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	return balance.ToBTC(), nil
 }
 
+// getAggregateBalance handles a getaggregatebalance request by calculating
+// the balances of all or the named accounts concurrently, and returning them
+// with their totals.
+func getAggregateBalance(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetAggregateBalanceCmd)
+
+	minConf := int32(1)
+	if cmd.MinConf != nil {
+		minConf = int32(*cmd.MinConf)
+	}
+	if minConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
+
+	var accounts []uint32
+	var names []string
+	if cmd.Accounts == nil {
+		var err error
+		accounts, err = w.AccountNumbers(waddrmgr.KeyScopeBIP0044)
+		if err != nil {
+			return nil, err
+		}
+		names = make([]string, len(accounts))
+		for i, account := range accounts {
+			names[i], err = w.AccountName(waddrmgr.KeyScopeBIP0044,
+				account)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// Accounts named more than once are only counted once.
+		seen := make(map[string]struct{}, len(*cmd.Accounts))
+		for _, name := range *cmd.Accounts {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044,
+				name)
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, account)
+			names = append(names, name)
+		}
+	}
+
+	bals, err := w.CalculateAccountsBalances(accounts, minConf,
+		parseTokenIdentity(cmd.Token))
+	if err != nil {
+		return nil, err
+	}
+
+	result := walletjson.GetAggregateBalanceResult{
+		Accounts: make([]walletjson.AggregateAccountBalance, len(bals)),
+	}
+	var total wallet.Balances
+	for i, b := range bals {
+		result.Accounts[i] = walletjson.AggregateAccountBalance{
+			Account:        names[i],
+			Total:          b.Total.ToBTC(),
+			Spendable:      b.Spendable.ToBTC(),
+			ImmatureReward: b.ImmatureReward.ToBTC(),
+			WatchOnly:      b.WatchOnly.ToBTC(),
+		}
+		total.Total += b.Total
+		total.Spendable += b.Spendable
+		total.ImmatureReward += b.ImmatureReward
+		total.WatchOnly += b.WatchOnly
+	}
+	result.Total = total.Total.ToBTC()
+	result.Spendable = total.Spendable.ToBTC()
+	result.ImmatureReward = total.ImmatureReward.ToBTC()
+	result.WatchOnly = total.WatchOnly.ToBTC()
+	return result, nil
+}
+
 // getBestBlock handles a getbestblock request by returning a JSON object
 // with the height and hash of the most recently processed block.
 func getBestBlock(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
@@ -545,7 +826,7 @@ func getInfo(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCClient) (
 	// to using the manager version.
 	info.WalletVersion = int32(waddrmgr.LatestMgrVersion)
 	info.Balance = bal.ToBTC()
-	info.PaytxFee = float64(txrules.DefaultRelayFeePerKb)
+	info.PaytxFee = w.TxFee().ToBTC()
 	// We don't set the following since they don't make much sense in the
 	// wallet architecture:
 	//  - unlocked_until
@@ -554,24 +835,71 @@ func getInfo(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCClient) (
 	return info, nil
 }
 
+// decodeAddress decodes an address of a request, describing what is wrong
+// with invalid addresses in the error.
 func decodeAddress(s string, params *chaincfg.Params) (btcutil.Address, error) {
-	addr, err := btcutil.DecodeAddress(s, params)
+	addr, err := addrcheck.Decode(s, params)
 	if err != nil {
-		msg := fmt.Sprintf("Invalid address %q: decode failed with %#q", s, err)
 		return nil, &btcjson.RPCError{
 			Code:    btcjson.ErrRPCInvalidAddressOrKey,
-			Message: msg,
+			Message: err.Error(),
 		}
 	}
-	if !addr.IsForNet(params) {
-		msg := fmt.Sprintf("Invalid address %q: not intended for use on %s",
-			addr, params.Name)
-		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCInvalidAddressOrKey,
-			Message: msg,
+	return addr, nil
+}
+
+// exportTravelRule handles an exporttravelrule request by returning the
+// travel rule metadata of a transaction, or of every transaction with
+// metadata when no transaction is specified, as IVMS101 JSON.
+func exportTravelRule(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ExportTravelRuleCmd)
+
+	var doc interface{}
+	if cmd.TxID != nil {
+		txHash, err := chainhash.NewHashFromStr(*cmd.TxID)
+		if err != nil {
+			return nil, DeserializationError{err}
+		}
+		m, err := w.TravelRuleMetadata(txHash)
+		if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+			return nil, &ErrWalletUnlockNeeded
+		}
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "No travel rule metadata for transaction",
+			}
+		}
+		doc = m.IVMS101()
+	} else {
+		all, err := w.AllTravelRuleMetadata()
+		if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+			return nil, &ErrWalletUnlockNeeded
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs := make([]walletjson.TravelRuleRecord, 0, len(all))
+		for txHash, m := range all {
+			docs = append(docs, walletjson.TravelRuleRecord{
+				TxID:    txHash.String(),
+				IVMS101: m.IVMS101(),
+			})
 		}
+		sort.Slice(docs, func(i, j int) bool {
+			return docs[i].TxID < docs[j].TxID
+		})
+		doc = docs
 	}
-	return addr, nil
+
+	buf, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return string(buf), nil
 }
 
 // getAccount handles a getaccount request by returning the account name
@@ -636,7 +964,72 @@ func getUnconfirmedBalance(icmd interface{}, w *wallet.Wallet) (interface{}, err
 		return nil, err
 	}
 
-	return (bals.Total - bals.Spendable).ToBTC(), nil
+	return (bals.Total - bals.Spendable - bals.Locked).ToBTC(), nil
+}
+
+// sweepPrivKey handles a sweepprivkey request by sending the unspent outputs
+// of a WIF-encoded private key to an account without importing the key.
+func sweepPrivKey(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SweepPrivKeyCmd)
+
+	wif, err := btcutil.DecodeWIF(cmd.PrivKey)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "WIF decode failed: " + err.Error(),
+		}
+	}
+	if !wif.IsForNet(w.ChainParams()) {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Key is not intended for " + w.ChainParams().Name,
+		}
+	}
+
+	var startHeight int32
+	if cmd.StartHeight != nil {
+		startHeight = *cmd.StartHeight
+	}
+	if startHeight < 0 {
+		return nil, InvalidParameterError{
+			errors.New("startheight must not be negative"),
+		}
+	}
+	token := parseTokenIdentity(cmd.Token)
+
+	var sweep *wallet.Sweep
+	if cmd.Pool != nil {
+		if cmd.Account != nil {
+			return nil, InvalidParameterError{
+				errors.New("account and pool must not both be set"),
+			}
+		}
+		sweep, err = w.SweepPrivateKeyToPool(wif, *cmd.Pool, token,
+			startHeight, w.FeeRate(0))
+	} else {
+		acctName := "default"
+		if cmd.Account != nil {
+			acctName = *cmd.Account
+		}
+		var account uint32
+		account, err = w.AccountNumber(waddrmgr.KeyScopeBIP0044, acctName)
+		if err != nil {
+			return nil, err
+		}
+		sweep, err = w.SweepPrivateKey(wif, account,
+			waddrmgr.KeyScopeBIP0044, token, startHeight,
+			w.FeeRate(0))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &walletjson.SweepPrivKeyResult{
+		TxID:    sweep.Hash.String(),
+		Address: sweep.Address.EncodeAddress(),
+		Amount:  sweep.Amount.ToBTC(),
+		Fee:     sweep.Fee.ToBTC(),
+		Inputs:  sweep.Inputs,
+	}, nil
 }
 
 // importPrivKey handles an importprivkey request by parsing
@@ -678,80 +1071,462 @@ func importPrivKey(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	return nil, err
 }
 
-// keypoolRefill handles the keypoolrefill command. Since we handle the keypool
-// automatically this does nothing since refilling is never manually required.
-func keypoolRefill(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+// importWallet handles an importwallet request by importing the private keys
+// of a wallet dump file written by dumpwallet or bitcoind to the imported
+// account.
+func importWallet(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.ImportWalletCmd)
+
+	keys, err := walletdump.ReadFile(cmd.Filename)
+	if err != nil {
+		return nil, InvalidParameterError{err}
+	}
+	if w.Locked() {
+		return nil, &ErrWalletUnlockNeeded
+	}
+
+	imported, err := w.ImportWallet(keys, true)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Imported %d of %d keys from wallet dump %s", imported,
+		len(keys), cmd.Filename)
 	return nil, nil
 }
 
-// createNewAccount handles a createnewaccount request by creating and
-// returning a new account. If the last account has no transaction history
-// as per BIP 0044 a new account cannot be created so an error will be returned.
-func createNewAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.CreateNewAccountCmd)
+// importAddress handles an importaddress request by adding a watch-only
+// address to an account.  The address is imported to the default account
+// when the account name is empty.
+func importAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.ImportAddressCmd)
 
-	// The wildcard * is reserved by the rpc server with the special meaning
-	// of "all accounts", so disallow naming accounts to this string.
-	if cmd.Account == "*" {
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+
+	accountName := cmd.Account
+	if accountName == "" {
+		accountName = "default"
+	}
+	if accountName == "*" {
 		return nil, &ErrReservedAccountName
 	}
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, accountName)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := w.NextAccount(waddrmgr.KeyScopeBIP0044, cmd.Account)
-	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
-		return nil, &btcjson.RPCError{
-			Code: btcjson.ErrRPCWalletUnlockNeeded,
-			Message: "Creating an account requires the wallet to be unlocked. " +
-				"Enter the wallet passphrase with walletpassphrase to unlock",
-		}
+	rescan := true
+	if cmd.Rescan != nil {
+		rescan = *cmd.Rescan
+	}
+	err = w.ImportAddress(waddrmgr.KeyScopeBIP0044, addr, account, rescan)
+	if waddrmgr.IsError(err, waddrmgr.ErrDuplicateAddress) {
+		// Do not return duplicate address errors to the client.
+		return nil, nil
 	}
 	return nil, err
 }
 
-// renameAccount handles a renameaccount request by renaming an account.
-// If the account does not exist an appropiate error will be returned.
-func renameAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.RenameAccountCmd)
+// exportLedger handles an exportledger request by returning the mined wallet
+// transactions as a double-entry ledger in a plain text accounting format.
+func exportLedger(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ExportLedgerCmd)
 
-	// The wildcard * is reserved by the rpc server with the special meaning
-	// of "all accounts", so disallow naming accounts to this string.
-	if cmd.NewAccount == "*" {
-		return nil, &ErrReservedAccountName
+	format, err := ledger.ParseFormat(cmd.Format)
+	if err != nil {
+		return nil, InvalidParameterError{err}
 	}
 
-	// Check that given account exists
-	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.OldAccount)
+	startHeight := int32(0)
+	if cmd.StartHeight != nil {
+		startHeight = *cmd.StartHeight
+	}
+	endHeight := w.Manager.SyncedTo().Height
+	if cmd.EndHeight != nil {
+		endHeight = *cmd.EndHeight
+	}
+
+	entries, err := w.LedgerEntries(startHeight, endHeight)
 	if err != nil {
 		return nil, err
 	}
-	return nil, w.RenameAccount(waddrmgr.KeyScopeBIP0044, account, cmd.NewAccount)
+	var buf bytes.Buffer
+	if err := ledger.Write(&buf, entries, format); err != nil {
+		return nil, err
+	}
+	return buf.String(), nil
 }
 
-// getNewAddress handles a getnewaddress request by returning a new
-// address for an account.  If the account does not exist an appropiate
-// error is returned.
-// TODO: Follow BIP 0044 and warn if number of unused addresses exceeds
-// the gap limit.
-func getNewAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.GetNewAddressCmd)
+// getAddressUsage handles a getaddressusage request by returning when a wallet
+// address was generated, first seen and last used.
+func getAddressUsage(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetAddressUsageCmd)
 
-	acctName := "default"
-	if cmd.Account != nil {
-		acctName = *cmd.Account
-	}
-	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, acctName)
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
 	if err != nil {
 		return nil, err
 	}
-	addr, err := w.NewAddress(account, waddrmgr.KeyScopeBIP0044)
+
+	usage, err := w.AddressUsage(addr)
+	if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+		return nil, &ErrAddressNotInWallet
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Return the new payment address string.
-	return addr.EncodeAddress(), nil
-}
-
-// getRawChangeAddress handles a getrawchangeaddress request by creating
+	result := walletjson.GetAddressUsageResult{
+		Address:   addr.EncodeAddress(),
+		Generated: usage.Generated.Unix(),
+		Used:      usage.Used,
+	}
+	if !usage.FirstSeen.IsZero() {
+		result.FirstSeen = usage.FirstSeen.Unix()
+	}
+	if !usage.LastUsed.IsZero() {
+		result.LastUsed = usage.LastUsed.Unix()
+	}
+	return result, nil
+}
+
+// importMulti handles an importmulti request by importing many private keys,
+// watch-only addresses and witness scripts with a single rescan.  Requests
+// that cannot be parsed fail without preventing the others from being
+// imported.
+func importMulti(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ImportMultiCmd)
+
+	results := make([]walletjson.ImportMultiResult, len(cmd.Requests))
+	imports := make([]wallet.Import, 0, len(cmd.Requests))
+	indexes := make([]int, 0, len(cmd.Requests))
+	for i, req := range cmd.Requests {
+		imp, err := parseImportMultiRequest(&req, w)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		imports = append(imports, *imp)
+		indexes = append(indexes, i)
+	}
+
+	rescan := true
+	if cmd.Rescan != nil {
+		rescan = *cmd.Rescan
+	}
+	errs, err := w.ImportMulti(waddrmgr.KeyScopeBIP0044, imports, rescan)
+	if err != nil {
+		return nil, err
+	}
+	for i, err := range errs {
+		result := &results[indexes[i]]
+		switch {
+		case err == nil:
+			result.Success = true
+		case waddrmgr.IsError(err, waddrmgr.ErrDuplicateAddress):
+			// Duplicate imports are not errors to the client.
+			result.Success = true
+		default:
+			result.Error = err.Error()
+		}
+	}
+	return results, nil
+}
+
+// importPrivKeys handles an importprivkeys request by importing many private
+// keys to the imported account with a single rescan.  Keys that cannot be
+// decoded or imported fail without preventing the others from being
+// imported, and are reported by their index in the request.
+func importPrivKeys(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ImportPrivKeysCmd)
+
+	var timestamp time.Time
+	if *cmd.Timestamp < 0 {
+		return nil, InvalidParameterError{errors.New("negative timestamp")}
+	}
+	if *cmd.Timestamp != 0 {
+		timestamp = time.Unix(*cmd.Timestamp, 0)
+	}
+	if w.Locked() {
+		return nil, &ErrWalletUnlockNeeded
+	}
+
+	result := walletjson.ImportPrivKeysResult{
+		Errors: []walletjson.ImportPrivKeysError{},
+	}
+	imports := make([]wallet.Import, 0, len(cmd.PrivKeys))
+	indexes := make([]int, 0, len(cmd.PrivKeys))
+	for i, privKey := range cmd.PrivKeys {
+		wif, err := btcutil.DecodeWIF(privKey)
+		if err != nil {
+			result.Errors = append(result.Errors,
+				walletjson.ImportPrivKeysError{
+					Index: i,
+					Error: fmt.Sprintf("WIF decode failed: %v", err),
+				})
+			continue
+		}
+		imports = append(imports, wallet.Import{
+			WIF:       wif,
+			Timestamp: timestamp,
+		})
+		indexes = append(indexes, i)
+	}
+
+	errs, err := w.ImportMulti(waddrmgr.KeyScopeBIP0044, imports,
+		*cmd.Rescan)
+	if err != nil {
+		return nil, err
+	}
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			result.Imported++
+		case waddrmgr.IsError(err, waddrmgr.ErrDuplicateAddress):
+			result.Duplicates++
+		default:
+			result.Errors = append(result.Errors,
+				walletjson.ImportPrivKeysError{
+					Index: indexes[i],
+					Error: err.Error(),
+				})
+		}
+	}
+	sort.Slice(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Index < result.Errors[j].Index
+	})
+	return result, nil
+}
+
+// parseImportMultiRequest parses a request of the importmulti command.
+func parseImportMultiRequest(req *walletjson.ImportMultiRequest,
+	w *wallet.Wallet) (*wallet.Import, error) {
+
+	imp := &wallet.Import{}
+	if req.Timestamp < 0 {
+		return nil, errors.New("negative timestamp")
+	}
+	if req.Timestamp != 0 {
+		imp.Timestamp = time.Unix(req.Timestamp, 0)
+	}
+
+	if req.PrivKey != nil {
+		wif, err := btcutil.DecodeWIF(*req.PrivKey)
+		if err != nil {
+			return nil, fmt.Errorf("WIF decode failed: %v", err)
+		}
+		if !wif.IsForNet(w.ChainParams()) {
+			return nil, fmt.Errorf("key is not intended for %s",
+				w.ChainParams().Name)
+		}
+		imp.WIF = wif
+	}
+	if req.Address != nil {
+		addr, err := addrcheck.Decode(*req.Address, w.ChainParams())
+		if err != nil {
+			return nil, err
+		}
+		imp.Address = addr
+
+		accountName := "default"
+		if req.Account != nil {
+			accountName = *req.Account
+		}
+		account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044,
+			accountName)
+		if err != nil {
+			return nil, err
+		}
+		imp.Account = account
+	}
+	if req.Script != nil {
+		script, err := hex.DecodeString(*req.Script)
+		if err != nil {
+			return nil, fmt.Errorf("invalid script: %v", err)
+		}
+		imp.Script = script
+	}
+	return imp, nil
+}
+
+// importWitnessScript handles an importwitnessscript request by adding a
+// P2WSH witness script to the wallet's script store.  Outputs paying to the
+// P2WSH address or its P2SH-P2WSH address are credited to the imported
+// account.
+func importWitnessScript(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ImportWitnessScriptCmd)
+
+	script, err := decodeHexStr(cmd.Script)
+	if err != nil {
+		return nil, err
+	}
+	p2wshAddr, p2shAddr, err := w.ImportWitnessScript(script)
+	if err != nil {
+		return nil, err
+	}
+
+	return walletjson.ImportWitnessScriptResult{
+		Address:     p2wshAddr.EncodeAddress(),
+		P2SHAddress: p2shAddr.EncodeAddress(),
+	}, nil
+}
+
+// keypoolRefill handles the keypoolrefill command. Since we handle the keypool
+// automatically this does nothing since refilling is never manually required.
+func keypoolRefill(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	return nil, nil
+}
+
+// createNewAccount handles a createnewaccount request by creating and
+// returning a new account. If the last account has no transaction history
+// as per BIP 0044 a new account cannot be created so an error will be returned.
+func createNewAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.CreateNewAccountCmd)
+
+	// The wildcard * is reserved by the rpc server with the special meaning
+	// of "all accounts", so disallow naming accounts to this string.
+	if cmd.Account == "*" {
+		return nil, &ErrReservedAccountName
+	}
+
+	_, err := w.NextAccount(waddrmgr.KeyScopeBIP0044, cmd.Account)
+	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCWalletUnlockNeeded,
+			Message: "Creating an account requires the wallet to be unlocked. " +
+				"Enter the wallet passphrase with walletpassphrase to unlock",
+		}
+	}
+	return nil, err
+}
+
+// createAccountWithPath handles a createaccountwithpath request by creating
+// an account with the key at a custom derivation path.
+func createAccountWithPath(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.CreateAccountWithPathCmd)
+
+	// The wildcard * is reserved by the rpc server with the special meaning
+	// of "all accounts", so disallow naming accounts to this string.
+	if cmd.Account == "*" {
+		return nil, &ErrReservedAccountName
+	}
+	path, err := descriptor.ParsePath(cmd.Path)
+	if err != nil {
+		return nil, InvalidParameterError{err}
+	}
+	if len(path) == 0 {
+		return nil, InvalidParameterError{
+			errors.New("path must not be the master key"),
+		}
+	}
+
+	_, err = w.NextAccountWithPath(waddrmgr.KeyScopeBIP0044, cmd.Account,
+		path)
+	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCWalletUnlockNeeded,
+			Message: "Creating an account requires the wallet to be unlocked. " +
+				"Enter the wallet passphrase with walletpassphrase to unlock",
+		}
+	}
+	return nil, err
+}
+
+// renameAccount handles a renameaccount request by renaming an account.
+// If the account does not exist an appropiate error will be returned.
+func renameAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.RenameAccountCmd)
+
+	// The wildcard * is reserved by the rpc server with the special meaning
+	// of "all accounts", so disallow naming accounts to this string.
+	if cmd.NewAccount == "*" {
+		return nil, &ErrReservedAccountName
+	}
+
+	// Check that given account exists
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.OldAccount)
+	if err != nil {
+		return nil, err
+	}
+	return nil, w.RenameAccount(waddrmgr.KeyScopeBIP0044, account, cmd.NewAccount)
+}
+
+// getNewAddress handles a getnewaddress request by returning a new
+// address for an account.  If the account does not exist an appropiate
+// error is returned.
+// TODO: Follow BIP 0044 and warn if number of unused addresses exceeds
+// the gap limit.
+func getNewAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.GetNewAddressCmd)
+
+	acctName := "default"
+	if cmd.Account != nil {
+		acctName = *cmd.Account
+	}
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, acctName)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := w.NewAddress(account, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the new payment address string.
+	return addr.EncodeAddress(), nil
+}
+
+// reserveAddressIndexes handles a reserveaddressindexes request by reserving
+// a range of external address indexes of an account for addresses derived
+// outside the wallet.
+func reserveAddressIndexes(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ReserveAddressIndexesCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	res, err := w.ReserveAddressIndexes(account, cmd.Count,
+		waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		return nil, err
+	}
+
+	return walletjson.ReserveAddressIndexesResult{
+		Account:    cmd.Account,
+		Branch:     res.Branch,
+		FirstIndex: res.FirstIndex,
+		LastIndex:  res.LastIndex,
+	}, nil
+}
+
+// getNewTaprootAddress handles a getnewtaprootaddress request by returning
+// the next external BIP0086 address of an account, encoded as bech32m.
+func getNewTaprootAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetNewTaprootAddressCmd)
+
+	// Wallets created before taproot support gain the BIP0086 scope the
+	// first time an address is requested, and a new scope only has the
+	// default account, so skip the lookup for it.
+	account := uint32(waddrmgr.DefaultAccountNum)
+	if cmd.Account != nil && *cmd.Account != "default" {
+		var err error
+		account, err = w.AccountNumber(waddrmgr.KeyScopeBIP0086, *cmd.Account)
+		if err != nil {
+			return nil, err
+		}
+	}
+	addr, err := w.NewAddress(account, waddrmgr.KeyScopeBIP0086)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the new payment address string.
+	return addr.EncodeAddress(), nil
+}
+
+// getRawChangeAddress handles a getrawchangeaddress request by creating
 // and returning a new change address for an account.
 //
 // Note: bitcoind allows specifying the account as an optional parameter,
@@ -853,7 +1628,7 @@ func getTransaction(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 
 	// TODO: Add a "generated" field to this result type.  "generated":true
 	// is only added if the transaction is a coinbase.
-	ret := btcjson.GetTransactionResult{
+	ret := walletjson.GetTransactionResult{
 		TxID:            cmd.Txid,
 		Hex:             hex.EncodeToString(txBuf.Bytes()),
 		Time:            details.Received.Unix(),
@@ -868,6 +1643,39 @@ func getTransaction(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 		ret.Confirmations = int64(confirms(details.Block.Height, syncBlock.Height))
 	}
 
+	replaceable, err := w.TxReplaceable(details)
+	if err != nil {
+		return nil, err
+	}
+	ret.BIP125Replaceable = "no"
+	if replaceable {
+		ret.BIP125Replaceable = "yes"
+	}
+	replaces, replacedBy, err := w.TxReplacements(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if replaces != nil {
+		ret.ReplacesTxID = replaces.String()
+	}
+	if replacedBy != nil {
+		ret.ReplacedByTxID = replacedBy.String()
+	}
+	attestation, signed, err := w.TransactionSignerAttestation(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if attestation != nil {
+		ret.SignerAttestation = &walletjson.SignerAttestationResult{
+			Signer:      attestation.Signer,
+			Type:        attestation.DeviceType,
+			Model:       attestation.Model,
+			Fingerprint: attestation.Fingerprint,
+			Evidence:    hex.EncodeToString(attestation.Evidence),
+			Time:        signed.Unix(),
+		}
+	}
+
 	var (
 		debitTotal  btcutil.Amount
 		creditTotal btcutil.Amount // Excludes change
@@ -927,7 +1735,7 @@ func getTransaction(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 
 		var address string
 		var accountName string
-		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(
 			details.MsgTx.TxOut[cred.Index].PkScript, w.ChainParams())
 		if err == nil && len(addrs) == 1 {
 			addr := addrs[0]
@@ -1183,8 +1991,12 @@ func listReceivedByAddress(icmd interface{}, w *wallet.Wallet) (interface{}, err
 		confirmations := confirms(details[0].Block.Height, syncBlock.Height)
 		for _, tx := range details {
 			for _, cred := range tx.Credits {
+				// Change is not received by the wallet.
+				if cred.Change {
+					continue
+				}
 				pkScript := tx.MsgTx.TxOut[cred.Index].PkScript
-				_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+				_, addrs, _, err := taproot.ExtractPkScriptAddrs(
 					pkScript, w.ChainParams())
 				if err != nil {
 					// Non standard script, skip.
@@ -1345,20 +2157,58 @@ func listAllTransactions(icmd interface{}, w *wallet.Wallet) (interface{}, error
 func listUnspent(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	cmd := icmd.(*btcjson.ListUnspentCmd)
 
-	var addresses map[string]struct{}
-	if cmd.Addresses != nil {
-		addresses = make(map[string]struct{})
-		// confirm that all of them are good:
-		for _, as := range *cmd.Addresses {
-			a, err := decodeAddress(as, w.ChainParams())
-			if err != nil {
-				return nil, err
-			}
-			addresses[a.EncodeAddress()] = struct{}{}
+	addresses, err := addressFilter(cmd.Addresses, w)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.ListUnspent(int32(*cmd.MinConf), int32(*cmd.MaxConf), addresses, parseOptionalTokenIdentity(cmd.Token), 0)
+}
+
+// listUnspentFiltered handles the listunspentfiltered command, which is
+// listunspent with a minimum amount filter.
+func listUnspentFiltered(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ListUnspentFilteredCmd)
+
+	if *cmd.MinConf < 0 || *cmd.MaxConf < *cmd.MinConf {
+		return nil, InvalidParameterError{
+			errors.New("minconf must be positive and at most maxconf"),
+		}
+	}
+	addresses, err := addressFilter(cmd.Addresses, w)
+	if err != nil {
+		return nil, err
+	}
+	minAmount, err := btcutil.NewAmount(*cmd.MinimumAmount)
+	if err != nil {
+		return nil, InvalidParameterError{err}
+	}
+	if minAmount < 0 {
+		return nil, InvalidParameterError{
+			errors.New("minimumamount must not be negative"),
 		}
 	}
 
-	return w.ListUnspent(int32(*cmd.MinConf), int32(*cmd.MaxConf), addresses, parseOptionalTokenIdentity(cmd.Token))
+	return w.ListUnspent(int32(*cmd.MinConf), int32(*cmd.MaxConf),
+		addresses, parseOptionalTokenIdentity(cmd.Token), minAmount)
+}
+
+// addressFilter decodes the addresses filtering the outputs listed by
+// listunspent requests, returning a nil filter when there is none.
+func addressFilter(addrs *[]string, w *wallet.Wallet) (map[string]struct{}, error) {
+	if addrs == nil {
+		return nil, nil
+	}
+	addresses := make(map[string]struct{})
+	// confirm that all of them are good:
+	for _, as := range *addrs {
+		a, err := decodeAddress(as, w.ChainParams())
+		if err != nil {
+			return nil, err
+		}
+		addresses[a.EncodeAddress()] = struct{}{}
+	}
+	return addresses, nil
 }
 
 // lockUnspent handles the lockunspent command.
@@ -1397,12 +2247,12 @@ func makeOutputs(pairs map[string]btcutil.Amount, token wire.TokenIdentity, chai
 	delete(pairs, "")
 
 	for addrStr, amt := range pairs {
-		addr, err := btcutil.DecodeAddress(addrStr, chainParams)
+		addr, err := decodeAddress(addrStr, chainParams)
 		if err != nil {
-			return nil, fmt.Errorf("cannot decode address: %s", err)
+			return nil, err
 		}
 
-		pkScript, err := txscript.PayToAddrScript(addr)
+		pkScript, err := taproot.PayToAddrScript(addr)
 		if err != nil {
 			return nil, fmt.Errorf("cannot create txout script: %s", err)
 		}
@@ -1422,13 +2272,25 @@ func makeOutputs(pairs map[string]btcutil.Amount, token wire.TokenIdentity, chai
 // It returns the transaction hash in string format upon success
 // All errors are returned in btcjson.RPCError format
 func sendPairs(w *wallet.Wallet, amounts map[string]btcutil.Amount,
-	account uint32, token wire.TokenIdentity, minconf int32, feeSatPerKb btcutil.Amount) (string, error) {
+	account uint32, token wire.TokenIdentity, minconf int32, feeSatPerKb btcutil.Amount,
+	opts *wallet.TxOptions) (string, error) {
 
 	outputs, err := makeOutputs(amounts, token, w.ChainParams())
 	if err != nil {
 		return "", err
 	}
-	txHash, err := w.SendOutputs(outputs, account, minconf, feeSatPerKb)
+	return sendOutputs(w, outputs, account, minconf, feeSatPerKb, opts)
+}
+
+// sendOutputs creates and sends a payment transaction to outputs.
+// It returns the transaction hash in string format upon success
+// All errors are returned in btcjson.RPCError format
+func sendOutputs(w *wallet.Wallet, outputs []*wire.TxOut, account uint32,
+	minconf int32, feeSatPerKb btcutil.Amount,
+	opts *wallet.TxOptions) (string, error) {
+
+	txHash, err := w.SendOutputs(outputs, account, minconf, feeSatPerKb,
+		opts)
 	if err != nil {
 		if err == txrules.ErrAmountNegative {
 			return "", ErrNeedPositiveAmount
@@ -1439,6 +2301,11 @@ func sendPairs(w *wallet.Wallet, amounts map[string]btcutil.Amount,
 		switch err.(type) {
 		case btcjson.RPCError:
 			return "", err
+		case *wallet.FeeCapError:
+			return "", &btcjson.RPCError{
+				Code:    btcjson.ErrRPCWallet,
+				Message: err.Error(),
+			}
 		}
 
 		return "", &btcjson.RPCError{
@@ -1462,7 +2329,7 @@ func isNilOrEmpty(s *string) bool {
 // the miner are sent back to a new address in the wallet.  Upon success,
 // the TxID for the created transaction is returned.
 func sendFrom(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCClient) (interface{}, error) {
-	cmd := icmd.(*btcjson.SendFromCmd)
+	cmd := icmd.(*walletjson.SendFromCmd)
 
 	// Transaction comments are not yet supported.  Error instead of
 	// pretending to save them.
@@ -1496,8 +2363,13 @@ func sendFrom(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCClient)
 	pairs := map[string]btcutil.Amount{
 		cmd.ToAddress: amt,
 	}
-	return sendPairs(w, pairs, account, parseTokenIdentity(cmd.Token), minConf,
-		txrules.DefaultRelayFeePerKb)
+	travelRule, err := sendTravelRule(cmd.TravelRule)
+	if err != nil {
+		return nil, err
+	}
+	txid, err := sendPairs(w, pairs, account, parseTokenIdentity(cmd.Token),
+		minConf, w.FeeRate(0), nil)
+	return attachTravelRule(w, txid, err, travelRule)
 }
 
 // sendMany handles a sendmany RPC request by creating a new transaction
@@ -1506,7 +2378,7 @@ func sendFrom(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCClient)
 // or a fee for the miner are sent back to a new address in the wallet.
 // Upon success, the TxID for the created transaction is returned.
 func sendMany(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.SendManyCmd)
+	cmd := icmd.(*walletjson.SendManyCmd)
 
 	// Transaction comments are not yet supported.  Error instead of
 	// pretending to save them.
@@ -1537,17 +2409,136 @@ func sendMany(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 		}
 		pairs[k] = amt
 	}
+	travelRule, err := sendTravelRule(cmd.TravelRule)
+	if err != nil {
+		return nil, err
+	}
+	overrideFeeCap := cmd.OverrideFeeCap != nil && *cmd.OverrideFeeCap
+	if cmd.SubtractFeeFrom == nil && cmd.Data == nil &&
+		cmd.ChangeAddress == nil && cmd.ChangeAccount == nil {
 
-	return sendPairs(w, pairs, account, parseTokenIdentity(cmd.Token), minConf, txrules.DefaultRelayFeePerKb)
-}
+		opts := &wallet.TxOptions{OverrideFeeCap: overrideFeeCap}
+		txid, err := sendPairs(w, pairs, account,
+			parseTokenIdentity(cmd.Token), minConf, w.FeeRate(0), opts)
+		return attachTravelRule(w, txid, err, travelRule)
+	}
 
-// sendToAddress handles a sendtoaddress RPC request by creating a new
-// transaction spending unspent transaction outputs for a wallet to another
+	token := parseTokenIdentity(cmd.Token)
+	outputs, err := makeOutputs(pairs, token, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Data != nil {
+		outputs, err = withDataOutput(outputs, *cmd.Data, token)
+		if err != nil {
+			return nil, err
+		}
+	}
+	opts := &wallet.TxOptions{OverrideFeeCap: overrideFeeCap}
+	if cmd.SubtractFeeFrom != nil {
+		opts.SubtractFeeFrom, err = outputIndexes(outputs,
+			*cmd.SubtractFeeFrom, w.ChainParams())
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = setChangeOptions(w, opts, cmd.ChangeAddress, cmd.ChangeAccount)
+	if err != nil {
+		return nil, err
+	}
+	txid, err := sendOutputs(w, outputs, account, minConf, w.FeeRate(0), opts)
+	return attachTravelRule(w, txid, err, travelRule)
+}
+
+// setChangeOptions sets the address or account receiving the change of a
+// transaction from the optional parameters of a request.  Both cannot be set.
+func setChangeOptions(w *wallet.Wallet, opts *wallet.TxOptions,
+	changeAddress, changeAccount *string) error {
+
+	if changeAddress != nil && changeAccount != nil {
+		return InvalidParameterError{errors.New("change address and " +
+			"change account cannot both be set")}
+	}
+	if changeAddress != nil {
+		addr, err := decodeAddress(*changeAddress, w.ChainParams())
+		if err != nil {
+			return err
+		}
+		opts.ChangeAddress = addr
+	}
+	if changeAccount != nil {
+		account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044,
+			*changeAccount)
+		if err != nil {
+			return err
+		}
+		opts.ChangeAccount = &account
+	}
+	return nil
+}
+
+// withDataOutput returns the outputs preceded by a zero-value OP_RETURN output
+// embedding a hex-encoded data payload.  Payloads larger than the data carried
+// by standard OP_RETURN outputs are rejected.
+func withDataOutput(outputs []*wire.TxOut, hexData string,
+	token wire.TokenIdentity) ([]*wire.TxOut, error) {
+
+	data, err := hex.DecodeString(hexData)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDecodeHexString,
+			Message: "Hex string decode failed: " + err.Error(),
+		}
+	}
+	if len(data) > txscript.MaxDataCarrierSize {
+		return nil, InvalidParameterError{fmt.Errorf("data payload of "+
+			"%d bytes exceeds the %d bytes of standard OP_RETURN "+
+			"outputs", len(data), txscript.MaxDataCarrierSize)}
+	}
+	script, err := txscript.NullDataScript(data)
+	if err != nil {
+		return nil, err
+	}
+	// The data output is first so that order outputs, which have no
+	// script, stay last.
+	dataOut := wire.NewTxOutToken(0, script, token)
+	return append([]*wire.TxOut{dataOut}, outputs...), nil
+}
+
+// outputIndexes returns the indexes of the outputs paying the addresses.
+func outputIndexes(outputs []*wire.TxOut, addrs []string,
+	chainParams *chaincfg.Params) ([]int, error) {
+
+	indexes := make([]int, 0, len(addrs))
+	for _, addrStr := range addrs {
+		addr, err := decodeAddress(addrStr, chainParams)
+		if err != nil {
+			return nil, err
+		}
+		pkScript, err := taproot.PayToAddrScript(addr)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create txout script: %s", err)
+		}
+		i := 0
+		for i < len(outputs) && !bytes.Equal(outputs[i].PkScript, pkScript) {
+			i++
+		}
+		if i == len(outputs) {
+			return nil, InvalidParameterError{fmt.Errorf("address %s "+
+				"paying the fee is not a recipient", addrStr)}
+		}
+		indexes = append(indexes, i)
+	}
+	return indexes, nil
+}
+
+// sendToAddress handles a sendtoaddress RPC request by creating a new
+// transaction spending unspent transaction outputs for a wallet to another
 // payment address.  Leftover inputs not sent to the payment address or a fee
 // for the miner are sent back to a new address in the wallet.  Upon success,
 // the TxID for the created transaction is returned.
 func sendToAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.SendToAddressCmd)
+	cmd := icmd.(*walletjson.SendToAddressCmd)
 
 	// Transaction comments are not yet supported.  Error instead of
 	// pretending to save them.
@@ -1573,9 +2564,32 @@ func sendToAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 		cmd.Address: amt,
 	}
 
+	opts := &wallet.TxOptions{
+		OverrideFeeCap: cmd.OverrideFeeCap != nil && *cmd.OverrideFeeCap,
+	}
+	travelRule, err := sendTravelRule(cmd.TravelRule)
+	if err != nil {
+		return nil, err
+	}
+
 	// sendtoaddress always spends from the default account, this matches bitcoind
-	return sendPairs(w, pairs, waddrmgr.DefaultAccountNum, parseTokenIdentity(cmd.Token), 1,
-		txrules.DefaultRelayFeePerKb)
+	if cmd.Data == nil {
+		txid, err := sendPairs(w, pairs, waddrmgr.DefaultAccountNum,
+			parseTokenIdentity(cmd.Token), 1, w.FeeRate(0), opts)
+		return attachTravelRule(w, txid, err, travelRule)
+	}
+	token := parseTokenIdentity(cmd.Token)
+	outputs, err := makeOutputs(pairs, token, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	outputs, err = withDataOutput(outputs, *cmd.Data, token)
+	if err != nil {
+		return nil, err
+	}
+	txid, err := sendOutputs(w, outputs, waddrmgr.DefaultAccountNum, 1,
+		w.FeeRate(0), opts)
+	return attachTravelRule(w, txid, err, travelRule)
 }
 
 // bid handles a bid RPC request
@@ -1646,7 +2660,7 @@ func order(w *wallet.Wallet, token wire.TokenIdentity, amount, payout btcutil.Am
 	}
 
 	return sendPairs(w, pairs, waddrmgr.DefaultAccountNum, token, int32(minConf),
-		txrules.DefaultRelayFeePerKb)
+		w.FeeRate(0), nil)
 }
 
 // setTxFee sets the transaction fee per kilobyte added to transactions.
@@ -1657,11 +2671,134 @@ func setTxFee(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	if cmd.Amount < 0 {
 		return nil, ErrNeedPositiveAmount
 	}
+	feeRate, err := btcutil.NewAmount(cmd.Amount)
+	if err != nil {
+		return nil, InvalidParameterError{err}
+	}
+	w.SetTxFee(feeRate)
 
 	// A boolean true result is returned upon success.
 	return true, nil
 }
 
+// setChangePolicy handles a setchangepolicy request by changing the policy
+// choosing the change addresses of the transactions created by the wallet.
+func setChangePolicy(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SetChangePolicyCmd)
+
+	policy, err := wallet.ParseChangePolicy(cmd.Policy)
+	if err != nil {
+		return nil, InvalidParameterError{err}
+	}
+	w.SetChangePolicy(policy)
+	return nil, nil
+}
+
+// setTravelRule handles a settravelrule request by attaching encrypted
+// originator and beneficiary metadata to a wallet transaction.
+func setTravelRule(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SetTravelRuleCmd)
+
+	txHash, err := chainhash.NewHashFromStr(cmd.TxID)
+	if err != nil {
+		return nil, DeserializationError{err}
+	}
+
+	m, err := travelRuleMetadata(&walletjson.TravelRule{
+		Originator:      cmd.Originator,
+		Beneficiary:     cmd.Beneficiary,
+		OriginatingVASP: cmd.OriginatingVASP,
+		BeneficiaryVASP: cmd.BeneficiaryVASP,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.SetTravelRuleMetadata(txHash, m)
+	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+		return nil, &ErrWalletUnlockNeeded
+	}
+	return nil, err
+}
+
+// travelRuleMetadata returns the validated travel rule metadata of a request.
+func travelRuleMetadata(r *walletjson.TravelRule) (*travelrule.Metadata, error) {
+	m := &travelrule.Metadata{
+		Originator:  travelRuleParty(&r.Originator),
+		Beneficiary: travelRuleParty(&r.Beneficiary),
+	}
+	if r.OriginatingVASP != nil {
+		m.OriginatingVASP = *r.OriginatingVASP
+	}
+	if r.BeneficiaryVASP != nil {
+		m.BeneficiaryVASP = *r.BeneficiaryVASP
+	}
+	if err := m.Validate(); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+	return m, nil
+}
+
+// sendTravelRule returns the validated travel rule metadata of the optional
+// parameter of a send request, or nil when it is not set.  It is validated
+// before the transaction is sent, so that invalid metadata fails the request
+// without sending anything.
+func sendTravelRule(r *walletjson.TravelRule) (*travelrule.Metadata, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return travelRuleMetadata(r)
+}
+
+// attachTravelRule attaches the travel rule metadata of a send request, if
+// any, to the transaction sent by the request, and returns the result of the
+// request.  The transaction is already broadcast when the metadata cannot be
+// stored, so the error reports its hash for the metadata to be attached with
+// settravelrule.
+func attachTravelRule(w *wallet.Wallet, txid string, sendErr error,
+	m *travelrule.Metadata) (interface{}, error) {
+
+	if sendErr != nil {
+		return nil, sendErr
+	}
+	if m == nil {
+		return txid, nil
+	}
+	txHash, err := chainhash.NewHashFromStr(txid)
+	if err == nil {
+		err = w.SetTravelRuleMetadata(txHash, m)
+	}
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCWallet,
+			Message: fmt.Sprintf("transaction %s was sent, but its "+
+				"travel rule metadata was not stored: %v", txid, err),
+		}
+	}
+	return txid, nil
+}
+
+func travelRuleParty(p *walletjson.TravelRuleParty) travelrule.Party {
+	return travelrule.Party{
+		FirstName:      p.FirstName,
+		LastName:       p.LastName,
+		LegalName:      p.LegalName,
+		StreetName:     p.StreetName,
+		BuildingNumber: p.BuildingNumber,
+		PostCode:       p.PostCode,
+		TownName:       p.TownName,
+		Country:        p.Country,
+		NationalID:     p.NationalID,
+		NationalIDType: p.NationalIDType,
+		DateOfBirth:    p.DateOfBirth,
+		PlaceOfBirth:   p.PlaceOfBirth,
+		AccountNumber:  p.AccountNumber,
+	}
+}
+
 // signMessage signs the given message with the private key for the given
 // address
 func signMessage(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
@@ -1672,17 +2809,7 @@ func signMessage(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 		return nil, err
 	}
 
-	privKey, err := w.PrivKeyForAddress(addr)
-	if err != nil {
-		return nil, err
-	}
-
-	var buf bytes.Buffer
-	wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
-	wire.WriteVarString(&buf, 0, cmd.Message)
-	messageHash := chainhash.DoubleHashB(buf.Bytes())
-	sigbytes, err := btcec.SignCompact(btcec.S256(), privKey,
-		messageHash, true)
+	sigbytes, err := w.SignMessage(addr, cmd.Message)
 	if err != nil {
 		return nil, err
 	}
@@ -1693,8 +2820,30 @@ func signMessage(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 // signRawTransaction handles the signrawtransaction command.
 func signRawTransaction(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCClient) (interface{}, error) {
 	cmd := icmd.(*btcjson.SignRawTransactionCmd)
+	return signRawTx(w, chainClient, cmd.RawTx, cmd.Inputs, cmd.PrivKeys,
+		*cmd.Flags)
+}
+
+// signRawTransactionWithWallet handles a signrawtransactionwithwallet request
+// by signing the inputs of a raw transaction with the keys of the wallet.
+// The chain server, when connected, is only queried for the previous outputs
+// that are neither passed with the request nor known to the wallet.
+func signRawTransactionWithWallet(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SignRawTransactionWithWalletCmd)
+	chainClient, _ := w.ChainClient().(*chain.RPCClient)
+	return signRawTx(w, chainClient, cmd.RawTx, cmd.Inputs, nil,
+		*cmd.SigHashType)
+}
+
+// signRawTx signs the inputs of the hex-encoded transaction rawTx with the
+// wallet keys, or only with privKeys when any are passed.  The previous output
+// scripts of the inputs are taken from cmdInputs, the wallet transactions, or
+// are queried from the chain server when it is not nil.
+func signRawTx(w *wallet.Wallet, chainClient *chain.RPCClient, rawTx string,
+	cmdInputs *[]btcjson.RawTxInput, privKeys *[]string,
+	flags string) (interface{}, error) {
 
-	serializedTx, err := decodeHexStr(cmd.RawTx)
+	serializedTx, err := decodeHexStr(rawTx)
 	if err != nil {
 		return nil, err
 	}
@@ -1705,34 +2854,20 @@ func signRawTransaction(icmd interface{}, w *wallet.Wallet, chainClient *chain.R
 		return nil, DeserializationError{e}
 	}
 
-	var hashType txscript.SigHashType
-	switch *cmd.Flags {
-	case "ALL":
-		hashType = txscript.SigHashAll
-	case "NONE":
-		hashType = txscript.SigHashNone
-	case "SINGLE":
-		hashType = txscript.SigHashSingle
-	case "ALL|ANYONECANPAY":
-		hashType = txscript.SigHashAll | txscript.SigHashAnyOneCanPay
-	case "NONE|ANYONECANPAY":
-		hashType = txscript.SigHashNone | txscript.SigHashAnyOneCanPay
-	case "SINGLE|ANYONECANPAY":
-		hashType = txscript.SigHashSingle | txscript.SigHashAnyOneCanPay
-	default:
-		e := errors.New("Invalid sighash parameter")
-		return nil, InvalidParameterError{e}
+	hashType, err := parseSigHashType(flags)
+	if err != nil {
+		return nil, err
 	}
 
 	// TODO: really we probably should look these up with btcd anyway to
 	// make sure that they match the blockchain if present.
 	inputs := make(map[wire.OutPoint][]byte)
 	scripts := make(map[string][]byte)
-	var cmdInputs []btcjson.RawTxInput
-	if cmd.Inputs != nil {
-		cmdInputs = *cmd.Inputs
+	var rawTxInputs []btcjson.RawTxInput
+	if cmdInputs != nil {
+		rawTxInputs = *cmdInputs
 	}
-	for _, rti := range cmdInputs {
+	for _, rti := range rawTxInputs {
 		inputHash, err := chainhash.NewHashFromStr(rti.Txid)
 		if err != nil {
 			return nil, DeserializationError{err}
@@ -1749,7 +2884,7 @@ func signRawTransaction(icmd interface{}, w *wallet.Wallet, chainClient *chain.R
 		// get scripts from the wallet.
 		// Empty strings are ok for this one and hex.DecodeString will
 		// DTRT.
-		if cmd.PrivKeys != nil && len(*cmd.PrivKeys) != 0 {
+		if privKeys != nil && len(*privKeys) != 0 {
 			redeemScript, err := decodeHexStr(rti.RedeemScript)
 			if err != nil {
 				return nil, err
@@ -1768,16 +2903,27 @@ func signRawTransaction(icmd interface{}, w *wallet.Wallet, chainClient *chain.R
 		}] = script
 	}
 
-	// Now we go and look for any inputs that we were not provided by
-	// querying btcd with getrawtransaction. We queue up a bunch of async
-	// requests and will wait for replies after we have checked the rest of
-	// the arguments.
+	// Now we go and look for any inputs that we were not provided and
+	// that the wallet does not know by querying btcd with gettxout. We
+	// queue up a bunch of async requests and will wait for replies after
+	// we have checked the rest of the arguments.
 	requested := make(map[wire.OutPoint]rpcclient.FutureGetTxOutResult)
 	for _, txIn := range tx.TxIn {
 		// Did we get this outpoint from the arguments?
 		if _, ok := inputs[txIn.PreviousOutPoint]; ok {
 			continue
 		}
+		prevOut, err := w.PrevOutput(&txIn.PreviousOutPoint)
+		if err != nil {
+			return nil, err
+		}
+		if prevOut != nil {
+			continue
+		}
+		if chainClient == nil {
+			return nil, InvalidParameterError{fmt.Errorf("previous "+
+				"output %v is unknown", txIn.PreviousOutPoint)}
+		}
 
 		// Asynchronously request the output script.
 		requested[txIn.PreviousOutPoint] = chainClient.GetTxOutAsync(
@@ -1789,10 +2935,10 @@ func signRawTransaction(icmd interface{}, w *wallet.Wallet, chainClient *chain.R
 	// they are the keys that we may use for signing. If empty we will
 	// use any keys known to us already.
 	var keys map[string]*btcutil.WIF
-	if cmd.PrivKeys != nil {
+	if privKeys != nil {
 		keys = make(map[string]*btcutil.WIF)
 
-		for _, key := range *cmd.PrivKeys {
+		for _, key := range *privKeys {
 			wif, err := btcutil.DecodeWIF(key)
 			if err != nil {
 				return nil, DeserializationError{err}
@@ -1820,6 +2966,10 @@ func signRawTransaction(icmd interface{}, w *wallet.Wallet, chainClient *chain.R
 		if err != nil {
 			return nil, err
 		}
+		if result == nil {
+			return nil, InvalidParameterError{fmt.Errorf("input %v "+
+				"not found or already spent", outPoint)}
+		}
 		script, err := hex.DecodeString(result.ScriptPubKey.Hex)
 		if err != nil {
 			return nil, err
@@ -1864,138 +3014,531 @@ func signRawTransaction(icmd interface{}, w *wallet.Wallet, chainClient *chain.R
 	}, nil
 }
 
-// validateAddress handles the validateaddress command.
-func validateAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.ValidateAddressCmd)
-
-	result := btcjson.ValidateAddressWalletResult{}
-	addr, err := decodeAddress(cmd.Address, w.ChainParams())
-	if err != nil {
-		// Use result zero value (IsValid=false).
-		return result, nil
+// parseSigHashType returns the signature hash type named by the sighash
+// parameter of signing requests.
+func parseSigHashType(flags string) (txscript.SigHashType, error) {
+	switch flags {
+	case "ALL":
+		return txscript.SigHashAll, nil
+	case "NONE":
+		return txscript.SigHashNone, nil
+	case "SINGLE":
+		return txscript.SigHashSingle, nil
+	case "ALL|ANYONECANPAY":
+		return txscript.SigHashAll | txscript.SigHashAnyOneCanPay, nil
+	case "NONE|ANYONECANPAY":
+		return txscript.SigHashNone | txscript.SigHashAnyOneCanPay, nil
+	case "SINGLE|ANYONECANPAY":
+		return txscript.SigHashSingle | txscript.SigHashAnyOneCanPay, nil
+	default:
+		e := errors.New("Invalid sighash parameter")
+		return 0, InvalidParameterError{e}
 	}
+}
 
-	// We could put whether or not the address is a script here,
-	// by checking the type of "addr", however, the reference
-	// implementation only puts that information if the script is
-	// "ismine", and we follow that behaviour.
-	result.Address = addr.EncodeAddress()
-	result.IsValid = true
+// fundRawTransaction handles a fundrawtransaction request by adding inputs
+// spending unspent outputs of an account to a raw transaction until they pay
+// for its outputs and fee, and a change output when the leftover value is not
+// dust.  The inputs already in the transaction must spend wallet outputs.  The
+// funded transaction is returned unsigned.
+func fundRawTransaction(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.FundRawTransactionCmd)
 
-	ainfo, err := w.AddressInfo(addr)
+	serializedTx, err := decodeHexStr(cmd.HexTx)
 	if err != nil {
-		if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
-			// No additional information available about the address.
-			return result, nil
-		}
 		return nil, err
 	}
-
-	// The address lookup was successful which means there is further
-	// information about it available and it is "mine".
-	result.IsMine = true
-	acctName, err := w.AccountName(waddrmgr.KeyScopeBIP0044, ainfo.Account())
+	var tx wire.MsgTx
+	err = tx.Deserialize(bytes.NewBuffer(serializedTx))
 	if err != nil {
-		return nil, &ErrAccountNameNotFound
+		e := errors.New("TX decode failed")
+		return nil, DeserializationError{e}
 	}
-	result.Account = acctName
-
-	switch ma := ainfo.(type) {
-	case waddrmgr.ManagedPubKeyAddress:
-		result.IsCompressed = ma.Compressed()
-		result.PubKey = ma.ExportPubKey()
 
-	case waddrmgr.ManagedScriptAddress:
-		result.IsScript = true
+	opts := cmd.Options
+	if opts == nil {
+		opts = new(walletjson.FundRawTransactionOpts)
+	}
 
-		// The script is only available if the manager is unlocked, so
-		// just break out now if there is an error.
-		script, err := ma.Script()
+	account := uint32(waddrmgr.DefaultAccountNum)
+	if opts.FromAccount != nil {
+		account, err = w.AccountNumber(waddrmgr.KeyScopeBIP0044,
+			*opts.FromAccount)
 		if err != nil {
-			break
+			return nil, err
 		}
-		result.Hex = hex.EncodeToString(script)
+	}
 
-		// This typically shouldn't fail unless an invalid script was
-		// imported.  However, if it fails for any reason, there is no
-		// further information available, so just set the script type
-		// a non-standard and break out now.
-		class, addrs, reqSigs, err := txscript.ExtractPkScriptAddrs(
-			script, w.ChainParams())
-		if err != nil {
-			result.Script = txscript.NonStandardTy.String()
-			break
-		}
+	// Check that minconf is positive.
+	minConf := int32(1)
+	if opts.MinConf != nil {
+		minConf = int32(*opts.MinConf)
+	}
+	if minConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
 
-		addrStrings := make([]string, len(addrs))
-		for i, a := range addrs {
-			addrStrings[i] = a.EncodeAddress()
+	changePos := -1
+	if opts.ChangePosition != nil {
+		changePos = *opts.ChangePosition
+		if changePos < -1 || changePos > len(tx.TxOut) {
+			return nil, InvalidParameterError{
+				errors.New("changePosition out of bounds"),
+			}
 		}
-		result.Addresses = addrStrings
+	}
 
-		// Multi-signature scripts also provide the number of required
-		// signatures.
-		result.Script = class.String()
-		if class == txscript.MultiSigTy {
-			result.SigsRequired = int32(reqSigs)
+	// feeRate is in BTC per kilobyte, like the fee rates of Bitcoin Core,
+	// and fee_rate in satoshis per virtual byte.
+	satPerVByte := opts.SatPerVByte
+	if opts.FeeRate != nil {
+		if satPerVByte != nil {
+			return nil, InvalidParameterError{
+				errors.New("feeRate and fee_rate may not be " +
+					"used together"),
+			}
 		}
+		rate := *opts.FeeRate * btcutil.SatoshiPerBitcoin / 1000
+		satPerVByte = &rate
 	}
-
-	return result, nil
-}
-
-// verifyMessage handles the verifymessage command by verifying the provided
-// compact signature for the given address and message.
-func verifyMessage(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.VerifyMessageCmd)
-
-	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	feeRate, err := txFeeRate(w, satPerVByte, opts.ConfTarget)
 	if err != nil {
 		return nil, err
 	}
 
-	// decode base64 signature
-	sig, err := base64.StdEncoding.DecodeString(cmd.Signature)
+	txOpts := &wallet.TxOptions{
+		Replaceable:     opts.Replaceable,
+		SubtractFeeFrom: opts.SubtractFeeFromOutputs,
+		OverrideFeeCap:  opts.OverrideFeeCap != nil && *opts.OverrideFeeCap,
+	}
+	if opts.CoinSelection != nil {
+		txOpts.CoinSelector, err = wallet.CoinSelectorByName(*opts.CoinSelection)
+		if err != nil {
+			return nil, InvalidParameterError{err}
+		}
+	}
+	err = setChangeOptions(w, txOpts, opts.ChangeAddress, opts.ChangeAccount)
 	if err != nil {
 		return nil, err
 	}
+	lockUnspents := opts.LockUnspents != nil && *opts.LockUnspents
 
-	// Validate the signature - this just shows that it was valid at all.
-	// we will compare it with the key next.
-	var buf bytes.Buffer
-	wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
-	wire.WriteVarString(&buf, 0, cmd.Message)
-	expectedMessageHash := chainhash.DoubleHashB(buf.Bytes())
-	pk, wasCompressed, err := btcec.RecoverCompact(btcec.S256(), sig,
-		expectedMessageHash)
+	funded, fee, changePos, err := w.FundRawTransaction(&tx, account,
+		minConf, feeRate, changePos, lockUnspents, txOpts)
 	if err != nil {
 		return nil, err
 	}
 
-	var serializedPubKey []byte
-	if wasCompressed {
-		serializedPubKey = pk.SerializeCompressed()
-	} else {
-		serializedPubKey = pk.SerializeUncompressed()
-	}
-	// Verify that the signed-by address matches the given address
-	switch checkAddr := addr.(type) {
-	case *btcutil.AddressPubKeyHash: // ok
-		return bytes.Equal(btcutil.Hash160(serializedPubKey), checkAddr.Hash160()[:]), nil
-	case *btcutil.AddressPubKey: // ok
-		return string(serializedPubKey) == checkAddr.String(), nil
-	default:
-		return nil, errors.New("address type not supported")
+	var buf bytes.Buffer
+	buf.Grow(funded.SerializeSize())
+	if err := funded.Serialize(&buf); err != nil {
+		return nil, err
 	}
+	return walletjson.FundRawTransactionResult{
+		Hex:       hex.EncodeToString(buf.Bytes()),
+		Fee:       fee.ToBTC(),
+		ChangePos: changePos,
+	}, nil
+}
+
+// walletCreateFundedPsbt handles a walletcreatefundedpsbt request by
+// authoring an unsigned transaction spending unspent transaction outputs of
+// an account to any number of payment addresses, and returning it as a PSBT
+// for external signers.  Leftover inputs not sent to the payment addresses or
+// a fee for the miner are sent back to a new address in the wallet.
+func walletCreateFundedPsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.WalletCreateFundedPsbtCmd)
+
+	account := uint32(waddrmgr.DefaultAccountNum)
+	if cmd.FromAccount != nil {
+		var err error
+		account, err = w.AccountNumber(waddrmgr.KeyScopeBIP0044,
+			*cmd.FromAccount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check that minconf is positive.
+	minConf := int32(1)
+	if cmd.MinConf != nil {
+		minConf = int32(*cmd.MinConf)
+	}
+	if minConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
+
+	// Orders are published as a whole and can't be signed elsewhere.
+	pairs := make(map[string]btcutil.Amount, len(cmd.Amounts))
+	for k, v := range cmd.Amounts {
+		if k == "" {
+			return nil, InvalidParameterError{
+				errors.New("orders can not be created as a PSBT"),
+			}
+		}
+		amt, err := btcutil.NewAmount(v)
+		if err != nil {
+			return nil, err
+		}
+		pairs[k] = amt
+	}
+	outputs, err := makeOutputs(pairs, parseTokenIdentity(cmd.Token),
+		w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	for _, output := range outputs {
+		err := txrules.CheckOutput(output, txrules.DefaultRelayFeePerKb)
+		if err == txrules.ErrAmountNegative {
+			return nil, ErrNeedPositiveAmount
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts := &wallet.TxOptions{
+		Replaceable:    cmd.Replaceable,
+		OverrideFeeCap: cmd.OverrideFeeCap != nil && *cmd.OverrideFeeCap,
+	}
+	if cmd.CoinSelection != nil {
+		opts.CoinSelector, err = wallet.CoinSelectorByName(*cmd.CoinSelection)
+		if err != nil {
+			return nil, InvalidParameterError{err}
+		}
+	}
+	if cmd.LockTime != nil {
+		if *cmd.LockTime < 0 || *cmd.LockTime > math.MaxUint32 {
+			return nil, InvalidParameterError{
+				errors.New("locktime out of range"),
+			}
+		}
+		lockTime := uint32(*cmd.LockTime)
+		opts.LockTime = &lockTime
+	}
+
+	feeRate, err := txFeeRate(w, cmd.FeeRate, cmd.ConfTarget)
+	if err != nil {
+		return nil, err
+	}
+	packet, fee, changePos, err := w.FundPsbt(outputs, account, minConf,
+		feeRate, opts)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := packet.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return walletjson.WalletCreateFundedPsbtResult{
+		Psbt:      encoded,
+		Fee:       fee.ToBTC(),
+		ChangePos: changePos,
+	}, nil
+}
+
+// walletProcessPsbt handles a walletprocesspsbt request by updating a PSBT
+// with the information known to the wallet, signing its inputs with wallet
+// keys when requested, and finalizing the inputs that have all of their
+// signatures.
+func walletProcessPsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.WalletProcessPsbtCmd)
+
+	packet, err := psbt.Decode(cmd.Psbt)
+	if err != nil {
+		return nil, DeserializationError{err}
+	}
+	sign := cmd.Sign == nil || *cmd.Sign
+	hashType := txscript.SigHashAll
+	if cmd.SigHashType != nil {
+		hashType, err = parseSigHashType(*cmd.SigHashType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	complete, err := w.ProcessPsbt(packet, sign, hashType)
+	if err != nil {
+		if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+			return nil, &ErrWalletUnlockNeeded
+		}
+		return nil, err
+	}
+	encoded, err := packet.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return walletjson.WalletProcessPsbtResult{
+		Psbt:     encoded,
+		Complete: complete,
+	}, nil
+}
+
+// finalizePsbt handles a finalizepsbt request by finalizing the inputs of a
+// PSBT that have all of their signatures.  When every input is finalized,
+// the signed transaction is returned instead of the PSBT, unless extraction
+// was not requested.
+func finalizePsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.FinalizePsbtCmd)
+
+	packet, err := psbt.Decode(cmd.Psbt)
+	if err != nil {
+		return nil, DeserializationError{err}
+	}
+	packet.FinalizeAll()
+	complete := packet.IsComplete()
+
+	if complete && (cmd.Extract == nil || *cmd.Extract) {
+		tx, err := packet.Extract()
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		buf.Grow(tx.SerializeSize())
+		if err := tx.Serialize(&buf); err != nil {
+			return nil, err
+		}
+		return walletjson.FinalizePsbtResult{
+			Hex:      hex.EncodeToString(buf.Bytes()),
+			Complete: true,
+		}, nil
+	}
+
+	encoded, err := packet.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return walletjson.FinalizePsbtResult{
+		Psbt:     encoded,
+		Complete: complete,
+	}, nil
+}
+
+// defaultQRPartLen is the default number of characters of the QR code parts
+// returned by exportpsbt, which keeps each QR code readable by the cameras
+// of signing devices.
+const defaultQRPartLen = 400
+
+// exportPsbt handles an exportpsbt request by returning a PSBT as the parts
+// of an animated QR code and writing it to a file for an offline signer.
+func exportPsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ExportPsbtCmd)
+
+	packet, err := psbt.Decode(cmd.Psbt)
+	if err != nil {
+		return nil, DeserializationError{err}
+	}
+	partLen := defaultQRPartLen
+	if cmd.QRPartLen != nil {
+		partLen = *cmd.QRPartLen
+	}
+
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	parts, err := bbqr.Split(buf.Bytes(), bbqr.PSBT, partLen)
+	if err != nil {
+		return nil, InvalidParameterError{err}
+	}
+
+	result := walletjson.ExportPsbtResult{QRParts: parts}
+	if cmd.File != nil && *cmd.File != "" {
+		if err := wallet.WritePsbtFile(*cmd.File, packet); err != nil {
+			return nil, err
+		}
+		result.File = *cmd.File
+	}
+	return result, nil
+}
+
+// importSignedTx handles an importsignedtx request by broadcasting a
+// transaction signed by an offline signer, which is read from a file or
+// passed as the scanned parts of an animated QR code or as text.
+func importSignedTx(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ImportSignedTxCmd)
+
+	var data []byte
+	switch {
+	case cmd.File != nil && *cmd.File != "":
+		if len(cmd.Parts) != 0 {
+			return nil, InvalidParameterError{
+				errors.New("parts and file can not both be set"),
+			}
+		}
+		var err error
+		data, err = ioutil.ReadFile(*cmd.File)
+		if err != nil {
+			return nil, err
+		}
+
+	case len(cmd.Parts) != 0 && strings.HasPrefix(cmd.Parts[0], "B$"):
+		var err error
+		data, _, err = bbqr.Join(cmd.Parts)
+		if err != nil {
+			return nil, DeserializationError{err}
+		}
+
+	case len(cmd.Parts) == 1:
+		data = []byte(cmd.Parts[0])
+
+	default:
+		return nil, InvalidParameterError{
+			errors.New("expected the QR code parts, a single PSBT " +
+				"or transaction, or a file"),
+		}
+	}
+
+	tx, err := wallet.ParseSignedTx(data)
+	if err != nil {
+		return nil, DeserializationError{err}
+	}
+	txHash, err := w.PublishSignedTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	return txHash.String(), nil
+}
+
+// validateAddress handles the validateaddress command.
+func validateAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.ValidateAddressCmd)
+
+	result := btcjson.ValidateAddressWalletResult{}
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		// Use result zero value (IsValid=false).
+		return result, nil
+	}
+
+	// We could put whether or not the address is a script here,
+	// by checking the type of "addr", however, the reference
+	// implementation only puts that information if the script is
+	// "ismine", and we follow that behaviour.
+	result.Address = addr.EncodeAddress()
+	result.IsValid = true
+
+	ainfo, err := w.AddressInfo(addr)
+	if err != nil {
+		if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+			// No additional information available about the address.
+			return result, nil
+		}
+		return nil, err
+	}
+
+	// The address lookup was successful which means there is further
+	// information about it available and it is "mine".
+	result.IsMine = true
+	acctName, err := w.AccountName(waddrmgr.KeyScopeBIP0044, ainfo.Account())
+	if err != nil {
+		return nil, &ErrAccountNameNotFound
+	}
+	result.Account = acctName
+
+	switch ma := ainfo.(type) {
+	case waddrmgr.ManagedPubKeyAddress:
+		result.IsCompressed = ma.Compressed()
+		result.PubKey = ma.ExportPubKey()
+
+	case waddrmgr.ManagedScriptAddress:
+		result.IsScript = true
+
+		// The script is only available if the manager is unlocked, so
+		// just break out now if there is an error.
+		script, err := ma.Script()
+		if err != nil {
+			break
+		}
+		result.Hex = hex.EncodeToString(script)
+
+		// This typically shouldn't fail unless an invalid script was
+		// imported.  However, if it fails for any reason, there is no
+		// further information available, so just set the script type
+		// a non-standard and break out now.
+		class, addrs, reqSigs, err := taproot.ExtractPkScriptAddrs(
+			script, w.ChainParams())
+		if err != nil {
+			result.Script = txscript.NonStandardTy.String()
+			break
+		}
+
+		addrStrings := make([]string, len(addrs))
+		for i, a := range addrs {
+			addrStrings[i] = a.EncodeAddress()
+		}
+		result.Addresses = addrStrings
+
+		// Multi-signature scripts also provide the number of required
+		// signatures.
+		result.Script = class.String()
+		if class == txscript.MultiSigTy {
+			result.SigsRequired = int32(reqSigs)
+		}
+	}
+
+	return result, nil
+}
+
+// verifyMessage handles the verifymessage command by verifying the provided
+// compact signature for the given address and message.
+func verifyMessage(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.VerifyMessageCmd)
+
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+
+	// decode base64 signature
+	sig, err := base64.StdEncoding.DecodeString(cmd.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compact signatures are always 65 bytes, while BIP0322 simple
+	// signatures of P2WPKH and P2TR addresses are serialized witnesses.
+	switch addr.(type) {
+	case *btcutil.AddressWitnessPubKeyHash:
+		if len(sig) != 65 {
+			return wallet.VerifyMessageBIP322(addr, sig, cmd.Message)
+		}
+	case *taproot.AddressTaproot:
+		return wallet.VerifyMessageBIP322(addr, sig, cmd.Message)
+	}
+	return wallet.VerifyMessage(addr, sig, cmd.Message, w.ChainParams())
+}
+
+// walletIsLocked handles the walletislocked extension request by
+// returning the current lock state (false for unlocked, true for locked)
+// of an account.
+func walletIsLocked(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	return w.Locked(), nil
+}
+
+// getUnlockTimeout handles a getunlocktimeout request by returning whether the
+// wallet is locked and, when it was unlocked with a timeout, the time it is
+// relocked and the number of seconds remaining until then.
+func getUnlockTimeout(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	locked, lockAt := w.UnlockExpiration()
+	result := walletjson.GetUnlockTimeoutResult{Locked: locked}
+	if locked || lockAt.IsZero() {
+		return result, nil
+	}
+
+	// The wallet may remain unlocked past the timeout while an operation
+	// holds the unlock.
+	remaining := time.Until(lockAt)
+	if remaining < 0 {
+		remaining = 0
+	}
+	result.UnlockedUntil = lockAt.Unix()
+	result.Remaining = int64((remaining + time.Second - 1) / time.Second)
+	return result, nil
 }
 
-// walletIsLocked handles the walletislocked extension request by
-// returning the current lock state (false for unlocked, true for locked)
-// of an account.
-func walletIsLocked(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	return w.Locked(), nil
-}
-
 // walletLock handles a walletlock request by locking the all account
 // wallets, returning an error if any wallet is not encrypted (for example,
 // a watching-only wallet).
@@ -2004,21 +3547,1852 @@ func walletLock(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	return nil, nil
 }
 
-// walletPassphrase responds to the walletpassphrase request by unlocking
-// the wallet.  The decryption key is saved in the wallet until timeout
-// seconds expires, after which the wallet is locked.
-func walletPassphrase(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.WalletPassphraseCmd)
+// walletPassphrase responds to the walletpassphrase request by unlocking
+// the wallet.  The decryption key is saved in the wallet until timeout
+// seconds expires, after which the wallet is locked.
+func walletPassphrase(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.WalletPassphraseCmd)
+
+	// The passphrase is only held in a secure buffer for the unlock.
+	passphrase := zero.NewBufferFrom([]byte(cmd.Passphrase))
+	defer passphrase.Destroy()
+
+	timeout := time.Second * time.Duration(cmd.Timeout)
+	if cmd.Account != nil {
+		account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044,
+			*cmd.Account)
+		if err != nil {
+			return nil, err
+		}
+		err = w.UnlockAccount(waddrmgr.KeyScopeBIP0044, account,
+			passphrase.Bytes(), timeout)
+		return nil, err
+	}
+	err := w.UnlockFor(passphrase.Bytes(), timeout)
+	return nil, err
+}
+
+// setAccountPassphrase handles a setaccountpassphrase request by protecting an
+// account with its own passphrase, or removing the passphrase of the account
+// when the passphrase is empty.
+func setAccountPassphrase(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SetAccountPassphraseCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	passphrase := zero.NewBufferFrom([]byte(cmd.Passphrase))
+	defer passphrase.Destroy()
+
+	err = w.SetAccountPassphrase(waddrmgr.KeyScopeBIP0044, account,
+		passphrase.Bytes())
+	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+		return nil, &ErrWalletUnlockNeeded
+	}
+	return nil, err
+}
+
+// getOperationSequence handles a getoperationsequence request by returning the
+// wallet operation sequence.
+func getOperationSequence(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	return w.OperationSequence(), nil
+}
+
+// getAccountFootprint handles a getaccountfootprint request by returning the
+// size of the transactions of the accounts, and their quotas.
+func getAccountFootprint(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetAccountFootprintCmd)
+
+	footprints, err := w.AccountFootprints()
+	if err != nil {
+		return nil, err
+	}
+	quotas := w.AccountQuotas()
+
+	var names []string
+	if cmd.Account != nil {
+		_, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *cmd.Account)
+		if err != nil {
+			return nil, err
+		}
+		names = []string{*cmd.Account}
+	} else {
+		for name := range footprints {
+			names = append(names, name)
+		}
+		for name := range quotas {
+			if _, ok := footprints[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	results := make([]walletjson.GetAccountFootprintResult, 0, len(names))
+	for _, name := range names {
+		results = append(results, walletjson.GetAccountFootprintResult{
+			Account:   name,
+			Footprint: footprints[name],
+			Quota:     quotas[name],
+		})
+	}
+	return results, nil
+}
+
+// getAuditLog handles a getauditlog request by returning the records of the
+// audit log, after verifying their chain of hashes.
+func getAuditLog(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetAuditLogCmd)
+	if *cmd.Count < 0 {
+		return nil, InvalidParameterError{
+			errors.New("count must not be negative"),
+		}
+	}
+
+	records, err := w.AuditLog(*cmd.From, *cmd.Count)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]walletjson.GetAuditLogResult, 0, len(records))
+	for i := range records {
+		r := &records[i]
+		results = append(results, walletjson.GetAuditLogResult{
+			Seq:       r.Seq,
+			Time:      r.Time.Unix(),
+			Operation: r.Operation,
+			Details:   r.Details,
+			Hash:      hex.EncodeToString(r.Hash[:]),
+			PrevHash:  hex.EncodeToString(r.PrevHash[:]),
+		})
+	}
+	return results, nil
+}
+
+// getStateCommitment handles a getstatecommitment request by returning the
+// commitment to the unspent outputs and balances of the wallet at a height.
+func getStateCommitment(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetStateCommitmentCmd)
+
+	height := int32(-1)
+	if cmd.Height != nil {
+		if *cmd.Height < 0 {
+			return nil, InvalidParameterError{
+				errors.New("height must not be negative"),
+			}
+		}
+		height = *cmd.Height
+	}
+
+	c, err := w.StateCommitment(height)
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]walletjson.StateCommitmentBalance, 0, len(c.Balances))
+	for _, b := range c.Balances {
+		balances = append(balances, walletjson.StateCommitmentBalance{
+			Account: b.Account,
+			Token:   b.Token,
+			Amount:  b.Amount.ToBTC(),
+		})
+	}
+	return &walletjson.GetStateCommitmentResult{
+		Height:      c.Height,
+		BlockHash:   c.BlockHash.String(),
+		Utxos:       c.Utxos,
+		UtxoRoot:    c.UtxoRoot.String(),
+		Balances:    balances,
+		BalanceRoot: c.BalanceRoot.String(),
+		AuditSeq:    c.AuditSeq,
+		AuditHash:   hex.EncodeToString(c.AuditHash[:]),
+		Root:        c.Root.String(),
+	}, nil
+}
+
+// getProofOfReserves handles a getproofofreserves request by returning the
+// unspent outputs of the addresses of the wallet, each address signing a
+// message committing to the synced block and the challenge.
+func getProofOfReserves(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetProofOfReservesCmd)
+
+	proof, err := w.ProveReserves(cmd.Challenge)
+	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+		return nil, &ErrWalletUnlockNeeded
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &walletjson.GetProofOfReservesResult{
+		Height:    proof.Height,
+		BlockHash: proof.BlockHash.String(),
+		Message:   proof.Message,
+		Addresses: make([]walletjson.ReserveAddress, 0, len(proof.Addresses)),
+	}
+	type total struct {
+		amount, signed btcutil.Amount
+	}
+	var tokens []string
+	totals := make(map[string]*total)
+	for _, r := range proof.Addresses {
+		addr := walletjson.ReserveAddress{
+			Address: r.Address.EncodeAddress(),
+			Outputs: make([]walletjson.ReserveOutput, 0, len(r.Outputs)),
+		}
+		if r.Signature != nil {
+			addr.Signature = base64.StdEncoding.EncodeToString(r.Signature)
+		}
+		for _, output := range r.Outputs {
+			addr.Outputs = append(addr.Outputs, walletjson.ReserveOutput{
+				TxID:   output.OutPoint.Hash.String(),
+				Vout:   output.OutPoint.Index,
+				Height: output.Height,
+				Amount: output.Amount.ToBTC(),
+				Token:  output.Token,
+			})
+			t, ok := totals[output.Token]
+			if !ok {
+				t = new(total)
+				totals[output.Token] = t
+				tokens = append(tokens, output.Token)
+			}
+			t.amount += output.Amount
+			if r.Signature != nil {
+				t.signed += output.Amount
+			}
+		}
+		result.Addresses = append(result.Addresses, addr)
+	}
+	sort.Strings(tokens)
+	for _, token := range tokens {
+		result.Totals = append(result.Totals, walletjson.ReserveTotal{
+			Token:  token,
+			Amount: totals[token].amount.ToBTC(),
+			Signed: totals[token].signed.ToBTC(),
+		})
+	}
+	return result, nil
+}
+
+// enrollTOTP handles an enrolltotp request by enrolling a new TOTP secret and
+// returning it to be added to an authenticator app.
+func enrollTOTP(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	secret, err := w.EnrollTOTP()
+	if err != nil {
+		return nil, err
+	}
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).
+		EncodeToString(secret)
+	uri := url.URL{
+		Scheme: "otpauth",
+		Host:   "totp",
+		Path:   "/btcwallet",
+		RawQuery: url.Values{
+			"secret": {encoded},
+			"issuer": {"btcwallet"},
+		}.Encode(),
+	}
+	return &walletjson.EnrollTOTPResult{
+		Secret: encoded,
+		URI:    uri.String(),
+	}, nil
+}
+
+// disableTOTP handles a disabletotp request by removing the enrolled TOTP
+// secret.
+func disableTOTP(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	return nil, w.DisableTOTP()
+}
+
+// signMessageBIP322 handles a signmessagebip322 request by returning the
+// base64-encoded BIP0322 simple signature of a message by the key of a P2WPKH
+// or P2TR address.
+func signMessageBIP322(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SignMessageBIP322Cmd)
+
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := w.SignMessageBIP322(addr, cmd.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// pinAddressPool handles a pinaddresspool request by adding an address pool
+// derived from an external descriptor, or updating the window and pinned
+// accounts of an existing pool.
+func pinAddressPool(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.PinAddressPoolCmd)
+
+	pool := &wallet.AddressPool{
+		Name:       cmd.Name,
+		Descriptor: cmd.Descriptor,
+		Window:     *cmd.Window,
+	}
+	if cmd.Accounts != nil {
+		for _, name := range *cmd.Accounts {
+			account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044,
+				name)
+			if err != nil {
+				return nil, err
+			}
+			pool.Accounts = append(pool.Accounts, account)
+		}
+	}
+	if err := w.PinAddressPool(pool); err != nil {
+		return nil, InvalidParameterError{err}
+	}
+	return nil, nil
+}
+
+// unpinAddressPool handles an unpinaddresspool request by removing an address
+// pool.
+func unpinAddressPool(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.UnpinAddressPoolCmd)
+	return nil, w.UnpinAddressPool(cmd.Name)
+}
+
+// listAddressPools handles a listaddresspools request by returning the
+// address pools and the accounts pinned to them.
+func listAddressPools(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	pools, err := w.AddressPools()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]walletjson.ListAddressPoolsResult, 0, len(pools))
+	for i := range pools {
+		p := &pools[i]
+		accounts := make([]string, 0, len(p.Accounts))
+		for _, account := range p.Accounts {
+			name, err := w.AccountName(waddrmgr.KeyScopeBIP0044, account)
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, name)
+		}
+		results = append(results, walletjson.ListAddressPoolsResult{
+			Name:       p.Name,
+			Descriptor: p.Descriptor,
+			NextIndex:  p.NextIndex,
+			Window:     p.Window,
+			Accounts:   accounts,
+		})
+	}
+	return results, nil
+}
+
+// getPoolAddress handles a getpooladdress request by returning the next
+// address of an address pool.
+func getPoolAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetPoolAddressCmd)
+
+	addr, err := w.NextPoolAddress(cmd.Name)
+	if err != nil {
+		return nil, err
+	}
+	return addr.EncodeAddress(), nil
+}
+
+// consolidateChange handles a consolidatechange request by consolidating the
+// legacy and segwit v0 change outputs of every account into taproot outputs,
+// or only reporting the consolidations for dry runs.
+func consolidateChange(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ConsolidateChangeCmd)
+	if *cmd.MaxInputs < 1 {
+		return nil, InvalidParameterError{
+			errors.New("maxinputs must be positive"),
+		}
+	}
+
+	var feeRate btcutil.Amount
+	if cmd.FeeRate != nil {
+		var err error
+		feeRate, err = btcutil.NewAmount(*cmd.FeeRate)
+		if err != nil {
+			return nil, err
+		}
+		if feeRate < 0 {
+			return nil, InvalidParameterError{
+				errors.New("feerate must not be negative"),
+			}
+		}
+	} else {
+		var err error
+		feeRate, err = w.EstimateFeeRate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report, err := w.ConsolidateChange(feeRate, *cmd.MaxInputs, *cmd.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	return consolidationReportResult(w, report)
+}
+
+// getConsolidationReport handles a getconsolidationreport request by
+// returning the report of the last consolidation, or null if there was none.
+func getConsolidationReport(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	report := w.LastConsolidationReport()
+	if report == nil {
+		return nil, nil
+	}
+	return consolidationReportResult(w, report)
+}
+
+// consolidationReportResult returns the JSON result of a consolidation
+// report.
+func consolidationReportResult(w *wallet.Wallet,
+	report *wallet.ConsolidationReport) (*walletjson.ConsolidationReportResult, error) {
+
+	result := &walletjson.ConsolidationReportResult{
+		Time:           report.Time.Unix(),
+		FeeRate:        report.FeeRate.ToBTC(),
+		DryRun:         report.DryRun,
+		Consolidations: make([]walletjson.ConsolidationResult, 0, len(report.Consolidations)),
+	}
+	for i := range report.Consolidations {
+		c := &report.Consolidations[i]
+		account, err := w.AccountName(waddrmgr.KeyScopeBIP0044, c.Account)
+		if err != nil {
+			return nil, err
+		}
+		r := walletjson.ConsolidationResult{
+			Account: account,
+			Token:   c.Token.String(),
+			Inputs:  make([]string, 0, len(c.Inputs)),
+			Amount:  c.Amount.ToBTC(),
+			Fee:     c.Fee.ToBTC(),
+		}
+		for _, op := range c.Inputs {
+			r.Inputs = append(r.Inputs, op.String())
+		}
+		if c.Address != nil {
+			r.Address = c.Address.EncodeAddress()
+		}
+		if c.Hash != nil {
+			r.TxID = c.Hash.String()
+		}
+		if c.Err != nil {
+			r.Error = c.Err.Error()
+		}
+		result.Consolidations = append(result.Consolidations, r)
+	}
+	return result, nil
+}
+
+// consolidateUTXOs handles a consolidateutxos request by spending the small
+// outputs of an account to a single change address of the account.
+func consolidateUTXOs(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ConsolidateUTXOsCmd)
+
+	threshold, err := btcutil.NewAmount(cmd.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	if threshold <= 0 {
+		return nil, InvalidParameterError{
+			errors.New("threshold must be positive"),
+		}
+	}
+	if *cmd.MaxInputs < 2 {
+		return nil, InvalidParameterError{
+			errors.New("maxinputs must be at least 2"),
+		}
+	}
+	minConf := int32(*cmd.MinConf)
+	if minConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *cmd.FromAccount)
+	if err != nil {
+		return nil, err
+	}
+	feeRate, err := txFeeRate(w, cmd.FeeRate, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sweep, addr, err := w.ConsolidateUTXOs(account,
+		parseTokenIdentity(cmd.Token), threshold, *cmd.MaxInputs, minConf,
+		feeRate)
+	if err != nil {
+		if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+			return nil, &ErrWalletUnlockNeeded
+		}
+		return nil, err
+	}
+	return &walletjson.ConsolidateUTXOsResult{
+		TxID:    sweep.Hash.String(),
+		Address: addr.EncodeAddress(),
+		Amount:  sweep.Amounts[0].ToBTC(),
+		Fee:     sweep.Fee.ToBTC(),
+		Inputs:  sweep.Inputs,
+	}, nil
+}
+
+// getDustPolicy handles a getdustpolicy request by returning the dust limits
+// of created outputs and the quarantine threshold of received outputs.
+func getDustPolicy(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	policy := w.DustPolicy()
+	limits := policy.DustLimits()
+	result := walletjson.GetDustPolicyResult{
+		DustRelayFee:     policy.RelayFeePerKb.ToBTC(),
+		RejectDustChange: policy.RejectChange,
+		DustLimits:       make(map[string]float64, len(limits)),
+	}
+	for addrType, limit := range limits {
+		result.DustLimits[addrType] = limit.ToBTC()
+	}
+	threshold, spendable := w.DustQuarantine()
+	result.QuarantineThreshold = threshold.ToBTC()
+	result.SpendQuarantined = spendable
+	return result, nil
+}
+
+// freezeAccount handles a freezeaccount request by blocking the spends of the
+// outputs of an account until it is unfrozen.
+func freezeAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.FreezeAccountCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	var reason string
+	if cmd.Reason != nil {
+		reason = *cmd.Reason
+	}
+	return nil, w.FreezeAccount(account, reason)
+}
+
+// unfreezeAccount handles an unfreezeaccount request by lifting the freeze of
+// an account, given the private passphrase of the wallet.
+func unfreezeAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.UnfreezeAccountCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	passphrase := zero.NewBufferFrom([]byte(cmd.Passphrase))
+	defer passphrase.Destroy()
+
+	err = w.UnfreezeAccount(account, passphrase.Bytes())
+	if waddrmgr.IsError(err, waddrmgr.ErrWrongPassphrase) {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWalletPassphraseIncorrect,
+			Message: "Incorrect passphrase",
+		}
+	}
+	return nil, err
+}
+
+// listFrozenAccounts handles a listfrozenaccounts request by returning the
+// frozen accounts.
+func listFrozenAccounts(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	frozen := w.FrozenAccounts()
+	results := make([]walletjson.ListFrozenAccountsResult, len(frozen))
+	for i, f := range frozen {
+		account, err := w.AccountName(waddrmgr.KeyScopeBIP0044, f.Account)
+		if err != nil {
+			account = fmt.Sprint(f.Account)
+		}
+		results[i] = walletjson.ListFrozenAccountsResult{
+			Account: account,
+			Frozen:  f.Frozen.Unix(),
+			Reason:  f.Reason,
+		}
+	}
+	return results, nil
+}
+
+// getDerivationProof handles a getderivationproof request by returning the
+// proof that an address is derived from the extended public key of its
+// account.
+func getDerivationProof(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetDerivationProofCmd)
+
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	proof, err := w.DerivationProof(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	accountPath := descriptor.FormatPath(proof.AccountPath)
+	result := &walletjson.GetDerivationProofResult{
+		Address:     proof.Address,
+		AddressType: proof.AddressType,
+		AccountXPub: proof.AccountXPub,
+		AccountPath: accountPath,
+		Path: fmt.Sprintf("%s/%d/%d", accountPath, proof.Branch,
+			proof.Index),
+		Steps:     make([]walletjson.DerivationProofStep, len(proof.Steps)),
+		OutputKey: hex.EncodeToString(proof.OutputKey),
+	}
+	for i, step := range proof.Steps {
+		result.Steps[i] = walletjson.DerivationProofStep{
+			Index:          step.Index,
+			ParentKey:      hex.EncodeToString(step.ParentKey),
+			ChainCode:      hex.EncodeToString(step.ChainCode),
+			Tweak:          hex.EncodeToString(step.Tweak),
+			ChildKey:       hex.EncodeToString(step.ChildKey),
+			ChildChainCode: hex.EncodeToString(step.ChildChainCode),
+		}
+	}
+	return result, nil
+}
+
+// prepareTransaction handles a preparetransaction request by performing the
+// coin selection and fee calculation of a transaction paying to any number of
+// payment addresses, and returning its inputs, outputs, fee and virtual size
+// without signing nor sending it.
+func prepareTransaction(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.PrepareTransactionCmd)
+
+	account := uint32(waddrmgr.DefaultAccountNum)
+	if cmd.FromAccount != nil {
+		var err error
+		account, err = w.AccountNumber(waddrmgr.KeyScopeBIP0044,
+			*cmd.FromAccount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check that minconf is positive.
+	minConf := int32(1)
+	if cmd.MinConf != nil {
+		minConf = int32(*cmd.MinConf)
+	}
+	if minConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
+
+	pairs := make(map[string]btcutil.Amount, len(cmd.Amounts))
+	for k, v := range cmd.Amounts {
+		if k == "" {
+			return nil, InvalidParameterError{
+				errors.New("orders can not be prepared"),
+			}
+		}
+		amt, err := btcutil.NewAmount(v)
+		if err != nil {
+			return nil, err
+		}
+		pairs[k] = amt
+	}
+	outputs, err := makeOutputs(pairs, parseTokenIdentity(cmd.Token),
+		w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	for _, output := range outputs {
+		err := txrules.CheckOutput(output, txrules.DefaultRelayFeePerKb)
+		if err == txrules.ErrAmountNegative {
+			return nil, ErrNeedPositiveAmount
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts := &wallet.TxOptions{}
+	if cmd.CoinSelection != nil {
+		opts.CoinSelector, err = wallet.CoinSelectorByName(*cmd.CoinSelection)
+		if err != nil {
+			return nil, InvalidParameterError{err}
+		}
+	}
+	if cmd.SubtractFeeFrom != nil {
+		opts.SubtractFeeFrom, err = outputIndexes(outputs,
+			*cmd.SubtractFeeFrom, w.ChainParams())
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = setChangeOptions(w, opts, cmd.ChangeAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	feeRate, err := txFeeRate(w, cmd.FeeRate, cmd.ConfTarget)
+	if err != nil {
+		return nil, err
+	}
+	preview, err := w.PreviewTx(account, outputs, minConf, feeRate, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	params := w.ChainParams()
+	scriptAddress := func(pkScript []byte) string {
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(pkScript, params)
+		if err != nil || len(addrs) != 1 {
+			return ""
+		}
+		return addrs[0].EncodeAddress()
+	}
+	result := &walletjson.PrepareTransactionResult{
+		Inputs: make([]walletjson.PrepareTransactionInput, 0,
+			len(preview.Tx.TxIn)),
+		Outputs: make([]walletjson.PrepareTransactionOutput, 0,
+			len(preview.Tx.TxOut)),
+		Fee:   preview.Fee.ToBTC(),
+		VSize: preview.VirtualSize,
+	}
+	for i, txIn := range preview.Tx.TxIn {
+		op := txIn.PreviousOutPoint
+		result.Inputs = append(result.Inputs, walletjson.PrepareTransactionInput{
+			TxID:    op.Hash.String(),
+			Vout:    op.Index,
+			Address: scriptAddress(preview.PrevScripts[i]),
+			Amount:  preview.PrevInputValues[i].ToBTC(),
+		})
+	}
+	for i, txOut := range preview.Tx.TxOut {
+		output := walletjson.PrepareTransactionOutput{
+			Amount: btcutil.Amount(txOut.Value).ToBTC(),
+			Change: i == preview.ChangeIndex,
+		}
+		if !wallet.IsPreviewChange(txOut.PkScript) {
+			output.Address = scriptAddress(txOut.PkScript)
+		}
+		result.Outputs = append(result.Outputs, output)
+	}
+	return result, nil
+}
+
+// enterMaintenance handles an entermaintenance request by pausing the wallet
+// and closing its database, so that the database file can be backed up.
+func enterMaintenance(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.EnterMaintenanceCmd)
+
+	timeout := wallet.DefaultMaintenanceTimeout
+	if cmd.Timeout != nil {
+		if *cmd.Timeout <= 0 {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "timeout must be positive",
+			}
+		}
+		timeout = time.Duration(*cmd.Timeout) * time.Second
+	}
+	status, err := w.EnterMaintenance(timeout)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
+		}
+	}
+	return maintenanceResult(status), nil
+}
+
+// exitMaintenance handles an exitmaintenance request by reopening the wallet
+// database and resuming the wallet with a catch-up rescan.
+func exitMaintenance(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	status, err := w.ExitMaintenance()
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
+		}
+	}
+	result := maintenanceResult(status)
+	result.Active = false
+	return result, nil
+}
+
+// getMaintenanceInfo handles a getmaintenanceinfo request by returning the
+// maintenance mode of the wallet.
+func getMaintenanceInfo(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	return maintenanceResult(w.MaintenanceStatus()), nil
+}
+
+// maintenanceResult returns the result of the maintenance requests.
+func maintenanceResult(status *wallet.MaintenanceStatus) *walletjson.MaintenanceResult {
+	if !status.Active {
+		return &walletjson.MaintenanceResult{}
+	}
+	return &walletjson.MaintenanceResult{
+		Active: true,
+		Since:  status.Since.Unix(),
+		DBPath: status.DBPath,
+		Height: status.SyncedTo.Height,
+		Hash:   status.SyncedTo.Hash.String(),
+	}
+}
+
+// accelerateTx handles an acceleratetx request by submitting a stuck
+// transaction to the third-party acceleration services.
+func accelerateTx(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.AccelerateTxCmd)
+
+	txHash, err := chainhash.NewHashFromStr(cmd.TxID)
+	if err != nil {
+		return nil, DeserializationError{err}
+	}
+	var names []string
+	if cmd.Accelerators != nil {
+		names = *cmd.Accelerators
+	}
+	accels, err := w.AccelerateTx(txHash, names)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
+		}
+	}
+	return accelerationsResult(accels), nil
+}
+
+// listAccelerations handles a listaccelerations request by returning the
+// submissions of transactions to acceleration services.
+func listAccelerations(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ListAccelerationsCmd)
+
+	var txHash *chainhash.Hash
+	if cmd.TxID != nil {
+		var err error
+		txHash, err = chainhash.NewHashFromStr(*cmd.TxID)
+		if err != nil {
+			return nil, DeserializationError{err}
+		}
+	}
+	accels, err := w.Accelerations(txHash)
+	if err != nil {
+		return nil, err
+	}
+	return accelerationsResult(accels), nil
+}
+
+// accelerationsResult returns the result of the acceleration requests, which
+// notes that accelerators are third-party services.
+func accelerationsResult(accels []wallet.Acceleration) *walletjson.AccelerationsResult {
+	result := &walletjson.AccelerationsResult{
+		Notice:        wallet.AccelerationNotice,
+		Accelerations: make([]walletjson.AccelerationResult, len(accels)),
+	}
+	for i, a := range accels {
+		result.Accelerations[i] = walletjson.AccelerationResult{
+			TxID:        a.TxHash.String(),
+			Accelerator: a.Accelerator,
+			Submitted:   a.Submitted.Unix(),
+			Accepted:    a.Accepted,
+			Reference:   a.Reference,
+			Message:     a.Message,
+			Mined:       a.Mined,
+		}
+	}
+	return result
+}
+
+// evaluatePolicy handles an evaluatepolicy request by dry running a send
+// through the spend policies and returning the outcome of every rule.
+func evaluatePolicy(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.EvaluatePolicyCmd)
+
+	account := uint32(waddrmgr.DefaultAccountNum)
+	if cmd.FromAccount != nil {
+		var err error
+		account, err = w.AccountNumber(waddrmgr.KeyScopeBIP0044,
+			*cmd.FromAccount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check that minconf is positive.
+	minConf := int32(1)
+	if cmd.MinConf != nil {
+		minConf = int32(*cmd.MinConf)
+	}
+	if minConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
+
+	// Outputs below the dust limit are not refused here, as they are
+	// reported by the dust rule.
+	amt, err := btcutil.NewAmount(cmd.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if amt <= 0 {
+		return nil, ErrNeedPositiveAmount
+	}
+	pairs := map[string]btcutil.Amount{cmd.Address: amt}
+	outputs, err := makeOutputs(pairs, parseTokenIdentity(cmd.Token),
+		w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+
+	feeRate, err := txFeeRate(w, cmd.FeeRate, nil)
+	if err != nil {
+		return nil, err
+	}
+	eval, err := w.EvaluatePolicy(account, outputs, minConf, feeRate, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &walletjson.EvaluatePolicyResult{
+		Allowed: eval.Allowed,
+		Rules:   make([]walletjson.PolicyRuleResult, len(eval.Rules)),
+	}
+	for i, r := range eval.Rules {
+		result.Rules[i] = walletjson.PolicyRuleResult{
+			Rule:    r.Rule,
+			Outcome: string(r.Outcome),
+			Detail:  r.Detail,
+		}
+	}
+	if eval.Preview != nil {
+		fee := eval.Preview.Fee.ToBTC()
+		result.Fee = &fee
+		result.VSize = eval.Preview.VirtualSize
+	}
+	return result, nil
+}
+
+// createPaymentReference handles a createpaymentreference request by
+// returning the payment reference of an address, created when it has none.
+func createPaymentReference(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.CreatePaymentReferenceCmd)
+
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	ref, err := w.PaymentReference(addr)
+	if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+		return nil, &ErrAddressNotInWallet
+	}
+	if err != nil {
+		return nil, err
+	}
+	return paymentReferenceResult(w, ref), nil
+}
+
+// lookupPaymentReference handles a lookuppaymentreference request by
+// returning the address a payment reference is tied to, with the amount it
+// received.
+func lookupPaymentReference(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.LookupPaymentReferenceCmd)
+
+	minConf := int32(*cmd.MinConf)
+	if minConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
+	ref, err := w.LookupPaymentReference(cmd.Reference)
+	switch err {
+	case nil:
+	case wallet.ErrInvalidPaymentRef:
+		return nil, InvalidParameterError{err}
+	case wallet.ErrUnknownPaymentRef:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
+		}
+	default:
+		return nil, err
+	}
+	received, err := w.TotalReceivedForAddr(ref.Address, minConf)
+	if err != nil {
+		return nil, err
+	}
+
+	result := paymentReferenceResult(w, ref)
+	btc := received.ToBTC()
+	result.Received = &btc
+	return result, nil
+}
+
+// listPaymentReferences handles a listpaymentreferences request by returning
+// the payment references of the wallet.
+func listPaymentReferences(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	refs, err := w.PaymentReferences()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*walletjson.PaymentReferenceResult, len(refs))
+	for i := range refs {
+		results[i] = paymentReferenceResult(w, &refs[i])
+	}
+	return results, nil
+}
+
+// paymentReferenceResult returns the result describing a payment reference,
+// with a payment URI labeled with the reference.
+func paymentReferenceResult(w *wallet.Wallet, ref *wallet.PaymentRef) *walletjson.PaymentReferenceResult {
+	account, err := w.AccountName(waddrmgr.KeyScopeBIP0044, ref.Account)
+	if err != nil {
+		account = strconv.FormatUint(uint64(ref.Account), 10)
+	}
+	addr := ref.Address.EncodeAddress()
+	return &walletjson.PaymentReferenceResult{
+		Reference: ref.Reference,
+		Address:   addr,
+		Account:   account,
+		Created:   ref.Created.Unix(),
+		URI:       fmt.Sprintf("bitcoin:%s?label=%s", addr, ref.Reference),
+	}
+}
+
+// enrollBackupToken handles an enrollbackuptoken request by enrolling the
+// backup token of the wallet under a name.
+func enrollBackupToken(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.EnrollBackupTokenCmd)
+
+	e, err := w.EnrollBackupToken(cmd.Name)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
+		}
+	}
+	return &walletjson.BackupTokenResult{
+		Name:     e.Name,
+		Enrolled: e.Enrolled.Unix(),
+	}, nil
+}
+
+// listBackupTokens handles a listbackuptokens request by returning the
+// enrolled backup tokens.
+func listBackupTokens(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	tokens, err := w.BackupTokens()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]walletjson.BackupTokenResult, len(tokens))
+	for i, e := range tokens {
+		results[i] = walletjson.BackupTokenResult{
+			Name:     e.Name,
+			Enrolled: e.Enrolled.Unix(),
+		}
+	}
+	return results, nil
+}
+
+// removeBackupToken handles a removebackuptoken request by removing an
+// enrolled backup token.
+func removeBackupToken(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.RemoveBackupTokenCmd)
+
+	err := w.RemoveBackupToken(cmd.Name)
+	if err == wallet.ErrUnknownBackupToken {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
+		}
+	}
+	return nil, err
+}
+
+// recoverBackup handles a recoverbackup request by decrypting a backup
+// wrapped by the enrolled backup tokens to a new wallet database file.
+func recoverBackup(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.RecoverBackupCmd)
+
+	err := w.RecoverBackup(cmd.Backup, cmd.Destination)
+	if waddrmgr.IsError(err, waddrmgr.ErrWrongPassphrase) {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWalletPassphraseIncorrect,
+			Message: err.Error(),
+		}
+	}
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
+		}
+	}
+	return nil, nil
+}
+
+// heightTriggerResult returns the JSON result describing a height trigger.
+func heightTriggerResult(t *wallet.HeightTrigger) walletjson.HeightTriggerResult {
+	return walletjson.HeightTriggerResult{
+		ID:          t.ID,
+		Height:      t.Height,
+		Webhook:     t.Webhook,
+		Label:       t.Label,
+		Created:     t.Created.Unix(),
+		FiredHeight: t.FiredHeight,
+	}
+}
+
+// addHeightTrigger handles an addheighttrigger request by adding a trigger
+// firing when the wallet reaches a height.
+func addHeightTrigger(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.AddHeightTriggerCmd)
+
+	var webhook, label string
+	if cmd.Webhook != nil {
+		webhook = *cmd.Webhook
+	}
+	if cmd.Label != nil {
+		label = *cmd.Label
+	}
+	t, err := w.AddHeightTrigger(cmd.Height, webhook, label)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+	result := heightTriggerResult(t)
+	return &result, nil
+}
+
+// listHeightTriggers handles a listheighttriggers request by returning the
+// height triggers which did not fire or are not yet delivered.
+func listHeightTriggers(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	triggers, err := w.HeightTriggers()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]walletjson.HeightTriggerResult, len(triggers))
+	for i := range triggers {
+		results[i] = heightTriggerResult(&triggers[i])
+	}
+	return results, nil
+}
+
+// removeHeightTrigger handles a removeheighttrigger request by removing a
+// height trigger.
+func removeHeightTrigger(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.RemoveHeightTriggerCmd)
+
+	err := w.RemoveHeightTrigger(cmd.ID)
+	if err == wallet.ErrUnknownHeightTrigger {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+	return nil, err
+}
+
+// createAPIToken handles a createapitoken request by creating a named API
+// token, whose secret is only returned this once.
+func createAPIToken(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.CreateAPITokenCmd)
+
+	t, err := w.CreateAPIToken(cmd.Name)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
+		}
+	}
+	return &walletjson.APITokenResult{
+		Name:    t.Name,
+		Created: t.Created.Unix(),
+		Secret:  t.Secret,
+	}, nil
+}
+
+// listAPITokens handles a listapitokens request by returning the API tokens.
+func listAPITokens(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	tokens, err := w.APITokens()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]walletjson.APITokenResult, len(tokens))
+	for i, t := range tokens {
+		results[i] = walletjson.APITokenResult{
+			Name:    t.Name,
+			Created: t.Created.Unix(),
+		}
+	}
+	return results, nil
+}
+
+// revokeAPIToken handles a revokeapitoken request by revoking an API token.
+func revokeAPIToken(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.RevokeAPITokenCmd)
+
+	err := w.RevokeAPIToken(cmd.Name)
+	if err == wallet.ErrUnknownAPIToken {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
+		}
+	}
+	return nil, err
+}
+
+// getRefillRequest handles a getrefillrequest request by returning the pending
+// request to refill the hot account, or nil.
+func getRefillRequest(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	r, err := w.RefillRequest()
+	if err != nil || r == nil {
+		return nil, err
+	}
+	encoded, err := r.Psbt.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return &walletjson.RefillRequestResult{
+		Created: r.Created.Unix(),
+		Balance: r.Balance.ToBTC(),
+		Amount:  r.Amount.ToBTC(),
+		Fee:     r.Fee.ToBTC(),
+		Path:    r.Path,
+		Psbt:    encoded,
+	}, nil
+}
+
+// listQuarantined handles a listquarantined request by returning the unspent
+// outputs quarantined as dust.
+func listQuarantined(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	outputs, err := w.QuarantinedOutputs()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]walletjson.ListQuarantinedResult, len(outputs))
+	for i := range outputs {
+		o := &outputs[i]
+		account, err := w.AccountName(waddrmgr.KeyScopeBIP0044, o.Account)
+		if err != nil {
+			account = fmt.Sprint(o.Account)
+		}
+		results[i] = walletjson.ListQuarantinedResult{
+			TxID:        o.OutPoint.Hash.String(),
+			Vout:        o.OutPoint.Index,
+			Account:     account,
+			Amount:      o.Amount.ToBTC(),
+			Token:       o.Token.String(),
+			Confirmed:   o.Confirmed,
+			Quarantined: o.Quarantined.Unix(),
+		}
+		if o.Address != nil {
+			results[i].Address = o.Address.EncodeAddress()
+		}
+	}
+	return results, nil
+}
+
+// spendQuarantined handles a spendquarantined request by spending outputs
+// quarantined as dust to an address, or burning them when no address is
+// given.
+func spendQuarantined(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SpendQuarantinedCmd)
+
+	ops := make([]wire.OutPoint, 0, len(cmd.Outputs))
+	for _, output := range cmd.Outputs {
+		txHash, err := chainhash.NewHashFromStr(output.Txid)
+		if err != nil {
+			return nil, ParseError{err}
+		}
+		ops = append(ops, wire.OutPoint{Hash: *txHash, Index: output.Vout})
+	}
+	if len(ops) == 0 {
+		return nil, InvalidParameterError{errors.New("no outputs chosen")}
+	}
+	var dest btcutil.Address
+	if cmd.Address != nil {
+		var err error
+		dest, err = decodeAddress(*cmd.Address, w.ChainParams())
+		if err != nil {
+			return nil, err
+		}
+	}
+	feeRate, err := txFeeRate(w, cmd.FeeRate, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	txHash, fee, err := w.SpendQuarantined(ops, dest, feeRate)
+	if err != nil {
+		if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+			return nil, &ErrWalletUnlockNeeded
+		}
+		return nil, err
+	}
+	return &walletjson.SpendQuarantinedResult{
+		TxID:   txHash.String(),
+		Fee:    fee.ToBTC(),
+		Burned: dest == nil,
+	}, nil
+}
+
+// sweepAll handles a sweepall request by sending the spendable outputs of an
+// account to destinations split by percentage, without change.
+func sweepAll(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SweepAllCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.FromAccount)
+	if err != nil {
+		return nil, err
+	}
+	minConf := int32(*cmd.MinConf)
+	if minConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
+
+	// Destinations are sorted by address so that the remainders of the
+	// split are assigned in a stable order.
+	addrs := make([]string, 0, len(cmd.Destinations))
+	for addr := range cmd.Destinations {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	splits := make([]wallet.SweepSplit, 0, len(addrs))
+	for _, a := range addrs {
+		percent := cmd.Destinations[a]
+		share := int64(percent*100 + 0.5)
+		diff := float64(share) - percent*100
+		if percent <= 0 || percent > 100 || diff > 1e-6 || diff < -1e-6 {
+			return nil, InvalidParameterError{fmt.Errorf("percentage "+
+				"%v of %s is not a positive percentage with at "+
+				"most two decimals", percent, a)}
+		}
+		addr, err := decodeAddress(a, w.ChainParams())
+		if err != nil {
+			return nil, err
+		}
+		splits = append(splits, wallet.SweepSplit{
+			Address: addr,
+			Share:   uint32(share),
+		})
+	}
+
+	var total uint32
+	for _, split := range splits {
+		total += split.Share
+	}
+	if total != wallet.SweepSplitTotal {
+		return nil, InvalidParameterError{errors.New("percentages " +
+			"must add up to 100")}
+	}
+
+	var from []btcutil.Address
+	if cmd.Addresses != nil {
+		for _, a := range *cmd.Addresses {
+			addr, err := decodeAddress(a, w.ChainParams())
+			if err != nil {
+				return nil, err
+			}
+			from = append(from, addr)
+		}
+	}
+
+	feeRate, err := txFeeRate(w, cmd.FeeRate, nil)
+	if err != nil {
+		return nil, err
+	}
+	sweep, err := w.SweepAccount(account, from, parseTokenIdentity(cmd.Token),
+		splits, minConf, feeRate)
+	if err != nil {
+		if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+			return nil, &ErrWalletUnlockNeeded
+		}
+		return nil, err
+	}
+
+	result := &walletjson.SweepAllResult{
+		TxID:   sweep.Hash.String(),
+		Fee:    sweep.Fee.ToBTC(),
+		Inputs: sweep.Inputs,
+	}
+	for i, amount := range sweep.Amounts {
+		result.Outputs = append(result.Outputs, walletjson.SweepAllOutput{
+			Address: splits[i].Address.EncodeAddress(),
+			Amount:  amount.ToBTC(),
+		})
+	}
+	return result, nil
+}
+
+// txFeeRate returns the fee rate per kilobyte of a sent transaction, either
+// given in satoshis per virtual byte or estimated for the confirmation target.
+// The wallet's fee rate is returned when neither is given.
+func txFeeRate(w *wallet.Wallet, satPerVByte *float64, confTarget *int) (btcutil.Amount, error) {
+	if satPerVByte != nil && confTarget != nil {
+		return 0, InvalidParameterError{
+			errors.New("feerate and conftarget may not be used together"),
+		}
+	}
+	if satPerVByte != nil {
+		// Rates over the maximum amount per byte also reject NaN.
+		if !(*satPerVByte > 0 && *satPerVByte <= btcutil.MaxSatoshi/1000) {
+			return 0, InvalidParameterError{
+				errors.New("feerate must be a positive rate in " +
+					"satoshis per virtual byte"),
+			}
+		}
+		return txrules.FeePerKbForSatPerVByte(*satPerVByte), nil
+	}
+	if confTarget != nil {
+		if *confTarget < 1 {
+			return 0, InvalidParameterError{
+				errors.New("conftarget must be positive"),
+			}
+		}
+		return w.FeeRate(int64(*confTarget)), nil
+	}
+	return w.FeeRate(0), nil
+}
+
+// sendWithInputs handles a sendwithinputs RPC request by creating a new
+// transaction spending the chosen outputs of an account, and no other output,
+// to the payment addresses.  Upon success, the TxID for the created
+// transaction is returned.
+func sendWithInputs(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SendWithInputsCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.FromAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check that minconf is positive.
+	minConf := int32(*cmd.MinConf)
+	if minConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
+
+	chosen := make(wallet.ChosenOutpoints, 0, len(cmd.Inputs))
+	for _, input := range cmd.Inputs {
+		txHash, err := chainhash.NewHashFromStr(input.Txid)
+		if err != nil {
+			return nil, ParseError{err}
+		}
+		chosen = append(chosen, wire.OutPoint{Hash: *txHash, Index: input.Vout})
+	}
+	if len(chosen) == 0 {
+		return nil, InvalidParameterError{errors.New("no inputs chosen")}
+	}
+
+	pairs := make(map[string]btcutil.Amount, len(cmd.Amounts))
+	for k, v := range cmd.Amounts {
+		amt, err := btcutil.NewAmount(v)
+		if err != nil {
+			return nil, err
+		}
+		pairs[k] = amt
+	}
+
+	feeRate, err := txFeeRate(w, cmd.FeeRate, cmd.ConfTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &wallet.TxOptions{
+		CoinSelector:   chosen,
+		Replaceable:    cmd.Replaceable,
+		OverrideFeeCap: cmd.OverrideFeeCap != nil && *cmd.OverrideFeeCap,
+	}
+	return sendPairs(w, pairs, account, parseTokenIdentity(cmd.Token), minConf,
+		feeRate, opts)
+}
+
+// cancelBroadcast handles a cancelbroadcast request by cancelling a
+// transaction held before it is broadcast.
+func cancelBroadcast(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.CancelBroadcastCmd)
 
-	timeout := time.Second * time.Duration(cmd.Timeout)
-	var unlockAfter <-chan time.Time
-	if timeout != 0 {
-		unlockAfter = time.After(timeout)
+	txHash, err := chainhash.NewHashFromStr(cmd.TxID)
+	if err != nil {
+		return nil, ParseError{err}
+	}
+	err = w.CancelBroadcast(txHash)
+	if err == wallet.ErrNotPendingBroadcast {
+		return nil, InvalidParameterError{err}
+	}
+	return nil, err
+}
+
+// setAccountMetadata handles a setaccountmetadata request by setting or
+// removing a metadata key of an account.
+func setAccountMetadata(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SetAccountMetadataCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Key == "" {
+		return nil, InvalidParameterError{
+			errors.New("metadata key must not be empty"),
+		}
+	}
+	return nil, w.SetAccountMetadata(account, cmd.Key, cmd.Value)
+}
+
+// getAccountMetadata handles a getaccountmetadata request by returning the
+// metadata of an account, its hash and its last anchor.
+func getAccountMetadata(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetAccountMetadataCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := w.AccountMetadata(account)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := w.AccountMetadataHash(account)
+	if err != nil {
+		return nil, err
+	}
+	last, err := w.LastMetadataAnchor(account)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &walletjson.GetAccountMetadataResult{
+		Metadata: meta,
+		Hash:     hash.String(),
+	}
+	if last != nil {
+		result.LastAnchor = &walletjson.MetadataAnchorResult{
+			Hash: last.Hash.String(),
+			TxID: last.TxHash.String(),
+			Time: last.Time.Unix(),
+		}
+	}
+	return result, nil
+}
+
+// anchorAccountMetadata handles an anchoraccountmetadata request by sending a
+// transaction committing to the metadata of an account.
+func anchorAccountMetadata(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.AnchorAccountMetadataCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	anchor, err := w.AnchorAccountMetadata(account)
+	switch {
+	case err == wallet.ErrMetadataAnchored:
+		return nil, InvalidParameterError{err}
+	case waddrmgr.IsError(err, waddrmgr.ErrLocked):
+		return nil, &ErrWalletUnlockNeeded
+	case err != nil:
+		return nil, err
+	}
+	return anchor.TxHash.String(), nil
+}
+
+// bumpFee handles a bumpfee request by replacing an unconfirmed transaction
+// sent by the wallet with the same transaction paying a higher fee.
+func bumpFee(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.BumpFeeCmd)
+
+	txHash, err := chainhash.NewHashFromStr(cmd.TxID)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDecodeHexString,
+			Message: "Transaction hash string decode failed: " + err.Error(),
+		}
+	}
+	var feeSatPerKb btcutil.Amount
+	if cmd.FeeRate != nil {
+		feeSatPerKb, err = txFeeRate(w, cmd.FeeRate, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bump, err := w.BumpFee(txHash, feeSatPerKb)
+	switch {
+	case err == wallet.ErrNotReplaceable:
+		return nil, InvalidParameterError{err}
+	case waddrmgr.IsError(err, waddrmgr.ErrLocked):
+		return nil, &ErrWalletUnlockNeeded
+	case err != nil:
+		return nil, err
+	}
+	return &walletjson.BumpFeeResult{
+		TxID:    bump.Hash.String(),
+		OrigFee: bump.OrigFee.ToBTC(),
+		Fee:     bump.Fee.ToBTC(),
+	}, nil
+}
+
+// bumpFeeCPFP handles a bumpfeecpfp request by spending an output of an
+// unconfirmed transaction paying the wallet with a fee raising the fee rate of
+// both transactions to the requested rate.
+func bumpFeeCPFP(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.BumpFeeCPFPCmd)
+
+	txHash, err := chainhash.NewHashFromStr(cmd.TxID)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDecodeHexString,
+			Message: "Transaction hash string decode failed: " + err.Error(),
+		}
+	}
+	feeSatPerKb, err := txFeeRate(w, cmd.FeeRate, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bump, err := w.BumpFeeCPFP(txHash, feeSatPerKb)
+	switch {
+	case waddrmgr.IsError(err, waddrmgr.ErrLocked):
+		return nil, &ErrWalletUnlockNeeded
+	case err != nil:
+		return nil, err
+	}
+	return &walletjson.BumpFeeCPFPResult{
+		TxID:      bump.Hash.String(),
+		Fee:       bump.Fee.ToBTC(),
+		ParentFee: bump.ParentFee.ToBTC(),
+	}, nil
+}
+
+// getKeyUsage handles a getkeyusage request by returning how often the keys of
+// the accounts, or of a single account, and of their addresses signed since
+// the wallet started.  Addresses are ordered by decreasing signatures.
+func getKeyUsage(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetKeyUsageCmd)
+
+	usages := w.KeyUsage()
+	results := make([]walletjson.GetKeyUsageResult, 0, len(usages))
+	for i := range usages {
+		u := &usages[i]
+		name, err := w.AccountName(waddrmgr.KeyScopeBIP0044, u.Account)
+		if err != nil {
+			return nil, err
+		}
+		if cmd.Account != nil && *cmd.Account != name {
+			continue
+		}
+		addrs := make([]walletjson.KeyUsageAddressResult, 0,
+			len(u.Addresses))
+		for addr, a := range u.Addresses {
+			addrs = append(addrs, walletjson.KeyUsageAddressResult{
+				Address:     addr,
+				Signatures:  a.Signatures,
+				CurrentHour: a.CurrentHour,
+				Baseline:    a.Baseline,
+			})
+		}
+		sort.Slice(addrs, func(i, j int) bool {
+			if addrs[i].Signatures != addrs[j].Signatures {
+				return addrs[i].Signatures > addrs[j].Signatures
+			}
+			return addrs[i].Address < addrs[j].Address
+		})
+		results = append(results, walletjson.GetKeyUsageResult{
+			Account:     name,
+			Signatures:  u.Signatures,
+			CurrentHour: u.CurrentHour,
+			Baseline:    u.Baseline,
+			Addresses:   addrs,
+		})
+	}
+	return results, nil
+}
+
+// queuePayment handles a queuepayment request by queuing a payment from an
+// account, sent with the other queued payments of the account in a single
+// batch transaction.  It returns the ID of the queued payment.
+func queuePayment(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.QueuePaymentCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.FromAccount)
+	if err != nil {
+		return nil, err
+	}
+	amt, err := btcutil.NewAmount(cmd.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if amt <= 0 {
+		return nil, ErrNeedPositiveAmount
+	}
+	outputs, err := makeOutputs(map[string]btcutil.Amount{cmd.Address: amt},
+		parseTokenIdentity(cmd.Token), w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	return w.QueuePayment(account, outputs[0])
+}
+
+// queuedPaymentResult returns the result describing a queued payment.
+func queuedPaymentResult(w *wallet.Wallet,
+	p *wallet.QueuedPayment) (*walletjson.QueuedPaymentResult, error) {
+
+	account, err := w.AccountName(waddrmgr.KeyScopeBIP0044, p.Account)
+	if err != nil {
+		return nil, err
+	}
+	result := &walletjson.QueuedPaymentResult{
+		ID:        p.ID,
+		Account:   account,
+		Amount:    p.Amount.ToBTC(),
+		Token:     p.Token.String(),
+		Time:      p.Time.Unix(),
+		Cancelled: p.Cancelled,
+	}
+	_, addrs, _, err := taproot.ExtractPkScriptAddrs(p.PkScript,
+		w.ChainParams())
+	if err == nil && len(addrs) == 1 {
+		result.Address = addrs[0].EncodeAddress()
+	}
+	if p.TxHash != nil {
+		result.TxID = p.TxHash.String()
+	}
+	return result, nil
+}
+
+// getQueuedPayment handles a getqueuedpayment request by returning a queued,
+// sent or cancelled payment.
+func getQueuedPayment(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetQueuedPaymentCmd)
+
+	p, err := w.QueuedPayment(cmd.ID)
+	if err == wallet.ErrPaymentNotQueued {
+		return nil, InvalidParameterError{err}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return queuedPaymentResult(w, p)
+}
+
+// listQueuedPayments handles a listqueuedpayments request by returning the
+// payments waiting for their batch transaction.
+func listQueuedPayments(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	payments, err := w.QueuedPayments()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]walletjson.QueuedPaymentResult, 0, len(payments))
+	for i := range payments {
+		result, err := queuedPaymentResult(w, &payments[i])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// cancelQueuedPayment handles a cancelqueuedpayment request by removing a
+// payment from the queue before it is sent.
+func cancelQueuedPayment(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.CancelQueuedPaymentCmd)
+
+	err := w.CancelQueuedPayment(cmd.ID)
+	if err == wallet.ErrPaymentNotQueued {
+		return nil, InvalidParameterError{err}
+	}
+	return nil, err
+}
+
+// sendQueuedPayments handles a sendqueuedpayments request by sending the
+// queued payments in one batch transaction per account and token.  An error
+// is only returned when no batch could be sent; payments which could not be
+// sent stay queued.
+func sendQueuedPayments(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	batches, err := w.SendPaymentBatches()
+	if err != nil && len(batches) == 0 {
+		if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+			return nil, &ErrWalletUnlockNeeded
+		}
+		return nil, err
+	}
+	results := make([]walletjson.PaymentBatchResult, 0, len(batches))
+	for i := range batches {
+		b := &batches[i]
+		account, err := w.AccountName(waddrmgr.KeyScopeBIP0044, b.Account)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, walletjson.PaymentBatchResult{
+			Account:  account,
+			Token:    b.Token.String(),
+			TxID:     b.TxHash.String(),
+			Payments: b.Payments,
+		})
+	}
+	return results, nil
+}
+
+// setTransactionLabel handles a settransactionlabel request by setting or
+// removing the label of a wallet transaction.
+func setTransactionLabel(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SetTransactionLabelCmd)
+
+	txHash, err := chainhash.NewHashFromStr(cmd.TxID)
+	if err != nil {
+		return nil, ParseError{err}
+	}
+	err = w.SetTxLabel(txHash, cmd.Label)
+	if err == wallet.ErrUnknownTx {
+		return nil, &ErrNoTransactionInfo
 	}
-	err := w.Unlock([]byte(cmd.Passphrase), unlockAfter)
 	return nil, err
 }
 
+// spendingCategoryResult returns the result describing a category of a
+// spending report.
+func spendingCategoryResult(c *wallet.SpendingCategory) walletjson.SpendingCategoryResult {
+	return walletjson.SpendingCategoryResult{
+		Label:        c.Label,
+		Received:     c.Received.ToBTC(),
+		ReceiveCount: c.ReceiveCount,
+		Sent:         c.Sent.ToBTC(),
+		SendCount:    c.SendCount,
+		Fees:         c.Fees.ToBTC(),
+	}
+}
+
+// getSpendingReport handles a getspendingreport request by returning the
+// funds received and sent by the transactions mined over a period, summed by
+// transaction label.
+func getSpendingReport(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetSpendingReportCmd)
+
+	if cmd.EndTime <= cmd.StartTime {
+		return nil, InvalidParameterError{
+			errors.New("end time must be after start time"),
+		}
+	}
+	var account string
+	if cmd.Account != nil && *cmd.Account != "*" {
+		account = *cmd.Account
+		_, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, account)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report, err := w.SpendingReport(time.Unix(cmd.StartTime, 0),
+		time.Unix(cmd.EndTime, 0), account, parseTokenIdentity(cmd.Token))
+	if err != nil {
+		return nil, err
+	}
+	result := &walletjson.GetSpendingReportResult{
+		StartTime:  cmd.StartTime,
+		EndTime:    cmd.EndTime,
+		Categories: make([]walletjson.SpendingCategoryResult, 0, len(report.Categories)),
+		Total:      spendingCategoryResult(&report.Total),
+	}
+	for i := range report.Categories {
+		result.Categories = append(result.Categories,
+			spendingCategoryResult(&report.Categories[i]))
+	}
+	return result, nil
+}
+
 // walletPassphraseChange responds to the walletpassphrasechange request
 // by unlocking all accounts with the provided old passphrase, and
 // re-encrypting each private key with an AES key derived from the new
@@ -2029,17 +5403,176 @@ func walletPassphrase(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 func walletPassphraseChange(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	cmd := icmd.(*btcjson.WalletPassphraseChangeCmd)
 
-	err := w.ChangePrivatePassphrase([]byte(cmd.OldPassphrase),
-		[]byte(cmd.NewPassphrase))
+	// An empty passphrase would leave the keys effectively unencrypted.
+	if cmd.NewPassphrase == "" {
+		return nil, InvalidParameterError{
+			errors.New("new passphrase must not be empty"),
+		}
+	}
+
+	oldPassphrase := zero.NewBufferFrom([]byte(cmd.OldPassphrase))
+	defer oldPassphrase.Destroy()
+	newPassphrase := zero.NewBufferFrom([]byte(cmd.NewPassphrase))
+	defer newPassphrase.Destroy()
+
+	err := w.ChangePrivatePassphrase(oldPassphrase.Bytes(),
+		newPassphrase.Bytes())
 	if waddrmgr.IsError(err, waddrmgr.ErrWrongPassphrase) {
 		return nil, &btcjson.RPCError{
 			Code:    btcjson.ErrRPCWalletPassphraseIncorrect,
 			Message: "Incorrect passphrase",
 		}
 	}
+	if waddrmgr.IsError(err, waddrmgr.ErrWatchingOnly) {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWalletWrongEncState,
+			Message: "Watching-only wallets have no passphrase",
+		}
+	}
 	return nil, err
 }
 
+// recoveryHandlers maps the methods of the recovery wizard, which are handled
+// before a wallet is loaded since the wizard creates it, to their handlers.
+var recoveryHandlers = map[string]func(interface{}, *wallet.RecoveryWizard) (interface{}, error){
+	"getrecoverystatus":         getRecoveryStatus,
+	"recoveryenterseed":         recoveryEnterSeed,
+	"recoverychoosederivations": recoveryChooseDerivations,
+	"recoverystartscan":         recoveryStartScan,
+	"recoveryfinalize":          recoveryFinalize,
+	"recoveryabort":             recoveryAbort,
+}
+
+// getRecoveryStatus handles a getrecoverystatus request by returning the step
+// and progress of the recovery wizard.
+func getRecoveryStatus(icmd interface{}, wz *wallet.RecoveryWizard) (interface{}, error) {
+	return recoveryStatusResult(wz)
+}
+
+// recoveryEnterSeed handles a recoveryenterseed request by starting a
+// recovery from a hex encoded seed.
+func recoveryEnterSeed(icmd interface{}, wz *wallet.RecoveryWizard) (interface{}, error) {
+	cmd := icmd.(*walletjson.RecoveryEnterSeedCmd)
+
+	seed, err := decodeHexStr(cmd.Seed)
+	if err != nil {
+		return nil, err
+	}
+	defer zero.Bytes(seed)
+	var birthday time.Time
+	if cmd.Birthday != nil {
+		birthday = time.Unix(*cmd.Birthday, 0)
+	}
+	err = wz.EnterSeed(seed, birthday)
+	if err == hdkeychain.ErrInvalidSeedLen {
+		return nil, InvalidParameterError{err}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return recoveryStatusResult(wz)
+}
+
+// recoveryChooseDerivations handles a recoverychoosederivations request by
+// choosing the BIP0044, BIP0049, BIP0084 or BIP0086 key scopes scanned by the
+// recovery, by purpose.
+func recoveryChooseDerivations(icmd interface{}, wz *wallet.RecoveryWizard) (interface{}, error) {
+	cmd := icmd.(*walletjson.RecoveryChooseDerivationsCmd)
+
+	scopes := make([]waddrmgr.KeyScope, 0, len(cmd.Purposes))
+	for _, purpose := range cmd.Purposes {
+		var scope *waddrmgr.KeyScope
+		for i := range waddrmgr.DefaultKeyScopes {
+			if waddrmgr.DefaultKeyScopes[i].Purpose == purpose {
+				scope = &waddrmgr.DefaultKeyScopes[i]
+			}
+		}
+		if scope == nil {
+			return nil, InvalidParameterError{fmt.Errorf("no "+
+				"default key scope with purpose %d", purpose)}
+		}
+		scopes = append(scopes, *scope)
+	}
+	err := wz.ChooseDerivations(scopes, *cmd.RecoveryWindow)
+	if err != nil {
+		return nil, err
+	}
+	return recoveryStatusResult(wz)
+}
+
+// recoveryStartScan handles a recoverystartscan request by creating the
+// recovered wallet and starting the scan of the chosen derivations.
+func recoveryStartScan(icmd interface{}, wz *wallet.RecoveryWizard) (interface{}, error) {
+	cmd := icmd.(*walletjson.RecoveryStartScanCmd)
+
+	if cmd.Passphrase == "" {
+		return nil, InvalidParameterError{
+			errors.New("passphrase must not be empty"),
+		}
+	}
+	pubPassphrase := []byte(wallet.InsecurePubPassphrase)
+	if cmd.PublicPassphrase != nil && *cmd.PublicPassphrase != "" {
+		pubPassphrase = []byte(*cmd.PublicPassphrase)
+	}
+	_, err := wz.StartScan(pubPassphrase, []byte(cmd.Passphrase))
+	if err != nil {
+		return nil, err
+	}
+	return recoveryStatusResult(wz)
+}
+
+// recoveryFinalize handles a recoveryfinalize request by ending the review of
+// the funds found by the recovery.
+func recoveryFinalize(icmd interface{}, wz *wallet.RecoveryWizard) (interface{}, error) {
+	if err := wz.Finalize(); err != nil {
+		return nil, err
+	}
+	return recoveryStatusResult(wz)
+}
+
+// recoveryAbort handles a recoveryabort request by forgetting the seed and
+// choices of a recovery whose scan has not started.
+func recoveryAbort(icmd interface{}, wz *wallet.RecoveryWizard) (interface{}, error) {
+	if err := wz.Abort(); err != nil {
+		return nil, err
+	}
+	return recoveryStatusResult(wz)
+}
+
+// recoveryStatusResult returns the JSON result of the status of the recovery
+// wizard.
+func recoveryStatusResult(wz *wallet.RecoveryWizard) (*walletjson.RecoveryStatusResult, error) {
+	status, err := wz.Status()
+	if err != nil {
+		return nil, err
+	}
+	result := &walletjson.RecoveryStatusResult{
+		Step:           string(status.Step),
+		RecoveryWindow: status.RecoveryWindow,
+		SyncedHeight:   status.SyncedHeight,
+		BestHeight:     status.BestHeight,
+	}
+	if !status.Birthday.IsZero() {
+		result.Birthday = status.Birthday.Unix()
+	}
+	for i := range status.Scopes {
+		result.Scopes = append(result.Scopes, status.Scopes[i].String())
+	}
+	for i := range status.Found {
+		found := &status.Found[i]
+		r := walletjson.RecoveredScopeResult{
+			Scope:     found.Scope.String(),
+			Addresses: found.Addresses,
+			Balances:  make(map[string]float64, len(found.Balances)),
+		}
+		for token, amount := range found.Balances {
+			r.Balances[token.String()] = amount.ToBTC()
+		}
+		result.Found = append(result.Found, r)
+	}
+	return result, nil
+}
+
 // decodeHexStr decodes the hex encoding of a string, possibly prepending a
 // leading '0' character if there is an odd number of bytes in the hex string.
 // This is to prevent an error for an invalid hex string when using an odd
@@ -5,10 +5,23 @@
 package legacyrpc
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/rpc/walletjson"
+	"github.com/gorilla/websocket"
 )
 
 func TestThrottle(t *testing.T) {
@@ -46,3 +59,239 @@ func TestThrottle(t *testing.T) {
 		t.Fatalf("status codes: want: %v, got: %v", want, got)
 	}
 }
+
+func TestBlindedAuth(t *testing.T) {
+	blindedAuthsha := sha256.Sum256(httpBasicAuth("display", "pass2"))
+	s := &Server{
+		authsha:        sha256.Sum256(httpBasicAuth("user", "pass")),
+		blindedAuthsha: &blindedAuthsha,
+	}
+
+	tests := []struct {
+		username, passphrase string
+		invalid, blinded     bool
+	}{
+		{"user", "pass", false, false},
+		{"display", "pass2", false, true},
+		{"display", "pass", true, false},
+	}
+	for _, test := range tests {
+		req, err := btcjson.NewRequest(1, "authenticate", []interface{}{
+			test.username, test.passphrase,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		invalid, blinded, _ := s.invalidAuth(req)
+		if invalid != test.invalid || blinded != test.blinded {
+			t.Errorf("%s:%s: got invalid=%v blinded=%v, want "+
+				"invalid=%v blinded=%v", test.username,
+				test.passphrase, invalid, blinded, test.invalid,
+				test.blinded)
+		}
+	}
+
+	// POST clients are never authenticated by the blinded credentials,
+	// and the credentials are disabled unless configured.
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("Authorization", string(httpBasicAuth("display", "pass2")))
+	if s.checkAuthHeader(r) == nil {
+		t.Errorf("blinded credentials authenticated a POST client")
+	}
+	s.blindedAuthsha = nil
+	if s.checkBlindedAuth(string(httpBasicAuth("display", "pass2"))) {
+		t.Errorf("disabled blinded credentials authenticated a client")
+	}
+
+	// The shared credentials are refused when they are disabled, and API
+	// tokens are refused until a wallet is loaded.
+	s.noSharedAuth = true
+	r.Header.Set("Authorization", string(httpBasicAuth("user", "pass")))
+	if s.checkAuthHeader(r) == nil {
+		t.Errorf("disabled shared credentials authenticated a client")
+	}
+	if s.checkAPIToken(string(httpBasicAuth("user", "pass"))) != nil {
+		t.Errorf("API token authenticated a client without a wallet")
+	}
+}
+
+func TestTxFeeRate(t *testing.T) {
+	tests := []struct {
+		satPerVByte float64
+		want        btcutil.Amount
+		valid       bool
+	}{
+		{1, 1000, true},
+		{2.5, 2500, true},
+		{0.0015, 2, true},
+		{0, 0, false},
+		{-1, 0, false},
+		{math.NaN(), 0, false},
+		{math.Inf(1), 0, false},
+	}
+	for _, test := range tests {
+		satPerVByte := test.satPerVByte
+		feeRate, err := txFeeRate(nil, &satPerVByte, nil)
+		if (err == nil) != test.valid {
+			t.Errorf("%v sat/vB: unexpected error %v", satPerVByte, err)
+			continue
+		}
+		if feeRate != test.want {
+			t.Errorf("%v sat/vB: got %v, want %v", satPerVByte,
+				feeRate, test.want)
+		}
+	}
+
+	// A fee rate is not estimated for a confirmation target as well.
+	satPerVByte, confTarget := 1.0, 6
+	if _, err := txFeeRate(nil, &satPerVByte, &confTarget); err == nil {
+		t.Errorf("feerate was used with conftarget")
+	}
+}
+
+// TestSendTravelRule checks that the travel rule metadata of send requests is
+// unmarshaled and validated.
+func TestSendTravelRule(t *testing.T) {
+	tests := []struct {
+		method string
+		params string
+	}{
+		{"sendfrom", `["", "addr", 1, 1, null, null, null, %s]`},
+		{"sendmany", `["", {"addr": 1}, 1, null, null, null, null, null, null, null, %s]`},
+		{"sendtoaddress", `["addr", 1, null, null, null, null, null, %s]`},
+	}
+	const travelRule = `{"originator": {"legalname": "Exchange Ltd"},
+		"beneficiary": {"firstname": "Alice", "lastname": "Smith"},
+		"beneficiaryvasp": "Custodian Inc"}`
+	for _, test := range tests {
+		request := &btcjson.Request{
+			Jsonrpc: "1.0",
+			Method:  test.method,
+			Params:  []json.RawMessage{},
+		}
+		params := fmt.Sprintf(test.params, travelRule)
+		if err := json.Unmarshal([]byte(params), &request.Params); err != nil {
+			t.Fatal(err)
+		}
+		cmd, err := unmarshalCmd(request)
+		if err != nil {
+			t.Fatalf("%s: %v", test.method, err)
+		}
+
+		var r *walletjson.TravelRule
+		switch cmd := cmd.(type) {
+		case *walletjson.SendFromCmd:
+			r = cmd.TravelRule
+		case *walletjson.SendManyCmd:
+			r = cmd.TravelRule
+		case *walletjson.SendToAddressCmd:
+			r = cmd.TravelRule
+		default:
+			t.Fatalf("%s: unmarshaled as %T", test.method, cmd)
+		}
+		m, err := sendTravelRule(r)
+		if err != nil {
+			t.Fatalf("%s: %v", test.method, err)
+		}
+		if m == nil || m.Originator.LegalName != "Exchange Ltd" ||
+			m.Beneficiary.LastName != "Smith" ||
+			m.BeneficiaryVASP != "Custodian Inc" {
+
+			t.Errorf("%s: unexpected metadata %+v", test.method, m)
+		}
+	}
+
+	if m, err := sendTravelRule(nil); m != nil || err != nil {
+		t.Errorf("metadata %v, %v without a parameter", m, err)
+	}
+	_, err := sendTravelRule(&walletjson.TravelRule{
+		Originator: walletjson.TravelRuleParty{LegalName: "Exchange Ltd"},
+	})
+	if err == nil {
+		t.Errorf("metadata without a beneficiary name was accepted")
+	}
+}
+
+// recordingConn records the bytes read from a connection.
+type recordingConn struct {
+	net.Conn
+	read bytes.Buffer
+}
+
+func (c *recordingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.read.Write(b[:n])
+	return n, err
+}
+
+func TestWriteFramed(t *testing.T) {
+	const maxFrameSize = 512
+	msg := []byte(strings.Repeat("0123456789", 200))
+
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  maxFrameSize,
+		WriteBufferSize: maxFrameSize,
+	}
+	errc := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				errc <- err
+				return
+			}
+			errc <- writeFramed(conn, msg, maxFrameSize)
+		}))
+	defer srv.Close()
+
+	var rc *recordingConn
+	dialer := websocket.Dialer{
+		NetDial: func(network, addr string) (net.Conn, error) {
+			c, err := net.Dial(network, addr)
+			rc = &recordingConn{Conn: c}
+			return rc, err
+		},
+	}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, got, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Fatalf("read message of %d bytes, want %d", len(got), len(msg))
+	}
+
+	// The recorded frames follow the handshake response.  Server frames
+	// are not masked.
+	raw := rc.read.Bytes()
+	raw = raw[bytes.Index(raw, []byte("\r\n\r\n"))+4:]
+	var frames int
+	for len(raw) > 0 {
+		fin := raw[0]&0x80 != 0
+		size, header := int(raw[1]&0x7f), 2
+		switch size {
+		case 126:
+			size, header = int(binary.BigEndian.Uint16(raw[2:])), 4
+		case 127:
+			size, header = int(binary.BigEndian.Uint64(raw[2:])), 10
+		}
+		if size > maxFrameSize {
+			t.Errorf("frame %d has a payload of %d bytes", frames, size)
+		}
+		raw = raw[header+size:]
+		frames++
+		if fin != (len(raw) == 0) {
+			t.Errorf("frame %d has fin=%v", frames-1, fin)
+		}
+	}
+	if want := (len(msg) + maxFrameSize - 1) / maxFrameSize; frames != want {
+		t.Errorf("message was sent in %d frames, want %d", frames, want)
+	}
+}
@@ -4,51 +4,147 @@ package legacyrpc
 
 func helpDescsEnUS() map[string]string {
 	return map[string]string{
-		"addmultisigaddress":      "addmultisigaddress nrequired [\"key\",...] (\"account\")\n\nGenerates and imports a multisig address and redeeming script to the 'imported' account.\n\nArguments:\n1. nrequired (numeric, required)         The number of signatures required to redeem outputs paid to this address\n2. keys      (array of string, required) Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address\n3. account   (string, optional)          DEPRECATED -- Unused (all imported addresses belong to the imported account)\n\nResult:\n\"value\" (string) The imported pay-to-script-hash address\n",
-		"createmultisig":          "createmultisig nrequired [\"key\",...]\n\nGenerate a multisig address and redeem script.\n\nArguments:\n1. nrequired (numeric, required)         The number of signatures required to redeem outputs paid to this address\n2. keys      (array of string, required) Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address\n\nResult:\n{\n \"address\": \"value\",      (string) The generated pay-to-script-hash address\n \"redeemScript\": \"value\", (string) The script required to redeem outputs paid to the multisig address\n}                         \n",
-		"dumpprivkey":             "dumpprivkey \"address\"\n\nReturns the private key in WIF encoding that controls some wallet address.\n\nArguments:\n1. address (string, required) The address to return a private key for\n\nResult:\n\"value\" (string) The WIF-encoded private key\n",
-		"getaccount":              "getaccount \"address\"\n\nDEPRECATED -- Lookup the account name that some wallet address belongs to.\n\nArguments:\n1. address (string, required) The address to query the account for\n\nResult:\n\"value\" (string) The name of the account that 'address' belongs to\n",
-		"getaccountaddress":       "getaccountaddress \"account\"\n\nDEPRECATED -- Returns the most recent external payment address for an account that has not been seen publicly.\nA new address is generated for the account if the most recently generated address has been seen on the blockchain or in mempool.\n\nArguments:\n1. account (string, required) The account of the returned address\n\nResult:\n\"value\" (string) The unused address for 'account'\n",
-		"getaddressesbyaccount":   "getaddressesbyaccount \"account\"\n\nDEPRECATED -- Returns all addresses strings controlled by a single account.\n\nArguments:\n1. account (string, required) Account name to fetch addresses for\n\nResult:\n[\"value\",...] (array of string) All addresses controlled by 'account'\n",
-		"getbalance":              "getbalance (\"account\" minconf=1)\n\nCalculates and returns the balance of one or all accounts.\n\nArguments:\n1. account (string, optional)             DEPRECATED -- The account name to query the balance for, or \"*\" to consider all accounts (default=\"*\")\n2. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an unspent output's value is included in the balance\n\nResult (account != \"*\"):\nn.nnn (numeric) The balance of 'account' valued in bitcoin\n\nResult (account = \"*\"):\nn.nnn (numeric) The balance of all accounts valued in bitcoin\n",
-		"getbestblockhash":        "getbestblockhash\n\nReturns the hash of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\n\"value\" (string) The hash of the most recent synced-to block\n",
-		"getblockcount":           "getblockcount\n\nReturns the blockchain height of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\nn.nnn (numeric) The blockchain height of the most recent synced-to block\n",
-		"getinfo":                 "getinfo\n\nReturns a JSON object containing various state info.\n\nArguments:\nNone\n\nResult:\n{\n \"version\": n,          (numeric) The version of the server\n \"protocolversion\": n,  (numeric) The latest supported protocol version\n \"walletversion\": n,    (numeric) The version of the address manager database\n \"balance\": n.nnn,      (numeric) The balance of all accounts calculated with one block confirmation\n \"blocks\": n,           (numeric) The number of blocks processed\n \"timeoffset\": n,       (numeric) The time offset\n \"connections\": n,      (numeric) The number of connected peers\n \"proxy\": \"value\",      (string)  The proxy used by the server\n \"difficulty\": n.nnn,   (numeric) The current target difficulty\n \"testnet\": true|false, (boolean) Whether or not server is using testnet\n \"keypoololdest\": n,    (numeric) Unset\n \"keypoolsize\": n,      (numeric) Unset\n \"unlocked_until\": n,   (numeric) Unset\n \"paytxfee\": n.nnn,     (numeric) The increment used each time more fee is required for an authored transaction\n \"relayfee\": n.nnn,     (numeric) The minimum relay fee for non-free transactions in BTC/KB\n \"errors\": \"value\",     (string)  Any current errors\n}                       \n",
-		"getnewaddress":           "getnewaddress (\"account\")\n\nGenerates and returns a new payment address.\n\nArguments:\n1. account (string, optional) DEPRECATED -- Account name the new address will belong to (default=\"default\")\n\nResult:\n\"value\" (string) The payment address\n",
-		"getrawchangeaddress":     "getrawchangeaddress (\"account\")\n\nGenerates and returns a new internal payment address for use as a change address in raw transactions.\n\nArguments:\n1. account (string, optional) Account name the new internal address will belong to (default=\"default\")\n\nResult:\n\"value\" (string) The internal payment address\n",
-		"getreceivedbyaccount":    "getreceivedbyaccount \"account\" (minconf=1)\n\nDEPRECATED -- Returns the total amount received by addresses of some account, including spent outputs.\n\nArguments:\n1. account (string, required)             Account name to query total received amount for\n2. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an output's value is included in the total\n\nResult:\nn.nnn (numeric) The total received amount valued in bitcoin\n",
-		"getreceivedbyaddress":    "getreceivedbyaddress \"address\" (minconf=1)\n\nReturns the total amount received by a single address, including spent outputs.\n\nArguments:\n1. address (string, required)             Payment address which received outputs to include in total\n2. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an output's value is included in the total\n\nResult:\nn.nnn (numeric) The total received amount valued in bitcoin\n",
-		"gettransaction":          "gettransaction \"txid\" (includewatchonly=false)\n\nReturns a JSON object with details regarding a transaction relevant to this wallet.\n\nArguments:\n1. txid             (string, required)                 Hash of the transaction to query\n2. includewatchonly (boolean, optional, default=false) Also consider transactions involving watched addresses\n\nResult:\n{\n \"amount\": n.nnn,                  (numeric)         The total amount this transaction credits to the wallet, valued in bitcoin\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value, or 0 if 'txid' is not a sent transaction\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"txid\": \"value\",                  (string)          The transaction hash\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"details\": [{                     (array of object) Additional details for each recorded wallet credit and debit\n  \"account\": \"value\",              (string)          DEPRECATED -- Unset\n  \"address\": \"value\",              (string)          The address an output was paid to, or the empty string if the output is nonstandard or this detail is regarding a transaction input\n  \"amount\": n.nnn,                 (numeric)         The amount of a received output\n  \"category\": \"value\",             (string)          The kind of detail: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs\n  \"involveswatchonly\": true|false, (boolean)         Unset\n  \"fee\": n.nnn,                    (numeric)         The included fee for a sent transaction\n  \"vout\": n,                       (numeric)         The transaction output index\n },...],                                             \n \"hex\": \"value\",                   (string)          The transaction encoded as a hexadecimal string\n}                                  \n",
-		"help":                    "help (\"command\")\n\nReturns a list of all commands or help for a specified command.\n\nArguments:\n1. command (string, optional) The command to retrieve help for\n\nResult (no command provided):\n\"value\" (string) List of commands\n\nResult (command specified):\n\"value\" (string) Help for specified command\n",
-		"importprivkey":           "importprivkey \"privkey\" (\"label\" rescan=true)\n\nImports a WIF-encoded private key to the 'imported' account.\n\nArguments:\n1. privkey (string, required)                The WIF-encoded private key\n2. label   (string, optional)                Unused (must be unset or 'imported')\n3. rescan  (boolean, optional, default=true) Rescan the blockchain (since the genesis block) for outputs controlled by the imported key\n\nResult:\nNothing\n",
-		"keypoolrefill":           "keypoolrefill (newsize=100)\n\nDEPRECATED -- This request does nothing since no keypool is maintained.\n\nArguments:\n1. newsize (numeric, optional, default=100) Unused\n\nResult:\nNothing\n",
-		"listaccounts":            "listaccounts (minconf=1)\n\nDEPRECATED -- Returns a JSON object of all accounts and their balances.\n\nArguments:\n1. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an unspent output's value is included in the balance\n\nResult:\n{\n \"The account name\": The account balance valued in bitcoin, (object) JSON object with account names as keys and bitcoin amounts as values\n ...\n}\n",
-		"listlockunspent":         "listlockunspent\n\nReturns a JSON array of outpoints marked as locked (with lockunspent) for this wallet session.\n\nArguments:\nNone\n\nResult:\n[{\n \"txid\": \"value\", (string)  The transaction hash of the referenced output\n \"vout\": n,       (numeric) The output index of the referenced output\n},...]\n",
-		"listreceivedbyaccount":   "listreceivedbyaccount (minconf=1 includeempty=false includewatchonly=false)\n\nDEPRECATED -- Returns a JSON array of objects listing all accounts and the total amount received by each account.\n\nArguments:\n1. minconf          (numeric, optional, default=1)     Minimum number of block confirmations required before a transaction is considered\n2. includeempty     (boolean, optional, default=false) Unused\n3. includewatchonly (boolean, optional, default=false) Unused\n\nResult:\n[{\n \"account\": \"value\", (string)  The name of the account\n \"amount\": n.nnn,    (numeric) Total amount received by payment addresses of the account valued in bitcoin\n \"confirmations\": n, (numeric) Number of block confirmations of the most recent transaction relevant to the account\n},...]\n",
-		"listreceivedbyaddress":   "listreceivedbyaddress (minconf=1 includeempty=false includewatchonly=false)\n\nReturns a JSON array of objects listing wallet payment addresses and their total received amounts.\n\nArguments:\n1. minconf          (numeric, optional, default=1)     Minimum number of block confirmations required before a transaction is considered\n2. includeempty     (boolean, optional, default=false) Unused\n3. includewatchonly (boolean, optional, default=false) Unused\n\nResult:\n[{\n \"account\": \"value\",              (string)          DEPRECATED -- Unset\n \"address\": \"value\",              (string)          The payment address\n \"amount\": n.nnn,                 (numeric)         Total amount received by the payment address valued in bitcoin\n \"confirmations\": n,              (numeric)         Number of block confirmations of the most recent transaction relevant to the address\n \"txids\": [\"value\",...],          (array of string) Transaction hashes of all transactions involving this address\n \"involvesWatchonly\": true|false, (boolean)         Unset\n},...]\n",
-		"listsinceblock":          "listsinceblock (\"blockhash\" targetconfirmations=1 includewatchonly=false)\n\nReturns a JSON array of objects listing details of all wallet transactions after some block.\n\nArguments:\n1. blockhash           (string, optional)                 Hash of the parent block of the first block to consider transactions from, or unset to list all transactions\n2. targetconfirmations (numeric, optional, default=1)     Minimum number of block confirmations of the last block in the result object.  Must be 1 or greater.  Note: The transactions array in the result object is not affected by this parameter\n3. includewatchonly    (boolean, optional, default=false) Unused\n\nResult:\n{\n \"transactions\": [{                 (array of object) JSON array of objects containing verbose details of the each transaction\n  \"abandoned\": true|false,          (boolean)         Unset\n  \"account\": \"value\",               (string)          DEPRECATED -- Unset\n  \"address\": \"value\",               (string)          Payment address for a transaction output\n  \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n  \"bip125-replaceable\": \"value\",    (string)          Unset\n  \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n  \"blockindex\": n,                  (numeric)         Unset\n  \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n  \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n  \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n  \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n  \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n  \"involveswatchonly\": true|false,  (boolean)         Unset\n  \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n  \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n  \"trusted\": true|false,            (boolean)         Unset\n  \"txid\": \"value\",                  (string)          The hash of the transaction\n  \"vout\": n,                        (numeric)         The transaction output index\n  \"walletconflicts\": [\"value\",...], (array of string) Unset\n  \"comment\": \"value\",               (string)          Unset\n  \"otheraccount\": \"value\",          (string)          Unset\n },...],                                              \n \"lastblock\": \"value\",              (string)          Hash of the latest-synced block to be used in later calls to listsinceblock\n}                                   \n",
-		"listtransactions":        "listtransactions (\"account\" count=10 from=0 includewatchonly=false)\n\nReturns a JSON array of objects containing verbose details for wallet transactions.\n\nArguments:\n1. account          (string, optional)                 DEPRECATED -- Unused (must be unset or \"*\")\n2. count            (numeric, optional, default=10)    Maximum number of transactions to create results from\n3. from             (numeric, optional, default=0)     Number of transactions to skip before results are created\n4. includewatchonly (boolean, optional, default=false) Unused\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Unset\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          Unset\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
-		"listunspent":             "listunspent (minconf=1 maxconf=9999999 [\"address\",...])\n\nReturns a JSON array of objects representing unlocked unspent outputs controlled by wallet keys.\n\nArguments:\n1. minconf   (numeric, optional, default=1)       Minimum number of block confirmations required before a transaction output is considered\n2. maxconf   (numeric, optional, default=9999999) Maximum number of block confirmations required before a transaction output is excluded\n3. addresses (array of string, optional)          If set, limits the returned details to unspent outputs received by any of these payment addresses\n\nResult:\n{\n \"txid\": \"value\",         (string)  The transaction hash of the referenced output\n \"vout\": n,               (numeric) The output index of the referenced output\n \"address\": \"value\",      (string)  The payment address that received the output\n \"account\": \"value\",      (string)  The account associated with the receiving payment address\n \"scriptPubKey\": \"value\", (string)  The output script encoded as a hexadecimal string\n \"redeemScript\": \"value\", (string)  Unset\n \"amount\": n.nnn,         (numeric) The amount of the output valued in bitcoin\n \"confirmations\": n,      (numeric) The number of block confirmations of the transaction\n \"spendable\": true|false, (boolean) Whether the output is entirely controlled by wallet keys/scripts (false for partially controlled multisig outputs or outputs to watch-only addresses)\n}                         \n",
-		"lockunspent":             "lockunspent unlock [{\"txid\":\"value\",\"vout\":n},...]\n\nLocks or unlocks an unspent output.\nLocked outputs are not chosen for transaction inputs of authored transactions and are not included in 'listunspent' results.\nLocked outputs are volatile and are not saved across wallet restarts.\nIf unlock is true and no transaction outputs are specified, all locked outputs are marked unlocked.\n\nArguments:\n1. unlock       (boolean, required)         True to unlock outputs, false to lock\n2. transactions (array of object, required) Transaction outputs to lock or unlock\n[{\n \"txid\": \"value\", (string)  The transaction hash of the referenced output\n \"vout\": n,       (numeric) The output index of the referenced output\n},...]\n\nResult:\ntrue|false (boolean) The boolean 'true'\n",
-		"sendfrom":                "sendfrom \"fromaccount\" \"toaddress\" amount (minconf=1 \"comment\" \"commentto\")\n\nDEPRECATED -- Authors, signs, and sends a transaction that outputs some amount to a payment address.\nA change output is automatically included to send extra output value back to the original account.\n\nArguments:\n1. fromaccount (string, required)             Account to pick unspent outputs from\n2. toaddress   (string, required)             Address to pay\n3. amount      (numeric, required)            Amount to send to the payment address valued in bitcoin\n4. minconf     (numeric, optional, default=1) Minimum number of block confirmations required before a transaction output is eligible to be spent\n5. comment     (string, optional)             Unused\n6. commentto   (string, optional)             Unused\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
-		"sendmany":                "sendmany \"fromaccount\" {\"address\":amount,...} (minconf=1 \"comment\")\n\nAuthors, signs, and sends a transaction that outputs to many payment addresses.\nA change output is automatically included to send extra output value back to the original account.\n\nArguments:\n1. fromaccount (string, required) DEPRECATED -- Account to pick unspent outputs from\n2. amounts     (object, required) Pairs of payment addresses and the output amount to pay each\n{\n \"Address to pay\": Amount to send to the payment address valued in bitcoin, (object) JSON object using payment addresses as keys and output amounts valued in bitcoin to send to each address\n ...\n}\n3. minconf (numeric, optional, default=1) Minimum number of block confirmations required before a transaction output is eligible to be spent\n4. comment (string, optional)             Unused\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
-		"sendtoaddress":           "sendtoaddress \"address\" amount (\"comment\" \"commentto\")\n\nAuthors, signs, and sends a transaction that outputs some amount to a payment address.\nUnlike sendfrom, outputs are always chosen from the default account.\nA change output is automatically included to send extra output value back to the original account.\n\nArguments:\n1. address   (string, required)  Address to pay\n2. amount    (numeric, required) Amount to send to the payment address valued in bitcoin\n3. comment   (string, optional)  Unused\n4. commentto (string, optional)  Unused\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
-		"settxfee":                "settxfee amount\n\nModify the increment used each time more fee is required for an authored transaction.\n\nArguments:\n1. amount (numeric, required) The new fee increment valued in bitcoin\n\nResult:\ntrue|false (boolean) The boolean 'true'\n",
-		"signmessage":             "signmessage \"address\" \"message\"\n\nSigns a message using the private key of a payment address.\n\nArguments:\n1. address (string, required) Payment address of private key used to sign the message with\n2. message (string, required) Message to sign\n\nResult:\n\"value\" (string) The signed message encoded as a base64 string\n",
-		"signrawtransaction":      "signrawtransaction \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] [\"privkey\",...] flags=\"ALL\")\n\nSigns transaction inputs using private keys from this wallet and request.\nThe valid flags options are ALL, NONE, SINGLE, ALL|ANYONECANPAY, NONE|ANYONECANPAY, and SINGLE|ANYONECANPAY.\n\nArguments:\n1. rawtx    (string, required)                Unsigned or partially unsigned transaction to sign encoded as a hexadecimal string\n2. inputs   (array of object, optional)       Additional data regarding inputs that this wallet may not be tracking\n3. privkeys (array of string, optional)       Additional WIF-encoded private keys to use when creating signatures\n4. flags    (string, optional, default=\"ALL\") Sighash flags\n\nResult:\n{\n \"hex\": \"value\",         (string)          The resulting transaction encoded as a hexadecimal string\n \"complete\": true|false, (boolean)         Whether all input signatures have been created\n \"errors\": [{            (array of object) Script verification errors (if exists)\n  \"txid\": \"value\",       (string)          The transaction hash of the referenced previous output\n  \"vout\": n,             (numeric)         The output index of the referenced previous output\n  \"scriptSig\": \"value\",  (string)          The hex-encoded signature script\n  \"sequence\": n,         (numeric)         Script sequence number\n  \"error\": \"value\",      (string)          Verification or signing error related to the input\n },...],                                   \n}                        \n",
-		"validateaddress":         "validateaddress \"address\"\n\nVerify that an address is valid.\nExtra details are returned if the address is controlled by this wallet.\nThe following fields are valid only when the address is controlled by this wallet (ismine=true): isscript, pubkey, iscompressed, account, addresses, hex, script, and sigsrequired.\nThe following fields are only valid when address has an associated public key: pubkey, iscompressed.\nThe following fields are only valid when address is a pay-to-script-hash address: addresses, hex, and script.\nIf the address is a multisig address controlled by this wallet, the multisig fields will be left unset if the wallet is locked since the redeem script cannot be decrypted.\n\nArguments:\n1. address (string, required) Address to validate\n\nResult:\n{\n \"isvalid\": true|false,      (boolean)         Whether or not the address is valid\n \"address\": \"value\",         (string)          The payment address (only when isvalid is true)\n \"ismine\": true|false,       (boolean)         Whether this address is controlled by the wallet (only when isvalid is true)\n \"iswatchonly\": true|false,  (boolean)         Unset\n \"isscript\": true|false,     (boolean)         Whether the payment address is a pay-to-script-hash address (only when isvalid is true)\n \"pubkey\": \"value\",          (string)          The associated public key of the payment address, if any (only when isvalid is true)\n \"iscompressed\": true|false, (boolean)         Whether the address was created by hashing a compressed public key, if any (only when isvalid is true)\n \"account\": \"value\",         (string)          The account this payment address belongs to (only when isvalid is true)\n \"addresses\": [\"value\",...], (array of string) All associated payment addresses of the script if address is a multisig address (only when isvalid is true)\n \"hex\": \"value\",             (string)          The redeem script \n \"script\": \"value\",          (string)          The class of redeem script for a multisig address\n \"sigsrequired\": n,          (numeric)         The number of required signatures to redeem outputs to the multisig address\n}                            \n",
-		"verifymessage":           "verifymessage \"address\" \"signature\" \"message\"\n\nVerify a message was signed with the associated private key of some address.\n\nArguments:\n1. address   (string, required) Address used to sign message\n2. signature (string, required) The signature to verify\n3. message   (string, required) The message to verify\n\nResult:\ntrue|false (boolean) Whether the message was signed with the private key of 'address'\n",
-		"walletlock":              "walletlock\n\nLock the wallet.\n\nArguments:\nNone\n\nResult:\nNothing\n",
-		"walletpassphrase":        "walletpassphrase \"passphrase\" timeout\n\nUnlock the wallet.\n\nArguments:\n1. passphrase (string, required)  The wallet passphrase\n2. timeout    (numeric, required) The number of seconds to wait before the wallet automatically locks\n\nResult:\nNothing\n",
-		"walletpassphrasechange":  "walletpassphrasechange \"oldpassphrase\" \"newpassphrase\"\n\nChange the wallet passphrase.\n\nArguments:\n1. oldpassphrase (string, required) The old wallet passphrase\n2. newpassphrase (string, required) The new wallet passphrase\n\nResult:\nNothing\n",
-		"createnewaccount":        "createnewaccount \"account\"\n\nCreates a new account.\nThe wallet must be unlocked for this request to succeed.\n\nArguments:\n1. account (string, required) Name of the new account\n\nResult:\nNothing\n",
-		"exportwatchingwallet":    "exportwatchingwallet (\"account\" download=false)\n\nCreates and returns a duplicate of the wallet database without any private keys to be used as a watching-only wallet.\n\nArguments:\n1. account  (string, optional)                 Unused (must be unset or \"*\")\n2. download (boolean, optional, default=false) Unused\n\nResult:\n\"value\" (string) The watching-only database encoded as a base64 string\n",
-		"getbestblock":            "getbestblock\n\nReturns the hash and height of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\n{\n \"hash\": \"value\", (string)  The hash of the block\n \"height\": n,     (numeric) The blockchain height of the block\n}                 \n",
-		"getunconfirmedbalance":   "getunconfirmedbalance (\"account\")\n\nCalculates the unspent output value of all unmined transaction outputs for an account.\n\nArguments:\n1. account (string, optional) The account to query the unconfirmed balance for (default=\"default\")\n\nResult:\nn.nnn (numeric) Total amount of all unmined unspent outputs of the account valued in bitcoin.\n",
-		"listaddresstransactions": "listaddresstransactions [\"address\",...] (\"account\")\n\nReturns a JSON array of objects containing verbose details for wallet transactions pertaining some addresses.\n\nArguments:\n1. addresses (array of string, required) Addresses to filter transaction results by\n2. account   (string, optional)          Unused (must be unset or \"*\")\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Unset\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          Unset\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
-		"listalltransactions":     "listalltransactions (\"account\")\n\nReturns a JSON array of objects in the same format as 'listtransactions' without limiting the number of returned objects.\n\nArguments:\n1. account (string, optional) Unused (must be unset or \"*\")\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Unset\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          Unset\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
-		"renameaccount":           "renameaccount \"oldaccount\" \"newaccount\"\n\nRenames an account.\n\nArguments:\n1. oldaccount (string, required) The old account name to rename\n2. newaccount (string, required) The new name for the account\n\nResult:\nNothing\n",
-		"walletislocked":          "walletislocked\n\nReturns whether or not the wallet is locked.\n\nArguments:\nNone\n\nResult:\ntrue|false (boolean) Whether the wallet is locked\n",
+		"addmultisigaddress":           "addmultisigaddress nrequired [\"key\",...] (\"account\")\n\nGenerates and imports a multisig address and redeeming script to the 'imported' account.\n\nArguments:\n1. nrequired (numeric, required)         The number of signatures required to redeem outputs paid to this address\n2. keys      (array of string, required) Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address\n3. account   (string, optional)          DEPRECATED -- Unused (all imported addresses belong to the imported account)\n\nResult:\n\"value\" (string) The imported pay-to-script-hash address\n",
+		"backupwallet":                 "backupwallet \"destination\"\n\nWrites a backup of the wallet database to a file, replacing any existing file.\nThe backup is encrypted with the --backuppass passphrase or to the --backupgpgrecipient keys when they are set, so that it can be kept by operators who do not know the private passphrase.\nBackups encrypted with the backup passphrase are decrypted with the decryptbackup utility, and backups encrypted to GPG recipients with gpg --decrypt.\n\nArguments:\n1. destination (string, required) Path of the backup file\n\nResult:\nNothing\n",
+		"createmultisig":               "createmultisig nrequired [\"key\",...]\n\nGenerate a multisig address and redeem script.\n\nArguments:\n1. nrequired (numeric, required)         The number of signatures required to redeem outputs paid to this address\n2. keys      (array of string, required) Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address\n\nResult:\n{\n \"address\": \"value\",      (string) The generated pay-to-script-hash address\n \"redeemScript\": \"value\", (string) The script required to redeem outputs paid to the multisig address\n}                         \n",
+		"dumpprivkey":                  "dumpprivkey \"address\"\n\nReturns the private key in WIF encoding that controls some wallet address.\n\nArguments:\n1. address (string, required) The address to return a private key for\n\nResult:\n\"value\" (string) The WIF-encoded private key\n",
+		"dumpwallet":                   "dumpwallet \"filename\"\n\nWrites the private keys of all wallet addresses to a new file in the wallet dump format of bitcoind, for import by importwallet or other software.\nEach key is written with its address, account name and BIP0032 derivation path from the master key, whose fingerprint is written in the header of the file.\n\nArguments:\n1. filename (string, required) Path of the file to create, which must not already exist\n\nResult:\n{\n \"filename\": \"value\", (string) The absolute path of the written file\n}                     \n",
+		"getaccount":                   "getaccount \"address\"\n\nDEPRECATED -- Lookup the account name that some wallet address belongs to.\n\nArguments:\n1. address (string, required) The address to query the account for\n\nResult:\n\"value\" (string) The name of the account that 'address' belongs to\n",
+		"getaccountaddress":            "getaccountaddress \"account\"\n\nDEPRECATED -- Returns the most recent external payment address for an account that has not been seen publicly.\nA new address is generated for the account if the most recently generated address has been seen on the blockchain or in mempool.\n\nArguments:\n1. account (string, required) The account of the returned address\n\nResult:\n\"value\" (string) The unused address for 'account'\n",
+		"getaddressesbyaccount":        "getaddressesbyaccount \"account\"\n\nDEPRECATED -- Returns all payment address strings controlled by a single account.  Change addresses are not returned.\n\nArguments:\n1. account (string, required) Account name to fetch addresses for\n\nResult:\n[\"value\",...] (array of string) All addresses controlled by 'account'\n",
+		"getbalance":                   "getbalance (\"account\" minconf=1 \"token\")\n\nCalculates and returns the balance of one or all accounts.\n\nArguments:\n1. account (string, optional)             DEPRECATED -- The account name to query the balance for, or \"*\" to consider all accounts (default=\"*\")\n2. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an unspent output's value is included in the balance\n3. token   (string, optional)             Token of the balance (default=\"STB\")\n\nResult (account != \"*\"):\nn.nnn (numeric) The balance of 'account' valued in bitcoin\n\nResult (account = \"*\"):\nn.nnn (numeric) The balance of all accounts valued in bitcoin\n",
+		"getbestblockhash":             "getbestblockhash\n\nReturns the hash of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\n\"value\" (string) The hash of the most recent synced-to block\n",
+		"getblockcount":                "getblockcount\n\nReturns the blockchain height of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\nn.nnn (numeric) The blockchain height of the most recent synced-to block\n",
+		"getinfo":                      "getinfo\n\nReturns a JSON object containing various state info.\n\nArguments:\nNone\n\nResult:\n{\n \"version\": n,          (numeric) The version of the server\n \"protocolversion\": n,  (numeric) The latest supported protocol version\n \"walletversion\": n,    (numeric) The version of the address manager database\n \"balance\": n.nnn,      (numeric) The balance of all accounts calculated with one block confirmation\n \"blocks\": n,           (numeric) The number of blocks processed\n \"timeoffset\": n,       (numeric) The time offset\n \"connections\": n,      (numeric) The number of connected peers\n \"proxy\": \"value\",      (string)  The proxy used by the server\n \"difficulty\": n.nnn,   (numeric) The current target difficulty\n \"testnet\": true|false, (boolean) Whether or not server is using testnet\n \"keypoololdest\": n,    (numeric) Unset\n \"keypoolsize\": n,      (numeric) Unset\n \"unlocked_until\": n,   (numeric) Unset\n \"paytxfee\": n.nnn,     (numeric) The increment used each time more fee is required for an authored transaction\n \"relayfee\": n.nnn,     (numeric) The minimum relay fee for non-free transactions in BTC/KB\n \"errors\": \"value\",     (string)  Any current errors\n}                       \n",
+		"getnewaddress":                "getnewaddress (\"account\")\n\nGenerates and returns a new payment address.\n\nArguments:\n1. account (string, optional) DEPRECATED -- Account name the new address will belong to (default=\"default\")\n\nResult:\n\"value\" (string) The payment address\n",
+		"getrawchangeaddress":          "getrawchangeaddress (\"account\")\n\nGenerates and returns a new internal payment address for use as a change address in raw transactions.\n\nArguments:\n1. account (string, optional) Account name the new internal address will belong to (default=\"default\")\n\nResult:\n\"value\" (string) The internal payment address\n",
+		"getreceivedbyaccount":         "getreceivedbyaccount \"account\" (minconf=1)\n\nDEPRECATED -- Returns the total amount received by addresses of some account, including spent outputs and excluding change.\n\nArguments:\n1. account (string, required)             Account name to query total received amount for\n2. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an output's value is included in the total\n\nResult:\nn.nnn (numeric) The total received amount valued in bitcoin\n",
+		"getreceivedbyaddress":         "getreceivedbyaddress \"address\" (minconf=1)\n\nReturns the total amount received by a single address, including spent outputs.\n\nArguments:\n1. address (string, required)             Payment address which received outputs to include in total\n2. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an output's value is included in the total\n\nResult:\nn.nnn (numeric) The total received amount valued in bitcoin\n",
+		"gettransaction":               "gettransaction \"txid\" (includewatchonly=false)\n\nReturns a JSON object with details regarding a transaction relevant to this wallet.\n\nArguments:\n1. txid             (string, required)                 Hash of the transaction to query\n2. includewatchonly (boolean, optional, default=false) Also consider transactions involving watched addresses\n\nResult:\n{\n \"amount\": n.nnn,                  (numeric)         The total amount this transaction credits to the wallet, valued in bitcoin\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value, or 0 if 'txid' is not a sent transaction\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"txid\": \"value\",                  (string)          The transaction hash\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"bip125-replaceable\": \"value\",    (string)          Whether the transaction can be replaced by BIP0125 replacement: \"yes\" while it is unmined and signals replaceability, or \"no\"\n \"replaces_txid\": \"value\",         (string)          The hash of the transaction this transaction replaced with bumpfee (omitted if it is not a replacement)\n \"replaced_by_txid\": \"value\",      (string)          The hash of the transaction which replaced this transaction with bumpfee (omitted if it was not replaced)\n \"signerattestation\": {            (object)          The attestation of the external signing device of the transaction (omitted unless signed by a device identifying itself)\n  \"signer\": \"value\",               (string)          The kind of external signer, such as hwi\n  \"type\": \"value\",                 (string)          The vendor of the signing device (omitted if unknown)\n  \"model\": \"value\",                (string)          The model of the signing device (omitted if unknown)\n  \"fingerprint\": \"value\",          (string)          The hex encoded master key fingerprint of the signing device\n  \"evidence\": \"value\",             (string)          The hex encoded attestation evidence signed by the device (omitted if the device provides none)\n  \"time\": n,                       (numeric)         The Unix time the transaction was signed\n },                                                  \n \"details\": [{                     (array of object) Additional details for each recorded wallet credit and debit\n  \"account\": \"value\",              (string)          DEPRECATED -- Unset\n  \"address\": \"value\",              (string)          The address an output was paid to, or the empty string if the output is nonstandard or this detail is regarding a transaction input\n  \"amount\": n.nnn,                 (numeric)         The amount of a received output\n  \"category\": \"value\",             (string)          The kind of detail: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs\n  \"involveswatchonly\": true|false, (boolean)         Unset\n  \"fee\": n.nnn,                    (numeric)         The included fee for a sent transaction\n  \"vout\": n,                       (numeric)         The transaction output index\n },...],                                             \n \"hex\": \"value\",                   (string)          The transaction encoded as a hexadecimal string\n}                                  \n",
+		"help":                         "help (\"command\")\n\nReturns a list of all commands or help for a specified command.\n\nArguments:\n1. command (string, optional) The command to retrieve help for\n\nResult (no command provided):\n\"value\" (string) List of commands\n\nResult (command specified):\n\"value\" (string) Help for specified command\n",
+		"importaddress":                "importaddress \"address\" \"account\" (rescan=true)\n\nImports an address without its private key to an account.\nOutputs paying to the address are included in the balances and transactions of the account as watch-only, and are never spent by the wallet.\n\nArguments:\n1. address (string, required)                The address to watch\n2. account (string, required)                The name of the account to import the address to (default=\"default\")\n3. rescan  (boolean, optional, default=true) Rescan the blockchain (since the genesis block) for outputs paying to the address\n\nResult:\nNothing\n",
+		"importprivkey":                "importprivkey \"privkey\" (\"label\" rescan=true)\n\nImports a WIF-encoded private key to the 'imported' account.\n\nArguments:\n1. privkey (string, required)                The WIF-encoded private key\n2. label   (string, optional)                Unused (must be unset or 'imported')\n3. rescan  (boolean, optional, default=true) Rescan the blockchain (since the genesis block) for outputs controlled by the imported key\n\nResult:\nNothing\n",
+		"importwallet":                 "importwallet \"filename\"\n\nImports the private keys of a wallet dump file written by dumpwallet or bitcoind to the 'imported' account, and rescans the blockchain from the block of the earliest key time.\nKeys already in the wallet are skipped, and scripts of the dump are ignored.\n\nArguments:\n1. filename (string, required) Path of the wallet dump file\n\nResult:\nNothing\n",
+		"keypoolrefill":                "keypoolrefill (newsize=100)\n\nDEPRECATED -- This request does nothing since no keypool is maintained.\n\nArguments:\n1. newsize (numeric, optional, default=100) Unused\n\nResult:\nNothing\n",
+		"listaccounts":                 "listaccounts (minconf=1)\n\nDEPRECATED -- Returns a JSON object of all accounts and their balances.\n\nArguments:\n1. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an unspent output's value is included in the balance\n\nResult:\n{\n \"The account name\": The account balance valued in bitcoin, (object) JSON object with account names as keys and bitcoin amounts as values\n ...\n}\n",
+		"listlockunspent":              "listlockunspent\n\nReturns a JSON array of outpoints marked as locked (with lockunspent) for this wallet.\n\nArguments:\nNone\n\nResult:\n[{\n \"txid\": \"value\", (string)  The transaction hash of the referenced output\n \"vout\": n,       (numeric) The output index of the referenced output\n},...]\n",
+		"listreceivedbyaccount":        "listreceivedbyaccount (minconf=1 includeempty=false includewatchonly=false)\n\nDEPRECATED -- Returns a JSON array of objects listing all accounts and the total amount received by each account, excluding change.\n\nArguments:\n1. minconf          (numeric, optional, default=1)     Minimum number of block confirmations required before a transaction is considered\n2. includeempty     (boolean, optional, default=false) Unused\n3. includewatchonly (boolean, optional, default=false) Unused\n\nResult:\n[{\n \"account\": \"value\", (string)  The name of the account\n \"amount\": n.nnn,    (numeric) Total amount received by payment addresses of the account valued in bitcoin\n \"confirmations\": n, (numeric) Number of block confirmations of the most recent transaction relevant to the account\n},...]\n",
+		"listreceivedbyaddress":        "listreceivedbyaddress (minconf=1 includeempty=false includewatchonly=false)\n\nReturns a JSON array of objects listing wallet payment addresses and their total received amounts.  Change addresses and the change received by them are not listed.\n\nArguments:\n1. minconf          (numeric, optional, default=1)     Minimum number of block confirmations required before a transaction is considered\n2. includeempty     (boolean, optional, default=false) Unused\n3. includewatchonly (boolean, optional, default=false) Unused\n\nResult:\n[{\n \"account\": \"value\",              (string)          DEPRECATED -- Unset\n \"address\": \"value\",              (string)          The payment address\n \"amount\": n.nnn,                 (numeric)         Total amount received by the payment address valued in bitcoin\n \"confirmations\": n,              (numeric)         Number of block confirmations of the most recent transaction relevant to the address\n \"txids\": [\"value\",...],          (array of string) Transaction hashes of all transactions involving this address\n \"involvesWatchonly\": true|false, (boolean)         Unset\n},...]\n",
+		"listsinceblock":               "listsinceblock (\"blockhash\" targetconfirmations=1 includewatchonly=false)\n\nReturns a JSON array of objects listing details of all wallet transactions after some block.\n\nArguments:\n1. blockhash           (string, optional)                 Hash of the parent block of the first block to consider transactions from, or unset to list all transactions\n2. targetconfirmations (numeric, optional, default=1)     Minimum number of block confirmations of the last block in the result object.  Must be 1 or greater.  Note: The transactions array in the result object is not affected by this parameter\n3. includewatchonly    (boolean, optional, default=false) Unused\n\nResult:\n{\n \"transactions\": [{                 (array of object) JSON array of objects containing verbose details of the each transaction\n  \"abandoned\": true|false,          (boolean)         Unset\n  \"account\": \"value\",               (string)          DEPRECATED -- Unset\n  \"address\": \"value\",               (string)          Payment address for a transaction output\n  \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n  \"bip125-replaceable\": \"value\",    (string)          Unset\n  \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n  \"blockindex\": n,                  (numeric)         Unset\n  \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n  \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n  \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n  \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n  \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n  \"involveswatchonly\": true|false,  (boolean)         Whether the output pays to a watch-only address\n  \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n  \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n  \"trusted\": true|false,            (boolean)         Unset\n  \"txid\": \"value\",                  (string)          The hash of the transaction\n  \"vout\": n,                        (numeric)         The transaction output index\n  \"walletconflicts\": [\"value\",...], (array of string) Unset\n  \"comment\": \"value\",               (string)          Unset\n  \"otheraccount\": \"value\",          (string)          Unset\n },...],                                              \n \"lastblock\": \"value\",              (string)          Hash of the latest-synced block to be used in later calls to listsinceblock\n}                                   \n",
+		"listtransactions":             "listtransactions (\"account\" count=10 from=0 includewatchonly=false)\n\nReturns a JSON array of objects containing verbose details for wallet transactions.\n\nArguments:\n1. account          (string, optional)                 DEPRECATED -- Unused (must be unset or \"*\")\n2. count            (numeric, optional, default=10)    Maximum number of transactions to create results from\n3. from             (numeric, optional, default=0)     Number of transactions to skip before results are created\n4. includewatchonly (boolean, optional, default=false) Unused\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Whether the output pays to a watch-only address\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          Unset\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
+		"listunspent":                  "listunspent (minconf=1 maxconf=9999999 [\"address\",...] \"token\")\n\nReturns a JSON array of objects representing unlocked unspent outputs controlled by wallet keys.\n\nArguments:\n1. minconf   (numeric, optional, default=1)       Minimum number of block confirmations required before a transaction output is considered\n2. maxconf   (numeric, optional, default=9999999) Maximum number of block confirmations required before a transaction output is excluded\n3. addresses (array of string, optional)          If set, limits the returned details to unspent outputs received by any of these payment addresses\n4. token     (string, optional)                   If set, limits the returned details to unspent outputs of this token\n\nResult:\n{\n \"txid\": \"value\",         (string)  The transaction hash of the referenced output\n \"vout\": n,               (numeric) The output index of the referenced output\n \"address\": \"value\",      (string)  The payment address that received the output\n \"account\": \"value\",      (string)  The account associated with the receiving payment address\n \"scriptPubKey\": \"value\", (string)  The output script encoded as a hexadecimal string\n \"redeemScript\": \"value\", (string)  Unset\n \"amount\": n.nnn,         (numeric) The amount of the output valued in bitcoin\n \"confirmations\": n,      (numeric) The number of block confirmations of the transaction\n \"spendable\": true|false, (boolean) Whether the output is entirely controlled by wallet keys/scripts (false for partially controlled multisig outputs or outputs to watch-only addresses)\n \"token\": \"value\",        (string)  The token of the output\n}                         \n",
+		"lockunspent":                  "lockunspent unlock [{\"txid\":\"value\",\"vout\":n},...]\n\nLocks or unlocks an unspent output.\nLocked outputs are not chosen for transaction inputs of authored transactions and are not included in 'listunspent' results.\nLocked outputs are saved across wallet restarts and are not included in spendable balances.\nIf unlock is true and no transaction outputs are specified, all locked outputs are marked unlocked.\n\nArguments:\n1. unlock       (boolean, required)         True to unlock outputs, false to lock\n2. transactions (array of object, required) Transaction outputs to lock or unlock\n[{\n \"txid\": \"value\", (string)  The transaction hash of the referenced output\n \"vout\": n,       (numeric) The output index of the referenced output\n},...]\n\nResult:\ntrue|false (boolean) The boolean 'true'\n",
+		"sendfrom":                     "sendfrom \"fromaccount\" \"toaddress\" amount (minconf=1 \"comment\" \"commentto\" \"token\")\n\nDEPRECATED -- Authors, signs, and sends a transaction that outputs some amount to a payment address.\nA change output is automatically included to send extra output value back to the original account.\nThe fee rate is set with settxfee, or estimated by the chain server for the --conftarget confirmation target.\nAn optional eighth parameter, after the token, is a JSON object of travel rule metadata attached to the sent transaction, with the \"originator\" and \"beneficiary\" objects and optional \"originatingvasp\" and \"beneficiaryvasp\" names of settravelrule.\n\nArguments:\n1. fromaccount (string, required)             Account to pick unspent outputs from\n2. toaddress   (string, required)             Address to pay\n3. amount      (numeric, required)            Amount to send to the payment address valued in bitcoin\n4. minconf     (numeric, optional, default=1) Minimum number of block confirmations required before a transaction output is eligible to be spent\n5. comment     (string, optional)             Unused\n6. commentto   (string, optional)             Unused\n7. token       (string, optional)             Token to send (default=\"STB\")\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
+		"sendmany":                     "sendmany \"fromaccount\" {\"address\":amount,...} (minconf=1 \"comment\" \"token\")\n\nAuthors, signs, and sends a transaction that outputs to many payment addresses.\nA change output is automatically included to send extra output value back to the original account.\nThe fee rate is set with settxfee, or estimated by the chain server for the --conftarget confirmation target.\nAn optional sixth parameter, after the token, lists recipient addresses paying the fee instead of the account; the fee is deducted from their amounts in proportion to them.\nAn optional seventh parameter is a hex-encoded data payload of at most 80 bytes, embedded in a zero-value OP_RETURN output of the transaction.\nAn optional eighth parameter is a P2WPKH address receiving the change instead of the account, and an optional ninth parameter an account whose internal address receives it; both may not be set.\nAn optional tenth parameter creates the transaction even when its fee exceeds the fee cap of the wallet, and an optional eleventh parameter is a JSON object of travel rule metadata attached to the sent transaction, with the \"originator\" and \"beneficiary\" objects and optional \"originatingvasp\" and \"beneficiaryvasp\" names of settravelrule.\n\nArguments:\n1. fromaccount (string, required) DEPRECATED -- Account to pick unspent outputs from\n2. amounts     (object, required) Pairs of payment addresses and the output amount to pay each\n{\n \"Address to pay\": Amount to send to the payment address valued in bitcoin, (object) JSON object using payment addresses as keys and output amounts valued in bitcoin to send to each address\n ...\n}\n3. minconf (numeric, optional, default=1) Minimum number of block confirmations required before a transaction output is eligible to be spent\n4. comment (string, optional)             Unused\n5. token   (string, optional)             Token to send (default=\"STB\")\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
+		"sendtoaddress":                "sendtoaddress \"address\" amount (\"comment\" \"commentto\" \"token\")\n\nAuthors, signs, and sends a transaction that outputs some amount to a payment address.\nUnlike sendfrom, outputs are always chosen from the default account.\nA change output is automatically included to send extra output value back to the original account.\nThe fee rate is set with settxfee, or estimated by the chain server for the --conftarget confirmation target.\nAn optional sixth parameter, after the token, is a hex-encoded data payload of at most 80 bytes, embedded in a zero-value OP_RETURN output of the transaction.\nAn optional seventh parameter creates the transaction even when its fee exceeds the fee cap of the wallet, and an optional eighth parameter is a JSON object of travel rule metadata attached to the sent transaction, with the \"originator\" and \"beneficiary\" objects and optional \"originatingvasp\" and \"beneficiaryvasp\" names of settravelrule.\n\nArguments:\n1. address   (string, required)  Address to pay\n2. amount    (numeric, required) Amount to send to the payment address valued in bitcoin\n3. comment   (string, optional)  Unused\n4. commentto (string, optional)  Unused\n5. token     (string, optional)  Token to send (default=\"STB\")\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
+		"bid":                          "bid amount price (minconf=1)\n\nAuthors, signs, and sends a bidding order to buy some amount of NDR.\nSTB outputs are chosen from the default account.\nReturn and change output are automatically included to send output value back to the original account.\n\nArguments:\n1. amount  (numeric, required)            Amount to buy valued in NDR\n2. price   (numeric, required)            Buying price valued in NDR/STB\n3. minconf (numeric, optional, default=1) Minimum number of block confirmations required before a transaction output is eligible to be spent\n\nResult:\n\"value\" (string) The hash of the sent order\n",
+		"ask":                          "ask amount price (minconf=1)\n\nAuthors, signs, and sends an asking order to sell some amount of NDR.\nNDR outputs are chosen from the default account.\nReturn and change output are automatically included to send output value back to the original account.\n\nArguments:\n1. amount  (numeric, required)            Amount to buy valued in NDR\n2. price   (numeric, required)            Selling price valued in NDR/STB\n3. minconf (numeric, optional, default=1) Minimum number of block confirmations required before a transaction output is eligible to be spent\n\nResult:\n\"value\" (string) The hash of the sent order\n",
+		"settxfee":                     "settxfee amount\n\nSets the fee rate per kilobyte of sent transactions, overriding the fee rate estimated by the chain server for the --conftarget confirmation target.\nA zero fee rate removes the override, and the relay fee rate is used when the chain server has no estimate.\n\nArguments:\n1. amount (numeric, required) The new fee rate per kilobyte valued in bitcoin, or 0 to estimate it\n\nResult:\ntrue|false (boolean) The boolean 'true'\n",
+		"signmessage":                  "signmessage \"address\" \"message\"\n\nSigns a message using the private key of a payment address.\nThe signature records whether the public key of the address is compressed, so that it can be verified against P2PKH addresses of uncompressed keys.\n\nArguments:\n1. address (string, required) Payment address of private key used to sign the message with\n2. message (string, required) Message to sign\n\nResult:\n\"value\" (string) The signed message encoded as a base64 string\n",
+		"signrawtransaction":           "signrawtransaction \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] [\"privkey\",...] flags=\"ALL\")\n\nSigns transaction inputs using private keys from this wallet and request.\nThe valid flags options are ALL, NONE, SINGLE, ALL|ANYONECANPAY, NONE|ANYONECANPAY, and SINGLE|ANYONECANPAY.\n\nArguments:\n1. rawtx    (string, required)                Unsigned or partially unsigned transaction to sign encoded as a hexadecimal string\n2. inputs   (array of object, optional)       Additional data regarding inputs that this wallet may not be tracking\n3. privkeys (array of string, optional)       Additional WIF-encoded private keys to use when creating signatures\n4. flags    (string, optional, default=\"ALL\") Sighash flags\n\nResult:\n{\n \"hex\": \"value\",         (string)          The resulting transaction encoded as a hexadecimal string\n \"complete\": true|false, (boolean)         Whether all input signatures have been created\n \"errors\": [{            (array of object) Script verification errors (if exists)\n  \"txid\": \"value\",       (string)          The transaction hash of the referenced previous output\n  \"vout\": n,             (numeric)         The output index of the referenced previous output\n  \"scriptSig\": \"value\",  (string)          The hex-encoded signature script\n  \"sequence\": n,         (numeric)         Script sequence number\n  \"error\": \"value\",      (string)          Verification or signing error related to the input\n },...],                                   \n}                        \n",
+		"validateaddress":              "validateaddress \"address\"\n\nVerify that an address is valid.\nExtra details are returned if the address is controlled by this wallet.\nThe following fields are valid only when the address is controlled by this wallet (ismine=true): isscript, pubkey, iscompressed, account, addresses, hex, script, and sigsrequired.\nThe following fields are only valid when address has an associated public key: pubkey, iscompressed.\nThe following fields are only valid when address is a pay-to-script-hash address: addresses, hex, and script.\nIf the address is a multisig address controlled by this wallet, the multisig fields will be left unset if the wallet is locked since the redeem script cannot be decrypted.\n\nArguments:\n1. address (string, required) Address to validate\n\nResult:\n{\n \"isvalid\": true|false,      (boolean)         Whether or not the address is valid\n \"address\": \"value\",         (string)          The payment address (only when isvalid is true)\n \"ismine\": true|false,       (boolean)         Whether this address is controlled by the wallet (only when isvalid is true)\n \"iswatchonly\": true|false,  (boolean)         Unset\n \"isscript\": true|false,     (boolean)         Whether the payment address is a pay-to-script-hash address (only when isvalid is true)\n \"pubkey\": \"value\",          (string)          The associated public key of the payment address, if any (only when isvalid is true)\n \"iscompressed\": true|false, (boolean)         Whether the address was created by hashing a compressed public key, if any (only when isvalid is true)\n \"account\": \"value\",         (string)          The account this payment address belongs to (only when isvalid is true)\n \"addresses\": [\"value\",...], (array of string) All associated payment addresses of the script if address is a multisig address (only when isvalid is true)\n \"hex\": \"value\",             (string)          The redeem script \n \"script\": \"value\",          (string)          The class of redeem script for a multisig address\n \"sigsrequired\": n,          (numeric)         The number of required signatures to redeem outputs to the multisig address\n}                            \n",
+		"verifymessage":                "verifymessage \"address\" \"signature\" \"message\"\n\nVerify a message was signed with the associated private key of some address.\nP2PKH, P2PK, P2WPKH and nested P2WPKH addresses are supported; the last two only commit to compressed public keys.\nBIP0322 simple signatures created by signmessagebip322 are also accepted for P2WPKH and P2TR addresses.\n\nArguments:\n1. address   (string, required) Address used to sign message\n2. signature (string, required) The signature to verify\n3. message   (string, required) The message to verify\n\nResult:\ntrue|false (boolean) Whether the message was signed with the private key of 'address'\n",
+		"walletlock":                   "walletlock\n\nLock the wallet.\n\nArguments:\nNone\n\nResult:\nNothing\n",
+		"walletpassphrase":             "walletpassphrase \"passphrase\" timeout\n\nUnlock the wallet.\nAn optional third parameter names an account protected by its own passphrase, set with setaccountpassphrase, to unlock with its passphrase instead of the wallet.\nAfter a wrong passphrase, passphrases are not tried again for a delay doubling with every consecutive wrong passphrase, and requests fail with error code -41 until it expires; the wallet may also be configured to disable unlocking after too many wrong passphrases.\nWebsocket clients subscribed with notifyunlockfailures are sent an unlockfailed notification for every wrong passphrase.\n\nArguments:\n1. passphrase (string, required)  The wallet passphrase\n2. timeout    (numeric, required) The number of seconds to wait before the wallet automatically locks\n\nResult:\nNothing\n",
+		"walletpassphrasechange":       "walletpassphrasechange \"oldpassphrase\" \"newpassphrase\"\n\nChange the wallet passphrase, re-encrypting the wallet keys under the new passphrase in a single database transaction.\nThe wallet keeps its lock state and unlock timeout, and websocket clients subscribed with notifylockstate are sent a walletlockstate notification, since the old passphrase no longer unlocks the wallet.\n\nArguments:\n1. oldpassphrase (string, required) The old wallet passphrase\n2. newpassphrase (string, required) The new wallet passphrase\n\nResult:\nNothing\n",
+		"createnewaccount":             "createnewaccount \"account\"\n\nCreates a new account.\nThe wallet must be unlocked for this request to succeed.\n\nArguments:\n1. account (string, required) Name of the new account\n\nResult:\nNothing\n",
+		"exportwatchingwallet":         "exportwatchingwallet (\"account\" download=false)\n\nCreates and returns a duplicate of the wallet database without any private keys to be used as a watching-only wallet.\n\nArguments:\n1. account  (string, optional)                 Unused (must be unset or \"*\")\n2. download (boolean, optional, default=false) Unused\n\nResult:\n\"value\" (string) The watching-only database encoded as a base64 string\n",
+		"getbestblock":                 "getbestblock\n\nReturns the hash and height of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\n{\n \"hash\": \"value\", (string)  The hash of the block\n \"height\": n,     (numeric) The blockchain height of the block\n}                 \n",
+		"getunconfirmedbalance":        "getunconfirmedbalance (\"account\")\n\nCalculates the unspent output value of all unmined transaction outputs for an account.\n\nArguments:\n1. account (string, optional) The account to query the unconfirmed balance for (default=\"default\")\n\nResult:\nn.nnn (numeric) Total amount of all unmined unspent outputs of the account valued in bitcoin.\n",
+		"listaddresstransactions":      "listaddresstransactions [\"address\",...] (\"account\")\n\nReturns a JSON array of objects containing verbose details for wallet transactions pertaining some addresses.\n\nArguments:\n1. addresses (array of string, required) Addresses to filter transaction results by\n2. account   (string, optional)          Unused (must be unset or \"*\")\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Whether the output pays to a watch-only address\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          Unset\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
+		"listalltransactions":          "listalltransactions (\"account\")\n\nReturns a JSON array of objects in the same format as 'listtransactions' without limiting the number of returned objects.\n\nArguments:\n1. account (string, optional) Unused (must be unset or \"*\")\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Whether the output pays to a watch-only address\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          Unset\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
+		"renameaccount":                "renameaccount \"oldaccount\" \"newaccount\"\n\nRenames an account.\n\nArguments:\n1. oldaccount (string, required) The old account name to rename\n2. newaccount (string, required) The new name for the account\n\nResult:\nNothing\n",
+		"walletislocked":               "walletislocked\n\nReturns whether or not the wallet is locked.\n\nArguments:\nNone\n\nResult:\ntrue|false (boolean) Whether the wallet is locked\n",
+		"getnewtaprootaddress":         "getnewtaprootaddress (\"account\")\n\nGenerates and returns a new BIP0086 pay-to-taproot address encoded as bech32m.\nThe wallet must be unlocked the first time this is called on a wallet created without the BIP0086 key scope.\n\nArguments:\n1. account (string, optional) Account name of the BIP0086 key scope the new address will belong to (default=\"default\")\n\nResult:\n\"value\" (string) The payment address\n",
+		"importwitnessscript":          "importwitnessscript \"script\"\n\nAdds a P2WSH witness script to the wallet so that outputs paying to its P2WSH and P2SH-P2WSH addresses are credited to the imported account and can be spent.\nMultisig, pay-to-pubkey and pay-to-pubkey-hash witness scripts can be spent when the wallet controls enough of their keys.\n\nArguments:\n1. script (string, required) Hex-encoded witness script\n\nResult:\n{\n \"address\": \"value\",     (string) The P2WSH address of the script\n \"p2shaddress\": \"value\", (string) The P2SH-P2WSH address of the script\n}                        \n",
+		"settravelrule":                "settravelrule \"txid\" {\"firstname\":\"value\",\"lastname\":\"value\",\"legalname\":\"value\",\"streetname\":\"value\",\"buildingnumber\":\"value\",\"postcode\":\"value\",\"townname\":\"value\",\"country\":\"value\",\"nationalid\":\"value\",\"nationalidtype\":\"value\",\"dateofbirth\":\"value\",\"placeofbirth\":\"value\",\"accountnumber\":\"value\"} {\"firstname\":\"value\",\"lastname\":\"value\",\"legalname\":\"value\",\"streetname\":\"value\",\"buildingnumber\":\"value\",\"postcode\":\"value\",\"townname\":\"value\",\"country\":\"value\",\"nationalid\":\"value\",\"nationalidtype\":\"value\",\"dateofbirth\":\"value\",\"placeofbirth\":\"value\",\"accountnumber\":\"value\"} (\"originatingvasp\" \"beneficiaryvasp\")\n\nAttaches travel rule originator and beneficiary metadata to a wallet transaction, replacing any metadata attached earlier.\nThe metadata is stored encrypted and requires the wallet to be unlocked.\n\nArguments:\n1. txid       (string, required) Hash of the wallet transaction\n2. originator (object, required) The person or institution sending the funds\n{\n \"firstname\": \"value\",      (string) First name of a natural person\n \"lastname\": \"value\",       (string) Last name of a natural person\n \"legalname\": \"value\",      (string) Name of a legal person; may not be combined with a natural person name\n \"streetname\": \"value\",     (string) Street of the geographic address\n \"buildingnumber\": \"value\", (string) Building number of the geographic address\n \"postcode\": \"value\",       (string) Post code of the geographic address\n \"townname\": \"value\",       (string) Town of the geographic address\n \"country\": \"value\",        (string) ISO 3166-1 alpha-2 country code of the geographic address\n \"nationalid\": \"value\",     (string) National identifier, such as a passport number or LEI\n \"nationalidtype\": \"value\", (string) IVMS101 national identifier type code (such as CCPT, RAID or LEIX)\n \"dateofbirth\": \"value\",    (string) Date of birth of a natural person (YYYY-MM-DD)\n \"placeofbirth\": \"value\",   (string) Place of birth of a natural person\n \"accountnumber\": \"value\",  (string) Account or address of the party used for the transfer\n}                           \n3. beneficiary (object, required) The person or institution receiving the funds\n{\n \"firstname\": \"value\",      (string) First name of a natural person\n \"lastname\": \"value\",       (string) Last name of a natural person\n \"legalname\": \"value\",      (string) Name of a legal person; may not be combined with a natural person name\n \"streetname\": \"value\",     (string) Street of the geographic address\n \"buildingnumber\": \"value\", (string) Building number of the geographic address\n \"postcode\": \"value\",       (string) Post code of the geographic address\n \"townname\": \"value\",       (string) Town of the geographic address\n \"country\": \"value\",        (string) ISO 3166-1 alpha-2 country code of the geographic address\n \"nationalid\": \"value\",     (string) National identifier, such as a passport number or LEI\n \"nationalidtype\": \"value\", (string) IVMS101 national identifier type code (such as CCPT, RAID or LEIX)\n \"dateofbirth\": \"value\",    (string) Date of birth of a natural person (YYYY-MM-DD)\n \"placeofbirth\": \"value\",   (string) Place of birth of a natural person\n \"accountnumber\": \"value\",  (string) Account or address of the party used for the transfer\n}                           \n4. originatingvasp (string, optional) Legal name of the virtual asset service provider of the originator\n5. beneficiaryvasp (string, optional) Legal name of the virtual asset service provider of the beneficiary\n\nResult:\nNothing\n",
+		"exporttravelrule":             "exporttravelrule (\"txid\")\n\nExports travel rule metadata as IVMS101 JSON.\nThe wallet must be unlocked.\n\nArguments:\n1. txid (string, optional) Hash of the transaction to export; when omitted, the metadata of every transaction is exported as an array of objects with txid and ivms101 keys\n\nResult:\n\"value\" (string) The IVMS101 JSON document\n",
+		"walletcreatefundedpsbt":       "walletcreatefundedpsbt {\"address\":amount,...} (\"fromaccount\" minconf \"token\" \"coinselection\" replaceable conftarget feerate locktime overridefeecap)\n\nAuthors an unsigned transaction that outputs to many payment addresses and returns it as a BIP0174 partially signed transaction (PSBT) for external signers.\nA change output is automatically included to send extra output value back to the original account.\nThe spent outputs are locked until they are unlocked with lockunspent.\n\nArguments:\n1. amounts (object, required) Pairs of payment addresses and the output amount to pay each\n{\n \"Address to pay\": Amount to send to the payment address valued in bitcoin, (object) JSON object using payment addresses as keys and output amounts valued in bitcoin to send to each address\n ...\n}\n2.  fromaccount    (string, optional)  Account to pick unspent outputs from (default=\"default\")\n3.  minconf        (numeric, optional) Minimum number of block confirmations required before a transaction output is eligible to be spent (default=1)\n4.  token          (string, optional)  Token of the outputs (default=\"STB\")\n5.  coinselection  (string, optional)  Strategy picking the outputs to spend, one of oldestfirst, largestfirst or branchandbound (default=the wallet's coinselection option)\n6.  replaceable    (boolean, optional) Whether the transaction signals BIP0125 replaceability (default=true unless the wallet runs with --norbf)\n7.  conftarget     (numeric, optional) Number of blocks within which the fee rate is estimated to get the transaction mined, unless set with settxfee (default=the wallet's conftarget option)\n8.  feerate        (numeric, optional) Fee rate of the transaction in satoshis per virtual byte, which may not be used with conftarget (default=the wallet's fee rate)\n9.  locktime       (numeric, optional) Lock time of the transaction, a block height below 500000000 and a UNIX timestamp otherwise, such as required to spend outputs locked with OP_CHECKLOCKTIMEVERIFY (default=the current block height unless the wallet runs with --nolocktime)\n10. overridefeecap (boolean, optional) Create the transaction even when its fee exceeds the fee cap of the wallet set by --maxtxfee, --maxfeepercent and --maxdailyfee (default=false)\n\nResult:\n{\n \"psbt\": \"value\", (string)  The base64-encoded PSBT\n \"fee\": n.nnn,    (numeric) The fee paid by the transaction valued in bitcoin\n \"changepos\": n,  (numeric) The index of the change output, or -1 if no change output was added\n}                 \n",
+		"walletprocesspsbt":            "walletprocesspsbt \"psbt\" (sign \"sighashtype\")\n\nUpdates a PSBT with the UTXO data, scripts and key derivations known to the wallet, optionally adds the signatures of wallet keys, and finalizes the inputs that have all of their signatures.\nSigning requires the wallet to be unlocked.\n\nArguments:\n1. psbt        (string, required)  The base64-encoded PSBT\n2. sign        (boolean, optional) Sign the inputs with wallet keys (default=true)\n3. sighashtype (string, optional)  The signature hash type to sign with, which must match the type of inputs that specify one, one of \"ALL\", \"NONE\", \"SINGLE\", \"ALL|ANYONECANPAY\", \"NONE|ANYONECANPAY\", or \"SINGLE|ANYONECANPAY\" (default=\"ALL\")\n\nResult:\n{\n \"psbt\": \"value\",        (string)  The base64-encoded updated PSBT\n \"complete\": true|false, (boolean) Whether every input of the PSBT is finalized\n}                        \n",
+		"finalizepsbt":                 "finalizepsbt \"psbt\" (extract)\n\nFinalizes the inputs of a PSBT that have all of their signatures and, when every input is finalized, extracts the signed transaction.\n\nArguments:\n1. psbt    (string, required)  The base64-encoded PSBT\n2. extract (boolean, optional) Return the signed transaction instead of the PSBT when the PSBT is complete (default=true)\n\nResult:\n{\n \"psbt\": \"value\",        (string)  The base64-encoded PSBT, if the transaction was not extracted\n \"hex\": \"value\",         (string)  The hex-encoded signed transaction, if it was extracted\n \"complete\": true|false, (boolean) Whether every input of the PSBT is finalized\n}                        \n",
+		"exportpsbt":                   "exportpsbt \"psbt\" (\"file\" qrpartlen)\n\nExports a PSBT for an offline signer, as the parts of an animated QR code in the BBQr format and optionally as a binary PSBT file.\n\nArguments:\n1. psbt      (string, required)  The base64-encoded PSBT\n2. file      (string, optional)  Path of a new file the binary PSBT is written to\n3. qrpartlen (numeric, optional) Maximum number of characters of each QR code part (default=400)\n\nResult:\n{\n \"file\": \"value\",          (string)          The path of the written file, if any\n \"qrparts\": [\"value\",...], (array of string) The BBQr parts of the PSBT, to be shown in order as an animated QR code\n}                          \n",
+		"importsignedtx":               "importsignedtx [\"part\",...] (\"file\")\n\nBroadcasts a transaction signed by an offline signer, usually a PSBT created with walletcreatefundedpsbt, and adds it to the wallet.\nThe signed transaction is either read from a file or passed as the scanned BBQr parts of an animated QR code, as a base64-encoded PSBT, or as a hex-encoded transaction.\nReturns the transaction hash of the broadcast transaction.\n\nArguments:\n1. parts (array of string, required) The BBQr parts in any order, or a single base64-encoded PSBT or hex-encoded transaction; empty when file is set\n2. file  (string, optional)          Path of a file holding the signed PSBT or transaction, in binary or text encoding\n\nResult:\n\"value\" (string) The transaction hash of the broadcast transaction\n",
+		"getaggregatebalance":          "getaggregatebalance ([\"account\",...] minconf \"token\")\n\nCalculates the balances of all or some accounts concurrently and returns them with their totals.\n\nArguments:\n1. accounts (array of string, optional) Names of the accounts to include (default=all accounts)\n2. minconf  (numeric, optional)         Minimum number of block confirmations required before an unspent output's value is included in the spendable balance (default=1)\n3. token    (string, optional)          Token of the balances (default=\"STB\")\n\nResult:\n{\n \"accounts\": [{            (array of object) The balances of each account\n  \"account\": \"value\",      (string)          The name of the account\n  \"total\": n.nnn,          (numeric)         The total balance of the account valued in bitcoin\n  \"spendable\": n.nnn,      (numeric)         The balance of the account with at least minconf confirmations valued in bitcoin\n  \"immaturereward\": n.nnn, (numeric)         The immature coinbase reward balance of the account valued in bitcoin\n  \"watchonly\": n.nnn,      (numeric)         The balance of the account paid to watch-only addresses valued in bitcoin\n },...],                                     \n \"total\": n.nnn,           (numeric)         The total balance of the accounts valued in bitcoin\n \"spendable\": n.nnn,       (numeric)         The spendable balance of the accounts valued in bitcoin\n \"immaturereward\": n.nnn,  (numeric)         The immature coinbase reward balance of the accounts valued in bitcoin\n \"watchonly\": n.nnn,       (numeric)         The balance of the accounts paid to watch-only addresses valued in bitcoin\n}                          \n",
+		"sweepprivkey":                 "sweepprivkey \"privkey\" (\"account\" startheight \"token\" \"pool\")\n\nSends every unspent output paying to a private key to the next address of an account, without importing the key.\nThe outputs are found by filtering the blocks from startheight for the P2PKH, P2WPKH and nested P2WPKH addresses of the key.\n\nArguments:\n1. privkey     (string, required)  The private key to sweep encoded as a WIF string\n2. account     (string, optional)  The account the outputs are swept to (default=\"default\")\n3. startheight (numeric, optional) Height of the first block filtered for outputs of the key (default=0)\n4. token       (string, optional)  Token of the swept outputs (default=\"STB\")\n5. pool        (string, optional)  Address pool the outputs are swept to instead of an account, using the next address of the pool\n\nResult:\n{\n \"txid\": \"value\",    (string)  The hash of the sweep transaction\n \"address\": \"value\", (string)  The address the outputs were swept to\n \"amount\": n.nnn,    (numeric) The amount received by the wallet valued in bitcoin\n \"fee\": n.nnn,       (numeric) The fee paid by the sweep transaction valued in bitcoin\n \"inputs\": n,        (numeric) The number of swept outputs\n}                    \n",
+		"createaccountwithpath":        "createaccountwithpath \"account\" \"path\"\n\nCreates a new account whose extended key is derived from the master key along a custom derivation path instead of m/44'/<coin type>'/<account>'.\nAddresses of the account are derived below the account key as for other accounts.  The wallet must be unlocked.\n\nArguments:\n1. account (string, required) Name of the new account\n2. path    (string, required) Derivation path of the account key, such as m/44'/60'/0', with hardened levels marked with ' or h\n\nResult:\nNothing\n",
+		"reserveaddressindexes":        "reserveaddressindexes \"account\" count\n\nReserves the next external address indexes of an account for a system deriving addresses itself from the account extended public key.\nThe wallet never returns addresses at reserved indexes from getnewaddress, but watches them for transactions.\n\nArguments:\n1. account (string, required)  Name of the account\n2. count   (numeric, required) Number of indexes to reserve (at most 10000)\n\nResult:\n{\n \"account\": \"value\", (string)  The name of the account\n \"branch\": n,        (numeric) The branch of the reserved indexes, which is always the external branch 0\n \"firstindex\": n,    (numeric) The first reserved index\n \"lastindex\": n,     (numeric) The last reserved index\n}                    \n",
+		"importmulti":                  "importmulti [{\"privkey\":privkey,\"address\":address,\"script\":script,\"account\":account,\"timestamp\":n},...] (rescan)\n\nImports private keys, watch-only addresses and witness scripts, each with the time it was first used on the chain.\nA single rescan is started from the first block within two hours of the earliest timestamp, instead of a rescan from the genesis block for each import.\n\nArguments:\n1. requests (array of object, required) The keys, addresses and scripts to import\n[{\n \"privkey\": \"value\", (string)  WIF-encoded private key to import to the 'imported' account\n \"address\": \"value\", (string)  Address to import as watch-only\n \"script\": \"value\",  (string)  Hex-encoded witness script to import\n \"account\": \"value\", (string)  The account a watch-only address is imported to (default=\"default\")\n \"timestamp\": n,     (numeric) Unix time the key, address or script was first used, or 0 to rescan from the genesis block\n},...]\n2. rescan (boolean, optional) Rescan the blockchain for outputs paying to the imports (default=true)\n\nResult:\n[{\n \"success\": true|false, (boolean) Whether the import succeeded\n \"error\": \"value\",      (string)  The reason the import failed\n},...]\n",
+		"exportledger":                 "exportledger \"format\" (startheight endheight)\n\nExports the mined wallet transactions as a double-entry accounting ledger.\nEach account is an asset account under Assets:Wallet, with fees posted to Expenses:Fees and value exchanged with other wallets to Income:Received and Expenses:Sent.\n\nArguments:\n1. format      (string, required)  The plain text accounting format, either \"ledger\" for ledger-cli and hledger or \"beancount\"\n2. startheight (numeric, optional) Height of the first block of the exported transactions (default=0)\n3. endheight   (numeric, optional) Height of the last block of the exported transactions (default=the height the wallet is synced to)\n\nResult:\n\"value\" (string) The ledger file contents\n",
+		"getaddressusage":              "getaddressusage \"address\"\n\nReturns when a wallet address was generated or imported, and when it was first seen and last used on the chain.\nAddresses are used by the transactions paying to and spending from them, at the time of their block or when received if unmined.\n\nArguments:\n1. address (string, required) The wallet address\n\nResult:\n{\n \"address\": \"value\", (string)  The address\n \"generated\": n,     (numeric) Unix time the address was derived or imported\n \"used\": true|false, (boolean) Whether a transaction used the address\n \"firstseen\": n,     (numeric) Unix time of the first transaction using the address (omitted when unknown)\n \"lastused\": n,      (numeric) Unix time of the last transaction using the address (omitted when unknown)\n}                    \n",
+		"getunlocktimeout":             "getunlocktimeout\n\nReturns whether the wallet is locked and, when it was unlocked by walletpassphrase with a timeout, when it is relocked.\nThe wallet is relocked automatically when the timeout expires, and websocket clients subscribed with notifylockstate are sent a walletlockstate notification.\n\nArguments:\nNone\n\nResult:\n{\n \"locked\": true|false, (boolean) Whether the wallet is locked\n \"unlockeduntil\": n,   (numeric) Unix time the wallet is relocked (omitted when locked or unlocked without a timeout)\n \"remaining\": n,       (numeric) Seconds remaining until the wallet is relocked (omitted when locked or unlocked without a timeout)\n}                      \n",
+		"importprivkeys":               "importprivkeys [\"privkey\",...] (timestamp=0 rescan=true)\n\nImports many WIF-encoded private keys to the 'imported' account in a single database update, with a single rescan for all of them.\nThe keys are validated and encrypted concurrently, so that thousands of keys may be imported at once. The wallet must be unlocked.\n\nArguments:\n1. privkeys  (array of string, required)       The WIF-encoded private keys to import\n2. timestamp (numeric, optional, default=0)    Unix time the keys were first used, or 0 to rescan from the genesis block\n3. rescan    (boolean, optional, default=true) Rescan the blockchain for outputs paying to the imported keys\n\nResult:\n{\n \"imported\": n,     (numeric)         The number of keys imported\n \"duplicates\": n,   (numeric)         The number of keys that were already in the wallet\n \"errors\": [{       (array of object) The keys that failed to import\n  \"index\": n,       (numeric)         The index of the key in the request\n  \"error\": \"value\", (string)          The reason the key failed to import\n },...],                              \n}                   \n",
+		"setaccountpassphrase":         "setaccountpassphrase \"account\" \"passphrase\"\n\nProtects an account with its own passphrase, or removes the passphrase of the account when the passphrase is empty. The wallet must be unlocked.\nThe private keys of an account protected by its own passphrase are only available after the account is unlocked with walletpassphrase and the account name, independently from the lock state of the wallet.\n\nArguments:\n1. account    (string, required) The name of the account\n2. passphrase (string, required) The passphrase of the account, or an empty string to remove it\n\nResult:\nNothing\n",
+		"getoperationsequence":         "getoperationsequence\n\nReturns the wallet operation sequence, the number of mutating requests that succeeded since the wallet was created.\nThe responses to mutating requests include the sequence after the request in a sequence member, next to the result.\nA mutating request with a sequence member is only run when the wallet operation sequence still has that value, and otherwise fails with error code -40.\n\nArguments:\nNone\n\nResult:\nn.nnn (numeric) The wallet operation sequence\n",
+		"getaccountfootprint":          "getaccountfootprint (\"account\")\n\nReturns the size of the transactions of each account, counting a transaction for every account it credits or debits, with the quota configured for the account.\nWebsocket clients subscribed with notifyaccountquota are sent an accountquota notification when an account first exceeds its quota.\n\nArguments:\n1. account (string, optional) The account to return the footprint of (default=all accounts)\n\nResult:\n[{\n \"account\": \"value\", (string)  The name of the account\n \"footprint\": n,     (numeric) The size in bytes of the serialized transactions of the account\n \"quota\": n,         (numeric) The maximum footprint of the account in bytes (omitted when the account has no quota)\n},...]\n",
+		"getauditlog":                  "getauditlog (from=1 count=100)\n\nReturns records of the audit log of sensitive wallet operations: unlocks, wrong passphrases, passphrase changes, private key exports, imports, sends and address generation.\nEvery record includes the hash of the previous record, and the request fails when a record was removed or modified.\n\nArguments:\n1. from  (numeric, optional, default=1)   The sequence number of the first record to return\n2. count (numeric, optional, default=100) The maximum number of records to return\n\nResult:\n[{\n \"seq\": n,             (numeric) The sequence number of the record, starting at 1\n \"time\": n,            (numeric) The time of the operation in seconds since 1 Jan 1970 GMT\n \"operation\": \"value\", (string)  The operation (unlock, wrongpassphrase, passphrasechange, keyexport, import, send, newaddress or totp)\n \"details\": \"value\",   (string)  A description of the operation\n \"hash\": \"value\",      (string)  The hex-encoded SHA256 hash of the record\n \"prevhash\": \"value\",  (string)  The hex-encoded hash of the previous record, or zeros for the first record\n},...]\n",
+		"getstatecommitment":           "getstatecommitment (height)\n\nReturns a commitment to the outputs of the wallet unspent at a block height and to the balances of its accounts at that height.\nThe outputs and the balances are the leaves of two merkle trees, and the root commits to both trees, the block and the last record of the audit log.\nRecomputing the commitment of a height later returns the same merkle roots unless the wallet history of that height was altered or pruned.\n\nArguments:\n1. height (numeric, optional) The height of the state to commit to (default=the height the wallet is synced to)\n\nResult:\n{\n \"height\": n,            (numeric)         The height of the committed state\n \"blockhash\": \"value\",   (string)          The hash of the block at the height\n \"utxos\": n,             (numeric)         The number of unspent outputs\n \"utxoroot\": \"value\",    (string)          The merkle root of the unspent outputs, sorted by outpoint\n \"balances\": [{          (array of object) The balances of the accounts, sorted by account and token\n  \"account\": \"value\",    (string)          The name of the account\n  \"token\": \"value\",      (string)          The token of the balance\n  \"amount\": n.nnn,       (numeric)         The balance of the token in the unspent outputs of the account\n },...],                                   \n \"balanceroot\": \"value\", (string)          The merkle root of the balances\n \"auditseq\": n,          (numeric)         The sequence number of the last record of the audit log, or 0 when it is empty\n \"audithash\": \"value\",   (string)          The hex-encoded hash of the last record of the audit log\n \"root\": \"value\",        (string)          The commitment to the block, both merkle roots and the last record of the audit log\n}                        \n",
+		"getproofofreserves":           "getproofofreserves \"challenge\"\n\nReturns a proof of the reserves of the wallet: the outputs unspent at the block the wallet is synced to, grouped by address, with every address signing a message which commits to the block and a challenge.\nThe signatures are created like those of signmessage, and can be checked with verifymessage by anyone holding the message, without exporting any key.\nOutputs already spent by unmined transactions are not included, and the wallet must be unlocked.\n\nArguments:\n1. challenge (string, required) A challenge chosen by the verifier, such as a random nonce, included in the signed message so that proofs cannot be reused\n\nResult:\n{\n \"height\": n,           (numeric)         The height of the block of the proof\n \"blockhash\": \"value\",  (string)          The hash of the block of the proof\n \"message\": \"value\",    (string)          The message signed by every address\n \"addresses\": [{        (array of object) The addresses with unspent outputs\n  \"address\": \"value\",   (string)          The address\n  \"signature\": \"value\", (string)          The base64-encoded signature of the message by the key of the address (omitted when the wallet has no private key for the address)\n  \"outputs\": [{         (array of object) The unspent outputs of the address\n   \"txid\": \"value\",     (string)          The hash of the transaction of the output\n   \"vout\": n,           (numeric)         The index of the output in its transaction\n   \"height\": n,         (numeric)         The height of the block of the transaction\n   \"amount\": n.nnn,     (numeric)         The amount of the output\n   \"token\": \"value\",    (string)          The token of the output\n  },...],                                 \n },...],                                  \n \"totals\": [{           (array of object) The total reserves of each token\n  \"token\": \"value\",     (string)          The token\n  \"amount\": n.nnn,      (numeric)         The total amount of the unspent outputs of the token\n  \"signed\": n.nnn,      (numeric)         The total amount of the unspent outputs of the token paying to addresses which signed the message\n },...],                                  \n}                       \n",
+		"enrolltotp":                   "enrolltotp\n\nEnrolls a new TOTP secret, replacing any enrolled secret.\nOnce a secret is enrolled, requests spending outputs, signing transactions or exporting private keys must include the current one-time password of the secret in a totp member of the request object, next to the params, and otherwise fail with error code -42.\nEvery one-time password is only accepted once, and wrong passwords are throttled like wrong passphrases.\nReplacing an enrolled secret requires a one-time password of that secret.\n\nArguments:\nNone\n\nResult:\n{\n \"secret\": \"value\", (string) The base32-encoded secret to add to an authenticator app\n \"uri\": \"value\",    (string) The otpauth URI of the secret, which authenticator apps can read from a QR code\n}                   \n",
+		"disabletotp":                  "disabletotp\n\nRemoves the enrolled TOTP secret, so that one-time passwords are no longer required.\nThe request requires a one-time password of the secret.\n\nArguments:\nNone\n\nResult:\nNothing\n",
+		"signmessagebip322":            "signmessagebip322 \"address\" \"message\"\n\nSigns a message using the private key of a P2WPKH or P2TR address with the BIP0322 simple signature format.\nThe signature is the witness spending the address output of a virtual transaction committing to the message, and can be checked with verifymessage.\n\nArguments:\n1. address (string, required) Address of the private key used to sign the message\n2. message (string, required) Message to sign\n\nResult:\n\"value\" (string) The base64-encoded serialized witness\n",
+		"pinaddresspool":               "pinaddresspool \"name\" \"descriptor\" (window=20 [\"account\",...])\n\nAdds a receive-only pool of addresses derived from the first branch of an external descriptor, such as the cold wallet of a custodian, or updates the window and accounts of the pool with the same descriptor.\nThe wallet holds no keys of the pool and does not watch its addresses.\nOutputs may be swept to the pool with sweepprivkey, and transactions of the pinned accounts may only pay to addresses of their pools.\n\nArguments:\n1. name       (string, required)              The name of the pool\n2. descriptor (string, required)              The output descriptor of the pool addresses\n3. window     (numeric, optional, default=20) The number of addresses past the next pool address which are also accepted as destinations, at most 1000\n4. accounts   (array of string, optional)     The accounts pinned to the pool\n\nResult:\nNothing\n",
+		"unpinaddresspool":             "unpinaddresspool \"name\"\n\nRemoves an address pool, unpinning its accounts.\n\nArguments:\n1. name (string, required) The name of the pool\n\nResult:\nNothing\n",
+		"listaddresspools":             "listaddresspools\n\nReturns the address pools.\n\nArguments:\nNone\n\nResult:\n[{\n \"name\": \"value\",           (string)          The name of the pool\n \"descriptor\": \"value\",     (string)          The output descriptor of the pool addresses\n \"nextindex\": n,            (numeric)         The index of the next pool address\n \"window\": n,               (numeric)         The number of addresses past the next pool address which are also accepted as destinations\n \"accounts\": [\"value\",...], (array of string) The accounts pinned to the pool\n},...]\n",
+		"getpooladdress":               "getpooladdress \"name\"\n\nReturns the next address of an address pool, which is not returned again.\n\nArguments:\n1. name (string, required) The name of the pool\n\nResult:\n\"value\" (string) The pool address\n",
+		"consolidatechange":            "consolidatechange (feerate maxinputs=100 dryrun=false)\n\nConsolidates the legacy and segwit v0 change outputs with at least 6 confirmations of every account into taproot outputs of the account, so that they are cheaper to spend later.\nEach transaction spends the change outputs of one token of one account, and the amount of each output pays its fee.\nThe wallet must be unlocked unless dryrun is set.\n\nArguments:\n1. feerate   (numeric, optional)                The fee rate in BTC/kB (default is the rate estimated by btcd for confirmation within a day)\n2. maxinputs (numeric, optional, default=100)   The maximum number of change outputs spent by one transaction\n3. dryrun    (boolean, optional, default=false) Only report the consolidations without sending them\n\nResult:\n{\n \"time\": n,                (numeric)         The time of the consolidation as a unix timestamp\n \"feerate\": n.nnn,         (numeric)         The fee rate in BTC/kB\n \"dryrun\": true|false,     (boolean)         Whether the consolidations were only reported\n \"consolidations\": [{      (array of object) The consolidation transactions\n  \"account\": \"value\",      (string)          The account of the change outputs\n  \"token\": \"value\",        (string)          The token of the change outputs\n  \"inputs\": [\"value\",...], (array of string) The consolidated outpoints as txid:vout\n  \"amount\": n.nnn,         (numeric)         The total amount of the change outputs\n  \"fee\": n.nnn,            (numeric)         The fee of the transaction\n  \"address\": \"value\",      (string)          The taproot address paid by the transaction (omitted for dry runs and failures)\n  \"txid\": \"value\",         (string)          The hash of the transaction (omitted for dry runs and failures)\n  \"error\": \"value\",        (string)          The error which prevented the consolidation, if any\n },...],                                     \n}                          \n",
+		"getconsolidationreport":       "getconsolidationreport\n\nReturns the report of the last consolidation of change outputs by consolidatechange or the --consolidatefeerate policy, or of fragmented accounts by the --fragmentationfeerate policy, or null if outputs were not consolidated since the wallet started.\n\nArguments:\nNone\n\nResult:\n{\n \"time\": n,                (numeric)         The time of the consolidation as a unix timestamp\n \"feerate\": n.nnn,         (numeric)         The fee rate in BTC/kB\n \"dryrun\": true|false,     (boolean)         Whether the consolidations were only reported\n \"consolidations\": [{      (array of object) The consolidation transactions\n  \"account\": \"value\",      (string)          The account of the change outputs\n  \"token\": \"value\",        (string)          The token of the change outputs\n  \"inputs\": [\"value\",...], (array of string) The consolidated outpoints as txid:vout\n  \"amount\": n.nnn,         (numeric)         The total amount of the change outputs\n  \"fee\": n.nnn,            (numeric)         The fee of the transaction\n  \"address\": \"value\",      (string)          The taproot address paid by the transaction (omitted for dry runs and failures)\n  \"txid\": \"value\",         (string)          The hash of the transaction (omitted for dry runs and failures)\n  \"error\": \"value\",        (string)          The error which prevented the consolidation, if any\n },...],                                     \n}                          \n",
+		"sweepall":                     "sweepall \"fromaccount\" {\"address\":percent,...} ([\"address\",...] \"token\" minconf=1 feerate)\n\nSends every spendable output of a token of an account, or only those paying to some of its addresses, to one or more destinations split by percentage, without change.\nThe fee is deducted before the swept amount is split, and the satoshis left over by rounding go to the destinations with the largest remainders, so the outputs add up to the swept amount less the fee exactly.\n\nArguments:\n1. fromaccount  (string, required) The account to sweep\n2. destinations (object, required) Pairs of destination addresses and their percentage of the swept amount\n{\n \"Destination address\": Percentage of the swept amount sent to the address, (object) JSON object using destination addresses as keys and percentages with at most two decimals, adding up to 100, as values\n ...\n}\n3. addresses (array of string, optional)    Addresses of the account whose outputs are swept (default is every address of the account)\n4. token     (string, optional)             Token of the swept outputs (default=\"STB\")\n5. minconf   (numeric, optional, default=1) Minimum number of block confirmations of the swept outputs\n6. feerate   (numeric, optional)            Fee rate of the transaction in satoshis per virtual byte (default=the wallet's fee rate)\n\nResult:\n{\n \"txid\": \"value\",     (string)          The hash of the sweep transaction\n \"outputs\": [{        (array of object) The outputs of the sweep transaction\n  \"address\": \"value\", (string)          The destination address\n  \"amount\": n.nnn,    (numeric)         The amount sent to the address valued in bitcoin\n },...],                                \n \"fee\": n.nnn,        (numeric)         The fee paid by the sweep transaction valued in bitcoin\n \"inputs\": n,         (numeric)         The number of swept outputs\n}                     \n",
+		"sendwithinputs":               "sendwithinputs \"fromaccount\" {\"address\":amount,...} [{\"txid\":\"value\",\"vout\":n},...] (\"token\" minconf=1 replaceable conftarget feerate overridefeecap)\n\nAuthors, signs, and sends a transaction that outputs to many payment addresses, spending every one of the chosen outputs of an account and no other output.\nThe chosen outputs must be unlocked and have at least minconf confirmations, and leftover inputs not sent to the payment addresses or paid as fee are sent back to a change address.\n\nArguments:\n1. fromaccount (string, required) Account of the spent outputs\n2. amounts     (object, required) Pairs of payment addresses and the output amount to pay each\n{\n \"Address to pay\": Amount to send to the payment address valued in bitcoin, (object) JSON object using payment addresses as keys and output amounts valued in bitcoin to send to each address\n ...\n}\n3. inputs (array of object, required) The outputs to spend\n[{\n \"txid\": \"value\", (string)  The transaction hash of the referenced output\n \"vout\": n,       (numeric) The output index of the referenced output\n},...]\n4. token          (string, optional)             Token of the outputs (default=\"STB\")\n5. minconf        (numeric, optional, default=1) Minimum number of block confirmations of the spent outputs\n6. replaceable    (boolean, optional)            Whether the transaction signals BIP0125 replaceability (default=true unless the wallet runs with --norbf)\n7. conftarget     (numeric, optional)            Number of blocks within which the fee rate is estimated to get the transaction mined, unless set with settxfee (default=the wallet's conftarget option)\n8. feerate        (numeric, optional)            Fee rate of the transaction in satoshis per virtual byte, which may not be used with conftarget (default=the wallet's fee rate)\n9. overridefeecap (boolean, optional)            Create the transaction even when its fee exceeds the fee cap of the wallet set by --maxtxfee, --maxfeepercent and --maxdailyfee (default=false)\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
+		"cancelbroadcast":              "cancelbroadcast \"txid\"\n\nCancels a transaction held before it is broadcast, removing it and any transaction spending its outputs from the wallet.\nWhen the wallet runs with --broadcasthold, the transactions it sends are held for the hold window before they are broadcast, and can be cancelled until then.\nWebsocket clients subscribed with notifypendingbroadcast are sent a pendingbroadcast notification with the decoded transaction and its annotations for every held transaction.\n\nArguments:\n1. txid (string, required) The hash of the held transaction\n\nResult:\nNothing\n",
+		"listunspentfiltered":          "listunspentfiltered (minconf=1 maxconf=9999999 [\"address\",...] \"token\" minimumamount=0)\n\nReturns a JSON array of objects representing unlocked unspent outputs controlled by wallet keys, like listunspent, excluding the outputs worth less than a minimum amount.\n\nArguments:\n1. minconf       (numeric, optional, default=1)       Minimum number of block confirmations required before a transaction output is considered\n2. maxconf       (numeric, optional, default=9999999) Maximum number of block confirmations required before a transaction output is excluded\n3. addresses     (array of string, optional)          If set, limits the returned details to unspent outputs received by any of these payment addresses\n4. token         (string, optional)                   If set, limits the returned details to unspent outputs of this token\n5. minimumamount (numeric, optional, default=0)       Minimum amount of the returned outputs valued in bitcoin\n\nResult:\n{\n \"txid\": \"value\",         (string)  The transaction hash of the referenced output\n \"vout\": n,               (numeric) The output index of the referenced output\n \"address\": \"value\",      (string)  The payment address that received the output\n \"account\": \"value\",      (string)  The account associated with the receiving payment address\n \"scriptPubKey\": \"value\", (string)  The output script encoded as a hexadecimal string\n \"redeemScript\": \"value\", (string)  Unset\n \"amount\": n.nnn,         (numeric) The amount of the output valued in bitcoin\n \"confirmations\": n,      (numeric) The number of block confirmations of the transaction\n \"spendable\": true|false, (boolean) Whether the output is entirely controlled by wallet keys/scripts (false for partially controlled multisig outputs or outputs to watch-only addresses)\n \"token\": \"value\",        (string)  The token of the output\n}                         \n",
+		"getdiagnostics":               "getdiagnostics\n\nReturns the current diagnostics metrics and the snapshots recorded periodically to the diagnostics ring file, including those of previous runs of the wallet, with the errors logged between them.\nThe method is available without a loaded wallet, unless diagnostics are disabled with --diagnosticsinterval=0.\n\nArguments:\nNone\n\nResult:\n{\n \"current\": {  (object)  The current metrics, and the errors logged since the last recorded snapshot\n  \"time\": n,   (numeric) The Unix time of the snapshot\n  \"metrics\": { (object)  The metrics, such as notification queue depths, request handler counts and store sizes\n   \"The metric name\": The metric value, (object) JSON object using metric names as keys and metric values as values\n   ...\n  }\n  \"errors\": [\"value\",...], (array of string) The errors logged before the snapshot, since the previous one (omitted when there are none)\n },                                          \n \"snapshots\": [{           (array of object) The recorded snapshots, oldest first\n  \"time\": n,               (numeric)         The Unix time of the snapshot\n  \"metrics\": {             (object)          The metrics, such as notification queue depths, request handler counts and store sizes\n   \"The metric name\": The metric value, (object) JSON object using metric names as keys and metric values as values\n   ...\n  }\n  \"errors\": [\"value\",...], (array of string) The errors logged before the snapshot, since the previous one (omitted when there are none)\n },...],                                     \n}                          \n",
+		"setaccountmetadata":           "setaccountmetadata \"account\" \"key\" \"value\"\n\nSets a metadata key of an account, such as a label or a bookkeeping reference, or removes it when the value is empty.\n\nArguments:\n1. account (string, required) The name of the account\n2. key     (string, required) The metadata key\n3. value   (string, required) The value of the key, or an empty string to remove it\n\nResult:\nNothing\n",
+		"getaccountmetadata":           "getaccountmetadata \"account\"\n\nReturns the metadata of an account, the hash committing to it, and the last transaction anchoring the metadata on chain.\n\nArguments:\n1. account (string, required) The name of the account\n\nResult:\n{\n \"metadata\": { (object) The metadata of the account\n  \"The metadata key\": The value of the key, (object) JSON object using metadata keys as keys and their values as values\n  ...\n }\n \"hash\": \"value\",  (string)  The double SHA256 hash of the account number and of the keys and values sorted by key\n \"lastanchor\": {   (object)  The last anchor of the metadata (omitted if it was never anchored)\n  \"hash\": \"value\", (string)  The metadata hash committed to by the anchor\n  \"txid\": \"value\", (string)  The hash of the anchor transaction\n  \"time\": n,       (numeric) The Unix time of the anchor\n },                          \n}                  \n",
+		"anchoraccountmetadata":        "anchoraccountmetadata \"account\"\n\nSends a transaction from an account with an OP_RETURN output committing to the hash of its metadata, prefixed with \"BWMA\", as a timestamped proof of the metadata once mined.\nThe fee is paid from the account.  With --metadataanchorinterval, the metadata of the accounts is also anchored periodically when it changed.\n\nArguments:\n1. account (string, required) The name of the account\n\nResult:\n\"value\" (string) The hash of the anchor transaction\n",
+		"bumpfee":                      "bumpfee \"txid\" (feerate)\n\nReplaces an unconfirmed transaction sent by the wallet which signals BIP0125 replaceability with the same transaction paying a higher fee, deducted from its change output.\nThe replaced transaction is removed from the wallet once the replacement is broadcast, and gettransaction reports the replacements of a transaction.\n\nArguments:\n1. txid    (string, required)  The hash of the transaction to replace\n2. feerate (numeric, optional) The fee rate of the replacement in satoshis per virtual byte (default: the larger of the wallet's fee rate and the fee rate of the transaction increased by 1 satoshi per virtual byte)\n\nResult:\n{\n \"txid\": \"value\",  (string)  The hash of the replacement transaction\n \"origfee\": n.nnn, (numeric) The fee of the replaced transaction, valued in bitcoin\n \"fee\": n.nnn,     (numeric) The fee of the replacement transaction, valued in bitcoin\n}                  \n",
+		"bumpfeecpfp":                  "bumpfeecpfp \"txid\" (feerate)\n\nRaises the effective fee rate of an unconfirmed transaction paying the wallet, such as an incoming transaction or a send which does not signal BIP0125 replaceability, by sending one of its outputs back to the wallet with a fee bringing the fee rate of both transactions up to the requested rate (child pays for parent).\nThe change output of the transaction is spent when there is one.  The fee of a transaction spending outputs of other wallets is unknown, so the child pays the fee of both transactions.\n\nArguments:\n1. txid    (string, required)  The hash of the unconfirmed transaction\n2. feerate (numeric, optional) The fee rate of both transactions in satoshis per virtual byte (default=the wallet's fee rate)\n\nResult:\n{\n \"txid\": \"value\",    (string)  The hash of the child transaction\n \"fee\": n.nnn,       (numeric) The fee of the child transaction, valued in bitcoin\n \"parentfee\": n.nnn, (numeric) The fee of the unconfirmed transaction, valued in bitcoin, or 0 if it spends outputs of other wallets\n}                    \n",
+		"getkeyusage":                  "getkeyusage (\"account\")\n\nReturns how often the keys of the accounts and of their addresses signed since the wallet started, with the signatures of the current hour and the baseline of signatures per hour, averaged over about a day.\nWhen the signatures of an address or account in an hour exceed --keyusagealertfactor times its baseline, an alert is logged and websocket clients subscribed with notifykeyusagealerts are sent a keyusagealert notification.\n\nArguments:\n1. account (string, optional) Only return the signatures of this account\n\nResult:\n[{\n \"account\": \"value\",  (string)          The name of the account\n \"signatures\": n,     (numeric)         The number of signatures by the keys of the account\n \"currenthour\": n,    (numeric)         The number of signatures in the current hour\n \"baseline\": n.nnn,   (numeric)         The average number of signatures per hour\n \"addresses\": [{      (array of object) The addresses of the account which signed, by decreasing signatures\n  \"address\": \"value\", (string)          The address whose key signed\n  \"signatures\": n,    (numeric)         The number of signatures by the key of the address\n  \"currenthour\": n,   (numeric)         The number of signatures in the current hour\n  \"baseline\": n.nnn,  (numeric)         The average number of signatures per hour\n },...],                                \n},...]\n",
+		"queuepayment":                 "queuepayment \"fromaccount\" \"address\" amount (\"token\")\n\nQueues a payment from an account to be sent with the other queued payments of the account in a single batch transaction, reducing the fees of frequent payouts.\nQueued payments are sent every --batchinterval, or when sendqueuedpayments is called.  The fee of the batch transaction is paid from the account.\n\nArguments:\n1. fromaccount (string, required)  The account the payment is sent from\n2. address     (string, required)  The address to pay\n3. amount      (numeric, required) The amount to pay\n4. token       (string, optional)  The token to pay (default=STB)\n\nResult:\nn.nnn (numeric) The ID of the queued payment\n",
+		"getqueuedpayment":             "getqueuedpayment id\n\nReturns a queued payment, with the hash of its batch transaction once it is sent.\n\nArguments:\n1. id (numeric, required) The ID of the payment\n\nResult:\n{\n \"id\": n,                 (numeric) The ID of the payment\n \"account\": \"value\",      (string)  The account the payment is sent from\n \"address\": \"value\",      (string)  The address paid (omitted for scripts without a single address)\n \"amount\": n.nnn,         (numeric) The amount paid\n \"token\": \"value\",        (string)  The token paid\n \"time\": n,               (numeric) The Unix time the payment was queued\n \"txid\": \"value\",         (string)  The hash of the batch transaction which sent the payment (omitted until it is sent)\n \"cancelled\": true|false, (boolean) Whether the payment was cancelled\n}                         \n",
+		"listqueuedpayments":           "listqueuedpayments\n\nReturns the queued payments which are neither sent nor cancelled, ordered by ID.\n\nArguments:\nNone\n\nResult:\n[{\n \"id\": n,                 (numeric) The ID of the payment\n \"account\": \"value\",      (string)  The account the payment is sent from\n \"address\": \"value\",      (string)  The address paid (omitted for scripts without a single address)\n \"amount\": n.nnn,         (numeric) The amount paid\n \"token\": \"value\",        (string)  The token paid\n \"time\": n,               (numeric) The Unix time the payment was queued\n \"txid\": \"value\",         (string)  The hash of the batch transaction which sent the payment (omitted until it is sent)\n \"cancelled\": true|false, (boolean) Whether the payment was cancelled\n},...]\n",
+		"cancelqueuedpayment":          "cancelqueuedpayment id\n\nCancels a queued payment which was not sent yet.\n\nArguments:\n1. id (numeric, required) The ID of the payment\n\nResult:\nNothing\n",
+		"sendqueuedpayments":           "sendqueuedpayments\n\nSends the queued payments now, in one batch transaction per account and token paying the wallet's fee rate.\nPayments which cannot be sent, such as those of an account without enough confirmed funds, stay queued; an error is only returned when no batch transaction could be sent.\n\nArguments:\nNone\n\nResult:\n[{\n \"account\": \"value\",  (string)           The account the payments are sent from\n \"token\": \"value\",    (string)           The token paid\n \"txid\": \"value\",     (string)           The hash of the batch transaction\n \"payments\": [n,...], (array of numeric) The IDs of the payments sent by the transaction\n},...]\n",
+		"settransactionlabel":          "settransactionlabel \"txid\" \"label\"\n\nSets the label of a wallet transaction, such as a bookkeeping category, used to group the transactions of getspendingreport.\n\nArguments:\n1. txid  (string, required) The hash of the wallet transaction\n2. label (string, required) The label of the transaction, or an empty string to remove its label\n\nResult:\nNothing\n",
+		"getspendingreport":            "getspendingreport starttime endtime (\"account\" \"token\")\n\nReturns the funds received from and sent to other wallets by the transactions mined over a period, with their counts and fees, summed by transaction label.\nTransactions without label are summed in the category with an empty label.  Transactions are dated by the time of their block, and fees are only known for transactions spending wallet outputs only.\n\nArguments:\n1. starttime (numeric, required) The Unix time the period starts at\n2. endtime   (numeric, required) The Unix time the period ends before\n3. account   (string, optional)  Only sum the transactions debiting or crediting this account (default=\"*\" for all accounts)\n4. token     (string, optional)  The token of the amounts summed (default=STB)\n\nResult:\n{\n \"starttime\": n,     (numeric)         The Unix time the period starts at\n \"endtime\": n,       (numeric)         The Unix time the period ends before\n \"categories\": [{    (array of object) The sums of the transactions of every label, ordered by label\n  \"label\": \"value\",  (string)          The label of the transactions\n  \"received\": n.nnn, (numeric)         The amount received from other wallets\n  \"receivecount\": n, (numeric)         The number of transactions receiving funds\n  \"sent\": n.nnn,     (numeric)         The amount sent to other wallets, excluding fees\n  \"sendcount\": n,    (numeric)         The number of transactions sending funds\n  \"fees\": n.nnn,     (numeric)         The fees paid by the transactions\n },...],                               \n \"total\": {          (object)          The sums of all transactions\n  \"label\": \"value\",  (string)          The label of the transactions\n  \"received\": n.nnn, (numeric)         The amount received from other wallets\n  \"receivecount\": n, (numeric)         The number of transactions receiving funds\n  \"sent\": n.nnn,     (numeric)         The amount sent to other wallets, excluding fees\n  \"sendcount\": n,    (numeric)         The number of transactions sending funds\n  \"fees\": n.nnn,     (numeric)         The fees paid by the transactions\n },                                    \n}                    \n",
+		"fundrawtransaction":           "fundrawtransaction \"hextx\" ({\"fromaccount\":fromaccount,\"minconf\":minconf,\"changeposition\":changeposition,\"lockunspents\":lockunspents,\"feerate\":feerate,\"satpervbyte\":satpervbyte,\"conftarget\":conftarget,\"subtractfeefromoutputs\":[subtractfeefromoutput,...],\"replaceable\":replaceable,\"coinselection\":coinselection,\"changeaddress\":changeaddress,\"changeaccount\":changeaccount,\"overridefeecap\":overridefeecap})\n\nAdds inputs spending unspent outputs of an account to a raw transaction until they pay for its outputs and fee, and a change output when the leftover value is not dust.\nThe inputs and outputs of the transaction are kept, and its inputs must spend outputs of wallet transactions.  The funded transaction is not signed.\n\nArguments:\n1. hextx   (string, required) The hex-encoded raw transaction to fund\n2. options (object, optional) Funding options\n{\n \"fromaccount\": \"value\",            (string)           Account to pick unspent outputs from (default=\"default\")\n \"minconf\": n,                      (numeric)          Minimum number of block confirmations required before a transaction output is eligible to be spent (default=1)\n \"changePosition\": n,               (numeric)          Index of the change output (default=random)\n \"lockUnspents\": true|false,        (boolean)          Lock the added inputs so that other transactions do not spend them (default=false)\n \"feeRate\": n.nnn,                  (numeric)          Fee rate of the transaction in bitcoin per kilobyte, which may not be used with fee_rate nor conf_target (default=the wallet's fee rate)\n \"fee_rate\": n.nnn,                 (numeric)          Fee rate of the transaction in satoshis per virtual byte, which may not be used with feeRate nor conf_target (default=the wallet's fee rate)\n \"conf_target\": n,                  (numeric)          Number of blocks within which the fee rate is estimated to get the transaction mined, unless set with settxfee (default=the wallet's conftarget option)\n \"subtractFeeFromOutputs\": [n,...], (array of numeric) Indexes of the outputs paying the fee in proportion to their amounts, instead of the inputs\n \"replaceable\": true|false,         (boolean)          Whether the added inputs signal BIP0125 replaceability (default=true unless the wallet runs with --norbf)\n \"coinselection\": \"value\",          (string)           Strategy picking the outputs to spend, one of oldestfirst, largestfirst or branchandbound (default=the wallet's coinselection option)\n \"changeAddress\": \"value\",          (string)           P2WPKH address receiving the change, which may not be used with changeaccount (default=an internal address following the change policy)\n \"changeaccount\": \"value\",          (string)           Account whose internal address receives the change, which may not be used with changeAddress (default=fromaccount)\n \"overridefeecap\": true|false,      (boolean)          Create the transaction even when its fee exceeds the fee cap of the wallet set by --maxtxfee, --maxfeepercent and --maxdailyfee (default=false)\n}                                   \n\nResult:\n{\n \"hex\": \"value\", (string)  The funded transaction encoded as a hexadecimal string\n \"fee\": n.nnn,   (numeric) The fee paid by the transaction valued in bitcoin\n \"changepos\": n, (numeric) The index of the change output, or -1 if no change output was added\n}                \n",
+		"signrawtransactionwithwallet": "signrawtransactionwithwallet \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] sighashtype=\"ALL\")\n\nSigns transaction inputs using private keys from this wallet.\nThe previous outputs of the inputs are taken from the inputs argument, the wallet transactions, or the chain server when it is connected.\nThe valid sighashtype options are ALL, NONE, SINGLE, ALL|ANYONECANPAY, NONE|ANYONECANPAY, and SINGLE|ANYONECANPAY.\n\nArguments:\n1. rawtx       (string, required)                Unsigned or partially unsigned transaction to sign encoded as a hexadecimal string\n2. inputs      (array of object, optional)       Previous outputs spent by the transaction that this wallet may not be tracking\n3. sighashtype (string, optional, default=\"ALL\") Sighash type\n\nResult:\n{\n \"hex\": \"value\",         (string)          The resulting transaction encoded as a hexadecimal string\n \"complete\": true|false, (boolean)         Whether all input signatures have been created\n \"errors\": [{            (array of object) Script verification errors (if exists)\n  \"txid\": \"value\",       (string)          The transaction hash of the referenced previous output\n  \"vout\": n,             (numeric)         The output index of the referenced previous output\n  \"scriptSig\": \"value\",  (string)          The hex-encoded signature script\n  \"sequence\": n,         (numeric)         Script sequence number\n  \"error\": \"value\",      (string)          Verification or signing error related to the input\n },...],                                   \n}                        \n",
+		"setchangepolicy":              "setchangepolicy \"policy\"\n\nSets the policy choosing the internal addresses receiving the change of the transactions created by the wallet, until the wallet is restarted.\nThe new policy derives a new address for every change output, and the reuse policy pays the change of an account to its last internal address.\n\nArguments:\n1. policy (string, required) The change policy, new or reuse\n\nResult:\nNothing\n",
+		"consolidateutxos":             "consolidateutxos threshold (feerate fromaccount=\"default\" \"token\" maxinputs=100 minconf=1)\n\nSpends the outputs of a token of an account worth less than a threshold, the smallest first, to a single new change address of the account, so that later transactions spend fewer inputs.\nAt least two outputs must be below the threshold. The outputs of the imported account are consolidated to an address of the default account.\n\nArguments:\n1. threshold   (numeric, required)                   The amount in bitcoin below which outputs are consolidated\n2. feerate     (numeric, optional)                   Fee rate of the transaction in satoshis per virtual byte (default=the wallet's fee rate)\n3. fromaccount (string, optional, default=\"default\") The account whose outputs are consolidated\n4. token       (string, optional)                    Token of the consolidated outputs (default=\"STB\")\n5. maxinputs   (numeric, optional, default=100)      The maximum number of outputs spent by the transaction\n6. minconf     (numeric, optional, default=1)        Minimum number of block confirmations of the consolidated outputs\n\nResult:\n{\n \"txid\": \"value\",    (string)  The hash of the consolidation transaction\n \"address\": \"value\", (string)  The change address receiving the consolidated amount\n \"amount\": n.nnn,    (numeric) The amount sent to the address valued in bitcoin\n \"fee\": n.nnn,       (numeric) The fee paid by the consolidation transaction valued in bitcoin\n \"inputs\": n,        (numeric) The number of consolidated outputs\n}                    \n",
+		"getrecoverystatus":            "getrecoverystatus\n\nReturns the step and progress of the recovery wizard, which restores a wallet from its seed in the steps seed, derivations, scanning and review, before the recovery is done.\nThe wizard is available before a wallet is loaded. The scan resumes when the recovered wallet is reopened until the recovery is finalized.\n\nArguments:\nNone\n\nResult:\n{\n \"step\": \"value\",         (string)          The step of the recovery: seed, derivations, scanning, review or done\n \"birthday\": n,           (numeric)         The Unix time before which blocks are not scanned (omitted at the seed step)\n \"scopes\": [\"value\",...], (array of string) The scanned key scopes (omitted at the seed step)\n \"recoverywindow\": n,     (numeric)         The number of unused addresses scanned past the last used address of each branch (omitted at the seed step)\n \"syncedheight\": n,       (numeric)         The height of the block the recovered wallet is synced to (omitted before the scan)\n \"bestheight\": n,         (numeric)         The height of the best block of the chain server (omitted before the scan)\n \"found\": [{              (array of object) The addresses and funds found in each scanned key scope (omitted before the scan)\n  \"scope\": \"value\",       (string)          The derivation path of the key scope\n  \"addresses\": n,         (numeric)         The number of addresses of the default account of the scope up to its last used address\n  \"balances\": {           (object)          The unspent outputs paying to the addresses of the scope by token\n   \"The token\": The amount of the token valued in bitcoin, (object) JSON object using tokens as keys and amounts as values\n   ...\n  }\n },...],  \n}        \n",
+		"recoveryenterseed":            "recoveryenterseed \"seed\" (birthday)\n\nEnters the seed of the recovered wallet at the seed or derivations step, moving the recovery to the derivations step.\nThe seed is only kept in memory until the scan starts.\n\nArguments:\n1. seed     (string, required)  The hex encoded seed of the recovered wallet\n2. birthday (numeric, optional) The Unix time of the creation of the recovered wallet, before which blocks are not scanned (default is the genesis block)\n\nResult:\n{\n \"step\": \"value\",         (string)          The step of the recovery: seed, derivations, scanning, review or done\n \"birthday\": n,           (numeric)         The Unix time before which blocks are not scanned (omitted at the seed step)\n \"scopes\": [\"value\",...], (array of string) The scanned key scopes (omitted at the seed step)\n \"recoverywindow\": n,     (numeric)         The number of unused addresses scanned past the last used address of each branch (omitted at the seed step)\n \"syncedheight\": n,       (numeric)         The height of the block the recovered wallet is synced to (omitted before the scan)\n \"bestheight\": n,         (numeric)         The height of the best block of the chain server (omitted before the scan)\n \"found\": [{              (array of object) The addresses and funds found in each scanned key scope (omitted before the scan)\n  \"scope\": \"value\",       (string)          The derivation path of the key scope\n  \"addresses\": n,         (numeric)         The number of addresses of the default account of the scope up to its last used address\n  \"balances\": {           (object)          The unspent outputs paying to the addresses of the scope by token\n   \"The token\": The amount of the token valued in bitcoin, (object) JSON object using tokens as keys and amounts as values\n   ...\n  }\n },...],  \n}        \n",
+		"recoverychoosederivations":    "recoverychoosederivations [purpos,...] (recoverywindow=250)\n\nChooses the key scopes scanned for used addresses at the derivations step. Every default key scope is scanned until derivations are chosen.\n\nArguments:\n1. purposes       (array of numeric, required)     The BIP0044 purposes of the scanned key scopes, among 44, 49, 84 and 86\n2. recoverywindow (numeric, optional, default=250) The number of unused addresses scanned past the last used address of each branch\n\nResult:\n{\n \"step\": \"value\",         (string)          The step of the recovery: seed, derivations, scanning, review or done\n \"birthday\": n,           (numeric)         The Unix time before which blocks are not scanned (omitted at the seed step)\n \"scopes\": [\"value\",...], (array of string) The scanned key scopes (omitted at the seed step)\n \"recoverywindow\": n,     (numeric)         The number of unused addresses scanned past the last used address of each branch (omitted at the seed step)\n \"syncedheight\": n,       (numeric)         The height of the block the recovered wallet is synced to (omitted before the scan)\n \"bestheight\": n,         (numeric)         The height of the best block of the chain server (omitted before the scan)\n \"found\": [{              (array of object) The addresses and funds found in each scanned key scope (omitted before the scan)\n  \"scope\": \"value\",       (string)          The derivation path of the key scope\n  \"addresses\": n,         (numeric)         The number of addresses of the default account of the scope up to its last used address\n  \"balances\": {           (object)          The unspent outputs paying to the addresses of the scope by token\n   \"The token\": The amount of the token valued in bitcoin, (object) JSON object using tokens as keys and amounts as values\n   ...\n  }\n },...],  \n}        \n",
+		"recoverystartscan":            "recoverystartscan \"passphrase\" (\"publicpassphrase\")\n\nCreates the recovered wallet at the derivations step and starts the scan of the chosen derivations once the wallet is synced with the chain server, moving the recovery to the scanning step.\nThe recovery moves to the review step once the wallet is synced.\n\nArguments:\n1. passphrase       (string, required) The private passphrase of the recovered wallet\n2. publicpassphrase (string, optional) The public passphrase of the recovered wallet (default is the insecure public passphrase)\n\nResult:\n{\n \"step\": \"value\",         (string)          The step of the recovery: seed, derivations, scanning, review or done\n \"birthday\": n,           (numeric)         The Unix time before which blocks are not scanned (omitted at the seed step)\n \"scopes\": [\"value\",...], (array of string) The scanned key scopes (omitted at the seed step)\n \"recoverywindow\": n,     (numeric)         The number of unused addresses scanned past the last used address of each branch (omitted at the seed step)\n \"syncedheight\": n,       (numeric)         The height of the block the recovered wallet is synced to (omitted before the scan)\n \"bestheight\": n,         (numeric)         The height of the best block of the chain server (omitted before the scan)\n \"found\": [{              (array of object) The addresses and funds found in each scanned key scope (omitted before the scan)\n  \"scope\": \"value\",       (string)          The derivation path of the key scope\n  \"addresses\": n,         (numeric)         The number of addresses of the default account of the scope up to its last used address\n  \"balances\": {           (object)          The unspent outputs paying to the addresses of the scope by token\n   \"The token\": The amount of the token valued in bitcoin, (object) JSON object using tokens as keys and amounts as values\n   ...\n  }\n },...],  \n}        \n",
+		"recoveryfinalize":             "recoveryfinalize\n\nEnds the review of the funds found by the recovery, after which the wallet is done recovering.\n\nArguments:\nNone\n\nResult:\n{\n \"step\": \"value\",         (string)          The step of the recovery: seed, derivations, scanning, review or done\n \"birthday\": n,           (numeric)         The Unix time before which blocks are not scanned (omitted at the seed step)\n \"scopes\": [\"value\",...], (array of string) The scanned key scopes (omitted at the seed step)\n \"recoverywindow\": n,     (numeric)         The number of unused addresses scanned past the last used address of each branch (omitted at the seed step)\n \"syncedheight\": n,       (numeric)         The height of the block the recovered wallet is synced to (omitted before the scan)\n \"bestheight\": n,         (numeric)         The height of the best block of the chain server (omitted before the scan)\n \"found\": [{              (array of object) The addresses and funds found in each scanned key scope (omitted before the scan)\n  \"scope\": \"value\",       (string)          The derivation path of the key scope\n  \"addresses\": n,         (numeric)         The number of addresses of the default account of the scope up to its last used address\n  \"balances\": {           (object)          The unspent outputs paying to the addresses of the scope by token\n   \"The token\": The amount of the token valued in bitcoin, (object) JSON object using tokens as keys and amounts as values\n   ...\n  }\n },...],  \n}        \n",
+		"recoveryabort":                "recoveryabort\n\nForgets the seed and choices of a recovery whose scan has not started, returning to the seed step.\n\nArguments:\nNone\n\nResult:\n{\n \"step\": \"value\",         (string)          The step of the recovery: seed, derivations, scanning, review or done\n \"birthday\": n,           (numeric)         The Unix time before which blocks are not scanned (omitted at the seed step)\n \"scopes\": [\"value\",...], (array of string) The scanned key scopes (omitted at the seed step)\n \"recoverywindow\": n,     (numeric)         The number of unused addresses scanned past the last used address of each branch (omitted at the seed step)\n \"syncedheight\": n,       (numeric)         The height of the block the recovered wallet is synced to (omitted before the scan)\n \"bestheight\": n,         (numeric)         The height of the best block of the chain server (omitted before the scan)\n \"found\": [{              (array of object) The addresses and funds found in each scanned key scope (omitted before the scan)\n  \"scope\": \"value\",       (string)          The derivation path of the key scope\n  \"addresses\": n,         (numeric)         The number of addresses of the default account of the scope up to its last used address\n  \"balances\": {           (object)          The unspent outputs paying to the addresses of the scope by token\n   \"The token\": The amount of the token valued in bitcoin, (object) JSON object using tokens as keys and amounts as values\n   ...\n  }\n },...],  \n}        \n",
+		"listquarantined":              "listquarantined\n\nReturns the unspent outputs quarantined as dust, the oldest first.\nOutputs worth at most the --dustthreshold amount received by transactions not spending wallet outputs, as sent to track the addresses of a wallet, are quarantined and not spent by coin selection unless --spendquarantined is set.\n\nArguments:\nNone\n\nResult:\n[{\n \"txid\": \"value\",         (string)  The hash of the transaction of the output\n \"vout\": n,               (numeric) The output index\n \"account\": \"value\",      (string)  The account of the address paid by the output\n \"address\": \"value\",      (string)  The address paid by the output (omitted for non-standard scripts)\n \"amount\": n.nnn,         (numeric) The amount of the output valued in bitcoin\n \"token\": \"value\",        (string)  The token of the output\n \"confirmed\": true|false, (boolean) Whether the transaction of the output is mined\n \"quarantined\": n,        (numeric) The Unix time the output was quarantined\n},...]\n",
+		"spendquarantined":             "spendquarantined [{\"txid\":\"value\",\"vout\":n},...] (\"address\" feerate)\n\nSpends quarantined outputs of the same token to an address, or provably burns them when no address is given.\nBurning transactions pay the whole value of the outputs as fee to a single zero-value OP_RETURN output, which must cover the fee at the fee rate.\n\nArguments:\n1. outputs (array of object, required) The quarantined outputs to spend\n[{\n \"txid\": \"value\", (string)  The transaction hash of the referenced output\n \"vout\": n,       (numeric) The output index of the referenced output\n},...]\n2. address (string, optional)  The address paid the value of the outputs less the fee (default=burn the outputs)\n3. feerate (numeric, optional) Fee rate of the transaction in satoshis per virtual byte (default=the wallet's fee rate)\n\nResult:\n{\n \"txid\": \"value\",      (string)  The hash of the transaction\n \"fee\": n.nnn,         (numeric) The fee paid by the transaction valued in bitcoin\n \"burned\": true|false, (boolean) Whether the outputs were burned\n}                      \n",
+		"getconfighash":                "getconfighash (verbose=false)\n\nReturns the SHA-256 hash of the canonical serialization of the effective configuration, including the change policy and fee rate of the loaded wallet set over RPC, so that configuration drift across wallet daemons can be detected by comparing hashes.\nSecret options only record whether they are set, and options requesting one-time actions such as --create are left out.\nThe method is available without a loaded wallet, and changes of the hash are logged.\n\nArguments:\n1. verbose (boolean, optional, default=false) Also return the canonical settings the hash is computed from\n\nResult:\n{\n \"hash\": \"value\",           (string)          The hash of the configuration in hex\n \"settings\": [\"value\",...], (array of string) The settings as name=\"value\" lines ordered by name, options specified multiple times having a line per value (only when verbose)\n}                           \n",
+		"getdustpolicy":                "getdustpolicy\n\nReturns the dust policy of the wallet: the dust limits below which outputs are not created, how change below the limit is handled, and the quarantine of received dust.\n\nArguments:\nNone\n\nResult:\n{\n \"dustrelayfee\": n.nnn,          (numeric) The relay fee rate in BTC/kB the dust limits are derived from, 0 when outputs are not checked\n \"rejectdustchange\": true|false, (boolean) Whether transactions whose change is below the dust limit are refused, rather than adding the change to the fee\n \"dustlimits\": {                 (object)  The dust limits by address type\n  \"The address type, one of p2pkh, p2sh, p2wpkh, p2wsh or p2tr\": The amount below which outputs paying the address type are dust valued in bitcoin, (object) JSON object using address types as keys and dust limits as values\n  ...\n }\n \"quarantinethreshold\": n.nnn,   (numeric) The amount at or below which received outputs are quarantined valued in bitcoin, 0 when the quarantine is disabled\n \"spendquarantined\": true|false, (boolean) Whether coin selection spends quarantined outputs\n}                                \n",
+		"freezeaccount":                "freezeaccount \"account\" (\"reason\")\n\nImmediately blocks the spends of the outputs of an account, as an emergency brake during a suspected compromise.\nTransactions spending outputs of the account are neither created, signed nor broadcast, including transactions held for broadcast, while the account still receives outputs and is queried.\nThe account number is frozen in every key scope, and the freeze persists across restarts until it is lifted with unfreezeaccount.\n\nArguments:\n1. account (string, required) The name of the account to freeze\n2. reason  (string, optional) The reason of the freeze, recorded in the audit log (default=\"\")\n\nResult:\nNothing\n",
+		"unfreezeaccount":              "unfreezeaccount \"account\" \"passphrase\"\n\nLifts the freeze of an account set with freezeaccount.\nLifting a freeze requires the private passphrase of the wallet, and the one-time password when enrolled, so that clients able to freeze accounts can't lift freezes.\n\nArguments:\n1. account    (string, required) The name of the frozen account\n2. passphrase (string, required) The private passphrase of the wallet\n\nResult:\nNothing\n",
+		"listfrozenaccounts":           "listfrozenaccounts\n\nReturns the frozen accounts ordered by account number.\n\nArguments:\nNone\n\nResult:\n[{\n \"account\": \"value\", (string)  The name of the account\n \"frozen\": n,        (numeric) The Unix time the account was frozen\n \"reason\": \"value\",  (string)  The reason of the freeze (omitted when none was given)\n},...]\n",
+		"getderivationproof":           "getderivationproof \"address\"\n\nReturns a proof that an address of the wallet is derived from the extended public key of its account, so that auditors and counterparties can verify that the address belongs to the account without trusting the wallet.\nEach step is a BIP0032 public child key derivation: the tweak is the left half of HMAC-SHA512(chaincode, parentkey || index as 4 big endian bytes), the child key is the parent key plus the tweak times the generator point, and the right half of the HMAC is the child chain code.\nThe first step derives the branch key from the account key, and the second the address key, which encodes the address by its type.\nImported addresses have no proof.\n\nArguments:\n1. address (string, required) The address to prove\n\nResult:\n{\n \"address\": \"value\",         (string)          The proven address\n \"addresstype\": \"value\",     (string)          The type of the address: p2pkh, p2sh-p2wpkh, p2wpkh or p2tr\n \"accountxpub\": \"value\",     (string)          The extended public key of the account\n \"accountpath\": \"value\",     (string)          The derivation path of the account key from the master key\n \"path\": \"value\",            (string)          The derivation path of the address key from the master key\n \"steps\": [{                 (array of object) The derivations of the branch key from the account key and of the address key from the branch key\n  \"index\": n,                (numeric)         The index of the child key\n  \"parentkey\": \"value\",      (string)          The hex-encoded compressed parent public key\n  \"chaincode\": \"value\",      (string)          The hex-encoded chain code of the parent key\n  \"tweak\": \"value\",          (string)          The hex-encoded left half of the HMAC added to the parent key\n  \"childkey\": \"value\",       (string)          The hex-encoded compressed child public key\n  \"childchaincode\": \"value\", (string)          The hex-encoded chain code of the child key\n },...],                                       \n \"outputkey\": \"value\",       (string)          The hex-encoded x-only taproot output key committing to the address key (only for p2tr addresses)\n}                            \n",
+		"preparetransaction":           "preparetransaction {\"address\":amount,...} (\"fromaccount\" minconf \"token\" \"coinselection\" conftarget feerate [\"subtractfeefrom\",...] \"changeaddress\")\n\nPerforms the coin selection and fee calculation of a transaction that outputs to many payment addresses, and returns its inputs, outputs, fee and virtual size without signing nor sending it, so that frontends can show a confirmation screen.\nThe chosen outputs are not locked, and the transaction sent afterwards may spend other outputs and pay another fee when the wallet or the fee estimate changed in between.\nThe change output has no address unless changeaddress is given, since the change address is only derived when the transaction is sent.\n\nArguments:\n1. amounts (object, required) Pairs of payment addresses and the output amount to pay each\n{\n \"Address to pay\": Amount to send to the payment address valued in bitcoin, (object) JSON object using payment addresses as keys and output amounts valued in bitcoin to send to each address\n ...\n}\n2. fromaccount     (string, optional)          Account to pick unspent outputs from (default=\"default\")\n3. minconf         (numeric, optional)         Minimum number of block confirmations required before a transaction output is eligible to be spent (default=1)\n4. token           (string, optional)          Token of the outputs (default=\"STB\")\n5. coinselection   (string, optional)          Strategy picking the outputs to spend, one of oldestfirst, largestfirst or branchandbound (default=the wallet's coinselection option)\n6. conftarget      (numeric, optional)         Number of blocks within which the fee rate is estimated to get the transaction mined, unless set with settxfee (default=the wallet's conftarget option)\n7. feerate         (numeric, optional)         Fee rate of the transaction in satoshis per virtual byte, which may not be used with conftarget (default=the wallet's fee rate)\n8. subtractfeefrom (array of string, optional) Payment addresses paying the fee in proportion to their amounts, instead of the inputs\n9. changeaddress   (string, optional)          P2WPKH address receiving the change (default=an internal address following the change policy)\n\nResult:\n{\n \"inputs\": [{           (array of object) The outputs spent by the transaction\n  \"txid\": \"value\",      (string)          The hash of the transaction of the spent output\n  \"vout\": n,            (numeric)         The index of the spent output\n  \"address\": \"value\",   (string)          The address of the spent output\n  \"amount\": n.nnn,      (numeric)         The amount of the spent output valued in bitcoin\n },...],                                  \n \"outputs\": [{          (array of object) The outputs of the transaction\n  \"address\": \"value\",   (string)          The address paid by the output (omitted for the change output until the transaction is sent, unless changeaddress is given)\n  \"amount\": n.nnn,      (numeric)         The amount of the output valued in bitcoin\n  \"change\": true|false, (boolean)         Whether the output returns the change to the wallet\n },...],                                  \n \"fee\": n.nnn,          (numeric)         The fee of the transaction valued in bitcoin\n \"vsize\": n,            (numeric)         The estimated virtual size of the signed transaction in virtual bytes\n}                       \n",
+		"entermaintenance":             "entermaintenance (timeout=30)\n\nPuts the wallet in maintenance mode so that its database file can be backed up or snapshotted without restarting the daemon.\nThe processing of chain notifications is paused and the database is closed with its checksums once the open database transactions are done.\nUntil exitmaintenance is called, the requests accessing the database fail, and the database file must not be modified.\n\nArguments:\n1. timeout (numeric, optional, default=30) Seconds to wait for the open database transactions to be done before giving up (default=30)\n\nResult:\n{\n \"active\": true|false, (boolean) Whether the wallet is in maintenance mode\n \"since\": n,           (numeric) The Unix time maintenance was entered\n \"dbpath\": \"value\",    (string)  The path of the closed wallet database file\n \"height\": n,          (numeric) The height of the block the wallet was synced to when maintenance was entered, from which the catch-up rescan starts\n \"hash\": \"value\",      (string)  The hash of the block the wallet was synced to when maintenance was entered\n}                      \n",
+		"exitmaintenance":              "exitmaintenance\n\nReopens and verifies the wallet database closed by entermaintenance, resumes the processing of chain notifications, and rescans the blocks connected since maintenance was entered.\nThe result describes the ended maintenance.\n\nArguments:\nNone\n\nResult:\n{\n \"active\": true|false, (boolean) Whether the wallet is in maintenance mode\n \"since\": n,           (numeric) The Unix time maintenance was entered\n \"dbpath\": \"value\",    (string)  The path of the closed wallet database file\n \"height\": n,          (numeric) The height of the block the wallet was synced to when maintenance was entered, from which the catch-up rescan starts\n \"hash\": \"value\",      (string)  The hash of the block the wallet was synced to when maintenance was entered\n}                      \n",
+		"getmaintenanceinfo":           "getmaintenanceinfo\n\nReturns whether the wallet is in maintenance mode.\n\nArguments:\nNone\n\nResult:\n{\n \"active\": true|false, (boolean) Whether the wallet is in maintenance mode\n \"since\": n,           (numeric) The Unix time maintenance was entered\n \"dbpath\": \"value\",    (string)  The path of the closed wallet database file\n \"height\": n,          (numeric) The height of the block the wallet was synced to when maintenance was entered, from which the catch-up rescan starts\n \"hash\": \"value\",      (string)  The hash of the block the wallet was synced to when maintenance was entered\n}                      \n",
+		"acceleratetx":                 "acceleratetx \"txid\" ([\"accelerator\",...])\n\nSubmits an unmined wallet transaction to third-party transaction acceleration services, such as the acceleration APIs of mining pools, configured with the accelerator option.\nAccelerators are outside the control of the wallet and of the network: acceleration is best effort, may be charged by the service, and discloses the transaction to it.\nA refusal of a service is reported in its acceleration rather than as an error.\n\nArguments:\n1. txid         (string, required)          The hash of the stuck transaction\n2. accelerators (array of string, optional) The names of the accelerators to submit the transaction to (default=every configured accelerator)\n\nResult:\n{\n \"notice\": \"value\",       (string)          A reminder that accelerators are third-party services\n \"accelerations\": [{      (array of object) The submissions of transactions to accelerators\n  \"txid\": \"value\",        (string)          The hash of the submitted transaction\n  \"accelerator\": \"value\", (string)          The name of the accelerator, the host of its service\n  \"submitted\": n,         (numeric)         The Unix time the transaction was submitted\n  \"accepted\": true|false, (boolean)         Whether the accelerator accepted the transaction\n  \"reference\": \"value\",   (string)          The reference of the acceleration at the accelerator (omitted when none was given)\n  \"message\": \"value\",     (string)          The message of the accelerator, or the reason of its refusal (omitted when none was given)\n  \"mined\": true|false,    (boolean)         Whether the transaction is mined\n },...],                                    \n}                         \n",
+		"listaccelerations":            "listaccelerations (\"txid\")\n\nReturns the submissions of transactions to third-party acceleration services, and whether the transactions are mined.\n\nArguments:\n1. txid (string, optional) The hash of the transaction whose submissions are returned (default=every transaction)\n\nResult:\n{\n \"notice\": \"value\",       (string)          A reminder that accelerators are third-party services\n \"accelerations\": [{      (array of object) The submissions of transactions to accelerators\n  \"txid\": \"value\",        (string)          The hash of the submitted transaction\n  \"accelerator\": \"value\", (string)          The name of the accelerator, the host of its service\n  \"submitted\": n,         (numeric)         The Unix time the transaction was submitted\n  \"accepted\": true|false, (boolean)         Whether the accelerator accepted the transaction\n  \"reference\": \"value\",   (string)          The reference of the acceleration at the accelerator (omitted when none was given)\n  \"message\": \"value\",     (string)          The message of the accelerator, or the reason of its refusal (omitted when none was given)\n  \"mined\": true|false,    (boolean)         Whether the transaction is mined\n },...],                                    \n}                         \n",
+		"evaluatepolicy":               "evaluatepolicy \"address\" amount (\"token\" \"fromaccount\" minconf feerate)\n\nDry runs a send through the spend policies, the address screening provider and the broadcast hold, and reports the outcome of every rule.\nNo transaction is created, signed or sent, and transaction hooks are not run, but the destination address is sent to the screening provider.\n\nArguments:\n1. address     (string, required)  The address to send to\n2. amount      (numeric, required) The amount to send\n3. token       (string, optional)  The token to send\n4. fromaccount (string, optional)  The account to send from\n5. minconf     (numeric, optional) The minimum number of block confirmations of the spent outputs\n6. feerate     (numeric, optional) The fee rate in satoshis per virtual byte (default=the wallet fee rate)\n\nResult:\n{\n \"allowed\": true|false, (boolean)         Whether no rule blocks the send\n \"rules\": [{            (array of object) The outcome of every rule, in the order they are applied\n  \"rule\": \"value\",      (string)          The name of the rule (accountfreeze, addresspool, dust, funds, feecap, screening, txhooks or broadcasthold)\n  \"outcome\": \"value\",   (string)          The outcome of the rule (pass, warn, hold, block or skipped)\n  \"detail\": \"value\",    (string)          Why the rule triggered\n },...],                                  \n \"fee\": n.nnn,          (numeric)         The fee of the transaction the send would create (omitted when it could not be created)\n \"vsize\": n,            (numeric)         The estimated virtual size of the transaction the send would create (omitted when it could not be created)\n}                       \n",
+		"createpaymentreference":       "createpaymentreference \"address\"\n\nReturns the payment reference tied to an external wallet address, creating one unique to the wallet when the address has none.\nReferences are ISO 11649 creditor references, which payers may quote as bank payment references.\n\nArguments:\n1. address (string, required) The address the reference is tied to\n\nResult:\n{\n \"reference\": \"value\", (string)  The payment reference\n \"address\": \"value\",   (string)  The address the reference is tied to\n \"account\": \"value\",   (string)  The account of the address\n \"created\": n,         (numeric) The Unix time the reference was created\n \"uri\": \"value\",       (string)  A BIP21 payment URI for the address, labeled with the reference\n \"received\": n.nnn,    (numeric) The amount received by the address (only returned by lookuppaymentreference)\n}                      \n",
+		"lookuppaymentreference":       "lookuppaymentreference \"reference\" (minconf=1)\n\nReturns the address a payment reference is tied to, and the amount it received.\nReferences are matched regardless of case and spaces, and mistyped references are refused.\n\nArguments:\n1. reference (string, required)             The payment reference\n2. minconf   (numeric, optional, default=1) The minimum number of block confirmations of the received outputs\n\nResult:\n{\n \"reference\": \"value\", (string)  The payment reference\n \"address\": \"value\",   (string)  The address the reference is tied to\n \"account\": \"value\",   (string)  The account of the address\n \"created\": n,         (numeric) The Unix time the reference was created\n \"uri\": \"value\",       (string)  A BIP21 payment URI for the address, labeled with the reference\n \"received\": n.nnn,    (numeric) The amount received by the address (only returned by lookuppaymentreference)\n}                      \n",
+		"listpaymentreferences":        "listpaymentreferences\n\nReturns the payment references of the wallet, ordered by reference.\n\nArguments:\nNone\n\nResult:\n[{\n \"reference\": \"value\", (string)  The payment reference\n \"address\": \"value\",   (string)  The address the reference is tied to\n \"account\": \"value\",   (string)  The account of the address\n \"created\": n,         (numeric) The Unix time the reference was created\n \"uri\": \"value\",       (string)  A BIP21 payment URI for the address, labeled with the reference\n \"received\": n.nnn,    (numeric) The amount received by the address (only returned by lookuppaymentreference)\n},...]\n",
+		"enrollbackuptoken":            "enrollbackuptoken \"name\"\n\nEnrolls the backup token configured with --backuptoken, such as a YubiKey, under a name.\nBackups made by backupwallet with --backuptoken are wrapped to every enrolled token, without requiring the tokens, and can only be decrypted with one of them.\nThe token may need to be touched.\n\nArguments:\n1. name (string, required) A name distinguishing the token from the other enrolled tokens\n\nResult:\n{\n \"name\": \"value\", (string)  The name of the token\n \"enrolled\": n,   (numeric) The Unix time the token was enrolled\n}                 \n",
+		"listbackuptokens":             "listbackuptokens\n\nReturns the enrolled backup tokens, ordered by name.\n\nArguments:\nNone\n\nResult:\n[{\n \"name\": \"value\", (string)  The name of the token\n \"enrolled\": n,   (numeric) The Unix time the token was enrolled\n},...]\n",
+		"removebackuptoken":            "removebackuptoken \"name\"\n\nRemoves an enrolled backup token, which no longer wraps new backups.\nBackups already wrapped by the token can still be decrypted with it.\n\nArguments:\n1. name (string, required) The name of the token\n\nResult:\nNothing\n",
+		"recoverbackup":                "recoverbackup \"backup\" \"destination\"\n\nDecrypts a backup wrapped by the enrolled backup tokens with the backup token configured with --backuptoken, and writes the wallet database to a new file.\nThe token may need to be touched once for each token the backup is wrapped to.\n\nArguments:\n1. backup      (string, required) The path of the backup made by backupwallet\n2. destination (string, required) The path of the wallet database to write, which must not exist\n\nResult:\nNothing\n",
+		"addheighttrigger":             "addheighttrigger height (\"webhook\" \"label\")\n\nAdds a trigger firing when the wallet connects a block at or above a height, such as the height a timelock expires.\nFired triggers are POSTed as JSON objects with the id, height, label and firedheight fields to the webhook, which is retried at every following block until it responds with a 2xx status.\nTriggers persist across restarts, and triggers whose height is already reached fire at the next block.\n\nArguments:\n1. height  (numeric, required) The height firing the trigger\n2. webhook (string, optional)  The HTTP URL POSTed the trigger when it fires\n3. label   (string, optional)  A label describing the trigger, posted with it\n\nResult:\n{\n \"id\": n,            (numeric) The ID of the trigger\n \"height\": n,        (numeric) The height firing the trigger\n \"webhook\": \"value\", (string)  The HTTP URL POSTed the trigger when it fires\n \"label\": \"value\",   (string)  The label of the trigger\n \"created\": n,       (numeric) The Unix time the trigger was added\n \"firedheight\": n,   (numeric) The height of the block which fired the trigger, omitted while the trigger is pending\n}                    \n",
+		"listheighttriggers":           "listheighttriggers\n\nReturns the height triggers which are pending, or fired but not yet accepted by their webhooks, ordered by ID.\n\nArguments:\nNone\n\nResult:\n[{\n \"id\": n,            (numeric) The ID of the trigger\n \"height\": n,        (numeric) The height firing the trigger\n \"webhook\": \"value\", (string)  The HTTP URL POSTed the trigger when it fires\n \"label\": \"value\",   (string)  The label of the trigger\n \"created\": n,       (numeric) The Unix time the trigger was added\n \"firedheight\": n,   (numeric) The height of the block which fired the trigger, omitted while the trigger is pending\n},...]\n",
+		"removeheighttrigger":          "removeheighttrigger id\n\nRemoves a height trigger which is pending, or whose webhook is still retried.\n\nArguments:\n1. id (numeric, required) The ID of the trigger\n\nResult:\nNothing\n",
+		"createapitoken":               "createapitoken \"name\"\n\nCreates a named API token authenticating a frontend, such as a point of sale or a dashboard.\nClients authenticate with the token by using its name and secret as their RPC username and password, so they are distinguished in the logs and may be revoked one by one.\nThe secret is only returned once, and the wallet only records its hash.\n\nArguments:\n1. name (string, required) A name distinguishing the token from the other tokens, without colons\n\nResult:\n{\n \"name\": \"value\",   (string)  The name of the token\n \"created\": n,      (numeric) The Unix time the token was created\n \"secret\": \"value\", (string)  The secret of the token, only returned when it is created\n}                   \n",
+		"listapitokens":                "listapitokens\n\nReturns the API tokens, ordered by name, without their secrets.\n\nArguments:\nNone\n\nResult:\n[{\n \"name\": \"value\",   (string)  The name of the token\n \"created\": n,      (numeric) The Unix time the token was created\n \"secret\": \"value\", (string)  The secret of the token, only returned when it is created\n},...]\n",
+		"revokeapitoken":               "revokeapitoken \"name\"\n\nRevokes an API token.\nClients can no longer authenticate with the token, and the websocket clients it authenticated are disconnected.\n\nArguments:\n1. name (string, required) The name of the token\n\nResult:\nNothing\n",
+		"getrefillrequest":             "getrefillrequest\n\nReturns the pending request to refill the hot account from the watch-only cold account, or null when the hot account was not below its threshold since it was last refilled.\nThe request is created when the confirmed spendable balance of the hot account falls below the --refillthreshold option, and removed once the hot account is refilled.\n\nArguments:\nNone\n\nResult:\n{\n \"created\": n,     (numeric) The Unix time the request was created\n \"balance\": n.nnn, (numeric) The balance of the hot account when the request was created\n \"amount\": n.nnn,  (numeric) The amount paid to the hot account\n \"fee\": n.nnn,     (numeric) The fee of the refill transaction\n \"path\": \"value\",  (string)  The path of the PSBT file written for the cold storage signers\n \"psbt\": \"value\",  (string)  The base64-encoded PSBT of the refill transaction\n}                  \n",
 	}
 }
 
@@ -56,4 +152,4 @@ var localeHelpDescs = map[string]func() map[string]string{
 	"en_US": helpDescsEnUS,
 }
 
-var requestUsages = "addmultisigaddress nrequired [\"key\",...] (\"account\")\ncreatemultisig nrequired [\"key\",...]\ndumpprivkey \"address\"\ngetaccount \"address\"\ngetaccountaddress \"account\"\ngetaddressesbyaccount \"account\"\ngetbalance (\"account\" minconf=1)\ngetbestblockhash\ngetblockcount\ngetinfo\ngetnewaddress (\"account\")\ngetrawchangeaddress (\"account\")\ngetreceivedbyaccount \"account\" (minconf=1)\ngetreceivedbyaddress \"address\" (minconf=1)\ngettransaction \"txid\" (includewatchonly=false)\nhelp (\"command\")\nimportprivkey \"privkey\" (\"label\" rescan=true)\nkeypoolrefill (newsize=100)\nlistaccounts (minconf=1)\nlistlockunspent\nlistreceivedbyaccount (minconf=1 includeempty=false includewatchonly=false)\nlistreceivedbyaddress (minconf=1 includeempty=false includewatchonly=false)\nlistsinceblock (\"blockhash\" targetconfirmations=1 includewatchonly=false)\nlisttransactions (\"account\" count=10 from=0 includewatchonly=false)\nlistunspent (minconf=1 maxconf=9999999 [\"address\",...])\nlockunspent unlock [{\"txid\":\"value\",\"vout\":n},...]\nsendfrom \"fromaccount\" \"toaddress\" amount (minconf=1 \"comment\" \"commentto\")\nsendmany \"fromaccount\" {\"address\":amount,...} (minconf=1 \"comment\")\nsendtoaddress \"address\" amount (\"comment\" \"commentto\")\nsettxfee amount\nsignmessage \"address\" \"message\"\nsignrawtransaction \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] [\"privkey\",...] flags=\"ALL\")\nvalidateaddress \"address\"\nverifymessage \"address\" \"signature\" \"message\"\nwalletlock\nwalletpassphrase \"passphrase\" timeout\nwalletpassphrasechange \"oldpassphrase\" \"newpassphrase\"\ncreatenewaccount \"account\"\nexportwatchingwallet (\"account\" download=false)\ngetbestblock\ngetunconfirmedbalance (\"account\")\nlistaddresstransactions [\"address\",...] (\"account\")\nlistalltransactions (\"account\")\nrenameaccount \"oldaccount\" \"newaccount\"\nwalletislocked"
+var requestUsages = "addmultisigaddress nrequired [\"key\",...] (\"account\")\nbackupwallet \"destination\"\ncreatemultisig nrequired [\"key\",...]\ndumpprivkey \"address\"\ndumpwallet \"filename\"\ngetaccount \"address\"\ngetaccountaddress \"account\"\ngetaddressesbyaccount \"account\"\ngetbalance (\"account\" minconf=1 \"token\")\ngetbestblockhash\ngetblockcount\ngetinfo\ngetnewaddress (\"account\")\ngetrawchangeaddress (\"account\")\ngetreceivedbyaccount \"account\" (minconf=1)\ngetreceivedbyaddress \"address\" (minconf=1)\ngettransaction \"txid\" (includewatchonly=false)\nhelp (\"command\")\nimportaddress \"address\" \"account\" (rescan=true)\nimportprivkey \"privkey\" (\"label\" rescan=true)\nimportwallet \"filename\"\nkeypoolrefill (newsize=100)\nlistaccounts (minconf=1)\nlistlockunspent\nlistreceivedbyaccount (minconf=1 includeempty=false includewatchonly=false)\nlistreceivedbyaddress (minconf=1 includeempty=false includewatchonly=false)\nlistsinceblock (\"blockhash\" targetconfirmations=1 includewatchonly=false)\nlisttransactions (\"account\" count=10 from=0 includewatchonly=false)\nlistunspent (minconf=1 maxconf=9999999 [\"address\",...] \"token\")\nlockunspent unlock [{\"txid\":\"value\",\"vout\":n},...]\nsendfrom \"fromaccount\" \"toaddress\" amount (minconf=1 \"comment\" \"commentto\" \"token\")\nsendmany \"fromaccount\" {\"address\":amount,...} (minconf=1 \"comment\" \"token\")\nsendtoaddress \"address\" amount (\"comment\" \"commentto\" \"token\")\nbid amount price (minconf=1)\nask amount price (minconf=1)\nsettxfee amount\nsignmessage \"address\" \"message\"\nsignrawtransaction \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] [\"privkey\",...] flags=\"ALL\")\nvalidateaddress \"address\"\nverifymessage \"address\" \"signature\" \"message\"\nwalletlock\nwalletpassphrase \"passphrase\" timeout\nwalletpassphrasechange \"oldpassphrase\" \"newpassphrase\"\ncreatenewaccount \"account\"\nexportwatchingwallet (\"account\" download=false)\ngetbestblock\ngetunconfirmedbalance (\"account\")\nlistaddresstransactions [\"address\",...] (\"account\")\nlistalltransactions (\"account\")\nrenameaccount \"oldaccount\" \"newaccount\"\nwalletislocked\ngetnewtaprootaddress (\"account\")\nimportwitnessscript \"script\"\nsettravelrule \"txid\" {\"firstname\":\"value\",\"lastname\":\"value\",\"legalname\":\"value\",\"streetname\":\"value\",\"buildingnumber\":\"value\",\"postcode\":\"value\",\"townname\":\"value\",\"country\":\"value\",\"nationalid\":\"value\",\"nationalidtype\":\"value\",\"dateofbirth\":\"value\",\"placeofbirth\":\"value\",\"accountnumber\":\"value\"} {\"firstname\":\"value\",\"lastname\":\"value\",\"legalname\":\"value\",\"streetname\":\"value\",\"buildingnumber\":\"value\",\"postcode\":\"value\",\"townname\":\"value\",\"country\":\"value\",\"nationalid\":\"value\",\"nationalidtype\":\"value\",\"dateofbirth\":\"value\",\"placeofbirth\":\"value\",\"accountnumber\":\"value\"} (\"originatingvasp\" \"beneficiaryvasp\")\nexporttravelrule (\"txid\")\nwalletcreatefundedpsbt {\"address\":amount,...} (\"fromaccount\" minconf \"token\" \"coinselection\" replaceable conftarget feerate locktime overridefeecap)\nwalletprocesspsbt \"psbt\" (sign \"sighashtype\")\nfinalizepsbt \"psbt\" (extract)\nexportpsbt \"psbt\" (\"file\" qrpartlen)\nimportsignedtx [\"part\",...] (\"file\")\ngetaggregatebalance ([\"account\",...] minconf \"token\")\nsweepprivkey \"privkey\" (\"account\" startheight \"token\" \"pool\")\ncreateaccountwithpath \"account\" \"path\"\nreserveaddressindexes \"account\" count\nimportmulti [{\"privkey\":privkey,\"address\":address,\"script\":script,\"account\":account,\"timestamp\":n},...] (rescan)\nexportledger \"format\" (startheight endheight)\ngetaddressusage \"address\"\ngetunlocktimeout\nimportprivkeys [\"privkey\",...] (timestamp=0 rescan=true)\nsetaccountpassphrase \"account\" \"passphrase\"\ngetoperationsequence\ngetaccountfootprint (\"account\")\ngetauditlog (from=1 count=100)\ngetstatecommitment (height)\ngetproofofreserves \"challenge\"\nenrolltotp\ndisabletotp\nsignmessagebip322 \"address\" \"message\"\npinaddresspool \"name\" \"descriptor\" (window=20 [\"account\",...])\nunpinaddresspool \"name\"\nlistaddresspools\ngetpooladdress \"name\"\nconsolidatechange (feerate maxinputs=100 dryrun=false)\ngetconsolidationreport\nsweepall \"fromaccount\" {\"address\":percent,...} ([\"address\",...] \"token\" minconf=1 feerate)\nsendwithinputs \"fromaccount\" {\"address\":amount,...} [{\"txid\":\"value\",\"vout\":n},...] (\"token\" minconf=1 replaceable conftarget feerate overridefeecap)\ncancelbroadcast \"txid\"\nlistunspentfiltered (minconf=1 maxconf=9999999 [\"address\",...] \"token\" minimumamount=0)\ngetdiagnostics\nsetaccountmetadata \"account\" \"key\" \"value\"\ngetaccountmetadata \"account\"\nanchoraccountmetadata \"account\"\nbumpfee \"txid\" (feerate)\nbumpfeecpfp \"txid\" (feerate)\ngetkeyusage (\"account\")\nqueuepayment \"fromaccount\" \"address\" amount (\"token\")\ngetqueuedpayment id\nlistqueuedpayments\ncancelqueuedpayment id\nsendqueuedpayments\nsettransactionlabel \"txid\" \"label\"\ngetspendingreport starttime endtime (\"account\" \"token\")\nfundrawtransaction \"hextx\" ({\"fromaccount\":fromaccount,\"minconf\":minconf,\"changeposition\":changeposition,\"lockunspents\":lockunspents,\"feerate\":feerate,\"satpervbyte\":satpervbyte,\"conftarget\":conftarget,\"subtractfeefromoutputs\":[subtractfeefromoutput,...],\"replaceable\":replaceable,\"coinselection\":coinselection,\"changeaddress\":changeaddress,\"changeaccount\":changeaccount,\"overridefeecap\":overridefeecap})\nsignrawtransactionwithwallet \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] sighashtype=\"ALL\")\nsetchangepolicy \"policy\"\nconsolidateutxos threshold (feerate fromaccount=\"default\" \"token\" maxinputs=100 minconf=1)\ngetrecoverystatus\nrecoveryenterseed \"seed\" (birthday)\nrecoverychoosederivations [purpos,...] (recoverywindow=250)\nrecoverystartscan \"passphrase\" (\"publicpassphrase\")\nrecoveryfinalize\nrecoveryabort\nlistquarantined\nspendquarantined [{\"txid\":\"value\",\"vout\":n},...] (\"address\" feerate)\ngetconfighash (verbose=false)\ngetdustpolicy\nfreezeaccount \"account\" (\"reason\")\nunfreezeaccount \"account\" \"passphrase\"\nlistfrozenaccounts\ngetderivationproof \"address\"\npreparetransaction {\"address\":amount,...} (\"fromaccount\" minconf \"token\" \"coinselection\" conftarget feerate [\"subtractfeefrom\",...] \"changeaddress\")\nentermaintenance (timeout=30)\nexitmaintenance\ngetmaintenanceinfo\nacceleratetx \"txid\" ([\"accelerator\",...])\nlistaccelerations (\"txid\")\nevaluatepolicy \"address\" amount (\"token\" \"fromaccount\" minconf feerate)\ncreatepaymentreference \"address\"\nlookuppaymentreference \"reference\" (minconf=1)\nlistpaymentreferences\nenrollbackuptoken \"name\"\nlistbackuptokens\nremovebackuptoken \"name\"\nrecoverbackup \"backup\" \"destination\"\naddheighttrigger height (\"webhook\" \"label\")\nlistheighttriggers\nremoveheighttrigger id\ncreateapitoken \"name\"\nlistapitokens\nrevokeapitoken \"name\"\ngetrefillrequest"
@@ -5,9 +5,11 @@
 package legacyrpc
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,14 +17,23 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/internal/cfgutil"
+	"github.com/btcsuite/btcwallet/internal/diagnostics"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/rpc/walletjson"
+	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet"
-	"github.com/btcsuite/websocket"
+	"github.com/gorilla/websocket"
 )
 
 type websocketClient struct {
@@ -33,12 +44,78 @@ type websocketClient struct {
 	responses     chan []byte
 	quit          chan struct{} // closed on disconnect
 	wg            sync.WaitGroup
+
+	// blinded is set for clients authenticated with the blinded
+	// credentials, which may only subscribe to amount-blinded receive
+	// notifications.
+	blinded bool
+
+	// apiToken is the API token authenticating the client, or nil when
+	// the client authenticated with other credentials.
+	apiToken *apiTokenAuth
+
+	// blocks receives the block notifications requested by the client
+	// with notifyblocks.  It is only accessed by websocketClientRespond.
+	blocks *wallet.BlockNotificationsClient
+
+	// balances receives the balance notifications requested by the
+	// client with notifybalances.  It is only accessed by
+	// websocketClientRespond.
+	balances *wallet.BalanceNotificationsClient
+
+	// lockState receives the lock state notifications requested by the
+	// client with notifylockstate.  It is only accessed by
+	// websocketClientRespond.
+	lockState *wallet.LockStateNotificationsClient
+
+	// accountQuota receives the account quota notifications requested by
+	// the client with notifyaccountquota.  It is only accessed by
+	// websocketClientRespond.
+	accountQuota *wallet.AccountQuotaNotificationsClient
+
+	// unlockFailures receives the wrong passphrase notifications requested
+	// by the client with notifyunlockfailures.  It is only accessed by
+	// websocketClientRespond.
+	unlockFailures *wallet.UnlockFailureNotificationsClient
+
+	// pendingBroadcasts receives the notifications of transactions held
+	// before their broadcast requested by the client with
+	// notifypendingbroadcast.  It is only accessed by
+	// websocketClientRespond.
+	pendingBroadcasts *wallet.PendingBroadcastNotificationsClient
+
+	// keyUsage receives the signing volume alerts requested by the client
+	// with notifykeyusagealerts.  It is only accessed by
+	// websocketClientRespond.
+	keyUsage *wallet.KeyUsageNotificationsClient
+
+	// consolidations receives the consolidation notifications requested
+	// by the client with notifyconsolidations.  It is only accessed by
+	// websocketClientRespond.
+	consolidations *wallet.ConsolidationNotificationsClient
+
+	// received receives the transaction notifications forwarded as the
+	// receive notifications requested by the client with notifyreceived.
+	// It is only accessed by websocketClientRespond.
+	received *wallet.TransactionNotificationsClient
+
+	// balanceAccounts, receivedBlinded and receivedAccounts are the
+	// filters of the balance and receive notifications requested by the
+	// client, which also apply to the notifications replayed with
+	// replaynotifications.  They are only accessed by
+	// websocketClientRespond.
+	balanceAccounts  map[uint32]struct{}
+	receivedBlinded  bool
+	receivedAccounts map[uint32]struct{}
 }
 
-func newWebsocketClient(c *websocket.Conn, authenticated bool, remoteAddr string) *websocketClient {
+func newWebsocketClient(c *websocket.Conn, authenticated, blinded bool,
+	remoteAddr string) *websocketClient {
+
 	return &websocketClient{
 		conn:          c,
 		authenticated: authenticated,
+		blinded:       blinded,
 		remoteAddr:    remoteAddr,
 		allRequests:   make(chan []byte),
 		responses:     make(chan []byte),
@@ -46,6 +123,16 @@ func newWebsocketClient(c *websocket.Conn, authenticated bool, remoteAddr string
 	}
 }
 
+// apiTokenRevoked returns a channel closed when the API token authenticating
+// the client is revoked, or nil when the client is not authenticated by an
+// API token.
+func (c *websocketClient) apiTokenRevoked() <-chan struct{} {
+	if c.apiToken == nil {
+		return nil
+	}
+	return c.apiToken.revoked
+}
+
 func (c *websocketClient) send(b []byte) error {
 	select {
 	case c.responses <- b:
@@ -58,6 +145,14 @@ func (c *websocketClient) send(b []byte) error {
 // Server holds the items the RPC server may need to access (auth,
 // config, shutdown, etc.)
 type Server struct {
+	// handledRequests is the number of requests handled since the server
+	// started.  It is used atomically and must be 64-bit aligned.
+	handledRequests uint64
+
+	// activeHandlers is the number of requests being handled.  It is used
+	// atomically.
+	activeHandlers int32
+
 	httpServer    http.Server
 	wallet        *wallet.Wallet
 	walletLoader  *wallet.Loader
@@ -69,9 +164,32 @@ type Server struct {
 	authsha   [sha256.Size]byte
 	upgrader  websocket.Upgrader
 
+	// allowedOrigins are the origins of the browsers permitted to upgrade
+	// to websocket connections, or nil when every origin is permitted.
+	allowedOrigins map[string]struct{}
+
+	// blindedAuthsha is the hash of the HTTP basic auth string of the
+	// blinded credentials, or nil when they are disabled.
+	blindedAuthsha *[sha256.Size]byte
+
+	// noSharedAuth is set when clients may not authenticate with the
+	// shared username and password, and must use API tokens.
+	noSharedAuth bool
+
 	maxPostClients      int64 // Max concurrent HTTP POST clients.
 	maxWebsocketClients int64 // Max concurrent websocket clients.
 
+	// maxFrameSize is the max payload size of the frames sent to websocket
+	// clients, or zero when messages are sent in a single frame.
+	maxFrameSize int
+
+	// maxMessageSize is the max size of the messages read from websocket
+	// clients, or zero when there is no limit.
+	maxMessageSize int64
+
+	diagnostics       *diagnostics.Recorder
+	configFingerprint func() *cfgutil.Fingerprint
+
 	wg      sync.WaitGroup
 	quit    chan struct{}
 	quitMtx sync.Mutex
@@ -103,16 +221,36 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 		maxPostClients:      opts.MaxPOSTClients,
 		maxWebsocketClients: opts.MaxWebsocketClients,
 		listeners:           listeners,
+		diagnostics:         opts.Diagnostics,
+		configFingerprint:   opts.ConfigFingerprint,
 		// A hash of the HTTP basic auth string is used for a constant
 		// time comparison.
 		authsha: sha256.Sum256(httpBasicAuth(opts.Username, opts.Password)),
 		upgrader: websocket.Upgrader{
-			// Allow all origins.
-			CheckOrigin: func(r *http.Request) bool { return true },
+			// Origins are checked by checkOrigin before the
+			// client is authenticated.
+			CheckOrigin:       func(r *http.Request) bool { return true },
+			ReadBufferSize:    opts.MaxWebsocketFrameSize,
+			WriteBufferSize:   opts.MaxWebsocketFrameSize,
+			EnableCompression: opts.WebsocketCompression,
 		},
+		maxFrameSize:        opts.MaxWebsocketFrameSize,
+		maxMessageSize:      opts.MaxWebsocketMessageSize,
+		noSharedAuth:        opts.DisableSharedAuth,
 		quit:                make(chan struct{}),
 		requestShutdownChan: make(chan struct{}, 1),
 	}
+	if opts.BlindedUsername != "" || opts.BlindedPassword != "" {
+		authsha := sha256.Sum256(httpBasicAuth(opts.BlindedUsername,
+			opts.BlindedPassword))
+		server.blindedAuthsha = &authsha
+	}
+	if len(opts.AllowedOrigins) != 0 {
+		server.allowedOrigins = make(map[string]struct{})
+		for _, origin := range opts.AllowedOrigins {
+			server.allowedOrigins[origin] = struct{}{}
+		}
+	}
 
 	serveMux.Handle("/", throttledFn(opts.MaxPOSTClients,
 		func(w http.ResponseWriter, r *http.Request) {
@@ -120,25 +258,49 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 			w.Header().Set("Content-Type", "application/json")
 			r.Close = true
 
+			var token *apiTokenAuth
 			if err := server.checkAuthHeader(r); err != nil {
-				log.Warnf("Unauthorized client connection attempt")
-				jsonAuthFail(w)
-				return
+				token = server.checkAPIToken(r.Header.Get("Authorization"))
+				if token == nil {
+					log.Warnf("Unauthorized client connection attempt")
+					jsonAuthFail(w)
+					return
+				}
 			}
 			server.wg.Add(1)
-			server.postClientRPC(w, r)
+			server.postClientRPC(w, r, token)
 			server.wg.Done()
 		}))
 
 	serveMux.Handle("/ws", throttledFn(opts.MaxWebsocketClients,
 		func(w http.ResponseWriter, r *http.Request) {
-			authenticated := false
+			if !server.checkOrigin(r) {
+				log.Warnf("Refused websocket upgrade of client %s "+
+					"from origin %q", r.RemoteAddr,
+					r.Header.Get("Origin"))
+				http.Error(w, "403 Forbidden", http.StatusForbidden)
+				return
+			}
+
+			authenticated, blinded := false, false
+			var token *apiTokenAuth
 			switch server.checkAuthHeader(r) {
 			case nil:
 				authenticated = true
 			case ErrNoAuth:
 				// nothing
 			default:
+				auth := r.Header.Get("Authorization")
+				if server.checkBlindedAuth(auth) {
+					authenticated, blinded = true, true
+					break
+				}
+				token = server.checkAPIToken(auth)
+				if token != nil {
+					authenticated = true
+					break
+				}
+
 				// If auth was supplied but incorrect, rather than simply
 				// being missing, immediately terminate the connection.
 				log.Warnf("Disconnecting improperly authorized " +
@@ -153,7 +315,12 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 					r.RemoteAddr, err)
 				return
 			}
-			wsc := newWebsocketClient(conn, authenticated, r.RemoteAddr)
+			if server.maxMessageSize > 0 {
+				conn.SetReadLimit(server.maxMessageSize)
+			}
+			wsc := newWebsocketClient(conn, authenticated, blinded,
+				r.RemoteAddr)
+			wsc.apiToken = token
 			server.websocketClientRPC(wsc)
 		}))
 
@@ -164,6 +331,18 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 	return server
 }
 
+// checkOrigin returns whether the origin of a websocket upgrade request is
+// permitted.  Requests without an Origin header are not made by browsers, and
+// are always permitted.
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if s.allowedOrigins == nil || origin == "" {
+		return true
+	}
+	_, ok := s.allowedOrigins[strings.ToLower(origin)]
+	return ok
+}
+
 // httpBasicAuth returns the UTF-8 bytes of the HTTP Basic authentication
 // string:
 //
@@ -271,7 +450,7 @@ func (s *Server) SetChainServer(chainClient chain.Interface) {
 // NOTE: These handlers do not handle special cases, such as the authenticate
 // method.  Each of these must be checked beforehand (the method is already
 // known) and handled accordingly.
-func (s *Server) handlerClosure(request *btcjson.Request) lazyHandler {
+func (s *Server) handlerClosure(request *btcjson.Request, ext *requestExtensions) lazyHandler {
 	s.handlerMu.Lock()
 	// With the lock held, make copies of these pointers for the closure.
 	wallet := s.wallet
@@ -282,7 +461,156 @@ func (s *Server) handlerClosure(request *btcjson.Request) lazyHandler {
 	}
 	s.handlerMu.Unlock()
 
-	return lazyApplyHandler(request, wallet, chainClient)
+	var f lazyHandler
+	if request.Method == "getdiagnostics" {
+		// Diagnostics are available without a loaded wallet.
+		f = s.getDiagnostics
+	} else if request.Method == "getconfighash" {
+		// The configuration hash is available without a loaded
+		// wallet.
+		f = s.getConfigHash(request)
+	} else if handler, ok := recoveryHandlers[request.Method]; ok {
+		// The recovery wizard creates the wallet, and is available
+		// without a loaded wallet.
+		f = s.recoveryHandler(request, handler)
+	} else {
+		f = lazyApplyHandler(request, ext, wallet, chainClient)
+	}
+	return func() (interface{}, *uint64, *btcjson.RPCError) {
+		atomic.AddInt32(&s.activeHandlers, 1)
+		defer atomic.AddInt32(&s.activeHandlers, -1)
+		atomic.AddUint64(&s.handledRequests, 1)
+		return f()
+	}
+}
+
+// HandlerStats returns the number of requests being handled and the number
+// of requests handled since the server started.
+func (s *Server) HandlerStats() (active int, handled uint64) {
+	return int(atomic.LoadInt32(&s.activeHandlers)),
+		atomic.LoadUint64(&s.handledRequests)
+}
+
+// getDiagnostics handles a getdiagnostics request by returning the current
+// state of the diagnostics metrics and the recorded snapshots.
+func (s *Server) getDiagnostics() (interface{}, *uint64, *btcjson.RPCError) {
+	if s.diagnostics == nil {
+		return nil, nil, &btcjson.RPCError{
+			Code:    -1,
+			Message: "Diagnostics are disabled",
+		}
+	}
+	result := &walletjson.GetDiagnosticsResult{
+		Current: diagnosticsSnapshotResult(s.diagnostics.Current()),
+	}
+	for _, snapshot := range s.diagnostics.Snapshots() {
+		result.Snapshots = append(result.Snapshots,
+			diagnosticsSnapshotResult(snapshot))
+	}
+	return result, nil, nil
+}
+
+// getConfigHash returns the handler of a getconfighash request, returning the
+// hash of the effective configuration and, when verbose, its canonical
+// settings.
+func (s *Server) getConfigHash(request *btcjson.Request) lazyHandler {
+	return func() (interface{}, *uint64, *btcjson.RPCError) {
+		if s.configFingerprint == nil {
+			return nil, nil, &btcjson.RPCError{
+				Code:    -1,
+				Message: "Configuration hash is unavailable",
+			}
+		}
+		cmd, err := unmarshalCmd(request)
+		if err != nil {
+			return nil, nil, btcjson.ErrRPCInvalidRequest
+		}
+		f := s.configFingerprint()
+		result := &walletjson.GetConfigHashResult{Hash: f.String()}
+		if *cmd.(*walletjson.GetConfigHashCmd).Verbose {
+			result.Settings = make([]string, len(f.Settings))
+			for i, setting := range f.Settings {
+				result.Settings[i] = setting.String()
+			}
+		}
+		return result, nil, nil
+	}
+}
+
+// recoveryHandler returns the handler of a recovery wizard request.
+func (s *Server) recoveryHandler(request *btcjson.Request,
+	handler func(interface{}, *wallet.RecoveryWizard) (interface{}, error)) lazyHandler {
+
+	return func() (interface{}, *uint64, *btcjson.RPCError) {
+		if s.walletLoader == nil {
+			return nil, nil, &btcjson.RPCError{
+				Code:    -1,
+				Message: "Wallet loader is unavailable",
+			}
+		}
+		cmd, err := unmarshalCmd(request)
+		if err != nil {
+			return nil, nil, btcjson.ErrRPCInvalidRequest
+		}
+		result, err := handler(cmd, s.walletLoader.RecoveryWizard())
+		if err != nil {
+			return nil, nil, jsonError(err)
+		}
+		return result, nil, nil
+	}
+}
+
+// diagnosticsSnapshotResult converts a diagnostics snapshot to its JSON-RPC
+// result.
+func diagnosticsSnapshotResult(s *diagnostics.Snapshot) walletjson.DiagnosticsSnapshot {
+	return walletjson.DiagnosticsSnapshot{
+		Time:    s.Time,
+		Metrics: s.Metrics,
+		Errors:  s.Errors,
+	}
+}
+
+// requestExtensions are the members of request objects extending JSON-RPC
+// requests.  Sequence is the wallet operation sequence a mutating request is
+// conditional on, and TOTP is the one-time password of requests requiring
+// one.
+type requestExtensions struct {
+	Sequence *uint64 `json:"sequence"`
+	TOTP     string  `json:"totp"`
+}
+
+// parseRequestExtensions returns the extension members of a request.  Missing
+// or malformed members are left unset.
+func parseRequestExtensions(b []byte) *requestExtensions {
+	var ext requestExtensions
+	if err := json.Unmarshal(b, &ext); err != nil {
+		return &requestExtensions{}
+	}
+	return &ext
+}
+
+// sequencedResponse is the response to a mutating request, extended with the
+// wallet operation sequence after the request.
+type sequencedResponse struct {
+	btcjson.Response
+	Sequence uint64 `json:"sequence"`
+}
+
+// marshalResponse marshals the response to a request, adding the wallet
+// operation sequence when it is not nil.
+func marshalResponse(id, result interface{}, seq *uint64, rpcErr *btcjson.RPCError) ([]byte, error) {
+	if seq == nil {
+		return btcjson.MarshalResponse(id, result, rpcErr)
+	}
+	marshalledResult, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := btcjson.NewResponse(id, marshalledResult, rpcErr)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&sequencedResponse{Response: *resp, Sequence: *seq})
 }
 
 // ErrNoAuth represents an error where authentication could not succeed
@@ -292,7 +620,8 @@ var ErrNoAuth = errors.New("no auth")
 // checkAuthHeader checks the HTTP Basic authentication supplied by a client
 // in the HTTP request r.  It errors with ErrNoAuth if the request does not
 // contain the Authorization header, or another non-nil error if the
-// authentication was provided but incorrect.
+// authentication was provided but incorrect, or when the shared credentials
+// are disabled.
 //
 // This check is time-constant.
 func (s *Server) checkAuthHeader(r *http.Request) error {
@@ -300,6 +629,9 @@ func (s *Server) checkAuthHeader(r *http.Request) error {
 	if len(authhdr) == 0 {
 		return ErrNoAuth
 	}
+	if s.noSharedAuth {
+		return errors.New("shared auth disabled")
+	}
 
 	authsha := sha256.Sum256([]byte(authhdr[0]))
 	cmp := subtle.ConstantTimeCompare(authsha[:], s.authsha[:])
@@ -309,6 +641,64 @@ func (s *Server) checkAuthHeader(r *http.Request) error {
 	return nil
 }
 
+// checkBlindedAuth checks whether the HTTP Basic authentication string auth
+// matches the blinded credentials.  POST clients are never authenticated by
+// the blinded credentials.
+//
+// This check is time-constant.
+func (s *Server) checkBlindedAuth(auth string) bool {
+	if s.blindedAuthsha == nil {
+		return false
+	}
+	authsha := sha256.Sum256([]byte(auth))
+	return subtle.ConstantTimeCompare(authsha[:], s.blindedAuthsha[:]) == 1
+}
+
+// apiTokenAuth is the authentication of a client by an API token of the
+// wallet.
+type apiTokenAuth struct {
+	name    string
+	revoked <-chan struct{}
+}
+
+// checkAPIToken checks whether the HTTP Basic authentication string auth
+// carries the name and secret of an API token of the loaded wallet as its
+// username and password, and returns the authentication of the client, or
+// nil when it does not.  API tokens are only accepted once a wallet is
+// loaded.
+//
+// The secret check is time-constant.
+func (s *Server) checkAPIToken(auth string) *apiTokenAuth {
+	const prefix = "Basic "
+	if !strings.HasPrefix(auth, prefix) {
+		return nil
+	}
+	login, err := base64.StdEncoding.DecodeString(auth[len(prefix):])
+	if err != nil {
+		return nil
+	}
+	i := bytes.IndexByte(login, ':')
+	if i < 0 {
+		return nil
+	}
+
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return nil
+	}
+	name := string(login[:i])
+	revoked, err := w.AuthenticateAPIToken(name, string(login[i+1:]))
+	if err != nil {
+		if err != wallet.ErrInvalidAPIToken {
+			log.Errorf("Cannot check API token %q: %v", name, err)
+		}
+		return nil
+	}
+	return &apiTokenAuth{name: name, revoked: revoked}
+}
+
 // throttledFn wraps an http.HandlerFunc with throttling of concurrent active
 // clients by responding with an HTTP 429 when the threshold is crossed.
 func throttledFn(threshold int64, f http.HandlerFunc) http.Handler {
@@ -340,9 +730,9 @@ func throttled(threshold int64, h http.Handler) http.Handler {
 func sanitizeRequest(r *btcjson.Request) string {
 	// These are considered unsafe to log, so sanitize parameters.
 	switch r.Method {
-	case "encryptwallet", "importprivkey", "importwallet",
-		"signrawtransaction", "walletpassphrase",
-		"walletpassphrasechange":
+	case "encryptwallet", "importprivkey", "importprivkeys",
+		"importwallet", "setaccountpassphrase", "signrawtransaction", "walletpassphrase",
+		"walletpassphrasechange", "unfreezeaccount":
 
 		return fmt.Sprintf(`{"id":%v,"method":"%s","params":SANITIZED %d parameters}`,
 			r.ID, r.Method, len(r.Params))
@@ -365,21 +755,34 @@ func idPointer(id interface{}) (p *interface{}) {
 
 // invalidAuth checks whether a websocket request is a valid (parsable)
 // authenticate request and checks the supplied username and passphrase
-// against the server auth.
-func (s *Server) invalidAuth(req *btcjson.Request) bool {
+// against the server auth.  Clients supplying the blinded credentials are
+// authenticated as blinded clients, and clients supplying the name and secret
+// of an API token are authenticated by the token.
+func (s *Server) invalidAuth(req *btcjson.Request) (invalid, blinded bool, token *apiTokenAuth) {
 	cmd, err := btcjson.UnmarshalCmd(req)
 	if err != nil {
-		return false
+		return false, false, nil
 	}
 	authCmd, ok := cmd.(*btcjson.AuthenticateCmd)
 	if !ok {
-		return false
+		return false, false, nil
 	}
 	// Check credentials.
 	login := authCmd.Username + ":" + authCmd.Passphrase
 	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
 	authSha := sha256.Sum256([]byte(auth))
-	return subtle.ConstantTimeCompare(authSha[:], s.authsha[:]) != 1
+	if !s.noSharedAuth &&
+		subtle.ConstantTimeCompare(authSha[:], s.authsha[:]) == 1 {
+
+		return false, false, nil
+	}
+	if s.checkBlindedAuth(auth) {
+		return false, true, nil
+	}
+	if token := s.checkAPIToken(auth); token != nil {
+		return false, false, token
+	}
+	return true, false, nil
 }
 
 func (s *Server) websocketClientRead(wsc *websocketClient) {
@@ -436,11 +839,23 @@ out:
 			}
 
 			if req.Method == "authenticate" {
-				if wsc.authenticated || s.invalidAuth(&req) {
+				if wsc.authenticated {
+					// Disconnect immediately.
+					break out
+				}
+				invalid, blinded, token := s.invalidAuth(&req)
+				if invalid {
 					// Disconnect immediately.
 					break out
 				}
 				wsc.authenticated = true
+				wsc.blinded = blinded
+				wsc.apiToken = token
+				if token != nil {
+					log.Infof("Websocket client %s authenticated "+
+						"with API token %q", wsc.remoteAddr,
+						token.name)
+				}
 				resp := makeResponse(req.ID, nil, nil)
 				// Expected to never fail.
 				mresp, err := json.Marshal(resp)
@@ -459,6 +874,26 @@ out:
 				break out
 			}
 
+			// Blinded clients are refused every request other than
+			// the subscription to blinded receive notifications
+			// and their replay.
+			if wsc.blinded && req.Method != "notifyreceived" &&
+				req.Method != "stopnotifyreceived" &&
+				req.Method != "replaynotifications" {
+
+				mresp, err := btcjson.MarshalResponse(req.ID, nil,
+					&ErrBlindedClient)
+				// Expected to never fail.
+				if err != nil {
+					panic(err)
+				}
+				err = wsc.send(mresp)
+				if err != nil {
+					break out
+				}
+				continue
+			}
+
 			switch req.Method {
 			case "stop":
 				resp := makeResponse(req.ID,
@@ -475,13 +910,188 @@ out:
 				s.requestProcessShutdown()
 				break
 
+			case "notifyblocks", "stopnotifyblocks":
+				var jsonErr *btcjson.RPCError
+				if req.Method == "notifyblocks" {
+					jsonErr = s.notifyBlocks(wsc)
+				} else if wsc.blocks != nil {
+					wsc.blocks.Done()
+					wsc.blocks = nil
+				}
+				mresp, err := btcjson.MarshalResponse(req.ID, nil, jsonErr)
+				// Expected to never fail.
+				if err != nil {
+					panic(err)
+				}
+				err = wsc.send(mresp)
+				if err != nil {
+					break out
+				}
+
+			case "notifybalances", "stopnotifybalances":
+				var jsonErr *btcjson.RPCError
+				if req.Method == "notifybalances" {
+					jsonErr = s.notifyBalances(wsc, &req)
+				} else if wsc.balances != nil {
+					wsc.balances.Done()
+					wsc.balances = nil
+				}
+				mresp, err := btcjson.MarshalResponse(req.ID, nil, jsonErr)
+				// Expected to never fail.
+				if err != nil {
+					panic(err)
+				}
+				err = wsc.send(mresp)
+				if err != nil {
+					break out
+				}
+
+			case "notifylockstate", "stopnotifylockstate":
+				var jsonErr *btcjson.RPCError
+				if req.Method == "notifylockstate" {
+					jsonErr = s.notifyLockState(wsc)
+				} else if wsc.lockState != nil {
+					wsc.lockState.Done()
+					wsc.lockState = nil
+				}
+				mresp, err := btcjson.MarshalResponse(req.ID, nil, jsonErr)
+				// Expected to never fail.
+				if err != nil {
+					panic(err)
+				}
+				err = wsc.send(mresp)
+				if err != nil {
+					break out
+				}
+
+			case "notifyaccountquota", "stopnotifyaccountquota":
+				var jsonErr *btcjson.RPCError
+				if req.Method == "notifyaccountquota" {
+					jsonErr = s.notifyAccountQuota(wsc)
+				} else if wsc.accountQuota != nil {
+					wsc.accountQuota.Done()
+					wsc.accountQuota = nil
+				}
+				mresp, err := btcjson.MarshalResponse(req.ID, nil, jsonErr)
+				// Expected to never fail.
+				if err != nil {
+					panic(err)
+				}
+				err = wsc.send(mresp)
+				if err != nil {
+					break out
+				}
+
+			case "notifyunlockfailures", "stopnotifyunlockfailures":
+				var jsonErr *btcjson.RPCError
+				if req.Method == "notifyunlockfailures" {
+					jsonErr = s.notifyUnlockFailures(wsc)
+				} else if wsc.unlockFailures != nil {
+					wsc.unlockFailures.Done()
+					wsc.unlockFailures = nil
+				}
+				mresp, err := btcjson.MarshalResponse(req.ID, nil, jsonErr)
+				// Expected to never fail.
+				if err != nil {
+					panic(err)
+				}
+				err = wsc.send(mresp)
+				if err != nil {
+					break out
+				}
+
+			case "notifypendingbroadcast", "stopnotifypendingbroadcast":
+				var jsonErr *btcjson.RPCError
+				if req.Method == "notifypendingbroadcast" {
+					jsonErr = s.notifyPendingBroadcasts(wsc)
+				} else if wsc.pendingBroadcasts != nil {
+					wsc.pendingBroadcasts.Done()
+					wsc.pendingBroadcasts = nil
+				}
+				mresp, err := btcjson.MarshalResponse(req.ID, nil, jsonErr)
+				// Expected to never fail.
+				if err != nil {
+					panic(err)
+				}
+				err = wsc.send(mresp)
+				if err != nil {
+					break out
+				}
+
+			case "notifykeyusagealerts", "stopnotifykeyusagealerts":
+				var jsonErr *btcjson.RPCError
+				if req.Method == "notifykeyusagealerts" {
+					jsonErr = s.notifyKeyUsageAlerts(wsc)
+				} else if wsc.keyUsage != nil {
+					wsc.keyUsage.Done()
+					wsc.keyUsage = nil
+				}
+				mresp, err := btcjson.MarshalResponse(req.ID, nil, jsonErr)
+				// Expected to never fail.
+				if err != nil {
+					panic(err)
+				}
+				err = wsc.send(mresp)
+				if err != nil {
+					break out
+				}
+
+			case "notifyconsolidations", "stopnotifyconsolidations":
+				var jsonErr *btcjson.RPCError
+				if req.Method == "notifyconsolidations" {
+					jsonErr = s.notifyConsolidations(wsc)
+				} else if wsc.consolidations != nil {
+					wsc.consolidations.Done()
+					wsc.consolidations = nil
+				}
+				mresp, err := btcjson.MarshalResponse(req.ID, nil, jsonErr)
+				// Expected to never fail.
+				if err != nil {
+					panic(err)
+				}
+				err = wsc.send(mresp)
+				if err != nil {
+					break out
+				}
+
+			case "replaynotifications":
+				result, jsonErr := s.replayNotifications(wsc, &req)
+				mresp, err := btcjson.MarshalResponse(req.ID, result, jsonErr)
+				// Expected to never fail.
+				if err != nil {
+					panic(err)
+				}
+				err = wsc.send(mresp)
+				if err != nil {
+					break out
+				}
+
+			case "notifyreceived", "stopnotifyreceived":
+				var jsonErr *btcjson.RPCError
+				if req.Method == "notifyreceived" {
+					jsonErr = s.notifyReceived(wsc, &req)
+				} else if wsc.received != nil {
+					wsc.received.Done()
+					wsc.received = nil
+				}
+				mresp, err := btcjson.MarshalResponse(req.ID, nil, jsonErr)
+				// Expected to never fail.
+				if err != nil {
+					panic(err)
+				}
+				err = wsc.send(mresp)
+				if err != nil {
+					break out
+				}
+
 			default:
 				req := req // Copy for the closure
-				f := s.handlerClosure(&req)
+				f := s.handlerClosure(&req,
+					parseRequestExtensions(reqBytes))
 				wsc.wg.Add(1)
 				go func() {
-					resp, jsonErr := f()
-					mresp, err := btcjson.MarshalResponse(req.ID, resp, jsonErr)
+					resp, seq, jsonErr := f()
+					mresp, err := marshalResponse(req.ID, resp, seq, jsonErr)
 					if err != nil {
 						log.Errorf("Unable to marshal response: %v", err)
 					} else {
@@ -491,17 +1101,703 @@ out:
 				}()
 			}
 
+			// Notifications queued while no client subscribed to
+			// them are sent to the first client subscribing.
+			if strings.HasPrefix(req.Method, "notify") {
+				s.deliverQueuedNtfns(wsc)
+			}
+
+		case <-wsc.apiTokenRevoked():
+			log.Infof("Disconnecting websocket client %s: API token "+
+				"%q revoked", wsc.remoteAddr, wsc.apiToken.name)
+			break out
+
 		case <-s.quit:
 			break out
 		}
 	}
 
+	// Stop forwarding block, balance, lock state, account quota, unlock
+	// failure, pending broadcast, key usage, consolidation and receive
+	// notifications, if requested, before the responses channel is closed.
+	if wsc.blocks != nil {
+		wsc.blocks.Done()
+	}
+	if wsc.balances != nil {
+		wsc.balances.Done()
+	}
+	if wsc.lockState != nil {
+		wsc.lockState.Done()
+	}
+	if wsc.accountQuota != nil {
+		wsc.accountQuota.Done()
+	}
+	if wsc.unlockFailures != nil {
+		wsc.unlockFailures.Done()
+	}
+	if wsc.pendingBroadcasts != nil {
+		wsc.pendingBroadcasts.Done()
+	}
+	if wsc.keyUsage != nil {
+		wsc.keyUsage.Done()
+	}
+	if wsc.consolidations != nil {
+		wsc.consolidations.Done()
+	}
+	if wsc.received != nil {
+		wsc.received.Done()
+	}
+
 	// allow client to disconnect after all handler goroutines are done
 	wsc.wg.Wait()
 	close(wsc.responses)
 	s.wg.Done()
 }
 
+// notifyBlocks subscribes a websocket client to the blocks connected and
+// disconnected by the wallet, so frontends can follow the chain tip without
+// their own connection to the consensus server.  Notifications are sent as
+// the blockconnected and blockdisconnected notifications of btcd.
+func (s *Server) notifyBlocks(wsc *websocketClient) *btcjson.RPCError {
+	if wsc.blocks != nil {
+		return nil
+	}
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return &ErrUnloadedWallet
+	}
+
+	blocks := w.NtfnServer.BlockNotifications()
+	wsc.blocks = &blocks
+	wsc.wg.Add(1)
+	go func() {
+		defer wsc.wg.Done()
+		for n := range blocks.C {
+			sendBlockNtfn(wsc, n)
+		}
+	}()
+	return nil
+}
+
+// sendBlockNtfn sends a block notification to a websocket client.
+func sendBlockNtfn(wsc *websocketClient, n *wallet.BlockNotification) {
+	var ntfn interface{}
+	if n.Disconnected {
+		ntfn = btcjson.NewBlockDisconnectedNtfn(n.Hash.String(),
+			n.Height, n.Time.Unix())
+	} else {
+		ntfn = btcjson.NewBlockConnectedNtfn(n.Hash.String(),
+			n.Height, n.Time.Unix())
+	}
+	sendNtfn(wsc, ntfn, n.Sequence)
+}
+
+// notifyBalances subscribes a websocket client to the total balances of the
+// accounts whose balances change, debounced by the wallet's balance
+// notification interval.  The optional parameter of the request restricts the
+// notifications to the named accounts.  Notifications are sent as the
+// accountbalance notifications of btcwallet, with the unconfirmed total
+// balance of each account.
+func (s *Server) notifyBalances(wsc *websocketClient, req *btcjson.Request) *btcjson.RPCError {
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return &ErrUnloadedWallet
+	}
+	var accounts map[uint32]struct{}
+	if len(req.Params) > 0 {
+		var jsonErr *btcjson.RPCError
+		accounts, jsonErr = accountFilter(w, req.Params[0])
+		if jsonErr != nil {
+			return jsonErr
+		}
+	}
+
+	// Subscribing again updates the accounts of the notifications.
+	if wsc.balances != nil {
+		wsc.balances.Done()
+		wsc.balances = nil
+	}
+	balances := w.NtfnServer.BalanceNotifications()
+	wsc.balances = &balances
+	wsc.balanceAccounts = accounts
+	wsc.wg.Add(1)
+	go func() {
+		defer wsc.wg.Done()
+		for n := range balances.C {
+			sendBalanceNtfns(wsc, w, n, accounts)
+		}
+	}()
+	return nil
+}
+
+// sendBalanceNtfns sends an accountbalance notification to a websocket client
+// for each balance of the filtered accounts in a balance notification.
+func sendBalanceNtfns(wsc *websocketClient, w *wallet.Wallet,
+	n *wallet.BalanceNotification, accounts map[uint32]struct{}) {
+
+	for _, b := range n.Balances {
+		if !accountFiltered(accounts, b.Account) {
+			continue
+		}
+		name, err := w.AccountName(waddrmgr.KeyScopeBIP0044, b.Account)
+		if err != nil {
+			log.Errorf("Unable to look up account %d: %v",
+				b.Account, err)
+			continue
+		}
+		ntfn := btcjson.NewAccountBalanceNtfn(name,
+			b.TotalBalance.ToBTC(), false)
+		sendNtfn(wsc, ntfn, n.Sequence)
+	}
+}
+
+// notifyLockState subscribes a websocket client to the changes of the lock
+// state of the wallet, so frontends know when the passphrase must be entered
+// again.  Notifications are sent as the walletlockstate notifications of
+// btcwallet.  A notification with the current lock state is also sent after
+// the private passphrase is changed, since frontends holding the old
+// passphrase can no longer unlock the wallet with it.
+func (s *Server) notifyLockState(wsc *websocketClient) *btcjson.RPCError {
+	if wsc.lockState != nil {
+		return nil
+	}
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return &ErrUnloadedWallet
+	}
+
+	lockState := w.NtfnServer.LockStateNotifications()
+	wsc.lockState = &lockState
+	wsc.wg.Add(1)
+	go func() {
+		defer wsc.wg.Done()
+		for n := range lockState.C {
+			ntfn := btcjson.NewWalletLockStateNtfn(n.Locked)
+			sendNtfn(wsc, ntfn, n.Sequence)
+		}
+	}()
+	return nil
+}
+
+// notifyAccountQuota subscribes a websocket client to the accounts whose
+// transactions first exceed their footprint quotas.  Notifications are sent as
+// accountquota notifications.
+func (s *Server) notifyAccountQuota(wsc *websocketClient) *btcjson.RPCError {
+	if wsc.accountQuota != nil {
+		return nil
+	}
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return &ErrUnloadedWallet
+	}
+
+	accountQuota := w.NtfnServer.AccountQuotaNotifications()
+	wsc.accountQuota = &accountQuota
+	wsc.wg.Add(1)
+	go func() {
+		defer wsc.wg.Done()
+		for n := range accountQuota.C {
+			ntfn := walletjson.NewAccountQuotaNtfn(n.AccountName,
+				n.Footprint, n.Quota)
+			sendNtfn(wsc, ntfn, n.Sequence)
+		}
+	}()
+	return nil
+}
+
+// notifyUnlockFailures subscribes a websocket client to the wrong passphrases
+// tried to unlock the wallet or its accounts, or to change the wallet
+// passphrases, so frontends can audit brute-force attempts.  Notifications are
+// sent as unlockfailed notifications.
+func (s *Server) notifyUnlockFailures(wsc *websocketClient) *btcjson.RPCError {
+	if wsc.unlockFailures != nil {
+		return nil
+	}
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return &ErrUnloadedWallet
+	}
+
+	unlockFailures := w.NtfnServer.UnlockFailureNotifications()
+	wsc.unlockFailures = &unlockFailures
+	wsc.wg.Add(1)
+	go func() {
+		defer wsc.wg.Done()
+		for n := range unlockFailures.C {
+			ntfn := walletjson.NewUnlockFailedNtfn(n.Failures,
+				int64(n.RetryAfter/time.Second), n.LockedOut)
+			sendNtfn(wsc, ntfn, n.Sequence)
+		}
+	}()
+	return nil
+}
+
+// notifyPendingBroadcasts subscribes a websocket client to the transactions
+// held before their broadcast, so approvers can cancel them with
+// cancelbroadcast.  Notifications are sent as pendingbroadcast notifications.
+func (s *Server) notifyPendingBroadcasts(wsc *websocketClient) *btcjson.RPCError {
+	if wsc.pendingBroadcasts != nil {
+		return nil
+	}
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return &ErrUnloadedWallet
+	}
+
+	pendingBroadcasts := w.NtfnServer.PendingBroadcastNotifications()
+	wsc.pendingBroadcasts = &pendingBroadcasts
+	wsc.wg.Add(1)
+	go func() {
+		defer wsc.wg.Done()
+		for n := range pendingBroadcasts.C {
+			sendPendingBroadcastNtfn(wsc, n)
+		}
+	}()
+	return nil
+}
+
+// sendPendingBroadcastNtfn sends a pendingbroadcast notification to a
+// websocket client.
+func sendPendingBroadcastNtfn(wsc *websocketClient,
+	n *wallet.PendingBroadcastNotification) {
+
+	var buf bytes.Buffer
+	buf.Grow(n.Tx.SerializeSize())
+	if err := n.Tx.Serialize(&buf); err != nil {
+		log.Errorf("Unable to serialize transaction: %v", err)
+		return
+	}
+	outputs := make([]walletjson.PendingBroadcastOutput, len(n.Outputs))
+	for i, output := range n.Outputs {
+		outputs[i] = walletjson.PendingBroadcastOutput{
+			Address: output.Address,
+			Amount:  output.Amount.ToBTC(),
+			Token:   output.Token.String(),
+		}
+	}
+	ntfn := walletjson.NewPendingBroadcastNtfn(n.TxHash.String(),
+		hex.EncodeToString(buf.Bytes()), outputs, n.Annotations,
+		n.Replaceable, n.BroadcastAt.Unix())
+	sendNtfn(wsc, ntfn, n.Sequence)
+}
+
+// notifyKeyUsageAlerts subscribes a websocket client to the alerts raised when
+// the signing volume of an address or account deviates sharply from its
+// baseline.  Notifications are sent as keyusagealert notifications.
+func (s *Server) notifyKeyUsageAlerts(wsc *websocketClient) *btcjson.RPCError {
+	if wsc.keyUsage != nil {
+		return nil
+	}
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return &ErrUnloadedWallet
+	}
+
+	keyUsage := w.NtfnServer.KeyUsageNotifications()
+	wsc.keyUsage = &keyUsage
+	wsc.wg.Add(1)
+	go func() {
+		defer wsc.wg.Done()
+		for n := range keyUsage.C {
+			sendKeyUsageAlertNtfn(wsc, w, n)
+		}
+	}()
+	return nil
+}
+
+// sendKeyUsageAlertNtfn sends a keyusagealert notification to a websocket
+// client.
+func sendKeyUsageAlertNtfn(wsc *websocketClient, w *wallet.Wallet,
+	n *wallet.KeyUsageNotification) {
+
+	account, err := w.AccountName(waddrmgr.KeyScopeBIP0044, n.Account)
+	if err != nil {
+		account = strconv.FormatUint(uint64(n.Account), 10)
+	}
+	ntfn := walletjson.NewKeyUsageAlertNtfn(account, n.Address,
+		n.CurrentHour, n.Baseline)
+	sendNtfn(wsc, ntfn, n.Sequence)
+}
+
+// notifyConsolidations subscribes a websocket client to the consolidations of
+// change outputs and fragmented accounts, including those of the background
+// consolidation policies.  Notifications are sent as walletconsolidated
+// notifications.
+func (s *Server) notifyConsolidations(wsc *websocketClient) *btcjson.RPCError {
+	if wsc.consolidations != nil {
+		return nil
+	}
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return &ErrUnloadedWallet
+	}
+
+	consolidations := w.NtfnServer.ConsolidationNotifications()
+	wsc.consolidations = &consolidations
+	wsc.wg.Add(1)
+	go func() {
+		defer wsc.wg.Done()
+		for n := range consolidations.C {
+			sendConsolidatedNtfn(wsc, w, n)
+		}
+	}()
+	return nil
+}
+
+// sendConsolidatedNtfn sends a walletconsolidated notification to a websocket
+// client.
+func sendConsolidatedNtfn(wsc *websocketClient, w *wallet.Wallet,
+	n *wallet.ConsolidationNotification) {
+
+	account, err := w.AccountName(waddrmgr.KeyScopeBIP0044, n.Account)
+	if err != nil {
+		account = strconv.FormatUint(uint64(n.Account), 10)
+	}
+	var txID, address, errStr string
+	if n.Hash != nil {
+		txID = n.Hash.String()
+	}
+	if n.Address != nil {
+		address = n.Address.EncodeAddress()
+	}
+	if n.Err != nil {
+		errStr = n.Err.Error()
+	}
+	ntfn := walletjson.NewWalletConsolidatedNtfn(account, n.Token.String(),
+		len(n.Inputs), n.Amount.ToBTC(), n.Fee.ToBTC(), txID, address,
+		errStr)
+	sendNtfn(wsc, ntfn, n.Sequence)
+}
+
+// notifyReceived subscribes a websocket client to the outputs paying to the
+// external addresses of the wallet, notified when their transactions are
+// received and again when they are mined.  The first optional parameter of
+// the request blinds the notifications, which then omit the amounts of the
+// outputs, and the second restricts them to the outputs of the named
+// accounts.  Notifications of blinded clients are always blinded.
+// Notifications are sent as walletreceived notifications.
+func (s *Server) notifyReceived(wsc *websocketClient, req *btcjson.Request) *btcjson.RPCError {
+	blinded := wsc.blinded
+	if len(req.Params) > 0 {
+		var blindedParam bool
+		if err := json.Unmarshal(req.Params[0], &blindedParam); err != nil {
+			return &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "blinded parameter must be a boolean",
+			}
+		}
+		blinded = blinded || blindedParam
+	}
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return &ErrUnloadedWallet
+	}
+	var accounts map[uint32]struct{}
+	if len(req.Params) > 1 {
+		var jsonErr *btcjson.RPCError
+		accounts, jsonErr = accountFilter(w, req.Params[1])
+		if jsonErr != nil {
+			return jsonErr
+		}
+	}
+
+	// Subscribing again updates the blinding and the accounts of the
+	// notifications.
+	if wsc.received != nil {
+		wsc.received.Done()
+		wsc.received = nil
+	}
+
+	received := w.NtfnServer.TransactionNotifications()
+	wsc.received = &received
+	wsc.receivedBlinded = blinded
+	wsc.receivedAccounts = accounts
+	wsc.wg.Add(1)
+	go func() {
+		defer wsc.wg.Done()
+		for n := range received.C {
+			sendTransactionNtfns(wsc, w, n, blinded, accounts)
+		}
+	}()
+	return nil
+}
+
+// sendTransactionNtfns sends the walletreceived notifications of the unmined
+// and mined transactions of a transaction notification to a websocket client.
+func sendTransactionNtfns(wsc *websocketClient, w *wallet.Wallet,
+	n *wallet.TransactionNotifications, blinded bool,
+	accounts map[uint32]struct{}) {
+
+	for _, tx := range n.UnminedTransactions {
+		sendReceivedNtfns(wsc, w, &tx, -1, blinded, accounts,
+			n.Sequence)
+	}
+	for _, b := range n.AttachedBlocks {
+		for _, tx := range b.Transactions {
+			sendReceivedNtfns(wsc, w, &tx, b.Height, blinded,
+				accounts, n.Sequence)
+		}
+	}
+}
+
+// sendReceivedNtfns sends a walletreceived notification to a websocket client
+// for each output of a transaction paying to an external address of the
+// filtered accounts, with the payment reference of the address.  Blinded
+// notifications omit the amounts.
+func sendReceivedNtfns(wsc *websocketClient, w *wallet.Wallet,
+	tx *wallet.TransactionSummary, height int32, blinded bool,
+	accounts map[uint32]struct{}, seq uint64) {
+
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(tx.Transaction)); err != nil {
+		log.Errorf("Cannot deserialize transaction %v: %v", tx.Hash, err)
+		return
+	}
+	for _, output := range tx.MyOutputs {
+		if output.Internal || int(output.Index) >= len(msgTx.TxOut) ||
+			!accountFiltered(accounts, output.Account) {
+			continue
+		}
+		txOut := msgTx.TxOut[output.Index]
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+			txOut.PkScript, w.ChainParams(),
+		)
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+
+		var amount *float64
+		if !blinded {
+			btc := btcutil.Amount(txOut.Value).ToBTC()
+			amount = &btc
+		}
+		var reference *string
+		ref, err := w.AddressPaymentReference(addrs[0])
+		if err != nil {
+			log.Errorf("Unable to look up the payment reference of "+
+				"%v: %v", addrs[0], err)
+		} else if ref != "" {
+			reference = &ref
+		}
+		ntfn := walletjson.NewWalletReceivedNtfn(tx.Hash.String(),
+			output.Index, addrs[0].EncodeAddress(), height, amount,
+			reference)
+		sendNtfn(wsc, ntfn, seq)
+	}
+}
+
+// replayNotifications handles a replaynotifications request of a websocket
+// client reconnecting after the notification numbered by the sequence
+// parameter, by sending again the following notifications of the kinds the
+// client subscribed to, with its current filters.  The sequence of the last
+// replayed notification is returned.  Notifications sent while they are
+// replayed may precede replayed notifications, so clients order them by
+// sequence.  Clients must resync all state when the notifications are no
+// longer kept.
+func (s *Server) replayNotifications(wsc *websocketClient, req *btcjson.Request) (interface{}, *btcjson.RPCError) {
+	var seq uint64
+	if len(req.Params) == 0 || json.Unmarshal(req.Params[0], &seq) != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "sequence parameter must be a notification sequence number",
+		}
+	}
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return nil, &ErrUnloadedWallet
+	}
+
+	ntfns, err := w.NtfnServer.ReplayNotifications(seq)
+	if err == wallet.ErrNotificationsExpired {
+		return nil, &btcjson.RPCError{
+			Code: ErrRPCNotificationsExpired,
+			Message: fmt.Sprintf("notifications after sequence %d "+
+				"are no longer kept; resync all state", seq),
+		}
+	}
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
+		}
+	}
+	for _, n := range ntfns {
+		sendWalletNtfn(wsc, w, n)
+	}
+	return seq + uint64(len(ntfns)), nil
+}
+
+// sendWalletNtfn sends the notifications of a wallet notification to a
+// websocket client when it subscribed to the kind of the notification, with
+// its current filters, and returns whether it did.
+func sendWalletNtfn(wsc *websocketClient, w *wallet.Wallet, n interface{}) bool {
+	switch n := n.(type) {
+	case *wallet.TransactionNotifications:
+		if wsc.received == nil {
+			return false
+		}
+		sendTransactionNtfns(wsc, w, n, wsc.receivedBlinded,
+			wsc.receivedAccounts)
+	case *wallet.BlockNotification:
+		if wsc.blocks == nil {
+			return false
+		}
+		sendBlockNtfn(wsc, n)
+	case *wallet.BalanceNotification:
+		if wsc.balances == nil {
+			return false
+		}
+		sendBalanceNtfns(wsc, w, n, wsc.balanceAccounts)
+	case *wallet.LockStateNotification:
+		if wsc.lockState == nil {
+			return false
+		}
+		ntfn := btcjson.NewWalletLockStateNtfn(n.Locked)
+		sendNtfn(wsc, ntfn, n.Sequence)
+	case *wallet.AccountQuotaNotification:
+		if wsc.accountQuota == nil {
+			return false
+		}
+		ntfn := walletjson.NewAccountQuotaNtfn(n.AccountName,
+			n.Footprint, n.Quota)
+		sendNtfn(wsc, ntfn, n.Sequence)
+	case *wallet.UnlockFailureNotification:
+		if wsc.unlockFailures == nil {
+			return false
+		}
+		ntfn := walletjson.NewUnlockFailedNtfn(n.Failures,
+			int64(n.RetryAfter/time.Second), n.LockedOut)
+		sendNtfn(wsc, ntfn, n.Sequence)
+	case *wallet.PendingBroadcastNotification:
+		if wsc.pendingBroadcasts == nil {
+			return false
+		}
+		sendPendingBroadcastNtfn(wsc, n)
+	case *wallet.KeyUsageNotification:
+		if wsc.keyUsage == nil {
+			return false
+		}
+		sendKeyUsageAlertNtfn(wsc, w, n)
+	case *wallet.ConsolidationNotification:
+		if wsc.consolidations == nil {
+			return false
+		}
+		sendConsolidatedNtfn(wsc, w, n)
+	default:
+		return false
+	}
+	return true
+}
+
+// deliverQueuedNtfns sends to a websocket client the notifications queued by
+// the wallet while no client subscribed to them, of the kinds the client
+// subscribed to.  Queued notifications are delivered to the first client
+// subscribing to them.
+func (s *Server) deliverQueuedNtfns(wsc *websocketClient) {
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return
+	}
+	w.NtfnServer.DeliverQueuedNotifications(func(n interface{}) bool {
+		return sendWalletNtfn(wsc, w, n)
+	})
+}
+
+// sequencedNotification is a notification extended with the sequence number
+// of the wallet notification it is sent for.  Notifications sent for the same
+// wallet notification, such as the balances of several accounts, share its
+// sequence number.
+type sequencedNotification struct {
+	btcjson.Request
+	Sequence uint64 `json:"sequence"`
+}
+
+// sendNtfn sends a notification to a websocket client with the sequence number
+// of its wallet notification.  Failed sends are ignored so the notifications
+// are drained until the client is done.
+func sendNtfn(wsc *websocketClient, ntfn interface{}, seq uint64) {
+	mntfn, err := btcjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		log.Errorf("Unable to marshal notification: %v", err)
+		return
+	}
+	var req btcjson.Request
+	if err := json.Unmarshal(mntfn, &req); err != nil {
+		log.Errorf("Unable to marshal notification: %v", err)
+		return
+	}
+	mntfn, err = json.Marshal(&sequencedNotification{
+		Request:  req,
+		Sequence: seq,
+	})
+	if err != nil {
+		log.Errorf("Unable to marshal notification: %v", err)
+		return
+	}
+	_ = wsc.send(mntfn)
+}
+
+// accountFilter returns the numbers of the accounts named by a parameter of a
+// notification request, which restrict the notifications to these accounts.
+// A null or empty parameter returns a nil filter, which notifies every
+// account.
+func accountFilter(w *wallet.Wallet, param json.RawMessage) (map[uint32]struct{}, *btcjson.RPCError) {
+	var names []string
+	if err := json.Unmarshal(param, &names); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "accounts parameter must be an array of account names",
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	accounts := make(map[uint32]struct{}, len(names))
+	for _, name := range names {
+		account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, name)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCWalletInvalidAccountName,
+				Message: fmt.Sprintf("unknown account %q", name),
+			}
+		}
+		accounts[account] = struct{}{}
+	}
+	return accounts, nil
+}
+
+// accountFiltered returns whether the notifications of an account pass an
+// account filter.
+func accountFiltered(accounts map[uint32]struct{}, account uint32) bool {
+	if accounts == nil {
+		return true
+	}
+	_, ok := accounts[account]
+	return ok
+}
+
 func (s *Server) websocketClientSend(wsc *websocketClient) {
 	const deadline time.Duration = 2 * time.Second
 out:
@@ -517,8 +1813,7 @@ out:
 				log.Warnf("Cannot set write deadline on "+
 					"client %s: %v", wsc.remoteAddr, err)
 			}
-			err = wsc.conn.WriteMessage(websocket.TextMessage,
-				response)
+			err = writeFramed(wsc.conn, response, s.maxFrameSize)
 			if err != nil {
 				log.Warnf("Failed websocket send to client "+
 					"%s: %v", wsc.remoteAddr, err)
@@ -534,10 +1829,45 @@ out:
 	s.wg.Done()
 }
 
+// writeFramed writes a text message to a websocket connection in frames
+// carrying at most maxFrameSize bytes of payload, or in a single frame when
+// maxFrameSize is zero.  The message is compressed when compression was
+// negotiated with the client.
+func writeFramed(conn *websocket.Conn, msg []byte, maxFrameSize int) error {
+	if maxFrameSize <= 0 {
+		return conn.WriteMessage(websocket.TextMessage, msg)
+	}
+
+	// The writer sends a frame whenever its buffer, which is as large as
+	// the max frame size, is full.  Writes larger than the buffer would
+	// be sent as a single frame, so the message is written in chunks.
+	w, err := conn.NextWriter(websocket.TextMessage)
+	if err != nil {
+		return err
+	}
+	for len(msg) > 0 {
+		n := len(msg)
+		if n > maxFrameSize {
+			n = maxFrameSize
+		}
+		if _, err := w.Write(msg[:n]); err != nil {
+			w.Close()
+			return err
+		}
+		msg = msg[n:]
+	}
+	return w.Close()
+}
+
 // websocketClientRPC starts the goroutines to serve JSON-RPC requests over a
 // websocket connection for a single client.
 func (s *Server) websocketClientRPC(wsc *websocketClient) {
-	log.Infof("New websocket client %s", wsc.remoteAddr)
+	if wsc.apiToken != nil {
+		log.Infof("New websocket client %s with API token %q",
+			wsc.remoteAddr, wsc.apiToken.name)
+	} else {
+		log.Infof("New websocket client %s", wsc.remoteAddr)
+	}
 
 	// Clear the read deadline set before the websocket hijacked
 	// the connection.
@@ -562,8 +1892,12 @@ func (s *Server) websocketClientRPC(wsc *websocketClient) {
 // that may be read from a client.  This is currently limited to 4MB.
 const maxRequestSize = 1024 * 1024 * 4
 
-// postClientRPC processes and replies to a JSON-RPC client request.
-func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request) {
+// postClientRPC processes and replies to a JSON-RPC client request.  The
+// requests of clients authenticated by an API token are logged with the name
+// of the token.
+func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request,
+	token *apiTokenAuth) {
+
 	body := http.MaxBytesReader(w, r.Body, maxRequestSize)
 	rpcRequest, err := ioutil.ReadAll(body)
 	if err != nil {
@@ -595,9 +1929,15 @@ func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if token != nil {
+		log.Debugf("Request %s from client %s with API token %q",
+			req.Method, r.RemoteAddr, token.name)
+	}
+
 	// Create the response and error from the request.  Two special cases
 	// are handled for the authenticate and stop request methods.
 	var res interface{}
+	var seq *uint64
 	var jsonErr *btcjson.RPCError
 	var stop bool
 	switch req.Method {
@@ -608,11 +1948,12 @@ func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request) {
 		stop = true
 		res = "btcwallet stopping"
 	default:
-		res, jsonErr = s.handlerClosure(&req)()
+		f := s.handlerClosure(&req, parseRequestExtensions(rpcRequest))
+		res, seq, jsonErr = f()
 	}
 
 	// Marshal and send.
-	mresp, err := btcjson.MarshalResponse(req.ID, res, jsonErr)
+	mresp, err := marshalResponse(req.ID, res, seq, jsonErr)
 	if err != nil {
 		log.Errorf("Unable to marshal response: %v", err)
 		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
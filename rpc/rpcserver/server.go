@@ -21,6 +21,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/btcsuite/btcwallet/internal/taproot"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -353,7 +354,7 @@ func (s *walletServer) FundTransaction(ctx context.Context, req *pb.FundTransact
 		if err != nil {
 			return nil, translateError(err)
 		}
-		changeScript, err = txscript.PayToAddrScript(changeAddr)
+		changeScript, err = taproot.PayToAddrScript(changeAddr)
 		if err != nil {
 			return nil, translateError(err)
 		}
@@ -0,0 +1,13 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package walletjson provides the JSON-RPC command and result types of the
+wallet-specific extensions which are not part of the btcjson package.
+
+Each command is registered with btcjson when the package is initialized, so
+btcjson.UnmarshalCmd, btcjson.MarshalCmd and the help generation functions
+work for these commands exactly as they do for the btcjson commands.
+*/
+package walletjson
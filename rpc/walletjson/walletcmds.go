@@ -0,0 +1,1797 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package walletjson
+
+import "github.com/btcsuite/btcd/btcjson"
+
+// GetNewTaprootAddressCmd defines the getnewtaprootaddress JSON-RPC command.
+type GetNewTaprootAddressCmd struct {
+	Account *string
+}
+
+// NewGetNewTaprootAddressCmd returns a new instance which can be used to
+// issue a getnewtaprootaddress JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetNewTaprootAddressCmd(account *string) *GetNewTaprootAddressCmd {
+	return &GetNewTaprootAddressCmd{
+		Account: account,
+	}
+}
+
+// ImportWitnessScriptCmd defines the importwitnessscript JSON-RPC command.
+type ImportWitnessScriptCmd struct {
+	Script string
+}
+
+// NewImportWitnessScriptCmd returns a new instance which can be used to issue
+// an importwitnessscript JSON-RPC command.
+func NewImportWitnessScriptCmd(script string) *ImportWitnessScriptCmd {
+	return &ImportWitnessScriptCmd{
+		Script: script,
+	}
+}
+
+// BackupWalletCmd defines the backupwallet JSON-RPC command.
+type BackupWalletCmd struct {
+	Destination string
+}
+
+// NewBackupWalletCmd returns a new instance which can be used to issue a
+// backupwallet JSON-RPC command.
+func NewBackupWalletCmd(destination string) *BackupWalletCmd {
+	return &BackupWalletCmd{
+		Destination: destination,
+	}
+}
+
+// TravelRuleParty describes the originator or beneficiary of a transfer for
+// the settravelrule JSON-RPC command and the travel rule metadata of sends.
+type TravelRuleParty struct {
+	FirstName      string `json:"firstname,omitempty"`
+	LastName       string `json:"lastname,omitempty"`
+	LegalName      string `json:"legalname,omitempty"`
+	StreetName     string `json:"streetname,omitempty"`
+	BuildingNumber string `json:"buildingnumber,omitempty"`
+	PostCode       string `json:"postcode,omitempty"`
+	TownName       string `json:"townname,omitempty"`
+	Country        string `json:"country,omitempty"`
+	NationalID     string `json:"nationalid,omitempty"`
+	NationalIDType string `json:"nationalidtype,omitempty"`
+	DateOfBirth    string `json:"dateofbirth,omitempty"`
+	PlaceOfBirth   string `json:"placeofbirth,omitempty"`
+	AccountNumber  string `json:"accountnumber,omitempty"`
+}
+
+// TravelRule is the travel rule metadata optionally attached to the
+// transaction of a sendfrom, sendmany or sendtoaddress JSON-RPC command.
+type TravelRule struct {
+	Originator      TravelRuleParty `json:"originator"`
+	Beneficiary     TravelRuleParty `json:"beneficiary"`
+	OriginatingVASP *string         `json:"originatingvasp,omitempty"`
+	BeneficiaryVASP *string         `json:"beneficiaryvasp,omitempty"`
+}
+
+// SetTravelRuleCmd defines the settravelrule JSON-RPC command.
+type SetTravelRuleCmd struct {
+	TxID            string
+	Originator      TravelRuleParty
+	Beneficiary     TravelRuleParty
+	OriginatingVASP *string
+	BeneficiaryVASP *string
+}
+
+// NewSetTravelRuleCmd returns a new instance which can be used to issue a
+// settravelrule JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSetTravelRuleCmd(txID string, originator, beneficiary TravelRuleParty,
+	originatingVASP, beneficiaryVASP *string) *SetTravelRuleCmd {
+
+	return &SetTravelRuleCmd{
+		TxID:            txID,
+		Originator:      originator,
+		Beneficiary:     beneficiary,
+		OriginatingVASP: originatingVASP,
+		BeneficiaryVASP: beneficiaryVASP,
+	}
+}
+
+// ExportTravelRuleCmd defines the exporttravelrule JSON-RPC command.
+type ExportTravelRuleCmd struct {
+	TxID *string
+}
+
+// NewExportTravelRuleCmd returns a new instance which can be used to issue an
+// exporttravelrule JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewExportTravelRuleCmd(txID *string) *ExportTravelRuleCmd {
+	return &ExportTravelRuleCmd{
+		TxID: txID,
+	}
+}
+
+// WalletCreateFundedPsbtCmd defines the walletcreatefundedpsbt JSON-RPC
+// command.
+type WalletCreateFundedPsbtCmd struct {
+	Amounts        map[string]float64 `jsonrpcusage:"{\"address\":amount,...}"` // In BTC
+	FromAccount    *string
+	MinConf        *int
+	Token          *string
+	CoinSelection  *string
+	Replaceable    *bool
+	ConfTarget     *int
+	FeeRate        *float64 // In satoshis per virtual byte
+	LockTime       *int64
+	OverrideFeeCap *bool
+}
+
+// NewWalletCreateFundedPsbtCmd returns a new instance which can be used to
+// issue a walletcreatefundedpsbt JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewWalletCreateFundedPsbtCmd(amounts map[string]float64, fromAccount *string,
+	minConf *int, token *string, coinSelection *string,
+	replaceable *bool, confTarget *int, feeRate *float64,
+	lockTime *int64, overrideFeeCap *bool) *WalletCreateFundedPsbtCmd {
+
+	return &WalletCreateFundedPsbtCmd{
+		Amounts:        amounts,
+		FromAccount:    fromAccount,
+		MinConf:        minConf,
+		Token:          token,
+		CoinSelection:  coinSelection,
+		Replaceable:    replaceable,
+		ConfTarget:     confTarget,
+		FeeRate:        feeRate,
+		LockTime:       lockTime,
+		OverrideFeeCap: overrideFeeCap,
+	}
+}
+
+// WalletProcessPsbtCmd defines the walletprocesspsbt JSON-RPC command.
+type WalletProcessPsbtCmd struct {
+	Psbt        string
+	Sign        *bool
+	SigHashType *string
+}
+
+// NewWalletProcessPsbtCmd returns a new instance which can be used to issue a
+// walletprocesspsbt JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewWalletProcessPsbtCmd(psbt string, sign *bool,
+	sigHashType *string) *WalletProcessPsbtCmd {
+
+	return &WalletProcessPsbtCmd{
+		Psbt:        psbt,
+		Sign:        sign,
+		SigHashType: sigHashType,
+	}
+}
+
+// FinalizePsbtCmd defines the finalizepsbt JSON-RPC command.
+type FinalizePsbtCmd struct {
+	Psbt    string
+	Extract *bool
+}
+
+// NewFinalizePsbtCmd returns a new instance which can be used to issue a
+// finalizepsbt JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewFinalizePsbtCmd(psbt string, extract *bool) *FinalizePsbtCmd {
+	return &FinalizePsbtCmd{
+		Psbt:    psbt,
+		Extract: extract,
+	}
+}
+
+// ExportPsbtCmd defines the exportpsbt JSON-RPC command.
+type ExportPsbtCmd struct {
+	Psbt      string
+	File      *string
+	QRPartLen *int
+}
+
+// NewExportPsbtCmd returns a new instance which can be used to issue an
+// exportpsbt JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewExportPsbtCmd(psbt string, file *string, qrPartLen *int) *ExportPsbtCmd {
+	return &ExportPsbtCmd{
+		Psbt:      psbt,
+		File:      file,
+		QRPartLen: qrPartLen,
+	}
+}
+
+// ImportSignedTxCmd defines the importsignedtx JSON-RPC command.
+type ImportSignedTxCmd struct {
+	Parts []string
+	File  *string
+}
+
+// NewImportSignedTxCmd returns a new instance which can be used to issue an
+// importsignedtx JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewImportSignedTxCmd(parts []string, file *string) *ImportSignedTxCmd {
+	return &ImportSignedTxCmd{
+		Parts: parts,
+		File:  file,
+	}
+}
+
+// GetAggregateBalanceCmd defines the getaggregatebalance JSON-RPC command.
+type GetAggregateBalanceCmd struct {
+	Accounts *[]string
+	MinConf  *int
+	Token    *string
+}
+
+// NewGetAggregateBalanceCmd returns a new instance which can be used to issue
+// a getaggregatebalance JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetAggregateBalanceCmd(accounts *[]string, minConf *int,
+	token *string) *GetAggregateBalanceCmd {
+
+	return &GetAggregateBalanceCmd{
+		Accounts: accounts,
+		MinConf:  minConf,
+		Token:    token,
+	}
+}
+
+// SweepPrivKeyCmd defines the sweepprivkey JSON-RPC command.
+type SweepPrivKeyCmd struct {
+	PrivKey     string
+	Account     *string
+	StartHeight *int32
+	Token       *string
+	Pool        *string
+}
+
+// NewSweepPrivKeyCmd returns a new instance which can be used to issue a
+// sweepprivkey JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSweepPrivKeyCmd(privKey string, account *string, startHeight *int32,
+	token, pool *string) *SweepPrivKeyCmd {
+
+	return &SweepPrivKeyCmd{
+		PrivKey:     privKey,
+		Account:     account,
+		StartHeight: startHeight,
+		Token:       token,
+		Pool:        pool,
+	}
+}
+
+// CreateAccountWithPathCmd defines the createaccountwithpath JSON-RPC command.
+type CreateAccountWithPathCmd struct {
+	Account string
+	Path    string
+}
+
+// NewCreateAccountWithPathCmd returns a new instance which can be used to
+// issue a createaccountwithpath JSON-RPC command.
+func NewCreateAccountWithPathCmd(account, path string) *CreateAccountWithPathCmd {
+	return &CreateAccountWithPathCmd{
+		Account: account,
+		Path:    path,
+	}
+}
+
+// ReserveAddressIndexesCmd defines the reserveaddressindexes JSON-RPC
+// command.
+type ReserveAddressIndexesCmd struct {
+	Account string
+	Count   uint32
+}
+
+// NewReserveAddressIndexesCmd returns a new instance which can be used to
+// issue a reserveaddressindexes JSON-RPC command.
+func NewReserveAddressIndexesCmd(account string, count uint32) *ReserveAddressIndexesCmd {
+	return &ReserveAddressIndexesCmd{
+		Account: account,
+		Count:   count,
+	}
+}
+
+// ImportMultiRequest describes a private key, address or witness script
+// imported by the importmulti JSON-RPC command.
+type ImportMultiRequest struct {
+	PrivKey   *string `json:"privkey,omitempty"`
+	Address   *string `json:"address,omitempty"`
+	Script    *string `json:"script,omitempty"`
+	Account   *string `json:"account,omitempty"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// ImportMultiCmd defines the importmulti JSON-RPC command.
+type ImportMultiCmd struct {
+	Requests []ImportMultiRequest
+	Rescan   *bool
+}
+
+// NewImportMultiCmd returns a new instance which can be used to issue an
+// importmulti JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewImportMultiCmd(requests []ImportMultiRequest, rescan *bool) *ImportMultiCmd {
+	return &ImportMultiCmd{
+		Requests: requests,
+		Rescan:   rescan,
+	}
+}
+
+// ImportPrivKeysCmd defines the importprivkeys JSON-RPC command.
+type ImportPrivKeysCmd struct {
+	PrivKeys  []string
+	Timestamp *int64 `jsonrpcdefault:"0"`
+	Rescan    *bool  `jsonrpcdefault:"true"`
+}
+
+// NewImportPrivKeysCmd returns a new instance which can be used to issue an
+// importprivkeys JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewImportPrivKeysCmd(privKeys []string, timestamp *int64, rescan *bool) *ImportPrivKeysCmd {
+	return &ImportPrivKeysCmd{
+		PrivKeys:  privKeys,
+		Timestamp: timestamp,
+		Rescan:    rescan,
+	}
+}
+
+// ExportLedgerCmd defines the exportledger JSON-RPC command.
+type ExportLedgerCmd struct {
+	Format      string
+	StartHeight *int32
+	EndHeight   *int32
+}
+
+// NewExportLedgerCmd returns a new instance which can be used to issue an
+// exportledger JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewExportLedgerCmd(format string, startHeight, endHeight *int32) *ExportLedgerCmd {
+	return &ExportLedgerCmd{
+		Format:      format,
+		StartHeight: startHeight,
+		EndHeight:   endHeight,
+	}
+}
+
+// GetAddressUsageCmd defines the getaddressusage JSON-RPC command.
+type GetAddressUsageCmd struct {
+	Address string
+}
+
+// NewGetAddressUsageCmd returns a new instance which can be used to issue a
+// getaddressusage JSON-RPC command.
+func NewGetAddressUsageCmd(address string) *GetAddressUsageCmd {
+	return &GetAddressUsageCmd{
+		Address: address,
+	}
+}
+
+// GetUnlockTimeoutCmd defines the getunlocktimeout JSON-RPC command.
+type GetUnlockTimeoutCmd struct{}
+
+// NewGetUnlockTimeoutCmd returns a new instance which can be used to issue a
+// getunlocktimeout JSON-RPC command.
+func NewGetUnlockTimeoutCmd() *GetUnlockTimeoutCmd {
+	return &GetUnlockTimeoutCmd{}
+}
+
+// WalletPassphraseAccountMethod is the method the walletpassphrase command
+// extended with an account is registered with, since btcjson registers the
+// walletpassphrase method.  The wallet server unmarshals walletpassphrase
+// requests as WalletPassphraseCmd.
+const WalletPassphraseAccountMethod = "walletpassphraseaccount"
+
+// WalletPassphraseCmd defines the walletpassphrase JSON-RPC command, extended
+// with an optional account protected by its own passphrase to unlock instead
+// of the wallet.
+type WalletPassphraseCmd struct {
+	Passphrase string
+	Timeout    int64
+	Account    *string
+}
+
+// NewWalletPassphraseCmd returns a new instance which can be used to issue a
+// walletpassphrase JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewWalletPassphraseCmd(passphrase string, timeout int64, account *string) *WalletPassphraseCmd {
+	return &WalletPassphraseCmd{
+		Passphrase: passphrase,
+		Timeout:    timeout,
+		Account:    account,
+	}
+}
+
+// SetAccountPassphraseCmd defines the setaccountpassphrase JSON-RPC command.
+type SetAccountPassphraseCmd struct {
+	Account    string
+	Passphrase string
+}
+
+// NewSetAccountPassphraseCmd returns a new instance which can be used to
+// issue a setaccountpassphrase JSON-RPC command.
+func NewSetAccountPassphraseCmd(account, passphrase string) *SetAccountPassphraseCmd {
+	return &SetAccountPassphraseCmd{
+		Account:    account,
+		Passphrase: passphrase,
+	}
+}
+
+// GetOperationSequenceCmd defines the getoperationsequence JSON-RPC command.
+type GetOperationSequenceCmd struct{}
+
+// NewGetOperationSequenceCmd returns a new instance which can be used to
+// issue a getoperationsequence JSON-RPC command.
+func NewGetOperationSequenceCmd() *GetOperationSequenceCmd {
+	return &GetOperationSequenceCmd{}
+}
+
+// GetAccountFootprintCmd defines the getaccountfootprint JSON-RPC command.
+type GetAccountFootprintCmd struct {
+	Account *string
+}
+
+// NewGetAccountFootprintCmd returns a new instance which can be used to issue
+// a getaccountfootprint JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetAccountFootprintCmd(account *string) *GetAccountFootprintCmd {
+	return &GetAccountFootprintCmd{
+		Account: account,
+	}
+}
+
+// GetAuditLogCmd defines the getauditlog JSON-RPC command.
+type GetAuditLogCmd struct {
+	From  *uint64 `jsonrpcdefault:"1"`
+	Count *int    `jsonrpcdefault:"100"`
+}
+
+// NewGetAuditLogCmd returns a new instance which can be used to issue a
+// getauditlog JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetAuditLogCmd(from *uint64, count *int) *GetAuditLogCmd {
+	return &GetAuditLogCmd{
+		From:  from,
+		Count: count,
+	}
+}
+
+// GetStateCommitmentCmd defines the getstatecommitment JSON-RPC command.
+type GetStateCommitmentCmd struct {
+	Height *int32
+}
+
+// NewGetStateCommitmentCmd returns a new instance which can be used to issue a
+// getstatecommitment JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetStateCommitmentCmd(height *int32) *GetStateCommitmentCmd {
+	return &GetStateCommitmentCmd{
+		Height: height,
+	}
+}
+
+// GetProofOfReservesCmd defines the getproofofreserves JSON-RPC command.
+type GetProofOfReservesCmd struct {
+	Challenge string
+}
+
+// NewGetProofOfReservesCmd returns a new instance which can be used to issue a
+// getproofofreserves JSON-RPC command.
+func NewGetProofOfReservesCmd(challenge string) *GetProofOfReservesCmd {
+	return &GetProofOfReservesCmd{
+		Challenge: challenge,
+	}
+}
+
+// EnrollTOTPCmd defines the enrolltotp JSON-RPC command.
+type EnrollTOTPCmd struct{}
+
+// NewEnrollTOTPCmd returns a new instance which can be used to issue an
+// enrolltotp JSON-RPC command.
+func NewEnrollTOTPCmd() *EnrollTOTPCmd {
+	return &EnrollTOTPCmd{}
+}
+
+// DisableTOTPCmd defines the disabletotp JSON-RPC command.
+type DisableTOTPCmd struct{}
+
+// NewDisableTOTPCmd returns a new instance which can be used to issue a
+// disabletotp JSON-RPC command.
+func NewDisableTOTPCmd() *DisableTOTPCmd {
+	return &DisableTOTPCmd{}
+}
+
+// SignMessageBIP322Cmd defines the signmessagebip322 JSON-RPC command.
+type SignMessageBIP322Cmd struct {
+	Address string
+	Message string
+}
+
+// NewSignMessageBIP322Cmd returns a new instance which can be used to issue a
+// signmessagebip322 JSON-RPC command.
+func NewSignMessageBIP322Cmd(address, message string) *SignMessageBIP322Cmd {
+	return &SignMessageBIP322Cmd{
+		Address: address,
+		Message: message,
+	}
+}
+
+// PinAddressPoolCmd defines the pinaddresspool JSON-RPC command.
+type PinAddressPoolCmd struct {
+	Name       string
+	Descriptor string
+	Window     *uint32 `jsonrpcdefault:"20"`
+	Accounts   *[]string
+}
+
+// NewPinAddressPoolCmd returns a new instance which can be used to issue a
+// pinaddresspool JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewPinAddressPoolCmd(name, descriptor string, window *uint32,
+	accounts *[]string) *PinAddressPoolCmd {
+
+	return &PinAddressPoolCmd{
+		Name:       name,
+		Descriptor: descriptor,
+		Window:     window,
+		Accounts:   accounts,
+	}
+}
+
+// UnpinAddressPoolCmd defines the unpinaddresspool JSON-RPC command.
+type UnpinAddressPoolCmd struct {
+	Name string
+}
+
+// NewUnpinAddressPoolCmd returns a new instance which can be used to issue an
+// unpinaddresspool JSON-RPC command.
+func NewUnpinAddressPoolCmd(name string) *UnpinAddressPoolCmd {
+	return &UnpinAddressPoolCmd{
+		Name: name,
+	}
+}
+
+// ListAddressPoolsCmd defines the listaddresspools JSON-RPC command.
+type ListAddressPoolsCmd struct{}
+
+// NewListAddressPoolsCmd returns a new instance which can be used to issue a
+// listaddresspools JSON-RPC command.
+func NewListAddressPoolsCmd() *ListAddressPoolsCmd {
+	return &ListAddressPoolsCmd{}
+}
+
+// GetPoolAddressCmd defines the getpooladdress JSON-RPC command.
+type GetPoolAddressCmd struct {
+	Name string
+}
+
+// NewGetPoolAddressCmd returns a new instance which can be used to issue a
+// getpooladdress JSON-RPC command.
+func NewGetPoolAddressCmd(name string) *GetPoolAddressCmd {
+	return &GetPoolAddressCmd{
+		Name: name,
+	}
+}
+
+// ConsolidateChangeCmd defines the consolidatechange JSON-RPC command.
+type ConsolidateChangeCmd struct {
+	FeeRate   *float64
+	MaxInputs *int  `jsonrpcdefault:"100"`
+	DryRun    *bool `jsonrpcdefault:"false"`
+}
+
+// NewConsolidateChangeCmd returns a new instance which can be used to issue a
+// consolidatechange JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewConsolidateChangeCmd(feeRate *float64, maxInputs *int,
+	dryRun *bool) *ConsolidateChangeCmd {
+
+	return &ConsolidateChangeCmd{
+		FeeRate:   feeRate,
+		MaxInputs: maxInputs,
+		DryRun:    dryRun,
+	}
+}
+
+// GetConsolidationReportCmd defines the getconsolidationreport JSON-RPC
+// command.
+type GetConsolidationReportCmd struct{}
+
+// NewGetConsolidationReportCmd returns a new instance which can be used to
+// issue a getconsolidationreport JSON-RPC command.
+func NewGetConsolidationReportCmd() *GetConsolidationReportCmd {
+	return &GetConsolidationReportCmd{}
+}
+
+// SweepAllCmd defines the sweepall JSON-RPC command.
+type SweepAllCmd struct {
+	FromAccount  string
+	Destinations map[string]float64 `jsonrpcusage:"{\"address\":percent,...}"`
+	Addresses    *[]string
+	Token        *string
+	MinConf      *int     `jsonrpcdefault:"1"`
+	FeeRate      *float64 // In satoshis per virtual byte
+}
+
+// NewSweepAllCmd returns a new instance which can be used to issue a sweepall
+// JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSweepAllCmd(fromAccount string, destinations map[string]float64,
+	addresses *[]string, token *string, minConf *int,
+	feeRate *float64) *SweepAllCmd {
+
+	return &SweepAllCmd{
+		FromAccount:  fromAccount,
+		Destinations: destinations,
+		Addresses:    addresses,
+		Token:        token,
+		MinConf:      minConf,
+		FeeRate:      feeRate,
+	}
+}
+
+// SendWithInputsCmd defines the sendwithinputs JSON-RPC command.
+type SendWithInputsCmd struct {
+	FromAccount    string
+	Amounts        map[string]float64 `jsonrpcusage:"{\"address\":amount,...}"` // In BTC
+	Inputs         []btcjson.TransactionInput
+	Token          *string
+	MinConf        *int `jsonrpcdefault:"1"`
+	Replaceable    *bool
+	ConfTarget     *int
+	FeeRate        *float64 // In satoshis per virtual byte
+	OverrideFeeCap *bool
+}
+
+// NewSendWithInputsCmd returns a new instance which can be used to issue a
+// sendwithinputs JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSendWithInputsCmd(fromAccount string, amounts map[string]float64,
+	inputs []btcjson.TransactionInput, token *string, minConf *int,
+	replaceable *bool, confTarget *int, feeRate *float64,
+	overrideFeeCap *bool) *SendWithInputsCmd {
+
+	return &SendWithInputsCmd{
+		FromAccount:    fromAccount,
+		Amounts:        amounts,
+		Inputs:         inputs,
+		Token:          token,
+		MinConf:        minConf,
+		Replaceable:    replaceable,
+		ConfTarget:     confTarget,
+		FeeRate:        feeRate,
+		OverrideFeeCap: overrideFeeCap,
+	}
+}
+
+// CancelBroadcastCmd defines the cancelbroadcast JSON-RPC command.
+type CancelBroadcastCmd struct {
+	TxID string
+}
+
+// NewCancelBroadcastCmd returns a new instance which can be used to issue a
+// cancelbroadcast JSON-RPC command.
+func NewCancelBroadcastCmd(txID string) *CancelBroadcastCmd {
+	return &CancelBroadcastCmd{
+		TxID: txID,
+	}
+}
+
+// ListUnspentFilteredCmd defines the listunspentfiltered JSON-RPC command.
+type ListUnspentFilteredCmd struct {
+	MinConf       *int `jsonrpcdefault:"1"`
+	MaxConf       *int `jsonrpcdefault:"9999999"`
+	Addresses     *[]string
+	Token         *string
+	MinimumAmount *float64 `jsonrpcdefault:"0"`
+}
+
+// NewListUnspentFilteredCmd returns a new instance which can be used to issue
+// a listunspentfiltered JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewListUnspentFilteredCmd(minConf, maxConf *int, addresses *[]string,
+	token *string, minimumAmount *float64) *ListUnspentFilteredCmd {
+
+	return &ListUnspentFilteredCmd{
+		MinConf:       minConf,
+		MaxConf:       maxConf,
+		Addresses:     addresses,
+		Token:         token,
+		MinimumAmount: minimumAmount,
+	}
+}
+
+// GetDiagnosticsCmd defines the getdiagnostics JSON-RPC command.
+type GetDiagnosticsCmd struct{}
+
+// NewGetDiagnosticsCmd returns a new instance which can be used to issue a
+// getdiagnostics JSON-RPC command.
+func NewGetDiagnosticsCmd() *GetDiagnosticsCmd {
+	return &GetDiagnosticsCmd{}
+}
+
+// SetAccountMetadataCmd defines the setaccountmetadata JSON-RPC command.
+type SetAccountMetadataCmd struct {
+	Account string
+	Key     string
+	Value   string
+}
+
+// NewSetAccountMetadataCmd returns a new instance which can be used to issue
+// a setaccountmetadata JSON-RPC command.
+func NewSetAccountMetadataCmd(account, key, value string) *SetAccountMetadataCmd {
+	return &SetAccountMetadataCmd{
+		Account: account,
+		Key:     key,
+		Value:   value,
+	}
+}
+
+// GetAccountMetadataCmd defines the getaccountmetadata JSON-RPC command.
+type GetAccountMetadataCmd struct {
+	Account string
+}
+
+// NewGetAccountMetadataCmd returns a new instance which can be used to issue
+// a getaccountmetadata JSON-RPC command.
+func NewGetAccountMetadataCmd(account string) *GetAccountMetadataCmd {
+	return &GetAccountMetadataCmd{
+		Account: account,
+	}
+}
+
+// AnchorAccountMetadataCmd defines the anchoraccountmetadata JSON-RPC
+// command.
+type AnchorAccountMetadataCmd struct {
+	Account string
+}
+
+// NewAnchorAccountMetadataCmd returns a new instance which can be used to
+// issue an anchoraccountmetadata JSON-RPC command.
+func NewAnchorAccountMetadataCmd(account string) *AnchorAccountMetadataCmd {
+	return &AnchorAccountMetadataCmd{
+		Account: account,
+	}
+}
+
+// BumpFeeCmd defines the bumpfee JSON-RPC command.
+type BumpFeeCmd struct {
+	TxID    string
+	FeeRate *float64 // In satoshis per virtual byte
+}
+
+// NewBumpFeeCmd returns a new instance which can be used to issue a bumpfee
+// JSON-RPC command.
+func NewBumpFeeCmd(txID string, feeRate *float64) *BumpFeeCmd {
+	return &BumpFeeCmd{
+		TxID:    txID,
+		FeeRate: feeRate,
+	}
+}
+
+// BumpFeeCPFPCmd defines the bumpfeecpfp JSON-RPC command.
+type BumpFeeCPFPCmd struct {
+	TxID    string
+	FeeRate *float64 // In satoshis per virtual byte
+}
+
+// NewBumpFeeCPFPCmd returns a new instance which can be used to issue a
+// bumpfeecpfp JSON-RPC command.
+func NewBumpFeeCPFPCmd(txID string, feeRate *float64) *BumpFeeCPFPCmd {
+	return &BumpFeeCPFPCmd{
+		TxID:    txID,
+		FeeRate: feeRate,
+	}
+}
+
+// SendManySubtractFeeMethod is the method the sendmany command extended with
+// recipients paying the fee is registered with, since btcjson registers the
+// sendmany method.  The wallet server unmarshals sendmany requests as
+// SendManyCmd.
+const SendManySubtractFeeMethod = "sendmanysubtractfee"
+
+// SendManyCmd defines the sendmany JSON-RPC command, extended with the
+// addresses of the recipients paying the fee of the transaction in proportion
+// to their amounts, with a hex-encoded data payload embedded in an OP_RETURN
+// output, and with travel rule metadata.
+type SendManyCmd struct {
+	FromAccount     string
+	Amounts         map[string]float64 `jsonrpcusage:"{\"address\":amount,...}"` // In BTC
+	MinConf         *int               `jsonrpcdefault:"1"`
+	Comment         *string
+	Token           *string
+	SubtractFeeFrom *[]string
+	Data            *string
+	ChangeAddress   *string
+	ChangeAccount   *string
+	OverrideFeeCap  *bool
+	TravelRule      *TravelRule
+}
+
+// NewSendManyCmd returns a new instance which can be used to issue a sendmany
+// JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSendManyCmd(fromAccount string, amounts map[string]float64,
+	minConf *int, comment *string, token *string,
+	subtractFeeFrom *[]string, data, changeAddress,
+	changeAccount *string, overrideFeeCap *bool,
+	travelRule *TravelRule) *SendManyCmd {
+
+	return &SendManyCmd{
+		FromAccount:     fromAccount,
+		Amounts:         amounts,
+		MinConf:         minConf,
+		Comment:         comment,
+		Token:           token,
+		SubtractFeeFrom: subtractFeeFrom,
+		Data:            data,
+		ChangeAddress:   changeAddress,
+		ChangeAccount:   changeAccount,
+		OverrideFeeCap:  overrideFeeCap,
+		TravelRule:      travelRule,
+	}
+}
+
+// SendToAddressDataMethod is the method the sendtoaddress command extended
+// with a data payload is registered with, since btcjson registers the
+// sendtoaddress method.  The wallet server unmarshals sendtoaddress requests
+// as SendToAddressCmd.
+const SendToAddressDataMethod = "sendtoaddressdata"
+
+// SendToAddressCmd defines the sendtoaddress JSON-RPC command, extended with a
+// hex-encoded data payload embedded in an OP_RETURN output, and with travel
+// rule metadata.
+type SendToAddressCmd struct {
+	Address        string
+	Amount         float64 // In BTC
+	Comment        *string
+	CommentTo      *string
+	Token          *string
+	Data           *string
+	OverrideFeeCap *bool
+	TravelRule     *TravelRule
+}
+
+// NewSendToAddressCmd returns a new instance which can be used to issue a
+// sendtoaddress JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSendToAddressCmd(address string, amount float64, comment,
+	commentTo, token, data *string, overrideFeeCap *bool,
+	travelRule *TravelRule) *SendToAddressCmd {
+
+	return &SendToAddressCmd{
+		Address:        address,
+		Amount:         amount,
+		Comment:        comment,
+		CommentTo:      commentTo,
+		Token:          token,
+		Data:           data,
+		OverrideFeeCap: overrideFeeCap,
+		TravelRule:     travelRule,
+	}
+}
+
+// SendFromTravelRuleMethod is the method the sendfrom command extended with
+// travel rule metadata is registered with, since btcjson registers the
+// sendfrom method.  The wallet server unmarshals sendfrom requests as
+// SendFromCmd.
+const SendFromTravelRuleMethod = "sendfromtravelrule"
+
+// SendFromCmd defines the sendfrom JSON-RPC command, extended with travel
+// rule metadata.
+type SendFromCmd struct {
+	FromAccount string
+	ToAddress   string
+	Amount      float64 // In BTC
+	MinConf     *int    `jsonrpcdefault:"1"`
+	Comment     *string
+	CommentTo   *string
+	Token       *string
+	TravelRule  *TravelRule
+}
+
+// NewSendFromCmd returns a new instance which can be used to issue a sendfrom
+// JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSendFromCmd(fromAccount, toAddress string, amount float64,
+	minConf *int, comment, commentTo, token *string,
+	travelRule *TravelRule) *SendFromCmd {
+
+	return &SendFromCmd{
+		FromAccount: fromAccount,
+		ToAddress:   toAddress,
+		Amount:      amount,
+		MinConf:     minConf,
+		Comment:     comment,
+		CommentTo:   commentTo,
+		Token:       token,
+		TravelRule:  travelRule,
+	}
+}
+
+// GetKeyUsageCmd defines the getkeyusage JSON-RPC command.
+type GetKeyUsageCmd struct {
+	Account *string
+}
+
+// NewGetKeyUsageCmd returns a new instance which can be used to issue a
+// getkeyusage JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetKeyUsageCmd(account *string) *GetKeyUsageCmd {
+	return &GetKeyUsageCmd{
+		Account: account,
+	}
+}
+
+// QueuePaymentCmd defines the queuepayment JSON-RPC command.
+type QueuePaymentCmd struct {
+	FromAccount string
+	Address     string
+	Amount      float64 // In BTC
+	Token       *string
+}
+
+// NewQueuePaymentCmd returns a new instance which can be used to issue a
+// queuepayment JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewQueuePaymentCmd(fromAccount, address string, amount float64,
+	token *string) *QueuePaymentCmd {
+
+	return &QueuePaymentCmd{
+		FromAccount: fromAccount,
+		Address:     address,
+		Amount:      amount,
+		Token:       token,
+	}
+}
+
+// GetQueuedPaymentCmd defines the getqueuedpayment JSON-RPC command.
+type GetQueuedPaymentCmd struct {
+	ID uint64
+}
+
+// NewGetQueuedPaymentCmd returns a new instance which can be used to issue a
+// getqueuedpayment JSON-RPC command.
+func NewGetQueuedPaymentCmd(id uint64) *GetQueuedPaymentCmd {
+	return &GetQueuedPaymentCmd{
+		ID: id,
+	}
+}
+
+// ListQueuedPaymentsCmd defines the listqueuedpayments JSON-RPC command.
+type ListQueuedPaymentsCmd struct{}
+
+// NewListQueuedPaymentsCmd returns a new instance which can be used to issue
+// a listqueuedpayments JSON-RPC command.
+func NewListQueuedPaymentsCmd() *ListQueuedPaymentsCmd {
+	return &ListQueuedPaymentsCmd{}
+}
+
+// CancelQueuedPaymentCmd defines the cancelqueuedpayment JSON-RPC command.
+type CancelQueuedPaymentCmd struct {
+	ID uint64
+}
+
+// NewCancelQueuedPaymentCmd returns a new instance which can be used to issue
+// a cancelqueuedpayment JSON-RPC command.
+func NewCancelQueuedPaymentCmd(id uint64) *CancelQueuedPaymentCmd {
+	return &CancelQueuedPaymentCmd{
+		ID: id,
+	}
+}
+
+// SendQueuedPaymentsCmd defines the sendqueuedpayments JSON-RPC command.
+type SendQueuedPaymentsCmd struct{}
+
+// NewSendQueuedPaymentsCmd returns a new instance which can be used to issue
+// a sendqueuedpayments JSON-RPC command.
+func NewSendQueuedPaymentsCmd() *SendQueuedPaymentsCmd {
+	return &SendQueuedPaymentsCmd{}
+}
+
+// SetTransactionLabelCmd defines the settransactionlabel JSON-RPC command.
+type SetTransactionLabelCmd struct {
+	TxID  string
+	Label string
+}
+
+// NewSetTransactionLabelCmd returns a new instance which can be used to issue
+// a settransactionlabel JSON-RPC command.
+func NewSetTransactionLabelCmd(txID, label string) *SetTransactionLabelCmd {
+	return &SetTransactionLabelCmd{
+		TxID:  txID,
+		Label: label,
+	}
+}
+
+// GetSpendingReportCmd defines the getspendingreport JSON-RPC command.
+type GetSpendingReportCmd struct {
+	StartTime int64
+	EndTime   int64
+	Account   *string
+	Token     *string
+}
+
+// NewGetSpendingReportCmd returns a new instance which can be used to issue a
+// getspendingreport JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetSpendingReportCmd(startTime, endTime int64, account *string,
+	token *string) *GetSpendingReportCmd {
+
+	return &GetSpendingReportCmd{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Account:   account,
+		Token:     token,
+	}
+}
+
+// FundRawTransactionOpts are the options of the fundrawtransaction JSON-RPC
+// command.
+type FundRawTransactionOpts struct {
+	FromAccount            *string  `json:"fromaccount,omitempty"`
+	MinConf                *int     `json:"minconf,omitempty"`
+	ChangePosition         *int     `json:"changePosition,omitempty"`
+	LockUnspents           *bool    `json:"lockUnspents,omitempty"`
+	FeeRate                *float64 `json:"feeRate,omitempty"`  // In BTC per kilobyte
+	SatPerVByte            *float64 `json:"fee_rate,omitempty"` // In satoshis per virtual byte
+	ConfTarget             *int     `json:"conf_target,omitempty"`
+	SubtractFeeFromOutputs []int    `json:"subtractFeeFromOutputs,omitempty"`
+	Replaceable            *bool    `json:"replaceable,omitempty"`
+	CoinSelection          *string  `json:"coinselection,omitempty"`
+	ChangeAddress          *string  `json:"changeAddress,omitempty"`
+	ChangeAccount          *string  `json:"changeaccount,omitempty"`
+	OverrideFeeCap         *bool    `json:"overridefeecap,omitempty"`
+}
+
+// FundRawTransactionCmd defines the fundrawtransaction JSON-RPC command.
+type FundRawTransactionCmd struct {
+	HexTx   string
+	Options *FundRawTransactionOpts
+}
+
+// NewFundRawTransactionCmd returns a new instance which can be used to issue
+// a fundrawtransaction JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewFundRawTransactionCmd(hexTx string,
+	options *FundRawTransactionOpts) *FundRawTransactionCmd {
+
+	return &FundRawTransactionCmd{
+		HexTx:   hexTx,
+		Options: options,
+	}
+}
+
+// SignRawTransactionWithWalletCmd defines the signrawtransactionwithwallet
+// JSON-RPC command.
+type SignRawTransactionWithWalletCmd struct {
+	RawTx       string
+	Inputs      *[]btcjson.RawTxInput
+	SigHashType *string `jsonrpcdefault:"\"ALL\""`
+}
+
+// NewSignRawTransactionWithWalletCmd returns a new instance which can be used
+// to issue a signrawtransactionwithwallet JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSignRawTransactionWithWalletCmd(rawTx string,
+	inputs *[]btcjson.RawTxInput,
+	sigHashType *string) *SignRawTransactionWithWalletCmd {
+
+	return &SignRawTransactionWithWalletCmd{
+		RawTx:       rawTx,
+		Inputs:      inputs,
+		SigHashType: sigHashType,
+	}
+}
+
+// SetChangePolicyCmd defines the setchangepolicy JSON-RPC command.
+type SetChangePolicyCmd struct {
+	Policy string
+}
+
+// NewSetChangePolicyCmd returns a new instance which can be used to issue a
+// setchangepolicy JSON-RPC command.
+func NewSetChangePolicyCmd(policy string) *SetChangePolicyCmd {
+	return &SetChangePolicyCmd{
+		Policy: policy,
+	}
+}
+
+// ConsolidateUTXOsCmd defines the consolidateutxos JSON-RPC command.
+type ConsolidateUTXOsCmd struct {
+	Threshold   float64  // In BTC
+	FeeRate     *float64 // In satoshis per virtual byte
+	FromAccount *string  `jsonrpcdefault:"\"default\""`
+	Token       *string
+	MaxInputs   *int `jsonrpcdefault:"100"`
+	MinConf     *int `jsonrpcdefault:"1"`
+}
+
+// NewConsolidateUTXOsCmd returns a new instance which can be used to issue a
+// consolidateutxos JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewConsolidateUTXOsCmd(threshold float64, feeRate *float64,
+	fromAccount *string, token *string, maxInputs *int,
+	minConf *int) *ConsolidateUTXOsCmd {
+
+	return &ConsolidateUTXOsCmd{
+		Threshold:   threshold,
+		FeeRate:     feeRate,
+		FromAccount: fromAccount,
+		Token:       token,
+		MaxInputs:   maxInputs,
+		MinConf:     minConf,
+	}
+}
+
+// GetRecoveryStatusCmd defines the getrecoverystatus JSON-RPC command.
+type GetRecoveryStatusCmd struct{}
+
+// NewGetRecoveryStatusCmd returns a new instance which can be used to issue a
+// getrecoverystatus JSON-RPC command.
+func NewGetRecoveryStatusCmd() *GetRecoveryStatusCmd {
+	return &GetRecoveryStatusCmd{}
+}
+
+// RecoveryEnterSeedCmd defines the recoveryenterseed JSON-RPC command.
+type RecoveryEnterSeedCmd struct {
+	Seed     string
+	Birthday *int64 // Unix time
+}
+
+// NewRecoveryEnterSeedCmd returns a new instance which can be used to issue a
+// recoveryenterseed JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewRecoveryEnterSeedCmd(seed string, birthday *int64) *RecoveryEnterSeedCmd {
+	return &RecoveryEnterSeedCmd{
+		Seed:     seed,
+		Birthday: birthday,
+	}
+}
+
+// RecoveryChooseDerivationsCmd defines the recoverychoosederivations JSON-RPC
+// command.
+type RecoveryChooseDerivationsCmd struct {
+	Purposes       []uint32
+	RecoveryWindow *uint32 `jsonrpcdefault:"250"`
+}
+
+// NewRecoveryChooseDerivationsCmd returns a new instance which can be used to
+// issue a recoverychoosederivations JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewRecoveryChooseDerivationsCmd(purposes []uint32,
+	recoveryWindow *uint32) *RecoveryChooseDerivationsCmd {
+
+	return &RecoveryChooseDerivationsCmd{
+		Purposes:       purposes,
+		RecoveryWindow: recoveryWindow,
+	}
+}
+
+// RecoveryStartScanCmd defines the recoverystartscan JSON-RPC command.
+type RecoveryStartScanCmd struct {
+	Passphrase       string
+	PublicPassphrase *string
+}
+
+// NewRecoveryStartScanCmd returns a new instance which can be used to issue a
+// recoverystartscan JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewRecoveryStartScanCmd(passphrase string,
+	publicPassphrase *string) *RecoveryStartScanCmd {
+
+	return &RecoveryStartScanCmd{
+		Passphrase:       passphrase,
+		PublicPassphrase: publicPassphrase,
+	}
+}
+
+// RecoveryFinalizeCmd defines the recoveryfinalize JSON-RPC command.
+type RecoveryFinalizeCmd struct{}
+
+// NewRecoveryFinalizeCmd returns a new instance which can be used to issue a
+// recoveryfinalize JSON-RPC command.
+func NewRecoveryFinalizeCmd() *RecoveryFinalizeCmd {
+	return &RecoveryFinalizeCmd{}
+}
+
+// RecoveryAbortCmd defines the recoveryabort JSON-RPC command.
+type RecoveryAbortCmd struct{}
+
+// NewRecoveryAbortCmd returns a new instance which can be used to issue a
+// recoveryabort JSON-RPC command.
+func NewRecoveryAbortCmd() *RecoveryAbortCmd {
+	return &RecoveryAbortCmd{}
+}
+
+// ListQuarantinedCmd defines the listquarantined JSON-RPC command.
+type ListQuarantinedCmd struct{}
+
+// NewListQuarantinedCmd returns a new instance which can be used to issue a
+// listquarantined JSON-RPC command.
+func NewListQuarantinedCmd() *ListQuarantinedCmd {
+	return &ListQuarantinedCmd{}
+}
+
+// SpendQuarantinedCmd defines the spendquarantined JSON-RPC command.  The
+// outputs are burned when no address is given.
+type SpendQuarantinedCmd struct {
+	Outputs []btcjson.TransactionInput
+	Address *string
+	FeeRate *float64 // In satoshis per virtual byte
+}
+
+// NewSpendQuarantinedCmd returns a new instance which can be used to issue a
+// spendquarantined JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSpendQuarantinedCmd(outputs []btcjson.TransactionInput,
+	address *string, feeRate *float64) *SpendQuarantinedCmd {
+
+	return &SpendQuarantinedCmd{
+		Outputs: outputs,
+		Address: address,
+		FeeRate: feeRate,
+	}
+}
+
+// GetConfigHashCmd defines the getconfighash JSON-RPC command.
+type GetConfigHashCmd struct {
+	Verbose *bool `jsonrpcdefault:"false"`
+}
+
+// NewGetConfigHashCmd returns a new instance which can be used to issue a
+// getconfighash JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetConfigHashCmd(verbose *bool) *GetConfigHashCmd {
+	return &GetConfigHashCmd{
+		Verbose: verbose,
+	}
+}
+
+// GetDustPolicyCmd defines the getdustpolicy JSON-RPC command.
+type GetDustPolicyCmd struct{}
+
+// NewGetDustPolicyCmd returns a new instance which can be used to issue a
+// getdustpolicy JSON-RPC command.
+func NewGetDustPolicyCmd() *GetDustPolicyCmd {
+	return &GetDustPolicyCmd{}
+}
+
+// FreezeAccountCmd defines the freezeaccount JSON-RPC command.
+type FreezeAccountCmd struct {
+	Account string
+	Reason  *string
+}
+
+// NewFreezeAccountCmd returns a new instance which can be used to issue a
+// freezeaccount JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewFreezeAccountCmd(account string, reason *string) *FreezeAccountCmd {
+	return &FreezeAccountCmd{
+		Account: account,
+		Reason:  reason,
+	}
+}
+
+// UnfreezeAccountCmd defines the unfreezeaccount JSON-RPC command.
+type UnfreezeAccountCmd struct {
+	Account    string
+	Passphrase string
+}
+
+// NewUnfreezeAccountCmd returns a new instance which can be used to issue an
+// unfreezeaccount JSON-RPC command.
+func NewUnfreezeAccountCmd(account, passphrase string) *UnfreezeAccountCmd {
+	return &UnfreezeAccountCmd{
+		Account:    account,
+		Passphrase: passphrase,
+	}
+}
+
+// ListFrozenAccountsCmd defines the listfrozenaccounts JSON-RPC command.
+type ListFrozenAccountsCmd struct{}
+
+// NewListFrozenAccountsCmd returns a new instance which can be used to issue
+// a listfrozenaccounts JSON-RPC command.
+func NewListFrozenAccountsCmd() *ListFrozenAccountsCmd {
+	return &ListFrozenAccountsCmd{}
+}
+
+// GetDerivationProofCmd defines the getderivationproof JSON-RPC command.
+type GetDerivationProofCmd struct {
+	Address string
+}
+
+// NewGetDerivationProofCmd returns a new instance which can be used to issue a
+// getderivationproof JSON-RPC command.
+func NewGetDerivationProofCmd(address string) *GetDerivationProofCmd {
+	return &GetDerivationProofCmd{
+		Address: address,
+	}
+}
+
+// PrepareTransactionCmd defines the preparetransaction JSON-RPC command.
+type PrepareTransactionCmd struct {
+	Amounts         map[string]float64 `jsonrpcusage:"{\"address\":amount,...}"` // In BTC
+	FromAccount     *string
+	MinConf         *int
+	Token           *string
+	CoinSelection   *string
+	ConfTarget      *int
+	FeeRate         *float64 // In satoshis per virtual byte
+	SubtractFeeFrom *[]string
+	ChangeAddress   *string
+}
+
+// NewPrepareTransactionCmd returns a new instance which can be used to issue
+// a preparetransaction JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewPrepareTransactionCmd(amounts map[string]float64, fromAccount *string,
+	minConf *int, token, coinSelection *string, confTarget *int,
+	feeRate *float64, subtractFeeFrom *[]string,
+	changeAddress *string) *PrepareTransactionCmd {
+
+	return &PrepareTransactionCmd{
+		Amounts:         amounts,
+		FromAccount:     fromAccount,
+		MinConf:         minConf,
+		Token:           token,
+		CoinSelection:   coinSelection,
+		ConfTarget:      confTarget,
+		FeeRate:         feeRate,
+		SubtractFeeFrom: subtractFeeFrom,
+		ChangeAddress:   changeAddress,
+	}
+}
+
+// EnterMaintenanceCmd defines the entermaintenance JSON-RPC command.
+type EnterMaintenanceCmd struct {
+	Timeout *int64 `jsonrpcdefault:"30"` // In seconds
+}
+
+// NewEnterMaintenanceCmd returns a new instance which can be used to issue an
+// entermaintenance JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewEnterMaintenanceCmd(timeout *int64) *EnterMaintenanceCmd {
+	return &EnterMaintenanceCmd{
+		Timeout: timeout,
+	}
+}
+
+// ExitMaintenanceCmd defines the exitmaintenance JSON-RPC command.
+type ExitMaintenanceCmd struct{}
+
+// NewExitMaintenanceCmd returns a new instance which can be used to issue an
+// exitmaintenance JSON-RPC command.
+func NewExitMaintenanceCmd() *ExitMaintenanceCmd {
+	return &ExitMaintenanceCmd{}
+}
+
+// GetMaintenanceInfoCmd defines the getmaintenanceinfo JSON-RPC command.
+type GetMaintenanceInfoCmd struct{}
+
+// NewGetMaintenanceInfoCmd returns a new instance which can be used to issue
+// a getmaintenanceinfo JSON-RPC command.
+func NewGetMaintenanceInfoCmd() *GetMaintenanceInfoCmd {
+	return &GetMaintenanceInfoCmd{}
+}
+
+// AccelerateTxCmd defines the acceleratetx JSON-RPC command.
+type AccelerateTxCmd struct {
+	TxID         string
+	Accelerators *[]string
+}
+
+// NewAccelerateTxCmd returns a new instance which can be used to issue an
+// acceleratetx JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewAccelerateTxCmd(txID string, accelerators *[]string) *AccelerateTxCmd {
+	return &AccelerateTxCmd{
+		TxID:         txID,
+		Accelerators: accelerators,
+	}
+}
+
+// ListAccelerationsCmd defines the listaccelerations JSON-RPC command.
+type ListAccelerationsCmd struct {
+	TxID *string
+}
+
+// NewListAccelerationsCmd returns a new instance which can be used to issue a
+// listaccelerations JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewListAccelerationsCmd(txID *string) *ListAccelerationsCmd {
+	return &ListAccelerationsCmd{
+		TxID: txID,
+	}
+}
+
+// EvaluatePolicyCmd defines the evaluatepolicy JSON-RPC command.
+type EvaluatePolicyCmd struct {
+	Address     string
+	Amount      float64 // In BTC
+	Token       *string
+	FromAccount *string
+	MinConf     *int
+	FeeRate     *float64 // In satoshis per virtual byte
+}
+
+// NewEvaluatePolicyCmd returns a new instance which can be used to issue an
+// evaluatepolicy JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewEvaluatePolicyCmd(address string, amount float64, token,
+	fromAccount *string, minConf *int, feeRate *float64) *EvaluatePolicyCmd {
+
+	return &EvaluatePolicyCmd{
+		Address:     address,
+		Amount:      amount,
+		Token:       token,
+		FromAccount: fromAccount,
+		MinConf:     minConf,
+		FeeRate:     feeRate,
+	}
+}
+
+// CreatePaymentReferenceCmd defines the createpaymentreference JSON-RPC
+// command.
+type CreatePaymentReferenceCmd struct {
+	Address string
+}
+
+// NewCreatePaymentReferenceCmd returns a new instance which can be used to
+// issue a createpaymentreference JSON-RPC command.
+func NewCreatePaymentReferenceCmd(address string) *CreatePaymentReferenceCmd {
+	return &CreatePaymentReferenceCmd{
+		Address: address,
+	}
+}
+
+// LookupPaymentReferenceCmd defines the lookuppaymentreference JSON-RPC
+// command.
+type LookupPaymentReferenceCmd struct {
+	Reference string
+	MinConf   *int `jsonrpcdefault:"1"`
+}
+
+// NewLookupPaymentReferenceCmd returns a new instance which can be used to
+// issue a lookuppaymentreference JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewLookupPaymentReferenceCmd(reference string, minConf *int) *LookupPaymentReferenceCmd {
+	return &LookupPaymentReferenceCmd{
+		Reference: reference,
+		MinConf:   minConf,
+	}
+}
+
+// ListPaymentReferencesCmd defines the listpaymentreferences JSON-RPC command.
+type ListPaymentReferencesCmd struct{}
+
+// NewListPaymentReferencesCmd returns a new instance which can be used to
+// issue a listpaymentreferences JSON-RPC command.
+func NewListPaymentReferencesCmd() *ListPaymentReferencesCmd {
+	return &ListPaymentReferencesCmd{}
+}
+
+// EnrollBackupTokenCmd defines the enrollbackuptoken JSON-RPC command.
+type EnrollBackupTokenCmd struct {
+	Name string
+}
+
+// NewEnrollBackupTokenCmd returns a new instance which can be used to issue an
+// enrollbackuptoken JSON-RPC command.
+func NewEnrollBackupTokenCmd(name string) *EnrollBackupTokenCmd {
+	return &EnrollBackupTokenCmd{
+		Name: name,
+	}
+}
+
+// ListBackupTokensCmd defines the listbackuptokens JSON-RPC command.
+type ListBackupTokensCmd struct{}
+
+// NewListBackupTokensCmd returns a new instance which can be used to issue a
+// listbackuptokens JSON-RPC command.
+func NewListBackupTokensCmd() *ListBackupTokensCmd {
+	return &ListBackupTokensCmd{}
+}
+
+// RemoveBackupTokenCmd defines the removebackuptoken JSON-RPC command.
+type RemoveBackupTokenCmd struct {
+	Name string
+}
+
+// NewRemoveBackupTokenCmd returns a new instance which can be used to issue a
+// removebackuptoken JSON-RPC command.
+func NewRemoveBackupTokenCmd(name string) *RemoveBackupTokenCmd {
+	return &RemoveBackupTokenCmd{
+		Name: name,
+	}
+}
+
+// RecoverBackupCmd defines the recoverbackup JSON-RPC command.
+type RecoverBackupCmd struct {
+	Backup      string
+	Destination string
+}
+
+// NewRecoverBackupCmd returns a new instance which can be used to issue a
+// recoverbackup JSON-RPC command.
+func NewRecoverBackupCmd(backup, destination string) *RecoverBackupCmd {
+	return &RecoverBackupCmd{
+		Backup:      backup,
+		Destination: destination,
+	}
+}
+
+// AddHeightTriggerCmd defines the addheighttrigger JSON-RPC command.
+type AddHeightTriggerCmd struct {
+	Height  int32
+	Webhook *string
+	Label   *string
+}
+
+// NewAddHeightTriggerCmd returns a new instance which can be used to issue an
+// addheighttrigger JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewAddHeightTriggerCmd(height int32, webhook, label *string) *AddHeightTriggerCmd {
+	return &AddHeightTriggerCmd{
+		Height:  height,
+		Webhook: webhook,
+		Label:   label,
+	}
+}
+
+// ListHeightTriggersCmd defines the listheighttriggers JSON-RPC command.
+type ListHeightTriggersCmd struct{}
+
+// NewListHeightTriggersCmd returns a new instance which can be used to issue
+// a listheighttriggers JSON-RPC command.
+func NewListHeightTriggersCmd() *ListHeightTriggersCmd {
+	return &ListHeightTriggersCmd{}
+}
+
+// RemoveHeightTriggerCmd defines the removeheighttrigger JSON-RPC command.
+type RemoveHeightTriggerCmd struct {
+	ID uint64
+}
+
+// NewRemoveHeightTriggerCmd returns a new instance which can be used to issue
+// a removeheighttrigger JSON-RPC command.
+func NewRemoveHeightTriggerCmd(id uint64) *RemoveHeightTriggerCmd {
+	return &RemoveHeightTriggerCmd{
+		ID: id,
+	}
+}
+
+// CreateAPITokenCmd defines the createapitoken JSON-RPC command.
+type CreateAPITokenCmd struct {
+	Name string
+}
+
+// NewCreateAPITokenCmd returns a new instance which can be used to issue a
+// createapitoken JSON-RPC command.
+func NewCreateAPITokenCmd(name string) *CreateAPITokenCmd {
+	return &CreateAPITokenCmd{
+		Name: name,
+	}
+}
+
+// ListAPITokensCmd defines the listapitokens JSON-RPC command.
+type ListAPITokensCmd struct{}
+
+// NewListAPITokensCmd returns a new instance which can be used to issue a
+// listapitokens JSON-RPC command.
+func NewListAPITokensCmd() *ListAPITokensCmd {
+	return &ListAPITokensCmd{}
+}
+
+// RevokeAPITokenCmd defines the revokeapitoken JSON-RPC command.
+type RevokeAPITokenCmd struct {
+	Name string
+}
+
+// NewRevokeAPITokenCmd returns a new instance which can be used to issue a
+// revokeapitoken JSON-RPC command.
+func NewRevokeAPITokenCmd(name string) *RevokeAPITokenCmd {
+	return &RevokeAPITokenCmd{
+		Name: name,
+	}
+}
+
+// GetRefillRequestCmd defines the getrefillrequest JSON-RPC command.
+type GetRefillRequestCmd struct{}
+
+// NewGetRefillRequestCmd returns a new instance which can be used to issue a
+// getrefillrequest JSON-RPC command.
+func NewGetRefillRequestCmd() *GetRefillRequestCmd {
+	return &GetRefillRequestCmd{}
+}
+
+func init() {
+	// The commands in this file are only usable with a wallet server.
+	flags := btcjson.UFWalletOnly
+
+	btcjson.MustRegisterCmd("getnewtaprootaddress", (*GetNewTaprootAddressCmd)(nil), flags)
+	btcjson.MustRegisterCmd("importwitnessscript", (*ImportWitnessScriptCmd)(nil), flags)
+	btcjson.MustRegisterCmd("backupwallet", (*BackupWalletCmd)(nil), flags)
+	btcjson.MustRegisterCmd("settravelrule", (*SetTravelRuleCmd)(nil), flags)
+	btcjson.MustRegisterCmd("exporttravelrule", (*ExportTravelRuleCmd)(nil), flags)
+	btcjson.MustRegisterCmd("walletcreatefundedpsbt", (*WalletCreateFundedPsbtCmd)(nil), flags)
+	btcjson.MustRegisterCmd("walletprocesspsbt", (*WalletProcessPsbtCmd)(nil), flags)
+	btcjson.MustRegisterCmd("finalizepsbt", (*FinalizePsbtCmd)(nil), flags)
+	btcjson.MustRegisterCmd("exportpsbt", (*ExportPsbtCmd)(nil), flags)
+	btcjson.MustRegisterCmd("importsignedtx", (*ImportSignedTxCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getaggregatebalance", (*GetAggregateBalanceCmd)(nil), flags)
+	btcjson.MustRegisterCmd("sweepprivkey", (*SweepPrivKeyCmd)(nil), flags)
+	btcjson.MustRegisterCmd("createaccountwithpath", (*CreateAccountWithPathCmd)(nil), flags)
+	btcjson.MustRegisterCmd("reserveaddressindexes", (*ReserveAddressIndexesCmd)(nil), flags)
+	btcjson.MustRegisterCmd("importmulti", (*ImportMultiCmd)(nil), flags)
+	btcjson.MustRegisterCmd("exportledger", (*ExportLedgerCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getaddressusage", (*GetAddressUsageCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getunlocktimeout", (*GetUnlockTimeoutCmd)(nil), flags)
+	btcjson.MustRegisterCmd("importprivkeys", (*ImportPrivKeysCmd)(nil), flags)
+	btcjson.MustRegisterCmd(WalletPassphraseAccountMethod, (*WalletPassphraseCmd)(nil), flags)
+	btcjson.MustRegisterCmd("setaccountpassphrase", (*SetAccountPassphraseCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getoperationsequence", (*GetOperationSequenceCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getaccountfootprint", (*GetAccountFootprintCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getauditlog", (*GetAuditLogCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getstatecommitment", (*GetStateCommitmentCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getproofofreserves", (*GetProofOfReservesCmd)(nil), flags)
+	btcjson.MustRegisterCmd("enrolltotp", (*EnrollTOTPCmd)(nil), flags)
+	btcjson.MustRegisterCmd("disabletotp", (*DisableTOTPCmd)(nil), flags)
+	btcjson.MustRegisterCmd("signmessagebip322", (*SignMessageBIP322Cmd)(nil), flags)
+	btcjson.MustRegisterCmd("pinaddresspool", (*PinAddressPoolCmd)(nil), flags)
+	btcjson.MustRegisterCmd("unpinaddresspool", (*UnpinAddressPoolCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listaddresspools", (*ListAddressPoolsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getpooladdress", (*GetPoolAddressCmd)(nil), flags)
+	btcjson.MustRegisterCmd("consolidatechange", (*ConsolidateChangeCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getconsolidationreport", (*GetConsolidationReportCmd)(nil), flags)
+	btcjson.MustRegisterCmd("sweepall", (*SweepAllCmd)(nil), flags)
+	btcjson.MustRegisterCmd("sendwithinputs", (*SendWithInputsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("cancelbroadcast", (*CancelBroadcastCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listunspentfiltered", (*ListUnspentFilteredCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getdiagnostics", (*GetDiagnosticsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("setaccountmetadata", (*SetAccountMetadataCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getaccountmetadata", (*GetAccountMetadataCmd)(nil), flags)
+	btcjson.MustRegisterCmd("anchoraccountmetadata", (*AnchorAccountMetadataCmd)(nil), flags)
+	btcjson.MustRegisterCmd("bumpfee", (*BumpFeeCmd)(nil), flags)
+	btcjson.MustRegisterCmd("bumpfeecpfp", (*BumpFeeCPFPCmd)(nil), flags)
+	btcjson.MustRegisterCmd(SendManySubtractFeeMethod, (*SendManyCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getkeyusage", (*GetKeyUsageCmd)(nil), flags)
+	btcjson.MustRegisterCmd("queuepayment", (*QueuePaymentCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getqueuedpayment", (*GetQueuedPaymentCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listqueuedpayments", (*ListQueuedPaymentsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("cancelqueuedpayment", (*CancelQueuedPaymentCmd)(nil), flags)
+	btcjson.MustRegisterCmd("sendqueuedpayments", (*SendQueuedPaymentsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("settransactionlabel", (*SetTransactionLabelCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getspendingreport", (*GetSpendingReportCmd)(nil), flags)
+	btcjson.MustRegisterCmd(SendToAddressDataMethod, (*SendToAddressCmd)(nil), flags)
+	btcjson.MustRegisterCmd(SendFromTravelRuleMethod, (*SendFromCmd)(nil), flags)
+	btcjson.MustRegisterCmd("fundrawtransaction", (*FundRawTransactionCmd)(nil), flags)
+	btcjson.MustRegisterCmd("signrawtransactionwithwallet", (*SignRawTransactionWithWalletCmd)(nil), flags)
+	btcjson.MustRegisterCmd("setchangepolicy", (*SetChangePolicyCmd)(nil), flags)
+	btcjson.MustRegisterCmd("consolidateutxos", (*ConsolidateUTXOsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getrecoverystatus", (*GetRecoveryStatusCmd)(nil), flags)
+	btcjson.MustRegisterCmd("recoveryenterseed", (*RecoveryEnterSeedCmd)(nil), flags)
+	btcjson.MustRegisterCmd("recoverychoosederivations", (*RecoveryChooseDerivationsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("recoverystartscan", (*RecoveryStartScanCmd)(nil), flags)
+	btcjson.MustRegisterCmd("recoveryfinalize", (*RecoveryFinalizeCmd)(nil), flags)
+	btcjson.MustRegisterCmd("recoveryabort", (*RecoveryAbortCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listquarantined", (*ListQuarantinedCmd)(nil), flags)
+	btcjson.MustRegisterCmd("spendquarantined", (*SpendQuarantinedCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getconfighash", (*GetConfigHashCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getdustpolicy", (*GetDustPolicyCmd)(nil), flags)
+	btcjson.MustRegisterCmd("freezeaccount", (*FreezeAccountCmd)(nil), flags)
+	btcjson.MustRegisterCmd("unfreezeaccount", (*UnfreezeAccountCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listfrozenaccounts", (*ListFrozenAccountsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getderivationproof", (*GetDerivationProofCmd)(nil), flags)
+	btcjson.MustRegisterCmd("preparetransaction", (*PrepareTransactionCmd)(nil), flags)
+	btcjson.MustRegisterCmd("entermaintenance", (*EnterMaintenanceCmd)(nil), flags)
+	btcjson.MustRegisterCmd("exitmaintenance", (*ExitMaintenanceCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getmaintenanceinfo", (*GetMaintenanceInfoCmd)(nil), flags)
+	btcjson.MustRegisterCmd("acceleratetx", (*AccelerateTxCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listaccelerations", (*ListAccelerationsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("evaluatepolicy", (*EvaluatePolicyCmd)(nil), flags)
+	btcjson.MustRegisterCmd("createpaymentreference", (*CreatePaymentReferenceCmd)(nil), flags)
+	btcjson.MustRegisterCmd("lookuppaymentreference", (*LookupPaymentReferenceCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listpaymentreferences", (*ListPaymentReferencesCmd)(nil), flags)
+	btcjson.MustRegisterCmd("enrollbackuptoken", (*EnrollBackupTokenCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listbackuptokens", (*ListBackupTokensCmd)(nil), flags)
+	btcjson.MustRegisterCmd("removebackuptoken", (*RemoveBackupTokenCmd)(nil), flags)
+	btcjson.MustRegisterCmd("recoverbackup", (*RecoverBackupCmd)(nil), flags)
+	btcjson.MustRegisterCmd("addheighttrigger", (*AddHeightTriggerCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listheighttriggers", (*ListHeightTriggersCmd)(nil), flags)
+	btcjson.MustRegisterCmd("removeheighttrigger", (*RemoveHeightTriggerCmd)(nil), flags)
+	btcjson.MustRegisterCmd("createapitoken", (*CreateAPITokenCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listapitokens", (*ListAPITokensCmd)(nil), flags)
+	btcjson.MustRegisterCmd("revokeapitoken", (*RevokeAPITokenCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getrefillrequest", (*GetRefillRequestCmd)(nil), flags)
+}
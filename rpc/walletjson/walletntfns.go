@@ -0,0 +1,208 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package walletjson
+
+import "github.com/btcsuite/btcd/btcjson"
+
+const (
+	// WalletReceivedNtfnMethod is the method used for notifications from
+	// the wallet server that an output paying to an external wallet address
+	// was received or mined.
+	WalletReceivedNtfnMethod = "walletreceived"
+
+	// AccountQuotaNtfnMethod is the method used for notifications from
+	// the wallet server that the transactions of an account exceed its
+	// footprint quota.
+	AccountQuotaNtfnMethod = "accountquota"
+
+	// UnlockFailedNtfnMethod is the method used for notifications from the
+	// wallet server that a wrong passphrase was tried.
+	UnlockFailedNtfnMethod = "unlockfailed"
+
+	// PendingBroadcastNtfnMethod is the method used for notifications
+	// from the wallet server that a published transaction is held before
+	// it is broadcast.
+	PendingBroadcastNtfnMethod = "pendingbroadcast"
+
+	// KeyUsageAlertNtfnMethod is the method used for notifications from
+	// the wallet server that the signing volume of an address or account
+	// deviates sharply from its baseline.
+	KeyUsageAlertNtfnMethod = "keyusagealert"
+
+	// WalletConsolidatedNtfnMethod is the method used for notifications
+	// from the wallet server that outputs of an account were consolidated,
+	// or failed to be.
+	WalletConsolidatedNtfnMethod = "walletconsolidated"
+)
+
+// WalletReceivedNtfn defines the walletreceived JSON-RPC notification.  The
+// height is -1 for unmined transactions, and the amount is omitted by the
+// notifications of amount-blinded subscriptions.  The reference is the
+// payment reference of the address, or null when it has none.
+type WalletReceivedNtfn struct {
+	TxID      string
+	Vout      uint32
+	Address   string
+	Height    int32
+	Amount    *float64
+	Reference *string
+}
+
+// NewWalletReceivedNtfn returns a new instance which can be used to issue a
+// walletreceived JSON-RPC notification.
+func NewWalletReceivedNtfn(txID string, vout uint32, address string,
+	height int32, amount *float64, reference *string) *WalletReceivedNtfn {
+
+	return &WalletReceivedNtfn{
+		TxID:      txID,
+		Vout:      vout,
+		Address:   address,
+		Height:    height,
+		Amount:    amount,
+		Reference: reference,
+	}
+}
+
+// AccountQuotaNtfn defines the accountquota JSON-RPC notification.  The
+// footprint and quota are in bytes.
+type AccountQuotaNtfn struct {
+	Account   string
+	Footprint int64
+	Quota     int64
+}
+
+// NewAccountQuotaNtfn returns a new instance which can be used to issue an
+// accountquota JSON-RPC notification.
+func NewAccountQuotaNtfn(account string, footprint, quota int64) *AccountQuotaNtfn {
+	return &AccountQuotaNtfn{
+		Account:   account,
+		Footprint: footprint,
+		Quota:     quota,
+	}
+}
+
+// UnlockFailedNtfn defines the unlockfailed JSON-RPC notification.  No
+// passphrase is tried again for RetryAfter seconds, and when the wallet is
+// locked out with a zero RetryAfter, not until the wallet is restarted.
+type UnlockFailedNtfn struct {
+	Failures   uint32
+	RetryAfter int64
+	LockedOut  bool
+}
+
+// NewUnlockFailedNtfn returns a new instance which can be used to issue an
+// unlockfailed JSON-RPC notification.
+func NewUnlockFailedNtfn(failures uint32, retryAfter int64, lockedOut bool) *UnlockFailedNtfn {
+	return &UnlockFailedNtfn{
+		Failures:   failures,
+		RetryAfter: retryAfter,
+		LockedOut:  lockedOut,
+	}
+}
+
+// PendingBroadcastOutput describes an output of a transaction notified by a
+// pendingbroadcast notification.  The address is empty for outputs without a
+// single address.
+type PendingBroadcastOutput struct {
+	Address string  `json:"address,omitempty"`
+	Amount  float64 `json:"amount"`
+	Token   string  `json:"token"`
+}
+
+// PendingBroadcastNtfn defines the pendingbroadcast JSON-RPC notification.
+// The transaction is broadcast at the BroadcastAt Unix time unless it is
+// cancelled with cancelbroadcast before.
+type PendingBroadcastNtfn struct {
+	TxID        string
+	Hex         string
+	Outputs     []PendingBroadcastOutput
+	Annotations map[string]string
+	Replaceable bool
+	BroadcastAt int64
+}
+
+// NewPendingBroadcastNtfn returns a new instance which can be used to issue a
+// pendingbroadcast JSON-RPC notification.
+func NewPendingBroadcastNtfn(txID, hex string, outputs []PendingBroadcastOutput,
+	annotations map[string]string, replaceable bool,
+	broadcastAt int64) *PendingBroadcastNtfn {
+
+	return &PendingBroadcastNtfn{
+		TxID:        txID,
+		Hex:         hex,
+		Outputs:     outputs,
+		Annotations: annotations,
+		Replaceable: replaceable,
+		BroadcastAt: broadcastAt,
+	}
+}
+
+// KeyUsageAlertNtfn defines the keyusagealert JSON-RPC notification.  The
+// address is empty when the signing volume of the whole account is notified.
+// Signatures is the number of signatures of the current hour, and Baseline the
+// average number of signatures per hour.
+type KeyUsageAlertNtfn struct {
+	Account    string
+	Address    string
+	Signatures uint64
+	Baseline   float64
+}
+
+// NewKeyUsageAlertNtfn returns a new instance which can be used to issue a
+// keyusagealert JSON-RPC notification.
+func NewKeyUsageAlertNtfn(account, address string, signatures uint64,
+	baseline float64) *KeyUsageAlertNtfn {
+
+	return &KeyUsageAlertNtfn{
+		Account:    account,
+		Address:    address,
+		Signatures: signatures,
+		Baseline:   baseline,
+	}
+}
+
+// WalletConsolidatedNtfn defines the walletconsolidated JSON-RPC notification.
+// The transaction hash and address are empty and the error is set for failed
+// consolidations.
+type WalletConsolidatedNtfn struct {
+	Account string
+	Token   string
+	Inputs  int
+	Amount  float64
+	Fee     float64
+	TxID    string
+	Address string
+	Error   string
+}
+
+// NewWalletConsolidatedNtfn returns a new instance which can be used to issue
+// a walletconsolidated JSON-RPC notification.
+func NewWalletConsolidatedNtfn(account, token string, inputs int, amount,
+	fee float64, txID, address, errStr string) *WalletConsolidatedNtfn {
+
+	return &WalletConsolidatedNtfn{
+		Account: account,
+		Token:   token,
+		Inputs:  inputs,
+		Amount:  amount,
+		Fee:     fee,
+		TxID:    txID,
+		Address: address,
+		Error:   errStr,
+	}
+}
+
+func init() {
+	// The commands in this file are only usable with a wallet server via
+	// websockets and are notifications.
+	flags := btcjson.UFWalletOnly | btcjson.UFWebsocketOnly | btcjson.UFNotification
+
+	btcjson.MustRegisterCmd(WalletReceivedNtfnMethod, (*WalletReceivedNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(AccountQuotaNtfnMethod, (*AccountQuotaNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(UnlockFailedNtfnMethod, (*UnlockFailedNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(PendingBroadcastNtfnMethod, (*PendingBroadcastNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(KeyUsageAlertNtfnMethod, (*KeyUsageAlertNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(WalletConsolidatedNtfnMethod, (*WalletConsolidatedNtfn)(nil), flags)
+}
@@ -0,0 +1,629 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package walletjson
+
+import "github.com/btcsuite/btcd/btcjson"
+
+// ImportWitnessScriptResult models the data returned from the
+// importwitnessscript command.
+type ImportWitnessScriptResult struct {
+	Address     string `json:"address"`
+	P2SHAddress string `json:"p2shaddress"`
+}
+
+// TravelRuleRecord models an element of the JSON array returned by the
+// exporttravelrule command when no transaction is specified.
+type TravelRuleRecord struct {
+	TxID    string      `json:"txid"`
+	IVMS101 interface{} `json:"ivms101"`
+}
+
+// WalletCreateFundedPsbtResult models the data returned from the
+// walletcreatefundedpsbt command.
+type WalletCreateFundedPsbtResult struct {
+	Psbt      string  `json:"psbt"`
+	Fee       float64 `json:"fee"`
+	ChangePos int     `json:"changepos"`
+}
+
+// WalletProcessPsbtResult models the data returned from the walletprocesspsbt
+// command.
+type WalletProcessPsbtResult struct {
+	Psbt     string `json:"psbt"`
+	Complete bool   `json:"complete"`
+}
+
+// FinalizePsbtResult models the data returned from the finalizepsbt command.
+// Hex is only set when the transaction was extracted from a complete packet,
+// and Psbt is set otherwise.
+type FinalizePsbtResult struct {
+	Psbt     string `json:"psbt,omitempty"`
+	Hex      string `json:"hex,omitempty"`
+	Complete bool   `json:"complete"`
+}
+
+// ExportPsbtResult models the data returned from the exportpsbt command.
+type ExportPsbtResult struct {
+	File    string   `json:"file,omitempty"`
+	QRParts []string `json:"qrparts"`
+}
+
+// AggregateAccountBalance models the balances of an account returned by the
+// getaggregatebalance command.
+type AggregateAccountBalance struct {
+	Account        string  `json:"account"`
+	Total          float64 `json:"total"`
+	Spendable      float64 `json:"spendable"`
+	ImmatureReward float64 `json:"immaturereward"`
+	WatchOnly      float64 `json:"watchonly"`
+}
+
+// GetAggregateBalanceResult models the data returned from the
+// getaggregatebalance command.  The totals are the sums of the balances of
+// the accounts.
+type GetAggregateBalanceResult struct {
+	Accounts       []AggregateAccountBalance `json:"accounts"`
+	Total          float64                   `json:"total"`
+	Spendable      float64                   `json:"spendable"`
+	ImmatureReward float64                   `json:"immaturereward"`
+	WatchOnly      float64                   `json:"watchonly"`
+}
+
+// SweepPrivKeyResult models the data returned from the sweepprivkey command.
+type SweepPrivKeyResult struct {
+	TxID    string  `json:"txid"`
+	Address string  `json:"address"`
+	Amount  float64 `json:"amount"`
+	Fee     float64 `json:"fee"`
+	Inputs  int     `json:"inputs"`
+}
+
+// ReserveAddressIndexesResult models the data returned from the
+// reserveaddressindexes command.
+type ReserveAddressIndexesResult struct {
+	Account    string `json:"account"`
+	Branch     uint32 `json:"branch"`
+	FirstIndex uint32 `json:"firstindex"`
+	LastIndex  uint32 `json:"lastindex"`
+}
+
+// ImportMultiResult models an element of the JSON array returned by the
+// importmulti command.
+type ImportMultiResult struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ImportPrivKeysError models a private key that failed to import in the
+// data returned from the importprivkeys command.
+type ImportPrivKeysError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// ImportPrivKeysResult models the data returned from the importprivkeys
+// command.
+type ImportPrivKeysResult struct {
+	Imported   int                   `json:"imported"`
+	Duplicates int                   `json:"duplicates"`
+	Errors     []ImportPrivKeysError `json:"errors"`
+}
+
+// DumpWalletResult models the data returned from the dumpwallet command.
+type DumpWalletResult struct {
+	Filename string `json:"filename"`
+}
+
+// GetAddressUsageResult models the data returned from the getaddressusage
+// command.  Times are unix times, and the first seen and last used times are
+// omitted when they are unknown.
+type GetAddressUsageResult struct {
+	Address   string `json:"address"`
+	Generated int64  `json:"generated"`
+	Used      bool   `json:"used"`
+	FirstSeen int64  `json:"firstseen,omitempty"`
+	LastUsed  int64  `json:"lastused,omitempty"`
+}
+
+// GetUnlockTimeoutResult models the data returned from the getunlocktimeout
+// command.  The unlock expiration is omitted for locked wallets and for
+// wallets unlocked without a timeout.
+type GetUnlockTimeoutResult struct {
+	Locked        bool  `json:"locked"`
+	UnlockedUntil int64 `json:"unlockeduntil,omitempty"`
+	Remaining     int64 `json:"remaining,omitempty"`
+}
+
+// GetAccountFootprintResult models an element of the JSON array returned by
+// the getaccountfootprint command.  The quota is omitted for accounts without
+// a quota.
+type GetAccountFootprintResult struct {
+	Account   string `json:"account"`
+	Footprint int64  `json:"footprint"`
+	Quota     int64  `json:"quota,omitempty"`
+}
+
+// GetAuditLogResult models an element of the JSON array returned by the
+// getauditlog command.
+type GetAuditLogResult struct {
+	Seq       uint64 `json:"seq"`
+	Time      int64  `json:"time"`
+	Operation string `json:"operation"`
+	Details   string `json:"details"`
+	Hash      string `json:"hash"`
+	PrevHash  string `json:"prevhash"`
+}
+
+// StateCommitmentBalance models the balance of a token in an account of the
+// getstatecommitment result.
+type StateCommitmentBalance struct {
+	Account string  `json:"account"`
+	Token   string  `json:"token"`
+	Amount  float64 `json:"amount"`
+}
+
+// GetStateCommitmentResult models the data returned from the
+// getstatecommitment command.
+type GetStateCommitmentResult struct {
+	Height      int32                    `json:"height"`
+	BlockHash   string                   `json:"blockhash"`
+	Utxos       int                      `json:"utxos"`
+	UtxoRoot    string                   `json:"utxoroot"`
+	Balances    []StateCommitmentBalance `json:"balances"`
+	BalanceRoot string                   `json:"balanceroot"`
+	AuditSeq    uint64                   `json:"auditseq"`
+	AuditHash   string                   `json:"audithash"`
+	Root        string                   `json:"root"`
+}
+
+// ReserveOutput models an unspent output of an address of the
+// getproofofreserves result.
+type ReserveOutput struct {
+	TxID   string  `json:"txid"`
+	Vout   uint32  `json:"vout"`
+	Height int32   `json:"height"`
+	Amount float64 `json:"amount"`
+	Token  string  `json:"token"`
+}
+
+// ReserveAddress models an address of the getproofofreserves result.  The
+// signature is omitted for addresses the wallet has no private key for.
+type ReserveAddress struct {
+	Address   string          `json:"address"`
+	Signature string          `json:"signature,omitempty"`
+	Outputs   []ReserveOutput `json:"outputs"`
+}
+
+// ReserveTotal models the total reserves of a token of the
+// getproofofreserves result.
+type ReserveTotal struct {
+	Token  string  `json:"token"`
+	Amount float64 `json:"amount"`
+	Signed float64 `json:"signed"`
+}
+
+// GetProofOfReservesResult models the data returned from the
+// getproofofreserves command.
+type GetProofOfReservesResult struct {
+	Height    int32            `json:"height"`
+	BlockHash string           `json:"blockhash"`
+	Message   string           `json:"message"`
+	Addresses []ReserveAddress `json:"addresses"`
+	Totals    []ReserveTotal   `json:"totals"`
+}
+
+// EnrollTOTPResult models the data returned from the enrolltotp command.
+type EnrollTOTPResult struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// ListAddressPoolsResult models an element of the JSON array returned by the
+// listaddresspools command.
+type ListAddressPoolsResult struct {
+	Name       string   `json:"name"`
+	Descriptor string   `json:"descriptor"`
+	NextIndex  uint32   `json:"nextindex"`
+	Window     uint32   `json:"window"`
+	Accounts   []string `json:"accounts"`
+}
+
+// ConsolidationResult models a consolidation transaction of the
+// consolidatechange and getconsolidationreport results.
+type ConsolidationResult struct {
+	Account string   `json:"account"`
+	Token   string   `json:"token"`
+	Inputs  []string `json:"inputs"`
+	Amount  float64  `json:"amount"`
+	Fee     float64  `json:"fee"`
+	Address string   `json:"address,omitempty"`
+	TxID    string   `json:"txid,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// ConsolidationReportResult models the data returned from the
+// consolidatechange and getconsolidationreport commands.
+type ConsolidationReportResult struct {
+	Time           int64                 `json:"time"`
+	FeeRate        float64               `json:"feerate"`
+	DryRun         bool                  `json:"dryrun"`
+	Consolidations []ConsolidationResult `json:"consolidations"`
+}
+
+// SweepAllOutput models an output of the sweepall result.
+type SweepAllOutput struct {
+	Address string  `json:"address"`
+	Amount  float64 `json:"amount"`
+}
+
+// SweepAllResult models the data returned from the sweepall command.
+type SweepAllResult struct {
+	TxID    string           `json:"txid"`
+	Outputs []SweepAllOutput `json:"outputs"`
+	Fee     float64          `json:"fee"`
+	Inputs  int              `json:"inputs"`
+}
+
+// GetTransactionResult models the data returned from the gettransaction
+// command, extending btcjson.GetTransactionResult with the BIP0125
+// replaceability and replacements of the transaction.
+type GetTransactionResult struct {
+	Amount            float64                               `json:"amount"`
+	Fee               float64                               `json:"fee,omitempty"`
+	Confirmations     int64                                 `json:"confirmations"`
+	BlockHash         string                                `json:"blockhash"`
+	BlockIndex        int64                                 `json:"blockindex"`
+	BlockTime         int64                                 `json:"blocktime"`
+	TxID              string                                `json:"txid"`
+	WalletConflicts   []string                              `json:"walletconflicts"`
+	Time              int64                                 `json:"time"`
+	TimeReceived      int64                                 `json:"timereceived"`
+	BIP125Replaceable string                                `json:"bip125-replaceable"`
+	ReplacesTxID      string                                `json:"replaces_txid,omitempty"`
+	ReplacedByTxID    string                                `json:"replaced_by_txid,omitempty"`
+	SignerAttestation *SignerAttestationResult              `json:"signerattestation,omitempty"`
+	Details           []btcjson.GetTransactionDetailsResult `json:"details"`
+	Hex               string                                `json:"hex"`
+}
+
+// SignerAttestationResult models the attestation of the device which signed a
+// transaction, returned by the gettransaction command.
+type SignerAttestationResult struct {
+	Signer      string `json:"signer"`
+	Type        string `json:"type,omitempty"`
+	Model       string `json:"model,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+	Evidence    string `json:"evidence,omitempty"`
+	Time        int64  `json:"time"`
+}
+
+// DiagnosticsSnapshot models a snapshot of the getdiagnostics result.
+type DiagnosticsSnapshot struct {
+	Time    int64            `json:"time"`
+	Metrics map[string]int64 `json:"metrics"`
+	Errors  []string         `json:"errors,omitempty"`
+}
+
+// GetDiagnosticsResult models the data returned from the getdiagnostics
+// command.
+type GetDiagnosticsResult struct {
+	Current   DiagnosticsSnapshot   `json:"current"`
+	Snapshots []DiagnosticsSnapshot `json:"snapshots"`
+}
+
+// MetadataAnchorResult models the last anchor of the getaccountmetadata
+// result.
+type MetadataAnchorResult struct {
+	Hash string `json:"hash"`
+	TxID string `json:"txid"`
+	Time int64  `json:"time"`
+}
+
+// GetAccountMetadataResult models the data returned from the
+// getaccountmetadata command.
+type GetAccountMetadataResult struct {
+	Metadata   map[string]string     `json:"metadata"`
+	Hash       string                `json:"hash"`
+	LastAnchor *MetadataAnchorResult `json:"lastanchor,omitempty"`
+}
+
+// BumpFeeResult models the data returned from the bumpfee command.
+type BumpFeeResult struct {
+	TxID    string  `json:"txid"`
+	OrigFee float64 `json:"origfee"`
+	Fee     float64 `json:"fee"`
+}
+
+// BumpFeeCPFPResult models the data returned from the bumpfeecpfp command.
+type BumpFeeCPFPResult struct {
+	TxID      string  `json:"txid"`
+	Fee       float64 `json:"fee"`
+	ParentFee float64 `json:"parentfee"`
+}
+
+// KeyUsageAddressResult models the signatures of an address in the
+// getkeyusage result.
+type KeyUsageAddressResult struct {
+	Address     string  `json:"address"`
+	Signatures  uint64  `json:"signatures"`
+	CurrentHour uint64  `json:"currenthour"`
+	Baseline    float64 `json:"baseline"`
+}
+
+// GetKeyUsageResult models the data returned from the getkeyusage command.
+type GetKeyUsageResult struct {
+	Account     string                  `json:"account"`
+	Signatures  uint64                  `json:"signatures"`
+	CurrentHour uint64                  `json:"currenthour"`
+	Baseline    float64                 `json:"baseline"`
+	Addresses   []KeyUsageAddressResult `json:"addresses"`
+}
+
+// QueuedPaymentResult models the data returned from the getqueuedpayment and
+// listqueuedpayments commands.
+type QueuedPaymentResult struct {
+	ID        uint64  `json:"id"`
+	Account   string  `json:"account"`
+	Address   string  `json:"address,omitempty"`
+	Amount    float64 `json:"amount"`
+	Token     string  `json:"token"`
+	Time      int64   `json:"time"`
+	TxID      string  `json:"txid,omitempty"`
+	Cancelled bool    `json:"cancelled"`
+}
+
+// PaymentBatchResult models the data returned from the sendqueuedpayments
+// command.
+type PaymentBatchResult struct {
+	Account  string   `json:"account"`
+	Token    string   `json:"token"`
+	TxID     string   `json:"txid"`
+	Payments []uint64 `json:"payments"`
+}
+
+// SpendingCategoryResult models the sums of the transactions with the same
+// label in the getspendingreport result.
+type SpendingCategoryResult struct {
+	Label        string  `json:"label"`
+	Received     float64 `json:"received"`
+	ReceiveCount int     `json:"receivecount"`
+	Sent         float64 `json:"sent"`
+	SendCount    int     `json:"sendcount"`
+	Fees         float64 `json:"fees"`
+}
+
+// GetSpendingReportResult models the data returned from the getspendingreport
+// command.
+type GetSpendingReportResult struct {
+	StartTime  int64                    `json:"starttime"`
+	EndTime    int64                    `json:"endtime"`
+	Categories []SpendingCategoryResult `json:"categories"`
+	Total      SpendingCategoryResult   `json:"total"`
+}
+
+// FundRawTransactionResult models the data returned from the
+// fundrawtransaction command.
+type FundRawTransactionResult struct {
+	Hex       string  `json:"hex"`
+	Fee       float64 `json:"fee"`
+	ChangePos int     `json:"changepos"`
+}
+
+// ConsolidateUTXOsResult models the data returned from the consolidateutxos
+// command.
+type ConsolidateUTXOsResult struct {
+	TxID    string  `json:"txid"`
+	Address string  `json:"address"`
+	Amount  float64 `json:"amount"`
+	Fee     float64 `json:"fee"`
+	Inputs  int     `json:"inputs"`
+}
+
+// RecoveredScopeResult models a key scope of the recovery wizard results.
+type RecoveredScopeResult struct {
+	Scope     string             `json:"scope"`
+	Addresses uint32             `json:"addresses"`
+	Balances  map[string]float64 `json:"balances"`
+}
+
+// RecoveryStatusResult models the data returned from the recovery wizard
+// commands.
+type RecoveryStatusResult struct {
+	Step           string                 `json:"step"`
+	Birthday       int64                  `json:"birthday,omitempty"`
+	Scopes         []string               `json:"scopes,omitempty"`
+	RecoveryWindow uint32                 `json:"recoverywindow,omitempty"`
+	SyncedHeight   int32                  `json:"syncedheight,omitempty"`
+	BestHeight     int32                  `json:"bestheight,omitempty"`
+	Found          []RecoveredScopeResult `json:"found,omitempty"`
+}
+
+// ListQuarantinedResult models the data returned from the listquarantined
+// command.
+type ListQuarantinedResult struct {
+	TxID        string  `json:"txid"`
+	Vout        uint32  `json:"vout"`
+	Account     string  `json:"account"`
+	Address     string  `json:"address,omitempty"`
+	Amount      float64 `json:"amount"`
+	Token       string  `json:"token"`
+	Confirmed   bool    `json:"confirmed"`
+	Quarantined int64   `json:"quarantined"`
+}
+
+// SpendQuarantinedResult models the data returned from the spendquarantined
+// command.
+type SpendQuarantinedResult struct {
+	TxID   string  `json:"txid"`
+	Fee    float64 `json:"fee"`
+	Burned bool    `json:"burned"`
+}
+
+// GetConfigHashResult models the data returned from the getconfighash
+// command.
+type GetConfigHashResult struct {
+	Hash     string   `json:"hash"`
+	Settings []string `json:"settings,omitempty"`
+}
+
+// GetDustPolicyResult models the data returned from the getdustpolicy
+// command.
+type GetDustPolicyResult struct {
+	DustRelayFee        float64            `json:"dustrelayfee"`
+	RejectDustChange    bool               `json:"rejectdustchange"`
+	DustLimits          map[string]float64 `json:"dustlimits"`
+	QuarantineThreshold float64            `json:"quarantinethreshold"`
+	SpendQuarantined    bool               `json:"spendquarantined"`
+}
+
+// ListFrozenAccountsResult models the data returned from the
+// listfrozenaccounts command.
+type ListFrozenAccountsResult struct {
+	Account string `json:"account"`
+	Frozen  int64  `json:"frozen"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// DerivationProofStep models a public child key derivation of the
+// getderivationproof result.
+type DerivationProofStep struct {
+	Index          uint32 `json:"index"`
+	ParentKey      string `json:"parentkey"`
+	ChainCode      string `json:"chaincode"`
+	Tweak          string `json:"tweak"`
+	ChildKey       string `json:"childkey"`
+	ChildChainCode string `json:"childchaincode"`
+}
+
+// GetDerivationProofResult models the data returned from the
+// getderivationproof command.
+type GetDerivationProofResult struct {
+	Address     string                `json:"address"`
+	AddressType string                `json:"addresstype"`
+	AccountXPub string                `json:"accountxpub"`
+	AccountPath string                `json:"accountpath"`
+	Path        string                `json:"path"`
+	Steps       []DerivationProofStep `json:"steps"`
+	OutputKey   string                `json:"outputkey,omitempty"`
+}
+
+// PrepareTransactionInput models an input of the preparetransaction result.
+type PrepareTransactionInput struct {
+	TxID    string  `json:"txid"`
+	Vout    uint32  `json:"vout"`
+	Address string  `json:"address,omitempty"`
+	Amount  float64 `json:"amount"`
+}
+
+// PrepareTransactionOutput models an output of the preparetransaction result.
+type PrepareTransactionOutput struct {
+	Address string  `json:"address,omitempty"`
+	Amount  float64 `json:"amount"`
+	Change  bool    `json:"change"`
+}
+
+// PrepareTransactionResult models the data returned from the
+// preparetransaction command.
+type PrepareTransactionResult struct {
+	Inputs  []PrepareTransactionInput  `json:"inputs"`
+	Outputs []PrepareTransactionOutput `json:"outputs"`
+	Fee     float64                    `json:"fee"`
+	VSize   int                        `json:"vsize"`
+}
+
+// MaintenanceResult models the data returned from the entermaintenance,
+// exitmaintenance and getmaintenanceinfo commands.
+type MaintenanceResult struct {
+	Active bool   `json:"active"`
+	Since  int64  `json:"since,omitempty"`
+	DBPath string `json:"dbpath,omitempty"`
+	Height int32  `json:"height,omitempty"`
+	Hash   string `json:"hash,omitempty"`
+}
+
+// AccelerationResult models a submission of a transaction to an accelerator
+// in the acceleratetx and listaccelerations results.
+type AccelerationResult struct {
+	TxID        string `json:"txid"`
+	Accelerator string `json:"accelerator"`
+	Submitted   int64  `json:"submitted"`
+	Accepted    bool   `json:"accepted"`
+	Reference   string `json:"reference,omitempty"`
+	Message     string `json:"message,omitempty"`
+	Mined       bool   `json:"mined"`
+}
+
+// AccelerationsResult models the data returned from the acceleratetx and
+// listaccelerations commands.
+type AccelerationsResult struct {
+	Notice        string               `json:"notice"`
+	Accelerations []AccelerationResult `json:"accelerations"`
+}
+
+// PolicyRuleResult models the outcome of a policy rule in the evaluatepolicy
+// result.
+type PolicyRuleResult struct {
+	Rule    string `json:"rule"`
+	Outcome string `json:"outcome"`
+	Detail  string `json:"detail"`
+}
+
+// EvaluatePolicyResult models the data returned from the evaluatepolicy
+// command.
+type EvaluatePolicyResult struct {
+	Allowed bool               `json:"allowed"`
+	Rules   []PolicyRuleResult `json:"rules"`
+	Fee     *float64           `json:"fee,omitempty"`
+	VSize   int                `json:"vsize,omitempty"`
+}
+
+// PaymentReferenceResult models the data returned from the
+// createpaymentreference, lookuppaymentreference and listpaymentreferences
+// commands.
+type PaymentReferenceResult struct {
+	Reference string   `json:"reference"`
+	Address   string   `json:"address"`
+	Account   string   `json:"account"`
+	Created   int64    `json:"created"`
+	URI       string   `json:"uri"`
+	Received  *float64 `json:"received,omitempty"`
+}
+
+// BackupTokenResult models the data returned from the enrollbackuptoken and
+// listbackuptokens commands.
+type BackupTokenResult struct {
+	Name     string `json:"name"`
+	Enrolled int64  `json:"enrolled"`
+}
+
+// HeightTriggerResult models the data returned from the addheighttrigger and
+// listheighttriggers commands.
+type HeightTriggerResult struct {
+	ID          uint64 `json:"id"`
+	Height      int32  `json:"height"`
+	Webhook     string `json:"webhook,omitempty"`
+	Label       string `json:"label,omitempty"`
+	Created     int64  `json:"created"`
+	FiredHeight int32  `json:"firedheight,omitempty"`
+}
+
+// APITokenResult models the data returned from the createapitoken and
+// listapitokens commands.
+type APITokenResult struct {
+	Name    string `json:"name"`
+	Created int64  `json:"created"`
+	Secret  string `json:"secret,omitempty"`
+}
+
+// RefillRequestResult models the data returned from the getrefillrequest
+// command.
+type RefillRequestResult struct {
+	Created int64   `json:"created"`
+	Balance float64 `json:"balance"`
+	Amount  float64 `json:"amount"`
+	Fee     float64 `json:"fee"`
+	Path    string  `json:"path"`
+	Psbt    string  `json:"psbt"`
+}
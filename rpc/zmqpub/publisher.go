@@ -0,0 +1,455 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package zmqpub publishes wallet events on ZMQ PUB sockets, so that external
+services can consume them with standard ZMQ tooling.
+
+The publisher speaks ZMTP 3.0 with the NULL security mechanism, as bitcoind
+does, and is implemented without linking libzmq.  Each event is a message of
+three frames: the topic, a JSON body, and a 4-byte little endian sequence
+number incremented for every message of the topic, so that subscribers
+detect the messages they missed.  Subscribers filter the topics by prefix.
+*/
+package zmqpub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Frame flags of ZMTP 3.0.
+const (
+	flagMore    = 0x01
+	flagLong    = 0x02
+	flagCommand = 0x04
+)
+
+const (
+	// greetingSize is the size of the greeting exchanged by ZMTP peers.
+	greetingSize = 64
+
+	// maxFrameSize limits the size of the frames read from subscribers,
+	// which only send subscriptions and commands.
+	maxFrameSize = 4096
+
+	// queueSize is the number of messages queued for a subscriber above
+	// which messages are dropped, as by the high water mark of ZMQ PUB
+	// sockets, so that a slow subscriber does not stall the wallet.
+	queueSize = 1000
+
+	// handshakeTimeout is how long subscribers have to complete the ZMTP
+	// handshake.
+	handshakeTimeout = 10 * time.Second
+)
+
+// errFrameTooLarge describes a frame read from a subscriber larger than
+// maxFrameSize.
+var errFrameTooLarge = errors.New("frame too large")
+
+// Publisher is a ZMQ PUB socket bound to listeners, publishing messages to
+// the subscribers connected to them.
+type Publisher struct {
+	listeners []net.Listener
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	sequences   map[string]uint32
+
+	wg       sync.WaitGroup
+	quit     chan struct{}
+	stopOnce sync.Once
+}
+
+// subscriber is a connected ZMQ SUB socket.
+type subscriber struct {
+	conn          net.Conn
+	queue         chan [][]byte
+	subscriptions map[string]int
+	dropping      bool
+}
+
+// NewPublisher returns a publisher accepting subscribers on listeners.
+// Start must be called to accept them.
+func NewPublisher(listeners []net.Listener) *Publisher {
+	return &Publisher{
+		listeners:   listeners,
+		subscribers: make(map[*subscriber]struct{}),
+		sequences:   make(map[string]uint32),
+		quit:        make(chan struct{}),
+	}
+}
+
+// Start accepts subscribers on the listeners of the publisher.
+func (p *Publisher) Start() {
+	for _, l := range p.listeners {
+		log.Infof("ZMQ publisher listening on %s", l.Addr())
+		p.wg.Add(1)
+		go p.acceptSubscribers(l)
+	}
+}
+
+// Stop closes the listeners of the publisher and disconnects its
+// subscribers.  Messages still queued for subscribers are dropped.
+func (p *Publisher) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.quit)
+		for _, l := range p.listeners {
+			l.Close()
+		}
+		p.mu.Lock()
+		for s := range p.subscribers {
+			s.conn.Close()
+		}
+		p.mu.Unlock()
+	})
+	p.wg.Wait()
+}
+
+// Publish sends a message with a topic and a body to the subscribers of the
+// topic.  It never blocks: messages are dropped for subscribers that do not
+// keep up.
+func (p *Publisher) Publish(topic string, body []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var seq [4]byte
+	binary.LittleEndian.PutUint32(seq[:], p.sequences[topic])
+	p.sequences[topic]++
+
+	msg := [][]byte{[]byte(topic), body, seq[:]}
+	for s := range p.subscribers {
+		if !s.subscribed(topic) {
+			continue
+		}
+		select {
+		case s.queue <- msg:
+			s.dropping = false
+		default:
+			if !s.dropping {
+				log.Warnf("Dropping ZMQ messages for slow "+
+					"subscriber %s", s.conn.RemoteAddr())
+				s.dropping = true
+			}
+		}
+	}
+}
+
+// acceptSubscribers accepts subscribers on a listener until the publisher is
+// stopped.  It must be run as a goroutine.
+func (p *Publisher) acceptSubscribers(l net.Listener) {
+	defer p.wg.Done()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-p.quit:
+			default:
+				log.Errorf("Cannot accept ZMQ subscriber: %v", err)
+			}
+			return
+		}
+		p.wg.Add(1)
+		go p.serveSubscriber(conn)
+	}
+}
+
+// serveSubscriber performs the handshake with a subscriber and reads its
+// subscriptions until it disconnects.  It must be run as a goroutine.
+func (p *Publisher) serveSubscriber(conn net.Conn) {
+	defer p.wg.Done()
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err := handshake(conn); err != nil {
+		log.Warnf("ZMQ handshake with %s failed: %v", conn.RemoteAddr(),
+			err)
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	s := &subscriber{
+		conn:          conn,
+		queue:         make(chan [][]byte, queueSize),
+		subscriptions: make(map[string]int),
+	}
+	p.mu.Lock()
+	select {
+	case <-p.quit:
+		p.mu.Unlock()
+		return
+	default:
+	}
+	p.subscribers[s] = struct{}{}
+	p.mu.Unlock()
+	log.Debugf("ZMQ subscriber %s connected", conn.RemoteAddr())
+
+	p.wg.Add(1)
+	go p.sendMessages(s)
+
+	err := p.readSubscriptions(s)
+	if err != nil && err != io.EOF {
+		select {
+		case <-p.quit:
+		default:
+			log.Warnf("ZMQ subscriber %s: %v", conn.RemoteAddr(), err)
+		}
+	}
+
+	// Messages are no longer queued once the subscriber is removed, so
+	// its queue may be closed to stop sendMessages.
+	p.mu.Lock()
+	delete(p.subscribers, s)
+	p.mu.Unlock()
+	close(s.queue)
+	log.Debugf("ZMQ subscriber %s disconnected", conn.RemoteAddr())
+}
+
+// sendMessages writes the messages queued for a subscriber until its queue
+// is closed.  It must be run as a goroutine.
+func (p *Publisher) sendMessages(s *subscriber) {
+	defer p.wg.Done()
+	for msg := range s.queue {
+		if err := writeMessage(s.conn, msg); err != nil {
+			// Closing the connection stops readSubscriptions,
+			// which closes the queue.
+			s.conn.Close()
+			for range s.queue {
+			}
+			return
+		}
+	}
+}
+
+// readSubscriptions reads the subscriptions and cancellations of a
+// subscriber until it disconnects.  Subscriptions are sent as messages
+// starting with 1 by ZMTP 3.0 subscribers, and as SUBSCRIBE commands by ZMTP
+// 3.1 subscribers.
+func (p *Publisher) readSubscriptions(s *subscriber) error {
+	for {
+		flags, body, err := readFrame(s.conn)
+		if err != nil {
+			return err
+		}
+
+		var subscribe bool
+		var topic []byte
+		switch {
+		case flags&flagCommand != 0:
+			name, data, err := parseCommand(body)
+			if err != nil {
+				return err
+			}
+			switch name {
+			case "SUBSCRIBE":
+				subscribe, topic = true, data
+			case "CANCEL":
+				topic = data
+			default:
+				continue
+			}
+		case len(body) != 0 && (body[0] == 0 || body[0] == 1):
+			subscribe, topic = body[0] == 1, body[1:]
+		default:
+			continue
+		}
+
+		p.mu.Lock()
+		if subscribe {
+			s.subscriptions[string(topic)]++
+		} else if s.subscriptions[string(topic)] > 1 {
+			s.subscriptions[string(topic)]--
+		} else {
+			delete(s.subscriptions, string(topic))
+		}
+		p.mu.Unlock()
+	}
+}
+
+// subscribed returns whether a subscriber subscribed to a prefix of topic.
+// The publisher mutex must be held.
+func (s *subscriber) subscribed(topic string) bool {
+	for prefix := range s.subscriptions {
+		if len(prefix) <= len(topic) && topic[:len(prefix)] == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// handshake exchanges the ZMTP 3.0 greeting and READY commands with a
+// subscriber, using the NULL security mechanism.
+func handshake(conn net.Conn) error {
+	// The greeting is written concurrently with reading the greeting of
+	// the subscriber, as peers may wait for each other's signature.
+	errc := make(chan error, 1)
+	go func() {
+		_, err := conn.Write(greeting())
+		errc <- err
+	}()
+	var peer [greetingSize]byte
+	if _, err := io.ReadFull(conn, peer[:]); err != nil {
+		return err
+	}
+	if err := <-errc; err != nil {
+		return err
+	}
+	if peer[0] != 0xff || peer[9] != 0x7f {
+		return errors.New("not a ZMTP peer")
+	}
+	if peer[10] < 3 {
+		return fmt.Errorf("unsupported ZMTP version %d", peer[10])
+	}
+	if mechanism := bytes.TrimRight(peer[12:32], "\x00"); string(mechanism) != "NULL" {
+		return fmt.Errorf("unsupported security mechanism %q", mechanism)
+	}
+
+	err := writeFrame(conn, flagCommand, readyCommand("PUB"))
+	if err != nil {
+		return err
+	}
+	flags, body, err := readFrame(conn)
+	if err != nil {
+		return err
+	}
+	if flags&flagCommand == 0 {
+		return errors.New("expected READY command")
+	}
+	name, data, err := parseCommand(body)
+	if err != nil {
+		return err
+	}
+	if name != "READY" {
+		return fmt.Errorf("expected READY command, got %s", name)
+	}
+	props, err := parseProperties(data)
+	if err != nil {
+		return err
+	}
+	switch socketType := props["Socket-Type"]; socketType {
+	case "SUB", "XSUB":
+	default:
+		return fmt.Errorf("incompatible socket type %q", socketType)
+	}
+	return nil
+}
+
+// greeting returns the ZMTP 3.0 greeting of a publisher using the NULL
+// security mechanism.
+func greeting() []byte {
+	g := make([]byte, greetingSize)
+	g[0] = 0xff
+	g[9] = 0x7f
+	g[10] = 3
+	g[11] = 0
+	copy(g[12:32], "NULL")
+	return g
+}
+
+// readyCommand returns the body of a READY command for a socket type.
+func readyCommand(socketType string) []byte {
+	var b bytes.Buffer
+	b.WriteByte(byte(len("READY")))
+	b.WriteString("READY")
+	b.WriteByte(byte(len("Socket-Type")))
+	b.WriteString("Socket-Type")
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(socketType)))
+	b.Write(size[:])
+	b.WriteString(socketType)
+	return b.Bytes()
+}
+
+// parseCommand returns the name and data of the body of a command frame.
+func parseCommand(body []byte) (string, []byte, error) {
+	if len(body) == 0 || int(body[0]) > len(body)-1 {
+		return "", nil, errors.New("malformed command")
+	}
+	return string(body[1 : 1+body[0]]), body[1+body[0]:], nil
+}
+
+// parseProperties returns the metadata properties of a READY command.
+func parseProperties(data []byte) (map[string]string, error) {
+	props := make(map[string]string)
+	for len(data) != 0 {
+		n := int(data[0])
+		if len(data) < 1+n+4 {
+			return nil, errors.New("malformed metadata")
+		}
+		name := string(data[1 : 1+n])
+		data = data[1+n:]
+		size := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(size) > uint64(len(data)) {
+			return nil, errors.New("malformed metadata")
+		}
+		props[name] = string(data[:size])
+		data = data[size:]
+	}
+	return props, nil
+}
+
+// readFrame reads a frame and returns its flags and body.
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var header [9]byte
+	if _, err := io.ReadFull(r, header[:2]); err != nil {
+		return 0, nil, err
+	}
+	flags := header[0]
+	size := uint64(header[1])
+	if flags&flagLong != 0 {
+		if _, err := io.ReadFull(r, header[2:]); err != nil {
+			return 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(header[1:])
+	}
+	if size > maxFrameSize {
+		return 0, nil, errFrameTooLarge
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return flags, body, nil
+}
+
+// writeFrame writes a frame with flags and a body, using a long size when
+// the body does not fit a short one.
+func writeFrame(w io.Writer, flags byte, body []byte) error {
+	var header []byte
+	if len(body) > 255 {
+		header = make([]byte, 9)
+		header[0] = flags | flagLong
+		binary.BigEndian.PutUint64(header[1:], uint64(len(body)))
+	} else {
+		header = []byte{flags, byte(len(body))}
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// writeMessage writes the frames of a message.
+func writeMessage(w io.Writer, msg [][]byte) error {
+	var b bytes.Buffer
+	for i, frame := range msg {
+		var flags byte
+		if i != len(msg)-1 {
+			flags = flagMore
+		}
+		if err := writeFrame(&b, flags, frame); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package zmqpub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// dialSubscriber connects a ZMTP 3.0 SUB socket to a publisher and subscribes
+// it to topic.
+func dialSubscriber(t *testing.T, addr, topic string) net.Conn {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := conn.Write(greeting()); err != nil {
+		t.Fatal(err)
+	}
+	var g [greetingSize]byte
+	if _, err := io.ReadFull(conn, g[:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFrame(conn, flagCommand, readyCommand("SUB")); err != nil {
+		t.Fatal(err)
+	}
+	flags, body, err := readFrame(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, data, err := parseCommand(body)
+	if err != nil || flags&flagCommand == 0 || name != "READY" {
+		t.Fatalf("unexpected handshake frame %x", body)
+	}
+	props, err := parseProperties(data)
+	if err != nil || props["Socket-Type"] != "PUB" {
+		t.Fatalf("unexpected READY properties %v: %v", props, err)
+	}
+
+	err = writeFrame(conn, 0, append([]byte{1}, topic...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// readMessage reads the frames of a message.
+func readMessage(t *testing.T, conn net.Conn) [][]byte {
+	var msg [][]byte
+	for {
+		flags, body, err := readFrame(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg = append(msg, body)
+		if flags&flagMore == 0 {
+			return msg
+		}
+	}
+}
+
+// TestPublisher checks the ZMTP handshake with subscribers, the filtering of
+// the published messages by topic prefix, and their sequence numbers.
+func TestPublisher(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPublisher([]net.Listener{l})
+	p.Start()
+	defer p.Stop()
+
+	blocks := dialSubscriber(t, l.Addr().String(), "block")
+	defer blocks.Close()
+	all := dialSubscriber(t, l.Addr().String(), "")
+	defer all.Close()
+
+	// Wait for both subscriptions to be read by the publisher.
+	for i := 0; ; i++ {
+		p.mu.Lock()
+		subscribed := 0
+		for s := range p.subscribers {
+			subscribed += len(s.subscriptions)
+		}
+		p.mu.Unlock()
+		if subscribed == 2 {
+			break
+		}
+		if i == 100 {
+			t.Fatal("subscriptions were not received")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	long := bytes.Repeat([]byte{'x'}, 300)
+	p.Publish(TopicBalance, []byte("b0"))
+	p.Publish(TopicBlock, []byte("k0"))
+	p.Publish(TopicBlock, long)
+
+	tests := []struct {
+		conn  net.Conn
+		topic string
+		body  []byte
+		seq   uint32
+	}{
+		{blocks, TopicBlock, []byte("k0"), 0},
+		{blocks, TopicBlock, long, 1},
+		{all, TopicBalance, []byte("b0"), 0},
+		{all, TopicBlock, []byte("k0"), 0},
+		{all, TopicBlock, long, 1},
+	}
+	for i, test := range tests {
+		msg := readMessage(t, test.conn)
+		if len(msg) != 3 {
+			t.Fatalf("%d: message has %d frames", i, len(msg))
+		}
+		if string(msg[0]) != test.topic || !bytes.Equal(msg[1], test.body) {
+			t.Errorf("%d: message %s %q, want %s %q", i, msg[0],
+				msg[1], test.topic, test.body)
+		}
+		if seq := binary.LittleEndian.Uint32(msg[2]); seq != test.seq {
+			t.Errorf("%d: sequence %d, want %d", i, seq, test.seq)
+		}
+	}
+}
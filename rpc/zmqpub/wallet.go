@@ -0,0 +1,221 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package zmqpub
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// Topics of the wallet events.
+const (
+	// TopicReceivedTx is the topic of the transactions paying to external
+	// addresses of the wallet, published when they are first seen unmined
+	// and again when they are mined.
+	TopicReceivedTx = "recvtx"
+
+	// TopicSpent is the topic of the wallet outputs spent by transactions,
+	// published when the spending transactions are first seen unmined and
+	// again when they are mined.
+	TopicSpent = "spent"
+
+	// TopicBalance is the topic of the new total balances of the accounts
+	// whose balances changed.
+	TopicBalance = "balance"
+
+	// TopicBlock is the topic of the blocks connected and disconnected by
+	// the wallet as it syncs with the chain.
+	TopicBlock = "block"
+)
+
+// ReceivedTxEvent is the body of the messages of TopicReceivedTx.  Height is
+// -1 for unmined transactions.
+type ReceivedTxEvent struct {
+	TxID      string           `json:"txid"`
+	Hex       string           `json:"hex"`
+	BlockHash string           `json:"blockhash,omitempty"`
+	Height    int32            `json:"height"`
+	Outputs   []ReceivedOutput `json:"outputs"`
+}
+
+// ReceivedOutput is an output of a ReceivedTxEvent paying to the wallet.
+type ReceivedOutput struct {
+	Vout    uint32  `json:"vout"`
+	Address string  `json:"address"`
+	Account uint32  `json:"account"`
+	Amount  float64 `json:"amount"`
+}
+
+// SpentEvent is the body of the messages of TopicSpent.  Height is -1 for
+// unmined spending transactions.
+type SpentEvent struct {
+	TxID         string  `json:"txid"`
+	Vout         uint32  `json:"vout"`
+	Account      uint32  `json:"account"`
+	Amount       float64 `json:"amount"`
+	SpendingTxID string  `json:"spendingtxid"`
+	SpendingVin  uint32  `json:"spendingvin"`
+	Height       int32   `json:"height"`
+}
+
+// BalanceEvent is the body of the messages of TopicBalance.
+type BalanceEvent struct {
+	Account     uint32  `json:"account"`
+	AccountName string  `json:"accountname"`
+	Balance     float64 `json:"balance"`
+}
+
+// BlockEvent is the body of the messages of TopicBlock.
+type BlockEvent struct {
+	Hash         string `json:"hash"`
+	Height       int32  `json:"height"`
+	Time         int64  `json:"time"`
+	Disconnected bool   `json:"disconnected"`
+}
+
+// PublishWallet publishes the events of a wallet until the publisher is
+// stopped.
+func (p *Publisher) PublishWallet(w *wallet.Wallet) {
+	txs := w.NtfnServer.TransactionNotifications()
+	balances := w.NtfnServer.BalanceNotifications()
+	blocks := w.NtfnServer.BlockNotifications()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer txs.Done()
+		defer balances.Done()
+		defer blocks.Done()
+		for {
+			select {
+			case n := <-txs.C:
+				p.publishTransactions(w, n)
+			case n := <-balances.C:
+				p.publishBalances(w, n)
+			case n := <-blocks.C:
+				p.publishBlock(n)
+			case <-p.quit:
+				return
+			}
+		}
+	}()
+}
+
+// publishTransactions publishes the received transactions and spent outputs
+// of a transaction notification.
+func (p *Publisher) publishTransactions(w *wallet.Wallet,
+	n *wallet.TransactionNotifications) {
+
+	for i := range n.UnminedTransactions {
+		p.publishTransaction(w, &n.UnminedTransactions[i], nil, -1)
+	}
+	for _, b := range n.AttachedBlocks {
+		for i := range b.Transactions {
+			p.publishTransaction(w, &b.Transactions[i], &b, b.Height)
+		}
+	}
+}
+
+// publishTransaction publishes a transaction when it pays to external wallet
+// addresses, and the wallet outputs it spends.
+func (p *Publisher) publishTransaction(w *wallet.Wallet,
+	tx *wallet.TransactionSummary, block *wallet.Block, height int32) {
+
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(tx.Transaction)); err != nil {
+		log.Errorf("Cannot deserialize transaction %v: %v", tx.Hash, err)
+		return
+	}
+
+	recv := &ReceivedTxEvent{
+		TxID:   tx.Hash.String(),
+		Height: height,
+	}
+	if block != nil {
+		recv.BlockHash = block.Hash.String()
+	}
+	for _, output := range tx.MyOutputs {
+		if output.Internal || int(output.Index) >= len(msgTx.TxOut) {
+			continue
+		}
+		txOut := msgTx.TxOut[output.Index]
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+			txOut.PkScript, w.ChainParams(),
+		)
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+		recv.Outputs = append(recv.Outputs, ReceivedOutput{
+			Vout:    output.Index,
+			Address: addrs[0].EncodeAddress(),
+			Account: output.Account,
+			Amount:  btcutil.Amount(txOut.Value).ToBTC(),
+		})
+	}
+	if len(recv.Outputs) != 0 {
+		recv.Hex = hex.EncodeToString(tx.Transaction)
+		p.publishJSON(TopicReceivedTx, recv)
+	}
+
+	for _, input := range tx.MyInputs {
+		if int(input.Index) >= len(msgTx.TxIn) {
+			continue
+		}
+		prevOut := &msgTx.TxIn[input.Index].PreviousOutPoint
+		p.publishJSON(TopicSpent, &SpentEvent{
+			TxID:         prevOut.Hash.String(),
+			Vout:         prevOut.Index,
+			Account:      input.PreviousAccount,
+			Amount:       input.PreviousAmount.ToBTC(),
+			SpendingTxID: tx.Hash.String(),
+			SpendingVin:  input.Index,
+			Height:       height,
+		})
+	}
+}
+
+// publishBalances publishes the new balances of a balance notification.
+func (p *Publisher) publishBalances(w *wallet.Wallet, n *wallet.BalanceNotification) {
+	for _, b := range n.Balances {
+		name, err := w.AccountName(waddrmgr.KeyScopeBIP0044, b.Account)
+		if err != nil {
+			log.Errorf("Unable to look up account %d: %v", b.Account,
+				err)
+			continue
+		}
+		p.publishJSON(TopicBalance, &BalanceEvent{
+			Account:     b.Account,
+			AccountName: name,
+			Balance:     b.TotalBalance.ToBTC(),
+		})
+	}
+}
+
+// publishBlock publishes a block notification.
+func (p *Publisher) publishBlock(n *wallet.BlockNotification) {
+	p.publishJSON(TopicBlock, &BlockEvent{
+		Hash:         n.Hash.String(),
+		Height:       n.Height,
+		Time:         n.Time.Unix(),
+		Disconnected: n.Disconnected,
+	})
+}
+
+// publishJSON publishes an event with a JSON body.
+func (p *Publisher) publishJSON(topic string, event interface{}) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Errorf("Cannot marshal %s event: %v", topic, err)
+		return
+	}
+	p.Publish(topic, body)
+}
@@ -5,7 +5,10 @@
 package main
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -17,8 +20,10 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/cfgutil"
 	"github.com/btcsuite/btcwallet/rpc/legacyrpc"
 	"github.com/btcsuite/btcwallet/rpc/rpcserver"
+	"github.com/btcsuite/btcwallet/rpc/zmqpub"
 	"github.com/btcsuite/btcwallet/wallet"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -102,6 +107,63 @@ func generateRPCKeyPair(writeKey bool) (tls.Certificate, error) {
 	return keyPair, nil
 }
 
+// loadClientCertPins reads the client certificates pinned with
+// --rpcclientcert, and returns the SHA256 hashes of their DER encodings.
+func loadClientCertPins() (map[[sha256.Size]byte]struct{}, error) {
+	pins := make(map[[sha256.Size]byte]struct{})
+	for _, path := range cfg.RPCClientCerts {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var n int
+		for {
+			var block *pem.Block
+			block, b = pem.Decode(b)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+			pins[sha256.Sum256(block.Bytes)] = struct{}{}
+			n++
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("%s: no PEM certificate", path)
+		}
+	}
+	return pins, nil
+}
+
+// verifyPinnedClientCert returns a function verifying that TLS clients
+// present one of the pinned certificates, which is not expired.  Pinned
+// certificates are compared exactly, so self-signed certificates of frontends
+// may be pinned without a certificate authority.
+func verifyPinnedClientCert(pins map[[sha256.Size]byte]struct{}) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("no client certificate")
+		}
+		if _, ok := pins[sha256.Sum256(rawCerts[0])]; !ok {
+			return errors.New("client certificate is not pinned")
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+			return fmt.Errorf("pinned client certificate %q is "+
+				"expired or not yet valid", cert.Subject.CommonName)
+		}
+		return nil
+	}
+}
+
 func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Server, error) {
 	var (
 		server       *grpc.Server
@@ -117,6 +179,8 @@ func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Serv
 		if err != nil {
 			return nil, nil, err
 		}
+		log.Infof("RPC server certificate SHA256 fingerprint: %x",
+			sha256.Sum256(keyPair.Certificate[0]))
 
 		// Change the standard net.Listen function to the tls one.
 		tlsConfig := &tls.Config{
@@ -124,6 +188,20 @@ func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Serv
 			MinVersion:   tls.VersionTLS12,
 			NextProtos:   []string{"h2"}, // HTTP/2 over TLS
 		}
+
+		// Require clients to present a pinned certificate when
+		// client certificates are pinned.  The certificate chain is
+		// not verified, as pinned certificates are trusted as is.
+		if len(cfg.RPCClientCerts) != 0 {
+			pins, err := loadClientCertPins()
+			if err != nil {
+				return nil, nil, err
+			}
+			tlsConfig.ClientAuth = tls.RequireAnyClientCert
+			tlsConfig.VerifyPeerCertificate = verifyPinnedClientCert(pins)
+			log.Infof("Requiring one of %d pinned RPC client "+
+				"certificates", len(pins))
+		}
 		legacyListen = func(net string, laddr string) (net.Listener, error) {
 			return tls.Listen(net, laddr, tlsConfig)
 		}
@@ -134,7 +212,7 @@ func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Serv
 				err := errors.New("failed to create listeners for RPC server")
 				return nil, nil, err
 			}
-			creds := credentials.NewServerTLSFromCert(&keyPair)
+			creds := credentials.NewTLS(tlsConfig)
 			server = grpc.NewServer(grpc.Creds(creds))
 			rpcserver.StartVersionService(server)
 			rpcserver.StartWalletLoaderService(server, walletLoader, activeNet)
@@ -162,8 +240,22 @@ func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Serv
 		opts := legacyrpc.Options{
 			Username:            cfg.Username,
 			Password:            cfg.Password,
+			BlindedUsername:     cfg.BlindedUsername,
+			BlindedPassword:     cfg.BlindedPassword,
+			DisableSharedAuth:   cfg.RPCNoSharedAuth,
+			AllowedOrigins:      cfg.RPCAllowedOrigins,
 			MaxPOSTClients:      cfg.LegacyRPCMaxClients,
 			MaxWebsocketClients: cfg.LegacyRPCMaxWebsockets,
+
+			WebsocketCompression:    !cfg.RPCWSNoCompression,
+			MaxWebsocketFrameSize:   cfg.RPCWSMaxFrameSize,
+			MaxWebsocketMessageSize: cfg.RPCWSMaxMessageSize,
+		}
+		if cfg.DiagnosticsInterval > 0 {
+			opts.Diagnostics = diagnosticsRecorder
+		}
+		opts.ConfigFingerprint = func() *cfgutil.Fingerprint {
+			return checkConfigFingerprint(walletLoader)
 		}
 		legacyServer = legacyrpc.NewServer(&opts, walletLoader, listeners)
 	}
@@ -176,6 +268,19 @@ func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Serv
 	return server, legacyServer, nil
 }
 
+// startZMQPublisher creates and starts the ZMQ publisher of the wallet
+// events, bound to the --zmqpub addresses.  The connections of subscribers
+// are subject to the --rpcallow and --rpcdeny rules.
+func startZMQPublisher() (*zmqpub.Publisher, error) {
+	listeners := makeListeners(cfg.ZMQPub, net.Listen)
+	if len(listeners) == 0 {
+		return nil, errors.New("failed to create listeners for ZMQ publisher")
+	}
+	publisher := zmqpub.NewPublisher(listeners)
+	publisher.Start()
+	return publisher, nil
+}
+
 type listenFunc func(net string, laddr string) (net.Listener, error)
 
 // makeListeners splits the normalized listen addresses into IPv4 and IPv6
@@ -227,7 +332,7 @@ func makeListeners(normalizedListenAddrs []string, listen listenFunc) []net.List
 			log.Warnf("Can't listen on %s: %v", addr, err)
 			continue
 		}
-		listeners = append(listeners, listener)
+		listeners = append(listeners, filterListener(listener, addr))
 	}
 	for _, addr := range ipv6Addrs {
 		listener, err := listen("tcp6", addr)
@@ -235,11 +340,86 @@ func makeListeners(normalizedListenAddrs []string, listen listenFunc) []net.List
 			log.Warnf("Can't listen on %s: %v", addr, err)
 			continue
 		}
-		listeners = append(listeners, listener)
+		listeners = append(listeners, filterListener(listener, addr))
 	}
 	return listeners
 }
 
+// connFilter holds the --rpcallow and --rpcdeny networks applying to a
+// listener.
+type connFilter struct {
+	allow, deny []*net.IPNet
+}
+
+// permits returns whether connections from ip are accepted.  Denied networks
+// take precedence, and when there are allowed networks, only connections
+// from them are accepted.
+func (f *connFilter) permits(ip net.IP) bool {
+	for _, network := range f.deny {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, network := range f.allow {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// filteredListener is a listener closing the connections refused by its
+// filter as soon as they are accepted, before any TLS handshake or
+// authentication.
+type filteredListener struct {
+	net.Listener
+	filter *connFilter
+}
+
+// Accept waits for and returns the next connection permitted by the filter.
+func (l *filteredListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok &&
+			l.filter.permits(addr.IP) {
+			return conn, nil
+		}
+		log.Warnf("Refused RPC connection from %s on %s",
+			conn.RemoteAddr(), l.Addr())
+		conn.Close()
+	}
+}
+
+// filterListener returns the listener of addr filtered by the --rpcallow and
+// --rpcdeny rules applying to it, or the listener itself when no rule
+// applies.  The rules are validated when the config is loaded.
+func filterListener(listener net.Listener, addr string) net.Listener {
+	var filter connFilter
+	rules := func(rules []string) []*net.IPNet {
+		var networks []*net.IPNet
+		for _, rule := range rules {
+			network, ruleListener, err := parseListenerRule(rule)
+			if err != nil || (ruleListener != "" && ruleListener != addr) {
+				continue
+			}
+			networks = append(networks, network)
+		}
+		return networks
+	}
+	filter.allow = rules(cfg.RPCAllow)
+	filter.deny = rules(cfg.RPCDeny)
+	if len(filter.allow) == 0 && len(filter.deny) == 0 {
+		return listener
+	}
+	return &filteredListener{Listener: listener, filter: &filter}
+}
+
 // startWalletRPCServices associates each of the (optionally-nil) RPC servers
 // with a wallet to enable remote wallet access.  For the GRPC server, this
 // registers the WalletService service, and for the legacy JSON-RPC server it
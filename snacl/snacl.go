@@ -10,12 +10,15 @@ import (
 	"crypto/subtle"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"math"
 	"runtime/debug"
 
 	"github.com/btcsuite/btcwallet/internal/zero"
 	"github.com/btcsuite/golangcrypto/nacl/secretbox"
 	"github.com/btcsuite/golangcrypto/scrypt"
+	"golang.org/x/crypto/argon2"
 )
 
 var (
@@ -38,8 +41,40 @@ const (
 	DefaultN  = 16384 // 2^14
 	DefaultR  = 8
 	DefaultP  = 1
+
+	// Default Argon2id costs, as recommended by RFC 9106 for memory
+	// constrained environments.
+	DefaultArgon2Memory  = 64 * 1024 // KiB
+	DefaultArgon2Time    = 3
+	DefaultArgon2Threads = 4
 )
 
+// KDF identifies the key derivation function deriving secret keys from
+// passphrases.
+type KDF uint8
+
+// The supported key derivation functions.
+const (
+	// KDFScrypt derives keys with scrypt, using the N, R and P
+	// parameters.
+	KDFScrypt KDF = iota
+
+	// KDFArgon2id derives keys with Argon2id, using the Memory, Time and
+	// Threads parameters.
+	KDFArgon2id
+)
+
+// String returns the name of the key derivation function.
+func (kdf KDF) String() string {
+	switch kdf {
+	case KDFScrypt:
+		return "scrypt"
+	case KDFArgon2id:
+		return "argon2id"
+	}
+	return fmt.Sprintf("KDF(%d)", uint8(kdf))
+}
+
 // CryptoKey represents a secret key which can be used to encrypt and decrypt
 // data.
 type CryptoKey [KeySize]byte
@@ -97,9 +132,17 @@ func GenerateCryptoKey() (*CryptoKey, error) {
 type Parameters struct {
 	Salt   [KeySize]byte
 	Digest [sha256.Size]byte
-	N      int
-	R      int
-	P      int
+	KDF    KDF
+
+	// Costs of scrypt.
+	N int
+	R int
+	P int
+
+	// Costs of Argon2id, with the memory in KiB.
+	Memory  uint32
+	Time    uint32
+	Threads uint8
 }
 
 // SecretKey houses a crypto key and the parameters needed to derive it from a
@@ -111,13 +154,28 @@ type SecretKey struct {
 
 // deriveKey fills out the Key field.
 func (sk *SecretKey) deriveKey(password *[]byte) error {
-	key, err := scrypt.Key(*password, sk.Parameters.Salt[:],
-		sk.Parameters.N,
-		sk.Parameters.R,
-		sk.Parameters.P,
-		len(sk.Key))
-	if err != nil {
-		return err
+	params := &sk.Parameters
+	var key []byte
+	switch params.KDF {
+	case KDFScrypt:
+		var err error
+		key, err = scrypt.Key(*password, params.Salt[:], params.N,
+			params.R, params.P, len(sk.Key))
+		if err != nil {
+			return err
+		}
+
+	case KDFArgon2id:
+		// Argon2id panics on costs that can not be stored by
+		// NewArgon2idSecretKey.
+		if params.Time < 1 || params.Threads < 1 {
+			return ErrMalformed
+		}
+		key = argon2.IDKey(*password, params.Salt[:], params.Time,
+			params.Memory, params.Threads, uint32(len(sk.Key)))
+
+	default:
+		return ErrMalformed
 	}
 	copy(sk.Key[:], key)
 	zero.Bytes(key)
@@ -127,7 +185,8 @@ func (sk *SecretKey) deriveKey(password *[]byte) error {
 	// between means you end up needing twice the amount of memory.  For
 	// example, if your scrypt parameters are such that you require 1GB and
 	// you call it twice in a row, without this you end up allocating 2GB
-	// since the first GB probably hasn't been released yet.
+	// since the first GB probably hasn't been released yet.  The same
+	// holds for Argon2id.
 	debug.FreeOSMemory()
 
 	return nil
@@ -142,18 +201,32 @@ func (sk *SecretKey) Marshal() []byte {
 	//   <salt><digest><N><R><P>
 	//
 	// KeySize + sha256.Size + N (8 bytes) + R (8 bytes) + P (8 bytes)
-	marshalled := make([]byte, KeySize+sha256.Size+24)
+	//
+	// Argon2id params store the memory, time and threads costs in place
+	// of N, R and P, followed by a byte identifying the KDF.  Scrypt
+	// params omit the byte so they remain readable by older versions.
+	size := KeySize + sha256.Size + 24
+	n, r, p := uint64(params.N), uint64(params.R), uint64(params.P)
+	if params.KDF != KDFScrypt {
+		size++
+		n, r, p = uint64(params.Memory), uint64(params.Time),
+			uint64(params.Threads)
+	}
+	marshalled := make([]byte, size)
 
 	b := marshalled
 	copy(b[:KeySize], params.Salt[:])
 	b = b[KeySize:]
 	copy(b[:sha256.Size], params.Digest[:])
 	b = b[sha256.Size:]
-	binary.LittleEndian.PutUint64(b[:8], uint64(params.N))
+	binary.LittleEndian.PutUint64(b[:8], n)
 	b = b[8:]
-	binary.LittleEndian.PutUint64(b[:8], uint64(params.R))
+	binary.LittleEndian.PutUint64(b[:8], r)
 	b = b[8:]
-	binary.LittleEndian.PutUint64(b[:8], uint64(params.P))
+	binary.LittleEndian.PutUint64(b[:8], p)
+	if params.KDF != KDFScrypt {
+		b[8] = byte(params.KDF)
+	}
 
 	return marshalled
 }
@@ -166,23 +239,44 @@ func (sk *SecretKey) Unmarshal(marshalled []byte) error {
 	}
 
 	// The marshalled format for the the params is as follows:
-	//   <salt><digest><N><R><P>
+	//   <salt><digest><N><R><P>[<KDF>]
 	//
 	// KeySize + sha256.Size + N (8 bytes) + R (8 bytes) + P (8 bytes)
-	if len(marshalled) != KeySize+sha256.Size+24 {
+	// + KDF (1 byte, only for other KDFs than scrypt)
+	const scryptSize = KeySize + sha256.Size + 24
+	kdf := KDFScrypt
+	switch len(marshalled) {
+	case scryptSize:
+	case scryptSize + 1:
+		kdf = KDF(marshalled[scryptSize])
+		if kdf != KDFArgon2id {
+			return ErrMalformed
+		}
+	default:
 		return ErrMalformed
 	}
 
 	params := &sk.Parameters
+	params.KDF = kdf
 	copy(params.Salt[:], marshalled[:KeySize])
 	marshalled = marshalled[KeySize:]
 	copy(params.Digest[:], marshalled[:sha256.Size])
 	marshalled = marshalled[sha256.Size:]
-	params.N = int(binary.LittleEndian.Uint64(marshalled[:8]))
+	n := binary.LittleEndian.Uint64(marshalled[:8])
 	marshalled = marshalled[8:]
-	params.R = int(binary.LittleEndian.Uint64(marshalled[:8]))
+	r := binary.LittleEndian.Uint64(marshalled[:8])
 	marshalled = marshalled[8:]
-	params.P = int(binary.LittleEndian.Uint64(marshalled[:8]))
+	p := binary.LittleEndian.Uint64(marshalled[:8])
+
+	if kdf == KDFArgon2id {
+		if n > math.MaxUint32 || r > math.MaxUint32 || p > math.MaxUint8 {
+			return ErrMalformed
+		}
+		params.Memory, params.Time, params.Threads = uint32(n),
+			uint32(r), uint8(p)
+		return nil
+	}
+	params.N, params.R, params.P = int(n), int(r), int(p)
 
 	return nil
 }
@@ -223,13 +317,39 @@ func (sk *SecretKey) Decrypt(in []byte) ([]byte, error) {
 
 // NewSecretKey returns a SecretKey structure based on the passed parameters.
 func NewSecretKey(password *[]byte, N, r, p int) (*SecretKey, error) {
+	return newSecretKey(password, Parameters{
+		KDF: KDFScrypt,
+		N:   N,
+		R:   r,
+		P:   p,
+	})
+}
+
+// NewArgon2idSecretKey returns a SecretKey structure derived with Argon2id
+// from the passed costs, with the memory in KiB.
+func NewArgon2idSecretKey(password *[]byte, memory, time uint32,
+	threads uint8) (*SecretKey, error) {
+
+	if time < 1 || threads < 1 {
+		return nil, errors.New("argon2id time and threads must be " +
+			"at least 1")
+	}
+	return newSecretKey(password, Parameters{
+		KDF:     KDFArgon2id,
+		Memory:  memory,
+		Time:    time,
+		Threads: threads,
+	})
+}
+
+// newSecretKey returns a SecretKey structure derived with the KDF and costs
+// of params and a random salt.
+func newSecretKey(password *[]byte, params Parameters) (*SecretKey, error) {
 	sk := SecretKey{
-		Key: (*CryptoKey)(&[KeySize]byte{}),
+		Key:        (*CryptoKey)(&[KeySize]byte{}),
+		Parameters: params,
 	}
 	// setup parameters
-	sk.Parameters.N = N
-	sk.Parameters.R = r
-	sk.Parameters.P = p
 	_, err := io.ReadFull(prng, sk.Parameters.Salt[:])
 	if err != nil {
 		return nil, err
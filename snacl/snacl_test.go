@@ -114,3 +114,55 @@ func TestDeriveKeyInvalid(t *testing.T) {
 		t.Errorf("unexpected DeriveKey key failure: %v", err)
 	}
 }
+
+func TestArgon2idSecretKey(t *testing.T) {
+	sk, err := NewArgon2idSecretKey(&password, 1024, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var unmarshalled SecretKey
+	if err := unmarshalled.Unmarshal(sk.Marshal()); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if unmarshalled.Parameters != sk.Parameters {
+		t.Fatalf("unmarshalled parameters %+v, want %+v",
+			unmarshalled.Parameters, sk.Parameters)
+	}
+	if err := unmarshalled.DeriveKey(&password); err != nil {
+		t.Fatalf("unexpected DeriveKey error: %v", err)
+	}
+	if !bytes.Equal(unmarshalled.Key[:], sk.Key[:]) {
+		t.Errorf("keys not equal")
+	}
+
+	bogusPass := []byte("bogus")
+	if err := unmarshalled.DeriveKey(&bogusPass); err != ErrInvalidPassword {
+		t.Errorf("unexpected DeriveKey key failure: %v", err)
+	}
+
+	// Scrypt parameters are not marshalled with a KDF so they remain
+	// readable by older versions.
+	if len(key.Marshal())+1 != len(sk.Marshal()) {
+		t.Errorf("unexpected marshalled sizes")
+	}
+}
+
+func TestArgon2idSecretKeyInvalid(t *testing.T) {
+	if _, err := NewArgon2idSecretKey(&password, 1024, 0, 1); err == nil {
+		t.Errorf("zero time did not fail")
+	}
+	if _, err := NewArgon2idSecretKey(&password, 1024, 1, 0); err == nil {
+		t.Errorf("zero threads did not fail")
+	}
+
+	sk, err := NewArgon2idSecretKey(&password, 1024, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marshalled := sk.Marshal()
+	marshalled[len(marshalled)-1] = 0xff
+	if err := sk.Unmarshal(marshalled); err != ErrMalformed {
+		t.Errorf("unknown KDF did not fail: %v", err)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/internal/taproot"
 	"github.com/btcsuite/btcwallet/internal/zero"
 	"github.com/btcsuite/btcwallet/walletdb"
 )
@@ -47,6 +48,11 @@ const (
 	// WitnessPubKey represents a p2wkh (pay-to-witness-key-hash) address
 	// type.
 	WitnessPubKey
+
+	// TaprootPubKey represents a p2tr (pay-to-taproot) address type which
+	// commits to the public key with no script tree, as described by
+	// BIP0086.
+	TaprootPubKey
 )
 
 // ManagedAddress is an interface that provides acces to information regarding
@@ -143,10 +149,10 @@ var _ ManagedPubKeyAddress = (*managedAddress)(nil)
 
 // unlock decrypts and stores a pointer to the associated private key.  It will
 // fail if the key is invalid or the encrypted private key is not available.
-// The returned clear text private key will always be a copy that may be safely
-// used by the caller without worrying about it being zeroed during an address
-// lock.
-func (a *managedAddress) unlock(key EncryptorDecryptor) ([]byte, error) {
+// The returned clear text private key will always be a copy in a secure
+// buffer that may be safely used by the caller without worrying about it
+// being zeroed during an address lock.  The caller must destroy the buffer.
+func (a *managedAddress) unlock(key EncryptorDecryptor) (*zero.Buffer, error) {
 	// Protect concurrent access to clear text private key.
 	a.privKeyMutex.Lock()
 	defer a.privKeyMutex.Unlock()
@@ -162,8 +168,8 @@ func (a *managedAddress) unlock(key EncryptorDecryptor) ([]byte, error) {
 		a.privKeyCT = privKey
 	}
 
-	privKeyCopy := make([]byte, len(a.privKeyCT))
-	copy(privKeyCopy, a.privKeyCT)
+	privKeyCopy := zero.NewBuffer(len(a.privKeyCT))
+	copy(privKeyCopy.Bytes(), a.privKeyCT)
 	return privKeyCopy, nil
 }
 
@@ -213,6 +219,8 @@ func (a *managedAddress) AddrHash() []byte {
 		hash = n.Hash160()[:]
 	case *btcutil.AddressWitnessPubKeyHash:
 		hash = n.Hash160()[:]
+	case *taproot.AddressTaproot:
+		hash = n.OutputKey()
 	}
 
 	return hash
@@ -285,8 +293,9 @@ func (a *managedAddress) PrivKey() (*btcec.PrivateKey, error) {
 	a.manager.mtx.Lock()
 	defer a.manager.mtx.Unlock()
 
-	// Account manager must be unlocked to decrypt the private key.
-	if a.manager.rootManager.IsLocked() {
+	// The account of the address must be unlocked to decrypt the private
+	// key.
+	if a.manager.accountLocked(a.derivationPath.Account) {
 		return nil, managerError(ErrLocked, errLocked, nil)
 	}
 
@@ -297,9 +306,9 @@ func (a *managedAddress) PrivKey() (*btcec.PrivateKey, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer privKeyCopy.Destroy()
 
-	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), privKeyCopy)
-	zero.Bytes(privKeyCopy)
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), privKeyCopy.Bytes())
 	return privKey, nil
 }
 
@@ -404,6 +413,16 @@ func newManagedAddressWithoutPrivKey(m *ScopedKeyManager,
 		if err != nil {
 			return nil, err
 		}
+
+	case TaprootPubKey:
+		// Taproot addresses pay to the tweaked output key rather than
+		// a hash of the public key, and are always compressed.
+		address, err = taproot.NewAddressTaprootFromPubKey(
+			pubKey, m.rootManager.chainParams,
+		)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &managedAddress{
@@ -210,8 +210,25 @@ var (
 	// account_id => string
 	acctIDIdxBucketName = []byte("acctididx")
 
+	// acctPathBucketName is the name of the bucket, created under a scope
+	// bucket with the first account bound to a custom derivation path,
+	// that maps the number of such accounts to the serialized derivation
+	// path of their account key from the master HD key.
+	//
+	// account_id => path
+	acctPathBucketName = []byte("acctpath")
+
+	// acctPassphraseBucketName is the name of the bucket, created with the
+	// first account protected by its own passphrase, that maps the number
+	// of such accounts to the parameters of the account master key and
+	// the crypto private key encrypted with it.
+	//
+	// account_id => <paramslen><params><enccryptokeypriv>
+	acctPassphraseBucketName = []byte("acctpassphrase")
+
 	// usedAddrBucketName is the name of the bucket that stores an
-	// addresses hash if the address has been used or not.
+	// addresses hash if the address has been used or not, along with the
+	// times the address was first seen and last used on the chain.
 	usedAddrBucketName = []byte("usedaddrs")
 
 	// meta is used to store meta-data about the address manager
@@ -238,6 +255,12 @@ var (
 	// encryption key. This reside under the main bucket.
 	masterHDPubName = []byte("mhdpub")
 
+	// masterFingerprintName is the name of the key that stores the
+	// fingerprint of the master HD key of watching-only managers created
+	// from an account extended public key, which do not have the master
+	// HD public key.  This resides under the main bucket.
+	masterFingerprintName = []byte("mfingerprint")
+
 	// syncBucketName is the name of the bucket that stores the current
 	// sync state of the root manager.
 	syncBucketName = []byte("sync")
@@ -587,6 +610,31 @@ func fetchMasterHDKeys(ns walletdb.ReadBucket) ([]byte, []byte, error) {
 	return masterHDPrivEnc, masterHDPubEnc, nil
 }
 
+// putMasterFingerprint stores the fingerprint of the master HD key in the
+// top level main bucket.
+func putMasterFingerprint(ns walletdb.ReadWriteBucket, fingerprint []byte) error {
+	bucket := ns.NestedReadWriteBucket(mainBucketName)
+
+	err := bucket.Put(masterFingerprintName, fingerprint)
+	if err != nil {
+		str := "failed to store master HD key fingerprint"
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// fetchMasterFingerprint loads the fingerprint of the master HD key from the
+// database.  It is nil when the fingerprint was not stored.
+func fetchMasterFingerprint(ns walletdb.ReadBucket) []byte {
+	bucket := ns.NestedReadBucket(mainBucketName)
+
+	fingerprint := bucket.Get(masterFingerprintName)
+	if fingerprint == nil {
+		return nil
+	}
+	return append([]byte(nil), fingerprint...)
+}
+
 // fetchCryptoKeys loads the encrypted crypto keys which are in turn used to
 // protect the extended keys, imported keys, and scripts.  Any of the returned
 // values can be nil, but in practice only the crypto private and script keys
@@ -1109,6 +1157,198 @@ func putAccountInfo(ns walletdb.ReadWriteBucket, scope *KeyScope,
 	return nil
 }
 
+// putAccountPath stores the derivation path from the master HD key of an
+// account bound to a custom path.
+func putAccountPath(ns walletdb.ReadWriteBucket, scope *KeyScope,
+	account uint32, path []uint32) error {
+
+	scopedBucket, err := fetchWriteScopeBucket(ns, scope)
+	if err != nil {
+		return err
+	}
+
+	bucket, err := scopedBucket.CreateBucketIfNotExists(acctPathBucketName)
+	if err != nil {
+		str := "failed to create account path bucket"
+		return managerError(ErrDatabase, str, err)
+	}
+
+	serialized := make([]byte, 0, 4*len(path))
+	for _, index := range path {
+		serialized = append(serialized, uint32ToBytes(index)...)
+	}
+	err = bucket.Put(uint32ToBytes(account), serialized)
+	if err != nil {
+		str := fmt.Sprintf("failed to store path of account %d", account)
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// fetchAccountPath loads the derivation path from the master HD key of an
+// account bound to a custom path.  It is nil for accounts derived from the
+// coin type key of their scope.
+func fetchAccountPath(ns walletdb.ReadBucket, scope *KeyScope,
+	account uint32) ([]uint32, error) {
+
+	scopedBucket, err := fetchReadScopeBucket(ns, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := scopedBucket.NestedReadBucket(acctPathBucketName)
+	if bucket == nil {
+		return nil, nil
+	}
+	serialized := bucket.Get(uint32ToBytes(account))
+	if serialized == nil {
+		return nil, nil
+	}
+	if len(serialized)%4 != 0 {
+		str := fmt.Sprintf("malformed path of account %d", account)
+		return nil, managerError(ErrDatabase, str, nil)
+	}
+
+	path := make([]uint32, len(serialized)/4)
+	for i := range path {
+		path[i] = binary.LittleEndian.Uint32(serialized[4*i:])
+	}
+	return path, nil
+}
+
+// dbAccountPassphrase is the record of an account protected by its own
+// passphrase.
+type dbAccountPassphrase struct {
+	masterKeyParams        []byte
+	cryptoKeyPrivEncrypted []byte
+}
+
+// serializeAccountPassphrase returns the serialization of the record of an
+// account protected by its own passphrase.
+func serializeAccountPassphrase(row *dbAccountPassphrase) []byte {
+	// The serialized account passphrase format is:
+	//   <paramslen><params><enccryptokeypriv>
+	//
+	// 4 bytes params len + marshalled master key params + encrypted crypto
+	// private key
+	paramsLen := uint32(len(row.masterKeyParams))
+	serialized := make([]byte, 4+paramsLen,
+		4+paramsLen+uint32(len(row.cryptoKeyPrivEncrypted)))
+	binary.LittleEndian.PutUint32(serialized[0:4], paramsLen)
+	copy(serialized[4:], row.masterKeyParams)
+	return append(serialized, row.cryptoKeyPrivEncrypted...)
+}
+
+// deserializeAccountPassphrase deserializes the record of an account
+// protected by its own passphrase.
+func deserializeAccountPassphrase(account uint32,
+	serialized []byte) (*dbAccountPassphrase, error) {
+
+	if len(serialized) < 4 {
+		str := fmt.Sprintf("malformed passphrase of account %d", account)
+		return nil, managerError(ErrDatabase, str, nil)
+	}
+	paramsLen := binary.LittleEndian.Uint32(serialized[0:4])
+	if uint32(len(serialized)-4) < paramsLen {
+		str := fmt.Sprintf("malformed passphrase of account %d", account)
+		return nil, managerError(ErrDatabase, str, nil)
+	}
+
+	row := &dbAccountPassphrase{
+		masterKeyParams: make([]byte, paramsLen),
+		cryptoKeyPrivEncrypted: make([]byte,
+			uint32(len(serialized)-4)-paramsLen),
+	}
+	copy(row.masterKeyParams, serialized[4:4+paramsLen])
+	copy(row.cryptoKeyPrivEncrypted, serialized[4+paramsLen:])
+	return row, nil
+}
+
+// putAccountPassphrase stores the record of an account protected by its own
+// passphrase.
+func putAccountPassphrase(ns walletdb.ReadWriteBucket, scope *KeyScope,
+	account uint32, row *dbAccountPassphrase) error {
+
+	scopedBucket, err := fetchWriteScopeBucket(ns, scope)
+	if err != nil {
+		return err
+	}
+
+	bucket, err := scopedBucket.CreateBucketIfNotExists(
+		acctPassphraseBucketName,
+	)
+	if err != nil {
+		str := "failed to create account passphrase bucket"
+		return managerError(ErrDatabase, str, err)
+	}
+
+	err = bucket.Put(uint32ToBytes(account), serializeAccountPassphrase(row))
+	if err != nil {
+		str := fmt.Sprintf("failed to store passphrase of account %d",
+			account)
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// deleteAccountPassphrase removes the record of an account protected by its
+// own passphrase, if any.
+func deleteAccountPassphrase(ns walletdb.ReadWriteBucket, scope *KeyScope,
+	account uint32) error {
+
+	scopedBucket, err := fetchWriteScopeBucket(ns, scope)
+	if err != nil {
+		return err
+	}
+
+	bucket := scopedBucket.NestedReadWriteBucket(acctPassphraseBucketName)
+	if bucket == nil {
+		return nil
+	}
+	err = bucket.Delete(uint32ToBytes(account))
+	if err != nil {
+		str := fmt.Sprintf("failed to delete passphrase of account %d",
+			account)
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// fetchAccountPassphrases loads the records of every account of a scope that
+// is protected by its own passphrase.
+func fetchAccountPassphrases(ns walletdb.ReadBucket,
+	scope *KeyScope) (map[uint32]*dbAccountPassphrase, error) {
+
+	scopedBucket, err := fetchReadScopeBucket(ns, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[uint32]*dbAccountPassphrase)
+	bucket := scopedBucket.NestedReadBucket(acctPassphraseBucketName)
+	if bucket == nil {
+		return rows, nil
+	}
+	err = bucket.ForEach(func(k, v []byte) error {
+		if len(k) != 4 {
+			str := fmt.Sprintf("malformed account passphrase key %x",
+				k)
+			return managerError(ErrDatabase, str, nil)
+		}
+		account := binary.LittleEndian.Uint32(k)
+		row, err := deserializeAccountPassphrase(account, v)
+		if err != nil {
+			return err
+		}
+		rows[account] = row
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
 // putLastAccount stores the provided metadata - last account - to the
 // database.
 func putLastAccount(ns walletdb.ReadWriteBucket, scope *KeyScope,
@@ -1356,24 +1596,73 @@ func fetchAddressByHash(ns walletdb.ReadBucket, scope *KeyScope,
 	return nil, managerError(ErrDatabase, str, nil)
 }
 
+// dbAddressUsage houses the times an address was first seen and last used on
+// the chain, as unix times.  Addresses flagged used before the times were
+// recorded have unknown times of 0.
+type dbAddressUsage struct {
+	firstSeen uint64
+	lastUsed  uint64
+}
+
+// deserializeAddressUsage deserializes the passed serialized address usage.
+func deserializeAddressUsage(serializedUsage []byte) *dbAddressUsage {
+	// The serialized address usage format is:
+	//   <firstseen><lastused>
+	//
+	// 8 bytes first seen time + 8 bytes last used time
+	//
+	// Addresses flagged used by previous versions have a single null byte
+	// instead.
+	if len(serializedUsage) != 16 {
+		return &dbAddressUsage{}
+	}
+	return &dbAddressUsage{
+		firstSeen: binary.LittleEndian.Uint64(serializedUsage[0:8]),
+		lastUsed:  binary.LittleEndian.Uint64(serializedUsage[8:16]),
+	}
+}
+
+// serializeAddressUsage returns the serialization of the passed address
+// usage.
+func serializeAddressUsage(usage *dbAddressUsage) []byte {
+	buf := make([]byte, 16)
+	binary.LittleEndian.PutUint64(buf[0:8], usage.firstSeen)
+	binary.LittleEndian.PutUint64(buf[8:16], usage.lastUsed)
+	return buf
+}
+
 // fetchAddressUsed returns true if the provided address id was flagged as used.
 func fetchAddressUsed(ns walletdb.ReadBucket, scope *KeyScope,
 	addressID []byte) bool {
 
+	return fetchAddressUsage(ns, scope, addressID) != nil
+}
+
+// fetchAddressUsage returns the times the provided address id was first seen
+// and last used, or nil when it was not flagged as used.
+func fetchAddressUsage(ns walletdb.ReadBucket, scope *KeyScope,
+	addressID []byte) *dbAddressUsage {
+
 	scopedBucket, err := fetchReadScopeBucket(ns, scope)
 	if err != nil {
-		return false
+		return nil
 	}
 
 	bucket := scopedBucket.NestedReadBucket(usedAddrBucketName)
 
 	addrHash := sha256.Sum256(addressID)
-	return bucket.Get(addrHash[:]) != nil
+	val := bucket.Get(addrHash[:])
+	if val == nil {
+		return nil
+	}
+	return deserializeAddressUsage(val)
 }
 
-// markAddressUsed flags the provided address id as used in the database.
+// markAddressUsed flags the provided address id as used in the database at the
+// passed time, which extends the times the address was first seen and last
+// used.
 func markAddressUsed(ns walletdb.ReadWriteBucket, scope *KeyScope,
-	addressID []byte) error {
+	addressID []byte, t time.Time) error {
 
 	scopedBucket, err := fetchWriteScopeBucket(ns, scope)
 	if err != nil {
@@ -1382,13 +1671,25 @@ func markAddressUsed(ns walletdb.ReadWriteBucket, scope *KeyScope,
 
 	bucket := scopedBucket.NestedReadWriteBucket(usedAddrBucketName)
 
+	// The first seen time of addresses flagged used by previous versions
+	// remains unknown, as they were used before any recorded time.
 	addrHash := sha256.Sum256(addressID)
-	val := bucket.Get(addrHash[:])
-	if val != nil {
-		return nil
+	unixTime := uint64(t.Unix())
+	usage := &dbAddressUsage{firstSeen: unixTime, lastUsed: unixTime}
+	if val := bucket.Get(addrHash[:]); val != nil {
+		prev := deserializeAddressUsage(val)
+		if prev.firstSeen == 0 || prev.firstSeen < unixTime {
+			usage.firstSeen = prev.firstSeen
+		}
+		if prev.lastUsed > unixTime {
+			usage.lastUsed = prev.lastUsed
+		}
+		if *usage == *prev && len(val) == 16 {
+			return nil
+		}
 	}
 
-	err = bucket.Put(addrHash[:], []byte{0})
+	err = bucket.Put(addrHash[:], serializeAddressUsage(usage))
 	if err != nil {
 		str := fmt.Sprintf("failed to mark address used %x", addressID)
 		return managerError(ErrDatabase, str, err)
@@ -1793,6 +2094,18 @@ func deletePrivateKeys(ns walletdb.ReadWriteBucket) error {
 			return maybeConvertDbError(err)
 		}
 
+		// Delete the crypto private keys encrypted with the passphrases
+		// of accounts.
+		if managerScopeBucket.NestedReadBucket(acctPassphraseBucketName) != nil {
+			err := managerScopeBucket.DeleteNestedBucket(
+				acctPassphraseBucketName,
+			)
+			if err != nil {
+				str := "failed to delete account passphrases"
+				return managerError(ErrDatabase, str, err)
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -66,6 +66,11 @@ const (
 	// derivation.
 	maxCoinType = hdkeychain.HardenedKeyStart - 1
 
+	// maxAccountPathLen is the maximum number of levels of the derivation
+	// path of an account bound to a custom path, which is the maximum depth
+	// of extended keys.
+	maxAccountPathLen = 255
+
 	// ExternalBranch is the child number to use when performing BIP0044
 	// style hierarchical deterministic key derivation for the external
 	// branch.
@@ -94,10 +99,33 @@ func isReservedAccountNum(acct uint32) bool {
 	return acct == ImportedAddrAccount
 }
 
-// ScryptOptions is used to hold the scrypt parameters needed when deriving new
-// passphrase keys.
+// ScryptOptions is used to hold the key derivation function and its
+// parameters needed when deriving new passphrase keys.  Despite its name, the
+// keys may be derived with Argon2id instead of scrypt.
 type ScryptOptions struct {
+	// KDF is the key derivation function.  The zero value is scrypt.
+	KDF snacl.KDF
+
+	// N, R and P are the costs of scrypt.
 	N, R, P int
+
+	// Memory, in KiB, Time and Threads are the costs of Argon2id.
+	Memory, Time uint32
+	Threads      uint8
+}
+
+// kdfOptions returns the options deriving keys with the same key derivation
+// function and costs as the passed parameters.
+func kdfOptions(params *snacl.Parameters) *ScryptOptions {
+	return &ScryptOptions{
+		KDF:     params.KDF,
+		N:       params.N,
+		R:       params.R,
+		P:       params.P,
+		Memory:  params.Memory,
+		Time:    params.Time,
+		Threads: params.Threads,
+	}
 }
 
 // OpenCallbacks houses caller-provided callbacks that may be called when
@@ -123,6 +151,14 @@ var DefaultScryptOptions = ScryptOptions{
 	P: 1,
 }
 
+// DefaultArgon2idOptions is the default options used with Argon2id.
+var DefaultArgon2idOptions = ScryptOptions{
+	KDF:     snacl.KDFArgon2id,
+	Memory:  snacl.DefaultArgon2Memory,
+	Time:    snacl.DefaultArgon2Time,
+	Threads: snacl.DefaultArgon2Threads,
+}
+
 // addrKey is used to uniquely identify an address even when those addresses
 // would end up being the same bitcoin address (as is the case for
 // pay-to-pubkey and pay-to-pubkey-hash style of addresses).
@@ -182,7 +218,17 @@ type SecretKeyGenerator func(
 // defaultNewSecretKey returns a new secret key.  See newSecretKey.
 func defaultNewSecretKey(passphrase *[]byte,
 	config *ScryptOptions) (*snacl.SecretKey, error) {
-	return snacl.NewSecretKey(passphrase, config.N, config.R, config.P)
+
+	switch config.KDF {
+	case snacl.KDFScrypt:
+		return snacl.NewSecretKey(passphrase, config.N, config.R,
+			config.P)
+	case snacl.KDFArgon2id:
+		return snacl.NewArgon2idSecretKey(passphrase, config.Memory,
+			config.Time, config.Threads)
+	}
+	return nil, fmt.Errorf("unknown key derivation function %v",
+		config.KDF)
 }
 
 var (
@@ -215,6 +261,13 @@ func newSecretKey(passphrase *[]byte,
 	return secretKeyGen(passphrase, config)
 }
 
+// NewSecretKey returns a new secret key derived from a passphrase with the key
+// derivation function and costs of config, using the active secret key
+// generator.
+func NewSecretKey(passphrase *[]byte, config *ScryptOptions) (*snacl.SecretKey, error) {
+	return newSecretKey(passphrase, config)
+}
+
 // EncryptorDecryptor provides an abstraction on top of snacl.CryptoKey so that
 // our tests can use dependency injection to force the behaviour they need.
 type EncryptorDecryptor interface {
@@ -329,6 +382,25 @@ type Manager struct {
 	// manager is already unlocked.  The hash is zeroed each lock.
 	privPassphraseSalt   [saltSize]byte
 	hashedPrivPassphrase [sha512.Size]byte
+
+	// unlockedAccounts is the number of accounts unlocked with their own
+	// passphrases.  The crypto private key remains in memory while it is
+	// not zero, even when the manager is locked.
+	unlockedAccounts int
+}
+
+// hashPassphrase returns the hash of a salted passphrase, which detects a
+// correct passphrase on unlock when the manager is already unlocked.  The
+// salted passphrase is only held in a secure buffer, which is destroyed
+// before returning.
+func hashPassphrase(salt *[saltSize]byte, passphrase []byte) [sha512.Size]byte {
+	buf := zero.NewBuffer(saltSize + len(passphrase))
+	defer buf.Destroy()
+
+	saltedPassphrase := buf.Bytes()
+	copy(saltedPassphrase, salt[:])
+	copy(saltedPassphrase[saltSize:], passphrase)
+	return sha512.Sum512(saltedPassphrase)
 }
 
 // WatchOnly returns true if the root manager is in watch only mode, and false
@@ -367,9 +439,13 @@ func (m *Manager) lock() {
 		}
 	}
 
-	// Remove clear text private master and crypto keys from memory.
+	// Remove clear text private master and crypto keys from memory.  The
+	// crypto private key is kept while accounts unlocked with their own
+	// passphrases need it.
 	m.cryptoKeyScript.Zero()
-	m.cryptoKeyPriv.Zero()
+	if m.unlockedAccounts == 0 {
+		m.cryptoKeyPriv.Zero()
+	}
 	m.masterKeyPriv.Zero()
 
 	// Zero the hashed passphrase.
@@ -508,11 +584,12 @@ func (m *Manager) NewScopedKeyManager(ns walletdb.ReadWriteBucket, scope KeyScop
 	// Finally, we'll register this new scoped manager with the root
 	// manager.
 	m.scopedManagers[scope] = &ScopedKeyManager{
-		scope:       scope,
-		addrSchema:  addrSchema,
-		rootManager: m,
-		addrs:       make(map[addrKey]ManagedAddress),
-		acctInfo:    make(map[uint32]*accountInfo),
+		scope:           scope,
+		addrSchema:      addrSchema,
+		rootManager:     m,
+		addrs:           make(map[addrKey]ManagedAddress),
+		acctInfo:        make(map[uint32]*accountInfo),
+		acctPassphrases: make(map[uint32]*accountPassphrase),
 	}
 	m.externalAddrSchemas[addrSchema.ExternalAddrType] = append(
 		m.externalAddrSchemas[addrSchema.ExternalAddrType], scope,
@@ -601,6 +678,48 @@ func (m *Manager) NeuterRootKey(ns walletdb.ReadWriteBucket) error {
 	return ns.NestedReadWriteBucket(mainBucketName).Delete(masterHDPrivName)
 }
 
+// MasterKeyFingerprint returns the BIP0032 fingerprint of the master HD root
+// key, which identifies the root of derivation paths to external signers.
+// The fingerprint is the first four bytes of the HASH160 of the serialized
+// root public key.  Watching-only managers created from an account key
+// return the fingerprint of the key origin they were created with.
+func (m *Manager) MasterKeyFingerprint(ns walletdb.ReadBucket) ([4]byte, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	var fingerprint [4]byte
+	_, masterHDPubEnc, err := fetchMasterHDKeys(ns)
+	if err != nil {
+		return fingerprint, err
+	}
+	if masterHDPubEnc == nil {
+		stored := fetchMasterFingerprint(ns)
+		if len(stored) != len(fingerprint) {
+			str := "master HD public key is not available"
+			return fingerprint, managerError(ErrNoExist, str, nil)
+		}
+		copy(fingerprint[:], stored)
+		return fingerprint, nil
+	}
+	serKey, err := m.cryptoKeyPub.Decrypt(masterHDPubEnc)
+	if err != nil {
+		str := "failed to decrypt master HD public key"
+		return fingerprint, managerError(ErrCrypto, str, err)
+	}
+	rootPubKey, err := hdkeychain.NewKeyFromString(string(serKey))
+	if err != nil {
+		str := "failed to parse master HD public key"
+		return fingerprint, managerError(ErrKeyChain, str, err)
+	}
+	pubKey, err := rootPubKey.ECPubKey()
+	if err != nil {
+		str := "failed to parse master HD public key"
+		return fingerprint, managerError(ErrKeyChain, str, err)
+	}
+	copy(fingerprint[:], btcutil.Hash160(pubKey.SerializeCompressed()))
+	return fingerprint, nil
+}
+
 // Address returns a managed address given the passed address if it is known to
 // the address manager. A managed address differs from the passed address in
 // that it also potentially contains extra information needed to sign
@@ -630,8 +749,17 @@ func (m *Manager) Address(ns walletdb.ReadBucket,
 	return nil, managerError(ErrAddressNotFound, str, nil)
 }
 
-// MarkUsed updates the used flag for the provided address.
+// MarkUsed updates the used flag for the provided address, recording the
+// current time as the time the address was used.
 func (m *Manager) MarkUsed(ns walletdb.ReadWriteBucket, address btcutil.Address) error {
+	return m.MarkUsedAt(ns, address, time.Now())
+}
+
+// MarkUsedAt updates the used flag for the provided address, which was used by
+// a transaction at the passed time.  See ScopedKeyManager.MarkUsedAt.
+func (m *Manager) MarkUsedAt(ns walletdb.ReadWriteBucket,
+	address btcutil.Address, t time.Time) error {
+
 	m.mtx.RLock()
 	defer m.mtx.RUnlock()
 
@@ -646,7 +774,7 @@ func (m *Manager) MarkUsed(ns walletdb.ReadWriteBucket, address btcutil.Address)
 
 		// We've found the manager that this address belongs to, so we
 		// can mark the address as used and return.
-		return scopedMgr.MarkUsed(ns, address)
+		return scopedMgr.MarkUsedAt(ns, address, t)
 	}
 
 	// If we get to this point, then we weren't able to find the address in
@@ -655,6 +783,25 @@ func (m *Manager) MarkUsed(ns walletdb.ReadWriteBucket, address btcutil.Address)
 	return managerError(ErrAddressNotFound, str, nil)
 }
 
+// AddressUsage returns when the provided address was generated or imported,
+// and when it was first seen and last used on the chain.
+func (m *Manager) AddressUsage(ns walletdb.ReadBucket,
+	address btcutil.Address) (*AddressUsage, error) {
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	for _, scopedMgr := range m.scopedManagers {
+		if _, err := scopedMgr.Address(ns, address); err != nil {
+			continue
+		}
+		return scopedMgr.AddressUsage(ns, address)
+	}
+
+	str := fmt.Sprintf("unable to find key for addr %v", address)
+	return nil, managerError(ErrAddressNotFound, str, nil)
+}
+
 // AddrAccount returns the account to which the given address belongs. We also
 // return the scoped manager that owns the addr+account combo.
 func (m *Manager) AddrAccount(ns walletdb.ReadBucket,
@@ -722,6 +869,25 @@ func (m *Manager) ForEachActiveAddress(ns walletdb.ReadBucket, fn func(addr btcu
 	return nil
 }
 
+// ForEachActivePaymentAddress calls the given function with each active
+// address stored in the manager which is not an internal (change) address,
+// breaking early on error.
+func (m *Manager) ForEachActivePaymentAddress(ns walletdb.ReadBucket,
+	fn func(maddr ManagedAddress) error) error {
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	for _, scopedMgr := range m.scopedManagers {
+		err := scopedMgr.ForEachActivePaymentAddress(ns, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // ForEachAccountAddress calls the given function with each address of
 // the given account stored in the manager, breaking early on error.
 func (m *Manager) ForEachAccountAddress(ns walletdb.ReadBucket, account uint32,
@@ -748,12 +914,38 @@ func (m *Manager) ChainParams() *chaincfg.Params {
 	return m.chainParams
 }
 
+// CheckPrivatePassphrase returns an error with ErrWrongPassphrase when the
+// passphrase is not the private passphrase.  Unlike Unlock, it does not change
+// the lock state of the address manager.
+func (m *Manager) CheckPrivatePassphrase(passphrase []byte) error {
+	if m.watchingOnly {
+		return managerError(ErrWatchingOnly, errWatchingOnly, nil)
+	}
+
+	m.mtx.RLock()
+	secretKey := snacl.SecretKey{Key: &snacl.CryptoKey{}}
+	secretKey.Parameters = m.masterKeyPriv.Parameters
+	m.mtx.RUnlock()
+	if err := secretKey.DeriveKey(&passphrase); err != nil {
+		if err == snacl.ErrInvalidPassword {
+			str := "invalid passphrase for private master key"
+			return managerError(ErrWrongPassphrase, str, nil)
+		}
+
+		str := "failed to derive private master key"
+		return managerError(ErrCrypto, str, err)
+	}
+	secretKey.Zero()
+	return nil
+}
+
 // ChangePassphrase changes either the public or private passphrase to the
 // provided value depending on the private flag.  In order to change the
 // private password, the address manager must not be watching-only.  The new
-// passphrase keys are derived using the scrypt parameters in the options, so
-// changing the passphrase may be used to bump the computational difficulty
-// needed to brute force the passphrase.
+// passphrase keys are derived using the key derivation function and costs in
+// the options, so changing the passphrase may be used to bump the
+// computational difficulty needed to brute force the passphrase.  When the
+// options are nil, those of the current passphrase key are kept.
 func (m *Manager) ChangePassphrase(ns walletdb.ReadWriteBucket, oldPassphrase,
 	newPassphrase []byte, private bool, config *ScryptOptions) error {
 
@@ -790,6 +982,10 @@ func (m *Manager) ChangePassphrase(ns walletdb.ReadWriteBucket, oldPassphrase,
 	}
 	defer secretKey.Zero()
 
+	if config == nil {
+		config = kdfOptions(&secretKey.Parameters)
+	}
+
 	// Generate a new master key from the passphrase which is used to secure
 	// the actual secret keys.
 	newMasterKey, err := newSecretKey(&newPassphrase, config)
@@ -852,10 +1048,8 @@ func (m *Manager) ChangePassphrase(ns walletdb.ReadWriteBucket, oldPassphrase,
 		if m.locked {
 			newMasterKey.Zero()
 		} else {
-			saltedPassphrase := append(passphraseSalt[:],
-				newPassphrase...)
-			hashedPassphrase = sha512.Sum512(saltedPassphrase)
-			zero.Bytes(saltedPassphrase)
+			hashedPassphrase = hashPassphrase(&passphraseSalt,
+				newPassphrase)
 		}
 
 		// Save the new keys and params to the db in a single
@@ -941,8 +1135,10 @@ func (m *Manager) ConvertToWatchingOnly(ns walletdb.ReadWriteBucket) error {
 	}
 
 	// Lock the manager to remove all clear text private key material from
-	// memory if needed.
-	if !m.locked {
+	// memory if needed, including the crypto private key of the accounts
+	// unlocked with their own passphrases.
+	if !m.locked || m.unlockedAccounts != 0 {
+		m.unlockedAccounts = 0
 		m.lock()
 	}
 
@@ -974,6 +1170,15 @@ func (m *Manager) ConvertToWatchingOnly(ns walletdb.ReadWriteBucket) error {
 		}
 	}
 
+	// Clear and remove the crypto private keys encrypted with the
+	// passphrases of accounts.
+	for _, manager := range m.scopedManagers {
+		for account, p := range manager.acctPassphrases {
+			zero.Bytes(p.cryptoKeyPrivEncrypted)
+			delete(manager.acctPassphrases, account)
+		}
+	}
+
 	// Clear and remove encrypted private and script crypto keys.
 	zero.Bytes(m.cryptoKeyScriptEncrypted)
 	m.cryptoKeyScriptEncrypted = nil
@@ -1035,6 +1240,27 @@ func (m *Manager) Lock() error {
 	return nil
 }
 
+// LockAccounts locks every account unlocked with its own passphrase.  See
+// ScopedKeyManager.LockAccount.
+func (m *Manager) LockAccounts() {
+	m.mtx.RLock()
+	managers := make([]*ScopedKeyManager, 0, len(m.scopedManagers))
+	for _, manager := range m.scopedManagers {
+		managers = append(managers, manager)
+	}
+	m.mtx.RUnlock()
+
+	for _, manager := range managers {
+		manager.mtx.Lock()
+		for account, p := range manager.acctPassphrases {
+			if p.unlocked {
+				manager.lockAccount(account, p)
+			}
+		}
+		manager.mtx.Unlock()
+	}
+}
+
 // Unlock derives the master private key from the specified passphrase.  An
 // invalid passphrase will return an error.  Otherwise, the derived secret key
 // is stored in memory until the address manager is locked.  Any failures that
@@ -1055,10 +1281,8 @@ func (m *Manager) Unlock(ns walletdb.ReadBucket, passphrase []byte) error {
 	// Avoid actually unlocking if the manager is already unlocked
 	// and the passphrases match.
 	if !m.locked {
-		saltedPassphrase := append(m.privPassphraseSalt[:],
-			passphrase...)
-		hashedPassphrase := sha512.Sum512(saltedPassphrase)
-		zero.Bytes(saltedPassphrase)
+		hashedPassphrase := hashPassphrase(&m.privPassphraseSalt,
+			passphrase)
 		if hashedPassphrase != m.hashedPrivPassphrase {
 			m.lock()
 			str := "invalid passphrase for master private key"
@@ -1154,9 +1378,7 @@ func (m *Manager) Unlock(ns walletdb.ReadBucket, passphrase []byte) error {
 	}
 
 	m.locked = false
-	saltedPassphrase := append(m.privPassphraseSalt[:], passphrase...)
-	m.hashedPrivPassphrase = sha512.Sum512(saltedPassphrase)
-	zero.Bytes(saltedPassphrase)
+	m.hashedPrivPassphrase = hashPassphrase(&m.privPassphraseSalt, passphrase)
 	return nil
 }
 
@@ -1467,11 +1689,17 @@ func loadManager(ns walletdb.ReadBucket, pubPassphrase []byte,
 			return err
 		}
 
+		acctPassphrases, err := loadAccountPassphrases(ns, &scope)
+		if err != nil {
+			return err
+		}
+
 		scopedManagers[scope] = &ScopedKeyManager{
-			scope:      scope,
-			addrSchema: *scopeSchema,
-			addrs:      make(map[addrKey]ManagedAddress),
-			acctInfo:   make(map[uint32]*accountInfo),
+			scope:           scope,
+			addrSchema:      *scopeSchema,
+			addrs:           make(map[addrKey]ManagedAddress),
+			acctInfo:        make(map[uint32]*accountInfo),
+			acctPassphrases: acctPassphrases,
 		}
 
 		return nil
@@ -1820,3 +2048,123 @@ func Create(ns walletdb.ReadWriteBucket, seed, pubPassphrase, privPassphrase []b
 	// Use 48 hours as margin of safety for wallet birthday.
 	return putBirthday(ns, birthday.Add(-48*time.Hour))
 }
+
+// CreateWatchOnly creates a new watching-only address manager in the given
+// namespace from the extended public key of an account, such as one exported
+// by another wallet.  The manager has a single key scope, whose default
+// account derives its addresses from acctKeyPub, and no private key material,
+// so it never needs to be unlocked.
+//
+// The optional masterFingerprint is the fingerprint of the master HD key the
+// account key was derived from.  It is returned by MasterKeyFingerprint so
+// that derivation paths can be reported to external signers.
+//
+// A ManagerError with an error code of ErrAlreadyExists will be returned the
+// address manager already exists in the specified namespace.
+func CreateWatchOnly(ns walletdb.ReadWriteBucket, pubPassphrase []byte,
+	chainParams *chaincfg.Params, config *ScryptOptions, birthday time.Time,
+	scope KeyScope, addrSchema ScopeAddrSchema,
+	acctKeyPub *hdkeychain.ExtendedKey, masterFingerprint []byte) error {
+
+	// Return an error if the manager has already been created in
+	// the given database namespace.
+	exists := managerExists(ns)
+	if exists {
+		return managerError(ErrAlreadyExists, errAlreadyExists, nil)
+	}
+
+	if acctKeyPub.IsPrivate() {
+		str := "account key must be an extended public key"
+		return managerError(ErrKeyChain, str, nil)
+	}
+	if err := checkBranchKeys(acctKeyPub); err != nil {
+		str := "the provided account key is unusable"
+		return managerError(ErrKeyChain, str, err)
+	}
+
+	// Perform the initial bucket creation and database namespace setup
+	// for the only scope of the manager.
+	scopes := map[KeyScope]ScopeAddrSchema{scope: addrSchema}
+	if err := createManagerNS(ns, scopes); err != nil {
+		return maybeConvertDbError(err)
+	}
+
+	if config == nil {
+		config = &DefaultScryptOptions
+	}
+
+	// Generate the master public key protecting the crypto public key,
+	// which is the only crypto key of a watching-only manager.
+	masterKeyPub, err := newSecretKey(&pubPassphrase, config)
+	if err != nil {
+		str := "failed to master public key"
+		return managerError(ErrCrypto, str, err)
+	}
+	cryptoKeyPub, err := newCryptoKey()
+	if err != nil {
+		str := "failed to generate crypto public key"
+		return managerError(ErrCrypto, str, err)
+	}
+	cryptoKeyPubEnc, err := masterKeyPub.Encrypt(cryptoKeyPub.Bytes())
+	if err != nil {
+		str := "failed to encrypt crypto public key"
+		return managerError(ErrCrypto, str, err)
+	}
+	err = putMasterKeyParams(ns, masterKeyPub.Marshal(), nil)
+	if err != nil {
+		return maybeConvertDbError(err)
+	}
+	err = putCryptoKeys(ns, cryptoKeyPubEnc, nil, nil)
+	if err != nil {
+		return maybeConvertDbError(err)
+	}
+
+	// Save the account key as the default account of the scope.  The
+	// cointype key is not known, so no further accounts can be created.
+	acctPubEnc, err := cryptoKeyPub.Encrypt([]byte(acctKeyPub.String()))
+	if err != nil {
+		str := "failed to  encrypt public key for account 0"
+		return managerError(ErrCrypto, str, err)
+	}
+	err = putAccountInfo(
+		ns, &scope, DefaultAccountNum, acctPubEnc, nil, 0, 0,
+		defaultAccountName,
+	)
+	if err != nil {
+		return err
+	}
+	err = putAccountInfo(
+		ns, &scope, ImportedAddrAccount, nil, nil, 0, 0,
+		ImportedAddrAccountName,
+	)
+	if err != nil {
+		return err
+	}
+
+	if masterFingerprint != nil {
+		err := putMasterFingerprint(ns, masterFingerprint)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = putWatchingOnly(ns, true)
+	if err != nil {
+		return maybeConvertDbError(err)
+	}
+
+	// Use the genesis block for the passed chain as the created at block
+	// and save the initial synced to state.
+	createdAt := &BlockStamp{Hash: *chainParams.GenesisHash, Height: 0}
+	err = putSyncedTo(ns, createdAt)
+	if err != nil {
+		return maybeConvertDbError(err)
+	}
+	err = putStartBlock(ns, createdAt)
+	if err != nil {
+		return maybeConvertDbError(err)
+	}
+
+	// Use 48 hours as margin of safety for wallet birthday.
+	return putBirthday(ns, birthday.Add(-48*time.Hour))
+}
@@ -13,9 +13,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcwallet/snacl"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/walletdb"
@@ -2435,3 +2437,533 @@ func TestNewRawAccount(t *testing.T) {
 			accountTargetAddr.AddrHash())
 	}
 }
+
+// TestCreateWatchOnly tests that a watching-only manager created from the
+// extended public key of an account derives the same addresses as the
+// manager created from the seed of that account.
+func TestCreateWatchOnly(t *testing.T) {
+	t.Parallel()
+
+	teardown, db, mgr := setupManager(t)
+	defer teardown()
+
+	scope := waddrmgr.KeyScopeBIP0084
+	scopedMgr, err := mgr.FetchScopedKeyManager(scope)
+	if err != nil {
+		t.Fatalf("unable to fetch scope %v: %v", scope, err)
+	}
+	var wantAddr waddrmgr.ManagedAddress
+	var wantFingerprint [4]byte
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(waddrmgrNamespaceKey)
+		wantAddr, err = scopedMgr.DeriveFromKeyPath(
+			ns, waddrmgr.DerivationPath{Branch: 1, Index: 5},
+		)
+		if err != nil {
+			return err
+		}
+		wantFingerprint, err = mgr.MasterKeyFingerprint(ns)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unable to derive address: %v", err)
+	}
+
+	// Derive the account key m/84'/0'/0' from the seed of the manager.
+	acctKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create master key: %v", err)
+	}
+	for _, index := range []uint32{scope.Purpose, scope.Coin, 0} {
+		acctKey, err = acctKey.Child(index + hdkeychain.HardenedKeyStart)
+		if err != nil {
+			t.Fatalf("unable to derive account key: %v", err)
+		}
+	}
+	acctKeyPub, err := acctKey.Neuter()
+	if err != nil {
+		t.Fatalf("unable to neuter account key: %v", err)
+	}
+
+	woTeardown, woDB := emptyDB(t)
+	defer woTeardown()
+
+	var woMgr *waddrmgr.Manager
+	err = walletdb.Update(woDB, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket(waddrmgrNamespaceKey)
+		if err != nil {
+			return err
+		}
+		err = waddrmgr.CreateWatchOnly(
+			ns, pubPassphrase, &chaincfg.MainNetParams, fastScrypt,
+			time.Time{}, scope, waddrmgr.ScopeAddrMap[scope],
+			acctKeyPub, wantFingerprint[:],
+		)
+		if err != nil {
+			return err
+		}
+		woMgr, err = waddrmgr.Open(
+			ns, pubPassphrase, &chaincfg.MainNetParams,
+		)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("create/open: unexpected error: %v", err)
+	}
+	defer woMgr.Close()
+
+	if !woMgr.WatchOnly() {
+		t.Fatalf("manager created from an account key is not " +
+			"watching-only")
+	}
+	if len(woMgr.ActiveScopedKeyManagers()) != 1 {
+		t.Fatalf("got %d scoped managers, want 1",
+			len(woMgr.ActiveScopedKeyManagers()))
+	}
+	err = walletdb.View(woDB, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(waddrmgrNamespaceKey)
+		return woMgr.Unlock(ns, privPassphrase)
+	})
+	checkManagerError(t, "Unlock", err, waddrmgr.ErrWatchingOnly)
+
+	woScopedMgr, err := woMgr.FetchScopedKeyManager(scope)
+	if err != nil {
+		t.Fatalf("unable to fetch scope %v: %v", scope, err)
+	}
+	err = walletdb.Update(woDB, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		addrs, err := woScopedMgr.NextInternalAddresses(ns, 0, 6)
+		if err != nil {
+			return err
+		}
+		if addrs[5].Address().String() != wantAddr.Address().String() {
+			return fmt.Errorf("got address %v, want %v",
+				addrs[5].Address(), wantAddr.Address())
+		}
+
+		fingerprint, err := woMgr.MasterKeyFingerprint(ns)
+		if err != nil {
+			return err
+		}
+		if fingerprint != wantFingerprint {
+			return fmt.Errorf("got fingerprint %x, want %x",
+				fingerprint, wantFingerprint)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestNewAccountWithPath tests that an account bound to a custom derivation
+// path derives its addresses from the key at that path.
+func TestNewAccountWithPath(t *testing.T) {
+	t.Parallel()
+
+	teardown, db, mgr := setupManager(t)
+	defer teardown()
+
+	scope := waddrmgr.KeyScopeBIP0044
+	scopedMgr, err := mgr.FetchScopedKeyManager(scope)
+	if err != nil {
+		t.Fatalf("unable to fetch scope %v: %v", scope, err)
+	}
+	path := []uint32{
+		44 + hdkeychain.HardenedKeyStart,
+		60 + hdkeychain.HardenedKeyStart,
+		hdkeychain.HardenedKeyStart,
+		0,
+	}
+
+	// Derive the external address 3 below the path from the seed.
+	key, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create master key: %v", err)
+	}
+	for _, index := range append(path, waddrmgr.ExternalBranch, 3) {
+		key, err = key.Child(index)
+		if err != nil {
+			t.Fatalf("unable to derive key: %v", err)
+		}
+	}
+	wantAddr, err := key.Address(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		_, err := scopedMgr.NewAccountWithPath(ns, "custom", path)
+		return err
+	})
+	checkManagerError(t, "NewAccountWithPath", err, waddrmgr.ErrLocked)
+
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		if err := mgr.Unlock(ns, privPassphrase); err != nil {
+			return err
+		}
+		account, err := scopedMgr.NewAccountWithPath(ns, "custom", path)
+		if err != nil {
+			return err
+		}
+
+		addrs, err := scopedMgr.NextExternalAddresses(ns, account, 4)
+		if err != nil {
+			return err
+		}
+		if addrs[3].Address().String() != wantAddr.String() {
+			return fmt.Errorf("got address %v, want %v",
+				addrs[3].Address(), wantAddr)
+		}
+
+		acctPath, err := scopedMgr.AccountDerivationPath(ns, account)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(acctPath, path) {
+			return fmt.Errorf("got account path %v, want %v",
+				acctPath, path)
+		}
+
+		// Accounts created afterwards still follow BIP0044.
+		next, err := scopedMgr.NewAccount(ns, "next")
+		if err != nil {
+			return err
+		}
+		acctPath, err = scopedMgr.AccountDerivationPath(ns, next)
+		if err != nil {
+			return err
+		}
+		want := []uint32{
+			scope.Purpose + hdkeychain.HardenedKeyStart,
+			scope.Coin + hdkeychain.HardenedKeyStart,
+			next + hdkeychain.HardenedKeyStart,
+		}
+		if !reflect.DeepEqual(acctPath, want) {
+			return fmt.Errorf("got account path %v, want %v",
+				acctPath, want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAddressUsage checks that the first seen and last used times of an
+// address are extended by the times it is used at.
+func TestAddressUsage(t *testing.T) {
+	t.Parallel()
+
+	teardown, db, mgr := setupManager(t)
+	defer teardown()
+
+	scopedMgr, err := mgr.FetchScopedKeyManager(waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		t.Fatalf("unable to fetch scope: %v", err)
+	}
+
+	first := time.Unix(1500000000, 0)
+	last := first.Add(24 * time.Hour)
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		addrs, err := scopedMgr.NextExternalAddresses(ns, 0, 1)
+		if err != nil {
+			return err
+		}
+		addr := addrs[0].Address()
+
+		usage, err := mgr.AddressUsage(ns, addr)
+		if err != nil {
+			return err
+		}
+		if usage.Used || !usage.FirstSeen.IsZero() ||
+			!usage.LastUsed.IsZero() || usage.Generated.IsZero() {
+
+			return fmt.Errorf("unexpected usage of new address %+v",
+				usage)
+		}
+
+		// Rescans may use the address out of order.
+		for _, usedAt := range []time.Time{last, first, first.Add(time.Hour)} {
+			if err := mgr.MarkUsedAt(ns, addr, usedAt); err != nil {
+				return err
+			}
+		}
+		usage, err = mgr.AddressUsage(ns, addr)
+		if err != nil {
+			return err
+		}
+		if !usage.Used || !usage.FirstSeen.Equal(first) ||
+			!usage.LastUsed.Equal(last) {
+
+			return fmt.Errorf("unexpected usage %+v", usage)
+		}
+		if !addrs[0].Used(ns) {
+			return fmt.Errorf("address is not flagged used")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestForEachActivePaymentAddress tests that change addresses, derived on the
+// internal branch, are not iterated as payment addresses.
+func TestForEachActivePaymentAddress(t *testing.T) {
+	t.Parallel()
+
+	teardown, db, mgr := setupManager(t)
+	defer teardown()
+
+	scopedMgr, err := mgr.FetchScopedKeyManager(waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to fetch scope: %v", err)
+	}
+
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		external, err := scopedMgr.NextExternalAddresses(ns, 0, 2)
+		if err != nil {
+			return err
+		}
+		internal, err := scopedMgr.NextInternalAddresses(ns, 0, 1)
+		if err != nil {
+			return err
+		}
+
+		var all int
+		err = mgr.ForEachActiveAddress(ns, func(btcutil.Address) error {
+			all++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		payment := make(map[string]struct{})
+		err = mgr.ForEachActivePaymentAddress(ns,
+			func(maddr waddrmgr.ManagedAddress) error {
+				payment[maddr.Address().EncodeAddress()] = struct{}{}
+				return nil
+			})
+		if err != nil {
+			return err
+		}
+
+		if len(payment) != all-1 {
+			return fmt.Errorf("%d payment addresses of %d "+
+				"addresses, want %d", len(payment), all, all-1)
+		}
+		change := internal[0].Address().EncodeAddress()
+		if _, ok := payment[change]; ok {
+			return fmt.Errorf("change address %v iterated", change)
+		}
+		for _, maddr := range external {
+			addr := maddr.Address().EncodeAddress()
+			if _, ok := payment[addr]; !ok {
+				return fmt.Errorf("payment address %v not "+
+					"iterated", addr)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestImportPrivateKeys tests that many private keys are imported at once,
+// with the keys of other networks and duplicates failing independently.
+func TestImportPrivateKeys(t *testing.T) {
+	t.Parallel()
+
+	teardown, db, mgr := setupManager(t)
+	defer teardown()
+
+	scopedMgr, err := mgr.FetchScopedKeyManager(waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		t.Fatalf("unable to fetch scope: %v", err)
+	}
+
+	const numKeys = 50
+	wifs := make([]*btcutil.WIF, 0, numKeys+2)
+	for i := 0; i < numKeys; i++ {
+		privKey, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			t.Fatalf("unable to create private key: %v", err)
+		}
+		wif, err := btcutil.NewWIF(privKey, &chaincfg.MainNetParams, true)
+		if err != nil {
+			t.Fatalf("unable to create WIF: %v", err)
+		}
+		wifs = append(wifs, wif)
+	}
+	testNetWIF, err := btcutil.NewWIF(wifs[0].PrivKey,
+		&chaincfg.TestNet3Params, true)
+	if err != nil {
+		t.Fatalf("unable to create WIF: %v", err)
+	}
+	wifs = append(wifs, testNetWIF, wifs[1])
+
+	bs := &waddrmgr.BlockStamp{Height: 0}
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+
+		// Keys cannot be imported while the manager is locked.
+		_, _, err := scopedMgr.ImportPrivateKeys(ns, wifs, bs)
+		if !checkManagerError(t, "locked import", err,
+			waddrmgr.ErrLocked) {
+
+			return nil
+		}
+
+		if err := mgr.Unlock(ns, privPassphrase); err != nil {
+			return err
+		}
+		addrs, errs, err := scopedMgr.ImportPrivateKeys(ns, wifs, bs)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < numKeys; i++ {
+			if errs[i] != nil {
+				return fmt.Errorf("key %d failed to import: %v",
+					i, errs[i])
+			}
+			ma, err := mgr.Address(ns, addrs[i].Address())
+			if err != nil {
+				return err
+			}
+			if !ma.Imported() {
+				return fmt.Errorf("address %d is not imported", i)
+			}
+		}
+		if !waddrmgr.IsError(errs[numKeys], waddrmgr.ErrWrongNet) {
+			return fmt.Errorf("key of another network: got error %v",
+				errs[numKeys])
+		}
+		if !waddrmgr.IsError(errs[numKeys+1], waddrmgr.ErrDuplicateAddress) {
+			return fmt.Errorf("duplicate key: got error %v",
+				errs[numKeys+1])
+		}
+
+		// Importing the keys again only fails with duplicates.
+		_, errs, err = scopedMgr.ImportPrivateKeys(ns, wifs[:numKeys], bs)
+		if err != nil {
+			return err
+		}
+		for i, err := range errs {
+			if !waddrmgr.IsError(err, waddrmgr.ErrDuplicateAddress) {
+				return fmt.Errorf("reimport of key %d: got error "+
+					"%v", i, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAccountPassphrase tests that accounts protected by their own
+// passphrases are locked and unlocked independently from the manager.
+func TestAccountPassphrase(t *testing.T) {
+	t.Parallel()
+
+	teardown, db, mgr := setupManager(t)
+	defer teardown()
+
+	scopedMgr, err := mgr.FetchScopedKeyManager(waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		t.Fatalf("unable to fetch scope: %v", err)
+	}
+
+	acctPassphrase := []byte("account passphrase")
+	var acctAddr, defaultAddr waddrmgr.ManagedPubKeyAddress
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+
+		// The passphrase of an account is set with the manager
+		// unlocked.
+		err := scopedMgr.SetAccountPassphrase(ns, 0, acctPassphrase)
+		if !checkManagerError(t, "locked set", err, waddrmgr.ErrLocked) {
+			return nil
+		}
+		if err := mgr.Unlock(ns, privPassphrase); err != nil {
+			return err
+		}
+
+		account, err := scopedMgr.NewAccount(ns, "separate")
+		if err != nil {
+			return err
+		}
+		err = scopedMgr.SetAccountPassphrase(ns, account, acctPassphrase)
+		if err != nil {
+			return err
+		}
+
+		addrs, err := scopedMgr.NextExternalAddresses(ns, account, 1)
+		if err != nil {
+			return err
+		}
+		acctAddr = addrs[0].(waddrmgr.ManagedPubKeyAddress)
+		addrs, err = scopedMgr.NextExternalAddresses(ns, 0, 1)
+		if err != nil {
+			return err
+		}
+		defaultAddr = addrs[0].(waddrmgr.ManagedPubKeyAddress)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := acctAddr.Account()
+
+	// The manager passphrase does not unlock the account.
+	if !scopedMgr.IsAccountLocked(account) {
+		t.Fatal("account is unlocked by the manager passphrase")
+	}
+	_, err = acctAddr.PrivKey()
+	checkManagerError(t, "locked account", err, waddrmgr.ErrLocked)
+	if _, err := defaultAddr.PrivKey(); err != nil {
+		t.Fatalf("private key of the default account: %v", err)
+	}
+
+	err = scopedMgr.UnlockAccount(account, []byte("wrong"))
+	checkManagerError(t, "wrong passphrase", err, waddrmgr.ErrWrongPassphrase)
+
+	// The account remains unlocked when the manager is locked, while the
+	// other accounts are locked.
+	if err := scopedMgr.UnlockAccount(account, acctPassphrase); err != nil {
+		t.Fatalf("unable to unlock account: %v", err)
+	}
+	if err := mgr.Lock(); err != nil {
+		t.Fatalf("unable to lock manager: %v", err)
+	}
+	if _, err := acctAddr.PrivKey(); err != nil {
+		t.Fatalf("private key of the unlocked account: %v", err)
+	}
+	_, err = defaultAddr.PrivKey()
+	checkManagerError(t, "locked manager", err, waddrmgr.ErrLocked)
+
+	if err := scopedMgr.LockAccount(account); err != nil {
+		t.Fatalf("unable to lock account: %v", err)
+	}
+	_, err = acctAddr.PrivKey()
+	checkManagerError(t, "relocked account", err, waddrmgr.ErrLocked)
+
+	// The account can be unlocked with the manager locked, after the
+	// crypto private key was removed from memory.
+	if err := scopedMgr.UnlockAccount(account, acctPassphrase); err != nil {
+		t.Fatalf("unable to unlock account: %v", err)
+	}
+	if _, err := acctAddr.PrivKey(); err != nil {
+		t.Fatalf("private key of the unlocked account: %v", err)
+	}
+	mgr.LockAccounts()
+	if !scopedMgr.IsAccountLocked(account) {
+		t.Fatal("account is not locked by LockAccounts")
+	}
+}
@@ -2,13 +2,16 @@ package waddrmgr
 
 import (
 	"fmt"
+	"runtime"
 	"sync"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcwallet/internal/zero"
+	"github.com/btcsuite/btcwallet/snacl"
 	"github.com/btcsuite/btcwallet/walletdb"
 )
 
@@ -98,6 +101,13 @@ var (
 		Coin:    0,
 	}
 
+	// KeyScopeBIP0086 is the key scope for BIP0086 derivation. BIP0086
+	// will be used to derive all p2tr addresses.
+	KeyScopeBIP0086 = KeyScope{
+		Purpose: 86,
+		Coin:    0,
+	}
+
 	// KeyScopeBIP0044 is the key scope for BIP0044 derivation. Legacy
 	// wallets will only be able to use this key scope, and no keys beyond
 	// it.
@@ -111,6 +121,7 @@ var (
 	DefaultKeyScopes = []KeyScope{
 		KeyScopeBIP0049Plus,
 		KeyScopeBIP0084,
+		KeyScopeBIP0086,
 		KeyScopeBIP0044,
 	}
 
@@ -126,6 +137,10 @@ var (
 			ExternalAddrType: WitnessPubKey,
 			InternalAddrType: WitnessPubKey,
 		},
+		KeyScopeBIP0086: {
+			ExternalAddrType: TaprootPubKey,
+			InternalAddrType: TaprootPubKey,
+		},
 		KeyScopeBIP0044: {
 			InternalAddrType: PubKeyHash,
 			ExternalAddrType: PubKeyHash,
@@ -169,9 +184,49 @@ type ScopedKeyManager struct {
 	// order to encrypt it.
 	deriveOnUnlock []*unlockDeriveInfo
 
+	// acctPassphrases houses the secrets of the accounts protected by
+	// their own passphrases, which have a lock state independent from the
+	// one of the root manager.
+	acctPassphrases map[uint32]*accountPassphrase
+
 	mtx sync.RWMutex
 }
 
+// accountPassphrase houses the secrets of an account protected by its own
+// passphrase.  The crypto private key of the root manager is encrypted with
+// the master key derived from the passphrase, so that the account can be
+// unlocked without the passphrase of the root manager.
+type accountPassphrase struct {
+	masterKey              snacl.SecretKey
+	cryptoKeyPrivEncrypted []byte
+	unlocked               bool
+}
+
+// loadAccountPassphrases loads the secrets of the accounts of a scope that
+// are protected by their own passphrases.
+func loadAccountPassphrases(ns walletdb.ReadBucket,
+	scope *KeyScope) (map[uint32]*accountPassphrase, error) {
+
+	rows, err := fetchAccountPassphrases(ns, scope)
+	if err != nil {
+		return nil, maybeConvertDbError(err)
+	}
+
+	acctPassphrases := make(map[uint32]*accountPassphrase, len(rows))
+	for account, row := range rows {
+		p := &accountPassphrase{
+			cryptoKeyPrivEncrypted: row.cryptoKeyPrivEncrypted,
+		}
+		if err := p.masterKey.Unmarshal(row.masterKeyParams); err != nil {
+			str := fmt.Sprintf("failed to unmarshal master key of "+
+				"account %d", account)
+			return nil, managerError(ErrCrypto, str, err)
+		}
+		acctPassphrases[account] = p
+	}
+	return acctPassphrases, nil
+}
+
 // Scope returns the exact KeyScope of this scoped key manager.
 func (s *ScopedKeyManager) Scope() KeyScope {
 	return s.scope
@@ -1278,6 +1333,18 @@ func (s *ScopedKeyManager) newAccount(ns walletdb.ReadWriteBucket,
 		str := "failed to convert private key for account"
 		return managerError(ErrKeyChain, str, err)
 	}
+	defer acctKeyPriv.Zero()
+
+	return s.putAccountKey(ns, account, name, acctKeyPriv)
+}
+
+// putAccountKey encrypts the extended keys of a new account and stores them
+// with the account name as the last account of the scope.
+//
+// NOTE: This function MUST be called with the manager lock held for writes.
+func (s *ScopedKeyManager) putAccountKey(ns walletdb.ReadWriteBucket,
+	account uint32, name string, acctKeyPriv *hdkeychain.ExtendedKey) error {
+
 	acctKeyPub, err := acctKeyPriv.Neuter()
 	if err != nil {
 		str := "failed to convert public key for account"
@@ -1313,6 +1380,357 @@ func (s *ScopedKeyManager) newAccount(ns walletdb.ReadWriteBucket,
 	return putLastAccount(ns, &s.scope, account)
 }
 
+// NewAccountWithPath creates a new account whose extended key is derived
+// from the master HD key along path, rather than from the coin type key of
+// the scope, for interoperability with services that dictate nonstandard
+// derivation paths.  Addresses of the account are derived below the account
+// key like for any other account, using the address schema of the scope.
+// Creating the account requires the master HD private key, so the manager
+// must be unlocked and the root key must not have been neutered.
+func (s *ScopedKeyManager) NewAccountWithPath(ns walletdb.ReadWriteBucket,
+	name string, path []uint32) (uint32, error) {
+
+	if s.rootManager.WatchOnly() {
+		return 0, managerError(ErrWatchingOnly, errWatchingOnly, nil)
+	}
+	if len(path) == 0 || len(path) > maxAccountPathLen {
+		str := fmt.Sprintf("account path must have between 1 and %d "+
+			"levels", maxAccountPathLen)
+		return 0, managerError(ErrInvalidAccount, str, nil)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.rootManager.IsLocked() {
+		return 0, managerError(ErrLocked, errLocked, nil)
+	}
+
+	// Validate the account name before deriving any keys.
+	if err := ValidateAccountName(name); err != nil {
+		return 0, err
+	}
+	if _, err := s.lookupAccount(ns, name); err == nil {
+		str := fmt.Sprintf("account with the same name already exists")
+		return 0, managerError(ErrDuplicateAccount, str, err)
+	}
+
+	masterHDPrivEnc, _, err := fetchMasterHDKeys(ns)
+	if err != nil {
+		return 0, err
+	}
+	if masterHDPrivEnc == nil {
+		str := "master HD private key is not available"
+		return 0, managerError(ErrNoExist, str, nil)
+	}
+	serializedKeyPriv, err := s.rootManager.cryptoKeyPriv.Decrypt(masterHDPrivEnc)
+	if err != nil {
+		str := "failed to decrypt master HD private key"
+		return 0, managerError(ErrLocked, str, err)
+	}
+	acctKeyPriv, err := hdkeychain.NewKeyFromString(string(serializedKeyPriv))
+	zero.Bytes(serializedKeyPriv)
+	if err != nil {
+		str := "failed to create master HD private key"
+		return 0, managerError(ErrKeyChain, str, err)
+	}
+
+	for _, index := range path {
+		child, err := acctKeyPriv.Child(index)
+		acctKeyPriv.Zero()
+		if err != nil {
+			str := "failed to derive private key for account path"
+			return 0, managerError(ErrKeyChain, str, err)
+		}
+		acctKeyPriv = child
+	}
+	defer acctKeyPriv.Zero()
+	if err := checkBranchKeys(acctKeyPriv); err != nil {
+		str := "failed to derive branch keys for account path"
+		return 0, managerError(ErrKeyChain, str, err)
+	}
+
+	account, err := fetchLastAccount(ns, &s.scope)
+	if err != nil {
+		return 0, err
+	}
+	account++
+	if account > MaxAccountNum {
+		return 0, managerError(ErrAccountNumTooHigh, errAcctTooHigh, nil)
+	}
+
+	if err := s.putAccountKey(ns, account, name, acctKeyPriv); err != nil {
+		return 0, err
+	}
+	if err := putAccountPath(ns, &s.scope, account, path); err != nil {
+		return 0, err
+	}
+	return account, nil
+}
+
+// AccountDerivationPath returns the derivation path of the extended key of
+// an account from the master HD key.  This is
+// m/purpose'/<coin type>'/<account>' unless the account was created with
+// NewAccountWithPath.
+func (s *ScopedKeyManager) AccountDerivationPath(ns walletdb.ReadBucket,
+	account uint32) ([]uint32, error) {
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	path, err := fetchAccountPath(ns, &s.scope, account)
+	if err != nil || path != nil {
+		return path, err
+	}
+	return []uint32{
+		s.scope.Purpose + hdkeychain.HardenedKeyStart,
+		s.scope.Coin + hdkeychain.HardenedKeyStart,
+		account + hdkeychain.HardenedKeyStart,
+	}, nil
+}
+
+// AccountExtendedPubKey returns the extended public key of an account, from
+// which the keys of its external and internal branches are derived.  The
+// imported account has no extended key.
+func (s *ScopedKeyManager) AccountExtendedPubKey(ns walletdb.ReadBucket,
+	account uint32) (*hdkeychain.ExtendedKey, error) {
+
+	if account == ImportedAddrAccount {
+		str := "the imported account has no extended key"
+		return nil, managerError(ErrInvalidAccount, str, nil)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	acctInfo, err := s.loadAccountInfo(ns, account)
+	if err != nil {
+		return nil, err
+	}
+	return acctInfo.acctKeyPub, nil
+}
+
+// SetAccountPassphrase protects an account with its own passphrase, or
+// removes the passphrase of the account when the passphrase is empty.  The
+// private keys of an account protected by its own passphrase are only
+// available after the account is unlocked with UnlockAccount, whatever the
+// lock state of the root manager, and the account remains unlocked when the
+// root manager is locked.  The account is locked after its passphrase is set.
+//
+// The root manager must be unlocked, since the account passphrase protects
+// the crypto private key of the root manager.  Accounts unlocked with their
+// own passphrase thus share the crypto private key, and the separation of
+// the accounts is enforced by the lock states of the address manager.
+func (s *ScopedKeyManager) SetAccountPassphrase(ns walletdb.ReadWriteBucket,
+	account uint32, passphrase []byte) error {
+
+	// A watching-only address manager has no private keys to protect.
+	if s.rootManager.WatchOnly() {
+		return managerError(ErrWatchingOnly, errWatchingOnly, nil)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.rootManager.IsLocked() {
+		return managerError(ErrLocked, errLocked, nil)
+	}
+
+	// Ensure the account exists.
+	if _, err := fetchAccountName(ns, &s.scope, account); err != nil {
+		return maybeConvertDbError(err)
+	}
+
+	// Any current passphrase is replaced, locking the account.
+	if p, ok := s.acctPassphrases[account]; ok {
+		if p.unlocked {
+			s.lockAccount(account, p)
+		}
+	}
+
+	if len(passphrase) == 0 {
+		err := deleteAccountPassphrase(ns, &s.scope, account)
+		if err != nil {
+			return maybeConvertDbError(err)
+		}
+		delete(s.acctPassphrases, account)
+		return nil
+	}
+
+	// The account master key is derived with the same key derivation
+	// function and costs as the master private key of the root manager.
+	m := s.rootManager
+	m.mtx.RLock()
+	config := kdfOptions(&m.masterKeyPriv.Parameters)
+	m.mtx.RUnlock()
+	masterKey, err := newSecretKey(&passphrase, config)
+	if err != nil {
+		str := fmt.Sprintf("failed to create master key of account %d",
+			account)
+		return managerError(ErrCrypto, str, err)
+	}
+	defer masterKey.Zero()
+
+	m.mtx.RLock()
+	cryptoKeyPrivEncrypted, err := masterKey.Encrypt(m.cryptoKeyPriv.Bytes())
+	m.mtx.RUnlock()
+	if err != nil {
+		str := fmt.Sprintf("failed to encrypt crypto private key for "+
+			"account %d", account)
+		return managerError(ErrCrypto, str, err)
+	}
+
+	row := &dbAccountPassphrase{
+		masterKeyParams:        masterKey.Marshal(),
+		cryptoKeyPrivEncrypted: cryptoKeyPrivEncrypted,
+	}
+	err = putAccountPassphrase(ns, &s.scope, account, row)
+	if err != nil {
+		return err
+	}
+
+	p := &accountPassphrase{cryptoKeyPrivEncrypted: cryptoKeyPrivEncrypted}
+	if err := p.masterKey.Unmarshal(row.masterKeyParams); err != nil {
+		str := fmt.Sprintf("failed to unmarshal master key of account %d",
+			account)
+		return managerError(ErrCrypto, str, err)
+	}
+	s.acctPassphrases[account] = p
+	return nil
+}
+
+// HasAccountPassphrase returns whether an account is protected by its own
+// passphrase.
+func (s *ScopedKeyManager) HasAccountPassphrase(account uint32) bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	_, ok := s.acctPassphrases[account]
+	return ok
+}
+
+// IsAccountLocked returns whether the private keys of an account are
+// unavailable.  Accounts protected by their own passphrase are locked until
+// unlocked with UnlockAccount, while the other accounts are locked with the
+// root manager.
+func (s *ScopedKeyManager) IsAccountLocked(account uint32) bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.accountLocked(account)
+}
+
+// accountLocked returns whether the private keys of an account are
+// unavailable.
+//
+// This function MUST be called with the manager lock held for reads.
+func (s *ScopedKeyManager) accountLocked(account uint32) bool {
+	if p, ok := s.acctPassphrases[account]; ok {
+		return !p.unlocked
+	}
+	return s.rootManager.IsLocked()
+}
+
+// UnlockAccount unlocks an account protected by its own passphrase, making
+// its private keys available until the account is locked with LockAccount.
+// The root manager may remain locked, and its passphrase does not unlock
+// the account.  Unlocking an unlocked account only checks the passphrase.
+func (s *ScopedKeyManager) UnlockAccount(account uint32,
+	passphrase []byte) error {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	p, ok := s.acctPassphrases[account]
+	if !ok {
+		str := fmt.Sprintf("account %d has no passphrase", account)
+		return managerError(ErrInvalidAccount, str, nil)
+	}
+
+	// Derive the account master key and use it to decrypt the crypto
+	// private key.
+	if err := p.masterKey.DeriveKey(&passphrase); err != nil {
+		p.masterKey.Zero()
+		if err == snacl.ErrInvalidPassword {
+			str := fmt.Sprintf("invalid passphrase for account %d",
+				account)
+			return managerError(ErrWrongPassphrase, str, nil)
+		}
+
+		str := fmt.Sprintf("failed to derive master key of account %d",
+			account)
+		return managerError(ErrCrypto, str, err)
+	}
+	cryptoKeyPriv, err := p.masterKey.Decrypt(p.cryptoKeyPrivEncrypted)
+	p.masterKey.Zero()
+	if err != nil {
+		str := fmt.Sprintf("failed to decrypt crypto private key for "+
+			"account %d", account)
+		return managerError(ErrCrypto, str, err)
+	}
+	defer zero.Bytes(cryptoKeyPriv)
+
+	if p.unlocked {
+		return nil
+	}
+
+	m := s.rootManager
+	m.mtx.Lock()
+	if m.locked && m.unlockedAccounts == 0 {
+		m.cryptoKeyPriv.CopyBytes(cryptoKeyPriv)
+	}
+	m.unlockedAccounts++
+	m.mtx.Unlock()
+
+	p.unlocked = true
+	return nil
+}
+
+// LockAccount locks an account protected by its own passphrase, removing the
+// clear text private keys of its addresses from memory.  The crypto private
+// key is removed from memory too when no other account is unlocked and the
+// root manager is locked.
+func (s *ScopedKeyManager) LockAccount(account uint32) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	p, ok := s.acctPassphrases[account]
+	if !ok {
+		str := fmt.Sprintf("account %d has no passphrase", account)
+		return managerError(ErrInvalidAccount, str, nil)
+	}
+	if !p.unlocked {
+		return managerError(ErrLocked, errLocked, nil)
+	}
+
+	s.lockAccount(account, p)
+	return nil
+}
+
+// lockAccount locks an unlocked account protected by its own passphrase.
+//
+// This function MUST be called with the manager lock held for writes.
+func (s *ScopedKeyManager) lockAccount(account uint32, p *accountPassphrase) {
+	p.unlocked = false
+
+	for _, ma := range s.addrs {
+		if ma.Account() != account {
+			continue
+		}
+		if addr, ok := ma.(*managedAddress); ok {
+			addr.lock()
+		}
+	}
+
+	m := s.rootManager
+	m.mtx.Lock()
+	m.unlockedAccounts--
+	if m.locked && m.unlockedAccounts == 0 {
+		m.cryptoKeyPriv.Zero()
+	}
+	m.mtx.Unlock()
+}
+
 // RenameAccount renames an account stored in the manager based on the given
 // account number with the given name.  If an account with the same name
 // already exists, ErrDuplicateAccount will be returned.
@@ -1401,6 +1819,135 @@ func (s *ScopedKeyManager) RenameAccount(ns walletdb.ReadWriteBucket,
 func (s *ScopedKeyManager) ImportPrivateKey(ns walletdb.ReadWriteBucket,
 	wif *btcutil.WIF, bs *BlockStamp) (ManagedPubKeyAddress, error) {
 
+	addrs, errs, err := s.ImportPrivateKeys(ns, []*btcutil.WIF{wif}, bs)
+	if err != nil {
+		return nil, err
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	return addrs[0], nil
+}
+
+// ImportPrivateKeys imports many WIF private keys into the address manager,
+// as ImportPrivateKey does for a single key.  The keys are validated and
+// encrypted concurrently by a pool of workers, one for each CPU, before
+// being written to the database in the single transaction of ns.  The start
+// block is updated once, for the earliest block of the imports.
+//
+// The returned slices hold the managed address and the error of each key,
+// which are independent from each other, so a key of the wrong network or an
+// address that already exists does not prevent the other keys from being
+// imported.  The error return is reserved for failures affecting every key,
+// such as a locked address manager or a database error.
+func (s *ScopedKeyManager) ImportPrivateKeys(ns walletdb.ReadWriteBucket,
+	wifs []*btcutil.WIF, bs *BlockStamp) ([]ManagedPubKeyAddress, []error, error) {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// The manager must be unlocked to encrypt the imported private keys.
+	if s.rootManager.IsLocked() && !s.rootManager.WatchOnly() {
+		return nil, nil, managerError(ErrLocked, errLocked, nil)
+	}
+
+	// Encrypt the keys with a pool of workers, since encryption is the
+	// bulk of the work of importing many keys.
+	keys := make([]*importedKey, len(wifs))
+	errs := make([]error, len(wifs))
+	workers := runtime.NumCPU()
+	if workers > len(wifs) {
+		workers = len(wifs)
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				keys[i], errs[i] = s.encryptImportedKey(wifs[i])
+			}
+		}()
+	}
+	for i := range wifs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	// Save the new imported addresses to the db, preventing duplicates of
+	// both the existing addresses and the earlier keys of the batch.
+	addrs := make([]ManagedPubKeyAddress, len(wifs))
+	imported := false
+	for i, key := range keys {
+		if errs[i] != nil {
+			continue
+		}
+		if s.existsAddress(ns, key.pubKeyHash) {
+			str := fmt.Sprintf("address for public key %x already "+
+				"exists", key.serializedPubKey)
+			errs[i] = managerError(ErrDuplicateAddress, str, nil)
+			continue
+		}
+
+		err := putImportedAddress(
+			ns, &s.scope, key.pubKeyHash, ImportedAddrAccount,
+			ssNone, key.encryptedPubKey, key.encryptedPrivKey,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Add the new managed address to the cache of recent
+		// addresses.
+		s.addrs[addrKey(key.addr.Address().ScriptAddress())] = key.addr
+		addrs[i] = key.addr
+		imported = true
+	}
+	if !imported {
+		return addrs, errs, nil
+	}
+
+	// The start block needs to be updated when the newly imported
+	// addresses are before the current one.
+	s.rootManager.mtx.Lock()
+	updateStartBlock := bs.Height < s.rootManager.syncState.startBlock.Height
+	s.rootManager.mtx.Unlock()
+
+	if updateStartBlock {
+		err := putStartBlock(ns, bs)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Now that the database has been updated, update the start
+		// block in memory too.
+		s.rootManager.mtx.Lock()
+		s.rootManager.syncState.startBlock = *bs
+		s.rootManager.mtx.Unlock()
+	}
+
+	return addrs, errs, nil
+}
+
+// importedKey houses the encrypted keys and the managed address of a private
+// key being imported.
+type importedKey struct {
+	serializedPubKey []byte
+	pubKeyHash       []byte
+	encryptedPubKey  []byte
+	encryptedPrivKey []byte
+	addr             *managedAddress
+}
+
+// encryptImportedKey validates a private key being imported, encrypts it and
+// its public key with the crypto keys of the address manager, and creates
+// its managed address.  It does not access the database nor the mutable state
+// of the manager, so many keys may be encrypted concurrently.
+//
+// This function MUST be called with the manager lock held for writes.
+func (s *ScopedKeyManager) encryptImportedKey(wif *btcutil.WIF) (*importedKey, error) {
 	// Ensure the address is intended for network the address manager is
 	// associated with.
 	if !wif.IsForNet(s.rootManager.chainParams) {
@@ -1410,26 +1957,15 @@ func (s *ScopedKeyManager) ImportPrivateKey(ns walletdb.ReadWriteBucket,
 		return nil, managerError(ErrWrongNet, str, nil)
 	}
 
-	s.mtx.Lock()
-	defer s.mtx.Unlock()
-
-	// The manager must be unlocked to encrypt the imported private key.
-	if s.rootManager.IsLocked() && !s.rootManager.WatchOnly() {
-		return nil, managerError(ErrLocked, errLocked, nil)
-	}
-
-	// Prevent duplicates.
 	serializedPubKey := wif.SerializePubKey()
-	pubKeyHash := btcutil.Hash160(serializedPubKey)
-	alreadyExists := s.existsAddress(ns, pubKeyHash)
-	if alreadyExists {
-		str := fmt.Sprintf("address for public key %x already exists",
-			serializedPubKey)
-		return nil, managerError(ErrDuplicateAddress, str, nil)
+	key := &importedKey{
+		serializedPubKey: serializedPubKey,
+		pubKeyHash:       btcutil.Hash160(serializedPubKey),
 	}
 
 	// Encrypt public key.
-	encryptedPubKey, err := s.rootManager.cryptoKeyPub.Encrypt(
+	var err error
+	key.encryptedPubKey, err = s.rootManager.cryptoKeyPub.Encrypt(
 		serializedPubKey,
 	)
 	if err != nil {
@@ -1439,10 +1975,12 @@ func (s *ScopedKeyManager) ImportPrivateKey(ns walletdb.ReadWriteBucket,
 	}
 
 	// Encrypt the private key when not a watching-only address manager.
-	var encryptedPrivKey []byte
-	if !s.rootManager.WatchOnly() {
+	watchOnly := s.rootManager.WatchOnly()
+	if !watchOnly {
 		privKeyBytes := wif.PrivKey.Serialize()
-		encryptedPrivKey, err = s.rootManager.cryptoKeyPriv.Encrypt(privKeyBytes)
+		key.encryptedPrivKey, err = s.rootManager.cryptoKeyPriv.Encrypt(
+			privKeyBytes,
+		)
 		zero.Bytes(privKeyBytes)
 		if err != nil {
 			str := fmt.Sprintf("failed to encrypt private key for %x",
@@ -1451,37 +1989,6 @@ func (s *ScopedKeyManager) ImportPrivateKey(ns walletdb.ReadWriteBucket,
 		}
 	}
 
-	// The start block needs to be updated when the newly imported address
-	// is before the current one.
-	s.rootManager.mtx.Lock()
-	updateStartBlock := bs.Height < s.rootManager.syncState.startBlock.Height
-	s.rootManager.mtx.Unlock()
-
-	// Save the new imported address to the db and update start block (if
-	// needed) in a single transaction.
-	err = putImportedAddress(
-		ns, &s.scope, pubKeyHash, ImportedAddrAccount, ssNone,
-		encryptedPubKey, encryptedPrivKey,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	if updateStartBlock {
-		err := putStartBlock(ns, bs)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	// Now that the database has been updated, update the start block in
-	// memory too if needed.
-	if updateStartBlock {
-		s.rootManager.mtx.Lock()
-		s.rootManager.syncState.startBlock = *bs
-		s.rootManager.mtx.Unlock()
-	}
-
 	// The full derivation path for an imported key is incomplete as we
 	// don't know exactly how it was derived.
 	importedDerivationPath := DerivationPath{
@@ -1489,15 +1996,14 @@ func (s *ScopedKeyManager) ImportPrivateKey(ns walletdb.ReadWriteBucket,
 	}
 
 	// Create a new managed address based on the imported address.
-	var managedAddr *managedAddress
-	if !s.rootManager.WatchOnly() {
-		managedAddr, err = newManagedAddress(
+	if !watchOnly {
+		key.addr, err = newManagedAddress(
 			s, importedDerivationPath, wif.PrivKey,
 			wif.CompressPubKey, s.addrSchema.ExternalAddrType,
 		)
 	} else {
 		pubKey := (*btcec.PublicKey)(&wif.PrivKey.PublicKey)
-		managedAddr, err = newManagedAddressWithoutPrivKey(
+		key.addr, err = newManagedAddressWithoutPrivKey(
 			s, importedDerivationPath, pubKey, wif.CompressPubKey,
 			s.addrSchema.ExternalAddrType,
 		)
@@ -1505,12 +2011,9 @@ func (s *ScopedKeyManager) ImportPrivateKey(ns walletdb.ReadWriteBucket,
 	if err != nil {
 		return nil, err
 	}
-	managedAddr.imported = true
+	key.addr.imported = true
 
-	// Add the new managed address to the cache of recent addresses and
-	// return it.
-	s.addrs[addrKey(managedAddr.Address().ScriptAddress())] = managedAddr
-	return managedAddr, nil
+	return key, nil
 }
 
 // ImportScript imports a user-provided script into the address manager.  The
@@ -1647,12 +2150,26 @@ func (s *ScopedKeyManager) fetchUsed(ns walletdb.ReadBucket,
 	return fetchAddressUsed(ns, &s.scope, addressID)
 }
 
-// MarkUsed updates the used flag for the provided address.
+// MarkUsed updates the used flag for the provided address, recording the
+// current time as the time the address was used.
 func (s *ScopedKeyManager) MarkUsed(ns walletdb.ReadWriteBucket,
 	address btcutil.Address) error {
 
+	return s.MarkUsedAt(ns, address, time.Now())
+}
+
+// MarkUsedAt updates the used flag for the provided address, which was used by
+// a transaction at the passed time, such as the time of its block.  The time
+// extends the times the address was first seen and last used on the chain.
+func (s *ScopedKeyManager) MarkUsedAt(ns walletdb.ReadWriteBucket,
+	address btcutil.Address, t time.Time) error {
+
+	if pka, ok := address.(*btcutil.AddressPubKey); ok {
+		address = pka.AddressPubKeyHash()
+	}
+
 	addressID := address.ScriptAddress()
-	err := markAddressUsed(ns, &s.scope, addressID)
+	err := markAddressUsed(ns, &s.scope, addressID, t)
 	if err != nil {
 		return maybeConvertDbError(err)
 	}
@@ -1664,6 +2181,67 @@ func (s *ScopedKeyManager) MarkUsed(ns walletdb.ReadWriteBucket,
 	return nil
 }
 
+// AddressUsage describes when an address was generated and used.  Times that
+// are unknown, such as the first seen time of addresses used before the times
+// were recorded, are zero.
+type AddressUsage struct {
+	// Generated is the time the address was derived or imported.
+	Generated time.Time
+
+	// Used is set when the address was used by a transaction.
+	Used bool
+
+	// FirstSeen is the time of the first transaction paying to the
+	// address.
+	FirstSeen time.Time
+
+	// LastUsed is the time of the last transaction paying to or spending
+	// from the address.
+	LastUsed time.Time
+}
+
+// AddressUsage returns when the provided address was generated or imported,
+// and when it was first seen and last used on the chain.
+func (s *ScopedKeyManager) AddressUsage(ns walletdb.ReadBucket,
+	address btcutil.Address) (*AddressUsage, error) {
+
+	if pka, ok := address.(*btcutil.AddressPubKey); ok {
+		address = pka.AddressPubKeyHash()
+	}
+
+	addressID := address.ScriptAddress()
+	rowInterface, err := fetchAddress(ns, &s.scope, addressID)
+	if err != nil {
+		return nil, maybeConvertDbError(err)
+	}
+	var row *dbAddressRow
+	switch r := rowInterface.(type) {
+	case *dbChainAddressRow:
+		row = &r.dbAddressRow
+	case *dbImportedAddressRow:
+		row = &r.dbAddressRow
+	case *dbScriptAddressRow:
+		row = &r.dbAddressRow
+	default:
+		str := fmt.Sprintf("unsupported address type %T", rowInterface)
+		return nil, managerError(ErrDatabase, str, nil)
+	}
+
+	usage := &AddressUsage{
+		Generated: time.Unix(int64(row.addTime), 0),
+	}
+	if dbUsage := fetchAddressUsage(ns, &s.scope, addressID); dbUsage != nil {
+		usage.Used = true
+		if dbUsage.firstSeen != 0 {
+			usage.FirstSeen = time.Unix(int64(dbUsage.firstSeen), 0)
+		}
+		if dbUsage.lastUsed != 0 {
+			usage.LastUsed = time.Unix(int64(dbUsage.lastUsed), 0)
+		}
+	}
+	return usage, nil
+}
+
 // ChainParams returns the chain parameters for this address manager.
 func (s *ScopedKeyManager) ChainParams() *chaincfg.Params {
 	// NOTE: No need for mutex here since the net field does not change
@@ -1747,3 +2325,31 @@ func (s *ScopedKeyManager) ForEachActiveAddress(ns walletdb.ReadBucket,
 
 	return nil
 }
+
+// ForEachActivePaymentAddress calls the given function with each active
+// address stored in the manager which is not an internal (change) address,
+// breaking early on error.
+func (s *ScopedKeyManager) ForEachActivePaymentAddress(ns walletdb.ReadBucket,
+	fn func(maddr ManagedAddress) error) error {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	addrFn := func(rowInterface interface{}) error {
+		managedAddr, err := s.rowInterfaceToManaged(ns, rowInterface)
+		if err != nil {
+			return err
+		}
+		if managedAddr.Internal() {
+			return nil
+		}
+		return fn(managedAddr)
+	}
+
+	err := forEachActiveAddress(ns, &s.scope, addrFn)
+	if err != nil {
+		return maybeConvertDbError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// AccelerationNotice describes the nature of transaction accelerators to the
+// users submitting transactions to them.
+const AccelerationNotice = "Transaction accelerators are third-party " +
+	"services outside the control of the wallet and of the network: " +
+	"acceleration is best effort, may be charged by the service, and " +
+	"discloses the transaction to it."
+
+// accelerationsBucketKey is the key of the bucket in the transaction metadata
+// namespace recording the transactions submitted to accelerators, keyed by
+// the transaction hash followed by the name of the accelerator.
+var accelerationsBucketKey = []byte("accelerations")
+
+// Accelerations are serialized as such:
+//
+//   [0:8]   Time submitted, as unix seconds (8 bytes)
+//   [8]     Whether the accelerator accepted the transaction (1 byte)
+//   [9:11]  Reference length (2 bytes)
+//   [11:]   Reference, followed by the message
+
+// AccelerationRequest describes a stuck transaction submitted to an
+// accelerator.
+type AccelerationRequest struct {
+	TxHash chainhash.Hash
+	Tx     *wire.MsgTx
+}
+
+// AccelerationReceipt is the response of an accelerator accepting a
+// transaction.
+type AccelerationReceipt struct {
+	// Reference identifies the acceleration at the accelerator, such as
+	// an order or ticket number.
+	Reference string
+
+	// Message is a free form message of the accelerator.
+	Message string
+}
+
+// TxAccelerator is implemented by the clients of third-party services, such
+// as the acceleration APIs of mining pools, that prioritize the mining of
+// stuck transactions.  Accelerators act outside the consensus rules, so that
+// a submitted transaction may still not be mined sooner.
+type TxAccelerator interface {
+	// Name returns a name identifying the accelerator.
+	Name() string
+
+	// Accelerate submits the transaction of the request.  An error is
+	// returned when the accelerator refuses it.
+	Accelerate(req *AccelerationRequest) (*AccelerationReceipt, error)
+}
+
+// Acceleration records the submission of a transaction to an accelerator.
+type Acceleration struct {
+	TxHash      chainhash.Hash
+	Accelerator string
+	Submitted   time.Time
+
+	// Accepted is whether the accelerator accepted the transaction, with
+	// the reference of its receipt.  Message is the message of the
+	// receipt, or the error of a refused submission.
+	Accepted  bool
+	Reference string
+	Message   string
+
+	// Mined is whether the transaction is mined, which is only known when
+	// the accelerations are queried.
+	Mined bool
+}
+
+// txAccelerators holds the registered accelerators.
+type txAccelerators struct {
+	mu           sync.Mutex
+	accelerators []TxAccelerator
+}
+
+// RegisterTxAccelerator adds an accelerator that AccelerateTx may submit
+// transactions to.
+func (w *Wallet) RegisterTxAccelerator(a TxAccelerator) {
+	w.accelerators.mu.Lock()
+	w.accelerators.accelerators = append(w.accelerators.accelerators, a)
+	w.accelerators.mu.Unlock()
+}
+
+// TxAccelerators returns the names of the registered accelerators.
+func (w *Wallet) TxAccelerators() []string {
+	w.accelerators.mu.Lock()
+	defer w.accelerators.mu.Unlock()
+	names := make([]string, len(w.accelerators.accelerators))
+	for i, a := range w.accelerators.accelerators {
+		names[i] = a.Name()
+	}
+	return names
+}
+
+// AccelerateTx submits an unmined wallet transaction to the named
+// accelerators, or to every registered accelerator when no name is passed,
+// and records the submissions.  Accelerators are third-party services, so
+// this is best effort: a refusal of an accelerator is recorded in its
+// acceleration rather than returned as an error.
+func (w *Wallet) AccelerateTx(txHash *chainhash.Hash, names []string) ([]Acceleration, error) {
+	accelerators, err := w.selectAccelerators(names)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx *wire.MsgTx
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+		details, err := w.TxStore.TxDetails(txmgrNs, txHash)
+		if err != nil {
+			return err
+		}
+		if details == nil {
+			return fmt.Errorf("transaction %v is not a wallet "+
+				"transaction", txHash)
+		}
+		if details.Block.Height != -1 {
+			return fmt.Errorf("transaction %v is already mined",
+				txHash)
+		}
+		tx = &details.MsgTx
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req := &AccelerationRequest{TxHash: *txHash, Tx: tx}
+	accels := make([]Acceleration, len(accelerators))
+	for i, a := range accelerators {
+		log.Infof("Submitting transaction %v to the third-party "+
+			"accelerator %s", txHash, a.Name())
+		accels[i] = Acceleration{
+			TxHash:      *txHash,
+			Accelerator: a.Name(),
+			Submitted:   time.Now(),
+		}
+		receipt, err := a.Accelerate(req)
+		if err != nil {
+			log.Warnf("Accelerator %s refused transaction %v: %v",
+				a.Name(), txHash, err)
+			accels[i].Message = err.Error()
+			continue
+		}
+		accels[i].Accepted = true
+		accels[i].Reference = receipt.Reference
+		accels[i].Message = receipt.Message
+	}
+
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket, err := ns.CreateBucketIfNotExists(accelerationsBucketKey)
+		if err != nil {
+			return err
+		}
+		for i := range accels {
+			k, v := serializeAcceleration(&accels[i])
+			if err := bucket.Put(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.audit(AuditAccelerate, "transaction %v submitted to %d "+
+		"accelerators", txHash, len(accels))
+	return accels, nil
+}
+
+// Accelerations returns the recorded submissions of a transaction to
+// accelerators, or of every transaction when txHash is nil, ordered by
+// transaction hash and accelerator name.
+func (w *Wallet) Accelerations(txHash *chainhash.Hash) ([]Acceleration, error) {
+	var accels []Acceleration
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		ns := dbtx.ReadBucket(wtxmetaNamespaceKey)
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+		bucket := ns.NestedReadBucket(accelerationsBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			a, err := deserializeAcceleration(k, v)
+			if err != nil {
+				return err
+			}
+			if txHash != nil && a.TxHash != *txHash {
+				return nil
+			}
+			details, err := w.TxStore.TxDetails(txmgrNs, &a.TxHash)
+			if err != nil {
+				return err
+			}
+			a.Mined = details != nil && details.Block.Height != -1
+			accels = append(accels, *a)
+			return nil
+		})
+	})
+	return accels, err
+}
+
+// selectAccelerators returns the registered accelerators with the passed
+// names, or every registered accelerator when no name is passed.
+func (w *Wallet) selectAccelerators(names []string) ([]TxAccelerator, error) {
+	w.accelerators.mu.Lock()
+	defer w.accelerators.mu.Unlock()
+
+	if len(w.accelerators.accelerators) == 0 {
+		return nil, errors.New("no transaction accelerator is configured")
+	}
+	if len(names) == 0 {
+		return w.accelerators.accelerators, nil
+	}
+	selected := make([]TxAccelerator, 0, len(names))
+	for _, name := range names {
+		var found TxAccelerator
+		for _, a := range w.accelerators.accelerators {
+			if a.Name() == name {
+				found = a
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("unknown transaction accelerator %q",
+				name)
+		}
+		selected = append(selected, found)
+	}
+	return selected, nil
+}
+
+// serializeAcceleration returns the key and value recording an acceleration.
+func serializeAcceleration(a *Acceleration) ([]byte, []byte) {
+	k := make([]byte, chainhash.HashSize+len(a.Accelerator))
+	copy(k, a.TxHash[:])
+	copy(k[chainhash.HashSize:], a.Accelerator)
+
+	ref := a.Reference
+	if len(ref) > math.MaxUint16 {
+		ref = ref[:math.MaxUint16]
+	}
+	v := make([]byte, 11+len(ref)+len(a.Message))
+	binary.BigEndian.PutUint64(v, uint64(a.Submitted.Unix()))
+	if a.Accepted {
+		v[8] = 1
+	}
+	binary.BigEndian.PutUint16(v[9:11], uint16(len(ref)))
+	copy(v[11:], ref)
+	copy(v[11+len(ref):], a.Message)
+	return k, v
+}
+
+// deserializeAcceleration returns the acceleration recorded with a key and a
+// value.
+func deserializeAcceleration(k, v []byte) (*Acceleration, error) {
+	if len(k) < chainhash.HashSize || len(v) < 11 {
+		return nil, fmt.Errorf("acceleration %x is malformed", k)
+	}
+	refLen := int(binary.BigEndian.Uint16(v[9:11]))
+	if len(v) < 11+refLen {
+		return nil, fmt.Errorf("acceleration %x is malformed", k)
+	}
+	a := &Acceleration{
+		Accelerator: string(k[chainhash.HashSize:]),
+		Submitted:   time.Unix(int64(binary.BigEndian.Uint64(v)), 0),
+		Accepted:    v[8] == 1,
+		Reference:   string(v[11 : 11+refLen]),
+		Message:     string(v[11+refLen:]),
+	}
+	copy(a.TxHash[:], k)
+	return a, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TestHTTPAccelerator checks the requests and responses of HTTPAccelerator,
+// and the records of the submissions.
+func TestHTTPAccelerator(t *testing.T) {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxOut(wire.NewTxOut(1e6, make([]byte, 22)))
+	txHash := tx.TxHash()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"invalid key"}`))
+			return
+		}
+		var req httpAccelerationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.TxID != txHash.String() || req.Hex == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"reference":"order-1","message":"queued"}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTPAccelerator("ftp://example.com", ""); err == nil {
+		t.Errorf("accepted non-HTTP accelerator URL")
+	}
+
+	a, err := NewHTTPAccelerator(server.URL, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &AccelerationRequest{TxHash: txHash, Tx: tx}
+	receipt, err := a.Accelerate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Reference != "order-1" || receipt.Message != "queued" {
+		t.Errorf("unexpected receipt %v", receipt)
+	}
+
+	a.apiKey = "wrong"
+	_, err = a.Accelerate(req)
+	if err == nil || !strings.Contains(err.Error(), "invalid key") {
+		t.Errorf("unexpected error for refused request: %v", err)
+	}
+
+	accel := &Acceleration{
+		TxHash:      txHash,
+		Accelerator: a.Name(),
+		Submitted:   time.Unix(1e9, 0),
+		Accepted:    true,
+		Reference:   receipt.Reference,
+		Message:     receipt.Message,
+	}
+	got, err := deserializeAcceleration(serializeAcceleration(accel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *accel {
+		t.Errorf("acceleration %v, want %v", got, accel)
+	}
+}
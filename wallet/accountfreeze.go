@@ -0,0 +1,212 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// frozenAccountsBucketKey is the key of the bucket in the transaction
+// metadata namespace recording the frozen accounts, keyed by big endian
+// account number.
+var frozenAccountsBucketKey = []byte("frozenaccounts")
+
+// Frozen accounts are serialized as such:
+//
+//   [0:8]  Time frozen, as unix seconds (8 bytes)
+//   [8:]   Reason
+
+// FrozenAccount describes an account whose outputs may not be spent.
+type FrozenAccount struct {
+	Account uint32
+	Frozen  time.Time
+	Reason  string
+}
+
+// AccountFrozenError describes an error where a transaction spending outputs
+// of a frozen account is not signed or published.
+type AccountFrozenError struct {
+	Account uint32
+}
+
+// Error implements the error interface.
+func (e *AccountFrozenError) Error() string {
+	return fmt.Sprintf("account %d is frozen", e.Account)
+}
+
+// accountFreezes holds the frozen accounts, loaded when the wallet is opened
+// so that spends are checked without reading the database.
+type accountFreezes struct {
+	mu     sync.Mutex
+	frozen map[uint32]FrozenAccount
+}
+
+// fetchFrozenAccounts returns the frozen accounts recorded in the transaction
+// metadata namespace.
+func fetchFrozenAccounts(ns walletdb.ReadBucket) (map[uint32]FrozenAccount, error) {
+	frozen := make(map[uint32]FrozenAccount)
+	bucket := ns.NestedReadBucket(frozenAccountsBucketKey)
+	if bucket == nil {
+		return frozen, nil
+	}
+	err := bucket.ForEach(func(k, v []byte) error {
+		if len(k) != 4 || len(v) < 8 {
+			return fmt.Errorf("frozen account %x is malformed", k)
+		}
+		account := binary.BigEndian.Uint32(k)
+		frozen[account] = FrozenAccount{
+			Account: account,
+			Frozen:  time.Unix(int64(binary.BigEndian.Uint64(v)), 0),
+			Reason:  string(v[8:]),
+		}
+		return nil
+	})
+	return frozen, err
+}
+
+// FreezeAccount immediately blocks the spends of the outputs of an account,
+// which are neither signed nor published until the account is unfrozen,
+// while its outputs are still received and queried.  The account number
+// applies to every key scope, and the freeze persists across restarts.
+// Freezing a frozen account updates its reason.
+func (w *Wallet) FreezeAccount(account uint32, reason string) error {
+	f := FrozenAccount{
+		Account: account,
+		Frozen:  time.Now(),
+		Reason:  reason,
+	}
+
+	w.freezes.mu.Lock()
+	defer w.freezes.mu.Unlock()
+	if w.freezes.frozen == nil {
+		w.freezes.frozen = make(map[uint32]FrozenAccount)
+	}
+	if prev, ok := w.freezes.frozen[account]; ok {
+		f.Frozen = prev.Frozen
+	}
+
+	// The account is frozen in memory even when the freeze can't be
+	// recorded, so that it is frozen until the wallet is restarted.
+	w.freezes.frozen[account] = f
+	err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket, err := ns.CreateBucketIfNotExists(frozenAccountsBucketKey)
+		if err != nil {
+			return err
+		}
+		v := make([]byte, 8+len(reason))
+		binary.BigEndian.PutUint64(v, uint64(f.Frozen.Unix()))
+		copy(v[8:], reason)
+		return bucket.Put(accountKey(account), v)
+	})
+	if err != nil {
+		return err
+	}
+	log.Warnf("Froze account %d: %s", account, reason)
+	w.audit(AuditFreeze, "account %d frozen: %s", account, reason)
+	return nil
+}
+
+// UnfreezeAccount lifts the freeze of an account.  It requires the private
+// passphrase of the wallet, so that clients able to freeze accounts can't lift
+// a freeze, and fails with ErrWrongPassphrase otherwise.
+func (w *Wallet) UnfreezeAccount(account uint32, privPassphrase []byte) error {
+	if err := w.Manager.CheckPrivatePassphrase(privPassphrase); err != nil {
+		w.audit(AuditWrongPassphrase, "account %d unfreeze refused",
+			account)
+		return err
+	}
+
+	w.freezes.mu.Lock()
+	defer w.freezes.mu.Unlock()
+	if _, ok := w.freezes.frozen[account]; !ok {
+		return fmt.Errorf("account %d is not frozen", account)
+	}
+	err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket := ns.NestedReadWriteBucket(frozenAccountsBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete(accountKey(account))
+	})
+	if err != nil {
+		return err
+	}
+	delete(w.freezes.frozen, account)
+	log.Infof("Unfroze account %d", account)
+	w.audit(AuditFreeze, "account %d unfrozen", account)
+	return nil
+}
+
+// FrozenAccounts returns the frozen accounts ordered by account number.
+func (w *Wallet) FrozenAccounts() []FrozenAccount {
+	w.freezes.mu.Lock()
+	frozen := make([]FrozenAccount, 0, len(w.freezes.frozen))
+	for _, f := range w.freezes.frozen {
+		frozen = append(frozen, f)
+	}
+	w.freezes.mu.Unlock()
+
+	sort.Slice(frozen, func(i, j int) bool {
+		return frozen[i].Account < frozen[j].Account
+	})
+	return frozen
+}
+
+// checkAccountFrozen returns an AccountFrozenError when an account is frozen.
+func (w *Wallet) checkAccountFrozen(account uint32) error {
+	w.freezes.mu.Lock()
+	_, ok := w.freezes.frozen[account]
+	w.freezes.mu.Unlock()
+	if ok {
+		return &AccountFrozenError{Account: account}
+	}
+	return nil
+}
+
+// checkFrozenSpends returns an AccountFrozenError when an input of tx spends
+// an output of a frozen account.
+func (w *Wallet) checkFrozenSpends(dbtx walletdb.ReadTx, tx *wire.MsgTx) error {
+	w.freezes.mu.Lock()
+	none := len(w.freezes.frozen) == 0
+	w.freezes.mu.Unlock()
+	if none {
+		return nil
+	}
+
+	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+	txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+	for _, txIn := range tx.TxIn {
+		prevOut, err := w.prevOutput(txmgrNs, &txIn.PreviousOutPoint)
+		if err != nil {
+			return err
+		}
+		if prevOut == nil {
+			continue
+		}
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+			prevOut.PkScript, w.chainParams)
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+		_, account, err := w.Manager.AddrAccount(addrmgrNs, addrs[0])
+		if err != nil {
+			continue
+		}
+		if err := w.checkAccountFrozen(account); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestFreezeAccount checks that frozen accounts are refused and persisted.
+func TestFreezeAccount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accountfreeze")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+
+	if err := w.checkAccountFrozen(1); err != nil {
+		t.Fatalf("account frozen before the freeze: %v", err)
+	}
+	if err := w.FreezeAccount(1, "suspected compromise"); err != nil {
+		t.Fatal(err)
+	}
+	err = w.checkAccountFrozen(1)
+	if e, ok := err.(*AccountFrozenError); !ok || e.Account != 1 {
+		t.Errorf("frozen account not refused: %v", err)
+	}
+	if err := w.checkAccountFrozen(0); err != nil {
+		t.Errorf("other account refused: %v", err)
+	}
+
+	var frozen map[uint32]FrozenAccount
+	err = walletdb.View(db, func(dbtx walletdb.ReadTx) error {
+		var err error
+		frozen, err = fetchFrozenAccounts(
+			dbtx.ReadBucket(wtxmetaNamespaceKey))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, ok := frozen[1]
+	if len(frozen) != 1 || !ok || f.Reason != "suspected compromise" {
+		t.Errorf("unexpected persisted freezes %v", frozen)
+	}
+	if list := w.FrozenAccounts(); len(list) != 1 || list[0].Account != 1 {
+		t.Errorf("unexpected frozen accounts %v", list)
+	}
+}
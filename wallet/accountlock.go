@@ -0,0 +1,173 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// scopedAccount identifies an account of a key scope.
+type scopedAccount struct {
+	scope   waddrmgr.KeyScope
+	account uint32
+}
+
+// accountLocks are the timers relocking the accounts unlocked with their own
+// passphrases.
+type accountLocks struct {
+	mu     sync.Mutex
+	timers map[scopedAccount]*time.Timer
+}
+
+// stop stops the timer relocking an account, if any.
+//
+// This function MUST be called with the mutex held.
+func (l *accountLocks) stop(acct scopedAccount) {
+	if t, ok := l.timers[acct]; ok {
+		t.Stop()
+		delete(l.timers, acct)
+	}
+}
+
+// SetAccountPassphrase protects an account with its own passphrase, or
+// removes the passphrase of the account when the passphrase is empty.  The
+// account is locked, independently from the wallet, until it is unlocked with
+// UnlockAccount, and the wallet passphrase no longer gives access to its
+// private keys.  The wallet must be unlocked.
+func (w *Wallet) SetAccountPassphrase(scope waddrmgr.KeyScope, account uint32,
+	passphrase []byte) error {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+
+	w.acctLocks.mu.Lock()
+	defer w.acctLocks.mu.Unlock()
+
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return manager.SetAccountPassphrase(addrmgrNs, account, passphrase)
+	})
+	if err != nil {
+		return err
+	}
+	w.acctLocks.stop(scopedAccount{scope, account})
+
+	if len(passphrase) == 0 {
+		log.Infof("Removed the passphrase of account %d", account)
+	} else {
+		log.Infof("Account %d is now protected by its own passphrase",
+			account)
+	}
+	return nil
+}
+
+// UnlockAccount unlocks an account protected by its own passphrase and
+// relocks it after the timeout has expired, or never when the timeout is
+// zero.  The account is unlocked independently from the wallet, which may
+// remain locked.  Unlocking an unlocked account replaces its timeout.
+func (w *Wallet) UnlockAccount(scope waddrmgr.KeyScope, account uint32,
+	passphrase []byte, timeout time.Duration) error {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+
+	if err := w.throttleUnlock(); err != nil {
+		return err
+	}
+
+	w.acctLocks.mu.Lock()
+	defer w.acctLocks.mu.Unlock()
+
+	err = manager.UnlockAccount(account, passphrase)
+	w.recordUnlock(err)
+	if err != nil {
+		return err
+	}
+
+	w.audit(AuditUnlock, "account %d unlocked", account)
+
+	acct := scopedAccount{scope, account}
+	w.acctLocks.stop(acct)
+	if timeout == 0 {
+		log.Infof("Account %d has been unlocked without a time limit",
+			account)
+		return nil
+	}
+
+	// The timer is only stored once the mutex is released by this
+	// function, so the expired timer is compared with the stored one to
+	// ignore the timers replaced by later unlocks.
+	var t *time.Timer
+	t = time.AfterFunc(timeout, func() {
+		w.acctLocks.mu.Lock()
+		defer w.acctLocks.mu.Unlock()
+
+		if w.acctLocks.timers[acct] != t {
+			return
+		}
+		delete(w.acctLocks.timers, acct)
+		if err := manager.LockAccount(account); err != nil {
+			log.Errorf("Could not lock account %d: %v", account, err)
+			return
+		}
+		log.Infof("Account %d has been locked", account)
+	})
+	if w.acctLocks.timers == nil {
+		w.acctLocks.timers = make(map[scopedAccount]*time.Timer)
+	}
+	w.acctLocks.timers[acct] = t
+
+	log.Infof("Account %d has been temporarily unlocked", account)
+	return nil
+}
+
+// LockAccount locks an account protected by its own passphrase.
+func (w *Wallet) LockAccount(scope waddrmgr.KeyScope, account uint32) error {
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+
+	w.acctLocks.mu.Lock()
+	defer w.acctLocks.mu.Unlock()
+
+	w.acctLocks.stop(scopedAccount{scope, account})
+	if err := manager.LockAccount(account); err != nil {
+		return err
+	}
+	log.Infof("Account %d has been locked", account)
+	return nil
+}
+
+// AccountLocked returns whether the private keys of an account are
+// unavailable.  Accounts protected by their own passphrases are locked
+// independently from the wallet, while the other accounts are locked with
+// the wallet.
+func (w *Wallet) AccountLocked(scope waddrmgr.KeyScope, account uint32) (bool, error) {
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return false, err
+	}
+	return manager.IsAccountLocked(account), nil
+}
+
+// lockAccounts locks every account unlocked with its own passphrase.
+func (w *Wallet) lockAccounts() {
+	w.acctLocks.mu.Lock()
+	defer w.acctLocks.mu.Unlock()
+
+	for acct := range w.acctLocks.timers {
+		w.acctLocks.stop(acct)
+	}
+	w.Manager.LockAccounts()
+}
@@ -0,0 +1,327 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+const (
+	// metadataAnchorCheckInterval is the interval between two checks of
+	// the accounts whose metadata is due to be anchored.
+	metadataAnchorCheckInterval = 10 * time.Minute
+
+	// metadataAnchorMinConf is the number of confirmations of the outputs
+	// spent by anchor transactions.
+	metadataAnchorMinConf = 1
+)
+
+var (
+	// accountMetaBucketKey is the key of the bucket in the transaction
+	// metadata namespace holding the metadata of the accounts, in a
+	// nested bucket per big endian account number.
+	accountMetaBucketKey = []byte("accountmeta")
+
+	// metadataAnchorBucketKey is the key of the bucket in the transaction
+	// metadata namespace holding the last anchor of the metadata of each
+	// account, keyed by the big endian account number.
+	metadataAnchorBucketKey = []byte("metadataanchor")
+
+	// metadataAnchorTag prefixes the metadata hash in the OP_RETURN
+	// output of anchor transactions.
+	metadataAnchorTag = []byte("BWMA")
+)
+
+// ErrMetadataAnchored describes an error where the metadata of an account is
+// anchored while its last anchor already commits to it.
+var ErrMetadataAnchored = errors.New("account metadata is already anchored")
+
+// MetadataAnchor is a transaction committing to the metadata of an account
+// in an OP_RETURN output, a timestamped proof of the metadata once mined.
+type MetadataAnchor struct {
+	Account uint32
+	Hash    chainhash.Hash
+	TxHash  chainhash.Hash
+	Time    time.Time
+}
+
+// metadataAnchoring holds the interval between two anchors of the metadata
+// of an account.
+type metadataAnchoring struct {
+	mu       sync.Mutex
+	interval time.Duration
+}
+
+func accountKey(account uint32) []byte {
+	var k [4]byte
+	binary.BigEndian.PutUint32(k[:], account)
+	return k[:]
+}
+
+// SetAccountMetadata sets the value of a metadata key of an account, such as
+// a label or a bookkeeping reference.  An empty value removes the key.
+func (w *Wallet) SetAccountMetadata(account uint32, key, value string) error {
+	if key == "" {
+		return errors.New("metadata key must not be empty")
+	}
+	return walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket, err := ns.CreateBucketIfNotExists(accountMetaBucketKey)
+		if err != nil {
+			return err
+		}
+		bucket, err = bucket.CreateBucketIfNotExists(accountKey(account))
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			return bucket.Delete([]byte(key))
+		}
+		return bucket.Put([]byte(key), []byte(value))
+	})
+}
+
+// AccountMetadata returns the metadata of an account.
+func (w *Wallet) AccountMetadata(account uint32) (map[string]string, error) {
+	meta := make(map[string]string)
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(accountMetaBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		bucket = bucket.NestedReadBucket(accountKey(account))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			meta[string(k)] = string(v)
+			return nil
+		})
+	})
+	return meta, err
+}
+
+// AccountMetadataHash returns the commitment to the metadata of an account,
+// the double SHA256 hash of the account number and of the keys and values
+// sorted by key.
+func (w *Wallet) AccountMetadataHash(account uint32) (chainhash.Hash, error) {
+	meta, err := w.AccountMetadata(account)
+	if err != nil {
+		return chainhash.Hash{}, err
+	}
+	return accountMetadataHash(account, meta), nil
+}
+
+func accountMetadataHash(account uint32, meta map[string]string) chainhash.Hash {
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(accountKey(account))
+	wire.WriteVarInt(&buf, 0, uint64(len(keys)))
+	for _, k := range keys {
+		wire.WriteVarString(&buf, 0, k)
+		wire.WriteVarString(&buf, 0, meta[k])
+	}
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// metadataAnchorScript returns the OP_RETURN output script committing to a
+// metadata hash.
+func metadataAnchorScript(hash *chainhash.Hash) ([]byte, error) {
+	data := make([]byte, 0, len(metadataAnchorTag)+chainhash.HashSize)
+	data = append(data, metadataAnchorTag...)
+	data = append(data, hash[:]...)
+	return txscript.NullDataScript(data)
+}
+
+// LastMetadataAnchor returns the last anchor of the metadata of an account,
+// or nil if it was never anchored.
+func (w *Wallet) LastMetadataAnchor(account uint32) (*MetadataAnchor, error) {
+	var anchor *MetadataAnchor
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(metadataAnchorBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		v := bucket.Get(accountKey(account))
+		if v == nil {
+			return nil
+		}
+		if len(v) != 72 {
+			return errors.New("malformed metadata anchor")
+		}
+		anchor = &MetadataAnchor{
+			Account: account,
+			Time:    time.Unix(int64(binary.BigEndian.Uint64(v[64:])), 0),
+		}
+		copy(anchor.Hash[:], v[:32])
+		copy(anchor.TxHash[:], v[32:64])
+		return nil
+	})
+	return anchor, err
+}
+
+// Metadata anchors are serialized as such:
+//
+//   [0:32]  Metadata hash
+//   [32:64] Anchor transaction hash
+//   [64:72] Unix time (8 bytes)
+
+func putMetadataAnchor(ns walletdb.ReadWriteBucket, a *MetadataAnchor) error {
+	bucket, err := ns.CreateBucketIfNotExists(metadataAnchorBucketKey)
+	if err != nil {
+		return err
+	}
+	v := make([]byte, 72)
+	copy(v, a.Hash[:])
+	copy(v[32:], a.TxHash[:])
+	binary.BigEndian.PutUint64(v[64:], uint64(a.Time.Unix()))
+	return bucket.Put(accountKey(a.Account), v)
+}
+
+// AnchorAccountMetadata sends a transaction from an account committing to its
+// metadata in an OP_RETURN output, paying the fee from the account.
+// ErrMetadataAnchored is returned when the last anchor of the account already
+// commits to its metadata.
+func (w *Wallet) AnchorAccountMetadata(account uint32) (*MetadataAnchor, error) {
+	hash, err := w.AccountMetadataHash(account)
+	if err != nil {
+		return nil, err
+	}
+	last, err := w.LastMetadataAnchor(account)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil && last.Hash == hash {
+		return nil, ErrMetadataAnchored
+	}
+
+	script, err := metadataAnchorScript(&hash)
+	if err != nil {
+		return nil, err
+	}
+	outputs := []*wire.TxOut{wire.NewTxOutToken(0, script, wire.STB)}
+	txHash, err := w.SendOutputs(outputs, account, metadataAnchorMinConf,
+		w.FeeRate(0), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	anchor := &MetadataAnchor{
+		Account: account,
+		Hash:    hash,
+		TxHash:  *txHash,
+		Time:    time.Now(),
+	}
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		return putMetadataAnchor(dbtx.ReadWriteBucket(wtxmetaNamespaceKey),
+			anchor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Anchored metadata %v of account %d in transaction %v",
+		hash, account, txHash)
+	return anchor, nil
+}
+
+// SetMetadataAnchorInterval configures the wallet to anchor the metadata of
+// its accounts when it changed and the last anchor is older than the
+// interval.  A zero interval disables anchoring, except by
+// AnchorAccountMetadata.
+func (w *Wallet) SetMetadataAnchorInterval(interval time.Duration) {
+	w.metadataAnchoring.mu.Lock()
+	w.metadataAnchoring.interval = interval
+	w.metadataAnchoring.mu.Unlock()
+}
+
+// metadataAnchorMonitor periodically anchors the metadata of the accounts
+// which are due.  It must be run as a goroutine.
+func (w *Wallet) metadataAnchorMonitor() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(metadataAnchorCheckInterval)
+	defer ticker.Stop()
+	quit := w.quitChan()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.anchorDueMetadata(); err != nil {
+				log.Errorf("Cannot anchor account metadata: %v",
+					err)
+			}
+		case <-quit:
+			return
+		}
+	}
+}
+
+// anchorDueMetadata anchors the metadata of the accounts whose metadata
+// changed since their last anchor, when it is older than the anchor interval.
+func (w *Wallet) anchorDueMetadata() error {
+	w.metadataAnchoring.mu.Lock()
+	interval := w.metadataAnchoring.interval
+	w.metadataAnchoring.mu.Unlock()
+	if interval == 0 {
+		return nil
+	}
+	if w.Manager.IsLocked() {
+		log.Debugf("Not anchoring account metadata: wallet is locked")
+		return nil
+	}
+
+	var accounts []uint32
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(accountMetaBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			if v == nil && len(k) == 4 {
+				accounts = append(accounts,
+					binary.BigEndian.Uint32(k))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		last, err := w.LastMetadataAnchor(account)
+		if err != nil {
+			return err
+		}
+		if last != nil && time.Since(last.Time) < interval {
+			continue
+		}
+		_, err = w.AnchorAccountMetadata(account)
+		switch err {
+		case nil, ErrMetadataAnchored:
+		default:
+			log.Warnf("Cannot anchor metadata of account %d: %v",
+				account, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestAccountMetadata checks that the metadata of accounts is stored
+// separately, that its hash commits to every key and value, and that metadata
+// anchors are recorded.
+func TestAccountMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accountmeta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+
+	empty, err := w.AccountMetadataHash(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetAccountMetadata(1, "label", "payroll"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetAccountMetadata(1, "ledger", "4100"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetAccountMetadata(2, "label", "payroll"); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := w.AccountMetadata(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta) != 2 || meta["label"] != "payroll" || meta["ledger"] != "4100" {
+		t.Fatalf("unexpected metadata %v", meta)
+	}
+
+	hash1, _ := w.AccountMetadataHash(1)
+	hash2, _ := w.AccountMetadataHash(2)
+	if hash1 == empty || hash1 == hash2 {
+		t.Fatalf("metadata hash does not commit to the account metadata")
+	}
+	if err := w.SetAccountMetadata(1, "ledger", "4200"); err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := w.AccountMetadataHash(1); changed == hash1 {
+		t.Fatalf("metadata hash does not commit to the values")
+	}
+
+	// Removing every key returns the hash of empty metadata.
+	w.SetAccountMetadata(1, "label", "")
+	w.SetAccountMetadata(1, "ledger", "")
+	if removed, _ := w.AccountMetadataHash(1); removed != empty {
+		t.Fatalf("removed metadata hash %v, want %v", removed, empty)
+	}
+
+	script, err := metadataAnchorScript(&hash1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pushes, err := txscript.PushedData(script)
+	if err != nil || len(pushes) != 1 ||
+		!bytes.Equal(pushes[0], append([]byte("BWMA"), hash1[:]...)) {
+		t.Fatalf("unexpected anchor script %x", script)
+	}
+
+	anchor := &MetadataAnchor{
+		Account: 2,
+		Hash:    hash2,
+		TxHash:  chainhash.Hash{1},
+		Time:    time.Unix(1500000000, 0),
+	}
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		return putMetadataAnchor(dbtx.ReadWriteBucket(wtxmetaNamespaceKey),
+			anchor)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, err := w.LastMetadataAnchor(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last == nil || *last != *anchor {
+		t.Fatalf("last anchor is %+v, want %+v", last, anchor)
+	}
+	if last, _ := w.LastMetadataAnchor(1); last != nil {
+		t.Fatalf("unexpected anchor %+v", last)
+	}
+
+	// Anchoring metadata committed to by the last anchor fails before
+	// a transaction is created.
+	if _, err := w.AnchorAccountMetadata(2); err != ErrMetadataAnchored {
+		t.Fatalf("anchored metadata returned %v", err)
+	}
+}
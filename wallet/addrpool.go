@@ -0,0 +1,286 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/wallet/descriptor"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// An address pool is a receive-only set of addresses derived from the
+// extended public key of a third party, such as the cold wallet of a
+// custodian.  The wallet holds no keys of the pool and does not watch its
+// addresses: pool addresses are only used as destinations.  Sweeps may be
+// sent to the next address of a pool, and accounts pinned to a pool may only
+// pay to addresses of the pool.
+
+// addrPoolBucketKey is the key of the bucket in the transaction metadata
+// namespace holding the address pools, keyed by name, with their JSON
+// encoding.
+var addrPoolBucketKey = []byte("addrpools")
+
+// MaxAddressPoolWindow is the maximum number of addresses past the next
+// address of a pool which are accepted as destinations.
+const MaxAddressPoolWindow = 1000
+
+// ErrAddressPoolNotFound is returned for names of pools which do not exist.
+var ErrAddressPoolNotFound = errors.New("address pool not found")
+
+// AddressPool is a receive-only pool of addresses derived from an external
+// extended public key.
+type AddressPool struct {
+	// Name is the name of the pool.
+	Name string `json:"-"`
+
+	// Descriptor is the output descriptor of the pool addresses.  Pool
+	// addresses are derived from the first branch of the descriptor.
+	Descriptor string `json:"descriptor"`
+
+	// NextIndex is the index of the next address returned for the pool.
+	NextIndex uint32 `json:"nextindex"`
+
+	// Window is the number of addresses past NextIndex which are also
+	// accepted as destinations, so that the third party may hand out
+	// addresses of the pool itself.
+	Window uint32 `json:"window"`
+
+	// Accounts are the accounts pinned to the pool.  Accounts pinned to
+	// pools may only pay to addresses of those pools.
+	Accounts []uint32 `json:"accounts,omitempty"`
+}
+
+// PoolDestinationError is returned when a transaction of an account pinned to
+// address pools pays to an address outside of them.  Address is nil when the
+// output does not pay to an address.
+type PoolDestinationError struct {
+	Account uint32
+	Address btcutil.Address
+}
+
+func (e *PoolDestinationError) Error() string {
+	if e.Address == nil {
+		return fmt.Sprintf("account %d may only pay to the addresses of "+
+			"its address pools", e.Account)
+	}
+	return fmt.Sprintf("account %d may only pay to the addresses of its "+
+		"address pools, not to %v", e.Account, e.Address)
+}
+
+// PinAddressPool adds an address pool, or replaces the window and pinned
+// accounts of the pool with the same name and descriptor.
+func (w *Wallet) PinAddressPool(pool *AddressPool) error {
+	if pool.Name == "" {
+		return errors.New("address pool name is required")
+	}
+	if pool.Window > MaxAddressPoolWindow {
+		return fmt.Errorf("address pool window %d exceeds %d", pool.Window,
+			MaxAddressPoolWindow)
+	}
+	desc, err := descriptor.Parse(pool.Descriptor, w.chainParams)
+	if err != nil {
+		return err
+	}
+
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket, err := ns.CreateBucketIfNotExists(addrPoolBucketKey)
+		if err != nil {
+			return err
+		}
+
+		p := *pool
+		p.Descriptor = desc.String()
+		p.NextIndex = 0
+		if v := bucket.Get([]byte(pool.Name)); v != nil {
+			var old AddressPool
+			if err := json.Unmarshal(v, &old); err != nil {
+				return err
+			}
+			if old.Descriptor != p.Descriptor {
+				return fmt.Errorf("address pool %q already exists "+
+					"with another descriptor", pool.Name)
+			}
+			p.NextIndex = old.NextIndex
+		}
+		v, err := json.Marshal(&p)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(pool.Name), v)
+	})
+	if err != nil {
+		return err
+	}
+	w.audit(AuditAddressPool, "address pool %s pinned to %s for accounts %v",
+		pool.Name, desc, pool.Accounts)
+	return nil
+}
+
+// UnpinAddressPool removes an address pool.
+func (w *Wallet) UnpinAddressPool(name string) error {
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		bucket := tx.ReadWriteBucket(wtxmetaNamespaceKey).
+			NestedReadWriteBucket(addrPoolBucketKey)
+		if bucket == nil || bucket.Get([]byte(name)) == nil {
+			return ErrAddressPoolNotFound
+		}
+		return bucket.Delete([]byte(name))
+	})
+	if err != nil {
+		return err
+	}
+	w.audit(AuditAddressPool, "address pool %s removed", name)
+	return nil
+}
+
+// addressPools returns the address pools sorted by name.
+func addressPools(tx walletdb.ReadTx) ([]AddressPool, error) {
+	bucket := tx.ReadBucket(wtxmetaNamespaceKey).
+		NestedReadBucket(addrPoolBucketKey)
+	if bucket == nil {
+		return nil, nil
+	}
+	var pools []AddressPool
+	err := bucket.ForEach(func(k, v []byte) error {
+		pool := AddressPool{Name: string(k)}
+		if err := json.Unmarshal(v, &pool); err != nil {
+			return err
+		}
+		pools = append(pools, pool)
+		return nil
+	})
+	sort.Slice(pools, func(i, j int) bool {
+		return pools[i].Name < pools[j].Name
+	})
+	return pools, err
+}
+
+// AddressPools returns the address pools sorted by name.
+func (w *Wallet) AddressPools() ([]AddressPool, error) {
+	var pools []AddressPool
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		var err error
+		pools, err = addressPools(tx)
+		return err
+	})
+	return pools, err
+}
+
+// NextPoolAddress returns the next address of an address pool, which is not
+// returned again.
+func (w *Wallet) NextPoolAddress(name string) (btcutil.Address, error) {
+	var addr btcutil.Address
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		bucket := tx.ReadWriteBucket(wtxmetaNamespaceKey).
+			NestedReadWriteBucket(addrPoolBucketKey)
+		if bucket == nil {
+			return ErrAddressPoolNotFound
+		}
+		v := bucket.Get([]byte(name))
+		if v == nil {
+			return ErrAddressPoolNotFound
+		}
+		var pool AddressPool
+		if err := json.Unmarshal(v, &pool); err != nil {
+			return err
+		}
+		desc, err := descriptor.Parse(pool.Descriptor, w.chainParams)
+		if err != nil {
+			return err
+		}
+		addr, err = desc.Address(desc.Branches[0], pool.NextIndex,
+			w.chainParams)
+		if err != nil {
+			return err
+		}
+
+		pool.NextIndex++
+		v, err = json.Marshal(&pool)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(name), v)
+	})
+	return addr, err
+}
+
+// poolAddresses returns the encoded addresses of a pool accepted as
+// destinations.
+func (w *Wallet) poolAddresses(pool *AddressPool) (map[string]struct{}, error) {
+	desc, err := descriptor.Parse(pool.Descriptor, w.chainParams)
+	if err != nil {
+		return nil, err
+	}
+	end := pool.NextIndex + pool.Window
+	addrs := make(map[string]struct{}, end)
+	for i := uint32(0); i < end; i++ {
+		addr, err := desc.Address(desc.Branches[0], i, w.chainParams)
+		if err != nil {
+			// Indexes which do not derive a valid key are
+			// skipped.
+			continue
+		}
+		addrs[addr.EncodeAddress()] = struct{}{}
+	}
+	return addrs, nil
+}
+
+// checkPoolDestinations returns a *PoolDestinationError if the account is
+// pinned to address pools and an output does not pay to an address of one of
+// them.
+func (w *Wallet) checkPoolDestinations(account uint32, outputs []*wire.TxOut) error {
+	pools, err := w.AddressPools()
+	if err != nil {
+		return err
+	}
+
+	var allowed map[string]struct{}
+	for i := range pools {
+		pinned := false
+		for _, a := range pools[i].Accounts {
+			pinned = pinned || a == account
+		}
+		if !pinned {
+			continue
+		}
+		addrs, err := w.poolAddresses(&pools[i])
+		if err != nil {
+			return err
+		}
+		if allowed == nil {
+			allowed = addrs
+			continue
+		}
+		for addr := range addrs {
+			allowed[addr] = struct{}{}
+		}
+	}
+	if allowed == nil {
+		return nil
+	}
+
+	for _, output := range outputs {
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(output.PkScript,
+			w.chainParams)
+		if err != nil || len(addrs) != 1 {
+			return &PoolDestinationError{Account: account}
+		}
+		if _, ok := allowed[addrs[0].EncodeAddress()]; !ok {
+			return &PoolDestinationError{
+				Account: account,
+				Address: addrs[0],
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/wallet/descriptor"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// xpubPool is an account extended public key of the seed 000102...1f.
+const xpubPool = "xpub6DEHh42YXj7gdKbP1zxehEzQt47iW2AuUHYsqGaRxtkEGrK3bCYh" +
+	"2bsw1H6WUW26k9TBdQoe6gZ8ydoAP5eGAC2fDJGmFkwXcgv5feY9N7p"
+
+// TestAddressPool checks that pool addresses are handed out once, and that
+// accounts pinned to a pool may only pay to its addresses.
+func TestAddressPool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "addrpool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db, chainParams: &chaincfg.MainNetParams}
+
+	pool := &AddressPool{
+		Name:       "cold",
+		Descriptor: "wpkh(" + xpubPool + "/0/*)",
+		Window:     2,
+		Accounts:   []uint32{1},
+	}
+	if err := w.PinAddressPool(pool); err != nil {
+		t.Fatal(err)
+	}
+	first, err := w.NextPoolAddress("cold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := w.NextPoolAddress("cold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.EncodeAddress() == second.EncodeAddress() {
+		t.Fatal("pool address returned twice")
+	}
+	if _, err := w.NextPoolAddress("hot"); err != ErrAddressPoolNotFound {
+		t.Fatalf("address of unknown pool returned %v", err)
+	}
+
+	output := func(addr btcutil.Address) []*wire.TxOut {
+		pkScript, err := taproot.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []*wire.TxOut{wire.NewTxOut(1e8, pkScript)}
+	}
+	other, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20),
+		w.chainParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Addresses within the window past the next address are accepted,
+	// but not the addresses after them.
+	pools, err := w.AddressPools()
+	if err != nil || len(pools) != 1 || pools[0].NextIndex != 2 {
+		t.Fatalf("unexpected pools %+v: %v", pools, err)
+	}
+	desc, err := descriptor.Parse(pool.Descriptor, w.chainParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ahead, err := desc.Address(0, 3, w.chainParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	beyond, err := desc.Address(0, 4, w.chainParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range []btcutil.Address{first, second, ahead} {
+		if err := w.checkPoolDestinations(1, output(addr)); err != nil {
+			t.Errorf("payment to pool address %v rejected: %v", addr, err)
+		}
+	}
+	for _, addr := range []btcutil.Address{beyond, other} {
+		err := w.checkPoolDestinations(1, output(addr))
+		if _, ok := err.(*PoolDestinationError); !ok {
+			t.Errorf("payment to %v returned %v", addr, err)
+		}
+	}
+	if err := w.checkPoolDestinations(0, output(other)); err != nil {
+		t.Errorf("payment of an unpinned account rejected: %v", err)
+	}
+
+	// Pinning again keeps the next address, but another descriptor may
+	// not replace the pool.
+	pool.Accounts = nil
+	if err := w.PinAddressPool(pool); err != nil {
+		t.Fatal(err)
+	}
+	pools, err = w.AddressPools()
+	if err != nil || len(pools) != 1 || pools[0].NextIndex != 2 {
+		t.Fatalf("unexpected pools %+v: %v", pools, err)
+	}
+	if err := w.checkPoolDestinations(1, output(other)); err != nil {
+		t.Errorf("payment of an unpinned account rejected: %v", err)
+	}
+	pool.Descriptor = "tr(" + xpubPool + "/0/*)"
+	if err := w.PinAddressPool(pool); err == nil {
+		t.Error("pool replaced with another descriptor")
+	}
+
+	if err := w.UnpinAddressPool("cold"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.UnpinAddressPool("cold"); err != ErrAddressPoolNotFound {
+		t.Fatalf("removing a removed pool returned %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/psbt"
+)
+
+// psbtMagic is the prefix of binary PSBTs.
+var psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+// WritePsbtFile writes the binary serialization of a PSBT to a new file, as
+// read by offline signers from removable media.  Existing files are not
+// overwritten.
+func WritePsbtFile(path string, packet *psbt.Packet) error {
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ParseSignedTx decodes a transaction signed by an offline signer.  The data
+// is either a PSBT, whose inputs must all be finalized or have all of their
+// signatures, or a network serialized transaction.  Both may be binary or
+// text: PSBTs in base64 and transactions in hex.
+func ParseSignedTx(data []byte) (*wire.MsgTx, error) {
+	if text := bytes.TrimSpace(data); len(text) != 0 {
+		if decoded, err := base64.StdEncoding.DecodeString(string(text)); err == nil &&
+			bytes.HasPrefix(decoded, psbtMagic) {
+
+			data = decoded
+		} else if decoded, err := hex.DecodeString(string(text)); err == nil {
+			data = decoded
+		}
+	}
+
+	if bytes.HasPrefix(data, psbtMagic) {
+		packet, err := psbt.Parse(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		packet.FinalizeAll()
+		return packet.Extract()
+	}
+
+	tx := new(wire.MsgTx)
+	if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// PublishSignedTx publishes a transaction created unsigned by the wallet,
+// such as with FundPsbt, once it was signed by an offline signer.  The
+// inputs, which were locked when the unsigned transaction was created, are
+// unlocked since they are now spent by a wallet transaction.
+func (w *Wallet) PublishSignedTx(tx *wire.MsgTx) (*chainhash.Hash, error) {
+	for _, txIn := range tx.TxIn {
+		if len(txIn.SignatureScript) == 0 && len(txIn.Witness) == 0 {
+			return nil, errors.New("transaction is not signed")
+		}
+	}
+
+	txHash, err := w.publishTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, txIn := range tx.TxIn {
+		w.UnlockOutpoint(txIn.PreviousOutPoint)
+	}
+	return txHash, nil
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// apiTokensBucketKey is the key of the bucket in the transaction metadata
+// namespace recording the API tokens authenticating the RPC clients, keyed by
+// token name.
+var apiTokensBucketKey = []byte("apitokens")
+
+// API tokens are serialized as such:
+//
+//   [0:8]  Time created, as unix seconds (8 bytes)
+//   [8:40] SHA256 hash of the secret (32 bytes)
+//
+// Only the hash of the secret is recorded, so the secret cannot be recovered
+// from the wallet database.
+
+// apiTokenSecretSize is the number of random bytes of the API token secrets.
+const apiTokenSecretSize = 32
+
+var (
+	// ErrUnknownAPIToken describes an error where an API token does not
+	// exist.
+	ErrUnknownAPIToken = errors.New("unknown API token")
+
+	// ErrInvalidAPIToken describes an error where an API token is
+	// presented with a wrong secret, or does not exist.
+	ErrInvalidAPIToken = errors.New("invalid API token")
+)
+
+// APIToken describes a named API token authenticating an RPC client, such as
+// one of several frontends of the wallet.
+type APIToken struct {
+	Name    string
+	Created time.Time
+
+	// Secret is only set for the token returned by CreateAPIToken.  The
+	// wallet only records its hash.
+	Secret string
+
+	secretHash [sha256.Size]byte
+}
+
+// apiTokens holds the channels closed when the API tokens authenticating
+// connected clients are revoked.
+type apiTokens struct {
+	mu      sync.Mutex
+	revoked map[string]chan struct{}
+}
+
+func serializeAPIToken(t *APIToken) []byte {
+	v := make([]byte, 40)
+	binary.BigEndian.PutUint64(v[0:8], uint64(t.Created.Unix()))
+	copy(v[8:40], t.secretHash[:])
+	return v
+}
+
+func deserializeAPIToken(name string, v []byte) (*APIToken, error) {
+	if len(v) != 40 {
+		return nil, fmt.Errorf("malformed API token %q", name)
+	}
+	t := &APIToken{
+		Name:    name,
+		Created: time.Unix(int64(binary.BigEndian.Uint64(v[0:8])), 0),
+	}
+	copy(t.secretHash[:], v[8:40])
+	return t, nil
+}
+
+// CreateAPIToken creates a named API token with a new random secret, which is
+// only returned this once.  RPC clients authenticate with the token by using
+// its name and secret as their username and password, so that each frontend
+// of the wallet is distinguished in the logs and may be revoked without
+// changing the credentials of the others.
+func (w *Wallet) CreateAPIToken(name string) (*APIToken, error) {
+	if name == "" || len(name) > 255 || strings.ContainsRune(name, ':') {
+		return nil, errors.New("API token names must have 1 to 255 " +
+			"characters and no colon")
+	}
+
+	var secret [apiTokenSecretSize]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		return nil, err
+	}
+	t := &APIToken{
+		Name:    name,
+		Created: time.Unix(time.Now().Unix(), 0),
+		Secret:  hex.EncodeToString(secret[:]),
+	}
+	t.secretHash = sha256.Sum256([]byte(t.Secret))
+
+	err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket, err := ns.CreateBucketIfNotExists(apiTokensBucketKey)
+		if err != nil {
+			return err
+		}
+		if bucket.Get([]byte(name)) != nil {
+			return fmt.Errorf("API token %q already exists", name)
+		}
+		return bucket.Put([]byte(name), serializeAPIToken(t))
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.audit(AuditAPIToken, "API token %q created", name)
+	return t, nil
+}
+
+// APITokens returns the API tokens, ordered by name, without their secrets.
+func (w *Wallet) APITokens() ([]APIToken, error) {
+	var tokens []APIToken
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(apiTokensBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			t, err := deserializeAPIToken(string(k), v)
+			if err != nil {
+				return err
+			}
+			tokens = append(tokens, *t)
+			return nil
+		})
+	})
+	return tokens, err
+}
+
+// RevokeAPIToken revokes an API token.  Clients can no longer authenticate
+// with it, and the channels returned by AuthenticateAPIToken for the clients
+// it authenticated are closed, so they may be disconnected.
+func (w *Wallet) RevokeAPIToken(name string) error {
+	w.apiTokens.mu.Lock()
+	defer w.apiTokens.mu.Unlock()
+
+	err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		bucket := dbtx.ReadWriteBucket(wtxmetaNamespaceKey).
+			NestedReadWriteBucket(apiTokensBucketKey)
+		if bucket == nil || bucket.Get([]byte(name)) == nil {
+			return ErrUnknownAPIToken
+		}
+		return bucket.Delete([]byte(name))
+	})
+	if err != nil {
+		return err
+	}
+	if c, ok := w.apiTokens.revoked[name]; ok {
+		close(c)
+		delete(w.apiTokens.revoked, name)
+	}
+	w.audit(AuditAPIToken, "API token %q revoked", name)
+	return nil
+}
+
+// AuthenticateAPIToken checks the secret of an API token, and returns a
+// channel closed when the token is revoked.  ErrInvalidAPIToken is returned
+// when the token does not exist or the secret is wrong.
+//
+// The secret check is time-constant.
+func (w *Wallet) AuthenticateAPIToken(name, secret string) (<-chan struct{}, error) {
+	w.apiTokens.mu.Lock()
+	defer w.apiTokens.mu.Unlock()
+
+	var t *APIToken
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(apiTokensBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		v := bucket.Get([]byte(name))
+		if v == nil {
+			return nil
+		}
+		var err error
+		t, err = deserializeAPIToken(name, v)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrInvalidAPIToken
+	}
+	secretHash := sha256.Sum256([]byte(secret))
+	if subtle.ConstantTimeCompare(secretHash[:], t.secretHash[:]) != 1 {
+		return nil, ErrInvalidAPIToken
+	}
+
+	c, ok := w.apiTokens.revoked[name]
+	if !ok {
+		if w.apiTokens.revoked == nil {
+			w.apiTokens.revoked = make(map[string]chan struct{})
+		}
+		c = make(chan struct{})
+		w.apiTokens.revoked[name] = c
+	}
+	return c, nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestAPITokens checks that API tokens authenticate with their secrets until
+// they are revoked, and that revoking a token only affects its clients.
+func TestAPITokens(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apitoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+
+	if _, err := w.CreateAPIToken("bad:name"); err == nil {
+		t.Fatal("API token created with a colon in its name")
+	}
+	pos, err := w.CreateAPIToken("pos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dashboard, err := w.CreateAPIToken("dashboard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.CreateAPIToken("pos"); err == nil {
+		t.Fatal("API token created twice")
+	}
+	if pos.Secret == "" || pos.Secret == dashboard.Secret {
+		t.Fatalf("unexpected API token secrets %q and %q", pos.Secret,
+			dashboard.Secret)
+	}
+
+	tokens, err := w.APITokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0].Name != "dashboard" ||
+		tokens[1].Name != "pos" || tokens[0].Secret != "" {
+
+		t.Fatalf("unexpected API tokens %+v", tokens)
+	}
+
+	if _, err := w.AuthenticateAPIToken("pos", dashboard.Secret); err != ErrInvalidAPIToken {
+		t.Fatalf("API token authenticated with another secret: %v", err)
+	}
+	if _, err := w.AuthenticateAPIToken("pay", pos.Secret); err != ErrInvalidAPIToken {
+		t.Fatalf("unknown API token authenticated: %v", err)
+	}
+	posRevoked, err := w.AuthenticateAPIToken("pos", pos.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dashboardRevoked, err := w.AuthenticateAPIToken("dashboard",
+		dashboard.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.RevokeAPIToken("pos"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.RevokeAPIToken("pos"); err != ErrUnknownAPIToken {
+		t.Fatalf("revoked API token revoked again: %v", err)
+	}
+	select {
+	case <-posRevoked:
+	default:
+		t.Fatal("clients of the revoked API token were not notified")
+	}
+	select {
+	case <-dashboardRevoked:
+		t.Fatal("clients of another API token were notified")
+	default:
+	}
+	if _, err := w.AuthenticateAPIToken("pos", pos.Secret); err != ErrInvalidAPIToken {
+		t.Fatalf("revoked API token authenticated: %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// signerAttestationBucketKey is the key of the bucket in the transaction
+// metadata namespace holding the attestations of the external signers of
+// wallet transactions, keyed by transaction hash.
+var signerAttestationBucketKey = []byte("signerattestation")
+
+// signerAttestationFields is the number of variable length fields of
+// serialized signer attestations.
+const signerAttestationFields = 5
+
+// SignerAttestation identifies the device which signed a transaction, so that
+// auditors can verify which device produced each signature.
+type SignerAttestation struct {
+	// Signer is the kind of signer, such as hwi.
+	Signer string
+
+	// DeviceType and Model are the vendor and model of the device.
+	DeviceType string
+	Model      string
+
+	// Fingerprint is the hex encoded master key fingerprint of the device.
+	Fingerprint string
+
+	// Evidence is the attestation data signed by devices able to prove
+	// their identity, and is empty for the others.
+	Evidence []byte
+}
+
+// AttestingSigner is a Signer able to identify the device which signs the
+// transactions.  The attestation of the device is recorded with every
+// transaction it signs.
+type AttestingSigner interface {
+	Signer
+
+	// Attestation returns the identity of the signing device.
+	Attestation() (*SignerAttestation, error)
+}
+
+// Signer attestations are serialized as such:
+//
+//   [0:8]     Unix time of the signature in seconds (8 bytes)
+//   Signer, DeviceType, Model, Fingerprint and Evidence, each as
+//   [0:4]     Length (4 bytes)
+//   [4:4+n]   Value
+//
+// The length prefixes are big endian.
+
+func serializeSignerAttestation(a *SignerAttestation, t time.Time) []byte {
+	fields := [][]byte{[]byte(a.Signer), []byte(a.DeviceType),
+		[]byte(a.Model), []byte(a.Fingerprint), a.Evidence}
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, uint64(t.Unix()))
+	for _, field := range fields {
+		var n [4]byte
+		binary.BigEndian.PutUint32(n[:], uint32(len(field)))
+		v = append(v, n[:]...)
+		v = append(v, field...)
+	}
+	return v
+}
+
+func deserializeSignerAttestation(v []byte) (*SignerAttestation, time.Time, error) {
+	if len(v) < 8 {
+		return nil, time.Time{}, fmt.Errorf("malformed signer attestation")
+	}
+	t := time.Unix(int64(binary.BigEndian.Uint64(v[:8])), 0)
+	v = v[8:]
+	fields := make([][]byte, 0, signerAttestationFields)
+	for i := 0; i < signerAttestationFields; i++ {
+		if len(v) < 4 {
+			return nil, time.Time{}, fmt.Errorf("malformed signer " +
+				"attestation")
+		}
+		n := 4 + uint64(binary.BigEndian.Uint32(v))
+		if uint64(len(v)) < n {
+			return nil, time.Time{}, fmt.Errorf("malformed signer " +
+				"attestation")
+		}
+		fields = append(fields, v[4:n])
+		v = v[n:]
+	}
+	a := &SignerAttestation{
+		Signer:      string(fields[0]),
+		DeviceType:  string(fields[1]),
+		Model:       string(fields[2]),
+		Fingerprint: string(fields[3]),
+	}
+	if len(fields[4]) != 0 {
+		a.Evidence = append([]byte(nil), fields[4]...)
+	}
+	return a, t, nil
+}
+
+// String returns a short description of the device for log messages and the
+// audit log.
+func (a *SignerAttestation) String() string {
+	s := fmt.Sprintf("%s device %s", a.Signer, a.Fingerprint)
+	if a.DeviceType != "" || a.Model != "" {
+		s += fmt.Sprintf(" (%s %s)", a.DeviceType, a.Model)
+	}
+	if len(a.Evidence) != 0 {
+		s += fmt.Sprintf(" with %d bytes of attestation evidence",
+			len(a.Evidence))
+	}
+	return s
+}
+
+// recordSigner records the external signer of a signed transaction in the
+// audit log and, when the signer identifies its device, the attestation of the
+// device with the transaction.  Attestation failures do not fail the signature
+// but are recorded as such.
+func (w *Wallet) recordSigner(s Signer, txHash *chainhash.Hash) {
+	var attestation *SignerAttestation
+	details := fmt.Sprintf("transaction %v signed by an external signer "+
+		"without attestation", txHash)
+	if as, ok := s.(AttestingSigner); ok {
+		var err error
+		attestation, err = as.Attestation()
+		if err != nil {
+			log.Warnf("Cannot get the attestation of the signer of "+
+				"transaction %v: %v", txHash, err)
+			details = fmt.Sprintf("transaction %v signed by an "+
+				"external signer whose attestation failed: %v",
+				txHash, err)
+		} else {
+			details = fmt.Sprintf("transaction %v signed by %v",
+				txHash, attestation)
+		}
+	}
+
+	now := time.Now()
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmetaNamespaceKey)
+		if attestation != nil {
+			bucket, err := ns.CreateBucketIfNotExists(
+				signerAttestationBucketKey)
+			if err != nil {
+				return err
+			}
+			err = bucket.Put(txHash[:],
+				serializeSignerAttestation(attestation, now))
+			if err != nil {
+				return err
+			}
+		}
+		return appendAuditRecord(ns, now, AuditSign, details)
+	})
+	if err != nil {
+		log.Errorf("Cannot record the signer of transaction %v: %v",
+			txHash, err)
+	}
+}
+
+// TransactionSignerAttestation returns the attestation of the device which
+// signed a wallet transaction, and the time of the signature.  A nil
+// attestation is returned when the transaction was not signed by an attesting
+// signer.
+func (w *Wallet) TransactionSignerAttestation(txHash *chainhash.Hash) (
+	*SignerAttestation, time.Time, error) {
+
+	var attestation *SignerAttestation
+	var t time.Time
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		bucket := tx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(signerAttestationBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		v := bucket.Get(txHash[:])
+		if v == nil {
+			return nil
+		}
+		var err error
+		attestation, t, err = deserializeSignerAttestation(v)
+		return err
+	})
+	return attestation, t, err
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// testAttestingSigner is a testSigner identifying its device.
+type testAttestingSigner struct {
+	testSigner
+	attestation *SignerAttestation
+}
+
+func (s *testAttestingSigner) Attestation() (*SignerAttestation, error) {
+	return s.attestation, nil
+}
+
+// TestRecordSigner checks that the external signers of transactions are
+// recorded in the audit log, and that the attestations of their devices are
+// kept with the transactions.
+func TestRecordSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "attestation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+
+	attestation := &SignerAttestation{
+		Signer:      "hwi",
+		DeviceType:  "trezor",
+		Model:       "trezor_t",
+		Fingerprint: "5a3469b6",
+		Evidence:    []byte{1, 2, 3},
+	}
+	w.recordSigner(&testAttestingSigner{attestation: attestation},
+		&chainhash.Hash{1})
+	w.recordSigner(&testSigner{}, &chainhash.Hash{2})
+
+	a, signed, err := w.TransactionSignerAttestation(&chainhash.Hash{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == nil || a.Signer != attestation.Signer ||
+		a.DeviceType != attestation.DeviceType ||
+		a.Model != attestation.Model ||
+		a.Fingerprint != attestation.Fingerprint ||
+		!bytes.Equal(a.Evidence, attestation.Evidence) || signed.IsZero() {
+
+		t.Fatalf("unexpected attestation %+v signed at %v", a, signed)
+	}
+	a, _, err = w.TransactionSignerAttestation(&chainhash.Hash{2})
+	if err != nil || a != nil {
+		t.Fatalf("transaction signed without attestation has attestation "+
+			"%+v: %v", a, err)
+	}
+
+	records, err := w.AuditLog(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Operation != AuditSign ||
+		!strings.Contains(records[0].Details, "5a3469b6") ||
+		!strings.Contains(records[1].Details, "without attestation") {
+
+		t.Fatalf("unexpected audit records %+v", records)
+	}
+
+	if _, _, err := deserializeSignerAttestation([]byte{0}); err == nil {
+		t.Fatal("malformed attestation was deserialized")
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// auditLogBucketKey is the key of the bucket in the transaction metadata
+// namespace holding the audit log, keyed by the big endian sequence number of
+// each record, starting at 1.
+var auditLogBucketKey = []byte("auditlog")
+
+// Operations recorded in the audit log.
+const (
+	AuditUnlock           = "unlock"
+	AuditWrongPassphrase  = "wrongpassphrase"
+	AuditPassphraseChange = "passphrasechange"
+	AuditKeyExport        = "keyexport"
+	AuditImport           = "import"
+	AuditSend             = "send"
+	AuditNewAddress       = "newaddress"
+	AuditTOTP             = "totp"
+	AuditAddressPool      = "addresspool"
+	AuditPrune            = "prune"
+	AuditSign             = "sign"
+	AuditBackup           = "backup"
+	AuditFreeze           = "freeze"
+	AuditMaintenance      = "maintenance"
+	AuditAccelerate       = "accelerate"
+	AuditAPIToken         = "apitoken"
+	AuditScreening        = "screening"
+)
+
+// AuditRecord is a record of a sensitive operation in the audit log.  Every
+// record commits to the previous record with its hash, so records cannot be
+// removed or modified without breaking the chain of hashes of the later
+// records.
+type AuditRecord struct {
+	Seq       uint64
+	Time      time.Time
+	Operation string
+	Details   string
+	PrevHash  [sha256.Size]byte
+	Hash      [sha256.Size]byte
+}
+
+// Audit log records are serialized as such:
+//
+//   [0:32]    Hash of the previous record, or zero for the first record
+//   [32:40]   Unix time in nanoseconds (8 bytes)
+//   [40]      Operation length (1 byte)
+//   [41:41+n] Operation
+//   [41+n:]   Details
+//
+// The hash of a record is the SHA256 hash of its key and serialized value.
+
+func serializeAuditRecord(r *AuditRecord) []byte {
+	v := make([]byte, 41, 41+len(r.Operation)+len(r.Details))
+	copy(v, r.PrevHash[:])
+	binary.BigEndian.PutUint64(v[32:40], uint64(r.Time.UnixNano()))
+	v[40] = byte(len(r.Operation))
+	v = append(v, r.Operation...)
+	return append(v, r.Details...)
+}
+
+func deserializeAuditRecord(k, v []byte) (*AuditRecord, error) {
+	if len(k) != 8 || len(v) < 41 || len(v) < 41+int(v[40]) {
+		return nil, fmt.Errorf("malformed audit log record")
+	}
+	r := &AuditRecord{
+		Seq:       binary.BigEndian.Uint64(k),
+		Time:      time.Unix(0, int64(binary.BigEndian.Uint64(v[32:40]))),
+		Operation: string(v[41 : 41+int(v[40])]),
+		Details:   string(v[41+int(v[40]):]),
+	}
+	copy(r.PrevHash[:], v[:32])
+	r.Hash = auditRecordHash(k, v)
+	return r, nil
+}
+
+func auditRecordHash(k, v []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(k)
+	h.Write(v)
+	var hash [sha256.Size]byte
+	copy(hash[:], h.Sum(nil))
+	return hash
+}
+
+// appendAuditRecord appends a record of an operation to the audit log.
+func appendAuditRecord(ns walletdb.ReadWriteBucket, t time.Time, op,
+	details string) error {
+
+	bucket, err := ns.CreateBucketIfNotExists(auditLogBucketKey)
+	if err != nil {
+		return err
+	}
+
+	prev, err := lastAuditRecord(bucket)
+	if err != nil {
+		return err
+	}
+	r := &AuditRecord{Seq: 1, Time: t, Operation: op, Details: details}
+	if prev != nil {
+		r.Seq = prev.Seq + 1
+		r.PrevHash = prev.Hash
+	}
+
+	var k [8]byte
+	binary.BigEndian.PutUint64(k[:], r.Seq)
+	return bucket.Put(k[:], serializeAuditRecord(r))
+}
+
+// lastAuditRecord returns the last record of the audit log bucket, or nil when
+// the audit log is empty.
+func lastAuditRecord(bucket walletdb.ReadBucket) (*AuditRecord, error) {
+	if bucket == nil {
+		return nil, nil
+	}
+	k, v := bucket.ReadCursor().Last()
+	if k == nil {
+		return nil, nil
+	}
+	return deserializeAuditRecord(k, v)
+}
+
+// audit records a sensitive operation in the audit log.  The operation was
+// already performed, so a failure to record it is only logged.
+func (w *Wallet) audit(op, format string, args ...interface{}) {
+	details := fmt.Sprintf(format, args...)
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmetaNamespaceKey)
+		return appendAuditRecord(ns, time.Now(), op, details)
+	})
+	if err != nil {
+		log.Errorf("Cannot record %s operation (%s) in the audit "+
+			"log: %v", op, details, err)
+	}
+}
+
+// AuditLog returns at most count records of the audit log, starting with the
+// record with sequence number from.  The chain of hashes of every record up to
+// the last returned one is verified, starting from the last record pruned by
+// the retention policy, and an error is returned when a record was removed or
+// modified.
+func (w *Wallet) AuditLog(from uint64, count int) ([]AuditRecord, error) {
+	var records []AuditRecord
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(wtxmetaNamespaceKey)
+		bucket := ns.NestedReadBucket(auditLogBucketKey)
+		if bucket == nil {
+			return nil
+		}
+
+		prevSeq, prevHash, err := auditPrunedMarker(ns)
+		if err != nil {
+			return err
+		}
+		c := bucket.ReadCursor()
+		for k, v := c.First(); k != nil && len(records) < count; k, v = c.Next() {
+			r, err := deserializeAuditRecord(k, v)
+			if err != nil {
+				return err
+			}
+			if r.Seq != prevSeq+1 || r.PrevHash != prevHash {
+				return fmt.Errorf("audit log is broken at record %d",
+					prevSeq+1)
+			}
+			if r.Seq >= from {
+				records = append(records, *r)
+			}
+			prevSeq, prevHash = r.Seq, r.Hash
+		}
+		return nil
+	})
+	return records, err
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestAuditLog checks that audit log records are chained by their hashes, that
+// they can be read from any sequence number, and that modified or removed
+// records are detected.
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+
+	records, err := w.AuditLog(1, 10)
+	if err != nil || len(records) != 0 {
+		t.Fatalf("empty audit log returned %v, %v", records, err)
+	}
+
+	w.audit(AuditUnlock, "wallet unlocked")
+	w.audit(AuditKeyExport, "private key of %s exported", "addr")
+	w.audit(AuditSend, "transaction %d broadcast", 1)
+
+	records, err = w.AuditLog(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("audit log has %d records, want 3", len(records))
+	}
+	if records[1].Operation != AuditKeyExport ||
+		records[1].Details != "private key of addr exported" {
+		t.Fatalf("second record is %+v", records[1])
+	}
+	for i, r := range records {
+		if r.Seq != uint64(i+1) {
+			t.Fatalf("record %d has sequence number %d", i, r.Seq)
+		}
+		if i != 0 && r.PrevHash != records[i-1].Hash {
+			t.Fatalf("record %d is not chained to the previous one", r.Seq)
+		}
+	}
+
+	records, err = w.AuditLog(2, 1)
+	if err != nil || len(records) != 1 || records[0].Seq != 2 {
+		t.Fatalf("AuditLog(2, 1) returned %v, %v", records, err)
+	}
+
+	// Modify the details of the second record.
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		bucket := dbtx.ReadWriteBucket(wtxmetaNamespaceKey).
+			NestedReadWriteBucket(auditLogBucketKey)
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], 2)
+		v := append([]byte(nil), bucket.Get(k[:])...)
+		v[len(v)-1] ^= 1
+		return bucket.Put(k[:], v)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.AuditLog(1, 1); err != nil {
+		t.Fatalf("records before the modified record are rejected: %v", err)
+	}
+	if _, err := w.AuditLog(3, 1); err == nil {
+		t.Fatal("modified audit log was not rejected")
+	}
+}
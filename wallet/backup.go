@@ -0,0 +1,249 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcwallet/snacl"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// backupMagic starts the wallet backups encrypted with a backup passphrase.
+var backupMagic = []byte("btcwbak1")
+
+// errMalformedBackup describes an encrypted backup that cannot be
+// deserialized.
+var errMalformedBackup = errors.New("malformed encrypted wallet backup")
+
+// BackupEncrypter encrypts the wallet backups made with BackupWallet.  The
+// backups are encrypted with a key separate from the wallet passphrases, so
+// that the operators keeping the backups cannot necessarily spend from the
+// wallet, nor the operators spending restore its backups.
+type BackupEncrypter interface {
+	// Name returns a short description of the encryption used in log
+	// messages and the audit log.
+	Name() string
+
+	// Encrypt returns the encrypted backup of a wallet database.
+	Encrypt(db []byte) ([]byte, error)
+}
+
+// PassphraseBackupEncrypter is a BackupEncrypter encrypting backups with a key
+// derived from a backup passphrase.  The backups are decrypted with
+// DecryptBackup.
+type PassphraseBackupEncrypter struct {
+	passphrase []byte
+	kdf        *waddrmgr.ScryptOptions
+}
+
+// NewPassphraseBackupEncrypter returns a backup encrypter deriving the keys of
+// the backups from passphrase with the key derivation function and costs of
+// kdf.  The default scrypt costs are used when kdf is nil.
+func NewPassphraseBackupEncrypter(passphrase []byte,
+	kdf *waddrmgr.ScryptOptions) *PassphraseBackupEncrypter {
+
+	if kdf == nil {
+		kdf = &waddrmgr.DefaultScryptOptions
+	}
+	return &PassphraseBackupEncrypter{
+		passphrase: passphrase,
+		kdf:        kdf,
+	}
+}
+
+// Name describes the encryption of the backups.
+//
+// This is part of the BackupEncrypter interface implementation.
+func (e *PassphraseBackupEncrypter) Name() string {
+	return "backup passphrase"
+}
+
+// Encrypt encrypts a wallet database with a new key derived from the backup
+// passphrase.
+//
+// This is part of the BackupEncrypter interface implementation.
+func (e *PassphraseBackupEncrypter) Encrypt(db []byte) ([]byte, error) {
+	// The encrypted backup format is:
+	//   <magic><paramslen><params><encdb>
+	//
+	// 8 bytes magic + 4 bytes params len + marshalled key params +
+	// encrypted database
+	key, err := waddrmgr.NewSecretKey(&e.passphrase, e.kdf)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+
+	encrypted, err := key.Encrypt(db)
+	if err != nil {
+		return nil, err
+	}
+	params := key.Marshal()
+	n := len(backupMagic) + 4 + len(params)
+	backup := make([]byte, n, n+len(encrypted))
+	copy(backup, backupMagic)
+	binary.LittleEndian.PutUint32(backup[len(backupMagic):], uint32(len(params)))
+	copy(backup[len(backupMagic)+4:], params)
+	return append(backup, encrypted...), nil
+}
+
+// DecryptBackup returns the wallet database of a backup encrypted with a
+// backup passphrase.
+func DecryptBackup(backup, passphrase []byte) ([]byte, error) {
+	if len(backup) < len(backupMagic)+4 ||
+		!bytes.Equal(backup[:len(backupMagic)], backupMagic) {
+
+		return nil, errMalformedBackup
+	}
+	backup = backup[len(backupMagic):]
+	paramsLen := binary.LittleEndian.Uint32(backup[0:4])
+	if uint32(len(backup)-4) < paramsLen {
+		return nil, errMalformedBackup
+	}
+
+	var key snacl.SecretKey
+	if err := key.Unmarshal(backup[4 : 4+paramsLen]); err != nil {
+		return nil, err
+	}
+	err := key.DeriveKey(&passphrase)
+	if err == snacl.ErrInvalidPassword {
+		return nil, waddrmgr.ManagerError{
+			ErrorCode:   waddrmgr.ErrWrongPassphrase,
+			Description: "invalid passphrase for wallet backup",
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+
+	return key.Decrypt(backup[4+paramsLen:])
+}
+
+// GPGBackupEncrypter is a BackupEncrypter encrypting backups to the public
+// keys of GPG recipients, which must be in the keyring of the gpg program.
+// Any recipient can decrypt the backups with gpg --decrypt.
+type GPGBackupEncrypter struct {
+	command    string
+	recipients []string
+	timeout    time.Duration
+}
+
+// NewGPGBackupEncrypter returns a backup encrypter running the gpg program at
+// command to encrypt the backups to the recipients, named by key ID,
+// fingerprint or email.
+func NewGPGBackupEncrypter(command string, recipients ...string) *GPGBackupEncrypter {
+	return &GPGBackupEncrypter{
+		command:    command,
+		recipients: recipients,
+		timeout:    DefaultExecHookTimeout,
+	}
+}
+
+// Name lists the recipients of the backups.
+//
+// This is part of the BackupEncrypter interface implementation.
+func (e *GPGBackupEncrypter) Name() string {
+	return fmt.Sprintf("gpg recipients %v", e.recipients)
+}
+
+// Encrypt runs gpg to encrypt a wallet database to the recipients.
+//
+// This is part of the BackupEncrypter interface implementation.
+func (e *GPGBackupEncrypter) Encrypt(db []byte) ([]byte, error) {
+	if len(e.recipients) == 0 {
+		return nil, errors.New("no gpg recipients")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	args := []string{"--batch", "--yes", "--trust-model", "always",
+		"--encrypt"}
+	for _, r := range e.recipients {
+		args = append(args, "--recipient", r)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.command, args...)
+	cmd.Stdin = bytes.NewReader(db)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) != 0 {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// backups holds the encrypter of the wallet backups and the backup token
+// wrapping them.
+type backups struct {
+	mu        sync.Mutex
+	encrypter BackupEncrypter
+	token     BackupToken
+}
+
+// SetBackupEncrypter sets the encrypter of the backups made with
+// BackupWallet.  Backups are unencrypted copies of the wallet database, whose
+// private keys remain encrypted with the private passphrase, when enc is nil.
+func (w *Wallet) SetBackupEncrypter(enc BackupEncrypter) {
+	w.backups.mu.Lock()
+	w.backups.encrypter = enc
+	w.backups.mu.Unlock()
+}
+
+// BackupWallet writes a backup of the wallet database to the file at dest,
+// encrypted with the backup encrypter of the wallet when one is set.  The
+// file is only replaced once the backup is complete.
+func (w *Wallet) BackupWallet(dest string) error {
+	w.backups.mu.Lock()
+	enc := w.backups.encrypter
+	w.backups.mu.Unlock()
+
+	var buf bytes.Buffer
+	if err := w.db.Copy(&buf); err != nil {
+		return err
+	}
+	backup := buf.Bytes()
+	encryption := "unencrypted"
+	if enc != nil {
+		var err error
+		backup, err = enc.Encrypt(backup)
+		if err != nil {
+			return fmt.Errorf("cannot encrypt the backup with %s: %v",
+				enc.Name(), err)
+		}
+		encryption = "encrypted with " + enc.Name()
+	}
+
+	tmp := dest + ".tmp"
+	if err := ioutil.WriteFile(tmp, backup, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	abs, err := filepath.Abs(dest)
+	if err != nil {
+		abs = dest
+	}
+	w.audit(AuditBackup, "wallet backed up to %s, %s", abs, encryption)
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestBackupWallet checks that backups encrypted with a backup passphrase
+// decrypt to the wallet database only with that passphrase, and that backups
+// are recorded in the audit log.
+func TestBackupWallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+
+	kdf := &waddrmgr.ScryptOptions{N: 16, R: 8, P: 1}
+	w.SetBackupEncrypter(NewPassphraseBackupEncrypter([]byte("backup"), kdf))
+	dest := filepath.Join(dir, "wallet.bak")
+	if err := w.BackupWallet(dest); err != nil {
+		t.Fatalf("BackupWallet: %v", err)
+	}
+	backup, err := ioutil.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecryptBackup(backup, []byte("private")); !waddrmgr.IsError(
+		err, waddrmgr.ErrWrongPassphrase) {
+
+		t.Fatalf("backup decrypted with the wrong passphrase: %v", err)
+	}
+	if _, err := DecryptBackup(backup[:10], []byte("backup")); err == nil {
+		t.Fatal("truncated backup was decrypted")
+	}
+	decrypted, err := DecryptBackup(backup, []byte("backup"))
+	if err != nil {
+		t.Fatalf("DecryptBackup: %v", err)
+	}
+
+	restored := filepath.Join(dir, "restored.db")
+	if err := ioutil.WriteFile(restored, decrypted, 0600); err != nil {
+		t.Fatal(err)
+	}
+	rdb, err := walletdb.Open("bdb", restored)
+	if err != nil {
+		t.Fatalf("restored backup does not open: %v", err)
+	}
+	err = walletdb.View(rdb, func(dbtx walletdb.ReadTx) error {
+		if dbtx.ReadBucket(wtxmetaNamespaceKey) == nil {
+			t.Error("restored backup has no metadata namespace")
+		}
+		return nil
+	})
+	rdb.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unencrypted backups are plain copies of the database.
+	w.SetBackupEncrypter(nil)
+	if err := w.BackupWallet(dest); err != nil {
+		t.Fatalf("BackupWallet: %v", err)
+	}
+	plain, err := ioutil.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.HasPrefix(plain, backupMagic) {
+		t.Fatal("backup without encrypter was encrypted")
+	}
+
+	records, err := w.AuditLog(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Operation != AuditBackup ||
+		records[1].Operation != AuditBackup {
+
+		t.Fatalf("unexpected audit records %+v", records)
+	}
+}
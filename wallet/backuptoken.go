@@ -0,0 +1,455 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/btcsuite/btcwallet/internal/zero"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// backupTokensBucketKey is the key of the bucket in the transaction metadata
+// namespace recording the hardware tokens enrolled to wrap the wallet
+// backups, keyed by token name.
+var backupTokensBucketKey = []byte("backuptokens")
+
+// Backup token enrollments are serialized as such:
+//
+//   [0:8]   Time enrolled, as unix seconds (8 bytes)
+//   [8:40]  Challenge (32 bytes)
+//   [40:72] Wrapping public key (32 bytes)
+
+// tokenBackupMagic starts the wallet backups wrapped by backup tokens.
+var tokenBackupMagic = []byte("btcwtok1")
+
+// backupTokenTimeout is the time a backup token is given to respond to a
+// challenge, which may require touching the token.
+const backupTokenTimeout = time.Minute
+
+var (
+	// ErrNoBackupToken describes an error where a backup token operation
+	// is requested but no backup token is configured.
+	ErrNoBackupToken = errors.New("no backup token is configured")
+
+	// ErrUnknownBackupToken describes an error where a backup token is not
+	// enrolled.
+	ErrUnknownBackupToken = errors.New("unknown backup token")
+)
+
+// BackupToken is a hardware token computing HMAC challenge-responses with a
+// secret that never leaves it, such as the challenge-response slot of a
+// YubiKey or the hmac-secret extension of a FIDO2 authenticator.  Backups
+// wrapped by enrolled tokens can only be decrypted with one of the tokens.
+type BackupToken interface {
+	// Name returns a short description of the token used in log messages
+	// and the audit log.
+	Name() string
+
+	// Respond returns the response of the token to a challenge.  The
+	// same challenge must always get the same response.
+	Respond(challenge []byte) ([]byte, error)
+}
+
+// ExecBackupToken is a BackupToken running a program, which adapts the
+// vendor tools of the token such as ykman or fido2-assert.  The program is
+// invoked with the hexadecimal challenge as its only argument, and prints
+// the hexadecimal response.
+type ExecBackupToken struct {
+	command string
+	timeout time.Duration
+}
+
+// NewExecBackupToken returns a backup token running the program at command.
+func NewExecBackupToken(command string) *ExecBackupToken {
+	return &ExecBackupToken{
+		command: command,
+		timeout: backupTokenTimeout,
+	}
+}
+
+// Name describes the program.
+//
+// This is part of the BackupToken interface implementation.
+func (t *ExecBackupToken) Name() string {
+	return t.command
+}
+
+// Respond runs the program with a challenge and returns its response.
+//
+// This is part of the BackupToken interface implementation.
+func (t *ExecBackupToken) Respond(challenge []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.command, hex.EncodeToString(challenge))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) != 0 {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	response, err := hex.DecodeString(string(bytes.TrimSpace(stdout.Bytes())))
+	if err != nil {
+		return nil, fmt.Errorf("malformed token response: %v", err)
+	}
+	if len(response) == 0 {
+		return nil, errors.New("empty token response")
+	}
+	return response, nil
+}
+
+// BackupTokenEnrollment describes a backup token enrolled to wrap the wallet
+// backups.
+type BackupTokenEnrollment struct {
+	Name     string
+	Enrolled time.Time
+
+	challenge [32]byte
+	publicKey [32]byte
+}
+
+// SetBackupToken sets the backup token enrolled with EnrollBackupToken and
+// decrypting backups with RecoverBackup.
+func (w *Wallet) SetBackupToken(token BackupToken) {
+	w.backups.mu.Lock()
+	w.backups.token = token
+	w.backups.mu.Unlock()
+}
+
+// backupToken returns the backup token of the wallet.
+func (w *Wallet) backupToken() (BackupToken, error) {
+	w.backups.mu.Lock()
+	token := w.backups.token
+	w.backups.mu.Unlock()
+	if token == nil {
+		return nil, ErrNoBackupToken
+	}
+	return token, nil
+}
+
+// EnrollBackupToken enrolls the backup token of the wallet under a name, so
+// that the backups wrapped by TokenBackupEncrypter can be decrypted with it.
+// The token answers a new random challenge, from which a wrapping key pair
+// is derived, and only the public key is recorded, so that backups are
+// wrapped without the token while decrypting them requires it.  Several
+// tokens, such as a spare one kept offsite, may be enrolled one after the
+// other under different names.
+func (w *Wallet) EnrollBackupToken(name string) (*BackupTokenEnrollment, error) {
+	if name == "" || len(name) > 255 {
+		return nil, errors.New("backup token names must have 1 to 255 " +
+			"characters")
+	}
+	token, err := w.backupToken()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &BackupTokenEnrollment{
+		Name:     name,
+		Enrolled: time.Unix(time.Now().Unix(), 0),
+	}
+	if _, err := rand.Read(e.challenge[:]); err != nil {
+		return nil, err
+	}
+	response, err := token.Respond(e.challenge[:])
+	if err != nil {
+		return nil, fmt.Errorf("backup token %s did not respond: %v",
+			token.Name(), err)
+	}
+	priv := backupTokenKey(e.challenge[:], response)
+	curve25519.ScalarBaseMult(&e.publicKey, priv)
+	zero.Bytea32(priv)
+
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket, err := ns.CreateBucketIfNotExists(backupTokensBucketKey)
+		if err != nil {
+			return err
+		}
+		if bucket.Get([]byte(name)) != nil {
+			return fmt.Errorf("backup token %q is already enrolled",
+				name)
+		}
+		return bucket.Put([]byte(name), serializeBackupToken(e))
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.audit(AuditBackup, "backup token %q enrolled", name)
+	return e, nil
+}
+
+// BackupTokens returns the enrolled backup tokens, ordered by name.
+func (w *Wallet) BackupTokens() ([]BackupTokenEnrollment, error) {
+	var tokens []BackupTokenEnrollment
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(backupTokensBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			e, err := deserializeBackupToken(string(k), v)
+			if err != nil {
+				return err
+			}
+			tokens = append(tokens, *e)
+			return nil
+		})
+	})
+	return tokens, err
+}
+
+// RemoveBackupToken removes an enrolled backup token, which no longer wraps
+// new backups.  Backups already wrapped by the token are unaffected.
+func (w *Wallet) RemoveBackupToken(name string) error {
+	err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		bucket := dbtx.ReadWriteBucket(wtxmetaNamespaceKey).
+			NestedReadWriteBucket(backupTokensBucketKey)
+		if bucket == nil || bucket.Get([]byte(name)) == nil {
+			return ErrUnknownBackupToken
+		}
+		return bucket.Delete([]byte(name))
+	})
+	if err != nil {
+		return err
+	}
+	w.audit(AuditBackup, "backup token %q removed", name)
+	return nil
+}
+
+// RecoverBackup decrypts a backup wrapped by the enrolled backup tokens with
+// the backup token of the wallet, and writes the wallet database to dest,
+// which must not exist.  The database may then be restored in place of the
+// database of a wallet.
+func (w *Wallet) RecoverBackup(src, dest string) error {
+	token, err := w.backupToken()
+	if err != nil {
+		return err
+	}
+	backup, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	db, err := DecryptTokenBackup(backup, token)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(db)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(dest)
+		return err
+	}
+
+	abs, err := filepath.Abs(dest)
+	if err != nil {
+		abs = dest
+	}
+	w.audit(AuditBackup, "backup %s recovered to %s with backup token %s",
+		src, abs, token.Name())
+	return nil
+}
+
+// TokenBackupEncrypter is a BackupEncrypter wrapping backups to the backup
+// tokens enrolled when the backups are made.  Backups are decrypted with any
+// of the tokens, by RecoverBackup or DecryptTokenBackup.
+type TokenBackupEncrypter struct {
+	w *Wallet
+}
+
+// TokenBackupEncrypter returns a backup encrypter wrapping the backups to
+// the enrolled backup tokens of the wallet.
+func (w *Wallet) TokenBackupEncrypter() *TokenBackupEncrypter {
+	return &TokenBackupEncrypter{w: w}
+}
+
+// Name describes the encryption of the backups.
+//
+// This is part of the BackupEncrypter interface implementation.
+func (e *TokenBackupEncrypter) Name() string {
+	return "backup tokens"
+}
+
+// Encrypt encrypts a wallet database with a new key wrapped to each enrolled
+// backup token.
+//
+// This is part of the BackupEncrypter interface implementation.
+func (e *TokenBackupEncrypter) Encrypt(db []byte) ([]byte, error) {
+	tokens, err := e.w.BackupTokens()
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("no backup token is enrolled")
+	}
+	return encryptTokenBackup(db, tokens)
+}
+
+// encryptTokenBackup encrypts a wallet database with a new key wrapped to
+// each backup token.
+func encryptTokenBackup(db []byte, tokens []BackupTokenEnrollment) ([]byte, error) {
+	// The wrapped backup format is:
+	//   <magic><count>
+	//   count * <namelen><name><challenge><wrapped key>
+	//   <nonce><encdb>
+	if len(tokens) > 255 {
+		return nil, errors.New("too many backup tokens")
+	}
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return nil, err
+	}
+	defer zero.Bytea32(&key)
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.Write(tokenBackupMagic)
+	b.WriteByte(byte(len(tokens)))
+	for i := range tokens {
+		t := &tokens[i]
+		wrapped, err := box.SealAnonymous(nil, key[:], &t.publicKey,
+			rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteByte(byte(len(t.Name)))
+		b.WriteString(t.Name)
+		b.Write(t.challenge[:])
+		b.Write(wrapped)
+	}
+	b.Write(nonce[:])
+	return secretbox.Seal(b.Bytes(), db, &nonce, &key), nil
+}
+
+// DecryptTokenBackup returns the wallet database of a backup wrapped by
+// backup tokens, decrypted with a token enrolled when the backup was made.
+// The token answers the challenge of each enrolled token until one unwraps
+// the backup, so restoring from a spare token may require several touches.
+func DecryptTokenBackup(backup []byte, token BackupToken) ([]byte, error) {
+	if len(backup) < len(tokenBackupMagic)+1 ||
+		!bytes.Equal(backup[:len(tokenBackupMagic)], tokenBackupMagic) {
+
+		return nil, errMalformedBackup
+	}
+	backup = backup[len(tokenBackupMagic):]
+	count := int(backup[0])
+	backup = backup[1:]
+
+	const wrappedLen = 32 + box.AnonymousOverhead
+	var key *[32]byte
+	for i := 0; i < count; i++ {
+		if len(backup) < 1 || len(backup) < 1+int(backup[0])+32+wrappedLen {
+			return nil, errMalformedBackup
+		}
+		nameLen := int(backup[0])
+		challenge := backup[1+nameLen : 1+nameLen+32]
+		wrapped := backup[1+nameLen+32 : 1+nameLen+32+wrappedLen]
+		backup = backup[1+nameLen+32+wrappedLen:]
+		if key != nil {
+			continue
+		}
+
+		response, err := token.Respond(challenge)
+		if err != nil {
+			return nil, err
+		}
+		priv := backupTokenKey(challenge, response)
+		var pub [32]byte
+		curve25519.ScalarBaseMult(&pub, priv)
+		unwrapped, ok := box.OpenAnonymous(nil, wrapped, &pub, priv)
+		zero.Bytea32(priv)
+		if ok && len(unwrapped) == 32 {
+			key = new([32]byte)
+			copy(key[:], unwrapped)
+			zero.Bytes(unwrapped)
+		}
+	}
+	if key == nil {
+		return nil, waddrmgr.ManagerError{
+			ErrorCode:   waddrmgr.ErrWrongPassphrase,
+			Description: "backup token is not enrolled for the wallet backup",
+		}
+	}
+	defer zero.Bytea32(key)
+
+	if len(backup) < 24 {
+		return nil, errMalformedBackup
+	}
+	var nonce [24]byte
+	copy(nonce[:], backup)
+	db, ok := secretbox.Open(nil, backup[24:], &nonce, key)
+	if !ok {
+		return nil, errMalformedBackup
+	}
+	return db, nil
+}
+
+// backupTokenKey returns the private wrapping key derived from the response
+// of a backup token to a challenge.
+func backupTokenKey(challenge, response []byte) *[32]byte {
+	h := sha256.New()
+	h.Write([]byte("btcwallet backup token"))
+	h.Write(challenge)
+	h.Write(response)
+	var key [32]byte
+	copy(key[:], h.Sum(nil))
+	return &key
+}
+
+// serializeBackupToken returns the value recording a backup token
+// enrollment.
+func serializeBackupToken(e *BackupTokenEnrollment) []byte {
+	v := make([]byte, 72)
+	binary.BigEndian.PutUint64(v, uint64(e.Enrolled.Unix()))
+	copy(v[8:40], e.challenge[:])
+	copy(v[40:72], e.publicKey[:])
+	return v
+}
+
+// deserializeBackupToken returns the backup token enrollment recorded with a
+// value.
+func deserializeBackupToken(name string, v []byte) (*BackupTokenEnrollment, error) {
+	if len(v) != 72 {
+		return nil, fmt.Errorf("backup token %q is malformed", name)
+	}
+	e := &BackupTokenEnrollment{
+		Name:     name,
+		Enrolled: time.Unix(int64(binary.BigEndian.Uint64(v)), 0),
+	}
+	copy(e.challenge[:], v[8:40])
+	copy(e.publicKey[:], v[40:72])
+	return e, nil
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// hmacToken is a BackupToken computing HMAC-SHA1 responses with a secret, as
+// the challenge-response slot of a YubiKey.
+type hmacToken struct {
+	secret  []byte
+	touches int
+}
+
+func (t *hmacToken) Name() string { return "hmac" }
+
+func (t *hmacToken) Respond(challenge []byte) ([]byte, error) {
+	t.touches++
+	mac := hmac.New(sha1.New, t.secret)
+	mac.Write(challenge)
+	return mac.Sum(nil), nil
+}
+
+// TestBackupTokens checks that backups wrapped by enrolled backup tokens
+// decrypt to the wallet database only with one of the tokens.
+func TestBackupTokens(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backuptoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+
+	if _, err := w.EnrollBackupToken("primary"); err != ErrNoBackupToken {
+		t.Fatalf("enrolled without a backup token: %v", err)
+	}
+	primary := &hmacToken{secret: []byte("primary")}
+	spare := &hmacToken{secret: []byte("spare")}
+	other := &hmacToken{secret: []byte("other")}
+	w.SetBackupToken(primary)
+	if _, err := w.EnrollBackupToken("primary"); err != nil {
+		t.Fatalf("EnrollBackupToken: %v", err)
+	}
+	if _, err := w.EnrollBackupToken("primary"); err == nil {
+		t.Fatal("token enrolled twice under the same name")
+	}
+	w.SetBackupToken(spare)
+	if _, err := w.EnrollBackupToken("spare"); err != nil {
+		t.Fatalf("EnrollBackupToken: %v", err)
+	}
+	tokens, err := w.BackupTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0].Name != "primary" ||
+		tokens[1].Name != "spare" {
+
+		t.Fatalf("unexpected enrolled tokens %v", tokens)
+	}
+
+	// Backups are wrapped without touching the tokens.
+	w.SetBackupEncrypter(w.TokenBackupEncrypter())
+	touches := primary.touches + spare.touches
+	dest := filepath.Join(dir, "wallet.bak")
+	if err := w.BackupWallet(dest); err != nil {
+		t.Fatalf("BackupWallet: %v", err)
+	}
+	if primary.touches+spare.touches != touches {
+		t.Fatal("backup required the tokens")
+	}
+	backup, err := ioutil.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecryptTokenBackup(backup, other); !waddrmgr.IsError(
+		err, waddrmgr.ErrWrongPassphrase) {
+
+		t.Fatalf("backup decrypted with an unenrolled token: %v", err)
+	}
+	if _, err := DecryptTokenBackup(backup[:40], primary); err == nil {
+		t.Fatal("truncated backup was decrypted")
+	}
+	for _, token := range []*hmacToken{primary, spare} {
+		if _, err := DecryptTokenBackup(backup, token); err != nil {
+			t.Fatalf("DecryptTokenBackup: %v", err)
+		}
+	}
+
+	restored := filepath.Join(dir, "restored.db")
+	if err := w.RecoverBackup(dest, restored); err != nil {
+		t.Fatalf("RecoverBackup: %v", err)
+	}
+	if err := w.RecoverBackup(dest, restored); err == nil {
+		t.Fatal("recovered backup overwrote an existing file")
+	}
+	rdb, err := walletdb.Open("bdb", restored)
+	if err != nil {
+		t.Fatalf("recovered backup does not open: %v", err)
+	}
+	rdb.Close()
+
+	// Removed tokens no longer wrap new backups.
+	if err := w.RemoveBackupToken("spare"); err != nil {
+		t.Fatalf("RemoveBackupToken: %v", err)
+	}
+	if err := w.RemoveBackupToken("spare"); err != ErrUnknownBackupToken {
+		t.Fatalf("removed unknown token: %v", err)
+	}
+	if err := w.BackupWallet(dest); err != nil {
+		t.Fatalf("BackupWallet: %v", err)
+	}
+	backup, err = ioutil.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptTokenBackup(backup, spare); err == nil {
+		t.Fatal("backup decrypted with a removed token")
+	}
+	if _, err := DecryptTokenBackup(backup, primary); err != nil {
+		t.Fatalf("DecryptTokenBackup: %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"math"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/internal/zero"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// errBIP322Address describes the error returned for addresses without a
+// BIP0322 simple signature format.
+var errBIP322Address = errors.New("BIP0322 signatures are only supported " +
+	"for P2WPKH and P2TR addresses")
+
+// bip322MessageHash returns the BIP0340 tagged hash of a message signed with
+// BIP0322.
+func bip322MessageHash(message string) []byte {
+	return taproot.TaggedHash("BIP0322-signed-message", []byte(message))
+}
+
+// bip322ToSpend returns the virtual transaction whose only output, paying to
+// pkScript, is spent by the signature of a message.  Its input commits to the
+// message hash so that the signature can not spend any real output.
+func bip322ToSpend(pkScript []byte, message string) *wire.MsgTx {
+	// The script only pushes data, so building it can not fail.
+	sigScript, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(bip322MessageHash(message)).Script()
+
+	tx := wire.NewMsgTx(0)
+	prevOut := wire.NewOutPoint(&chainhash.Hash{}, math.MaxUint32)
+	txIn := wire.NewTxIn(prevOut, sigScript, nil)
+	txIn.Sequence = 0
+	tx.AddTxIn(txIn)
+	tx.AddTxOut(wire.NewTxOut(0, pkScript))
+	return tx
+}
+
+// bip322ToSign returns the virtual transaction spending the output of
+// toSpend, whose input witness is the signature of the message.
+func bip322ToSign(toSpend *wire.MsgTx, witness wire.TxWitness) *wire.MsgTx {
+	tx := wire.NewMsgTx(0)
+	toSpendHash := toSpend.TxHash()
+	txIn := wire.NewTxIn(wire.NewOutPoint(&toSpendHash, 0), nil, witness)
+	txIn.Sequence = 0
+	tx.AddTxIn(txIn)
+	tx.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	return tx
+}
+
+// serializeWitness returns the consensus serialization of a witness stack,
+// which is the BIP0322 simple signature format.
+func serializeWitness(witness wire.TxWitness) []byte {
+	var buf bytes.Buffer
+	wire.WriteVarInt(&buf, 0, uint64(len(witness)))
+	for _, item := range witness {
+		wire.WriteVarBytes(&buf, 0, item)
+	}
+	return buf.Bytes()
+}
+
+// parseWitness parses a witness stack serialized by serializeWitness.
+func parseWitness(b []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(b)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(b)) {
+		return nil, errors.New("witness item count exceeds signature size")
+	}
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(r, 0, uint32(len(b)),
+			"witness item")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.Len() != 0 {
+		return nil, errors.New("trailing bytes after witness")
+	}
+	return witness, nil
+}
+
+// signBIP322 returns the BIP0322 simple signature of a message by the private
+// key of a P2WPKH or P2TR pkScript.  Taproot keys are the internal keys, and
+// are tweaked to the output key before signing.
+func signBIP322(privKey *btcec.PrivateKey, pkScript []byte, message string) ([]byte, error) {
+	toSpend := bip322ToSpend(pkScript, message)
+	toSign := bip322ToSign(toSpend, nil)
+
+	var witness wire.TxWitness
+	var err error
+	switch {
+	case taproot.IsPayToTaproot(pkScript):
+		prevOuts := []taproot.PrevOutput{{Value: 0, PkScript: pkScript}}
+		witness, err = taproot.KeyPathWitness(toSign, 0, prevOuts,
+			taproot.SigHashDefault, privKey)
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		sigHashes := txscript.NewTxSigHashes(toSign)
+		witness, err = txscript.WitnessSignature(toSign, sigHashes, 0, 0,
+			pkScript, txscript.SigHashAll, privKey, true)
+	default:
+		return nil, errBIP322Address
+	}
+	if err != nil {
+		return nil, err
+	}
+	return serializeWitness(witness), nil
+}
+
+// SignMessageBIP322 returns the BIP0322 simple signature of a message by the
+// private key of a P2WPKH or P2TR wallet address.  The signature is the
+// witness spending the address output of a virtual transaction committing to
+// the message.
+func (w *Wallet) SignMessageBIP322(addr btcutil.Address, message string) ([]byte, error) {
+	pkScript, err := taproot.PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !taproot.IsPayToTaproot(pkScript) &&
+		!txscript.IsPayToWitnessPubKeyHash(pkScript) {
+		return nil, errBIP322Address
+	}
+
+	var sig []byte
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		managedAddr, err := w.Manager.Address(addrmgrNs, addr)
+		if err != nil {
+			return err
+		}
+		pka, ok := managedAddr.(waddrmgr.ManagedPubKeyAddress)
+		if !ok {
+			return errors.New("address does not have an associated private key")
+		}
+		privKey, err := pka.PrivKey()
+		if err != nil {
+			return err
+		}
+		defer zero.BigInt(privKey.D)
+		w.recordKeyUse(pka)
+		sig, err = signBIP322(privKey, pkScript, message)
+		return err
+	})
+	return sig, err
+}
+
+// VerifyMessageBIP322 returns whether a BIP0322 simple signature of a message
+// was made by the key of a P2WPKH or P2TR address.  P2WPKH witnesses are
+// checked by the script engine with the standard verification flags, and P2TR
+// witnesses must be key path spends.
+func VerifyMessageBIP322(addr btcutil.Address, sig []byte, message string) (bool, error) {
+	pkScript, err := taproot.PayToAddrScript(addr)
+	if err != nil {
+		return false, err
+	}
+	witness, err := parseWitness(sig)
+	if err != nil {
+		return false, err
+	}
+	toSpend := bip322ToSpend(pkScript, message)
+	toSign := bip322ToSign(toSpend, witness)
+
+	switch {
+	case taproot.IsPayToTaproot(pkScript):
+		if len(witness) != 1 {
+			return false, nil
+		}
+		schnorrSig := witness[0]
+		hashType := taproot.SigHashDefault
+		switch len(schnorrSig) {
+		case taproot.SignatureSize:
+		case taproot.SignatureSize + 1:
+			hashType = txscript.SigHashType(schnorrSig[taproot.SignatureSize])
+			if hashType == taproot.SigHashDefault {
+				return false, nil
+			}
+			schnorrSig = schnorrSig[:taproot.SignatureSize]
+		default:
+			return false, nil
+		}
+		prevOuts := []taproot.PrevOutput{{Value: 0, PkScript: pkScript}}
+		hash, err := taproot.CalcSignatureHash(toSign, 0, hashType, prevOuts)
+		if err != nil {
+			return false, nil
+		}
+		return taproot.Verify(pkScript[2:], hash, schnorrSig), nil
+
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		vm, err := txscript.NewEngine(pkScript, toSign, 0,
+			txscript.StandardVerifyFlags, nil,
+			txscript.NewTxSigHashes(toSign), 0)
+		if err != nil {
+			return false, err
+		}
+		return vm.Execute() == nil, nil
+
+	default:
+		return false, errBIP322Address
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+)
+
+// TestBIP322MessageHash checks message hashes against the test vectors of
+// BIP0322.
+func TestBIP322MessageHash(t *testing.T) {
+	tests := []struct {
+		message string
+		hash    string
+	}{
+		{"", "c90c269c4f8fcbe6880f72a721ddfbf1914268a794cbb21cfafee13770ae19f1"},
+		{"Hello World", "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a"},
+	}
+	for _, test := range tests {
+		hash := hex.EncodeToString(bip322MessageHash(test.message))
+		if hash != test.hash {
+			t.Errorf("hash of %q is %s, want %s", test.message, hash,
+				test.hash)
+		}
+	}
+}
+
+// TestVerifyMessageBIP322 checks that simple signatures of P2WPKH and P2TR
+// addresses verify only for the signed message and the signing key.
+func TestVerifyMessageBIP322(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(privKey.PubKey().SerializeCompressed()), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2tr, err := taproot.NewAddressTaprootFromPubKey(privKey.PubKey(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const message = "Hello World"
+	for _, addr := range []btcutil.Address{p2wpkh, p2tr} {
+		pkScript, err := taproot.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := signBIP322(privKey, pkScript, message)
+		if err != nil {
+			t.Fatalf("%v: %v", addr, err)
+		}
+		otherSig, err := signBIP322(otherKey, pkScript, message)
+		if err != nil {
+			t.Fatalf("%v: %v", addr, err)
+		}
+
+		tests := []struct {
+			name    string
+			sig     []byte
+			message string
+			valid   bool
+		}{
+			{"signature", sig, message, true},
+			{"signature of another message", sig, "other", false},
+			{"signature of another key", otherSig, message, false},
+		}
+		for _, test := range tests {
+			valid, err := VerifyMessageBIP322(addr, test.sig, test.message)
+			if err != nil {
+				t.Errorf("%v %s: %v", addr, test.name, err)
+				continue
+			}
+			if valid != test.valid {
+				t.Errorf("%v %s: valid is %v, want %v", addr, test.name,
+					valid, test.valid)
+			}
+		}
+
+		// Trailing bytes make the witness malformed.
+		_, err = VerifyMessageBIP322(addr, append(sig, 0), message)
+		if err == nil {
+			t.Errorf("%v: signature with trailing bytes was parsed", addr)
+		}
+	}
+}
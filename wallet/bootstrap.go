@@ -0,0 +1,471 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/internal/addrcheck"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/descriptor"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// A watching-only wallet can be bootstrapped from the state of another
+// system instead of rescanning the chain for its history.  The bootstrap
+// records the outpoints the other system knew to be unspent, and the wallet
+// birthday is set to the time of the export so that the initial sync only
+// scans blocks mined after it.  The transactions of the bootstrap outpoints
+// are then reconciled in the background by fetching the block each one was
+// mined in, after which their outputs are watched for spends since the
+// export.
+
+// bootstrapBucketKey is the key of the bucket in the transaction metadata
+// namespace holding the bootstrap outpoints which have not been reconciled
+// yet, keyed by outpoint, with the height of the block they were mined in.
+var bootstrapBucketKey = []byte("bootstrap")
+
+// bootstrapHeightKey is the key in the bootstrap bucket of the chain height
+// at the time of the export.
+var bootstrapHeightKey = []byte("height")
+
+// bootstrapAddrLimit is the number of addresses of each branch searched for
+// the addresses of a bootstrap.
+const bootstrapAddrLimit = 100000
+
+// Bootstrap describes a watching-only wallet exported by another system.
+type Bootstrap struct {
+	// Descriptor is the output descriptor of the account.
+	Descriptor string `json:"descriptor"`
+
+	// Addresses are the addresses of the account that were used.  The
+	// wallet derives all addresses through the last of them.
+	Addresses []string `json:"addresses"`
+
+	// OutPoints are the outputs of the account that were unspent at the
+	// time of the export.
+	OutPoints []BootstrapOutPoint `json:"outpoints"`
+
+	// Timestamp is the time of the export as a unix timestamp.  It is used
+	// as the wallet birthday.
+	Timestamp int64 `json:"timestamp"`
+
+	// Height is the optional chain height at the time of the export.
+	// Spends of the outpoints are searched for from this height, or else
+	// from the height of each outpoint.
+	Height int32 `json:"height,omitempty"`
+}
+
+// BootstrapOutPoint is an unspent output of a bootstrap.
+type BootstrapOutPoint struct {
+	TxID   string `json:"txid"`
+	Vout   uint32 `json:"vout"`
+	Height int32  `json:"height"`
+
+	// Address is the optional address of the output, which is derived
+	// like the addresses of the bootstrap.
+	Address string `json:"address,omitempty"`
+}
+
+// ReadBootstrapFile reads a bootstrap from a JSON file.
+func ReadBootstrapFile(path string) (*Bootstrap, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bootstrap := new(Bootstrap)
+	if err := json.Unmarshal(b, bootstrap); err != nil {
+		return nil, fmt.Errorf("invalid bootstrap file %s: %v", path, err)
+	}
+	return bootstrap, nil
+}
+
+// CreateWatchOnly creates a new watching-only wallet in the passed database
+// from a bootstrap exported by another system.  The account of the bootstrap
+// descriptor becomes the default account of the only key scope of the
+// wallet, and its addresses are derived through the last address of the
+// bootstrap.  The outpoints of the bootstrap are reconciled once the wallet
+// is synced.
+func CreateWatchOnly(db walletdb.DB, pubPass []byte, params *chaincfg.Params,
+	bootstrap *Bootstrap) error {
+
+	desc, err := descriptor.Parse(bootstrap.Descriptor, params)
+	if err != nil {
+		return err
+	}
+	if bootstrap.Timestamp <= 0 {
+		return errors.New("bootstrap timestamp is required")
+	}
+
+	outpoints := make(map[wire.OutPoint]int32, len(bootstrap.OutPoints))
+	addrs := append([]string(nil), bootstrap.Addresses...)
+	for _, op := range bootstrap.OutPoints {
+		hash, err := chainhash.NewHashFromStr(op.TxID)
+		if err != nil {
+			return err
+		}
+		if op.Height <= 0 {
+			return fmt.Errorf("bootstrap outpoint %v:%d has no "+
+				"block height", hash, op.Vout)
+		}
+		outpoints[*wire.NewOutPoint(hash, op.Vout)] = op.Height
+		if op.Address != "" {
+			addrs = append(addrs, op.Address)
+		}
+	}
+
+	var fingerprint []byte
+	if desc.HasOrigin {
+		fingerprint = desc.Fingerprint[:]
+	}
+
+	return walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs, err := tx.CreateTopLevelBucket(waddrmgrNamespaceKey)
+		if err != nil {
+			return err
+		}
+		txmgrNs, err := tx.CreateTopLevelBucket(wtxmgrNamespaceKey)
+		if err != nil {
+			return err
+		}
+
+		err = waddrmgr.CreateWatchOnly(
+			addrmgrNs, pubPass, params, nil,
+			time.Unix(bootstrap.Timestamp, 0), desc.Scope(),
+			desc.AddrSchema(), desc.AccountKey, fingerprint,
+		)
+		if err != nil {
+			return err
+		}
+		err = wtxmgr.Create(txmgrNs)
+		if err != nil {
+			return err
+		}
+		err = createOptionalNamespaces(tx)
+		if err != nil {
+			return err
+		}
+
+		mgr, err := waddrmgr.Open(addrmgrNs, pubPass, params)
+		if err != nil {
+			return err
+		}
+		defer mgr.Close()
+		scopedMgr, err := mgr.FetchScopedKeyManager(desc.Scope())
+		if err != nil {
+			return err
+		}
+		err = extendBootstrapAddresses(
+			addrmgrNs, scopedMgr, desc.Branches, addrs, params,
+		)
+		if err != nil {
+			return err
+		}
+
+		return putBootstrapOutPoints(
+			tx.ReadWriteBucket(wtxmetaNamespaceKey), outpoints,
+			bootstrap.Height,
+		)
+	})
+}
+
+// extendBootstrapAddresses derives the addresses of the branches of the
+// default account through the last index of any of the passed addresses.
+func extendBootstrapAddresses(ns walletdb.ReadWriteBucket,
+	scopedMgr *waddrmgr.ScopedKeyManager, branches []uint32,
+	addrs []string, params *chaincfg.Params) error {
+
+	pending := make(map[string]struct{}, len(addrs))
+	for _, s := range addrs {
+		addr, err := addrcheck.Decode(s, params)
+		if err != nil {
+			return err
+		}
+		pending[addr.EncodeAddress()] = struct{}{}
+	}
+
+	lastIndex := make(map[uint32]uint32)
+	for index := uint32(0); len(pending) > 0; index++ {
+		if index == bootstrapAddrLimit {
+			missing := make([]string, 0, len(pending))
+			for addr := range pending {
+				missing = append(missing, addr)
+			}
+			sort.Strings(missing)
+			return fmt.Errorf("bootstrap addresses %v are not among "+
+				"the first %d addresses of the descriptor",
+				missing, bootstrapAddrLimit)
+		}
+		for _, branch := range branches {
+			path := waddrmgr.DerivationPath{
+				Account: waddrmgr.DefaultAccountNum,
+				Branch:  branch,
+				Index:   index,
+			}
+			ma, err := scopedMgr.DeriveFromKeyPath(ns, path)
+			if waddrmgr.IsError(err, waddrmgr.ErrKeyChain) {
+				// Skip the invalid child, like address
+				// derivation does.
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			addr := ma.Address().EncodeAddress()
+			if _, ok := pending[addr]; ok {
+				delete(pending, addr)
+				lastIndex[branch] = index
+			}
+		}
+	}
+
+	for branch, index := range lastIndex {
+		var err error
+		if branch == waddrmgr.InternalBranch {
+			err = scopedMgr.ExtendInternalAddresses(
+				ns, waddrmgr.DefaultAccountNum, index,
+			)
+		} else {
+			err = scopedMgr.ExtendExternalAddresses(
+				ns, waddrmgr.DefaultAccountNum, index,
+			)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putBootstrapOutPoints adds the outpoints of a bootstrap to the bootstrap
+// bucket of the transaction metadata namespace.
+func putBootstrapOutPoints(ns walletdb.ReadWriteBucket,
+	outpoints map[wire.OutPoint]int32, height int32) error {
+
+	bucket, err := ns.CreateBucketIfNotExists(bootstrapBucketKey)
+	if err != nil {
+		return err
+	}
+	if height > 0 {
+		var v [4]byte
+		binary.LittleEndian.PutUint32(v[:], uint32(height))
+		if err := bucket.Put(bootstrapHeightKey, v[:]); err != nil {
+			return err
+		}
+	}
+	for op, height := range outpoints {
+		var v [4]byte
+		binary.LittleEndian.PutUint32(v[:], uint32(height))
+		if err := bucket.Put(bootstrapOutPointKey(&op), v[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bootstrapOutPointKey returns the key of an outpoint in the bootstrap
+// bucket.
+func bootstrapOutPointKey(op *wire.OutPoint) []byte {
+	k := make([]byte, chainhash.HashSize+4)
+	copy(k, op.Hash[:])
+	binary.LittleEndian.PutUint32(k[chainhash.HashSize:], op.Index)
+	return k
+}
+
+// fetchBootstrapOutPoints returns the outpoints of the bootstrap that have
+// not been reconciled, grouped by the height of the block they were mined in,
+// and the chain height of the export.
+func fetchBootstrapOutPoints(ns walletdb.ReadBucket) (map[int32][]wire.OutPoint, int32, error) {
+	bucket := ns.NestedReadBucket(bootstrapBucketKey)
+	if bucket == nil {
+		return nil, 0, nil
+	}
+	var exportHeight int32
+	if v := bucket.Get(bootstrapHeightKey); len(v) == 4 {
+		exportHeight = int32(binary.LittleEndian.Uint32(v))
+	}
+	outpoints := make(map[int32][]wire.OutPoint)
+	err := bucket.ForEach(func(k, v []byte) error {
+		if len(k) != chainhash.HashSize+4 {
+			return nil
+		}
+		if len(v) != 4 {
+			return fmt.Errorf("malformed bootstrap outpoint height")
+		}
+		var op wire.OutPoint
+		copy(op.Hash[:], k)
+		op.Index = binary.LittleEndian.Uint32(k[chainhash.HashSize:])
+		height := int32(binary.LittleEndian.Uint32(v))
+		outpoints[height] = append(outpoints[height], op)
+		return nil
+	})
+	return outpoints, exportHeight, err
+}
+
+// reconcileBootstrap adds the transactions of the bootstrap outpoints that
+// have not been reconciled yet to the wallet, and then rescans for spends of
+// their outputs since the export.  Outpoints in blocks the chain server does
+// not have yet are left for the next sync.
+func (w *Wallet) reconcileBootstrap(chainClient chain.Interface) error {
+	w.bootstrapMtx.Lock()
+	defer w.bootstrapMtx.Unlock()
+
+	var pending map[int32][]wire.OutPoint
+	var exportHeight int32
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		var err error
+		pending, exportHeight, err = fetchBootstrapOutPoints(
+			dbtx.ReadBucket(wtxmetaNamespaceKey),
+		)
+		return err
+	})
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	_, bestHeight, err := chainClient.GetBestBlock()
+	if err != nil {
+		return err
+	}
+	heights := make([]int32, 0, len(pending))
+	for height := range pending {
+		if height <= bestHeight {
+			heights = append(heights, height)
+		}
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+
+	log.Infof("Reconciling %d blocks of bootstrap outputs", len(heights))
+	rescanOutPoints := make(map[wire.OutPoint]btcutil.Address)
+	for _, height := range heights {
+		if w.ShuttingDown() {
+			return nil
+		}
+		credits, err := w.reconcileBootstrapBlock(
+			chainClient, height, pending[height],
+		)
+		if err != nil {
+			return err
+		}
+		for op, addr := range credits {
+			rescanOutPoints[op] = addr
+		}
+	}
+	if len(rescanOutPoints) == 0 {
+		return nil
+	}
+
+	// The reconciled outputs were not watched by the initial sync, so
+	// rescan for spends from the export, or from the first reconciled
+	// block when the height of the export is unknown.
+	rescanHeight := exportHeight
+	if rescanHeight <= 0 {
+		rescanHeight = heights[0]
+	} else if rescanHeight > bestHeight {
+		rescanHeight = bestHeight
+	}
+	hash, err := chainClient.GetBlockHash(int64(rescanHeight))
+	if err != nil {
+		return err
+	}
+	job := &RescanJob{
+		OutPoints:  rescanOutPoints,
+		BlockStamp: waddrmgr.BlockStamp{Hash: *hash, Height: rescanHeight},
+	}
+	return <-w.SubmitRescan(job)
+}
+
+// reconcileBootstrapBlock adds the transactions of the bootstrap outpoints
+// mined in the block at the passed height to the wallet and removes the
+// outpoints from the bootstrap.  The wallet outputs of the outpoints are
+// returned with their addresses.
+func (w *Wallet) reconcileBootstrapBlock(chainClient chain.Interface,
+	height int32, outpoints []wire.OutPoint) (map[wire.OutPoint]btcutil.Address, error) {
+
+	hash, err := chainClient.GetBlockHash(int64(height))
+	if err != nil {
+		return nil, err
+	}
+	block, err := chainClient.GetBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	blockMeta := &wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{Hash: *hash, Height: height},
+		Time:  block.Header.Timestamp,
+	}
+	txs := make(map[chainhash.Hash]*wire.MsgTx, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		txs[tx.TxHash()] = tx
+	}
+
+	credits := make(map[wire.OutPoint]btcutil.Address)
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		bucket := dbtx.ReadWriteBucket(wtxmetaNamespaceKey).
+			NestedReadWriteBucket(bootstrapBucketKey)
+
+		inserted := make(map[chainhash.Hash]struct{})
+		for i := range outpoints {
+			op := &outpoints[i]
+			err := bucket.Delete(bootstrapOutPointKey(op))
+			if err != nil {
+				return err
+			}
+
+			tx, ok := txs[op.Hash]
+			if !ok {
+				log.Warnf("Bootstrap outpoint %v is not in block "+
+					"%v (height %d)", op, hash, height)
+				continue
+			}
+			if op.Index >= uint32(len(tx.TxOut)) {
+				log.Warnf("Bootstrap outpoint %v does not exist", op)
+				continue
+			}
+			_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+				tx.TxOut[op.Index].PkScript, w.chainParams,
+			)
+			if err != nil || len(addrs) == 0 {
+				log.Warnf("Bootstrap outpoint %v has a "+
+					"non-standard script", op)
+				continue
+			}
+			if _, err := w.addrAccount(dbtx, addrs[0]); err != nil {
+				log.Warnf("Bootstrap outpoint %v does not pay to "+
+					"a wallet address", op)
+				continue
+			}
+			credits[*op] = addrs[0]
+
+			if _, ok := inserted[op.Hash]; ok {
+				continue
+			}
+			rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, blockMeta.Time)
+			if err != nil {
+				return err
+			}
+			err = w.addRelevantTx(dbtx, rec, blockMeta)
+			if err != nil {
+				return err
+			}
+			inserted[op.Hash] = struct{}{}
+		}
+		return nil
+	})
+	return credits, err
+}
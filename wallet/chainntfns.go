@@ -8,8 +8,8 @@ import (
 	"bytes"
 	"strings"
 
-	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/internal/taproot"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/btcsuite/btcwallet/wtxmgr"
@@ -30,8 +30,26 @@ func (w *Wallet) handleChainNotifications() {
 		// and many methods will error early since the wallet is known
 		// to be out of date.
 		err := w.syncWithChain()
+		if err != nil {
+			if !w.ShuttingDown() {
+				log.Warnf("Unable to synchronize wallet to "+
+					"chain: %v", err)
+			}
+			return
+		}
+
+		// Outpoints of a bootstrapped wallet are reconciled once the
+		// wallet is synced, and retried by the next sync on failure.
+		err = w.reconcileBootstrap(chainClient)
+		if err != nil && !w.ShuttingDown() {
+			log.Warnf("Unable to reconcile bootstrap outputs: %v", err)
+		}
+
+		// Transactions published while the wallet was offline are
+		// broadcast now that it is synced.
+		err = w.broadcastQueued(chainClient)
 		if err != nil && !w.ShuttingDown() {
-			log.Warnf("Unable to synchronize wallet to chain: %v", err)
+			log.Warnf("Unable to broadcast queued transactions: %v", err)
 		}
 	}
 
@@ -92,6 +110,12 @@ func (w *Wallet) handleChainNotifications() {
 				return
 			}
 
+			// Notifications wait while the database is closed for
+			// maintenance.
+			if !w.waitMaintenance() {
+				return
+			}
+
 			var notificationName string
 			var err error
 			switch n := n.(type) {
@@ -101,6 +125,10 @@ func (w *Wallet) handleChainNotifications() {
 				err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
 					return w.connectBlock(tx, wtxmgr.BlockMeta(n))
 				})
+				if err == nil {
+					w.heightReached(n.Height)
+					w.checkRefill()
+				}
 				notificationName = "blockconnected"
 			case chain.BlockDisconnected:
 				err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
@@ -189,6 +217,7 @@ func (w *Wallet) connectBlock(dbtx walletdb.ReadWriteTx, b wtxmgr.BlockMeta) err
 	//
 	// TODO: move all notifications outside of the database transaction.
 	w.NtfnServer.notifyAttachedBlock(dbtx, &b)
+	w.NtfnServer.notifyBlock(&b, false)
 	return nil
 }
 
@@ -203,6 +232,10 @@ func (w *Wallet) disconnectBlock(dbtx walletdb.ReadWriteTx, b wtxmgr.BlockMeta)
 		return nil
 	}
 
+	// The hash of b is replaced below when the block is rolled back, so
+	// keep the disconnected block for its notification.
+	detached := b
+
 	// Disconnect the removed block and all blocks after it if we know about
 	// the disconnected block. Otherwise, the block is in the future.
 	if b.Height <= w.Manager.SyncedTo().Height {
@@ -241,6 +274,7 @@ func (w *Wallet) disconnectBlock(dbtx walletdb.ReadWriteTx, b wtxmgr.BlockMeta)
 
 	// Notify interested clients of the disconnected block.
 	w.NtfnServer.notifyDetachedBlock(&b.Hash)
+	w.NtfnServer.notifyBlock(&detached, true)
 
 	return nil
 }
@@ -248,6 +282,8 @@ func (w *Wallet) disconnectBlock(dbtx walletdb.ReadWriteTx, b wtxmgr.BlockMeta)
 func (w *Wallet) addRelevantTx(dbtx walletdb.ReadWriteTx, rec *wtxmgr.TxRecord, block *wtxmgr.BlockMeta) error {
 	addrmgrNs := dbtx.ReadWriteBucket(waddrmgrNamespaceKey)
 	txmgrNs := dbtx.ReadWriteBucket(wtxmgrNamespaceKey)
+	scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+	watchNs := dbtx.ReadBucket(wwatchNamespaceKey)
 
 	// At the moment all notified transactions are assumed to actually be
 	// relevant.  This assumption will not hold true when SPV support is
@@ -258,10 +294,43 @@ func (w *Wallet) addRelevantTx(dbtx walletdb.ReadWriteTx, rec *wtxmgr.TxRecord,
 		return err
 	}
 
+	// Addresses are used at the time of the block of the transaction, or
+	// when it was received if it is unmined.
+	usedTime := rec.Received
+	var minedBlock *wtxmgr.Block
+	if block != nil {
+		usedTime = block.Time
+		minedBlock = &block.Block
+	}
+
+	// Record the use of the addresses of the spent wallet outputs.
+	prevPkScripts, err := w.TxStore.PreviousPkScripts(txmgrNs, rec,
+		minedBlock)
+	if err != nil {
+		return err
+	}
+	for _, pkScript := range prevPkScripts {
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(pkScript,
+			w.chainParams)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			err := w.Manager.MarkUsedAt(addrmgrNs, addr, usedTime)
+			if err != nil && !waddrmgr.IsError(err,
+				waddrmgr.ErrAddressNotFound) {
+
+				return err
+			}
+		}
+	}
+
 	// Check every output to determine whether it is controlled by a wallet
-	// key.  If so, mark the output as a credit.
+	// key.  If so, mark the output as a credit.  Credits to external
+	// addresses are recorded for receive screening.
+	var received []uint32
 	for i, output := range rec.MsgTx.TxOut {
-		_, addrs, _, err := txscript.ExtractPkScriptAddrs(output.PkScript,
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(output.PkScript,
 			w.chainParams)
 		if err != nil {
 			// Non-standard outputs are skipped.
@@ -278,7 +347,11 @@ func (w *Wallet) addRelevantTx(dbtx walletdb.ReadWriteTx, rec *wtxmgr.TxRecord,
 				if err != nil {
 					return err
 				}
-				err = w.Manager.MarkUsed(addrmgrNs, addr)
+				if !ma.Internal() {
+					received = append(received, uint32(i))
+				}
+				err = w.Manager.MarkUsedAt(addrmgrNs, addr,
+					usedTime)
 				if err != nil {
 					return err
 				}
@@ -286,14 +359,45 @@ func (w *Wallet) addRelevantTx(dbtx walletdb.ReadWriteTx, rec *wtxmgr.TxRecord,
 				continue
 			}
 
-			// Missing addresses are skipped.  Other errors should
-			// be propagated.
+			// Missing addresses are skipped, unless they pay to a
+			// script in the script store or are watched.  Other
+			// errors should be propagated.
 			if !waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
 				return err
 			}
+			_, err = fetchScript(scriptNs, addr)
+			if err == nil {
+				err = w.TxStore.AddCredit(txmgrNs, rec, block,
+					uint32(i), false)
+				if err != nil {
+					return err
+				}
+				received = append(received, uint32(i))
+				continue
+			}
+
+			// Watch-only credits are not screened, since the
+			// wallet never spends them.
+			_, _, err = fetchWatchedAccount(watchNs, output.PkScript)
+			if err == nil {
+				err = w.TxStore.AddCredit(txmgrNs, rec, block,
+					uint32(i), false)
+				if err != nil {
+					return err
+				}
+				break
+			}
 		}
 	}
 
+	// Unsolicited dust is quarantined so coin selection does not link
+	// the receiving addresses by spending it.
+	err = w.quarantineDust(dbtx, rec, received, len(prevPkScripts) != 0)
+	if err != nil {
+		return err
+	}
+	w.screenIncoming(rec, received, block != nil)
+
 	// Send notification of mined or unmined transaction to any interested
 	// clients.
 	//
@@ -324,6 +428,7 @@ func (w *Wallet) addRelevantTx(dbtx walletdb.ReadWriteTx, rec *wtxmgr.TxRecord,
 		// wallet's set of confirmed transactions.
 		if details != nil {
 			w.NtfnServer.notifyMinedTransaction(dbtx, details, block)
+			w.notifyTxConfirmed(&rec.MsgTx, block)
 		}
 	}
 
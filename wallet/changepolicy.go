@@ -0,0 +1,104 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/internal/txsizes"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// ChangePolicy chooses the wallet addresses receiving the change of the
+// transactions created by the wallet.
+type ChangePolicy string
+
+// These constants name the change policies of the wallet.
+const (
+	// ChangePolicyNew pays the change of every transaction to a new
+	// internal address of the account.  It is the default.
+	ChangePolicyNew ChangePolicy = "new"
+
+	// ChangePolicyReuse pays the change of the transactions of an account
+	// to its last internal address, which links its transactions together
+	// but keeps the number of addresses to watch and back up small.
+	ChangePolicyReuse ChangePolicy = "reuse"
+)
+
+// ParseChangePolicy returns the change policy with a name.
+func ParseChangePolicy(name string) (ChangePolicy, error) {
+	switch p := ChangePolicy(name); p {
+	case ChangePolicyNew, ChangePolicyReuse:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown change policy %q", name)
+}
+
+// changePolicy is the change policy of the transactions created without one.
+type changePolicy struct {
+	mu     sync.Mutex
+	policy ChangePolicy
+}
+
+// SetChangePolicy sets the change policy of the transactions created without
+// one.
+func (w *Wallet) SetChangePolicy(policy ChangePolicy) {
+	w.changePolicy.mu.Lock()
+	w.changePolicy.policy = policy
+	w.changePolicy.mu.Unlock()
+}
+
+// ChangePolicy returns the change policy of the transactions created without
+// one.
+func (w *Wallet) ChangePolicy() ChangePolicy {
+	w.changePolicy.mu.Lock()
+	defer w.changePolicy.mu.Unlock()
+	if w.changePolicy.policy == "" {
+		return ChangePolicyNew
+	}
+	return w.changePolicy.policy
+}
+
+// changeAddress returns the address receiving the change of a transaction of
+// account following policy.  Reused change addresses are only derived for
+// accounts without internal addresses.
+func (w *Wallet) changeAddress(addrmgrNs walletdb.ReadWriteBucket,
+	account uint32, policy ChangePolicy) (btcutil.Address, error) {
+
+	if policy != ChangePolicyReuse {
+		return w.newChangeAddress(addrmgrNs, account)
+	}
+
+	manager, err := w.changeKeyManager()
+	if err != nil {
+		return nil, err
+	}
+	addr, err := manager.LastInternalAddress(addrmgrNs, account)
+	if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+		return w.newChangeAddress(addrmgrNs, account)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return addr.Address(), nil
+}
+
+// checkChangeAddress returns an error unless the output script of addr fits
+// the change output the fees of the transactions are estimated with.
+func checkChangeAddress(addr btcutil.Address) error {
+	pkScript, err := taproot.PayToAddrScript(addr)
+	if err != nil {
+		return err
+	}
+	if len(pkScript) > txsizes.P2WPKHPkScriptSize {
+		return fmt.Errorf("change address %v is not a P2WPKH address",
+			addr)
+	}
+	return nil
+}
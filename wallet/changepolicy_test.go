@@ -0,0 +1,58 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+)
+
+// TestChangePolicy checks the parsing of change policies, the default policy
+// of the wallet, and that only P2WPKH change addresses are accepted.
+func TestChangePolicy(t *testing.T) {
+	for _, name := range []string{"new", "reuse"} {
+		p, err := ParseChangePolicy(name)
+		if err != nil || string(p) != name {
+			t.Fatalf("ParseChangePolicy(%q) = %q, %v", name, p, err)
+		}
+	}
+	if _, err := ParseChangePolicy("random"); err == nil {
+		t.Fatal("parsed unknown change policy")
+	}
+
+	w := &Wallet{}
+	if p := w.ChangePolicy(); p != ChangePolicyNew {
+		t.Fatalf("default change policy %q, want %q", p, ChangePolicyNew)
+	}
+	w.SetChangePolicy(ChangePolicyReuse)
+	if p := w.ChangePolicy(); p != ChangePolicyReuse {
+		t.Fatalf("change policy %q, want %q", p, ChangePolicyReuse)
+	}
+	opts := &TxOptions{ChangePolicy: ChangePolicyNew}
+	if p := opts.changePolicy(w); p != ChangePolicyNew {
+		t.Fatalf("transaction change policy %q, want %q", p,
+			ChangePolicyNew)
+	}
+
+	params := &chaincfg.RegressionNetParams
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20),
+		params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkChangeAddress(p2wpkh); err != nil {
+		t.Fatalf("P2WPKH change address rejected: %v", err)
+	}
+	p2wsh, err := btcutil.NewAddressWitnessScriptHash(make([]byte, 32),
+		params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkChangeAddress(p2wsh); err == nil {
+		t.Fatal("P2WSH change address accepted")
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/wallet/internal/txsizes"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// These constants name the coin selectors of the wallet.
+const (
+	// CoinSelectionOldestFirst spends the oldest outputs first.  It is
+	// the default.
+	CoinSelectionOldestFirst = "oldestfirst"
+
+	// CoinSelectionLargestFirst spends the largest outputs first, which
+	// spends the fewest outputs.
+	CoinSelectionLargestFirst = "largestfirst"
+
+	// CoinSelectionBranchAndBound searches for outputs matching the
+	// amount to pay so that no change is needed, and spends the largest
+	// outputs first when there is no match.
+	CoinSelectionBranchAndBound = "branchandbound"
+)
+
+// bnbMaxTries is the number of branches the branch and bound search visits
+// before giving up on an exact match.
+const bnbMaxTries = 100000
+
+// CoinSelector selects the outputs spent by a transaction from the eligible
+// outputs of an account.
+type CoinSelector interface {
+	// SelectCoins returns the outputs of eligible to spend, with a total
+	// value of at least target, or every eligible output when they do not
+	// add up to target.  The target includes the fee of the transaction
+	// estimated with a single P2WPKH input and a change output, and
+	// SelectCoins is called again with a larger target when the selected
+	// outputs do not cover the fee of spending them.
+	SelectCoins(eligible []wtxmgr.Credit, target,
+		feeSatPerKb btcutil.Amount) []wtxmgr.Credit
+}
+
+// CoinSelectorByName returns the coin selector with a name.
+func CoinSelectorByName(name string) (CoinSelector, error) {
+	switch name {
+	case CoinSelectionOldestFirst:
+		return OldestFirst{}, nil
+	case CoinSelectionLargestFirst:
+		return LargestFirst{}, nil
+	case CoinSelectionBranchAndBound:
+		return BranchAndBound{}, nil
+	}
+	return nil, fmt.Errorf("unknown coin selection %q", name)
+}
+
+// selectUntil returns the outputs of sorted up to the first one reaching a
+// total value of target.
+func selectUntil(sorted []wtxmgr.Credit, target btcutil.Amount) []wtxmgr.Credit {
+	var total btcutil.Amount
+	for i := range sorted {
+		total += sorted[i].Amount
+		if total >= target {
+			return sorted[:i+1]
+		}
+	}
+	return sorted
+}
+
+// OldestFirst is a CoinSelector spending the oldest outputs first.
+// Unconfirmed outputs are treated as the oldest.
+type OldestFirst struct{}
+
+// SelectCoins implements the CoinSelector interface.
+func (OldestFirst) SelectCoins(eligible []wtxmgr.Credit, target,
+	feeSatPerKb btcutil.Amount) []wtxmgr.Credit {
+
+	sorted := append([]wtxmgr.Credit(nil), eligible...)
+	sort.Sort(byHeight(sorted))
+	return selectUntil(sorted, target)
+}
+
+// LargestFirst is a CoinSelector spending the largest outputs first.
+type LargestFirst struct{}
+
+// SelectCoins implements the CoinSelector interface.
+func (LargestFirst) SelectCoins(eligible []wtxmgr.Credit, target,
+	feeSatPerKb btcutil.Amount) []wtxmgr.Credit {
+
+	sorted := append([]wtxmgr.Credit(nil), eligible...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Amount > sorted[j].Amount
+	})
+	return selectUntil(sorted, target)
+}
+
+// BranchAndBound is a CoinSelector searching for outputs whose values, less
+// the fee of spending them, match the target closely enough that the change
+// would be worth less than the cost of creating and later spending it.  Such
+// transactions have no change output.  When no match is found, the largest
+// outputs are spent first.
+type BranchAndBound struct{}
+
+// inputVirtualSize returns the virtual size of an input spending pkScript.
+func inputVirtualSize(pkScript []byte) int {
+	var n [4]int
+	switch {
+	case txscript.IsPayToScriptHash(pkScript):
+		n[2] = 1
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		n[1] = 1
+	case taproot.IsPayToTaproot(pkScript):
+		n[3] = 1
+	default:
+		n[0] = 1
+	}
+	one := txsizes.EstimateVirtualSize(n[0], n[1], n[2], n[3], nil, false)
+	two := txsizes.EstimateVirtualSize(2*n[0], 2*n[1], 2*n[2], 2*n[3],
+		nil, false)
+	return two - one
+}
+
+// bnbSearch is the state of a branch and bound search over outputs sorted
+// by decreasing effective value.
+type bnbSearch struct {
+	outputs   []wtxmgr.Credit
+	effective []btcutil.Amount
+	target    btcutil.Amount
+	lower     btcutil.Amount
+	upper     btcutil.Amount
+	selected  []bool
+	tries     int
+}
+
+// search explores the selections of the outputs from index i, given the
+// effective and actual totals of the outputs selected before i and the
+// effective value of the outputs after it.
+func (s *bnbSearch) search(i int, effective, total, remaining btcutil.Amount) bool {
+	s.tries++
+	if s.tries > bnbMaxTries || effective > s.upper {
+		return false
+	}
+	if effective >= s.lower && total >= s.target {
+		return true
+	}
+	if i == len(s.outputs) || effective+remaining < s.lower {
+		return false
+	}
+
+	remaining -= s.effective[i]
+	s.selected[i] = true
+	if s.search(i+1, effective+s.effective[i], total+s.outputs[i].Amount,
+		remaining) {
+		return true
+	}
+	s.selected[i] = false
+	return s.search(i+1, effective, total, remaining)
+}
+
+// SelectCoins implements the CoinSelector interface.
+func (BranchAndBound) SelectCoins(eligible []wtxmgr.Credit, target,
+	feeSatPerKb btcutil.Amount) []wtxmgr.Credit {
+
+	fee := func(size int) btcutil.Amount {
+		return feeSatPerKb * btcutil.Amount(size) / 1000
+	}
+
+	// The target includes the fee of one P2WPKH input, which is instead
+	// deducted from the effective value of every output.  Without change,
+	// the amount above the target is added to the fee, so a match may
+	// exceed the target by the cost of the change output and of the
+	// input spending it.
+	p2wpkhInput := txsizes.EstimateVirtualSize(0, 2, 0, 0, nil, false) -
+		txsizes.EstimateVirtualSize(0, 1, 0, 0, nil, false)
+	s := &bnbSearch{
+		target: target,
+		lower:  target - fee(p2wpkhInput),
+	}
+	s.upper = s.lower + fee(txsizes.P2WPKHOutputSize+p2wpkhInput)
+
+	var remaining btcutil.Amount
+	for i := range eligible {
+		output := &eligible[i]
+		effective := output.Amount - fee(inputVirtualSize(output.PkScript))
+		if effective <= 0 {
+			continue
+		}
+		s.outputs = append(s.outputs, *output)
+		s.effective = append(s.effective, effective)
+		remaining += effective
+	}
+	sort.Sort(byEffectiveValue{s})
+	s.selected = make([]bool, len(s.outputs))
+
+	if !s.search(0, 0, 0, remaining) {
+		return LargestFirst{}.SelectCoins(eligible, target, feeSatPerKb)
+	}
+	var selected []wtxmgr.Credit
+	for i, ok := range s.selected {
+		if ok {
+			selected = append(selected, s.outputs[i])
+		}
+	}
+	return selected
+}
+
+// byEffectiveValue sorts the outputs of a search by decreasing effective
+// value.
+type byEffectiveValue struct{ s *bnbSearch }
+
+func (b byEffectiveValue) Len() int { return len(b.s.outputs) }
+func (b byEffectiveValue) Less(i, j int) bool {
+	return b.s.effective[i] > b.s.effective[j]
+}
+func (b byEffectiveValue) Swap(i, j int) {
+	b.s.outputs[i], b.s.outputs[j] = b.s.outputs[j], b.s.outputs[i]
+	b.s.effective[i], b.s.effective[j] = b.s.effective[j], b.s.effective[i]
+}
+
+// ChosenOutpoints is a CoinSelector for coin control, spending every one of
+// its outpoints and no other output.  Each outpoint must be an eligible
+// output of the account paying the transaction.
+type ChosenOutpoints []wire.OutPoint
+
+// SelectCoins implements the CoinSelector interface.
+func (c ChosenOutpoints) SelectCoins(eligible []wtxmgr.Credit, target,
+	feeSatPerKb btcutil.Amount) []wtxmgr.Credit {
+
+	chosen := make(map[wire.OutPoint]struct{}, len(c))
+	for _, op := range c {
+		chosen[op] = struct{}{}
+	}
+	var selected []wtxmgr.Credit
+	for i := range eligible {
+		if _, ok := chosen[eligible[i].OutPoint]; ok {
+			selected = append(selected, eligible[i])
+		}
+	}
+	return selected
+}
+
+// check returns an error unless every chosen outpoint is eligible.
+func (c ChosenOutpoints) check(eligible []wtxmgr.Credit) error {
+	if len(c) == 0 {
+		return errors.New("no outpoints chosen")
+	}
+	found := make(map[wire.OutPoint]struct{}, len(eligible))
+	for i := range eligible {
+		found[eligible[i].OutPoint] = struct{}{}
+	}
+	for _, op := range c {
+		if _, ok := found[op]; !ok {
+			return fmt.Errorf("output %v is not spendable", op)
+		}
+	}
+	return nil
+}
+
+// coinSelection is the coin selector of transactions which do not pick
+// their own.
+type coinSelection struct {
+	mu       sync.Mutex
+	selector CoinSelector
+}
+
+// SetCoinSelector configures the coin selector of the transactions created
+// without one.  A nil selector restores the default, OldestFirst.
+func (w *Wallet) SetCoinSelector(s CoinSelector) {
+	w.coinSelection.mu.Lock()
+	w.coinSelection.selector = s
+	w.coinSelection.mu.Unlock()
+}
+
+// CoinSelector returns the coin selector of the transactions created without
+// one.
+func (w *Wallet) CoinSelector() CoinSelector {
+	w.coinSelection.mu.Lock()
+	defer w.coinSelection.mu.Unlock()
+	if w.coinSelection.selector == nil {
+		return OldestFirst{}
+	}
+	return w.coinSelection.selector
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TestCoinSelectors checks the outputs picked by each coin selector.
+func TestCoinSelectors(t *testing.T) {
+	const feeSatPerKb = 1000
+
+	pkScript := make([]byte, 22)
+	pkScript[0], pkScript[1] = 0x00, 0x14
+	credit := func(index uint32, amount btcutil.Amount, height int32) wtxmgr.Credit {
+		var c wtxmgr.Credit
+		c.Index = index
+		c.Height = height
+		c.Amount = amount
+		c.PkScript = pkScript
+		return c
+	}
+	eligible := []wtxmgr.Credit{
+		credit(0, 500000, 10),
+		credit(1, 300000, 5),
+		credit(2, 210000, -1),
+		credit(3, 100000, 7),
+	}
+	inputFee := btcutil.Amount(feeSatPerKb * inputVirtualSize(pkScript) / 1000)
+
+	tests := []struct {
+		name     string
+		selector CoinSelector
+		target   btcutil.Amount
+		indexes  []uint32
+	}{
+		{"oldest first", OldestFirst{}, 250000, []uint32{2, 1}},
+		{"largest first", LargestFirst{}, 600000, []uint32{0, 1}},
+		{"insufficient", LargestFirst{}, 2e6, []uint32{0, 1, 2, 3}},
+		// Outputs 1 and 3 pay the target and the fee of their second
+		// input exactly, without change.
+		{"exact match", BranchAndBound{}, 400000 - inputFee,
+			[]uint32{1, 3}},
+		{"no match", BranchAndBound{}, 1e6, []uint32{0, 1, 2}},
+		{"chosen", ChosenOutpoints{{Index: 3}, {Index: 0}}, 1,
+			[]uint32{0, 3}},
+	}
+	for _, test := range tests {
+		selected := test.selector.SelectCoins(eligible, test.target,
+			feeSatPerKb)
+		if len(selected) != len(test.indexes) {
+			t.Errorf("%s: selected %d outputs, want %d", test.name,
+				len(selected), len(test.indexes))
+			continue
+		}
+		for i, c := range selected {
+			if c.Index != test.indexes[i] {
+				t.Errorf("%s: output %d is %d, want %d", test.name,
+					i, c.Index, test.indexes[i])
+			}
+		}
+	}
+
+	for _, name := range []string{CoinSelectionOldestFirst,
+		CoinSelectionLargestFirst, CoinSelectionBranchAndBound} {
+
+		if _, err := CoinSelectorByName(name); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := CoinSelectorByName("random"); err == nil {
+		t.Error("unknown coin selection was accepted")
+	}
+
+	if err := (ChosenOutpoints{{Index: 2}}).check(eligible); err != nil {
+		t.Errorf("eligible chosen outpoint: %v", err)
+	}
+	if err := (ChosenOutpoints{{Index: 4}}).check(eligible); err == nil {
+		t.Error("ineligible chosen outpoint was accepted")
+	}
+}
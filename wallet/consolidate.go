@@ -0,0 +1,675 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/internal/txsizes"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+const (
+	// consolidationCheckInterval is the interval between two checks of
+	// the estimated fee rate against the consolidation policy.
+	consolidationCheckInterval = 30 * time.Minute
+
+	// consolidationMinConf is the number of confirmations change outputs
+	// must have before they are consolidated.
+	consolidationMinConf = 6
+
+	// consolidationTarget is the number of blocks within which
+	// consolidation transactions are estimated to be mined.
+	consolidationTarget = 144
+
+	// DefaultConsolidationMaxInputs is the default maximum number of
+	// outputs spent by a consolidation transaction.
+	DefaultConsolidationMaxInputs = 100
+
+	// DefaultFragmentationThreshold is the default amount below which
+	// outputs fragment an account.
+	DefaultFragmentationThreshold = btcutil.Amount(1e4)
+
+	// DefaultFragmentationMinOutputs is the default number of outputs
+	// below the threshold from which an account is fragmented.
+	DefaultFragmentationMinOutputs = 20
+)
+
+// ErrNoFeeEstimate is returned when the chain server has no fee rate
+// estimate.
+var ErrNoFeeEstimate = errors.New("no fee rate estimate available")
+
+// FeeEstimator is implemented by chain clients which can estimate fee rates.
+type FeeEstimator interface {
+	// EstimateFeeRate returns the fee rate per kilobyte for a
+	// transaction to be mined within numBlocks blocks, or a negative
+	// rate when there is no estimate.
+	EstimateFeeRate(numBlocks int64) (btcutil.Amount, error)
+}
+
+// ConsolidationPolicy describes when change outputs are consolidated in the
+// background.  Legacy and segwit version 0 change outputs are consolidated
+// into taproot outputs of their account while the estimated fee rate is at
+// most MaxFeeRate, so that they are cheaper to spend later.
+type ConsolidationPolicy struct {
+	// MaxFeeRate is the fee rate per kilobyte at or below which change
+	// outputs are consolidated.
+	MaxFeeRate btcutil.Amount
+
+	// MaxInputs is the maximum number of outputs spent by one
+	// consolidation transaction.
+	MaxInputs int
+
+	// DryRun only reports the consolidations the policy would make.
+	DryRun bool
+}
+
+// FragmentationPolicy describes when fragmented accounts are consolidated in
+// the background.  An account is fragmented when it holds at least MinOutputs
+// confirmed outputs of a token worth less than Threshold.  Its smallest
+// outputs are then consolidated into a taproot output of the account while
+// the estimated fee rate is at most MaxFeeRate.
+type FragmentationPolicy struct {
+	// MaxFeeRate is the fee rate per kilobyte at or below which
+	// fragmented accounts are consolidated.
+	MaxFeeRate btcutil.Amount
+
+	// Threshold is the amount below which outputs fragment an account.
+	Threshold btcutil.Amount
+
+	// MinOutputs is the number of outputs below the threshold from which
+	// an account is fragmented.
+	MinOutputs int
+
+	// MaxInputs is the maximum number of outputs spent by one
+	// consolidation transaction.
+	MaxInputs int
+
+	// DryRun only reports the consolidations the policy would make.
+	DryRun bool
+}
+
+// Consolidation describes a transaction consolidating change outputs, or the
+// small outputs of a fragmented account, into a taproot output of the
+// account.
+type Consolidation struct {
+	Account uint32
+	Token   wire.TokenIdentity
+	Inputs  []wire.OutPoint
+	Amount  btcutil.Amount
+	Fee     btcutil.Amount
+
+	// Address and Hash are the taproot address paid by the transaction
+	// and its hash.  They are nil for dry runs and failed
+	// consolidations.
+	Address btcutil.Address
+	Hash    *chainhash.Hash
+
+	// Err is the error which prevented the consolidation, if any.
+	Err error
+}
+
+// ConsolidationReport describes the consolidations of a run of the
+// consolidation policy or of ConsolidateChange.
+type ConsolidationReport struct {
+	Time           time.Time
+	FeeRate        btcutil.Amount
+	DryRun         bool
+	Consolidations []Consolidation
+}
+
+// changeConsolidation holds the consolidation and fragmentation policies and
+// the report of the last consolidation.
+type changeConsolidation struct {
+	mu            sync.Mutex
+	policy        *ConsolidationPolicy
+	fragmentation *FragmentationPolicy
+	last          *ConsolidationReport
+}
+
+// SetConsolidationPolicy configures the consolidation of change outputs in
+// the background.  A nil policy disables it.
+func (w *Wallet) SetConsolidationPolicy(policy *ConsolidationPolicy) {
+	w.consolidation.mu.Lock()
+	defer w.consolidation.mu.Unlock()
+
+	if policy == nil {
+		w.consolidation.policy = nil
+		return
+	}
+	p := *policy
+	if p.MaxInputs <= 0 {
+		p.MaxInputs = DefaultConsolidationMaxInputs
+	}
+	w.consolidation.policy = &p
+}
+
+// SetFragmentationPolicy configures the consolidation of fragmented accounts
+// in the background.  A nil policy disables it.
+func (w *Wallet) SetFragmentationPolicy(policy *FragmentationPolicy) {
+	w.consolidation.mu.Lock()
+	defer w.consolidation.mu.Unlock()
+
+	if policy == nil {
+		w.consolidation.fragmentation = nil
+		return
+	}
+	p := *policy
+	if p.MaxInputs <= 0 {
+		p.MaxInputs = DefaultConsolidationMaxInputs
+	}
+	w.consolidation.fragmentation = &p
+}
+
+// LastConsolidationReport returns the report of the last consolidation, or
+// nil if change outputs were never consolidated since the wallet started.
+func (w *Wallet) LastConsolidationReport() *ConsolidationReport {
+	w.consolidation.mu.Lock()
+	defer w.consolidation.mu.Unlock()
+	return w.consolidation.last
+}
+
+// EstimateFeeRate returns the fee rate per kilobyte estimated by the chain
+// server for consolidation transactions.
+func (w *Wallet) EstimateFeeRate() (btcutil.Amount, error) {
+	return w.estimateFeeRate(consolidationTarget)
+}
+
+// consolidationMonitor periodically consolidates change outputs and
+// fragmented accounts when the estimated fee rate is low enough.  It must be
+// run as a goroutine.
+func (w *Wallet) consolidationMonitor() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(consolidationCheckInterval)
+	defer ticker.Stop()
+	quit := w.quitChan()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.applyConsolidationPolicy(); err != nil {
+				log.Errorf("Cannot consolidate change outputs: %v",
+					err)
+			}
+			if err := w.applyFragmentationPolicy(); err != nil {
+				log.Errorf("Cannot consolidate fragmented "+
+					"accounts: %v", err)
+			}
+		case <-quit:
+			return
+		}
+	}
+}
+
+// applyConsolidationPolicy consolidates change outputs if the estimated fee
+// rate is at most the maximum fee rate of the policy.
+func (w *Wallet) applyConsolidationPolicy() error {
+	w.consolidation.mu.Lock()
+	policy := w.consolidation.policy
+	w.consolidation.mu.Unlock()
+	if policy == nil {
+		return nil
+	}
+
+	feeRate, err := w.EstimateFeeRate()
+	if err != nil {
+		return err
+	}
+	if feeRate > policy.MaxFeeRate {
+		log.Debugf("Not consolidating change outputs: estimated fee "+
+			"rate %v/kB exceeds %v/kB", feeRate, policy.MaxFeeRate)
+		return nil
+	}
+	if !policy.DryRun && w.Manager.IsLocked() {
+		log.Debugf("Not consolidating change outputs: wallet is locked")
+		return nil
+	}
+
+	report, err := w.ConsolidateChange(feeRate, policy.MaxInputs,
+		policy.DryRun)
+	if err != nil {
+		return err
+	}
+	logConsolidations(report, "change outputs")
+	return nil
+}
+
+// applyFragmentationPolicy consolidates the fragmented accounts if the
+// estimated fee rate is at most the maximum fee rate of the fragmentation
+// policy.
+func (w *Wallet) applyFragmentationPolicy() error {
+	w.consolidation.mu.Lock()
+	policy := w.consolidation.fragmentation
+	w.consolidation.mu.Unlock()
+	if policy == nil {
+		return nil
+	}
+
+	feeRate, err := w.EstimateFeeRate()
+	if err != nil {
+		return err
+	}
+	if feeRate > policy.MaxFeeRate {
+		log.Debugf("Not consolidating fragmented accounts: estimated "+
+			"fee rate %v/kB exceeds %v/kB", feeRate,
+			policy.MaxFeeRate)
+		return nil
+	}
+	if !policy.DryRun && w.Manager.IsLocked() {
+		log.Debugf("Not consolidating fragmented accounts: wallet is " +
+			"locked")
+		return nil
+	}
+
+	report, err := w.ConsolidateFragmented(feeRate, policy.Threshold,
+		policy.MinOutputs, policy.MaxInputs, policy.DryRun)
+	if err != nil {
+		return err
+	}
+	logConsolidations(report, "outputs")
+	return nil
+}
+
+// logConsolidations logs the consolidations of a report, describing the
+// consolidated outputs with noun.
+func logConsolidations(report *ConsolidationReport, noun string) {
+	for i := range report.Consolidations {
+		c := &report.Consolidations[i]
+		switch {
+		case c.Err != nil:
+			log.Warnf("Cannot consolidate %d %s of account %d: %v",
+				len(c.Inputs), noun, c.Account, c.Err)
+		case report.DryRun:
+			log.Infof("Would consolidate %d %s of account %d "+
+				"(%v %v, fee %v)", len(c.Inputs), noun,
+				c.Account, c.Amount, c.Token, c.Fee)
+		default:
+			log.Infof("Consolidated %d %s of account %d (%v %v, "+
+				"fee %v) in transaction %v", len(c.Inputs), noun,
+				c.Account, c.Amount, c.Token, c.Fee, c.Hash)
+		}
+	}
+}
+
+// consolidationGroup is the change outputs of a token in an account.
+type consolidationGroup struct {
+	account uint32
+	token   wire.TokenIdentity
+	outputs []wtxmgr.Credit
+}
+
+// ConsolidateChange consolidates the confirmed legacy and segwit version 0
+// change outputs of every account into taproot outputs, paying the fee rate
+// per kilobyte.  Each transaction spends the outputs of one token of one
+// account, up to maxInputs of them.  With dryRun, the consolidations are only
+// reported.  Failed consolidations are reported with their error.
+func (w *Wallet) ConsolidateChange(feeRate btcutil.Amount, maxInputs int,
+	dryRun bool) (*ConsolidationReport, error) {
+
+	if maxInputs <= 0 {
+		maxInputs = DefaultConsolidationMaxInputs
+	}
+	groups, err := w.consolidationGroups(true)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ConsolidationReport{
+		Time:    time.Now(),
+		FeeRate: feeRate,
+		DryRun:  dryRun,
+	}
+	for _, g := range groups {
+		for len(g.outputs) != 0 {
+			n := len(g.outputs)
+			if n > maxInputs {
+				n = maxInputs
+			}
+			c := w.consolidate(g.account, g.token, g.outputs[:n],
+				feeRate, dryRun)
+			report.Consolidations = append(report.Consolidations, *c)
+			g.outputs = g.outputs[n:]
+		}
+	}
+	w.reportConsolidations(report)
+	return report, nil
+}
+
+// ConsolidateFragmented consolidates the fragmented accounts, which hold at
+// least minOutputs confirmed outputs of a token worth less than threshold,
+// paying the fee rate per kilobyte.  The smallest of these outputs are spent
+// to a taproot output of the account, up to maxInputs of them, in one
+// transaction per account and token.  With dryRun, the consolidations are only
+// reported.  Failed consolidations are reported with their error.
+func (w *Wallet) ConsolidateFragmented(feeRate, threshold btcutil.Amount,
+	minOutputs, maxInputs int, dryRun bool) (*ConsolidationReport, error) {
+
+	if maxInputs <= 0 {
+		maxInputs = DefaultConsolidationMaxInputs
+	}
+	groups, err := w.consolidationGroups(false)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ConsolidationReport{
+		Time:    time.Now(),
+		FeeRate: feeRate,
+		DryRun:  dryRun,
+	}
+	for _, g := range groups {
+		small := outputsBelow(g.outputs, threshold, len(g.outputs))
+		if len(small) < minOutputs || len(small) < 2 {
+			continue
+		}
+		if len(small) > maxInputs {
+			small = small[:maxInputs]
+		}
+		c := w.consolidate(g.account, g.token, small, feeRate, dryRun)
+		report.Consolidations = append(report.Consolidations, *c)
+	}
+	w.reportConsolidations(report)
+	return report, nil
+}
+
+// reportConsolidations records the report of the last consolidation and
+// notifies the consolidations which were not dry runs.
+func (w *Wallet) reportConsolidations(report *ConsolidationReport) {
+	w.consolidation.mu.Lock()
+	w.consolidation.last = report
+	w.consolidation.mu.Unlock()
+
+	if report.DryRun || w.NtfnServer == nil {
+		return
+	}
+	for i := range report.Consolidations {
+		c := report.Consolidations[i]
+		w.NtfnServer.notifyConsolidation(&c)
+	}
+}
+
+// consolidationGroups returns the outputs to consolidate, grouped by account
+// and token.  With changeOnly, only the legacy and segwit version 0 change
+// outputs are returned, and otherwise every output of the account keys.
+// Outputs of accounts with a Signer and of frozen accounts are not included.
+func (w *Wallet) consolidationGroups(changeOnly bool) ([]*consolidationGroup, error) {
+	type groupKey struct {
+		account uint32
+		token   wire.TokenIdentity
+	}
+	groups := make(map[groupKey]*consolidationGroup)
+
+	syncHeight := w.Manager.SyncedTo().Height
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+
+		unspent, err := w.TxStore.UnspentOutputs(txmgrNs, nil)
+		if err != nil {
+			return err
+		}
+		for i := range unspent {
+			output := &unspent[i]
+			if output.FromCoinBase ||
+				!confirmed(consolidationMinConf, output.Height, syncHeight) ||
+				w.LockedOutpoint(output.OutPoint) ||
+				isWatchedScript(dbtx, output.PkScript) ||
+				isQuarantined(dbtx, &output.OutPoint) ||
+				changeOnly && taproot.IsPayToTaproot(output.PkScript) {
+				continue
+			}
+			_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+				output.PkScript, w.chainParams)
+			if err != nil || len(addrs) != 1 {
+				continue
+			}
+			ma, err := w.Manager.Address(addrmgrNs, addrs[0])
+			if err != nil || changeOnly && !ma.Internal() ||
+				ma.Imported() {
+				continue
+			}
+			if _, ok := ma.(waddrmgr.ManagedPubKeyAddress); !ok {
+				continue
+			}
+			account := ma.Account()
+			if w.AccountSigner(account) != nil ||
+				w.checkAccountFrozen(account) != nil {
+				continue
+			}
+
+			key := groupKey{account, wire.TokenID(output.PkScript)}
+			g, ok := groups[key]
+			if !ok {
+				g = &consolidationGroup{
+					account: key.account,
+					token:   key.token,
+				}
+				groups[key] = g
+			}
+			g.outputs = append(g.outputs, *output)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]*consolidationGroup, 0, len(groups))
+	for _, g := range groups {
+		sort.Slice(g.outputs, func(i, j int) bool {
+			return g.outputs[i].Amount > g.outputs[j].Amount
+		})
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].account != sorted[j].account {
+			return sorted[i].account < sorted[j].account
+		}
+		return sorted[i].token.String() < sorted[j].token.String()
+	})
+	return sorted, nil
+}
+
+// consolidate spends outputs of a token of an account to a new taproot change
+// address of the account.
+func (w *Wallet) consolidate(account uint32, token wire.TokenIdentity,
+	outputs []wtxmgr.Credit, feeRate btcutil.Amount, dryRun bool) *Consolidation {
+
+	c := &Consolidation{Account: account, Token: token}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	prevScripts := make([][]byte, 0, len(outputs))
+	inputValues := make([]btcutil.Amount, 0, len(outputs))
+	var numP2PKH, numP2WPKH, numNested, numP2TR int
+	for i := range outputs {
+		output := &outputs[i]
+		switch {
+		case txscript.IsPayToWitnessPubKeyHash(output.PkScript):
+			numP2WPKH++
+		case txscript.IsPayToScriptHash(output.PkScript):
+			numNested++
+		case taproot.IsPayToTaproot(output.PkScript):
+			numP2TR++
+		default:
+			numP2PKH++
+		}
+		op := output.OutPoint
+		tx.AddTxIn(wire.NewTxIn(&op, nil, nil))
+		prevScripts = append(prevScripts, output.PkScript)
+		inputValues = append(inputValues, output.Amount)
+		c.Inputs = append(c.Inputs, op)
+		c.Amount += output.Amount
+	}
+
+	// The size of the taproot output does not depend on its key, so the
+	// fee is known before deriving the address.
+	placeholder, err := taproot.PayToTaprootScript(make([]byte, 32))
+	if err != nil {
+		c.Err = err
+		return c
+	}
+	size := txsizes.EstimateVirtualSize(numP2PKH, numP2WPKH, numNested,
+		numP2TR, []*wire.TxOut{wire.NewTxOutToken(0, placeholder, token)},
+		false)
+	c.Fee = txrules.FeeForSerializeSize(feeRate, size)
+	if txrules.IsDustAmount(c.Amount-c.Fee, len(placeholder), feeRate) {
+		c.Err = fmt.Errorf("consolidated amount %v does not cover the "+
+			"fee %v", c.Amount, c.Fee)
+		return c
+	}
+	if dryRun {
+		return c
+	}
+
+	addr, err := w.newTaprootChangeAddress(account)
+	if err != nil {
+		c.Err = err
+		return c
+	}
+	pkScript, err := taproot.PayToAddrScript(addr)
+	if err != nil {
+		c.Err = err
+		return c
+	}
+	tx.AddTxOut(wire.NewTxOutToken(int64(c.Amount-c.Fee), pkScript, token))
+
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+		return txauthor.AddAllInputScripts(tx, prevScripts, inputValues,
+			secretSource{w.Manager, addrmgrNs, scriptNs, w})
+	})
+	if err == nil {
+		err = validateMsgTx(tx, prevScripts, inputValues)
+	}
+	if err != nil {
+		c.Err = err
+		return c
+	}
+
+	c.Hash, c.Err = w.publishTransaction(tx)
+	if c.Err == nil {
+		c.Address = addr
+	}
+	return c
+}
+
+// newTaprootChangeAddress returns the next internal address of the BIP0086
+// scope for an account, and registers it with the chain server.
+func (w *Wallet) newTaprootChangeAddress(account uint32) (btcutil.Address, error) {
+	manager, err := w.Manager.FetchScopedKeyManager(waddrmgr.KeyScopeBIP0086)
+	if err != nil {
+		return nil, err
+	}
+
+	var addr btcutil.Address
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		addrs, err := manager.NextInternalAddresses(addrmgrNs, account, 1)
+		if err != nil {
+			return err
+		}
+		addr = addrs[0].Address()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if chainClient := w.ChainClient(); chainClient != nil {
+		err := chainClient.NotifyReceived([]btcutil.Address{addr})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return addr, nil
+}
+
+// ConsolidateUTXOs spends the unspent outputs of token of an account worth
+// less than threshold, the smallest first and at most maxInputs of them, to a
+// single change address of the account, paying feeSatPerKb.  It reduces the
+// number of small outputs while fees are low, so that later transactions
+// spend fewer inputs.  The consolidation is described like an account sweep
+// with a single destination.
+func (w *Wallet) ConsolidateUTXOs(account uint32, token wire.TokenIdentity,
+	threshold btcutil.Amount, maxInputs int, minconf int32,
+	feeSatPerKb btcutil.Amount) (*AccountSweep, btcutil.Address, error) {
+
+	if threshold <= 0 {
+		return nil, nil, errors.New("consolidation threshold must be " +
+			"positive")
+	}
+	if maxInputs <= 0 {
+		maxInputs = DefaultConsolidationMaxInputs
+	}
+
+	// As with change, the outputs of the imported account are
+	// consolidated to an address of account 0.
+	changeAccount := account
+	if changeAccount == waddrmgr.ImportedAddrAccount {
+		changeAccount = 0
+	}
+	var addr btcutil.Address
+	err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		addrmgrNs := dbtx.ReadWriteBucket(waddrmgrNamespaceKey)
+		var err error
+		addr, err = w.changeAddress(addrmgrNs, changeAccount,
+			w.ChangePolicy())
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if chainClient := w.ChainClient(); chainClient != nil {
+		err := chainClient.NotifyReceived([]btcutil.Address{addr})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	req := createTxRequest{
+		account:     account,
+		minconf:     minconf,
+		feeSatPerKB: feeSatPerKb,
+		sweep: &sweepRequest{
+			token:     token,
+			splits:    []SweepSplit{{Address: addr, Share: SweepSplitTotal}},
+			below:     threshold,
+			maxInputs: maxInputs,
+		},
+		resp: make(chan createTxResponse),
+	}
+	w.createTxRequests <- req
+	resp := <-req.resp
+	if resp.err != nil {
+		return nil, nil, resp.err
+	}
+	tx := resp.tx
+
+	txHash, err := w.publishTransaction(tx.Tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	amount := btcutil.Amount(tx.Tx.TxOut[0].Value)
+	return &AccountSweep{
+		Hash:    *txHash,
+		Amounts: []btcutil.Amount{amount},
+		Fee:     tx.TotalInput - amount,
+		Inputs:  len(tx.Tx.TxIn),
+	}, addr, nil
+}
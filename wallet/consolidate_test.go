@@ -0,0 +1,107 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TestConsolidateDryRun checks that dry runs report the fee of consolidating
+// change outputs without spending them, and that outputs which do not cover
+// the fee are not consolidated.
+func TestConsolidateDryRun(t *testing.T) {
+	pkScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(make([]byte, 20)).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	credit := func(i byte, amount btcutil.Amount) wtxmgr.Credit {
+		return wtxmgr.Credit{
+			OutPoint: wire.OutPoint{Hash: chainhash.Hash{i}},
+			Amount:   amount,
+			PkScript: pkScript,
+		}
+	}
+	w := &Wallet{}
+
+	const feeRate = 1000
+	outputs := []wtxmgr.Credit{credit(1, 1e6), credit(2, 2e6)}
+	c := w.consolidate(1, wire.STB, outputs, feeRate, true)
+	if c.Err != nil {
+		t.Fatal(c.Err)
+	}
+	if c.Account != 1 || len(c.Inputs) != 2 || c.Amount != 3e6 {
+		t.Errorf("unexpected consolidation %+v", c)
+	}
+	// Two P2WPKH inputs and a taproot output weigh about 180 vbytes.
+	if c.Fee < 150 || c.Fee > 250 {
+		t.Errorf("fee %v, want about 180 satoshis", c.Fee)
+	}
+	if c.Address != nil || c.Hash != nil {
+		t.Error("dry run sent a transaction")
+	}
+
+	c = w.consolidate(1, wire.STB, []wtxmgr.Credit{credit(3, 300)},
+		feeRate, true)
+	if c.Err == nil {
+		t.Error("output not covering the fee was consolidated")
+	}
+
+	w.SetConsolidationPolicy(&ConsolidationPolicy{MaxFeeRate: feeRate})
+	if w.consolidation.policy.MaxInputs != DefaultConsolidationMaxInputs {
+		t.Errorf("max inputs %d, want the default",
+			w.consolidation.policy.MaxInputs)
+	}
+}
+
+// TestReportConsolidations checks that consolidations are notified to
+// subscribed clients unless they are dry runs.
+func TestReportConsolidations(t *testing.T) {
+	w := &Wallet{}
+	w.NtfnServer = newNotificationServer(w)
+	client := w.NtfnServer.ConsolidationNotifications()
+	defer client.Done()
+
+	received := make(chan *ConsolidationNotification, 2)
+	go func() {
+		for n := range client.C {
+			received <- n
+		}
+	}()
+
+	w.reportConsolidations(&ConsolidationReport{
+		DryRun:         true,
+		Consolidations: []Consolidation{{Account: 1}},
+	})
+	report := &ConsolidationReport{
+		Consolidations: []Consolidation{{Account: 2, Amount: 1e6}},
+	}
+	w.reportConsolidations(report)
+	if n := <-received; n.Account != 2 || n.Amount != 1e6 {
+		t.Errorf("unexpected notification %+v", n)
+	}
+	select {
+	case n := <-received:
+		t.Errorf("dry run was notified: %+v", n)
+	default:
+	}
+	if w.LastConsolidationReport() != report {
+		t.Error("last consolidation report was not recorded")
+	}
+
+	w.SetFragmentationPolicy(&FragmentationPolicy{MaxFeeRate: 1000})
+	if w.consolidation.fragmentation.MaxInputs !=
+		DefaultConsolidationMaxInputs {
+
+		t.Errorf("max inputs %d, want the default",
+			w.consolidation.fragmentation.MaxInputs)
+	}
+}
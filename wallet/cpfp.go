@@ -0,0 +1,216 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/internal/txsizes"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// CPFPBump describes a child transaction spending an output of an unmined
+// transaction with a fee raising the fee rate of both to a target, so that
+// miners including the child also include its parent.
+type CPFPBump struct {
+	Parent    chainhash.Hash
+	Hash      chainhash.Hash
+	ParentFee btcutil.Amount
+	Fee       btcutil.Amount
+}
+
+// cpfpFee returns the fee of a child transaction of childSize virtual bytes
+// bringing the fee rate of the package with its parent of parentSize virtual
+// bytes, paying parentFee, up to feeSatPerKb.  The child pays at least the
+// fee rate on its own.
+func cpfpFee(parentFee btcutil.Amount, parentSize, childSize int,
+	feeSatPerKb btcutil.Amount) btcutil.Amount {
+
+	packageFee := txrules.FeeForSerializeSize(feeSatPerKb,
+		parentSize+childSize)
+	childFee := txrules.FeeForSerializeSize(feeSatPerKb, childSize)
+	if packageFee-parentFee < childFee {
+		return childFee
+	}
+	return packageFee - parentFee
+}
+
+// BumpFeeCPFP raises the effective fee rate of an unmined transaction paying
+// the wallet, such as an incoming transaction or a send which does not signal
+// replaceability, by spending one of its outputs to the wallet with a fee
+// large enough for the fee rate of both transactions to reach feeSatPerKb, or
+// the wallet's fee rate when it is zero.  The unspent change output of the
+// transaction is spent when there is one, and its largest unspent credit
+// otherwise.  The fee of transactions spending outputs of other wallets is
+// unknown and taken to be zero, so the child pays for the whole package.
+func (w *Wallet) BumpFeeCPFP(txHash *chainhash.Hash,
+	feeSatPerKb btcutil.Amount) (*CPFPBump, error) {
+
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return nil, err
+	}
+	if feeSatPerKb == 0 {
+		feeSatPerKb = w.FeeRate(0)
+	}
+
+	var details *wtxmgr.TxDetails
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		var err error
+		details, err = w.TxStore.TxDetails(
+			dbtx.ReadBucket(wtxmgrNamespaceKey), txHash,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if details == nil {
+		return nil, fmt.Errorf("no wallet transaction %v", txHash)
+	}
+	if details.Block.Height != -1 {
+		return nil, fmt.Errorf("transaction %v is already mined", txHash)
+	}
+
+	var credit *wtxmgr.CreditRecord
+	for i := range details.Credits {
+		c := &details.Credits[i]
+		op := wire.OutPoint{Hash: *txHash, Index: c.Index}
+		if c.Spent || w.LockedOutpoint(op) {
+			continue
+		}
+		if credit == nil || (c.Change && !credit.Change) ||
+			(c.Change == credit.Change && c.Amount > credit.Amount) {
+
+			credit = c
+		}
+	}
+	if credit == nil {
+		return nil, fmt.Errorf("transaction %v has no unspent output "+
+			"to the wallet", txHash)
+	}
+
+	var parentFee btcutil.Amount
+	if len(details.Debits) == len(details.MsgTx.TxIn) {
+		for _, d := range details.Debits {
+			parentFee += d.Amount
+		}
+		for _, txOut := range details.MsgTx.TxOut {
+			parentFee -= btcutil.Amount(txOut.Value)
+		}
+	}
+	weight := blockchain.GetTransactionWeight(btcutil.NewTx(&details.MsgTx))
+	parentSize := int((weight + blockchain.WitnessScaleFactor - 1) /
+		blockchain.WitnessScaleFactor)
+
+	prevOut := details.MsgTx.TxOut[credit.Index]
+	var numP2PKH, numP2WPKH, numNested, numP2TR int
+	switch {
+	case txscript.IsPayToScriptHash(prevOut.PkScript):
+		numNested++
+	case txscript.IsPayToWitnessPubKeyHash(prevOut.PkScript):
+		numP2WPKH++
+	case taproot.IsPayToTaproot(prevOut.PkScript):
+		numP2TR++
+	default:
+		numP2PKH++
+	}
+
+	tx := &txauthor.AuthoredTx{
+		Tx:              wire.NewMsgTx(wire.TxVersion),
+		PrevScripts:     [][]byte{prevOut.PkScript},
+		PrevInputValues: []btcutil.Amount{credit.Amount},
+		TotalInput:      credit.Amount,
+		ChangeIndex:     0,
+	}
+	op := wire.NewOutPoint(txHash, credit.Index)
+	tx.Tx.AddTxIn(wire.NewTxIn(op, nil, nil))
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		addrmgrNs := dbtx.ReadWriteBucket(waddrmgrNamespaceKey)
+
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+			prevOut.PkScript, w.chainParams)
+		if err != nil || len(addrs) != 1 {
+			return fmt.Errorf("output %v does not pay an address", op)
+		}
+		account, err := w.addrAccount(dbtx, addrs[0])
+		if err != nil {
+			return err
+		}
+		if account == waddrmgr.ImportedAddrAccount {
+			account = 0
+		}
+		changeAddr, err := w.changeAddress(addrmgrNs, account,
+			w.ChangePolicy())
+		if err != nil {
+			return err
+		}
+		err = chainClient.NotifyReceived([]btcutil.Address{changeAddr})
+		if err != nil {
+			return err
+		}
+		pkScript, err := taproot.PayToAddrScript(changeAddr)
+		if err != nil {
+			return err
+		}
+		output := wire.NewTxOutToken(0, pkScript, prevOut.TokenID())
+
+		childSize := txsizes.EstimateVirtualSize(numP2PKH, numP2WPKH,
+			numNested, numP2TR, []*wire.TxOut{output}, false)
+		fee := cpfpFee(parentFee, parentSize, childSize, feeSatPerKb)
+		amount := credit.Amount - fee
+		if amount <= 0 || txrules.IsDustAmount(amount,
+			len(pkScript), feeSatPerKb) {
+
+			return fmt.Errorf("output %v of %v cannot pay the fee %v",
+				op, credit.Amount, fee)
+		}
+		output.Value = int64(amount)
+		tx.Tx.AddTxOut(output)
+
+		replaceable := w.ReplaceableByDefault()
+		setReplaceable(tx.Tx, replaceable)
+		childHash := tx.Tx.TxHash()
+		err = putReplaceable(dbtx.ReadWriteBucket(wtxmetaNamespaceKey),
+			&childHash, replaceable)
+		if err != nil {
+			return err
+		}
+
+		scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+		return tx.AddAllInputScripts(secretSource{w.Manager, addrmgrNs, scriptNs, w})
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = validateMsgTx(tx.Tx, tx.PrevScripts, tx.PrevInputValues)
+	if err != nil {
+		return nil, err
+	}
+
+	childHash, err := w.publishTransaction(tx.Tx)
+	if err != nil {
+		return nil, err
+	}
+	fee := credit.Amount - btcutil.Amount(tx.Tx.TxOut[0].Value)
+	log.Infof("Spent output %v with fee %v to bump the fee of transaction "+
+		"%v at %v/kB", op, fee, txHash, feeSatPerKb)
+	return &CPFPBump{
+		Parent:    *txHash,
+		Hash:      *childHash,
+		ParentFee: parentFee,
+		Fee:       fee,
+	}, nil
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+)
+
+// TestCPFPFee checks that child transactions pay for the fee missing from the
+// package with their parent, and at least the fee rate on their own.
+func TestCPFPFee(t *testing.T) {
+	tests := []struct {
+		name                  string
+		parentFee             btcutil.Amount
+		parentSize, childSize int
+		feeRate               btcutil.Amount
+		want                  btcutil.Amount
+	}{
+		{"unknown parent fee", 0, 200, 110, 10000, 3100},
+		{"low parent fee", 200, 200, 110, 10000, 2900},
+		{"parent fee over target", 5000, 200, 110, 10000, 1100},
+	}
+	for _, test := range tests {
+		got := cpfpFee(test.parentFee, test.parentSize, test.childSize,
+			test.feeRate)
+		if got != test.want {
+			t.Errorf("%s: fee is %v, want %v", test.name, got,
+				test.want)
+		}
+	}
+}
@@ -6,8 +6,8 @@
 package wallet
 
 import (
+	"errors"
 	"fmt"
-	"sort"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/btcjson"
@@ -15,7 +15,9 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/internal/helpers"
+	"github.com/btcsuite/btcwallet/internal/taproot"
 	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/psbt"
 	"github.com/btcsuite/btcwallet/wallet/txauthor"
 	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/btcsuite/btcwallet/wtxmgr"
@@ -29,38 +31,40 @@ func (s byHeight) Len() int           { return len(s) }
 func (s byHeight) Less(i, j int) bool { return s[i].Height < s[j].Height }
 func (s byHeight) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
-func makeInputSource(eligible []wtxmgr.Credit) txauthor.InputSource {
-	// Pick oldest outputs first.
-	sort.Sort(byHeight(eligible))
-
-	// Current inputs and their total value.  These are closed over by the
-	// returned input source and reused across multiple calls.
-	currentTotal := btcutil.Amount(0)
-	currentInputs := make([]*wire.TxIn, 0, len(eligible))
-	currentScripts := make([][]byte, 0, len(eligible))
-	currentInputValues := make([]btcutil.Amount, 0, len(eligible))
+// makeInputSource returns an input source spending the outputs of eligible
+// picked by selector.
+func makeInputSource(eligible []wtxmgr.Credit, selector CoinSelector,
+	feeSatPerKb btcutil.Amount) txauthor.InputSource {
 
 	return func(target btcutil.Amount) (btcutil.Amount, []*wire.TxIn,
 		[]btcutil.Amount, [][]byte, error) {
 
-		for currentTotal < target && len(eligible) != 0 {
-			nextCredit := &eligible[0]
-			eligible = eligible[1:]
-			nextInput := wire.NewTxIn(&nextCredit.OutPoint, nil, nil)
-			currentTotal += nextCredit.Amount
-			currentInputs = append(currentInputs, nextInput)
-			currentScripts = append(currentScripts, nextCredit.PkScript)
-			currentInputValues = append(currentInputValues, nextCredit.Amount)
+		selected := selector.SelectCoins(eligible, target, feeSatPerKb)
+		currentTotal := btcutil.Amount(0)
+		currentInputs := make([]*wire.TxIn, 0, len(selected))
+		currentScripts := make([][]byte, 0, len(selected))
+		currentInputValues := make([]btcutil.Amount, 0, len(selected))
+		for i := range selected {
+			credit := &selected[i]
+			currentTotal += credit.Amount
+			currentInputs = append(currentInputs,
+				wire.NewTxIn(&credit.OutPoint, nil, nil))
+			currentScripts = append(currentScripts, credit.PkScript)
+			currentInputValues = append(currentInputValues, credit.Amount)
 		}
 		return currentTotal, currentInputs, currentInputValues, currentScripts, nil
 	}
 }
 
 // secretSource is an implementation of txauthor.SecretSource for the wallet's
-// address manager.
+// address manager and script store.
 type secretSource struct {
 	*waddrmgr.Manager
 	addrmgrNs walletdb.ReadBucket
+	scriptNs  walletdb.ReadBucket
+
+	// w counts the signatures of the keys returned by GetKey.
+	w *Wallet
 }
 
 func (s secretSource) GetKey(addr btcutil.Address) (*btcec.PrivateKey, bool, error) {
@@ -68,6 +72,9 @@ func (s secretSource) GetKey(addr btcutil.Address) (*btcec.PrivateKey, bool, err
 	if err != nil {
 		return nil, false, err
 	}
+	if err := s.w.checkAccountFrozen(ma.Account()); err != nil {
+		return nil, false, err
+	}
 
 	mpka, ok := ma.(waddrmgr.ManagedPubKeyAddress)
 	if !ok {
@@ -79,10 +86,15 @@ func (s secretSource) GetKey(addr btcutil.Address) (*btcec.PrivateKey, bool, err
 	if err != nil {
 		return nil, false, err
 	}
+	s.w.recordKeyUse(ma)
 	return privKey, ma.Compressed(), nil
 }
 
 func (s secretSource) GetScript(addr btcutil.Address) ([]byte, error) {
+	if script, err := fetchScript(s.scriptNs, addr); err == nil {
+		return script, nil
+	}
+
 	ma, err := s.Address(s.addrmgrNs, addr)
 	if err != nil {
 		return nil, err
@@ -97,13 +109,110 @@ func (s secretSource) GetScript(addr btcutil.Address) ([]byte, error) {
 	return msa.Script()
 }
 
-// txToOutputs creates a signed transaction which includes each output from
+// TxOptions are the options of a transaction created by the wallet.  Options
+// left unset, like those of a nil *TxOptions, follow the wallet's policies.
+type TxOptions struct {
+	// CoinSelector picks the outputs spent by the transaction instead of
+	// the wallet's coin selector.
+	CoinSelector CoinSelector
+
+	// Replaceable, when set, chooses whether the transaction signals
+	// BIP0125 replaceability instead of the wallet's default.
+	Replaceable *bool
+
+	// SubtractFeeFrom are the indexes of the outputs paying the fee of the
+	// transaction, in proportion to their values, instead of the inputs.
+	SubtractFeeFrom []int
+
+	// ChangeAddress, when set, receives the change of the transaction
+	// instead of an internal address of the wallet.  It must be a P2WPKH
+	// address.
+	ChangeAddress btcutil.Address
+
+	// ChangeAccount, when set, is the account whose internal address
+	// receives the change instead of the account spending its outputs.
+	ChangeAccount *uint32
+
+	// ChangePolicy, when set, chooses the internal address receiving the
+	// change instead of the wallet's change policy.
+	ChangePolicy ChangePolicy
+
+	// LockTime, when set, is the lock time of the transaction instead of
+	// the height of the current block chosen by anti-fee-sniping.  It is a
+	// block height below 500000000 and a timestamp otherwise, as spending
+	// outputs locked with OP_CHECKLOCKTIMEVERIFY requires.
+	LockTime *uint32
+
+	// OverrideFeeCap creates the transaction even when its fee exceeds
+	// the wallet's fee cap.
+	OverrideFeeCap bool
+}
+
+// coinSelector returns the coin selector of a transaction.
+func (o *TxOptions) coinSelector(w *Wallet) CoinSelector {
+	if o == nil || o.CoinSelector == nil {
+		return w.CoinSelector()
+	}
+	return o.CoinSelector
+}
+
+// subtractFeeFrom returns the indexes of the outputs paying the fee of a
+// transaction.
+func (o *TxOptions) subtractFeeFrom() []int {
+	if o == nil {
+		return nil
+	}
+	return o.SubtractFeeFrom
+}
+
+// changePolicy returns the change policy of a transaction.
+func (o *TxOptions) changePolicy(w *Wallet) ChangePolicy {
+	if o == nil || o.ChangePolicy == "" {
+		return w.ChangePolicy()
+	}
+	return o.ChangePolicy
+}
+
+// replaceable returns whether a transaction signals replaceability.
+func (o *TxOptions) replaceable(w *Wallet) bool {
+	if o == nil || o.Replaceable == nil {
+		return w.ReplaceableByDefault()
+	}
+	return *o.Replaceable
+}
+
+// lockTime returns the lock time of a transaction created at the block of
+// height.
+func (o *TxOptions) lockTime(w *Wallet, height int32) uint32 {
+	if o == nil || o.LockTime == nil {
+		return w.antiFeeSnipingLockTime(height)
+	}
+	return *o.LockTime
+}
+
+// overrideFeeCap returns whether a transaction is created even when its fee
+// exceeds the fee cap.
+func (o *TxOptions) overrideFeeCap() bool {
+	return o != nil && o.OverrideFeeCap
+}
+
+// txToOutputs creates a transaction which includes each output from
 // outputs.  Previous outputs to reedeem are chosen from the passed account's
 // UTXO set and minconf policy. An additional output may be added to return
 // change to the wallet.  An appropriate fee is included based on the wallet's
-// current relay fee.  The wallet must be unlocked to create the transaction.
+// current relay fee.  The spent outputs and the replaceability of the
+// transaction follow opts.  When sign is set, the inputs are signed and the
+// wallet must be unlocked to create the transaction, unless the account has a
+// Signer.  With dryRun, the transaction is only previewed: no change address
+// is derived, transaction hooks are not invoked and nothing is recorded.
 func (w *Wallet) txToOutputs(outputs []*wire.TxOut, account uint32,
-	minconf int32, feeSatPerKb btcutil.Amount) (tx *txauthor.AuthoredTx, err error) {
+	minconf int32, feeSatPerKb btcutil.Amount, opts *TxOptions,
+	sign, dryRun bool) (tx *txauthor.AuthoredTx, err error) {
+
+	if err := w.checkAccountFrozen(account); err != nil {
+		return nil, err
+	}
+	selector := opts.coinSelector(w)
 
 	// sign of an order
 	var orderAmount int64
@@ -112,6 +221,14 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, account uint32,
 		outputs = outputs[:len(outputs)-1]
 	}
 
+	if err := w.checkPoolDestinations(account, outputs); err != nil {
+		return nil, err
+	}
+	dust := w.DustPolicy()
+	if err := dust.checkDustOutputs(outputs); err != nil {
+		return nil, err
+	}
+
 	token, ok := helpers.GetSingleToken(outputs)
 	if !ok {
 		return nil, &btcjson.RPCError{
@@ -120,16 +237,35 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, account uint32,
 		}
 	}
 
-	chainClient, err := w.requireChainClient()
+	chainClient, err := w.optionalChainClient()
 	if err != nil {
 		return nil, err
 	}
 
+	var signer Signer
+	var packet *psbt.Packet
+	if sign {
+		signer = w.AccountSigner(account)
+	}
+
+	hookEvent := &TxHookEvent{
+		Point:   HookBeforeCoinSelection,
+		Account: account,
+		Outputs: outputs,
+	}
+	if !dryRun {
+		if err := w.runTxHooks(hookEvent); err != nil {
+			return nil, err
+		}
+	}
+
+	var feeCapOverride error
+	var replaceable bool
 	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
 		addrmgrNs := dbtx.ReadWriteBucket(waddrmgrNamespaceKey)
 
 		// Get current block's height and hash.
-		bs, err := chainClient.BlockStamp()
+		bs, err := w.blockStamp(chainClient)
 		if err != nil {
 			return err
 		}
@@ -138,35 +274,29 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, account uint32,
 		if err != nil {
 			return err
 		}
-
-		inputSource := makeInputSource(eligible)
-		changeSource := func() ([]byte, error) {
-			// Derive the change output script.  As a hack to allow
-			// spending from the imported account, change addresses
-			// are created from account 0.
-			var changeAddr btcutil.Address
-			var err error
-			if account == waddrmgr.ImportedAddrAccount {
-				changeAddr, err = w.newChangeAddress(addrmgrNs, 0)
-			} else {
-				changeAddr, err = w.newChangeAddress(addrmgrNs, account)
-			}
-			if err != nil {
-				return nil, err
-			}
-
-			if chainClient := w.ChainClient(); chainClient != nil {
-				// Notify the rpc server about the newly created address.
-				err = chainClient.NotifyReceived([]btcutil.Address{changeAddr})
-				if err != nil {
-					return nil, err
-				}
+		if chosen, ok := selector.(ChosenOutpoints); ok {
+			if err := chosen.check(eligible); err != nil {
+				return err
 			}
+		}
 
-			return txscript.PayToAddrScript(changeAddr)
+		inputSource := makeInputSource(eligible, selector, feeSatPerKb)
+		changeSource := w.changeSource(addrmgrNs, account, opts)
+		if dryRun {
+			changeSource = previewChangeSource(opts)
+		}
+		scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+		scriptSource := secretSource{w.Manager, addrmgrNs, scriptNs, w}
+		tx, err = txauthor.NewUnsignedTransactionSubtractFee(outputs,
+			feeSatPerKb, inputSource, changeSource, scriptSource,
+			opts.subtractFeeFrom())
+		if err != nil {
+			return err
+		}
+		if err := dust.applyDustChange(tx); err != nil {
+			return err
 		}
-		tx, err = txauthor.NewUnsignedTransaction(outputs, feeSatPerKb,
-			inputSource, changeSource)
+		feeCapOverride, err = w.checkFeeCap(dbtx, tx, token, opts)
 		if err != nil {
 			return err
 		}
@@ -184,17 +314,85 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, account uint32,
 			tx.RandomizeChangePosition()
 		}
 
-		return tx.AddAllInputScripts(secretSource{w.Manager, addrmgrNs})
+		// Orders are never replaceable.
+		replaceable = orderAmount == 0 && opts.replaceable(w)
+		setReplaceable(tx.Tx, replaceable)
+
+		// Transactions signed to be published must be final in the
+		// next block.
+		lockTime := opts.lockTime(w, bs.Height)
+		if sign && isLockTimeHeight(lockTime) &&
+			int32(lockTime) > bs.Height {
+
+			return fmt.Errorf("transaction can not be mined before "+
+				"block %d", lockTime+1)
+		}
+		setLockTime(tx.Tx, lockTime)
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	err = validateMsgTx(tx.Tx, tx.PrevScripts, tx.PrevInputValues)
-	if err != nil {
-		return nil, err
+	if !dryRun {
+		// The hooks before signing may run external programs calling
+		// back into the wallet, so they run between the database
+		// transactions creating and signing the transaction.
+		hookEvent.Point = HookBeforeSigning
+		hookEvent.Outputs = nil
+		hookEvent.Tx = tx.Tx
+		if err := w.runTxHooks(hookEvent); err != nil {
+			return nil, err
+		}
+
+		err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+			txHash := tx.Tx.TxHash()
+			err := putReplaceable(dbtx.ReadWriteBucket(wtxmetaNamespaceKey),
+				&txHash, replaceable)
+			if err != nil {
+				return err
+			}
+
+			if !sign {
+				return nil
+			}
+			if signer != nil {
+				packet, err = w.newSignerPsbt(dbtx, tx.Tx)
+				return err
+			}
+			addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+			scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+			return tx.AddAllInputScripts(secretSource{w.Manager,
+				addrmgrNs, scriptNs, w})
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	if signer != nil {
+		if err := signWithSigner(signer, packet, tx.Tx); err != nil {
+			return nil, err
+		}
+		txHash := tx.Tx.TxHash()
+		w.recordSigner(signer, &txHash)
+	}
+
+	if sign {
+		err = validateMsgTx(tx.Tx, tx.PrevScripts, tx.PrevInputValues)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if dryRun {
+		return tx, nil
+	}
+	if feeCapOverride != nil {
+		w.auditFeeCapOverride(feeCapOverride)
+	}
+
+	w.saveTxAnnotations(tx.Tx.TxHash(), hookEvent.Annotations)
+
 	if tx.ChangeIndex >= 0 && account == waddrmgr.ImportedAddrAccount {
 		changeAmount := btcutil.Amount(tx.Tx.TxOut[tx.ChangeIndex].Value)
 		log.Warnf("Spend from imported account produced change: moving"+
@@ -204,8 +402,48 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, account uint32,
 	return tx, nil
 }
 
+// changeSource returns a change source deriving the change addresses of
+// transactions spending outputs of account, following opts.
+func (w *Wallet) changeSource(addrmgrNs walletdb.ReadWriteBucket,
+	account uint32, opts *TxOptions) txauthor.ChangeSource {
+
+	return func() ([]byte, error) {
+		if opts != nil && opts.ChangeAddress != nil {
+			if err := checkChangeAddress(opts.ChangeAddress); err != nil {
+				return nil, err
+			}
+			return taproot.PayToAddrScript(opts.ChangeAddress)
+		}
+
+		// Derive the change output script.  As a hack to allow
+		// spending from the imported account, change addresses
+		// are created from account 0.
+		changeAccount := account
+		if opts != nil && opts.ChangeAccount != nil {
+			changeAccount = *opts.ChangeAccount
+		}
+		if changeAccount == waddrmgr.ImportedAddrAccount {
+			changeAccount = 0
+		}
+		changeAddr, err := w.changeAddress(addrmgrNs, changeAccount,
+			opts.changePolicy(w))
+		if err != nil {
+			return nil, err
+		}
+
+		if chainClient := w.ChainClient(); chainClient != nil {
+			// Notify the rpc server about the newly created address.
+			err = chainClient.NotifyReceived([]btcutil.Address{changeAddr})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return taproot.PayToAddrScript(changeAddr)
+	}
+}
+
 func (w *Wallet) findEligibleOutputs(dbtx walletdb.ReadTx, account uint32, token wire.TokenIdentity, minconf int32, bs *waddrmgr.BlockStamp) ([]wtxmgr.Credit, error) {
-	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
 	txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
 
 	unspent, err := w.TxStore.UnspentOutputs(txmgrNs, &token)
@@ -218,6 +456,7 @@ func (w *Wallet) findEligibleOutputs(dbtx walletdb.ReadTx, account uint32, token
 	// Because one of these filters requires matching the output script to
 	// the desired account, this change depends on making wtxmgr a waddrmgr
 	// dependancy and requesting unspent outputs for a single account.
+	spendQuarantined := w.quarantineSpendable()
 	eligible := make([]wtxmgr.Credit, 0, len(unspent))
 	for i := range unspent {
 		output := &unspent[i]
@@ -235,22 +474,32 @@ func (w *Wallet) findEligibleOutputs(dbtx walletdb.ReadTx, account uint32, token
 			}
 		}
 
-		// Locked unspent outputs are skipped.
+		// Locked unspent outputs are skipped, as are outputs to
+		// watch-only addresses which the wallet cannot sign for.
 		if w.LockedOutpoint(output.OutPoint) {
 			continue
 		}
+		if isWatchedScript(dbtx, output.PkScript) {
+			continue
+		}
+
+		// Quarantined dust is only spent when allowed by the
+		// quarantine policy.
+		if !spendQuarantined && isQuarantined(dbtx, &output.OutPoint) {
+			continue
+		}
 
 		// Only include the output if it is associated with the passed
 		// account.
 		//
 		// TODO: Handle multisig outputs by determining if enough of the
 		// addresses are controlled.
-		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(
 			output.PkScript, w.chainParams)
 		if err != nil || len(addrs) != 1 {
 			continue
 		}
-		_, addrAcct, err := w.Manager.AddrAccount(addrmgrNs, addrs[0])
+		addrAcct, err := w.addrAccount(dbtx, addrs[0])
 		if err != nil || addrAcct != account {
 			continue
 		}
@@ -265,6 +514,15 @@ func (w *Wallet) findEligibleOutputs(dbtx walletdb.ReadTx, account uint32, token
 func validateMsgTx(tx *wire.MsgTx, prevScripts [][]byte, inputValues []btcutil.Amount) error {
 	hashCache := txscript.NewTxSigHashes(tx)
 	for i, prevScript := range prevScripts {
+		// The script engine predates taproot, so key path spends are
+		// checked against the output key directly.
+		if taproot.IsPayToTaproot(prevScript) {
+			err := validateTaprootInput(tx, i, prevScripts, inputValues)
+			if err != nil {
+				return fmt.Errorf("cannot validate transaction: %s", err)
+			}
+			continue
+		}
 		vm, err := txscript.NewEngine(prevScript, tx, i,
 			txscript.StandardVerifyFlags, nil, hashCache, int64(inputValues[i]))
 		if err != nil {
@@ -277,3 +535,40 @@ func validateMsgTx(tx *wire.MsgTx, prevScripts [][]byte, inputValues []btcutil.A
 	}
 	return nil
 }
+
+// validateTaprootInput verifies the key path spend of the p2tr output
+// redeemed by input idx of tx.
+func validateTaprootInput(tx *wire.MsgTx, idx int, prevScripts [][]byte,
+	inputValues []btcutil.Amount) error {
+
+	witness := tx.TxIn[idx].Witness
+	if len(witness) != 1 {
+		return errors.New("taproot input is not a key path spend")
+	}
+	sig := witness[0]
+	hashType := taproot.SigHashDefault
+	switch len(sig) {
+	case taproot.SignatureSize:
+	case taproot.SignatureSize + 1:
+		hashType = txscript.SigHashType(sig[taproot.SignatureSize])
+		sig = sig[:taproot.SignatureSize]
+	default:
+		return errors.New("invalid taproot signature length")
+	}
+
+	prevOuts := make([]taproot.PrevOutput, len(prevScripts))
+	for i, prevScript := range prevScripts {
+		prevOuts[i] = taproot.PrevOutput{
+			Value:    int64(inputValues[i]),
+			PkScript: prevScript,
+		}
+	}
+	hash, err := taproot.CalcSignatureHash(tx, idx, hashType, prevOuts)
+	if err != nil {
+		return err
+	}
+	if !taproot.Verify(prevScripts[idx][2:], hash, sig) {
+		return errors.New("invalid taproot signature")
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// Address types of derivation proofs.
+const (
+	ProofAddressP2PKH      = "p2pkh"
+	ProofAddressP2SHP2WPKH = "p2sh-p2wpkh"
+	ProofAddressP2WPKH     = "p2wpkh"
+	ProofAddressP2TR       = "p2tr"
+)
+
+// DerivationStep is a BIP0032 public child key derivation.  The tweak is the
+// left half of HMAC-SHA512(ChainCode, ParentKey || ser32(Index)), and its
+// right half is the child chain code.  The child key is the parent key plus
+// the tweak times the generator point.  Keys are compressed public keys.
+type DerivationStep struct {
+	Index          uint32
+	ParentKey      []byte
+	ChainCode      []byte
+	Tweak          []byte
+	ChildKey       []byte
+	ChildChainCode []byte
+}
+
+// DerivationProof proves that an address is derived from the extended public
+// key of an account, so that auditors and counterparties can verify that the
+// address belongs to the account without trusting the wallet.  The steps
+// derive the branch key from the account key, and the address key from the
+// branch key.
+type DerivationProof struct {
+	Address     string
+	AddressType string
+	AccountXPub string
+	AccountPath []uint32
+	Branch      uint32
+	Index       uint32
+	Steps       []DerivationStep
+
+	// OutputKey is the x-only taproot output key committing to the
+	// address key, for p2tr addresses.
+	OutputKey []byte
+}
+
+// DerivationProof returns the proof that an HD address of the wallet is
+// derived from the extended public key of its account.  Imported addresses
+// have no proof.
+func (w *Wallet) DerivationProof(addr btcutil.Address) (*DerivationProof, error) {
+	proof := &DerivationProof{Address: addr.EncodeAddress()}
+	var acctKey *hdkeychain.ExtendedKey
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		ma, err := w.Manager.Address(addrmgrNs, addr)
+		if err != nil {
+			return err
+		}
+		mpka, ok := ma.(waddrmgr.ManagedPubKeyAddress)
+		if !ok || ma.Imported() {
+			return fmt.Errorf("address %v is not derived from an "+
+				"account key", proof.Address)
+		}
+		scope, path, ok := mpka.DerivationInfo()
+		if !ok {
+			return fmt.Errorf("address %v has no derivation path",
+				proof.Address)
+		}
+		switch ma.AddrType() {
+		case waddrmgr.PubKeyHash:
+			proof.AddressType = ProofAddressP2PKH
+		case waddrmgr.NestedWitnessPubKey:
+			proof.AddressType = ProofAddressP2SHP2WPKH
+		case waddrmgr.WitnessPubKey:
+			proof.AddressType = ProofAddressP2WPKH
+		case waddrmgr.TaprootPubKey:
+			proof.AddressType = ProofAddressP2TR
+		default:
+			return fmt.Errorf("address %v has unsupported type %v",
+				proof.Address, ma.AddrType())
+		}
+
+		manager, err := w.Manager.FetchScopedKeyManager(scope)
+		if err != nil {
+			return err
+		}
+		proof.AccountPath, err = manager.AccountDerivationPath(
+			addrmgrNs, path.Account)
+		if err != nil {
+			return err
+		}
+		proof.Branch, proof.Index = path.Branch, path.Index
+		acctKey, err = manager.AccountExtendedPubKey(addrmgrNs,
+			path.Account)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	proof.AccountXPub = acctKey.String()
+	key := acctKey
+	for _, index := range []uint32{proof.Branch, proof.Index} {
+		var step DerivationStep
+		step, key, err = deriveStep(key, index)
+		if err != nil {
+			return nil, err
+		}
+		proof.Steps = append(proof.Steps, step)
+	}
+	if proof.AddressType == ProofAddressP2TR {
+		pubKey, err := key.ECPubKey()
+		if err != nil {
+			return nil, err
+		}
+		proof.OutputKey, err = taproot.ComputeOutputKey(pubKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return proof, nil
+}
+
+// Verify checks that the proof derives its address from the account key, on
+// the network of params.  It only relies on the account key of the proof.
+func (p *DerivationProof) Verify(params *chaincfg.Params) error {
+	key, err := hdkeychain.NewKeyFromString(p.AccountXPub)
+	if err != nil {
+		return err
+	}
+	if key.IsPrivate() {
+		return errors.New("account key is private")
+	}
+	if !key.IsForNet(params) {
+		return errors.New("account key is for another network")
+	}
+	indexes := []uint32{p.Branch, p.Index}
+	if len(p.Steps) != len(indexes) {
+		return fmt.Errorf("proof has %d derivation steps, want %d",
+			len(p.Steps), len(indexes))
+	}
+	for i, index := range indexes {
+		var step DerivationStep
+		step, key, err = deriveStep(key, index)
+		if err != nil {
+			return err
+		}
+		if !step.equal(&p.Steps[i]) {
+			return fmt.Errorf("derivation step %d does not match", i)
+		}
+	}
+
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return err
+	}
+	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+	var addr btcutil.Address
+	switch p.AddressType {
+	case ProofAddressP2PKH:
+		addr, err = btcutil.NewAddressPubKeyHash(pubKeyHash, params)
+	case ProofAddressP2WPKH:
+		addr, err = btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+	case ProofAddressP2SHP2WPKH:
+		var witAddr btcutil.Address
+		witAddr, err = btcutil.NewAddressWitnessPubKeyHash(pubKeyHash,
+			params)
+		if err != nil {
+			return err
+		}
+		var witnessProgram []byte
+		witnessProgram, err = txscript.PayToAddrScript(witAddr)
+		if err != nil {
+			return err
+		}
+		addr, err = btcutil.NewAddressScriptHash(witnessProgram, params)
+	case ProofAddressP2TR:
+		var outputKey []byte
+		outputKey, err = taproot.ComputeOutputKey(pubKey)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(outputKey, p.OutputKey) {
+			return errors.New("taproot output key does not match")
+		}
+		addr, err = taproot.NewAddressTaproot(outputKey, params)
+	default:
+		return fmt.Errorf("unknown address type %q", p.AddressType)
+	}
+	if err != nil {
+		return err
+	}
+	if addr.EncodeAddress() != p.Address {
+		return fmt.Errorf("derived address %v does not match %v",
+			addr.EncodeAddress(), p.Address)
+	}
+	return nil
+}
+
+// deriveStep derives the public child key of an extended key at index, and
+// returns the step with the inputs and outputs of the derivation.
+func deriveStep(parent *hdkeychain.ExtendedKey, index uint32) (DerivationStep,
+	*hdkeychain.ExtendedKey, error) {
+
+	if index >= hdkeychain.HardenedKeyStart {
+		return DerivationStep{}, nil, fmt.Errorf("hardened index %d "+
+			"can't be derived from a public key", index)
+	}
+	chainCode, parentKey, err := extendedKeyParts(parent)
+	if err != nil {
+		return DerivationStep{}, nil, err
+	}
+	child, err := parent.Child(index)
+	if err != nil {
+		return DerivationStep{}, nil, err
+	}
+	childChainCode, childKey, err := extendedKeyParts(child)
+	if err != nil {
+		return DerivationStep{}, nil, err
+	}
+
+	var data [33 + 4]byte
+	copy(data[:], parentKey)
+	binary.BigEndian.PutUint32(data[33:], index)
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data[:])
+	tweak := mac.Sum(nil)[:32]
+
+	return DerivationStep{
+		Index:          index,
+		ParentKey:      parentKey,
+		ChainCode:      chainCode,
+		Tweak:          tweak,
+		ChildKey:       childKey,
+		ChildChainCode: childChainCode,
+	}, child, nil
+}
+
+// extendedKeyParts returns the chain code and compressed public key of an
+// extended public key, read from its BIP0032 serialization.
+func extendedKeyParts(key *hdkeychain.ExtendedKey) (chainCode, pubKey []byte,
+	err error) {
+
+	// The serialization is the version (4 bytes), depth (1 byte), parent
+	// fingerprint (4 bytes), child number (4 bytes), chain code (32
+	// bytes), key (33 bytes) and checksum (4 bytes).
+	serialized := base58.Decode(key.String())
+	if len(serialized) != 4+1+4+4+32+33+4 {
+		return nil, nil, errors.New("malformed extended key")
+	}
+	return serialized[13:45], serialized[45:78], nil
+}
+
+// equal returns whether two derivation steps are the same.
+func (s *DerivationStep) equal(o *DerivationStep) bool {
+	return s.Index == o.Index &&
+		bytes.Equal(s.ParentKey, o.ParentKey) &&
+		bytes.Equal(s.ChainCode, o.ChainCode) &&
+		bytes.Equal(s.Tweak, o.Tweak) &&
+		bytes.Equal(s.ChildKey, o.ChildKey) &&
+		bytes.Equal(s.ChildChainCode, o.ChildChainCode)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+)
+
+// TestDerivationProof checks that derivation proofs verify the address derived
+// from the account key, and that altered proofs are refused.
+func TestDerivationProof(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	seed := bytes.Repeat([]byte{0x01}, hdkeychain.RecommendedSeedLen)
+	master, err := hdkeychain.NewMaster(seed, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acctKey := master
+	for _, index := range []uint32{44, 0, 0} {
+		acctKey, err = acctKey.Child(hdkeychain.HardenedKeyStart + index)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	acctKey, err = acctKey.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof := &DerivationProof{
+		AddressType: ProofAddressP2PKH,
+		AccountXPub: acctKey.String(),
+		Branch:      0,
+		Index:       5,
+	}
+	key := acctKey
+	for _, index := range []uint32{proof.Branch, proof.Index} {
+		var step DerivationStep
+		step, key, err = deriveStep(key, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof.Steps = append(proof.Steps, step)
+	}
+	addr, err := key.Address(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof.Address = addr.EncodeAddress()
+	if !bytes.Equal(proof.Steps[1].ParentKey, proof.Steps[0].ChildKey) {
+		t.Fatal("derivation steps are not chained")
+	}
+	if err := proof.Verify(params); err != nil {
+		t.Fatalf("valid proof refused: %v", err)
+	}
+
+	if err := proof.Verify(&chaincfg.TestNet3Params); err == nil {
+		t.Error("proof verified on another network")
+	}
+	proof.Steps[1].Tweak[0] ^= 0xff
+	if err := proof.Verify(params); err == nil {
+		t.Error("proof with an altered tweak verified")
+	}
+	proof.Steps[1].Tweak[0] ^= 0xff
+	proof.Index = 6
+	if err := proof.Verify(params); err == nil {
+		t.Error("proof of another index verified")
+	}
+	proof.Index = 5
+
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trAddr, err := taproot.NewAddressTaprootFromPubKey(pubKey, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof.AddressType = ProofAddressP2TR
+	proof.Address = trAddr.EncodeAddress()
+	proof.OutputKey = trAddr.OutputKey()
+	if err := proof.Verify(params); err != nil {
+		t.Errorf("valid taproot proof refused: %v", err)
+	}
+}
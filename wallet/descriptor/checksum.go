@@ -0,0 +1,102 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// inputCharset is the set of characters that may appear in a descriptor.
+// The checksum encodes the position of each character in groups of 32,
+// which keeps the most common typing errors within one group.
+const inputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+	"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+	"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+
+// checksumCharset is the bech32 character set used to encode checksums.
+const checksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// checksumLen is the number of characters of a descriptor checksum.
+const checksumLen = 8
+
+// polymod is the BCH code generator of the descriptor checksum.
+func polymod(c uint64, val int) uint64 {
+	c0 := c >> 35
+	c = ((c & 0x7ffffffff) << 5) ^ uint64(val)
+	if c0&1 != 0 {
+		c ^= 0xf5dee51989
+	}
+	if c0&2 != 0 {
+		c ^= 0xa9fdca3312
+	}
+	if c0&4 != 0 {
+		c ^= 0x1bab10e32d
+	}
+	if c0&8 != 0 {
+		c ^= 0x3706b1677a
+	}
+	if c0&16 != 0 {
+		c ^= 0x644d626ffd
+	}
+	return c
+}
+
+// Checksum returns the BIP0380 checksum of a descriptor without its
+// checksum suffix.
+func Checksum(desc string) (string, error) {
+	c := uint64(1)
+	cls, clsCount := 0, 0
+	for i := 0; i < len(desc); i++ {
+		pos := strings.IndexByte(inputCharset, desc[i])
+		if pos == -1 {
+			return "", fmt.Errorf("invalid character %q in descriptor",
+				desc[i])
+		}
+		c = polymod(c, pos&31)
+		cls = cls*3 + pos>>5
+		clsCount++
+		if clsCount == 3 {
+			c = polymod(c, cls)
+			cls, clsCount = 0, 0
+		}
+	}
+	if clsCount > 0 {
+		c = polymod(c, cls)
+	}
+	for i := 0; i < checksumLen; i++ {
+		c = polymod(c, 0)
+	}
+	c ^= 1
+
+	var sum [checksumLen]byte
+	for i := range sum {
+		sum[i] = checksumCharset[(c>>uint(5*(checksumLen-1-i)))&31]
+	}
+	return string(sum[:]), nil
+}
+
+// splitChecksum splits the optional checksum suffix from a descriptor and
+// verifies it.
+func splitChecksum(desc string) (string, error) {
+	i := strings.LastIndexByte(desc, '#')
+	if i == -1 {
+		return desc, nil
+	}
+	desc, sum := desc[:i], desc[i+1:]
+	if len(sum) != checksumLen {
+		return "", fmt.Errorf("descriptor checksum %q must be %d "+
+			"characters", sum, checksumLen)
+	}
+	want, err := Checksum(desc)
+	if err != nil {
+		return "", err
+	}
+	if sum != want {
+		return "", fmt.Errorf("descriptor checksum mismatch: got %s, "+
+			"expected %s", sum, want)
+	}
+	return desc, nil
+}
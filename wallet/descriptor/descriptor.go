@@ -0,0 +1,357 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package descriptor parses the output script descriptors (BIP0380) that
+// other wallets export to describe a single-key account: the address type,
+// the account extended public key and the origin of that key.
+package descriptor
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// Type is the address type of a descriptor.
+type Type uint8
+
+// These constants define the supported descriptor types.
+const (
+	// PubKeyHash is a pkh(KEY) descriptor of P2PKH addresses.
+	PubKeyHash Type = iota
+
+	// NestedWitnessPubKeyHash is a sh(wpkh(KEY)) descriptor of P2WPKH
+	// addresses nested in P2SH.
+	NestedWitnessPubKeyHash
+
+	// WitnessPubKeyHash is a wpkh(KEY) descriptor of P2WPKH addresses.
+	WitnessPubKeyHash
+
+	// Taproot is a tr(KEY) descriptor of key path only P2TR addresses.
+	Taproot
+)
+
+// scriptExprs maps the script expression wrapping the key of each
+// descriptor type to the type and the BIP0043 purpose of its accounts.
+var scriptExprs = []struct {
+	prefix, suffix string
+	typ            Type
+	purpose        uint32
+}{
+	{"pkh(", ")", PubKeyHash, 44},
+	{"sh(wpkh(", "))", NestedWitnessPubKeyHash, 49},
+	{"wpkh(", ")", WitnessPubKeyHash, 84},
+	{"tr(", ")", Taproot, 86},
+}
+
+// ErrUnsupported is returned for valid descriptors which do not describe a
+// single-key account.
+var ErrUnsupported = errors.New("unsupported descriptor")
+
+// Descriptor is a parsed single-key account descriptor.
+type Descriptor struct {
+	// Type is the address type of the descriptor.
+	Type Type
+
+	// HasOrigin is whether the descriptor has a key origin.  When it
+	// does, Fingerprint and Path describe where AccountKey was derived
+	// from.
+	HasOrigin bool
+
+	// Fingerprint is the fingerprint of the master key of the origin.
+	Fingerprint [4]byte
+
+	// Path is the derivation path of the origin from the master key.
+	Path []uint32
+
+	// AccountKey is the account extended public key.
+	AccountKey *hdkeychain.ExtendedKey
+
+	// Branches are the branches of the account key described by the
+	// descriptor: the external branch, the internal branch or both.
+	Branches []uint32
+}
+
+// Parse parses a descriptor of the form
+//
+//   wpkh([d34db33f/84'/0'/0']xpub.../<0;1>/*)#checksum
+//
+// The script expression is one of pkh, sh(wpkh), wpkh or tr with a single
+// key.  The key must be an account extended public key for the passed
+// network, followed by the external branch /0/*, the internal branch /1/*
+// or both as /<0;1>/*.  The key origin and checksum are optional, but the
+// checksum is verified when it is present.
+func Parse(desc string, params *chaincfg.Params) (*Descriptor, error) {
+	desc, err := splitChecksum(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	d := new(Descriptor)
+	var keyExpr string
+	var purpose uint32
+	for _, expr := range scriptExprs {
+		if strings.HasPrefix(desc, expr.prefix) &&
+			strings.HasSuffix(desc, expr.suffix) {
+
+			keyExpr = desc[len(expr.prefix) : len(desc)-len(expr.suffix)]
+			d.Type = expr.typ
+			purpose = expr.purpose
+			break
+		}
+	}
+	if keyExpr == "" || strings.ContainsAny(keyExpr, "(),") {
+		return nil, ErrUnsupported
+	}
+
+	if strings.HasPrefix(keyExpr, "[") {
+		end := strings.IndexByte(keyExpr, ']')
+		if end == -1 {
+			return nil, errors.New("unterminated key origin")
+		}
+		err := d.parseOrigin(keyExpr[1:end])
+		if err != nil {
+			return nil, err
+		}
+		keyExpr = keyExpr[end+1:]
+	}
+
+	parts := strings.SplitN(keyExpr, "/", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("descriptor key must be followed by " +
+			"a ranged derivation path")
+	}
+	switch parts[1] {
+	case "0/*":
+		d.Branches = []uint32{waddrmgr.ExternalBranch}
+	case "1/*":
+		d.Branches = []uint32{waddrmgr.InternalBranch}
+	case "<0;1>/*":
+		d.Branches = []uint32{
+			waddrmgr.ExternalBranch, waddrmgr.InternalBranch,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported derivation path /%s: "+
+			"must be /0/*, /1/* or /<0;1>/*", parts[1])
+	}
+
+	d.AccountKey, err = hdkeychain.NewKeyFromString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	if d.AccountKey.IsPrivate() {
+		return nil, errors.New("descriptor key must be an extended " +
+			"public key")
+	}
+	if !d.AccountKey.IsForNet(params) {
+		return nil, fmt.Errorf("descriptor key is not for %s",
+			params.Name)
+	}
+	if d.AccountKey.Depth() != 3 {
+		return nil, fmt.Errorf("descriptor key at depth %d is not an "+
+			"account key", d.AccountKey.Depth())
+	}
+
+	if d.HasOrigin {
+		if len(d.Path) != 3 {
+			return nil, fmt.Errorf("key origin path has %d "+
+				"elements, expected purpose'/coin'/account'",
+				len(d.Path))
+		}
+		for _, index := range d.Path {
+			if index < hdkeychain.HardenedKeyStart {
+				return nil, errors.New("key origin path must " +
+					"be hardened")
+			}
+		}
+		if d.Path[0] != purpose+hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("key origin purpose %d does "+
+				"not match descriptor type, expected %d",
+				d.Path[0]-hdkeychain.HardenedKeyStart, purpose)
+		}
+
+		// Account keys are imported as the default account of their
+		// scope, so derivation paths reported to signers are only
+		// correct for account 0.
+		if d.Path[2] != hdkeychain.HardenedKeyStart {
+			return nil, errors.New("only account 0 descriptors " +
+				"are supported")
+		}
+	}
+
+	return d, nil
+}
+
+// parseOrigin parses the fingerprint/path key origin of a descriptor.
+func (d *Descriptor) parseOrigin(origin string) error {
+	parts := strings.SplitN(origin, "/", 2)
+	fingerprint, err := hex.DecodeString(parts[0])
+	if err != nil || len(fingerprint) != len(d.Fingerprint) {
+		return fmt.Errorf("invalid key origin fingerprint %q", parts[0])
+	}
+	copy(d.Fingerprint[:], fingerprint)
+
+	if len(parts) == 2 {
+		d.Path, err = ParsePath(parts[1])
+		if err != nil {
+			return err
+		}
+	}
+	d.HasOrigin = true
+	return nil
+}
+
+// ParsePath parses a BIP0032 derivation path such as m/84'/0'/0', where
+// hardened elements are marked with ', h or H.  The leading m/ is optional.
+func ParsePath(path string) ([]uint32, error) {
+	path = strings.TrimPrefix(path, "m/")
+	if path == "" || path == "m" {
+		return nil, nil
+	}
+
+	var indexes []uint32
+	for _, elem := range strings.Split(path, "/") {
+		hardened := strings.HasSuffix(elem, "'") ||
+			strings.HasSuffix(elem, "h") || strings.HasSuffix(elem, "H")
+		if hardened {
+			elem = elem[:len(elem)-1]
+		}
+		index, err := strconv.ParseUint(elem, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path element "+
+				"%q", elem)
+		}
+		if hardened {
+			index += hdkeychain.HardenedKeyStart
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+// FormatPath formats a BIP0032 derivation path as parsed by ParsePath, with
+// hardened elements marked with '.
+func FormatPath(path []uint32) string {
+	var s strings.Builder
+	s.WriteString("m")
+	for _, index := range path {
+		if index >= hdkeychain.HardenedKeyStart {
+			fmt.Fprintf(&s, "/%d'", index-hdkeychain.HardenedKeyStart)
+		} else {
+			fmt.Fprintf(&s, "/%d", index)
+		}
+	}
+	return s.String()
+}
+
+// purpose returns the BIP0043 purpose of the descriptor type.
+func (d *Descriptor) purpose() uint32 {
+	for _, expr := range scriptExprs {
+		if expr.typ == d.Type {
+			return expr.purpose
+		}
+	}
+	return 0
+}
+
+// Scope returns the key scope of the account described by the descriptor.
+// The coin type is taken from the key origin and is otherwise 0, which is
+// the coin type of the default key scopes.
+func (d *Descriptor) Scope() waddrmgr.KeyScope {
+	scope := waddrmgr.KeyScope{Purpose: d.purpose()}
+	if d.HasOrigin {
+		scope.Coin = d.Path[1] - hdkeychain.HardenedKeyStart
+	}
+	return scope
+}
+
+// AddrSchema returns the address schema of the default key scope with the
+// purpose of the descriptor.
+func (d *Descriptor) AddrSchema() waddrmgr.ScopeAddrSchema {
+	purpose := d.purpose()
+	for scope, schema := range waddrmgr.ScopeAddrMap {
+		if scope.Purpose == purpose {
+			return schema
+		}
+	}
+	return waddrmgr.ScopeAddrSchema{}
+}
+
+// Address derives the address at an index of a branch of the descriptor.
+func (d *Descriptor) Address(branch, index uint32, params *chaincfg.Params) (btcutil.Address, error) {
+	branchKey, err := d.AccountKey.Child(branch)
+	if err != nil {
+		return nil, err
+	}
+	key, err := branchKey.Child(index)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+
+	switch d.Type {
+	case PubKeyHash:
+		return btcutil.NewAddressPubKeyHash(pubKeyHash, params)
+
+	case NestedWitnessPubKeyHash:
+		witnessAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+			pubKeyHash, params)
+		if err != nil {
+			return nil, err
+		}
+		witnessScript, err := txscript.PayToAddrScript(witnessAddr)
+		if err != nil {
+			return nil, err
+		}
+		return btcutil.NewAddressScriptHash(witnessScript, params)
+
+	case WitnessPubKeyHash:
+		return btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+
+	case Taproot:
+		return taproot.NewAddressTaprootFromPubKey(pubKey, params)
+
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+// String returns the descriptor with its checksum.
+func (d *Descriptor) String() string {
+	var key strings.Builder
+	if d.HasOrigin {
+		key.WriteString("[" + hex.EncodeToString(d.Fingerprint[:]))
+		key.WriteString(strings.TrimPrefix(FormatPath(d.Path), "m"))
+		key.WriteString("]")
+	}
+	key.WriteString(d.AccountKey.String())
+	if len(d.Branches) == 1 {
+		fmt.Fprintf(&key, "/%d/*", d.Branches[0])
+	} else {
+		key.WriteString("/<0;1>/*")
+	}
+
+	var desc string
+	for _, expr := range scriptExprs {
+		if expr.typ == d.Type {
+			desc = expr.prefix + key.String() + expr.suffix
+			break
+		}
+	}
+	sum, _ := Checksum(desc)
+	return desc + "#" + sum
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// Account 0 keys derived from the seed 000102...1f with master key
+// fingerprint 5a3469b6.
+const (
+	xpubBIP0084 = "xpub6DEHh42YXj7gdKbP1zxehEzQt47iW2AuUHYsqGaRxtkEGrK3bCYh" +
+		"2bsw1H6WUW26k9TBdQoe6gZ8ydoAP5eGAC2fDJGmFkwXcgv5feY9N7p"
+	tpubBIP0049 = "tpubDCeNbbPT93BKAKCc1Zq4DrPxx8HQBT6LELe6byhsDELifQjgmwsg" +
+		"tm1SYBRjiw8QD5SDLu4D9rgU2Jzqxp3FQSiSxESGTx8U4Et4US15AbV"
+	xpubBIP0086 = "xpub6Cx47kkB7dkMy515HJa3WH2iRSqqScxnsstoSqF1NEyjXKC7N2vT" +
+		"BqVjx1LZAb6hVhEdunJYTxNShqgo9rZ4DEV7rWGazkkzck7vjxjKdLu"
+	xpubMaster = "xpub661MyMwAqRbcFiyme9xRZe855HWfYxvTcYoWpX1E8ZW8DGu35Dbt" +
+		"hdTxz222XRihFsxrdH4BCEe32DBRyKEerW8CUMAB8FDziiNyDG4ecgT"
+	xprvMaster = "xprv9s21ZrQH143K3EuJY8RRCWBLXFgB9WCcFKsv28bcaDy9LUZtXgHe" +
+		"9q9V8kLi4aJ6H8r5X2wu9gz2ZYXbAhtsAcJKX8Z1Ackw6Wq1oi8DEEk"
+)
+
+// TestChecksum checks descriptor checksums against the BIP0380 test vector
+// and the reference implementation.
+func TestChecksum(t *testing.T) {
+	tests := []struct {
+		desc string
+		sum  string
+	}{
+		{"raw(deadbeef)", "89f8spxm"},
+		{"wpkh([5a3469b6/84'/0'/0']" + xpubBIP0084 + "/<0;1>/*)", "k48u2ed6"},
+		{"tr(" + xpubBIP0086 + "/1/*)", "lq8t2zjy"},
+	}
+	for _, test := range tests {
+		sum, err := Checksum(test.desc)
+		if err != nil {
+			t.Fatalf("Checksum(%s): %v", test.desc, err)
+		}
+		if sum != test.sum {
+			t.Errorf("Checksum(%s) = %s, want %s", test.desc, sum,
+				test.sum)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	desc := "sh(wpkh([5a3469b6/49h/1'/0']" + tpubBIP0049 + "/0/*))#lt7mcngs"
+	d, err := Parse(desc, &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if d.Type != NestedWitnessPubKeyHash {
+		t.Errorf("type %v, want %v", d.Type, NestedWitnessPubKeyHash)
+	}
+	if !d.HasOrigin || d.Fingerprint != [4]byte{0x5a, 0x34, 0x69, 0xb6} {
+		t.Errorf("fingerprint %x, want 5a3469b6", d.Fingerprint)
+	}
+	if len(d.Branches) != 1 || d.Branches[0] != waddrmgr.ExternalBranch {
+		t.Errorf("branches %v, want external branch", d.Branches)
+	}
+	scope := waddrmgr.KeyScope{Purpose: 49, Coin: 1}
+	if d.Scope() != scope {
+		t.Errorf("scope %v, want %v", d.Scope(), scope)
+	}
+	schema := waddrmgr.ScopeAddrMap[waddrmgr.KeyScopeBIP0049Plus]
+	if d.AddrSchema() != schema {
+		t.Errorf("address schema %v, want %v", d.AddrSchema(), schema)
+	}
+
+	// The hardened marker is normalized, so the checksum changes.
+	want := "sh(wpkh([5a3469b6/49'/1'/0']" + tpubBIP0049 + "/0/*))#"
+	sum, _ := Checksum(want[:len(want)-1])
+	if d.String() != want+sum {
+		t.Errorf("String() = %s, want %s", d.String(), want+sum)
+	}
+
+	d, err = Parse("tr("+xpubBIP0086+"/<0;1>/*)", &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if d.HasOrigin || d.Scope() != waddrmgr.KeyScopeBIP0086 ||
+		len(d.Branches) != 2 {
+
+		t.Errorf("unexpected descriptor %+v", d)
+	}
+}
+
+// TestAddress checks that addresses of every descriptor type commit to the key
+// derived at the index of the branch.
+func TestAddress(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	for _, expr := range scriptExprs {
+		desc := expr.prefix + xpubBIP0084 + "/<0;1>/*" + expr.suffix
+		d, err := Parse(desc, params)
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", desc, err)
+		}
+		branchKey, err := d.AccountKey.Child(waddrmgr.InternalBranch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := branchKey.Child(5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p2pkh, err := key.Address(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr, err := d.Address(waddrmgr.InternalBranch, 5, params)
+		if err != nil {
+			t.Fatalf("%s: %v", desc, err)
+		}
+		var ok bool
+		switch addr := addr.(type) {
+		case *btcutil.AddressPubKeyHash:
+			ok = expr.typ == PubKeyHash &&
+				addr.EncodeAddress() == p2pkh.EncodeAddress()
+		case *btcutil.AddressScriptHash:
+			ok = expr.typ == NestedWitnessPubKeyHash
+		case *btcutil.AddressWitnessPubKeyHash:
+			ok = expr.typ == WitnessPubKeyHash &&
+				bytes.Equal(addr.ScriptAddress(), p2pkh.ScriptAddress())
+		case *taproot.AddressTaproot:
+			ok = expr.typ == Taproot
+		}
+		if !ok {
+			t.Errorf("%s: unexpected address %v", desc, addr)
+		}
+
+		other, err := d.Address(waddrmgr.ExternalBranch, 5, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other.EncodeAddress() == addr.EncodeAddress() {
+			t.Errorf("%s: branches derive the same address", desc)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		desc string
+	}{
+		{"bad checksum", "wpkh(" + xpubBIP0084 + "/0/*)#qqqqqqqq"},
+		{"multisig", "wsh(multi(1," + xpubBIP0084 + "/0/*))"},
+		{"script tree", "tr(" + xpubBIP0086 + "/0/*,pk(" + xpubBIP0086 + "/1/*))"},
+		{"no range", "wpkh(" + xpubBIP0084 + ")"},
+		{"hardened range", "wpkh(" + xpubBIP0084 + "/0/*')"},
+		{"private key", "wpkh(" + xprvMaster + "/0/*)"},
+		{"master key", "wpkh(" + xpubMaster + "/0/*)"},
+		{"wrong network", "wpkh(" + tpubBIP0049 + "/0/*)"},
+		{"wrong purpose", "wpkh([5a3469b6/44'/0'/0']" + xpubBIP0084 + "/0/*)"},
+		{"unhardened origin", "wpkh([5a3469b6/84'/0'/0]" + xpubBIP0084 + "/0/*)"},
+		{"other account", "wpkh([5a3469b6/84'/0'/1']" + xpubBIP0084 + "/0/*)"},
+		{"bad fingerprint", "wpkh([5a3469/84'/0'/0']" + xpubBIP0084 + "/0/*)"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.desc, &chaincfg.MainNetParams)
+		if err == nil {
+			t.Errorf("%s: descriptor was accepted", test.name)
+		}
+	}
+}
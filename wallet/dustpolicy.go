@@ -0,0 +1,130 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/internal/txsizes"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+)
+
+// DefaultDustRelayFeePerKb is the default relay fee rate per kilobyte from
+// which the dust limit of outputs is derived, the minimum relay fee rate of
+// the back ends.  It makes the dust limit of P2PKH outputs 546 satoshis.
+const DefaultDustRelayFeePerKb = btcutil.Amount(1000)
+
+// dustPolicy holds the policy of the outputs created by the wallet below the
+// dust limit, which mempools reject.
+type dustPolicy struct {
+	mu            sync.Mutex
+	configured    bool
+	relayFeePerKb btcutil.Amount
+	rejectChange  bool
+}
+
+// DustPolicy describes the dust policy of the wallet.
+type DustPolicy struct {
+	// RelayFeePerKb is the relay fee rate per kilobyte from which the dust
+	// limit of outputs is derived.  Zero disables the dust limit.
+	RelayFeePerKb btcutil.Amount
+
+	// RejectChange is set when transactions whose change is below the
+	// dust limit are not created, rather than paying the change as fee.
+	RejectChange bool
+}
+
+// SetDustPolicy configures the dust policy.  The wallet refuses to create
+// outputs below the dust limit derived from relayFeePerKb, and change below
+// the limit is added to the fee of the transaction, unless rejectChange is
+// set and the transaction is not created.  Until this is called, the dust
+// limit is derived from DefaultDustRelayFeePerKb.
+func (w *Wallet) SetDustPolicy(relayFeePerKb btcutil.Amount, rejectChange bool) {
+	w.dustPolicy.mu.Lock()
+	w.dustPolicy.configured = true
+	w.dustPolicy.relayFeePerKb = relayFeePerKb
+	w.dustPolicy.rejectChange = rejectChange
+	w.dustPolicy.mu.Unlock()
+}
+
+// DustPolicy returns the dust policy of the wallet.
+func (w *Wallet) DustPolicy() DustPolicy {
+	w.dustPolicy.mu.Lock()
+	defer w.dustPolicy.mu.Unlock()
+	if !w.dustPolicy.configured {
+		return DustPolicy{RelayFeePerKb: DefaultDustRelayFeePerKb}
+	}
+	return DustPolicy{
+		RelayFeePerKb: w.dustPolicy.relayFeePerKb,
+		RejectChange:  w.dustPolicy.rejectChange,
+	}
+}
+
+// DustLimit returns the amount below which an output with a script of
+// scriptSize bytes is dust.
+func (p DustPolicy) DustLimit(scriptSize int) btcutil.Amount {
+	return txrules.GetDustThreshold(scriptSize, p.RelayFeePerKb)
+}
+
+// dustScriptSizes are the sizes of the output scripts of the standard
+// address types whose dust limits are reported.
+var dustScriptSizes = map[string]int{
+	"p2pkh":  txsizes.P2PKHPkScriptSize,
+	"p2sh":   1 + 1 + 20 + 1,
+	"p2wpkh": txsizes.P2WPKHPkScriptSize,
+	"p2wsh":  1 + 1 + 32,
+	"p2tr":   1 + 1 + 32,
+}
+
+// DustLimits returns the dust limits of the outputs paying the standard
+// address types, keyed by p2pkh, p2sh, p2wpkh, p2wsh and p2tr.
+func (p DustPolicy) DustLimits() map[string]btcutil.Amount {
+	limits := make(map[string]btcutil.Amount, len(dustScriptSizes))
+	for addrType, size := range dustScriptSizes {
+		limits[addrType] = p.DustLimit(size)
+	}
+	return limits
+}
+
+// checkDustOutputs returns an error when an output is below the dust limit.
+// Outputs carrying data are not checked.
+func (p DustPolicy) checkDustOutputs(outputs []*wire.TxOut) error {
+	for i, output := range outputs {
+		if !txrules.IsDustOutput(output, p.RelayFeePerKb) {
+			continue
+		}
+		return fmt.Errorf("output %d of %v is below the dust limit %v",
+			i, btcutil.Amount(output.Value),
+			p.DustLimit(len(output.PkScript)))
+	}
+	return nil
+}
+
+// applyDustChange removes the change output of tx when it is below the dust
+// limit, so that it is added to the fee, or returns an error when dust change
+// is rejected.
+func (p DustPolicy) applyDustChange(tx *txauthor.AuthoredTx) error {
+	if tx.ChangeIndex < 0 {
+		return nil
+	}
+	change := tx.Tx.TxOut[tx.ChangeIndex]
+	if !txrules.IsDustOutput(change, p.RelayFeePerKb) {
+		return nil
+	}
+	if p.RejectChange {
+		return fmt.Errorf("change %v is below the dust limit %v",
+			btcutil.Amount(change.Value),
+			p.DustLimit(len(change.PkScript)))
+	}
+	outputs := tx.Tx.TxOut
+	tx.Tx.TxOut = append(outputs[:tx.ChangeIndex:tx.ChangeIndex],
+		outputs[tx.ChangeIndex+1:]...)
+	tx.ChangeIndex = -1
+	return nil
+}
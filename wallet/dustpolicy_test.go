@@ -0,0 +1,66 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+)
+
+// TestDustPolicy checks that outputs below the dust limit are refused, and
+// that dust change is added to the fee unless it is rejected.
+func TestDustPolicy(t *testing.T) {
+	w := &Wallet{}
+	policy := w.DustPolicy()
+	if limit := policy.DustLimits()["p2pkh"]; limit != 546 {
+		t.Fatalf("default P2PKH dust limit %v, want 546", limit)
+	}
+
+	pkScript := make([]byte, 25)
+	data, err := txscript.NullDataScript([]byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = policy.checkDustOutputs([]*wire.TxOut{
+		wire.NewTxOut(546, pkScript),
+		wire.NewTxOut(0, data),
+	})
+	if err != nil {
+		t.Errorf("outputs at the dust limit refused: %v", err)
+	}
+	err = policy.checkDustOutputs([]*wire.TxOut{wire.NewTxOut(545, pkScript)})
+	if err == nil {
+		t.Error("dust output not refused")
+	}
+
+	authored := func() *txauthor.AuthoredTx {
+		return &txauthor.AuthoredTx{
+			Tx: &wire.MsgTx{TxOut: []*wire.TxOut{
+				wire.NewTxOut(1e4, pkScript),
+				wire.NewTxOut(100, make([]byte, 22)),
+			}},
+			ChangeIndex: 1,
+		}
+	}
+	tx := authored()
+	if err := policy.applyDustChange(tx); err != nil {
+		t.Fatal(err)
+	}
+	if tx.ChangeIndex != -1 || len(tx.Tx.TxOut) != 1 {
+		t.Errorf("dust change not added to the fee: %v", tx.Tx)
+	}
+
+	w.SetDustPolicy(DefaultDustRelayFeePerKb, true)
+	if err := w.DustPolicy().applyDustChange(authored()); err == nil {
+		t.Error("dust change not rejected")
+	}
+	w.SetDustPolicy(0, true)
+	if err := w.DustPolicy().applyDustChange(authored()); err != nil {
+		t.Errorf("dust change rejected without dust limit: %v", err)
+	}
+}
@@ -0,0 +1,464 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// DefaultEmailDigestInterval is the default interval between two
+	// digest emails.
+	DefaultEmailDigestInterval = 24 * time.Hour
+
+	// emailDigestCheckInterval is the interval between two checks whether
+	// a digest email is due.
+	emailDigestCheckInterval = time.Minute
+
+	// emailDigestMaxEntries is the maximum number of entries of each
+	// section of a digest.  Further entries are only counted, so the
+	// digest of a rescan or a long outage remains readable.
+	emailDigestMaxEntries = 100
+)
+
+// EmailDigestConfig configures the digest emails sent by the wallet to
+// summarize its activity.
+type EmailDigestConfig struct {
+	// SMTPServer is the host:port of the SMTP server relaying the emails.
+	// The connection is upgraded with STARTTLS when the server supports
+	// it.
+	SMTPServer string
+
+	// Username and Password authenticate to the SMTP server with the
+	// PLAIN mechanism when Username is set.
+	Username string
+	Password string
+
+	// From and To are the sender and the recipients of the emails.
+	From string
+	To   []string
+
+	// Interval is the interval between two digests.  No digest is sent
+	// for an interval without activity nor alerts.
+	Interval time.Duration
+
+	// LowBalance alerts when the confirmed balance of the wallet is below
+	// it, or never when zero.
+	LowBalance btcutil.Amount
+}
+
+// digestReceipt is a transaction paying the wallet recorded for a digest.
+type digestReceipt struct {
+	hash    chainhash.Hash
+	amounts map[wire.TokenIdentity]int64
+}
+
+// digestConfirmation is a wallet transaction mined in a block recorded for a
+// digest.
+type digestConfirmation struct {
+	hash   chainhash.Hash
+	height int32
+}
+
+// digestFailure is a failed broadcast recorded for a digest.
+type digestFailure struct {
+	hash chainhash.Hash
+	err  string
+}
+
+// emailDigestEntries are the events of the wallet reported by a digest.
+type emailDigestEntries struct {
+	received      []digestReceipt
+	confirmed     []digestConfirmation
+	failed        []digestFailure
+	lowBalance    btcutil.Amount
+	isLowBalance  bool
+	receivedCount int
+	confirmCount  int
+	failedCount   int
+}
+
+func (e *emailDigestEntries) empty() bool {
+	return e.receivedCount == 0 && e.confirmCount == 0 &&
+		e.failedCount == 0 && !e.isLowBalance
+}
+
+// emailDigest accumulates the events of the wallet between two digest
+// emails.
+type emailDigest struct {
+	mu       sync.Mutex
+	config   *EmailDigestConfig
+	entries  emailDigestEntries
+	lastSent time.Time
+	sending  bool
+
+	// lowBalanceAlerted records that a digest already alerted of the
+	// current low balance, so that it does not by itself cause another
+	// digest until the balance was restored.
+	lowBalanceAlerted bool
+
+	// unmined are the incoming transactions already reported as received
+	// while unmined, so they are not reported again once mined.
+	unmined map[chainhash.Hash]struct{}
+
+	// changed is signaled when the configuration changes.
+	changed chan struct{}
+}
+
+// SetEmailDigest configures the wallet to periodically email a digest of the
+// funds it received, the confirmations of its transactions, and alerts of
+// a low balance and failed broadcasts.  A nil config disables the digests.
+func (w *Wallet) SetEmailDigest(config *EmailDigestConfig) {
+	w.emailDigest.mu.Lock()
+	w.emailDigest.config = config
+	w.emailDigest.entries = emailDigestEntries{}
+	w.emailDigest.lastSent = time.Now()
+	w.emailDigest.unmined = make(map[chainhash.Hash]struct{})
+	w.emailDigest.mu.Unlock()
+
+	select {
+	case w.emailDigest.changed <- struct{}{}:
+	default:
+	}
+}
+
+// emailDigestMonitor records the wallet events for the digest emails and
+// sends them when they are due.  It must be run as a goroutine.
+func (w *Wallet) emailDigestMonitor() {
+	defer w.wg.Done()
+
+	// Transaction notifications are only subscribed to while digests are
+	// configured, as every subscriber makes the notification server
+	// describe each wallet transaction.
+	var client *TransactionNotificationsClient
+	var txNtfns <-chan *TransactionNotifications
+	subscribe := func() {
+		w.emailDigest.mu.Lock()
+		enabled := w.emailDigest.config != nil
+		w.emailDigest.mu.Unlock()
+		switch {
+		case enabled && client == nil:
+			c := w.NtfnServer.TransactionNotifications()
+			client, txNtfns = &c, c.C
+		case !enabled && client != nil:
+			client.Done()
+			client, txNtfns = nil, nil
+		}
+	}
+	defer func() {
+		if client != nil {
+			client.Done()
+		}
+	}()
+	subscribe()
+
+	ticker := time.NewTicker(emailDigestCheckInterval)
+	defer ticker.Stop()
+	quit := w.quitChan()
+	for {
+		select {
+		case n, ok := <-txNtfns:
+			if !ok {
+				client, txNtfns = nil, nil
+				continue
+			}
+			w.emailDigest.recordTransactions(n)
+		case <-w.emailDigest.changed:
+			subscribe()
+		case <-ticker.C:
+			w.sendDueEmailDigest()
+		case <-quit:
+			return
+		}
+	}
+}
+
+// recordTransactions records the incoming transactions and the confirmations
+// of a transaction notification.
+func (d *emailDigest) recordTransactions(n *TransactionNotifications) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.config == nil {
+		return
+	}
+
+	for i := range n.UnminedTransactions {
+		tx := &n.UnminedTransactions[i]
+		if !isIncoming(tx) {
+			continue
+		}
+		if _, ok := d.unmined[*tx.Hash]; ok {
+			continue
+		}
+		d.unmined[*tx.Hash] = struct{}{}
+		d.recordReceipt(tx)
+	}
+	for _, b := range n.AttachedBlocks {
+		for i := range b.Transactions {
+			tx := &b.Transactions[i]
+			if isIncoming(tx) {
+				if _, ok := d.unmined[*tx.Hash]; ok {
+					delete(d.unmined, *tx.Hash)
+				} else {
+					d.recordReceipt(tx)
+				}
+			}
+			d.entries.confirmCount++
+			if len(d.entries.confirmed) < emailDigestMaxEntries {
+				d.entries.confirmed = append(d.entries.confirmed,
+					digestConfirmation{*tx.Hash, b.Height})
+			}
+		}
+	}
+}
+
+// isIncoming returns whether a transaction pays the wallet without spending
+// any of its outputs.
+func isIncoming(tx *TransactionSummary) bool {
+	return len(tx.MyInputs) == 0 && len(tx.MyOutputs) != 0
+}
+
+func (d *emailDigest) recordReceipt(tx *TransactionSummary) {
+	d.entries.receivedCount++
+	if len(d.entries.received) >= emailDigestMaxEntries {
+		return
+	}
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(tx.Transaction)); err != nil {
+		log.Errorf("Cannot decode transaction %v for the digest: %v",
+			tx.Hash, err)
+		return
+	}
+	amounts := make(map[wire.TokenIdentity]int64)
+	for _, out := range tx.MyOutputs {
+		if int(out.Index) >= len(msgTx.TxOut) {
+			continue
+		}
+		txOut := msgTx.TxOut[out.Index]
+		amounts[txOut.TokenID()] += txOut.Value
+	}
+	d.entries.received = append(d.entries.received,
+		digestReceipt{*tx.Hash, amounts})
+}
+
+// recordBroadcastFailure records a failed broadcast for the next digest.
+func (d *emailDigest) recordBroadcastFailure(txHash *chainhash.Hash, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.config == nil {
+		return
+	}
+	d.entries.failedCount++
+	if len(d.entries.failed) < emailDigestMaxEntries {
+		d.entries.failed = append(d.entries.failed,
+			digestFailure{*txHash, err.Error()})
+	}
+}
+
+// sendDueEmailDigest sends the digest email when its interval elapsed and
+// there is activity or an alert to report.  The email is sent by another
+// goroutine, so a slow SMTP server does not delay the notifications.
+func (w *Wallet) sendDueEmailDigest() {
+	d := &w.emailDigest
+	d.mu.Lock()
+	config := d.config
+	if config == nil || d.sending || time.Since(d.lastSent) < config.Interval {
+		d.mu.Unlock()
+		return
+	}
+	d.mu.Unlock()
+
+	var balance btcutil.Amount
+	if config.LowBalance > 0 {
+		var err error
+		balance, err = w.CalculateBalance(1)
+		if err != nil {
+			log.Errorf("Cannot check the balance for the digest: %v",
+				err)
+			return
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.config != config {
+		return
+	}
+	entries := d.entries
+	if config.LowBalance > 0 && balance < config.LowBalance {
+		entries.isLowBalance = true
+		entries.lowBalance = balance
+	} else {
+		d.lowBalanceAlerted = false
+	}
+	if entries.empty() || (entries.receivedCount == 0 &&
+		entries.confirmCount == 0 && entries.failedCount == 0 &&
+		d.lowBalanceAlerted) {
+
+		d.lastSent = time.Now()
+		return
+	}
+	d.entries = emailDigestEntries{}
+	d.sending = true
+
+	msg := formatEmailDigest(config, &entries, w.chainParams.Name, time.Now())
+	go func() {
+		err := sendEmail(config, msg)
+		d.mu.Lock()
+		d.sending = false
+		d.lastSent = time.Now()
+		if err == nil {
+			if entries.isLowBalance {
+				d.lowBalanceAlerted = true
+			}
+		} else if d.config == config {
+			// Keep the entries for the next digest, before those
+			// recorded since.
+			d.entries = mergeDigestEntries(&entries, &d.entries)
+		}
+		d.mu.Unlock()
+		if err != nil {
+			log.Errorf("Cannot send the digest email: %v", err)
+			return
+		}
+		log.Infof("Sent the digest email to %s",
+			strings.Join(config.To, ", "))
+	}()
+}
+
+// mergeDigestEntries returns the entries of a failed digest followed by the
+// entries recorded since.  The low balance alert is evaluated again.
+func mergeDigestEntries(failed, since *emailDigestEntries) emailDigestEntries {
+	merged := emailDigestEntries{
+		receivedCount: failed.receivedCount + since.receivedCount,
+		confirmCount:  failed.confirmCount + since.confirmCount,
+		failedCount:   failed.failedCount + since.failedCount,
+	}
+	merged.received = append(append(merged.received, failed.received...),
+		since.received...)
+	merged.confirmed = append(append(merged.confirmed, failed.confirmed...),
+		since.confirmed...)
+	merged.failed = append(append(merged.failed, failed.failed...),
+		since.failed...)
+	if len(merged.received) > emailDigestMaxEntries {
+		merged.received = merged.received[:emailDigestMaxEntries]
+	}
+	if len(merged.confirmed) > emailDigestMaxEntries {
+		merged.confirmed = merged.confirmed[:emailDigestMaxEntries]
+	}
+	if len(merged.failed) > emailDigestMaxEntries {
+		merged.failed = merged.failed[:emailDigestMaxEntries]
+	}
+	return merged
+}
+
+// formatAmounts formats the amounts of a receipt, ordered by token.
+func formatAmounts(amounts map[wire.TokenIdentity]int64) string {
+	parts := make([]string, 0, len(amounts))
+	for token, amount := range amounts {
+		btc := btcutil.Amount(amount).ToBTC()
+		parts = append(parts, strconv.FormatFloat(btc, 'f', -1, 64)+
+			" "+token.String())
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// formatEmailDigest formats the digest email of entries as a plain text
+// message with its headers.
+func formatEmailDigest(config *EmailDigestConfig, entries *emailDigestEntries,
+	network string, now time.Time) []byte {
+
+	var summary []string
+	if entries.receivedCount != 0 {
+		summary = append(summary, fmt.Sprintf("%d received",
+			entries.receivedCount))
+	}
+	if entries.confirmCount != 0 {
+		summary = append(summary, fmt.Sprintf("%d confirmed",
+			entries.confirmCount))
+	}
+	if alerts := entries.failedCount; alerts != 0 || entries.isLowBalance {
+		if entries.isLowBalance {
+			alerts++
+		}
+		summary = append(summary, fmt.Sprintf("%d alerts", alerts))
+	}
+
+	var buf bytes.Buffer
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&buf, format, args...)
+		buf.WriteString("\r\n")
+	}
+	more := func(listed, count int) {
+		if count > listed {
+			line("  and %d more", count-listed)
+		}
+	}
+	line("From: %s", config.From)
+	line("To: %s", strings.Join(config.To, ", "))
+	line("Subject: btcwallet %s digest: %s", network,
+		strings.Join(summary, ", "))
+	line("Date: %s", now.Format(time.RFC1123Z))
+	line("MIME-Version: 1.0")
+	line("Content-Type: text/plain; charset=utf-8")
+	line("")
+
+	if entries.isLowBalance || entries.failedCount != 0 {
+		line("Alerts:")
+		if entries.isLowBalance {
+			line("  Confirmed balance %v is below %v",
+				entries.lowBalance, config.LowBalance)
+		}
+		for _, f := range entries.failed {
+			line("  Broadcast of transaction %v failed: %s", f.hash,
+				f.err)
+		}
+		more(len(entries.failed), entries.failedCount)
+		line("")
+	}
+	if entries.receivedCount != 0 {
+		line("Received funds:")
+		for _, r := range entries.received {
+			line("  %v  %s", r.hash, formatAmounts(r.amounts))
+		}
+		more(len(entries.received), entries.receivedCount)
+		line("")
+	}
+	if entries.confirmCount != 0 {
+		line("Confirmed transactions:")
+		for _, c := range entries.confirmed {
+			line("  %v  in block %d", c.hash, c.height)
+		}
+		more(len(entries.confirmed), entries.confirmCount)
+		line("")
+	}
+	return buf.Bytes()
+}
+
+// sendEmail sends a message through the SMTP server of the config.
+func sendEmail(config *EmailDigestConfig, msg []byte) error {
+	var auth smtp.Auth
+	if config.Username != "" {
+		host, _, err := net.SplitHostPort(config.SMTPServer)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", config.Username, config.Password, host)
+	}
+	return smtp.SendMail(config.SMTPServer, auth, config.From, config.To, msg)
+}
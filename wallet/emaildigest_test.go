@@ -0,0 +1,139 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+func digestTxSummary(t *testing.T, incoming bool) TransactionSummary {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1e8, []byte{0x51}))
+	tx.AddTxOut(wire.NewTxOut(5e7, []byte{0x52}))
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	hash := tx.TxHash()
+	summary := TransactionSummary{
+		Hash:        &hash,
+		Transaction: buf.Bytes(),
+		MyOutputs:   []TransactionSummaryOutput{{Index: 0}},
+	}
+	if !incoming {
+		summary.MyInputs = []TransactionSummaryInput{{Index: 0}}
+	}
+	return summary
+}
+
+func TestEmailDigestRecord(t *testing.T) {
+	d := &emailDigest{
+		config:  &EmailDigestConfig{},
+		unmined: make(map[chainhash.Hash]struct{}),
+	}
+	received := digestTxSummary(t, true)
+	sent := digestTxSummary(t, false)
+	sent.MyOutputs[0].Index = 1
+
+	// An incoming transaction is reported as received once, whether it
+	// is notified again unmined or mined.
+	d.recordTransactions(&TransactionNotifications{
+		UnminedTransactions: []TransactionSummary{received, sent},
+	})
+	d.recordTransactions(&TransactionNotifications{
+		UnminedTransactions: []TransactionSummary{received},
+	})
+	d.recordTransactions(&TransactionNotifications{
+		AttachedBlocks: []Block{{
+			Height:       100,
+			Transactions: []TransactionSummary{received, sent},
+		}},
+	})
+	if d.entries.receivedCount != 1 || len(d.entries.received) != 1 {
+		t.Fatalf("received %d transactions, want 1",
+			d.entries.receivedCount)
+	}
+	var amount int64
+	for _, a := range d.entries.received[0].amounts {
+		amount += a
+	}
+	if amount != 1e8 {
+		t.Errorf("received %v, want %v", amount, int64(1e8))
+	}
+	if d.entries.confirmCount != 2 {
+		t.Errorf("confirmed %d transactions, want 2",
+			d.entries.confirmCount)
+	}
+	if len(d.unmined) != 0 {
+		t.Errorf("%d mined transactions still recorded as unmined",
+			len(d.unmined))
+	}
+
+	errRejected := errors.New("fee not met")
+	d.recordBroadcastFailure(sent.Hash, errRejected)
+	if d.entries.failedCount != 1 {
+		t.Errorf("recorded %d failures, want 1", d.entries.failedCount)
+	}
+
+	// Nothing is recorded while digests are disabled.
+	d.config = nil
+	d.recordBroadcastFailure(sent.Hash, errRejected)
+	if d.entries.failedCount != 1 {
+		t.Errorf("recorded %d failures, want 1", d.entries.failedCount)
+	}
+}
+
+func TestFormatEmailDigest(t *testing.T) {
+	config := &EmailDigestConfig{
+		From:       "wallet@example.com",
+		To:         []string{"ops@example.com", "cfo@example.com"},
+		LowBalance: btcutil.Amount(1e8),
+	}
+	received := digestTxSummary(t, true)
+	entries := &emailDigestEntries{
+		received: []digestReceipt{{
+			hash:    *received.Hash,
+			amounts: map[wire.TokenIdentity]int64{wire.STB: 15e7},
+		}},
+		receivedCount: 1,
+		confirmed:     []digestConfirmation{{*received.Hash, 100}},
+		confirmCount:  3,
+		failed:        []digestFailure{{*received.Hash, "fee not met"}},
+		failedCount:   1,
+		isLowBalance:  true,
+		lowBalance:    btcutil.Amount(2e7),
+	}
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	msg := string(formatEmailDigest(config, entries, "mainnet", now))
+
+	for _, want := range []string{
+		"To: ops@example.com, cfo@example.com\r\n",
+		"Subject: btcwallet mainnet digest: 1 received, 3 confirmed, 2 alerts\r\n",
+		"Date: Fri, 01 Jun 2018 12:00:00 +0000\r\n",
+		"  Confirmed balance " + entries.lowBalance.String() +
+			" is below " + config.LowBalance.String() + "\r\n",
+		"  Broadcast of transaction " + received.Hash.String() +
+			" failed: fee not met\r\n",
+		"  " + received.Hash.String() + "  1.5 " + wire.STB.String() + "\r\n",
+		"  " + received.Hash.String() + "  in block 100\r\n",
+		"  and 2 more\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("digest does not contain %q:\n%s", want, msg)
+		}
+	}
+	if strings.Index(msg, "Alerts:") > strings.Index(msg, "Received funds:") {
+		t.Errorf("alerts are not listed first:\n%s", msg)
+	}
+}
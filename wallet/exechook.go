@@ -0,0 +1,167 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// DefaultExecHookTimeout is the time an ExecTxHook subprocess is given to
+// respond before the invocation fails.
+const DefaultExecHookTimeout = 30 * time.Second
+
+// ExecTxHook is a TxHook that runs an external program for every hook event.
+// The program receives a JSON object describing the event on stdin and must
+// write a JSON object with the optional "veto", "reason" and "annotations"
+// fields to stdout and exit with status zero.
+// Any other exit status, or output that is not valid JSON, is treated as a
+// hook failure.
+type ExecTxHook struct {
+	path    string
+	args    []string
+	timeout time.Duration
+}
+
+// NewExecTxHook returns a hook running the program at path with the passed
+// arguments.
+func NewExecTxHook(path string, args ...string) *ExecTxHook {
+	return &ExecTxHook{
+		path:    path,
+		args:    args,
+		timeout: DefaultExecHookTimeout,
+	}
+}
+
+// SetTimeout changes the time the program is given to respond.
+func (h *ExecTxHook) SetTimeout(timeout time.Duration) {
+	h.timeout = timeout
+}
+
+// execHookOutput is the JSON description of a transaction output passed to
+// hook programs.
+type execHookOutput struct {
+	Value    int64  `json:"value"`
+	PkScript string `json:"pkscript"`
+	Token    string `json:"token"`
+}
+
+// execHookBlock is the JSON description of the block passed to confirmation
+// hook programs.
+type execHookBlock struct {
+	Hash   string `json:"hash"`
+	Height int32  `json:"height"`
+	Time   int64  `json:"time"`
+}
+
+// execHookRequest is the JSON encoded event written to the stdin of hook
+// programs.
+type execHookRequest struct {
+	Point       string            `json:"point"`
+	Account     uint32            `json:"account"`
+	Outputs     []execHookOutput  `json:"outputs,omitempty"`
+	TxID        string            `json:"txid,omitempty"`
+	Tx          string            `json:"tx,omitempty"`
+	Block       *execHookBlock    `json:"block,omitempty"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// execHookResponse is the JSON encoded result read from the stdout of hook
+// programs.
+type execHookResponse struct {
+	Veto        bool              `json:"veto"`
+	Reason      string            `json:"reason"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Name returns the base name of the hook program.
+//
+// This is part of the TxHook interface implementation.
+func (h *ExecTxHook) Name() string {
+	return filepath.Base(h.path)
+}
+
+// HandleTxEvent runs the hook program with the JSON encoding of the event.
+//
+// This is part of the TxHook interface implementation.
+func (h *ExecTxHook) HandleTxEvent(ev *TxHookEvent) (*TxHookResult, error) {
+	req, err := newExecHookRequest(ev)
+	if err != nil {
+		return nil, err
+	}
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, h.path, h.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) != 0 {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	var resp execHookResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("invalid hook response: %v", err)
+	}
+	return &TxHookResult{
+		Veto:        resp.Veto,
+		Reason:      resp.Reason,
+		Annotations: resp.Annotations,
+	}, nil
+}
+
+func newExecHookRequest(ev *TxHookEvent) (*execHookRequest, error) {
+	req := &execHookRequest{
+		Point:       ev.Point.String(),
+		Account:     ev.Account,
+		Annotations: ev.Annotations,
+	}
+	for _, out := range ev.Outputs {
+		req.Outputs = append(req.Outputs, execHookOutput{
+			Value:    out.Value,
+			PkScript: hex.EncodeToString(out.PkScript),
+			Token:    out.TokenID().String(),
+		})
+	}
+	if ev.Tx != nil {
+		var buf bytes.Buffer
+		buf.Grow(ev.Tx.SerializeSize())
+		if err := ev.Tx.Serialize(&buf); err != nil {
+			return nil, err
+		}
+		req.TxID = ev.Tx.TxHash().String()
+		req.Tx = hex.EncodeToString(buf.Bytes())
+	}
+	if ev.Block != nil {
+		req.Block = newExecHookBlock(ev.Block)
+	}
+	return req, nil
+}
+
+func newExecHookBlock(block *wtxmgr.BlockMeta) *execHookBlock {
+	return &execHookBlock{
+		Hash:   block.Hash.String(),
+		Height: block.Height,
+		Time:   block.Time.Unix(),
+	}
+}
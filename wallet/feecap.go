@@ -0,0 +1,197 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/helpers"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// DefaultMaxTxFee is the default maximum fee of a transaction created by the
+// wallet.
+const DefaultMaxTxFee = btcutil.Amount(1e7)
+
+// feeCapWindow is the period over which the fees of the transactions sent by
+// the wallet are summed against the daily fee cap.
+const feeCapWindow = 24 * time.Hour
+
+// FeeCap limits the fees paid by the transactions created by the wallet, so
+// that a fee estimation bug or a mistyped fee rate can't burn a large
+// fraction of a payment.  Zero limits are disabled.
+type FeeCap struct {
+	// MaxFee is the maximum fee of a transaction.
+	MaxFee btcutil.Amount
+
+	// MaxFeePercent is the maximum fee of a transaction as a percentage
+	// of the amount it pays, excluding its change.
+	MaxFeePercent float64
+
+	// MaxDailyFee is the maximum sum of the fees of the transactions sent
+	// by the wallet over the last 24 hours, including the created
+	// transaction.
+	MaxDailyFee btcutil.Amount
+}
+
+// feeCapPolicy holds the fee cap of the wallet.
+type feeCapPolicy struct {
+	mu         sync.Mutex
+	configured bool
+	limits     FeeCap
+}
+
+// FeeCapError describes an error where a transaction is not created because
+// its fee exceeds a limit of the fee cap.
+type FeeCapError struct {
+	Fee   btcutil.Amount
+	Limit btcutil.Amount
+	Cap   string
+}
+
+// Error implements the error interface.
+func (e *FeeCapError) Error() string {
+	return fmt.Sprintf("fee %v exceeds the %s fee cap of %v", e.Fee, e.Cap,
+		e.Limit)
+}
+
+// SetFeeCap configures the fee cap of the transactions created by the wallet,
+// which are refused when their fee exceeds it unless their options override
+// the cap.  Until this is called, the fee of a transaction is capped at
+// DefaultMaxTxFee.
+func (w *Wallet) SetFeeCap(limits FeeCap) {
+	w.feeCap.mu.Lock()
+	w.feeCap.configured = true
+	w.feeCap.limits = limits
+	w.feeCap.mu.Unlock()
+}
+
+// FeeCap returns the fee cap of the wallet.
+func (w *Wallet) FeeCap() FeeCap {
+	w.feeCap.mu.Lock()
+	defer w.feeCap.mu.Unlock()
+	if !w.feeCap.configured {
+		return FeeCap{MaxFee: DefaultMaxTxFee}
+	}
+	return w.feeCap.limits
+}
+
+// DailyFees returns the sum of the fees paid in a token by the transactions
+// sent by the wallet over the last 24 hours.
+func (w *Wallet) DailyFees(token wire.TokenIdentity) (btcutil.Amount, error) {
+	var fees btcutil.Amount
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		var err error
+		fees, err = w.dailyFees(dbtx, token, time.Now())
+		return err
+	})
+	return fees, err
+}
+
+// dailyFees returns the sum of the fees paid in a token by the wallet
+// transactions received in the 24 hours before now.  Fees are only known for
+// the transactions spending wallet outputs only.
+func (w *Wallet) dailyFees(dbtx walletdb.ReadTx, token wire.TokenIdentity,
+	now time.Time) (btcutil.Amount, error) {
+
+	txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+	since := now.Add(-feeCapWindow)
+	commodity := token.String()
+
+	var fees btcutil.Amount
+	rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
+		for i := range details {
+			// Unmined transactions come first, then blocks from
+			// the tip, which are older than the window once their
+			// time is.
+			d := &details[i]
+			if d.Block.Height != -1 && d.Block.Time.Before(since) {
+				return true, nil
+			}
+			if len(d.Debits) == 0 || d.Received.Before(since) {
+				continue
+			}
+			entry, err := w.ledgerEntry(dbtx, d)
+			if err != nil {
+				return false, err
+			}
+			_, _, fee, _ := spendingAmounts(entry, commodity, "")
+			fees += fee
+		}
+		return false, nil
+	}
+	err := w.TxStore.RangeTransactions(txmgrNs, -1, 0, rangeFn)
+	return fees, err
+}
+
+// checkFeeCap returns a FeeCapError when the fee of a transaction paying a
+// token exceeds the fee cap.  When opts override the fee cap, the exceeded cap
+// is returned as overridden instead, for the caller to record it with
+// auditFeeCapOverride once the transaction is created.  The audit log can not
+// be written by the database transaction checking the fee cap.
+func (w *Wallet) checkFeeCap(dbtx walletdb.ReadTx, tx *txauthor.AuthoredTx,
+	token wire.TokenIdentity, opts *TxOptions) (overridden, err error) {
+
+	feeCap := w.FeeCap()
+	fee := tx.TotalInput - helpers.SumOutputValues(tx.Tx.TxOut)
+	var amount btcutil.Amount
+	for i, txOut := range tx.Tx.TxOut {
+		if i != tx.ChangeIndex {
+			amount += btcutil.Amount(txOut.Value)
+		}
+	}
+
+	err = feeCap.check(fee, amount)
+	if err == nil && feeCap.MaxDailyFee > 0 {
+		var dailyFee btcutil.Amount
+		dailyFee, err = w.dailyFees(dbtx, token, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if dailyFee+fee > feeCap.MaxDailyFee {
+			err = &FeeCapError{
+				Fee:   dailyFee + fee,
+				Limit: feeCap.MaxDailyFee,
+				Cap:   "daily",
+			}
+		}
+	}
+	if err == nil || !opts.overrideFeeCap() {
+		return nil, err
+	}
+	return err, nil
+}
+
+// auditFeeCapOverride logs and records in the audit log the fee cap
+// overridden by a created transaction.
+func (w *Wallet) auditFeeCapOverride(overridden error) {
+	log.Warnf("Overriding the fee cap of a transaction: %v", overridden)
+	w.audit(AuditSend, "fee cap overridden: %v", overridden)
+}
+
+// check returns a FeeCapError when the fee of a transaction paying amount
+// exceeds the per transaction limits of the fee cap.
+func (c FeeCap) check(fee, amount btcutil.Amount) error {
+	if c.MaxFee > 0 && fee > c.MaxFee {
+		return &FeeCapError{Fee: fee, Limit: c.MaxFee, Cap: "per transaction"}
+	}
+	if c.MaxFeePercent > 0 {
+		limit := btcutil.Amount(float64(amount) * c.MaxFeePercent / 100)
+		if fee > limit {
+			return &FeeCapError{
+				Fee:   fee,
+				Limit: limit,
+				Cap:   fmt.Sprintf("%v%% of the amount", c.MaxFeePercent),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestFeeCap checks that transactions paying a fee above the fee cap are
+// refused unless the cap is overridden.
+func TestFeeCap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "feecap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+
+	if feeCap := w.FeeCap(); feeCap.MaxFee != DefaultMaxTxFee {
+		t.Fatalf("default maximum fee %v, want %v", feeCap.MaxFee,
+			DefaultMaxTxFee)
+	}
+
+	// The transaction pays 1e6 with 1e5 of change and a fee of 2e4.
+	tx := &txauthor.AuthoredTx{
+		Tx: &wire.MsgTx{TxOut: []*wire.TxOut{
+			wire.NewTxOut(1e6, make([]byte, 22)),
+			wire.NewTxOut(1e5, make([]byte, 22)),
+		}},
+		TotalInput:  1e6 + 1e5 + 2e4,
+		ChangeIndex: 1,
+	}
+	tests := []struct {
+		name     string
+		feeCap   FeeCap
+		override bool
+		refused  bool
+	}{
+		{name: "disabled"},
+		{name: "below maximum", feeCap: FeeCap{MaxFee: 2e4}},
+		{name: "above maximum", feeCap: FeeCap{MaxFee: 2e4 - 1}, refused: true},
+		{name: "below percentage", feeCap: FeeCap{MaxFeePercent: 2}},
+		{name: "above percentage", feeCap: FeeCap{MaxFeePercent: 1.9}, refused: true},
+		{name: "overridden", feeCap: FeeCap{MaxFee: 1}, override: true},
+	}
+	for _, test := range tests {
+		w.SetFeeCap(test.feeCap)
+		var overridden error
+		err := walletdb.View(db, func(dbtx walletdb.ReadTx) error {
+			opts := &TxOptions{OverrideFeeCap: test.override}
+			var err error
+			overridden, err = w.checkFeeCap(dbtx, tx, wire.STB, opts)
+			return err
+		})
+		_, refused := err.(*FeeCapError)
+		if refused != test.refused || !refused && err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if _, ok := overridden.(*FeeCapError); ok != test.override {
+			t.Errorf("%s: unexpected overridden fee cap %v",
+				test.name, overridden)
+		}
+	}
+}
+
+// TestFeeCapOverride checks that a transaction exceeding the fee cap is only
+// created when the cap is overridden, and that the override is recorded in the
+// audit log.
+func TestFeeCapOverride(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fundTestWallet(t, w, pkScript, 1e8)
+	w.SetFeeCap(FeeCap{MaxFee: 1})
+
+	outputs := []*wire.TxOut{wire.NewTxOut(1e7, pkScript)}
+	_, err = w.txToOutputs(outputs, 0, 0, 1e4, nil, true, false)
+	if _, ok := err.(*FeeCapError); !ok {
+		t.Fatalf("expected a fee cap error, got %v", err)
+	}
+
+	opts := &TxOptions{OverrideFeeCap: true}
+	if _, err := w.txToOutputs(outputs, 0, 0, 1e4, opts, true, false); err != nil {
+		t.Fatalf("overridden fee cap: %v", err)
+	}
+	records, err := w.AuditLog(1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		if r.Operation == AuditSend &&
+			strings.HasPrefix(r.Details, "fee cap overridden") {
+
+			return
+		}
+	}
+	t.Fatalf("fee cap override not recorded in the audit log: %v", records)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+)
+
+// DefaultConfTarget is the number of blocks within which the fee rate of sent
+// transactions is estimated to get them mined, unless they choose their own
+// target.
+const DefaultConfTarget = 6
+
+// feePolicy is the fee rate of sent transactions, either set manually or
+// estimated by the chain server for a confirmation target.
+type feePolicy struct {
+	mu         sync.Mutex
+	txFee      btcutil.Amount
+	confTarget int64
+}
+
+// SetTxFee sets the fee rate per kilobyte of sent transactions, overriding
+// the fee rate estimated by the chain server.  A zero fee rate removes the
+// override.
+func (w *Wallet) SetTxFee(feeSatPerKb btcutil.Amount) {
+	w.feePolicy.mu.Lock()
+	w.feePolicy.txFee = feeSatPerKb
+	w.feePolicy.mu.Unlock()
+}
+
+// TxFee returns the fee rate per kilobyte set with SetTxFee, or zero when the
+// fee rate of sent transactions is estimated.
+func (w *Wallet) TxFee() btcutil.Amount {
+	w.feePolicy.mu.Lock()
+	defer w.feePolicy.mu.Unlock()
+	return w.feePolicy.txFee
+}
+
+// SetConfTarget sets the number of blocks within which the fee rate of sent
+// transactions is estimated to get them mined, when they do not choose their
+// own target.
+func (w *Wallet) SetConfTarget(target int64) {
+	w.feePolicy.mu.Lock()
+	w.feePolicy.confTarget = target
+	w.feePolicy.mu.Unlock()
+}
+
+// ConfTarget returns the number of blocks within which the fee rate of sent
+// transactions is estimated to get them mined by default.
+func (w *Wallet) ConfTarget() int64 {
+	w.feePolicy.mu.Lock()
+	defer w.feePolicy.mu.Unlock()
+	if w.feePolicy.confTarget <= 0 {
+		return DefaultConfTarget
+	}
+	return w.feePolicy.confTarget
+}
+
+// FeeRate returns the fee rate per kilobyte of a transaction to be mined
+// within confTarget blocks, or the default confirmation target when it is
+// zero.  The fee rate set with SetTxFee is returned when there is one, and
+// the relay fee rate when the chain server has no estimate.
+func (w *Wallet) FeeRate(confTarget int64) btcutil.Amount {
+	if txFee := w.TxFee(); txFee > 0 {
+		return txFee
+	}
+	if confTarget <= 0 {
+		confTarget = w.ConfTarget()
+	}
+	feeRate, err := w.estimateFeeRate(confTarget)
+	if err != nil {
+		log.Debugf("Using the relay fee rate for a confirmation target "+
+			"of %d blocks: %v", confTarget, err)
+		return txrules.DefaultRelayFeePerKb
+	}
+	if feeRate < txrules.DefaultRelayFeePerKb {
+		return txrules.DefaultRelayFeePerKb
+	}
+	return feeRate
+}
+
+// estimateFeeRate returns the fee rate per kilobyte estimated by the chain
+// server for a transaction to be mined within numBlocks blocks.
+func (w *Wallet) estimateFeeRate(numBlocks int64) (btcutil.Amount, error) {
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return 0, err
+	}
+	estimator, ok := chainClient.(FeeEstimator)
+	if !ok {
+		return 0, fmt.Errorf("%s chain backend does not estimate fee "+
+			"rates", chainClient.BackEnd())
+	}
+	feeRate, err := estimator.EstimateFeeRate(numBlocks)
+	if err != nil {
+		return 0, err
+	}
+	if feeRate < 0 {
+		return 0, ErrNoFeeEstimate
+	}
+	return feeRate, nil
+}
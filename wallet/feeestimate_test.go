@@ -0,0 +1,42 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+)
+
+// TestFeeRate checks that the fee rate set with SetTxFee overrides the
+// estimated fee rate, and that the relay fee rate is used without estimate.
+func TestFeeRate(t *testing.T) {
+	w := &Wallet{}
+
+	if target := w.ConfTarget(); target != DefaultConfTarget {
+		t.Errorf("default confirmation target is %d, want %d", target,
+			DefaultConfTarget)
+	}
+	w.SetConfTarget(2)
+	if target := w.ConfTarget(); target != 2 {
+		t.Errorf("confirmation target is %d, want 2", target)
+	}
+
+	// Without a chain client, there is no estimate.
+	if feeRate := w.FeeRate(0); feeRate != txrules.DefaultRelayFeePerKb {
+		t.Errorf("fee rate without estimate is %v, want %v", feeRate,
+			txrules.DefaultRelayFeePerKb)
+	}
+
+	w.SetTxFee(2000)
+	if feeRate := w.FeeRate(1); feeRate != 2000 {
+		t.Errorf("fee rate is %v, want the set fee rate", feeRate)
+	}
+	w.SetTxFee(0)
+	if feeRate := w.FeeRate(1); feeRate != txrules.DefaultRelayFeePerKb {
+		t.Errorf("fee rate is %v after removing the set fee rate",
+			feeRate)
+	}
+}
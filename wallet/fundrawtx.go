@@ -0,0 +1,302 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/helpers"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// fundRequest is the part of a createTxRequest describing the funding of an
+// externally constructed transaction.
+type fundRequest struct {
+	tx           *wire.MsgTx
+	changePos    int
+	lockUnspents bool
+}
+
+// FundRawTransaction adds inputs spending unspent outputs of an account to an
+// externally constructed transaction until they pay for its outputs and fee,
+// and a change output when the leftover value is not dust.  The inputs and
+// outputs of tx are kept, and its inputs must spend outputs of wallet
+// transactions, whose values are needed to compute the fee.  The change
+// output is inserted at changePos, or at a random position when changePos is
+// negative.  The added inputs are locked when lockUnspents is set.
+//
+// The funded transaction is unsigned, and is returned with its fee and the
+// index of its change output, which is -1 without change.  tx is not
+// modified.
+func (w *Wallet) FundRawTransaction(tx *wire.MsgTx, account uint32,
+	minconf int32, satPerKb btcutil.Amount, changePos int,
+	lockUnspents bool, opts *TxOptions) (*wire.MsgTx, btcutil.Amount,
+	int, error) {
+
+	if len(tx.TxOut) == 0 {
+		return nil, 0, 0, errors.New("transaction has no outputs")
+	}
+	if changePos > len(tx.TxOut) {
+		return nil, 0, 0, fmt.Errorf("change position %d is out of "+
+			"bounds", changePos)
+	}
+
+	req := createTxRequest{
+		account:     account,
+		minconf:     minconf,
+		feeSatPerKB: satPerKb,
+		unsigned:    true,
+		fund: &fundRequest{
+			tx:           tx,
+			changePos:    changePos,
+			lockUnspents: lockUnspents,
+		},
+		opts: opts,
+		resp: make(chan createTxResponse),
+	}
+	w.createTxRequests <- req
+	resp := <-req.resp
+	if resp.err != nil {
+		return nil, 0, 0, resp.err
+	}
+
+	funded := resp.tx
+	fee := funded.TotalInput - helpers.SumOutputValues(funded.Tx.TxOut)
+	return funded.Tx, fee, funded.ChangeIndex, nil
+}
+
+// txToFund creates the transaction funding an externally constructed
+// transaction.  Its inputs are never signed.
+func (w *Wallet) txToFund(account uint32, fund *fundRequest, minconf int32,
+	feeSatPerKb btcutil.Amount, opts *TxOptions) (*txauthor.AuthoredTx, error) {
+
+	if err := w.checkAccountFrozen(account); err != nil {
+		return nil, err
+	}
+	selector := opts.coinSelector(w)
+
+	// The outputs are copied so that the transaction of the request is
+	// left unmodified.
+	outputs := make([]*wire.TxOut, len(fund.tx.TxOut))
+	for i, txOut := range fund.tx.TxOut {
+		output := *txOut
+		outputs[i] = &output
+	}
+	if err := w.checkPoolDestinations(account, outputs); err != nil {
+		return nil, err
+	}
+	dust := w.DustPolicy()
+	if err := dust.checkDustOutputs(outputs); err != nil {
+		return nil, err
+	}
+
+	token, ok := helpers.GetSingleToken(outputs)
+	if !ok {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCUnimplemented,
+			Message: "Multiple tokens transaction are not yet supported",
+		}
+	}
+
+	chainClient, err := w.optionalChainClient()
+	if err != nil {
+		return nil, err
+	}
+
+	hookEvent := &TxHookEvent{
+		Point:   HookBeforeCoinSelection,
+		Account: account,
+		Outputs: outputs,
+	}
+	if err := w.runTxHooks(hookEvent); err != nil {
+		return nil, err
+	}
+
+	var tx *txauthor.AuthoredTx
+	var feeCapOverride error
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		addrmgrNs := dbtx.ReadWriteBucket(waddrmgrNamespaceKey)
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+
+		// The values and scripts of the outputs spent by the inputs of
+		// the transaction are looked up in the wallet transactions.
+		var presetTotal btcutil.Amount
+		presetValues := make([]btcutil.Amount, len(fund.tx.TxIn))
+		presetScripts := make([][]byte, len(fund.tx.TxIn))
+		preset := make(map[wire.OutPoint]struct{}, len(fund.tx.TxIn))
+		for i, txIn := range fund.tx.TxIn {
+			op := txIn.PreviousOutPoint
+			prevOut, err := w.prevOutput(txmgrNs, &op)
+			if err != nil {
+				return err
+			}
+			if prevOut == nil {
+				return fmt.Errorf("input %v does not spend a "+
+					"wallet transaction output", op)
+			}
+			if prevOut.TokenID() != token {
+				return fmt.Errorf("input %v spends another token "+
+					"than the outputs", op)
+			}
+			presetValues[i] = btcutil.Amount(prevOut.Value)
+			presetScripts[i] = prevOut.PkScript
+			presetTotal += presetValues[i]
+			preset[op] = struct{}{}
+		}
+
+		bs, err := w.blockStamp(chainClient)
+		if err != nil {
+			return err
+		}
+		eligible, err := w.findEligibleOutputs(dbtx, account, token,
+			minconf, bs)
+		if err != nil {
+			return err
+		}
+		if chosen, ok := selector.(ChosenOutpoints); ok {
+			if err := chosen.check(eligible); err != nil {
+				return err
+			}
+		}
+		unspent := eligible[:0]
+		for _, credit := range eligible {
+			if _, ok := preset[credit.OutPoint]; !ok {
+				unspent = append(unspent, credit)
+			}
+		}
+
+		// The inputs of the transaction are always spent, and outputs
+		// of the account are only selected for the rest of the target.
+		selectInputs := makeInputSource(unspent, selector, feeSatPerKb)
+		inputSource := func(target btcutil.Amount) (btcutil.Amount,
+			[]*wire.TxIn, []btcutil.Amount, [][]byte, error) {
+
+			total := presetTotal
+			inputs := make([]*wire.TxIn, 0, len(fund.tx.TxIn))
+			for _, txIn := range fund.tx.TxIn {
+				input := *txIn
+				inputs = append(inputs, &input)
+			}
+			values := append([]btcutil.Amount(nil), presetValues...)
+			scripts := append([][]byte(nil), presetScripts...)
+			if target <= presetTotal {
+				return total, inputs, values, scripts, nil
+			}
+
+			selected, selectedInputs, selectedValues, selectedScripts,
+				err := selectInputs(target - presetTotal)
+			if err != nil {
+				return 0, nil, nil, nil, err
+			}
+			total += selected
+			inputs = append(inputs, selectedInputs...)
+			values = append(values, selectedValues...)
+			scripts = append(scripts, selectedScripts...)
+			return total, inputs, values, scripts, nil
+		}
+
+		scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+		tx, err = txauthor.NewUnsignedTransactionSubtractFee(outputs,
+			feeSatPerKb, inputSource,
+			w.changeSource(addrmgrNs, account, opts),
+			secretSource{w.Manager, addrmgrNs, scriptNs, w},
+			opts.subtractFeeFrom())
+		if err != nil {
+			return err
+		}
+		if err := dust.applyDustChange(tx); err != nil {
+			return err
+		}
+		feeCapOverride, err = w.checkFeeCap(dbtx, tx, token, opts)
+		if err != nil {
+			return err
+		}
+
+		// The transaction keeps the version, lock time and input
+		// sequence numbers it was constructed with, and the added
+		// inputs follow the replaceability of the options without
+		// disabling the lock time.
+		tx.Tx.Version = fund.tx.Version
+		tx.Tx.LockTime = fund.tx.LockTime
+		sequence := uint32(SequenceFinal)
+		switch {
+		case opts.replaceable(w):
+			sequence = SequenceReplaceable
+		case fund.tx.LockTime != 0:
+			sequence = SequenceLockTime
+		}
+		for _, txIn := range tx.Tx.TxIn[len(fund.tx.TxIn):] {
+			txIn.Sequence = sequence
+		}
+
+		if tx.ChangeIndex >= 0 {
+			if fund.changePos < 0 {
+				tx.RandomizeChangePosition()
+			} else {
+				moveChangeOutput(tx, fund.changePos)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The hooks may call back into the wallet, so they are not invoked
+	// by the database transaction funding the transaction.
+	hookEvent.Point = HookBeforeSigning
+	hookEvent.Outputs = nil
+	hookEvent.Tx = tx.Tx
+	if err := w.runTxHooks(hookEvent); err != nil {
+		return nil, err
+	}
+	if feeCapOverride != nil {
+		w.auditFeeCapOverride(feeCapOverride)
+	}
+
+	w.saveTxAnnotations(tx.Tx.TxHash(), hookEvent.Annotations)
+	return tx, nil
+}
+
+// PrevOutput returns the output spent by op when it is an output of a wallet
+// transaction, or nil when the transaction is not recorded by the wallet.
+func (w *Wallet) PrevOutput(op *wire.OutPoint) (*wire.TxOut, error) {
+	var prevOut *wire.TxOut
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		var err error
+		prevOut, err = w.prevOutput(dbtx.ReadBucket(wtxmgrNamespaceKey), op)
+		return err
+	})
+	return prevOut, err
+}
+
+func (w *Wallet) prevOutput(txmgrNs walletdb.ReadBucket,
+	op *wire.OutPoint) (*wire.TxOut, error) {
+
+	details, err := w.TxStore.TxDetails(txmgrNs, &op.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if details == nil || op.Index >= uint32(len(details.MsgTx.TxOut)) {
+		return nil, nil
+	}
+	return details.MsgTx.TxOut[op.Index], nil
+}
+
+// moveChangeOutput moves the change output of tx to index pos, shifting the
+// outputs after pos by one.  The change output must be the last output.
+func moveChangeOutput(tx *txauthor.AuthoredTx, pos int) {
+	txOuts := tx.Tx.TxOut
+	change := txOuts[tx.ChangeIndex]
+	copy(txOuts[pos+1:], txOuts[pos:tx.ChangeIndex])
+	txOuts[pos] = change
+	tx.ChangeIndex = pos
+}
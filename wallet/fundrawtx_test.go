@@ -0,0 +1,43 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+)
+
+// TestMoveChangeOutput checks that the change output of a funded transaction
+// is moved to the requested position without reordering the other outputs.
+func TestMoveChangeOutput(t *testing.T) {
+	for pos := 0; pos <= 3; pos++ {
+		tx := &txauthor.AuthoredTx{
+			Tx:          wire.NewMsgTx(wire.TxVersion),
+			ChangeIndex: 3,
+		}
+		for v := int64(1); v <= 4; v++ {
+			tx.Tx.AddTxOut(wire.NewTxOut(v, nil))
+		}
+
+		moveChangeOutput(tx, pos)
+		if tx.ChangeIndex != pos || tx.Tx.TxOut[pos].Value != 4 {
+			t.Fatalf("change output was not moved to %d", pos)
+		}
+		want := int64(1)
+		for i, txOut := range tx.Tx.TxOut {
+			if i == pos {
+				continue
+			}
+			if txOut.Value != want {
+				t.Fatalf("output %d has value %d after moving the "+
+					"change to %d, want %d", i, txOut.Value, pos,
+					want)
+			}
+			want++
+		}
+	}
+}
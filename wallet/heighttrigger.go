@@ -0,0 +1,420 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// DefaultHeightWebhookTimeout is the time a height trigger webhook request may
+// take before it fails.
+const DefaultHeightWebhookTimeout = 10 * time.Second
+
+// maxHeightTriggerLabel is the maximum length of the labels of height
+// triggers.
+const maxHeightTriggerLabel = 1024
+
+// heightTriggersBucketKey is the key of the bucket in the transaction
+// metadata namespace holding the height triggers, keyed by their big endian
+// ID.
+var heightTriggersBucketKey = []byte("heighttriggers")
+
+// ErrUnknownHeightTrigger describes an error where a height trigger which does
+// not exist, was removed, or already fired and was delivered, is removed.
+var ErrUnknownHeightTrigger = errors.New("unknown height trigger")
+
+// HeightTrigger fires when the wallet connects a block at or above its height,
+// such as the height a timelock the wallet is tracking expires.  Fired
+// triggers are passed to the height callbacks and, when Webhook is set,
+// POSTed to the webhook, which is retried at each following block until it
+// accepts them.  FiredHeight is the height of the block which fired the
+// trigger, or zero while the trigger is pending.  Delivered is set once the
+// webhook accepted the trigger, or when the trigger has no webhook.
+type HeightTrigger struct {
+	ID          uint64
+	Height      int32
+	Webhook     string
+	Label       string
+	Created     time.Time
+	FiredHeight int32
+	Delivered   bool
+	Removed     bool
+}
+
+// HeightCallback is called with the height triggers when they fire.  It must
+// not block, since triggers are fired one after the other.
+type HeightCallback func(t *HeightTrigger)
+
+// heightTriggers holds the height callbacks, and signals the height of the
+// latest connected block to the height trigger monitor.  The mutex protects
+// the callbacks, and serializes the firing of the triggers with their
+// removal.  The height is accessed atomically, since blocks are connected
+// while triggers are firing.
+type heightTriggers struct {
+	height    int32
+	reached   chan struct{}
+	mu        sync.Mutex
+	callbacks []HeightCallback
+}
+
+// Height triggers are serialized as such:
+//
+//   [0:8]   Unix time the trigger was created (8 bytes)
+//   [8:12]  Trigger height (4 bytes)
+//   [12:16] Height of the block which fired the trigger, or zero (4 bytes)
+//   [16]    Flags: 1 delivered, 2 removed (1 byte)
+//   [17:]   Webhook URL (varstring) and label (varstring)
+
+const (
+	heightTriggerDelivered = 1 << iota
+	heightTriggerRemoved
+)
+
+func serializeHeightTrigger(t *HeightTrigger) []byte {
+	var buf bytes.Buffer
+	var v [17]byte
+	binary.BigEndian.PutUint64(v[0:8], uint64(t.Created.Unix()))
+	binary.BigEndian.PutUint32(v[8:12], uint32(t.Height))
+	binary.BigEndian.PutUint32(v[12:16], uint32(t.FiredHeight))
+	if t.Delivered {
+		v[16] |= heightTriggerDelivered
+	}
+	if t.Removed {
+		v[16] |= heightTriggerRemoved
+	}
+	buf.Write(v[:])
+	wire.WriteVarString(&buf, 0, t.Webhook)
+	wire.WriteVarString(&buf, 0, t.Label)
+	return buf.Bytes()
+}
+
+func deserializeHeightTrigger(k, v []byte) (*HeightTrigger, error) {
+	if len(k) != 8 || len(v) < 17 {
+		return nil, errors.New("malformed height trigger")
+	}
+	t := &HeightTrigger{
+		ID:          binary.BigEndian.Uint64(k),
+		Created:     time.Unix(int64(binary.BigEndian.Uint64(v[0:8])), 0),
+		Height:      int32(binary.BigEndian.Uint32(v[8:12])),
+		FiredHeight: int32(binary.BigEndian.Uint32(v[12:16])),
+		Delivered:   v[16]&heightTriggerDelivered != 0,
+		Removed:     v[16]&heightTriggerRemoved != 0,
+	}
+	r := bytes.NewReader(v[17:])
+	var err error
+	t.Webhook, err = wire.ReadVarString(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	t.Label, err = wire.ReadVarString(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// RegisterHeightCallback adds a callback called with every height trigger
+// when it fires.
+func (w *Wallet) RegisterHeightCallback(fn HeightCallback) {
+	w.heightTriggers.mu.Lock()
+	w.heightTriggers.callbacks = append(w.heightTriggers.callbacks, fn)
+	w.heightTriggers.mu.Unlock()
+}
+
+// AddHeightTrigger records a trigger firing when the wallet connects a block
+// at or above height.  The optional webhook is an HTTP URL POSTed the trigger
+// when it fires, and the label describes the trigger to the webhook and the
+// height callbacks.  Triggers persist across restarts, and triggers whose
+// height is already reached fire at the next block.
+func (w *Wallet) AddHeightTrigger(height int32, webhook, label string) (*HeightTrigger, error) {
+	if height <= 0 {
+		return nil, errors.New("trigger height must be positive")
+	}
+	if webhook != "" {
+		u, err := url.Parse(webhook)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("webhook URL %q is not an HTTP URL",
+				webhook)
+		}
+	}
+	if len(label) > maxHeightTriggerLabel {
+		return nil, fmt.Errorf("trigger labels may not exceed %d bytes",
+			maxHeightTriggerLabel)
+	}
+
+	t := &HeightTrigger{
+		Height:  height,
+		Webhook: webhook,
+		Label:   label,
+		Created: time.Unix(time.Now().Unix(), 0),
+	}
+	err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket, err := ns.CreateBucketIfNotExists(heightTriggersBucketKey)
+		if err != nil {
+			return err
+		}
+		t.ID = 1
+		if k, _ := bucket.ReadCursor().Last(); len(k) == 8 {
+			t.ID = binary.BigEndian.Uint64(k) + 1
+		}
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], t.ID)
+		return bucket.Put(k[:], serializeHeightTrigger(t))
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Added height trigger %d at height %d", t.ID, height)
+	return t, nil
+}
+
+// HeightTriggers returns the height triggers which are pending, or fired but
+// not yet accepted by their webhooks, ordered by ID.
+func (w *Wallet) HeightTriggers() ([]HeightTrigger, error) {
+	var triggers []HeightTrigger
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(heightTriggersBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			t, err := deserializeHeightTrigger(k, v)
+			if err != nil {
+				return err
+			}
+			if !t.Delivered && !t.Removed {
+				triggers = append(triggers, *t)
+			}
+			return nil
+		})
+	})
+	return triggers, err
+}
+
+// RemoveHeightTrigger removes a height trigger which is pending, or whose
+// webhook is still retried.  Removed triggers are kept so that their IDs are
+// never reused.
+func (w *Wallet) RemoveHeightTrigger(id uint64) error {
+	w.heightTriggers.mu.Lock()
+	defer w.heightTriggers.mu.Unlock()
+
+	err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		bucket := dbtx.ReadWriteBucket(wtxmetaNamespaceKey).
+			NestedReadWriteBucket(heightTriggersBucketKey)
+		if bucket == nil {
+			return ErrUnknownHeightTrigger
+		}
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], id)
+		v := bucket.Get(k[:])
+		if v == nil {
+			return ErrUnknownHeightTrigger
+		}
+		t, err := deserializeHeightTrigger(k[:], v)
+		if err != nil {
+			return err
+		}
+		if t.Delivered || t.Removed {
+			return ErrUnknownHeightTrigger
+		}
+		t.Removed = true
+		return bucket.Put(k[:], serializeHeightTrigger(t))
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Removed height trigger %d", id)
+	return nil
+}
+
+// heightReached signals a connected block to the height trigger monitor.
+func (w *Wallet) heightReached(height int32) {
+	atomic.StoreInt32(&w.heightTriggers.height, height)
+	select {
+	case w.heightTriggers.reached <- struct{}{}:
+	default:
+	}
+}
+
+// heightTriggerMonitor fires the height triggers as blocks are connected.  It
+// must be run as a goroutine.
+func (w *Wallet) heightTriggerMonitor() {
+	defer w.wg.Done()
+
+	quit := w.quitChan()
+	for {
+		select {
+		case <-w.heightTriggers.reached:
+			w.fireHeightTriggers()
+		case <-quit:
+			return
+		}
+	}
+}
+
+// fireHeightTriggers fires the pending height triggers whose height is
+// reached, and POSTs the fired triggers not yet accepted to their webhooks.
+func (w *Wallet) fireHeightTriggers() {
+	w.heightTriggers.mu.Lock()
+	defer w.heightTriggers.mu.Unlock()
+	height := atomic.LoadInt32(&w.heightTriggers.height)
+
+	// Most blocks fire no trigger, so the triggers are first read without
+	// a write transaction.
+	var due bool
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(heightTriggersBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			t, err := deserializeHeightTrigger(k, v)
+			if err != nil {
+				return err
+			}
+			if !t.Delivered && !t.Removed && t.Height <= height {
+				due = true
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		log.Errorf("Cannot read height triggers: %v", err)
+		return
+	}
+	if !due {
+		return
+	}
+
+	var fired, undelivered []*HeightTrigger
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		fired, undelivered = nil, nil
+		bucket := dbtx.ReadWriteBucket(wtxmetaNamespaceKey).
+			NestedReadWriteBucket(heightTriggersBucketKey)
+		err := bucket.ForEach(func(k, v []byte) error {
+			t, err := deserializeHeightTrigger(k, v)
+			if err != nil {
+				return err
+			}
+			if t.Delivered || t.Removed || t.Height > height {
+				return nil
+			}
+			if t.FiredHeight == 0 {
+				t.FiredHeight = height
+				t.Delivered = t.Webhook == ""
+				fired = append(fired, t)
+			}
+			if !t.Delivered {
+				undelivered = append(undelivered, t)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return putHeightTriggers(bucket, fired)
+	})
+	if err != nil {
+		log.Errorf("Cannot fire height triggers: %v", err)
+		return
+	}
+
+	for _, t := range fired {
+		log.Infof("Height trigger %d fired at height %d", t.ID,
+			t.FiredHeight)
+		for _, fn := range w.heightTriggers.callbacks {
+			fn(t)
+		}
+	}
+
+	var delivered []*HeightTrigger
+	for _, t := range undelivered {
+		if err := postHeightTrigger(t); err != nil {
+			log.Warnf("Cannot post height trigger %d to its webhook, "+
+				"retrying at the next block: %v", t.ID, err)
+			continue
+		}
+		t.Delivered = true
+		delivered = append(delivered, t)
+	}
+	if len(delivered) == 0 {
+		return
+	}
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		bucket := dbtx.ReadWriteBucket(wtxmetaNamespaceKey).
+			NestedReadWriteBucket(heightTriggersBucketKey)
+		return putHeightTriggers(bucket, delivered)
+	})
+	if err != nil {
+		log.Errorf("Cannot record delivered height triggers: %v", err)
+	}
+}
+
+// putHeightTriggers records height triggers in the height triggers bucket.
+func putHeightTriggers(bucket walletdb.ReadWriteBucket, triggers []*HeightTrigger) error {
+	for _, t := range triggers {
+		k := make([]byte, 8)
+		binary.BigEndian.PutUint64(k, t.ID)
+		if err := bucket.Put(k, serializeHeightTrigger(t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// heightWebhookClient is the HTTP client POSTing the height triggers.
+var heightWebhookClient = &http.Client{Timeout: DefaultHeightWebhookTimeout}
+
+type heightTriggerJSON struct {
+	ID          uint64 `json:"id"`
+	Height      int32  `json:"height"`
+	Label       string `json:"label"`
+	FiredHeight int32  `json:"firedheight"`
+}
+
+// postHeightTrigger POSTs a fired height trigger to its webhook.
+func postHeightTrigger(t *HeightTrigger) error {
+	buf, err := json.Marshal(&heightTriggerJSON{
+		ID:          t.ID,
+		Height:      t.Height,
+		Label:       t.Label,
+		FiredHeight: t.FiredHeight,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := heightWebhookClient.Post(t.Webhook, "application/json",
+		bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %s",
+			resp.Status)
+	}
+	return nil
+}
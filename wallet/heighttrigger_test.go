@@ -0,0 +1,146 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestHeightTriggers checks that height triggers fire once when their height
+// is reached, and that their webhooks are retried until they accept them.
+func TestHeightTriggers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "heighttrigger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+
+	var (
+		mu     sync.Mutex
+		fail   = true
+		posted []heightTriggerJSON
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter,
+		r *http.Request) {
+
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var p heightTriggerJSON
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("cannot decode posted trigger: %v", err)
+		}
+		posted = append(posted, p)
+	}))
+	defer srv.Close()
+
+	var fired []uint64
+	w.RegisterHeightCallback(func(t *HeightTrigger) {
+		fired = append(fired, t.ID)
+	})
+
+	if _, err := w.AddHeightTrigger(100, "ftp://example.com", ""); err == nil {
+		t.Fatal("trigger added with a non-HTTP webhook")
+	}
+	a, err := w.AddHeightTrigger(100, "", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := w.AddHeightTrigger(101, srv.URL, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := w.AddHeightTrigger(200, "", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err := w.AddHeightTrigger(50, "", "removed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.RemoveHeightTrigger(removed.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.RemoveHeightTrigger(removed.ID); err != ErrUnknownHeightTrigger {
+		t.Fatalf("removed trigger removed again: %v", err)
+	}
+
+	w.heightReached(100)
+	w.fireHeightTriggers()
+	if len(fired) != 1 || fired[0] != a.ID {
+		t.Fatalf("fired triggers %v at height 100, want [%d]", fired, a.ID)
+	}
+
+	// The failing webhook is retried at the next blocks, and the
+	// triggers are only fired once.
+	w.heightReached(150)
+	w.fireHeightTriggers()
+	w.heightReached(151)
+	w.fireHeightTriggers()
+	if len(fired) != 2 || fired[1] != b.ID {
+		t.Fatalf("fired triggers %v at height 150, want [%d %d]",
+			fired, a.ID, b.ID)
+	}
+	triggers, err := w.HeightTriggers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(triggers) != 2 || triggers[0].ID != b.ID ||
+		triggers[0].FiredHeight != 150 || triggers[1].ID != c.ID ||
+		triggers[1].FiredHeight != 0 {
+
+		t.Fatalf("unexpected triggers %+v", triggers)
+	}
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	w.heightReached(152)
+	w.fireHeightTriggers()
+	w.heightReached(153)
+	w.fireHeightTriggers()
+	if len(posted) != 1 || posted[0].ID != b.ID || posted[0].Label != "b" ||
+		posted[0].Height != 101 || posted[0].FiredHeight != 150 {
+
+		t.Fatalf("unexpected posted triggers %+v", posted)
+	}
+	triggers, err = w.HeightTriggers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(triggers) != 1 || triggers[0].ID != c.ID {
+		t.Fatalf("unexpected triggers %+v", triggers)
+	}
+	if err := w.RemoveHeightTrigger(b.ID); err != ErrUnknownHeightTrigger {
+		t.Fatalf("delivered trigger removed: %v", err)
+	}
+}
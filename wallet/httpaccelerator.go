@@ -0,0 +1,120 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultAccelerationTimeout is the time an HTTPAccelerator request may take
+// before it fails.
+const DefaultAccelerationTimeout = 30 * time.Second
+
+// HTTPAccelerator is a TxAccelerator submitting transactions to a third-party
+// acceleration service over HTTP, such as the API of a mining pool or a
+// proxy in front of it.  Each transaction is POSTed as a JSON object with the
+// txid and hex keys.  The service accepts the transaction with a 2xx status
+// and a JSON object with the optional reference and message keys, and refuses
+// it with any other status, optionally explained by the message key.
+// Requests are authenticated with a bearer token when an API key is
+// configured.
+type HTTPAccelerator struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPAccelerator returns an accelerator for the service at the passed
+// URL.
+func NewHTTPAccelerator(serviceURL, apiKey string) (*HTTPAccelerator, error) {
+	u, err := url.Parse(serviceURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("accelerator URL %q is not an HTTP URL",
+			serviceURL)
+	}
+	return &HTTPAccelerator{
+		url:    serviceURL,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: DefaultAccelerationTimeout},
+	}, nil
+}
+
+type httpAccelerationRequest struct {
+	TxID string `json:"txid"`
+	Hex  string `json:"hex"`
+}
+
+type httpAccelerationResponse struct {
+	Reference string `json:"reference"`
+	Message   string `json:"message"`
+}
+
+// Name returns the host of the service.
+//
+// This is part of the TxAccelerator interface implementation.
+func (a *HTTPAccelerator) Name() string {
+	u, err := url.Parse(a.url)
+	if err != nil {
+		return a.url
+	}
+	return u.Host
+}
+
+// Accelerate POSTs the transaction to the service.
+//
+// This is part of the TxAccelerator interface implementation.
+func (a *HTTPAccelerator) Accelerate(req *AccelerationRequest) (*AccelerationReceipt, error) {
+	var buf bytes.Buffer
+	buf.Grow(req.Tx.SerializeSize())
+	if err := req.Tx.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(&httpAccelerationRequest{
+		TxID: req.TxHash.String(),
+		Hex:  hex.EncodeToString(buf.Bytes()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest("POST", a.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if a.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+a.apiKey)
+	}
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Services may respond without a body, so the response is only
+	// decoded on a best effort basis.
+	var r httpAccelerationResponse
+	json.NewDecoder(resp.Body).Decode(&r)
+	if resp.StatusCode/100 != 2 {
+		if r.Message != "" {
+			return nil, fmt.Errorf("accelerator responded %s: %s",
+				resp.Status, r.Message)
+		}
+		return nil, fmt.Errorf("accelerator responded %s", resp.Status)
+	}
+	return &AccelerationReceipt{
+		Reference: r.Reference,
+		Message:   r.Message,
+	}, nil
+}
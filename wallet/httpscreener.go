@@ -0,0 +1,126 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultScreeningTimeout is the time an HTTPScreener request may take
+// before it fails.
+const DefaultScreeningTimeout = 10 * time.Second
+
+// HTTPScreener is an AddressScreener querying a compliance provider over
+// HTTP.  Each request is POSTed as a JSON object with the direction, txid and
+// addresses keys, and the provider must respond with a JSON object whose
+// results key holds an array of objects with the address, risk and reason
+// keys.  Requests are authenticated with a bearer token when an API key is
+// configured.
+type HTTPScreener struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPScreener returns a screener for the provider at the passed URL.
+func NewHTTPScreener(providerURL, apiKey string) (*HTTPScreener, error) {
+	u, err := url.Parse(providerURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("screening provider URL %q is not an "+
+			"HTTP URL", providerURL)
+	}
+	return &HTTPScreener{
+		url:    providerURL,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: DefaultScreeningTimeout},
+	}, nil
+}
+
+type httpScreeningRequest struct {
+	Direction string   `json:"direction"`
+	TxID      string   `json:"txid"`
+	Addresses []string `json:"addresses"`
+}
+
+type httpScreeningResponse struct {
+	Results []struct {
+		Address string `json:"address"`
+		Risk    string `json:"risk"`
+		Reason  string `json:"reason"`
+	} `json:"results"`
+}
+
+// Name returns the host of the provider.
+//
+// This is part of the AddressScreener interface implementation.
+func (s *HTTPScreener) Name() string {
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return s.url
+	}
+	return u.Host
+}
+
+// ScreenAddresses POSTs the request to the provider.
+//
+// This is part of the AddressScreener interface implementation.
+func (s *HTTPScreener) ScreenAddresses(req *ScreeningRequest) ([]ScreeningResult, error) {
+	body := httpScreeningRequest{
+		Direction: req.Direction.String(),
+		TxID:      req.TxHash.String(),
+		Addresses: make([]string, len(req.Addresses)),
+	}
+	for i, addr := range req.Addresses {
+		body.Addresses[i] = addr.EncodeAddress()
+	}
+	buf, err := json.Marshal(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest("POST", s.url, bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil, fmt.Errorf("screening provider responded %s",
+			resp.Status)
+	}
+	var r httpScreeningResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("invalid screening response: %v", err)
+	}
+
+	results := make([]ScreeningResult, len(r.Results))
+	for i, res := range r.Results {
+		results[i] = ScreeningResult{
+			Address: res.Address,
+			Risk:    res.Risk,
+			Reason:  res.Reason,
+		}
+	}
+	return results, nil
+}
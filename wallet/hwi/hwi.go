@@ -0,0 +1,187 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package hwi signs transactions with hardware wallets such as Ledger and
+// Trezor devices through the HWI (Hardware Wallet Interface) command line
+// program, which exchanges PSBTs with the devices.
+package hwi
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/wallet/psbt"
+)
+
+// DefaultTimeout is the time the HWI program is given to respond.  Signing
+// waits for the user to confirm the transaction on the device, so it is
+// generous.
+const DefaultTimeout = 5 * time.Minute
+
+// Error is an error reported by the HWI program or the device.
+type Error struct {
+	Code    int
+	Message string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("hwi: %s (code %d)", e.Message, e.Code)
+}
+
+// Device is a hardware wallet identified by the fingerprint of its master
+// key.  It implements the wallet.AttestingSigner interface.
+type Device struct {
+	command     string
+	fingerprint string
+	chain       string
+	timeout     time.Duration
+}
+
+// New returns the device with the passed master key fingerprint, in hex,
+// driven by the HWI program at command.
+func New(command, fingerprint string, params *chaincfg.Params) (*Device, error) {
+	fp, err := hex.DecodeString(fingerprint)
+	if err != nil || len(fp) != 4 {
+		return nil, fmt.Errorf("invalid master key fingerprint %q",
+			fingerprint)
+	}
+	return &Device{
+		command:     command,
+		fingerprint: hex.EncodeToString(fp),
+		chain:       chainName(params),
+		timeout:     DefaultTimeout,
+	}, nil
+}
+
+// chainName returns the HWI name of the network.  Networks unknown to HWI
+// use the test network, whose keys and addresses are shared by the test
+// networks.
+func chainName(params *chaincfg.Params) string {
+	switch params.Net {
+	case chaincfg.MainNetParams.Net:
+		return "main"
+	case chaincfg.RegressionNetParams.Net:
+		return "regtest"
+	default:
+		return "test"
+	}
+}
+
+// SetTimeout changes the time the HWI program is given to respond.
+func (d *Device) SetTimeout(timeout time.Duration) {
+	d.timeout = timeout
+}
+
+// Fingerprint returns the hex encoded master key fingerprint of the device.
+func (d *Device) Fingerprint() string {
+	return d.fingerprint
+}
+
+// run runs an HWI command for the device and decodes its JSON output into
+// result.
+func (d *Device) run(result interface{}, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	args = append([]string{"--fingerprint", d.fingerprint, "--chain",
+		d.chain}, args...)
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, d.command, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	// HWI reports errors as a JSON object on stdout, with a zero or
+	// non-zero exit status depending on the version.
+	var hwiErr struct {
+		Error *string `json:"error"`
+		Code  int     `json:"code"`
+	}
+	if json.Unmarshal(stdout.Bytes(), &hwiErr) == nil && hwiErr.Error != nil {
+		return &Error{Code: hwiErr.Code, Message: *hwiErr.Error}
+	}
+	if runErr != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) != 0 {
+			return fmt.Errorf("%v: %s", runErr, msg)
+		}
+		return runErr
+	}
+	if err := json.Unmarshal(stdout.Bytes(), result); err != nil {
+		return fmt.Errorf("invalid hwi output: %v", err)
+	}
+	return nil
+}
+
+// SignPsbt has the device sign the inputs of the packet derived from its
+// master key.
+//
+// This is part of the wallet.Signer interface implementation.
+func (d *Device) SignPsbt(packet *psbt.Packet) (*psbt.Packet, error) {
+	encoded, err := packet.Encode()
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Psbt string `json:"psbt"`
+	}
+	if err := d.run(&result, "signtx", encoded); err != nil {
+		return nil, err
+	}
+	if result.Psbt == "" {
+		return nil, errors.New("hwi did not return a PSBT")
+	}
+	return psbt.Decode(result.Psbt)
+}
+
+// AccountXpub returns the extended public key of the device at the BIP0032
+// derivation path, such as m/84h/0h/0h.
+func (d *Device) AccountXpub(path string) (string, error) {
+	var result struct {
+		Xpub string `json:"xpub"`
+	}
+	if err := d.run(&result, "getxpub", path); err != nil {
+		return "", err
+	}
+	if result.Xpub == "" {
+		return "", errors.New("hwi did not return an extended public key")
+	}
+	return result.Xpub, nil
+}
+
+// Attestation identifies the device by the type and model HWI enumerates it
+// with.  HWI does not expose the attestation certificates of the devices, so
+// the attestation carries no evidence.
+//
+// This is part of the wallet.AttestingSigner interface implementation.
+func (d *Device) Attestation() (*wallet.SignerAttestation, error) {
+	var devices []struct {
+		Type        string `json:"type"`
+		Model       string `json:"model"`
+		Fingerprint string `json:"fingerprint"`
+	}
+	if err := d.run(&devices, "enumerate"); err != nil {
+		return nil, err
+	}
+	for _, dev := range devices {
+		if dev.Fingerprint != d.fingerprint {
+			continue
+		}
+		return &wallet.SignerAttestation{
+			Signer:      "hwi",
+			DeviceType:  dev.Type,
+			Model:       dev.Model,
+			Fingerprint: d.fingerprint,
+		}, nil
+	}
+	return nil, fmt.Errorf("device %s is not connected", d.fingerprint)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hwi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/psbt"
+)
+
+// fakeHWI records its arguments next to itself, returns the PSBT passed to
+// signtx unchanged, enumerates a single device and fails every other command
+// like HWI does when no device is connected.
+const fakeHWI = `#!/bin/sh
+echo "$@" > "$0.args"
+if [ "$5" = signtx ]; then
+	printf '{"psbt": "%s"}\n' "$6"
+	exit 0
+fi
+if [ "$5" = enumerate ]; then
+	echo '[{"type": "trezor", "model": "trezor_t", "fingerprint": "5a3469b6"}]'
+	exit 0
+fi
+echo '{"error": "Could not find device", "code": -3}'
+exit 1
+`
+
+func TestDevice(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake hwi program is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "hwi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	command := filepath.Join(dir, "hwi")
+	if err := ioutil.WriteFile(command, []byte(fakeHWI), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(command, "5a3469", &chaincfg.MainNetParams); err == nil {
+		t.Fatalf("short fingerprint was accepted")
+	}
+	d, err := New(command, "5A3469B6", &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_TRUE}))
+	packet, err := psbt.New(tx)
+	if err != nil {
+		t.Fatalf("psbt.New: %v", err)
+	}
+	signed, err := d.SignPsbt(packet)
+	if err != nil {
+		t.Fatalf("SignPsbt: %v", err)
+	}
+	if signed.UnsignedTx.TxHash() != tx.TxHash() {
+		t.Errorf("returned packet has a different transaction")
+	}
+	args, err := ioutil.ReadFile(command + ".args")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--fingerprint 5a3469b6 --chain test signtx "
+	if !strings.HasPrefix(string(args), want) {
+		t.Errorf("hwi arguments %q, want prefix %q", args, want)
+	}
+
+	a, err := d.Attestation()
+	if err != nil {
+		t.Fatalf("Attestation: %v", err)
+	}
+	if a.Signer != "hwi" || a.DeviceType != "trezor" ||
+		a.Model != "trezor_t" || a.Fingerprint != "5a3469b6" {
+
+		t.Errorf("unexpected attestation %+v", a)
+	}
+
+	_, err = d.AccountXpub("m/84h/1h/0h")
+	hwiErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	if hwiErr.Code != -3 || hwiErr.Message != "Could not find device" {
+		t.Errorf("unexpected error %+v", hwiErr)
+	}
+}
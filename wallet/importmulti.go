@@ -0,0 +1,198 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// importTimestampWindow is subtracted from the timestamps of imports before
+// locating the first block to rescan, since block timestamps are only
+// required to be later than the median time of the previous blocks and may
+// be up to two hours in the future.
+const importTimestampWindow = 2 * time.Hour
+
+// Import describes a private key, watch-only address or witness script
+// imported by ImportMulti.  Exactly one of WIF, Address and Script must be
+// set.
+type Import struct {
+	WIF     *btcutil.WIF
+	Address btcutil.Address
+	Script  []byte
+
+	// Account is the account a watch-only address is imported to.
+	Account uint32
+
+	// Timestamp is the time the key, address or script was first used on
+	// the chain.  The zero time rescans from the genesis block.
+	Timestamp time.Time
+}
+
+// ImportMulti imports many private keys, watch-only addresses and witness
+// scripts, and submits a single rescan for all of them starting at the block
+// of the earliest import timestamp.  The private keys are imported together
+// in a single database transaction, encrypted concurrently by the address
+// manager, so that thousands of keys may be imported at once.  The returned
+// slice holds the error of each import, which are independent from each
+// other.  The error return is reserved for failures affecting every import.
+func (w *Wallet) ImportMulti(scope waddrmgr.KeyScope, imports []Import,
+	rescan bool) ([]error, error) {
+
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(imports))
+	var addrs []btcutil.Address
+	var start *waddrmgr.BlockStamp
+	useBlock := func(bs *waddrmgr.BlockStamp) {
+		if start == nil || bs.Height < start.Height {
+			start = bs
+		}
+	}
+
+	// Imports commonly share timestamps, so the block of each timestamp
+	// is only searched for once.
+	blocks := make(map[int64]*waddrmgr.BlockStamp)
+
+	// Private keys are collected and imported after the other imports.
+	var wifs []*btcutil.WIF
+	var wifIndexes []int
+	var wifBlocks []*waddrmgr.BlockStamp
+
+	for i := range imports {
+		imp := &imports[i]
+		bs, ok := blocks[imp.Timestamp.Unix()]
+		if !ok {
+			bs, err = timestampBlock(chainClient, imp.Timestamp)
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			blocks[imp.Timestamp.Unix()] = bs
+		}
+
+		var impAddrs []btcutil.Address
+		switch {
+		case imp.WIF != nil && imp.Address == nil && imp.Script == nil:
+			wifs = append(wifs, imp.WIF)
+			wifIndexes = append(wifIndexes, i)
+			wifBlocks = append(wifBlocks, bs)
+			continue
+
+		case imp.Address != nil && imp.WIF == nil && imp.Script == nil:
+			err = w.importAddress(scope, imp.Address, imp.Account)
+			impAddrs = []btcutil.Address{imp.Address}
+
+		case imp.Script != nil && imp.WIF == nil && imp.Address == nil:
+			var p2wshAddr, p2shAddr btcutil.Address
+			p2wshAddr, p2shAddr, err = w.ImportWitnessScript(imp.Script)
+			impAddrs = []btcutil.Address{p2wshAddr, p2shAddr}
+
+		default:
+			err = errors.New("exactly one of a private key, address " +
+				"or script must be imported")
+		}
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		addrs = append(addrs, impAddrs...)
+		useBlock(bs)
+	}
+
+	if len(wifs) != 0 {
+		wifStart := wifBlocks[0]
+		for _, bs := range wifBlocks[1:] {
+			if bs.Height < wifStart.Height {
+				wifStart = bs
+			}
+		}
+		wifAddrs, wifErrs, err := w.importPrivateKeys(scope, wifs,
+			wifStart)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range wifIndexes {
+			if wifErrs[j] != nil {
+				errs[i] = wifErrs[j]
+				continue
+			}
+			addrs = append(addrs, wifAddrs[j])
+			useBlock(wifBlocks[j])
+		}
+	}
+
+	if len(addrs) == 0 {
+		return errs, nil
+	}
+
+	if rescan {
+		log.Infof("Rescanning for %d imported addresses from block %v "+
+			"(height %d)", len(addrs), start.Hash, start.Height)
+
+		// Do not block on finishing the rescan, which is logged
+		// elsewhere.
+		_ = w.SubmitRescan(&RescanJob{
+			Addrs:      addrs,
+			BlockStamp: *start,
+		})
+		return errs, nil
+	}
+	return errs, chainClient.NotifyReceived(addrs)
+}
+
+// timestampBlock returns the first block of the main chain with a timestamp
+// within importTimestampWindow of t, or the genesis block for the zero time.
+func timestampBlock(chainClient chain.Interface, t time.Time) (*waddrmgr.BlockStamp, error) {
+	blockStamp := func(height int32) (*waddrmgr.BlockStamp, error) {
+		hash, err := chainClient.GetBlockHash(int64(height))
+		if err != nil {
+			return nil, err
+		}
+		header, err := chainClient.GetBlockHeader(hash)
+		if err != nil {
+			return nil, err
+		}
+		return &waddrmgr.BlockStamp{
+			Height:    height,
+			Hash:      *hash,
+			Timestamp: header.Timestamp,
+		}, nil
+	}
+
+	if t.IsZero() {
+		return blockStamp(0)
+	}
+	t = t.Add(-importTimestampWindow)
+
+	_, bestHeight, err := chainClient.GetBestBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Binary search for the first block with a timestamp not before t.
+	low, high := int32(0), bestHeight
+	for low < high {
+		mid := low + (high-low)/2
+		bs, err := blockStamp(mid)
+		if err != nil {
+			return nil, err
+		}
+		if bs.Timestamp.Before(t) {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	return blockStamp(low)
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/chain"
+)
+
+// headerChain is a chain client serving the headers of a fixed chain.
+type headerChain struct {
+	chain.Interface
+	headers []wire.BlockHeader
+}
+
+func (c *headerChain) GetBestBlock() (*chainhash.Hash, int32, error) {
+	hash := c.headers[len(c.headers)-1].BlockHash()
+	return &hash, int32(len(c.headers) - 1), nil
+}
+
+func (c *headerChain) GetBlockHash(height int64) (*chainhash.Hash, error) {
+	if height < 0 || height >= int64(len(c.headers)) {
+		return nil, errors.New("block height out of range")
+	}
+	hash := c.headers[height].BlockHash()
+	return &hash, nil
+}
+
+func (c *headerChain) GetBlockHeader(hash *chainhash.Hash) (*wire.BlockHeader, error) {
+	for i := range c.headers {
+		if c.headers[i].BlockHash() == *hash {
+			return &c.headers[i], nil
+		}
+	}
+	return nil, errors.New("unknown block")
+}
+
+// TestTimestampBlock checks that imports are rescanned from the first block
+// within the timestamp window of their timestamp.
+func TestTimestampBlock(t *testing.T) {
+	genesis := time.Unix(1500000000, 0)
+	c := &headerChain{headers: make([]wire.BlockHeader, 100)}
+	for i := range c.headers {
+		c.headers[i].Nonce = uint32(i)
+		c.headers[i].Timestamp = genesis.Add(time.Duration(i) * 10 * time.Minute)
+	}
+
+	tests := []struct {
+		timestamp time.Time
+		height    int32
+	}{
+		{time.Time{}, 0},
+		{genesis, 0},
+		{genesis.Add(5 * time.Hour), 18},
+		{genesis.Add(5*time.Hour + time.Minute), 19},
+		{genesis.Add(1000 * time.Hour), 99},
+	}
+	for _, test := range tests {
+		bs, err := timestampBlock(c, test.timestamp)
+		if err != nil {
+			t.Fatalf("timestampBlock(%v): %v", test.timestamp, err)
+		}
+		if bs.Height != test.height {
+			t.Errorf("timestampBlock(%v) returned height %d, want %d",
+				test.timestamp, bs.Height, test.height)
+		}
+		if bs.Hash != c.headers[test.height].BlockHash() {
+			t.Errorf("timestampBlock(%v) returned hash %v of another "+
+				"block", test.timestamp, bs.Hash)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+const (
+	// integritySuffix is appended to the path of a wallet database to
+	// name the file holding its checksums.
+	integritySuffix = ".sum"
+
+	// backupDirName is the directory, next to the wallet database, of the
+	// backups made each time a verified wallet database is opened.
+	backupDirName = "backups"
+
+	// maxBackups is the number of backups kept.
+	maxBackups = 3
+)
+
+// errNoChecksums is returned when a wallet database has no checksums, which
+// happens after it was not closed cleanly.
+var errNoChecksums = errors.New("wallet database has no checksums")
+
+// IntegrityError describes a wallet database whose contents do not match the
+// checksums written when it was last closed.
+type IntegrityError struct {
+	// Path is the path of the wallet database.
+	Path string
+
+	// Part is the corrupted part of the database: "wallet" for the keys,
+	// addresses and metadata, "tx" for the transaction history, or "utxo"
+	// for the outputs received by the wallet.
+	Part string
+}
+
+// Error implements the error interface.
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("wallet database %s is corrupt: %s data does not "+
+		"match its checksum", e.Path, e.Part)
+}
+
+// dbChecksums are the SHA-256 checksums of the parts of a wallet database.
+type dbChecksums struct {
+	Wallet string `json:"wallet"`
+	Tx     string `json:"tx"`
+	Utxo   string `json:"utxo"`
+}
+
+// hashItem writes a length prefixed item to h.
+func hashItem(h hash.Hash, item []byte) {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(item)))
+	h.Write(n[:])
+	h.Write(item)
+}
+
+// hashPair writes a key/value pair of bucket b to h.  Nested buckets are
+// written recursively, with a marker to tell them from values.
+func hashPair(h hash.Hash, b walletdb.ReadBucket, k, v []byte) error {
+	hashItem(h, k)
+	if v == nil {
+		if nested := b.NestedReadBucket(k); nested != nil {
+			h.Write([]byte{1})
+			return hashBucket(h, nested)
+		}
+	}
+	h.Write([]byte{0})
+	hashItem(h, v)
+	return nil
+}
+
+// hashBucket writes every key/value pair of bucket b to h.
+func hashBucket(h hash.Hash, b walletdb.ReadBucket) error {
+	return b.ForEach(func(k, v []byte) error {
+		return hashPair(h, b, k, v)
+	})
+}
+
+// computeChecksums returns the checksums of the contents of a wallet
+// database.
+func computeChecksums(db walletdb.DB) (*dbChecksums, error) {
+	walletHash, txHash, utxoHash := sha256.New(), sha256.New(), sha256.New()
+	err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+		keys := append([][]byte{waddrmgrNamespaceKey}, optionalNamespaceKeys...)
+		for _, key := range keys {
+			ns := tx.ReadBucket(key)
+			if ns == nil {
+				continue
+			}
+			hashItem(walletHash, key)
+			if err := hashBucket(walletHash, ns); err != nil {
+				return err
+			}
+		}
+
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		if txmgrNs == nil {
+			return nil
+		}
+		return txmgrNs.ForEach(func(k, v []byte) error {
+			if wtxmgr.IsCreditBucket(k) {
+				return hashPair(utxoHash, txmgrNs, k, v)
+			}
+			return hashPair(txHash, txmgrNs, k, v)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dbChecksums{
+		Wallet: hex.EncodeToString(walletHash.Sum(nil)),
+		Tx:     hex.EncodeToString(txHash.Sum(nil)),
+		Utxo:   hex.EncodeToString(utxoHash.Sum(nil)),
+	}, nil
+}
+
+// writeChecksums writes checksums to the file at path.
+func writeChecksums(path string, sums *dbChecksums) error {
+	b, err := json.Marshal(sums)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readChecksums reads the checksums of the file at path.  errNoChecksums is
+// returned if the file does not exist.
+func readChecksums(path string) (*dbChecksums, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errNoChecksums
+	}
+	if err != nil {
+		return nil, err
+	}
+	sums := new(dbChecksums)
+	if err := json.Unmarshal(b, sums); err != nil {
+		return nil, fmt.Errorf("invalid checksum file %s: %v", path, err)
+	}
+	return sums, nil
+}
+
+// verifyChecksums verifies the contents of the wallet database at dbPath
+// against the checksums written when it was closed.  It returns the
+// checksums, or an *IntegrityError if a part of the database is corrupt.
+func verifyChecksums(db walletdb.DB, dbPath string) (*dbChecksums, error) {
+	want, err := readChecksums(dbPath + integritySuffix)
+	if err != nil {
+		return nil, err
+	}
+	got, err := computeChecksums(db)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case got.Wallet != want.Wallet:
+		return nil, &IntegrityError{Path: dbPath, Part: "wallet"}
+	case got.Tx != want.Tx:
+		return nil, &IntegrityError{Path: dbPath, Part: "tx"}
+	case got.Utxo != want.Utxo:
+		return nil, &IntegrityError{Path: dbPath, Part: "utxo"}
+	}
+	return got, nil
+}
+
+// openVerified opens the wallet database at dbPath and verifies it against
+// the checksums written when it was last closed.  A verified database is
+// backed up, and a corrupt database, including one that can not be opened, is
+// replaced with the most recent valid backup.
+func openVerified(dbPath string) (walletdb.DB, error) {
+	db, err := walletdb.Open("bdb", dbPath)
+	if err == walletdb.ErrDbDoesNotExist {
+		return nil, err
+	}
+	if err != nil {
+		return restoreAndOpen(dbPath, err)
+	}
+
+	sums, err := verifyChecksums(db, dbPath)
+	switch err.(type) {
+	case nil:
+		err := backupWallet(db, filepath.Dir(dbPath), sums)
+		if err != nil {
+			log.Warnf("Unable to back up the wallet database: %v", err)
+		}
+	case *IntegrityError:
+		db.Close()
+		return restoreAndOpen(dbPath, err)
+	default:
+		if err != errNoChecksums {
+			db.Close()
+			return nil, err
+		}
+		log.Warnf("Wallet database %s has no checksums, as after an "+
+			"unclean shutdown, so its integrity is not verified", dbPath)
+	}
+
+	// The database changes once the wallet is opened, so it has no valid
+	// checksums until it is closed.
+	if err := removeChecksums(dbPath); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// restoreAndOpen restores the most recent valid backup of the corrupt wallet
+// database at dbPath and opens it.
+func restoreAndOpen(dbPath string, cause error) (walletdb.DB, error) {
+	log.Errorf("%v", cause)
+	backup, err := restoreBackup(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", cause, err)
+	}
+	log.Warnf("Restored the wallet database from the backup %s.  "+
+		"Transactions since the backup are recovered when the wallet "+
+		"syncs", backup)
+
+	db, err := walletdb.Open("bdb", dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := removeChecksums(dbPath); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// removeChecksums removes the checksum file of the wallet database at dbPath.
+func removeChecksums(dbPath string) error {
+	err := os.Remove(dbPath + integritySuffix)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// closeWithChecksums writes the checksums of the wallet database at dbPath
+// and closes it.  The wallet must be stopped so the database no longer
+// changes.
+func closeWithChecksums(db walletdb.DB, dbPath string) error {
+	sums, err := computeChecksums(db)
+	if err == nil {
+		err = writeChecksums(dbPath+integritySuffix, sums)
+	}
+	if err != nil {
+		log.Warnf("Unable to write wallet database checksums: %v", err)
+	}
+	return db.Close()
+}
+
+// backupWallet copies a verified wallet database with its checksums to the
+// backup directory and removes the oldest backups.
+func backupWallet(db walletdb.DB, dbDir string, sums *dbChecksums) error {
+	backupDir := filepath.Join(dbDir, backupDirName)
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return err
+	}
+
+	name := "wallet-" + time.Now().UTC().Format("20060102T150405") + ".db"
+	path := filepath.Join(backupDir, name)
+	f, err := os.OpenFile(path+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	err = db.Copy(f)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(path + ".tmp")
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	if err := writeChecksums(path+integritySuffix, sums); err != nil {
+		return err
+	}
+
+	backups, err := listBackups(dbDir)
+	if err != nil {
+		return err
+	}
+	for i := maxBackups; i < len(backups); i++ {
+		os.Remove(backups[i])
+		os.Remove(backups[i] + integritySuffix)
+	}
+	return nil
+}
+
+// listBackups returns the paths of the wallet database backups, newest first.
+func listBackups(dbDir string) ([]string, error) {
+	backupDir := filepath.Join(dbDir, backupDirName)
+	infos, err := ioutil.ReadDir(backupDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, info := range infos {
+		name := info.Name()
+		if strings.HasPrefix(name, "wallet-") && strings.HasSuffix(name, ".db") {
+			backups = append(backups, filepath.Join(backupDir, name))
+		}
+	}
+	// The names sort in the order the backups were made.
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+// restoreBackup replaces the corrupt wallet database at dbPath with the most
+// recent backup that matches its checksums, and returns the path of the
+// backup.  The corrupt database is kept next to it for inspection.
+func restoreBackup(dbPath string) (string, error) {
+	backups, err := listBackups(filepath.Dir(dbPath))
+	if err != nil {
+		return "", err
+	}
+
+	for _, backup := range backups {
+		db, err := walletdb.Open("bdb", backup)
+		if err != nil {
+			log.Warnf("Unable to open wallet backup %s: %v", backup, err)
+			continue
+		}
+		_, err = verifyChecksums(db, backup)
+		db.Close()
+		if err != nil {
+			log.Warnf("Wallet backup %s is not valid: %v", backup, err)
+			continue
+		}
+
+		corrupt := dbPath + ".corrupt-" + time.Now().UTC().Format("20060102T150405")
+		if err := os.Rename(dbPath, corrupt); err != nil {
+			return "", err
+		}
+		if err := copyFile(backup, dbPath); err != nil {
+			return "", err
+		}
+		log.Warnf("Moved the corrupt wallet database to %s", corrupt)
+		return backup, nil
+	}
+	return "", errors.New("no valid backup to restore")
+}
+
+// copyFile copies the file at src to a new file at dst.
+func copyFile(src, dst string) error {
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, b, 0600)
+}
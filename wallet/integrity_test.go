@@ -0,0 +1,117 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// putUnspent writes a value to the unspent outputs bucket of the transaction
+// store namespace.
+func putUnspent(t *testing.T, db walletdb.DB, value string) {
+	err := walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+		if ns == nil {
+			var err error
+			ns, err = tx.CreateTopLevelBucket(wtxmgrNamespaceKey)
+			if err != nil {
+				return err
+			}
+		}
+		unspent, err := ns.CreateBucketIfNotExists([]byte("u"))
+		if err != nil {
+			return err
+		}
+		return unspent.Put([]byte("outpoint"), []byte(value))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestIntegrity checks that a wallet database modified after it was closed
+// is detected and replaced with its backup.
+func TestIntegrity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "integrity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dbPath := filepath.Join(dir, walletDbName)
+
+	db, err := walletdb.Create("bdb", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket(waddrmgrNamespaceKey)
+		if err != nil {
+			return err
+		}
+		return ns.Put([]byte("key"), []byte("value"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	putUnspent(t, db, "original")
+	if err := closeWithChecksums(db, dbPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Opening the verified database backs it up and removes the
+	// checksums until it is closed again.
+	db, err = openVerified(dbPath)
+	if err != nil {
+		t.Fatalf("openVerified: %v", err)
+	}
+	if _, err := os.Stat(dbPath + integritySuffix); !os.IsNotExist(err) {
+		t.Errorf("checksums of the open database were not removed")
+	}
+	backups, err := listBackups(dir)
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("got backups %v (%v), want one", backups, err)
+	}
+	if err := closeWithChecksums(db, dbPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Change the unspent outputs without updating the checksums.
+	db, err = walletdb.Open("bdb", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	putUnspent(t, db, "corrupt")
+	_, err = verifyChecksums(db, dbPath)
+	if e, ok := err.(*IntegrityError); !ok || e.Part != "utxo" {
+		t.Errorf("verifyChecksums: got %v, want utxo integrity error", err)
+	}
+	db.Close()
+
+	db, err = openVerified(dbPath)
+	if err != nil {
+		t.Fatalf("openVerified: %v", err)
+	}
+	defer db.Close()
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		unspent := tx.ReadBucket(wtxmgrNamespaceKey).NestedReadBucket([]byte("u"))
+		if v := string(unspent.Get([]byte("outpoint"))); v != "original" {
+			t.Errorf("restored value %q, want original", v)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt, _ := filepath.Glob(dbPath + ".corrupt-*")
+	if len(corrupt) != 1 {
+		t.Errorf("corrupt database was not kept")
+	}
+}
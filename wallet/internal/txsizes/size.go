@@ -6,6 +6,7 @@ package txsizes
 
 import (
 	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 
 	h "github.com/btcsuite/btcwallet/internal/helpers"
@@ -116,6 +117,23 @@ const (
 	//   - 1 wu compact int encoding value 33
 	//   - 33 wu serialized compressed pubkey
 	RedeemP2WPKHInputWitnessWeight = 1 + 1 + 73 + 1 + 33
+
+	// RedeemP2TRInputSize is the worst case size of a transaction input
+	// redeeming a P2TR output using the key path. It is calculated as:
+	//
+	//   - 32 bytes previous tx
+	//   - 4 bytes output index
+	//   - 1 byte encoding empty redeem script
+	//   - 4 bytes sequence
+	RedeemP2TRInputSize = 32 + 4 + 1 + 4
+
+	// RedeemP2TRInputWitnessWeight is the worst case weight of a witness
+	// for a key path spend of a P2TR output. It is calculated as:
+	//
+	//   - 1 wu compact int encoding value 1 (number of items)
+	//   - 1 wu compact int encoding value 65
+	//   - 64 wu schnorr signature + 1 wu sighash
+	RedeemP2TRInputWitnessWeight = 1 + 1 + 65
 )
 
 // EstimateSerializeSize returns a worst case serialize size estimate for a
@@ -138,13 +156,103 @@ func EstimateSerializeSize(inputCount int, txOuts []*wire.TxOut, addChangeOutput
 		changeSize
 }
 
+// InputSize is the worst case serialize size of a transaction input, without
+// its witness, and the weight of its witness.
+type InputSize struct {
+	Size          int
+	WitnessWeight int
+}
+
+// pushSize returns the size of a canonical data push of n bytes.
+func pushSize(n int) int {
+	switch {
+	case n <= txscript.OP_DATA_75:
+		return 1 + n
+	case n <= 0xff:
+		return 2 + n
+	case n <= 0xffff:
+		return 3 + n
+	default:
+		return 5 + n
+	}
+}
+
+// signatureItems returns the sizes of the worst case items, other than the
+// script itself, which satisfy a multisig, P2PK or P2PKH redeem or witness
+// script.  Any other script is assumed to be satisfied by one signature.
+func signatureItems(script []byte) []int {
+	const sigSize = 73 // 72 bytes DER signature + 1 byte sighash
+	switch txscript.GetScriptClass(script) {
+	case txscript.MultiSigTy:
+		_, numSigs, err := txscript.CalcMultiSigStats(script)
+		if err != nil {
+			break
+		}
+		// OP_CHECKMULTISIG pops an extra empty item.
+		items := []int{0}
+		for i := 0; i < numSigs; i++ {
+			items = append(items, sigSize)
+		}
+		return items
+	case txscript.PubKeyHashTy:
+		return []int{sigSize, 33}
+	}
+	return []int{sigSize}
+}
+
+// EstimateScriptHashInputSize returns a worst case size estimate of a
+// transaction input redeeming a P2SH, P2WSH or nested P2SH-P2WSH output.  For
+// P2SH outputs, redeemScript is the redeem script and witnessScript is nil.
+// For P2WSH outputs, redeemScript is nil and witnessScript is the witness
+// script, and for nested P2SH-P2WSH outputs the redeem script is the witness
+// program of the witness script.
+func EstimateScriptHashInputSize(redeemScript, witnessScript []byte) InputSize {
+	sigScriptSize := 0
+	witnessWeight := 0
+	if witnessScript == nil {
+		for _, item := range signatureItems(redeemScript) {
+			sigScriptSize += pushSize(item)
+		}
+		sigScriptSize += pushSize(len(redeemScript))
+	} else {
+		if redeemScript != nil {
+			sigScriptSize = pushSize(len(redeemScript))
+		}
+		items := signatureItems(witnessScript)
+		witnessWeight = wire.VarIntSerializeSize(uint64(len(items) + 1))
+		for _, item := range append(items, len(witnessScript)) {
+			witnessWeight += wire.VarIntSerializeSize(uint64(item)) + item
+		}
+	}
+
+	// 32 bytes previous tx + 4 bytes output index + signature script +
+	// 4 bytes sequence.
+	return InputSize{
+		Size: 32 + 4 + wire.VarIntSerializeSize(uint64(sigScriptSize)) +
+			sigScriptSize + 4,
+		WitnessWeight: witnessWeight,
+	}
+}
+
 // EstimateVirtualSize returns a worst case virtual size estimate for a
-// signed transaction that spends the given number of P2PKH, P2WPKH and
-// (nested) P2SH-P2WPKH outputs, and contains each transaction output
-// from txOuts. The estimate is incremented for an additional P2PKH
+// signed transaction that spends the given number of P2PKH, P2WPKH,
+// (nested) P2SH-P2WPKH and P2TR outputs, and contains each transaction
+// output from txOuts. The estimate is incremented for an additional P2PKH
 // change output if addChangeOutput is true.
-func EstimateVirtualSize(numP2PKHIns, numP2WPKHIns, numNestedP2WPKHIns int,
-	txOuts []*wire.TxOut, addChangeOutput bool) int {
+func EstimateVirtualSize(numP2PKHIns, numP2WPKHIns, numNestedP2WPKHIns,
+	numP2TRIns int, txOuts []*wire.TxOut, addChangeOutput bool) int {
+
+	return EstimateVirtualSizeScripts(numP2PKHIns, numP2WPKHIns,
+		numNestedP2WPKHIns, numP2TRIns, nil, txOuts, addChangeOutput)
+}
+
+// EstimateVirtualSizeScripts returns a worst case virtual size estimate like
+// EstimateVirtualSize, for a transaction which also spends inputs of the
+// sizes scriptIns, such as estimated by EstimateScriptHashInputSize.
+func EstimateVirtualSizeScripts(numP2PKHIns, numP2WPKHIns, numNestedP2WPKHIns,
+	numP2TRIns int, scriptIns []InputSize, txOuts []*wire.TxOut,
+	addChangeOutput bool) int {
+
 	changeSize := 0
 	outputCount := len(txOuts)
 	if addChangeOutput {
@@ -160,26 +268,42 @@ func EstimateVirtualSize(numP2PKHIns, numP2WPKHIns, numNestedP2WPKHIns int,
 	// Version 4 bytes + LockTime 4 bytes + Serialized var int size for the
 	// number of transaction inputs and outputs + size of redeem scripts +
 	// the size out the serialized outputs and change.
+	scriptInsSize := 0
+	numWitnessScriptIns := 0
+	scriptInsWitnessWeight := 0
+	for _, in := range scriptIns {
+		scriptInsSize += in.Size
+		if in.WitnessWeight != 0 {
+			numWitnessScriptIns++
+			scriptInsWitnessWeight += in.WitnessWeight
+		}
+	}
 	baseSize := 8 +
 		wire.VarIntSerializeSize(
-			uint64(numP2PKHIns+numP2WPKHIns+numNestedP2WPKHIns)) +
+			uint64(numP2PKHIns+numP2WPKHIns+numNestedP2WPKHIns+
+				numP2TRIns+len(scriptIns))) +
 		wire.VarIntSerializeSize(uint64(len(txOuts))) +
 		numP2PKHIns*RedeemP2PKHInputSize +
 		numP2WPKHIns*RedeemP2WPKHInputSize +
 		numNestedP2WPKHIns*RedeemNestedP2WPKHInputSize +
+		numP2TRIns*RedeemP2TRInputSize +
+		scriptInsSize +
 		h.SumOutputSerializeSizes(txOuts) +
 		changeSize
 
 	// If this transaction has any witness inputs, we must count the
 	// witness data.
 	witnessWeight := 0
-	if numP2WPKHIns+numNestedP2WPKHIns > 0 {
+	if numP2WPKHIns+numNestedP2WPKHIns+numP2TRIns+numWitnessScriptIns > 0 {
 		// Additional 2 weight units for segwit marker + flag.
 		witnessWeight = 2 +
 			wire.VarIntSerializeSize(
-				uint64(numP2WPKHIns+numNestedP2WPKHIns)) +
+				uint64(numP2WPKHIns+numNestedP2WPKHIns+
+					numP2TRIns+numWitnessScriptIns)) +
 			numP2WPKHIns*RedeemP2WPKHInputWitnessWeight +
-			numNestedP2WPKHIns*RedeemP2WPKHInputWitnessWeight
+			numNestedP2WPKHIns*RedeemP2WPKHInputWitnessWeight +
+			numP2TRIns*RedeemP2TRInputWitnessWeight +
+			scriptInsWitnessWeight
 	}
 
 	// We add 3 to the witness weight to make sure the result is
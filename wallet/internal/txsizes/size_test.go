@@ -69,6 +69,7 @@ func TestEstimateVirtualSize(t *testing.T) {
 		tx              func() (*wire.MsgTx, error)
 		p2wpkhIns       int
 		nestedp2wpkhIns int
+		p2trIns         int
 		p2pkhIns        int
 		change          bool
 		result          int
@@ -156,6 +157,26 @@ func TestEstimateVirtualSize(t *testing.T) {
 			p2pkhIns: 1,
 			result:   227,
 		},
+		{
+			// Spending one P2TR output using the key path to the two
+			// outputs of the BIP-143 P2WPKH example.
+			tx: func() (*wire.MsgTx, error) {
+				txHex := "01000000000101ef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac0247304402203609e17b84f6a7d30c80bfa610b5b4542f32a8a0d5447a12fb1366d7f01cc44a0220573a954c4518331561406f90300e8f3358f51928d43c212a8caed02de67eebee0121025476c2e83188368da1ff3e292e7acafcdb3566bb0ad253f62fc70f07aeee635711000000"
+				b, err := hex.DecodeString(txHex)
+				if err != nil {
+					return nil, err
+				}
+				tx := &wire.MsgTx{}
+				err = tx.Deserialize(bytes.NewReader(b))
+				if err != nil {
+					return nil, err
+				}
+
+				return tx, nil
+			},
+			p2trIns: 1,
+			result:  137,
+		},
 	}
 
 	for _, test := range tests {
@@ -165,7 +186,7 @@ func TestEstimateVirtualSize(t *testing.T) {
 		}
 
 		est := EstimateVirtualSize(test.p2pkhIns, test.p2wpkhIns,
-			test.nestedp2wpkhIns, tx.TxOut, test.change)
+			test.nestedp2wpkhIns, test.p2trIns, tx.TxOut, test.change)
 
 		if est != test.result {
 			t.Fatalf("expected estimated vsize to be %d, "+
@@ -0,0 +1,253 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+const (
+	// DefaultKeyUsageFactor is the default factor of its baseline the
+	// hourly signing volume of an address or account must exceed to be
+	// alerted.
+	DefaultKeyUsageFactor = 10
+
+	// DefaultKeyUsageMinSignatures is the default number of signatures in
+	// an hour below which no signing volume is alerted.
+	DefaultKeyUsageMinSignatures = 20
+
+	// keyUsageSmoothing is the weight of the last hour in the baseline,
+	// an exponential moving average of the hourly signatures over about a
+	// day.
+	keyUsageSmoothing = 1.0 / 24
+
+	// keyUsageWarmup is how long the signatures of an account are counted
+	// before its baseline is trusted to alert on.  Addresses alert
+	// without warmup, since most addresses only sign a few times.
+	keyUsageWarmup = 24 * time.Hour
+)
+
+// KeyUsagePolicy configures the alerts raised when the signing volume of an
+// address or account deviates sharply from its baseline, which may reveal a
+// compromised key or runaway automation.
+type KeyUsagePolicy struct {
+	// Factor is the factor of the baseline of hourly signatures which the
+	// signatures of the current hour must exceed to be alerted.  A zero
+	// factor disables the alerts.
+	Factor float64
+
+	// MinSignatures is the number of signatures in an hour below which no
+	// alert is raised, whatever the baseline.
+	MinSignatures uint64
+}
+
+// KeyUsage describes how often the keys of an address or account signed.
+// Baseline is the moving average of the signatures per hour, excluding the
+// current hour.
+type KeyUsage struct {
+	Signatures  uint64
+	CurrentHour uint64
+	Baseline    float64
+}
+
+// AccountKeyUsage describes how often the keys of an account signed, and the
+// addresses of the account which signed, keyed by address.
+type AccountKeyUsage struct {
+	KeyUsage
+	Account   uint32
+	Addresses map[string]KeyUsage
+}
+
+// usageCounter counts the signatures of an address or account.
+type usageCounter struct {
+	total    uint64
+	hour     int64
+	current  uint64
+	baseline float64
+	since    time.Time
+
+	// alerted is the hour of the last alert, so that an hour of
+	// anomalous signing is only alerted once.
+	alerted int64
+}
+
+// advance moves the counter to an hour, folding the signatures of the past
+// hours into the baseline.
+func (c *usageCounter) advance(hour int64) {
+	if hour <= c.hour {
+		return
+	}
+	c.baseline += keyUsageSmoothing * (float64(c.current) - c.baseline)
+	if idle := hour - c.hour - 1; idle > 0 {
+		c.baseline *= math.Pow(1-keyUsageSmoothing, float64(idle))
+	}
+	c.current = 0
+	c.hour = hour
+}
+
+// add counts a signature at now and returns whether the signatures of the
+// current hour are anomalous and not yet alerted.
+func (c *usageCounter) add(now time.Time, policy *KeyUsagePolicy,
+	warmup time.Duration) bool {
+
+	hour := now.Unix() / 3600
+	if c.total == 0 {
+		c.hour = hour
+		c.since = now
+		c.alerted = -1
+	}
+	c.advance(hour)
+	c.total++
+	c.current++
+
+	if policy.Factor == 0 || c.alerted == hour ||
+		c.current < policy.MinSignatures || now.Sub(c.since) < warmup {
+
+		return false
+	}
+	if float64(c.current) <= policy.Factor*math.Max(c.baseline, 1) {
+		return false
+	}
+	c.alerted = hour
+	return true
+}
+
+func (c *usageCounter) usage(now time.Time) KeyUsage {
+	c.advance(now.Unix() / 3600)
+	return KeyUsage{
+		Signatures:  c.total,
+		CurrentHour: c.current,
+		Baseline:    c.baseline,
+	}
+}
+
+// keyUsage counts the signatures made by the keys of the wallet.  Counters
+// are kept in memory, so baselines are learned again after a restart.
+type keyUsage struct {
+	mu       sync.Mutex
+	policy   KeyUsagePolicy
+	addrs    map[string]*usageCounter
+	accounts map[uint32]*usageCounter
+
+	// addrAccounts maps the counted addresses to their accounts.
+	addrAccounts map[string]uint32
+
+	alerts uint64
+}
+
+// SetKeyUsagePolicy configures the alerts raised when the signing volume of
+// an address or account deviates sharply from its baseline.  Alerts are logged
+// and sent as KeyUsageNotifications.
+func (w *Wallet) SetKeyUsagePolicy(policy *KeyUsagePolicy) {
+	w.keyUsage.mu.Lock()
+	w.keyUsage.policy = *policy
+	w.keyUsage.mu.Unlock()
+}
+
+// recordKeyUse counts a signature by the key of a wallet address, and alerts
+// of anomalous signing volumes of the address and its account.
+func (w *Wallet) recordKeyUse(ma waddrmgr.ManagedAddress) {
+	u := &w.keyUsage
+	now := time.Now()
+	addr := ma.Address().EncodeAddress()
+	account := ma.Account()
+
+	u.mu.Lock()
+	if u.addrs == nil {
+		u.addrs = make(map[string]*usageCounter)
+		u.accounts = make(map[uint32]*usageCounter)
+		u.addrAccounts = make(map[string]uint32)
+	}
+	addrCounter, ok := u.addrs[addr]
+	if !ok {
+		addrCounter = new(usageCounter)
+		u.addrs[addr] = addrCounter
+		u.addrAccounts[addr] = account
+	}
+	acctCounter, ok := u.accounts[account]
+	if !ok {
+		acctCounter = new(usageCounter)
+		u.accounts[account] = acctCounter
+	}
+	var alerts []*KeyUsageNotification
+	if addrCounter.add(now, &u.policy, 0) {
+		alerts = append(alerts, &KeyUsageNotification{
+			Account:  account,
+			Address:  addr,
+			KeyUsage: addrCounter.usage(now),
+		})
+	}
+	if acctCounter.add(now, &u.policy, keyUsageWarmup) {
+		alerts = append(alerts, &KeyUsageNotification{
+			Account:  account,
+			KeyUsage: acctCounter.usage(now),
+		})
+	}
+	u.alerts += uint64(len(alerts))
+	u.mu.Unlock()
+
+	for _, n := range alerts {
+		if n.Address != "" {
+			log.Warnf("Address %v of account %d signed %d times this "+
+				"hour, against a baseline of %.1f per hour",
+				n.Address, n.Account, n.CurrentHour, n.Baseline)
+		} else {
+			log.Warnf("Account %d signed %d times this hour, against "+
+				"a baseline of %.1f per hour", n.Account,
+				n.CurrentHour, n.Baseline)
+		}
+		// Keys are used while signing in database transactions, so
+		// the notifications are not sent synchronously.
+		go w.NtfnServer.notifyKeyUsage(n)
+	}
+}
+
+// KeyUsage returns how often the keys of the accounts and their addresses
+// signed since the wallet started, ordered by account number.
+func (w *Wallet) KeyUsage() []AccountKeyUsage {
+	u := &w.keyUsage
+	now := time.Now()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	usages := make([]AccountKeyUsage, 0, len(u.accounts))
+	indexes := make(map[uint32]int, len(u.accounts))
+	for account, c := range u.accounts {
+		indexes[account] = len(usages)
+		usages = append(usages, AccountKeyUsage{
+			KeyUsage:  c.usage(now),
+			Account:   account,
+			Addresses: make(map[string]KeyUsage),
+		})
+	}
+	for addr, c := range u.addrs {
+		i := indexes[u.addrAccounts[addr]]
+		usages[i].Addresses[addr] = c.usage(now)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Account < usages[j].Account
+	})
+	return usages
+}
+
+// KeyUsageTotals returns the number of signatures made by the keys of the
+// wallet, and the number of signing volume alerts raised, since the wallet
+// started.
+func (w *Wallet) KeyUsageTotals() (signatures, alerts uint64) {
+	u := &w.keyUsage
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for _, c := range u.accounts {
+		signatures += c.total
+	}
+	return signatures, u.alerts
+}
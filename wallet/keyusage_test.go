@@ -0,0 +1,87 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestUsageCounter(t *testing.T) {
+	policy := &KeyUsagePolicy{Factor: 4, MinSignatures: 5}
+	start := time.Unix(1000*3600, 0)
+	var c usageCounter
+
+	// Two signatures an hour for two days build a baseline of about two
+	// signatures an hour, without alerts.
+	for h := 0; h < 48; h++ {
+		now := start.Add(time.Duration(h) * time.Hour)
+		for i := 0; i < 2; i++ {
+			if c.add(now, policy, keyUsageWarmup) {
+				t.Fatalf("hour %d: steady signing alerted", h)
+			}
+		}
+	}
+	usage := c.usage(start.Add(48 * time.Hour))
+	if usage.Signatures != 96 || usage.CurrentHour != 0 {
+		t.Errorf("counted %d signatures, %d this hour, want 96 and 0",
+			usage.Signatures, usage.CurrentHour)
+	}
+	if math.Abs(usage.Baseline-2) > 0.5 {
+		t.Errorf("baseline is %v, want about 2", usage.Baseline)
+	}
+
+	// A burst more than four times the baseline of about 1.7 signatures
+	// an hour is alerted once.
+	now := start.Add(48 * time.Hour)
+	alerts := 0
+	for i := 0; i < 20; i++ {
+		if c.add(now, policy, keyUsageWarmup) {
+			alerts++
+			if c.current != 7 {
+				t.Errorf("alerted after %d signatures, want 7",
+					c.current)
+			}
+		}
+	}
+	if alerts != 1 {
+		t.Errorf("burst alerted %d times, want 1", alerts)
+	}
+
+	// Idle hours decay the baseline.
+	before := c.usage(now).Baseline
+	after := c.usage(now.Add(48 * time.Hour)).Baseline
+	if after >= before/2 {
+		t.Errorf("baseline decayed from %v to %v after two idle days",
+			before, after)
+	}
+
+	// Counters in their warmup period and disabled policies never alert.
+	var fresh usageCounter
+	for i := 0; i < 100; i++ {
+		if fresh.add(start, policy, keyUsageWarmup) {
+			t.Fatalf("counter alerted during its warmup")
+		}
+	}
+	var disabled usageCounter
+	for i := 0; i < 100; i++ {
+		if disabled.add(start, &KeyUsagePolicy{}, 0) {
+			t.Fatalf("disabled policy alerted")
+		}
+	}
+
+	// Without warmup, a new key signing more than the minimum alerts.
+	var addr usageCounter
+	alerts = 0
+	for i := 0; i < 10; i++ {
+		if addr.add(start, policy, 0) {
+			alerts++
+		}
+	}
+	if alerts != 1 {
+		t.Errorf("new key alerted %d times, want 1", alerts)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/ledger"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// unknownLedgerAccount names the wallet account of outputs whose account can
+// no longer be determined, such as those spent by a debit whose previous
+// transaction was removed from the wallet.
+const unknownLedgerAccount = "unknown"
+
+// LedgerEntries returns the double-entry ledger of the wallet transactions
+// mined in the blocks from startHeight to endHeight.  Each wallet account is
+// an asset account of the ledger, debited by the outputs it receives and
+// credited by the outputs it spends.  Fees of transactions spending only
+// wallet outputs are debited to the fees account, and the remaining value
+// exchanged with other wallets is balanced by the received income and sent
+// expense accounts.
+func (w *Wallet) LedgerEntries(startHeight, endHeight int32) ([]ledger.Entry, error) {
+	var entries []ledger.Entry
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+
+		rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
+			for i := range details {
+				// Unmined transactions have no date yet.
+				if details[i].Block.Height == -1 {
+					continue
+				}
+				entry, err := w.ledgerEntry(dbtx, &details[i])
+				if err != nil {
+					return false, err
+				}
+				entries = append(entries, *entry)
+			}
+			return false, nil
+		}
+		return w.TxStore.RangeTransactions(txmgrNs, startHeight,
+			endHeight, rangeFn)
+	})
+	return entries, err
+}
+
+// ledgerEntry returns the ledger entry of a mined wallet transaction.
+func (w *Wallet) ledgerEntry(dbtx walletdb.ReadTx, details *wtxmgr.TxDetails) (*ledger.Entry, error) {
+	txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+
+	entry := &ledger.Entry{
+		Date: details.Block.Time,
+		TxID: details.Hash.String(),
+	}
+
+	debits := make(map[string]int64)
+	for _, deb := range details.Debits {
+		prevOut := &details.MsgTx.TxIn[deb.Index].PreviousOutPoint
+		account := unknownLedgerAccount
+		commodity := wire.STB.String()
+		prev, err := w.TxStore.TxDetails(txmgrNs, &prevOut.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if prev != nil && int(prevOut.Index) < len(prev.MsgTx.TxOut) {
+			pkScript := prev.MsgTx.TxOut[prevOut.Index].PkScript
+			account = w.scriptAccountName(dbtx, pkScript)
+			commodity = wire.TokenID(pkScript).String()
+		}
+		entry.Postings = append(entry.Postings, ledger.Posting{
+			Account:   ledger.WalletAccount(account),
+			Amount:    -int64(deb.Amount),
+			Commodity: commodity,
+		})
+		debits[commodity] += int64(deb.Amount)
+	}
+
+	for _, cred := range details.Credits {
+		pkScript := details.MsgTx.TxOut[cred.Index].PkScript
+		entry.Postings = append(entry.Postings, ledger.Posting{
+			Account:   ledger.WalletAccount(w.scriptAccountName(dbtx, pkScript)),
+			Amount:    int64(cred.Amount),
+			Commodity: wire.TokenID(pkScript).String(),
+		})
+	}
+
+	// The fee is only known when every input is a debit.
+	if len(details.Debits) != 0 && len(details.Debits) == len(details.MsgTx.TxIn) {
+		outputs := make(map[string]int64)
+		for _, txOut := range details.MsgTx.TxOut {
+			outputs[txOut.TokenID().String()] += txOut.Value
+		}
+		for commodity, debit := range debits {
+			if fee := debit - outputs[commodity]; fee > 0 {
+				entry.Postings = append(entry.Postings, ledger.Posting{
+					Account:   ledger.FeesAccount,
+					Amount:    fee,
+					Commodity: commodity,
+				})
+			}
+		}
+	}
+
+	entry.Balance()
+
+	entry.Description = "Transfer"
+	for _, p := range entry.Postings {
+		switch p.Account {
+		case ledger.ReceivedAccount:
+			entry.Description = "Receive"
+		case ledger.SentAccount:
+			entry.Description = "Send"
+		}
+	}
+	return entry, nil
+}
+
+// scriptAccountName returns the name of the wallet account of an output
+// script.
+func (w *Wallet) scriptAccountName(dbtx walletdb.ReadTx, pkScript []byte) string {
+	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+
+	_, addrs, _, err := taproot.ExtractPkScriptAddrs(pkScript, w.chainParams)
+	if err != nil || len(addrs) == 0 {
+		return unknownLedgerAccount
+	}
+	manager, account, err := w.Manager.AddrAccount(addrmgrNs, addrs[0])
+	if err != nil {
+		watchNs := dbtx.ReadBucket(wwatchNamespaceKey)
+		scope, watchedAcct, werr := fetchWatchedAccount(watchNs, pkScript)
+		if werr != nil {
+			if _, err := fetchScript(dbtx.ReadBucket(wscriptNamespaceKey), addrs[0]); err == nil {
+				return waddrmgr.ImportedAddrAccountName
+			}
+			return unknownLedgerAccount
+		}
+		manager, err = w.Manager.FetchScopedKeyManager(scope)
+		if err != nil {
+			return unknownLedgerAccount
+		}
+		account = watchedAcct
+	}
+	name, err := manager.AccountName(addrmgrNs, account)
+	if err != nil {
+		return unknownLedgerAccount
+	}
+	return name
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package ledger provides a double-entry accounting ledger of wallet
+// transactions and its export to the ledger-cli and beancount plain text
+// accounting formats.
+package ledger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Accounts of the ledger that are not wallet accounts.
+const (
+	// FeesAccount is debited the fees of transactions spending only
+	// wallet outputs.
+	FeesAccount = "Expenses:Fees"
+
+	// SentAccount is debited the value sent to outputs of other wallets.
+	SentAccount = "Expenses:Sent"
+
+	// ReceivedAccount is credited the value received from inputs of other
+	// wallets.
+	ReceivedAccount = "Income:Received"
+
+	// walletAccountPrefix is the parent of the asset accounts of wallet
+	// accounts.
+	walletAccountPrefix = "Assets:Wallet:"
+)
+
+// WalletAccount returns the ledger asset account of a wallet account.
+func WalletAccount(name string) string {
+	return walletAccountPrefix + name
+}
+
+// Posting is a debit, with a positive amount, or credit, with a negative
+// amount, of a ledger account.  Amounts are in atoms of the commodity.
+type Posting struct {
+	Account   string
+	Amount    int64
+	Commodity string
+}
+
+// Entry is a balanced set of postings recording a transaction.
+type Entry struct {
+	Date        time.Time
+	TxID        string
+	Description string
+	Postings    []Posting
+}
+
+// Balance adds postings to the income or expense accounts so that the
+// amounts of every commodity of the entry sum to zero.  Value entering the
+// wallet accounts is credited to ReceivedAccount, and value leaving them is
+// debited to SentAccount.
+func (e *Entry) Balance() {
+	sums := make(map[string]int64)
+	var commodities []string
+	for _, p := range e.Postings {
+		if _, ok := sums[p.Commodity]; !ok {
+			commodities = append(commodities, p.Commodity)
+		}
+		sums[p.Commodity] += p.Amount
+	}
+	for _, c := range commodities {
+		switch sum := sums[c]; {
+		case sum > 0:
+			e.Postings = append(e.Postings, Posting{ReceivedAccount, -sum, c})
+		case sum < 0:
+			e.Postings = append(e.Postings, Posting{SentAccount, -sum, c})
+		}
+	}
+}
+
+// Balanced returns whether the amounts of every commodity of the entry sum
+// to zero.
+func (e *Entry) Balanced() bool {
+	sums := make(map[string]int64)
+	for _, p := range e.Postings {
+		sums[p.Commodity] += p.Amount
+	}
+	for _, sum := range sums {
+		if sum != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Format is a plain text accounting file format.
+type Format int
+
+// The supported export formats.
+const (
+	// FormatLedger is the format of ledger-cli and hledger journals.
+	FormatLedger Format = iota
+
+	// FormatBeancount is the format of beancount ledgers.
+	FormatBeancount
+)
+
+// ParseFormat returns the format named "ledger" or "beancount".
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "ledger":
+		return FormatLedger, nil
+	case "beancount":
+		return FormatBeancount, nil
+	}
+	return 0, fmt.Errorf("unknown ledger format %q", name)
+}
+
+// Write writes the entries to w in a format.
+func Write(w io.Writer, entries []Entry, format Format) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatLedger:
+		writeLedger(bw, entries)
+	case FormatBeancount:
+		writeBeancount(bw, entries)
+	default:
+		return fmt.Errorf("unknown ledger format %d", format)
+	}
+	return bw.Flush()
+}
+
+func writeLedger(w *bufio.Writer, entries []Entry) {
+	for i, e := range entries {
+		if i != 0 {
+			w.WriteString("\n")
+		}
+		fmt.Fprintf(w, "%s * %s\n", e.Date.UTC().Format("2006/01/02"),
+			e.Description)
+		fmt.Fprintf(w, "    ; txid: %s\n", e.TxID)
+		for _, p := range e.Postings {
+			fmt.Fprintf(w, "    %-40s  %s %s\n",
+				ledgerAccount(p.Account), formatAmount(p.Amount),
+				p.Commodity)
+		}
+	}
+}
+
+func writeBeancount(w *bufio.Writer, entries []Entry) {
+	// Accounts must be opened before the first entry posting to them.
+	opened := make(map[string]time.Time)
+	for _, e := range entries {
+		for _, p := range e.Postings {
+			account := beancountAccount(p.Account)
+			if date, ok := opened[account]; !ok || e.Date.Before(date) {
+				opened[account] = e.Date
+			}
+		}
+	}
+	accounts := make([]string, 0, len(opened))
+	for account := range opened {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	for _, account := range accounts {
+		fmt.Fprintf(w, "%s open %s\n",
+			opened[account].UTC().Format("2006-01-02"), account)
+	}
+
+	for _, e := range entries {
+		w.WriteString("\n")
+		fmt.Fprintf(w, "%s * %q\n", e.Date.UTC().Format("2006-01-02"),
+			e.Description)
+		fmt.Fprintf(w, "  txid: %q\n", e.TxID)
+		for _, p := range e.Postings {
+			fmt.Fprintf(w, "  %-40s  %s %s\n",
+				beancountAccount(p.Account), formatAmount(p.Amount),
+				beancountCommodity(p.Commodity))
+		}
+	}
+}
+
+// formatAmount formats an amount of atoms with eight decimal places.
+func formatAmount(atoms int64) string {
+	sign := ""
+	abs := uint64(atoms)
+	if atoms < 0 {
+		sign = "-"
+		abs = uint64(-atoms)
+	}
+	return fmt.Sprintf("%s%d.%08d", sign, abs/1e8, abs%1e8)
+}
+
+// ledgerAccount returns an account name ending at the first run of two
+// spaces or a tab, as ledger-cli requires.
+func ledgerAccount(account string) string {
+	return strings.Join(strings.Fields(account), " ")
+}
+
+// beancountAccount returns an account name with components starting with a
+// capital letter or digit, and made only of letters, digits and dashes, as
+// beancount requires.
+func beancountAccount(account string) string {
+	components := strings.Split(account, ":")
+	for i, c := range components {
+		var b bytes.Buffer
+		for _, r := range c {
+			switch {
+			case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+				b.WriteRune(r)
+			default:
+				b.WriteRune('-')
+			}
+		}
+		c = b.String()
+		if c == "" || !unicode.IsLetter(rune(c[0])) && !unicode.IsDigit(rune(c[0])) {
+			c = "X" + c
+		}
+		components[i] = strings.ToUpper(c[:1]) + c[1:]
+	}
+	return strings.Join(components, ":")
+}
+
+// beancountCommodity returns a commodity name made of capital letters and
+// digits, as beancount requires.
+func beancountCommodity(commodity string) string {
+	var b bytes.Buffer
+	for _, r := range strings.ToUpper(commodity) {
+		if r < unicode.MaxASCII && (unicode.IsUpper(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	c := b.String()
+	if c == "" || !unicode.IsUpper(rune(c[0])) {
+		c = "X" + c
+	}
+	return c
+}
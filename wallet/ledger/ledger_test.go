@@ -0,0 +1,106 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ledger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func testEntries() []Entry {
+	date := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	receive := Entry{
+		Date: date,
+		TxID: "aa",
+		Postings: []Posting{
+			{WalletAccount("default"), 150000000, "STB"},
+		},
+	}
+	receive.Balance()
+	send := Entry{
+		Date: date.Add(24 * time.Hour),
+		TxID: "bb",
+		Postings: []Posting{
+			{WalletAccount("default"), -150000000, "STB"},
+			{WalletAccount("savings acct"), 100000000, "STB"},
+			{FeesAccount, 10000, "STB"},
+		},
+	}
+	send.Balance()
+	return []Entry{receive, send}
+}
+
+func TestBalance(t *testing.T) {
+	for _, e := range testEntries() {
+		if !e.Balanced() {
+			t.Errorf("entry %s is not balanced: %v", e.TxID, e.Postings)
+		}
+	}
+
+	e := testEntries()[1]
+	last := e.Postings[len(e.Postings)-1]
+	if last.Account != SentAccount || last.Amount != 49990000 {
+		t.Errorf("unexpected balancing posting %v", last)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	entries := testEntries()
+	entries[0].Description = "Receive"
+	entries[1].Description = "Send"
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{
+			format: FormatLedger,
+			want: `2018/06/01 * Receive
+    ; txid: aa
+    Assets:Wallet:default                     1.50000000 STB
+    Income:Received                           -1.50000000 STB
+
+2018/06/02 * Send
+    ; txid: bb
+    Assets:Wallet:default                     -1.50000000 STB
+    Assets:Wallet:savings acct                1.00000000 STB
+    Expenses:Fees                             0.00010000 STB
+    Expenses:Sent                             0.49990000 STB
+`,
+		},
+		{
+			format: FormatBeancount,
+			want: `2018-06-01 open Assets:Wallet:Default
+2018-06-02 open Assets:Wallet:Savings-acct
+2018-06-02 open Expenses:Fees
+2018-06-02 open Expenses:Sent
+2018-06-01 open Income:Received
+
+2018-06-01 * "Receive"
+  txid: "aa"
+  Assets:Wallet:Default                     1.50000000 STB
+  Income:Received                           -1.50000000 STB
+
+2018-06-02 * "Send"
+  txid: "bb"
+  Assets:Wallet:Default                     -1.50000000 STB
+  Assets:Wallet:Savings-acct                1.00000000 STB
+  Expenses:Fees                             0.00010000 STB
+  Expenses:Sent                             0.49990000 STB
+`,
+		},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, entries, test.format); err != nil {
+			t.Fatalf("format %d: %v", test.format, err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("format %d: got\n%s\nwant\n%s", test.format,
+				buf.String(), test.want)
+		}
+	}
+}
@@ -47,6 +47,9 @@ type Loader struct {
 	chainParams    *chaincfg.Params
 	dbDirPath      string
 	recoveryWindow uint32
+	kdf            *waddrmgr.ScryptOptions
+	encryptTxStore bool
+	recoveryWizard *RecoveryWizard
 	wallet         *Wallet
 	db             walletdb.DB
 	mu             sync.Mutex
@@ -58,11 +61,33 @@ type Loader struct {
 func NewLoader(chainParams *chaincfg.Params, dbDirPath string,
 	recoveryWindow uint32) *Loader {
 
-	return &Loader{
+	l := &Loader{
 		chainParams:    chainParams,
 		dbDirPath:      dbDirPath,
 		recoveryWindow: recoveryWindow,
 	}
+	l.recoveryWizard = &RecoveryWizard{loader: l}
+	return l
+}
+
+// SetKDF sets the key derivation function and costs of the passphrase keys
+// of the wallets created by the loader.  The default scrypt costs are used
+// when kdf is nil.
+func (l *Loader) SetKDF(kdf *waddrmgr.ScryptOptions) {
+	l.mu.Lock()
+	l.kdf = kdf
+	l.mu.Unlock()
+}
+
+// SetEncryptTxStore sets whether the transaction stores of the wallets created
+// or opened by the loader are encrypted.  Unencrypted transaction stores of
+// existing wallets are encrypted when they are opened, with a key encrypted
+// with the public passphrase.  Encrypted transaction stores remain encrypted
+// regardless of this setting.
+func (l *Loader) SetEncryptTxStore(encrypt bool) {
+	l.mu.Lock()
+	l.encryptTxStore = encrypt
+	l.mu.Unlock()
 }
 
 // onLoaded executes each added callback and prevents loader from loading any
@@ -98,6 +123,29 @@ func (l *Loader) RunAfterLoad(fn func(*Wallet)) {
 func (l *Loader) CreateNewWallet(pubPassphrase, privPassphrase, seed []byte,
 	bday time.Time) (*Wallet, error) {
 
+	return l.createWallet(pubPassphrase, func(db walletdb.DB) error {
+		return CreateWithKDF(
+			db, pubPassphrase, privPassphrase, seed, l.chainParams,
+			bday, l.kdf,
+		)
+	})
+}
+
+// CreateWatchOnlyWallet creates a new watching-only wallet from a bootstrap
+// exported by another system.  See CreateWatchOnly for details.
+func (l *Loader) CreateWatchOnlyWallet(pubPassphrase []byte,
+	bootstrap *Bootstrap) (*Wallet, error) {
+
+	return l.createWallet(pubPassphrase, func(db walletdb.DB) error {
+		return CreateWatchOnly(db, pubPassphrase, l.chainParams, bootstrap)
+	})
+}
+
+// createWallet creates the wallet database, initializes it with the create
+// function and opens the new wallet.
+func (l *Loader) createWallet(pubPassphrase []byte,
+	create func(walletdb.DB) error) (*Wallet, error) {
+
 	defer l.mu.Unlock()
 	l.mu.Lock()
 
@@ -125,18 +173,23 @@ func (l *Loader) CreateNewWallet(pubPassphrase, privPassphrase, seed []byte,
 	}
 
 	// Initialize the newly created database for the wallet before opening.
-	err = Create(
-		db, pubPassphrase, privPassphrase, seed, l.chainParams, bday,
-	)
+	err = create(db)
 	if err != nil {
 		return nil, err
 	}
 
-	// Open the newly-created wallet.
+	// Open the newly-created wallet, with a database that can be closed
+	// for maintenance.
+	db = newMaintenanceDB(db, dbPath)
 	w, err := Open(db, pubPassphrase, nil, l.chainParams, l.recoveryWindow)
 	if err != nil {
 		return nil, err
 	}
+	if l.encryptTxStore {
+		if err := w.encryptTxStore(l.kdf); err != nil {
+			return nil, err
+		}
+	}
 	w.Start()
 
 	l.onLoaded(w, db)
@@ -166,13 +219,15 @@ func (l *Loader) OpenExistingWallet(pubPassphrase []byte, canConsolePrompt bool)
 		return nil, err
 	}
 
-	// Open the database using the boltdb backend.
+	// Open the database using the boltdb backend, verifying its contents
+	// and falling back to a backup if it is corrupt.
 	dbPath := filepath.Join(l.dbDirPath, walletDbName)
-	db, err := walletdb.Open("bdb", dbPath)
+	db, err := openVerified(dbPath)
 	if err != nil {
 		log.Errorf("Failed to open database: %v", err)
 		return nil, err
 	}
+	db = newMaintenanceDB(db, dbPath)
 
 	var cbs *waddrmgr.OpenCallbacks
 	if canConsolePrompt {
@@ -191,12 +246,21 @@ func (l *Loader) OpenExistingWallet(pubPassphrase []byte, canConsolePrompt bool)
 		// If opening the wallet fails (e.g. because of wrong
 		// passphrase), we must close the backing database to
 		// allow future calls to walletdb.Open().
-		e := db.Close()
+		e := closeWithChecksums(db, dbPath)
 		if e != nil {
 			log.Warnf("Error closing database: %v", e)
 		}
 		return nil, err
 	}
+	if l.encryptTxStore {
+		if err := w.encryptTxStore(l.kdf); err != nil {
+			e := closeWithChecksums(db, dbPath)
+			if e != nil {
+				log.Warnf("Error closing database: %v", e)
+			}
+			return nil, err
+		}
+	}
 	w.Start()
 
 	l.onLoaded(w, db)
@@ -234,7 +298,14 @@ func (l *Loader) UnloadWallet() error {
 
 	l.wallet.Stop()
 	l.wallet.WaitForShutdown()
-	err := l.db.Close()
+
+	// A database closed for maintenance already has its checksums.
+	if l.wallet.InMaintenance() {
+		l.wallet = nil
+		l.db = nil
+		return nil
+	}
+	err := closeWithChecksums(l.db, filepath.Join(l.dbDirPath, walletDbName))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"sync"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// SequenceLockTime is the sequence number of the inputs of transactions
+// opting out of replaceability which have a lock time.  Unlike SequenceFinal,
+// it does not disable the lock time of the transaction.
+const SequenceLockTime = wire.MaxTxInSequenceNum - 1
+
+// lockTimePolicy is the lock time of transactions which do not choose their
+// own.
+type lockTimePolicy struct {
+	mu     sync.Mutex
+	optOut bool
+}
+
+// SetAntiFeeSniping configures whether the transactions created without a
+// lock time option are locked to the height of the current block, so that
+// miners reorganizing the chain to take their fees can not include them in a
+// previous block.  They are unless this is called with false, and are not
+// locked otherwise.
+func (w *Wallet) SetAntiFeeSniping(enabled bool) {
+	w.lockTimePolicy.mu.Lock()
+	w.lockTimePolicy.optOut = !enabled
+	w.lockTimePolicy.mu.Unlock()
+}
+
+// AntiFeeSniping returns whether the transactions created without a lock time
+// option are locked to the height of the current block.
+func (w *Wallet) AntiFeeSniping() bool {
+	w.lockTimePolicy.mu.Lock()
+	defer w.lockTimePolicy.mu.Unlock()
+	return !w.lockTimePolicy.optOut
+}
+
+// antiFeeSnipingLockTime returns the lock time of the transactions created
+// without a lock time option at the block of height.
+func (w *Wallet) antiFeeSnipingLockTime(height int32) uint32 {
+	if !w.AntiFeeSniping() || height < 0 {
+		return 0
+	}
+	return uint32(height)
+}
+
+// setLockTime sets the lock time of tx, and the sequence numbers of its final
+// inputs so that the lock time is enforced.  Inputs signaling replaceability
+// already enable it.
+func setLockTime(tx *wire.MsgTx, lockTime uint32) {
+	tx.LockTime = lockTime
+	if lockTime == 0 {
+		return
+	}
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence == wire.MaxTxInSequenceNum {
+			txIn.Sequence = SequenceLockTime
+		}
+	}
+}
+
+// isLockTimeHeight returns whether a lock time is a block height rather than
+// a timestamp.
+func isLockTimeHeight(lockTime uint32) bool {
+	return lockTime < txscript.LockTimeThreshold
+}
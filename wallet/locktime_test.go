@@ -0,0 +1,47 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TestLockTime checks the lock times of created transactions and the
+// sequence numbers enforcing them.
+func TestLockTime(t *testing.T) {
+	w := &Wallet{}
+	if lockTime := (*TxOptions)(nil).lockTime(w, 100); lockTime != 100 {
+		t.Errorf("anti-fee-sniping lock time %d, want 100", lockTime)
+	}
+	explicit := uint32(500)
+	opts := &TxOptions{LockTime: &explicit}
+	if lockTime := opts.lockTime(w, 100); lockTime != explicit {
+		t.Errorf("explicit lock time %d, want %d", lockTime, explicit)
+	}
+	w.SetAntiFeeSniping(false)
+	if lockTime := (*TxOptions)(nil).lockTime(w, 100); lockTime != 0 {
+		t.Errorf("lock time %d without anti-fee-sniping, want 0",
+			lockTime)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(&wire.TxIn{Sequence: SequenceFinal})
+	tx.AddTxIn(&wire.TxIn{Sequence: SequenceReplaceable})
+	setLockTime(tx, 0)
+	if tx.TxIn[0].Sequence != SequenceFinal {
+		t.Error("final input changed without lock time")
+	}
+	setLockTime(tx, 100)
+	if tx.LockTime != 100 || tx.TxIn[0].Sequence != SequenceLockTime ||
+		tx.TxIn[1].Sequence != SequenceReplaceable {
+
+		t.Errorf("unexpected locked transaction %v", tx)
+	}
+	if signalsReplaceable(&wire.MsgTx{TxIn: tx.TxIn[:1]}) {
+		t.Error("locked final input signals replaceability")
+	}
+}
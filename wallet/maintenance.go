@@ -0,0 +1,404 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// DefaultMaintenanceTimeout is the default time EnterMaintenance waits for the
+// open database transactions to finish before giving up.
+const DefaultMaintenanceTimeout = 30 * time.Second
+
+var (
+	// ErrInMaintenance is returned when the wallet database is accessed
+	// while it is closed for maintenance, and when maintenance is entered
+	// twice.
+	ErrInMaintenance = errors.New("wallet is in maintenance mode")
+
+	// ErrNotInMaintenance is returned when maintenance is exited while the
+	// wallet is not in maintenance mode.
+	ErrNotInMaintenance = errors.New("wallet is not in maintenance mode")
+)
+
+// MaintenanceStatus describes the maintenance mode of the wallet.
+type MaintenanceStatus struct {
+	// Active is whether the wallet is in maintenance mode.
+	Active bool
+
+	// Since is the time maintenance was entered.
+	Since time.Time
+
+	// DBPath is the path of the wallet database file.
+	DBPath string
+
+	// SyncedTo is the block the wallet was synced to when maintenance was
+	// entered, from which the catch-up rescan starts when it is exited.
+	SyncedTo waddrmgr.BlockStamp
+}
+
+// maintenanceState holds the maintenance mode of the wallet.  While the
+// wallet is in maintenance mode, resume is open and the processing of chain
+// notifications waits for it to be closed.
+type maintenanceState struct {
+	mu       sync.Mutex
+	resume   chan struct{}
+	since    time.Time
+	syncedTo waddrmgr.BlockStamp
+}
+
+// EnterMaintenance puts the wallet in maintenance mode, so that its database
+// file can be backed up or snapshotted without stopping the daemon.  The
+// processing of chain notifications is paused, the open database transactions
+// are waited for up to timeout, and the database is closed with its checksums.
+// Until ExitMaintenance is called, every access to the database fails with
+// ErrInMaintenance, and the database file must not be modified.
+func (w *Wallet) EnterMaintenance(timeout time.Duration) (*MaintenanceStatus, error) {
+	mdb, err := w.maintenanceDB()
+	if err != nil {
+		return nil, err
+	}
+
+	w.maintenance.mu.Lock()
+	defer w.maintenance.mu.Unlock()
+	if w.maintenance.resume != nil {
+		return nil, ErrInMaintenance
+	}
+
+	syncedTo := w.Manager.SyncedTo()
+	w.audit(AuditMaintenance, "maintenance mode entered at height %d",
+		syncedTo.Height)
+	w.maintenance.resume = make(chan struct{})
+	if err := mdb.closeForMaintenance(timeout); err != nil {
+		close(w.maintenance.resume)
+		w.maintenance.resume = nil
+		return nil, err
+	}
+	w.maintenance.since = time.Now()
+	w.maintenance.syncedTo = syncedTo
+
+	log.Infof("Entered maintenance mode, the wallet database %s is "+
+		"closed", mdb.path)
+	return w.maintenanceStatus(mdb), nil
+}
+
+// ExitMaintenance reopens and verifies the wallet database closed by
+// EnterMaintenance, resumes the processing of chain notifications, and
+// rescans the blocks connected since maintenance was entered for the
+// transactions the wallet may have missed.  The returned status is the one of
+// the ended maintenance.
+func (w *Wallet) ExitMaintenance() (*MaintenanceStatus, error) {
+	mdb, err := w.maintenanceDB()
+	if err != nil {
+		return nil, err
+	}
+
+	w.maintenance.mu.Lock()
+	if w.maintenance.resume == nil {
+		w.maintenance.mu.Unlock()
+		return nil, ErrNotInMaintenance
+	}
+	if err := mdb.reopen(); err != nil {
+		w.maintenance.mu.Unlock()
+		return nil, err
+	}
+	status := w.maintenanceStatus(mdb)
+	close(w.maintenance.resume)
+	w.maintenance.resume = nil
+	w.maintenance.mu.Unlock()
+
+	log.Infof("Exited maintenance mode after %v",
+		time.Since(status.Since).Round(time.Second))
+	w.audit(AuditMaintenance, "maintenance mode exited")
+
+	if err := w.catchUpRescan(status.SyncedTo); err != nil {
+		return status, fmt.Errorf("maintenance mode exited, but the "+
+			"catch-up rescan failed: %v", err)
+	}
+	return status, nil
+}
+
+// MaintenanceStatus returns the maintenance mode of the wallet.
+func (w *Wallet) MaintenanceStatus() *MaintenanceStatus {
+	w.maintenance.mu.Lock()
+	defer w.maintenance.mu.Unlock()
+	mdb, err := w.maintenanceDB()
+	if err != nil || w.maintenance.resume == nil {
+		return &MaintenanceStatus{}
+	}
+	return w.maintenanceStatus(mdb)
+}
+
+// InMaintenance returns whether the wallet is in maintenance mode.
+func (w *Wallet) InMaintenance() bool {
+	w.maintenance.mu.Lock()
+	defer w.maintenance.mu.Unlock()
+	return w.maintenance.resume != nil
+}
+
+// maintenanceStatus returns the status of the maintenance in progress.  This
+// must be called with the maintenance mutex held.
+func (w *Wallet) maintenanceStatus(mdb *maintenanceDB) *MaintenanceStatus {
+	return &MaintenanceStatus{
+		Active:   true,
+		Since:    w.maintenance.since,
+		DBPath:   mdb.path,
+		SyncedTo: w.maintenance.syncedTo,
+	}
+}
+
+// waitMaintenance blocks the processing of chain notifications while the
+// wallet is in maintenance mode.  It returns false when the wallet shuts down
+// first.
+func (w *Wallet) waitMaintenance() bool {
+	w.maintenance.mu.Lock()
+	resume := w.maintenance.resume
+	w.maintenance.mu.Unlock()
+	if resume == nil {
+		return true
+	}
+
+	select {
+	case <-resume:
+		return true
+	case <-w.quitChan():
+		return false
+	}
+}
+
+// catchUpRescan rescans the blocks from syncedTo for the transactions of the
+// wallet addresses and outputs, as chain notifications may have been dropped
+// while their processing was paused.  Nothing is done without a chain client.
+func (w *Wallet) catchUpRescan(syncedTo waddrmgr.BlockStamp) error {
+	if _, err := w.requireChainClient(); err != nil {
+		return nil
+	}
+
+	var (
+		addrs   []btcutil.Address
+		unspent []wtxmgr.Credit
+	)
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		var err error
+		addrs, unspent, err = w.activeData(dbtx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Rescanning from height %d to catch up after maintenance",
+		syncedTo.Height)
+	return w.rescanWithTarget(addrs, unspent, &syncedTo)
+}
+
+// maintenanceDB returns the database of the wallet that can be closed for
+// maintenance, underneath the encryption of the transaction store.
+func (w *Wallet) maintenanceDB() (*maintenanceDB, error) {
+	db := w.db
+	if cdb, ok := db.(*cryptDB); ok {
+		db = cdb.DB
+	}
+	mdb, ok := db.(*maintenanceDB)
+	if !ok {
+		return nil, errors.New("the wallet database cannot be closed " +
+			"for maintenance")
+	}
+	return mdb, nil
+}
+
+// maintenanceDB is a wallet database that can be closed for maintenance and
+// reopened while the wallet runs.  It counts the open transactions, so that
+// it is only closed once they are done, and refuses new ones while it is
+// closed.
+type maintenanceDB struct {
+	path   string
+	mu     sync.Mutex
+	cond   *sync.Cond
+	db     walletdb.DB
+	closed bool
+	active int
+}
+
+// newMaintenanceDB returns the maintenance database of the wallet database db
+// opened from path.
+func newMaintenanceDB(db walletdb.DB, path string) *maintenanceDB {
+	mdb := &maintenanceDB{path: path, db: db}
+	mdb.cond = sync.NewCond(&mdb.mu)
+	return mdb
+}
+
+// begin returns the database to open a transaction with and counts the
+// transaction, which must be ended with end.
+func (db *maintenanceDB) begin() (walletdb.DB, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return nil, ErrInMaintenance
+	}
+	db.active++
+	return db.db, nil
+}
+
+// end uncounts a transaction opened after begin.
+func (db *maintenanceDB) end() {
+	db.mu.Lock()
+	db.active--
+	if db.active == 0 {
+		db.cond.Broadcast()
+	}
+	db.mu.Unlock()
+}
+
+// closeForMaintenance refuses new transactions, waits up to timeout for the
+// open ones to end, and closes the database with its checksums.  New
+// transactions are accepted again when the open ones do not end in time.
+func (db *maintenanceDB) closeForMaintenance(timeout time.Duration) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return ErrInMaintenance
+	}
+	db.closed = true
+
+	deadline := time.Now().Add(timeout)
+	timer := time.AfterFunc(timeout, func() {
+		db.mu.Lock()
+		db.cond.Broadcast()
+		db.mu.Unlock()
+	})
+	defer timer.Stop()
+	for db.active > 0 && time.Now().Before(deadline) {
+		db.cond.Wait()
+	}
+	if db.active > 0 {
+		db.closed = false
+		return fmt.Errorf("%d wallet database transactions still open "+
+			"after %v", db.active, timeout)
+	}
+
+	return closeWithChecksums(db.db, db.path)
+}
+
+// reopen opens and verifies the database closed by closeForMaintenance, and
+// accepts new transactions again.
+func (db *maintenanceDB) reopen() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if !db.closed {
+		return ErrNotInMaintenance
+	}
+	reopened, err := openVerified(db.path)
+	if err != nil {
+		return err
+	}
+	db.db = reopened
+	db.closed = false
+	return nil
+}
+
+// BeginReadTx implements the walletdb.DB interface.
+func (db *maintenanceDB) BeginReadTx() (walletdb.ReadTx, error) {
+	inner, err := db.begin()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := inner.BeginReadTx()
+	if err != nil {
+		db.end()
+		return nil, err
+	}
+	return &maintenanceReadTx{ReadTx: tx, db: db}, nil
+}
+
+// BeginReadWriteTx implements the walletdb.DB interface.
+func (db *maintenanceDB) BeginReadWriteTx() (walletdb.ReadWriteTx, error) {
+	inner, err := db.begin()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := inner.BeginReadWriteTx()
+	if err != nil {
+		db.end()
+		return nil, err
+	}
+	return &maintenanceReadWriteTx{ReadWriteTx: tx, db: db}, nil
+}
+
+// Copy implements the walletdb.DB interface.
+func (db *maintenanceDB) Copy(w io.Writer) error {
+	inner, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer db.end()
+	return inner.Copy(w)
+}
+
+// Close implements the walletdb.DB interface.  A database closed for
+// maintenance is already closed.
+func (db *maintenanceDB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return nil
+	}
+	return db.db.Close()
+}
+
+// maintenanceReadTx is a read transaction of a maintenanceDB.
+type maintenanceReadTx struct {
+	walletdb.ReadTx
+	db   *maintenanceDB
+	done bool
+}
+
+// Rollback implements the walletdb.ReadTx interface.
+func (tx *maintenanceReadTx) Rollback() error {
+	err := tx.ReadTx.Rollback()
+	if !tx.done {
+		tx.done = true
+		tx.db.end()
+	}
+	return err
+}
+
+// maintenanceReadWriteTx is a read/write transaction of a maintenanceDB.
+type maintenanceReadWriteTx struct {
+	walletdb.ReadWriteTx
+	db   *maintenanceDB
+	done bool
+}
+
+// Commit implements the walletdb.ReadWriteTx interface.
+func (tx *maintenanceReadWriteTx) Commit() error {
+	err := tx.ReadWriteTx.Commit()
+	tx.finish()
+	return err
+}
+
+// Rollback implements the walletdb.ReadTx interface.
+func (tx *maintenanceReadWriteTx) Rollback() error {
+	err := tx.ReadWriteTx.Rollback()
+	tx.finish()
+	return err
+}
+
+// finish uncounts the transaction once it is committed or rolled back.
+func (tx *maintenanceReadWriteTx) finish() {
+	if !tx.done {
+		tx.done = true
+		tx.db.end()
+	}
+}
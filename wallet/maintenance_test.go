@@ -0,0 +1,84 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestMaintenanceDB checks that the wallet database is only closed for
+// maintenance once its transactions are done, that it refuses transactions
+// while closed, and that it is reopened with its contents.
+func TestMaintenanceDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintenance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dbPath := filepath.Join(dir, walletDbName)
+	db, err := walletdb.Create("bdb", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mdb := newMaintenanceDB(db, dbPath)
+	defer mdb.Close()
+	err = walletdb.Update(mdb, func(dbtx walletdb.ReadWriteTx) error {
+		ns, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		if err != nil {
+			return err
+		}
+		return ns.Put([]byte("key"), []byte("value"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The database is not closed while a transaction is open.
+	dbtx, err := mdb.BeginReadTx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mdb.closeForMaintenance(10 * time.Millisecond); err == nil {
+		t.Fatal("database closed with an open transaction")
+	}
+	if err := dbtx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mdb.closeForMaintenance(time.Second); err != nil {
+		t.Fatalf("unable to close the database: %v", err)
+	}
+	if _, err := mdb.BeginReadTx(); err != ErrInMaintenance {
+		t.Fatalf("transaction of a closed database: %v", err)
+	}
+	if _, err := os.Stat(dbPath + integritySuffix); err != nil {
+		t.Fatalf("checksums of the closed database: %v", err)
+	}
+
+	if err := mdb.reopen(); err != nil {
+		t.Fatalf("unable to reopen the database: %v", err)
+	}
+	err = walletdb.View(mdb, func(dbtx walletdb.ReadTx) error {
+		ns := dbtx.ReadBucket(wtxmetaNamespaceKey)
+		if string(ns.Get([]byte("key"))) != "value" {
+			t.Error("contents of the database lost after maintenance")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mdb.active != 0 {
+		t.Errorf("%d transactions counted after they are done", mdb.active)
+	}
+}
@@ -6,7 +6,7 @@
 package wallet
 
 import (
-	"errors"
+	"fmt"
 
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcutil"
@@ -14,13 +14,41 @@ import (
 	"github.com/btcsuite/btcwallet/walletdb"
 )
 
+// maxMultiSigKeys is the maximum number of public keys a standard P2SH
+// multisig redeem script may commit to.
+const maxMultiSigKeys = 16
+
+// MultiSigScriptError describes keys, addresses or a number of required
+// signatures that cannot make a multisig script.
+type MultiSigScriptError struct {
+	Description string
+}
+
+// Error satisfies the error interface.
+func (e *MultiSigScriptError) Error() string {
+	return e.Description
+}
+
 // MakeMultiSigScript creates a multi-signature script that can be redeemed with
 // nRequired signatures of the passed keys and addresses.  If the address is a
-// P2PKH address, the associated pubkey is looked up by the wallet if possible,
-// otherwise an error is returned for a missing pubkey.
+// P2PKH or P2WPKH address, the associated pubkey is looked up by the wallet if
+// possible, otherwise an error is returned for a missing pubkey.  Keys,
+// addresses and numbers of signatures that cannot make a multisig script are
+// reported with a *MultiSigScriptError, unlike failures of the wallet.
 //
-// This function only works with pubkeys and P2PKH addresses derived from them.
+// This function only works with pubkeys and P2PKH or P2WPKH addresses derived
+// from them.
 func (w *Wallet) MakeMultiSigScript(addrs []btcutil.Address, nRequired int) ([]byte, error) {
+	if len(addrs) == 0 || len(addrs) > maxMultiSigKeys {
+		return nil, &MultiSigScriptError{fmt.Sprintf("multisig script "+
+			"requires between 1 and %d keys", maxMultiSigKeys)}
+	}
+	if nRequired < 1 || nRequired > len(addrs) {
+		return nil, &MultiSigScriptError{fmt.Sprintf("multisig script "+
+			"cannot require %d of %d signatures", nRequired,
+			len(addrs))}
+	}
+
 	pubKeys := make([]*btcutil.AddressPubKey, len(addrs))
 
 	var dbtx walletdb.ReadTx
@@ -37,13 +65,14 @@ func (w *Wallet) MakeMultiSigScript(addrs []btcutil.Address, nRequired int) ([]b
 	for i, addr := range addrs {
 		switch addr := addr.(type) {
 		default:
-			return nil, errors.New("cannot make multisig script for " +
-				"a non-secp256k1 public key or P2PKH address")
+			return nil, &MultiSigScriptError{"cannot make multisig " +
+				"script for a non-secp256k1 public key, P2PKH or " +
+				"P2WPKH address"}
 
 		case *btcutil.AddressPubKey:
 			pubKeys[i] = addr
 
-		case *btcutil.AddressPubKeyHash:
+		case *btcutil.AddressPubKeyHash, *btcutil.AddressWitnessPubKeyHash:
 			if dbtx == nil {
 				var err error
 				dbtx, err = w.db.BeginReadTx()
@@ -56,8 +85,13 @@ func (w *Wallet) MakeMultiSigScript(addrs []btcutil.Address, nRequired int) ([]b
 			if err != nil {
 				return nil, err
 			}
-			serializedPubKey := addrInfo.(waddrmgr.ManagedPubKeyAddress).
-				PubKey().SerializeCompressed()
+			pkAddr, ok := addrInfo.(waddrmgr.ManagedPubKeyAddress)
+			if !ok {
+				return nil, &MultiSigScriptError{fmt.Sprintf(
+					"address %s is not a public key address",
+					addr.EncodeAddress())}
+			}
+			serializedPubKey := pkAddr.PubKey().SerializeCompressed()
 
 			pubKeyAddr, err := btcutil.NewAddressPubKey(
 				serializedPubKey, w.chainParams)
@@ -71,7 +105,9 @@ func (w *Wallet) MakeMultiSigScript(addrs []btcutil.Address, nRequired int) ([]b
 	return txscript.MultiSigScript(pubKeys, nRequired)
 }
 
-// ImportP2SHRedeemScript adds a P2SH redeem script to the wallet.
+// ImportP2SHRedeemScript adds a P2SH redeem script to the wallet.  When the
+// wallet is connected to a chain backend, the P2SH address is also registered
+// for transaction notifications so that payments to it are tracked.
 func (w *Wallet) ImportP2SHRedeemScript(script []byte) (*btcutil.AddressScriptHash, error) {
 	var p2shAddr *btcutil.AddressScriptHash
 	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
@@ -92,6 +128,11 @@ func (w *Wallet) ImportP2SHRedeemScript(script []byte) (*btcutil.AddressScriptHa
 			return err
 		}
 
+		err = putRedeemScript(tx.ReadWriteBucket(wscriptNamespaceKey), script)
+		if err != nil {
+			return err
+		}
+
 		addrInfo, err := bip44Mgr.ImportScript(addrmgrNs, script, bs)
 		if err != nil {
 			// Don't care if it's already there, but still have to
@@ -110,5 +151,18 @@ func (w *Wallet) ImportP2SHRedeemScript(script []byte) (*btcutil.AddressScriptHa
 		p2shAddr = addrInfo.Address().(*btcutil.AddressScriptHash)
 		return nil
 	})
-	return p2shAddr, err
+	if err != nil {
+		return nil, err
+	}
+	w.audit(AuditImport, "redeem script of %s imported", p2shAddr)
+
+	if chainClient := w.ChainClient(); chainClient != nil {
+		err := chainClient.NotifyReceived([]btcutil.Address{p2shAddr})
+		if err != nil {
+			return nil, fmt.Errorf("unable to subscribe for address "+
+				"ntfns for address %s: %v", p2shAddr.EncodeAddress(), err)
+		}
+	}
+
+	return p2shAddr, nil
 }
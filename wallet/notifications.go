@@ -6,12 +6,14 @@ package wallet
 
 import (
 	"bytes"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
-	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/btcsuite/btcwallet/wtxmgr"
@@ -36,8 +38,58 @@ type NotificationServer struct {
 	currentTxNtfn  *TransactionNotifications // coalesce this since wallet does not add mined txs together
 	spentness      map[uint32][]chan *SpentnessNotifications
 	accountClients []chan *AccountNotification
+	blockClients   []chan *BlockNotification
+	balanceClients []chan *BalanceNotification
+	lockClients    []chan *LockStateNotification
+	quotaClients   []chan *AccountQuotaNotification
+	unlockClients  []chan *UnlockFailureNotification
+	pendingClients []chan *PendingBroadcastNotification
+	usageClients   []chan *KeyUsageNotification
+	consolidations []chan *ConsolidationNotification
 	mu             sync.Mutex // Only protects registered client channels
 	wallet         *Wallet    // smells like hacks
+
+	// Balance notifications are debounced, with the latest balance of each
+	// account changed since the previous notification kept in
+	// pendingBalances until balanceTimer fires.  These are protected by mu.
+	balanceInterval     time.Duration
+	flushBalancesOnSend bool
+	pendingBalances     map[uint32]btcutil.Amount
+	balanceTimer        *time.Timer
+
+	// Notifications are numbered by increasing sequence numbers, with
+	// sequence the number of the latest notification, and the latest
+	// replaySize notifications are kept in replay for clients catching up
+	// after reconnecting.  These are protected by mu.
+	sequence   uint64
+	replaySize int
+	replay     []sequencedNotification
+
+	// Notifications created while none of the clients of their kind are
+	// registered are kept in queue, up to queueSize, until they are
+	// delivered, and persisted by the queue writer so that they survive
+	// restarts.  queueWrites are the changes to the queue and sequence
+	// not yet written.  These are protected by mu.
+	queueSize   int
+	queue       []queuedNotification
+	queueWrites []queueWrite
+	queueSignal chan struct{}
+}
+
+// DefaultNotificationReplaySize is the default number of the latest
+// notifications kept for ReplayNotifications.
+const DefaultNotificationReplaySize = 1000
+
+// ErrNotificationsExpired describes a replay of notifications which are no
+// longer kept by the NotificationServer.
+var ErrNotificationsExpired = errors.New("notifications are no longer " +
+	"kept for replay")
+
+// sequencedNotification is a notification kept for replays with its sequence
+// number.
+type sequencedNotification struct {
+	sequence     uint64
+	notification interface{}
 }
 
 func newNotificationServer(wallet *Wallet) *NotificationServer {
@@ -47,6 +99,89 @@ func newNotificationServer(wallet *Wallet) *NotificationServer {
 	}
 }
 
+// SetReplaySize sets the number of the latest notifications kept for
+// ReplayNotifications.  While replays are enabled, notifications are created
+// even when no client is registered, so that they can be replayed to clients
+// registering later.  A zero size disables replays.
+func (s *NotificationServer) SetReplaySize(size int) {
+	s.mu.Lock()
+	s.replaySize = size
+	if len(s.replay) > size {
+		s.replay = append([]sequencedNotification(nil),
+			s.replay[len(s.replay)-size:]...)
+	}
+	s.mu.Unlock()
+}
+
+// Sequence returns the sequence number of the latest notification, or zero
+// before the first notification.  Sequence numbers start again from zero
+// when the wallet is loaded, unless the notification queue is enabled.
+func (s *NotificationServer) Sequence() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sequence
+}
+
+// ReplayNotifications returns the notifications following the one numbered
+// by sequence, in order, so that a client reconnecting can catch up from the
+// latest notification it received rather than resyncing all state.  Each
+// notification is a pointer to one of the transaction, block, balance, lock
+// state, account quota, unlock failure, pending broadcast, key usage or
+// consolidation notification types, whose Sequence field numbers it.
+// ErrNotificationsExpired is returned when some of the notifications
+// following sequence are no longer kept, or when sequence was never reached,
+// as after the wallet is loaded again, and the client must resync all state.
+func (s *NotificationServer) ReplayNotifications(sequence uint64) ([]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sequence > s.sequence {
+		return nil, ErrNotificationsExpired
+	}
+	if sequence == s.sequence {
+		return nil, nil
+	}
+	if len(s.replay) == 0 || s.replay[0].sequence > sequence+1 {
+		return nil, ErrNotificationsExpired
+	}
+	i := int(sequence + 1 - s.replay[0].sequence)
+	ntfns := make([]interface{}, 0, len(s.replay)-i)
+	for _, n := range s.replay[i:] {
+		ntfns = append(ntfns, n.notification)
+	}
+	return ntfns, nil
+}
+
+// numbering returns whether notifications are created even when no client is
+// registered, because they are kept for replays or queued.  The caller must
+// hold s.mu.
+func (s *NotificationServer) numbering() bool {
+	return s.replaySize > 0 || s.queueSize > 0
+}
+
+// nextSequence numbers a notification, keeps it for replays, and queues it
+// when none of the clients of its kind are registered.  The caller must hold
+// s.mu.
+func (s *NotificationServer) nextSequence(n interface{}, clients int) uint64 {
+	s.sequence++
+	if s.replaySize > 0 {
+		if len(s.replay) >= s.replaySize {
+			s.replay = s.replay[len(s.replay)-s.replaySize+1:]
+		}
+		s.replay = append(s.replay, sequencedNotification{
+			sequence:     s.sequence,
+			notification: n,
+		})
+	}
+	if s.queueSize > 0 {
+		if clients == 0 {
+			s.enqueue(n)
+		}
+		s.signalQueueWriter()
+	}
+	return s.sequence
+}
+
 func lookupInputAccount(dbtx walletdb.ReadTx, w *Wallet, details *wtxmgr.TxDetails, deb wtxmgr.DebitRecord) uint32 {
 	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
 	txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
@@ -64,7 +199,7 @@ func lookupInputAccount(dbtx walletdb.ReadTx, w *Wallet, details *wtxmgr.TxDetai
 		return 0
 	}
 	prevOut := prev.MsgTx.TxOut[prevOP.Index]
-	_, addrs, _, err := txscript.ExtractPkScriptAddrs(prevOut.PkScript, w.chainParams)
+	_, addrs, _, err := taproot.ExtractPkScriptAddrs(prevOut.PkScript, w.chainParams)
 	var inputAcct uint32
 	if err == nil && len(addrs) > 0 {
 		_, inputAcct, err = w.Manager.AddrAccount(addrmgrNs, addrs[0])
@@ -82,7 +217,7 @@ func lookupOutputChain(dbtx walletdb.ReadTx, w *Wallet, details *wtxmgr.TxDetail
 	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
 
 	output := details.MsgTx.TxOut[cred.Index]
-	_, addrs, _, err := txscript.ExtractPkScriptAddrs(output.PkScript, w.chainParams)
+	_, addrs, _, err := taproot.ExtractPkScriptAddrs(output.PkScript, w.chainParams)
 	var ma waddrmgr.ManagedAddress
 	if err == nil && len(addrs) > 0 {
 		ma, err = w.Manager.Address(addrmgrNs, addrs[0])
@@ -160,7 +295,7 @@ func totalBalances(dbtx walletdb.ReadTx, w *Wallet, m map[uint32]btcutil.Amount)
 	for i := range unspent {
 		output := &unspent[i]
 		var outputAcct uint32
-		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(
 			output.PkScript, w.chainParams)
 		if err == nil && len(addrs) > 0 {
 			_, outputAcct, err = w.Manager.AddrAccount(addrmgrNs, addrs[0])
@@ -205,7 +340,7 @@ func (s *NotificationServer) notifyUnminedTransaction(dbtx walletdb.ReadTx, deta
 	defer s.mu.Unlock()
 	s.mu.Lock()
 	clients := s.transactions
-	if len(clients) == 0 {
+	if len(clients) == 0 && len(s.balanceClients) == 0 && !s.numbering() {
 		return
 	}
 
@@ -222,11 +357,13 @@ func (s *NotificationServer) notifyUnminedTransaction(dbtx walletdb.ReadTx, deta
 		log.Errorf("Cannot determine balances for relevant accounts: %v", err)
 		return
 	}
+	s.queueBalances(bals)
 	n := &TransactionNotifications{
 		UnminedTransactions:      unminedTxs,
 		UnminedTransactionHashes: unminedHashes,
 		NewBalances:              flattenBalanceMap(bals),
 	}
+	n.Sequence = s.nextSequence(n, len(clients))
 	for _, c := range clients {
 		c <- n
 	}
@@ -284,7 +421,7 @@ func (s *NotificationServer) notifyAttachedBlock(dbtx walletdb.ReadTx, block *wt
 	defer s.mu.Unlock()
 	s.mu.Lock()
 	clients := s.transactions
-	if len(clients) == 0 {
+	if len(clients) == 0 && len(s.balanceClients) == 0 && !s.numbering() {
 		s.currentTxNtfn = nil
 		return
 	}
@@ -314,7 +451,9 @@ func (s *NotificationServer) notifyAttachedBlock(dbtx walletdb.ReadTx, block *wt
 		return
 	}
 	s.currentTxNtfn.NewBalances = flattenBalanceMap(bals)
+	s.queueBalances(bals)
 
+	s.currentTxNtfn.Sequence = s.nextSequence(s.currentTxNtfn, len(clients))
 	for _, c := range clients {
 		c <- s.currentTxNtfn
 	}
@@ -345,6 +484,7 @@ type TransactionNotifications struct {
 	UnminedTransactions      []TransactionSummary
 	UnminedTransactionHashes []*chainhash.Hash
 	NewBalances              []AccountBalance
+	Sequence                 uint64
 }
 
 // Block contains the properties and all relevant transactions of an attached
@@ -633,3 +773,608 @@ func (c *AccountNotificationsClient) Done() {
 		s.mu.Unlock()
 	}()
 }
+
+// BlockNotification is a notification of a block connected to or disconnected
+// from the main chain, fired as the wallet processes the block.
+type BlockNotification struct {
+	Hash         chainhash.Hash
+	Height       int32
+	Time         time.Time
+	Disconnected bool
+	Sequence     uint64
+}
+
+func (s *NotificationServer) notifyBlock(block *wtxmgr.BlockMeta, disconnected bool) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	clients := s.blockClients
+	if len(clients) == 0 && !s.numbering() {
+		return
+	}
+	n := &BlockNotification{
+		Hash:         block.Hash,
+		Height:       block.Height,
+		Time:         block.Time,
+		Disconnected: disconnected,
+	}
+	n.Sequence = s.nextSequence(n, len(clients))
+	for _, c := range clients {
+		c <- n
+	}
+}
+
+// BlockNotificationsClient receives BlockNotifications over the channel C.
+type BlockNotificationsClient struct {
+	C      chan *BlockNotification
+	server *NotificationServer
+}
+
+// BlockNotifications returns a client for receiving BlockNotifications over a
+// channel.  The channel is unbuffered.  When finished, the client's Done
+// method should be called to disassociate the client from the server.
+func (s *NotificationServer) BlockNotifications() BlockNotificationsClient {
+	c := make(chan *BlockNotification)
+	s.mu.Lock()
+	s.blockClients = append(s.blockClients, c)
+	s.mu.Unlock()
+	return BlockNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *BlockNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.blockClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.blockClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// BalanceNotification is a notification of the new total balances of the
+// accounts whose balances changed since the previous notification.
+type BalanceNotification struct {
+	Balances []AccountBalance
+	Sequence uint64
+}
+
+// SetBalanceNotificationInterval sets the interval balance notifications are
+// debounced over.  The balance changes during an interval are coalesced so
+// that clients receive at most one balance of each account per interval, the
+// latest.  A zero interval notifies every balance change.  With flushOnSend,
+// pending balances are notified as soon as the wallet publishes a
+// transaction, without waiting for the end of the interval.
+func (s *NotificationServer) SetBalanceNotificationInterval(interval time.Duration,
+	flushOnSend bool) {
+
+	s.mu.Lock()
+	s.balanceInterval = interval
+	s.flushBalancesOnSend = flushOnSend
+	s.mu.Unlock()
+}
+
+// queueBalances records new account balances for balance clients, and
+// notifies them when the balance notification interval elapses.  The caller
+// must hold s.mu.
+func (s *NotificationServer) queueBalances(bals map[uint32]btcutil.Amount) {
+	if (len(s.balanceClients) == 0 && !s.numbering()) || len(bals) == 0 {
+		return
+	}
+	if s.pendingBalances == nil {
+		s.pendingBalances = make(map[uint32]btcutil.Amount)
+	}
+	for account, balance := range bals {
+		s.pendingBalances[account] = balance
+	}
+
+	if s.balanceInterval == 0 {
+		s.flushBalances()
+		return
+	}
+	if s.balanceTimer != nil {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(s.balanceInterval, func() {
+		s.mu.Lock()
+		// The pending balances were already flushed if the timer was
+		// replaced.
+		if s.balanceTimer == timer {
+			s.flushBalances()
+		}
+		s.mu.Unlock()
+	})
+	s.balanceTimer = timer
+}
+
+// flushBalances notifies balance clients of the pending balances.  The caller
+// must hold s.mu.
+func (s *NotificationServer) flushBalances() {
+	if s.balanceTimer != nil {
+		s.balanceTimer.Stop()
+		s.balanceTimer = nil
+	}
+	if len(s.pendingBalances) == 0 {
+		return
+	}
+	n := &BalanceNotification{
+		Balances: flattenBalanceMap(s.pendingBalances),
+	}
+	s.pendingBalances = nil
+	n.Sequence = s.nextSequence(n, len(s.balanceClients))
+	for _, c := range s.balanceClients {
+		c <- n
+	}
+}
+
+// notifyPublishedTransaction flushes the pending balances after the wallet
+// publishes a transaction, when balance notifications are flushed on sends.
+func (s *NotificationServer) notifyPublishedTransaction() {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	if s.flushBalancesOnSend {
+		s.flushBalances()
+	}
+}
+
+// BalanceNotificationsClient receives BalanceNotifications over the channel
+// C.
+type BalanceNotificationsClient struct {
+	C      chan *BalanceNotification
+	server *NotificationServer
+}
+
+// BalanceNotifications returns a client for receiving BalanceNotifications
+// over a channel.  The channel is unbuffered.  When finished, the client's
+// Done method should be called to disassociate the client from the server.
+func (s *NotificationServer) BalanceNotifications() BalanceNotificationsClient {
+	c := make(chan *BalanceNotification)
+	s.mu.Lock()
+	s.balanceClients = append(s.balanceClients, c)
+	s.mu.Unlock()
+	return BalanceNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *BalanceNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.balanceClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.balanceClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// LockStateNotification is a notification of a change of the lock state of
+// the wallet, or of its private passphrase.  After a passphrase change, the
+// wallet keeps its lock state, but can only be unlocked again with the new
+// passphrase.
+type LockStateNotification struct {
+	Locked            bool
+	PassphraseChanged bool
+	Sequence          uint64
+}
+
+func (s *NotificationServer) notifyLockState(locked, passphraseChanged bool) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	clients := s.lockClients
+	if len(clients) == 0 && !s.numbering() {
+		return
+	}
+	n := &LockStateNotification{
+		Locked:            locked,
+		PassphraseChanged: passphraseChanged,
+	}
+	n.Sequence = s.nextSequence(n, len(clients))
+	for _, c := range clients {
+		c <- n
+	}
+}
+
+// LockStateNotificationsClient receives LockStateNotifications over the
+// channel C.
+type LockStateNotificationsClient struct {
+	C      chan *LockStateNotification
+	server *NotificationServer
+}
+
+// LockStateNotifications returns a client for receiving
+// LockStateNotifications over a channel.  The channel is unbuffered.  When
+// finished, the client's Done method should be called to disassociate the
+// client from the server.
+func (s *NotificationServer) LockStateNotifications() LockStateNotificationsClient {
+	c := make(chan *LockStateNotification)
+	s.mu.Lock()
+	s.lockClients = append(s.lockClients, c)
+	s.mu.Unlock()
+	return LockStateNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *LockStateNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.lockClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.lockClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// AccountQuotaNotification is a notification that the on-disk footprint of
+// the transactions of an account exceeds its quota.  It is sent when the
+// quota is first exceeded, and again only after the footprint has fallen back
+// under the quota.
+type AccountQuotaNotification struct {
+	AccountName string
+	Footprint   int64
+	Quota       int64
+	Sequence    uint64
+}
+
+func (s *NotificationServer) notifyAccountQuota(n *AccountQuotaNotification) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	n.Sequence = s.nextSequence(n, len(s.quotaClients))
+	for _, c := range s.quotaClients {
+		c <- n
+	}
+}
+
+// AccountQuotaNotificationsClient receives AccountQuotaNotifications over the
+// channel C.
+type AccountQuotaNotificationsClient struct {
+	C      chan *AccountQuotaNotification
+	server *NotificationServer
+}
+
+// AccountQuotaNotifications returns a client for receiving
+// AccountQuotaNotifications over a channel.  The channel is unbuffered.  When
+// finished, the client's Done method should be called to disassociate the
+// client from the server.
+func (s *NotificationServer) AccountQuotaNotifications() AccountQuotaNotificationsClient {
+	c := make(chan *AccountQuotaNotification)
+	s.mu.Lock()
+	s.quotaClients = append(s.quotaClients, c)
+	s.mu.Unlock()
+	return AccountQuotaNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *AccountQuotaNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.quotaClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.quotaClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// UnlockFailureNotification is a notification of a wrong passphrase of the
+// wallet or of one of its accounts.  Failures is the number of consecutive
+// wrong passphrases.  Passphrases are not tried again before RetryAfter, and
+// when LockedOut, not until the wallet is restarted if RetryAfter is zero.
+type UnlockFailureNotification struct {
+	Failures   uint32
+	RetryAfter time.Duration
+	LockedOut  bool
+	Sequence   uint64
+}
+
+func (s *NotificationServer) notifyUnlockFailure(n *UnlockFailureNotification) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	n.Sequence = s.nextSequence(n, len(s.unlockClients))
+	for _, c := range s.unlockClients {
+		c <- n
+	}
+}
+
+// UnlockFailureNotificationsClient receives UnlockFailureNotifications over
+// the channel C.
+type UnlockFailureNotificationsClient struct {
+	C      chan *UnlockFailureNotification
+	server *NotificationServer
+}
+
+// UnlockFailureNotifications returns a client for receiving
+// UnlockFailureNotifications over a channel.  The channel is unbuffered.  When
+// finished, the client's Done method should be called to disassociate the
+// client from the server.
+func (s *NotificationServer) UnlockFailureNotifications() UnlockFailureNotificationsClient {
+	c := make(chan *UnlockFailureNotification)
+	s.mu.Lock()
+	s.unlockClients = append(s.unlockClients, c)
+	s.mu.Unlock()
+	return UnlockFailureNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *UnlockFailureNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.unlockClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.unlockClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// PendingBroadcastNotification is a notification of a published transaction
+// held until BroadcastAt, which may be cancelled with CancelBroadcast until
+// then.
+type PendingBroadcastNotification struct {
+	Tx          *wire.MsgTx
+	TxHash      chainhash.Hash
+	Outputs     []PendingBroadcastOutput
+	Annotations map[string]string
+	Replaceable bool
+	BroadcastAt time.Time
+	Sequence    uint64
+}
+
+func (s *NotificationServer) notifyPendingBroadcast(n *PendingBroadcastNotification) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	n.Sequence = s.nextSequence(n, len(s.pendingClients))
+	for _, c := range s.pendingClients {
+		c <- n
+	}
+}
+
+// PendingBroadcastNotificationsClient receives PendingBroadcastNotifications
+// over the channel C.
+type PendingBroadcastNotificationsClient struct {
+	C      chan *PendingBroadcastNotification
+	server *NotificationServer
+}
+
+// PendingBroadcastNotifications returns a client for receiving
+// PendingBroadcastNotifications over a channel.  The channel is unbuffered.
+// When finished, the client's Done method should be called to disassociate
+// the client from the server.
+func (s *NotificationServer) PendingBroadcastNotifications() PendingBroadcastNotificationsClient {
+	c := make(chan *PendingBroadcastNotification)
+	s.mu.Lock()
+	s.pendingClients = append(s.pendingClients, c)
+	s.mu.Unlock()
+	return PendingBroadcastNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *PendingBroadcastNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.pendingClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.pendingClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// KeyUsageNotification is a notification of a signing volume deviating
+// sharply from its baseline.  Address is the address whose key signed, or
+// empty when the signing volume of the whole account is notified.
+type KeyUsageNotification struct {
+	KeyUsage
+	Account  uint32
+	Address  string
+	Sequence uint64
+}
+
+func (s *NotificationServer) notifyKeyUsage(n *KeyUsageNotification) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	n.Sequence = s.nextSequence(n, len(s.usageClients))
+	for _, c := range s.usageClients {
+		c <- n
+	}
+}
+
+// KeyUsageNotificationsClient receives KeyUsageNotifications over the channel
+// C.
+type KeyUsageNotificationsClient struct {
+	C      chan *KeyUsageNotification
+	server *NotificationServer
+}
+
+// KeyUsageNotifications returns a client for receiving KeyUsageNotifications
+// over a channel.  The channel is unbuffered.  When finished, the client's
+// Done method should be called to disassociate the client from the server.
+func (s *NotificationServer) KeyUsageNotifications() KeyUsageNotificationsClient {
+	c := make(chan *KeyUsageNotification)
+	s.mu.Lock()
+	s.usageClients = append(s.usageClients, c)
+	s.mu.Unlock()
+	return KeyUsageNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *KeyUsageNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.usageClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.usageClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// ConsolidationNotification is a notification of a consolidation sent, or
+// which failed, by ConsolidateChange or ConsolidateFragmented, including the
+// consolidations of the background consolidation policies.
+type ConsolidationNotification struct {
+	Consolidation
+	Sequence uint64
+}
+
+func (s *NotificationServer) notifyConsolidation(c *Consolidation) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	n := &ConsolidationNotification{Consolidation: *c}
+	n.Sequence = s.nextSequence(n, len(s.consolidations))
+	for _, ch := range s.consolidations {
+		ch <- n
+	}
+}
+
+// ConsolidationNotificationsClient receives ConsolidationNotifications over
+// the channel C.
+type ConsolidationNotificationsClient struct {
+	C      chan *ConsolidationNotification
+	server *NotificationServer
+}
+
+// ConsolidationNotifications returns a client for receiving
+// ConsolidationNotifications over a channel.  The channel is unbuffered.  When
+// finished, the client's Done method should be called to disassociate the
+// client from the server.
+func (s *NotificationServer) ConsolidationNotifications() ConsolidationNotificationsClient {
+	c := make(chan *ConsolidationNotification)
+	s.mu.Lock()
+	s.consolidations = append(s.consolidations, c)
+	s.mu.Unlock()
+	return ConsolidationNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *ConsolidationNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.consolidations
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.consolidations = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+)
+
+// receiveBalances returns the balances of the next balance notification, or
+// nil when none is received before the timeout.
+func receiveBalances(c *BalanceNotificationsClient, timeout time.Duration) map[uint32]btcutil.Amount {
+	select {
+	case n := <-c.C:
+		m := make(map[uint32]btcutil.Amount)
+		for _, b := range n.Balances {
+			m[b.Account] = b.TotalBalance
+		}
+		return m
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+// queue queues balances for balance clients as the wallet does when
+// processing transactions.
+func queue(s *NotificationServer, bals map[uint32]btcutil.Amount) {
+	s.mu.Lock()
+	s.queueBalances(bals)
+	s.mu.Unlock()
+}
+
+// TestBalanceNotificationDebounce checks that balance changes are coalesced
+// over the balance notification interval, and flushed early on sends.
+func TestBalanceNotificationDebounce(t *testing.T) {
+	s := newNotificationServer(nil)
+	c := s.BalanceNotifications()
+	defer c.Done()
+
+	// Without an interval, every balance change is notified.
+	go queue(s, map[uint32]btcutil.Amount{0: 1})
+	if bals := receiveBalances(&c, time.Second); bals[0] != 1 {
+		t.Fatalf("unexpected balances %v", bals)
+	}
+
+	// Changes during the interval are notified once, with the latest
+	// balance of each account.
+	const interval = 100 * time.Millisecond
+	s.SetBalanceNotificationInterval(interval, false)
+	queue(s, map[uint32]btcutil.Amount{0: 2, 1: 5})
+	queue(s, map[uint32]btcutil.Amount{0: 3})
+	if bals := receiveBalances(&c, interval/2); bals != nil {
+		t.Fatalf("balances %v notified before the end of the interval",
+			bals)
+	}
+	bals := receiveBalances(&c, time.Second)
+	if len(bals) != 2 || bals[0] != 3 || bals[1] != 5 {
+		t.Fatalf("unexpected balances %v", bals)
+	}
+	if bals := receiveBalances(&c, 2*interval); bals != nil {
+		t.Fatalf("unexpected second notification of balances %v", bals)
+	}
+
+	// Unless requested, sends do not flush the pending balances, which
+	// are notified at the end of the interval.
+	queue(s, map[uint32]btcutil.Amount{0: 4})
+	s.notifyPublishedTransaction()
+	if bals := receiveBalances(&c, time.Second); bals[0] != 4 {
+		t.Fatalf("unexpected balances %v", bals)
+	}
+
+	s.SetBalanceNotificationInterval(time.Hour, true)
+	queue(s, map[uint32]btcutil.Amount{0: 5})
+	go s.notifyPublishedTransaction()
+	if bals := receiveBalances(&c, time.Second); bals[0] != 5 {
+		t.Fatalf("balances %v were not flushed on send", bals)
+	}
+}
+
+// TestLockStateNotifications checks that lock state clients are notified
+// until they are done.
+func TestLockStateNotifications(t *testing.T) {
+	s := newNotificationServer(nil)
+	c := s.LockStateNotifications()
+
+	go s.notifyLockState(false, true)
+	select {
+	case n := <-c.C:
+		if n.Locked || !n.PassphraseChanged {
+			t.Fatalf("unexpected notification %+v", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no lock state notification")
+	}
+
+	c.Done()
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			s.notifyLockState(true, false)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("notifications blocked after the client is done")
+	}
+}
+
+// TestNotificationReplay checks that notifications are numbered in order, and
+// that only the latest are kept for replays.
+func TestNotificationReplay(t *testing.T) {
+	s := newNotificationServer(nil)
+	s.notifyLockState(true, false)
+	if seq := s.Sequence(); seq != 0 {
+		t.Fatalf("notification without client numbered %d while "+
+			"replays are disabled", seq)
+	}
+
+	s.SetReplaySize(3)
+	for i := 0; i < 4; i++ {
+		s.notifyLockState(i%2 == 0, false)
+	}
+	s.notifyUnlockFailure(&UnlockFailureNotification{Failures: 1})
+	if seq := s.Sequence(); seq != 5 {
+		t.Fatalf("sequence %d, want 5", seq)
+	}
+
+	ntfns, err := s.ReplayNotifications(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ntfns) != 3 {
+		t.Fatalf("replayed %d notifications, want 3", len(ntfns))
+	}
+	for i, n := range ntfns[:2] {
+		n, ok := n.(*LockStateNotification)
+		if !ok || n.Sequence != uint64(i+3) || n.Locked != (i%2 == 0) {
+			t.Errorf("unexpected replayed notification %+v", n)
+		}
+	}
+	if n, ok := ntfns[2].(*UnlockFailureNotification); !ok || n.Sequence != 5 {
+		t.Errorf("unexpected replayed notification %+v", ntfns[2])
+	}
+
+	if ntfns, err := s.ReplayNotifications(5); err != nil || len(ntfns) != 0 {
+		t.Errorf("replay of the latest notification: %v, %v", ntfns, err)
+	}
+	if _, err := s.ReplayNotifications(1); err != ErrNotificationsExpired {
+		t.Errorf("replay of dropped notifications: %v", err)
+	}
+	if _, err := s.ReplayNotifications(6); err != ErrNotificationsExpired {
+		t.Errorf("replay from a future sequence: %v", err)
+	}
+}
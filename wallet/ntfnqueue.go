@@ -0,0 +1,345 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// DefaultNotificationQueueSize is the default number of undelivered
+// notifications kept in the notification queue.
+const DefaultNotificationQueueSize = 10000
+
+var (
+	// ntfnQueueBucketKey is the key of the bucket in the transaction
+	// metadata namespace recording the queued notifications, keyed by
+	// their big endian sequence numbers.
+	ntfnQueueBucketKey = []byte("ntfnqueue")
+
+	// ntfnSequenceKey is the key in the transaction metadata namespace of
+	// the big endian sequence number of the latest notification, so that
+	// sequence numbers keep increasing when the wallet restarts.
+	ntfnSequenceKey = []byte("ntfnsequence")
+)
+
+// queuedNotification is a notification kept in the notification queue, with
+// its serialization.
+type queuedNotification struct {
+	sequence     uint64
+	notification interface{}
+	serialized   []byte
+}
+
+// queueWrite is a change to the persisted notification queue.  The queued
+// notification is removed when serialized is nil.
+type queueWrite struct {
+	sequence   uint64
+	serialized []byte
+}
+
+// serializedNotification is the JSON serialization of a queued notification,
+// with the field of its kind set.  Pending broadcast and consolidation
+// notifications are not queued, since they are only relevant while frontends
+// may still act on them.
+type serializedNotification struct {
+	Transactions  *TransactionNotifications  `json:"transactions,omitempty"`
+	Block         *BlockNotification         `json:"block,omitempty"`
+	Balance       *BalanceNotification       `json:"balance,omitempty"`
+	LockState     *LockStateNotification     `json:"lockstate,omitempty"`
+	AccountQuota  *AccountQuotaNotification  `json:"accountquota,omitempty"`
+	UnlockFailure *UnlockFailureNotification `json:"unlockfailure,omitempty"`
+	KeyUsage      *KeyUsageNotification      `json:"keyusage,omitempty"`
+}
+
+// serializeNotification serializes a notification for the notification
+// queue.  False is returned for the kinds of notifications which are not
+// queued.
+func serializeNotification(n interface{}) ([]byte, bool) {
+	var sn serializedNotification
+	switch n := n.(type) {
+	case *TransactionNotifications:
+		sn.Transactions = n
+	case *BlockNotification:
+		sn.Block = n
+	case *BalanceNotification:
+		sn.Balance = n
+	case *LockStateNotification:
+		sn.LockState = n
+	case *AccountQuotaNotification:
+		sn.AccountQuota = n
+	case *UnlockFailureNotification:
+		sn.UnlockFailure = n
+	case *KeyUsageNotification:
+		sn.KeyUsage = n
+	default:
+		return nil, false
+	}
+	b, err := json.Marshal(&sn)
+	if err != nil {
+		log.Errorf("Cannot serialize queued notification: %v", err)
+		return nil, false
+	}
+	return b, true
+}
+
+// deserializeNotification deserializes a queued notification numbered by
+// sequence.
+func deserializeNotification(sequence uint64, b []byte) (interface{}, error) {
+	var sn serializedNotification
+	if err := json.Unmarshal(b, &sn); err != nil {
+		return nil, err
+	}
+	switch {
+	case sn.Transactions != nil:
+		sn.Transactions.Sequence = sequence
+		return sn.Transactions, nil
+	case sn.Block != nil:
+		sn.Block.Sequence = sequence
+		return sn.Block, nil
+	case sn.Balance != nil:
+		sn.Balance.Sequence = sequence
+		return sn.Balance, nil
+	case sn.LockState != nil:
+		sn.LockState.Sequence = sequence
+		return sn.LockState, nil
+	case sn.AccountQuota != nil:
+		sn.AccountQuota.Sequence = sequence
+		return sn.AccountQuota, nil
+	case sn.UnlockFailure != nil:
+		sn.UnlockFailure.Sequence = sequence
+		return sn.UnlockFailure, nil
+	case sn.KeyUsage != nil:
+		sn.KeyUsage.Sequence = sequence
+		return sn.KeyUsage, nil
+	}
+	return nil, errors.New("unknown kind of queued notification")
+}
+
+// SetQueueSize enables the notification queue, which keeps up to size
+// notifications created while none of the clients of their kind are
+// registered, such as a deposit received while no frontend is connected,
+// until DeliverQueuedNotifications delivers them.  Queued notifications are
+// persisted in the wallet database, along with the sequence number of the
+// latest notification, so they are also delivered after the wallet restarts.
+// The oldest notifications are dropped when the queue is full.  A zero size
+// disables the queue and drops the notifications it kept.
+//
+// Pending broadcast and consolidation notifications are never queued.
+// SetQueueSize must be called once, before the wallet creates notifications.
+func (s *NotificationServer) SetQueueSize(size int) error {
+	var (
+		sequence uint64
+		queue    []queuedNotification
+	)
+	err := walletdb.Update(s.wallet.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		if size == 0 {
+			if ns.NestedReadWriteBucket(ntfnQueueBucketKey) == nil {
+				return nil
+			}
+			return ns.DeleteNestedBucket(ntfnQueueBucketKey)
+		}
+
+		if v := ns.Get(ntfnSequenceKey); len(v) == 8 {
+			sequence = binary.BigEndian.Uint64(v)
+		}
+		bucket, err := ns.CreateBucketIfNotExists(ntfnQueueBucketKey)
+		if err != nil {
+			return err
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			if len(k) != 8 {
+				return errors.New("invalid queued notification key")
+			}
+			seq := binary.BigEndian.Uint64(k)
+			n, err := deserializeNotification(seq, v)
+			if err != nil {
+				log.Warnf("Dropping queued notification %d: %v",
+					seq, err)
+				return nil
+			}
+			queue = append(queue, queuedNotification{
+				sequence:     seq,
+				notification: n,
+				serialized:   append([]byte(nil), v...),
+			})
+			return nil
+		})
+	})
+	if err != nil || size == 0 {
+		return err
+	}
+	if len(queue) != 0 {
+		log.Infof("Loaded %d undelivered notifications", len(queue))
+	}
+
+	s.mu.Lock()
+	if sequence > s.sequence {
+		s.sequence = sequence
+	}
+	s.queueSize = size
+	s.queue = queue
+	s.queueSignal = make(chan struct{}, 1)
+	s.trimQueue()
+	s.mu.Unlock()
+
+	s.wallet.wg.Add(1)
+	go s.queueWriter()
+	return nil
+}
+
+// enqueue queues a notification.  The caller must hold s.mu.
+func (s *NotificationServer) enqueue(n interface{}) {
+	b, ok := serializeNotification(n)
+	if !ok {
+		return
+	}
+	s.queue = append(s.queue, queuedNotification{
+		sequence:     s.sequence,
+		notification: n,
+		serialized:   b,
+	})
+	s.queueWrites = append(s.queueWrites, queueWrite{
+		sequence:   s.sequence,
+		serialized: b,
+	})
+	s.trimQueue()
+}
+
+// trimQueue drops the oldest queued notifications exceeding the queue size.
+// The caller must hold s.mu.
+func (s *NotificationServer) trimQueue() {
+	if len(s.queue) <= s.queueSize {
+		return
+	}
+	drop := s.queue[:len(s.queue)-s.queueSize]
+	log.Warnf("Notification queue is full, dropping %d undelivered "+
+		"notifications", len(drop))
+	for _, n := range drop {
+		s.queueWrites = append(s.queueWrites, queueWrite{
+			sequence: n.sequence,
+		})
+	}
+	s.queue = append([]queuedNotification(nil),
+		s.queue[len(drop):]...)
+}
+
+// DeliverQueuedNotifications calls deliver with each queued notification, in
+// order, and removes from the queue those it delivered, for which it returns
+// true.  Each notification is a pointer to one of the transaction, block,
+// balance, lock state, account quota, unlock failure or key usage
+// notification types, whose Sequence field numbers it.  Notifications queued
+// while deliver is called are not passed to it.
+func (s *NotificationServer) DeliverQueuedNotifications(deliver func(interface{}) bool) {
+	s.mu.Lock()
+	queue := s.queue
+	s.mu.Unlock()
+	if len(queue) == 0 {
+		return
+	}
+
+	delivered := make(map[uint64]struct{})
+	for _, n := range queue {
+		if deliver(n.notification) {
+			delivered[n.sequence] = struct{}{}
+		}
+	}
+	if len(delivered) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := make([]queuedNotification, 0, len(s.queue))
+	for _, n := range s.queue {
+		if _, ok := delivered[n.sequence]; !ok {
+			kept = append(kept, n)
+			continue
+		}
+		s.queueWrites = append(s.queueWrites, queueWrite{
+			sequence: n.sequence,
+		})
+	}
+	s.queue = kept
+	s.signalQueueWriter()
+}
+
+// signalQueueWriter wakes the queue writer to write the changes to the queue
+// and sequence.  The caller must hold s.mu.
+func (s *NotificationServer) signalQueueWriter() {
+	select {
+	case s.queueSignal <- struct{}{}:
+	default:
+	}
+}
+
+// queueWriter writes the changes to the notification queue and sequence to
+// the wallet database until the wallet stops.  Notifications are created
+// during database transactions, so they are written by this goroutine rather
+// than when they are queued.
+func (s *NotificationServer) queueWriter() {
+	defer s.wallet.wg.Done()
+
+	quit := s.wallet.quitChan()
+	var written uint64
+	for {
+		select {
+		case <-s.queueSignal:
+			written = s.writeQueue(written)
+		case <-quit:
+			s.writeQueue(written)
+			return
+		}
+	}
+}
+
+// writeQueue writes the changes to the notification queue, and the sequence
+// when it differs from the sequence written before.  The sequence written is
+// returned.
+func (s *NotificationServer) writeQueue(written uint64) uint64 {
+	s.mu.Lock()
+	writes := s.queueWrites
+	s.queueWrites = nil
+	sequence := s.sequence
+	s.mu.Unlock()
+	if len(writes) == 0 && sequence == written {
+		return written
+	}
+
+	err := walletdb.Update(s.wallet.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket := ns.NestedReadWriteBucket(ntfnQueueBucketKey)
+		for _, w := range writes {
+			k := make([]byte, 8)
+			binary.BigEndian.PutUint64(k, w.sequence)
+			var err error
+			if w.serialized == nil {
+				err = bucket.Delete(k)
+			} else {
+				err = bucket.Put(k, w.serialized)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		v := make([]byte, 8)
+		binary.BigEndian.PutUint64(v, sequence)
+		return ns.Put(ntfnSequenceKey, v)
+	})
+	if err != nil {
+		log.Errorf("Cannot write notification queue: %v", err)
+
+		// Retry the writes with the next changes.
+		s.mu.Lock()
+		s.queueWrites = append(writes, s.queueWrites...)
+		s.mu.Unlock()
+		return written
+	}
+	return sequence
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TestNotificationQueue checks that notifications created without clients
+// are queued until delivered, and that the queue and sequence numbers survive
+// restarts.
+func TestNotificationQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ntfnqueue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &Wallet{db: db, quit: make(chan struct{})}
+	s := newNotificationServer(w)
+	if err := s.SetQueueSize(3); err != nil {
+		t.Fatal(err)
+	}
+
+	// The oldest notifications are dropped when the queue is full, and
+	// notifications with clients are not queued.
+	for i := 0; i < 3; i++ {
+		s.notifyLockState(i%2 == 0, false)
+	}
+	c := s.LockStateNotifications()
+	done := make(chan struct{})
+	go func() {
+		<-c.C
+		close(done)
+	}()
+	s.notifyLockState(true, false)
+	<-done
+	c.Done()
+	s.notifyUnlockFailure(&UnlockFailureNotification{Failures: 2})
+	hash := chainhash.Hash{1}
+	s.notifyBlock(&wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{Hash: hash, Height: 7},
+	}, false)
+
+	var sequences []uint64
+	s.DeliverQueuedNotifications(func(n interface{}) bool {
+		switch n := n.(type) {
+		case *LockStateNotification:
+			sequences = append(sequences, n.Sequence)
+		case *UnlockFailureNotification:
+			sequences = append(sequences, n.Sequence)
+			return true
+		case *BlockNotification:
+			sequences = append(sequences, n.Sequence)
+		}
+		return false
+	})
+	if len(sequences) != 3 || sequences[0] != 3 || sequences[1] != 5 ||
+		sequences[2] != 6 {
+
+		t.Fatalf("queued notifications %v, want [3 5 6]", sequences)
+	}
+
+	// Restart the wallet with the same database.
+	close(w.quit)
+	w.wg.Wait()
+	w = &Wallet{db: db, quit: make(chan struct{})}
+	s = newNotificationServer(w)
+	if err := s.SetQueueSize(3); err != nil {
+		t.Fatal(err)
+	}
+	if seq := s.Sequence(); seq != 6 {
+		t.Fatalf("sequence %d after restart, want 6", seq)
+	}
+	var queued []interface{}
+	s.DeliverQueuedNotifications(func(n interface{}) bool {
+		queued = append(queued, n)
+		return true
+	})
+	if len(queued) != 2 {
+		t.Fatalf("%d notifications queued after restart, want 2",
+			len(queued))
+	}
+	if n, ok := queued[0].(*LockStateNotification); !ok || n.Sequence != 3 ||
+		!n.Locked {
+
+		t.Errorf("unexpected queued notification %+v", queued[0])
+	}
+	if n, ok := queued[1].(*BlockNotification); !ok || n.Sequence != 6 ||
+		n.Hash != hash || n.Height != 7 {
+
+		t.Errorf("unexpected queued notification %+v", queued[1])
+	}
+	s.DeliverQueuedNotifications(func(n interface{}) bool {
+		t.Errorf("delivered notification %+v delivered again", n)
+		return false
+	})
+	close(w.quit)
+	w.wg.Wait()
+
+	// Disabling the queue drops the notifications it kept.
+	w = &Wallet{db: db, quit: make(chan struct{})}
+	s = newNotificationServer(w)
+	if err := s.SetQueueSize(3); err != nil {
+		t.Fatal(err)
+	}
+	s.notifyLockState(false, false)
+	close(w.quit)
+	w.wg.Wait()
+	w = &Wallet{db: db, quit: make(chan struct{})}
+	if err := newNotificationServer(w).SetQueueSize(0); err != nil {
+		t.Fatal(err)
+	}
+	s = newNotificationServer(w)
+	if err := s.SetQueueSize(3); err != nil {
+		t.Fatal(err)
+	}
+	s.DeliverQueuedNotifications(func(n interface{}) bool {
+		t.Errorf("notification %+v queued after the queue was "+
+			"disabled", n)
+		return false
+	})
+	close(w.quit)
+	w.wg.Wait()
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// broadcastQueueBucketKey is the key of the bucket, nested in the transaction
+// metadata namespace, of the transactions published while the wallet was
+// offline.  Keys are transaction hashes and values are the serialized
+// transactions.
+var broadcastQueueBucketKey = []byte("broadcastqueue")
+
+// SetOffline sets whether the wallet runs without a consensus RPC server.
+// Offline wallets create addresses and transactions from the state recorded
+// when they were last synced, and queue published transactions until the
+// wallet is synced with a chain client.
+func (w *Wallet) SetOffline(offline bool) {
+	w.chainClientLock.Lock()
+	w.offline = offline
+	w.chainClientLock.Unlock()
+}
+
+// Offline returns whether the wallet runs without a consensus RPC server.
+func (w *Wallet) Offline() bool {
+	w.chainClientLock.Lock()
+	offline := w.offline
+	w.chainClientLock.Unlock()
+	return offline
+}
+
+// optionalChainClient is like requireChainClient, but returns a nil chain
+// client without an error when the wallet is offline.
+func (w *Wallet) optionalChainClient() (chain.Interface, error) {
+	w.chainClientLock.Lock()
+	chainClient, offline := w.chainClient, w.offline
+	w.chainClientLock.Unlock()
+	if chainClient == nil && !offline {
+		return nil, errors.New("blockchain RPC is inactive")
+	}
+	return chainClient, nil
+}
+
+// blockStamp returns the current block of the chain client, or the block the
+// wallet was last synced to when chainClient is nil.
+func (w *Wallet) blockStamp(chainClient chain.Interface) (*waddrmgr.BlockStamp, error) {
+	if chainClient == nil {
+		bs := w.Manager.SyncedTo()
+		return &bs, nil
+	}
+	return chainClient.BlockStamp()
+}
+
+// queueBroadcast stores a transaction published while the wallet is offline
+// so it is broadcast once the wallet is synced.
+func queueBroadcast(ns walletdb.ReadWriteBucket, tx *wire.MsgTx) error {
+	bucket, err := ns.CreateBucketIfNotExists(broadcastQueueBucketKey)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return err
+	}
+	txHash := tx.TxHash()
+	return bucket.Put(txHash[:], buf.Bytes())
+}
+
+// QueuedTransactions returns the transactions published while the wallet was
+// offline that are not yet broadcast.
+func (w *Wallet) QueuedTransactions() ([]*wire.MsgTx, error) {
+	var txs []*wire.MsgTx
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(broadcastQueueBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			tx := new(wire.MsgTx)
+			if err := tx.Deserialize(bytes.NewReader(v)); err != nil {
+				return err
+			}
+			txs = append(txs, tx)
+			return nil
+		})
+	})
+	return txs, err
+}
+
+// broadcastQueued broadcasts the transactions published while the wallet was
+// offline.  Transactions that were mined, removed from the wallet, or
+// rejected by the consensus server are dropped from the queue, and the others
+// are retried by the next sync.
+func (w *Wallet) broadcastQueued(chainClient chain.Interface) error {
+	txs, err := w.QueuedTransactions()
+	if err != nil || len(txs) == 0 {
+		return err
+	}
+
+	for _, tx := range txs {
+		txHash := tx.TxHash()
+
+		var details *wtxmgr.TxDetails
+		err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+			var err error
+			details, err = w.TxStore.TxDetails(
+				dbtx.ReadBucket(wtxmgrNamespaceKey), &txHash,
+			)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case details == nil:
+			log.Infof("Dropping queued transaction %v, which was "+
+				"removed from the wallet", txHash)
+		case details.Block.Height != -1:
+			log.Infof("Dropping queued transaction %v, which was "+
+				"mined in block %v", txHash, details.Block.Hash)
+		default:
+			_, err := w.sendTransaction(chainClient, &details.TxRecord)
+			if err == nil {
+				log.Infof("Broadcast queued transaction %v", txHash)
+				break
+			}
+			if !isRejection(err) {
+				log.Warnf("Unable to broadcast queued transaction "+
+					"%v: %v", txHash, err)
+				continue
+			}
+			log.Warnf("Queued transaction %v was rejected: %v",
+				txHash, err)
+		}
+
+		err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+			return dequeueBroadcast(
+				dbtx.ReadWriteBucket(wtxmetaNamespaceKey), &txHash,
+			)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dequeueBroadcast removes a transaction from the broadcast queue.
+func dequeueBroadcast(ns walletdb.ReadWriteBucket, txHash *chainhash.Hash) error {
+	bucket := ns.NestedReadWriteBucket(broadcastQueueBucketKey)
+	if bucket == nil {
+		return nil
+	}
+	return bucket.Delete(txHash[:])
+}
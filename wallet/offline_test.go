@@ -0,0 +1,81 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestBroadcastQueue checks that transactions queued while offline are
+// persisted until they are removed from the queue.
+func TestBroadcastQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "broadcastqueue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	w := &Wallet{db: db}
+
+	txs := make([]*wire.MsgTx, 2)
+	for i := range txs {
+		txs[i] = wire.NewMsgTx(wire.TxVersion)
+		prevOut := wire.NewOutPoint(&chainhash.Hash{byte(i)}, 0)
+		txs[i].AddTxIn(wire.NewTxIn(prevOut, nil, nil))
+		txs[i].AddTxOut(wire.NewTxOut(int64(1000*(i+1)), []byte{0x51}))
+	}
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		ns, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		if err != nil {
+			return err
+		}
+		for _, tx := range txs {
+			if err := queueBroadcast(ns, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queued, err := w.QueuedTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 2 {
+		t.Fatalf("got %d queued transactions, want 2", len(queued))
+	}
+
+	removed := txs[0].TxHash()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		return dequeueBroadcast(dbtx.ReadWriteBucket(wtxmetaNamespaceKey),
+			&removed)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued, err = w.QueuedTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 1 || queued[0].TxHash() != txs[1].TxHash() {
+		t.Errorf("unexpected queue after removing %v: %v", removed, queued)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// opSequenceKey is the key of the wallet operation sequence in the
+// transaction metadata namespace.
+var opSequenceKey = []byte("opsequence")
+
+// OperationSequenceError describes an operation that was not run because the
+// wallet operation sequence differed from the sequence it was conditional on.
+type OperationSequenceError struct {
+	Expected uint64
+	Current  uint64
+}
+
+// Error implements the error interface.
+func (e *OperationSequenceError) Error() string {
+	return fmt.Sprintf("wallet operation sequence is %d, not %d",
+		e.Current, e.Expected)
+}
+
+// operationSequence serializes the operations counted by the wallet
+// operation sequence.
+type operationSequence struct {
+	mu  sync.Mutex
+	seq uint64
+}
+
+// fetchOperationSequence returns the wallet operation sequence, which is 0
+// until the first operation.
+func fetchOperationSequence(ns walletdb.ReadBucket) uint64 {
+	v := ns.Get(opSequenceKey)
+	if len(v) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(v)
+}
+
+// putOperationSequence stores the wallet operation sequence.
+func putOperationSequence(ns walletdb.ReadWriteBucket, seq uint64) error {
+	var v [8]byte
+	binary.BigEndian.PutUint64(v[:], seq)
+	return ns.Put(opSequenceKey, v[:])
+}
+
+// OperationSequence returns the wallet operation sequence, the number of
+// operations run with Operation since the wallet was created.
+func (w *Wallet) OperationSequence() uint64 {
+	w.opSeq.mu.Lock()
+	defer w.opSeq.mu.Unlock()
+	return w.opSeq.seq
+}
+
+// Operation runs an operation changing the wallet, such as a mutating request
+// of an RPC client, and increments the wallet operation sequence when it
+// succeeds.  Operations are run one at a time, so when expected is not nil,
+// the operation is only run when the sequence is still *expected, which lets
+// several controllers of the wallet detect the changes made by the others
+// before acting.  The sequence after the operation is returned, even when it
+// fails.
+//
+// Changes that are not made by operations, such as the transactions received
+// by the wallet, do not increment the sequence.
+func (w *Wallet) Operation(expected *uint64, f func() error) (uint64, error) {
+	w.opSeq.mu.Lock()
+	defer w.opSeq.mu.Unlock()
+
+	if expected != nil && *expected != w.opSeq.seq {
+		return w.opSeq.seq, &OperationSequenceError{
+			Expected: *expected,
+			Current:  w.opSeq.seq,
+		}
+	}
+	if err := f(); err != nil {
+		return w.opSeq.seq, err
+	}
+
+	w.opSeq.seq++
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmetaNamespaceKey)
+		return putOperationSequence(ns, w.opSeq.seq)
+	})
+	if err != nil {
+		log.Errorf("Cannot store wallet operation sequence %d: %v",
+			w.opSeq.seq, err)
+	}
+	return w.opSeq.seq, nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestOperationSequence checks that the wallet operation sequence is only
+// incremented by successful operations, that conditional operations only run
+// at their sequence, and that the sequence is persisted.
+func TestOperationSequence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "opsequence")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+
+	runs := 0
+	op := func() error {
+		runs++
+		return nil
+	}
+
+	seq, err := w.Operation(nil, op)
+	if err != nil || seq != 1 {
+		t.Fatalf("Operation returned %d, %v, want 1", seq, err)
+	}
+
+	// A failed operation does not increment the sequence.
+	errOp := errors.New("operation failed")
+	seq, err = w.Operation(nil, func() error { return errOp })
+	if err != errOp || seq != 1 {
+		t.Fatalf("failed Operation returned %d, %v, want 1, %v", seq,
+			err, errOp)
+	}
+
+	// A conditional operation is not run at another sequence.
+	expected := uint64(0)
+	seq, err = w.Operation(&expected, op)
+	if _, ok := err.(*OperationSequenceError); !ok || seq != 1 {
+		t.Fatalf("stale Operation returned %d, %v", seq, err)
+	}
+	if runs != 1 {
+		t.Fatalf("stale operation was run")
+	}
+	expected = 1
+	seq, err = w.Operation(&expected, op)
+	if err != nil || seq != 2 || runs != 2 {
+		t.Fatalf("Operation returned %d, %v, want 2", seq, err)
+	}
+
+	err = walletdb.View(db, func(dbtx walletdb.ReadTx) error {
+		ns := dbtx.ReadBucket(wtxmetaNamespaceKey)
+		if seq := fetchOperationSequence(ns); seq != 2 {
+			t.Errorf("stored sequence is %d, want 2", seq)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// lockedOutpointsBucketKey is the key of the bucket in the transaction
+// metadata namespace holding the locked outpoints, so that they stay locked
+// when the wallet is restarted.
+var lockedOutpointsBucketKey = []byte("lockedoutpoints")
+
+// outpointKey serializes an outpoint as the key of a locked outpoint.
+func outpointKey(op *wire.OutPoint) []byte {
+	k := make([]byte, 36)
+	copy(k, op.Hash[:])
+	binary.BigEndian.PutUint32(k[32:], op.Index)
+	return k
+}
+
+// putLockedOutpoint records an outpoint as locked.
+func putLockedOutpoint(ns walletdb.ReadWriteBucket, op *wire.OutPoint) error {
+	bucket, err := ns.CreateBucketIfNotExists(lockedOutpointsBucketKey)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(outpointKey(op), nil)
+}
+
+// deleteLockedOutpoints removes the records of locked outpoints, or of every
+// locked outpoint when ops is nil.
+func deleteLockedOutpoints(ns walletdb.ReadWriteBucket, ops []wire.OutPoint) error {
+	if ops == nil {
+		err := ns.DeleteNestedBucket(lockedOutpointsBucketKey)
+		if err == walletdb.ErrBucketNotFound {
+			return nil
+		}
+		return err
+	}
+	bucket := ns.NestedReadWriteBucket(lockedOutpointsBucketKey)
+	if bucket == nil {
+		return nil
+	}
+	for i := range ops {
+		if err := bucket.Delete(outpointKey(&ops[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchLockedOutpoints returns the recorded locked outpoints.
+func fetchLockedOutpoints(ns walletdb.ReadBucket) (map[wire.OutPoint]struct{}, error) {
+	locked := make(map[wire.OutPoint]struct{})
+	bucket := ns.NestedReadBucket(lockedOutpointsBucketKey)
+	if bucket == nil {
+		return locked, nil
+	}
+	err := bucket.ForEach(func(k, v []byte) error {
+		if len(k) != 36 {
+			return fmt.Errorf("locked outpoint key has length %d",
+				len(k))
+		}
+		var op wire.OutPoint
+		copy(op.Hash[:], k)
+		op.Index = binary.BigEndian.Uint32(k[32:])
+		locked[op] = struct{}{}
+		return nil
+	})
+	return locked, err
+}
+
+// storeOutpointLocks records changes to the locked outpoints.  A failure is
+// logged, since the outpoints are still locked or unlocked until the wallet
+// is restarted.
+func (w *Wallet) storeOutpointLocks(f func(ns walletdb.ReadWriteBucket) error) {
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		return f(tx.ReadWriteBucket(wtxmetaNamespaceKey))
+	})
+	if err != nil {
+		log.Errorf("Cannot store locked outpoints: %v", err)
+	}
+}
+
+// lockedBalance returns the value of the unspent locked outputs with at least
+// confirms confirmations, excluding immature coinbase outputs, which
+// CalculateBalance subtracts from the spendable balance.
+func (w *Wallet) lockedBalance(tx walletdb.ReadTx, confirms, syncHeight int32) (btcutil.Amount, error) {
+	txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+	var balance btcutil.Amount
+	for _, op := range w.lockedOutpointList() {
+		details, err := w.TxStore.TxDetails(txmgrNs, &op.Hash)
+		if err != nil {
+			return 0, err
+		}
+		if details == nil ||
+			!confirmed(confirms, details.Block.Height, syncHeight) {
+			continue
+		}
+		if blockchain.IsCoinBaseTx(&details.MsgTx) &&
+			!confirmed(int32(w.chainParams.CoinbaseMaturity),
+				details.Block.Height, syncHeight) {
+			continue
+		}
+		for _, credit := range details.Credits {
+			if credit.Index == op.Index && !credit.Spent {
+				balance += credit.Amount
+			}
+		}
+	}
+	return balance, nil
+}
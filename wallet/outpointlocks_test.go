@@ -0,0 +1,80 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestLockedOutpointsPersisted checks that locked outpoints are stored until
+// they are unlocked.
+func TestLockedOutpointsPersisted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lockedoutpoints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := func() map[wire.OutPoint]struct{} {
+		var locked map[wire.OutPoint]struct{}
+		err := walletdb.View(db, func(dbtx walletdb.ReadTx) error {
+			var err error
+			locked, err = fetchLockedOutpoints(
+				dbtx.ReadBucket(wtxmetaNamespaceKey))
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return locked
+	}
+
+	w := &Wallet{db: db, lockedOutpoints: stored()}
+	ops := []wire.OutPoint{{Index: 1}, {Index: 1 << 20}}
+	ops[1].Hash[0] = 0xff
+	for _, op := range ops {
+		w.LockOutpoint(op)
+	}
+	want := map[wire.OutPoint]struct{}{ops[0]: {}, ops[1]: {}}
+	if locked := stored(); !reflect.DeepEqual(locked, want) {
+		t.Errorf("stored locked outpoints %v, want %v", locked, want)
+	}
+
+	w.UnlockOutpoint(ops[0])
+	delete(want, ops[0])
+	if locked := stored(); !reflect.DeepEqual(locked, want) {
+		t.Errorf("stored locked outpoints %v after unlocking, want %v",
+			locked, want)
+	}
+
+	w.ResetLockedOutpoints()
+	if locked := stored(); len(locked) != 0 {
+		t.Errorf("stored locked outpoints %v after reset", locked)
+	}
+	if w.LockedOutpoint(ops[1]) {
+		t.Error("outpoint is locked after reset")
+	}
+}
@@ -0,0 +1,429 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+const (
+	// paymentBatchCheckInterval is the interval between two checks of
+	// whether the queued payments are due to be sent.
+	paymentBatchCheckInterval = time.Minute
+
+	// paymentBatchMinConf is the number of confirmations of the outputs
+	// spent by batch transactions.
+	paymentBatchMinConf = 1
+
+	// paymentBatchMaxOutputs is the maximum number of payments sent by a
+	// single batch transaction.  Larger batches are split between several
+	// transactions.
+	paymentBatchMaxOutputs = 1000
+)
+
+// paymentQueueBucketKey is the key of the bucket in the transaction metadata
+// namespace holding the queued payments, keyed by their big endian ID.
+var paymentQueueBucketKey = []byte("paymentqueue")
+
+// ErrPaymentNotQueued describes an error where a payment which is not queued,
+// or was already sent, is cancelled.
+var ErrPaymentNotQueued = errors.New("payment is not queued")
+
+// QueuedPayment is a payment queued to be sent in the next batch transaction
+// of its account.  TxHash is the hash of the batch transaction which sent the
+// payment, or nil while the payment is queued.  Cancelled payments are kept so
+// that their IDs are never reused.
+type QueuedPayment struct {
+	ID        uint64
+	Account   uint32
+	PkScript  []byte
+	Amount    btcutil.Amount
+	Token     wire.TokenIdentity
+	Time      time.Time
+	TxHash    *chainhash.Hash
+	Cancelled bool
+}
+
+// PaymentBatch is a transaction sending the queued payments of an account.
+type PaymentBatch struct {
+	Account  uint32
+	Token    wire.TokenIdentity
+	TxHash   chainhash.Hash
+	Payments []uint64
+}
+
+// paymentBatching holds the interval between two batch transactions.  The
+// mutex also serializes the batch transactions with the cancellation of
+// queued payments, so a payment is never cancelled while it is being sent.
+type paymentBatching struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+}
+
+// Queued payments are serialized as such:
+//
+//   [0:8]   Unix time the payment was queued (8 bytes)
+//   [8:12]  Account number (4 bytes)
+//   [12:20] Amount (8 bytes)
+//   [20:52] Batch transaction hash, or zero while queued
+//   [52]    Cancelled flag (1 byte)
+//   [53:]   Token name (varstring) and output script (varbytes)
+
+func serializeQueuedPayment(p *QueuedPayment) []byte {
+	var buf bytes.Buffer
+	var v [53]byte
+	binary.BigEndian.PutUint64(v[0:8], uint64(p.Time.Unix()))
+	binary.BigEndian.PutUint32(v[8:12], p.Account)
+	binary.BigEndian.PutUint64(v[12:20], uint64(p.Amount))
+	if p.TxHash != nil {
+		copy(v[20:52], p.TxHash[:])
+	}
+	if p.Cancelled {
+		v[52] = 1
+	}
+	buf.Write(v[:])
+	wire.WriteVarString(&buf, 0, p.Token.String())
+	wire.WriteVarBytes(&buf, 0, p.PkScript)
+	return buf.Bytes()
+}
+
+func deserializeQueuedPayment(k, v []byte) (*QueuedPayment, error) {
+	if len(k) != 8 || len(v) < 53 {
+		return nil, errors.New("malformed queued payment")
+	}
+	p := &QueuedPayment{
+		ID:        binary.BigEndian.Uint64(k),
+		Time:      time.Unix(int64(binary.BigEndian.Uint64(v[0:8])), 0),
+		Account:   binary.BigEndian.Uint32(v[8:12]),
+		Amount:    btcutil.Amount(binary.BigEndian.Uint64(v[12:20])),
+		Cancelled: v[52] == 1,
+	}
+	var txHash chainhash.Hash
+	copy(txHash[:], v[20:52])
+	if txHash != (chainhash.Hash{}) {
+		p.TxHash = &txHash
+	}
+	r := bytes.NewReader(v[53:])
+	token, err := wire.ReadVarString(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case wire.STB.String():
+		p.Token = wire.STB
+	case wire.NDR.String():
+		p.Token = wire.NDR
+	default:
+		return nil, fmt.Errorf("queued payment %d has unknown token %q",
+			p.ID, token)
+	}
+	p.PkScript, err = wire.ReadVarBytes(r, 0, txscript.MaxScriptSize,
+		"pkScript")
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// QueuePayment queues a payment from an account, to be sent with the other
+// queued payments of the account in a single batch transaction, either every
+// payment batch interval or when SendPaymentBatches is called.  It returns
+// the ID of the queued payment.
+func (w *Wallet) QueuePayment(account uint32, output *wire.TxOut) (uint64, error) {
+	if err := txrules.CheckOutput(output, txrules.DefaultRelayFeePerKb); err != nil {
+		return 0, err
+	}
+	if len(output.PkScript) > txscript.MaxScriptSize {
+		return 0, errors.New("payment output script is too large")
+	}
+
+	p := &QueuedPayment{
+		Account:  account,
+		PkScript: output.PkScript,
+		Amount:   btcutil.Amount(output.Value),
+		Token:    output.TokenID(),
+		Time:     time.Now(),
+	}
+	err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket, err := ns.CreateBucketIfNotExists(paymentQueueBucketKey)
+		if err != nil {
+			return err
+		}
+		p.ID = 1
+		if k, _ := bucket.ReadCursor().Last(); len(k) == 8 {
+			p.ID = binary.BigEndian.Uint64(k) + 1
+		}
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], p.ID)
+		return bucket.Put(k[:], serializeQueuedPayment(p))
+	})
+	if err != nil {
+		return 0, err
+	}
+	log.Infof("Queued payment %d of %v from account %d", p.ID, p.Amount,
+		account)
+	return p.ID, nil
+}
+
+// QueuedPayment returns a queued, sent or cancelled payment.
+// ErrPaymentNotQueued is returned when no payment has the ID.
+func (w *Wallet) QueuedPayment(id uint64) (*QueuedPayment, error) {
+	var p *QueuedPayment
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(paymentQueueBucketKey)
+		if bucket == nil {
+			return ErrPaymentNotQueued
+		}
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], id)
+		v := bucket.Get(k[:])
+		if v == nil {
+			return ErrPaymentNotQueued
+		}
+		var err error
+		p, err = deserializeQueuedPayment(k[:], v)
+		return err
+	})
+	return p, err
+}
+
+// QueuedPayments returns the payments which are queued and not yet sent,
+// ordered by ID.
+func (w *Wallet) QueuedPayments() ([]QueuedPayment, error) {
+	var payments []QueuedPayment
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(paymentQueueBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			p, err := deserializeQueuedPayment(k, v)
+			if err != nil {
+				return err
+			}
+			if p.TxHash == nil && !p.Cancelled {
+				payments = append(payments, *p)
+			}
+			return nil
+		})
+	})
+	return payments, err
+}
+
+// CancelQueuedPayment removes a payment from the queue.  ErrPaymentNotQueued
+// is returned when the payment is not queued, including when it was already
+// sent or cancelled.
+func (w *Wallet) CancelQueuedPayment(id uint64) error {
+	w.paymentBatching.mu.Lock()
+	defer w.paymentBatching.mu.Unlock()
+
+	err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		bucket := dbtx.ReadWriteBucket(wtxmetaNamespaceKey).
+			NestedReadWriteBucket(paymentQueueBucketKey)
+		if bucket == nil {
+			return ErrPaymentNotQueued
+		}
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], id)
+		v := bucket.Get(k[:])
+		if v == nil {
+			return ErrPaymentNotQueued
+		}
+		p, err := deserializeQueuedPayment(k[:], v)
+		if err != nil {
+			return err
+		}
+		if p.TxHash != nil || p.Cancelled {
+			return ErrPaymentNotQueued
+		}
+		p.Cancelled = true
+		return bucket.Put(k[:], serializeQueuedPayment(p))
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Cancelled queued payment %d", id)
+	return nil
+}
+
+// paymentBatchGroups splits queued payments into the groups sent by a single
+// batch transaction: the payments of the same account and token, ordered by
+// ID, and at most paymentBatchMaxOutputs of them.
+func paymentBatchGroups(payments []QueuedPayment) [][]QueuedPayment {
+	type groupKey struct {
+		account uint32
+		token   string
+	}
+	var keys []groupKey
+	byKey := make(map[groupKey][]QueuedPayment)
+	for _, p := range payments {
+		k := groupKey{p.Account, p.Token.String()}
+		if _, ok := byKey[k]; !ok {
+			keys = append(keys, k)
+		}
+		byKey[k] = append(byKey[k], p)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].account != keys[j].account {
+			return keys[i].account < keys[j].account
+		}
+		return keys[i].token < keys[j].token
+	})
+
+	var groups [][]QueuedPayment
+	for _, k := range keys {
+		group := byKey[k]
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].ID < group[j].ID
+		})
+		for len(group) > paymentBatchMaxOutputs {
+			groups = append(groups, group[:paymentBatchMaxOutputs])
+			group = group[paymentBatchMaxOutputs:]
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// SendPaymentBatches sends the queued payments, in one batch transaction per
+// account and token, paying the wallet's fee rate.  Payments which cannot be
+// sent, such as those of an account without enough confirmed funds, stay
+// queued.  The sent batches are returned with the first error, if any.
+func (w *Wallet) SendPaymentBatches() ([]PaymentBatch, error) {
+	w.paymentBatching.mu.Lock()
+	defer w.paymentBatching.mu.Unlock()
+
+	w.paymentBatching.last = time.Now()
+	payments, err := w.QueuedPayments()
+	if err != nil {
+		return nil, err
+	}
+
+	var batches []PaymentBatch
+	var firstErr error
+	for _, group := range paymentBatchGroups(payments) {
+		batch, err := w.sendPaymentBatch(group)
+		if err != nil {
+			log.Warnf("Cannot send the %d queued payments of account "+
+				"%d: %v", len(group), group[0].Account, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		batches = append(batches, *batch)
+	}
+	return batches, firstErr
+}
+
+// sendPaymentBatch sends a group of queued payments in a single transaction
+// and records its hash in the payments.
+func (w *Wallet) sendPaymentBatch(group []QueuedPayment) (*PaymentBatch, error) {
+	account := group[0].Account
+	outputs := make([]*wire.TxOut, len(group))
+	batch := &PaymentBatch{
+		Account:  account,
+		Token:    group[0].Token,
+		Payments: make([]uint64, len(group)),
+	}
+	for i, p := range group {
+		outputs[i] = wire.NewTxOutToken(int64(p.Amount), p.PkScript,
+			p.Token)
+		batch.Payments[i] = p.ID
+	}
+
+	txHash, err := w.SendOutputs(outputs, account, paymentBatchMinConf,
+		w.FeeRate(0), nil)
+	if err != nil {
+		return nil, err
+	}
+	batch.TxHash = *txHash
+	log.Infof("Sent %d queued payments of account %d in transaction %v",
+		len(group), account, txHash)
+
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		bucket := dbtx.ReadWriteBucket(wtxmetaNamespaceKey).
+			NestedReadWriteBucket(paymentQueueBucketKey)
+		for i := range group {
+			p := group[i]
+			p.TxHash = txHash
+			var k [8]byte
+			binary.BigEndian.PutUint64(k[:], p.ID)
+			err := bucket.Put(k[:], serializeQueuedPayment(&p))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// The payments were sent, so they must not be sent again by
+		// the next batch.
+		log.Errorf("Cannot record queued payments as sent by "+
+			"transaction %v: %v", txHash, err)
+		return nil, err
+	}
+	return batch, nil
+}
+
+// SetPaymentBatchInterval configures the wallet to send the queued payments
+// every interval.  A zero interval only sends them when SendPaymentBatches is
+// called.
+func (w *Wallet) SetPaymentBatchInterval(interval time.Duration) {
+	w.paymentBatching.mu.Lock()
+	w.paymentBatching.interval = interval
+	w.paymentBatching.last = time.Now()
+	w.paymentBatching.mu.Unlock()
+}
+
+// paymentBatchMonitor periodically sends the queued payments when the payment
+// batch interval elapsed.  It must be run as a goroutine.
+func (w *Wallet) paymentBatchMonitor() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(paymentBatchCheckInterval)
+	defer ticker.Stop()
+	quit := w.quitChan()
+	for {
+		select {
+		case <-ticker.C:
+			w.paymentBatching.mu.Lock()
+			interval := w.paymentBatching.interval
+			due := interval != 0 &&
+				time.Since(w.paymentBatching.last) >= interval
+			w.paymentBatching.mu.Unlock()
+			if !due {
+				continue
+			}
+			if w.Manager.IsLocked() {
+				log.Debugf("Not sending queued payments: wallet " +
+					"is locked")
+				continue
+			}
+			if _, err := w.SendPaymentBatches(); err != nil {
+				log.Errorf("Cannot send queued payments: %v", err)
+			}
+		case <-quit:
+			return
+		}
+	}
+}
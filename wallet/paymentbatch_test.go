@@ -0,0 +1,148 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestPaymentQueue checks that queued payments are stored, listed until they
+// are sent or cancelled, and that cancelled payment IDs are not reused.
+func TestPaymentQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paymentqueue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+
+	script := []byte{0x00, 0x14, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13,
+		14, 15, 16, 17, 18, 19, 20}
+	id1, err := w.QueuePayment(1, wire.NewTxOutToken(1e6, script, wire.STB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id2, err := w.QueuePayment(2, wire.NewTxOutToken(2e6, script, wire.NDR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id1 != 1 || id2 != 2 {
+		t.Fatalf("queued payments %d and %d, want 1 and 2", id1, id2)
+	}
+	if _, err := w.QueuePayment(1, wire.NewTxOutToken(-1, script, wire.STB)); err == nil {
+		t.Fatal("queued a negative payment")
+	}
+
+	p, err := w.QueuedPayment(id2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Account != 2 || p.Amount != 2e6 || p.Token != wire.NDR ||
+		!bytes.Equal(p.PkScript, script) || p.TxHash != nil {
+
+		t.Fatalf("unexpected queued payment %+v", p)
+	}
+
+	// A cancelled payment is no longer listed and cannot be cancelled
+	// again, and its ID is not reused.
+	if err := w.CancelQueuedPayment(id2); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.CancelQueuedPayment(id2); err != ErrPaymentNotQueued {
+		t.Fatalf("cancelled a cancelled payment: %v", err)
+	}
+	if err := w.CancelQueuedPayment(99); err != ErrPaymentNotQueued {
+		t.Fatalf("cancelled an unknown payment: %v", err)
+	}
+	id3, err := w.QueuePayment(1, wire.NewTxOutToken(3e6, script, wire.STB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id3 != 3 {
+		t.Fatalf("queued payment %d, want 3", id3)
+	}
+	payments, err := w.QueuedPayments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) != 2 || payments[0].ID != id1 || payments[1].ID != id3 {
+		t.Fatalf("unexpected queued payments %+v", payments)
+	}
+
+	// Sent payments keep the hash of their batch transaction.
+	p = &payments[0]
+	p.TxHash = &chainhash.Hash{1}
+	k := []byte{0, 0, 0, 0, 0, 0, 0, 1}
+	sent, err := deserializeQueuedPayment(k, serializeQueuedPayment(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.TxHash == nil || *sent.TxHash != *p.TxHash || sent.ID != 1 ||
+		sent.Time.Unix() != p.Time.Unix() {
+
+		t.Fatalf("unexpected sent payment %+v", sent)
+	}
+}
+
+// TestPaymentBatchGroups checks that queued payments are batched by account
+// and token, in ID order, and that large batches are split.
+func TestPaymentBatchGroups(t *testing.T) {
+	var payments []QueuedPayment
+	for id := uint64(1); id <= paymentBatchMaxOutputs+2; id++ {
+		payments = append(payments, QueuedPayment{ID: id, Account: 1,
+			Token: wire.STB})
+	}
+	payments = append(payments,
+		QueuedPayment{ID: 2000, Account: 0, Token: wire.STB},
+		QueuedPayment{ID: 1999, Account: 0, Token: wire.STB},
+		QueuedPayment{ID: 2001, Account: 0, Token: wire.NDR},
+	)
+
+	groups := paymentBatchGroups(payments)
+	if len(groups) != 4 {
+		t.Fatalf("got %d batches, want 4", len(groups))
+	}
+	var stb0, ndr0 []QueuedPayment
+	for _, g := range groups[:2] {
+		if g[0].Token == wire.STB {
+			stb0 = g
+		} else {
+			ndr0 = g
+		}
+	}
+	if len(stb0) != 2 || stb0[0].ID != 1999 || stb0[1].ID != 2000 {
+		t.Errorf("unexpected batch %+v", stb0)
+	}
+	if len(ndr0) != 1 || ndr0[0].ID != 2001 || ndr0[0].Account != 0 {
+		t.Errorf("unexpected batch %+v", ndr0)
+	}
+	if len(groups[2]) != paymentBatchMaxOutputs || groups[2][0].ID != 1 ||
+		len(groups[3]) != 2 || groups[3][1].ID != paymentBatchMaxOutputs+2 {
+
+		t.Errorf("large batch was not split in ID order")
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// paymentRefsBucketKey is the key of the bucket in the transaction metadata
+// namespace recording the payment references, keyed by reference, and
+// paymentRefAddrsBucketKey the key of the bucket recording the reference of
+// each address, keyed by encoded address.
+var (
+	paymentRefsBucketKey     = []byte("paymentrefs")
+	paymentRefAddrsBucketKey = []byte("paymentrefaddrs")
+)
+
+// Payment references are serialized as such:
+//
+//   [0:8]   Time created, as unix seconds (8 bytes)
+//   [8:]    Encoded address
+
+// paymentRefBodyLen is the number of random characters of payment references,
+// which leaves about 2^51 references so that collisions are unlikely, and are
+// retried otherwise.
+const paymentRefBodyLen = 10
+
+// paymentRefAlphabet is the alphabet of the random characters of payment
+// references, which omits the characters easily mistaken for others.
+const paymentRefAlphabet = "0123456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+var (
+	// ErrUnknownPaymentRef describes an error where a payment reference
+	// is not recorded by the wallet.
+	ErrUnknownPaymentRef = errors.New("unknown payment reference")
+
+	// ErrInvalidPaymentRef describes an error where a payment reference
+	// is malformed or its check digits do not match, as when it is
+	// mistyped.
+	ErrInvalidPaymentRef = errors.New("invalid payment reference")
+)
+
+// PaymentRef is a payment reference tied to a wallet address.  References
+// are ISO 11649 creditor references, such as RF18 539007547034, made of the
+// RF prefix, two check digits and alphanumeric characters, so that payers may
+// quote them as bank payment references and they may be embedded in payment
+// URI labels and webhook payloads without escaping.
+type PaymentRef struct {
+	Reference string
+	Address   btcutil.Address
+	Account   uint32
+	Created   time.Time
+}
+
+// PaymentReference returns the payment reference tied to an external wallet
+// address, creating one unique to the wallet when the address has none.
+func (w *Wallet) PaymentReference(addr btcutil.Address) (*PaymentRef, error) {
+	var ref *PaymentRef
+	err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		ma, err := w.Manager.Address(addrmgrNs, addr)
+		if err != nil {
+			return err
+		}
+		if ma.Internal() {
+			return fmt.Errorf("address %v is a change address",
+				addr.EncodeAddress())
+		}
+		_, account, err := w.Manager.AddrAccount(addrmgrNs, addr)
+		if err != nil {
+			return err
+		}
+
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		refs, err := ns.CreateBucketIfNotExists(paymentRefsBucketKey)
+		if err != nil {
+			return err
+		}
+		addrs, err := ns.CreateBucketIfNotExists(paymentRefAddrsBucketKey)
+		if err != nil {
+			return err
+		}
+		addrStr := addr.EncodeAddress()
+		if r := addrs.Get([]byte(addrStr)); r != nil {
+			ref, err = deserializePaymentRef(string(r),
+				refs.Get(r), w.chainParams)
+			if err != nil {
+				return err
+			}
+			ref.Account = account
+			return nil
+		}
+
+		var reference string
+		for {
+			reference, err = newPaymentRef()
+			if err != nil {
+				return err
+			}
+			if refs.Get([]byte(reference)) == nil {
+				break
+			}
+		}
+		ref = &PaymentRef{
+			Reference: reference,
+			Address:   addr,
+			Account:   account,
+			Created:   time.Unix(time.Now().Unix(), 0),
+		}
+		err = refs.Put([]byte(reference), serializePaymentRef(ref))
+		if err != nil {
+			return err
+		}
+		return addrs.Put([]byte(addrStr), []byte(reference))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// LookupPaymentReference returns the address a payment reference is tied to.
+// References are matched regardless of case and spaces, as they may be
+// copied from bank statements.  ErrInvalidPaymentRef is returned when the
+// check digits of the reference do not match, and ErrUnknownPaymentRef when
+// the reference is not recorded by the wallet.
+func (w *Wallet) LookupPaymentReference(reference string) (*PaymentRef, error) {
+	reference = normalizePaymentRef(reference)
+	if !validPaymentRef(reference) {
+		return nil, ErrInvalidPaymentRef
+	}
+
+	var ref *PaymentRef
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(paymentRefsBucketKey)
+		if bucket == nil {
+			return ErrUnknownPaymentRef
+		}
+		v := bucket.Get([]byte(reference))
+		if v == nil {
+			return ErrUnknownPaymentRef
+		}
+		var err error
+		ref, err = deserializePaymentRef(reference, v, w.chainParams)
+		if err != nil {
+			return err
+		}
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		_, ref.Account, err = w.Manager.AddrAccount(addrmgrNs, ref.Address)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// PaymentReferences returns the payment references of the wallet, ordered by
+// reference.
+func (w *Wallet) PaymentReferences() ([]PaymentRef, error) {
+	var refs []PaymentRef
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(paymentRefsBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		return bucket.ForEach(func(k, v []byte) error {
+			ref, err := deserializePaymentRef(string(k), v,
+				w.chainParams)
+			if err != nil {
+				return err
+			}
+			_, ref.Account, err = w.Manager.AddrAccount(addrmgrNs,
+				ref.Address)
+			if err != nil {
+				return err
+			}
+			refs = append(refs, *ref)
+			return nil
+		})
+	})
+	return refs, err
+}
+
+// AddressPaymentReference returns the payment reference tied to an address,
+// or an empty string when it has none.
+func (w *Wallet) AddressPaymentReference(addr btcutil.Address) (string, error) {
+	var reference string
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(paymentRefAddrsBucketKey)
+		if bucket != nil {
+			reference = string(bucket.Get([]byte(addr.EncodeAddress())))
+		}
+		return nil
+	})
+	return reference, err
+}
+
+// newPaymentRef returns a random ISO 11649 creditor reference.
+func newPaymentRef() (string, error) {
+	var body [paymentRefBodyLen]byte
+	var b [1]byte
+	for i := range body {
+		// Bytes beyond the largest multiple of the alphabet size are
+		// rejected so that characters are uniformly distributed.
+		for {
+			if _, err := rand.Read(b[:]); err != nil {
+				return "", err
+			}
+			if int(b[0]) < 256-256%len(paymentRefAlphabet) {
+				break
+			}
+		}
+		body[i] = paymentRefAlphabet[int(b[0])%len(paymentRefAlphabet)]
+	}
+	check := 98 - paymentRefMod97(string(body[:])+"RF00")
+	return fmt.Sprintf("RF%02d%s", check, body[:]), nil
+}
+
+// normalizePaymentRef returns a payment reference in upper case without
+// spaces.
+func normalizePaymentRef(reference string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(reference), ""))
+}
+
+// validPaymentRef returns whether a normalized payment reference is an ISO
+// 11649 creditor reference with matching check digits.
+func validPaymentRef(reference string) bool {
+	if len(reference) < 5 || len(reference) > 25 ||
+		!strings.HasPrefix(reference, "RF") {
+		return false
+	}
+	for _, c := range reference {
+		if !('0' <= c && c <= '9') && !('A' <= c && c <= 'Z') {
+			return false
+		}
+	}
+	return paymentRefMod97(reference[4:]+reference[:4]) == 1
+}
+
+// paymentRefMod97 returns the ISO 7064 MOD 97-10 remainder of an alphanumeric
+// string, whose letters are replaced by the numbers 10 to 35.
+func paymentRefMod97(s string) int {
+	var digits strings.Builder
+	for _, c := range s {
+		if 'A' <= c && c <= 'Z' {
+			fmt.Fprintf(&digits, "%d", c-'A'+10)
+		} else {
+			digits.WriteRune(c)
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return -1
+	}
+	return int(new(big.Int).Mod(n, big.NewInt(97)).Int64())
+}
+
+// serializePaymentRef returns the value recording a payment reference.
+func serializePaymentRef(ref *PaymentRef) []byte {
+	addr := ref.Address.EncodeAddress()
+	v := make([]byte, 8+len(addr))
+	binary.BigEndian.PutUint64(v, uint64(ref.Created.Unix()))
+	copy(v[8:], addr)
+	return v
+}
+
+// deserializePaymentRef returns the payment reference recorded with a value.
+func deserializePaymentRef(reference string, v []byte,
+	params *chaincfg.Params) (*PaymentRef, error) {
+
+	if len(v) < 8 {
+		return nil, fmt.Errorf("payment reference %s is malformed",
+			reference)
+	}
+	addr, err := taproot.DecodeAddress(string(v[8:]), params)
+	if err != nil {
+		return nil, fmt.Errorf("payment reference %s is malformed: %v",
+			reference, err)
+	}
+	return &PaymentRef{
+		Reference: reference,
+		Address:   addr,
+		Created:   time.Unix(int64(binary.BigEndian.Uint64(v)), 0),
+	}, nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPaymentRef checks that payment references are valid ISO 11649
+// creditor references, and that mistyped references are detected.
+func TestPaymentRef(t *testing.T) {
+	tests := []struct {
+		reference string
+		valid     bool
+	}{
+		{"RF18 5390 0754 7034", true},
+		{"rf18539007547034", true},
+		{"RF712348231", true},
+		{"RF18 5390 0754 7043", false},
+		{"RF19 5390 0754 7034", false},
+		{"RF18-5390-0754-7034", false},
+		{"XX18539007547034", false},
+		{"RF18", false},
+	}
+	for _, test := range tests {
+		valid := validPaymentRef(normalizePaymentRef(test.reference))
+		if valid != test.valid {
+			t.Errorf("reference %q valid %v, want %v",
+				test.reference, valid, test.valid)
+		}
+	}
+
+	seen := make(map[string]struct{})
+	for i := 0; i < 100; i++ {
+		reference, err := newPaymentRef()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reference) != 4+paymentRefBodyLen ||
+			!validPaymentRef(reference) {
+			t.Fatalf("invalid payment reference %q", reference)
+		}
+		if strings.ContainsAny(reference[4:], "IO") {
+			t.Fatalf("ambiguous payment reference %q", reference)
+		}
+		if _, ok := seen[reference]; ok {
+			t.Fatalf("payment reference %q generated twice", reference)
+		}
+		seen[reference] = struct{}{}
+	}
+}
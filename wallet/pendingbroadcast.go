@@ -0,0 +1,346 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// DefaultBroadcastWebhookTimeout is the time a BroadcastWebhook request may
+// take before it fails.
+const DefaultBroadcastWebhookTimeout = 10 * time.Second
+
+// ErrNotPendingBroadcast describes an error where a transaction is cancelled
+// after the end of its broadcast hold window, or was never held.
+var ErrNotPendingBroadcast = errors.New("transaction is not pending broadcast")
+
+// broadcastHold holds the published transactions for a window before they are
+// broadcast, so approvers notified of them can cancel them.
+type broadcastHold struct {
+	mu       sync.Mutex
+	window   time.Duration
+	webhooks []*BroadcastWebhook
+	pending  map[chainhash.Hash]*heldBroadcast
+}
+
+// heldBroadcast is a transaction held until its broadcast timer fires.
+type heldBroadcast struct {
+	txRec *wtxmgr.TxRecord
+	timer *time.Timer
+}
+
+// PendingBroadcastOutput is an output of a transaction pending broadcast.
+// The address is empty for outputs without a single address.
+type PendingBroadcastOutput struct {
+	Address string
+	Amount  btcutil.Amount
+	Token   wire.TokenIdentity
+}
+
+// SetBroadcastHold configures the wallet to hold the transactions it
+// publishes for a window before they are broadcast.  Held transactions are
+// notified to the PendingBroadcastNotifications clients and POSTed to the
+// webhooks, and may be cancelled during the window.  A zero window broadcasts
+// transactions immediately.
+//
+// Transactions are recorded by the wallet while they are held, so those still
+// held when the wallet stops are broadcast with the other unmined
+// transactions once it restarts.
+func (w *Wallet) SetBroadcastHold(window time.Duration, webhooks ...*BroadcastWebhook) {
+	w.broadcastHold.mu.Lock()
+	w.broadcastHold.window = window
+	w.broadcastHold.webhooks = webhooks
+	w.broadcastHold.mu.Unlock()
+}
+
+// BroadcastHold returns the window published transactions are held for
+// before they are broadcast.
+func (w *Wallet) BroadcastHold() time.Duration {
+	w.broadcastHold.mu.Lock()
+	defer w.broadcastHold.mu.Unlock()
+	return w.broadcastHold.window
+}
+
+// broadcastHeld returns whether a transaction is held for broadcast.
+func (w *Wallet) broadcastHeld(txHash *chainhash.Hash) bool {
+	w.broadcastHold.mu.Lock()
+	_, ok := w.broadcastHold.pending[*txHash]
+	w.broadcastHold.mu.Unlock()
+	return ok
+}
+
+// holdBroadcast holds a recorded transaction for the broadcast hold window
+// and notifies it as pending broadcast.
+func (w *Wallet) holdBroadcast(txRec *wtxmgr.TxRecord, window time.Duration,
+	annotations map[string]string) {
+
+	n := &PendingBroadcastNotification{
+		Tx:          &txRec.MsgTx,
+		TxHash:      txRec.Hash,
+		Outputs:     w.pendingBroadcastOutputs(&txRec.MsgTx),
+		Annotations: annotations,
+		Replaceable: signalsReplaceable(&txRec.MsgTx),
+		BroadcastAt: time.Now().Add(window),
+	}
+
+	w.broadcastHold.mu.Lock()
+	if w.broadcastHold.pending == nil {
+		w.broadcastHold.pending = make(map[chainhash.Hash]*heldBroadcast)
+	}
+	w.broadcastHold.pending[txRec.Hash] = &heldBroadcast{
+		txRec: txRec,
+		timer: time.AfterFunc(window, func() {
+			w.releaseBroadcast(&txRec.Hash)
+		}),
+	}
+	webhooks := w.broadcastHold.webhooks
+	w.broadcastHold.mu.Unlock()
+
+	log.Infof("Holding transaction %v for broadcast until %v", txRec.Hash,
+		n.BroadcastAt.Format(time.RFC3339))
+	w.audit(AuditSend, "transaction %v held for broadcast until %v",
+		txRec.Hash, n.BroadcastAt.Format(time.RFC3339))
+
+	for _, h := range webhooks {
+		go func(h *BroadcastWebhook) {
+			if err := h.Post(n); err != nil {
+				log.Warnf("Cannot post pending transaction %v to "+
+					"%s: %v", txRec.Hash, h.Name(), err)
+			}
+		}(h)
+	}
+	w.NtfnServer.notifyPendingBroadcast(n)
+}
+
+// pendingBroadcastOutputs decodes the outputs of a transaction.
+func (w *Wallet) pendingBroadcastOutputs(tx *wire.MsgTx) []PendingBroadcastOutput {
+	outputs := make([]PendingBroadcastOutput, len(tx.TxOut))
+	for i, txOut := range tx.TxOut {
+		outputs[i].Amount = btcutil.Amount(txOut.Value)
+		outputs[i].Token = txOut.TokenID()
+		_, addrs, _, _ := txscript.ExtractPkScriptAddrs(txOut.PkScript,
+			w.chainParams)
+		if len(addrs) == 1 {
+			outputs[i].Address = addrs[0].EncodeAddress()
+		}
+	}
+	return outputs
+}
+
+// releaseBroadcast broadcasts a held transaction at the end of its hold
+// window, unless it was cancelled.  The transaction is queued when the wallet
+// is offline.
+func (w *Wallet) releaseBroadcast(txHash *chainhash.Hash) {
+	w.broadcastHold.mu.Lock()
+	held, ok := w.broadcastHold.pending[*txHash]
+	delete(w.broadcastHold.pending, *txHash)
+	w.broadcastHold.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	// Transactions spending outputs of accounts frozen during their hold
+	// window are removed instead.
+	var frozen error
+	err := walletdb.Update(w.db, func(dbTx walletdb.ReadWriteTx) error {
+		frozen = w.checkFrozenSpends(dbTx, &held.txRec.MsgTx)
+		if _, ok := frozen.(*AccountFrozenError); !ok {
+			return frozen
+		}
+		txmgrNs := dbTx.ReadWriteBucket(wtxmgrNamespaceKey)
+		return w.TxStore.RemoveUnminedTx(txmgrNs, held.txRec)
+	})
+	if err != nil {
+		log.Errorf("Cannot broadcast held transaction %v: %v", txHash, err)
+		return
+	}
+	if frozen != nil {
+		log.Warnf("Removed held transaction %v: %v", txHash, frozen)
+		w.audit(AuditSend, "transaction %v removed before broadcast: %v",
+			txHash, frozen)
+		return
+	}
+
+	server, err := w.optionalChainClient()
+	if err != nil {
+		log.Errorf("Cannot broadcast held transaction %v: %v", txHash, err)
+		return
+	}
+	if server == nil {
+		err := walletdb.Update(w.db, func(dbTx walletdb.ReadWriteTx) error {
+			return queueBroadcast(
+				dbTx.ReadWriteBucket(wtxmetaNamespaceKey),
+				&held.txRec.MsgTx,
+			)
+		})
+		if err != nil {
+			log.Errorf("Cannot queue held transaction %v: %v",
+				txHash, err)
+			return
+		}
+		log.Infof("Queued held transaction %v for broadcast once the "+
+			"wallet is online", txHash)
+		w.audit(AuditSend, "transaction %v queued for broadcast", txHash)
+		return
+	}
+
+	if _, err := w.sendTransaction(server, held.txRec); err != nil {
+		log.Errorf("Cannot broadcast held transaction %v: %v", txHash, err)
+		return
+	}
+	log.Infof("Broadcast held transaction %v", txHash)
+	w.audit(AuditSend, "transaction %v broadcast", txHash)
+}
+
+// CancelBroadcast cancels a transaction held for broadcast and removes it,
+// and any transaction spending its outputs, from the wallet.
+// ErrNotPendingBroadcast is returned when the transaction is not held.
+func (w *Wallet) CancelBroadcast(txHash *chainhash.Hash) error {
+	w.broadcastHold.mu.Lock()
+	held, ok := w.broadcastHold.pending[*txHash]
+	if ok {
+		held.timer.Stop()
+		delete(w.broadcastHold.pending, *txHash)
+	}
+	w.broadcastHold.mu.Unlock()
+	if !ok {
+		return ErrNotPendingBroadcast
+	}
+
+	err := walletdb.Update(w.db, func(dbTx walletdb.ReadWriteTx) error {
+		txmgrNs := dbTx.ReadWriteBucket(wtxmgrNamespaceKey)
+		return w.TxStore.RemoveUnminedTx(txmgrNs, held.txRec)
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Cancelled broadcast of transaction %v", txHash)
+	w.audit(AuditSend, "transaction %v cancelled before broadcast", txHash)
+	return nil
+}
+
+// BroadcastWebhook POSTs the transactions pending broadcast to an approval
+// service or other subscriber as JSON objects with the txid, hex, outputs,
+// annotations, replaceable and broadcastat keys.  Outputs are objects with
+// the address, amount and token keys, amounts are in coins and broadcastat
+// is a Unix time.  Requests are authenticated with a bearer token when an API
+// key is configured.
+type BroadcastWebhook struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewBroadcastWebhook returns a webhook for the subscriber at the passed URL.
+func NewBroadcastWebhook(webhookURL, apiKey string) (*BroadcastWebhook, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("webhook URL %q is not an HTTP URL",
+			webhookURL)
+	}
+	return &BroadcastWebhook{
+		url:    webhookURL,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: DefaultBroadcastWebhookTimeout},
+	}, nil
+}
+
+type pendingBroadcastOutputJSON struct {
+	Address string  `json:"address,omitempty"`
+	Amount  float64 `json:"amount"`
+	Token   string  `json:"token"`
+}
+
+type pendingBroadcastJSON struct {
+	TxID        string                       `json:"txid"`
+	Hex         string                       `json:"hex"`
+	Outputs     []pendingBroadcastOutputJSON `json:"outputs"`
+	Annotations map[string]string            `json:"annotations,omitempty"`
+	Replaceable bool                         `json:"replaceable"`
+	BroadcastAt int64                        `json:"broadcastat"`
+}
+
+// Name returns the host of the subscriber.
+func (h *BroadcastWebhook) Name() string {
+	u, err := url.Parse(h.url)
+	if err != nil {
+		return h.url
+	}
+	return u.Host
+}
+
+// Post POSTs a transaction pending broadcast to the subscriber.
+func (h *BroadcastWebhook) Post(n *PendingBroadcastNotification) error {
+	buf, err := marshalPendingBroadcast(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", h.url, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.apiKey)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// marshalPendingBroadcast serializes a transaction pending broadcast as the
+// body of webhook requests.
+func marshalPendingBroadcast(n *PendingBroadcastNotification) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(n.Tx.SerializeSize())
+	if err := n.Tx.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	body := pendingBroadcastJSON{
+		TxID:        n.TxHash.String(),
+		Hex:         hex.EncodeToString(buf.Bytes()),
+		Outputs:     make([]pendingBroadcastOutputJSON, len(n.Outputs)),
+		Annotations: n.Annotations,
+		Replaceable: n.Replaceable,
+		BroadcastAt: n.BroadcastAt.Unix(),
+	}
+	for i, output := range n.Outputs {
+		body.Outputs[i] = pendingBroadcastOutputJSON{
+			Address: output.Address,
+			Amount:  output.Amount.ToBTC(),
+			Token:   output.Token.String(),
+		}
+	}
+	return json.Marshal(&body)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// TestBroadcastWebhook checks the requests POSTed to broadcast webhooks, and
+// that only held transactions can be cancelled.
+func TestBroadcastWebhook(t *testing.T) {
+	w := &Wallet{chainParams: &chaincfg.RegressionNetParams}
+
+	addr, err := btcutil.NewAddressPubKeyHash(make([]byte, 20),
+		w.chainParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1e8, pkScript))
+	setReplaceable(tx, true)
+	n := &PendingBroadcastNotification{
+		Tx:          tx,
+		TxHash:      tx.TxHash(),
+		Outputs:     w.pendingBroadcastOutputs(tx),
+		Annotations: map[string]string{"approver": "alice"},
+		Replaceable: true,
+		BroadcastAt: time.Unix(1500000000, 0),
+	}
+
+	requests := make(chan pendingBroadcastJSON, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req pendingBroadcastJSON
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests <- req
+	}))
+	defer server.Close()
+
+	if _, err := NewBroadcastWebhook("ftp://example.com", ""); err == nil {
+		t.Errorf("accepted non-HTTP webhook URL")
+	}
+
+	h, err := NewBroadcastWebhook(server.URL, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Post(n); err != nil {
+		t.Fatal(err)
+	}
+	req := <-requests
+	if req.TxID != n.TxHash.String() {
+		t.Errorf("txid is %v, want %v", req.TxID, n.TxHash)
+	}
+	if len(req.Outputs) != 1 || req.Outputs[0].Address != addr.EncodeAddress() ||
+		req.Outputs[0].Amount != 1 {
+		t.Errorf("outputs are %+v", req.Outputs)
+	}
+	if req.Annotations["approver"] != "alice" || !req.Replaceable ||
+		req.BroadcastAt != 1500000000 {
+		t.Errorf("request is %+v", req)
+	}
+
+	unauthorized, err := NewBroadcastWebhook(server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unauthorized.Post(n); err == nil {
+		t.Error("unauthorized request succeeded")
+	}
+
+	if err := w.CancelBroadcast(&chainhash.Hash{}); err != ErrNotPendingBroadcast {
+		t.Errorf("cancelled a transaction which was not held: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// PolicyOutcome is the outcome of a policy rule for an evaluated operation.
+type PolicyOutcome string
+
+// These constants define the outcomes of policy rules.
+const (
+	// PolicyPass is the outcome of rules that let the operation through.
+	PolicyPass PolicyOutcome = "pass"
+
+	// PolicyWarn is the outcome of rules that let the operation through
+	// with a logged warning.
+	PolicyWarn PolicyOutcome = "warn"
+
+	// PolicyHold is the outcome of rules that delay the operation so that
+	// approvers may cancel it.
+	PolicyHold PolicyOutcome = "hold"
+
+	// PolicyBlock is the outcome of rules that refuse the operation.
+	PolicyBlock PolicyOutcome = "block"
+
+	// PolicySkipped is the outcome of rules that are not evaluated, either
+	// because they have side effects or because an earlier rule blocks
+	// the operation.
+	PolicySkipped PolicyOutcome = "skipped"
+)
+
+// Policy rules reported by EvaluatePolicy.
+const (
+	PolicyRuleAccountFreeze = "accountfreeze"
+	PolicyRuleAddressPool   = "addresspool"
+	PolicyRuleDust          = "dust"
+	PolicyRuleFunds         = "funds"
+	PolicyRuleFeeCap        = "feecap"
+	PolicyRuleScreening     = "screening"
+	PolicyRuleTxHooks       = "txhooks"
+	PolicyRuleBroadcastHold = "broadcasthold"
+)
+
+// PolicyRuleResult is the outcome of a policy rule for an evaluated
+// operation, with a description of why the rule triggered.
+type PolicyRuleResult struct {
+	Rule    string
+	Outcome PolicyOutcome
+	Detail  string
+}
+
+// PolicyEvaluation reports the outcome of every policy rule for a
+// hypothetical operation.  Allowed is whether no rule blocks it, and Preview
+// is the transaction the wallet would create, when it could be created.
+type PolicyEvaluation struct {
+	Rules   []PolicyRuleResult
+	Allowed bool
+	Preview *TxPreview
+}
+
+// add records the outcome of a rule.
+func (e *PolicyEvaluation) add(rule string, outcome PolicyOutcome,
+	format string, args ...interface{}) {
+
+	e.Rules = append(e.Rules, PolicyRuleResult{
+		Rule:    rule,
+		Outcome: outcome,
+		Detail:  fmt.Sprintf(format, args...),
+	})
+	if outcome == PolicyBlock {
+		e.Allowed = false
+	}
+}
+
+// EvaluatePolicy runs a hypothetical send of outputs from an account through
+// the spend policies of the wallet, the address screening provider and the
+// broadcast hold, and reports the outcome of every rule without creating,
+// signing or sending a transaction, so that integrators can validate their
+// flows before production.  The destination addresses are sent to the
+// screening provider as for a real send.  Transaction hooks, which may have
+// side effects, are not run and are reported as skipped.
+func (w *Wallet) EvaluatePolicy(account uint32, outputs []*wire.TxOut,
+	minconf int32, satPerKb btcutil.Amount, opts *TxOptions) (*PolicyEvaluation, error) {
+
+	eval := &PolicyEvaluation{Allowed: true}
+
+	if err := w.checkAccountFrozen(account); err != nil {
+		eval.add(PolicyRuleAccountFreeze, PolicyBlock, "%v", err)
+	} else {
+		eval.add(PolicyRuleAccountFreeze, PolicyPass, "account is not frozen")
+	}
+
+	err := w.checkPoolDestinations(account, outputs)
+	switch err.(type) {
+	case nil:
+		eval.add(PolicyRuleAddressPool, PolicyPass,
+			"destinations are allowed by the address pools")
+	case *PoolDestinationError:
+		eval.add(PolicyRuleAddressPool, PolicyBlock, "%v", err)
+	default:
+		return nil, err
+	}
+
+	if err := w.DustPolicy().checkDustOutputs(outputs); err != nil {
+		eval.add(PolicyRuleDust, PolicyBlock, "%v", err)
+	} else {
+		eval.add(PolicyRuleDust, PolicyPass,
+			"outputs are above the dust limit")
+	}
+
+	// The transaction is only previewed when the rules checked before
+	// coin selection let it through, as the preview checks them again.
+	if !eval.Allowed {
+		eval.add(PolicyRuleFunds, PolicySkipped,
+			"blocked by an earlier rule")
+		eval.add(PolicyRuleFeeCap, PolicySkipped,
+			"blocked by an earlier rule")
+	} else {
+		w.evaluatePreview(eval, account, outputs, minconf, satPerKb, opts)
+	}
+
+	if err := w.evaluateScreening(eval, outputs); err != nil {
+		return nil, err
+	}
+
+	w.txHooks.mu.Lock()
+	numHooks := len(w.txHooks.hooks)
+	w.txHooks.mu.Unlock()
+	if numHooks != 0 {
+		eval.add(PolicyRuleTxHooks, PolicySkipped,
+			"%d transaction hooks are not run by evaluations", numHooks)
+	} else {
+		eval.add(PolicyRuleTxHooks, PolicyPass,
+			"no transaction hook is registered")
+	}
+
+	if hold := w.BroadcastHold(); hold > 0 {
+		eval.add(PolicyRuleBroadcastHold, PolicyHold,
+			"broadcast is held for %v, during which it may be "+
+				"cancelled", hold)
+	} else {
+		eval.add(PolicyRuleBroadcastHold, PolicyPass,
+			"broadcast is immediate")
+	}
+	return eval, nil
+}
+
+// evaluatePreview records the outcomes of the coin selection and of the fee
+// cap of an evaluated send, with the transaction preview when it succeeds.
+func (w *Wallet) evaluatePreview(eval *PolicyEvaluation, account uint32,
+	outputs []*wire.TxOut, minconf int32, satPerKb btcutil.Amount,
+	opts *TxOptions) {
+
+	preview, err := w.PreviewTx(account, outputs, minconf, satPerKb, opts)
+	switch err.(type) {
+	case nil:
+		eval.Preview = preview
+		eval.add(PolicyRuleFunds, PolicyPass, "%d inputs are selected",
+			len(preview.Tx.TxIn))
+		eval.add(PolicyRuleFeeCap, PolicyPass, "fee %v is below the fee cap",
+			preview.Fee)
+	case *FeeCapError:
+		eval.add(PolicyRuleFunds, PolicyPass, "inputs are selected")
+		eval.add(PolicyRuleFeeCap, PolicyBlock, "%v", err)
+	default:
+		eval.add(PolicyRuleFunds, PolicyBlock, "%v", err)
+		eval.add(PolicyRuleFeeCap, PolicySkipped,
+			"blocked by an earlier rule")
+	}
+}
+
+// evaluateScreening records the outcomes of the address screening of the
+// destinations of an evaluated send that are not wallet addresses.
+func (w *Wallet) evaluateScreening(eval *PolicyEvaluation, outputs []*wire.TxOut) error {
+	w.screening.mu.Lock()
+	enabled := w.screening.screener != nil
+	w.screening.mu.Unlock()
+	if !enabled {
+		eval.add(PolicyRuleScreening, PolicyPass,
+			"no screening provider is configured")
+		return nil
+	}
+
+	req := &ScreeningRequest{Direction: ScreenOutgoing}
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		for _, txOut := range outputs {
+			_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+				txOut.PkScript, w.chainParams)
+			if err != nil {
+				continue
+			}
+			for _, addr := range addrs {
+				if _, err := w.Manager.Address(addrmgrNs, addr); err == nil {
+					continue
+				}
+				req.Addresses = append(req.Addresses, addr)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	outcomes := w.screen(req)
+	if len(outcomes) == 0 {
+		eval.add(PolicyRuleScreening, PolicyPass,
+			"%d destinations are allowed", len(req.Addresses))
+	}
+	for _, o := range outcomes {
+		outcome := PolicyWarn
+		if o.Action == ScreenBlock {
+			outcome = PolicyBlock
+		}
+		detail := fmt.Sprintf("address %s has risk %q", o.Address, o.Risk)
+		if o.Reason != "" {
+			detail += ": " + o.Reason
+		}
+		eval.add(PolicyRuleScreening, outcome, "%s", detail)
+	}
+	return nil
+}
@@ -0,0 +1,382 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/helpers"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/psbt"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// FundPsbt creates an unsigned transaction paying to each output from the
+// passed account's UTXO set, as CreateUnsignedTx does, and returns it as a
+// PSBT along with the fee paid and the index of the change output, which is
+// negative if no change was added.  The inputs and change output are updated
+// with the UTXO data, scripts and key derivations known to the wallet.
+// Options left unset in opts, which may be nil, follow the wallet's policies.
+func (w *Wallet) FundPsbt(outputs []*wire.TxOut, account uint32, minconf int32,
+	satPerKb btcutil.Amount, opts *TxOptions) (*psbt.Packet,
+	btcutil.Amount, int, error) {
+
+	tx, err := w.CreateUnsignedTx(account, outputs, minconf, satPerKb,
+		opts)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	packet, err := psbt.New(tx.Tx)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		return w.updatePsbt(dbtx, packet)
+	})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	fee := tx.TotalInput - helpers.SumOutputValues(tx.Tx.TxOut)
+	return packet, fee, tx.ChangeIndex, nil
+}
+
+// ProcessPsbt updates the inputs and outputs of a PSBT with the UTXO data,
+// scripts and key derivations known to the wallet.  When sign is set, a
+// partial signature is added for each wallet key an input can be signed
+// with, using hashType, and an error is returned for inputs specifying a
+// different sighash type.  Every input that has all of the signatures it
+// requires is then finalized, and the returned bool reports whether the
+// packet is complete.  Taproot inputs are not signed.
+//
+// UTXO data of the packet spending outputs of transactions known to the
+// wallet must match those outputs.
+//
+// The packet is modified by this function.
+func (w *Wallet) ProcessPsbt(packet *psbt.Packet, sign bool,
+	hashType txscript.SigHashType) (bool, error) {
+
+	if sign {
+		heldUnlock, err := w.holdUnlock()
+		if err != nil {
+			return false, err
+		}
+		defer heldUnlock.release()
+	}
+
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		if err := w.updatePsbt(dbtx, packet); err != nil {
+			return err
+		}
+		if !sign {
+			return nil
+		}
+		return w.signPsbt(dbtx, packet, hashType)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	packet.FinalizeAll()
+	return packet.IsComplete(), nil
+}
+
+// updatePsbt adds the information known to the wallet to every input that is
+// not finalized and every output of the packet.
+func (w *Wallet) updatePsbt(dbtx walletdb.ReadTx, packet *psbt.Packet) error {
+	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+	// Watching-only wallets created without a key origin do not know the
+	// fingerprint, which is then left zero.
+	fingerprint, err := w.Manager.MasterKeyFingerprint(addrmgrNs)
+	if err != nil && !waddrmgr.IsError(err, waddrmgr.ErrNoExist) {
+		return err
+	}
+	masterKey := binary.LittleEndian.Uint32(fingerprint[:])
+
+	for i := range packet.Inputs {
+		if packet.Inputs[i].IsFinalized() {
+			continue
+		}
+		err := w.updatePsbtInput(dbtx, packet, i, masterKey)
+		if err != nil {
+			return err
+		}
+	}
+	for i, txOut := range packet.UnsignedTx.TxOut {
+		out := &packet.Outputs[i]
+		redeemScript, addrs, err := w.psbtScriptKeys(dbtx,
+			txOut.PkScript)
+		if err != nil {
+			return err
+		}
+		if out.RedeemScript == nil && redeemScript != nil {
+			out.RedeemScript = redeemScript
+		}
+		for _, addr := range addrs {
+			d := w.psbtDerivation(addrmgrNs, addr, masterKey)
+			if d != nil {
+				out.AddBip32Derivation(d)
+			}
+		}
+	}
+	return nil
+}
+
+// updatePsbtInput adds the UTXO data, scripts and key derivations known to
+// the wallet to input idx of the packet.  Inputs spending outputs of
+// transactions unknown to the wallet are left for other updaters.  UTXO
+// data already in the packet is checked against the spent output when the
+// wallet knows its transaction, so that inputs are never signed for an
+// amount or script supplied by the packet alone.
+func (w *Wallet) updatePsbtInput(dbtx walletdb.ReadTx, packet *psbt.Packet,
+	idx int, masterKey uint32) error {
+
+	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+	txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+	in := &packet.Inputs[idx]
+
+	prevOut, err := packet.PrevOutput(idx)
+	if err != nil {
+		return err
+	}
+	outPoint := &packet.UnsignedTx.TxIn[idx].PreviousOutPoint
+	details, err := w.TxStore.TxDetails(txmgrNs, &outPoint.Hash)
+	if err != nil {
+		return err
+	}
+	if details != nil && outPoint.Index < uint32(len(details.MsgTx.TxOut)) {
+		spent := details.MsgTx.TxOut[outPoint.Index]
+		switch {
+		case prevOut == nil:
+			prevOut = spent
+
+			// Signatures of witness inputs commit to the spent
+			// amount, so the spent output is enough for signers
+			// to verify them.
+			if txscript.IsWitnessProgram(prevOut.PkScript) {
+				in.WitnessUtxo = prevOut
+			} else {
+				in.NonWitnessUtxo = &details.MsgTx
+			}
+
+		case prevOut.Value != spent.Value ||
+			!bytes.Equal(prevOut.PkScript, spent.PkScript):
+
+			return fmt.Errorf("UTXO of input %d does not match the "+
+				"spent output %v", idx, outPoint)
+		}
+	}
+	if prevOut == nil {
+		return nil
+	}
+
+	pkScript := prevOut.PkScript
+	if txscript.IsPayToScriptHash(pkScript) && in.RedeemScript == nil {
+		in.RedeemScript, _, err = w.psbtScriptKeys(dbtx, pkScript)
+		if err != nil {
+			return err
+		}
+	}
+
+	witnessProgram := pkScript
+	if txscript.IsPayToScriptHash(pkScript) {
+		witnessProgram = in.RedeemScript
+	}
+	if txscript.IsPayToWitnessScriptHash(witnessProgram) && in.WitnessScript == nil {
+		in.WitnessScript, _, err = w.psbtScriptKeys(dbtx, witnessProgram)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, addrs, err := w.psbtScriptKeys(dbtx, pkScript)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		d := w.psbtDerivation(addrmgrNs, addr, masterKey)
+		if d != nil {
+			in.AddBip32Derivation(d)
+		}
+	}
+	return nil
+}
+
+// psbtScriptKeys returns the script redeeming a P2SH or P2WSH output script
+// and the addresses of the keys that can sign for the output, as far as
+// they are known to the wallet.  A nil script is returned for other output
+// types, and for P2SH and P2WSH outputs unknown to the wallet.
+func (w *Wallet) psbtScriptKeys(dbtx walletdb.ReadTx, pkScript []byte) ([]byte,
+	[]btcutil.Address, error) {
+
+	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+	scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+	secrets := secretSource{w.Manager, addrmgrNs, scriptNs, w}
+
+	class, addrs, _, err := taproot.ExtractPkScriptAddrs(pkScript,
+		w.chainParams)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch class {
+	case txscript.ScriptHashTy, txscript.WitnessV0ScriptHashTy:
+	default:
+		return nil, addrs, nil
+	}
+	if len(addrs) != 1 {
+		return nil, nil, nil
+	}
+
+	script, err := secrets.GetScript(addrs[0])
+	if err != nil {
+		// Nested P2WPKH addresses are redeemed by the witness program
+		// of their key, which is not stored as a script.
+		ma, err := w.Manager.Address(addrmgrNs, addrs[0])
+		if err != nil || ma.AddrType() != waddrmgr.NestedWitnessPubKey {
+			return nil, nil, nil
+		}
+		mpka, ok := ma.(waddrmgr.ManagedPubKeyAddress)
+		if !ok {
+			return nil, nil, nil
+		}
+		program, err := txscript.NewScriptBuilder().
+			AddOp(txscript.OP_0).
+			AddData(btcutil.Hash160(psbtPubKey(mpka))).
+			Script()
+		if err != nil {
+			return nil, nil, err
+		}
+		return program, addrs, nil
+	}
+
+	// The keys of the redeem script itself, or of the witness script
+	// when the redeem script is a P2WSH witness program.
+	keyScript := script
+	if class == txscript.ScriptHashTy && txscript.IsPayToWitnessScriptHash(script) {
+		witnessScript, _, err := w.psbtScriptKeys(dbtx, script)
+		if err != nil {
+			return nil, nil, err
+		}
+		if witnessScript == nil {
+			return script, nil, nil
+		}
+		keyScript = witnessScript
+	}
+	_, addrs, _, err = txscript.ExtractPkScriptAddrs(keyScript,
+		w.chainParams)
+	if err != nil {
+		return nil, nil, err
+	}
+	return script, addrs, nil
+}
+
+// psbtDerivation returns the BIP0032 derivation of the key of a wallet
+// address, or nil if the address is not an HD key of the wallet.  Taproot
+// keys are skipped, since BIP0174 has no fields for them.
+func (w *Wallet) psbtDerivation(addrmgrNs walletdb.ReadBucket,
+	addr btcutil.Address, masterKey uint32) *psbt.Bip32Derivation {
+
+	ma, err := w.Manager.Address(addrmgrNs, addr)
+	if err != nil || ma.AddrType() == waddrmgr.TaprootPubKey {
+		return nil
+	}
+	mpka, ok := ma.(waddrmgr.ManagedPubKeyAddress)
+	if !ok {
+		return nil
+	}
+	scope, path, ok := mpka.DerivationInfo()
+	if !ok {
+		return nil
+	}
+
+	// Accounts bound to a custom derivation path are not derived from
+	// the coin type key of their scope.
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return nil
+	}
+	acctPath, err := manager.AccountDerivationPath(addrmgrNs, path.Account)
+	if err != nil {
+		return nil
+	}
+	return &psbt.Bip32Derivation{
+		PubKey:               psbtPubKey(mpka),
+		MasterKeyFingerprint: masterKey,
+		Path:                 append(acctPath, path.Branch, path.Index),
+	}
+}
+
+// psbtPubKey returns the serialized public key of a wallet address as it
+// appears in scripts.
+func psbtPubKey(mpka waddrmgr.ManagedPubKeyAddress) []byte {
+	if mpka.Compressed() {
+		return mpka.PubKey().SerializeCompressed()
+	}
+	return mpka.PubKey().SerializeUncompressed()
+}
+
+// signPsbt adds the partial signatures of wallet keys to every input of the
+// packet that is not finalized and has UTXO data.
+func (w *Wallet) signPsbt(dbtx walletdb.ReadTx, packet *psbt.Packet,
+	hashType txscript.SigHashType) error {
+
+	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+	scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+	secrets := secretSource{w.Manager, addrmgrNs, scriptNs, w}
+	hashCache := txscript.NewTxSigHashes(packet.UnsignedTx)
+
+	for i := range packet.Inputs {
+		in := &packet.Inputs[i]
+		if in.IsFinalized() {
+			continue
+		}
+		prevOut, err := packet.PrevOutput(i)
+		if err != nil {
+			return err
+		}
+		if prevOut == nil || taproot.IsPayToTaproot(prevOut.PkScript) {
+			continue
+		}
+
+		// Inputs redeemed by scripts unknown to the wallet and the
+		// packet are left to other signers.
+		witnessProgram := prevOut.PkScript
+		if txscript.IsPayToScriptHash(prevOut.PkScript) {
+			if in.RedeemScript == nil {
+				continue
+			}
+			witnessProgram = in.RedeemScript
+		}
+		if txscript.IsPayToWitnessScriptHash(witnessProgram) &&
+			in.WitnessScript == nil {
+			continue
+		}
+
+		if in.SighashType != 0 && in.SighashType != hashType {
+			return fmt.Errorf("input %d requires sighash type %#x, "+
+				"not %#x", i, in.SighashType, hashType)
+		}
+		sigs, err := txauthor.InputSignatures(packet.UnsignedTx,
+			hashCache, i, prevOut.PkScript, in.RedeemScript,
+			in.WitnessScript, prevOut.Value, hashType, secrets)
+		if err != nil {
+			return err
+		}
+		for _, sig := range sigs {
+			err := in.AddPartialSig(sig.PubKey, sig.Signature)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
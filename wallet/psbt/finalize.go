@@ -0,0 +1,238 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// ErrNotFinalized is returned when extracting a transaction from a packet
+// with inputs that are not finalized.
+var ErrNotFinalized = errors.New("PSBT has inputs that are not finalized")
+
+// IsComplete returns whether every input of the packet is finalized.
+func (p *Packet) IsComplete() bool {
+	for i := range p.Inputs {
+		if !p.Inputs[i].IsFinalized() {
+			return false
+		}
+	}
+	return true
+}
+
+// FinalizeAll finalizes every input that has the signatures required by its
+// scripts.  Inputs that can not be finalized yet are left unchanged, and it
+// is not an error for the packet to remain incomplete.
+func (p *Packet) FinalizeAll() {
+	for i := range p.Inputs {
+		if !p.Inputs[i].IsFinalized() {
+			p.Finalize(i)
+		}
+	}
+}
+
+// Finalize builds the final sigScript and witness of input idx from its
+// partial signatures and scripts.  P2PKH, P2PK, P2WPKH and P2SH-P2WPKH
+// inputs, as well as P2SH, P2WSH and P2SH-P2WSH inputs redeemed by multisig,
+// P2PK or P2PKH scripts are supported.  The signing fields of the input are
+// cleared once it is finalized.
+func (p *Packet) Finalize(idx int) error {
+	in := &p.Inputs[idx]
+	if in.IsFinalized() {
+		return nil
+	}
+	prevOut, err := p.PrevOutput(idx)
+	if err != nil {
+		return err
+	}
+	if prevOut == nil {
+		return fmt.Errorf("input %d has no UTXO data", idx)
+	}
+	pkScript := prevOut.PkScript
+
+	var sigScript []byte
+	var witness wire.TxWitness
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.PubKeyHashTy, txscript.PubKeyTy:
+		items, err := in.scriptItems(pkScript)
+		if err != nil {
+			return err
+		}
+		sigScript, err = pushItems(items)
+		if err != nil {
+			return err
+		}
+
+	case txscript.WitnessV0PubKeyHashTy:
+		witness, err = in.scriptItems(pkScript)
+		if err != nil {
+			return err
+		}
+
+	case txscript.WitnessV0ScriptHashTy:
+		witness, err = in.witnessScriptItems(pkScript[2:])
+		if err != nil {
+			return err
+		}
+
+	case txscript.ScriptHashTy:
+		redeemScript := in.RedeemScript
+		if redeemScript == nil {
+			return fmt.Errorf("input %d has no redeem script", idx)
+		}
+		if !bytes.Equal(btcutil.Hash160(redeemScript), pkScript[2:22]) {
+			return fmt.Errorf("redeem script of input %d does not "+
+				"match the spent output", idx)
+		}
+		switch txscript.GetScriptClass(redeemScript) {
+		case txscript.WitnessV0PubKeyHashTy:
+			witness, err = in.scriptItems(redeemScript)
+		case txscript.WitnessV0ScriptHashTy:
+			witness, err = in.witnessScriptItems(redeemScript[2:])
+		default:
+			var items [][]byte
+			items, err = in.scriptItems(redeemScript)
+			if err == nil {
+				sigScript, err = pushItems(append(items, redeemScript))
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if witness != nil {
+			sigScript, err = pushItems([][]byte{redeemScript})
+			if err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("input %d spends an unsupported output script",
+			idx)
+	}
+
+	in.FinalScriptSig = sigScript
+	in.FinalScriptWitness = witness
+	in.PartialSigs = nil
+	in.SighashType = 0
+	in.RedeemScript = nil
+	in.WitnessScript = nil
+	in.Bip32Derivation = nil
+	return nil
+}
+
+// witnessScriptItems returns the witness stack spending the P2WSH output
+// committing to scriptHash using the witness script of the input.
+func (in *Input) witnessScriptItems(scriptHash []byte) (wire.TxWitness, error) {
+	witnessScript := in.WitnessScript
+	if witnessScript == nil {
+		return nil, errors.New("input has no witness script")
+	}
+	h := sha256.Sum256(witnessScript)
+	if !bytes.Equal(h[:], scriptHash) {
+		return nil, errors.New("witness script does not match the " +
+			"spent output")
+	}
+	items, err := in.scriptItems(witnessScript)
+	if err != nil {
+		return nil, err
+	}
+	return append(items, witnessScript), nil
+}
+
+// partialSig returns the signature of the public key, if any.
+func (in *Input) partialSig(pubKey []byte) []byte {
+	for _, sig := range in.PartialSigs {
+		if bytes.Equal(sig.PubKey, pubKey) {
+			return sig.Signature
+		}
+	}
+	return nil
+}
+
+// scriptItems returns the stack items satisfying a P2PKH, P2WPKH, P2PK or
+// multisig script using the partial signatures of the input.
+func (in *Input) scriptItems(script []byte) ([][]byte, error) {
+	pushes, err := txscript.PushedData(script)
+	if err != nil {
+		return nil, err
+	}
+
+	switch txscript.GetScriptClass(script) {
+	// The key hash is the last push, after the witness version of
+	// P2WPKH scripts.
+	case txscript.PubKeyHashTy, txscript.WitnessV0PubKeyHashTy:
+		keyHash := pushes[len(pushes)-1]
+		for _, sig := range in.PartialSigs {
+			if bytes.Equal(btcutil.Hash160(sig.PubKey), keyHash) {
+				return [][]byte{sig.Signature, sig.PubKey}, nil
+			}
+		}
+		return nil, errors.New("missing signature for public key hash")
+
+	case txscript.PubKeyTy:
+		sig := in.partialSig(pushes[0])
+		if sig == nil {
+			return nil, errors.New("missing signature for public key")
+		}
+		return [][]byte{sig}, nil
+
+	case txscript.MultiSigTy:
+		nRequired := int(script[0] - (txscript.OP_1 - 1))
+
+		// OP_CHECKMULTISIG pops an extra unused stack element, and
+		// signatures must be in the same order as their keys.
+		items := [][]byte{nil}
+		for _, pubKey := range pushes {
+			if len(items)-1 == nRequired {
+				break
+			}
+			if sig := in.partialSig(pubKey); sig != nil {
+				items = append(items, sig)
+			}
+		}
+		if len(items)-1 < nRequired {
+			return nil, fmt.Errorf("script requires %d signatures but "+
+				"only %d are available", nRequired, len(items)-1)
+		}
+		return items, nil
+
+	default:
+		return nil, errors.New("unsupported script type")
+	}
+}
+
+// pushItems returns a script pushing each of the items.
+func pushItems(items [][]byte) ([]byte, error) {
+	b := txscript.NewScriptBuilder()
+	for _, item := range items {
+		if item == nil {
+			b.AddOp(txscript.OP_0)
+			continue
+		}
+		b.AddData(item)
+	}
+	return b.Script()
+}
+
+// Extract returns the signed transaction of a complete packet.
+func (p *Packet) Extract() (*wire.MsgTx, error) {
+	if !p.IsComplete() {
+		return nil, ErrNotFinalized
+	}
+	tx := p.UnsignedTx.Copy()
+	for i, txIn := range tx.TxIn {
+		txIn.SignatureScript = p.Inputs[i].FinalScriptSig
+		txIn.Witness = p.Inputs[i].FinalScriptWitness
+	}
+	return tx, nil
+}
@@ -0,0 +1,722 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package psbt implements the BIP0174 Partially Signed Bitcoin Transaction
+// format used to pass unsigned transactions between wallets and external
+// signers.
+package psbt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// magic is the prefix of every serialized packet.
+var magic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+// Key types of the global map.
+const (
+	globalUnsignedTxType = 0x00
+)
+
+// Key types of input maps.
+const (
+	inputNonWitnessUtxoType     = 0x00
+	inputWitnessUtxoType        = 0x01
+	inputPartialSigType         = 0x02
+	inputSighashType            = 0x03
+	inputRedeemScriptType       = 0x04
+	inputWitnessScriptType      = 0x05
+	inputBip32DerivationType    = 0x06
+	inputFinalScriptSigType     = 0x07
+	inputFinalScriptWitnessType = 0x08
+)
+
+// Key types of output maps.
+const (
+	outputRedeemScriptType    = 0x00
+	outputWitnessScriptType   = 0x01
+	outputBip32DerivationType = 0x02
+)
+
+// maxPsbtValueLength limits the size of a single key or value read from a
+// serialized packet.
+const maxPsbtValueLength = 4000000
+
+// These errors describe malformed packets.
+var (
+	ErrInvalidMagic      = errors.New("invalid PSBT magic bytes")
+	ErrDuplicateKey      = errors.New("duplicate key in PSBT")
+	ErrInvalidKey        = errors.New("invalid PSBT key")
+	ErrInvalidValue      = errors.New("invalid PSBT value")
+	ErrMissingUnsignedTx = errors.New("PSBT is missing the unsigned transaction")
+	ErrSignedUnsignedTx  = errors.New("unsigned transaction of PSBT has " +
+		"signature scripts or witnesses")
+	ErrInputCount  = errors.New("PSBT input maps do not match transaction inputs")
+	ErrOutputCount = errors.New("PSBT output maps do not match transaction outputs")
+)
+
+// Unknown is a key-value pair of a type not understood by this package.
+// Unknown pairs are kept so they are not lost when a packet is passed on.
+type Unknown struct {
+	Key   []byte
+	Value []byte
+}
+
+// PartialSig is the signature of a single public key for an input.
+type PartialSig struct {
+	PubKey    []byte
+	Signature []byte
+}
+
+// Bip32Derivation describes the BIP0032 derivation of a public key from the
+// master key identified by its fingerprint.
+type Bip32Derivation struct {
+	PubKey               []byte
+	MasterKeyFingerprint uint32
+	Path                 []uint32
+}
+
+// Input holds the information needed to sign and finalize an input of the
+// unsigned transaction.
+type Input struct {
+	NonWitnessUtxo     *wire.MsgTx
+	WitnessUtxo        *wire.TxOut
+	PartialSigs        []*PartialSig
+	SighashType        txscript.SigHashType
+	RedeemScript       []byte
+	WitnessScript      []byte
+	Bip32Derivation    []*Bip32Derivation
+	FinalScriptSig     []byte
+	FinalScriptWitness wire.TxWitness
+	Unknowns           []*Unknown
+}
+
+// IsFinalized returns whether the input has a final sigScript or witness.
+func (in *Input) IsFinalized() bool {
+	return in.FinalScriptSig != nil || in.FinalScriptWitness != nil
+}
+
+// addPartialSig adds a signature, replacing any earlier signature by the
+// same public key.
+func (in *Input) addPartialSig(sig *PartialSig) {
+	for i, s := range in.PartialSigs {
+		if bytes.Equal(s.PubKey, sig.PubKey) {
+			in.PartialSigs[i] = sig
+			return
+		}
+	}
+	in.PartialSigs = append(in.PartialSigs, sig)
+}
+
+// AddPartialSig adds the signature of a public key to the input.  The
+// signature must carry the sighash type of the input, if the input has one.
+func (in *Input) AddPartialSig(pubKey, sig []byte) error {
+	if _, err := btcec.ParsePubKey(pubKey, btcec.S256()); err != nil {
+		return err
+	}
+	if len(sig) == 0 {
+		return ErrInvalidValue
+	}
+	hashType := txscript.SigHashType(sig[len(sig)-1])
+	if in.SighashType != 0 && hashType != in.SighashType {
+		return fmt.Errorf("signature hash type %v does not match input "+
+			"hash type %v", hashType, in.SighashType)
+	}
+	in.addPartialSig(&PartialSig{PubKey: pubKey, Signature: sig})
+	return nil
+}
+
+// AddBip32Derivation adds the derivation of a public key to the input
+// unless it is already known.
+func (in *Input) AddBip32Derivation(d *Bip32Derivation) {
+	in.Bip32Derivation = addBip32Derivation(in.Bip32Derivation, d)
+}
+
+// Output holds the information about an output of the unsigned transaction
+// needed by signers to identify change.
+type Output struct {
+	RedeemScript    []byte
+	WitnessScript   []byte
+	Bip32Derivation []*Bip32Derivation
+	Unknowns        []*Unknown
+}
+
+// AddBip32Derivation adds the derivation of a public key to the output
+// unless it is already known.
+func (out *Output) AddBip32Derivation(d *Bip32Derivation) {
+	out.Bip32Derivation = addBip32Derivation(out.Bip32Derivation, d)
+}
+
+func addBip32Derivation(ds []*Bip32Derivation, d *Bip32Derivation) []*Bip32Derivation {
+	for _, e := range ds {
+		if bytes.Equal(e.PubKey, d.PubKey) {
+			return ds
+		}
+	}
+	return append(ds, d)
+}
+
+// Packet is a partially signed transaction.  The unsigned transaction must
+// not contain any signature scripts or witnesses, and there is one input and
+// output map for each of its inputs and outputs.
+type Packet struct {
+	UnsignedTx *wire.MsgTx
+	Inputs     []Input
+	Outputs    []Output
+	Unknowns   []*Unknown
+}
+
+// New returns a packet for the passed unsigned transaction with empty input
+// and output maps.
+func New(tx *wire.MsgTx) (*Packet, error) {
+	for _, txIn := range tx.TxIn {
+		if len(txIn.SignatureScript) != 0 || len(txIn.Witness) != 0 {
+			return nil, ErrSignedUnsignedTx
+		}
+	}
+	return &Packet{
+		UnsignedTx: tx,
+		Inputs:     make([]Input, len(tx.TxIn)),
+		Outputs:    make([]Output, len(tx.TxOut)),
+	}, nil
+}
+
+// PrevOutput returns the output spent by input idx using the UTXO data of
+// the input.  It returns nil when the input carries no UTXO data.  An input
+// carrying both a witness and a non-witness UTXO must spend the same output
+// with either.
+func (p *Packet) PrevOutput(idx int) (*wire.TxOut, error) {
+	in := &p.Inputs[idx]
+	if in.NonWitnessUtxo == nil {
+		return in.WitnessUtxo, nil
+	}
+	prevOut := &p.UnsignedTx.TxIn[idx].PreviousOutPoint
+	if in.NonWitnessUtxo.TxHash() != prevOut.Hash {
+		return nil, fmt.Errorf("non-witness UTXO of input %d does not "+
+			"match outpoint %v", idx, prevOut)
+	}
+	if prevOut.Index >= uint32(len(in.NonWitnessUtxo.TxOut)) {
+		return nil, fmt.Errorf("input %d spends missing output %v",
+			idx, prevOut)
+	}
+	txOut := in.NonWitnessUtxo.TxOut[prevOut.Index]
+	if in.WitnessUtxo != nil && (in.WitnessUtxo.Value != txOut.Value ||
+		!bytes.Equal(in.WitnessUtxo.PkScript, txOut.PkScript)) {
+
+		return nil, fmt.Errorf("witness UTXO of input %d does not "+
+			"match its non-witness UTXO", idx)
+	}
+	return txOut, nil
+}
+
+// Decode parses a base64 encoded packet.
+func Decode(s string) (*Packet, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(bytes.NewReader(b))
+}
+
+// Encode returns the base64 encoding of the packet.
+func (p *Packet) Encode() (string, error) {
+	var buf bytes.Buffer
+	if err := p.Serialize(&buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// readPair reads a key-value pair, returning a nil key for the separator
+// ending a map.
+func readPair(r io.Reader) (key, value []byte, err error) {
+	key, err = wire.ReadVarBytes(r, 0, maxPsbtValueLength, "psbt key")
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(key) == 0 {
+		return nil, nil, nil
+	}
+	value, err = wire.ReadVarBytes(r, 0, maxPsbtValueLength, "psbt value")
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, value, nil
+}
+
+func writePair(w io.Writer, keyType byte, keyData, value []byte) error {
+	key := append([]byte{keyType}, keyData...)
+	if err := wire.WriteVarBytes(w, 0, key); err != nil {
+		return err
+	}
+	return wire.WriteVarBytes(w, 0, value)
+}
+
+func writeSeparator(w io.Writer) error {
+	_, err := w.Write([]byte{0x00})
+	return err
+}
+
+func writeUnknowns(w io.Writer, unknowns []*Unknown) error {
+	for _, u := range unknowns {
+		if err := wire.WriteVarBytes(w, 0, u.Key); err != nil {
+			return err
+		}
+		if err := wire.WriteVarBytes(w, 0, u.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse reads a serialized packet.
+func Parse(r io.Reader) (*Packet, error) {
+	var m [5]byte
+	if _, err := io.ReadFull(r, m[:]); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(m[:], magic) {
+		return nil, ErrInvalidMagic
+	}
+
+	p := new(Packet)
+	seen := make(map[string]struct{})
+	for {
+		key, value, err := readPair(r)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			break
+		}
+		if _, ok := seen[string(key)]; ok {
+			return nil, ErrDuplicateKey
+		}
+		seen[string(key)] = struct{}{}
+
+		switch key[0] {
+		case globalUnsignedTxType:
+			if len(key) != 1 {
+				return nil, ErrInvalidKey
+			}
+			tx := new(wire.MsgTx)
+			err := tx.DeserializeNoWitness(bytes.NewReader(value))
+			if err != nil {
+				return nil, err
+			}
+			p.UnsignedTx = tx
+		default:
+			p.Unknowns = append(p.Unknowns, &Unknown{key, value})
+		}
+	}
+	if p.UnsignedTx == nil {
+		return nil, ErrMissingUnsignedTx
+	}
+	for _, txIn := range p.UnsignedTx.TxIn {
+		if len(txIn.SignatureScript) != 0 {
+			return nil, ErrSignedUnsignedTx
+		}
+	}
+
+	p.Inputs = make([]Input, len(p.UnsignedTx.TxIn))
+	for i := range p.Inputs {
+		if err := p.Inputs[i].parse(r); err != nil {
+			return nil, err
+		}
+	}
+	p.Outputs = make([]Output, len(p.UnsignedTx.TxOut))
+	for i := range p.Outputs {
+		if err := p.Outputs[i].parse(r); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// parseBip32Derivation parses the key data and value of a derivation pair.
+func parseBip32Derivation(pubKey, value []byte) (*Bip32Derivation, error) {
+	if _, err := btcec.ParsePubKey(pubKey, btcec.S256()); err != nil {
+		return nil, ErrInvalidKey
+	}
+	if len(value) < 4 || len(value)%4 != 0 {
+		return nil, ErrInvalidValue
+	}
+	d := &Bip32Derivation{
+		PubKey:               pubKey,
+		MasterKeyFingerprint: binary.LittleEndian.Uint32(value),
+	}
+	for i := 4; i < len(value); i += 4 {
+		d.Path = append(d.Path, binary.LittleEndian.Uint32(value[i:]))
+	}
+	return d, nil
+}
+
+func serializeBip32Derivation(d *Bip32Derivation) []byte {
+	value := make([]byte, 4+4*len(d.Path))
+	binary.LittleEndian.PutUint32(value, d.MasterKeyFingerprint)
+	for i, child := range d.Path {
+		binary.LittleEndian.PutUint32(value[4+4*i:], child)
+	}
+	return value
+}
+
+func parseTxOut(value []byte) (*wire.TxOut, error) {
+	if len(value) < 9 {
+		return nil, ErrInvalidValue
+	}
+	r := bytes.NewReader(value[8:])
+	pkScript, err := wire.ReadVarBytes(r, 0, maxPsbtValueLength, "pkScript")
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, ErrInvalidValue
+	}
+	amount := int64(binary.LittleEndian.Uint64(value))
+	return wire.NewTxOut(amount, pkScript), nil
+}
+
+func serializeTxOut(txOut *wire.TxOut) ([]byte, error) {
+	var buf bytes.Buffer
+	var amount [8]byte
+	binary.LittleEndian.PutUint64(amount[:], uint64(txOut.Value))
+	buf.Write(amount[:])
+	err := wire.WriteVarBytes(&buf, 0, txOut.PkScript)
+	return buf.Bytes(), err
+}
+
+func parseWitness(value []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(value)
+	n, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(value)) {
+		return nil, ErrInvalidValue
+	}
+	witness := make(wire.TxWitness, n)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(r, 0, maxPsbtValueLength,
+			"witness item")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.Len() != 0 {
+		return nil, ErrInvalidValue
+	}
+	return witness, nil
+}
+
+func serializeWitness(witness wire.TxWitness) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(witness))); err != nil {
+		return nil, err
+	}
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(&buf, 0, item); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (in *Input) parse(r io.Reader) error {
+	seen := make(map[string]struct{})
+	for {
+		key, value, err := readPair(r)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			break
+		}
+		if _, ok := seen[string(key)]; ok {
+			return ErrDuplicateKey
+		}
+		seen[string(key)] = struct{}{}
+
+		keyData := key[1:]
+		switch key[0] {
+		case inputNonWitnessUtxoType:
+			if len(keyData) != 0 {
+				return ErrInvalidKey
+			}
+			tx := new(wire.MsgTx)
+			if err := tx.Deserialize(bytes.NewReader(value)); err != nil {
+				return err
+			}
+			in.NonWitnessUtxo = tx
+		case inputWitnessUtxoType:
+			if len(keyData) != 0 {
+				return ErrInvalidKey
+			}
+			txOut, err := parseTxOut(value)
+			if err != nil {
+				return err
+			}
+			in.WitnessUtxo = txOut
+		case inputPartialSigType:
+			if _, err := btcec.ParsePubKey(keyData, btcec.S256()); err != nil {
+				return ErrInvalidKey
+			}
+			in.PartialSigs = append(in.PartialSigs, &PartialSig{
+				PubKey:    keyData,
+				Signature: value,
+			})
+		case inputSighashType:
+			if len(keyData) != 0 || len(value) != 4 {
+				return ErrInvalidValue
+			}
+			in.SighashType = txscript.SigHashType(
+				binary.LittleEndian.Uint32(value))
+		case inputRedeemScriptType:
+			if len(keyData) != 0 {
+				return ErrInvalidKey
+			}
+			in.RedeemScript = value
+		case inputWitnessScriptType:
+			if len(keyData) != 0 {
+				return ErrInvalidKey
+			}
+			in.WitnessScript = value
+		case inputBip32DerivationType:
+			d, err := parseBip32Derivation(keyData, value)
+			if err != nil {
+				return err
+			}
+			in.Bip32Derivation = append(in.Bip32Derivation, d)
+		case inputFinalScriptSigType:
+			if len(keyData) != 0 {
+				return ErrInvalidKey
+			}
+			in.FinalScriptSig = value
+		case inputFinalScriptWitnessType:
+			if len(keyData) != 0 {
+				return ErrInvalidKey
+			}
+			witness, err := parseWitness(value)
+			if err != nil {
+				return err
+			}
+			in.FinalScriptWitness = witness
+		default:
+			in.Unknowns = append(in.Unknowns, &Unknown{key, value})
+		}
+	}
+	return nil
+}
+
+func (out *Output) parse(r io.Reader) error {
+	seen := make(map[string]struct{})
+	for {
+		key, value, err := readPair(r)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			break
+		}
+		if _, ok := seen[string(key)]; ok {
+			return ErrDuplicateKey
+		}
+		seen[string(key)] = struct{}{}
+
+		keyData := key[1:]
+		switch key[0] {
+		case outputRedeemScriptType:
+			if len(keyData) != 0 {
+				return ErrInvalidKey
+			}
+			out.RedeemScript = value
+		case outputWitnessScriptType:
+			if len(keyData) != 0 {
+				return ErrInvalidKey
+			}
+			out.WitnessScript = value
+		case outputBip32DerivationType:
+			d, err := parseBip32Derivation(keyData, value)
+			if err != nil {
+				return err
+			}
+			out.Bip32Derivation = append(out.Bip32Derivation, d)
+		default:
+			out.Unknowns = append(out.Unknowns, &Unknown{key, value})
+		}
+	}
+	return nil
+}
+
+// Serialize writes the packet in the BIP0174 binary format.
+func (p *Packet) Serialize(w io.Writer) error {
+	if p.UnsignedTx == nil {
+		return ErrMissingUnsignedTx
+	}
+	if len(p.Inputs) != len(p.UnsignedTx.TxIn) {
+		return ErrInputCount
+	}
+	if len(p.Outputs) != len(p.UnsignedTx.TxOut) {
+		return ErrOutputCount
+	}
+
+	if _, err := w.Write(magic); err != nil {
+		return err
+	}
+	var txBuf bytes.Buffer
+	if err := p.UnsignedTx.SerializeNoWitness(&txBuf); err != nil {
+		return err
+	}
+	if err := writePair(w, globalUnsignedTxType, nil, txBuf.Bytes()); err != nil {
+		return err
+	}
+	if err := writeUnknowns(w, p.Unknowns); err != nil {
+		return err
+	}
+	if err := writeSeparator(w); err != nil {
+		return err
+	}
+
+	for i := range p.Inputs {
+		if err := p.Inputs[i].serialize(w); err != nil {
+			return err
+		}
+	}
+	for i := range p.Outputs {
+		if err := p.Outputs[i].serialize(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedDerivations returns the derivations ordered by public key so that
+// serialization is deterministic.
+func sortedDerivations(ds []*Bip32Derivation) []*Bip32Derivation {
+	sorted := append([]*Bip32Derivation(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].PubKey, sorted[j].PubKey) < 0
+	})
+	return sorted
+}
+
+func (in *Input) serialize(w io.Writer) error {
+	if in.NonWitnessUtxo != nil {
+		var buf bytes.Buffer
+		if err := in.NonWitnessUtxo.Serialize(&buf); err != nil {
+			return err
+		}
+		err := writePair(w, inputNonWitnessUtxoType, nil, buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+	if in.WitnessUtxo != nil {
+		value, err := serializeTxOut(in.WitnessUtxo)
+		if err != nil {
+			return err
+		}
+		if err := writePair(w, inputWitnessUtxoType, nil, value); err != nil {
+			return err
+		}
+	}
+
+	// Finalized inputs only carry the UTXO data and final scripts.
+	if !in.IsFinalized() {
+		sigs := append([]*PartialSig(nil), in.PartialSigs...)
+		sort.Slice(sigs, func(i, j int) bool {
+			return bytes.Compare(sigs[i].PubKey, sigs[j].PubKey) < 0
+		})
+		for _, sig := range sigs {
+			err := writePair(w, inputPartialSigType, sig.PubKey,
+				sig.Signature)
+			if err != nil {
+				return err
+			}
+		}
+		if in.SighashType != 0 {
+			var value [4]byte
+			binary.LittleEndian.PutUint32(value[:], uint32(in.SighashType))
+			err := writePair(w, inputSighashType, nil, value[:])
+			if err != nil {
+				return err
+			}
+		}
+		if in.RedeemScript != nil {
+			err := writePair(w, inputRedeemScriptType, nil, in.RedeemScript)
+			if err != nil {
+				return err
+			}
+		}
+		if in.WitnessScript != nil {
+			err := writePair(w, inputWitnessScriptType, nil, in.WitnessScript)
+			if err != nil {
+				return err
+			}
+		}
+		for _, d := range sortedDerivations(in.Bip32Derivation) {
+			err := writePair(w, inputBip32DerivationType, d.PubKey,
+				serializeBip32Derivation(d))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if in.FinalScriptSig != nil {
+		err := writePair(w, inputFinalScriptSigType, nil, in.FinalScriptSig)
+		if err != nil {
+			return err
+		}
+	}
+	if in.FinalScriptWitness != nil {
+		value, err := serializeWitness(in.FinalScriptWitness)
+		if err != nil {
+			return err
+		}
+		err = writePair(w, inputFinalScriptWitnessType, nil, value)
+		if err != nil {
+			return err
+		}
+	}
+	if err := writeUnknowns(w, in.Unknowns); err != nil {
+		return err
+	}
+	return writeSeparator(w)
+}
+
+func (out *Output) serialize(w io.Writer) error {
+	if out.RedeemScript != nil {
+		err := writePair(w, outputRedeemScriptType, nil, out.RedeemScript)
+		if err != nil {
+			return err
+		}
+	}
+	if out.WitnessScript != nil {
+		err := writePair(w, outputWitnessScriptType, nil, out.WitnessScript)
+		if err != nil {
+			return err
+		}
+	}
+	for _, d := range sortedDerivations(out.Bip32Derivation) {
+		err := writePair(w, outputBip32DerivationType, d.PubKey,
+			serializeBip32Derivation(d))
+		if err != nil {
+			return err
+		}
+	}
+	if err := writeUnknowns(w, out.Unknowns); err != nil {
+		return err
+	}
+	return writeSeparator(w)
+}
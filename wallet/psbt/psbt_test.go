@@ -0,0 +1,304 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+func newKey(b byte) *btcec.PrivateKey {
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(),
+		bytes.Repeat([]byte{b}, 32))
+	return privKey
+}
+
+// spendingTx returns a transaction spending output 0 of prevTx.
+func spendingTx(prevTx *wire.MsgTx) *wire.MsgTx {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	prevHash := prevTx.TxHash()
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(90000, []byte{txscript.OP_TRUE}))
+	return tx
+}
+
+// fundingTx returns a transaction paying 100000 satoshis to pkScript.
+func fundingTx(pkScript []byte) *wire.MsgTx {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(100000, pkScript))
+	return tx
+}
+
+func checkSpend(t *testing.T, tx *wire.MsgTx, prevOut *wire.TxOut) {
+	vm, err := txscript.NewEngine(prevOut.PkScript, tx, 0,
+		txscript.StandardVerifyFlags, nil, txscript.NewTxSigHashes(tx),
+		prevOut.Value)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	if err := vm.Execute(); err != nil {
+		t.Fatalf("extracted transaction does not validate: %v", err)
+	}
+}
+
+// TestRoundTrip checks that a packet with every supported field survives
+// encoding and decoding unchanged.
+func TestRoundTrip(t *testing.T) {
+	key := newKey(1)
+	pubKey := key.PubKey().SerializeCompressed()
+	prevTx := fundingTx([]byte{txscript.OP_TRUE})
+
+	p, err := New(spendingTx(prevTx))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	p.Inputs[0].NonWitnessUtxo = prevTx
+	p.Inputs[0].WitnessUtxo = prevTx.TxOut[0]
+	p.Inputs[0].SighashType = txscript.SigHashAll
+	p.Inputs[0].RedeemScript = []byte{txscript.OP_1}
+	p.Inputs[0].WitnessScript = []byte{txscript.OP_2}
+	if err := p.Inputs[0].AddPartialSig(pubKey, []byte{0x30, 0x01}); err != nil {
+		t.Fatalf("AddPartialSig: %v", err)
+	}
+	derivation := &Bip32Derivation{
+		PubKey:               pubKey,
+		MasterKeyFingerprint: 0xdeadbeef,
+		Path:                 []uint32{0x8000002c, 0x80000000, 0x80000000, 1, 7},
+	}
+	p.Inputs[0].AddBip32Derivation(derivation)
+	p.Outputs[0].AddBip32Derivation(derivation)
+	p.Unknowns = []*Unknown{{Key: []byte{0xfc, 0x01}, Value: []byte{0x02}}}
+
+	encoded, err := p.Encode()
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	decoded, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	reencoded, err := decoded.Encode()
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if reencoded != encoded {
+		t.Fatalf("re-encoded packet differs:\n%s\n%s", reencoded, encoded)
+	}
+
+	in := &decoded.Inputs[0]
+	if in.NonWitnessUtxo.TxHash() != prevTx.TxHash() {
+		t.Errorf("non-witness UTXO mismatch")
+	}
+	if in.WitnessUtxo.Value != 100000 {
+		t.Errorf("witness UTXO value %d, want 100000", in.WitnessUtxo.Value)
+	}
+	if in.SighashType != txscript.SigHashAll {
+		t.Errorf("sighash type %v, want %v", in.SighashType,
+			txscript.SigHashAll)
+	}
+	if len(in.PartialSigs) != 1 || !bytes.Equal(in.PartialSigs[0].PubKey, pubKey) {
+		t.Errorf("partial signature mismatch")
+	}
+	d := decoded.Outputs[0].Bip32Derivation
+	if len(d) != 1 || d[0].MasterKeyFingerprint != 0xdeadbeef ||
+		len(d[0].Path) != 5 || d[0].Path[4] != 7 {
+		t.Errorf("output derivation mismatch: %+v", d)
+	}
+	if len(decoded.Unknowns) != 1 {
+		t.Errorf("unknown global pair was dropped")
+	}
+}
+
+// TestDecodeErrors checks that malformed packets are rejected.
+func TestDecodeErrors(t *testing.T) {
+	prevTx := fundingTx([]byte{txscript.OP_TRUE})
+	p, err := New(spendingTx(prevTx))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := p.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	valid := buf.Bytes()
+
+	badMagic := append([]byte{0x70, 0x73, 0x62, 0x74, 0x00}, valid[5:]...)
+	if _, err := Parse(bytes.NewReader(badMagic)); err != ErrInvalidMagic {
+		t.Errorf("bad magic: got %v, want %v", err, ErrInvalidMagic)
+	}
+	if _, err := Parse(bytes.NewReader(valid[:len(valid)-1])); err == nil {
+		t.Errorf("truncated packet was accepted")
+	}
+
+	signed := spendingTx(prevTx)
+	signed.TxIn[0].SignatureScript = []byte{txscript.OP_TRUE}
+	if _, err := New(signed); err != ErrSignedUnsignedTx {
+		t.Errorf("signed transaction: got %v, want %v", err,
+			ErrSignedUnsignedTx)
+	}
+}
+
+// TestPrevOutput checks that the output spent by an input is taken from its
+// UTXO data, which must be consistent.
+func TestPrevOutput(t *testing.T) {
+	prevTx := fundingTx([]byte{txscript.OP_TRUE})
+	p, err := New(spendingTx(prevTx))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if prevOut, err := p.PrevOutput(0); prevOut != nil || err != nil {
+		t.Errorf("input without UTXO data: got %v, %v", prevOut, err)
+	}
+
+	p.Inputs[0].NonWitnessUtxo = prevTx
+	p.Inputs[0].WitnessUtxo = wire.NewTxOut(100000,
+		[]byte{txscript.OP_TRUE})
+	prevOut, err := p.PrevOutput(0)
+	if err != nil {
+		t.Fatalf("PrevOutput: %v", err)
+	}
+	if prevOut.Value != 100000 {
+		t.Errorf("spent output value %v, want 100000", prevOut.Value)
+	}
+
+	// The witness UTXO may not contradict the non-witness UTXO.
+	p.Inputs[0].WitnessUtxo = wire.NewTxOut(1e8, []byte{txscript.OP_TRUE})
+	if _, err := p.PrevOutput(0); err == nil {
+		t.Errorf("mismatched witness UTXO value was accepted")
+	}
+	p.Inputs[0].WitnessUtxo = wire.NewTxOut(100000, []byte{txscript.OP_2})
+	if _, err := p.PrevOutput(0); err == nil {
+		t.Errorf("mismatched witness UTXO script was accepted")
+	}
+
+	p.Inputs[0].NonWitnessUtxo = fundingTx([]byte{txscript.OP_FALSE})
+	p.Inputs[0].WitnessUtxo = nil
+	if _, err := p.PrevOutput(0); err == nil {
+		t.Errorf("non-witness UTXO of another transaction was accepted")
+	}
+}
+
+// TestFinalizeWitnessMultisig checks that a 2-of-3 P2WSH input signed by two
+// signers in a different order than their keys is finalized and extracted
+// into a valid transaction.
+func TestFinalizeWitnessMultisig(t *testing.T) {
+	keys := []*btcec.PrivateKey{newKey(1), newKey(2), newKey(3)}
+	builder := txscript.NewScriptBuilder().AddOp(txscript.OP_2)
+	for _, key := range keys {
+		builder.AddData(key.PubKey().SerializeCompressed())
+	}
+	witnessScript, err := builder.AddOp(txscript.OP_3).
+		AddOp(txscript.OP_CHECKMULTISIG).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptHash := sha256.Sum256(witnessScript)
+	addr, err := btcutil.NewAddressWitnessScriptHash(scriptHash[:],
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevTx := fundingTx(pkScript)
+
+	p, err := New(spendingTx(prevTx))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	p.Inputs[0].WitnessUtxo = prevTx.TxOut[0]
+	p.Inputs[0].WitnessScript = witnessScript
+
+	hashCache := txscript.NewTxSigHashes(p.UnsignedTx)
+	sign := func(key *btcec.PrivateKey) {
+		sig, err := txscript.RawTxInWitnessSignature(p.UnsignedTx,
+			hashCache, 0, prevTx.TxOut[0].Value, witnessScript,
+			txscript.SigHashAll, key)
+		if err != nil {
+			t.Fatalf("RawTxInWitnessSignature: %v", err)
+		}
+		pubKey := key.PubKey().SerializeCompressed()
+		if err := p.Inputs[0].AddPartialSig(pubKey, sig); err != nil {
+			t.Fatalf("AddPartialSig: %v", err)
+		}
+	}
+
+	sign(keys[2])
+	if err := p.Finalize(0); err == nil {
+		t.Fatalf("input with one of two signatures was finalized")
+	}
+	p.FinalizeAll()
+	if p.IsComplete() {
+		t.Fatalf("packet with missing signatures is complete")
+	}
+	if _, err := p.Extract(); err != ErrNotFinalized {
+		t.Fatalf("Extract: got %v, want %v", err, ErrNotFinalized)
+	}
+
+	sign(keys[0])
+	p.FinalizeAll()
+	if !p.IsComplete() {
+		t.Fatalf("packet with all signatures is not complete")
+	}
+	if p.Inputs[0].PartialSigs != nil || p.Inputs[0].WitnessScript != nil {
+		t.Errorf("signing fields of finalized input were not cleared")
+	}
+	tx, err := p.Extract()
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	checkSpend(t, tx, prevTx.TxOut[0])
+}
+
+// TestFinalizePubKeyHash checks finalizing a P2PKH input using the
+// non-witness UTXO of the spent output.
+func TestFinalizePubKeyHash(t *testing.T) {
+	key := newKey(4)
+	pubKey := key.PubKey().SerializeCompressed()
+	addr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKey),
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevTx := fundingTx(pkScript)
+
+	p, err := New(spendingTx(prevTx))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	p.Inputs[0].NonWitnessUtxo = prevTx
+	sig, err := txscript.RawTxInSignature(p.UnsignedTx, 0, pkScript,
+		txscript.SigHashAll, key)
+	if err != nil {
+		t.Fatalf("RawTxInSignature: %v", err)
+	}
+	if err := p.Inputs[0].AddPartialSig(pubKey, sig); err != nil {
+		t.Fatalf("AddPartialSig: %v", err)
+	}
+
+	if err := p.Finalize(0); err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	tx, err := p.Extract()
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	checkSpend(t, tx, prevTx.TxOut[0])
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/psbt"
+)
+
+// TestProcessPsbtChecks checks that PSBT inputs are not signed with another
+// sighash type than they specify, or for UTXO data contradicting the outputs
+// known to the wallet.
+func TestProcessPsbtChecks(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outPoint := fundTestWallet(t, w, pkScript, 1e8)
+
+	// The funded output is unlocked again, so that every packet spends
+	// it.
+	outputs := []*wire.TxOut{wire.NewTxOut(1e7, pkScript)}
+	fundPsbt := func() *psbt.Packet {
+		packet, _, _, err := w.FundPsbt(outputs, 0, 0, 1e4, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.UnlockOutpoint(outPoint)
+		return packet
+	}
+
+	packet := fundPsbt()
+	packet.Inputs[0].SighashType = txscript.SigHashSingle
+	if _, err := w.ProcessPsbt(packet, true, txscript.SigHashAll); err == nil {
+		t.Errorf("input was signed with another sighash type")
+	}
+
+	packet = fundPsbt()
+	packet.Inputs[0].WitnessUtxo = wire.NewTxOut(1e9, pkScript)
+	if _, err := w.ProcessPsbt(packet, true, txscript.SigHashAll); err == nil {
+		t.Errorf("input was signed for a forged amount")
+	}
+
+	packet = fundPsbt()
+	packet.Inputs[0].SighashType = txscript.SigHashAll
+	complete, err := w.ProcessPsbt(packet, true, txscript.SigHashAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !complete {
+		t.Errorf("signed packet is not complete")
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/wallet/internal/txsizes"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// quarantineBucketKey is the key of the bucket in the transaction metadata
+// namespace holding the quarantined outputs.
+var quarantineBucketKey = []byte("quarantine")
+
+// DefaultDustThreshold is the default amount at or below which received
+// outputs are quarantined.
+const DefaultDustThreshold = btcutil.Amount(1000)
+
+// burnMarker is the data of the OP_RETURN output of transactions burning
+// quarantined outputs.
+var burnMarker = []byte("dust burn")
+
+// Quarantined outputs are serialized as such:
+//
+//   [0:8]  Time quarantined, as unix seconds (8 bytes)
+//
+// The key is the serialized outpoint, as for locked outpoints.
+
+// dustQuarantine holds the quarantine policy of received dust.
+type dustQuarantine struct {
+	mu        sync.Mutex
+	threshold btcutil.Amount
+	spendable bool
+}
+
+// QuarantinedOutput describes an unspent output quarantined as dust, which
+// is not spent by coin selection.
+type QuarantinedOutput struct {
+	OutPoint    wire.OutPoint
+	Account     uint32
+	Address     btcutil.Address
+	Amount      btcutil.Amount
+	Token       wire.TokenIdentity
+	Confirmed   bool
+	Quarantined time.Time
+}
+
+// SetDustQuarantine configures the quarantine of received dust.  Outputs
+// worth at most threshold received by transactions not spending wallet
+// outputs, as sent by address tracking attacks, are quarantined, and are not
+// spent by coin selection unless spendable is set.  A zero threshold disables
+// the quarantine of new outputs.
+func (w *Wallet) SetDustQuarantine(threshold btcutil.Amount, spendable bool) {
+	w.dustQuarantine.mu.Lock()
+	w.dustQuarantine.threshold = threshold
+	w.dustQuarantine.spendable = spendable
+	w.dustQuarantine.mu.Unlock()
+}
+
+// DustQuarantine returns the threshold at or below which received outputs are
+// quarantined, and whether coin selection spends quarantined outputs.
+func (w *Wallet) DustQuarantine() (btcutil.Amount, bool) {
+	w.dustQuarantine.mu.Lock()
+	defer w.dustQuarantine.mu.Unlock()
+	return w.dustQuarantine.threshold, w.dustQuarantine.spendable
+}
+
+// quarantineSpendable returns whether coin selection spends quarantined
+// outputs.
+func (w *Wallet) quarantineSpendable() bool {
+	w.dustQuarantine.mu.Lock()
+	defer w.dustQuarantine.mu.Unlock()
+	return w.dustQuarantine.spendable
+}
+
+// isQuarantined returns whether an output is quarantined.
+func isQuarantined(dbtx walletdb.ReadTx, op *wire.OutPoint) bool {
+	bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).NestedReadBucket(
+		quarantineBucketKey)
+	return bucket != nil && bucket.Get(outpointKey(op)) != nil
+}
+
+// quarantineDust quarantines the credited outputs of a received transaction
+// worth at most the dust threshold.  Transactions spending wallet outputs
+// are not checked, since their credits are change or payments to self.
+func (w *Wallet) quarantineDust(dbtx walletdb.ReadWriteTx, rec *wtxmgr.TxRecord,
+	credited []uint32, spendsWallet bool) error {
+
+	w.dustQuarantine.mu.Lock()
+	threshold := w.dustQuarantine.threshold
+	w.dustQuarantine.mu.Unlock()
+	if threshold <= 0 || spendsWallet || len(credited) == 0 {
+		return nil
+	}
+
+	var bucket walletdb.ReadWriteBucket
+	for _, idx := range credited {
+		if btcutil.Amount(rec.MsgTx.TxOut[idx].Value) > threshold {
+			continue
+		}
+		op := wire.OutPoint{Hash: rec.Hash, Index: idx}
+		if bucket == nil {
+			var err error
+			bucket, err = dbtx.ReadWriteBucket(wtxmetaNamespaceKey).
+				CreateBucketIfNotExists(quarantineBucketKey)
+			if err != nil {
+				return err
+			}
+		}
+		k := outpointKey(&op)
+		if bucket.Get(k) != nil {
+			continue
+		}
+		var v [8]byte
+		binary.BigEndian.PutUint64(v[:], uint64(rec.Received.Unix()))
+		if err := bucket.Put(k, v[:]); err != nil {
+			return err
+		}
+		log.Warnf("Quarantined dust output %v of %v", op,
+			btcutil.Amount(rec.MsgTx.TxOut[idx].Value))
+	}
+	return nil
+}
+
+// QuarantinedOutputs returns the unspent quarantined outputs, the oldest
+// first.
+func (w *Wallet) QuarantinedOutputs() ([]QuarantinedOutput, error) {
+	var outputs []QuarantinedOutput
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+		bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).NestedReadBucket(
+			quarantineBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			if len(k) != 36 || len(v) != 8 {
+				return fmt.Errorf("quarantined output %x is malformed",
+					k)
+			}
+			var op wire.OutPoint
+			copy(op.Hash[:], k)
+			op.Index = binary.BigEndian.Uint32(k[32:])
+			credit, err := w.unspentCredit(txmgrNs, &op)
+			if err != nil || credit == nil {
+				return err
+			}
+			q := QuarantinedOutput{
+				OutPoint:  op,
+				Amount:    credit.Amount,
+				Token:     wire.TokenID(credit.PkScript),
+				Confirmed: credit.Height != -1,
+				Quarantined: time.Unix(
+					int64(binary.BigEndian.Uint64(v)), 0),
+			}
+			_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+				credit.PkScript, w.chainParams)
+			if err == nil && len(addrs) == 1 {
+				q.Address = addrs[0]
+				q.Account, _ = w.addrAccount(dbtx, addrs[0])
+			}
+			outputs = append(outputs, q)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(outputs, func(i, j int) bool {
+		return outputs[i].Quarantined.Before(outputs[j].Quarantined)
+	})
+	return outputs, nil
+}
+
+// unspentCredit returns the wallet credit of an unspent output, or nil when
+// the output is spent or not a wallet credit.
+func (w *Wallet) unspentCredit(txmgrNs walletdb.ReadBucket,
+	op *wire.OutPoint) (*wtxmgr.Credit, error) {
+
+	details, err := w.TxStore.TxDetails(txmgrNs, &op.Hash)
+	if err != nil || details == nil {
+		return nil, err
+	}
+	for _, c := range details.Credits {
+		if c.Index != op.Index || c.Spent {
+			continue
+		}
+		return &wtxmgr.Credit{
+			OutPoint:  *op,
+			BlockMeta: details.Block,
+			Amount:    c.Amount,
+			PkScript:  details.MsgTx.TxOut[op.Index].PkScript,
+			Received:  details.Received,
+		}, nil
+	}
+	return nil, nil
+}
+
+// SpendQuarantined spends quarantined outputs of the same token.  With a
+// destination, their value less the fee at feeSatPerKb is paid to it.
+// Without one, they are provably burned: the transaction only has an
+// unspendable OP_RETURN output, and their whole value, which must be at least
+// the fee at feeSatPerKb, is paid as fee.  The spent outputs are removed from
+// the quarantine.
+func (w *Wallet) SpendQuarantined(ops []wire.OutPoint, dest btcutil.Address,
+	feeSatPerKb btcutil.Amount) (*chainhash.Hash, btcutil.Amount, error) {
+
+	if len(ops) == 0 {
+		return nil, 0, errors.New("no quarantined outputs to spend")
+	}
+
+	var credits []wtxmgr.Credit
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+		for i := range ops {
+			if !isQuarantined(dbtx, &ops[i]) {
+				return fmt.Errorf("output %v is not quarantined",
+					ops[i])
+			}
+			credit, err := w.unspentCredit(txmgrNs, &ops[i])
+			if err != nil {
+				return err
+			}
+			if credit == nil {
+				return fmt.Errorf("quarantined output %v is "+
+					"spent", ops[i])
+			}
+			if len(credits) != 0 && wire.TokenID(credit.PkScript) !=
+				wire.TokenID(credits[0].PkScript) {
+
+				return errors.New("quarantined outputs of " +
+					"different tokens cannot be spent together")
+			}
+			credits = append(credits, *credit)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tx, fee, err := quarantineSpendTx(credits, dest, feeSatPerKb)
+	if err != nil {
+		return nil, 0, err
+	}
+	prevScripts := make([][]byte, len(credits))
+	inputValues := make([]btcutil.Amount, len(credits))
+	for i := range credits {
+		prevScripts[i] = credits[i].PkScript
+		inputValues[i] = credits[i].Amount
+	}
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+		return txauthor.AddAllInputScripts(tx, prevScripts, inputValues,
+			secretSource{w.Manager, addrmgrNs, scriptNs, w})
+	})
+	if err == nil {
+		err = validateMsgTx(tx, prevScripts, inputValues)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hash, err := w.publishTransaction(tx)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		bucket := dbtx.ReadWriteBucket(wtxmetaNamespaceKey).
+			NestedReadWriteBucket(quarantineBucketKey)
+		for i := range ops {
+			if err := bucket.Delete(outpointKey(&ops[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Cannot remove spent outputs from the quarantine: %v",
+			err)
+	}
+	return hash, fee, nil
+}
+
+// quarantineSpendTx returns the unsigned transaction spending quarantined
+// credits to dest, or burning them when dest is nil, and its fee.
+func quarantineSpendTx(credits []wtxmgr.Credit, dest btcutil.Address,
+	feeSatPerKb btcutil.Amount) (*wire.MsgTx, btcutil.Amount, error) {
+
+	token := wire.TokenID(credits[0].PkScript)
+	tx := wire.NewMsgTx(wire.TxVersion)
+	var total btcutil.Amount
+	var numP2PKH, numP2WPKH, numNested, numP2TR int
+	for i := range credits {
+		c := &credits[i]
+		switch {
+		case txscript.IsPayToWitnessPubKeyHash(c.PkScript):
+			numP2WPKH++
+		case txscript.IsPayToScriptHash(c.PkScript):
+			numNested++
+		case taproot.IsPayToTaproot(c.PkScript):
+			numP2TR++
+		default:
+			numP2PKH++
+		}
+		op := c.OutPoint
+		tx.AddTxIn(wire.NewTxIn(&op, nil, nil))
+		total += c.Amount
+	}
+
+	if dest == nil {
+		pkScript, err := txscript.NullDataScript(burnMarker)
+		if err != nil {
+			return nil, 0, err
+		}
+		txOut := wire.NewTxOutToken(0, pkScript, token)
+		size := txsizes.EstimateVirtualSize(numP2PKH, numP2WPKH,
+			numNested, numP2TR, []*wire.TxOut{txOut}, false)
+		minFee := txrules.FeeForSerializeSize(feeSatPerKb, size)
+		if total < minFee {
+			return nil, 0, fmt.Errorf("burned amount %v does not "+
+				"cover the fee %v", total, minFee)
+		}
+		tx.AddTxOut(txOut)
+		return tx, total, nil
+	}
+
+	pkScript, err := taproot.PayToAddrScript(dest)
+	if err != nil {
+		return nil, 0, err
+	}
+	size := txsizes.EstimateVirtualSize(numP2PKH, numP2WPKH, numNested,
+		numP2TR, []*wire.TxOut{wire.NewTxOutToken(0, pkScript, token)},
+		false)
+	fee := txrules.FeeForSerializeSize(feeSatPerKb, size)
+	if txrules.IsDustAmount(total-fee, len(pkScript), feeSatPerKb) {
+		return nil, 0, fmt.Errorf("quarantined amount %v does not "+
+			"cover the fee %v", total, fee)
+	}
+	tx.AddTxOut(wire.NewTxOutToken(int64(total-fee), pkScript, token))
+	return tx, fee, nil
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TestQuarantineDust checks that only the dust credits of transactions not
+// spending wallet outputs are quarantined.
+func TestQuarantineDust(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quarantine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+	w.SetDustQuarantine(1000, false)
+
+	record := func(lockTime uint32) *wtxmgr.TxRecord {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.LockTime = lockTime
+		tx.AddTxOut(wire.NewTxOut(546, nil))
+		tx.AddTxOut(wire.NewTxOut(5000, nil))
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+	received, sent := record(1), record(2)
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		err := w.quarantineDust(dbtx, received, []uint32{0, 1}, false)
+		if err != nil {
+			return err
+		}
+		return w.quarantineDust(dbtx, sent, []uint32{0, 1}, true)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = walletdb.View(db, func(dbtx walletdb.ReadTx) error {
+		for _, test := range []struct {
+			op   wire.OutPoint
+			want bool
+		}{
+			{wire.OutPoint{Hash: received.Hash, Index: 0}, true},
+			{wire.OutPoint{Hash: received.Hash, Index: 1}, false},
+			{wire.OutPoint{Hash: sent.Hash, Index: 0}, false},
+		} {
+			if isQuarantined(dbtx, &test.op) != test.want {
+				t.Errorf("output %v quarantined %v, want %v",
+					test.op, !test.want, test.want)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestQuarantineSpendTx checks the transactions spending and burning
+// quarantined outputs.
+func TestQuarantineSpendTx(t *testing.T) {
+	pkScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(make([]byte, 20)).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	credits := []wtxmgr.Credit{
+		{OutPoint: wire.OutPoint{Hash: chainhash.Hash{1}}, Amount: 600,
+			PkScript: pkScript},
+		{OutPoint: wire.OutPoint{Hash: chainhash.Hash{2}}, Amount: 800,
+			PkScript: pkScript},
+	}
+
+	tx, fee, err := quarantineSpendTx(credits, nil, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee != 1400 || len(tx.TxIn) != 2 || len(tx.TxOut) != 1 ||
+		tx.TxOut[0].Value != 0 ||
+		txscript.GetScriptClass(tx.TxOut[0].PkScript) != txscript.NullDataTy {
+
+		t.Errorf("unexpected burn transaction %v with fee %v", tx, fee)
+	}
+	if _, _, err := quarantineSpendTx(credits, nil, 1e5); err == nil {
+		t.Error("outputs not covering the fee were burned")
+	}
+
+	dest, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20),
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, fee, err = quarantineSpendTx(credits, dest, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.TxOut) != 1 || btcutil.Amount(tx.TxOut[0].Value)+fee != 1400 {
+		t.Errorf("unexpected spending transaction %v with fee %v", tx,
+			fee)
+	}
+	if _, _, err := quarantineSpendTx(credits, dest, 5000); err == nil {
+		t.Error("dust output was created")
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+const (
+	// quotaCheckInterval is the interval between two checks of the
+	// account footprints against their quotas.
+	quotaCheckInterval = 10 * time.Minute
+
+	// quotaPruneDepth is the number of confirmations a transaction must
+	// have before it may be pruned to bring an account under its quota,
+	// so that pruned transactions are never rolled back by a reorg.
+	quotaPruneDepth = 1008
+)
+
+// accountQuotas are the limits on the footprint of the transactions of
+// accounts, keyed by account name.
+type accountQuotas struct {
+	mu       sync.Mutex
+	quotas   map[string]int64
+	prune    bool
+	exceeded map[string]bool
+}
+
+// SetAccountQuotas configures the maximum footprint, in bytes, of the
+// transactions of the accounts named in quotas.  The footprints are checked
+// periodically, and an AccountQuotaNotification is sent when an account first
+// exceeds its quota.  With prune, the oldest deeply confirmed transactions of
+// the account which no longer affect its balance are then removed from the
+// wallet until the account is under its quota again.
+func (w *Wallet) SetAccountQuotas(quotas map[string]int64, prune bool) {
+	w.quotas.mu.Lock()
+	defer w.quotas.mu.Unlock()
+
+	w.quotas.quotas = make(map[string]int64, len(quotas))
+	for name, quota := range quotas {
+		w.quotas.quotas[name] = quota
+	}
+	w.quotas.prune = prune
+	w.quotas.exceeded = make(map[string]bool)
+}
+
+// AccountQuotas returns the configured footprint quotas of the accounts,
+// keyed by account name.
+func (w *Wallet) AccountQuotas() map[string]int64 {
+	w.quotas.mu.Lock()
+	defer w.quotas.mu.Unlock()
+
+	quotas := make(map[string]int64, len(w.quotas.quotas))
+	for name, quota := range w.quotas.quotas {
+		quotas[name] = quota
+	}
+	return quotas
+}
+
+// quotaTx describes a wallet transaction counted in account footprints.
+type quotaTx struct {
+	hash  chainhash.Hash
+	block wtxmgr.Block
+	size  int64
+
+	// account is the name of the only account the transaction belongs
+	// to, or empty when it belongs to several accounts.  Only transactions
+	// of a single account are pruned.
+	account string
+}
+
+// AccountFootprints returns the size in bytes of the serialized transactions
+// of every account with wallet transactions, keyed by account name.  A
+// transaction is counted in the footprint of each account it credits or
+// debits.
+func (w *Wallet) AccountFootprints() (map[string]int64, error) {
+	var footprints map[string]int64
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		var err error
+		footprints, _, err = w.accountFootprints(dbtx)
+		return err
+	})
+	return footprints, err
+}
+
+// accountFootprints returns the footprints of the accounts, and every wallet
+// transaction in the order it was mined.
+func (w *Wallet) accountFootprints(dbtx walletdb.ReadTx) (map[string]int64, []quotaTx, error) {
+	txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+
+	footprints := make(map[string]int64)
+	var txs []quotaTx
+	rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
+		for i := range details {
+			d := &details[i]
+			accounts := make(map[string]struct{})
+			for _, cred := range d.Credits {
+				pkScript := d.MsgTx.TxOut[cred.Index].PkScript
+				accounts[w.scriptAccountName(dbtx, pkScript)] = struct{}{}
+			}
+			for _, deb := range d.Debits {
+				prevOut := &d.MsgTx.TxIn[deb.Index].PreviousOutPoint
+				prev, err := w.TxStore.TxDetails(txmgrNs, &prevOut.Hash)
+				if err != nil {
+					return false, err
+				}
+				// The previous transaction may have been pruned.
+				if prev == nil || int(prevOut.Index) >= len(prev.MsgTx.TxOut) {
+					continue
+				}
+				pkScript := prev.MsgTx.TxOut[prevOut.Index].PkScript
+				accounts[w.scriptAccountName(dbtx, pkScript)] = struct{}{}
+			}
+			delete(accounts, unknownLedgerAccount)
+
+			size := int64(len(d.SerializedTx))
+			if d.SerializedTx == nil {
+				size = int64(d.MsgTx.SerializeSize())
+			}
+			tx := quotaTx{hash: d.Hash, block: d.Block.Block, size: size}
+			for name := range accounts {
+				footprints[name] += size
+				if len(accounts) == 1 {
+					tx.account = name
+				}
+			}
+			txs = append(txs, tx)
+		}
+		return false, nil
+	}
+	err := w.TxStore.RangeTransactions(txmgrNs, 0, -1, rangeFn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return footprints, txs, nil
+}
+
+// quotaMonitor periodically checks the account footprints against their
+// quotas.  It must be run as a goroutine.
+func (w *Wallet) quotaMonitor() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(quotaCheckInterval)
+	defer ticker.Stop()
+	quit := w.quitChan()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.checkAccountQuotas(); err != nil {
+				log.Errorf("Cannot check account quotas: %v", err)
+			}
+		case <-quit:
+			return
+		}
+	}
+}
+
+// checkAccountQuotas notifies the accounts exceeding their quotas, after
+// pruning their transactions if configured to.
+func (w *Wallet) checkAccountQuotas() error {
+	w.quotas.mu.Lock()
+	quotas := w.quotas.quotas
+	prune := w.quotas.prune
+	w.quotas.mu.Unlock()
+	if len(quotas) == 0 {
+		return nil
+	}
+
+	var footprints map[string]int64
+	var txs []quotaTx
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		var err error
+		footprints, txs, err = w.accountFootprints(dbtx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if prune {
+		maxHeight := w.Manager.SyncedTo().Height - quotaPruneDepth
+		for name, quota := range quotas {
+			if footprints[name] <= quota {
+				continue
+			}
+			pruned, err := w.pruneAccount(name, footprints[name]-quota,
+				txs, maxHeight)
+			if err != nil {
+				return err
+			}
+			if pruned != 0 {
+				log.Infof("Pruned %d bytes of transactions of account %q",
+					pruned, name)
+			}
+			footprints[name] -= pruned
+		}
+	}
+
+	w.quotas.mu.Lock()
+	defer w.quotas.mu.Unlock()
+	for name, quota := range quotas {
+		footprint := footprints[name]
+		if footprint <= quota {
+			delete(w.quotas.exceeded, name)
+			continue
+		}
+		if w.quotas.exceeded[name] {
+			continue
+		}
+		w.quotas.exceeded[name] = true
+		log.Warnf("Transactions of account %q use %d bytes, exceeding "+
+			"its quota of %d bytes", name, footprint, quota)
+		w.NtfnServer.notifyAccountQuota(&AccountQuotaNotification{
+			AccountName: name,
+			Footprint:   footprint,
+			Quota:       quota,
+		})
+	}
+	return nil
+}
+
+// pruneAccount prunes the oldest transactions of a single account mined at or
+// below maxHeight until at least excess bytes are removed.  The number of
+// bytes pruned is returned.
+func (w *Wallet) pruneAccount(name string, excess int64, txs []quotaTx,
+	maxHeight int32) (int64, error) {
+
+	var pruned int64
+	err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		txmgrNs := dbtx.ReadWriteBucket(wtxmgrNamespaceKey)
+		for i := range txs {
+			tx := &txs[i]
+			if pruned >= excess {
+				return nil
+			}
+			if tx.account != name || tx.block.Height == -1 ||
+				tx.block.Height > maxHeight {
+				continue
+			}
+			ok, err := w.TxStore.PruneTx(txmgrNs, &tx.hash, &tx.block,
+				maxHeight)
+			if err != nil {
+				return err
+			}
+			if ok {
+				pruned += tx.size
+			}
+		}
+		return nil
+	})
+	return pruned, err
+}
@@ -0,0 +1,353 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/wallet/internal/txsizes"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// Input sequence numbers of the transactions created by the wallet.
+const (
+	// SequenceReplaceable is the sequence number of the inputs of
+	// transactions signaling BIP0125 replaceability.
+	SequenceReplaceable = wire.MaxTxInSequenceNum - 2
+
+	// SequenceFinal is the sequence number of the inputs of transactions
+	// opting out of replaceability, for recipients relying on the first
+	// transaction seen.
+	SequenceFinal = wire.MaxTxInSequenceNum
+)
+
+// incrementalRelayFeePerKb is the fee rate per kilobyte by which a
+// replacement must at least increase the fee of the transaction it replaces,
+// the BIP0125 incremental relay fee rate of the back ends.
+const incrementalRelayFeePerKb btcutil.Amount = 1000
+
+// ErrNotReplaceable describes an error where a fee bump is requested for a
+// transaction which is mined or does not signal BIP0125 replaceability.
+var ErrNotReplaceable = errors.New("transaction is not replaceable")
+
+// replaceableBucketKey is the key of the bucket in the transaction metadata
+// namespace recording whether the transactions created by the wallet signal
+// replaceability, keyed by transaction hash.
+var replaceableBucketKey = []byte("replaceable")
+
+// replacePolicy is the replaceability of transactions which do not choose
+// their own.
+type replacePolicy struct {
+	mu     sync.Mutex
+	optOut bool
+}
+
+// SetReplaceableByDefault configures whether the transactions created without
+// a replaceability option signal BIP0125 replaceability, which they do unless
+// this is called with false.
+func (w *Wallet) SetReplaceableByDefault(replaceable bool) {
+	w.replacePolicy.mu.Lock()
+	w.replacePolicy.optOut = !replaceable
+	w.replacePolicy.mu.Unlock()
+}
+
+// ReplaceableByDefault returns whether the transactions created without a
+// replaceability option signal BIP0125 replaceability.
+func (w *Wallet) ReplaceableByDefault() bool {
+	w.replacePolicy.mu.Lock()
+	defer w.replacePolicy.mu.Unlock()
+	return !w.replacePolicy.optOut
+}
+
+// setReplaceable sets the sequence numbers of the inputs of tx so that it
+// signals replaceability or not.
+func setReplaceable(tx *wire.MsgTx, replaceable bool) {
+	sequence := uint32(SequenceFinal)
+	if replaceable {
+		sequence = SequenceReplaceable
+	}
+	for _, txIn := range tx.TxIn {
+		txIn.Sequence = sequence
+	}
+}
+
+// signalsReplaceable returns whether any input of tx signals BIP0125
+// replaceability.
+func signalsReplaceable(tx *wire.MsgTx) bool {
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence < wire.MaxTxInSequenceNum-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// putReplaceable records whether a transaction created by the wallet signals
+// replaceability.
+func putReplaceable(ns walletdb.ReadWriteBucket, txHash *chainhash.Hash,
+	replaceable bool) error {
+
+	bucket, err := ns.CreateBucketIfNotExists(replaceableBucketKey)
+	if err != nil {
+		return err
+	}
+	v := []byte{0}
+	if replaceable {
+		v[0] = 1
+	}
+	return bucket.Put(txHash[:], v)
+}
+
+// TxReplaceable returns whether a wallet transaction can be replaced by
+// BIP0125 replacement, which is only possible while it is unmined.  The
+// replaceability recorded when the wallet created the transaction is returned
+// when there is one, and the other transactions are replaceable when any of
+// their inputs signals it.
+func (w *Wallet) TxReplaceable(details *wtxmgr.TxDetails) (bool, error) {
+	if details.Block.Height != -1 {
+		return false, nil
+	}
+	var v []byte
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		bucket := tx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(replaceableBucketKey)
+		if bucket != nil {
+			v = bucket.Get(details.Hash[:])
+		}
+		if v != nil {
+			v = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(v) == 1 {
+		return v[0] == 1, nil
+	}
+	return signalsReplaceable(&details.MsgTx), nil
+}
+
+// TxReplacements returns the hash of the transaction replaced by a wallet
+// transaction, and the hash of the transaction which replaced it.  Either is
+// nil when there is no such replacement.
+func (w *Wallet) TxReplacements(txHash *chainhash.Hash) (replaces,
+	replacedBy *chainhash.Hash, err error) {
+
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+		replaces = w.TxStore.Replaces(txmgrNs, txHash)
+		replacedBy = w.TxStore.ReplacedBy(txmgrNs, txHash)
+		return nil
+	})
+	return replaces, replacedBy, err
+}
+
+// FeeBump describes the replacement of a transaction by the same transaction
+// paying a higher fee.
+type FeeBump struct {
+	Replaced chainhash.Hash
+	Hash     chainhash.Hash
+	OrigFee  btcutil.Amount
+	Fee      btcutil.Amount
+}
+
+// BumpFee replaces an unmined replaceable transaction created by the wallet by
+// the same transaction paying a higher fee, deducted from its change output.
+// The fee rate of the replacement is feeSatPerKb, or when it is zero the
+// larger of the wallet's fee rate and the fee rate of the replaced
+// transaction increased by the incremental relay fee rate.  The replacement
+// is broadcast before the transaction store records it, so a replacement
+// rejected by the back end leaves the replaced transaction in place.
+func (w *Wallet) BumpFee(txHash *chainhash.Hash,
+	feeSatPerKb btcutil.Amount) (*FeeBump, error) {
+
+	server, err := w.requireChainClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var details *wtxmgr.TxDetails
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		var err error
+		details, err = w.TxStore.TxDetails(
+			dbtx.ReadBucket(wtxmgrNamespaceKey), txHash,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if details == nil {
+		return nil, fmt.Errorf("no wallet transaction %v", txHash)
+	}
+	replaceable, err := w.TxReplaceable(details)
+	if err != nil {
+		return nil, err
+	}
+	if !replaceable {
+		return nil, ErrNotReplaceable
+	}
+	if len(details.Debits) != len(details.MsgTx.TxIn) {
+		return nil, fmt.Errorf("transaction %v spends outputs which "+
+			"do not belong to the wallet", txHash)
+	}
+	changeIndex := -1
+	for _, c := range details.Credits {
+		if c.Change {
+			changeIndex = int(c.Index)
+			break
+		}
+	}
+	if changeIndex < 0 {
+		return nil, fmt.Errorf("transaction %v has no change output "+
+			"to pay a higher fee", txHash)
+	}
+
+	tx := &txauthor.AuthoredTx{
+		Tx:          details.MsgTx.Copy(),
+		ChangeIndex: changeIndex,
+	}
+	for _, txIn := range tx.Tx.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	var numP2PKH, numP2WPKH, numNested, numP2TR int
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+		for _, txIn := range tx.Tx.TxIn {
+			prevOut := &txIn.PreviousOutPoint
+			prev, err := w.TxStore.TxDetails(txmgrNs, &prevOut.Hash)
+			if err != nil {
+				return err
+			}
+			if prev == nil || int(prevOut.Index) >= len(prev.MsgTx.TxOut) {
+				return fmt.Errorf("previous output %v is unknown",
+					prevOut)
+			}
+			output := prev.MsgTx.TxOut[prevOut.Index]
+			switch {
+			case txscript.IsPayToScriptHash(output.PkScript):
+				numNested++
+			case txscript.IsPayToWitnessPubKeyHash(output.PkScript):
+				numP2WPKH++
+			case taproot.IsPayToTaproot(output.PkScript):
+				numP2TR++
+			default:
+				numP2PKH++
+			}
+			tx.PrevScripts = append(tx.PrevScripts, output.PkScript)
+			tx.PrevInputValues = append(tx.PrevInputValues,
+				btcutil.Amount(output.Value))
+			tx.TotalInput += btcutil.Amount(output.Value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var totalOutput btcutil.Amount
+	for _, txOut := range tx.Tx.TxOut {
+		totalOutput += btcutil.Amount(txOut.Value)
+	}
+	origFee := tx.TotalInput - totalOutput
+	size := txsizes.EstimateVirtualSize(numP2PKH, numP2WPKH, numNested,
+		numP2TR, tx.Tx.TxOut, false)
+	if feeSatPerKb == 0 {
+		feeSatPerKb = origFee*1000/btcutil.Amount(size) +
+			incrementalRelayFeePerKb
+		if feeRate := w.FeeRate(0); feeRate > feeSatPerKb {
+			feeSatPerKb = feeRate
+		}
+	}
+	fee := txrules.FeeForSerializeSize(feeSatPerKb, size)
+	minFee := origFee + txrules.FeeForSerializeSize(incrementalRelayFeePerKb,
+		size)
+	if fee < minFee {
+		return nil, fmt.Errorf("fee %v at %v/kB is below the minimum "+
+			"replacement fee %v", fee, feeSatPerKb, minFee)
+	}
+	change := tx.Tx.TxOut[changeIndex]
+	changeAmount := btcutil.Amount(change.Value) - (fee - origFee)
+	if changeAmount <= 0 || txrules.IsDustAmount(changeAmount,
+		len(change.PkScript), feeSatPerKb) {
+
+		return nil, fmt.Errorf("change of transaction %v cannot pay "+
+			"the fee %v", txHash, fee)
+	}
+	change.Value = int64(changeAmount)
+
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+		return tx.AddAllInputScripts(secretSource{w.Manager, addrmgrNs, scriptNs, w})
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = validateMsgTx(tx.Tx, tx.PrevScripts, tx.PrevInputValues)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.screenOutgoing(tx.Tx); err != nil {
+		return nil, err
+	}
+	hookEvent := &TxHookEvent{Point: HookBeforeBroadcast, Tx: tx.Tx}
+	if err := w.runTxHooks(hookEvent); err != nil {
+		return nil, err
+	}
+	w.saveTxAnnotations(tx.Tx.TxHash(), hookEvent.Annotations)
+
+	if _, err := server.SendRawTransaction(tx.Tx, false); err != nil {
+		return nil, err
+	}
+
+	txRec, err := wtxmgr.NewTxRecordFromMsgTx(tx.Tx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		txmgrNs := dbtx.ReadWriteBucket(wtxmgrNamespaceKey)
+		err := w.TxStore.ReplaceUnminedTx(txmgrNs, &details.TxRecord,
+			txRec)
+		if err != nil {
+			return err
+		}
+		if err := w.addRelevantTx(dbtx, txRec, nil); err != nil {
+			return err
+		}
+		return putReplaceable(dbtx.ReadWriteBucket(wtxmetaNamespaceKey),
+			&txRec.Hash, true)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("replacement %v was broadcast but cannot "+
+			"be recorded: %v", txRec.Hash, err)
+	}
+	w.NtfnServer.notifyPublishedTransaction()
+
+	log.Infof("Replaced transaction %v by %v, raising the fee from %v "+
+		"to %v", txHash, txRec.Hash, origFee, fee)
+	w.audit(AuditSend, "transaction %v replaced by %v with fee %v",
+		txHash, txRec.Hash, fee)
+	return &FeeBump{
+		Replaced: *txHash,
+		Hash:     txRec.Hash,
+		OrigFee:  origFee,
+		Fee:      fee,
+	}, nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TestTxReplaceable checks that the replaceability of unmined transactions is
+// the recorded one when there is one, and is signaled by their inputs
+// otherwise.
+func TestTxReplaceable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replaceable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	w := &Wallet{db: db}
+
+	newDetails := func(index uint32, replaceable bool) *wtxmgr.TxDetails {
+		details := &wtxmgr.TxDetails{
+			Block: wtxmgr.BlockMeta{Block: wtxmgr.Block{Height: -1}},
+		}
+		details.MsgTx = *wire.NewMsgTx(wire.TxVersion)
+		details.MsgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: index},
+			nil, nil))
+		setReplaceable(&details.MsgTx, replaceable)
+		details.Hash = details.MsgTx.TxHash()
+		return details
+	}
+	signaling := newDetails(0, true)
+	final := newDetails(1, false)
+	recorded := newDetails(2, true)
+
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		ns, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		if err != nil {
+			return err
+		}
+		return putReplaceable(ns, &recorded.Hash, false)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		details *wtxmgr.TxDetails
+		want    bool
+	}{
+		{"signaling", signaling, true},
+		{"final", final, false},
+		{"recorded", recorded, false},
+	}
+	for _, test := range tests {
+		replaceable, err := w.TxReplaceable(test.details)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if replaceable != test.want {
+			t.Errorf("%s: replaceable is %v, want %v", test.name,
+				replaceable, test.want)
+		}
+	}
+
+	signaling.Block.Height = 100
+	if replaceable, _ := w.TxReplaceable(signaling); replaceable {
+		t.Error("mined transaction is replaceable")
+	}
+}
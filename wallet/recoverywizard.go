@@ -0,0 +1,491 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/internal/zero"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// recoveryWizardKey is the key of the derivations scanned by a recovery
+// started with the recovery wizard in the transaction metadata namespace.  It
+// is removed when the recovery is finalized.
+var recoveryWizardKey = []byte("recoverywizard")
+
+// defaultRecoveryWindow is the recovery window of the recoveries started with
+// the recovery wizard of loaders without one.
+const defaultRecoveryWindow = 250
+
+// RecoveryStep is a step of the recovery of a wallet from its seed with the
+// recovery wizard.
+type RecoveryStep string
+
+// These constants name the steps of the recovery wizard, in order.
+const (
+	// RecoveryStepSeed waits for the seed of the recovered wallet.
+	RecoveryStepSeed RecoveryStep = "seed"
+
+	// RecoveryStepDerivations waits for the choice of the derivations
+	// scanned for used addresses, and the passphrases of the recovered
+	// wallet which start the scan.
+	RecoveryStepDerivations RecoveryStep = "derivations"
+
+	// RecoveryStepScanning is the step of the scan of the chain for the
+	// addresses of the recovered wallet.  It resumes when the wallet is
+	// reopened.
+	RecoveryStepScanning RecoveryStep = "scanning"
+
+	// RecoveryStepReview waits for the review of the funds found by the
+	// scan before the recovery is finalized.
+	RecoveryStepReview RecoveryStep = "review"
+
+	// RecoveryStepDone is the step of loaded wallets without a recovery in
+	// progress.
+	RecoveryStepDone RecoveryStep = "done"
+)
+
+// recoveryDerivations are the derivations scanned by a recovery started with
+// the recovery wizard.
+type recoveryDerivations struct {
+	scopes         []waddrmgr.KeyScope
+	recoveryWindow uint32
+}
+
+// Recovery derivations are serialized as such:
+//
+//   [0:4]     Recovery window (4 bytes)
+//   [4:8]     Number of key scopes n (4 bytes)
+//   [8:8+8n]  Purpose and coin type of each key scope (4 bytes each)
+//
+// All values are big endian.
+
+func serializeRecoveryDerivations(d *recoveryDerivations) []byte {
+	v := make([]byte, 8+8*len(d.scopes))
+	binary.BigEndian.PutUint32(v[0:4], d.recoveryWindow)
+	binary.BigEndian.PutUint32(v[4:8], uint32(len(d.scopes)))
+	for i, scope := range d.scopes {
+		binary.BigEndian.PutUint32(v[8+8*i:], scope.Purpose)
+		binary.BigEndian.PutUint32(v[12+8*i:], scope.Coin)
+	}
+	return v
+}
+
+func deserializeRecoveryDerivations(v []byte) (*recoveryDerivations, error) {
+	if len(v) < 8 {
+		return nil, fmt.Errorf("malformed recovery derivations")
+	}
+	n := binary.BigEndian.Uint32(v[4:8])
+	if uint64(len(v)) != 8+8*uint64(n) {
+		return nil, fmt.Errorf("malformed recovery derivations")
+	}
+	d := &recoveryDerivations{
+		recoveryWindow: binary.BigEndian.Uint32(v[0:4]),
+		scopes:         make([]waddrmgr.KeyScope, n),
+	}
+	for i := range d.scopes {
+		d.scopes[i] = waddrmgr.KeyScope{
+			Purpose: binary.BigEndian.Uint32(v[8+8*i:]),
+			Coin:    binary.BigEndian.Uint32(v[12+8*i:]),
+		}
+	}
+	return d, nil
+}
+
+// fetchRecoveryDerivations returns the derivations of the recovery in
+// progress, or nil when no recovery was started with the recovery wizard.
+func fetchRecoveryDerivations(ns walletdb.ReadBucket) (*recoveryDerivations, error) {
+	v := ns.Get(recoveryWizardKey)
+	if v == nil {
+		return nil, nil
+	}
+	return deserializeRecoveryDerivations(v)
+}
+
+// wizardRecovery holds the derivations of the recovery started with the
+// recovery wizard until it is finalized.
+type wizardRecovery struct {
+	mu          sync.Mutex
+	derivations *recoveryDerivations
+}
+
+// recoveryScanned returns whether the recovery of the wallet scans a key
+// scope.  Recoveries not started with the recovery wizard scan every default
+// scope.
+func (w *Wallet) recoveryScanned(scope waddrmgr.KeyScope) bool {
+	w.wizardRecovery.mu.Lock()
+	defer w.wizardRecovery.mu.Unlock()
+	d := w.wizardRecovery.derivations
+	if d == nil {
+		return true
+	}
+	for _, s := range d.scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RecoveredScope describes the addresses and funds found in a key scope by a
+// recovery.
+type RecoveredScope struct {
+	Scope waddrmgr.KeyScope
+
+	// Addresses is the number of addresses of the default account of the
+	// scope up to its last used address.
+	Addresses uint32
+
+	// Balances are the unspent outputs paying to the addresses of the
+	// scope, by token.
+	Balances map[wire.TokenIdentity]btcutil.Amount
+}
+
+// RecoveryStatus describes the step and progress of the recovery wizard.
+type RecoveryStatus struct {
+	Step RecoveryStep
+
+	// Birthday, Scopes and RecoveryWindow are the choices made for the
+	// recovery, and are unset at the seed step.
+	Birthday       time.Time
+	Scopes         []waddrmgr.KeyScope
+	RecoveryWindow uint32
+
+	// SyncedHeight and BestHeight are the heights of the block the wallet
+	// is synced to and of the best block of the chain server during the
+	// scan and the review.
+	SyncedHeight int32
+	BestHeight   int32
+
+	// Found describes the addresses and funds found in each scanned scope
+	// during the scan and the review.
+	Found []RecoveredScope
+}
+
+// RecoveryWizard guides the recovery of a wallet from its seed through the
+// steps of RecoveryStep, so that frontends can restore wallets without
+// orchestrating the creation of the wallet and the scan of the chain
+// themselves.  The seed and the choices made before the scan only live in
+// memory, while the scan resumes when the recovered wallet is reopened until
+// the recovery is finalized.
+//
+// RecoveryWizard is safe for concurrent access.
+type RecoveryWizard struct {
+	loader *Loader
+
+	mu             sync.Mutex
+	seed           []byte
+	birthday       time.Time
+	scopes         []waddrmgr.KeyScope
+	recoveryWindow uint32
+}
+
+// RecoveryWizard returns the recovery wizard of the wallets created by the
+// loader.
+func (l *Loader) RecoveryWizard() *RecoveryWizard {
+	return l.recoveryWizard
+}
+
+// step returns the current step of the wizard and the loaded wallet, if any.
+// The wizard mutex must be held.
+func (wz *RecoveryWizard) step() (RecoveryStep, *Wallet) {
+	w, loaded := wz.loader.LoadedWallet()
+	if !loaded {
+		if wz.seed == nil {
+			return RecoveryStepSeed, nil
+		}
+		return RecoveryStepDerivations, nil
+	}
+	w.wizardRecovery.mu.Lock()
+	d := w.wizardRecovery.derivations
+	w.wizardRecovery.mu.Unlock()
+	switch {
+	case d == nil:
+		return RecoveryStepDone, w
+	case w.ChainSynced():
+		return RecoveryStepReview, w
+	default:
+		return RecoveryStepScanning, w
+	}
+}
+
+// Status returns the step and progress of the recovery.
+func (wz *RecoveryWizard) Status() (*RecoveryStatus, error) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	step, w := wz.step()
+	status := &RecoveryStatus{Step: step}
+	switch step {
+	case RecoveryStepDerivations:
+		status.Birthday = wz.birthday
+		status.Scopes = wz.scopes
+		status.RecoveryWindow = wz.recoveryWindow
+
+	case RecoveryStepScanning, RecoveryStepReview:
+		w.wizardRecovery.mu.Lock()
+		d := w.wizardRecovery.derivations
+		w.wizardRecovery.mu.Unlock()
+		status.Birthday = w.Manager.Birthday()
+		status.Scopes = d.scopes
+		status.RecoveryWindow = d.recoveryWindow
+		status.SyncedHeight = w.Manager.SyncedTo().Height
+		if chainClient := w.ChainClient(); chainClient != nil {
+			_, height, err := chainClient.GetBestBlock()
+			if err == nil {
+				status.BestHeight = height
+			}
+		}
+		found, err := w.recoveredScopes(d.scopes)
+		if err != nil {
+			return nil, err
+		}
+		status.Found = found
+	}
+	return status, nil
+}
+
+// EnterSeed starts a recovery from a seed, scanning the blocks mined since
+// birthday.  A zero birthday scans the whole chain.  The seed replaces the
+// seed of a recovery whose scan has not started yet.
+func (wz *RecoveryWizard) EnterSeed(seed []byte, birthday time.Time) error {
+	if len(seed) < hdkeychain.MinSeedBytes ||
+		len(seed) > hdkeychain.MaxSeedBytes {
+
+		return hdkeychain.ErrInvalidSeedLen
+	}
+
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	switch step, _ := wz.step(); step {
+	case RecoveryStepSeed, RecoveryStepDerivations:
+	default:
+		return fmt.Errorf("cannot enter a seed at the %s step", step)
+	}
+	exists, err := wz.loader.WalletExists()
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrExists
+	}
+
+	if birthday.IsZero() {
+		birthday = wz.loader.chainParams.GenesisBlock.Header.Timestamp
+	}
+	zero.Bytes(wz.seed)
+	wz.seed = append([]byte(nil), seed...)
+	wz.birthday = birthday
+	wz.scopes = append([]waddrmgr.KeyScope(nil), waddrmgr.DefaultKeyScopes...)
+	wz.recoveryWindow = wz.loader.recoveryWindow
+	if wz.recoveryWindow == 0 {
+		wz.recoveryWindow = defaultRecoveryWindow
+	}
+	return nil
+}
+
+// ChooseDerivations chooses the key scopes scanned for used addresses and the
+// number of unused addresses scanned past the last used address of each
+// branch.  Every default scope is scanned until derivations are chosen.
+func (wz *RecoveryWizard) ChooseDerivations(scopes []waddrmgr.KeyScope,
+	recoveryWindow uint32) error {
+
+	if len(scopes) == 0 {
+		return errors.New("no key scope to scan")
+	}
+	if recoveryWindow == 0 {
+		return errors.New("recovery window must be positive")
+	}
+	for _, scope := range scopes {
+		if _, ok := waddrmgr.ScopeAddrMap[scope]; !ok {
+			return fmt.Errorf("key scope %v is not a default scope",
+				&scope)
+		}
+	}
+
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	if step, _ := wz.step(); step != RecoveryStepDerivations {
+		return fmt.Errorf("cannot choose derivations at the %s step",
+			step)
+	}
+	wz.scopes = append([]waddrmgr.KeyScope(nil), scopes...)
+	wz.recoveryWindow = recoveryWindow
+	return nil
+}
+
+// StartScan creates the recovered wallet with the passphrases and starts the
+// scan of the chosen derivations, which runs once the wallet is synced with
+// the chain server.  The seed is forgotten by the wizard.
+func (wz *RecoveryWizard) StartScan(pubPassphrase, privPassphrase []byte) (*Wallet, error) {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	if step, _ := wz.step(); step != RecoveryStepDerivations {
+		return nil, fmt.Errorf("cannot start the scan at the %s step",
+			step)
+	}
+
+	d := &recoveryDerivations{
+		scopes:         wz.scopes,
+		recoveryWindow: wz.recoveryWindow,
+	}
+	w, err := wz.loader.createRecoveredWallet(pubPassphrase, privPassphrase,
+		wz.seed, wz.birthday, d)
+	if err != nil {
+		return nil, err
+	}
+	wz.reset()
+	log.Infof("Started the recovery of the wallet scanning %d key scopes "+
+		"with recovery_window=%d", len(d.scopes), d.recoveryWindow)
+	return w, nil
+}
+
+// Finalize ends the review of the funds found by the recovery.  The wallet
+// recovers used addresses like wallets not restored with the wizard from then
+// on.
+func (wz *RecoveryWizard) Finalize() error {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	step, w := wz.step()
+	if step != RecoveryStepReview {
+		return fmt.Errorf("cannot finalize the recovery at the %s step",
+			step)
+	}
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		return tx.ReadWriteBucket(wtxmetaNamespaceKey).Delete(
+			recoveryWizardKey)
+	})
+	if err != nil {
+		return err
+	}
+	w.wizardRecovery.mu.Lock()
+	w.wizardRecovery.derivations = nil
+	w.wizardRecovery.mu.Unlock()
+	log.Infof("Finalized the recovery of the wallet")
+	return nil
+}
+
+// Abort forgets the seed and choices of a recovery whose scan has not started.
+// Recovered wallets cannot be aborted, but are removed like other wallets.
+func (wz *RecoveryWizard) Abort() error {
+	wz.mu.Lock()
+	defer wz.mu.Unlock()
+
+	switch step, _ := wz.step(); step {
+	case RecoveryStepSeed, RecoveryStepDerivations:
+		wz.reset()
+		return nil
+	default:
+		return fmt.Errorf("cannot abort the recovery at the %s step",
+			step)
+	}
+}
+
+// reset zeroes the seed and returns the wizard to the seed step.  The wizard
+// mutex must be held.
+func (wz *RecoveryWizard) reset() {
+	zero.Bytes(wz.seed)
+	wz.seed = nil
+	wz.birthday = time.Time{}
+	wz.scopes = nil
+	wz.recoveryWindow = 0
+}
+
+// recoveredScopes returns the addresses and funds found in the scanned key
+// scopes.
+func (w *Wallet) recoveredScopes(scopes []waddrmgr.KeyScope) ([]RecoveredScope, error) {
+	found := make([]RecoveredScope, 0, len(scopes))
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+
+		index := make(map[waddrmgr.KeyScope]int, len(scopes))
+		for _, scope := range scopes {
+			manager, err := w.Manager.FetchScopedKeyManager(scope)
+			if waddrmgr.IsError(err, waddrmgr.ErrScopeNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			props, err := manager.AccountProperties(addrmgrNs,
+				waddrmgr.DefaultAccountNum)
+			if err != nil {
+				return err
+			}
+			index[scope] = len(found)
+			found = append(found, RecoveredScope{
+				Scope: scope,
+				Addresses: props.ExternalKeyCount +
+					props.InternalKeyCount,
+				Balances: make(map[wire.TokenIdentity]btcutil.Amount),
+			})
+		}
+
+		unspent, err := w.TxStore.UnspentOutputs(txmgrNs, nil)
+		if err != nil {
+			return err
+		}
+		for i := range unspent {
+			output := &unspent[i]
+			_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+				output.PkScript, w.chainParams)
+			if err != nil || len(addrs) == 0 {
+				continue
+			}
+			manager, _, err := w.Manager.AddrAccount(addrmgrNs, addrs[0])
+			if err != nil {
+				continue
+			}
+			j, ok := index[manager.Scope()]
+			if !ok {
+				continue
+			}
+			token := wire.TokenID(output.PkScript)
+			found[j].Balances[token] += output.Amount
+		}
+		return nil
+	})
+	return found, err
+}
+
+// createRecoveredWallet creates a wallet from a restored seed, whose recovery
+// scans the derivations chosen with the recovery wizard until it is
+// finalized.
+func (l *Loader) createRecoveredWallet(pubPassphrase, privPassphrase,
+	seed []byte, bday time.Time, d *recoveryDerivations) (*Wallet, error) {
+
+	return l.createWallet(pubPassphrase, func(db walletdb.DB) error {
+		err := CreateWithKDF(
+			db, pubPassphrase, privPassphrase, seed, l.chainParams,
+			bday, l.kdf,
+		)
+		if err != nil {
+			return err
+		}
+		return walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+			if err := createOptionalNamespaces(tx); err != nil {
+				return err
+			}
+			ns := tx.ReadWriteBucket(wtxmetaNamespaceKey)
+			return ns.Put(recoveryWizardKey,
+				serializeRecoveryDerivations(d))
+		})
+	})
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// TestRecoveryWizardSteps checks the steps of the recovery wizard before the
+// recovered wallet is created.
+func TestRecoveryWizardSteps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recoverywizard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	params := &chaincfg.RegressionNetParams
+	wz := NewLoader(params, dir, 0).RecoveryWizard()
+	step := func(want RecoveryStep) *RecoveryStatus {
+		t.Helper()
+		status, err := wz.Status()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Step != want {
+			t.Fatalf("step %s, want %s", status.Step, want)
+		}
+		return status
+	}
+
+	step(RecoveryStepSeed)
+	err = wz.EnterSeed(make([]byte, 8), time.Time{})
+	if err != hdkeychain.ErrInvalidSeedLen {
+		t.Fatalf("short seed was entered: %v", err)
+	}
+	bip0084 := []waddrmgr.KeyScope{waddrmgr.KeyScopeBIP0084}
+	if err := wz.ChooseDerivations(bip0084, 20); err == nil {
+		t.Fatal("derivations were chosen without a seed")
+	}
+
+	seed := make([]byte, hdkeychain.RecommendedSeedLen)
+	if err := wz.EnterSeed(seed, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	status := step(RecoveryStepDerivations)
+	if !status.Birthday.Equal(params.GenesisBlock.Header.Timestamp) ||
+		!reflect.DeepEqual(status.Scopes, waddrmgr.DefaultKeyScopes) ||
+		status.RecoveryWindow != defaultRecoveryWindow {
+
+		t.Fatalf("unexpected default derivations %+v", status)
+	}
+
+	invalid := []waddrmgr.KeyScope{{Purpose: 1, Coin: 0}}
+	if err := wz.ChooseDerivations(invalid, 20); err == nil {
+		t.Fatal("non default key scope was chosen")
+	}
+	if err := wz.ChooseDerivations(bip0084, 0); err == nil {
+		t.Fatal("zero recovery window was chosen")
+	}
+	if err := wz.ChooseDerivations(bip0084, 20); err != nil {
+		t.Fatal(err)
+	}
+	status = step(RecoveryStepDerivations)
+	if !reflect.DeepEqual(status.Scopes, bip0084) ||
+		status.RecoveryWindow != 20 {
+
+		t.Fatalf("unexpected chosen derivations %+v", status)
+	}
+	if err := wz.Finalize(); err == nil {
+		t.Fatal("recovery was finalized before the scan")
+	}
+
+	if err := wz.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	step(RecoveryStepSeed)
+
+	// Seeds are refused when a wallet already exists.
+	err = ioutil.WriteFile(filepath.Join(dir, walletDbName), nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wz.EnterSeed(seed, time.Time{}); err != ErrExists {
+		t.Fatalf("seed was entered for an existing wallet: %v", err)
+	}
+}
+
+// TestRecoveryDerivationsSerialization checks that the derivations of wizard
+// recoveries round trip through their serialization.
+func TestRecoveryDerivationsSerialization(t *testing.T) {
+	d := &recoveryDerivations{
+		scopes: []waddrmgr.KeyScope{waddrmgr.KeyScopeBIP0084,
+			waddrmgr.KeyScopeBIP0086},
+		recoveryWindow: 100,
+	}
+	v := serializeRecoveryDerivations(d)
+	got, err := deserializeRecoveryDerivations(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, d) {
+		t.Fatalf("deserialized %+v, want %+v", got, d)
+	}
+	if _, err := deserializeRecoveryDerivations(v[:len(v)-1]); err == nil {
+		t.Fatal("truncated derivations were deserialized")
+	}
+}
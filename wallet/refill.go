@@ -0,0 +1,442 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/internal/txsizes"
+	"github.com/btcsuite/btcwallet/wallet/psbt"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// refillCheckInterval is the interval between two checks of the balance of
+// the hot account, which is also checked after every connected block.
+const refillCheckInterval = 10 * time.Minute
+
+// refillRequestKey is the key in the transaction metadata namespace of the
+// pending refill request, which is removed once the hot account is refilled.
+var refillRequestKey = []byte("refillrequest")
+
+// Refill requests are serialized as such:
+//
+//   [0:8]   Time created, as unix seconds (8 bytes)
+//   [8:16]  Balance of the hot account (8 bytes)
+//   [16:24] Amount paid to the hot account (8 bytes)
+//   [24:32] Fee (8 bytes)
+//   [32:]   Path of the PSBT file (varstring), followed by the PSBT
+
+// refillWebhookClient is the HTTP client POSTing the refill requests.
+var refillWebhookClient = &http.Client{Timeout: DefaultHeightWebhookTimeout}
+
+// RefillPolicy describes when the hot account, holding the float spent by
+// the wallet, is refilled from the cold account, whose outputs pay addresses
+// imported without their keys.  When the confirmed spendable balance of the
+// hot account falls below Threshold, a refill request is written to Dir: a
+// PSBT spending outputs of the cold account to pay the hot account up to
+// Target, to be signed offline by the cold storage keys.
+type RefillPolicy struct {
+	// HotAccount and ColdAccount are the names of the hot and cold
+	// accounts.
+	HotAccount  string
+	ColdAccount string
+
+	// Threshold is the balance below which the hot account is refilled,
+	// and Target is the balance it is refilled to.
+	Threshold btcutil.Amount
+	Target    btcutil.Amount
+
+	// Dir is the directory the PSBT files are written to.
+	Dir string
+
+	// Webhook is the URL POSTed the refill requests, or empty.
+	Webhook string
+}
+
+// RefillRequest is a request to refill the hot account from the cold account.
+type RefillRequest struct {
+	Created time.Time
+	Balance btcutil.Amount
+	Amount  btcutil.Amount
+	Fee     btcutil.Amount
+	Path    string
+	Psbt    *psbt.Packet
+}
+
+// refillPolicy holds the refill policy of the wallet.
+type refillPolicy struct {
+	mu     sync.Mutex
+	policy *RefillPolicy
+
+	// check is signaled when the balance of the hot account must be
+	// checked.
+	check chan struct{}
+}
+
+func serializeRefillRequest(r *RefillRequest) ([]byte, error) {
+	var buf bytes.Buffer
+	var v [32]byte
+	binary.BigEndian.PutUint64(v[0:8], uint64(r.Created.Unix()))
+	binary.BigEndian.PutUint64(v[8:16], uint64(r.Balance))
+	binary.BigEndian.PutUint64(v[16:24], uint64(r.Amount))
+	binary.BigEndian.PutUint64(v[24:32], uint64(r.Fee))
+	buf.Write(v[:])
+	if err := wire.WriteVarString(&buf, 0, r.Path); err != nil {
+		return nil, err
+	}
+	if err := r.Psbt.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func deserializeRefillRequest(v []byte) (*RefillRequest, error) {
+	if len(v) < 32 {
+		return nil, errors.New("malformed refill request")
+	}
+	r := &RefillRequest{
+		Created: time.Unix(int64(binary.BigEndian.Uint64(v[0:8])), 0),
+		Balance: btcutil.Amount(binary.BigEndian.Uint64(v[8:16])),
+		Amount:  btcutil.Amount(binary.BigEndian.Uint64(v[16:24])),
+		Fee:     btcutil.Amount(binary.BigEndian.Uint64(v[24:32])),
+	}
+	rd := bytes.NewReader(v[32:])
+	var err error
+	r.Path, err = wire.ReadVarString(rd, 0)
+	if err != nil {
+		return nil, err
+	}
+	r.Psbt, err = psbt.Parse(rd)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetRefillPolicy sets the policy requesting refills of the hot account from
+// the cold account.  A nil policy disables the refill requests.
+func (w *Wallet) SetRefillPolicy(policy *RefillPolicy) {
+	w.refill.mu.Lock()
+	w.refill.policy = policy
+	w.refill.mu.Unlock()
+	w.checkRefill()
+}
+
+// RefillRequest returns the pending refill request, or nil when the hot
+// account was not below its threshold since it was last refilled.
+func (w *Wallet) RefillRequest() (*RefillRequest, error) {
+	var r *RefillRequest
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		v := dbtx.ReadBucket(wtxmetaNamespaceKey).Get(refillRequestKey)
+		if v == nil {
+			return nil
+		}
+		var err error
+		r, err = deserializeRefillRequest(v)
+		return err
+	})
+	return r, err
+}
+
+// checkRefill wakes the refill monitor to check the balance of the hot
+// account.
+func (w *Wallet) checkRefill() {
+	select {
+	case w.refill.check <- struct{}{}:
+	default:
+	}
+}
+
+// refillMonitor requests refills of the hot account when its balance falls
+// below the threshold of the refill policy.  It must be run as a goroutine.
+func (w *Wallet) refillMonitor() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(refillCheckInterval)
+	defer ticker.Stop()
+	quit := w.quitChan()
+	for {
+		select {
+		case <-w.refill.check:
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+		if err := w.applyRefillPolicy(); err != nil {
+			log.Errorf("Cannot request a refill of the hot account: %v",
+				err)
+		}
+	}
+}
+
+// applyRefillPolicy creates a refill request when the hot account is below
+// its threshold and no request is pending, and removes the pending request
+// once the hot account was refilled.
+func (w *Wallet) applyRefillPolicy() error {
+	w.refill.mu.Lock()
+	policy := w.refill.policy
+	w.refill.mu.Unlock()
+	// Balances are stale until the wallet is synced to the chain, and
+	// the check is repeated at every block.
+	if policy == nil || !w.ChainSynced() {
+		return nil
+	}
+
+	hot, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, policy.HotAccount)
+	if err != nil {
+		return err
+	}
+	cold, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, policy.ColdAccount)
+	if err != nil {
+		return err
+	}
+	bals, err := w.CalculateAccountBalances(hot, 1, wire.STB)
+	if err != nil {
+		return err
+	}
+	pending, err := w.RefillRequest()
+	if err != nil {
+		return err
+	}
+
+	if bals.Spendable >= policy.Threshold {
+		if pending == nil {
+			return nil
+		}
+		err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+			ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+			return ns.Delete(refillRequestKey)
+		})
+		if err != nil {
+			return err
+		}
+		log.Infof("Hot account %q refilled to %v", policy.HotAccount,
+			bals.Spendable)
+		return nil
+	}
+	if pending != nil {
+		return nil
+	}
+
+	r := &RefillRequest{
+		Created: time.Unix(time.Now().Unix(), 0),
+		Balance: bals.Spendable,
+		Amount:  policy.Target - bals.Spendable,
+	}
+	r.Psbt, r.Fee, err = w.refillPsbt(hot, cold, r.Amount,
+		w.FeeRate(0))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(policy.Dir, 0700); err != nil {
+		return err
+	}
+	r.Path = filepath.Join(policy.Dir, fmt.Sprintf("refill-%d.psbt",
+		r.Created.Unix()))
+	if err := WritePsbtFile(r.Path, r.Psbt); err != nil {
+		return err
+	}
+	v, err := serializeRefillRequest(r)
+	if err != nil {
+		return err
+	}
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		return ns.Put(refillRequestKey, v)
+	})
+	if err != nil {
+		os.Remove(r.Path)
+		return err
+	}
+
+	log.Warnf("Hot account %q is below its float of %v with %v: refill "+
+		"request for %v from account %q written to %s",
+		policy.HotAccount, policy.Threshold, r.Balance, r.Amount,
+		policy.ColdAccount, r.Path)
+	w.audit(AuditSend, "refill of %v from account %q to account %q "+
+		"requested in %s", r.Amount, policy.ColdAccount,
+		policy.HotAccount, r.Path)
+	if policy.Webhook != "" {
+		if err := postRefillRequest(policy, r); err != nil {
+			log.Errorf("Cannot post the refill request to %s: %v",
+				policy.Webhook, err)
+		}
+	}
+	return nil
+}
+
+// refillPsbt returns a PSBT paying amount to a new address of the hot account
+// from the confirmed outputs of the watch-only addresses of the cold account,
+// the largest first, along with its fee at the fee rate per kilobyte.  The
+// cold account cannot derive addresses, so the change returns to the address
+// of the largest spent output.
+func (w *Wallet) refillPsbt(hot, cold uint32, amount,
+	feeRate btcutil.Amount) (*psbt.Packet, btcutil.Amount, error) {
+
+	var eligible []wtxmgr.Credit
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+		watchNs := dbtx.ReadBucket(wwatchNamespaceKey)
+		syncBlock := w.Manager.SyncedTo()
+		token := wire.STB
+		unspent, err := w.TxStore.UnspentOutputs(txmgrNs, &token)
+		if err != nil {
+			return err
+		}
+		for _, output := range unspent {
+			if !confirmed(1, output.Height, syncBlock.Height) ||
+				w.LockedOutpoint(output.OutPoint) {
+
+				continue
+			}
+			scope, account, err := fetchWatchedAccount(watchNs,
+				output.PkScript)
+			if err != nil || scope != waddrmgr.KeyScopeBIP0044 ||
+				account != cold {
+
+				continue
+			}
+			eligible = append(eligible, output)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	addr, err := w.NewAddress(hot, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		return nil, 0, err
+	}
+	pkScript, err := taproot.PayToAddrScript(addr)
+	if err != nil {
+		return nil, 0, err
+	}
+	tx, fee, err := refillTx(eligible, pkScript, amount, feeRate)
+	if err != nil {
+		return nil, 0, err
+	}
+	packet, err := psbt.New(tx)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		return w.updatePsbt(dbtx, packet)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return packet, fee, nil
+}
+
+// refillTx returns an unsigned transaction paying amount to pkScript from the
+// largest eligible outputs, and its fee at the fee rate per kilobyte.  The
+// change returns to the output script of the largest output, and is left to
+// the fee when it would be dust.
+func refillTx(eligible []wtxmgr.Credit, pkScript []byte, amount,
+	feeRate btcutil.Amount) (*wire.MsgTx, btcutil.Amount, error) {
+
+	sorted := append([]wtxmgr.Credit(nil), eligible...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Amount > sorted[j].Amount
+	})
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxOut(wire.NewTxOutToken(int64(amount), pkScript, wire.STB))
+
+	var total btcutil.Amount
+	var inputsSize int
+	for _, output := range sorted {
+		op := output.OutPoint
+		tx.AddTxIn(wire.NewTxIn(&op, nil, nil))
+		total += output.Amount
+		inputsSize += inputVirtualSize(output.PkScript)
+
+		changeScript := sorted[0].PkScript
+		change := wire.NewTxOutToken(0, changeScript, wire.STB)
+		withChange := append(tx.TxOut[:1:1], change)
+		size := inputsSize + txsizes.EstimateVirtualSize(0, 0, 0, 0,
+			withChange, false)
+		fee := txrules.FeeForSerializeSize(feeRate, size)
+		if total < amount+fee {
+			continue
+		}
+		change.Value = int64(total - amount - fee)
+		if txrules.IsDustAmount(btcutil.Amount(change.Value),
+			len(changeScript), feeRate) {
+
+			fee = total - amount
+		} else {
+			tx.TxOut = withChange
+		}
+		return tx, fee, nil
+	}
+	return nil, 0, fmt.Errorf("cold account holds %v in confirmed "+
+		"outputs, less than the refill of %v and its fee", total, amount)
+}
+
+type refillRequestJSON struct {
+	HotAccount  string  `json:"hotaccount"`
+	ColdAccount string  `json:"coldaccount"`
+	Balance     float64 `json:"balance"`
+	Threshold   float64 `json:"threshold"`
+	Amount      float64 `json:"amount"`
+	Fee         float64 `json:"fee"`
+	Created     int64   `json:"created"`
+	Path        string  `json:"path"`
+	Psbt        string  `json:"psbt"`
+}
+
+// postRefillRequest POSTs a refill request to the webhook of the refill
+// policy, with the base64 PSBT.
+func postRefillRequest(policy *RefillPolicy, r *RefillRequest) error {
+	encoded, err := r.Psbt.Encode()
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(&refillRequestJSON{
+		HotAccount:  policy.HotAccount,
+		ColdAccount: policy.ColdAccount,
+		Balance:     r.Balance.ToBTC(),
+		Threshold:   policy.Threshold.ToBTC(),
+		Amount:      r.Amount.ToBTC(),
+		Fee:         r.Fee.ToBTC(),
+		Created:     r.Created.Unix(),
+		Path:        r.Path,
+		Psbt:        encoded,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := refillWebhookClient.Post(policy.Webhook,
+		"application/json", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %s",
+			resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/psbt"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TestRefillTx checks that refills spend the largest cold outputs first, that
+// the change returns to the largest output, and that dust change is left to
+// the fee.
+func TestRefillTx(t *testing.T) {
+	script := func(b byte) []byte {
+		pkScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+			AddData(bytes.Repeat([]byte{b}, 20)).Script()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkScript
+	}
+	credit := func(i byte, amount btcutil.Amount) wtxmgr.Credit {
+		return wtxmgr.Credit{
+			OutPoint: wire.OutPoint{Hash: chainhash.Hash{i}},
+			Amount:   amount,
+			PkScript: script(i),
+		}
+	}
+	hot := script(0)
+
+	const feeRate = 1000
+	cold := []wtxmgr.Credit{credit(1, 1e6), credit(2, 5e6), credit(3, 3e6)}
+	tx, fee, err := refillTx(cold, hot, 7e6, feeRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.TxIn) != 2 || tx.TxIn[0].PreviousOutPoint != cold[1].OutPoint ||
+		tx.TxIn[1].PreviousOutPoint != cold[2].OutPoint {
+
+		t.Fatalf("unexpected refill inputs %v", tx.TxIn)
+	}
+	if len(tx.TxOut) != 2 || tx.TxOut[0].Value != 7e6 ||
+		!bytes.Equal(tx.TxOut[0].PkScript, hot) ||
+		!bytes.Equal(tx.TxOut[1].PkScript, cold[1].PkScript) {
+
+		t.Fatalf("unexpected refill outputs %v", tx.TxOut)
+	}
+	if change := btcutil.Amount(tx.TxOut[1].Value); change+fee != 1e6 {
+		t.Errorf("change %v and fee %v, want 1 BTC together", change, fee)
+	}
+
+	// The change of 100 satoshis after the fee is dust.
+	tx, fee, err = refillTx(cold, hot, 8e6-fee-100, feeRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.TxOut) != 1 || fee+btcutil.Amount(tx.TxOut[0].Value) != 8e6 {
+		t.Errorf("dust change not left to the fee: fee %v, outputs %v",
+			fee, tx.TxOut)
+	}
+
+	if _, _, err := refillTx(cold, hot, 9e6, feeRate); err == nil {
+		t.Error("refill created without outputs covering its fee")
+	}
+}
+
+// TestRefillRequestSerialization checks that pending refill requests round
+// trip through their serialization.
+func TestRefillRequestSerialization(t *testing.T) {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{1}}, nil,
+		nil))
+	tx.AddTxOut(wire.NewTxOutToken(1e6, []byte{txscript.OP_TRUE},
+		wire.STB))
+	packet, err := psbt.New(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &RefillRequest{
+		Created: time.Unix(1600000000, 0),
+		Balance: 2e5,
+		Amount:  1e6,
+		Fee:     300,
+		Path:    "/refills/refill-1600000000.psbt",
+		Psbt:    packet,
+	}
+	v, err := serializeRefillRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := deserializeRefillRequest(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Created.Equal(r.Created) || got.Balance != r.Balance ||
+		got.Amount != r.Amount || got.Fee != r.Fee || got.Path != r.Path ||
+		got.Psbt.UnsignedTx.TxHash() != tx.TxHash() {
+
+		t.Fatalf("unexpected refill request %+v", got)
+	}
+	if _, err := deserializeRefillRequest(v[:31]); err == nil {
+		t.Error("truncated refill request deserialized")
+	}
+}
@@ -5,10 +5,10 @@
 package wallet
 
 import (
-	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/internal/taproot"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wtxmgr"
 )
@@ -182,8 +182,10 @@ out:
 		select {
 		case msg := <-w.rescanProgress:
 			n := msg.Notification
-			log.Infof("Rescanned through block %v (height %d)",
-				n.Hash, n.Height)
+			addrs := msg.Addresses
+			noun := pickNoun(len(addrs), "address", "addresses")
+			log.Infof("Rescanned through block %v (height %d) for "+
+				"%d %s", n.Hash, n.Height, len(addrs), noun)
 
 		case msg := <-w.rescanFinished:
 			n := msg.Notification
@@ -255,7 +257,7 @@ func (w *Wallet) rescanWithTarget(addrs []btcutil.Address,
 
 	outpoints := make(map[wire.OutPoint]btcutil.Address, len(unspent))
 	for _, output := range unspent {
-		_, outputAddrs, _, err := txscript.ExtractPkScriptAddrs(
+		_, outputAddrs, _, err := taproot.ExtractPkScriptAddrs(
 			output.PkScript, w.chainParams,
 		)
 		if err != nil {
@@ -0,0 +1,109 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRescanBatchLatency is the default response time targeted by the
+// requests filtering blocks during catch-up rescans.
+const DefaultRescanBatchLatency = 2 * time.Second
+
+const (
+	// minRescanBatchSize and maxRescanBatchSize bound the number of blocks
+	// filtered by a single request.
+	minRescanBatchSize = 50
+	maxRescanBatchSize = 50000
+
+	// rescanBatchResults is the number of relevant transactions above
+	// which responses are considered too large, and the number of blocks
+	// filtered per request is reduced proportionally.
+	rescanBatchResults = 500
+)
+
+// rescanBatching sizes the block ranges filtered per request during
+// catch-up rescans.  Rather than filtering a fixed number of blocks, the
+// size follows the measured response latency of the chain backend, growing
+// on fast local backends where per-request overhead dominates and shrinking
+// on slow or remote backends where long requests stall or time out, and is
+// reduced when responses carry many relevant transactions.
+type rescanBatching struct {
+	mu     sync.Mutex
+	target time.Duration
+	size   int
+}
+
+// SetRescanBatchLatency sets the response time targeted by the requests
+// filtering blocks during catch-up rescans.  A zero latency disables the
+// adaptation, filtering a fixed number of blocks per request.
+func (w *Wallet) SetRescanBatchLatency(latency time.Duration) {
+	w.rescanBatching.mu.Lock()
+	w.rescanBatching.target = latency
+	w.rescanBatching.size = recoveryBatchSize
+	w.rescanBatching.mu.Unlock()
+}
+
+// Size returns the number of blocks to filter with the next request.
+func (b *rescanBatching) Size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.size == 0 || b.target == 0 {
+		return recoveryBatchSize
+	}
+	return b.size
+}
+
+// observe adjusts the batch size from a request filtering blocks, which took
+// elapsed and returned results relevant transactions.  Each adjustment at
+// most halves or doubles the size, so that a single slow or fast response
+// does not swing it across the whole range.
+func (b *rescanBatching) observe(blocks, results int, elapsed time.Duration) {
+	if blocks <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.target == 0 {
+		return
+	}
+	if b.size == 0 {
+		b.size = recoveryBatchSize
+	}
+
+	size := maxRescanBatchSize
+	if elapsed > 0 {
+		ideal := int64(b.target) * int64(blocks) / int64(elapsed)
+		if ideal < int64(size) {
+			size = int(ideal)
+		}
+	}
+	if results > rescanBatchResults {
+		if ideal := blocks * rescanBatchResults / results; ideal < size {
+			size = ideal
+		}
+	}
+
+	switch {
+	case size > 2*b.size:
+		size = 2 * b.size
+	case size < b.size/2:
+		size = b.size / 2
+	}
+	switch {
+	case size > maxRescanBatchSize:
+		size = maxRescanBatchSize
+	case size < minRescanBatchSize:
+		size = minRescanBatchSize
+	}
+	if size != b.size {
+		log.Debugf("Adjusting rescan batch size from %d to %d blocks "+
+			"(%d blocks filtered in %v with %d results)", b.size,
+			size, blocks, elapsed, results)
+		b.size = size
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRescanBatching checks the adaptation of the number of blocks filtered
+// per request to the response latency and result sizes of the backend.
+func TestRescanBatching(t *testing.T) {
+	var b rescanBatching
+	if b.Size() != recoveryBatchSize {
+		t.Fatalf("unconfigured size %d, want %d", b.Size(), recoveryBatchSize)
+	}
+	b.observe(recoveryBatchSize, 0, time.Millisecond)
+	if b.Size() != recoveryBatchSize {
+		t.Fatalf("size %d adapted without a target latency", b.Size())
+	}
+
+	b.target = time.Second
+	tests := []struct {
+		name    string
+		blocks  int
+		results int
+		elapsed time.Duration
+		want    int
+	}{
+		// Fast responses at most double the size.
+		{"fast", 2000, 0, 10 * time.Millisecond, 4000},
+		{"fast again", 4000, 0, 10 * time.Millisecond, 8000},
+		// Responses near the target latency converge on it.
+		{"near target", 8000, 0, 1600 * time.Millisecond, 5000},
+		// Slow responses at most halve the size.
+		{"slow", 5000, 0, time.Minute, 2500},
+		// Large responses shrink the size regardless of the latency.
+		{"large", 2500, 2000, 10 * time.Millisecond, 1250},
+		// Empty observations are ignored.
+		{"empty", 0, 0, time.Minute, 1250},
+	}
+	for _, test := range tests {
+		b.observe(test.blocks, test.results, test.elapsed)
+		if b.Size() != test.want {
+			t.Errorf("%s: size %d, want %d", test.name, b.Size(),
+				test.want)
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		b.observe(b.Size(), 0, time.Hour)
+	}
+	if b.Size() != minRescanBatchSize {
+		t.Errorf("size %d, want minimum %d", b.Size(), minRescanBatchSize)
+	}
+	for i := 0; i < 20; i++ {
+		b.observe(b.Size(), 0, 0)
+	}
+	if b.Size() != maxRescanBatchSize {
+		t.Errorf("size %d, want maximum %d", b.Size(), maxRescanBatchSize)
+	}
+}
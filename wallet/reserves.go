@@ -0,0 +1,143 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/internal/zero"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// ReserveOutput is an unspent output of an address of a proof of reserves.
+type ReserveOutput struct {
+	OutPoint wire.OutPoint
+	Height   int32
+	Amount   btcutil.Amount
+	Token    string
+}
+
+// AddressReserve is an address of a proof of reserves, with its unspent
+// outputs and the signature of the proof message by its private key.  The
+// signature is nil when the wallet has no private key for the address, such
+// as for watch-only and script addresses.
+type AddressReserve struct {
+	Address   btcutil.Address
+	Outputs   []ReserveOutput
+	Signature []byte
+}
+
+// ReserveProof proves that the wallet controls the outputs unspent at a
+// block.  Every address with unspent outputs signs Message, which commits to
+// the block and to a challenge chosen by the verifier so that proofs cannot be
+// reused, with the same compact signatures as SignMessage.
+type ReserveProof struct {
+	Height    int32
+	BlockHash chainhash.Hash
+	Message   string
+	Addresses []AddressReserve
+}
+
+// ReserveProofMessage returns the message signed by the addresses of a proof
+// of reserves at a block.
+func ReserveProofMessage(blockHash *chainhash.Hash, challenge string) string {
+	return fmt.Sprintf("Proof of reserves at block %v: %s", blockHash,
+		challenge)
+}
+
+// ProveReserves returns a proof of reserves covering every address of the
+// wallet with outputs unspent at the block the wallet is synced to.  Outputs
+// already spent by unmined transactions are not included.  The wallet must be
+// unlocked to sign the proof.
+func (w *Wallet) ProveReserves(challenge string) (*ReserveProof, error) {
+	synced := w.Manager.SyncedTo()
+	proof := &ReserveProof{
+		Height:    synced.Height,
+		BlockHash: synced.Hash,
+		Message:   ReserveProofMessage(&synced.Hash, challenge),
+	}
+
+	reserves := make(map[string]*AddressReserve)
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+
+		unspent, err := w.TxStore.UnspentOutputs(txmgrNs, nil)
+		if err != nil {
+			return err
+		}
+		for i := range unspent {
+			output := &unspent[i]
+			if output.Height == -1 || output.Height > synced.Height {
+				continue
+			}
+			_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+				output.PkScript, w.chainParams)
+			if err != nil || len(addrs) != 1 {
+				continue
+			}
+			encoded := addrs[0].EncodeAddress()
+			r, ok := reserves[encoded]
+			if !ok {
+				r = &AddressReserve{Address: addrs[0]}
+				reserves[encoded] = r
+			}
+			r.Outputs = append(r.Outputs, ReserveOutput{
+				OutPoint: output.OutPoint,
+				Height:   output.Height,
+				Amount:   output.Amount,
+				Token:    wire.TokenID(output.PkScript).String(),
+			})
+		}
+
+		for _, r := range reserves {
+			managedAddr, err := w.Manager.Address(addrmgrNs, r.Address)
+			if err != nil {
+				continue
+			}
+			pka, ok := managedAddr.(waddrmgr.ManagedPubKeyAddress)
+			if !ok {
+				continue
+			}
+			privKey, err := pka.PrivKey()
+			if err != nil {
+				return err
+			}
+			r.Signature, err = signMessage(privKey, proof.Message,
+				pka.Compressed())
+			zero.BigInt(privKey.D)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range reserves {
+		sort.Slice(r.Outputs, func(i, j int) bool {
+			a, b := &r.Outputs[i].OutPoint, &r.Outputs[j].OutPoint
+			if a.Hash != b.Hash {
+				return bytes.Compare(a.Hash[:], b.Hash[:]) < 0
+			}
+			return a.Index < b.Index
+		})
+		proof.Addresses = append(proof.Addresses, *r)
+	}
+	sort.Slice(proof.Addresses, func(i, j int) bool {
+		return proof.Addresses[i].Address.EncodeAddress() <
+			proof.Addresses[j].Address.EncodeAddress()
+	})
+	return proof, nil
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// TestReserveProofSignature checks that the signatures of proofs of reserves
+// commit to the block and the challenge, and recover the signing key like
+// verifymessage does.
+func TestReserveProofSignature(t *testing.T) {
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockHash := chainhash.DoubleHashH([]byte("block"))
+	message := ReserveProofMessage(&blockHash, "nonce")
+	if !strings.Contains(message, blockHash.String()) ||
+		!strings.HasSuffix(message, "nonce") {
+		t.Fatalf("message %q does not commit to the block and challenge",
+			message)
+	}
+
+	sig, err := signMessage(privKey, message, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, compressed, err := btcec.RecoverCompact(btcec.S256(), sig,
+		messageHash(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !compressed || !pubKey.IsEqual(privKey.PubKey()) {
+		t.Fatal("signature does not recover the signing key")
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// auditLogPrunedKey is the key in the transaction metadata namespace of the
+// sequence number and hash of the last pruned audit log record, from which the
+// chain of hashes of the remaining records is verified.
+var auditLogPrunedKey = []byte("auditlogpruned")
+
+// retentionCheckInterval is the interval at which the retention policies are
+// applied.
+const retentionCheckInterval = time.Hour
+
+// Kinds of data passed to PruneHooks.
+const (
+	PruneKindAuditLog = "auditlog"
+	PruneKindLogFile  = "logfile"
+)
+
+// PruneHook archives data before it is pruned by a retention policy, such as
+// to keep the records required for compliance outside of the wallet.
+type PruneHook interface {
+	// Name returns a short name of the hook used in log messages.
+	Name() string
+
+	// Export archives the data of the named file or record range of a
+	// kind of pruned data.  The data is not pruned when an error is
+	// returned.
+	Export(kind, name string, data []byte) error
+}
+
+// ExecPruneHook is a PruneHook that runs an external program for every export.
+// The program receives the kind and name of the exported data as its last two
+// arguments and the data on stdin, and must exit with status zero once the data
+// is archived.  Any other exit status aborts the prune.
+type ExecPruneHook struct {
+	path    string
+	args    []string
+	timeout time.Duration
+}
+
+// NewExecPruneHook returns a hook running the program at path with the passed
+// arguments.
+func NewExecPruneHook(path string, args ...string) *ExecPruneHook {
+	return &ExecPruneHook{
+		path:    path,
+		args:    args,
+		timeout: DefaultExecHookTimeout,
+	}
+}
+
+// SetTimeout changes the time the program is given to archive the data.
+func (h *ExecPruneHook) SetTimeout(timeout time.Duration) {
+	h.timeout = timeout
+}
+
+// Name returns the base name of the hook program.
+//
+// This is part of the PruneHook interface implementation.
+func (h *ExecPruneHook) Name() string {
+	return filepath.Base(h.path)
+}
+
+// Export runs the hook program with the exported data.
+//
+// This is part of the PruneHook interface implementation.
+func (h *ExecPruneHook) Export(kind, name string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	args := append(append([]string(nil), h.args...), kind, name)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, h.path, args...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) != 0 {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// AuditRetention is the retention policy of the audit log.  Records older than
+// MaxAge, and the oldest records exceeding MaxRecords, are pruned.  A zero
+// limit is not enforced.  The last record is never pruned, so that the records
+// appended later keep chaining to a known hash.
+type AuditRetention struct {
+	MaxAge     time.Duration
+	MaxRecords int
+}
+
+// retention holds the retention policies of the wallet data.
+type retention struct {
+	mu    sync.Mutex
+	audit AuditRetention
+	hook  PruneHook
+}
+
+// SetAuditRetention sets the retention policy of the audit log, applied
+// periodically once the wallet is started.  When hook is not nil, the pruned
+// records are exported to it before they are removed.
+func (w *Wallet) SetAuditRetention(policy AuditRetention, hook PruneHook) {
+	w.retention.mu.Lock()
+	w.retention.audit = policy
+	w.retention.hook = hook
+	w.retention.mu.Unlock()
+}
+
+// exportedAuditRecord is the JSON encoding of the audit log records exported
+// to prune hooks, one record per line.
+type exportedAuditRecord struct {
+	Seq       uint64 `json:"seq"`
+	Time      int64  `json:"time"`
+	Operation string `json:"operation"`
+	Details   string `json:"details"`
+	PrevHash  string `json:"prevhash"`
+	Hash      string `json:"hash"`
+}
+
+// auditPrunedMarker returns the sequence number and hash of the last pruned
+// audit log record, which are zero when no record was pruned.
+func auditPrunedMarker(ns walletdb.ReadBucket) (uint64, [sha256.Size]byte, error) {
+	var hash [sha256.Size]byte
+	v := ns.Get(auditLogPrunedKey)
+	if v == nil {
+		return 0, hash, nil
+	}
+	if len(v) != 8+sha256.Size {
+		return 0, hash, fmt.Errorf("malformed audit log prune marker")
+	}
+	copy(hash[:], v[8:])
+	return binary.BigEndian.Uint64(v[:8]), hash, nil
+}
+
+// prunableAuditRecords returns the records of the audit log bucket exceeding
+// the retention policy at time now, oldest first.
+func prunableAuditRecords(bucket walletdb.ReadBucket, policy AuditRetention,
+	now time.Time) ([]AuditRecord, error) {
+
+	var records []AuditRecord
+	err := bucket.ForEach(func(k, v []byte) error {
+		r, err := deserializeAuditRecord(k, v)
+		if err != nil {
+			return err
+		}
+		records = append(records, *r)
+		return nil
+	})
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+
+	n := 0
+	for n < len(records)-1 {
+		expired := policy.MaxAge > 0 &&
+			now.Sub(records[n].Time) > policy.MaxAge
+		excess := policy.MaxRecords > 0 &&
+			len(records)-n > policy.MaxRecords
+		if !expired && !excess {
+			break
+		}
+		n++
+	}
+	return records[:n], nil
+}
+
+// PruneAuditLog removes the audit log records exceeding the retention policy
+// at time now, and returns the number of pruned records.  The records are
+// exported to the prune hook first, and are kept when the export fails.  The
+// hash of the last pruned record is kept so that the remaining records can
+// still be verified, and the prune itself is recorded in the audit log.
+func (w *Wallet) PruneAuditLog(now time.Time) (int, error) {
+	w.retention.mu.Lock()
+	policy, hook := w.retention.audit, w.retention.hook
+	w.retention.mu.Unlock()
+	if policy.MaxAge == 0 && policy.MaxRecords == 0 {
+		return 0, nil
+	}
+
+	var records []AuditRecord
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		bucket := tx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(auditLogBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		var err error
+		records, err = prunableAuditRecords(bucket, policy, now)
+		return err
+	})
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+	first, last := records[0], records[len(records)-1]
+
+	if hook != nil {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for i := range records {
+			r := &records[i]
+			err := enc.Encode(&exportedAuditRecord{
+				Seq:       r.Seq,
+				Time:      r.Time.Unix(),
+				Operation: r.Operation,
+				Details:   r.Details,
+				PrevHash:  hex.EncodeToString(r.PrevHash[:]),
+				Hash:      hex.EncodeToString(r.Hash[:]),
+			})
+			if err != nil {
+				return 0, err
+			}
+		}
+		name := fmt.Sprintf("auditlog-%d-%d", first.Seq, last.Seq)
+		err := hook.Export(PruneKindAuditLog, name, buf.Bytes())
+		if err != nil {
+			return 0, fmt.Errorf("prune hook %s cannot export audit "+
+				"records %d to %d: %v", hook.Name(), first.Seq,
+				last.Seq, err)
+		}
+	}
+
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket := ns.NestedReadWriteBucket(auditLogBucketKey)
+		var k [8]byte
+		for i := range records {
+			binary.BigEndian.PutUint64(k[:], records[i].Seq)
+			v := bucket.Get(k[:])
+			if v == nil || auditRecordHash(k[:], v) != records[i].Hash {
+				return fmt.Errorf("audit log record %d changed "+
+					"while pruning", records[i].Seq)
+			}
+			if err := bucket.Delete(k[:]); err != nil {
+				return err
+			}
+		}
+
+		marker := make([]byte, 8+sha256.Size)
+		binary.BigEndian.PutUint64(marker[:8], last.Seq)
+		copy(marker[8:], last.Hash[:])
+		if err := ns.Put(auditLogPrunedKey, marker); err != nil {
+			return err
+		}
+		return appendAuditRecord(ns, time.Now(), AuditPrune,
+			fmt.Sprintf("audit records %d to %d pruned", first.Seq,
+				last.Seq))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+// retentionMonitor periodically prunes the data exceeding the retention
+// policies.  It must be run as a goroutine.
+func (w *Wallet) retentionMonitor() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(retentionCheckInterval)
+	defer ticker.Stop()
+	quit := w.quitChan()
+	for {
+		select {
+		case <-ticker.C:
+			n, err := w.PruneAuditLog(time.Now())
+			if err != nil {
+				log.Errorf("Cannot prune the audit log: %v", err)
+				continue
+			}
+			if n != 0 {
+				log.Infof("Pruned %d audit log %s", n,
+					pickNoun(n, "record", "records"))
+			}
+		case <-quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// testPruneHook records the exports of pruned data, or fails them.
+type testPruneHook struct {
+	fail    bool
+	exports []string
+	data    [][]byte
+}
+
+func (h *testPruneHook) Name() string { return "test" }
+
+func (h *testPruneHook) Export(kind, name string, data []byte) error {
+	if h.fail {
+		return errors.New("archive unavailable")
+	}
+	h.exports = append(h.exports, kind+"/"+name)
+	h.data = append(h.data, data)
+	return nil
+}
+
+// TestPruneAuditLog checks that the audit log records exceeding the retention
+// policy are exported before they are pruned, that a failed export keeps them,
+// and that the remaining records still verify.
+func TestPruneAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "retention")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+
+	for i := 0; i < 5; i++ {
+		w.audit(AuditSend, "transaction %d broadcast", i)
+	}
+
+	// Without a policy nothing is pruned.
+	if n, err := w.PruneAuditLog(time.Now()); n != 0 || err != nil {
+		t.Fatalf("pruned %d records without a policy: %v", n, err)
+	}
+
+	hook := &testPruneHook{fail: true}
+	w.SetAuditRetention(AuditRetention{MaxRecords: 2}, hook)
+	if n, err := w.PruneAuditLog(time.Now()); n != 0 || err == nil {
+		t.Fatalf("pruned %d records with a failing hook", n)
+	}
+	if records, _ := w.AuditLog(1, 10); len(records) != 5 {
+		t.Fatalf("audit log has %d records after a failed prune, want 5",
+			len(records))
+	}
+
+	hook.fail = false
+	n, err := w.PruneAuditLog(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("pruned %d records, want 3", n)
+	}
+	if len(hook.exports) != 1 || hook.exports[0] != "auditlog/auditlog-1-3" ||
+		bytes.Count(hook.data[0], []byte("\n")) != 3 {
+
+		t.Fatalf("unexpected exports %v", hook.exports)
+	}
+
+	// The remaining records and the record of the prune still verify.
+	records, err := w.AuditLog(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0].Seq != 4 ||
+		records[2].Operation != AuditPrune {
+
+		t.Fatalf("unexpected records after prune %+v", records)
+	}
+
+	// The last record is never pruned.
+	w.SetAuditRetention(AuditRetention{MaxAge: time.Nanosecond}, nil)
+	n, err = w.PruneAuditLog(time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("pruned %d expired records, want 2", n)
+	}
+	records, err = w.AuditLog(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Seq != 6 {
+		t.Fatalf("unexpected records after prune %+v", records)
+	}
+}
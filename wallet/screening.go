@@ -0,0 +1,324 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// ScreeningDirection describes whether a screened transaction sends funds out
+// of or into the wallet.
+type ScreeningDirection uint8
+
+// These constants define the screening directions.
+const (
+	ScreenOutgoing ScreeningDirection = iota
+	ScreenIncoming
+)
+
+// String returns the name of the direction.
+func (d ScreeningDirection) String() string {
+	if d == ScreenIncoming {
+		return "incoming"
+	}
+	return "outgoing"
+}
+
+// ScreeningRequest describes the addresses of a transaction to be checked by
+// an address screening provider.  Outgoing requests list the destination
+// addresses not controlled by the wallet, and incoming requests list the
+// wallet addresses credited by the transaction.
+type ScreeningRequest struct {
+	Direction ScreeningDirection
+	TxHash    chainhash.Hash
+	Addresses []btcutil.Address
+}
+
+// ScreeningResult is the risk category a screening provider assigned to an
+// address.  The meaning of the category is defined by the provider and is
+// mapped to an action by a ScreeningPolicy.
+type ScreeningResult struct {
+	Address string
+	Risk    string
+	Reason  string
+}
+
+// AddressScreener is implemented by compliance providers that assess the
+// risk of transacting with addresses.
+type AddressScreener interface {
+	// Name returns a name identifying the provider in logs.
+	Name() string
+
+	// ScreenAddresses returns the results for the addresses of the
+	// request.  Addresses without a result are considered to carry no
+	// risk category.
+	ScreenAddresses(req *ScreeningRequest) ([]ScreeningResult, error)
+}
+
+// ScreeningAction is the outcome of screening an address.
+type ScreeningAction uint8
+
+// These constants define the screening actions, ordered by severity.
+const (
+	ScreenAllow ScreeningAction = iota
+	ScreenWarn
+	ScreenBlock
+)
+
+// String returns the name of the action.
+func (a ScreeningAction) String() string {
+	switch a {
+	case ScreenAllow:
+		return "allow"
+	case ScreenWarn:
+		return "warn"
+	case ScreenBlock:
+		return "block"
+	default:
+		return fmt.Sprintf("ScreeningAction(%d)", uint8(a))
+	}
+}
+
+// ScreeningPolicy maps the risk categories of a provider to actions.
+// Categories are matched case insensitively, and categories without an
+// explicit action use the default action.  Provider failures use the
+// failure action, so that a policy may fail closed or open.
+type ScreeningPolicy struct {
+	Actions map[string]ScreeningAction
+	Default ScreeningAction
+	Failure ScreeningAction
+}
+
+// Action returns the action for a risk category.
+func (p *ScreeningPolicy) Action(risk string) ScreeningAction {
+	if a, ok := p.Actions[strings.ToLower(risk)]; ok {
+		return a
+	}
+	return p.Default
+}
+
+// ScreeningError describes a transaction blocked by address screening.
+type ScreeningError struct {
+	Address string
+	Risk    string
+	Reason  string
+}
+
+// Error satisfies the error interface.
+func (e *ScreeningError) Error() string {
+	msg := fmt.Sprintf("address %s blocked by screening (risk %q)",
+		e.Address, e.Risk)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// addressScreening holds the configured screening provider.
+type addressScreening struct {
+	mu        sync.Mutex
+	screener  AddressScreener
+	policy    ScreeningPolicy
+	onReceive bool
+
+	// screened records the unmined incoming transactions already
+	// screened so they are not screened again when they are mined.
+	screened map[chainhash.Hash]struct{}
+}
+
+// SetAddressScreener configures the provider used to screen the destination
+// addresses of every transaction before it is broadcast.  When onReceive is
+// set, the wallet addresses of received transactions are also screened, and
+// blocked outputs are locked so they are not spent.  A nil screener disables
+// screening.
+func (w *Wallet) SetAddressScreener(s AddressScreener, policy *ScreeningPolicy,
+	onReceive bool) {
+
+	w.screening.mu.Lock()
+	defer w.screening.mu.Unlock()
+
+	w.screening.screener = s
+	if policy != nil {
+		w.screening.policy = *policy
+	}
+	w.screening.onReceive = onReceive
+	w.screening.screened = make(map[chainhash.Hash]struct{})
+}
+
+// screeningOutcome is the action a policy assigned to a screening result.
+type screeningOutcome struct {
+	ScreeningResult
+	Action ScreeningAction
+}
+
+// screen queries the screening provider and returns the outcome of every
+// address that was not allowed, which is recorded in the audit log.  When
+// the provider fails, every address is given the failure action of the
+// policy.  It must not be called from within a database transaction.
+func (w *Wallet) screen(req *ScreeningRequest) []screeningOutcome {
+	outcomes := w.screeningOutcomes(req)
+	for _, o := range outcomes {
+		w.audit(AuditScreening, "%v address %s in %v transaction %v "+
+			"(risk %q): %s", o.Action, o.Address, req.Direction,
+			req.TxHash, o.Risk, o.Reason)
+	}
+	return outcomes
+}
+
+// screeningOutcomes queries the screening provider and returns the outcome
+// of every address that was not allowed.
+func (w *Wallet) screeningOutcomes(req *ScreeningRequest) []screeningOutcome {
+	w.screening.mu.Lock()
+	screener := w.screening.screener
+	policy := w.screening.policy
+	w.screening.mu.Unlock()
+
+	if screener == nil || len(req.Addresses) == 0 {
+		return nil
+	}
+
+	var outcomes []screeningOutcome
+	results, err := screener.ScreenAddresses(req)
+	if err != nil {
+		log.Errorf("Address screening by %s failed for %v transaction "+
+			"%v: %v", screener.Name(), req.Direction, req.TxHash, err)
+		if policy.Failure == ScreenAllow {
+			return nil
+		}
+		for _, addr := range req.Addresses {
+			outcomes = append(outcomes, screeningOutcome{
+				ScreeningResult: ScreeningResult{
+					Address: addr.EncodeAddress(),
+					Reason:  "screening provider failure",
+				},
+				Action: policy.Failure,
+			})
+		}
+		return outcomes
+	}
+
+	for _, r := range results {
+		a := policy.Action(r.Risk)
+		if a == ScreenAllow {
+			continue
+		}
+		log.Warnf("Address screening by %s returned %v for %s in %v "+
+			"transaction %v (risk %q): %s", screener.Name(), a,
+			r.Address, req.Direction, req.TxHash, r.Risk, r.Reason)
+		outcomes = append(outcomes, screeningOutcome{
+			ScreeningResult: r,
+			Action:          a,
+		})
+	}
+	return outcomes
+}
+
+// screenOutgoing screens the destination addresses of a transaction about to
+// be broadcast, returning a *ScreeningError if any of them is blocked.
+func (w *Wallet) screenOutgoing(tx *wire.MsgTx) error {
+	w.screening.mu.Lock()
+	enabled := w.screening.screener != nil
+	w.screening.mu.Unlock()
+	if !enabled {
+		return nil
+	}
+
+	req := &ScreeningRequest{Direction: ScreenOutgoing, TxHash: tx.TxHash()}
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		for _, txOut := range tx.TxOut {
+			_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+				txOut.PkScript, w.chainParams)
+			if err != nil {
+				continue
+			}
+			for _, addr := range addrs {
+				if _, err := w.Manager.Address(addrmgrNs, addr); err == nil {
+					continue
+				}
+				req.Addresses = append(req.Addresses, addr)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, o := range w.screen(req) {
+		if o.Action == ScreenBlock {
+			return &ScreeningError{
+				Address: o.Address,
+				Risk:    o.Risk,
+				Reason:  o.Reason,
+			}
+		}
+	}
+	return nil
+}
+
+// screenIncoming screens the wallet addresses credited by a received
+// transaction when receive screening is enabled.  Outputs paying to blocked
+// addresses are locked so they are not used by coin selection.  Screening
+// runs in its own goroutine so that the provider does not hold up
+// notification processing.
+func (w *Wallet) screenIncoming(rec *wtxmgr.TxRecord, credited []uint32, mined bool) {
+	w.screening.mu.Lock()
+	enabled := w.screening.screener != nil && w.screening.onReceive
+	if enabled {
+		_, ok := w.screening.screened[rec.Hash]
+		switch {
+		case ok && mined:
+			delete(w.screening.screened, rec.Hash)
+			enabled = false
+		case ok:
+			enabled = false
+		case !mined:
+			w.screening.screened[rec.Hash] = struct{}{}
+		}
+	}
+	w.screening.mu.Unlock()
+	if !enabled || len(credited) == 0 {
+		return
+	}
+
+	req := &ScreeningRequest{Direction: ScreenIncoming, TxHash: rec.Hash}
+	byAddr := make(map[string][]uint32)
+	for _, idx := range credited {
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+			rec.MsgTx.TxOut[idx].PkScript, w.chainParams)
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+		addr := addrs[0]
+		if _, ok := byAddr[addr.EncodeAddress()]; !ok {
+			req.Addresses = append(req.Addresses, addr)
+		}
+		byAddr[addr.EncodeAddress()] = append(byAddr[addr.EncodeAddress()], idx)
+	}
+
+	go func() {
+		for _, o := range w.screen(req) {
+			if o.Action != ScreenBlock {
+				continue
+			}
+			for _, idx := range byAddr[o.Address] {
+				op := wire.OutPoint{Hash: rec.Hash, Index: idx}
+				w.LockOutpoint(op)
+				log.Warnf("Locked output %v received by blocked "+
+					"address %s", op, o.Address)
+			}
+		}
+	}()
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+)
+
+type testScreener struct {
+	results []ScreeningResult
+	err     error
+}
+
+func (s *testScreener) Name() string { return "test" }
+
+func (s *testScreener) ScreenAddresses(*ScreeningRequest) ([]ScreeningResult, error) {
+	return s.results, s.err
+}
+
+// TestScreeningPolicy checks that screening results are mapped to actions by
+// the policy and recorded in the audit log, and that provider failures use
+// the failure action.
+func TestScreeningPolicy(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := btcutil.NewAddressPubKeyHash(make([]byte, 20),
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &ScreeningRequest{Addresses: []btcutil.Address{addr}}
+	policy := &ScreeningPolicy{
+		Actions: map[string]ScreeningAction{
+			"sanctions": ScreenBlock,
+			"gambling":  ScreenWarn,
+		},
+		Default: ScreenAllow,
+		Failure: ScreenBlock,
+	}
+
+	if outcomes := w.screen(req); outcomes != nil {
+		t.Errorf("screened without a screener: %v", outcomes)
+	}
+
+	s := &testScreener{results: []ScreeningResult{
+		{Address: "a", Risk: "Sanctions"},
+		{Address: "b", Risk: "gambling"},
+		{Address: "c", Risk: "exchange"},
+	}}
+	w.SetAddressScreener(s, policy, false)
+	outcomes := w.screen(req)
+	if len(outcomes) != 2 {
+		t.Fatalf("got %d outcomes, want 2", len(outcomes))
+	}
+	if outcomes[0].Address != "a" || outcomes[0].Action != ScreenBlock {
+		t.Errorf("outcome %v, want a blocked", outcomes[0])
+	}
+	if outcomes[1].Address != "b" || outcomes[1].Action != ScreenWarn {
+		t.Errorf("outcome %v, want b warned", outcomes[1])
+	}
+	records, err := w.AuditLog(1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var details []string
+	for _, r := range records {
+		if r.Operation == AuditScreening {
+			details = append(details, r.Details)
+		}
+	}
+	if len(details) != 2 ||
+		!strings.HasPrefix(details[0], "block address a in outgoing") ||
+		!strings.HasPrefix(details[1], "warn address b in outgoing") {
+
+		t.Errorf("unexpected screening audit records %q", details)
+	}
+
+	s.err = errors.New("unavailable")
+	outcomes = w.screen(req)
+	if len(outcomes) != 1 || outcomes[0].Action != ScreenBlock ||
+		outcomes[0].Address != addr.EncodeAddress() {
+		t.Errorf("failure outcomes %v, want %v blocked", outcomes,
+			addr.EncodeAddress())
+	}
+	policy.Failure = ScreenAllow
+	w.SetAddressScreener(s, policy, false)
+	if outcomes := w.screen(req); outcomes != nil {
+		t.Errorf("failure outcomes %v, want none when failing open",
+			outcomes)
+	}
+}
+
+// TestHTTPScreener checks the requests and responses of HTTPScreener.
+func TestHTTPScreener(t *testing.T) {
+	addr, err := btcutil.NewAddressPubKeyHash(make([]byte, 20),
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req httpScreeningRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.Direction != "outgoing" || len(req.Addresses) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"results":[{"address":"` + req.Addresses[0] +
+			`","risk":"sanctions","reason":"listed"}]}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTPScreener("ftp://example.com", ""); err == nil {
+		t.Errorf("accepted non-HTTP provider URL")
+	}
+
+	s, err := NewHTTPScreener(server.URL, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &ScreeningRequest{Addresses: []btcutil.Address{addr}}
+	results, err := s.ScreenAddresses(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Address != addr.EncodeAddress() ||
+		results[0].Risk != "sanctions" || results[0].Reason != "listed" {
+		t.Errorf("unexpected results %v", results)
+	}
+
+	s.apiKey = "wrong"
+	if _, err := s.ScreenAddresses(req); err == nil {
+		t.Errorf("no error for unauthorized request")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// The script store maps the hash committed to by a P2SH or P2WSH output to
+// the redeem or witness script needed to spend it.  P2SH redeem scripts are
+// keyed by their 20 byte HASH160 and P2WSH witness scripts by their 32 byte
+// SHA256, so the key length identifies the output type.  A P2SH-P2WSH
+// output is spent by chaining both lookups: the P2SH redeem script is the
+// P2WSH witness program.
+
+// errScriptNotFound is returned when a script hash is not in the script
+// store.
+var errScriptNotFound = errors.New("script not found in script store")
+
+// putRedeemScript adds a P2SH redeem script to the script store.
+func putRedeemScript(ns walletdb.ReadWriteBucket, script []byte) error {
+	return ns.Put(btcutil.Hash160(script), script)
+}
+
+// putWitnessScript adds a P2WSH witness script to the script store, along
+// with the witness program redeeming the P2SH-P2WSH output.
+func putWitnessScript(ns walletdb.ReadWriteBucket, script []byte) ([]byte, error) {
+	scriptHash := sha256.Sum256(script)
+	err := ns.Put(scriptHash[:], script)
+	if err != nil {
+		return nil, err
+	}
+	program, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(scriptHash[:]).Script()
+	if err != nil {
+		return nil, err
+	}
+	return program, putRedeemScript(ns, program)
+}
+
+// fetchScript returns the script stored for the script hash of a P2SH or
+// P2WSH address.
+func fetchScript(ns walletdb.ReadBucket, addr btcutil.Address) ([]byte, error) {
+	switch addr.(type) {
+	case *btcutil.AddressScriptHash, *btcutil.AddressWitnessScriptHash:
+	default:
+		return nil, errScriptNotFound
+	}
+	script := ns.Get(addr.ScriptAddress())
+	if script == nil {
+		return nil, errScriptNotFound
+	}
+	return append([]byte(nil), script...), nil
+}
+
+// forEachScriptAddress calls fn with the P2SH or P2WSH address of every
+// script in the script store.
+func forEachScriptAddress(ns walletdb.ReadBucket, params *chaincfg.Params,
+	fn func(btcutil.Address) error) error {
+
+	return ns.ForEach(func(k, v []byte) error {
+		var addr btcutil.Address
+		var err error
+		switch len(k) {
+		case 20:
+			addr, err = btcutil.NewAddressScriptHashFromHash(k, params)
+		case 32:
+			addr, err = btcutil.NewAddressWitnessScriptHash(k, params)
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return fn(addr)
+	})
+}
+
+// ImportWitnessScript adds a P2WSH witness script to the script store so
+// that outputs paying to its P2WSH and P2SH-P2WSH addresses are tracked by
+// the imported account and can be spent.  Both addresses are returned.
+func (w *Wallet) ImportWitnessScript(script []byte) (*btcutil.AddressWitnessScriptHash,
+	*btcutil.AddressScriptHash, error) {
+
+	var program []byte
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		var err error
+		program, err = putWitnessScript(
+			tx.ReadWriteBucket(wscriptNamespaceKey), script,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scriptHash := sha256.Sum256(script)
+	p2wshAddr, err := btcutil.NewAddressWitnessScriptHash(scriptHash[:],
+		w.chainParams)
+	if err != nil {
+		return nil, nil, err
+	}
+	p2shAddr, err := btcutil.NewAddressScriptHash(program, w.chainParams)
+	if err != nil {
+		return nil, nil, err
+	}
+	w.audit(AuditImport, "witness script of %s imported", p2wshAddr)
+
+	if chainClient := w.ChainClient(); chainClient != nil {
+		err := chainClient.NotifyReceived(
+			[]btcutil.Address{p2wshAddr, p2shAddr},
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return p2wshAddr, p2shAddr, nil
+}
+
+// addrAccount returns the account of a wallet address.  Addresses of scripts
+// in the script store that are unknown to the address manager belong to the
+// imported account, and watch-only addresses to the account they were
+// imported to.
+func (w *Wallet) addrAccount(dbtx walletdb.ReadTx, addr btcutil.Address) (uint32, error) {
+	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+	_, account, err := w.Manager.AddrAccount(addrmgrNs, addr)
+	if err != nil {
+		scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+		if _, serr := fetchScript(scriptNs, addr); serr == nil {
+			return waddrmgr.ImportedAddrAccount, nil
+		}
+		watchNs := dbtx.ReadBucket(wwatchNamespaceKey)
+		_, watchedAcct, werr := watchedAddressAccount(watchNs, addr)
+		if werr == nil {
+			return watchedAcct, nil
+		}
+	}
+	return account, err
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// TestImportWitnessScript checks that an imported witness script is found by
+// the P2WSH and P2SH-P2WSH addresses paying to it, which belong to the
+// imported account.
+func TestImportWitnessScript(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	keys := make([]btcutil.Address, 2)
+	for i := range keys {
+		var err error
+		keys[i], err = w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	script, err := w.MakeMultiSigScript(keys, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.MakeMultiSigScript(keys, 3)
+	if _, ok := err.(*MultiSigScriptError); !ok {
+		t.Errorf("3 of 2 signatures: got %v, want a MultiSigScriptError",
+			err)
+	}
+	p2wshAddr, p2shAddr, err := w.ImportWitnessScript(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, err := txscript.PayToAddrScript(p2wshAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownAddr, err := btcutil.NewAddressScriptHash(
+		[]byte{txscript.OP_TRUE}, w.ChainParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		ns := dbtx.ReadBucket(wscriptNamespaceKey)
+		tests := []struct {
+			addr   btcutil.Address
+			script []byte
+		}{
+			{p2wshAddr, script},
+			{p2shAddr, program},
+		}
+		for _, test := range tests {
+			stored, err := fetchScript(ns, test.addr)
+			if err != nil {
+				t.Errorf("%v: %v", test.addr, err)
+			} else if !bytes.Equal(stored, test.script) {
+				t.Errorf("%v: script %x, want %x", test.addr,
+					stored, test.script)
+			}
+			account, err := w.addrAccount(dbtx, test.addr)
+			if err != nil || account != waddrmgr.ImportedAddrAccount {
+				t.Errorf("%v: account %d (%v), want the imported "+
+					"account", test.addr, account, err)
+			}
+		}
+		if _, err := fetchScript(ns, unknownAddr); err != errScriptNotFound {
+			t.Errorf("unknown script: got %v, want %v", err,
+				errScriptNotFound)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMultiSigFeeRate checks that transactions spending multisig outputs of
+// the script store pay at least the requested fee rate.
+func TestMultiSigFeeRate(t *testing.T) {
+	const feeSatPerKb = 1e4
+
+	tests := []struct {
+		name         string
+		importScript func(w *Wallet, script []byte) (btcutil.Address, error)
+	}{{
+		name: "p2sh",
+		importScript: func(w *Wallet, script []byte) (btcutil.Address, error) {
+			return w.ImportP2SHRedeemScript(script)
+		},
+	}, {
+		name: "p2wsh",
+		importScript: func(w *Wallet, script []byte) (btcutil.Address, error) {
+			addr, _, err := w.ImportWitnessScript(script)
+			return addr, err
+		},
+	}, {
+		name: "p2sh-p2wsh",
+		importScript: func(w *Wallet, script []byte) (btcutil.Address, error) {
+			_, addr, err := w.ImportWitnessScript(script)
+			return addr, err
+		},
+	}}
+	for _, test := range tests {
+		w, cleanup := testWallet(t)
+
+		// The 2-of-3 multisig script is signed with wallet keys.
+		keys := make([]btcutil.Address, 3)
+		for i := range keys {
+			var err error
+			keys[i], err = w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+			if err != nil {
+				cleanup()
+				t.Fatal(err)
+			}
+		}
+		script, err := w.MakeMultiSigScript(keys, 2)
+		if err != nil {
+			cleanup()
+			t.Fatal(err)
+		}
+		addr, err := test.importScript(w, script)
+		if err != nil {
+			cleanup()
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			cleanup()
+			t.Fatal(err)
+		}
+		fundTestWallet(t, w, pkScript, 1e8)
+
+		outputs := []*wire.TxOut{wire.NewTxOut(1e7, pkScript)}
+		tx, err := w.txToOutputs(outputs, waddrmgr.ImportedAddrAccount, 0,
+			feeSatPerKb, nil, true, false)
+		cleanup()
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		var outputValue btcutil.Amount
+		for _, txOut := range tx.Tx.TxOut {
+			outputValue += btcutil.Amount(txOut.Value)
+		}
+		fee := tx.TotalInput - outputValue
+		weight := blockchain.GetTransactionWeight(btcutil.NewTx(tx.Tx))
+		vsize := int((weight + blockchain.WitnessScaleFactor - 1) /
+			blockchain.WitnessScaleFactor)
+		if minFee := txrules.FeeForSerializeSize(feeSatPerKb, vsize); fee < minFee {
+			t.Errorf("%s: fee %v for %d vbytes is below the fee rate, "+
+				"want at least %v", test.name, fee, vsize, minFee)
+		}
+	}
+}
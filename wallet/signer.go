@@ -0,0 +1,100 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/psbt"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// Signer signs transactions for an account whose private keys are held
+// outside of the wallet, such as on a hardware wallet.  The wallet only holds
+// the extended public key of such an account, so it can be watching-only.
+type Signer interface {
+	// SignPsbt returns the packet with partial signatures added for the
+	// inputs the signer holds keys for.  The wallet fills in the spent
+	// outputs and the BIP0032 derivation of every wallet key before the
+	// packet is passed to the signer.
+	SignPsbt(packet *psbt.Packet) (*psbt.Packet, error)
+}
+
+// accountSigners are the signers which replace the wallet's private keys for
+// the transactions created by some accounts.
+type accountSigners struct {
+	mu      sync.Mutex
+	signers map[uint32]Signer
+}
+
+// SetAccountSigner configures the signer used to sign the transactions
+// spending from an account instead of the private keys of the wallet.  The
+// wallet does not need to be unlocked to create these transactions.  A nil
+// signer restores signing with the wallet's private keys.
+func (w *Wallet) SetAccountSigner(account uint32, s Signer) {
+	w.signers.mu.Lock()
+	defer w.signers.mu.Unlock()
+
+	if s == nil {
+		delete(w.signers.signers, account)
+		return
+	}
+	if w.signers.signers == nil {
+		w.signers.signers = make(map[uint32]Signer)
+	}
+	w.signers.signers[account] = s
+}
+
+// AccountSigner returns the signer of an account, or nil when the account is
+// signed with the wallet's private keys.
+func (w *Wallet) AccountSigner(account uint32) Signer {
+	w.signers.mu.Lock()
+	defer w.signers.mu.Unlock()
+	return w.signers.signers[account]
+}
+
+// newSignerPsbt returns a packet for the unsigned transaction with the
+// information signers need to sign the wallet's inputs.
+func (w *Wallet) newSignerPsbt(dbtx walletdb.ReadTx, tx *wire.MsgTx) (*psbt.Packet, error) {
+	packet, err := psbt.New(tx.Copy())
+	if err != nil {
+		return nil, err
+	}
+	if err := w.updatePsbt(dbtx, packet); err != nil {
+		return nil, err
+	}
+	return packet, nil
+}
+
+// signWithSigner has the signer sign the packet of tx and copies the
+// finalized input scripts to tx.  Every input must be signed.
+//
+// Signers may wait for the user to confirm the transaction on a device, so
+// this must not be called with a database transaction open.
+func signWithSigner(s Signer, packet *psbt.Packet, tx *wire.MsgTx) error {
+	signed, err := s.SignPsbt(packet)
+	if err != nil {
+		return err
+	}
+	if signed.UnsignedTx.TxHash() != tx.TxHash() {
+		return errors.New("signer returned a different transaction")
+	}
+
+	signed.FinalizeAll()
+	final, err := signed.Extract()
+	if err == psbt.ErrNotFinalized {
+		return errors.New("signer did not sign every input")
+	}
+	if err != nil {
+		return err
+	}
+	for i, txIn := range final.TxIn {
+		tx.TxIn[i].SignatureScript = txIn.SignatureScript
+		tx.TxIn[i].Witness = txIn.Witness
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/psbt"
+)
+
+// testSigner signs P2PKH inputs with a single key.
+type testSigner struct {
+	key *btcec.PrivateKey
+}
+
+func (s *testSigner) SignPsbt(p *psbt.Packet) (*psbt.Packet, error) {
+	if s.key == nil {
+		return p, nil
+	}
+	for i := range p.Inputs {
+		prevOut, err := p.PrevOutput(i)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := txscript.RawTxInSignature(p.UnsignedTx, i,
+			prevOut.PkScript, txscript.SigHashAll, s.key)
+		if err != nil {
+			return nil, err
+		}
+		err = p.Inputs[i].AddPartialSig(
+			s.key.PubKey().SerializeCompressed(), sig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// TestSignWithSigner checks that the inputs signed by an account signer are
+// copied to the transaction, and that unsigned inputs are an error.
+func TestSignWithSigner(t *testing.T) {
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{1}, 32))
+	addr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(key.PubKey().SerializeCompressed()),
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevTx := wire.NewMsgTx(wire.TxVersion)
+	prevTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(100000, pkScript))
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	prevHash := prevTx.TxHash()
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(90000, pkScript))
+
+	newPacket := func() *psbt.Packet {
+		p, err := psbt.New(tx.Copy())
+		if err != nil {
+			t.Fatalf("psbt.New: %v", err)
+		}
+		p.Inputs[0].NonWitnessUtxo = prevTx
+		return p
+	}
+
+	w := &Wallet{}
+	if w.AccountSigner(0) != nil {
+		t.Fatalf("account has a signer before one is set")
+	}
+	w.SetAccountSigner(0, &testSigner{})
+	if err := signWithSigner(w.AccountSigner(0), newPacket(), tx); err == nil {
+		t.Fatalf("transaction with unsigned inputs was accepted")
+	}
+
+	w.SetAccountSigner(0, &testSigner{key: key})
+	if err := signWithSigner(w.AccountSigner(0), newPacket(), tx); err != nil {
+		t.Fatalf("signWithSigner: %v", err)
+	}
+	err = validateMsgTx(tx, [][]byte{pkScript},
+		[]btcutil.Amount{btcutil.Amount(prevTx.TxOut[0].Value)})
+	if err != nil {
+		t.Errorf("signed transaction does not validate: %v", err)
+	}
+
+	w.SetAccountSigner(0, nil)
+	if w.AccountSigner(0) != nil {
+		t.Errorf("signer was not removed")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/zero"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// messageHash returns the hash of a message signed with signmessage, which
+// is prefixed so that signatures of messages cannot be transaction
+// signatures.
+func messageHash(message string) []byte {
+	var buf bytes.Buffer
+	wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
+	wire.WriteVarString(&buf, 0, message)
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+// signMessage returns the compact signature of a message by a private key.
+// The signature records whether the public key of the signing address is
+// compressed, so that verifiers recover the same serialized key.
+func signMessage(privKey *btcec.PrivateKey, message string, compressed bool) ([]byte, error) {
+	return btcec.SignCompact(btcec.S256(), privKey, messageHash(message),
+		compressed)
+}
+
+// SignMessage returns the compact signature of a message by the private key
+// of a wallet address, with the compressed flag of the address.
+func (w *Wallet) SignMessage(addr btcutil.Address, message string) ([]byte, error) {
+	var sig []byte
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		managedAddr, err := w.Manager.Address(addrmgrNs, addr)
+		if err != nil {
+			return err
+		}
+		pka, ok := managedAddr.(waddrmgr.ManagedPubKeyAddress)
+		if !ok {
+			return errors.New("address does not have an associated private key")
+		}
+		privKey, err := pka.PrivKey()
+		if err != nil {
+			return err
+		}
+		defer zero.BigInt(privKey.D)
+		w.recordKeyUse(pka)
+		sig, err = signMessage(privKey, message, pka.Compressed())
+		return err
+	})
+	return sig, err
+}
+
+// VerifyMessage returns whether a compact signature of a message was made by
+// the key of an address.  The signature must be made with the key serialized
+// as the address commits to it: P2PKH addresses commit to the compressed or
+// uncompressed key given by the signature, P2PK addresses to their own
+// serialization, and P2WPKH and nested P2WPKH addresses only to compressed
+// keys.
+func VerifyMessage(addr btcutil.Address, sig []byte, message string,
+	params *chaincfg.Params) (bool, error) {
+
+	pubKey, compressed, err := btcec.RecoverCompact(btcec.S256(), sig,
+		messageHash(message))
+	if err != nil {
+		return false, err
+	}
+	serializedPubKey := pubKey.SerializeUncompressed()
+	if compressed {
+		serializedPubKey = pubKey.SerializeCompressed()
+	}
+
+	switch addr := addr.(type) {
+	case *btcutil.AddressPubKeyHash:
+		return bytes.Equal(btcutil.Hash160(serializedPubKey),
+			addr.Hash160()[:]), nil
+	case *btcutil.AddressPubKey:
+		return bytes.Equal(serializedPubKey, addr.ScriptAddress()), nil
+	case *btcutil.AddressWitnessPubKeyHash:
+		return compressed && bytes.Equal(btcutil.Hash160(serializedPubKey),
+			addr.ScriptAddress()), nil
+	case *btcutil.AddressScriptHash:
+		// Only nested P2WPKH scripts can be verified.
+		if !compressed {
+			return false, nil
+		}
+		witnessAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+			btcutil.Hash160(serializedPubKey), params)
+		if err != nil {
+			return false, err
+		}
+		witnessScript, err := txscript.PayToAddrScript(witnessAddr)
+		if err != nil {
+			return false, err
+		}
+		return bytes.Equal(btcutil.Hash160(witnessScript),
+			addr.ScriptAddress()), nil
+	default:
+		return false, errors.New("address type not supported")
+	}
+}
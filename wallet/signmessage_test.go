@@ -0,0 +1,99 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+)
+
+// TestVerifyMessage checks that signatures are verified against the key
+// serialization each kind of address commits to.
+func TestVerifyMessage(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey := privKey.PubKey()
+	compressedHash := btcutil.Hash160(pubKey.SerializeCompressed())
+
+	p2pkh, err := btcutil.NewAddressPubKeyHash(compressedHash, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncompressedP2PKH, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(pubKey.SerializeUncompressed()), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2pk, err := btcutil.NewAddressPubKey(pubKey.SerializeUncompressed(),
+		params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(compressedHash,
+		params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witnessScript, err := txscript.PayToAddrScript(p2wpkh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested, err := btcutil.NewAddressScriptHash(witnessScript, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const message = "message"
+	compressedSig, err := signMessage(privKey, message, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncompressedSig, err := signMessage(privKey, message, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		addr  btcutil.Address
+		sig   []byte
+		valid bool
+	}{
+		{"p2pkh", p2pkh, compressedSig, true},
+		{"p2pkh with uncompressed signature", p2pkh, uncompressedSig, false},
+		{"uncompressed p2pkh", uncompressedP2PKH, uncompressedSig, true},
+		{"uncompressed p2pkh with compressed signature",
+			uncompressedP2PKH, compressedSig, false},
+		{"p2pk", p2pk, uncompressedSig, true},
+		{"p2pk with compressed signature", p2pk, compressedSig, false},
+		{"p2wpkh", p2wpkh, compressedSig, true},
+		{"p2wpkh with uncompressed signature", p2wpkh, uncompressedSig, false},
+		{"nested p2wpkh", nested, compressedSig, true},
+	}
+	for _, test := range tests {
+		valid, err := VerifyMessage(test.addr, test.sig, message, params)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if valid != test.valid {
+			t.Errorf("%s: valid is %v, want %v", test.name, valid,
+				test.valid)
+		}
+	}
+
+	valid, err := VerifyMessage(p2pkh, compressedSig, "other", params)
+	if err != nil || valid {
+		t.Errorf("signature of another message returned %v, %v", valid,
+			err)
+	}
+}
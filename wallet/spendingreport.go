@@ -0,0 +1,198 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/ledger"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// txLabelBucketKey is the key of the bucket in the transaction metadata
+// namespace holding the labels of the wallet transactions, keyed by their
+// hash.
+var txLabelBucketKey = []byte("txlabels")
+
+// ErrUnknownTx describes an error where a transaction which is not recorded by
+// the wallet is labeled.
+var ErrUnknownTx = errors.New("transaction is not a wallet transaction")
+
+// SetTxLabel sets the label of a wallet transaction, such as a bookkeeping
+// category, used to group the transactions of spending reports.  An empty
+// label removes the label.  ErrUnknownTx is returned when the transaction is
+// not recorded by the wallet.
+func (w *Wallet) SetTxLabel(txHash *chainhash.Hash, label string) error {
+	return walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		txmgrNs := dbtx.ReadWriteBucket(wtxmgrNamespaceKey)
+		details, err := w.TxStore.TxDetails(txmgrNs, txHash)
+		if err != nil {
+			return err
+		}
+		if details == nil {
+			return ErrUnknownTx
+		}
+
+		ns := dbtx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket, err := ns.CreateBucketIfNotExists(txLabelBucketKey)
+		if err != nil {
+			return err
+		}
+		if label == "" {
+			return bucket.Delete(txHash[:])
+		}
+		return bucket.Put(txHash[:], []byte(label))
+	})
+}
+
+// TxLabel returns the label of a wallet transaction, or an empty string when
+// it is not labeled.
+func (w *Wallet) TxLabel(txHash *chainhash.Hash) (string, error) {
+	var label string
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		label = txLabel(dbtx, txHash)
+		return nil
+	})
+	return label, err
+}
+
+func txLabel(dbtx walletdb.ReadTx, txHash *chainhash.Hash) string {
+	bucket := dbtx.ReadBucket(wtxmetaNamespaceKey).
+		NestedReadBucket(txLabelBucketKey)
+	if bucket == nil {
+		return ""
+	}
+	return string(bucket.Get(txHash[:]))
+}
+
+// SpendingCategory sums the transactions of a spending report with the same
+// label.  Sent excludes the fees, which are only known for the transactions
+// spending wallet outputs only.
+type SpendingCategory struct {
+	Label        string
+	Received     btcutil.Amount
+	ReceiveCount int
+	Sent         btcutil.Amount
+	SendCount    int
+	Fees         btcutil.Amount
+}
+
+// add sums a transaction into the category.
+func (c *SpendingCategory) add(received, sent, fee btcutil.Amount) {
+	if received > 0 {
+		c.Received += received
+		c.ReceiveCount++
+	}
+	if sent > 0 {
+		c.Sent += sent
+		c.SendCount++
+	}
+	c.Fees += fee
+}
+
+// SpendingReport sums the funds received and sent by the wallet over a period,
+// by transaction label.
+type SpendingReport struct {
+	Start      time.Time
+	End        time.Time
+	Categories []SpendingCategory
+	Total      SpendingCategory
+}
+
+// SpendingReport returns the funds of a token received and sent by the mined
+// transactions of the wallet whose block time is in [start, end), summed by
+// transaction label.  Transactions without label are summed in the category
+// with an empty label.  When account is not empty, only the transactions
+// debiting or crediting the named account are summed, in full.  Transfers
+// between wallet accounts are neither received nor sent, but their fees are
+// summed.  Categories are ordered by label.
+func (w *Wallet) SpendingReport(start, end time.Time, account string,
+	token wire.TokenIdentity) (*SpendingReport, error) {
+
+	report := &SpendingReport{Start: start, End: end}
+	categories := make(map[string]*SpendingCategory)
+	commodity := token.String()
+
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+
+		rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
+			for i := range details {
+				// Unmined transactions have no date yet.
+				d := &details[i]
+				if d.Block.Height == -1 || d.Block.Time.Before(start) ||
+					!d.Block.Time.Before(end) {
+
+					continue
+				}
+				entry, err := w.ledgerEntry(dbtx, d)
+				if err != nil {
+					return false, err
+				}
+
+				received, sent, fee, touched := spendingAmounts(entry,
+					commodity, account)
+				if !touched || received == 0 && sent == 0 && fee == 0 {
+					continue
+				}
+
+				label := txLabel(dbtx, &d.Hash)
+				c, ok := categories[label]
+				if !ok {
+					c = &SpendingCategory{Label: label}
+					categories[label] = c
+				}
+				c.add(received, sent, fee)
+				report.Total.add(received, sent, fee)
+			}
+			return false, nil
+		}
+		return w.TxStore.RangeTransactions(txmgrNs, 0, -1, rangeFn)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range categories {
+		report.Categories = append(report.Categories, *c)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		return report.Categories[i].Label < report.Categories[j].Label
+	})
+	return report, nil
+}
+
+// spendingAmounts returns the amounts of a commodity received from and sent to
+// other wallets by the transaction of a ledger entry, and its fee.  touched
+// reports whether the entry debits or credits the named wallet account, and is
+// always true when account is empty.
+func spendingAmounts(entry *ledger.Entry, commodity, account string) (received,
+	sent, fee btcutil.Amount, touched bool) {
+
+	walletAccount := ledger.WalletAccount(account)
+	touched = account == ""
+	for _, p := range entry.Postings {
+		if p.Commodity != commodity {
+			continue
+		}
+		switch p.Account {
+		case ledger.ReceivedAccount:
+			received -= btcutil.Amount(p.Amount)
+		case ledger.SentAccount:
+			sent += btcutil.Amount(p.Amount)
+		case ledger.FeesAccount:
+			fee += btcutil.Amount(p.Amount)
+		case walletAccount:
+			touched = true
+		}
+	}
+	return received, sent, fee, touched
+}
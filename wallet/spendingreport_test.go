@@ -0,0 +1,66 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/ledger"
+)
+
+// TestSpendingAmounts checks that the received, sent and fee amounts of ledger
+// entries are summed by commodity, and that account filters only match the
+// entries posting to the account.
+func TestSpendingAmounts(t *testing.T) {
+	payroll := ledger.WalletAccount("payroll")
+	def := ledger.WalletAccount("default")
+	send := &ledger.Entry{Postings: []ledger.Posting{
+		{Account: payroll, Amount: -5000, Commodity: "STB"},
+		{Account: payroll, Amount: 1500, Commodity: "STB"},
+		{Account: ledger.FeesAccount, Amount: 500, Commodity: "STB"},
+	}}
+	send.Balance()
+	receive := &ledger.Entry{Postings: []ledger.Posting{
+		{Account: def, Amount: 2000, Commodity: "STB"},
+		{Account: def, Amount: 700, Commodity: "NDR"},
+	}}
+	receive.Balance()
+
+	tests := []struct {
+		name                string
+		entry               *ledger.Entry
+		commodity, account  string
+		received, sent, fee btcutil.Amount
+		touched             bool
+	}{
+		{"send", send, "STB", "", 0, 3000, 500, true},
+		{"send from account", send, "STB", "payroll", 0, 3000, 500, true},
+		{"send from other account", send, "STB", "default", 0, 3000, 500, false},
+		{"receive", receive, "STB", "", 2000, 0, 0, true},
+		{"receive token", receive, "NDR", "default", 700, 0, 0, true},
+		{"receive other token", send, "NDR", "", 0, 0, 0, true},
+	}
+	for _, test := range tests {
+		received, sent, fee, touched := spendingAmounts(test.entry,
+			test.commodity, test.account)
+		if received != test.received || sent != test.sent ||
+			fee != test.fee || touched != test.touched {
+
+			t.Errorf("%s: got %v received, %v sent, %v fee, touched %v",
+				test.name, received, sent, fee, touched)
+		}
+	}
+
+	var c SpendingCategory
+	c.add(0, 3000, 500)
+	c.add(2000, 0, 0)
+	c.add(0, 0, 100)
+	if c.Received != 2000 || c.ReceiveCount != 1 || c.Sent != 3000 ||
+		c.SendCount != 1 || c.Fees != 600 {
+
+		t.Errorf("unexpected category sums %+v", c)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// StateBalance is the balance of a token in the unspent outputs of an account.
+type StateBalance struct {
+	Account string
+	Token   string
+	Amount  btcutil.Amount
+}
+
+// StateCommitment is a commitment to the unspent outputs and balances of the
+// wallet at a block height.  The unspent outputs and the balances are the
+// leaves of two merkle trees, and Root commits to both trees together with
+// the block and the last record of the audit log when the commitment was
+// produced.  Recomputing the commitment of the same height later returns the
+// same roots unless the wallet history of that height was altered.
+type StateCommitment struct {
+	Height      int32
+	BlockHash   chainhash.Hash
+	Utxos       int
+	UtxoRoot    chainhash.Hash
+	Balances    []StateBalance
+	BalanceRoot chainhash.Hash
+	AuditSeq    uint64
+	AuditHash   [sha256.Size]byte
+	Root        chainhash.Hash
+}
+
+// stateUtxo is an output of the wallet unspent at the height of a state
+// commitment.
+type stateUtxo struct {
+	outPoint wire.OutPoint
+	height   int32
+	amount   btcutil.Amount
+	pkScript []byte
+}
+
+// leaf returns the merkle tree leaf of the output, the double SHA256 hash of
+// its outpoint, block height, amount and output script.
+func (u *stateUtxo) leaf() chainhash.Hash {
+	var buf bytes.Buffer
+	buf.Write(u.outPoint.Hash[:])
+	binary.Write(&buf, binary.LittleEndian, u.outPoint.Index)
+	binary.Write(&buf, binary.LittleEndian, u.height)
+	binary.Write(&buf, binary.LittleEndian, int64(u.amount))
+	wire.WriteVarBytes(&buf, 0, u.pkScript)
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// leaf returns the merkle tree leaf of the balance, the double SHA256 hash of
+// its account, token and amount.
+func (b *StateBalance) leaf() chainhash.Hash {
+	var buf bytes.Buffer
+	wire.WriteVarString(&buf, 0, b.Account)
+	wire.WriteVarString(&buf, 0, b.Token)
+	binary.Write(&buf, binary.LittleEndian, int64(b.Amount))
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// merkleRoot returns the root of the merkle tree of the leaves, duplicating
+// the last node of levels with an odd number of nodes like the transaction
+// trees of blocks.  The root of an empty tree is the zero hash.  Since
+// duplicating the last leaf does not change the root, commitments include the
+// number of leaves next to the root.
+func merkleRoot(leaves []chainhash.Hash) chainhash.Hash {
+	if len(leaves) == 0 {
+		return chainhash.Hash{}
+	}
+	level := append([]chainhash.Hash(nil), leaves...)
+	for len(level) > 1 {
+		if len(level)%2 != 0 {
+			level = append(level, level[len(level)-1])
+		}
+		next := level[:len(level)/2]
+		for i := range next {
+			var pair [2 * chainhash.HashSize]byte
+			copy(pair[:], level[2*i][:])
+			copy(pair[chainhash.HashSize:], level[2*i+1][:])
+			next[i] = chainhash.DoubleHashH(pair[:])
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// root returns the commitment to the block, both merkle trees and the audit
+// log.
+func (c *StateCommitment) root() chainhash.Hash {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, c.Height)
+	buf.Write(c.BlockHash[:])
+	binary.Write(&buf, binary.LittleEndian, uint32(c.Utxos))
+	buf.Write(c.UtxoRoot[:])
+	binary.Write(&buf, binary.LittleEndian, uint32(len(c.Balances)))
+	buf.Write(c.BalanceRoot[:])
+	binary.Write(&buf, binary.LittleEndian, c.AuditSeq)
+	buf.Write(c.AuditHash[:])
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// StateCommitment returns the commitment to the outputs of the wallet unspent
+// at a block height, and to the balances of its accounts at that height.  A
+// negative height commits to the block the wallet is synced to.  Only the
+// transactions recorded by the wallet are committed to, so the commitment of
+// a height changes after transactions of that height are pruned.
+func (w *Wallet) StateCommitment(height int32) (*StateCommitment, error) {
+	synced := w.Manager.SyncedTo()
+	if height < 0 {
+		height = synced.Height
+	}
+	if height > synced.Height {
+		return nil, fmt.Errorf("wallet is only synced to height %d",
+			synced.Height)
+	}
+
+	c := &StateCommitment{Height: height}
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+		metaNs := dbtx.ReadBucket(wtxmetaNamespaceKey)
+
+		blockHash, err := w.Manager.BlockHash(addrmgrNs, height)
+		if err != nil {
+			return err
+		}
+		c.BlockHash = *blockHash
+
+		// Outputs may be spent by transactions of the same block which
+		// are ranged before them, so spends are only removed once all
+		// transactions were ranged.
+		utxos := make(map[wire.OutPoint]*stateUtxo)
+		spent := make(map[wire.OutPoint]struct{})
+		rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
+			for i := range details {
+				d := &details[i]
+				for _, cred := range d.Credits {
+					op := wire.OutPoint{Hash: d.Hash, Index: cred.Index}
+					utxos[op] = &stateUtxo{
+						outPoint: op,
+						height:   d.Block.Height,
+						amount:   cred.Amount,
+						pkScript: d.MsgTx.TxOut[cred.Index].PkScript,
+					}
+				}
+				for _, txIn := range d.MsgTx.TxIn {
+					spent[txIn.PreviousOutPoint] = struct{}{}
+				}
+			}
+			return false, nil
+		}
+		err = w.TxStore.RangeTransactions(txmgrNs, 0, height, rangeFn)
+		if err != nil {
+			return err
+		}
+		for op := range spent {
+			delete(utxos, op)
+		}
+
+		sorted := make([]*stateUtxo, 0, len(utxos))
+		for _, u := range utxos {
+			sorted = append(sorted, u)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			a, b := &sorted[i].outPoint, &sorted[j].outPoint
+			if cmp := bytes.Compare(a.Hash[:], b.Hash[:]); cmp != 0 {
+				return cmp < 0
+			}
+			return a.Index < b.Index
+		})
+		leaves := make([]chainhash.Hash, 0, len(sorted))
+		balances := make(map[[2]string]btcutil.Amount)
+		for _, u := range sorted {
+			leaves = append(leaves, u.leaf())
+			account := w.scriptAccountName(dbtx, u.pkScript)
+			token := wire.TokenID(u.pkScript).String()
+			balances[[2]string{account, token}] += u.amount
+		}
+		c.Utxos = len(leaves)
+		c.UtxoRoot = merkleRoot(leaves)
+
+		for k, amount := range balances {
+			c.Balances = append(c.Balances, StateBalance{
+				Account: k[0],
+				Token:   k[1],
+				Amount:  amount,
+			})
+		}
+		sort.Slice(c.Balances, func(i, j int) bool {
+			a, b := &c.Balances[i], &c.Balances[j]
+			if a.Account != b.Account {
+				return a.Account < b.Account
+			}
+			return a.Token < b.Token
+		})
+		leaves = leaves[:0]
+		for i := range c.Balances {
+			leaves = append(leaves, c.Balances[i].leaf())
+		}
+		c.BalanceRoot = merkleRoot(leaves)
+
+		last, err := lastAuditRecord(metaNs.NestedReadBucket(auditLogBucketKey))
+		if err != nil {
+			return err
+		}
+		if last != nil {
+			c.AuditSeq = last.Seq
+			c.AuditHash = last.Hash
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.Root = c.root()
+	return c, nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// TestMerkleRoot checks the roots of the merkle trees of state commitments.
+func TestMerkleRoot(t *testing.T) {
+	pair := func(a, b chainhash.Hash) chainhash.Hash {
+		return chainhash.DoubleHashH(append(a[:], b[:]...))
+	}
+	a := chainhash.DoubleHashH([]byte("a"))
+	b := chainhash.DoubleHashH([]byte("b"))
+	c := chainhash.DoubleHashH([]byte("c"))
+
+	tests := []struct {
+		name   string
+		leaves []chainhash.Hash
+		root   chainhash.Hash
+	}{
+		{"empty", nil, chainhash.Hash{}},
+		{"one leaf", []chainhash.Hash{a}, a},
+		{"two leaves", []chainhash.Hash{a, b}, pair(a, b)},
+		{"three leaves", []chainhash.Hash{a, b, c},
+			pair(pair(a, b), pair(c, c))},
+	}
+	for _, test := range tests {
+		leaves := append([]chainhash.Hash(nil), test.leaves...)
+		if root := merkleRoot(leaves); root != test.root {
+			t.Errorf("%s: root is %v, want %v", test.name, root, test.root)
+		}
+		for i := range leaves {
+			if leaves[i] != test.leaves[i] {
+				t.Errorf("%s: leaves were modified", test.name)
+			}
+		}
+	}
+
+	// Balances and outputs are committed to by their values.
+	b1 := StateBalance{Account: "default", Token: "STB", Amount: 1}
+	b2 := b1
+	b2.Amount = 2
+	if b1.leaf() == b2.leaf() {
+		t.Error("balances of different amounts have the same leaf")
+	}
+}
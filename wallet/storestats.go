@@ -0,0 +1,60 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// StoreStats are the sizes of the wallet stores, recorded by diagnostics.
+type StoreStats struct {
+	UnminedTxs        int
+	UnspentOutputs    int
+	LockedOutpoints   int
+	QueuedBroadcasts  int
+	PendingBroadcasts int
+}
+
+// StoreStats returns the number of unmined transactions, unspent outputs,
+// locked outpoints, and transactions queued or held for broadcast.
+func (w *Wallet) StoreStats() (*StoreStats, error) {
+	var stats StoreStats
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+		unmined, err := w.TxStore.UnminedTxHashes(txmgrNs)
+		if err != nil {
+			return err
+		}
+		stats.UnminedTxs = len(unmined)
+		unspent, err := w.TxStore.UnspentOutputs(txmgrNs, nil)
+		if err != nil {
+			return err
+		}
+		stats.UnspentOutputs = len(unspent)
+
+		queue := dbtx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(broadcastQueueBucketKey)
+		if queue != nil {
+			return queue.ForEach(func(k, v []byte) error {
+				stats.QueuedBroadcasts++
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	w.lockedOutpointsMtx.Lock()
+	stats.LockedOutpoints = len(w.lockedOutpoints)
+	w.lockedOutpointsMtx.Unlock()
+
+	w.broadcastHold.mu.Lock()
+	stats.PendingBroadcasts = len(w.broadcastHold.pending)
+	w.broadcastHold.mu.Unlock()
+
+	return &stats, nil
+}
@@ -0,0 +1,291 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/internal/txsizes"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// ErrNothingToSweep is returned when a swept key has no unspent outputs.
+var ErrNothingToSweep = errors.New("no unspent outputs to sweep")
+
+// Sweep describes a transaction sweeping the outputs of a private key to the
+// wallet or to an address pool.
+type Sweep struct {
+	Hash    chainhash.Hash
+	Address btcutil.Address
+	Amount  btcutil.Amount
+	Fee     btcutil.Amount
+	Inputs  int
+}
+
+// sweepSecrets is an implementation of txauthor.SecretsSource for a single
+// private key that is not imported to the wallet.
+type sweepSecrets struct {
+	wif         *btcutil.WIF
+	addrs       map[string]struct{}
+	chainParams *chaincfg.Params
+}
+
+func (s sweepSecrets) GetKey(addr btcutil.Address) (*btcec.PrivateKey, bool, error) {
+	if _, ok := s.addrs[addr.EncodeAddress()]; !ok {
+		return nil, false, fmt.Errorf("no key for address %v", addr)
+	}
+	return s.wif.PrivKey, s.wif.CompressPubKey, nil
+}
+
+func (s sweepSecrets) GetScript(addr btcutil.Address) ([]byte, error) {
+	return nil, fmt.Errorf("no script for address %v", addr)
+}
+
+func (s sweepSecrets) ChainParams() *chaincfg.Params {
+	return s.chainParams
+}
+
+// sweepAddresses returns the addresses of a private key: its P2PKH address
+// and, for compressed keys, its P2WPKH and nested P2WPKH addresses.
+func sweepAddresses(wif *btcutil.WIF, chainParams *chaincfg.Params) ([]btcutil.Address, error) {
+	pubKeyHash := btcutil.Hash160(wif.SerializePubKey())
+	p2pkh, err := btcutil.NewAddressPubKeyHash(pubKeyHash, chainParams)
+	if err != nil {
+		return nil, err
+	}
+	if !wif.CompressPubKey {
+		return []btcutil.Address{p2pkh}, nil
+	}
+
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, chainParams)
+	if err != nil {
+		return nil, err
+	}
+	witnessProgram, err := txscript.PayToAddrScript(p2wpkh)
+	if err != nil {
+		return nil, err
+	}
+	nested, err := btcutil.NewAddressScriptHash(witnessProgram, chainParams)
+	if err != nil {
+		return nil, err
+	}
+	return []btcutil.Address{p2pkh, p2wpkh, nested}, nil
+}
+
+// findSweepOutputs filters the blocks from startHeight to the best block for
+// the outputs paying to addrs, and returns those that are still unspent.
+// Blocks are filtered in ranges sized by batching.
+func findSweepOutputs(chainClient chain.Interface, addrs []btcutil.Address,
+	startHeight int32, batching *rescanBatching) (map[wire.OutPoint]*wire.TxOut, error) {
+
+	scripts := make(map[string]btcutil.Address, len(addrs))
+	watched := make(map[waddrmgr.ScopedIndex]btcutil.Address, len(addrs))
+	for i, addr := range addrs {
+		script, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+		scripts[string(script)] = addr
+		watched[waddrmgr.ScopedIndex{Index: uint32(i)}] = addr
+	}
+
+	_, bestHeight, err := chainClient.GetBestBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	unspent := make(map[wire.OutPoint]*wire.TxOut)
+	for start := startHeight; start <= bestHeight; {
+		end := start + int32(batching.Size()) - 1
+		if end > bestHeight {
+			end = bestHeight
+		}
+		blocks := make([]wtxmgr.BlockMeta, 0, end-start+1)
+		for height := start; height <= end; height++ {
+			hash, err := chainClient.GetBlockHash(int64(height))
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, wtxmgr.BlockMeta{
+				Block: wtxmgr.Block{Hash: *hash, Height: height},
+			})
+		}
+
+		for len(blocks) != 0 {
+			outpoints := make(map[wire.OutPoint]btcutil.Address, len(unspent))
+			for op, txOut := range unspent {
+				outpoints[op] = scripts[string(txOut.PkScript)]
+			}
+			filterStart := time.Now()
+			resp, err := chainClient.FilterBlocks(&chain.FilterBlocksRequest{
+				Blocks:           blocks,
+				ExternalAddrs:    watched,
+				WatchedOutPoints: outpoints,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if resp == nil {
+				batching.observe(len(blocks), 0, time.Since(filterStart))
+				break
+			}
+			batching.observe(int(resp.BatchIndex)+1,
+				len(resp.RelevantTxns), time.Since(filterStart))
+
+			for _, tx := range resp.RelevantTxns {
+				for _, txIn := range tx.TxIn {
+					delete(unspent, txIn.PreviousOutPoint)
+				}
+				txHash := tx.TxHash()
+				for i, txOut := range tx.TxOut {
+					if _, ok := scripts[string(txOut.PkScript)]; ok {
+						op := wire.OutPoint{Hash: txHash, Index: uint32(i)}
+						unspent[op] = txOut
+					}
+				}
+			}
+			blocks = blocks[resp.BatchIndex+1:]
+		}
+		start = end + 1
+	}
+	return unspent, nil
+}
+
+// SweepPrivateKey sends every unspent output of token paying to a private key
+// to the next external address of an account, without importing the key.
+// The chain is filtered for the outputs of the key from startHeight, which
+// should be the height of the first block that may pay to the key.
+func (w *Wallet) SweepPrivateKey(wif *btcutil.WIF, account uint32,
+	scope waddrmgr.KeyScope, token wire.TokenIdentity, startHeight int32,
+	feeSatPerKb btcutil.Amount) (*Sweep, error) {
+
+	return w.sweepPrivateKey(wif, token, startHeight, feeSatPerKb,
+		func() (btcutil.Address, error) {
+			return w.NewAddress(account, scope)
+		})
+}
+
+// SweepPrivateKeyToPool sends every unspent output of token paying to a
+// private key to the next address of an address pool, like SweepPrivateKey.
+func (w *Wallet) SweepPrivateKeyToPool(wif *btcutil.WIF, pool string,
+	token wire.TokenIdentity, startHeight int32,
+	feeSatPerKb btcutil.Amount) (*Sweep, error) {
+
+	return w.sweepPrivateKey(wif, token, startHeight, feeSatPerKb,
+		func() (btcutil.Address, error) {
+			return w.NextPoolAddress(pool)
+		})
+}
+
+// sweepPrivateKey sends every unspent output of token paying to a private key
+// to the address returned by destination, which is only called once the
+// outputs are found.
+func (w *Wallet) sweepPrivateKey(wif *btcutil.WIF, token wire.TokenIdentity,
+	startHeight int32, feeSatPerKb btcutil.Amount,
+	destination func() (btcutil.Address, error)) (*Sweep, error) {
+
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := sweepAddresses(wif, w.chainParams)
+	if err != nil {
+		return nil, err
+	}
+	secrets := sweepSecrets{
+		wif:         wif,
+		addrs:       make(map[string]struct{}, len(addrs)),
+		chainParams: w.chainParams,
+	}
+	for _, addr := range addrs {
+		secrets.addrs[addr.EncodeAddress()] = struct{}{}
+	}
+
+	unspent, err := findSweepOutputs(chainClient, addrs, startHeight,
+		&w.rescanBatching)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	var prevScripts [][]byte
+	var inputValues []btcutil.Amount
+	var total btcutil.Amount
+	var numP2PKH, numP2WPKH, numNested int
+	for op, txOut := range unspent {
+		if txOut.TokenID() != token {
+			continue
+		}
+		switch {
+		case txscript.IsPayToWitnessPubKeyHash(txOut.PkScript):
+			numP2WPKH++
+		case txscript.IsPayToScriptHash(txOut.PkScript):
+			numNested++
+		default:
+			numP2PKH++
+		}
+		op := op
+		tx.AddTxIn(wire.NewTxIn(&op, nil, nil))
+		prevScripts = append(prevScripts, txOut.PkScript)
+		inputValues = append(inputValues, btcutil.Amount(txOut.Value))
+		total += btcutil.Amount(txOut.Value)
+	}
+	if len(tx.TxIn) == 0 {
+		return nil, ErrNothingToSweep
+	}
+
+	addr, err := destination()
+	if err != nil {
+		return nil, err
+	}
+	pkScript, err := taproot.PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+	txOut := wire.NewTxOutToken(0, pkScript, token)
+	size := txsizes.EstimateVirtualSize(numP2PKH, numP2WPKH, numNested, 0,
+		[]*wire.TxOut{txOut}, false)
+	fee := txrules.FeeForSerializeSize(feeSatPerKb, size)
+	if txrules.IsDustAmount(total-fee, len(pkScript), feeSatPerKb) {
+		return nil, fmt.Errorf("swept amount %v does not cover the fee %v",
+			total, fee)
+	}
+	txOut.Value = int64(total - fee)
+	tx.AddTxOut(txOut)
+
+	err = txauthor.AddAllInputScripts(tx, prevScripts, inputValues, secrets)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMsgTx(tx, prevScripts, inputValues); err != nil {
+		return nil, err
+	}
+
+	txHash, err := w.publishTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &Sweep{
+		Hash:    *txHash,
+		Address: addr,
+		Amount:  total - fee,
+		Fee:     fee,
+		Inputs:  len(tx.TxIn),
+	}, nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+)
+
+// TestSweepSecrets checks that the outputs of every address of a swept key
+// are signed with the key.
+func TestSweepSecrets(t *testing.T) {
+	params := &chaincfg.TestNet3Params
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, compressed := range []bool{false, true} {
+		wif, err := btcutil.NewWIF(privKey, params, compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs, err := sweepAddresses(wif, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if compressed && len(addrs) != 3 || !compressed && len(addrs) != 1 {
+			t.Fatalf("got %d addresses for compressed=%v", len(addrs),
+				compressed)
+		}
+		secrets := sweepSecrets{
+			wif:         wif,
+			addrs:       make(map[string]struct{}),
+			chainParams: params,
+		}
+		for _, addr := range addrs {
+			secrets.addrs[addr.EncodeAddress()] = struct{}{}
+		}
+
+		tx := wire.NewMsgTx(wire.TxVersion)
+		var prevScripts [][]byte
+		var inputValues []btcutil.Amount
+		for i, addr := range addrs {
+			pkScript, err := txscript.PayToAddrScript(addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			op := wire.OutPoint{Hash: chainhash.Hash{byte(i + 1)}}
+			tx.AddTxIn(wire.NewTxIn(&op, nil, nil))
+			prevScripts = append(prevScripts, pkScript)
+			inputValues = append(inputValues, 1e6)
+		}
+		tx.AddTxOut(wire.NewTxOut(int64(len(addrs))*1e6-1e4, prevScripts[0]))
+
+		err = txauthor.AddAllInputScripts(tx, prevScripts, inputValues,
+			secrets)
+		if err != nil {
+			t.Fatalf("AddAllInputScripts: %v", err)
+		}
+		if err := validateMsgTx(tx, prevScripts, inputValues); err != nil {
+			t.Errorf("invalid sweep of compressed=%v key: %v",
+				compressed, err)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/wallet/internal/txsizes"
+	"github.com/btcsuite/btcwallet/wallet/psbt"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// SweepSplitTotal is the sum of the shares of the destinations of an account
+// sweep, which are in hundredths of a percent.
+const SweepSplitTotal = 10000
+
+// SweepSplit is a destination of an account sweep and its share of the swept
+// amount, in hundredths of a percent.
+type SweepSplit struct {
+	Address btcutil.Address
+	Share   uint32
+}
+
+// AccountSweep describes a transaction sweeping the outputs of an account.
+// Amounts are the amounts sent to the destinations, in the order of the
+// splits.
+type AccountSweep struct {
+	Hash    chainhash.Hash
+	Amounts []btcutil.Amount
+	Fee     btcutil.Amount
+	Inputs  int
+}
+
+// sweepRequest is the part of a createTxRequest describing an account sweep.
+// When below is set, only the outputs worth less than below are swept, the
+// smallest first, and at most maxInputs of them.
+type sweepRequest struct {
+	from      []btcutil.Address
+	token     wire.TokenIdentity
+	splits    []SweepSplit
+	below     btcutil.Amount
+	maxInputs int
+}
+
+// outputsBelow returns the outputs worth less than below, the smallest first
+// and at most max of them.
+func outputsBelow(outputs []wtxmgr.Credit, below btcutil.Amount,
+	max int) []wtxmgr.Credit {
+
+	small := make([]wtxmgr.Credit, 0, len(outputs))
+	for _, output := range outputs {
+		if output.Amount < below {
+			small = append(small, output)
+		}
+	}
+	sort.Slice(small, func(i, j int) bool {
+		return small[i].Amount < small[j].Amount
+	})
+	if len(small) > max {
+		small = small[:max]
+	}
+	return small
+}
+
+// checkSweepSplits returns an error unless the splits have positive shares
+// adding up to SweepSplitTotal and distinct addresses.
+func checkSweepSplits(splits []SweepSplit) error {
+	if len(splits) == 0 {
+		return errors.New("no sweep destinations")
+	}
+	seen := make(map[string]struct{}, len(splits))
+	var total uint32
+	for _, s := range splits {
+		if s.Share == 0 || s.Share > SweepSplitTotal {
+			return fmt.Errorf("share of %v is out of range", s.Address)
+		}
+		if _, ok := seen[s.Address.EncodeAddress()]; ok {
+			return fmt.Errorf("duplicate sweep destination %v",
+				s.Address)
+		}
+		seen[s.Address.EncodeAddress()] = struct{}{}
+		total += s.Share
+	}
+	if total != SweepSplitTotal {
+		return errors.New("shares of the sweep destinations do not " +
+			"add up to 100 percent")
+	}
+	return nil
+}
+
+// splitAmounts divides amount between the splits by their shares.  The
+// satoshis left over by rounding down go one each to the splits with the
+// largest remainders, so that the amounts add up to amount exactly.
+func splitAmounts(amount btcutil.Amount, splits []SweepSplit) []btcutil.Amount {
+	amounts := make([]btcutil.Amount, len(splits))
+	remainders := make([]int64, len(splits))
+	order := make([]int, len(splits))
+
+	// The amount is divided before multiplying by the shares to keep the
+	// products of large amounts from overflowing.
+	q := int64(amount) / SweepSplitTotal
+	r := int64(amount) % SweepSplitTotal
+	left := amount
+	for i, s := range splits {
+		share := int64(s.Share)
+		amounts[i] = btcutil.Amount(q*share + r*share/SweepSplitTotal)
+		remainders[i] = r * share % SweepSplitTotal
+		left -= amounts[i]
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]] > remainders[order[j]]
+	})
+	for i := 0; left > 0; i++ {
+		amounts[order[i]]++
+		left--
+	}
+	return amounts
+}
+
+// SweepAccount sends every spendable output of token of an account, or only
+// those paying to the from addresses when any are passed, to the destinations
+// of splits without change.  The fee is deducted before the swept amount is
+// split, and every destination must receive more than dust.
+func (w *Wallet) SweepAccount(account uint32, from []btcutil.Address,
+	token wire.TokenIdentity, splits []SweepSplit, minconf int32,
+	feeSatPerKb btcutil.Amount) (*AccountSweep, error) {
+
+	if err := checkSweepSplits(splits); err != nil {
+		return nil, err
+	}
+
+	req := createTxRequest{
+		account:     account,
+		minconf:     minconf,
+		feeSatPerKB: feeSatPerKb,
+		sweep:       &sweepRequest{from: from, token: token, splits: splits},
+		resp:        make(chan createTxResponse),
+	}
+	w.createTxRequests <- req
+	resp := <-req.resp
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	tx := resp.tx
+
+	txHash, err := w.publishTransaction(tx.Tx)
+	if err != nil {
+		return nil, err
+	}
+	sweep := &AccountSweep{
+		Hash:   *txHash,
+		Fee:    tx.TotalInput,
+		Inputs: len(tx.Tx.TxIn),
+	}
+	for _, txOut := range tx.Tx.TxOut {
+		sweep.Amounts = append(sweep.Amounts, btcutil.Amount(txOut.Value))
+		sweep.Fee -= btcutil.Amount(txOut.Value)
+	}
+	return sweep, nil
+}
+
+// txToSweep creates a transaction spending the outputs of an account sweep to
+// its destinations.  Like txToOutputs, the inputs are signed when sign is set.
+func (w *Wallet) txToSweep(account uint32, sweep *sweepRequest, minconf int32,
+	feeSatPerKb btcutil.Amount, sign bool) (*txauthor.AuthoredTx, error) {
+
+	if err := w.checkAccountFrozen(account); err != nil {
+		return nil, err
+	}
+	outputs := make([]*wire.TxOut, len(sweep.splits))
+	for i, s := range sweep.splits {
+		pkScript, err := taproot.PayToAddrScript(s.Address)
+		if err != nil {
+			return nil, err
+		}
+		outputs[i] = wire.NewTxOutToken(0, pkScript, sweep.token)
+	}
+	if err := w.checkPoolDestinations(account, outputs); err != nil {
+		return nil, err
+	}
+
+	chainClient, err := w.optionalChainClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var signer Signer
+	var packet *psbt.Packet
+	if sign {
+		signer = w.AccountSigner(account)
+	}
+
+	hookEvent := &TxHookEvent{
+		Point:   HookBeforeCoinSelection,
+		Account: account,
+		Outputs: outputs,
+	}
+	if err := w.runTxHooks(hookEvent); err != nil {
+		return nil, err
+	}
+
+	tx := &txauthor.AuthoredTx{
+		Tx:          wire.NewMsgTx(wire.TxVersion),
+		ChangeIndex: -1,
+	}
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		from := make(map[string]struct{}, len(sweep.from))
+		for _, addr := range sweep.from {
+			addrAcct, err := w.addrAccount(dbtx, addr)
+			if err != nil || addrAcct != account {
+				return fmt.Errorf("address %v does not belong to "+
+					"account %d", addr, account)
+			}
+			from[addr.EncodeAddress()] = struct{}{}
+		}
+
+		bs, err := w.blockStamp(chainClient)
+		if err != nil {
+			return err
+		}
+		eligible, err := w.findEligibleOutputs(dbtx, account,
+			sweep.token, minconf, bs)
+		if err != nil {
+			return err
+		}
+
+		if sweep.below > 0 {
+			eligible = outputsBelow(eligible, sweep.below,
+				sweep.maxInputs)
+		}
+
+		var numP2PKH, numP2WPKH, numNested, numP2TR int
+		for i := range eligible {
+			output := &eligible[i]
+			if len(from) != 0 {
+				_, addrs, _, err := taproot.ExtractPkScriptAddrs(
+					output.PkScript, w.chainParams)
+				if err != nil || len(addrs) != 1 {
+					continue
+				}
+				if _, ok := from[addrs[0].EncodeAddress()]; !ok {
+					continue
+				}
+			}
+			switch {
+			case txscript.IsPayToScriptHash(output.PkScript):
+				numNested++
+			case txscript.IsPayToWitnessPubKeyHash(output.PkScript):
+				numP2WPKH++
+			case taproot.IsPayToTaproot(output.PkScript):
+				numP2TR++
+			default:
+				numP2PKH++
+			}
+			tx.Tx.AddTxIn(wire.NewTxIn(&output.OutPoint, nil, nil))
+			tx.PrevScripts = append(tx.PrevScripts, output.PkScript)
+			tx.PrevInputValues = append(tx.PrevInputValues,
+				output.Amount)
+			tx.TotalInput += output.Amount
+		}
+		if len(tx.Tx.TxIn) == 0 {
+			return ErrNothingToSweep
+		}
+		if sweep.below > 0 && len(tx.Tx.TxIn) == 1 {
+			return errors.New("a single output is below the " +
+				"consolidation threshold")
+		}
+
+		size := txsizes.EstimateVirtualSize(numP2PKH, numP2WPKH,
+			numNested, numP2TR, outputs, false)
+		fee := txrules.FeeForSerializeSize(feeSatPerKb, size)
+		if tx.TotalInput <= fee {
+			return fmt.Errorf("swept amount %v does not cover the "+
+				"fee %v", tx.TotalInput, fee)
+		}
+		amounts := splitAmounts(tx.TotalInput-fee, sweep.splits)
+		for i, amount := range amounts {
+			if txrules.IsDustAmount(amount, len(outputs[i].PkScript),
+				feeSatPerKb) {
+
+				return fmt.Errorf("share %v of %v is dust", amount,
+					sweep.splits[i].Address)
+			}
+			outputs[i].Value = int64(amount)
+		}
+		tx.Tx.TxOut = outputs
+		setReplaceable(tx.Tx, w.ReplaceableByDefault())
+		setLockTime(tx.Tx, w.antiFeeSnipingLockTime(bs.Height))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hookEvent.Point = HookBeforeSigning
+	hookEvent.Outputs = nil
+	hookEvent.Tx = tx.Tx
+	if err := w.runTxHooks(hookEvent); err != nil {
+		return nil, err
+	}
+
+	if sign {
+		err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+			if signer != nil {
+				var err error
+				packet, err = w.newSignerPsbt(dbtx, tx.Tx)
+				return err
+			}
+			addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+			scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+			return tx.AddAllInputScripts(secretSource{w.Manager,
+				addrmgrNs, scriptNs, w})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if signer != nil {
+		if err := signWithSigner(signer, packet, tx.Tx); err != nil {
+			return nil, err
+		}
+		txHash := tx.Tx.TxHash()
+		w.recordSigner(signer, &txHash)
+	}
+	if sign {
+		err = validateMsgTx(tx.Tx, tx.PrevScripts, tx.PrevInputValues)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.saveTxAnnotations(tx.Tx.TxHash(), hookEvent.Annotations)
+	return tx, nil
+}
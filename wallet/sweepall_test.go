@@ -0,0 +1,124 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TestSplitAmounts checks that swept amounts are split by share and that the
+// split amounts add up to the swept amount exactly.
+func TestSplitAmounts(t *testing.T) {
+	addr := func(i byte) btcutil.Address {
+		hash := make([]byte, 20)
+		hash[0] = i
+		a, err := btcutil.NewAddressWitnessPubKeyHash(hash,
+			&chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	tests := []struct {
+		name    string
+		amount  btcutil.Amount
+		shares  []uint32
+		amounts []btcutil.Amount
+	}{
+		{"whole", 1e8, []uint32{10000}, []btcutil.Amount{1e8}},
+		{"halves", 1e8 + 1, []uint32{5000, 5000},
+			[]btcutil.Amount{50000001, 50000000}},
+		{"thirds", 1000, []uint32{3333, 3333, 3334},
+			[]btcutil.Amount{333, 333, 334}},
+		{"remainders", 99, []uint32{1, 9999},
+			[]btcutil.Amount{0, 99}},
+		{"max supply", btcutil.MaxSatoshi, []uint32{2500, 7500},
+			[]btcutil.Amount{btcutil.MaxSatoshi / 4,
+				btcutil.MaxSatoshi / 4 * 3}},
+	}
+	for _, test := range tests {
+		splits := make([]SweepSplit, len(test.shares))
+		for i, share := range test.shares {
+			splits[i] = SweepSplit{Address: addr(byte(i)), Share: share}
+		}
+		if err := checkSweepSplits(splits); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		amounts := splitAmounts(test.amount, splits)
+		var total btcutil.Amount
+		for i, amount := range amounts {
+			if amount != test.amounts[i] {
+				t.Errorf("%s: amount %d is %v, want %v", test.name,
+					i, amount, test.amounts[i])
+			}
+			total += amount
+		}
+		if total != test.amount {
+			t.Errorf("%s: amounts add up to %v, want %v", test.name,
+				total, test.amount)
+		}
+	}
+
+	invalid := [][]SweepSplit{
+		nil,
+		{{Address: addr(0), Share: 5000}},
+		{{Address: addr(0), Share: 10000}, {Address: addr(1), Share: 0}},
+		{{Address: addr(0), Share: 5000}, {Address: addr(0), Share: 5000}},
+	}
+	for i, splits := range invalid {
+		if err := checkSweepSplits(splits); err == nil {
+			t.Errorf("invalid splits %d were accepted", i)
+		}
+	}
+}
+
+// TestOutputsBelow checks that consolidations spend the smallest outputs below
+// the threshold first, up to the maximum number of inputs.
+func TestOutputsBelow(t *testing.T) {
+	var outputs []wtxmgr.Credit
+	for i, amount := range []btcutil.Amount{5000, 100, 20000, 300, 200} {
+		outputs = append(outputs, wtxmgr.Credit{
+			OutPoint: wire.OutPoint{Hash: chainhash.Hash{byte(i)}},
+			Amount:   amount,
+		})
+	}
+
+	tests := []struct {
+		below   btcutil.Amount
+		max     int
+		amounts []btcutil.Amount
+	}{
+		{10000, 100, []btcutil.Amount{100, 200, 300, 5000}},
+		{10000, 2, []btcutil.Amount{100, 200}},
+		{300, 100, []btcutil.Amount{100, 200}},
+		{100, 100, nil},
+	}
+	for _, test := range tests {
+		small := outputsBelow(outputs, test.below, test.max)
+		if len(small) != len(test.amounts) {
+			t.Errorf("below %v max %d: %d outputs, want %d",
+				test.below, test.max, len(small), len(test.amounts))
+			continue
+		}
+		for i, output := range small {
+			if output.Amount != test.amounts[i] {
+				t.Errorf("below %v max %d: output %d is %v, "+
+					"want %v", test.below, test.max, i,
+					output.Amount, test.amounts[i])
+			}
+		}
+	}
+	if outputs[0].Amount != 5000 {
+		t.Error("outputs were reordered")
+	}
+}
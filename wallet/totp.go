@@ -0,0 +1,172 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// totpSecretKey is the key of the enrolled TOTP secret in the transaction
+// metadata namespace.
+var totpSecretKey = []byte("totpsecret")
+
+const (
+	// totpSecretSize is the size of the TOTP secrets generated by
+	// EnrollTOTP, the output size of HMAC-SHA1.
+	totpSecretSize = 20
+
+	// totpStep is the validity period of each one-time password, and
+	// totpDigits the number of its decimal digits, as used by most
+	// authenticator apps.
+	totpStep   = 30 * time.Second
+	totpDigits = 6
+
+	// totpSkew is the number of steps before and after the current one
+	// whose passwords are also accepted, to allow for clock drift.
+	totpSkew = 1
+)
+
+var (
+	// ErrTOTPRequired is returned when an operation requiring a one-time
+	// password is attempted without one.
+	ErrTOTPRequired = errors.New("a one-time password is required")
+
+	// ErrTOTPInvalid is returned for one-time passwords which are wrong,
+	// expired or were already used.
+	ErrTOTPInvalid = errors.New("invalid one-time password")
+)
+
+// totpState remembers the step of the last accepted one-time password, so
+// that every password is only accepted once.
+type totpState struct {
+	mu       sync.Mutex
+	lastStep uint64
+}
+
+// totpCode returns the one-time password of a step, as defined by RFC 4226
+// and RFC 6238 with HMAC-SHA1.
+func totpCode(secret []byte, step uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], step)
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// totpStepAt returns the TOTP step of a time.
+func totpStepAt(t time.Time) uint64 {
+	return uint64(t.Unix()) / uint64(totpStep/time.Second)
+}
+
+// fetchTOTPSecret returns the enrolled TOTP secret, or nil when no secret is
+// enrolled.
+func fetchTOTPSecret(ns walletdb.ReadBucket) []byte {
+	v := ns.Get(totpSecretKey)
+	if v == nil {
+		return nil
+	}
+	return append([]byte(nil), v...)
+}
+
+// TOTPEnrolled returns whether a TOTP secret is enrolled.
+func (w *Wallet) TOTPEnrolled() (bool, error) {
+	var enrolled bool
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(wtxmetaNamespaceKey)
+		enrolled = ns.Get(totpSecretKey) != nil
+		return nil
+	})
+	return enrolled, err
+}
+
+// EnrollTOTP generates and enrolls a new TOTP secret, replacing any enrolled
+// secret, and returns it to be added to an authenticator app.
+func (w *Wallet) EnrollTOTP() ([]byte, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmetaNamespaceKey)
+		return ns.Put(totpSecretKey, secret)
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.audit(AuditTOTP, "one-time password secret enrolled")
+	return secret, nil
+}
+
+// DisableTOTP removes the enrolled TOTP secret, so that one-time passwords
+// are no longer required.
+func (w *Wallet) DisableTOTP() error {
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmetaNamespaceKey)
+		return ns.Delete(totpSecretKey)
+	})
+	if err != nil {
+		return err
+	}
+	w.audit(AuditTOTP, "one-time password secret removed")
+	return nil
+}
+
+// CheckTOTP checks the one-time password of an operation requiring one.  No
+// password is required until a secret is enrolled.  Otherwise, the password
+// of the current step, or of a step within the allowed clock drift, must be
+// given, and every password is only accepted once.  Wrong passwords are
+// throttled like wrong passphrases, so that they cannot be guessed.
+func (w *Wallet) CheckTOTP(code string) error {
+	var secret []byte
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		secret = fetchTOTPSecret(tx.ReadBucket(wtxmetaNamespaceKey))
+		return nil
+	})
+	if err != nil || secret == nil {
+		return err
+	}
+	if code == "" {
+		return ErrTOTPRequired
+	}
+	if err := w.throttleUnlock(); err != nil {
+		return err
+	}
+
+	w.totp.mu.Lock()
+	defer w.totp.mu.Unlock()
+
+	now := totpStepAt(time.Now())
+	for step := now - totpSkew; step <= now+totpSkew; step++ {
+		if step <= w.totp.lastStep {
+			continue
+		}
+		expected := totpCode(secret, step)
+		if subtle.ConstantTimeCompare([]byte(code), []byte(expected)) == 1 {
+			w.totp.lastStep = step
+			return nil
+		}
+	}
+
+	w.recordUnlock(waddrmgr.ManagerError{
+		ErrorCode:   waddrmgr.ErrWrongPassphrase,
+		Description: "invalid one-time password",
+	})
+	return ErrTOTPInvalid
+}
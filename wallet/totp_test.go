@@ -0,0 +1,96 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestTOTPCode checks one-time passwords against the SHA1 test vectors of
+// RFC 6238, truncated to six digits.
+func TestTOTPCode(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	tests := []struct {
+		time int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, test := range tests {
+		step := totpStepAt(time.Unix(test.time, 0))
+		if code := totpCode(secret, step); code != test.code {
+			t.Errorf("code at %d is %s, want %s", test.time, code,
+				test.code)
+		}
+	}
+}
+
+// TestCheckTOTP checks that one-time passwords are only required once a
+// secret is enrolled, and that each password is only accepted once.
+func TestCheckTOTP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "totp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wallet{db: db}
+	w.NtfnServer = newNotificationServer(w)
+
+	if err := w.CheckTOTP(""); err != nil {
+		t.Fatalf("password required before enrollment: %v", err)
+	}
+
+	secret, err := w.EnrollTOTP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.CheckTOTP(""); err != ErrTOTPRequired {
+		t.Fatalf("missing password returned %v", err)
+	}
+	code := totpCode(secret, totpStepAt(time.Now()))
+	if err := w.CheckTOTP(code); err != nil {
+		t.Fatalf("current password rejected: %v", err)
+	}
+	if err := w.CheckTOTP(code); err != ErrTOTPInvalid {
+		t.Fatalf("reused password returned %v", err)
+	}
+
+	// Wrong passwords delay the next attempt.
+	if _, ok := w.CheckTOTP(code).(*UnlockThrottledError); !ok {
+		t.Fatal("password after a wrong password was not throttled")
+	}
+	w.unlockThrottle.retryAt = time.Time{}
+
+	if err := w.DisableTOTP(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.CheckTOTP(""); err != nil {
+		t.Fatalf("password required after disabling: %v", err)
+	}
+}
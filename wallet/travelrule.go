@@ -0,0 +1,123 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/travelrule"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// travelRuleBucketKey is the key of the bucket in the transaction metadata
+// namespace holding the encrypted travel rule metadata of transactions,
+// keyed by transaction hash.
+var travelRuleBucketKey = []byte("travelrule")
+
+// SetTravelRuleMetadata attaches originator and beneficiary metadata to a
+// wallet transaction, replacing any metadata attached earlier.  The metadata
+// is encrypted with the wallet's private key encryption key, so the wallet
+// must be unlocked.
+func (w *Wallet) SetTravelRuleMetadata(txHash *chainhash.Hash, m *travelrule.Metadata) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := w.Manager.Encrypt(waddrmgr.CKTPrivate, plaintext)
+	if err != nil {
+		return err
+	}
+
+	return walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		details, err := w.TxStore.TxDetails(txmgrNs, txHash)
+		if err != nil {
+			return err
+		}
+		if details == nil {
+			return fmt.Errorf("no wallet transaction %v", txHash)
+		}
+
+		ns := tx.ReadWriteBucket(wtxmetaNamespaceKey)
+		bucket, err := ns.CreateBucketIfNotExists(travelRuleBucketKey)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(txHash[:], ciphertext)
+	})
+}
+
+// TravelRuleMetadata returns the metadata attached to a transaction, or nil if
+// there is none.  The wallet must be unlocked.
+func (w *Wallet) TravelRuleMetadata(txHash *chainhash.Hash) (*travelrule.Metadata, error) {
+	var ciphertext []byte
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		bucket := tx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(travelRuleBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		if v := bucket.Get(txHash[:]); v != nil {
+			ciphertext = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	if err != nil || ciphertext == nil {
+		return nil, err
+	}
+	return w.decryptTravelRuleMetadata(ciphertext)
+}
+
+// AllTravelRuleMetadata returns the metadata of every transaction that has
+// metadata attached.  The wallet must be unlocked.
+func (w *Wallet) AllTravelRuleMetadata() (map[chainhash.Hash]*travelrule.Metadata, error) {
+	ciphertexts := make(map[chainhash.Hash][]byte)
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		bucket := tx.ReadBucket(wtxmetaNamespaceKey).
+			NestedReadBucket(travelRuleBucketKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var txHash chainhash.Hash
+			if err := txHash.SetBytes(k); err != nil {
+				return err
+			}
+			ciphertexts[txHash] = append([]byte(nil), v...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	all := make(map[chainhash.Hash]*travelrule.Metadata, len(ciphertexts))
+	for txHash, ciphertext := range ciphertexts {
+		m, err := w.decryptTravelRuleMetadata(ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		all[txHash] = m
+	}
+	return all, nil
+}
+
+func (w *Wallet) decryptTravelRuleMetadata(ciphertext []byte) (*travelrule.Metadata, error) {
+	plaintext, err := w.Manager.Decrypt(waddrmgr.CKTPrivate, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	m := new(travelrule.Metadata)
+	if err := json.Unmarshal(plaintext, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package travelrule
+
+// The IVMS101 name identifier and address type codes used by the export.
+const (
+	ivmsLegalName       = "LEGL"
+	ivmsGeographicAddrs = "GEOG"
+)
+
+// IVMS101 is the IVMS101 (interVASP Messaging Standard) JSON representation
+// of travel rule metadata.
+type IVMS101 struct {
+	Originator      IVMSOriginator       `json:"originator"`
+	Beneficiary     IVMSBeneficiary      `json:"beneficiary"`
+	OriginatingVASP *IVMSOriginatingVASP `json:"originatingVASP,omitempty"`
+	BeneficiaryVASP *IVMSBeneficiaryVASP `json:"beneficiaryVASP,omitempty"`
+}
+
+// IVMSOriginator is the IVMS101 Originator entity.
+type IVMSOriginator struct {
+	OriginatorPersons []IVMSPerson `json:"originatorPersons"`
+	AccountNumber     []string     `json:"accountNumber,omitempty"`
+}
+
+// IVMSBeneficiary is the IVMS101 Beneficiary entity.
+type IVMSBeneficiary struct {
+	BeneficiaryPersons []IVMSPerson `json:"beneficiaryPersons"`
+	AccountNumber      []string     `json:"accountNumber,omitempty"`
+}
+
+// IVMSOriginatingVASP is the IVMS101 OriginatingVASP entity.
+type IVMSOriginatingVASP struct {
+	OriginatingVASP IVMSPerson `json:"originatingVASP"`
+}
+
+// IVMSBeneficiaryVASP is the IVMS101 BeneficiaryVASP entity.
+type IVMSBeneficiaryVASP struct {
+	BeneficiaryVASP IVMSPerson `json:"beneficiaryVASP"`
+}
+
+// IVMSPerson is the IVMS101 Person choice of a natural or legal person.
+type IVMSPerson struct {
+	NaturalPerson *IVMSNaturalPerson `json:"naturalPerson,omitempty"`
+	LegalPerson   *IVMSLegalPerson   `json:"legalPerson,omitempty"`
+}
+
+// IVMSNaturalPerson is the IVMS101 NaturalPerson entity.
+type IVMSNaturalPerson struct {
+	Name                   []IVMSNaturalPersonName     `json:"name"`
+	GeographicAddress      []IVMSAddress               `json:"geographicAddress,omitempty"`
+	NationalIdentification *IVMSNationalIdentification `json:"nationalIdentification,omitempty"`
+	DateAndPlaceOfBirth    *IVMSDateAndPlaceOfBirth    `json:"dateAndPlaceOfBirth,omitempty"`
+}
+
+// IVMSNaturalPersonName is the IVMS101 NaturalPersonName entity.
+type IVMSNaturalPersonName struct {
+	NameIdentifier []IVMSNaturalPersonNameID `json:"nameIdentifier"`
+}
+
+// IVMSNaturalPersonNameID is the IVMS101 NaturalPersonNameIdentifier entity.
+type IVMSNaturalPersonNameID struct {
+	PrimaryIdentifier   string `json:"primaryIdentifier"`
+	SecondaryIdentifier string `json:"secondaryIdentifier,omitempty"`
+	NameIdentifierType  string `json:"nameIdentifierType"`
+}
+
+// IVMSLegalPerson is the IVMS101 LegalPerson entity.
+type IVMSLegalPerson struct {
+	Name                   IVMSLegalPersonName         `json:"name"`
+	GeographicAddress      []IVMSAddress               `json:"geographicAddress,omitempty"`
+	NationalIdentification *IVMSNationalIdentification `json:"nationalIdentification,omitempty"`
+}
+
+// IVMSLegalPersonName is the IVMS101 LegalPersonName entity.
+type IVMSLegalPersonName struct {
+	NameIdentifier []IVMSLegalPersonNameID `json:"nameIdentifier"`
+}
+
+// IVMSLegalPersonNameID is the IVMS101 LegalPersonNameIdentifier entity.
+type IVMSLegalPersonNameID struct {
+	LegalPersonName               string `json:"legalPersonName"`
+	LegalPersonNameIdentifierType string `json:"legalPersonNameIdentifierType"`
+}
+
+// IVMSAddress is the IVMS101 Address entity.
+type IVMSAddress struct {
+	AddressType    string `json:"addressType"`
+	StreetName     string `json:"streetName,omitempty"`
+	BuildingNumber string `json:"buildingNumber,omitempty"`
+	PostCode       string `json:"postCode,omitempty"`
+	TownName       string `json:"townName"`
+	Country        string `json:"country"`
+}
+
+// IVMSNationalIdentification is the IVMS101 NationalIdentification entity.
+type IVMSNationalIdentification struct {
+	NationalIdentifier     string `json:"nationalIdentifier"`
+	NationalIdentifierType string `json:"nationalIdentifierType"`
+}
+
+// IVMSDateAndPlaceOfBirth is the IVMS101 DateAndPlaceOfBirth entity.
+type IVMSDateAndPlaceOfBirth struct {
+	DateOfBirth  string `json:"dateOfBirth"`
+	PlaceOfBirth string `json:"placeOfBirth,omitempty"`
+}
+
+// IVMS101 returns the IVMS101 representation of the metadata.
+func (m *Metadata) IVMS101() *IVMS101 {
+	doc := &IVMS101{
+		Originator: IVMSOriginator{
+			OriginatorPersons: []IVMSPerson{m.Originator.ivmsPerson()},
+			AccountNumber:     accountNumbers(&m.Originator),
+		},
+		Beneficiary: IVMSBeneficiary{
+			BeneficiaryPersons: []IVMSPerson{m.Beneficiary.ivmsPerson()},
+			AccountNumber:      accountNumbers(&m.Beneficiary),
+		},
+	}
+	if m.OriginatingVASP != "" {
+		doc.OriginatingVASP = &IVMSOriginatingVASP{
+			OriginatingVASP: vaspPerson(m.OriginatingVASP),
+		}
+	}
+	if m.BeneficiaryVASP != "" {
+		doc.BeneficiaryVASP = &IVMSBeneficiaryVASP{
+			BeneficiaryVASP: vaspPerson(m.BeneficiaryVASP),
+		}
+	}
+	return doc
+}
+
+func accountNumbers(p *Party) []string {
+	if p.AccountNumber == "" {
+		return nil
+	}
+	return []string{p.AccountNumber}
+}
+
+func vaspPerson(name string) IVMSPerson {
+	return IVMSPerson{LegalPerson: &IVMSLegalPerson{
+		Name: IVMSLegalPersonName{
+			NameIdentifier: []IVMSLegalPersonNameID{{
+				LegalPersonName:               name,
+				LegalPersonNameIdentifierType: ivmsLegalName,
+			}},
+		},
+	}}
+}
+
+func (p *Party) ivmsPerson() IVMSPerson {
+	var addrs []IVMSAddress
+	if p.TownName != "" && p.Country != "" {
+		addrs = []IVMSAddress{{
+			AddressType:    ivmsGeographicAddrs,
+			StreetName:     p.StreetName,
+			BuildingNumber: p.BuildingNumber,
+			PostCode:       p.PostCode,
+			TownName:       p.TownName,
+			Country:        p.Country,
+		}}
+	}
+	var nationalID *IVMSNationalIdentification
+	if p.NationalID != "" {
+		idType := p.NationalIDType
+		if idType == "" {
+			idType = "MISC"
+		}
+		nationalID = &IVMSNationalIdentification{
+			NationalIdentifier:     p.NationalID,
+			NationalIdentifierType: idType,
+		}
+	}
+
+	if p.LegalName != "" {
+		return IVMSPerson{LegalPerson: &IVMSLegalPerson{
+			Name: IVMSLegalPersonName{
+				NameIdentifier: []IVMSLegalPersonNameID{{
+					LegalPersonName:               p.LegalName,
+					LegalPersonNameIdentifierType: ivmsLegalName,
+				}},
+			},
+			GeographicAddress:      addrs,
+			NationalIdentification: nationalID,
+		}}
+	}
+
+	person := &IVMSNaturalPerson{
+		Name: []IVMSNaturalPersonName{{
+			NameIdentifier: []IVMSNaturalPersonNameID{{
+				PrimaryIdentifier:   p.LastName,
+				SecondaryIdentifier: p.FirstName,
+				NameIdentifierType:  ivmsLegalName,
+			}},
+		}},
+		GeographicAddress:      addrs,
+		NationalIdentification: nationalID,
+	}
+	if p.DateOfBirth != "" {
+		person.DateAndPlaceOfBirth = &IVMSDateAndPlaceOfBirth{
+			DateOfBirth:  p.DateOfBirth,
+			PlaceOfBirth: p.PlaceOfBirth,
+		}
+	}
+	return IVMSPerson{NaturalPerson: person}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package travelrule provides the originator and beneficiary metadata that
+// regulated custodians attach to transactions to comply with AML travel rule
+// requirements, along with its IVMS101 representation.
+package travelrule
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Party describes the originator or beneficiary of a transfer.  Exactly one
+// of the natural person name (FirstName and LastName) or LegalName must be
+// set.
+type Party struct {
+	FirstName string `json:"firstname,omitempty"`
+	LastName  string `json:"lastname,omitempty"`
+	LegalName string `json:"legalname,omitempty"`
+
+	StreetName     string `json:"streetname,omitempty"`
+	BuildingNumber string `json:"buildingnumber,omitempty"`
+	PostCode       string `json:"postcode,omitempty"`
+	TownName       string `json:"townname,omitempty"`
+	Country        string `json:"country,omitempty"`
+
+	// NationalIDType is an IVMS101 national identifier type code such
+	// as CCPT (passport) or RAID (registration authority identifier).
+	NationalID     string `json:"nationalid,omitempty"`
+	NationalIDType string `json:"nationalidtype,omitempty"`
+
+	// DateOfBirth is formatted as YYYY-MM-DD.
+	DateOfBirth  string `json:"dateofbirth,omitempty"`
+	PlaceOfBirth string `json:"placeofbirth,omitempty"`
+
+	AccountNumber string `json:"accountnumber,omitempty"`
+}
+
+// Metadata is the travel rule information attached to a transaction.
+type Metadata struct {
+	Originator      Party  `json:"originator"`
+	Beneficiary     Party  `json:"beneficiary"`
+	OriginatingVASP string `json:"originatingvasp,omitempty"`
+	BeneficiaryVASP string `json:"beneficiaryvasp,omitempty"`
+}
+
+// nationalIDTypes are the IVMS101 national identifier type codes.
+var nationalIDTypes = map[string]struct{}{
+	"ARNU": {}, "CCPT": {}, "RAID": {}, "DRLC": {}, "FIIN": {},
+	"TXID": {}, "SOCS": {}, "IDCD": {}, "LEIX": {}, "MISC": {},
+}
+
+// ErrMissingName is returned when a party has neither a natural person nor a
+// legal person name.
+var ErrMissingName = errors.New("party requires either a natural person " +
+	"name or a legal name")
+
+// Validate checks that the party can be represented in IVMS101.
+func (p *Party) Validate() error {
+	natural := p.FirstName != "" || p.LastName != ""
+	switch {
+	case natural && p.LegalName != "":
+		return errors.New("party can not have both a natural person " +
+			"name and a legal name")
+	case p.LegalName == "" && p.LastName == "":
+		return ErrMissingName
+	}
+	if p.Country != "" && len(p.Country) != 2 {
+		return fmt.Errorf("country %q is not an ISO 3166-1 alpha-2 code",
+			p.Country)
+	}
+	if p.NationalIDType != "" {
+		if _, ok := nationalIDTypes[p.NationalIDType]; !ok {
+			return fmt.Errorf("unknown national identifier type %q",
+				p.NationalIDType)
+		}
+		if p.NationalID == "" {
+			return errors.New("national identifier type set without " +
+				"an identifier")
+		}
+	}
+	if p.DateOfBirth != "" {
+		if p.LegalName != "" {
+			return errors.New("legal persons do not have a date of birth")
+		}
+		if _, err := time.Parse("2006-01-02", p.DateOfBirth); err != nil {
+			return fmt.Errorf("invalid date of birth %q", p.DateOfBirth)
+		}
+	}
+	return nil
+}
+
+// Validate checks that both parties of the metadata are valid.
+func (m *Metadata) Validate() error {
+	if err := m.Originator.Validate(); err != nil {
+		return fmt.Errorf("originator: %v", err)
+	}
+	if err := m.Beneficiary.Validate(); err != nil {
+		return fmt.Errorf("beneficiary: %v", err)
+	}
+	return nil
+}
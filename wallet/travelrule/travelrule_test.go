@@ -0,0 +1,64 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package travelrule
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		party Party
+		valid bool
+	}{
+		{"natural person", Party{FirstName: "Satoshi", LastName: "Nakamoto"}, true},
+		{"legal person", Party{LegalName: "Example Ltd"}, true},
+		{"no name", Party{TownName: "Tokyo", Country: "JP"}, false},
+		{"both names", Party{LastName: "Nakamoto", LegalName: "Example Ltd"}, false},
+		{"bad country", Party{LastName: "Nakamoto", Country: "JPN"}, false},
+		{"bad id type", Party{LastName: "Nakamoto", NationalID: "1", NationalIDType: "XXXX"}, false},
+		{"bad birth date", Party{LastName: "Nakamoto", DateOfBirth: "05/04/1975"}, false},
+		{"legal person birth date", Party{LegalName: "Example Ltd", DateOfBirth: "1975-04-05"}, false},
+	}
+	for _, test := range tests {
+		err := test.party.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: invalid party accepted", test.name)
+		}
+	}
+}
+
+func TestIVMS101(t *testing.T) {
+	m := &Metadata{
+		Originator: Party{
+			FirstName:     "Satoshi",
+			LastName:      "Nakamoto",
+			TownName:      "Tokyo",
+			Country:       "JP",
+			DateOfBirth:   "1975-04-05",
+			AccountNumber: "bc1qexample",
+		},
+		Beneficiary:     Party{LegalName: "Example Ltd", NationalID: "5493001KJTIIGC8Y1R12", NationalIDType: "LEIX"},
+		OriginatingVASP: "Sending VASP",
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(m.IVMS101())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"originator":{"originatorPersons":[{"naturalPerson":{"name":[{"nameIdentifier":[{"primaryIdentifier":"Nakamoto","secondaryIdentifier":"Satoshi","nameIdentifierType":"LEGL"}]}],"geographicAddress":[{"addressType":"GEOG","townName":"Tokyo","country":"JP"}],"dateAndPlaceOfBirth":{"dateOfBirth":"1975-04-05"}}}],"accountNumber":["bc1qexample"]},` +
+		`"beneficiary":{"beneficiaryPersons":[{"legalPerson":{"name":{"nameIdentifier":[{"legalPersonName":"Example Ltd","legalPersonNameIdentifierType":"LEGL"}]},"nationalIdentification":{"nationalIdentifier":"5493001KJTIIGC8Y1R12","nationalIdentifierType":"LEIX"}}}]},` +
+		`"originatingVASP":{"originatingVASP":{"legalPerson":{"name":{"nameIdentifier":[{"legalPersonName":"Sending VASP","legalPersonNameIdentifierType":"LEGL"}]}}}}}`
+	if string(got) != want {
+		t.Errorf("IVMS101 encoding\n got: %s\nwant: %s", got, want)
+	}
+}
@@ -7,11 +7,14 @@ package txauthor
 
 import (
 	"errors"
+	"fmt"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
 	"github.com/btcsuite/btcwallet/wallet/txrules"
 
 	h "github.com/btcsuite/btcwallet/internal/helpers"
@@ -57,6 +60,90 @@ type AuthoredTx struct {
 // ChangeSource provides P2PKH change output scripts for transaction creation.
 type ChangeSource func() ([]byte, error)
 
+// ScriptSource provides the redeem scripts of P2SH outputs and the witness
+// scripts of P2WSH outputs, to estimate the size of the inputs spending them.
+// Scripts are looked up by the corresponding Address for the previous output
+// script, like with a SecretsSource.
+type ScriptSource interface {
+	txscript.ScriptDB
+	ChainParams() *chaincfg.Params
+}
+
+// scriptHashInputSize estimates the size of an input spending a P2SH or
+// P2WSH pkScript with a redeem or witness script known to the script source.
+// False is returned when the script is unknown, or the output is a nested
+// P2SH-P2WPKH output.
+func scriptHashInputSize(pkScript []byte, source ScriptSource) (txsizes.InputSize, bool) {
+	chainParams := source.ChainParams()
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, chainParams)
+	if err != nil || len(addrs) != 1 {
+		return txsizes.InputSize{}, false
+	}
+	script, err := source.GetScript(addrs[0])
+	if err != nil {
+		return txsizes.InputSize{}, false
+	}
+
+	switch {
+	case txscript.IsPayToWitnessScriptHash(pkScript):
+		return txsizes.EstimateScriptHashInputSize(nil, script), true
+	case txscript.IsPayToWitnessPubKeyHash(script):
+		return txsizes.InputSize{}, false
+
+	// The redeem script of a nested P2SH-P2WSH output is the witness
+	// program, which is spent with the witness script.
+	case txscript.IsPayToWitnessScriptHash(script):
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(script,
+			chainParams)
+		if err != nil || len(addrs) != 1 {
+			return txsizes.InputSize{}, false
+		}
+		witnessScript, err := source.GetScript(addrs[0])
+		if err != nil {
+			return txsizes.InputSize{}, false
+		}
+		return txsizes.EstimateScriptHashInputSize(script,
+			witnessScript), true
+	}
+	return txsizes.EstimateScriptHashInputSize(script, nil), true
+}
+
+// inputCounts counts the kinds of the previous output scripts of the inputs
+// of a transaction, to estimate its virtual size.  The sizes of inputs
+// spending P2SH and P2WSH outputs with scripts known to the script source,
+// which may be nil, are estimated from their scripts.
+func inputCounts(scripts [][]byte, source ScriptSource) (p2pkh, p2wpkh,
+	nested, p2tr int, scriptIns []txsizes.InputSize) {
+
+	for _, pkScript := range scripts {
+		if source != nil && (txscript.IsPayToScriptHash(pkScript) ||
+			txscript.IsPayToWitnessScriptHash(pkScript)) {
+
+			if in, ok := scriptHashInputSize(pkScript, source); ok {
+				scriptIns = append(scriptIns, in)
+				continue
+			}
+		}
+
+		switch {
+		// If this is a p2sh output of an unknown script, we assume
+		// this is a nested P2WKH.
+		case txscript.IsPayToScriptHash(pkScript):
+			nested++
+		case txscript.IsPayToWitnessPubKeyHash(pkScript):
+			p2wpkh++
+		case taproot.IsPayToTaproot(pkScript):
+			p2tr++
+		// P2WSH outputs of unknown witness scripts are estimated as
+		// P2PKH, which is an overestimate for small multisig witness
+		// scripts.
+		default:
+			p2pkh++
+		}
+	}
+	return
+}
+
 // NewUnsignedTransaction creates an unsigned transaction paying to one or more
 // non-change outputs.  An appropriate transaction fee is included based on the
 // transaction size.
@@ -80,8 +167,19 @@ type ChangeSource func() ([]byte, error)
 func NewUnsignedTransaction(outputs []*wire.TxOut, relayFeePerKb btcutil.Amount,
 	fetchInputs InputSource, fetchChange ChangeSource) (*AuthoredTx, error) {
 
+	return newUnsignedTransaction(outputs, relayFeePerKb, fetchInputs,
+		fetchChange, nil)
+}
+
+// newUnsignedTransaction creates an unsigned transaction like
+// NewUnsignedTransaction, estimating the size of inputs spending P2SH and
+// P2WSH outputs from the scripts of the script source, which may be nil.
+func newUnsignedTransaction(outputs []*wire.TxOut, relayFeePerKb btcutil.Amount,
+	fetchInputs InputSource, fetchChange ChangeSource,
+	scriptSource ScriptSource) (*AuthoredTx, error) {
+
 	targetAmount := h.SumOutputValues(outputs)
-	estimatedSize := txsizes.EstimateVirtualSize(0, 1, 0, outputs, true)
+	estimatedSize := txsizes.EstimateVirtualSize(0, 1, 0, 0, outputs, true)
 	targetFee := txrules.FeeForSerializeSize(relayFeePerKb, estimatedSize)
 
 	for {
@@ -95,22 +193,11 @@ func NewUnsignedTransaction(outputs []*wire.TxOut, relayFeePerKb btcutil.Amount,
 
 		// We count the types of inputs, which we'll use to estimate
 		// the vsize of the transaction.
-		var nested, p2wpkh, p2pkh int
-		for _, pkScript := range scripts {
-			switch {
-			// If this is a p2sh output, we assume this is a
-			// nested P2WKH.
-			case txscript.IsPayToScriptHash(pkScript):
-				nested++
-			case txscript.IsPayToWitnessPubKeyHash(pkScript):
-				p2wpkh++
-			default:
-				p2pkh++
-			}
-		}
+		p2pkh, p2wpkh, nested, p2tr, scriptIns := inputCounts(scripts,
+			scriptSource)
 
-		maxSignedSize := txsizes.EstimateVirtualSize(p2pkh, p2wpkh,
-			nested, outputs, true)
+		maxSignedSize := txsizes.EstimateVirtualSizeScripts(p2pkh,
+			p2wpkh, nested, p2tr, scriptIns, outputs, true)
 		maxRequiredFee := txrules.FeeForSerializeSize(relayFeePerKb, maxSignedSize)
 		remainingAmount := inputAmount - targetAmount
 		if remainingAmount < maxRequiredFee {
@@ -202,16 +289,31 @@ func AddAllInputScripts(tx *wire.MsgTx, prevPkScripts [][]byte, inputValues []bt
 			"have equal length")
 	}
 
+	// Taproot signature hashes commit to every spent output, so collect
+	// them up front should any input need them.
+	prevOuts := make([]taproot.PrevOutput, len(inputs))
+	for i := range inputs {
+		prevOuts[i] = taproot.PrevOutput{
+			Value:    int64(inputValues[i]),
+			PkScript: prevPkScripts[i],
+		}
+	}
+
 	for i := range inputs {
 		pkScript := prevPkScripts[i]
 
 		switch {
-		// If this is a p2sh output, who's script hash pre-image is a
-		// witness program, then we'll need to use a modified signing
-		// function which generates both the sigScript, and the witness
-		// script.
+		// If this is a p2sh output, the redeem script determines how the
+		// sigScript, and possibly the witness, must be generated.
 		case txscript.IsPayToScriptHash(pkScript):
-			err := spendNestedWitnessPubKeyHash(inputs[i], pkScript,
+			err := spendScriptHash(inputs[i], pkScript,
+				int64(inputValues[i]), chainParams, secrets,
+				tx, hashCache, i)
+			if err != nil {
+				return err
+			}
+		case txscript.IsPayToWitnessScriptHash(pkScript):
+			err := spendWitnessScriptHash(inputs[i], pkScript,
 				int64(inputValues[i]), chainParams, secrets,
 				tx, hashCache, i)
 			if err != nil {
@@ -224,6 +326,12 @@ func AddAllInputScripts(tx *wire.MsgTx, prevPkScripts [][]byte, inputValues []bt
 			if err != nil {
 				return err
 			}
+		case taproot.IsPayToTaproot(pkScript):
+			err := spendTaprootKeyPath(inputs[i], pkScript,
+				prevOuts, chainParams, secrets, tx, i)
+			if err != nil {
+				return err
+			}
 		default:
 			sigScript := inputs[i].SignatureScript
 			script, err := txscript.SignTxOutput(chainParams, tx, i,
@@ -239,6 +347,39 @@ func AddAllInputScripts(tx *wire.MsgTx, prevPkScripts [][]byte, inputValues []bt
 	return nil
 }
 
+// spendTaprootKeyPath generates, and sets a valid witness for spending the
+// passed p2tr pkScript using the key path. The previous outputs of every
+// input must be known, since the BIP0341 sighash digest commits to all of
+// their amounts and scripts.
+func spendTaprootKeyPath(txIn *wire.TxIn, pkScript []byte,
+	prevOuts []taproot.PrevOutput, chainParams *chaincfg.Params,
+	secrets SecretsSource, tx *wire.MsgTx, idx int) error {
+
+	// First obtain the internal key associated with this p2tr address.
+	_, addrs, _, err := taproot.ExtractPkScriptAddrs(pkScript,
+		chainParams)
+	if err != nil {
+		return err
+	}
+	privKey, _, err := secrets.GetKey(addrs[0])
+	if err != nil {
+		return err
+	}
+
+	// The private key is tweaked to match the output key before signing
+	// with the default sighash, which omits the sighash type byte.
+	witness, err := taproot.KeyPathWitness(tx, idx, prevOuts,
+		taproot.SigHashDefault, privKey)
+	if err != nil {
+		return err
+	}
+
+	txIn.Witness = witness
+	txIn.SignatureScript = nil
+
+	return nil
+}
+
 // spendWitnessKeyHash generates, and sets a valid witness for spending the
 // passed pkScript with the specified input amount. The input amount *must*
 // correspond to the output value of the previous pkScript, or else verification
@@ -354,6 +495,141 @@ func spendNestedWitnessPubKeyHash(txIn *wire.TxIn, pkScript []byte,
 	return nil
 }
 
+// spendScriptHash generates the sigScript, and for nested witness programs
+// the witness, spending the passed p2sh pkScript.  Outputs paying to a
+// redeem script known to the secrets source are spent using that script,
+// while all other p2sh outputs are assumed to be nested p2wkh outputs of
+// wallet keys.
+func spendScriptHash(txIn *wire.TxIn, pkScript []byte,
+	inputValue int64, chainParams *chaincfg.Params, secrets SecretsSource,
+	tx *wire.MsgTx, hashCache *txscript.TxSigHashes, idx int) error {
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
+		chainParams)
+	if err != nil {
+		return err
+	}
+	redeemScript, err := secrets.GetScript(addrs[0])
+	if err != nil {
+		return spendNestedWitnessPubKeyHash(txIn, pkScript, inputValue,
+			chainParams, secrets, tx, hashCache, idx)
+	}
+
+	switch {
+	case txscript.IsPayToWitnessPubKeyHash(redeemScript):
+		return spendNestedWitnessPubKeyHash(txIn, pkScript, inputValue,
+			chainParams, secrets, tx, hashCache, idx)
+
+	// A nested p2wsh output is spent by pushing the witness program in
+	// the sigScript, and otherwise signed like a regular p2wsh output.
+	case txscript.IsPayToWitnessScriptHash(redeemScript):
+		err := spendWitnessScriptHash(txIn, redeemScript, inputValue,
+			chainParams, secrets, tx, hashCache, idx)
+		if err != nil {
+			return err
+		}
+		sigScript, err := txscript.NewScriptBuilder().
+			AddData(redeemScript).Script()
+		if err != nil {
+			return err
+		}
+		txIn.SignatureScript = sigScript
+		return nil
+	}
+
+	sigScript, err := txscript.SignTxOutput(chainParams, tx, idx,
+		pkScript, txscript.SigHashAll, secrets, secrets,
+		txIn.SignatureScript)
+	if err != nil {
+		return err
+	}
+	txIn.SignatureScript = sigScript
+	return nil
+}
+
+// spendWitnessScriptHash generates, and sets a valid witness for spending the
+// passed p2wsh pkScript with the specified input amount.  The witness script
+// is looked up using the secrets source, and must be a multisig, p2pk, or
+// p2pkh script.  The input amount *must* correspond to the output value of
+// the previous pkScript, as the BIP0143 sighash digest commits to it.
+func spendWitnessScriptHash(txIn *wire.TxIn, pkScript []byte,
+	inputValue int64, chainParams *chaincfg.Params, secrets SecretsSource,
+	tx *wire.MsgTx, hashCache *txscript.TxSigHashes, idx int) error {
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
+		chainParams)
+	if err != nil {
+		return err
+	}
+	witnessScript, err := secrets.GetScript(addrs[0])
+	if err != nil {
+		return err
+	}
+	class, scriptAddrs, nRequired, err := txscript.ExtractPkScriptAddrs(
+		witnessScript, chainParams)
+	if err != nil {
+		return err
+	}
+
+	sign := func(privKey *btcec.PrivateKey) ([]byte, error) {
+		return txscript.RawTxInWitnessSignature(tx, hashCache, idx,
+			inputValue, witnessScript, txscript.SigHashAll, privKey)
+	}
+
+	var witness wire.TxWitness
+	switch class {
+	case txscript.MultiSigTy:
+		// OP_CHECKMULTISIG pops an extra unused stack element, so
+		// the witness begins with an empty item.  Signatures must be
+		// in the same order as their keys in the script.
+		witness = wire.TxWitness{nil}
+		for _, addr := range scriptAddrs {
+			if len(witness)-1 == nRequired {
+				break
+			}
+			privKey, _, err := secrets.GetKey(addr)
+			if err != nil {
+				continue
+			}
+			sig, err := sign(privKey)
+			if err != nil {
+				return err
+			}
+			witness = append(witness, sig)
+		}
+		if len(witness)-1 < nRequired {
+			return fmt.Errorf("witness script requires %d signatures "+
+				"but only %d keys are available", nRequired,
+				len(witness)-1)
+		}
+
+	case txscript.PubKeyTy, txscript.PubKeyHashTy:
+		privKey, compressed, err := secrets.GetKey(scriptAddrs[0])
+		if err != nil {
+			return err
+		}
+		sig, err := sign(privKey)
+		if err != nil {
+			return err
+		}
+		witness = wire.TxWitness{sig}
+		if class == txscript.PubKeyHashTy {
+			pubKey := privKey.PubKey().SerializeUncompressed()
+			if compressed {
+				pubKey = privKey.PubKey().SerializeCompressed()
+			}
+			witness = append(witness, pubKey)
+		}
+
+	default:
+		return fmt.Errorf("unsupported witness script type %v", class)
+	}
+
+	txIn.Witness = append(witness, witnessScript)
+
+	return nil
+}
+
 // AddAllInputScripts modifies an authored transaction by adding inputs scripts
 // for each input of an authored transaction.  Private keys and redeem scripts
 // are looked up using a SecretsSource based on the previous output script.
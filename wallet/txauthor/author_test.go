@@ -63,7 +63,7 @@ func TestNewUnsignedTransaction(t *testing.T) {
 			Outputs:        p2pkhOutputs(1e6),
 			RelayFee:       1e3,
 			ChangeAmount: 1e8 - 1e6 - txrules.FeeForSerializeSize(1e3,
-				txsizes.EstimateVirtualSize(1, 0, 0, p2pkhOutputs(1e6), true)),
+				txsizes.EstimateVirtualSize(1, 0, 0, 0, p2pkhOutputs(1e6), true)),
 			InputCount: 1,
 		},
 		2: {
@@ -71,7 +71,7 @@ func TestNewUnsignedTransaction(t *testing.T) {
 			Outputs:        p2pkhOutputs(1e6),
 			RelayFee:       1e4,
 			ChangeAmount: 1e8 - 1e6 - txrules.FeeForSerializeSize(1e4,
-				txsizes.EstimateVirtualSize(1, 0, 0, p2pkhOutputs(1e6), true)),
+				txsizes.EstimateVirtualSize(1, 0, 0, 0, p2pkhOutputs(1e6), true)),
 			InputCount: 1,
 		},
 		3: {
@@ -79,7 +79,7 @@ func TestNewUnsignedTransaction(t *testing.T) {
 			Outputs:        p2pkhOutputs(1e6, 1e6, 1e6),
 			RelayFee:       1e4,
 			ChangeAmount: 1e8 - 3e6 - txrules.FeeForSerializeSize(1e4,
-				txsizes.EstimateVirtualSize(1, 0, 0, p2pkhOutputs(1e6, 1e6, 1e6), true)),
+				txsizes.EstimateVirtualSize(1, 0, 0, 0, p2pkhOutputs(1e6, 1e6, 1e6), true)),
 			InputCount: 1,
 		},
 		4: {
@@ -87,7 +87,7 @@ func TestNewUnsignedTransaction(t *testing.T) {
 			Outputs:        p2pkhOutputs(1e6, 1e6, 1e6),
 			RelayFee:       2.55e3,
 			ChangeAmount: 1e8 - 3e6 - txrules.FeeForSerializeSize(2.55e3,
-				txsizes.EstimateVirtualSize(1, 0, 0, p2pkhOutputs(1e6, 1e6, 1e6), true)),
+				txsizes.EstimateVirtualSize(1, 0, 0, 0, p2pkhOutputs(1e6, 1e6, 1e6), true)),
 			InputCount: 1,
 		},
 
@@ -95,7 +95,7 @@ func TestNewUnsignedTransaction(t *testing.T) {
 		5: {
 			UnspentOutputs: p2pkhOutputs(1e8),
 			Outputs: p2pkhOutputs(1e8 - 545 - txrules.FeeForSerializeSize(1e3,
-				txsizes.EstimateVirtualSize(1, 0, 0, p2pkhOutputs(0), true))),
+				txsizes.EstimateVirtualSize(1, 0, 0, 0, p2pkhOutputs(0), true))),
 			RelayFee:     1e3,
 			ChangeAmount: 545,
 			InputCount:   1,
@@ -103,7 +103,7 @@ func TestNewUnsignedTransaction(t *testing.T) {
 		6: {
 			UnspentOutputs: p2pkhOutputs(1e8),
 			Outputs: p2pkhOutputs(1e8 - 546 - txrules.FeeForSerializeSize(1e3,
-				txsizes.EstimateVirtualSize(1, 0, 0, p2pkhOutputs(0), true))),
+				txsizes.EstimateVirtualSize(1, 0, 0, 0, p2pkhOutputs(0), true))),
 			RelayFee:     1e3,
 			ChangeAmount: 546,
 			InputCount:   1,
@@ -113,7 +113,7 @@ func TestNewUnsignedTransaction(t *testing.T) {
 		7: {
 			UnspentOutputs: p2pkhOutputs(1e8),
 			Outputs: p2pkhOutputs(1e8 - 1392 - txrules.FeeForSerializeSize(2.55e3,
-				txsizes.EstimateVirtualSize(1, 0, 0, p2pkhOutputs(0), true))),
+				txsizes.EstimateVirtualSize(1, 0, 0, 0, p2pkhOutputs(0), true))),
 			RelayFee:     2.55e3,
 			ChangeAmount: 1392,
 			InputCount:   1,
@@ -121,7 +121,7 @@ func TestNewUnsignedTransaction(t *testing.T) {
 		8: {
 			UnspentOutputs: p2pkhOutputs(1e8),
 			Outputs: p2pkhOutputs(1e8 - 1393 - txrules.FeeForSerializeSize(2.55e3,
-				txsizes.EstimateVirtualSize(1, 0, 0, p2pkhOutputs(0), true))),
+				txsizes.EstimateVirtualSize(1, 0, 0, 0, p2pkhOutputs(0), true))),
 			RelayFee:     2.55e3,
 			ChangeAmount: 1393,
 			InputCount:   1,
@@ -133,7 +133,7 @@ func TestNewUnsignedTransaction(t *testing.T) {
 		9: {
 			UnspentOutputs: p2pkhOutputs(1e8, 1e8),
 			Outputs: p2pkhOutputs(1e8 - 546 - txrules.FeeForSerializeSize(1e3,
-				txsizes.EstimateVirtualSize(1, 0, 0, p2pkhOutputs(0), true))),
+				txsizes.EstimateVirtualSize(1, 0, 0, 0, p2pkhOutputs(0), true))),
 			RelayFee:     1e3,
 			ChangeAmount: 546,
 			InputCount:   1,
@@ -147,7 +147,7 @@ func TestNewUnsignedTransaction(t *testing.T) {
 		10: {
 			UnspentOutputs: p2pkhOutputs(1e8, 1e8),
 			Outputs: p2pkhOutputs(1e8 - 545 - txrules.FeeForSerializeSize(1e3,
-				txsizes.EstimateVirtualSize(1, 0, 0, p2pkhOutputs(0), true))),
+				txsizes.EstimateVirtualSize(1, 0, 0, 0, p2pkhOutputs(0), true))),
 			RelayFee:     1e3,
 			ChangeAmount: 545,
 			InputCount:   1,
@@ -159,7 +159,7 @@ func TestNewUnsignedTransaction(t *testing.T) {
 			Outputs:        p2pkhOutputs(1e8),
 			RelayFee:       1e3,
 			ChangeAmount: 1e8 - txrules.FeeForSerializeSize(1e3,
-				txsizes.EstimateVirtualSize(2, 0, 0, p2pkhOutputs(1e8), true)),
+				txsizes.EstimateVirtualSize(2, 0, 0, 0, p2pkhOutputs(1e8), true)),
 			InputCount: 2,
 		},
 
@@ -219,3 +219,66 @@ func TestNewUnsignedTransaction(t *testing.T) {
 		}
 	}
 }
+
+func TestNewUnsignedTransactionSubtractFee(t *testing.T) {
+	changeSource := func() ([]byte, error) {
+		return make([]byte, txsizes.P2WPKHPkScriptSize), nil
+	}
+
+	// The fee is split between the first and last outputs in proportion
+	// to their values, and the inputs only pay the outputs.
+	outputs := p2pkhOutputs(3e6, 1e6, 1e6)
+	fee := txrules.FeeForSerializeSize(1e4,
+		txsizes.EstimateVirtualSize(1, 0, 0, 0, outputs, true))
+	tx, err := NewUnsignedTransactionSubtractFee(outputs, 1e4,
+		makeInputSource(p2pkhOutputs(1e8)), changeSource, nil,
+		[]int{0, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstShare := fee * 3 / 4
+	if v := btcutil.Amount(tx.Tx.TxOut[0].Value); v != 3e6-firstShare {
+		t.Errorf("first output is %v, want %v", v, 3e6-firstShare)
+	}
+	if v := tx.Tx.TxOut[1].Value; v != 1e6 {
+		t.Errorf("second output is %v, want %v", v, int64(1e6))
+	}
+	if v := btcutil.Amount(tx.Tx.TxOut[2].Value); v != 1e6-(fee-firstShare) {
+		t.Errorf("last output is %v, want %v", v, 1e6-(fee-firstShare))
+	}
+	if tx.ChangeIndex != 3 || tx.Tx.TxOut[3].Value != 1e8-5e6 {
+		t.Errorf("change output %d is not the input value left after "+
+			"the outputs", tx.ChangeIndex)
+	}
+	if outputs[0].Value != 3e6 {
+		t.Errorf("passed output was modified")
+	}
+
+	// An input paying exactly the outputs needs no change.
+	tx, err = NewUnsignedTransactionSubtractFee(p2pkhOutputs(1e8), 1e4,
+		makeInputSource(p2pkhOutputs(1e8)), changeSource, nil, []int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.ChangeIndex != -1 || len(tx.Tx.TxIn) != 1 {
+		t.Errorf("unexpected change %d or inputs %d", tx.ChangeIndex,
+			len(tx.Tx.TxIn))
+	}
+
+	// Outputs which would be dust after paying the fee are rejected.
+	_, err = NewUnsignedTransactionSubtractFee(p2pkhOutputs(1e3), 1e4,
+		makeInputSource(p2pkhOutputs(1e8)), changeSource, nil, []int{0})
+	if err == nil {
+		t.Errorf("dust output paying the fee was not rejected")
+	}
+
+	// Indexes must name distinct outputs.
+	for _, indexes := range [][]int{{1}, {0, 0}, {-1}} {
+		_, err = NewUnsignedTransactionSubtractFee(p2pkhOutputs(1e6),
+			1e4, makeInputSource(p2pkhOutputs(1e8)), changeSource,
+			nil, indexes)
+		if err == nil {
+			t.Errorf("indexes %v were not rejected", indexes)
+		}
+	}
+}
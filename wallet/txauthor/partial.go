@@ -0,0 +1,117 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txauthor
+
+import (
+	"errors"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+)
+
+// PartialSignature is the signature of a single key for a transaction input.
+// PubKey is serialized as it appears in the signed script.
+type PartialSignature struct {
+	PubKey    []byte
+	Signature []byte
+}
+
+// InputSignatures returns a signature for every key of the secrets source
+// that the script spending input idx commits to.  Unlike AddAllInputScripts,
+// no sigScript or witness is created, so signatures of several signers can be
+// collected before the input is finalized.  The redeem script must be passed
+// for p2sh outputs, and the witness script for p2wsh and nested p2wsh
+// outputs.  The input value is required for witness outputs, since the
+// BIP0143 sighash digest commits to it.  Taproot outputs are not supported.
+func InputSignatures(tx *wire.MsgTx, hashCache *txscript.TxSigHashes, idx int,
+	pkScript, redeemScript, witnessScript []byte, inputValue int64,
+	hashType txscript.SigHashType, secrets SecretsSource) ([]PartialSignature, error) {
+
+	// Keys of nested p2wpkh outputs are known to the secrets source by
+	// their p2sh address, so they are looked up using keyScript, which
+	// only differs from the signed script for these outputs.
+	var signScript, keyScript []byte
+	var witness bool
+	switch {
+	case txscript.IsPayToScriptHash(pkScript):
+		if redeemScript == nil {
+			return nil, errors.New("redeem script required to sign " +
+				"p2sh input")
+		}
+		switch {
+		case txscript.IsPayToWitnessPubKeyHash(redeemScript):
+			signScript, keyScript, witness = redeemScript, pkScript, true
+		case txscript.IsPayToWitnessScriptHash(redeemScript):
+			if witnessScript == nil {
+				return nil, errors.New("witness script required " +
+					"to sign nested p2wsh input")
+			}
+			signScript, witness = witnessScript, true
+		default:
+			signScript = redeemScript
+		}
+	case txscript.IsPayToWitnessScriptHash(pkScript):
+		if witnessScript == nil {
+			return nil, errors.New("witness script required to sign " +
+				"p2wsh input")
+		}
+		signScript, witness = witnessScript, true
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		signScript, witness = pkScript, true
+	case taproot.IsPayToTaproot(pkScript):
+		return nil, errors.New("partial signatures of taproot inputs " +
+			"are not supported")
+	default:
+		signScript = pkScript
+	}
+
+	if keyScript == nil {
+		keyScript = signScript
+	}
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(keyScript,
+		secrets.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+
+	var sigs []PartialSignature
+	for _, addr := range addrs {
+		privKey, compressed, err := secrets.GetKey(addr)
+		if err != nil {
+			continue
+		}
+
+		var sig []byte
+		if witness {
+			sig, err = txscript.RawTxInWitnessSignature(tx, hashCache,
+				idx, inputValue, signScript, hashType, privKey)
+		} else {
+			sig, err = txscript.RawTxInSignature(tx, idx, signScript,
+				hashType, privKey)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var pubKey []byte
+		switch addr := addr.(type) {
+		case *btcutil.AddressPubKey:
+			pubKey = addr.ScriptAddress()
+		default:
+			if compressed {
+				pubKey = privKey.PubKey().SerializeCompressed()
+			} else {
+				pubKey = privKey.PubKey().SerializeUncompressed()
+			}
+		}
+		sigs = append(sigs, PartialSignature{
+			PubKey:    pubKey,
+			Signature: sig,
+		})
+	}
+	return sigs, nil
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txauthor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// testSecrets is a SecretsSource backed by maps of keys and scripts.
+type testSecrets struct {
+	keys    map[string]*btcec.PrivateKey
+	scripts map[string][]byte
+}
+
+func (s *testSecrets) GetKey(addr btcutil.Address) (*btcec.PrivateKey, bool, error) {
+	if pk, ok := addr.(*btcutil.AddressPubKey); ok {
+		addr = pk.AddressPubKeyHash()
+	}
+	key, ok := s.keys[addr.EncodeAddress()]
+	if !ok {
+		return nil, false, errors.New("no key")
+	}
+	return key, true, nil
+}
+
+func (s *testSecrets) GetScript(addr btcutil.Address) ([]byte, error) {
+	script, ok := s.scripts[addr.EncodeAddress()]
+	if !ok {
+		return nil, errors.New("no script")
+	}
+	return script, nil
+}
+
+func (s *testSecrets) ChainParams() *chaincfg.Params {
+	return &chaincfg.RegressionNetParams
+}
+
+// TestSpendWitnessScriptHash checks that P2WSH and P2SH-P2WSH multisig
+// outputs are signed using the scripts of the secrets source, and that the
+// available keys must satisfy the script.
+func TestSpendWitnessScriptHash(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	secrets := &testSecrets{
+		keys:    make(map[string]*btcec.PrivateKey),
+		scripts: make(map[string][]byte),
+	}
+
+	var pubKeys []*btcutil.AddressPubKey
+	for i := byte(1); i <= 3; i++ {
+		privKey, pubKey := btcec.PrivKeyFromBytes(btcec.S256(),
+			bytes.Repeat([]byte{i}, 32))
+		addr, err := btcutil.NewAddressPubKey(pubKey.SerializeCompressed(), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubKeys = append(pubKeys, addr)
+		// Only the first two keys are controlled.
+		if i <= 2 {
+			secrets.keys[addr.AddressPubKeyHash().EncodeAddress()] = privKey
+		}
+	}
+	witnessScript, err := txscript.MultiSigScript(pubKeys, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptHash := sha256.Sum256(witnessScript)
+	p2wshAddr, err := btcutil.NewAddressWitnessScriptHash(scriptHash[:], params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2wshScript, err := txscript.PayToAddrScript(p2wshAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2shAddr, err := btcutil.NewAddressScriptHash(p2wshScript, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2shScript, err := txscript.PayToAddrScript(p2shAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets.scripts[p2wshAddr.EncodeAddress()] = witnessScript
+	secrets.scripts[p2shAddr.EncodeAddress()] = p2wshScript
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1e6, p2wshScript))
+	prevScripts := [][]byte{p2wshScript, p2shScript}
+	inputValues := []btcutil.Amount{1e6, 2e6}
+
+	err = AddAllInputScripts(tx, prevScripts, inputValues, secrets)
+	if err != nil {
+		t.Fatalf("unable to sign: %v", err)
+	}
+	hashCache := txscript.NewTxSigHashes(tx)
+	for i, prevScript := range prevScripts {
+		vm, err := txscript.NewEngine(prevScript, tx, i,
+			txscript.StandardVerifyFlags, nil, hashCache,
+			int64(inputValues[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := vm.Execute(); err != nil {
+			t.Errorf("input %d does not verify: %v", i, err)
+		}
+	}
+
+	// With a single key the 2-of-3 script can not be satisfied.
+	delete(secrets.keys, pubKeys[1].AddressPubKeyHash().EncodeAddress())
+	err = AddAllInputScripts(tx, prevScripts, inputValues, secrets)
+	if err == nil {
+		t.Errorf("signed multisig input without enough keys")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txauthor
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/internal/txsizes"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+
+	h "github.com/btcsuite/btcwallet/internal/helpers"
+)
+
+// NewUnsignedTransactionSubtractFee creates an unsigned transaction like
+// NewUnsignedTransaction, except that the fee is deducted from the outputs at
+// the indexes subtractFeeFrom instead of being paid by the inputs.  The fee is
+// split between these outputs in proportion to their values, and any rounding
+// remainder is deducted from the last of them.  Inputs are only chosen to pay
+// the output values, and the outputs are copied, so the values of the passed
+// outputs are not modified.  An error is returned if an output would be dust
+// after its share of the fee is deducted.  Without subtractFeeFrom indexes,
+// the transaction is created like NewUnsignedTransaction.
+//
+// The sizes of inputs spending P2SH and P2WSH outputs are estimated from the
+// redeem and witness scripts of the script source.  If scriptSource is nil,
+// P2SH outputs are assumed to be nested P2WPKH outputs.
+func NewUnsignedTransactionSubtractFee(outputs []*wire.TxOut,
+	relayFeePerKb btcutil.Amount, fetchInputs InputSource,
+	fetchChange ChangeSource, scriptSource ScriptSource,
+	subtractFeeFrom []int) (*AuthoredTx, error) {
+
+	if len(subtractFeeFrom) == 0 {
+		return newUnsignedTransaction(outputs, relayFeePerKb, fetchInputs,
+			fetchChange, scriptSource)
+	}
+	seen := make(map[int]struct{}, len(subtractFeeFrom))
+	for _, i := range subtractFeeFrom {
+		if i < 0 || i >= len(outputs) {
+			return nil, fmt.Errorf("no output %d to subtract the fee "+
+				"from", i)
+		}
+		if _, ok := seen[i]; ok {
+			return nil, fmt.Errorf("output %d is listed twice to "+
+				"subtract the fee from", i)
+		}
+		seen[i] = struct{}{}
+	}
+
+	targetAmount := h.SumOutputValues(outputs)
+	inputAmount, inputs, inputValues, scripts, err := fetchInputs(targetAmount)
+	if err != nil {
+		return nil, err
+	}
+	if inputAmount < targetAmount {
+		return nil, insufficientFundsError{}
+	}
+
+	// The fee is estimated with a change output, unless the value left
+	// after the outputs is dust, which then pays part of the fee.
+	p2pkh, p2wpkh, nested, p2tr, scriptIns := inputCounts(scripts,
+		scriptSource)
+	changeAmount := inputAmount - targetAmount
+	addChange := changeAmount != 0 && !txrules.IsDustAmount(changeAmount,
+		txsizes.P2WPKHPkScriptSize, relayFeePerKb)
+	maxSignedSize := txsizes.EstimateVirtualSizeScripts(p2pkh, p2wpkh,
+		nested, p2tr, scriptIns, outputs, addChange)
+	fee := txrules.FeeForSerializeSize(relayFeePerKb, maxSignedSize)
+	if !addChange {
+		fee -= changeAmount
+		if fee < 0 {
+			fee = 0
+		}
+	}
+
+	txOuts := make([]*wire.TxOut, len(outputs), len(outputs)+1)
+	for i, output := range outputs {
+		txOut := *output
+		txOuts[i] = &txOut
+	}
+	if err := subtractFee(txOuts, subtractFeeFrom, fee, relayFeePerKb); err != nil {
+		return nil, err
+	}
+
+	unsignedTransaction := &wire.MsgTx{
+		Version:  wire.TxVersion,
+		TxIn:     inputs,
+		TxOut:    txOuts,
+		LockTime: 0,
+	}
+	changeIndex := -1
+	if addChange {
+		changeScript, err := fetchChange()
+		if err != nil {
+			return nil, err
+		}
+		if len(changeScript) > txsizes.P2WPKHPkScriptSize {
+			return nil, errors.New("fee estimation requires change " +
+				"scripts no larger than P2WPKH output scripts")
+		}
+		change := wire.NewTxOutToken(int64(changeAmount), changeScript,
+			txOuts[0].TokenID())
+		unsignedTransaction.TxOut = append(txOuts, change)
+		changeIndex = len(txOuts)
+	}
+
+	return &AuthoredTx{
+		Tx:              unsignedTransaction,
+		PrevScripts:     scripts,
+		PrevInputValues: inputValues,
+		TotalInput:      inputAmount,
+		ChangeIndex:     changeIndex,
+	}, nil
+}
+
+// subtractFee deducts fee from the outputs at the indexes, in proportion to
+// their values.
+func subtractFee(outputs []*wire.TxOut, indexes []int, fee,
+	relayFeePerKb btcutil.Amount) error {
+
+	var total int64
+	for _, i := range indexes {
+		total += outputs[i].Value
+	}
+	if total <= 0 {
+		return errors.New("outputs paying the fee have no value")
+	}
+
+	// Shares are computed with big integers, since the product of the fee
+	// and an output value may overflow.
+	remaining := fee
+	for n, i := range indexes {
+		share := remaining
+		if n != len(indexes)-1 {
+			s := new(big.Int).Mul(big.NewInt(int64(fee)),
+				big.NewInt(outputs[i].Value))
+			s.Quo(s, big.NewInt(total))
+			share = btcutil.Amount(s.Int64())
+		}
+		remaining -= share
+		outputs[i].Value -= int64(share)
+		if outputs[i].Value <= 0 || txrules.IsDustOutput(outputs[i],
+			relayFeePerKb) {
+
+			return fmt.Errorf("output %d cannot pay its share %v of "+
+				"the fee %v", i, share, fee)
+		}
+	}
+	return nil
+}
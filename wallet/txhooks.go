@@ -0,0 +1,232 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TxHookPoint identifies a point in the transaction lifecycle at which
+// registered transaction hooks are invoked.
+type TxHookPoint uint8
+
+// These constants define the transaction hook points.
+const (
+	// HookBeforeCoinSelection is invoked with the requested outputs
+	// before any wallet outputs are chosen to fund them.
+	HookBeforeCoinSelection TxHookPoint = iota
+
+	// HookBeforeSigning is invoked with the unsigned transaction after
+	// coin selection and change creation.
+	HookBeforeSigning
+
+	// HookBeforeBroadcast is invoked with the signed transaction before
+	// it is recorded and published to the network.
+	HookBeforeBroadcast
+
+	// HookOnConfirmation is invoked when a wallet transaction is mined.
+	// Hooks at this point are purely informational and can not veto.
+	HookOnConfirmation
+)
+
+var txHookPointStrings = [...]string{
+	HookBeforeCoinSelection: "beforecoinselection",
+	HookBeforeSigning:       "beforesigning",
+	HookBeforeBroadcast:     "beforebroadcast",
+	HookOnConfirmation:      "onconfirmation",
+}
+
+// String returns the name of the hook point.
+func (p TxHookPoint) String() string {
+	if int(p) < len(txHookPointStrings) {
+		return txHookPointStrings[p]
+	}
+	return fmt.Sprintf("TxHookPoint(%d)", uint8(p))
+}
+
+// TxHookEvent describes the transaction being processed at a hook point.
+type TxHookEvent struct {
+	Point TxHookPoint
+
+	// Account is the account funding the transaction.  It is only set
+	// for the coin selection and signing hook points.
+	Account uint32
+
+	// Outputs are the outputs requested by the caller.  They are only
+	// set for the coin selection hook point.
+	Outputs []*wire.TxOut
+
+	// Tx is the transaction being processed.  It is nil before coin
+	// selection.
+	Tx *wire.MsgTx
+
+	// Block is the block containing the transaction.  It is only set for
+	// the confirmation hook point.
+	Block *wtxmgr.BlockMeta
+
+	// Annotations holds the annotations added to the transaction by
+	// hooks at earlier points.
+	Annotations map[string]string
+}
+
+// TxHookResult is the response of a transaction hook.
+type TxHookResult struct {
+	// Veto rejects the transaction when set.  The transaction is not
+	// created or broadcast.
+	Veto   bool
+	Reason string
+
+	// Annotations are merged into the annotations of the transaction and
+	// passed to hooks at later points.
+	Annotations map[string]string
+}
+
+// TxHook is implemented by plugins that inspect wallet transactions at the
+// defined hook points, for example to perform custom compliance or risk
+// checks.
+type TxHook interface {
+	// Name returns a name identifying the hook in logs and errors.
+	Name() string
+
+	// HandleTxEvent processes a hook event.  A nil result accepts the
+	// transaction without annotations.  A returned error is treated as
+	// a veto at every point except HookOnConfirmation.
+	HandleTxEvent(*TxHookEvent) (*TxHookResult, error)
+}
+
+// TxVetoError describes a transaction that was rejected by a hook.
+type TxVetoError struct {
+	Hook   string
+	Point  TxHookPoint
+	Reason string
+}
+
+// Error satisfies the error interface.
+func (e *TxVetoError) Error() string {
+	return fmt.Sprintf("transaction vetoed by hook %s at %v: %s", e.Hook,
+		e.Point, e.Reason)
+}
+
+// txHookSet holds the registered transaction hooks and the annotations of
+// transactions that have not yet been confirmed.
+type txHookSet struct {
+	mu          sync.Mutex
+	hooks       []TxHook
+	annotations map[chainhash.Hash]map[string]string
+}
+
+// RegisterTxHook adds a hook to be invoked at every transaction hook point.
+// Hooks are invoked in the order they were registered.
+func (w *Wallet) RegisterTxHook(h TxHook) {
+	w.txHooks.mu.Lock()
+	w.txHooks.hooks = append(w.txHooks.hooks, h)
+	w.txHooks.mu.Unlock()
+}
+
+// TxAnnotations returns the annotations added by hooks to an unconfirmed
+// transaction created or broadcast by the wallet.
+func (w *Wallet) TxAnnotations(txHash *chainhash.Hash) map[string]string {
+	w.txHooks.mu.Lock()
+	defer w.txHooks.mu.Unlock()
+
+	annotations := make(map[string]string)
+	for k, v := range w.txHooks.annotations[*txHash] {
+		annotations[k] = v
+	}
+	return annotations
+}
+
+// runTxHooks invokes every registered hook with the event, merging the
+// returned annotations into the event.  The first veto stops processing and
+// is returned as a *TxVetoError.
+func (w *Wallet) runTxHooks(ev *TxHookEvent) error {
+	w.txHooks.mu.Lock()
+	hooks := w.txHooks.hooks
+	if ev.Annotations == nil {
+		ev.Annotations = make(map[string]string)
+		if ev.Tx != nil {
+			for k, v := range w.txHooks.annotations[ev.Tx.TxHash()] {
+				ev.Annotations[k] = v
+			}
+		}
+	}
+	w.txHooks.mu.Unlock()
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	for _, h := range hooks {
+		res, err := h.HandleTxEvent(ev)
+		if err != nil {
+			if ev.Point == HookOnConfirmation {
+				log.Errorf("Transaction hook %s failed at %v: %v",
+					h.Name(), ev.Point, err)
+				continue
+			}
+			return &TxVetoError{Hook: h.Name(), Point: ev.Point,
+				Reason: err.Error()}
+		}
+		if res == nil {
+			continue
+		}
+		for k, v := range res.Annotations {
+			ev.Annotations[k] = v
+		}
+		if res.Veto && ev.Point != HookOnConfirmation {
+			return &TxVetoError{Hook: h.Name(), Point: ev.Point,
+				Reason: res.Reason}
+		}
+	}
+	return nil
+}
+
+// saveTxAnnotations records the annotations of an unconfirmed transaction so
+// they are passed to the hooks of later points.  Since signing changes the
+// hash of transactions with non-witness inputs, annotations must only be
+// saved once the transaction is signed.
+func (w *Wallet) saveTxAnnotations(txHash chainhash.Hash, annotations map[string]string) {
+	w.txHooks.mu.Lock()
+	defer w.txHooks.mu.Unlock()
+
+	if len(annotations) == 0 {
+		return
+	}
+	if w.txHooks.annotations == nil {
+		w.txHooks.annotations = make(map[chainhash.Hash]map[string]string)
+	}
+	w.txHooks.annotations[txHash] = annotations
+}
+
+// notifyTxConfirmed invokes the confirmation hooks for a mined transaction
+// and forgets its annotations.  The hooks run in their own goroutine so that
+// slow plugins do not hold up block processing.
+func (w *Wallet) notifyTxConfirmed(tx *wire.MsgTx, block *wtxmgr.BlockMeta) {
+	w.txHooks.mu.Lock()
+	n := len(w.txHooks.hooks)
+	w.txHooks.mu.Unlock()
+	if n == 0 {
+		return
+	}
+
+	blockCopy := *block
+	go func() {
+		ev := &TxHookEvent{
+			Point: HookOnConfirmation,
+			Tx:    tx,
+			Block: &blockCopy,
+		}
+		w.runTxHooks(ev)
+
+		w.txHooks.mu.Lock()
+		delete(w.txHooks.annotations, tx.TxHash())
+		w.txHooks.mu.Unlock()
+	}()
+}
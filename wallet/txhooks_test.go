@@ -0,0 +1,132 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+type testTxHook struct {
+	name  string
+	calls []TxHookPoint
+	fn    func(*TxHookEvent) (*TxHookResult, error)
+}
+
+func (h *testTxHook) Name() string { return h.name }
+
+func (h *testTxHook) HandleTxEvent(ev *TxHookEvent) (*TxHookResult, error) {
+	h.calls = append(h.calls, ev.Point)
+	return h.fn(ev)
+}
+
+// TestTxHooks checks that hooks annotate and veto transactions in
+// registration order.
+func TestTxHooks(t *testing.T) {
+	w := &Wallet{}
+	tx := wire.NewMsgTx(wire.TxVersion)
+
+	annotator := &testTxHook{name: "annotator", fn: func(ev *TxHookEvent) (*TxHookResult, error) {
+		return &TxHookResult{Annotations: map[string]string{
+			"seen": ev.Point.String(),
+		}}, nil
+	}}
+	vetoer := &testTxHook{name: "vetoer", fn: func(ev *TxHookEvent) (*TxHookResult, error) {
+		if ev.Annotations["seen"] != ev.Point.String() {
+			t.Errorf("%v: missing annotation of earlier hook", ev.Point)
+		}
+		switch ev.Point {
+		case HookBeforeBroadcast:
+			return &TxHookResult{Veto: true, Reason: "blocked"}, nil
+		case HookOnConfirmation:
+			return nil, errors.New("failure")
+		}
+		return nil, nil
+	}}
+	w.RegisterTxHook(annotator)
+	w.RegisterTxHook(vetoer)
+
+	ev := &TxHookEvent{Point: HookBeforeSigning, Tx: tx}
+	if err := w.runTxHooks(ev); err != nil {
+		t.Fatalf("unexpected veto: %v", err)
+	}
+	w.saveTxAnnotations(tx.TxHash(), ev.Annotations)
+	txHash := tx.TxHash()
+	if got := w.TxAnnotations(&txHash)["seen"]; got != "beforesigning" {
+		t.Errorf("saved annotation %q, want beforesigning", got)
+	}
+
+	err := w.runTxHooks(&TxHookEvent{Point: HookBeforeBroadcast, Tx: tx})
+	vetoErr, ok := err.(*TxVetoError)
+	if !ok {
+		t.Fatalf("expected *TxVetoError, got %v", err)
+	}
+	if vetoErr.Hook != "vetoer" || vetoErr.Point != HookBeforeBroadcast ||
+		vetoErr.Reason != "blocked" {
+		t.Errorf("unexpected veto %+v", vetoErr)
+	}
+
+	// Confirmation hooks can not veto, so every hook runs and errors are
+	// only logged.
+	err = w.runTxHooks(&TxHookEvent{Point: HookOnConfirmation, Tx: tx})
+	if err != nil {
+		t.Errorf("confirmation hook vetoed: %v", err)
+	}
+	if len(annotator.calls) != 3 || len(vetoer.calls) != 3 {
+		t.Errorf("hooks called %d and %d times, want 3", len(annotator.calls),
+			len(vetoer.calls))
+	}
+}
+
+// TestTxHooksBeforeSigningOutsideDBTx checks that the hooks invoked before
+// signing can write to the wallet database.
+func TestTxHooksBeforeSigningOutsideDBTx(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fundTestWallet(t, w, pkScript, 1e8)
+
+	hook := &testTxHook{name: "writer", fn: func(ev *TxHookEvent) (*TxHookResult, error) {
+		if ev.Point != HookBeforeSigning {
+			return nil, nil
+		}
+		return nil, walletdb.Update(w.db, func(walletdb.ReadWriteTx) error {
+			return nil
+		})
+	}}
+	w.RegisterTxHook(hook)
+
+	done := make(chan error, 1)
+	go func() {
+		outputs := []*wire.TxOut{wire.NewTxOut(1e7, pkScript)}
+		_, err := w.txToOutputs(outputs, 0, 0, 1e4, nil, true, false)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("hook before signing blocked on the database")
+	}
+	if len(hook.calls) != 2 || hook.calls[1] != HookBeforeSigning {
+		t.Errorf("unexpected hook calls %v", hook.calls)
+	}
+}
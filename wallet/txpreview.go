@@ -0,0 +1,110 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/helpers"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/wallet/internal/txsizes"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+)
+
+// previewChangeScript stands for the change output of a transaction preview,
+// whose change address is only derived when the transaction is created.  It
+// is a P2WPKH script, the size the fees of change outputs are estimated with.
+var previewChangeScript = append([]byte{txscript.OP_0, txscript.OP_DATA_20},
+	make([]byte, 20)...)
+
+// TxPreview describes the transaction the wallet would create to pay outputs,
+// without signing nor recording it.
+type TxPreview struct {
+	Tx              *wire.MsgTx
+	PrevScripts     [][]byte
+	PrevInputValues []btcutil.Amount
+
+	// ChangeIndex is the index of the change output, or -1 without
+	// change.  Unless the change address was chosen, the change output
+	// pays previewChangeScript, and IsPreviewChange reports it.
+	ChangeIndex int
+
+	Fee         btcutil.Amount
+	VirtualSize int
+}
+
+// IsPreviewChange returns whether an output script stands for the change
+// output of a preview, whose address is not derived yet.
+func IsPreviewChange(pkScript []byte) bool {
+	return string(pkScript) == string(previewChangeScript)
+}
+
+// PreviewTx performs the coin selection and fee calculation of the transaction
+// CreateSimpleTx would create, and returns the chosen inputs, the outputs, the
+// fee and the estimated virtual size of the signed transaction, so that the
+// transaction can be confirmed before it is sent.  The transaction is neither
+// signed nor recorded, its inputs are not locked, and no change address is
+// derived.  The wallet does not need to be unlocked.
+func (w *Wallet) PreviewTx(account uint32, outputs []*wire.TxOut,
+	minconf int32, satPerKb btcutil.Amount, opts *TxOptions) (*TxPreview, error) {
+
+	req := createTxRequest{
+		account:     account,
+		outputs:     outputs,
+		minconf:     minconf,
+		feeSatPerKB: satPerKb,
+		dryRun:      true,
+		opts:        opts,
+		resp:        make(chan createTxResponse),
+	}
+	w.createTxRequests <- req
+	resp := <-req.resp
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	return newTxPreview(resp.tx), nil
+}
+
+// newTxPreview returns the preview of an authored transaction.
+func newTxPreview(tx *txauthor.AuthoredTx) *TxPreview {
+	var numP2PKH, numP2WPKH, numNested, numP2TR int
+	for _, pkScript := range tx.PrevScripts {
+		switch {
+		case txscript.IsPayToScriptHash(pkScript):
+			numNested++
+		case txscript.IsPayToWitnessPubKeyHash(pkScript):
+			numP2WPKH++
+		case taproot.IsPayToTaproot(pkScript):
+			numP2TR++
+		default:
+			numP2PKH++
+		}
+	}
+
+	return &TxPreview{
+		Tx:              tx.Tx,
+		PrevScripts:     tx.PrevScripts,
+		PrevInputValues: tx.PrevInputValues,
+		ChangeIndex:     tx.ChangeIndex,
+		Fee:             tx.TotalInput - helpers.SumOutputValues(tx.Tx.TxOut),
+		VirtualSize: txsizes.EstimateVirtualSize(numP2PKH, numP2WPKH,
+			numNested, numP2TR, tx.Tx.TxOut, false),
+	}
+}
+
+// previewChangeSource returns the change source of a transaction preview,
+// which pays the change address chosen by opts, or previewChangeScript.
+func previewChangeSource(opts *TxOptions) txauthor.ChangeSource {
+	return func() ([]byte, error) {
+		if opts != nil && opts.ChangeAddress != nil {
+			if err := checkChangeAddress(opts.ChangeAddress); err != nil {
+				return nil, err
+			}
+			return taproot.PayToAddrScript(opts.ChangeAddress)
+		}
+		return previewChangeScript, nil
+	}
+}
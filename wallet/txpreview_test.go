@@ -0,0 +1,56 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+)
+
+// TestTxPreview checks the fee and virtual size of transaction previews, and
+// that their change output stands for the change address until it is derived.
+func TestTxPreview(t *testing.T) {
+	changeScript, err := previewChangeSource(nil)()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsPreviewChange(changeScript) {
+		t.Fatal("change of the preview not recognized")
+	}
+	if IsPreviewChange(make([]byte, 25)) {
+		t.Fatal("payment output recognized as change of the preview")
+	}
+
+	p2wpkh := append([]byte{0x00, 0x14}, make([]byte, 20)...)
+	tx := &txauthor.AuthoredTx{
+		Tx: &wire.MsgTx{
+			TxIn: []*wire.TxIn{{}, {}},
+			TxOut: []*wire.TxOut{
+				wire.NewTxOut(1e6, p2wpkh),
+				wire.NewTxOut(1e5, changeScript),
+			},
+		},
+		PrevScripts: [][]byte{p2wpkh, p2wpkh},
+		TotalInput:  1e6 + 1e5 + 300,
+		ChangeIndex: 1,
+	}
+	preview := newTxPreview(tx)
+	if preview.Fee != 300 {
+		t.Errorf("fee %v, want 300", preview.Fee)
+	}
+	oneInput := newTxPreview(&txauthor.AuthoredTx{
+		Tx:          tx.Tx,
+		PrevScripts: tx.PrevScripts[:1],
+	})
+	if preview.VirtualSize <= oneInput.VirtualSize {
+		t.Errorf("virtual size %d does not grow with the inputs",
+			preview.VirtualSize)
+	}
+	if preview.ChangeIndex != 1 {
+		t.Errorf("change index %d, want 1", preview.ChangeIndex)
+	}
+}
@@ -81,6 +81,13 @@ func CheckOutput(output *wire.TxOut, relayFeePerKb btcutil.Amount) error {
 	return nil
 }
 
+// FeePerKbForSatPerVByte converts a fee rate in satoshis per virtual byte to
+// the fee rate per kilobyte of virtual size used to author transactions, whose
+// sizes are estimated as virtual sizes with the witness discount.
+func FeePerKbForSatPerVByte(satPerVByte float64) btcutil.Amount {
+	return btcutil.Amount(satPerVByte*1000 + 0.5)
+}
+
 // FeeForSerializeSize calculates the required fee for a transaction of some
 // arbitrary size given a mempool's relay fee policy.
 func FeeForSerializeSize(relayFeePerKb btcutil.Amount, txSerializeSize int) btcutil.Amount {
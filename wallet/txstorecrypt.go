@@ -0,0 +1,490 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/btcsuite/btcwallet/snacl"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// txStoreKeyKey is the key, in the transaction metadata namespace, of the
+// record of the key encrypting the transaction store.  The transaction store
+// is encrypted when the record exists.
+var txStoreKeyKey = []byte("txstorekey")
+
+// errMalformedTxStoreKey describes a record of the transaction store key that
+// cannot be deserialized.
+var errMalformedTxStoreKey = errors.New("malformed transaction store key")
+
+// serializeTxStoreKey returns the record of the transaction store key, which
+// is encrypted with a master key derived from the public passphrase.
+func serializeTxStoreKey(masterKey *snacl.SecretKey, storeKey *snacl.CryptoKey) ([]byte, error) {
+	// The serialized transaction store key format is:
+	//   <paramslen><params><encstorekey>
+	//
+	// 4 bytes params len + marshalled master key params + encrypted store
+	// key
+	storeKeyEnc, err := masterKey.Encrypt(storeKey[:])
+	if err != nil {
+		return nil, err
+	}
+	params := masterKey.Marshal()
+	serialized := make([]byte, 4+len(params), 4+len(params)+len(storeKeyEnc))
+	binary.LittleEndian.PutUint32(serialized[0:4], uint32(len(params)))
+	copy(serialized[4:], params)
+	return append(serialized, storeKeyEnc...), nil
+}
+
+// deserializeTxStoreKey returns the master key parameters and the encrypted
+// store key of a record of the transaction store key.
+func deserializeTxStoreKey(serialized []byte) (*snacl.SecretKey, []byte, error) {
+	if len(serialized) < 4 {
+		return nil, nil, errMalformedTxStoreKey
+	}
+	paramsLen := binary.LittleEndian.Uint32(serialized[0:4])
+	if uint32(len(serialized)-4) < paramsLen {
+		return nil, nil, errMalformedTxStoreKey
+	}
+
+	var masterKey snacl.SecretKey
+	if err := masterKey.Unmarshal(serialized[4 : 4+paramsLen]); err != nil {
+		return nil, nil, err
+	}
+	return &masterKey, serialized[4+paramsLen:], nil
+}
+
+// decryptTxStoreKey decrypts the transaction store key of a record with the
+// public passphrase.
+func decryptTxStoreKey(serialized, pubPass []byte) (*snacl.CryptoKey, error) {
+	masterKey, storeKeyEnc, err := deserializeTxStoreKey(serialized)
+	if err != nil {
+		return nil, err
+	}
+	err = masterKey.DeriveKey(&pubPass)
+	if err == snacl.ErrInvalidPassword {
+		return nil, waddrmgr.ManagerError{
+			ErrorCode:   waddrmgr.ErrWrongPassphrase,
+			Description: "invalid passphrase for transaction store key",
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer masterKey.Zero()
+
+	decrypted, err := masterKey.Decrypt(storeKeyEnc)
+	if err != nil {
+		return nil, err
+	}
+	if len(decrypted) != snacl.KeySize {
+		return nil, errMalformedTxStoreKey
+	}
+	var storeKey snacl.CryptoKey
+	copy(storeKey[:], decrypted)
+	return &storeKey, nil
+}
+
+// putTxStoreKey encrypts the transaction store key with a new master key
+// derived from the public passphrase and stores it.
+func putTxStoreKey(tx walletdb.ReadWriteTx, storeKey *snacl.CryptoKey,
+	pubPass []byte, kdf *waddrmgr.ScryptOptions) error {
+
+	masterKey, err := waddrmgr.NewSecretKey(&pubPass, kdf)
+	if err != nil {
+		return err
+	}
+	defer masterKey.Zero()
+
+	serialized, err := serializeTxStoreKey(masterKey, storeKey)
+	if err != nil {
+		return err
+	}
+	ns := tx.ReadWriteBucket(wtxmetaNamespaceKey)
+	return ns.Put(txStoreKeyKey, serialized)
+}
+
+// changeTxStorePassphrase encrypts the transaction store key with the new
+// public passphrase, with the key derivation function and costs the key was
+// encrypted with.  Nothing is done for unencrypted transaction stores.
+func (w *Wallet) changeTxStorePassphrase(tx walletdb.ReadWriteTx, pubPass []byte) error {
+	cdb, ok := w.db.(*cryptDB)
+	if !ok {
+		return nil
+	}
+
+	ns := tx.ReadWriteBucket(wtxmetaNamespaceKey)
+	masterKey, _, err := deserializeTxStoreKey(ns.Get(txStoreKeyKey))
+	if err != nil {
+		return err
+	}
+	params := &masterKey.Parameters
+	kdf := &waddrmgr.ScryptOptions{
+		KDF:     params.KDF,
+		N:       params.N,
+		R:       params.R,
+		P:       params.P,
+		Memory:  params.Memory,
+		Time:    params.Time,
+		Threads: params.Threads,
+	}
+	return putTxStoreKey(tx, cdb.key, pubPass, kdf)
+}
+
+// openTxStoreCrypto returns the database of a wallet decrypting its
+// transaction store with the key encrypted with the public passphrase, or the
+// database itself when the transaction store is not encrypted.
+func openTxStoreCrypto(db walletdb.DB, pubPass []byte) (walletdb.DB, error) {
+	var storeKey *snacl.CryptoKey
+	err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(wtxmetaNamespaceKey)
+		if ns == nil {
+			return nil
+		}
+		serialized := ns.Get(txStoreKeyKey)
+		if serialized == nil {
+			return nil
+		}
+		var err error
+		storeKey, err = decryptTxStoreKey(serialized, pubPass)
+		return err
+	})
+	if err != nil || storeKey == nil {
+		return db, err
+	}
+	return &cryptDB{DB: db, key: storeKey}, nil
+}
+
+// encryptTxStore encrypts every value of the transaction store with a new
+// key, which is encrypted with a master key derived from the public
+// passphrase with the key derivation function and costs of kdf, or the
+// default scrypt costs when kdf is nil.  Transaction store keys, which are
+// transaction hashes, outpoints and block heights, are not encrypted.
+//
+// Nothing is done when the transaction store is already encrypted.  This must
+// be called before the wallet is started.
+func (w *Wallet) encryptTxStore(kdf *waddrmgr.ScryptOptions) error {
+	if _, ok := w.db.(*cryptDB); ok {
+		return nil
+	}
+	if kdf == nil {
+		kdf = &waddrmgr.DefaultScryptOptions
+	}
+
+	storeKey, err := snacl.GenerateCryptoKey()
+	if err != nil {
+		return err
+	}
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+		if err := encryptBucket(ns, storeKey); err != nil {
+			return err
+		}
+		return putTxStoreKey(tx, storeKey, w.publicPassphrase, kdf)
+	})
+	if err != nil {
+		return err
+	}
+	w.db = &cryptDB{DB: w.db, key: storeKey}
+
+	log.Infof("Encrypted the transaction store")
+	return nil
+}
+
+// encryptBucket encrypts the values of a bucket and of its nested buckets in
+// place.
+func encryptBucket(b walletdb.ReadWriteBucket, key *snacl.CryptoKey) error {
+	// The bucket may not be modified while iterating over it, so its
+	// contents are collected first.
+	var keys, values [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		keys = append(keys, append([]byte(nil), k...))
+		values = append(values, append([]byte(nil), v...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, k := range keys {
+		if nested := b.NestedReadWriteBucket(k); nested != nil {
+			if err := encryptBucket(nested, key); err != nil {
+				return err
+			}
+			continue
+		}
+		v, err := key.Encrypt(values[i])
+		if err != nil {
+			return err
+		}
+		if err := b.Put(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decryptValue decrypts a value of the transaction store.  Nil values, which
+// are nested buckets and missing keys, are returned unchanged, and empty
+// values are returned as empty, not nil, slices.
+func decryptValue(key *snacl.CryptoKey, v []byte) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	decrypted, err := key.Decrypt(v)
+	if err != nil {
+		return nil, err
+	}
+	if decrypted == nil {
+		decrypted = []byte{}
+	}
+	return decrypted, nil
+}
+
+// mustDecryptValue decrypts a value of the transaction store for the
+// accessors that cannot return errors.  Values that fail to decrypt are
+// logged and returned as nil.
+func mustDecryptValue(key *snacl.CryptoKey, k, v []byte) []byte {
+	decrypted, err := decryptValue(key, v)
+	if err != nil {
+		log.Errorf("Cannot decrypt transaction store value of key %x: %v",
+			k, err)
+		return nil
+	}
+	return decrypted
+}
+
+// cryptDB is a wallet database encrypting the values of the transaction
+// store namespace.  The other namespaces are accessed unchanged.
+type cryptDB struct {
+	walletdb.DB
+	key *snacl.CryptoKey
+}
+
+// BeginReadTx implements the walletdb.DB interface.
+func (db *cryptDB) BeginReadTx() (walletdb.ReadTx, error) {
+	tx, err := db.DB.BeginReadTx()
+	if err != nil {
+		return nil, err
+	}
+	return &cryptReadTx{ReadTx: tx, key: db.key}, nil
+}
+
+// BeginReadWriteTx implements the walletdb.DB interface.
+func (db *cryptDB) BeginReadWriteTx() (walletdb.ReadWriteTx, error) {
+	tx, err := db.DB.BeginReadWriteTx()
+	if err != nil {
+		return nil, err
+	}
+	return &cryptReadWriteTx{ReadWriteTx: tx, key: db.key}, nil
+}
+
+// cryptReadTx is a read transaction of a cryptDB.
+type cryptReadTx struct {
+	walletdb.ReadTx
+	key *snacl.CryptoKey
+}
+
+// ReadBucket implements the walletdb.ReadTx interface.
+func (tx *cryptReadTx) ReadBucket(key []byte) walletdb.ReadBucket {
+	b := tx.ReadTx.ReadBucket(key)
+	if b == nil || !bytes.Equal(key, wtxmgrNamespaceKey) {
+		return b
+	}
+	return &cryptReadBucket{b: b, key: tx.key}
+}
+
+// cryptReadWriteTx is a read/write transaction of a cryptDB.
+type cryptReadWriteTx struct {
+	walletdb.ReadWriteTx
+	key *snacl.CryptoKey
+}
+
+// ReadBucket implements the walletdb.ReadTx interface.
+func (tx *cryptReadWriteTx) ReadBucket(key []byte) walletdb.ReadBucket {
+	b := tx.ReadWriteTx.ReadBucket(key)
+	if b == nil || !bytes.Equal(key, wtxmgrNamespaceKey) {
+		return b
+	}
+	return &cryptReadBucket{b: b, key: tx.key}
+}
+
+// ReadWriteBucket implements the walletdb.ReadWriteTx interface.
+func (tx *cryptReadWriteTx) ReadWriteBucket(key []byte) walletdb.ReadWriteBucket {
+	b := tx.ReadWriteTx.ReadWriteBucket(key)
+	if b == nil || !bytes.Equal(key, wtxmgrNamespaceKey) {
+		return b
+	}
+	return newCryptReadWriteBucket(b, tx.key)
+}
+
+// CreateTopLevelBucket implements the walletdb.ReadWriteTx interface.
+func (tx *cryptReadWriteTx) CreateTopLevelBucket(key []byte) (walletdb.ReadWriteBucket, error) {
+	b, err := tx.ReadWriteTx.CreateTopLevelBucket(key)
+	if err != nil || !bytes.Equal(key, wtxmgrNamespaceKey) {
+		return b, err
+	}
+	return newCryptReadWriteBucket(b, tx.key), nil
+}
+
+// cryptReadBucket is a bucket of the transaction store decrypting its
+// values.
+type cryptReadBucket struct {
+	b   walletdb.ReadBucket
+	key *snacl.CryptoKey
+}
+
+// NestedReadBucket implements the walletdb.ReadBucket interface.
+func (b *cryptReadBucket) NestedReadBucket(key []byte) walletdb.ReadBucket {
+	nested := b.b.NestedReadBucket(key)
+	if nested == nil {
+		return nil
+	}
+	return &cryptReadBucket{b: nested, key: b.key}
+}
+
+// ForEach implements the walletdb.ReadBucket interface.
+func (b *cryptReadBucket) ForEach(f func(k, v []byte) error) error {
+	return b.b.ForEach(func(k, v []byte) error {
+		v, err := decryptValue(b.key, v)
+		if err != nil {
+			return err
+		}
+		return f(k, v)
+	})
+}
+
+// Get implements the walletdb.ReadBucket interface.
+func (b *cryptReadBucket) Get(key []byte) []byte {
+	return mustDecryptValue(b.key, key, b.b.Get(key))
+}
+
+// ReadCursor implements the walletdb.ReadBucket interface.
+func (b *cryptReadBucket) ReadCursor() walletdb.ReadCursor {
+	return &cryptReadCursor{c: b.b.ReadCursor(), key: b.key}
+}
+
+// cryptReadWriteBucket is a bucket of the transaction store encrypting and
+// decrypting its values.
+type cryptReadWriteBucket struct {
+	cryptReadBucket
+	rw walletdb.ReadWriteBucket
+}
+
+// newCryptReadWriteBucket returns a bucket encrypting the values of b.
+func newCryptReadWriteBucket(b walletdb.ReadWriteBucket, key *snacl.CryptoKey) *cryptReadWriteBucket {
+	return &cryptReadWriteBucket{
+		cryptReadBucket: cryptReadBucket{b: b, key: key},
+		rw:              b,
+	}
+}
+
+// NestedReadWriteBucket implements the walletdb.ReadWriteBucket interface.
+func (b *cryptReadWriteBucket) NestedReadWriteBucket(key []byte) walletdb.ReadWriteBucket {
+	nested := b.rw.NestedReadWriteBucket(key)
+	if nested == nil {
+		return nil
+	}
+	return newCryptReadWriteBucket(nested, b.key)
+}
+
+// CreateBucket implements the walletdb.ReadWriteBucket interface.
+func (b *cryptReadWriteBucket) CreateBucket(key []byte) (walletdb.ReadWriteBucket, error) {
+	nested, err := b.rw.CreateBucket(key)
+	if err != nil {
+		return nil, err
+	}
+	return newCryptReadWriteBucket(nested, b.key), nil
+}
+
+// CreateBucketIfNotExists implements the walletdb.ReadWriteBucket interface.
+func (b *cryptReadWriteBucket) CreateBucketIfNotExists(key []byte) (walletdb.ReadWriteBucket, error) {
+	nested, err := b.rw.CreateBucketIfNotExists(key)
+	if err != nil {
+		return nil, err
+	}
+	return newCryptReadWriteBucket(nested, b.key), nil
+}
+
+// DeleteNestedBucket implements the walletdb.ReadWriteBucket interface.
+func (b *cryptReadWriteBucket) DeleteNestedBucket(key []byte) error {
+	return b.rw.DeleteNestedBucket(key)
+}
+
+// Put implements the walletdb.ReadWriteBucket interface.
+func (b *cryptReadWriteBucket) Put(key, value []byte) error {
+	encrypted, err := b.key.Encrypt(value)
+	if err != nil {
+		return err
+	}
+	return b.rw.Put(key, encrypted)
+}
+
+// Delete implements the walletdb.ReadWriteBucket interface.
+func (b *cryptReadWriteBucket) Delete(key []byte) error {
+	return b.rw.Delete(key)
+}
+
+// ReadWriteCursor implements the walletdb.ReadWriteBucket interface.
+func (b *cryptReadWriteBucket) ReadWriteCursor() walletdb.ReadWriteCursor {
+	c := b.rw.ReadWriteCursor()
+	return &cryptReadWriteCursor{
+		cryptReadCursor: cryptReadCursor{c: c, key: b.key},
+		rw:              c,
+	}
+}
+
+// cryptReadCursor is a cursor over a bucket of the transaction store
+// decrypting its values.
+type cryptReadCursor struct {
+	c   walletdb.ReadCursor
+	key *snacl.CryptoKey
+}
+
+// First implements the walletdb.ReadCursor interface.
+func (c *cryptReadCursor) First() (key, value []byte) {
+	key, value = c.c.First()
+	return key, mustDecryptValue(c.key, key, value)
+}
+
+// Last implements the walletdb.ReadCursor interface.
+func (c *cryptReadCursor) Last() (key, value []byte) {
+	key, value = c.c.Last()
+	return key, mustDecryptValue(c.key, key, value)
+}
+
+// Next implements the walletdb.ReadCursor interface.
+func (c *cryptReadCursor) Next() (key, value []byte) {
+	key, value = c.c.Next()
+	return key, mustDecryptValue(c.key, key, value)
+}
+
+// Prev implements the walletdb.ReadCursor interface.
+func (c *cryptReadCursor) Prev() (key, value []byte) {
+	key, value = c.c.Prev()
+	return key, mustDecryptValue(c.key, key, value)
+}
+
+// Seek implements the walletdb.ReadCursor interface.
+func (c *cryptReadCursor) Seek(seek []byte) (key, value []byte) {
+	key, value = c.c.Seek(seek)
+	return key, mustDecryptValue(c.key, key, value)
+}
+
+// cryptReadWriteCursor is a cursor over a bucket of the transaction store
+// decrypting its values and deleting its pairs.
+type cryptReadWriteCursor struct {
+	cryptReadCursor
+	rw walletdb.ReadWriteCursor
+}
+
+// Delete implements the walletdb.ReadWriteCursor interface.
+func (c *cryptReadWriteCursor) Delete() error {
+	return c.rw.Delete()
+}
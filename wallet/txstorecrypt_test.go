@@ -0,0 +1,128 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestTxStoreCrypto checks that encrypting the transaction store encrypts its
+// values on disk, and that they are read back through the encrypted database
+// opened with the public passphrase only.
+func TestTxStoreCrypto(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txstorecrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	value := []byte("coinbase")
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket(wtxmgrNamespaceKey)
+		if err != nil {
+			return err
+		}
+		if err := ns.Put([]byte("k"), value); err != nil {
+			return err
+		}
+		if err := ns.Put([]byte("empty"), nil); err != nil {
+			return err
+		}
+		nested, err := ns.CreateBucket([]byte("b"))
+		if err != nil {
+			return err
+		}
+		if err := nested.Put([]byte("k"), value); err != nil {
+			return err
+		}
+		return createOptionalNamespaces(tx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pubPass := []byte("public")
+	w := &Wallet{db: db, publicPassphrase: pubPass}
+	kdf := &waddrmgr.ScryptOptions{N: 16, R: 8, P: 1}
+	if err := w.encryptTxStore(kdf); err != nil {
+		t.Fatalf("encryptTxStore: %v", err)
+	}
+
+	// The values are no longer stored in plain text.
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(wtxmgrNamespaceKey)
+		nested := ns.NestedReadBucket([]byte("b"))
+		if bytes.Equal(ns.Get([]byte("k")), value) ||
+			bytes.Equal(nested.Get([]byte("k")), value) {
+			t.Errorf("transaction store value is not encrypted")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := openTxStoreCrypto(db, []byte("wrong")); !waddrmgr.IsError(err, waddrmgr.ErrWrongPassphrase) {
+		t.Fatalf("opened with the wrong passphrase: %v", err)
+	}
+	cdb, err := openTxStoreCrypto(db, pubPass)
+	if err != nil {
+		t.Fatalf("openTxStoreCrypto: %v", err)
+	}
+	err = walletdb.Update(cdb, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+		if v := ns.Get([]byte("k")); !bytes.Equal(v, value) {
+			t.Errorf("got value %q, want %q", v, value)
+		}
+		if v := ns.Get([]byte("empty")); v == nil || len(v) != 0 {
+			t.Errorf("got value %q for an empty value", v)
+		}
+		nested := ns.NestedReadWriteBucket([]byte("b"))
+		if k, v := nested.ReadCursor().First(); !bytes.Equal(v, value) {
+			t.Errorf("cursor returned %q: %q, want %q", k, v, value)
+		}
+		return nested.Put([]byte("k2"), value)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The public passphrase change encrypts the same store key.
+	w.db = cdb
+	err = walletdb.Update(cdb, func(tx walletdb.ReadWriteTx) error {
+		return w.changeTxStorePassphrase(tx, []byte("new"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cdb, err = openTxStoreCrypto(db, []byte("new"))
+	if err != nil {
+		t.Fatalf("openTxStoreCrypto: %v", err)
+	}
+	err = walletdb.View(cdb, func(tx walletdb.ReadTx) error {
+		nested := tx.ReadBucket(wtxmgrNamespaceKey).NestedReadBucket([]byte("b"))
+		if v := nested.Get([]byte("k2")); !bytes.Equal(v, value) {
+			t.Errorf("got value %q, want %q", v, value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+const (
+	// unlockBackoffBase is the delay before another passphrase may be tried
+	// after the first wrong passphrase.  The delay doubles with every
+	// consecutive wrong passphrase, up to unlockBackoffMax.
+	unlockBackoffBase = time.Second
+	unlockBackoffMax  = 5 * time.Minute
+)
+
+// UnlockThrottledError describes a passphrase that was not tried because of
+// previous wrong passphrases.  Locked out wallets may not be unlocked until
+// RetryAfter, or until the wallet is restarted when RetryAfter is zero.
+type UnlockThrottledError struct {
+	RetryAfter time.Duration
+	LockedOut  bool
+}
+
+// Error implements the error interface.
+func (e *UnlockThrottledError) Error() string {
+	switch {
+	case e.LockedOut && e.RetryAfter == 0:
+		return "too many wrong passphrases: unlocking is disabled " +
+			"until the wallet is restarted"
+	case e.LockedOut:
+		return fmt.Sprintf("too many wrong passphrases: unlocking is "+
+			"disabled for %v", e.RetryAfter)
+	default:
+		return fmt.Sprintf("wrong passphrase: retry in %v", e.RetryAfter)
+	}
+}
+
+// unlockThrottle delays the passphrase attempts following wrong passphrases,
+// and locks out unlocking after too many consecutive wrong passphrases.  It
+// is shared by every operation checking a passphrase of the wallet or of its
+// accounts, or a one-time password, so that they cannot be used to try
+// passphrases faster.
+type unlockThrottle struct {
+	mu          sync.Mutex
+	failures    uint32
+	retryAt     time.Time
+	lockedOut   bool
+	maxFailures uint32
+	lockout     time.Duration
+}
+
+// SetUnlockLockout locks out unlocking the wallet after maxFailures
+// consecutive wrong passphrases, for the duration of lockout, or until the
+// wallet is restarted when lockout is zero.  A zero maxFailures disables the
+// lockout, but passphrase attempts are still delayed after wrong passphrases.
+func (w *Wallet) SetUnlockLockout(maxFailures uint32, lockout time.Duration) {
+	w.unlockThrottle.mu.Lock()
+	w.unlockThrottle.maxFailures = maxFailures
+	w.unlockThrottle.lockout = lockout
+	w.unlockThrottle.mu.Unlock()
+}
+
+// throttleUnlock returns an UnlockThrottledError when a passphrase may not be
+// tried yet.
+func (w *Wallet) throttleUnlock() error {
+	t := &w.unlockThrottle
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.lockedOut {
+		if t.retryAt.IsZero() {
+			return &UnlockThrottledError{LockedOut: true}
+		}
+		if now.Before(t.retryAt) {
+			return &UnlockThrottledError{
+				RetryAfter: t.retryAt.Sub(now),
+				LockedOut:  true,
+			}
+		}
+		// The lockout expired.
+		t.lockedOut = false
+		t.failures = 0
+	}
+	if now.Before(t.retryAt) {
+		return &UnlockThrottledError{RetryAfter: t.retryAt.Sub(now)}
+	}
+	return nil
+}
+
+// recordUnlock records the result of checking a passphrase.  Wrong
+// passphrases delay the next attempt, or lock out unlocking, and are notified
+// to UnlockFailureNotifications clients.
+func (w *Wallet) recordUnlock(err error) {
+	t := &w.unlockThrottle
+	t.mu.Lock()
+
+	if !waddrmgr.IsError(err, waddrmgr.ErrWrongPassphrase) {
+		if err == nil {
+			t.failures = 0
+			t.retryAt = time.Time{}
+		}
+		t.mu.Unlock()
+		return
+	}
+
+	t.failures++
+	n := &UnlockFailureNotification{Failures: t.failures}
+	if t.maxFailures != 0 && t.failures >= t.maxFailures {
+		t.lockedOut = true
+		t.retryAt = time.Time{}
+		if t.lockout != 0 {
+			t.retryAt = time.Now().Add(t.lockout)
+		}
+		n.LockedOut = true
+		n.RetryAfter = t.lockout
+		log.Warnf("Unlocking is disabled after %d wrong passphrases",
+			t.failures)
+	} else {
+		delay := unlockBackoffMax
+		if t.failures <= 16 {
+			delay = unlockBackoffBase << (t.failures - 1)
+		}
+		if delay > unlockBackoffMax {
+			delay = unlockBackoffMax
+		}
+		t.retryAt = time.Now().Add(delay)
+		n.RetryAfter = delay
+		log.Warnf("Wrong passphrase (%d consecutive failures): "+
+			"next attempt allowed in %v", t.failures, delay)
+	}
+	t.mu.Unlock()
+
+	w.audit(AuditWrongPassphrase, "%d consecutive wrong passphrases",
+		n.Failures)
+	w.NtfnServer.notifyUnlockFailure(n)
+}
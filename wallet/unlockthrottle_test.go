@@ -0,0 +1,104 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestUnlockThrottle checks that wrong passphrases delay the next attempt,
+// that a correct passphrase resets the delay, and that unlocking is locked
+// out after the configured number of wrong passphrases.
+func TestUnlockThrottle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unlockthrottle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		_, err := dbtx.CreateTopLevelBucket(wtxmetaNamespaceKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &Wallet{db: db}
+	w.NtfnServer = newNotificationServer(w)
+	wrong := waddrmgr.ManagerError{ErrorCode: waddrmgr.ErrWrongPassphrase}
+
+	if err := w.throttleUnlock(); err != nil {
+		t.Fatalf("first attempt throttled: %v", err)
+	}
+	w.recordUnlock(wrong)
+	terr, ok := w.throttleUnlock().(*UnlockThrottledError)
+	if !ok || terr.LockedOut || terr.RetryAfter <= 0 ||
+		terr.RetryAfter > unlockBackoffBase {
+		t.Fatalf("attempt after a wrong passphrase returned %v", terr)
+	}
+
+	// Other errors do not count as wrong passphrases.
+	w.unlockThrottle.retryAt = time.Time{}
+	w.recordUnlock(waddrmgr.ManagerError{ErrorCode: waddrmgr.ErrLocked})
+	if w.unlockThrottle.failures != 1 {
+		t.Fatalf("failures = %d, want 1", w.unlockThrottle.failures)
+	}
+	w.recordUnlock(wrong)
+	terr, ok = w.throttleUnlock().(*UnlockThrottledError)
+	if !ok || terr.RetryAfter <= unlockBackoffBase {
+		t.Fatalf("delay after two wrong passphrases is %v", terr)
+	}
+
+	w.recordUnlock(nil)
+	if err := w.throttleUnlock(); err != nil {
+		t.Fatalf("attempt after a correct passphrase throttled: %v", err)
+	}
+
+	// Receive the failures notified after the lockout is configured.
+	client := w.NtfnServer.UnlockFailureNotifications()
+	defer client.Done()
+	ntfns := make(chan *UnlockFailureNotification, 3)
+	go func() {
+		for n := range client.C {
+			ntfns <- n
+		}
+	}()
+
+	w.SetUnlockLockout(3, 0)
+	for i := 0; i < 3; i++ {
+		w.unlockThrottle.retryAt = time.Time{}
+		w.recordUnlock(wrong)
+	}
+	terr, ok = w.throttleUnlock().(*UnlockThrottledError)
+	if !ok || !terr.LockedOut || terr.RetryAfter != 0 {
+		t.Fatalf("attempt after the lockout returned %v", terr)
+	}
+	for i := uint32(1); i <= 3; i++ {
+		n := <-ntfns
+		if n.Failures != i || n.LockedOut != (i == 3) {
+			t.Fatalf("notification %d is %+v", i, n)
+		}
+	}
+
+	// Expired lockouts allow passphrases to be tried again.
+	w.unlockThrottle.retryAt = time.Now().Add(-time.Second)
+	if err := w.throttleUnlock(); err != nil {
+		t.Fatalf("attempt after an expired lockout throttled: %v", err)
+	}
+}
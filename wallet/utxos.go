@@ -6,8 +6,8 @@
 package wallet
 
 import (
-	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/internal/taproot"
 	"github.com/btcsuite/btcwallet/walletdb"
 )
 
@@ -47,7 +47,7 @@ func (w *Wallet) UnspentOutputs(policy OutputSelectionPolicy) ([]*TransactionOut
 			}
 
 			// Ignore outputs that are not controlled by the account.
-			_, addrs, _, err := txscript.ExtractPkScriptAddrs(output.PkScript,
+			_, addrs, _, err := taproot.ExtractPkScriptAddrs(output.PkScript,
 				w.chainParams)
 			if err != nil || len(addrs) == 0 {
 				// Cannot determine which account this belongs
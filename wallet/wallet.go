@@ -10,6 +10,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
@@ -23,6 +24,8 @@ import (
 	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/internal/zero"
 	"github.com/davecgh/go-spew/spew"
 
 	"github.com/btcsuite/btcutil"
@@ -50,7 +53,8 @@ const (
 
 	// recoveryBatchSize is the default number of blocks that will be
 	// scanned successively by the recovery manager, in the event that the
-	// wallet is started in recovery mode.
+	// wallet is started in recovery mode, until the batch size is adapted
+	// to the latency of the chain backend.
 	recoveryBatchSize = 2000
 )
 
@@ -63,6 +67,18 @@ var ErrNotSynced = errors.New("wallet is not synchronized with the chain server"
 var (
 	waddrmgrNamespaceKey = []byte("waddrmgr")
 	wtxmgrNamespaceKey   = []byte("wtxmgr")
+	wtxmetaNamespaceKey  = []byte("wtxmeta")
+	wscriptNamespaceKey  = []byte("wscript")
+	wwatchNamespaceKey   = []byte("wwatch")
+
+	// optionalNamespaceKeys are the namespaces of wallet features that
+	// were added after wallets were first created.  They are created when
+	// opening a wallet that does not have them yet.
+	optionalNamespaceKeys = [][]byte{
+		wtxmetaNamespaceKey,
+		wscriptNamespaceKey,
+		wwatchNamespaceKey,
+	}
 )
 
 // Wallet is a structure containing all the components for a
@@ -78,13 +94,54 @@ type Wallet struct {
 
 	chainClient        chain.Interface
 	chainClientLock    sync.Mutex
+	offline            bool
 	chainClientSynced  bool
 	chainClientSyncMtx sync.Mutex
 
-	lockedOutpoints map[wire.OutPoint]struct{}
+	lockedOutpoints    map[wire.OutPoint]struct{}
+	lockedOutpointsMtx sync.Mutex
+
+	txHooks   txHookSet
+	screening addressScreening
+	signers   accountSigners
+	acctLocks accountLocks
+	opSeq     operationSequence
+	quotas    accountQuotas
+
+	consolidation     changeConsolidation
+	coinSelection     coinSelection
+	replacePolicy     replacePolicy
+	lockTimePolicy    lockTimePolicy
+	freezes           accountFreezes
+	dustPolicy        dustPolicy
+	feeCap            feeCapPolicy
+	broadcastHold     broadcastHold
+	feePolicy         feePolicy
+	metadataAnchoring metadataAnchoring
+	emailDigest       emailDigest
+	paymentBatching   paymentBatching
+
+	unlockThrottle unlockThrottle
+	totp           totpState
+	keyUsage       keyUsage
+	retention      retention
+	changePolicy   changePolicy
+	backups        backups
+	wizardRecovery wizardRecovery
+	dustQuarantine dustQuarantine
+	maintenance    maintenanceState
+	accelerators   txAccelerators
+	rescanBatching rescanBatching
+	heightTriggers heightTriggers
+	apiTokens      apiTokens
+	refill         refillPolicy
 
 	recoveryWindow uint32
 
+	// bootstrapMtx serializes the reconciliation of bootstrap outpoints
+	// by concurrent syncs.
+	bootstrapMtx sync.Mutex
+
 	// Channels for rescan processing.  Requests are added and merged with
 	// any waiting requests, before being sent to another goroutine to
 	// call the rescan RPC.
@@ -102,6 +159,7 @@ type Wallet struct {
 	lockRequests       chan struct{}
 	holdUnlockRequests chan chan heldUnlock
 	lockState          chan bool
+	unlockExpirations  chan unlockExpiration
 	changePassphrase   chan changePassphraseRequest
 	changePassphrases  chan changePassphrasesRequest
 
@@ -138,9 +196,17 @@ func (w *Wallet) Start() {
 	}
 	w.quitMu.Unlock()
 
-	w.wg.Add(2)
+	w.wg.Add(10)
 	go w.txCreator()
 	go w.walletLocker()
+	go w.quotaMonitor()
+	go w.consolidationMonitor()
+	go w.metadataAnchorMonitor()
+	go w.emailDigestMonitor()
+	go w.paymentBatchMonitor()
+	go w.retentionMonitor()
+	go w.heightTriggerMonitor()
+	go w.refillMonitor()
 }
 
 // SynchronizeRPC associates the wallet with the consensus RPC client,
@@ -313,6 +379,27 @@ func (w *Wallet) activeData(dbtx walletdb.ReadTx) ([]btcutil.Address, []wtxmgr.C
 	if err != nil {
 		return nil, nil, err
 	}
+
+	// Imported P2SH redeem scripts are also known to the address manager,
+	// so only add the script store addresses it does not already watch.
+	scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
+	err = forEachScriptAddress(scriptNs, w.chainParams, func(addr btcutil.Address) error {
+		if _, err := w.Manager.Address(addrmgrNs, addr); err != nil {
+			addrs = append(addrs, addr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	watchNs := dbtx.ReadBucket(wwatchNamespaceKey)
+	err = forEachWatchedAddress(watchNs, w.chainParams, func(addr btcutil.Address) error {
+		addrs = append(addrs, addr)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 	unspent, err := w.TxStore.UnspentOutputs(txmgrNs, nil)
 	return addrs, unspent, err
 }
@@ -525,9 +612,11 @@ func (w *Wallet) syncWithChain() error {
 
 			// If we are in recovery mode, attempt a recovery on
 			// blocks that have been added to the recovery manager's
-			// block batch thus far. If block batch is empty, this
-			// will be a NOP.
-			if isRecovery && height%recoveryBatchSize == 0 {
+			// block batch once it reaches the batch size adapted
+			// to the latency of the chain backend.
+			if isRecovery && len(recoveryMgr.BlockBatch()) >=
+				w.rescanBatching.Size() {
+
 				err := w.recoverDefaultScopes(
 					chainClient, tx, ns,
 					recoveryMgr.BlockBatch(),
@@ -562,7 +651,7 @@ func (w *Wallet) syncWithChain() error {
 		}
 
 		// Perform one last recovery attempt for all blocks that were
-		// not batched at the default granularity of 2000 blocks.
+		// not batched yet.
 		if isRecovery {
 			err := w.recoverDefaultScopes(
 				chainClient, tx, ns, recoveryMgr.BlockBatch(),
@@ -677,7 +766,18 @@ func (w *Wallet) defaultScopeManagers() (
 
 	scopedMgrs := make(map[waddrmgr.KeyScope]*waddrmgr.ScopedKeyManager)
 	for _, scope := range waddrmgr.DefaultKeyScopes {
+		// Recoveries started with the recovery wizard only scan the
+		// chosen scopes.
+		if !w.recoveryScanned(scope) {
+			continue
+		}
+
 		scopedMgr, err := w.Manager.FetchScopedKeyManager(scope)
+		// Wallets created before a default scope was introduced
+		// (such as BIP0086) won't have it until it is first used.
+		if waddrmgr.IsError(err, waddrmgr.ErrScopeNotFound) {
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -751,10 +851,17 @@ expandHorizons:
 
 	// Initiate the filter blocks request using our chain backend. If an
 	// error occurs, we are unable to proceed with the recovery.
+	start := time.Now()
 	filterResp, err := chainClient.FilterBlocks(filterReq)
 	if err != nil {
 		return err
 	}
+	if filterResp == nil {
+		w.rescanBatching.observe(len(batch), 0, time.Since(start))
+	} else {
+		w.rescanBatching.observe(int(filterResp.BatchIndex)+1,
+			len(filterResp.RelevantTxns), time.Since(start))
+	}
 
 	// If the filter response is empty, this signals that the rest of the
 	// batch was completed, and no other addresses were discovered. As a
@@ -988,7 +1095,9 @@ func extendFoundAddresses(ns walletdb.ReadWriteBucket,
 		// belong to this scope.
 		for index := range indexes {
 			addr := scopeState.ExternalBranch.GetAddr(index)
-			err := scopedMgr.MarkUsed(ns, addr)
+			err := scopedMgr.MarkUsedAt(
+				ns, addr, filterResp.BlockMeta.Time,
+			)
 			if err != nil {
 				return err
 			}
@@ -1030,7 +1139,9 @@ func extendFoundAddresses(ns walletdb.ReadWriteBucket,
 		// to this scope.
 		for index := range indexes {
 			addr := scopeState.InternalBranch.GetAddr(index)
-			err := scopedMgr.MarkUsed(ns, addr)
+			err := scopedMgr.MarkUsedAt(
+				ns, addr, filterResp.BlockMeta.Time,
+			)
 			if err != nil {
 				return err
 			}
@@ -1080,6 +1191,11 @@ type (
 		outputs     []*wire.TxOut
 		minconf     int32
 		feeSatPerKB btcutil.Amount
+		unsigned    bool
+		dryRun      bool
+		sweep       *sweepRequest
+		fund        *fundRequest
+		opts        *TxOptions
 		resp        chan createTxResponse
 	}
 	createTxResponse struct {
@@ -1104,13 +1220,49 @@ out:
 	for {
 		select {
 		case txr := <-w.createTxRequests:
+			if txr.fund != nil {
+				tx, err := w.txToFund(txr.account, txr.fund,
+					txr.minconf, txr.feeSatPerKB, txr.opts)
+				if err == nil && txr.fund.lockUnspents {
+					added := tx.Tx.TxIn[len(txr.fund.tx.TxIn):]
+					for _, txIn := range added {
+						w.LockOutpoint(txIn.PreviousOutPoint)
+					}
+				}
+				txr.resp <- createTxResponse{tx, err}
+				continue
+			}
+			if txr.dryRun {
+				tx, err := w.createTx(&txr, false)
+				txr.resp <- createTxResponse{tx, err}
+				continue
+			}
+			if txr.unsigned {
+				tx, err := w.createTx(&txr, false)
+				if err == nil {
+					// The inputs are spent once the transaction
+					// is signed elsewhere, so keep later
+					// transactions from choosing them.
+					for _, txIn := range tx.Tx.TxIn {
+						w.LockOutpoint(txIn.PreviousOutPoint)
+					}
+				}
+				txr.resp <- createTxResponse{tx, err}
+				continue
+			}
+			if w.AccountSigner(txr.account) != nil {
+				// The account's private keys are not in the
+				// wallet, so it does not need to be unlocked.
+				tx, err := w.createTx(&txr, true)
+				txr.resp <- createTxResponse{tx, err}
+				continue
+			}
 			heldUnlock, err := w.holdUnlock()
 			if err != nil {
 				txr.resp <- createTxResponse{nil, err}
 				continue
 			}
-			tx, err := w.txToOutputs(txr.outputs, txr.account,
-				txr.minconf, txr.feeSatPerKB)
+			tx, err := w.createTx(&txr, true)
 			heldUnlock.release()
 			txr.resp <- createTxResponse{tx, err}
 		case <-quit:
@@ -1120,20 +1272,57 @@ out:
 	w.wg.Done()
 }
 
+// createTx creates the transaction of a request, either paying to its outputs
+// or sweeping an account.
+func (w *Wallet) createTx(txr *createTxRequest, sign bool) (*txauthor.AuthoredTx, error) {
+	if txr.sweep != nil {
+		return w.txToSweep(txr.account, txr.sweep, txr.minconf,
+			txr.feeSatPerKB, sign)
+	}
+	return w.txToOutputs(txr.outputs, txr.account, txr.minconf,
+		txr.feeSatPerKB, txr.opts, sign, txr.dryRun)
+}
+
 // CreateSimpleTx creates a new signed transaction spending unspent P2PKH
 // outputs with at laest minconf confirmations spending to any number of
 // address/amount pairs.  Change and an appropriate transaction fee are
 // automatically included, if necessary.  All transaction creation through this
 // function is serialized to prevent the creation of many transactions which
-// spend the same outputs.
+// spend the same outputs.  Options left unset in opts, which may be nil,
+// follow the wallet's policies.
 func (w *Wallet) CreateSimpleTx(account uint32, outputs []*wire.TxOut,
-	minconf int32, satPerKb btcutil.Amount) (*txauthor.AuthoredTx, error) {
+	minconf int32, satPerKb btcutil.Amount,
+	opts *TxOptions) (*txauthor.AuthoredTx, error) {
+
+	req := createTxRequest{
+		account:     account,
+		outputs:     outputs,
+		minconf:     minconf,
+		feeSatPerKB: satPerKb,
+		opts:        opts,
+		resp:        make(chan createTxResponse),
+	}
+	w.createTxRequests <- req
+	resp := <-req.resp
+	return resp.tx, resp.err
+}
+
+// CreateUnsignedTx creates a new unsigned transaction in the same way as
+// CreateSimpleTx, so that it can be signed by external signers.  The wallet
+// does not need to be unlocked.  The outputs spent by the transaction are
+// locked to keep them from being spent by other transactions created by the
+// wallet before this one is signed and published.
+func (w *Wallet) CreateUnsignedTx(account uint32, outputs []*wire.TxOut,
+	minconf int32, satPerKb btcutil.Amount,
+	opts *TxOptions) (*txauthor.AuthoredTx, error) {
 
 	req := createTxRequest{
 		account:     account,
 		outputs:     outputs,
 		minconf:     minconf,
 		feeSatPerKB: satPerKb,
+		unsigned:    true,
+		opts:        opts,
 		resp:        make(chan createTxResponse),
 	}
 	w.createTxRequests <- req
@@ -1145,9 +1334,17 @@ type (
 	unlockRequest struct {
 		passphrase []byte
 		lockAfter  <-chan time.Time // nil prevents the timeout.
+		lockAt     time.Time        // zero when unknown or no timeout.
 		err        chan error
 	}
 
+	// unlockExpiration describes the lock state of the wallet and, when
+	// known, the time an unlocked wallet is relocked.
+	unlockExpiration struct {
+		locked bool
+		lockAt time.Time
+	}
+
 	changePassphraseRequest struct {
 		old, new []byte
 		private  bool
@@ -1171,57 +1368,110 @@ type (
 // walletLocker manages the locked/unlocked state of a wallet.
 func (w *Wallet) walletLocker() {
 	var timeout <-chan time.Time
+	var lockAt time.Time
 	holdChan := make(heldUnlock)
 	quit := w.quitChan()
 out:
 	for {
 		select {
 		case req := <-w.unlockRequests:
+			if err := w.throttleUnlock(); err != nil {
+				req.err <- err
+				continue
+			}
+			wasLocked := w.Manager.IsLocked()
 			err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 				addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
 				return w.Manager.Unlock(addrmgrNs, req.passphrase)
 			})
+			w.recordUnlock(err)
 			if err != nil {
 				req.err <- err
 				continue
 			}
 			timeout = req.lockAfter
+			lockAt = req.lockAt
 			if timeout == nil {
 				log.Info("The wallet has been unlocked without a time limit")
+				w.audit(AuditUnlock, "wallet unlocked without a time limit")
 			} else {
 				log.Info("The wallet has been temporarily unlocked")
+				w.audit(AuditUnlock, "wallet temporarily unlocked")
 			}
 			req.err <- nil
+			if wasLocked {
+				w.NtfnServer.notifyLockState(false, false)
+			}
 			continue
 
 		case req := <-w.changePassphrase:
+			if err := w.throttleUnlock(); err != nil {
+				req.err <- err
+				continue
+			}
 			err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
 				addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
-				return w.Manager.ChangePassphrase(
+				err := w.Manager.ChangePassphrase(
 					addrmgrNs, req.old, req.new, req.private,
-					&waddrmgr.DefaultScryptOptions,
+					nil,
 				)
+				if err != nil || req.private {
+					return err
+				}
+				return w.changeTxStorePassphrase(tx, req.new)
 			})
+			w.recordUnlock(err)
+			if err == nil {
+				kind := "public"
+				if req.private {
+					kind = "private"
+				}
+				w.audit(AuditPassphraseChange, "%s passphrase changed",
+					kind)
+			}
 			req.err <- err
+			if err == nil && req.private {
+				w.NtfnServer.notifyLockState(
+					w.Manager.IsLocked(), true,
+				)
+			}
 			continue
 
 		case req := <-w.changePassphrases:
+			if err := w.throttleUnlock(); err != nil {
+				req.err <- err
+				continue
+			}
 			err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
 				addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
 				err := w.Manager.ChangePassphrase(
 					addrmgrNs, req.publicOld, req.publicNew,
-					false, &waddrmgr.DefaultScryptOptions,
+					false, nil,
 				)
 				if err != nil {
 					return err
 				}
+				err = w.changeTxStorePassphrase(tx, req.publicNew)
+				if err != nil {
+					return err
+				}
 
 				return w.Manager.ChangePassphrase(
 					addrmgrNs, req.privateOld, req.privateNew,
-					true, &waddrmgr.DefaultScryptOptions,
+					true, nil,
 				)
 			})
+			w.recordUnlock(err)
+			if err == nil {
+				w.audit(AuditPassphraseChange, "public and private "+
+					"passphrases changed")
+			}
 			req.err <- err
+			if err == nil {
+				w.NtfnServer.notifyLockState(
+					w.Manager.IsLocked(), true,
+				)
+			}
 			continue
 
 		case req := <-w.holdUnlockRequests:
@@ -1248,21 +1498,33 @@ out:
 		case w.lockState <- w.Manager.IsLocked():
 			continue
 
+		case w.unlockExpirations <- unlockExpiration{w.Manager.IsLocked(), lockAt}:
+			continue
+
 		case <-quit:
 			break out
 
 		case <-w.lockRequests:
+			// Explicit locks also lock the accounts unlocked with
+			// their own passphrases.
+			w.lockAccounts()
+
 		case <-timeout:
 		}
 
 		// Select statement fell through by an explicit lock or the
 		// timer expiring.  Lock the manager here.
 		timeout = nil
+		lockAt = time.Time{}
 		err := w.Manager.Lock()
-		if err != nil && !waddrmgr.IsError(err, waddrmgr.ErrLocked) {
-			log.Errorf("Could not lock wallet: %v", err)
-		} else {
+		switch {
+		case err == nil:
+			log.Info("The wallet has been locked")
+			w.NtfnServer.notifyLockState(true, false)
+		case waddrmgr.IsError(err, waddrmgr.ErrLocked):
 			log.Info("The wallet has been locked")
+		default:
+			log.Errorf("Could not lock wallet: %v", err)
 		}
 	}
 	w.wg.Done()
@@ -1283,7 +1545,38 @@ func (w *Wallet) Unlock(passphrase []byte, lock <-chan time.Time) error {
 	return <-err
 }
 
-// Lock locks the wallet's address manager.
+// UnlockFor unlocks the wallet's address manager and relocks it after the
+// timeout has expired, or never when the timeout is zero.  Unlike Unlock, the
+// time the wallet is relocked is tracked and reported by UnlockExpiration.
+// Relocking notifies lock state clients of the notification server.
+func (w *Wallet) UnlockFor(passphrase []byte, timeout time.Duration) error {
+	var lockAfter <-chan time.Time
+	var lockAt time.Time
+	if timeout != 0 {
+		lockAfter = time.After(timeout)
+		lockAt = time.Now().Add(timeout)
+	}
+	err := make(chan error, 1)
+	w.unlockRequests <- unlockRequest{
+		passphrase: passphrase,
+		lockAfter:  lockAfter,
+		lockAt:     lockAt,
+		err:        err,
+	}
+	return <-err
+}
+
+// UnlockExpiration returns whether the wallet is locked and, when it is
+// unlocked by UnlockFor with a timeout, the time it will be relocked.  The
+// time is zero for wallets unlocked without a timeout, or with a timeout
+// unknown to the wallet by Unlock.
+func (w *Wallet) UnlockExpiration() (locked bool, lockAt time.Time) {
+	e := <-w.unlockExpirations
+	return e.locked, e.lockAt
+}
+
+// Lock locks the wallet's address manager, and every account unlocked with its
+// own passphrase.
 func (w *Wallet) Lock() {
 	w.lockRequests <- struct{}{}
 }
@@ -1396,6 +1689,21 @@ func (w *Wallet) AccountAddresses(account uint32) (addrs []btcutil.Address, err
 	return
 }
 
+// AccountPaymentAddresses returns the addresses of an account which receive
+// payments, excluding the change addresses derived on its internal branch.
+func (w *Wallet) AccountPaymentAddresses(account uint32) (addrs []btcutil.Address, err error) {
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		return w.Manager.ForEachAccountAddress(addrmgrNs, account, func(maddr waddrmgr.ManagedAddress) error {
+			if !maddr.Internal() {
+				addrs = append(addrs, maddr.Address())
+			}
+			return nil
+		})
+	})
+	return
+}
+
 // CalculateBalance sums the amounts of all unspent transaction
 // outputs to addresses of a wallet and returns the balance.
 //
@@ -1403,7 +1711,8 @@ func (w *Wallet) AccountAddresses(account uint32) (addrs []btcutil.Address, err
 // block (height -1), will be used to get the balance.  Otherwise,
 // a UTXO must be in a block.  If confirmations is 1 or greater,
 // the balance will be calculated based on how many how many blocks
-// include a UTXO.
+// include a UTXO.  Locked outputs are not included, as they are not
+// spendable.
 func (w *Wallet) CalculateBalance(confirms int32) (btcutil.Amount, error) {
 	var balance btcutil.Amount
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
@@ -1411,17 +1720,27 @@ func (w *Wallet) CalculateBalance(confirms int32) (btcutil.Amount, error) {
 		var err error
 		blk := w.Manager.SyncedTo()
 		balance, err = w.TxStore.Balance(txmgrNs, confirms, blk.Height)
+		if err != nil {
+			return err
+		}
+		locked, err := w.lockedBalance(tx, confirms, blk.Height)
+		balance -= locked
 		return err
 	})
 	return balance, err
 }
 
 // Balances records total, spendable (by policy), and immature coinbase
-// reward balance amounts.
+// reward balance amounts.  The total includes the watch-only balance of
+// outputs paying to addresses imported without keys, which is never
+// spendable, and the balance of locked outputs, which would otherwise be
+// spendable.
 type Balances struct {
 	Total          btcutil.Amount
 	Spendable      btcutil.Amount
 	ImmatureReward btcutil.Amount
+	WatchOnly      btcutil.Amount
+	Locked         btcutil.Amount
 }
 
 // CalculateAccountBalances sums the amounts of all unspent transaction
@@ -1433,7 +1752,6 @@ type Balances struct {
 func (w *Wallet) CalculateAccountBalances(account uint32, confirms int32, token wire.TokenIdentity) (Balances, error) {
 	var bals Balances
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
-		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
 		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
 
 		// Get current block.  The block height used for calculating
@@ -1448,20 +1766,28 @@ func (w *Wallet) CalculateAccountBalances(account uint32, confirms int32, token
 			output := &unspent[i]
 
 			var outputAcct uint32
-			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			_, addrs, _, err := taproot.ExtractPkScriptAddrs(
 				output.PkScript, w.chainParams)
 			if err == nil && len(addrs) > 0 {
-				_, outputAcct, err = w.Manager.AddrAccount(addrmgrNs, addrs[0])
+				outputAcct, err = w.addrAccount(tx, addrs[0])
 			}
 			if err != nil || outputAcct != account {
 				continue
 			}
 
 			bals.Total += output.Amount
-			if output.FromCoinBase && !confirmed(int32(w.chainParams.CoinbaseMaturity),
-				output.Height, syncBlock.Height) {
+			if isWatchedScript(tx, output.PkScript) {
+				bals.WatchOnly += output.Amount
+				continue
+			}
+			switch {
+			case output.FromCoinBase && !confirmed(int32(w.chainParams.CoinbaseMaturity),
+				output.Height, syncBlock.Height):
 				bals.ImmatureReward += output.Amount
-			} else if confirmed(confirms, output.Height, syncBlock.Height) {
+			case !confirmed(confirms, output.Height, syncBlock.Height):
+			case w.LockedOutpoint(output.OutPoint):
+				bals.Locked += output.Amount
+			default:
 				bals.Spendable += output.Amount
 			}
 		}
@@ -1470,12 +1796,47 @@ func (w *Wallet) CalculateAccountBalances(account uint32, confirms int32, token
 	return bals, err
 }
 
+// CalculateAccountsBalances calculates the balances of several accounts
+// concurrently, as CalculateAccountBalances does for a single account, and
+// returns them in the order of the accounts.
+func (w *Wallet) CalculateAccountsBalances(accounts []uint32, confirms int32,
+	token wire.TokenIdentity) ([]Balances, error) {
+
+	bals := make([]Balances, len(accounts))
+	errs := make([]error, len(accounts))
+
+	// Every calculation iterates all unspent outputs, so limit the number
+	// running at once to the number of processors.
+	sem := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	for i, account := range accounts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, account uint32) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			bals[i], errs[i] = w.CalculateAccountBalances(account,
+				confirms, token)
+		}(i, account)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bals, nil
+}
+
 // CurrentAddress gets the most recently requested Bitcoin payment address
 // from a wallet for a particular key-chain scope.  If the address has already
 // been used (there is at least one transaction spending to it in the
 // blockchain or btcd mempool), the next chained address is returned.
 func (w *Wallet) CurrentAddress(account uint32, scope waddrmgr.KeyScope) (btcutil.Address, error) {
-	chainClient, err := w.requireChainClient()
+	chainClient, err := w.optionalChainClient()
 	if err != nil {
 		return nil, err
 	}
@@ -1522,9 +1883,11 @@ func (w *Wallet) CurrentAddress(account uint32, scope waddrmgr.KeyScope) (btcuti
 	// If the props have been initially, then we had to create a new address
 	// to satisfy the query. Notify the rpc server about the new address.
 	if props != nil {
-		err = chainClient.NotifyReceived([]btcutil.Address{addr})
-		if err != nil {
-			return nil, err
+		if chainClient != nil {
+			err = chainClient.NotifyReceived([]btcutil.Address{addr})
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		w.NtfnServer.notifyAccountProperties(props)
@@ -1592,9 +1955,8 @@ func (w *Wallet) HaveAddress(a btcutil.Address) (bool, error) {
 func (w *Wallet) AccountOfAddress(a btcutil.Address) (uint32, error) {
 	var account uint32
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
-		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
 		var err error
-		_, account, err = w.Manager.AddrAccount(addrmgrNs, a)
+		account, err = w.addrAccount(tx, a)
 		return err
 	})
 	return account, err
@@ -1612,6 +1974,19 @@ func (w *Wallet) AddressInfo(a btcutil.Address) (waddrmgr.ManagedAddress, error)
 	return managedAddress, err
 }
 
+// AddressUsage returns when the wallet address was generated or imported, and
+// when it was first seen and last used on the chain.
+func (w *Wallet) AddressUsage(a btcutil.Address) (*waddrmgr.AddressUsage, error) {
+	var usage *waddrmgr.AddressUsage
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		var err error
+		usage, err = w.Manager.AddressUsage(addrmgrNs, a)
+		return err
+	})
+	return usage, err
+}
+
 // AccountNumber returns the account number for an account name under a
 // particular key scope.
 func (w *Wallet) AccountNumber(scope waddrmgr.KeyScope, accountName string) (uint32, error) {
@@ -1725,6 +2100,38 @@ func (w *Wallet) NextAccount(scope waddrmgr.KeyScope, name string) (uint32, erro
 	return account, err
 }
 
+// NextAccountWithPath creates the next account of a key scope with the
+// extended key derived from the master HD key along path, and returns its
+// account number.  The wallet must be unlocked.
+func (w *Wallet) NextAccountWithPath(scope waddrmgr.KeyScope, name string,
+	path []uint32) (uint32, error) {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		account uint32
+		props   *waddrmgr.AccountProperties
+	)
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		var err error
+		account, err = manager.NewAccountWithPath(addrmgrNs, name, path)
+		if err != nil {
+			return err
+		}
+		props, err = manager.AccountProperties(addrmgrNs, account)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	w.NtfnServer.notifyAccountProperties(props)
+	return account, nil
+}
+
 // CreditCategory describes the type of wallet transaction output.  The category
 // of "sent transactions" (debits) is always "send", and is not expressed by
 // this type.
@@ -1838,7 +2245,7 @@ outputs:
 
 		var address string
 		var accountName string
-		_, addrs, _, _ := txscript.ExtractPkScriptAddrs(output.PkScript, net)
+		_, addrs, _, _ := taproot.ExtractPkScriptAddrs(output.PkScript, net)
 		if len(addrs) == 1 {
 			addr := addrs[0]
 			address = addr.EncodeAddress()
@@ -1851,10 +2258,21 @@ outputs:
 			}
 		}
 
+		// Outputs to watch-only addresses are credited to the account
+		// they were imported to.
+		watchNs := tx.ReadBucket(wwatchNamespaceKey)
+		scope, account, err := fetchWatchedAccount(watchNs, output.PkScript)
+		watched := err == nil
+		if watched {
+			mgr, err := addrMgr.FetchScopedKeyManager(scope)
+			if err == nil {
+				accountName, _ = mgr.AccountName(addrmgrNs, account)
+			}
+		}
+
 		amountF64 := btcutil.Amount(output.Value).ToBTC()
 		result := btcjson.ListTransactionsResult{
 			// Fields left zeroed:
-			//   InvolvesWatchOnly
 			//   BlockIndex
 			//
 			// Fields set below:
@@ -1862,16 +2280,17 @@ outputs:
 			//   Category
 			//   Amount
 			//   Fee
-			Address:         address,
-			Vout:            uint32(i),
-			Confirmations:   confirmations,
-			Generated:       generated,
-			BlockHash:       blockHashStr,
-			BlockTime:       blockTime,
-			TxID:            txHashStr,
-			WalletConflicts: []string{},
-			Time:            received,
-			TimeReceived:    received,
+			Address:           address,
+			InvolvesWatchOnly: watched,
+			Vout:              uint32(i),
+			Confirmations:     confirmations,
+			Generated:         generated,
+			BlockHash:         blockHashStr,
+			BlockTime:         blockTime,
+			TxID:              txHashStr,
+			WalletConflicts:   []string{},
+			Time:              received,
+			TimeReceived:      received,
 		}
 
 		// Add a received/generated/immature result if this is a credit.
@@ -1994,7 +2413,7 @@ func (w *Wallet) ListAddressTransactions(pkHashes map[string]struct{}) ([]btcjso
 
 				for _, cred := range detail.Credits {
 					pkScript := detail.MsgTx.TxOut[cred.Index].PkScript
-					_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+					_, addrs, _, err := taproot.ExtractPkScriptAddrs(
 						pkScript, w.chainParams)
 					if err != nil || len(addrs) != 1 {
 						continue
@@ -2285,7 +2704,7 @@ func (w *Wallet) Accounts(scope waddrmgr.KeyScope) (*AccountsResult, error) {
 		for i := range unspent {
 			output := unspent[i]
 			var outputAcct uint32
-			_, addrs, _, err := txscript.ExtractPkScriptAddrs(output.PkScript, w.chainParams)
+			_, addrs, _, err := taproot.ExtractPkScriptAddrs(output.PkScript, w.chainParams)
 			if err == nil && len(addrs) > 0 {
 				_, outputAcct, err = w.Manager.AddrAccount(addrmgrNs, addrs[0])
 			}
@@ -2363,7 +2782,7 @@ func (w *Wallet) AccountBalances(scope waddrmgr.KeyScope,
 				output.Height, syncBlock.Height) {
 				continue
 			}
-			_, addrs, _, err := txscript.ExtractPkScriptAddrs(output.PkScript, w.chainParams)
+			_, addrs, _, err := taproot.ExtractPkScriptAddrs(output.PkScript, w.chainParams)
 			if err != nil || len(addrs) == 0 {
 				continue
 			}
@@ -2426,15 +2845,18 @@ func (s creditSlice) Swap(i, j int) {
 // ListUnspent returns a slice of objects representing the unspent wallet
 // transactions fitting the given criteria. The confirmations will be more than
 // minconf, less than maxconf and if addresses is populated only the addresses
-// contained within it will be considered.  If we know nothing about a
-// transaction an empty array will be returned.
+// contained within it will be considered.  Outputs worth less than minAmount
+// are excluded.  If we know nothing about a transaction an empty array will be
+// returned.
 func (w *Wallet) ListUnspent(minconf, maxconf int32,
-	addresses map[string]struct{}, token *wire.TokenIdentity) ([]*btcjson.ListUnspentResult, error) {
+	addresses map[string]struct{}, token *wire.TokenIdentity,
+	minAmount btcutil.Amount) ([]*btcjson.ListUnspentResult, error) {
 
 	var results []*btcjson.ListUnspentResult
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
 		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		watchNs := tx.ReadBucket(wwatchNamespaceKey)
 
 		syncBlock := w.Manager.SyncedTo()
 
@@ -2458,6 +2880,12 @@ func (w *Wallet) ListUnspent(minconf, maxconf int32,
 				continue
 			}
 
+			// Outputs worth less than the minimum amount are
+			// excluded.
+			if output.Amount < minAmount {
+				continue
+			}
+
 			// Only mature coinbase outputs are included.
 			if output.FromCoinBase {
 				target := int32(w.ChainParams().CoinbaseMaturity)
@@ -2478,7 +2906,7 @@ func (w *Wallet) ListUnspent(minconf, maxconf int32,
 			// This will be unnecessary once transactions and outputs are
 			// grouped under the associated account in the db.
 			acctName := defaultAccountName
-			sc, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			sc, addrs, _, err := taproot.ExtractPkScriptAddrs(
 				output.PkScript, w.chainParams)
 			if err != nil {
 				continue
@@ -2492,6 +2920,18 @@ func (w *Wallet) ListUnspent(minconf, maxconf int32,
 					}
 				}
 			}
+			watchScope, watchAcct, err := fetchWatchedAccount(watchNs,
+				output.PkScript)
+			watched := err == nil
+			if watched {
+				smgr, err := w.Manager.FetchScopedKeyManager(watchScope)
+				if err == nil {
+					s, err := smgr.AccountName(addrmgrNs, watchAcct)
+					if err == nil {
+						acctName = s
+					}
+				}
+			}
 
 			if filter {
 				for _, addr := range addrs {
@@ -2504,17 +2944,15 @@ func (w *Wallet) ListUnspent(minconf, maxconf int32,
 			}
 
 		include:
-			// At the moment watch-only addresses are not supported, so all
-			// recorded outputs that are not multisig are "spendable".
-			// Multisig outputs are only "spendable" if all keys are
-			// controlled by this wallet.
+			// All recorded outputs that are not multisig or paying to
+			// watch-only addresses are "spendable".  Multisig outputs
+			// are only "spendable" if all keys are controlled by this
+			// wallet.
 			//
-			// TODO: Each case will need updates when watch-only addrs
-			// is added.  For P2PK, P2PKH, and P2SH, the address must be
-			// looked up and not be watching-only.  For multisig, all
-			// pubkeys must belong to the manager with the associated
-			// private key (currently it only checks whether the pubkey
-			// exists, since the private key is required at the moment).
+			// TODO: For multisig, all pubkeys must belong to the
+			// manager with the associated private key (currently it
+			// only checks whether the pubkey exists, since the private
+			// key is required at the moment).
 			var spendable bool
 		scSwitch:
 			switch sc {
@@ -2539,6 +2977,9 @@ func (w *Wallet) ListUnspent(minconf, maxconf int32,
 				}
 				spendable = true
 			}
+			if watched {
+				spendable = false
+			}
 
 			result := &btcjson.ListUnspentResult{
 				TxID:          output.OutPoint.Hash.String(),
@@ -2587,15 +3028,20 @@ func (w *Wallet) DumpPrivKeys() ([]string, error) {
 
 			wif, err := pka.ExportPrivKey()
 			if err != nil {
-				// It would be nice to zero out the array here. However,
-				// since strings in go are immutable, and we have no
-				// control over the caller I don't think we can. :(
 				return err
 			}
+
+			// The encoded keys are strings, which are immutable
+			// and cannot be zeroed, but the private key itself is
+			// zeroed as soon as it is encoded.
 			privkeys = append(privkeys, wif.String())
+			zero.BigInt(wif.PrivKey.D)
 			return nil
 		})
 	})
+	if err == nil {
+		w.audit(AuditKeyExport, "%d private keys exported", len(privkeys))
+	}
 	return privkeys, err
 }
 
@@ -2623,6 +3069,8 @@ func (w *Wallet) DumpWIFPrivateKey(addr btcutil.Address) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	defer zero.BigInt(wif.PrivKey.D)
+	w.audit(AuditKeyExport, "private key of %s exported", addr)
 	return wif.String(), nil
 }
 
@@ -2631,11 +3079,6 @@ func (w *Wallet) DumpWIFPrivateKey(addr btcutil.Address) (string, error) {
 func (w *Wallet) ImportPrivateKey(scope waddrmgr.KeyScope, wif *btcutil.WIF,
 	bs *waddrmgr.BlockStamp, rescan bool) (string, error) {
 
-	manager, err := w.Manager.FetchScopedKeyManager(scope)
-	if err != nil {
-		return "", err
-	}
-
 	// The starting block for the key is the genesis block unless otherwise
 	// specified.
 	var newBirthday time.Time
@@ -2653,6 +3096,48 @@ func (w *Wallet) ImportPrivateKey(scope waddrmgr.KeyScope, wif *btcutil.WIF,
 		}
 	}
 
+	addr, err := w.importPrivateKey(scope, wif, bs, newBirthday)
+	if err != nil {
+		return "", err
+	}
+
+	// Rescan blockchain for transactions with txout scripts paying to the
+	// imported address.
+	if rescan {
+		job := &RescanJob{
+			Addrs:      []btcutil.Address{addr},
+			OutPoints:  nil,
+			BlockStamp: *bs,
+		}
+
+		// Submit rescan job and log when the import has completed.
+		// Do not block on finishing the rescan.  The rescan success
+		// or failure is logged elsewhere, and the channel is not
+		// required to be read, so discard the return value.
+		_ = w.SubmitRescan(job)
+	} else {
+		err := w.chainClient.NotifyReceived([]btcutil.Address{addr})
+		if err != nil {
+			return "", fmt.Errorf("Failed to subscribe for address ntfns for "+
+				"address %s: %s", addr.EncodeAddress(), err)
+		}
+	}
+
+	// Return the payment address string of the imported private key.
+	return addr.EncodeAddress(), nil
+}
+
+// importPrivateKey imports a private key to the imported account of a scope
+// and sets the wallet birthday, without rescanning or watching the address
+// of the key.
+func (w *Wallet) importPrivateKey(scope waddrmgr.KeyScope, wif *btcutil.WIF,
+	bs *waddrmgr.BlockStamp, newBirthday time.Time) (btcutil.Address, error) {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return nil, err
+	}
+
 	// Attempt to import private key into wallet.
 	var addr btcutil.Address
 	var props *waddrmgr.AccountProperties
@@ -2672,77 +3157,144 @@ func (w *Wallet) ImportPrivateKey(scope waddrmgr.KeyScope, wif *btcutil.WIF,
 		return w.Manager.SetBirthday(addrmgrNs, newBirthday)
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Rescan blockchain for transactions with txout scripts paying to the
-	// imported address.
-	if rescan {
-		job := &RescanJob{
-			Addrs:      []btcutil.Address{addr},
-			OutPoints:  nil,
-			BlockStamp: *bs,
+	log.Infof("Imported payment address %s", addr.EncodeAddress())
+	w.audit(AuditImport, "private key of %s imported", addr)
+
+	w.NtfnServer.notifyAccountProperties(props)
+
+	return addr, nil
+}
+
+// importPrivateKeys imports many private keys to the imported account of a
+// scope in a single database transaction, and sets the wallet birthday to the
+// time of bs, the earliest block of the keys, without rescanning or watching
+// the addresses of the keys.  The returned slices hold the address and the
+// error of each key.
+func (w *Wallet) importPrivateKeys(scope waddrmgr.KeyScope, wifs []*btcutil.WIF,
+	bs *waddrmgr.BlockStamp) ([]btcutil.Address, []error, error) {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addrs := make([]btcutil.Address, len(wifs))
+	var errs []error
+	var imported int
+	var props *waddrmgr.AccountProperties
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		maddrs, keyErrs, err := manager.ImportPrivateKeys(
+			addrmgrNs, wifs, bs,
+		)
+		if err != nil {
+			return err
+		}
+		errs = keyErrs
+		for i, maddr := range maddrs {
+			if errs[i] == nil {
+				addrs[i] = maddr.Address()
+				imported++
+			}
+		}
+		if imported == 0 {
+			return nil
 		}
 
-		// Submit rescan job and log when the import has completed.
-		// Do not block on finishing the rescan.  The rescan success
-		// or failure is logged elsewhere, and the channel is not
-		// required to be read, so discard the return value.
-		_ = w.SubmitRescan(job)
-	} else {
-		err := w.chainClient.NotifyReceived([]btcutil.Address{addr})
+		props, err = manager.AccountProperties(
+			addrmgrNs, waddrmgr.ImportedAddrAccount,
+		)
 		if err != nil {
-			return "", fmt.Errorf("Failed to subscribe for address ntfns for "+
-				"address %s: %s", addr.EncodeAddress(), err)
+			return err
 		}
+		return w.Manager.SetBirthday(addrmgrNs, bs.Timestamp)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if imported == 0 {
+		return addrs, errs, nil
 	}
 
-	addrStr := addr.EncodeAddress()
-	log.Infof("Imported payment address %s", addrStr)
+	log.Infof("Imported %d of %d private keys", imported, len(wifs))
+	w.audit(AuditImport, "%d private keys imported", imported)
 
 	w.NtfnServer.notifyAccountProperties(props)
 
-	// Return the payment address string of the imported private key.
-	return addrStr, nil
+	return addrs, errs, nil
 }
 
 // LockedOutpoint returns whether an outpoint has been marked as locked and
 // should not be used as an input for created transactions.
 func (w *Wallet) LockedOutpoint(op wire.OutPoint) bool {
+	w.lockedOutpointsMtx.Lock()
 	_, locked := w.lockedOutpoints[op]
+	w.lockedOutpointsMtx.Unlock()
 	return locked
 }
 
 // LockOutpoint marks an outpoint as locked, that is, it should not be used as
-// an input for newly created transactions.
+// an input for newly created transactions.  The outpoint stays locked when
+// the wallet is restarted.
 func (w *Wallet) LockOutpoint(op wire.OutPoint) {
+	w.lockedOutpointsMtx.Lock()
 	w.lockedOutpoints[op] = struct{}{}
+	w.lockedOutpointsMtx.Unlock()
+
+	w.storeOutpointLocks(func(ns walletdb.ReadWriteBucket) error {
+		return putLockedOutpoint(ns, &op)
+	})
 }
 
 // UnlockOutpoint marks an outpoint as unlocked, that is, it may be used as an
 // input for newly created transactions.
 func (w *Wallet) UnlockOutpoint(op wire.OutPoint) {
+	w.lockedOutpointsMtx.Lock()
 	delete(w.lockedOutpoints, op)
+	w.lockedOutpointsMtx.Unlock()
+
+	w.storeOutpointLocks(func(ns walletdb.ReadWriteBucket) error {
+		return deleteLockedOutpoints(ns, []wire.OutPoint{op})
+	})
 }
 
 // ResetLockedOutpoints resets the set of locked outpoints so all may be used
 // as inputs for new transactions.
 func (w *Wallet) ResetLockedOutpoints() {
+	w.lockedOutpointsMtx.Lock()
 	w.lockedOutpoints = map[wire.OutPoint]struct{}{}
+	w.lockedOutpointsMtx.Unlock()
+
+	w.storeOutpointLocks(func(ns walletdb.ReadWriteBucket) error {
+		return deleteLockedOutpoints(ns, nil)
+	})
+}
+
+// lockedOutpointList returns the currently locked outpoints.
+func (w *Wallet) lockedOutpointList() []wire.OutPoint {
+	w.lockedOutpointsMtx.Lock()
+	defer w.lockedOutpointsMtx.Unlock()
+	ops := make([]wire.OutPoint, 0, len(w.lockedOutpoints))
+	for op := range w.lockedOutpoints {
+		ops = append(ops, op)
+	}
+	return ops
 }
 
 // LockedOutpoints returns a slice of currently locked outpoints.  This is
 // intended to be used by marshaling the result as a JSON array for
 // listlockunspent RPC results.
 func (w *Wallet) LockedOutpoints() []btcjson.TransactionInput {
-	locked := make([]btcjson.TransactionInput, len(w.lockedOutpoints))
-	i := 0
-	for op := range w.lockedOutpoints {
+	ops := w.lockedOutpointList()
+	locked := make([]btcjson.TransactionInput, len(ops))
+	for i, op := range ops {
 		locked[i] = btcjson.TransactionInput{
 			Txid: op.Hash.String(),
 			Vout: op.Index,
 		}
-		i++
 	}
 	return locked
 }
@@ -2770,6 +3322,13 @@ func (w *Wallet) resendUnminedTxs() {
 	}
 
 	for _, tx := range txs {
+		// Transactions held for broadcast are broadcast at the end
+		// of their hold window.
+		txHash := tx.TxHash()
+		if w.broadcastHeld(&txHash) {
+			continue
+		}
+
 		resp, err := chainClient.SendRawTransaction(tx, false)
 		if err != nil {
 			log.Debugf("Could not resend transaction %v: %v",
@@ -2833,13 +3392,14 @@ func (w *Wallet) resendUnminedTxs() {
 }
 
 // SortedActivePaymentAddresses returns a slice of all active payment
-// addresses in a wallet.
+// addresses in a wallet.  Change addresses, derived on the internal branch of
+// the accounts, are not payment addresses and are excluded.
 func (w *Wallet) SortedActivePaymentAddresses() ([]string, error) {
 	var addrStrs []string
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
-		return w.Manager.ForEachActiveAddress(addrmgrNs, func(addr btcutil.Address) error {
-			addrStrs = append(addrStrs, addr.EncodeAddress())
+		return w.Manager.ForEachActivePaymentAddress(addrmgrNs, func(maddr waddrmgr.ManagedAddress) error {
+			addrStrs = append(addrStrs, maddr.Address().EncodeAddress())
 			return nil
 		})
 	})
@@ -2855,7 +3415,7 @@ func (w *Wallet) SortedActivePaymentAddresses() ([]string, error) {
 func (w *Wallet) NewAddress(account uint32,
 	scope waddrmgr.KeyScope) (btcutil.Address, error) {
 
-	chainClient, err := w.requireChainClient()
+	chainClient, err := w.optionalChainClient()
 	if err != nil {
 		return nil, err
 	}
@@ -2873,22 +3433,108 @@ func (w *Wallet) NewAddress(account uint32,
 	if err != nil {
 		return nil, err
 	}
+	w.audit(AuditNewAddress, "address %s of account %d generated", addr,
+		account)
+
+	// Notify the rpc server about the newly created address.  Offline
+	// wallets request notifications for every address when they sync.
+	if chainClient != nil {
+		err = chainClient.NotifyReceived([]btcutil.Address{addr})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.NtfnServer.notifyAccountProperties(props)
+
+	return addr, nil
+}
 
-	// Notify the rpc server about the newly created address.
-	err = chainClient.NotifyReceived([]btcutil.Address{addr})
+// maxReservedIndexes is the maximum number of external address indexes that
+// may be reserved at once.
+const maxReservedIndexes = 10000
+
+// AddressReservation describes a range of external branch indexes of an
+// account reserved for addresses derived outside the wallet.
+type AddressReservation struct {
+	Account    uint32
+	Branch     uint32
+	FirstIndex uint32
+	LastIndex  uint32
+}
+
+// ReserveAddressIndexes reserves the next count indexes of the external
+// branch of an account for an external system deriving addresses from the
+// account extended public key.  The addresses are derived and watched like
+// those returned by NewAddress, so outputs paying to them are credited to the
+// account, but NewAddress never returns them.
+func (w *Wallet) ReserveAddressIndexes(account, count uint32,
+	scope waddrmgr.KeyScope) (*AddressReservation, error) {
+
+	if count == 0 || count > maxReservedIndexes {
+		return nil, fmt.Errorf("number of reserved indexes must be "+
+			"between 1 and %d", maxReservedIndexes)
+	}
+
+	chainClient, err := w.optionalChainClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		addrs []btcutil.Address
+		res   *AddressReservation
+		props *waddrmgr.AccountProperties
+	)
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		manager, err := w.fetchOrCreateScopedKeyManager(addrmgrNs, scope)
+		if err != nil {
+			return err
+		}
+		maddrs, err := manager.NextExternalAddresses(addrmgrNs, account,
+			count)
+		if err != nil {
+			return err
+		}
+		for _, maddr := range maddrs {
+			addrs = append(addrs, maddr.Address())
+		}
+
+		props, err = manager.AccountProperties(addrmgrNs, account)
+		if err != nil {
+			return err
+		}
+		res = &AddressReservation{
+			Account:    account,
+			Branch:     waddrmgr.ExternalBranch,
+			FirstIndex: props.ExternalKeyCount - count,
+			LastIndex:  props.ExternalKeyCount - 1,
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	if chainClient != nil {
+		if err := chainClient.NotifyReceived(addrs); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Infof("Reserved external indexes %d-%d of account %s",
+		res.FirstIndex, res.LastIndex, props.AccountName)
+
 	w.NtfnServer.notifyAccountProperties(props)
 
-	return addr, nil
+	return res, nil
 }
 
 func (w *Wallet) newAddress(addrmgrNs walletdb.ReadWriteBucket, account uint32,
 	scope waddrmgr.KeyScope) (btcutil.Address, *waddrmgr.AccountProperties, error) {
 
-	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	manager, err := w.fetchOrCreateScopedKeyManager(addrmgrNs, scope)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -2909,11 +3555,30 @@ func (w *Wallet) newAddress(addrmgrNs walletdb.ReadWriteBucket, account uint32,
 	return addrs[0].Address(), props, nil
 }
 
+// fetchOrCreateScopedKeyManager returns the scoped manager for a scope,
+// creating it first if it is one of the default scopes that didn't exist
+// yet when the wallet was created.  Creating a scope requires the wallet to
+// be unlocked.
+func (w *Wallet) fetchOrCreateScopedKeyManager(addrmgrNs walletdb.ReadWriteBucket,
+	scope waddrmgr.KeyScope) (*waddrmgr.ScopedKeyManager, error) {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if !waddrmgr.IsError(err, waddrmgr.ErrScopeNotFound) {
+		return manager, err
+	}
+
+	addrSchema, ok := waddrmgr.ScopeAddrMap[scope]
+	if !ok {
+		return nil, err
+	}
+	return w.Manager.NewScopedKeyManager(addrmgrNs, scope, addrSchema)
+}
+
 // NewChangeAddress returns a new change address for a wallet.
 func (w *Wallet) NewChangeAddress(account uint32,
 	scope waddrmgr.KeyScope) (btcutil.Address, error) {
 
-	chainClient, err := w.requireChainClient()
+	chainClient, err := w.optionalChainClient()
 	if err != nil {
 		return nil, err
 	}
@@ -2928,11 +3593,16 @@ func (w *Wallet) NewChangeAddress(account uint32,
 	if err != nil {
 		return nil, err
 	}
+	w.audit(AuditNewAddress, "change address %s of account %d generated",
+		addr, account)
 
-	// Notify the rpc server about the newly created address.
-	err = chainClient.NotifyReceived([]btcutil.Address{addr})
-	if err != nil {
-		return nil, err
+	// Notify the rpc server about the newly created address.  Offline
+	// wallets request notifications for every address when they sync.
+	if chainClient != nil {
+		err = chainClient.NotifyReceived([]btcutil.Address{addr})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return addr, nil
@@ -2941,12 +3611,7 @@ func (w *Wallet) NewChangeAddress(account uint32,
 func (w *Wallet) newChangeAddress(addrmgrNs walletdb.ReadWriteBucket,
 	account uint32) (btcutil.Address, error) {
 
-	// As we're making a change address, we'll fetch the type of manager
-	// that is able to make p2wkh output as they're the most efficient.
-	scopes := w.Manager.ScopesForExternalAddrType(
-		waddrmgr.WitnessPubKey,
-	)
-	manager, err := w.Manager.FetchScopedKeyManager(scopes[0])
+	manager, err := w.changeKeyManager()
 	if err != nil {
 		return nil, err
 	}
@@ -2960,6 +3625,24 @@ func (w *Wallet) newChangeAddress(addrmgrNs walletdb.ReadWriteBucket,
 	return addrs[0].Address(), nil
 }
 
+// changeKeyManager returns the scoped key manager deriving change addresses.
+func (w *Wallet) changeKeyManager() (*waddrmgr.ScopedKeyManager, error) {
+	// As we're making a change address, we'll fetch the type of manager
+	// that is able to make p2wkh output as they're the most efficient.
+	scopes := w.Manager.ScopesForExternalAddrType(
+		waddrmgr.WitnessPubKey,
+	)
+
+	// Watching-only wallets created from a descriptor only have the key
+	// scope of the descriptor, which may not be a p2wkh scope.
+	if len(scopes) == 0 {
+		for _, mgr := range w.Manager.ActiveScopedKeyManagers() {
+			scopes = append(scopes, mgr.Scope())
+		}
+	}
+	return w.Manager.FetchScopedKeyManager(scopes[0])
+}
+
 // confirmed checks whether a transaction at height txHeight has met minconf
 // confirmations for a blockchain at height curHeight.
 func confirmed(minconf, txHeight, curHeight int32) bool {
@@ -2988,7 +3671,8 @@ type AccountTotalReceivedResult struct {
 }
 
 // TotalReceivedForAccounts iterates through a wallet's transaction history,
-// returning the total amount of Bitcoin received for all accounts.
+// returning the total amount of Bitcoin received for all accounts.  Change is
+// not counted as received.
 func (w *Wallet) TotalReceivedForAccounts(scope waddrmgr.KeyScope,
 	minConf int32) ([]AccountTotalReceivedResult, error) {
 
@@ -3031,9 +3715,13 @@ func (w *Wallet) TotalReceivedForAccounts(scope waddrmgr.KeyScope,
 			for i := range details {
 				detail := &details[i]
 				for _, cred := range detail.Credits {
+					// Change is not received by the account.
+					if cred.Change {
+						continue
+					}
 					pkScript := detail.MsgTx.TxOut[cred.Index].PkScript
 					var outputAcct uint32
-					_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, w.chainParams)
+					_, addrs, _, err := taproot.ExtractPkScriptAddrs(pkScript, w.chainParams)
 					if err == nil && len(addrs) > 0 {
 						_, outputAcct, err = w.Manager.AddrAccount(addrmgrNs, addrs[0])
 					}
@@ -3081,7 +3769,7 @@ func (w *Wallet) TotalReceivedForAddr(addr btcutil.Address, minConf int32) (btcu
 				detail := &details[i]
 				for _, cred := range detail.Credits {
 					pkScript := detail.MsgTx.TxOut[cred.Index].PkScript
-					_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
+					_, addrs, _, err := taproot.ExtractPkScriptAddrs(pkScript,
 						w.chainParams)
 					// An error creating addresses from the output script only
 					// indicates a non-standard script, so ignore this credit.
@@ -3104,9 +3792,11 @@ func (w *Wallet) TotalReceivedForAddr(addr btcutil.Address, minConf int32) (btcu
 }
 
 // SendOutputs creates and sends payment transactions. It returns the
-// transaction hash upon success.
+// transaction hash upon success.  Options left unset in opts, which may be
+// nil, follow the wallet's policies.
 func (w *Wallet) SendOutputs(outputs []*wire.TxOut, account uint32,
-	minconf int32, satPerKb btcutil.Amount) (*chainhash.Hash, error) {
+	minconf int32, satPerKb btcutil.Amount,
+	opts *TxOptions) (*chainhash.Hash, error) {
 
 	// Ensure the outputs to be created adhere to the network's consensus
 	// rules.
@@ -3120,7 +3810,8 @@ func (w *Wallet) SendOutputs(outputs []*wire.TxOut, account uint32,
 	// transaction will be added to the database in order to ensure that we
 	// continue to re-broadcast the transaction upon restarts until it has
 	// been confirmed.
-	createdTx, err := w.CreateSimpleTx(account, outputs, minconf, satPerKb)
+	createdTx, err := w.CreateSimpleTx(account, outputs, minconf, satPerKb,
+		opts)
 	if err != nil {
 		return nil, err
 	}
@@ -3134,6 +3825,12 @@ func (w *Wallet) SendOutputs(outputs []*wire.TxOut, account uint32,
 	return w.publishTransaction(createdTx.Tx)
 }
 
+// errTaprootRawSign describes the error recorded for taproot inputs passed to
+// SignTransaction.  These can only be signed when the wallet authors the
+// transaction itself.
+var errTaprootRawSign = errors.New("taproot inputs can not be signed as " +
+	"raw transaction inputs")
+
 // SignatureError records the underlying error when validating a transaction
 // input signature.
 type SignatureError struct {
@@ -3159,6 +3856,7 @@ func (w *Wallet) SignTransaction(tx *wire.MsgTx, hashType txscript.SigHashType,
 	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
 		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
 		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+		scriptNs := dbtx.ReadBucket(wscriptNamespaceKey)
 
 		for i, txIn := range tx.TxIn {
 			prevOutScript, ok := additionalPrevScripts[txIn.PreviousOutPoint]
@@ -3177,6 +3875,17 @@ func (w *Wallet) SignTransaction(tx *wire.MsgTx, hashType txscript.SigHashType,
 				prevOutScript = txDetails.MsgTx.TxOut[prevIndex].PkScript
 			}
 
+			// Taproot signature hashes commit to the amounts of every
+			// input, which raw transaction signing does not know, and
+			// the script engine can't verify them.
+			if taproot.IsPayToTaproot(prevOutScript) {
+				signErrors = append(signErrors, SignatureError{
+					InputIndex: uint32(i),
+					Error:      errTaprootRawSign,
+				})
+				continue
+			}
+
 			// Set up our callbacks that we pass to txscript so it can
 			// look up the appropriate keys and scripts by address.
 			getKey := txscript.KeyClosure(func(addr btcutil.Address) (*btcec.PrivateKey, bool, error) {
@@ -3193,6 +3902,9 @@ func (w *Wallet) SignTransaction(tx *wire.MsgTx, hashType txscript.SigHashType,
 				if err != nil {
 					return nil, false, err
 				}
+				if err := w.checkAccountFrozen(address.Account()); err != nil {
+					return nil, false, err
+				}
 
 				pka, ok := address.(waddrmgr.ManagedPubKeyAddress)
 				if !ok {
@@ -3204,6 +3916,7 @@ func (w *Wallet) SignTransaction(tx *wire.MsgTx, hashType txscript.SigHashType,
 				if err != nil {
 					return nil, false, err
 				}
+				w.recordKeyUse(pka)
 
 				return key, pka.Compressed(), nil
 			})
@@ -3218,6 +3931,10 @@ func (w *Wallet) SignTransaction(tx *wire.MsgTx, hashType txscript.SigHashType,
 					}
 					return script, nil
 				}
+				script, err := fetchScript(scriptNs, addr)
+				if err == nil {
+					return script, nil
+				}
 				address, err := w.Manager.Address(addrmgrNs, addr)
 				if err != nil {
 					return nil, err
@@ -3292,6 +4009,15 @@ func (w *Wallet) publishOrder(order *wire.MsgOdr) (*chainhash.Hash, error) {
 		return nil, err
 	}
 
+	if err := w.screenOutgoing(order.MsgTx); err != nil {
+		return nil, err
+	}
+	hookEvent := &TxHookEvent{Point: HookBeforeBroadcast, Tx: order.MsgTx}
+	if err := w.runTxHooks(hookEvent); err != nil {
+		return nil, err
+	}
+	w.saveTxAnnotations(order.TxHash(), hookEvent.Annotations)
+
 	// As we aim for this to be general reliable order broadcast API,
 	// we'll write this order to disk as an unconfirmed order. This way,
 	// upon restarts, we'll always rebroadcast it, and also add it to our
@@ -3362,10 +4088,25 @@ func (w *Wallet) PublishTransaction(tx *wire.MsgTx) error {
 // from the database (along with cleaning up all inputs used, and outputs
 // created) if the transaction is rejected by the back end.
 func (w *Wallet) publishTransaction(tx *wire.MsgTx) (*chainhash.Hash, error) {
-	server, err := w.requireChainClient()
+	server, err := w.optionalChainClient()
+	if err != nil {
+		return nil, err
+	}
+
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		return w.checkFrozenSpends(dbtx, tx)
+	})
 	if err != nil {
 		return nil, err
 	}
+	if err := w.screenOutgoing(tx); err != nil {
+		return nil, err
+	}
+	hookEvent := &TxHookEvent{Point: HookBeforeBroadcast, Tx: tx}
+	if err := w.runTxHooks(hookEvent); err != nil {
+		return nil, err
+	}
+	w.saveTxAnnotations(tx.TxHash(), hookEvent.Annotations)
 
 	// As we aim for this to be general reliable transaction broadcast API,
 	// we'll write this tx to disk as an unconfirmed transaction. This way,
@@ -3375,32 +4116,60 @@ func (w *Wallet) publishTransaction(tx *wire.MsgTx) (*chainhash.Hash, error) {
 	if err != nil {
 		return nil, err
 	}
+	hold := w.BroadcastHold()
 	err = walletdb.Update(w.db, func(dbTx walletdb.ReadWriteTx) error {
-		return w.addRelevantTx(dbTx, txRec, nil)
+		if err := w.addRelevantTx(dbTx, txRec, nil); err != nil {
+			return err
+		}
+
+		// Offline wallets queue the transaction until they are
+		// synced with a chain client.  Held transactions are only
+		// queued at the end of their hold window.
+		if server == nil && hold == 0 {
+			return queueBroadcast(
+				dbTx.ReadWriteBucket(wtxmetaNamespaceKey), tx,
+			)
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	w.NtfnServer.notifyPublishedTransaction()
+	if hold > 0 {
+		w.holdBroadcast(txRec, hold, hookEvent.Annotations)
+		return &txRec.Hash, nil
+	}
+	if server == nil {
+		log.Infof("Queued transaction %v for broadcast once the "+
+			"wallet is online", txRec.Hash)
+		w.audit(AuditSend, "transaction %v queued for broadcast",
+			txRec.Hash)
+		return &txRec.Hash, nil
+	}
+
+	txid, err := w.sendTransaction(server, txRec)
+	if err != nil {
+		return nil, err
+	}
+	w.audit(AuditSend, "transaction %v broadcast", txid)
+	return txid, nil
+}
+
+// sendTransaction broadcasts a transaction recorded as unmined by the wallet.
+// A transaction rejected by the back end is removed from the wallet.
+func (w *Wallet) sendTransaction(server chain.Interface,
+	txRec *wtxmgr.TxRecord) (*chainhash.Hash, error) {
 
-	txid, err := server.SendRawTransaction(tx, false)
+	txid, err := server.SendRawTransaction(&txRec.MsgTx, false)
+	if err != nil {
+		w.emailDigest.recordBroadcastFailure(&txRec.Hash, err)
+	}
 	switch {
 	case err == nil:
 		return txid, nil
 
-	// The following are errors returned from btcd's mempool.
-	case strings.Contains(err.Error(), "spent"):
-		fallthrough
-	case strings.Contains(err.Error(), "orphan"):
-		fallthrough
-	case strings.Contains(err.Error(), "conflict"):
-		fallthrough
-
-	// The following errors are returned from bitcoind's mempool.
-	case strings.Contains(err.Error(), "fee not met"):
-		fallthrough
-	case strings.Contains(err.Error(), "Missing inputs"):
-		fallthrough
-	case strings.Contains(err.Error(), "already in block chain"):
+	case isRejection(err):
 		// If the transaction was rejected, then we'll remove it from
 		// the txstore, as otherwise, we'll attempt to continually
 		// re-broadcast it, and the utxo state of the wallet won't be
@@ -3421,6 +4190,31 @@ func (w *Wallet) publishTransaction(tx *wire.MsgTx) (*chainhash.Hash, error) {
 	}
 }
 
+// isRejection returns whether a broadcast error is the rejection of the
+// transaction by the mempool of the back end.
+func isRejection(err error) bool {
+	switch {
+	// The following are errors returned from btcd's mempool.
+	case strings.Contains(err.Error(), "spent"):
+		fallthrough
+	case strings.Contains(err.Error(), "orphan"):
+		fallthrough
+	case strings.Contains(err.Error(), "conflict"):
+		fallthrough
+
+	// The following errors are returned from bitcoind's mempool.
+	case strings.Contains(err.Error(), "fee not met"):
+		fallthrough
+	case strings.Contains(err.Error(), "Missing inputs"):
+		fallthrough
+	case strings.Contains(err.Error(), "already in block chain"):
+		return true
+
+	default:
+		return false
+	}
+}
+
 // ChainParams returns the network parameters for the blockchain the wallet
 // belongs to.
 func (w *Wallet) ChainParams() *chaincfg.Params {
@@ -3440,6 +4234,16 @@ func (w *Wallet) Database() walletdb.DB {
 func Create(db walletdb.DB, pubPass, privPass, seed []byte, params *chaincfg.Params,
 	birthday time.Time) error {
 
+	return CreateWithKDF(db, pubPass, privPass, seed, params, birthday, nil)
+}
+
+// CreateWithKDF creates a new wallet as Create does, deriving the keys
+// encrypting the wallet from the passphrases with the key derivation function
+// and costs of kdf.  The default scrypt costs are used when kdf is nil.
+func CreateWithKDF(db walletdb.DB, pubPass, privPass, seed []byte,
+	params *chaincfg.Params, birthday time.Time,
+	kdf *waddrmgr.ScryptOptions) error {
+
 	// If a seed was provided, ensure that it is of valid length. Otherwise,
 	// we generate a random seed for the wallet with the recommended seed
 	// length.
@@ -3467,16 +4271,34 @@ func Create(db walletdb.DB, pubPass, privPass, seed []byte, params *chaincfg.Par
 		}
 
 		err = waddrmgr.Create(
-			addrmgrNs, seed, pubPass, privPass, params, nil,
+			addrmgrNs, seed, pubPass, privPass, params, kdf,
 			birthday,
 		)
 		if err != nil {
 			return err
 		}
-		return wtxmgr.Create(txmgrNs)
+		err = wtxmgr.Create(txmgrNs)
+		if err != nil {
+			return err
+		}
+		return createOptionalNamespaces(tx)
 	})
 }
 
+// createOptionalNamespaces creates each optional namespace that does not yet
+// exist in the database.
+func createOptionalNamespaces(tx walletdb.ReadWriteTx) error {
+	for _, key := range optionalNamespaceKeys {
+		if tx.ReadWriteBucket(key) != nil {
+			continue
+		}
+		if _, err := tx.CreateTopLevelBucket(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Open loads an already-created wallet from the passed database and namespaces.
 func Open(db walletdb.DB, pubPass []byte, cbs *waddrmgr.OpenCallbacks,
 	params *chaincfg.Params, recoveryWindow uint32) (*Wallet, error) {
@@ -3507,20 +4329,48 @@ func Open(db walletdb.DB, pubPass []byte, cbs *waddrmgr.OpenCallbacks,
 	if err != nil {
 		return nil, err
 	}
+
+	// Encrypted transaction stores are decrypted with a key encrypted
+	// with the public passphrase, for the upgrades as well.
+	db, err = openTxStoreCrypto(db, pubPass)
+	if err != nil {
+		return nil, err
+	}
 	err = wtxmgr.DoUpgrades(db, wtxmgrNamespaceKey)
 	if err != nil {
 		return nil, err
 	}
+	err = walletdb.Update(db, createOptionalNamespaces)
+	if err != nil {
+		return nil, err
+	}
 
 	// Open database abstraction instances
 	var (
 		addrMgr *waddrmgr.Manager
 		txMgr   *wtxmgr.Store
+		opSeq   uint64
+		locked  map[wire.OutPoint]struct{}
+		frozen  map[uint32]FrozenAccount
+		wizard  *recoveryDerivations
 	)
 	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
 		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
 		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		opSeq = fetchOperationSequence(tx.ReadBucket(wtxmetaNamespaceKey))
 		var err error
+		locked, err = fetchLockedOutpoints(tx.ReadBucket(wtxmetaNamespaceKey))
+		if err != nil {
+			return err
+		}
+		frozen, err = fetchFrozenAccounts(tx.ReadBucket(wtxmetaNamespaceKey))
+		if err != nil {
+			return err
+		}
+		wizard, err = fetchRecoveryDerivations(tx.ReadBucket(wtxmetaNamespaceKey))
+		if err != nil {
+			return err
+		}
 		addrMgr, err = waddrmgr.Open(addrmgrNs, pubPass, params)
 		if err != nil {
 			return err
@@ -3538,7 +4388,7 @@ func Open(db walletdb.DB, pubPass []byte, cbs *waddrmgr.OpenCallbacks,
 		db:                  db,
 		Manager:             addrMgr,
 		TxStore:             txMgr,
-		lockedOutpoints:     map[wire.OutPoint]struct{}{},
+		lockedOutpoints:     locked,
 		recoveryWindow:      recoveryWindow,
 		rescanAddJob:        make(chan *RescanJob),
 		rescanBatch:         make(chan *rescanBatch),
@@ -3550,11 +4400,24 @@ func Open(db walletdb.DB, pubPass []byte, cbs *waddrmgr.OpenCallbacks,
 		lockRequests:        make(chan struct{}),
 		holdUnlockRequests:  make(chan chan heldUnlock),
 		lockState:           make(chan bool),
+		unlockExpirations:   make(chan unlockExpiration),
 		changePassphrase:    make(chan changePassphraseRequest),
 		changePassphrases:   make(chan changePassphrasesRequest),
 		chainParams:         params,
 		quit:                make(chan struct{}),
 	}
+	w.opSeq.seq = opSeq
+	w.freezes.frozen = frozen
+	if wizard != nil {
+		// Recoveries started with the recovery wizard resume with the
+		// chosen derivations until they are finalized.
+		w.recoveryWindow = wizard.recoveryWindow
+		w.wizardRecovery.derivations = wizard
+	}
+	w.emailDigest.changed = make(chan struct{}, 1)
+	w.heightTriggers.reached = make(chan struct{}, 1)
+	w.refill.check = make(chan struct{}, 1)
+	w.rescanBatching.target = DefaultRescanBatchLatency
 	w.NtfnServer = newNotificationServer(w)
 	w.TxStore.NotifyUnspent = func(hash *chainhash.Hash, index uint32) {
 		w.NtfnServer.notifyUnspentOutput(0, hash, index)
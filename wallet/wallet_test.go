@@ -0,0 +1,87 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+var (
+	testPubPass  = []byte("public")
+	testPrivPass = []byte("private")
+)
+
+// testWallet creates an unlocked offline wallet on the test network.  The
+// returned function unloads the wallet and removes its database.
+func testWallet(t *testing.T) (*Wallet, func()) {
+	dir, err := ioutil.TempDir("", "testwallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed, err := hdkeychain.GenerateSeed(hdkeychain.MinSeedBytes)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	loader := NewLoader(&chaincfg.TestNet3Params, dir, 250)
+	loader.SetKDF(&waddrmgr.ScryptOptions{N: 16, R: 8, P: 1})
+	w, err := loader.CreateNewWallet(testPubPass, testPrivPass, seed,
+		time.Now())
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	cleanup := func() {
+		loader.UnloadWallet()
+		os.RemoveAll(dir)
+	}
+
+	w.SetOffline(true)
+	if err := w.Unlock(testPrivPass, nil); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	return w, cleanup
+}
+
+// fundTestWallet credits a wallet with an unmined output of amount paying to
+// pkScript, and returns its outpoint.
+func fundTestWallet(t *testing.T, w *Wallet, pkScript []byte,
+	amount btcutil.Amount) wire.OutPoint {
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	prevOut := wire.NewOutPoint(&chainhash.Hash{1}, 0)
+	tx.AddTxIn(wire.NewTxIn(prevOut, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(int64(amount), pkScript))
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmgrNamespaceKey)
+		if err := w.TxStore.InsertTx(ns, rec, nil); err != nil {
+			return err
+		}
+		return w.TxStore.AddCredit(ns, rec, nil, 0, false)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wire.OutPoint{Hash: rec.Hash, Index: 0}
+}
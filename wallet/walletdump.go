@@ -0,0 +1,138 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/zero"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/walletdump"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// DumpWallet returns a wallet dump of the private keys of every active
+// address of the wallet, with the BIP0032 derivation paths of the HD keys.
+// The wallet must be unlocked.
+func (w *Wallet) DumpWallet() (*walletdump.Dump, error) {
+	syncBlock := w.Manager.SyncedTo()
+	d := &walletdump.Dump{
+		Created:    time.Now(),
+		BestHeight: syncBlock.Height,
+		BestHash:   syncBlock.Hash.String(),
+		BestTime:   syncBlock.Timestamp,
+	}
+	birthday := w.Manager.Birthday()
+
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+
+		fingerprint, err := w.Manager.MasterKeyFingerprint(addrmgrNs)
+		if err == nil {
+			d.MasterKeyFingerprint = &fingerprint
+		}
+
+		return w.Manager.ForEachActiveAddress(addrmgrNs, func(addr btcutil.Address) error {
+			ma, err := w.Manager.Address(addrmgrNs, addr)
+			if err != nil {
+				return err
+			}
+
+			// Only those addresses with keys are dumped.
+			pka, ok := ma.(waddrmgr.ManagedPubKeyAddress)
+			if !ok {
+				return nil
+			}
+			wif, err := pka.ExportPrivKey()
+			if err != nil {
+				return err
+			}
+
+			key := walletdump.Key{
+				WIF:     wif.String(),
+				Time:    birthday,
+				Change:  pka.Internal(),
+				Address: addr.EncodeAddress(),
+			}
+			zero.BigInt(wif.PrivKey.D)
+			manager, account, err := w.Manager.AddrAccount(addrmgrNs, addr)
+			if err != nil {
+				return err
+			}
+			key.Label, err = manager.AccountName(addrmgrNs, account)
+			if err != nil {
+				return err
+			}
+			if _, path, ok := pka.DerivationInfo(); ok {
+				acctPath, err := manager.AccountDerivationPath(
+					addrmgrNs, path.Account,
+				)
+				if err != nil {
+					return err
+				}
+				key.HDKeyPath = append(acctPath, path.Branch,
+					path.Index)
+			}
+
+			d.Keys = append(d.Keys, key)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.audit(AuditKeyExport, "wallet dump of %d private keys exported",
+		len(d.Keys))
+	return d, nil
+}
+
+// ImportWallet imports the private keys of a wallet dump to the imported
+// account, and rescans the blockchain for them from the block of the
+// earliest key time.  Keys already in the wallet, such as those of a dump of
+// this wallet, are skipped.  No key is imported unless every key is a valid
+// private key of the active network.  The number of imported keys is
+// returned, along with an error when some of the keys failed to import.
+func (w *Wallet) ImportWallet(keys []walletdump.Key, rescan bool) (int, error) {
+	imports := make([]Import, len(keys))
+	for i, k := range keys {
+		wif, err := btcutil.DecodeWIF(k.WIF)
+		if err != nil {
+			return 0, fmt.Errorf("invalid private key of address "+
+				"%s: %v", k.Address, err)
+		}
+		if !wif.IsForNet(w.chainParams) {
+			return 0, fmt.Errorf("private key of address %s is "+
+				"not for the %s network", k.Address,
+				w.chainParams.Name)
+		}
+		imports[i] = Import{WIF: wif, Timestamp: k.Time}
+	}
+
+	errs, err := w.ImportMulti(waddrmgr.KeyScopeBIP0044, imports, rescan)
+	if err != nil {
+		return 0, err
+	}
+	var imported, failed int
+	var firstErr error
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			imported++
+		case waddrmgr.IsError(err, waddrmgr.ErrDuplicateAddress):
+		default:
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+		}
+	}
+	if failed != 0 {
+		return imported, fmt.Errorf("failed to import %d keys: %v",
+			failed, firstErr)
+	}
+	return imported, nil
+}
@@ -0,0 +1,272 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package walletdump reads and writes the plain text wallet dump files of the
+// dumpwallet and importwallet commands of Bitcoin Core.
+//
+// Each key is written on its own line as the WIF-encoded private key, the
+// time the key was created in ISO 8601 format and a label=<label>,
+// change=1 or reserve=1 field, followed by a comment holding the address of
+// the key and, for HD keys, its BIP0032 derivation path from the master key:
+//
+//	<wif> 2018-06-01T12:00:00Z label=default # addr=<address> hdkeypath=m/44'/0'/0'/0/3
+package walletdump
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcwallet/wallet/descriptor"
+)
+
+// timeFormat is the ISO 8601 format of the times of dump files.
+const timeFormat = "2006-01-02T15:04:05Z"
+
+// Key is a private key of a wallet dump.
+type Key struct {
+	// WIF is the WIF-encoded private key.
+	WIF string
+
+	// Time is the time the key was created.  Rescans of imported keys
+	// start at the block of this time.
+	Time time.Time
+
+	// Label is the label, or account name, of keys that are neither
+	// change nor reserve keys.
+	Label string
+
+	// Change is set for keys of the internal branch of an account.
+	Change bool
+
+	// Reserve is set for unused keys of the external branch.
+	Reserve bool
+
+	// Address is the encoded address of the key, as written in the
+	// comment of its line.
+	Address string
+
+	// HDKeyPath is the BIP0032 derivation path of the key from the master
+	// key, or nil for imported keys.
+	HDKeyPath []uint32
+}
+
+// Dump is the contents of a wallet dump file.
+type Dump struct {
+	// Created is the time the dump was created.
+	Created time.Time
+
+	// BestHeight, BestHash and BestTime describe the block the wallet was
+	// synced to when the dump was created.
+	BestHeight int32
+	BestHash   string
+	BestTime   time.Time
+
+	// MasterKeyFingerprint is the fingerprint of the master key the
+	// HDKeyPath of the keys are derived from, when known.
+	MasterKeyFingerprint *[4]byte
+
+	Keys []Key
+}
+
+// Write writes a wallet dump to w.
+func Write(w io.Writer, d *Dump) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# Wallet dump created by btcwallet\n")
+	fmt.Fprintf(bw, "# * Created on %s\n", formatTime(d.Created))
+	fmt.Fprintf(bw, "# * Best block at time of backup was %d (%s),\n",
+		d.BestHeight, d.BestHash)
+	fmt.Fprintf(bw, "#   mined on %s\n", formatTime(d.BestTime))
+	if d.MasterKeyFingerprint != nil {
+		fmt.Fprintf(bw, "# * Master key fingerprint: %x\n",
+			d.MasterKeyFingerprint[:])
+	}
+	bw.WriteString("\n")
+
+	for _, k := range d.Keys {
+		fmt.Fprintf(bw, "%s %s ", k.WIF, formatTime(k.Time))
+		switch {
+		case k.Change:
+			bw.WriteString("change=1")
+		case k.Reserve:
+			bw.WriteString("reserve=1")
+		default:
+			fmt.Fprintf(bw, "label=%s", encodeString(k.Label))
+		}
+		fmt.Fprintf(bw, " # addr=%s", k.Address)
+		if k.HDKeyPath != nil {
+			fmt.Fprintf(bw, " hdkeypath=%s",
+				descriptor.FormatPath(k.HDKeyPath))
+		}
+		bw.WriteString("\n")
+	}
+
+	bw.WriteString("\n# End of dump\n")
+	return bw.Flush()
+}
+
+// Parse reads the keys of a wallet dump.  Scripts, which are dumped with a
+// script=1 field, are skipped, as are comments and unknown fields.
+func Parse(r io.Reader) ([]Key, error) {
+	var keys []Key
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		// As bitcoind does, the comment starts at the first field
+		// beginning with #, so labels may contain the character.
+		fields := strings.Fields(line)
+		var comment []string
+		for i, field := range fields {
+			if field[0] == '#' {
+				fields, comment = fields[:i], fields[i:]
+				comment[0] = comment[0][1:]
+				break
+			}
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: missing key time",
+				lineNum)
+		}
+
+		// Scripts are written with a time of 0 when it is unknown.
+		script := false
+		for _, field := range fields[2:] {
+			if field == "script=1" {
+				script = true
+			}
+		}
+		if script {
+			continue
+		}
+
+		t, err := parseTime(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid key time %q",
+				lineNum, fields[1])
+		}
+		k := Key{WIF: fields[0], Time: t}
+		for _, field := range fields[2:] {
+			switch {
+			case field == "change=1":
+				k.Change = true
+			case field == "reserve=1":
+				k.Reserve = true
+			case strings.HasPrefix(field, "label="):
+				k.Label, err = decodeString(field[len("label="):])
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v",
+						lineNum, err)
+				}
+			}
+		}
+
+		// The comment is informational, and derivation paths of other
+		// software that are not BIP0032 paths are ignored.
+		for _, field := range comment {
+			switch {
+			case strings.HasPrefix(field, "addr="):
+				k.Address = field[len("addr="):]
+			case strings.HasPrefix(field, "hdkeypath="):
+				path, err := descriptor.ParsePath(field[len("hdkeypath="):])
+				if err == nil && path != nil {
+					k.HDKeyPath = path
+				}
+			}
+		}
+
+		keys = append(keys, k)
+	}
+	return keys, scanner.Err()
+}
+
+// WriteFile writes a wallet dump to a new file readable only by its owner.
+// Existing files are not overwritten.
+func WriteFile(path string, d *Dump) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, d); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadFile reads the keys of a wallet dump file.
+func ReadFile(path string) ([]Key, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// formatTime formats a time as in dump files.  Unknown times are written as
+// the first second of the Unix epoch, as Bitcoin Core does.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Unix(1, 0)
+	}
+	return t.UTC().Format(timeFormat)
+}
+
+// parseTime parses a time of a dump file.  The times written by
+// formatTime for unknown times are parsed as the zero time.
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(timeFormat, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if t.Unix() <= 1 {
+		return time.Time{}, nil
+	}
+	return t, nil
+}
+
+// encodeString percent-encodes the spaces, control characters, non-ASCII
+// bytes and percent signs of a label so that it is a single field.
+func encodeString(s string) string {
+	var b bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x80 || c == '%' {
+			fmt.Fprintf(&b, "%%%02x", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// decodeString decodes a label encoded by encodeString.
+func decodeString(s string) (string, error) {
+	var b bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", fmt.Errorf("truncated escape in label %q", s)
+		}
+		c, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in label %q", s)
+		}
+		b.WriteByte(c[0])
+		i += 2
+	}
+	return b.String(), nil
+}
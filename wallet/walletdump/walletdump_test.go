@@ -0,0 +1,118 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package walletdump
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+const hardened = hdkeychain.HardenedKeyStart
+
+// coreDump is an excerpt of a wallet dump written by bitcoind.
+const coreDump = `# Wallet dump created by Bitcoin v0.16.0
+# * Created on 2018-06-01T12:00:00Z
+# * Best block at time of backup was 525000 (0000000000000000000a),
+#   mined on 2018-06-01T11:50:00Z
+
+# extended private masterkey: xprv9s21ZrQH143K
+
+L1aW4aubDFB7yfras2S1mN3bqg9nwySY8nkoLmJebSLD5BWv3ENZ 2018-05-01T10:00:00Z hdseed=1 # addr=1J1mEmNL5LHedfL9YC7wGJYzMpkDk8kFWB hdkeypath=s
+KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn 2018-05-01T10:00:00Z label=my%20savings%25 # addr=1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH hdkeypath=m/0'/0'/1'
+KxFC1jmwwCoACiCAWZ3eXa96mBM6tb3TYzGmf6YwgdGWZgawvrtJ 1970-01-01T00:00:01Z change=1 # addr=1cMh228HTCiwS8ZsaakH8A8wze1JR5ZsP
+0014751e76e8199196d454941c45d1b3a323f1433bd6 0 script=1 # addr=bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4
+`
+
+func TestParseCore(t *testing.T) {
+	keys, err := Parse(strings.NewReader(coreDump))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []Key{
+		{
+			WIF:     "L1aW4aubDFB7yfras2S1mN3bqg9nwySY8nkoLmJebSLD5BWv3ENZ",
+			Time:    time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC),
+			Address: "1J1mEmNL5LHedfL9YC7wGJYzMpkDk8kFWB",
+		},
+		{
+			WIF:       "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn",
+			Time:      time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC),
+			Label:     "my savings%",
+			Address:   "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH",
+			HDKeyPath: []uint32{hardened, hardened, hardened + 1},
+		},
+		{
+			WIF:     "KxFC1jmwwCoACiCAWZ3eXa96mBM6tb3TYzGmf6YwgdGWZgawvrtJ",
+			Change:  true,
+			Address: "1cMh228HTCiwS8ZsaakH8A8wze1JR5ZsP",
+		},
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("Parse returned\n%+v\nwant\n%+v", keys, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	fingerprint := [4]byte{0xd3, 0x4d, 0xb3, 0x3f}
+	d := &Dump{
+		Created:              time.Date(2018, 6, 2, 0, 0, 0, 0, time.UTC),
+		BestHeight:           1000,
+		BestHash:             "00000000000000000001",
+		BestTime:             time.Date(2018, 6, 1, 23, 50, 0, 0, time.UTC),
+		MasterKeyFingerprint: &fingerprint,
+		Keys: []Key{
+			{
+				WIF:       "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn",
+				Time:      time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC),
+				Label:     "cold #1 é",
+				Address:   "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH",
+				HDKeyPath: []uint32{hardened + 44, hardened, hardened, 0, 3},
+			},
+			{
+				WIF:     "KxFC1jmwwCoACiCAWZ3eXa96mBM6tb3TYzGmf6YwgdGWZgawvrtJ",
+				Label:   "imported",
+				Address: "1cMh228HTCiwS8ZsaakH8A8wze1JR5ZsP",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, d); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !strings.Contains(buf.String(), "# * Master key fingerprint: d34db33f\n") {
+		t.Errorf("dump is missing the master key fingerprint:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), " label=cold%20#1%20%c3%a9 # addr=1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH hdkeypath=m/44'/0'/0'/0/3\n") {
+		t.Errorf("dump has unexpected key lines:\n%s", buf.String())
+	}
+
+	keys, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !reflect.DeepEqual(keys, d.Keys) {
+		t.Errorf("Parse returned\n%+v\nwant\n%+v", keys, d.Keys)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn\n",
+		"KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn 2018-05-01 label=\n",
+		"KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn 2018-05-01T10:00:00Z label=a%2\n",
+		"KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn 2018-05-01T10:00:00Z label=a%zz\n",
+	}
+	for _, test := range tests {
+		if _, err := Parse(strings.NewReader(test)); err == nil {
+			t.Errorf("Parse(%q) did not fail", test)
+		}
+	}
+}
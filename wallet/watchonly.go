@@ -0,0 +1,167 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/taproot"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// The watch-only store records the addresses imported without a private key
+// or script.  Outputs paying to them are credited to the account they were
+// imported to, but are never spendable by the wallet.  Entries are keyed by
+// the output script of the address, and values are the key scope purpose,
+// coin type and account number, each as a little-endian uint32.
+
+// errNotWatched is returned when an address is not in the watch-only store.
+var errNotWatched = errors.New("address is not watched")
+
+// putWatchedAddress adds an output script to the watch-only store.
+func putWatchedAddress(ns walletdb.ReadWriteBucket, pkScript []byte,
+	scope waddrmgr.KeyScope, account uint32) error {
+
+	var v [12]byte
+	binary.LittleEndian.PutUint32(v[0:4], scope.Purpose)
+	binary.LittleEndian.PutUint32(v[4:8], scope.Coin)
+	binary.LittleEndian.PutUint32(v[8:12], account)
+	return ns.Put(pkScript, v[:])
+}
+
+// fetchWatchedAccount returns the key scope and account of a watched output
+// script.
+func fetchWatchedAccount(ns walletdb.ReadBucket, pkScript []byte) (waddrmgr.KeyScope, uint32, error) {
+	v := ns.Get(pkScript)
+	if len(v) != 12 {
+		return waddrmgr.KeyScope{}, 0, errNotWatched
+	}
+	scope := waddrmgr.KeyScope{
+		Purpose: binary.LittleEndian.Uint32(v[0:4]),
+		Coin:    binary.LittleEndian.Uint32(v[4:8]),
+	}
+	return scope, binary.LittleEndian.Uint32(v[8:12]), nil
+}
+
+// watchedAddressAccount returns the key scope and account of a watched
+// address.
+func watchedAddressAccount(ns walletdb.ReadBucket, addr btcutil.Address) (waddrmgr.KeyScope, uint32, error) {
+	pkScript, err := taproot.PayToAddrScript(addr)
+	if err != nil {
+		return waddrmgr.KeyScope{}, 0, errNotWatched
+	}
+	return fetchWatchedAccount(ns, pkScript)
+}
+
+// forEachWatchedAddress calls fn with every address in the watch-only store.
+func forEachWatchedAddress(ns walletdb.ReadBucket, params *chaincfg.Params,
+	fn func(btcutil.Address) error) error {
+
+	return ns.ForEach(func(k, v []byte) error {
+		_, addrs, _, err := taproot.ExtractPkScriptAddrs(k, params)
+		if err != nil || len(addrs) != 1 {
+			return nil
+		}
+		return fn(addrs[0])
+	})
+}
+
+// ImportAddress adds an address to an account without its private key or
+// script.  Outputs paying to the address are included in the balance and
+// transactions of the account, but are reported as watch-only and never
+// spent by the wallet.  When rescan is true, the blockchain is rescanned for
+// outputs paying to the address from the genesis block.
+func (w *Wallet) ImportAddress(scope waddrmgr.KeyScope, addr btcutil.Address,
+	account uint32, rescan bool) error {
+
+	if err := w.importAddress(scope, addr, account); err != nil {
+		return err
+	}
+
+	if rescan {
+		job := &RescanJob{
+			Addrs: []btcutil.Address{addr},
+			BlockStamp: waddrmgr.BlockStamp{
+				Hash:   *w.chainParams.GenesisHash,
+				Height: 0,
+			},
+		}
+
+		// Submit rescan job and log when the import has completed.
+		// Do not block on finishing the rescan.
+		_ = w.SubmitRescan(job)
+	} else if chainClient := w.ChainClient(); chainClient != nil {
+		err := chainClient.NotifyReceived([]btcutil.Address{addr})
+		if err != nil {
+			return fmt.Errorf("failed to subscribe for address ntfns "+
+				"for address %s: %v", addr.EncodeAddress(), err)
+		}
+	}
+	return nil
+}
+
+// importAddress adds a watch-only address to an account, without rescanning
+// or watching the address.
+func (w *Wallet) importAddress(scope waddrmgr.KeyScope, addr btcutil.Address,
+	account uint32) error {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+	pkScript, err := taproot.PayToAddrScript(addr)
+	if err != nil {
+		return err
+	}
+
+	var props *waddrmgr.AccountProperties
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		watchNs := tx.ReadWriteBucket(wwatchNamespaceKey)
+
+		// Addresses already controlled by the wallet, or watched by
+		// any account, may not be imported again.
+		_, err := w.addrAccount(tx, addr)
+		if err == nil {
+			str := fmt.Sprintf("address %v already exists", addr)
+			return waddrmgr.ManagerError{
+				ErrorCode:   waddrmgr.ErrDuplicateAddress,
+				Description: str,
+			}
+		}
+		if !waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+			return err
+		}
+
+		props, err = manager.AccountProperties(addrmgrNs, account)
+		if err != nil {
+			return err
+		}
+		return putWatchedAddress(watchNs, pkScript, scope, account)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Imported watch-only address %s to account %s",
+		addr.EncodeAddress(), props.AccountName)
+	w.audit(AuditImport, "watch-only address %s imported to account %s",
+		addr, props.AccountName)
+
+	w.NtfnServer.notifyAccountProperties(props)
+	return nil
+}
+
+// isWatchedScript returns whether an output script pays to a watch-only
+// address.
+func isWatchedScript(dbtx walletdb.ReadTx, pkScript []byte) bool {
+	_, _, err := fetchWatchedAccount(dbtx.ReadBucket(wwatchNamespaceKey), pkScript)
+	return err == nil
+}
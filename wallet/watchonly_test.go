@@ -0,0 +1,99 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+// TestWatchedAddresses checks that watch-only addresses are stored with the
+// account they were imported to.
+func TestWatchedAddresses(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watchonly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := walletdb.Create("bdb", filepath.Join(dir, walletDbName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	params := &chaincfg.TestNet3Params
+	watched, err := btcutil.NewAddressPubKeyHash(make([]byte, 20), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(watched)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = walletdb.Update(db, func(dbtx walletdb.ReadWriteTx) error {
+		ns, err := dbtx.CreateTopLevelBucket(wwatchNamespaceKey)
+		if err != nil {
+			return err
+		}
+		return putWatchedAddress(ns, pkScript, waddrmgr.KeyScopeBIP0044, 3)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = walletdb.View(db, func(dbtx walletdb.ReadTx) error {
+		ns := dbtx.ReadBucket(wwatchNamespaceKey)
+
+		scope, account, err := watchedAddressAccount(ns, watched)
+		if err != nil {
+			t.Fatalf("watched address not found: %v", err)
+		}
+		if scope != waddrmgr.KeyScopeBIP0044 || account != 3 {
+			t.Errorf("got scope %v account %d, want %v account 3",
+				scope, account, waddrmgr.KeyScopeBIP0044)
+		}
+
+		// An address with the same hash but another output script is
+		// not watched.
+		if _, _, err := watchedAddressAccount(ns, other); err != errNotWatched {
+			t.Errorf("got error %v for unwatched address, want %v",
+				err, errNotWatched)
+		}
+		if !isWatchedScript(dbtx, pkScript) {
+			t.Errorf("output script %x is not watched", pkScript)
+		}
+
+		var addrs []btcutil.Address
+		err = forEachWatchedAddress(ns, params, func(addr btcutil.Address) error {
+			addrs = append(addrs, addr)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(addrs) != 1 || addrs[0].EncodeAddress() != watched.EncodeAddress() {
+			t.Errorf("got watched addresses %v, want [%v]", addrs, watched)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/btcsuite/btcwallet/internal/prompt"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/wallet/hwi"
 	"github.com/btcsuite/btcwallet/walletdb"
 	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
 )
@@ -101,8 +102,25 @@ func convertLegacyKeystore(legacyKeyStore *keystore.Store, w *wallet.Wallet) err
 // and generates the wallet accordingly.  The new wallet will reside at the
 // provided path.
 func createWallet(cfg *config) error {
+	if cfg.Bootstrap != "" {
+		bootstrap, err := wallet.ReadBootstrapFile(cfg.Bootstrap)
+		if err != nil {
+			return err
+		}
+		return createWatchOnlyWallet(cfg, bootstrap)
+	}
+	if cfg.HWI != "" {
+		bootstrap, err := hardwareWalletBootstrap(cfg)
+		if err != nil {
+			return err
+		}
+		return createWatchOnlyWallet(cfg, bootstrap)
+	}
+
 	dbDir := networkDir(cfg.AppDataDir.Value, activeNet.Params)
 	loader := wallet.NewLoader(activeNet.Params, dbDir, 250)
+	loader.SetKDF(kdfOptions())
+	loader.SetEncryptTxStore(cfg.EncryptTxStore)
 	interactive := len(cfg.PassPhrase) == 0
 
 	// When there is a legacy keystore, open it now to ensure any errors
@@ -197,7 +215,14 @@ func createWallet(cfg *config) error {
 	// Ascertain the wallet generation seed.  This will either be an
 	// automatically generated value the user has already confirmed or a
 	// value the user has entered which has already been validated.
-	seed, err := prompt.Seed(reader)
+	var threshold, count int
+	if cfg.SeedShares != "" {
+		threshold, count, err = parseSeedShares(cfg.SeedShares)
+		if err != nil {
+			return err
+		}
+	}
+	seed, err := prompt.Seed(reader, threshold, count)
 	if err != nil {
 		return err
 	}
@@ -213,6 +238,60 @@ func createWallet(cfg *config) error {
 	return nil
 }
 
+// hardwareWalletBootstrap returns the bootstrap of a watching-only wallet for
+// account 0 of the BIP0084 key scope of the --hwi hardware wallet.  The
+// account is expected to be new, so there are no outputs to reconcile.
+func hardwareWalletBootstrap(cfg *config) (*wallet.Bootstrap, error) {
+	device, err := hwi.New(cfg.HWI, cfg.HWIFingerprint, activeNet.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Reading the account key from the hardware wallet...")
+	coin := activeNet.Params.HDCoinType
+	xpub, err := device.AccountXpub(fmt.Sprintf("m/84h/%dh/0h", coin))
+	if err != nil {
+		return nil, err
+	}
+	desc := fmt.Sprintf("wpkh([%s/84'/%d'/0']%s/<0;1>/*)",
+		device.Fingerprint(), coin, xpub)
+	return &wallet.Bootstrap{
+		Descriptor: desc,
+		Timestamp:  time.Now().Unix(),
+	}, nil
+}
+
+// createWatchOnlyWallet creates a watching-only wallet from a bootstrap,
+// either read from the file of the --bootstrap option or created for the
+// --hwi hardware wallet.  Only the public passphrase is needed since the
+// wallet has no private keys.  It is prompted for unless --walletpass is set.
+func createWatchOnlyWallet(cfg *config, bootstrap *wallet.Bootstrap) error {
+	var err error
+	pubPass := []byte(cfg.WalletPass)
+	if len(pubPass) == 0 {
+		reader := bufio.NewReader(os.Stdin)
+		pubPass, err = prompt.PublicPass(reader, nil,
+			[]byte(wallet.InsecurePubPassphrase), nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	dbDir := networkDir(cfg.AppDataDir.Value, activeNet.Params)
+	loader := wallet.NewLoader(activeNet.Params, dbDir, 0)
+
+	fmt.Println("Creating the watching-only wallet...")
+	w, err := loader.CreateWatchOnlyWallet(pubPass, bootstrap)
+	if err != nil {
+		return err
+	}
+
+	w.Manager.Close()
+	fmt.Printf("The watching-only wallet has been created successfully "+
+		"with %d outputs to reconcile.\n", len(bootstrap.OutPoints))
+	return nil
+}
+
 // createSimulationWallet is intended to be called from the rpcclient
 // and used to create a wallet for actors involved in simulations.
 func createSimulationWallet(cfg *config) error {
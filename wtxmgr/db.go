@@ -77,6 +77,14 @@ var (
 	bucketUnminedInputs  = []byte("mi")
 )
 
+// IsCreditBucket returns whether the key names a bucket of the transaction
+// store namespace which records credits, the outputs received by the wallet.
+func IsCreditBucket(key []byte) bool {
+	return bytes.Equal(key, bucketCredits) ||
+		bytes.Equal(key, bucketUnspent) ||
+		bytes.Equal(key, bucketUnminedCredits)
+}
+
 // Root (namespace) bucket keys
 var (
 	rootCreateDate   = []byte("date")
@@ -176,6 +184,26 @@ func appendRawBlockRecord(v []byte, txHash *chainhash.Hash) ([]byte, error) {
 	return newv, nil
 }
 
+// removeRawBlockRecord returns a new block record value without a transaction
+// hash and with a decremented number of transactions.
+func removeRawBlockRecord(v []byte, txHash *chainhash.Hash) ([]byte, error) {
+	if len(v) < 44 {
+		str := fmt.Sprintf("%s: short read (expected %d bytes, read %d)",
+			bucketBlocks, 44, len(v))
+		return nil, storeError(ErrData, str, nil)
+	}
+	newv := make([]byte, 44, len(v))
+	copy(newv, v[:44])
+	for off := 44; off+chainhash.HashSize <= len(v); off += chainhash.HashSize {
+		if !bytes.Equal(v[off:off+chainhash.HashSize], txHash[:]) {
+			newv = append(newv, v[off:off+chainhash.HashSize]...)
+		}
+	}
+	n := uint32((len(newv) - 44) / chainhash.HashSize)
+	byteOrder.PutUint32(newv[40:44], n)
+	return newv, nil
+}
+
 func putRawBlockRecord(ns walletdb.ReadWriteBucket, k, v []byte) error {
 	err := ns.NestedReadWriteBucket(bucketBlocks).Put(k, v)
 	if err != nil {
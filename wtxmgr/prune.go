@@ -0,0 +1,85 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wtxmgr
+
+import (
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// PruneTx removes a mined transaction, with its credits and debits, from the
+// store to reclaim its space.  Only transactions that no longer affect the
+// balance may be pruned: the transaction must be mined at or below maxHeight,
+// and every credit of the transaction must be spent by a transaction also
+// mined at or below maxHeight, so that neither is expected to be rolled back.
+// Whether the transaction was pruned is returned.
+//
+// The debits of later transactions spending the credits of a pruned
+// transaction keep their amounts, but their previous outputs are no longer
+// known to the store.
+func (s *Store) PruneTx(ns walletdb.ReadWriteBucket, txHash *chainhash.Hash,
+	block *Block, maxHeight int32) (bool, error) {
+
+	if block.Height < 0 || block.Height > maxHeight {
+		return false, nil
+	}
+	recKey := keyTxRecord(txHash, block)
+	if existsRawTxRecord(ns, recKey) == nil {
+		return false, nil
+	}
+
+	// The keys are collected first, since the buckets may not be modified
+	// by their iterators.
+	var credKeys, debKeys [][]byte
+	credIter := makeReadCreditIterator(ns, recKey)
+	for credIter.next() {
+		v := credIter.cv
+		if len(v) < 81 || v[8]&(1<<0) == 0 {
+			return false, nil
+		}
+		spenderHeight := int32(byteOrder.Uint32(v[41:45]))
+		if spenderHeight > maxHeight {
+			return false, nil
+		}
+		credKeys = append(credKeys, append([]byte(nil), credIter.ck...))
+	}
+	if credIter.err != nil {
+		return false, credIter.err
+	}
+	debIter := makeReadDebitIterator(ns, recKey)
+	for debIter.next() {
+		debKeys = append(debKeys, append([]byte(nil), debIter.ck...))
+	}
+	if debIter.err != nil {
+		return false, debIter.err
+	}
+
+	for _, k := range credKeys {
+		if err := deleteRawCredit(ns, k); err != nil {
+			return false, err
+		}
+	}
+	for _, k := range debKeys {
+		if err := deleteRawDebit(ns, k); err != nil {
+			return false, err
+		}
+	}
+	if err := deleteTxRecord(ns, txHash, block); err != nil {
+		return false, err
+	}
+
+	blockKey, blockVal := existsBlockRecord(ns, block.Height)
+	if blockVal == nil {
+		return true, nil
+	}
+	newVal, err := removeRawBlockRecord(blockVal, txHash)
+	if err != nil {
+		return false, err
+	}
+	if len(newVal) == 44 {
+		return true, deleteBlockRecord(ns, block.Height)
+	}
+	return true, putRawBlockRecord(ns, blockKey, newVal)
+}
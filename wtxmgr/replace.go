@@ -0,0 +1,170 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wtxmgr
+
+import (
+	"bytes"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// Buckets recording the BIP0125 replacements of unmined transactions.  They
+// are created by the first replacement, so stores without any replacement
+// are unchanged.
+var (
+	// bucketReplacedBy maps the hash of a replaced transaction to the hash
+	// of its replacement.
+	bucketReplacedBy = []byte("rb")
+
+	// bucketReplaces maps the hash of a replacement transaction to the
+	// hash of the transaction it replaced.
+	bucketReplaces = []byte("rp")
+)
+
+// ReplaceUnminedTx replaces an unmined transaction by a transaction spending
+// some of the same outputs, such as the same transaction with a higher fee.
+// The replaced transaction, and every unmined transaction spending its
+// outputs, is removed from the store and the replacement is inserted as
+// unmined.  The replacement is recorded, so the chain of replacements of a
+// transaction can be followed with Replaces and ReplacedBy.  Credits of the
+// replacement must be added by the caller.
+func (s *Store) ReplaceUnminedTx(ns walletdb.ReadWriteBucket, replaced,
+	replacement *TxRecord) error {
+
+	if existsRawUnmined(ns, replaced.Hash[:]) == nil {
+		str := "replaced transaction is not unmined"
+		return storeError(ErrInput, str, nil)
+	}
+	if err := s.removeConflict(ns, replaced); err != nil {
+		return err
+	}
+
+	// The replacement may have been inserted already, when its relay was
+	// notified before it was recorded.  The spends of the outputs shared
+	// with the replaced transaction were then deleted with it, and are
+	// restored.
+	if existsRawUnmined(ns, replacement.Hash[:]) == nil {
+		if err := s.insertMemPoolTx(ns, replacement); err != nil {
+			return err
+		}
+	} else {
+		for _, input := range replacement.MsgTx.TxIn {
+			prevOut := &input.PreviousOutPoint
+			k := canonicalOutPoint(&prevOut.Hash, prevOut.Index)
+			spends := existsRawUnminedInput(ns, k)
+			if spendsTx(spends, &replacement.Hash) {
+				continue
+			}
+			err := putRawUnminedInput(ns, k, replacement.Hash[:])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return putReplacement(ns, &replaced.Hash, &replacement.Hash)
+}
+
+// spendsTx returns whether the serialized spender hashes of an unmined input
+// include txHash.
+func spendsTx(spendTxHashes []byte, txHash *chainhash.Hash) bool {
+	for len(spendTxHashes) >= chainhash.HashSize {
+		if bytes.Equal(spendTxHashes[:chainhash.HashSize], txHash[:]) {
+			return true
+		}
+		spendTxHashes = spendTxHashes[chainhash.HashSize:]
+	}
+	return false
+}
+
+func putReplacement(ns walletdb.ReadWriteBucket, replaced,
+	replacement *chainhash.Hash) error {
+
+	replacedBy, err := ns.CreateBucketIfNotExists(bucketReplacedBy)
+	if err != nil {
+		str := "failed to create replacement bucket"
+		return storeError(ErrDatabase, str, err)
+	}
+	replaces, err := ns.CreateBucketIfNotExists(bucketReplaces)
+	if err != nil {
+		str := "failed to create replacement bucket"
+		return storeError(ErrDatabase, str, err)
+	}
+	if err := replacedBy.Put(replaced[:], replacement[:]); err != nil {
+		str := "failed to put replacement"
+		return storeError(ErrDatabase, str, err)
+	}
+	if err := replaces.Put(replacement[:], replaced[:]); err != nil {
+		str := "failed to put replacement"
+		return storeError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+func fetchReplacement(ns walletdb.ReadBucket, bucketKey []byte,
+	txHash *chainhash.Hash) *chainhash.Hash {
+
+	bucket := ns.NestedReadBucket(bucketKey)
+	if bucket == nil {
+		return nil
+	}
+	v := bucket.Get(txHash[:])
+	if len(v) != chainhash.HashSize {
+		return nil
+	}
+	var hash chainhash.Hash
+	copy(hash[:], v)
+	return &hash
+}
+
+// ReplacedBy returns the hash of the transaction which replaced a transaction,
+// or nil if it was not replaced.
+func (s *Store) ReplacedBy(ns walletdb.ReadBucket, txHash *chainhash.Hash) *chainhash.Hash {
+	return fetchReplacement(ns, bucketReplacedBy, txHash)
+}
+
+// Replaces returns the hash of the transaction replaced by a transaction, or
+// nil if it is not a replacement.
+func (s *Store) Replaces(ns walletdb.ReadBucket, txHash *chainhash.Hash) *chainhash.Hash {
+	return fetchReplacement(ns, bucketReplaces, txHash)
+}
+
+// ReplacementChain returns the hashes of the chain of replacements including
+// a transaction, from the first transaction replaced to the last replacement.
+// The chain of a transaction which was never replaced, nor replaces another,
+// only includes the transaction.
+func (s *Store) ReplacementChain(ns walletdb.ReadBucket, txHash *chainhash.Hash) []chainhash.Hash {
+	// The walks stop at transactions already seen, so corrupted records
+	// cycling back into the chain cannot loop forever.
+	seen := map[chainhash.Hash]struct{}{*txHash: {}}
+	first := *txHash
+	for {
+		prev := s.Replaces(ns, &first)
+		if prev == nil {
+			break
+		}
+		if _, ok := seen[*prev]; ok {
+			break
+		}
+		seen[*prev] = struct{}{}
+		first = *prev
+	}
+
+	chain := []chainhash.Hash{first}
+	seen = map[chainhash.Hash]struct{}{first: {}}
+	for {
+		next := s.ReplacedBy(ns, &chain[len(chain)-1])
+		if next == nil {
+			break
+		}
+		if _, ok := seen[*next]; ok {
+			break
+		}
+		seen[*next] = struct{}{}
+		chain = append(chain, *next)
+	}
+	return chain
+}
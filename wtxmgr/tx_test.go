@@ -1945,3 +1945,244 @@ func TestAddDuplicateCreditAfterConfirm(t *testing.T) {
 		}
 	})
 }
+
+// TestPruneTx checks that only mined transactions whose credits are all spent
+// by mined transactions below the pruning height are pruned from the store.
+func TestPruneTx(t *testing.T) {
+	t.Parallel()
+
+	store, db, teardown, err := testStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardown()
+
+	b100 := &BlockMeta{
+		Block: Block{Height: 100},
+		Time:  time.Now(),
+	}
+	cb := newCoinBase(1e8)
+	cbRec, err := NewTxRecordFromMsgTx(cb, b100.Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b101 := &BlockMeta{
+		Block: Block{Height: 101},
+		Time:  time.Now(),
+	}
+	spendTx := spendOutput(&cbRec.Hash, 0, 5e7, 4e7)
+	spendTxRec, err := NewTxRecordFromMsgTx(spendTx, b101.Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		if err := store.InsertTx(ns, cbRec, b100); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.AddCredit(ns, cbRec, b100, 0, false); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.InsertTx(ns, spendTxRec, b101); err != nil {
+			t.Fatal(err)
+		}
+		err := store.AddCredit(ns, spendTxRec, b101, 1, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		// The coinbase is spent at a height above the pruning height.
+		pruned, err := store.PruneTx(ns, &cbRec.Hash, &b100.Block, 100)
+		if err != nil || pruned {
+			t.Fatalf("PruneTx returned %v, %v, want false", pruned, err)
+		}
+
+		// The change output of the spending transaction is unspent.
+		pruned, err = store.PruneTx(ns, &spendTxRec.Hash, &b101.Block, 101)
+		if err != nil || pruned {
+			t.Fatalf("PruneTx returned %v, %v, want false", pruned, err)
+		}
+
+		pruned, err = store.PruneTx(ns, &cbRec.Hash, &b100.Block, 101)
+		if err != nil || !pruned {
+			t.Fatalf("PruneTx returned %v, %v, want true", pruned, err)
+		}
+		details, err := store.TxDetails(ns, &cbRec.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details != nil {
+			t.Fatalf("pruned transaction is still stored")
+		}
+		// The block record emptied by pruning is removed, so no
+		// block is ranged at its height.
+		var blocks int
+		err = store.RangeTransactions(ns, 100, 100,
+			func([]TxDetails) (bool, error) {
+				blocks++
+				return false, nil
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if blocks != 0 {
+			t.Fatalf("empty block record is still stored")
+		}
+	})
+
+	// The balance is unchanged by pruning.
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		bal, err := store.Balance(ns, 1, 101)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bal != 4e7 {
+			t.Fatalf("balance is %v, want %v", bal, btcutil.Amount(4e7))
+		}
+	})
+}
+
+// TestReplaceUnminedTx ensures that replacing an unmined transaction removes
+// it with the transactions spending its outputs, inserts the replacement, and
+// records the chain of replacements, whether or not the replacement was
+// already inserted.
+func TestReplaceUnminedTx(t *testing.T) {
+	t.Parallel()
+
+	for _, inserted := range []bool{false, true} {
+		store, db, teardown, err := testStore()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b100 := &BlockMeta{
+			Block: Block{Height: 100},
+			Time:  time.Now(),
+		}
+		cb := newCoinBase(1e8)
+		cbRec, err := NewTxRecordFromMsgTx(cb, b100.Time)
+		if err != nil {
+			t.Fatal(err)
+		}
+		spendTx := spendOutput(&cbRec.Hash, 0, 5e7, 4e7)
+		spendTxRec, err := NewTxRecordFromMsgTx(spendTx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		childTx := spendOutput(&spendTxRec.Hash, 1, 3e7)
+		childTxRec, err := NewTxRecordFromMsgTx(childTx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		bumpTx := spendOutput(&cbRec.Hash, 0, 5e7, 3e7)
+		bumpTxRec, err := NewTxRecordFromMsgTx(bumpTx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+			if err := store.InsertTx(ns, cbRec, b100); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.AddCredit(ns, cbRec, b100, 0, false); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.InsertTx(ns, spendTxRec, nil); err != nil {
+				t.Fatal(err)
+			}
+			err := store.AddCredit(ns, spendTxRec, nil, 1, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := store.InsertTx(ns, childTxRec, nil); err != nil {
+				t.Fatal(err)
+			}
+			if inserted {
+				err := store.InsertTx(ns, bumpTxRec, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+
+		commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+			err := store.ReplaceUnminedTx(ns, spendTxRec, bumpTxRec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := store.AddCredit(ns, bumpTxRec, nil, 1, true); err != nil {
+				t.Fatal(err)
+			}
+
+			// Replacing a transaction which is no longer unmined
+			// fails.
+			err = store.ReplaceUnminedTx(ns, spendTxRec, bumpTxRec)
+			if err == nil {
+				t.Fatal("replaced a removed transaction")
+			}
+		})
+
+		commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+			unmined, err := store.UnminedTxHashes(ns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(unmined) != 1 || *unmined[0] != bumpTxRec.Hash {
+				t.Fatalf("unmined transactions are %v, want %v",
+					unmined, bumpTxRec.Hash)
+			}
+			txs, err := store.UnminedTxs(ns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(txs) != 1 || txs[0].TxIn[0].PreviousOutPoint !=
+				*wire.NewOutPoint(&cbRec.Hash, 0) {
+
+				t.Fatalf("unmined transactions are %v, want the "+
+					"replacement spending the coinbase", txs)
+			}
+			unspent, err := store.UnspentOutputs(ns, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(unspent) != 1 || unspent[0].Hash != bumpTxRec.Hash ||
+				unspent[0].Index != 1 {
+
+				t.Fatalf("unspent outputs are %v, want the change "+
+					"of the replacement", unspent)
+			}
+			bal, err := store.Balance(ns, 0, 200)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bal != 3e7 {
+				t.Fatalf("balance is %v, want %v", bal,
+					btcutil.Amount(3e7))
+			}
+
+			if h := store.ReplacedBy(ns, &spendTxRec.Hash); h == nil ||
+				*h != bumpTxRec.Hash {
+
+				t.Fatalf("ReplacedBy returned %v, want %v", h,
+					bumpTxRec.Hash)
+			}
+			if h := store.Replaces(ns, &bumpTxRec.Hash); h == nil ||
+				*h != spendTxRec.Hash {
+
+				t.Fatalf("Replaces returned %v, want %v", h,
+					spendTxRec.Hash)
+			}
+			if h := store.Replaces(ns, &spendTxRec.Hash); h != nil {
+				t.Fatalf("Replaces returned %v, want nil", h)
+			}
+			chain := store.ReplacementChain(ns, &bumpTxRec.Hash)
+			if len(chain) != 2 || chain[0] != spendTxRec.Hash ||
+				chain[1] != bumpTxRec.Hash {
+
+				t.Fatalf("replacement chain is %v", chain)
+			}
+		})
+
+		teardown()
+	}
+}